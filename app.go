@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"net"
 	"net/http"
@@ -17,7 +18,9 @@ import (
 	"time"
 
 	goopapp "github.com/petervdpas/goop2/internal/app"
+	"github.com/petervdpas/goop2/internal/app/startup"
 	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/keystore"
 	"github.com/petervdpas/goop2/internal/util"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -33,6 +36,7 @@ type App struct {
 	cfgPath          string
 	peerName         string
 	started          bool
+	peerDone         chan struct{} // closed when goopapp.Run returns
 	viewerURL        string
 	isRendezvousOnly bool
 
@@ -133,8 +137,18 @@ func (a *App) shutdown(ctx context.Context) {
 		log.Println("========================================")
 		a.cancel()
 
-		// Give the peer time to send offline message
-		time.Sleep(500 * time.Millisecond)
+		// Wait for the peer's own shutdown (offline message, groups,
+		// database checkpoint); it keeps to p2p.shutdown_timeout_sec.
+		a.mu.RLock()
+		done := a.peerDone
+		a.mu.RUnlock()
+		if done != nil {
+			select {
+			case <-done:
+			case <-time.After(goopapp.ShutdownWaitMax):
+				log.Println("SHUTDOWN: peer did not stop in time")
+			}
+		}
 		log.Println("SHUTDOWN: Complete")
 	}
 }
@@ -286,6 +300,14 @@ func (a *App) StartPeer(peerName string) error {
 		return err
 	}
 
+	// A locked key would only fail deep inside startup; refuse early so the
+	// launcher can ask for the passphrase.
+	for _, f := range goopapp.KeyFiles(peerDir, cfg) {
+		if keystore.Locked(f) {
+			return keystore.ErrLocked
+		}
+	}
+
 	// pick free localhost port
 	l, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -303,22 +325,22 @@ func (a *App) StartPeer(peerName string) error {
 	a.viewerURL = "http://" + cfg.Viewer.HTTPAddr
 	a.isRendezvousOnly = cfg.Presence.RendezvousOnly
 
-	progress := func(step, total int, label string) {
-		runtime.EventsEmit(a.ctx, "startup:progress", map[string]interface{}{
-			"step":  step,
-			"total": total,
-			"label": label,
-		})
+	onStage := func(st startup.Stage) {
+		runtime.EventsEmit(a.ctx, "startup:stage", st)
 	}
 
+	done := make(chan struct{})
+	a.peerDone = done
 	go func() {
+		defer close(done)
 		if err := goopapp.Run(a.ctx, goopapp.Options{
 			PeerDir:           peerDir,
 			CfgPath:           cfgPath,
 			Cfg:               cfg,
 			BridgeURL:         a.GetBridgeURL(),
 			GoopClientVersion: appVersion,
-			Progress:          progress,
+			EchoPeer:          *echoPeer,
+			OnStage:           onStage,
 		}); err != nil {
 			log.Fatal(err)
 		}
@@ -333,6 +355,38 @@ func (a *App) StartPeer(peerName string) error {
 	return nil
 }
 
+// PeerKeysLocked reports whether the peer's key files are passphrase
+// protected and still need the passphrase before StartPeer.
+func (a *App) PeerKeysLocked(peerName string) (bool, error) {
+	peerDir := filepath.Join("./peers", peerName)
+	cfg, err := config.Load(filepath.Join(peerDir, "goop.json"))
+	if err != nil {
+		return false, err
+	}
+	for _, f := range goopapp.KeyFiles(peerDir, cfg) {
+		if keystore.Locked(f) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UnlockPeer checks the passphrase against the peer's protected key files
+// and keeps it in memory for StartPeer.
+func (a *App) UnlockPeer(peerName, passphrase string) error {
+	peerDir := filepath.Join("./peers", peerName)
+	cfg, err := config.Load(filepath.Join(peerDir, "goop.json"))
+	if err != nil {
+		return err
+	}
+	for _, f := range goopapp.KeyFiles(peerDir, cfg) {
+		if err := keystore.Unlock(f, passphrase); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
 func (a *App) GetVersion() string {
 	return appVersion
 }
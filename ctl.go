@@ -0,0 +1,88 @@
+// ctl.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/app/control"
+)
+
+// runCtl drives a running peer through its control API and prints the
+// result as JSON, for scripts.
+func runCtl(peerDirArg, command string, args []string) {
+	fset := flag.NewFlagSet("ctl", flag.ExitOnError)
+	addr := fset.String("addr", "", "Use the peer's control.addr instead of its socket")
+	var pos []string
+	for len(args) > 0 {
+		fset.Parse(args)
+		args = fset.Args()
+		if len(args) > 0 {
+			pos, args = append(pos, args[0]), args[1:]
+		}
+	}
+
+	absDir, err := filepath.Abs(peerDirArg)
+	if err != nil {
+		log.Fatalf("Invalid peer directory: %v", err)
+	}
+	c, err := control.NewClient(absDir, *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	need := func(n int, usage string) {
+		if len(pos) < n {
+			fmt.Fprintf(os.Stderr, "Usage: goop2 ctl <peer-directory> %s %s\n", command, usage)
+			os.Exit(1)
+		}
+	}
+	payload := func(s string) any {
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			log.Fatalf("payload must be JSON: %v", err)
+		}
+		return v
+	}
+
+	var result any = control.OK{OK: true}
+	switch command {
+	case "status":
+		result, err = c.Status()
+	case "peers":
+		result, err = c.Peers()
+	case "groups":
+		result, err = c.Groups()
+	case "join":
+		need(2, "<host-peer-id> <group-id>")
+		err = c.JoinGroup(pos[0], pos[1])
+	case "leave":
+		need(1, "<group-id>")
+		err = c.LeaveGroup(pos[0])
+	case "group-send":
+		need(2, "<group-id> <json-payload>")
+		err = c.SendGroup(pos[0], payload(pos[1]))
+	case "chat":
+		need(2, "<peer-id> <text...>")
+		err = c.SendChat(pos[0], strings.Join(pos[1:], " "))
+	case "mq":
+		need(3, "<peer-id> <topic> <json-payload>")
+		result, err = c.SendMQ(pos[0], pos[1], payload(pos[2]))
+	case "publish":
+		err = c.Publish()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown ctl command '%s'\n", command)
+		fmt.Fprintln(os.Stderr, "Commands: status, peers, groups, join, leave, group-send, chat, mq, publish")
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app/supervisor"
+)
+
+// runDaemon runs or controls a daemon that supervises several peer
+// directories. The action comes first: goop2 daemon start|status|reload|...
+func runDaemon(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		daemonUsage()
+		os.Exit(1)
+	}
+	action, args := args[0], args[1:]
+
+	fset := flag.NewFlagSet("daemon", flag.ExitOnError)
+	cfgPath := fset.String("config", "goop2d.json", "Daemon config file")
+	asJSON := fset.Bool("json", false, "Print the result as JSON")
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	fset.Parse(args)
+	if name == "" {
+		name = fset.Arg(0)
+	}
+
+	absCfg, err := filepath.Abs(*cfgPath)
+	if err != nil {
+		log.Fatalf("Invalid daemon config path: %v", err)
+	}
+
+	if action == "start" {
+		runDaemonForeground(absCfg)
+		return
+	}
+
+	cfg, err := supervisor.LoadConfig(absCfg)
+	if err != nil {
+		log.Fatalf("Daemon config: %v", err)
+	}
+	client := supervisor.NewClient(cfg)
+
+	var result any
+	switch action {
+	case "status":
+		if name != "" {
+			result, err = client.Peer(name)
+		} else {
+			result, err = client.Status()
+		}
+	case "reload":
+		result, err = client.Reload()
+	case "stop":
+		err = client.Shutdown()
+	case "start-peer", "stop-peer", "restart-peer":
+		if name == "" {
+			log.Fatalf("%s needs a peer name", action)
+		}
+		result, err = client.PeerAction(name, strings.TrimSuffix(action, "-peer"))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown daemon action '%s'\n", action)
+		daemonUsage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	switch r := result.(type) {
+	case []supervisor.PeerStatus:
+		printPeerTable(r)
+	case supervisor.PeerStatus:
+		printPeerTable([]supervisor.PeerStatus{r})
+		for _, line := range r.Output {
+			fmt.Println("  " + line)
+		}
+	case supervisor.ReloadResult:
+		fmt.Printf("started: %s\nstopped: %s\nrestarted: %s\n",
+			listOrNone(r.Started), listOrNone(r.Stopped), listOrNone(r.Restarted))
+	case nil:
+		fmt.Println("daemon stopping")
+	}
+}
+
+func runDaemonForeground(cfgPath string) {
+	// Children get the same passphrase file; the environment is inherited.
+	var childArgs []string
+	if *passphraseFile != "" {
+		abs, err := filepath.Abs(*passphraseFile)
+		if err != nil {
+			log.Fatalf("Key passphrase: %v", err)
+		}
+		childArgs = append(childArgs, "-key-passphrase-file", abs)
+	}
+
+	sup, err := supervisor.New(cfgPath, childArgs)
+	if err != nil {
+		log.Fatalf("Daemon: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				if res, err := sup.Reload(); err != nil {
+					log.Printf("DAEMON: reload: %v", err)
+				} else {
+					log.Printf("DAEMON: reloaded (started %d, stopped %d, restarted %d)",
+						len(res.Started), len(res.Stopped), len(res.Restarted))
+				}
+				continue
+			}
+			log.Println("\nShutting down gracefully...")
+			cancel()
+			return
+		}
+	}()
+
+	log.Printf("DAEMON: supervising %d peers from %s", len(sup.Config().Peers), cfgPath)
+	if err := sup.Run(ctx); err != nil {
+		log.Fatalf("Daemon: %v", err)
+	}
+}
+
+func printPeerTable(peers []supervisor.PeerStatus) {
+	fmt.Printf("%-16s %-10s %-8s %-8s %-9s %s\n", "NAME", "MODE", "STATE", "PID", "UPTIME", "URL")
+	for _, p := range peers {
+		pid, uptime := "-", "-"
+		if p.PID != 0 {
+			pid = fmt.Sprint(p.PID)
+		}
+		if p.StartedAt != 0 {
+			uptime = time.Since(time.Unix(p.StartedAt, 0)).Truncate(time.Second).String()
+		}
+		fmt.Printf("%-16s %-10s %-8s %-8s %-9s %s\n", p.Name, p.Mode, p.State, pid, uptime, p.URL)
+		if p.State != supervisor.StateRunning && p.LastExit != "" {
+			fmt.Printf("  last exit: %s (restarts: %d)\n", p.LastExit, p.Restarts)
+		}
+	}
+}
+
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ", ")
+}
+
+func daemonUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: goop2 daemon <action> [name] [-config goop2d.json] [-json]")
+	fmt.Fprintln(os.Stderr, "Actions:")
+	fmt.Fprintln(os.Stderr, "  start                 Run the daemon in the foreground")
+	fmt.Fprintln(os.Stderr, "  status [name]         Show all peers, or one peer with its recent output")
+	fmt.Fprintln(os.Stderr, "  reload                Reread the config; start, stop or restart what changed")
+	fmt.Fprintln(os.Stderr, "  stop                  Stop all peers and the daemon")
+	fmt.Fprintln(os.Stderr, "  start-peer <name>     Start a stopped peer")
+	fmt.Fprintln(os.Stderr, "  stop-peer <name>      Stop one peer")
+	fmt.Fprintln(os.Stderr, "  restart-peer <name>   Restart one peer")
+}
@@ -7,6 +7,9 @@ set -e
 echo "📝 Regenerating OpenAPI spec..."
 swag init --quiet
 
+echo "🧩 Regenerating SDK client..."
+go generate ./internal/sdk
+
 echo "🔨 Building application..."
 wails build -clean
 
@@ -4,6 +4,9 @@ set -e
 echo "📝 Regenerating OpenAPI spec..."
 swag init --quiet
 
+echo "🧩 Regenerating SDK client..."
+go generate ./internal/sdk
+
 echo "🔨 Building application..."
 wails build -clean -platform linux/arm64 -tags webkit2_41
 
@@ -15,6 +15,74 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/actions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "actions"
+                ],
+                "summary": "Actions registry: every invocable operation with its parameters and permissions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/actions.Action"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/actions/run": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "actions"
+                ],
+                "summary": "Run a server-side action by ID (local only); returns the underlying route's response",
+                "parameters": [
+                    {
+                        "description": "Action and parameters",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.actionRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "bad params or client-only action",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown action",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/avatar": {
             "get": {
                 "produces": [
@@ -203,7 +271,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/call/debug": {
+        "/api/call/audio-processing": {
             "get": {
                 "produces": [
                     "application/json"
@@ -211,19 +279,18 @@ const docTemplate = `{
                 "tags": [
                     "call"
                 ],
-                "summary": "Debug dump of all active sessions",
+                "summary": "Get mic noise and echo suppression state (native mode)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.callDebugResponse"
+                            "$ref": "#/definitions/routes.callAudioProcessing"
                         }
                     }
                 }
-            }
-        },
-        "/api/call/hangup": {
+            },
             "post": {
+                "description": "Processing runs on the captured mic between capture and Opus encoding (Linux native capture). Changes apply to calls in progress.",
                 "consumes": [
                     "application/json"
                 ],
@@ -233,15 +300,15 @@ const docTemplate = `{
                 "tags": [
                     "call"
                 ],
-                "summary": "Hang up a Pion session",
+                "summary": "Toggle mic noise and echo suppression (native mode)",
                 "parameters": [
                     {
-                        "description": "Hangup request",
+                        "description": "Toggles to change",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.callChannelRequest"
+                            "$ref": "#/definitions/routes.callAudioProcessing"
                         }
                     }
                 ],
@@ -249,14 +316,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.callAudioProcessing"
                         }
                     }
                 }
             }
         },
-        "/api/call/loopback/{channel}/ice": {
+        "/api/call/callback": {
             "post": {
+                "description": "Sends call-callback on a channel that was answered with call-busy.",
                 "consumes": [
                     "application/json"
                 ],
@@ -266,22 +334,15 @@ const docTemplate = `{
                 "tags": [
                     "call"
                 ],
-                "summary": "Send browser ICE candidates to Go LocalPC (Phase 4 loopback)",
+                "summary": "Ask a busy peer to call back (native mode)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "ICE candidate",
+                        "description": "Channel",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.loopbackICERequest"
+                            "$ref": "#/definitions/routes.callChannelRequest"
                         }
                     }
                 ],
@@ -295,140 +356,102 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/call/loopback/{channel}/offer": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/call/callbacks": {
+            "get": {
+                "description": "Peers that called while we were busy and asked to be called back, oldest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "call"
                 ],
-                "summary": "Send browser SDP offer to Go LocalPC (Phase 4 loopback)",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "SDP offer",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.loopbackOfferRequest"
-                        }
-                    }
-                ],
+                "summary": "List callback requests (native mode)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.loopbackOfferResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.callCallback"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/call/media/{channel}": {
-            "get": {
-                "description": "Binary WebM frames sent over WebSocket. First message is the init segment; subsequent messages are clusters.\\nBrowser feeds these to MSE (MediaSource Extensions) for display.",
+        "/api/call/callbacks/dismiss": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "call"
                 ],
-                "summary": "WebSocket — live WebM stream of remote video/audio (native mode)",
+                "summary": "Dismiss callback requests (native mode)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "101": {
-                        "description": "WebSocket upgrade",
+                        "description": "Peer (empty = all)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.callbackDismissRequest"
                         }
                     }
-                }
-            }
-        },
-        "/api/call/mode": {
-            "get": {
-                "description": "Returns native (Go/Pion active) or browser (standard WebRTC).\\nSafe to call regardless of whether the call feature is enabled.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "call"
                 ],
-                "summary": "Query call stack mode (native vs browser)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.callModeResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/call/self/{channel}": {
-            "get": {
-                "description": "Binary WebM frames (VP8 video only, no audio track in init segment).\\nBrowser feeds to MSE for the local camera inset (PiP).",
-                "tags": [
-                    "call"
-                ],
-                "summary": "WebSocket — self-view WebM stream (local camera, Linux native only)",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
-                    }
+        "/api/call/captions": {
+            "post": {
+                "description": "Received audio is transcribed locally by viewer.caption_command; captions arrive as call-caption signals on call:{channel}.",
+                "consumes": [
+                    "application/json"
                 ],
-                "responses": {
-                    "101": {
-                        "description": "WebSocket upgrade",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/call/selfvideo/{channel}": {
-            "get": {
-                "description": "Self-view camera stream for the PiP inset. Replays last keyframe for instant display.",
                 "produces": [
-                    "video/webm"
+                    "application/json"
                 ],
                 "tags": [
                     "call"
                 ],
-                "summary": "HTTP chunked WebM stream of local self-view (Linux native mode)",
+                "summary": "Turn live captions on or off for a call (native mode)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
+                        "description": "Channel, toggle and language (empty = auto)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.callCaptionsRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Chunked WebM stream",
+                        "description": "enabled, language",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "captions not configured",
                         "schema": {
                             "type": "string"
                         }
@@ -436,9 +459,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/call/start": {
+        "/api/call/chat": {
             "post": {
-                "description": "Creates the Go-side Pion PeerConnection.\\nThe browser must call this before sending call-request via MQ.\\nFlow: POST /api/call/start → _sendMQ(call-request) → wait for call-ack → _connectNative()",
+                "description": "Sent on the session's data channel and stored in chat history tagged with the call ID.",
                 "consumes": [
                     "application/json"
                 ],
@@ -448,15 +471,15 @@ const docTemplate = `{
                 "tags": [
                     "call"
                 ],
-                "summary": "Register a new outbound Pion session (native mode, origin)",
+                "summary": "Send an in-call chat message (native mode)",
                 "parameters": [
                     {
-                        "description": "Start request",
+                        "description": "Channel and text",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.callStartRequest"
+                            "$ref": "#/definitions/routes.callChatRequest"
                         }
                     }
                 ],
@@ -464,47 +487,46 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.callStartResponse"
+                            "$ref": "#/definitions/routes.callDataMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "data channel not open",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/call/toggle-audio": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/call/debug": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "call"
                 ],
-                "summary": "Toggle local audio track mute (native mode)",
-                "parameters": [
-                    {
-                        "description": "Channel",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.callChannelRequest"
-                        }
-                    }
-                ],
+                "summary": "Debug dump of all active sessions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.callMuteResponse"
+                            "$ref": "#/definitions/routes.callDebugResponse"
                         }
                     }
                 }
             }
         },
-        "/api/call/toggle-video": {
+        "/api/call/device": {
             "post": {
+                "description": "The new capture track replaces the old one without renegotiation. Empty channel_id switches every active call; empty device_id picks any device.",
                 "consumes": [
                     "application/json"
                 ],
@@ -514,15 +536,15 @@ const docTemplate = `{
                 "tags": [
                     "call"
                 ],
-                "summary": "Toggle local video track (native mode)",
+                "summary": "Switch camera or mic mid-call (native mode)",
                 "parameters": [
                     {
-                        "description": "Channel",
+                        "description": "Channel, kind (video|audio) and device",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.callChannelRequest"
+                            "$ref": "#/definitions/routes.callDeviceRequest"
                         }
                     }
                 ],
@@ -530,34 +552,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.callVideoResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
-                    }
-                }
-            }
-        },
-        "/api/call/video/{channel}": {
-            "get": {
-                "description": "Replaces the WebSocket+MSE path on Linux. GStreamer's souphttpsrc handles this natively via \u003cvideo src=\"http://...\"\u003e. Uses SubscribeMediaFresh + RequestPLI for clean keyframe-first delivery.",
-                "produces": [
-                    "video/webm"
-                ],
-                "tags": [
-                    "call"
-                ],
-                "summary": "HTTP chunked WebM stream of remote video/audio (Linux native mode)",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Chunked WebM stream",
+                    },
+                    "400": {
+                        "description": "bad kind",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "switch failed",
                         "schema": {
                             "type": "string"
                         }
@@ -565,83 +576,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/capabilities": {
+        "/api/call/devices": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rendezvous"
+                    "call"
                 ],
-                "summary": "Feature capabilities of the rendezvous server",
+                "summary": "List local cameras and mics (native mode)",
                 "responses": {
                     "200": {
-                        "description": "Feature flags: encryption, registration, credits, templates, bridge, relay",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
+                            "$ref": "#/definitions/routes.callDevices"
                         }
                     }
                 }
             }
         },
-        "/api/chat/history": {
-            "get": {
+        "/api/call/file": {
+            "post": {
+                "description": "At most 4 MiB; chunked over the session's data channel. Chat history records the name and size only.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "chat"
+                    "call"
                 ],
-                "summary": "Get chat history with a peer",
+                "summary": "Drop a small file into the call (native mode)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Peer ID",
-                        "name": "peer_id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                        "description": "Channel and base64 file",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.ChatMessage"
-                            }
+                            "$ref": "#/definitions/routes.callFileRequest"
                         }
                     }
-                }
-            },
-            "delete": {
-                "tags": [
-                    "chat"
-                ],
-                "summary": "Clear chat history with a peer",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Peer ID",
-                        "name": "peer_id",
-                        "in": "query",
-                        "required": true
-                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.callDataMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "file too large",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/chat/rooms/close": {
+        "/api/call/hangup": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -650,17 +650,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Close a chat room",
+                "summary": "Hang up a Pion session",
                 "parameters": [
                     {
-                        "description": "Close request",
+                        "description": "Hangup request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomGroupIDRequest"
+                            "$ref": "#/definitions/routes.callChannelRequest"
                         }
                     }
                 ],
@@ -674,41 +674,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/chat/rooms/create": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/call/history": {
+            "get": {
+                "description": "Incoming and outgoing calls with their outcome, plus the number of missed calls not yet marked seen.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Create a chat room",
+                "summary": "Call history (newest first)",
                 "parameters": [
                     {
-                        "description": "Create request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Only calls with this peer",
+                        "name": "peer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum entries (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "calls: []storage.CallLogEntry, missed_unseen: int",
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomCreateRequest"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
+                }
+            }
+        },
+        "/api/call/history/clear": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "call"
                 ],
+                "summary": "Delete the call history",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomInfo"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/chat/rooms/join": {
+        "/api/call/history/seen": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "call"
+                ],
+                "summary": "Mark all missed calls as seen",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/call/hold": {
             "post": {
+                "description": "Sends call-hold to the remote peer.",
                 "consumes": [
                     "application/json"
                 ],
@@ -716,17 +757,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Join a remote chat room",
+                "summary": "Put a call on hold (native mode)",
                 "parameters": [
                     {
-                        "description": "Join request",
+                        "description": "Channel",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomJoinRequest"
+                            "$ref": "#/definitions/routes.callChannelRequest"
                         }
                     }
                 ],
@@ -734,13 +775,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.callHoldResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "session not found",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/chat/rooms/leave": {
+        "/api/call/loopback/{channel}/ice": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -749,17 +796,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Leave a chat room",
+                "summary": "Send browser ICE candidates to Go LocalPC (Phase 4 loopback)",
                 "parameters": [
                     {
-                        "description": "Leave request",
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ICE candidate",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomGroupIDRequest"
+                            "$ref": "#/definitions/routes.loopbackICERequest"
                         }
                     }
                 ],
@@ -773,9 +827,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/chat/rooms/send": {
+        "/api/call/loopback/{channel}/offer": {
             "post": {
-                "description": "Broadcasts the message to all room members via the chat.room MQ topic.",
                 "consumes": [
                     "application/json"
                 ],
@@ -783,17 +836,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Send a message to a chat room",
+                "summary": "Send browser SDP offer to Go LocalPC (Phase 4 loopback)",
                 "parameters": [
                     {
-                        "description": "Send request",
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SDP offer",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomSendRequest"
+                            "$ref": "#/definitions/routes.loopbackOfferRequest"
                         }
                     }
                 ],
@@ -801,43 +861,61 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.loopbackOfferResponse"
                         }
                     }
                 }
             }
         },
-        "/api/chat/rooms/state": {
+        "/api/call/media/{channel}": {
             "get": {
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Binary WebM frames sent over WebSocket. First message is the init segment; subsequent messages are clusters.\\nBrowser feeds these to MSE (MediaSource Extensions) for display.",
                 "tags": [
-                    "chat-rooms"
+                    "call"
                 ],
-                "summary": "Get chat room state (members + recent messages)",
+                "summary": "WebSocket — live WebM stream of remote video/audio (native mode)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Group ID",
-                        "name": "group_id",
-                        "in": "query",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
                         "required": true
                     }
                 ],
+                "responses": {
+                    "101": {
+                        "description": "WebSocket upgrade",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/call/mode": {
+            "get": {
+                "description": "Returns native (Go/Pion active) or browser (standard WebRTC).\\nSafe to call regardless of whether the call feature is enabled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "call"
+                ],
+                "summary": "Query call stack mode (native vs browser)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.chatRoomStateResponse"
+                            "$ref": "#/definitions/routes.callModeResponse"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/binary": {
+        "/api/call/resume": {
             "post": {
-                "description": "Sets the local binary that will execute jobs for this cluster. The binary is a child process that speaks the goop2 cluster JSON protocol over stdin/stdout. Mode can be \"oneshot\" (started per job) or \"daemon\" (long-running). Setting the binary resets the verified flag — the host must re-verify via check-job before dispatching work.",
+                "description": "Sends call-resume to the remote peer.",
                 "consumes": [
                     "application/json"
                 ],
@@ -845,17 +923,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
                 ],
-                "summary": "Set the binary path for this worker (worker only)",
+                "summary": "Resume a held call (native mode)",
                 "parameters": [
                     {
-                        "description": "Binary configuration",
+                        "description": "Channel",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterBinaryRequest"
+                            "$ref": "#/definitions/routes.callChannelRequest"
                         }
                     }
                 ],
@@ -863,17 +941,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterBinaryResponse"
+                            "$ref": "#/definitions/routes.callHoldResponse"
                         }
                     },
-                    "400": {
-                        "description": "missing binary path",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster worker",
+                    "404": {
+                        "description": "session not found",
                         "schema": {
                             "type": "string"
                         }
@@ -881,44 +953,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cluster/cancel": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/api/call/self/{channel}": {
+            "get": {
+                "description": "Binary WebM frames (VP8 video only, no audio track in init segment).\\nBrowser feeds to MSE for the local camera inset (PiP).",
                 "tags": [
-                    "cluster"
+                    "call"
                 ],
-                "summary": "Cancel a job (host only)",
+                "summary": "WebSocket — self-view WebM stream (local camera, Linux native only)",
                 "parameters": [
                     {
-                        "description": "Cancel request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.clusterCancelRequest"
-                        }
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "400": {
-                        "description": "missing job_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster host",
+                    "101": {
+                        "description": "WebSocket upgrade",
                         "schema": {
                             "type": "string"
                         }
@@ -926,25 +979,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cluster/clear": {
-            "post": {
-                "description": "Removes all jobs (pending, completed, failed, cancelled) from the queue and database. This is irreversible.",
+        "/api/call/selfvideo/{channel}": {
+            "get": {
+                "description": "Self-view camera stream for the PiP inset. Replays last keyframe for instant display.",
                 "produces": [
-                    "application/json"
+                    "video/webm"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
+                ],
+                "summary": "HTTP chunked WebM stream of local self-view (Linux native mode)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Clear the entire job queue (host only)",
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster host",
+                        "description": "Chunked WebM stream",
                         "schema": {
                             "type": "string"
                         }
@@ -952,9 +1008,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cluster/create": {
+        "/api/call/start": {
             "post": {
-                "description": "Creates a new cluster group and sets this node as host. If group_id is provided, activates an existing group instead of creating a new one.",
+                "description": "Creates the Go-side Pion PeerConnection.\\nThe browser must call this before sending call-request via MQ.\\nFlow: POST /api/call/start → _sendMQ(call-request) → wait for call-ack → _connectNative()",
                 "consumes": [
                     "application/json"
                 ],
@@ -962,17 +1018,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
                 ],
-                "summary": "Create or activate a cluster (become host)",
+                "summary": "Register a new outbound Pion session (native mode, origin)",
                 "parameters": [
                     {
-                        "description": "Create request",
+                        "description": "Start request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterCreateRequest"
+                            "$ref": "#/definitions/routes.callStartRequest"
                         }
                     }
                 ],
@@ -980,21 +1036,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterCreateResponse"
-                        }
-                    },
-                    "409": {
-                        "description": "already in a cluster",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.callStartResponse"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/delete": {
+        "/api/call/toggle-audio": {
             "post": {
-                "description": "Removes a cancelled, completed, or failed job from the queue and database. Active jobs (pending, assigned, running) cannot be deleted — cancel them first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1002,17 +1051,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
                 ],
-                "summary": "Delete a terminal job from the queue (host only)",
+                "summary": "Toggle local audio track mute (native mode)",
                 "parameters": [
                     {
-                        "description": "Delete request",
+                        "description": "Channel",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterDeleteRequest"
+                            "$ref": "#/definitions/routes.callChannelRequest"
                         }
                     }
                 ],
@@ -1020,49 +1069,48 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "400": {
-                        "description": "missing job_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "job is pending — cancel it first",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.callMuteResponse"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/jobs": {
-            "get": {
+        "/api/call/toggle-video": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
+                ],
+                "summary": "Toggle local video track (native mode)",
+                "parameters": [
+                    {
+                        "description": "Channel",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.callChannelRequest"
+                        }
+                    }
                 ],
-                "summary": "List all jobs in the queue (host only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.clusterJobState"
-                            }
+                            "$ref": "#/definitions/routes.callVideoResponse"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/join": {
+        "/api/call/transfer": {
             "post": {
-                "description": "Joins a remote cluster group and registers this node as a worker.",
+                "description": "Blind transfer: the remote peer is sent call-transfer with the target and dials it; this session hangs up.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1070,17 +1118,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "call"
                 ],
-                "summary": "Join an existing cluster as worker",
+                "summary": "Transfer a call to another peer (native mode)",
                 "parameters": [
                     {
-                        "description": "Join request",
+                        "description": "Channel and target",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterJoinRequest"
+                            "$ref": "#/definitions/routes.callTransferRequest"
                         }
                     }
                 ],
@@ -1091,20 +1139,37 @@ const docTemplate = `{
                             "$ref": "#/definitions/routes.statusOK"
                         }
                     },
-                    "400": {
-                        "description": "missing host_peer_id or group_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "already in a cluster",
+                    "404": {
+                        "description": "session not found",
                         "schema": {
                             "type": "string"
                         }
-                    },
-                    "502": {
-                        "description": "failed to join group",
+                    }
+                }
+            }
+        },
+        "/api/call/video/{channel}": {
+            "get": {
+                "description": "Replaces the WebSocket+MSE path on Linux. GStreamer's souphttpsrc handles this natively via \u003cvideo src=\"http://...\"\u003e. Uses SubscribeMediaFresh + RequestPLI for clean keyframe-first delivery.",
+                "produces": [
+                    "video/webm"
+                ],
+                "tags": [
+                    "call"
+                ],
+                "summary": "HTTP chunked WebM stream of remote video/audio (Linux native mode)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Chunked WebM stream",
                         "schema": {
                             "type": "string"
                         }
@@ -1112,62 +1177,87 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cluster/leave": {
-            "post": {
-                "description": "Sends shutdown to all workers, leaves the cluster, and deletes the group.",
+        "/api/capabilities": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "rendezvous"
                 ],
-                "summary": "Close the current cluster",
+                "summary": "Feature capabilities of the rendezvous server",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Feature flags: encryption, registration, credits, templates, bridge, relay",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/cluster/pause": {
+        "/api/chat/call": {
             "post": {
-                "description": "Pauses the worker so the host dispatcher skips it when dispatching jobs. The worker stays in the cluster. Use POST /api/cluster/resume to resume.",
+                "description": "Browser-mode calls carry chat on the page's own data channel; the page reports each message here so it lands in chat history tagged with the call ID.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat"
+                ],
+                "summary": "Record an in-call message (browser-mode calls)",
+                "parameters": [
+                    {
+                        "description": "Message",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.chatCallMessageRequest"
+                        }
+                    }
                 ],
-                "summary": "Pause this worker (worker only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "409": {
-                        "description": "not a cluster worker",
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 }
             }
         },
-        "/api/cluster/resume": {
+        "/api/chat/group/backfill": {
             "post": {
-                "description": "Resumes a paused worker so the host dispatcher can dispatch jobs to it again.",
+                "description": "The host sends up to limit (max 500) messages on the room's history topic; they are merged into the local history.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
+                ],
+                "summary": "Ask the host of a joined chat room for its last messages",
+                "parameters": [
+                    {
+                        "description": "Backfill request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.chatGroupBackfillRequest"
+                        }
+                    }
                 ],
-                "summary": "Resume this worker (worker only)",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1176,7 +1266,7 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "not a cluster worker",
+                        "description": "not connected to the room",
                         "schema": {
                             "type": "string"
                         }
@@ -1184,46 +1274,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cluster/stats": {
+        "/api/chat/group/history": {
             "get": {
+                "description": "Oldest first, at most the last 1000. Rooms that were left or closed keep their history.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
+                ],
+                "summary": "Get the stored messages of a chat room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last N messages (default: all kept)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Queue statistics (host only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterQueueStats"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.chatRoomMessage"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/cluster/status": {
+        "/api/chat/history": {
             "get": {
-                "description": "Returns the current role (host, worker, or empty), the active group ID, and stats if host.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat"
+                ],
+                "summary": "Get chat history with a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer_id",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Current cluster role and group",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterStatusResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.ChatMessage"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Clear chat history with a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/submit": {
+        "/api/chat/rooms/close": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1232,17 +1375,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
                 ],
-                "summary": "Submit a job to the cluster queue (host only)",
+                "summary": "Close a chat room",
                 "parameters": [
                     {
-                        "description": "Job submission",
+                        "description": "Close request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterSubmitRequest"
+                            "$ref": "#/definitions/routes.chatRoomGroupIDRequest"
                         }
                     }
                 ],
@@ -1250,50 +1393,47 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterSubmitResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "missing job type",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster host",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/types": {
-            "get": {
-                "description": "Returns the 7 predefined job types (calculate, construct, transform, search, validate, distribute, custom) with descriptions, JSON payload templates, and help text.",
+        "/api/chat/rooms/create": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
+                ],
+                "summary": "Create a chat room",
+                "parameters": [
+                    {
+                        "description": "Create request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.chatRoomCreateRequest"
+                        }
+                    }
                 ],
-                "summary": "List predefined job types with payload templates",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/cluster.JobType"
-                            }
+                            "$ref": "#/definitions/routes.chatRoomInfo"
                         }
                     }
                 }
             }
         },
-        "/api/cluster/worker/pause": {
+        "/api/chat/rooms/join": {
             "post": {
-                "description": "Sends a pause command to the specified worker. The worker stops accepting new jobs but remains in the cluster. Existing running jobs are not interrupted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1301,17 +1441,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
                 ],
-                "summary": "Pause a remote worker (host only)",
+                "summary": "Join a remote chat room",
                 "parameters": [
                     {
-                        "description": "Worker peer ID",
+                        "description": "Join request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterWorkerPeerRequest"
+                            "$ref": "#/definitions/routes.chatRoomJoinRequest"
                         }
                     }
                 ],
@@ -1321,25 +1461,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "400": {
-                        "description": "missing peer_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster host",
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 }
             }
         },
-        "/api/cluster/worker/resume": {
+        "/api/chat/rooms/leave": {
             "post": {
-                "description": "Sends a resume command to the specified worker so the dispatcher can dispatch jobs to it again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1347,17 +1474,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cluster"
+                    "chat-rooms"
                 ],
-                "summary": "Resume a remote worker (host only)",
+                "summary": "Leave a chat room",
                 "parameters": [
                     {
-                        "description": "Worker peer ID",
+                        "description": "Leave request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clusterWorkerPeerRequest"
+                            "$ref": "#/definitions/routes.chatRoomGroupIDRequest"
                         }
                     }
                 ],
@@ -1367,113 +1494,75 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "400": {
-                        "description": "missing peer_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "not a cluster host",
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 }
             }
         },
-        "/api/cluster/workers": {
-            "get": {
-                "produces": [
+        "/api/chat/rooms/send": {
+            "post": {
+                "description": "Broadcasts the message to all room members via the chat.room MQ topic.",
+                "consumes": [
                     "application/json"
                 ],
-                "tags": [
-                    "cluster"
-                ],
-                "summary": "List all workers (host only)",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.clusterWorkerInfo"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/api/credits/access": {
-            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
+                    "chat-rooms"
                 ],
-                "summary": "Check template access for a peer",
+                "summary": "Send a message to a chat room",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Template directory name",
-                        "name": "template_dir",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Peer ID (defaults to caller)",
-                        "name": "peer_id",
-                        "in": "query"
+                        "description": "Send request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.chatRoomSendRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "allowed",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/credits/balance": {
+        "/api/chat/rooms/state": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
+                    "chat-rooms"
                 ],
-                "summary": "Fetch account credit balance",
+                "summary": "Get chat room state (members + recent messages)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Peer ID (defaults to caller)",
-                        "name": "peer_id",
-                        "in": "query"
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "balance",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "$ref": "#/definitions/routes.chatRoomStateResponse"
                         }
                     }
                 }
             }
         },
-        "/api/credits/grant": {
+        "/api/cluster/binary": {
             "post": {
+                "description": "Sets the local binary that will execute jobs for this cluster. The binary is a child process that speaks the goop2 cluster JSON protocol over stdin/stdout. Mode can be \"oneshot\" (started per job) or \"daemon\" (long-running). Setting the binary resets the verified flag — the host must re-verify via check-job before dispatching work.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1481,17 +1570,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
+                    "cluster"
                 ],
-                "summary": "Grant credits to an account",
+                "summary": "Set the binary path for this worker (worker only)",
                 "parameters": [
                     {
-                        "description": "Grant details",
+                        "description": "Binary configuration",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/routes.clusterBinaryRequest"
                         }
                     }
                 ],
@@ -1499,14 +1588,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.clusterBinaryResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/credits/spend": {
+                    },
+                    "400": {
+                        "description": "missing binary path",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster worker",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cluster/cancel": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1515,17 +1615,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
+                    "cluster"
                 ],
-                "summary": "Spend credits on a template purchase",
+                "summary": "Cancel a job (host only)",
                 "parameters": [
                     {
-                        "description": "Template to purchase",
+                        "description": "Cancel request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/routes.clusterCancelRequest"
                         }
                     }
                 ],
@@ -1533,78 +1633,53 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "400": {
+                        "description": "missing job_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster host",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/credits/store-data": {
-            "get": {
+        "/api/cluster/clear": {
+            "post": {
+                "description": "Removes all jobs (pending, completed, failed, cancelled) from the queue and database. This is irreversible.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
-                ],
-                "summary": "Fetch store page data (balance, email, credits active)",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Peer ID (defaults to caller)",
-                        "name": "peer_id",
-                        "in": "query"
-                    }
+                    "cluster"
                 ],
+                "summary": "Clear the entire job queue (host only)",
                 "responses": {
                     "200": {
-                        "description": "credits_active, email, balance, app_name",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
-                    }
-                }
-            }
-        },
-        "/api/credits/template-info": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "credits"
-                ],
-                "summary": "Fetch per-template pricing and ownership info",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Template directory name",
-                        "name": "template_dir",
-                        "in": "query",
-                        "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "Peer ID (defaults to caller)",
-                        "name": "peer_id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "price, status",
+                    "409": {
+                        "description": "not a cluster host",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/aggregate": {
+        "/api/cluster/create": {
             "post": {
+                "description": "Creates a new cluster group and sets this node as host. If group_id is provided, activates an existing group instead of creating a new one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1612,17 +1687,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Run aggregate query (COUNT, SUM, MAX, MIN, AVG) with optional GROUP BY",
+                "summary": "Create or activate a cluster (become host)",
                 "parameters": [
                     {
-                        "description": "Aggregate expression",
+                        "description": "Create request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataAggregateRequest"
+                            "$ref": "#/definitions/routes.clusterCreateRequest"
                         }
                     }
                 ],
@@ -1630,18 +1705,21 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/routes.clusterCreateResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "already in a cluster",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/count": {
+        "/api/cluster/delete": {
             "post": {
+                "description": "Removes a cancelled, completed, or failed job from the queue and database. Active jobs (pending, assigned, running) cannot be deleted — cancel them first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1649,17 +1727,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Count rows matching criteria",
+                "summary": "Delete a terminal job from the queue (host only)",
                 "parameters": [
                     {
-                        "description": "Where clause",
+                        "description": "Delete request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataWhereRequest"
+                            "$ref": "#/definitions/routes.clusterDeleteRequest"
                         }
                     }
                 ],
@@ -1667,47 +1745,49 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataCountResponse"
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "400": {
+                        "description": "missing job_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "job is pending — cancel it first",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/delete": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/cluster/jobs": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
-                ],
-                "summary": "Delete rows from a table",
-                "parameters": [
-                    {
-                        "description": "Delete request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataDeleteRequest"
-                        }
-                    }
+                    "cluster"
                 ],
+                "summary": "List all jobs in the queue (host only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.clusterJobState"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/delete-where": {
+        "/api/cluster/join": {
             "post": {
+                "description": "Joins a remote cluster group and registers this node as a worker.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1715,17 +1795,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Delete rows matching a WHERE clause",
+                "summary": "Join an existing cluster as worker",
                 "parameters": [
                     {
-                        "description": "Delete parameters",
+                        "description": "Join request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataDeleteWhereRequest"
+                            "$ref": "#/definitions/routes.clusterJoinRequest"
                         }
                     }
                 ],
@@ -1733,117 +1813,142 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataAffectedResponse"
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "400": {
+                        "description": "missing host_peer_id or group_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "already in a cluster",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "failed to join group",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/distinct": {
+        "/api/cluster/leave": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Sends shutdown to all workers, leaves the cluster, and deletes the group.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
-                ],
-                "summary": "Get unique values for a column",
-                "parameters": [
-                    {
-                        "description": "Column",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataDistinctRequest"
-                        }
-                    }
+                    "cluster"
                 ],
+                "summary": "Close the current cluster",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {}
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/data/exists": {
+        "/api/cluster/pause": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Pauses the worker so the host dispatcher skips it when dispatching jobs. The worker stays in the cluster. Use POST /api/cluster/resume to resume.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
-                ],
-                "summary": "Check if any rows match criteria",
-                "parameters": [
-                    {
-                        "description": "Where clause",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataWhereRequest"
-                        }
-                    }
+                    "cluster"
                 ],
+                "summary": "Pause this worker (worker only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataExistsResponse"
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster worker",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/find": {
+        "/api/cluster/resume": {
             "post": {
-                "consumes": [
+                "description": "Resumes a paused worker so the host dispatcher can dispatch jobs to it again.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "cluster"
+                ],
+                "summary": "Resume this worker (worker only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster worker",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cluster/stats": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Find rows with filtering, ordering, and pagination",
-                "parameters": [
-                    {
-                        "description": "Find parameters",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                "summary": "Queue statistics (host only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataFindRequest"
+                            "$ref": "#/definitions/routes.clusterQueueStats"
                         }
                     }
+                }
+            }
+        },
+        "/api/cluster/status": {
+            "get": {
+                "description": "Returns the current role (host, worker, or empty), the active group ID, and stats if host.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cluster"
                 ],
+                "summary": "Current cluster role and group",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/routes.clusterStatusResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/find-one": {
+        "/api/cluster/submit": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1852,17 +1957,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Find a single row matching criteria",
+                "summary": "Submit a job to the cluster queue (host only)",
                 "parameters": [
                     {
-                        "description": "Find parameters",
+                        "description": "Job submission",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataFindRequest"
+                            "$ref": "#/definitions/routes.clusterSubmitRequest"
                         }
                     }
                 ],
@@ -1870,49 +1975,50 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.clusterSubmitResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "missing job type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster host",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/get-by": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/cluster/types": {
+            "get": {
+                "description": "Returns the 7 predefined job types (calculate, construct, transform, search, validate, distribute, custom) with descriptions, JSON payload templates, and help text.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
-                ],
-                "summary": "Get a single row by any column value",
-                "parameters": [
-                    {
-                        "description": "Column lookup",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataGetByRequest"
-                        }
-                    }
+                    "cluster"
                 ],
+                "summary": "List predefined job types with payload templates",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/cluster.JobType"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/insert": {
+        "/api/cluster/worker/pause": {
             "post": {
+                "description": "Sends a pause command to the specified worker. The worker stops accepting new jobs but remains in the cluster. Existing running jobs are not interrupted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1920,17 +2026,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Insert a row into a table (ORM tables validate column types)",
+                "summary": "Pause a remote worker (host only)",
                 "parameters": [
                     {
-                        "description": "Insert request",
+                        "description": "Worker peer ID",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataInsertRequest"
+                            "$ref": "#/definitions/routes.clusterWorkerPeerRequest"
                         }
                     }
                 ],
@@ -1938,11 +2044,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataInsertResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "Type validation error (ORM tables)",
+                        "description": "missing peer_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "not a cluster host",
                         "schema": {
                             "type": "string"
                         }
@@ -1950,9 +2062,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/data/lua/call": {
+        "/api/cluster/worker/resume": {
             "post": {
-                "description": "Invokes a server-side Lua function by name with parameters. Used by the virtual REST API (Goop.api) and custom data functions.",
+                "description": "Sends a resume command to the specified worker so the dispatcher can dispatch jobs to it again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1960,17 +2072,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Call a Lua data function",
+                "summary": "Resume a remote worker (host only)",
                 "parameters": [
                     {
-                        "description": "Function name and parameters",
+                        "description": "Worker peer ID",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataLuaCallRequest"
+                            "$ref": "#/definitions/routes.clusterWorkerPeerRequest"
                         }
                     }
                 ],
@@ -1978,18 +2090,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "function name required",
+                        "description": "missing peer_id",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "lua scripting not enabled / function error",
+                    "409": {
+                        "description": "not a cluster host",
                         "schema": {
                             "type": "string"
                         }
@@ -1997,49 +2108,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/data/lua/list": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "data"
-                ],
-                "summary": "List available Lua data functions",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataLuaListResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/data/orm-schema": {
+        "/api/cluster/workers": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "cluster"
                 ],
-                "summary": "Get all ORM schemas with full column info and access policies",
+                "summary": "List all workers (host only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "$ref": "#/definitions/routes.ormSchema"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.clusterWorkerInfo"
                             }
                         }
                     }
                 }
             }
         },
-        "/api/data/pluck": {
+        "/api/config/preset": {
             "post": {
+                "description": "Rewrites the settings the preset controls and keeps everything else. Takes effect after a restart.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2047,17 +2140,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "settings"
                 ],
-                "summary": "Get flat array of a single column's values",
+                "summary": "Apply a deployment preset",
                 "parameters": [
                     {
-                        "description": "Column to pluck",
+                        "description": "Preset name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataPluckRequest"
+                            "type": "object"
                         }
                     }
                 ],
@@ -2065,109 +2158,153 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {}
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "unknown preset",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/query": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/config/presets": {
+            "get": {
+                "description": "Named combinations of ports, discovery, relay and service settings: desktop, public-rendezvous, lan-kiosk and headless-bot.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
-                ],
-                "summary": "Query rows from a table",
-                "parameters": [
-                    {
-                        "description": "Query request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.dataQueryRequest"
-                        }
-                    }
+                    "settings"
                 ],
+                "summary": "List deployment presets",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object",
-                                "additionalProperties": true
+                                "$ref": "#/definitions/config.Preset"
                             }
                         }
                     }
                 }
             }
         },
-        "/api/data/role": {
-            "post": {
-                "description": "Returns the calling peer's role in the template group and their permissions for the specified schema. Proxied through P2P for remote viewers. The host is the authority.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/config/validate": {
+            "get": {
+                "description": "Every problem in the config: JSON errors, invalid values (errors, which keep the peer from starting) and unknown keys with a suggested spelling or likely mistakes (warnings).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "settings"
                 ],
-                "summary": "Get caller's role and permissions for a schema",
-                "parameters": [
-                    {
-                        "description": "Schema/table name",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                "summary": "Validate goop.json",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataRoleRequest"
+                            "$ref": "#/definitions/routes.configValidation"
                         }
                     }
+                }
+            },
+            "post": {
+                "description": "Checks the posted goop.json content the same way, without writing it.",
+                "consumes": [
+                    "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Validate a config document",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataRoleResponse"
+                            "$ref": "#/definitions/routes.configValidation"
                         }
                     }
                 }
             }
         },
-        "/api/data/schemas": {
+        "/api/credits/access": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "credits"
+                ],
+                "summary": "Check template access for a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template directory name",
+                        "name": "template_dir",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Peer ID (defaults to caller)",
+                        "name": "peer_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all stored schema definitions (JSON files)",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "allowed",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.schemaListEntry"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
                             }
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/data/schemas/apply": {
+        "/api/credits/balance": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Fetch account credit balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID (defaults to caller)",
+                        "name": "peer_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/credits/grant": {
             "post": {
-                "description": "Reads the schema JSON file, validates it, and creates the table with full ORM type support.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2175,17 +2312,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "credits"
                 ],
-                "summary": "Create an ORM table from a stored schema definition",
+                "summary": "Grant credits to an account",
                 "parameters": [
                     {
-                        "description": "Schema name",
+                        "description": "Grant details",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaNameRequest"
+                            "type": "object"
                         }
                     }
                 ],
@@ -2193,25 +2330,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "400": {
-                        "description": "validation error",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "schema not found",
-                        "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/data/schemas/ddl": {
+        "/api/credits/spend": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2220,17 +2347,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "credits"
                 ],
-                "summary": "Preview the DDL (CREATE TABLE statement) for a schema",
+                "summary": "Spend credits on a template purchase",
                 "parameters": [
                     {
-                        "description": "Schema to preview",
+                        "description": "Template to purchase",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSaveRequest"
+                            "type": "object"
                         }
                     }
                 ],
@@ -2238,46 +2365,80 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaDdlResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/data/schemas/delete": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/credits/store-data": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "credits"
                 ],
-                "summary": "Delete a schema definition",
+                "summary": "Fetch store page data (balance, email, credits active)",
                 "parameters": [
                     {
-                        "description": "Schema name",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Peer ID (defaults to caller)",
+                        "name": "peer_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "credits_active, email, balance, app_name",
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaNameRequest"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/credits/template-info": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Fetch per-template pricing and ownership info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template directory name",
+                        "name": "template_dir",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Peer ID (defaults to caller)",
+                        "name": "peer_id",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "price, status",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/data/schemas/get": {
+        "/api/data/aggregate": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2286,17 +2447,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "data"
                 ],
-                "summary": "Get a schema definition by name",
+                "summary": "Run aggregate query (COUNT, SUM, MAX, MIN, AVG) with optional GROUP BY",
                 "parameters": [
                     {
-                        "description": "Schema name",
+                        "description": "Aggregate expression",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaNameRequest"
+                            "$ref": "#/definitions/routes.dataAggregateRequest"
                         }
                     }
                 ],
@@ -2304,19 +2465,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSaveRequest"
-                        }
-                    },
-                    "404": {
-                        "description": "schema not found",
-                        "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/schemas/save": {
+        "/api/data/count": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2325,17 +2484,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "data"
                 ],
-                "summary": "Create or update a schema definition (saves as JSON file)",
+                "summary": "Count rows matching criteria",
                 "parameters": [
                     {
-                        "description": "Schema definition",
+                        "description": "Where clause",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSaveRequest"
+                            "$ref": "#/definitions/routes.dataWhereRequest"
                         }
                     }
                 ],
@@ -2343,19 +2502,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "400": {
-                        "description": "validation error",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.dataCountResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/schemas/set-access": {
+        "/api/data/delete": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2364,17 +2517,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "data"
                 ],
-                "summary": "Update access policy for a stored schema",
+                "summary": "Delete a row by id",
                 "parameters": [
                     {
-                        "description": "Schema name and access policy",
+                        "description": "Delete request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSetAccessRequest"
+                            "$ref": "#/definitions/routes.dataDeleteRequest"
                         }
                     }
                 ],
@@ -2384,19 +2537,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "404": {
-                        "description": "schema not found",
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 }
             }
         },
-        "/api/data/schemas/set-context": {
+        "/api/data/delete-where": {
             "post": {
-                "description": "When context is true, the schema's table is exposed via the GraphQL API. Auto-rebuilds the GraphQL schema.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2404,17 +2550,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "data"
                 ],
-                "summary": "Toggle whether a schema is included in the GraphQL context",
+                "summary": "Delete rows matching a WHERE clause",
                 "parameters": [
                     {
-                        "description": "Schema name and context flag",
+                        "description": "Delete parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSetContextRequest"
+                            "$ref": "#/definitions/routes.dataDeleteWhereRequest"
                         }
                     }
                 ],
@@ -2422,19 +2568,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "404": {
-                        "description": "schema not found",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.dataAffectedResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/schemas/set-roles": {
+        "/api/data/distinct": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2443,17 +2583,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "schema"
+                    "data"
                 ],
-                "summary": "Update role access matrix for a stored schema",
+                "summary": "Get unique values for a column",
                 "parameters": [
                     {
-                        "description": "Schema name and roles map",
+                        "description": "Column",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSetRolesRequest"
+                            "$ref": "#/definitions/routes.dataDistinctRequest"
                         }
                     }
                 ],
@@ -2461,41 +2601,47 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    },
-                    "404": {
-                        "description": "schema not found",
-                        "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {}
                         }
                     }
                 }
             }
         },
-        "/api/data/tables": {
-            "get": {
+        "/api/data/exists": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "data"
                 ],
-                "summary": "List tables with schema, policies, and mode (orm/classic)",
+                "summary": "Check if any rows match criteria",
+                "parameters": [
+                    {
+                        "description": "Where clause",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.dataWhereRequest"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.dataTableListEntry"
-                            }
+                            "$ref": "#/definitions/routes.dataExistsResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/add-column": {
+        "/api/data/find": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2506,15 +2652,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Add a column to a table",
+                "summary": "Find rows with filtering, ordering, and pagination",
                 "parameters": [
                     {
-                        "description": "Add column request",
+                        "description": "Find parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataColumnRequest"
+                            "$ref": "#/definitions/routes.dataFindRequest"
                         }
                     }
                 ],
@@ -2522,15 +2668,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/create": {
+        "/api/data/find-one": {
             "post": {
-                "description": "Accepts two column formats. Classic: columns with name/type/not_null/default. ORM: columns with name/type/key/required/default (lowercase types: text, integer, real, blob). Format is auto-detected from column fields.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2540,15 +2689,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Create a new table (classic or ORM schema format, auto-detected)",
+                "summary": "Find a single row matching criteria",
                 "parameters": [
                     {
-                        "description": "Create request",
+                        "description": "Find parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataTableCreateRequest"
+                            "$ref": "#/definitions/routes.dataFindOneRequest"
                         }
                     }
                 ],
@@ -2556,19 +2705,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataTableCreateResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Validation error",
-                        "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/delete": {
+        "/api/data/get-by": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2579,15 +2723,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Drop a table",
+                "summary": "Get a single row by any column value",
                 "parameters": [
                     {
-                        "description": "Table name",
+                        "description": "Column lookup",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataTableRequest"
+                            "$ref": "#/definitions/routes.dataGetByRequest"
                         }
                     }
                 ],
@@ -2595,13 +2739,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/describe": {
+        "/api/data/insert": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2612,15 +2757,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Describe a table — ORM tables return typed JSON schema, classic returns PRAGMA columns",
+                "summary": "Insert a row into a table (ORM tables validate column types)",
                 "parameters": [
                     {
-                        "description": "Table name",
+                        "description": "Insert request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataTableRequest"
+                            "$ref": "#/definitions/routes.dataInsertRequest"
                         }
                     }
                 ],
@@ -2628,14 +2773,21 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataDescribeResponse"
+                            "$ref": "#/definitions/routes.dataInsertResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Type validation error (ORM tables)",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/drop-column": {
+        "/api/data/lua/call": {
             "post": {
+                "description": "Invokes a server-side Lua function by name with parameters. Used by the virtual REST API (Goop.api) and custom data functions.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2645,15 +2797,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Drop a column from a table",
+                "summary": "Call a Lua data function",
                 "parameters": [
                     {
-                        "description": "Drop column request",
+                        "description": "Function name and parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataColumnRequest"
+                            "$ref": "#/definitions/routes.dataLuaCallRequest"
                         }
                     }
                 ],
@@ -2661,46 +2813,67 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "function name required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "lua scripting not enabled / function error",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/export-schema": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/data/lua/list": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "data"
                 ],
-                "summary": "Export table schema as portable JSON (ORM returns stored schema, classic reads PRAGMA)",
-                "parameters": [
-                    {
-                        "description": "Table name",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                "summary": "List available Lua data functions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataTableRequest"
+                            "$ref": "#/definitions/routes.dataLuaListResponse"
                         }
                     }
+                }
+            }
+        },
+        "/api/data/orm-schema": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
                 ],
+                "summary": "Get all ORM schemas with full column info and access policies",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.ormSchema"
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/routes.ormSchema"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/rename": {
+        "/api/data/pluck": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2711,15 +2884,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Rename a table",
+                "summary": "Get flat array of a single column's values",
                 "parameters": [
                     {
-                        "description": "Rename request",
+                        "description": "Column to pluck",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataRenameRequest"
+                            "$ref": "#/definitions/routes.dataPluckRequest"
                         }
                     }
                 ],
@@ -2727,13 +2900,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {}
                         }
                     }
                 }
             }
         },
-        "/api/data/tables/set-policy": {
+        "/api/data/query": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2744,15 +2918,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Set insert policy for a table (owner, open, group, local)",
+                "summary": "Query rows from a table",
                 "parameters": [
                     {
-                        "description": "Policy request",
+                        "description": "Query request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataPolicyRequest"
+                            "$ref": "#/definitions/routes.dataQueryRequest"
                         }
                     }
                 ],
@@ -2760,36 +2934,42 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/data/transformations": {
+        "/api/data/replicas": {
             "get": {
+                "description": "Tables with a \"group\" policy are replicated to the members of the host's template group over /goop/data-sync/1.0.0. While a host is offline, /api/p/{peerID}/data answers from its replica with an X-Goop-Data-Copy header.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "data"
                 ],
-                "summary": "List all stored transformation definitions",
+                "summary": "Replicas of template group hosts' shared tables",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/routes.transformListEntry"
+                                "$ref": "#/definitions/datasync.ReplicaStatus"
                             }
                         }
                     }
                 }
             }
         },
-        "/api/data/transformations/delete": {
+        "/api/data/role": {
             "post": {
+                "description": "Returns the calling peer's role in the template group and their permissions for the specified schema. Proxied through P2P for remote viewers. The host is the authority.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2797,17 +2977,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "data"
                 ],
-                "summary": "Delete a transformation definition",
+                "summary": "Get caller's role and permissions for a schema",
                 "parameters": [
                     {
-                        "description": "Transformation name",
+                        "description": "Schema/table name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformNameRequest"
+                            "$ref": "#/definitions/routes.dataRoleRequest"
                         }
                     }
                 ],
@@ -2815,15 +2995,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.dataRoleResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/transformations/execute": {
+        "/api/data/schemas": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schema"
+                ],
+                "summary": "List all stored schema definitions (JSON files)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.schemaListEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/data/schemas/apply": {
             "post": {
-                "description": "Reads rows from source_table (with optional WHERE filter), applies the named mapping, and inserts results into target_table. Both tables must exist.",
+                "description": "Reads the schema JSON file, validates it, and creates the table with full ORM type support.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2831,17 +3033,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "schema"
                 ],
-                "summary": "Execute a transformation: read from source table, transform, insert into target table",
+                "summary": "Create an ORM table from a stored schema definition",
                 "parameters": [
                     {
-                        "description": "Execute request",
+                        "description": "Schema name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformExecuteRequest"
+                            "$ref": "#/definitions/routes.schemaNameRequest"
                         }
                     }
                 ],
@@ -2849,17 +3051,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.transformExecuteResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "validation/transformation error",
+                        "description": "validation error",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "transformation not found",
+                        "description": "schema not found",
                         "schema": {
                             "type": "string"
                         }
@@ -2867,7 +3069,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/data/transformations/file-exists": {
+        "/api/data/schemas/ddl": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2876,17 +3078,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformations"
+                    "schema"
                 ],
-                "summary": "Check if a local file path exists",
+                "summary": "Preview the DDL (CREATE TABLE statement) for a schema",
                 "parameters": [
                     {
-                        "description": "File path",
+                        "description": "Schema to preview",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformFileExistsRequest"
+                            "$ref": "#/definitions/routes.schemaSaveRequest"
                         }
                     }
                 ],
@@ -2894,16 +3096,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
+                            "$ref": "#/definitions/routes.schemaDdlResponse"
                         }
                     }
                 }
             }
         },
-        "/api/data/transformations/get": {
+        "/api/data/schemas/delete": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2912,17 +3111,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "schema"
                 ],
-                "summary": "Get a transformation definition by name",
+                "summary": "Delete a schema definition",
                 "parameters": [
                     {
-                        "description": "Transformation name",
+                        "description": "Schema name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformNameRequest"
+                            "$ref": "#/definitions/routes.schemaNameRequest"
                         }
                     }
                 ],
@@ -2930,19 +3129,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.transformSaveRequest"
-                        }
-                    },
-                    "404": {
-                        "description": "transformation not found",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/data/transformations/preview": {
+        "/api/data/schemas/get": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2951,17 +3144,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "schema"
                 ],
-                "summary": "Preview a transformation by applying it to sample rows (dry run)",
+                "summary": "Get a schema definition by name",
                 "parameters": [
                     {
-                        "description": "Transformation name and sample rows",
+                        "description": "Schema name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformPreviewRequest"
+                            "$ref": "#/definitions/routes.schemaNameRequest"
                         }
                     }
                 ],
@@ -2969,21 +3162,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "transformation error",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.schemaSaveRequest"
                         }
                     },
                     "404": {
-                        "description": "transformation not found",
+                        "description": "schema not found",
                         "schema": {
                             "type": "string"
                         }
@@ -2991,7 +3174,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/data/transformations/save": {
+        "/api/data/schemas/save": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3000,17 +3183,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformation"
+                    "schema"
                 ],
-                "summary": "Create or update a transformation definition (saves as JSON file)",
+                "summary": "Create or update a schema definition (saves as JSON file)",
                 "parameters": [
                     {
-                        "description": "Transformation definition",
+                        "description": "Schema definition",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.transformSaveRequest"
+                            "$ref": "#/definitions/routes.schemaSaveRequest"
                         }
                     }
                 ],
@@ -3030,7 +3213,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/data/transformations/source-fields": {
+        "/api/data/schemas/set-access": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3039,46 +3222,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transformations"
+                    "schema"
                 ],
-                "summary": "Discover column names from a data source (table or file)",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                "summary": "Update access policy for a stored schema",
+                "parameters": [
+                    {
+                        "description": "Schema name and access policy",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/routes.schemaSetAccessRequest"
                         }
                     }
-                }
-            }
-        },
-        "/api/data/transformations/transforms": {
-            "get": {
-                "produces": [
-                    "application/json"
                 ],
-                "tags": [
-                    "transformation"
-                ],
-                "summary": "List available transform functions for transformation fields",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "schema not found",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/update": {
+        "/api/data/schemas/set-context": {
             "post": {
+                "description": "When context is true, the schema's table is exposed via the GraphQL API. Auto-rebuilds the GraphQL schema.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3086,17 +3262,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "schema"
                 ],
-                "summary": "Update rows in a table",
+                "summary": "Toggle whether a schema is included in the GraphQL context",
                 "parameters": [
                     {
-                        "description": "Update request",
+                        "description": "Schema name and context flag",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataUpdateRequest"
+                            "$ref": "#/definitions/routes.schemaSetContextRequest"
                         }
                     }
                 ],
@@ -3106,11 +3282,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
+                    },
+                    "404": {
+                        "description": "schema not found",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/api/data/update-where": {
+        "/api/data/schemas/set-roles": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3119,17 +3301,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "schema"
                 ],
-                "summary": "Update rows matching a WHERE clause",
+                "summary": "Update role access matrix for a stored schema",
                 "parameters": [
                     {
-                        "description": "Update parameters",
+                        "description": "Schema name and roles map",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataUpdateWhereRequest"
+                            "$ref": "#/definitions/routes.schemaSetRolesRequest"
                         }
                     }
                 ],
@@ -3137,13 +3319,41 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.dataAffectedResponse"
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "schema not found",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/data/upsert": {
+        "/api/data/tables": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "List tables with schema, policies, and mode (orm/classic)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.dataTableListEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/data/tables/add-column": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3154,15 +3364,15 @@ const docTemplate = `{
                 "tags": [
                     "data"
                 ],
-                "summary": "Insert or update a row by key column",
+                "summary": "Add a column to a table",
                 "parameters": [
                     {
-                        "description": "Upsert parameters",
+                        "description": "Add column request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.dataUpsertRequest"
+                            "$ref": "#/definitions/routes.dataColumnRequest"
                         }
                     }
                 ],
@@ -3176,9 +3386,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/datafed/contributions": {
+        "/api/data/tables/create": {
             "post": {
-                "description": "Returns each peer's contributed tables and relationships. Tables with the same name from multiple peers are merged by column intersection in the federated GraphQL schema.",
+                "description": "Accepts two column formats. Classic: columns with name/type/not_null/default. ORM: columns with name/type/key/required/default (lowercase types: text, integer, real, blob). Format is auto-detected from column fields.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3186,17 +3396,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data-federation"
+                    "data"
                 ],
-                "summary": "Get all peer contributions for a data-federation group",
+                "summary": "Create a new table (classic or ORM schema format, auto-detected)",
                 "parameters": [
                     {
-                        "description": "Group ID",
+                        "description": "Create request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.datafedGroupIDRequest"
+                            "$ref": "#/definitions/routes.dataTableCreateRequest"
                         }
                     }
                 ],
@@ -3204,40 +3414,20 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.datafedPeerContribution"
-                            }
+                            "$ref": "#/definitions/routes.dataTableCreateResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/datafed/groups": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "data-federation"
-                ],
-                "summary": "List all active data-federation groups and their contributions",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "Validation error",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.datafedGroupInfo"
-                            }
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/datafed/offer": {
+        "/api/data/tables/delete": {
             "post": {
-                "description": "Contributes selected tables (and optional relationships) to the group's federated graph. Triggers a federated schema rebuild.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3245,17 +3435,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data-federation"
+                    "data"
                 ],
-                "summary": "Offer context tables to a data-federation group",
+                "summary": "Drop a table",
                 "parameters": [
                     {
-                        "description": "Tables to offer",
+                        "description": "Table name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.datafedOfferRequest"
+                            "$ref": "#/definitions/routes.dataTableRequest"
                         }
                     }
                 ],
@@ -3265,17 +3455,11 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "400": {
-                        "description": "validation error",
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 }
             }
         },
-        "/api/datafed/withdraw": {
+        "/api/data/tables/describe": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3284,17 +3468,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "data-federation"
+                    "data"
                 ],
-                "summary": "Withdraw all contributed tables from a data-federation group",
+                "summary": "Describe a table — ORM tables return typed JSON schema, classic returns PRAGMA columns",
                 "parameters": [
                     {
-                        "description": "Group ID",
+                        "description": "Table name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.datafedGroupIDRequest"
+                            "$ref": "#/definitions/routes.dataTableRequest"
                         }
                     }
                 ],
@@ -3302,42 +3486,46 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.dataDescribeResponse"
                         }
                     }
                 }
             }
         },
-        "/api/docs/browse": {
-            "get": {
+        "/api/data/tables/drop-column": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
+                    "data"
                 ],
-                "summary": "Aggregate file lists from all group members (parallel fetch)",
+                "summary": "Drop a column from a table",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Group ID",
-                        "name": "group_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Drop column request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.dataColumnRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/docs/delete": {
+        "/api/data/tables/export-schema": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3346,17 +3534,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
+                    "data"
                 ],
-                "summary": "Delete a shared file (local access only)",
+                "summary": "Export table schema as portable JSON (ORM returns stored schema, classic reads PRAGMA)",
                 "parameters": [
                     {
-                        "description": "Delete request",
+                        "description": "Table name",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.docsDeleteRequest"
+                            "$ref": "#/definitions/routes.dataTableRequest"
                         }
                     }
                 ],
@@ -3364,133 +3552,121 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.ormSchema"
                         }
                     }
                 }
             }
         },
-        "/api/docs/download": {
-            "get": {
-                "tags": [
-                    "docs"
-                ],
-                "summary": "Download a file (local store or proxied from remote peer)",
+        "/api/data/tables/rename": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Rename a table",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Group ID",
-                        "name": "group_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filename",
-                        "name": "file",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Peer ID (empty = self)",
-                        "name": "peer_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Pass '1' for Content-Disposition: inline",
-                        "name": "inline",
-                        "in": "query"
+                        "description": "Rename request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.dataRenameRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "File content",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/docs/groups": {
-            "get": {
-                "description": "Returns hosted, subscribed, and local file groups with per-group file lists.",
+        "/api/data/tables/set-policy": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
+                    "data"
+                ],
+                "summary": "Set insert policy for a table (owner, open, group, local)",
+                "parameters": [
+                    {
+                        "description": "Policy request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.dataPolicyRequest"
+                        }
+                    }
                 ],
-                "summary": "List all file-sharing groups with their local files",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.docGroupsResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/docs/my": {
+        "/api/data/transformations": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
-                ],
-                "summary": "List my shared files for a group",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Group ID",
-                        "name": "group_id",
-                        "in": "query",
-                        "required": true
-                    }
+                    "transformation"
                 ],
+                "summary": "List all stored transformation definitions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/routes.docFileInfo"
+                                "$ref": "#/definitions/routes.transformListEntry"
                             }
                         }
                     }
                 }
             }
         },
-        "/api/docs/upload": {
+        "/api/data/transformations/delete": {
             "post": {
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
+                    "transformation"
                 ],
-                "summary": "Upload a file to share with the group (multipart)",
+                "summary": "Delete a transformation definition",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Group ID",
-                        "name": "group_id",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "File to upload (max 50 MB)",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Transformation name",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.transformNameRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -3503,8 +3679,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/docs/upload-local": {
+        "/api/data/transformations/execute": {
             "post": {
+                "description": "Reads rows from source_table (with optional WHERE filter), applies the named mapping, and inserts results into target_table. Both tables must exist.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3512,17 +3689,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "docs"
+                    "transformation"
                 ],
-                "summary": "Upload a file from a local filesystem path to share with the group",
+                "summary": "Execute a transformation: read from source table, transform, insert into target table",
                 "parameters": [
                     {
-                        "description": "Upload request",
+                        "description": "Execute request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.docsUploadLocalRequest"
+                            "$ref": "#/definitions/routes.transformExecuteRequest"
                         }
                     }
                 ],
@@ -3530,48 +3707,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.transformExecuteResponse"
                         }
                     },
                     "400": {
-                        "description": "Missing group_id or path / Cannot read file",
+                        "description": "validation/transformation error",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
-            }
-        },
-        "/api/email/": {
-            "get": {
-                "tags": [
-                    "services"
-                ],
-                "summary": "Reverse proxy for email service",
-                "responses": {}
-            }
-        },
-        "/api/encryption/broadcast-key": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "encryption"
-                ],
-                "summary": "Fetch sealed broadcast key for a peer",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "transformation not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/encryption/keys": {
+        "/api/data/transformations/file-exists": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3580,36 +3734,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "encryption"
-                ],
-                "summary": "Upload peer's public encryption key",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            }
-        },
-        "/api/encryption/keys/{peer_id}": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "encryption"
+                    "transformations"
                 ],
-                "summary": "Fetch a peer's public encryption key",
+                "summary": "Check if a local file path exists",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Peer ID",
-                        "name": "peer_id",
-                        "in": "path",
-                        "required": true
+                        "description": "File path",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.transformFileExistsRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -3617,57 +3753,46 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/executor-api.yaml": {
-            "get": {
-                "produces": [
-                    "text/yaml"
-                ],
-                "tags": [
-                    "rendezvous"
+        "/api/data/transformations/get": {
+            "post": {
+                "consumes": [
+                    "application/json"
                 ],
-                "summary": "Executor OpenAPI specification (YAML)",
-                "responses": {
-                    "200": {
-                        "description": "OpenAPI YAML spec",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/fs/browse": {
-            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "fs"
+                    "transformation"
                 ],
-                "summary": "Browse the local filesystem (directories and files)",
+                "summary": "Get a transformation definition by name",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Directory to list (defaults to home directory)",
-                        "name": "dir",
-                        "in": "query"
+                        "description": "Transformation name",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.transformNameRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.fsBrowseResponse"
+                            "$ref": "#/definitions/routes.transformSaveRequest"
                         }
                     },
-                    "400": {
-                        "description": "cannot read directory",
+                    "404": {
+                        "description": "transformation not found",
                         "schema": {
                             "type": "string"
                         }
@@ -3675,9 +3800,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/graphql": {
+        "/api/data/transformations/preview": {
             "post": {
-                "description": "Queries and mutations are auto-generated from ORM schemas with context: true. Supports filtering, ordering, pagination, insert, update, and delete.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3685,17 +3809,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "graphql"
+                    "transformation"
                 ],
-                "summary": "Execute a GraphQL query or mutation",
+                "summary": "Preview a transformation by applying it to sample rows (dry run)",
                 "parameters": [
                     {
-                        "description": "GraphQL request",
+                        "description": "Transformation name and sample rows",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.graphqlRequest"
+                            "$ref": "#/definitions/routes.transformPreviewRequest"
                         }
                     }
                 ],
@@ -3703,34 +3827,29 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
                         }
-                    }
-                }
-            }
-        },
-        "/api/graphql/rebuild": {
-            "post": {
-                "description": "Forces a rebuild of the GraphQL schema. Called automatically when schemas change, but can be triggered manually.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "graphql"
-                ],
-                "summary": "Rebuild the GraphQL schema from current context tables",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "transformation error",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "transformation not found",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/graphql/schema": {
+        "/api/data/transformations/save": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3739,17 +3858,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "graphql"
+                    "transformation"
                 ],
-                "summary": "Preview GraphQL SDL for a table schema",
+                "summary": "Create or update a transformation definition (saves as JSON file)",
                 "parameters": [
                     {
-                        "description": "Table schema definition",
+                        "description": "Transformation definition",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.schemaSaveRequest"
+                            "$ref": "#/definitions/routes.transformSaveRequest"
                         }
                     }
                 ],
@@ -3757,52 +3876,77 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.graphqlSchemaResponse"
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "400": {
+                        "description": "validation error",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/graphql/status": {
-            "get": {
+        "/api/data/transformations/source-fields": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "graphql"
+                    "transformations"
+                ],
+                "summary": "Discover column names from a data source (table or file)",
+                "parameters": [
+                    {
+                        "description": "Data source",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.transformDataEndpoint"
+                        }
+                    }
                 ],
-                "summary": "Get GraphQL engine status and context tables",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.graphqlStatusResponse"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/groups": {
+        "/api/data/transformations/transforms": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "transformation"
                 ],
-                "summary": "List hosted groups with live member data",
+                "summary": "List available transform functions for transformation fields",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/routes.hostedGroupInfo"
+                                "type": "string"
                             }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/data/update": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3811,17 +3955,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data"
                 ],
-                "summary": "Create a new hosted group",
+                "summary": "Update a row by id",
                 "parameters": [
                     {
-                        "description": "Create request",
+                        "description": "Update request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupCreateRequest"
+                            "$ref": "#/definitions/routes.dataUpdateRequest"
                         }
                     }
                 ],
@@ -3829,13 +3973,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.groupCreateResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/groups/close": {
+        "/api/data/update-where": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3844,17 +3988,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data"
                 ],
-                "summary": "Close and delete a hosted group (broadcasts group:close via MQ to all members)",
+                "summary": "Update rows matching a WHERE clause",
                 "parameters": [
                     {
-                        "description": "Group ID",
+                        "description": "Update parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupIDRequest"
+                            "$ref": "#/definitions/routes.dataUpdateWhereRequest"
                         }
                     }
                 ],
@@ -3862,33 +4006,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/groups/events": {
-            "get": {
-                "description": "Re-emits group events from the unified MQ stream in SSE wire format. Used by SDK templates that subscribe to group events without connecting to the full MQ SSE endpoint.",
-                "produces": [
-                    "text/event-stream"
-                ],
-                "tags": [
-                    "groups"
-                ],
-                "summary": "SSE stream — group lifecycle events for SDK and templates",
-                "responses": {
-                    "200": {
-                        "description": "SSE stream",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.dataAffectedResponse"
                         }
                     }
                 }
             }
         },
-        "/api/groups/invite": {
+        "/api/data/upsert": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -3897,17 +4021,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data"
                 ],
-                "summary": "Invite a peer to a hosted group (sends group.invite via MQ)",
+                "summary": "Insert or update a row by key column",
                 "parameters": [
                     {
-                        "description": "Invite request",
+                        "description": "Upsert parameters",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupPeerRequest"
+                            "$ref": "#/definitions/routes.dataUpsertRequest"
                         }
                     }
                 ],
@@ -3921,8 +4045,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/groups/join": {
+        "/api/datafed/contributions": {
             "post": {
+                "description": "Returns each peer's contributed tables and relationships. Tables with the same name from multiple peers are merged by column intersection in the federated GraphQL schema.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3930,17 +4055,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data-federation"
                 ],
-                "summary": "Join a remote group as a member (sends group:join via MQ)",
+                "summary": "Get all peer contributions for a data-federation group",
                 "parameters": [
                     {
-                        "description": "Join request",
+                        "description": "Group ID",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupHostJoinRequest"
+                            "$ref": "#/definitions/routes.datafedGroupIDRequest"
                         }
                     }
                 ],
@@ -3948,47 +4073,40 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.datafedPeerContribution"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/groups/join-own": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/datafed/groups": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
-                ],
-                "summary": "Host joins own group as a member",
-                "parameters": [
-                    {
-                        "description": "Group ID",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.groupIDRequest"
-                        }
-                    }
+                    "data-federation"
                 ],
+                "summary": "List all active data-federation groups and their contributions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.datafedGroupInfo"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/groups/kick": {
+        "/api/datafed/offer": {
             "post": {
+                "description": "Contributes selected tables (and optional relationships) to the group's federated graph. Triggers a federated schema rebuild.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3996,17 +4114,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data-federation"
                 ],
-                "summary": "Kick a member from a hosted group",
+                "summary": "Offer context tables to a data-federation group",
                 "parameters": [
                     {
-                        "description": "Kick request",
+                        "description": "Tables to offer",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupPeerRequest"
+                            "$ref": "#/definitions/routes.datafedOfferRequest"
                         }
                     }
                 ],
@@ -4016,11 +4134,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
+                    },
+                    "400": {
+                        "description": "validation error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/api/groups/leave": {
+        "/api/datafed/withdraw": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4029,9 +4153,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "data-federation"
                 ],
-                "summary": "Leave a group as a member (sends group:leave via MQ)",
+                "summary": "Withdraw all contributed tables from a data-federation group",
                 "parameters": [
                     {
                         "description": "Group ID",
@@ -4039,7 +4163,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupIDRequest"
+                            "$ref": "#/definitions/routes.datafedGroupIDRequest"
                         }
                     }
                 ],
@@ -4053,8 +4177,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/groups/leave-own": {
+        "/api/digest": {
+            "get": {
+                "description": "Reads this peer's digest subscription from the rendezvous. available is false, with an error, when the email is not verified or no rendezvous offers digests.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Email digest subscription (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
             "post": {
+                "description": "frequency is off, daily or weekly. With favorites, new listen stations of your favorite peers are included; their peer IDs are sent to the rendezvous.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4062,17 +4206,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "settings"
                 ],
-                "summary": "Host leaves own group",
+                "summary": "Change the email digest subscription (local only)",
                 "parameters": [
                     {
-                        "description": "Group ID",
+                        "description": "Preferences",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupIDRequest"
+                            "$ref": "#/definitions/routes.digestRequest"
                         }
                     }
                 ],
@@ -4080,14 +4224,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/rendezvous.DigestStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "email not verified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "no rendezvous accepted the change",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/groups/max-members": {
+        "/api/docs/acl": {
             "post": {
+                "description": "\"group\" (the default) lets the group's members fetch the file, \"peers\" only the listed peers, \"public\" any peer that asks. Other peers' file lists leave out what they may not fetch.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4095,17 +4252,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Update max member limit for a hosted group",
+                "summary": "Set who may fetch a shared file (local access only)",
                 "parameters": [
                     {
-                        "description": "Max members request",
+                        "description": "File and access",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupMaxMembersRequest"
+                            "$ref": "#/definitions/routes.docsACLRequest"
                         }
                     }
                 ],
@@ -4113,47 +4270,62 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/files.ACL"
                         }
-                    }
-                }
-            }
+                    },
+                    "400": {
+                        "description": "Bad access, peers or filename",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "No such file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
         },
-        "/api/groups/meta": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/docs/browse": {
+            "get": {
+                "description": "Members are asked fastest first. The reply comes once all answered or after 2s; members not done by then have error \"slow\" and are listed in slow. With stream=1 the reply is a server-sent event stream instead: a \"peer\" event per member as it answers, then \"done\".",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Update group name and/or max_members (broadcasts group:meta via MQ)",
+                "summary": "Aggregate file lists from all group members",
                 "parameters": [
                     {
-                        "description": "Meta request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.groupMetaRequest"
-                        }
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1 to stream members as they answer",
+                        "name": "stream",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.docsBrowseResponse"
                         }
                     }
                 }
             }
         },
-        "/api/groups/rejoin": {
+        "/api/docs/browse/refresh": {
             "post": {
+                "description": "Waits for the fetch still running for each member, or uses its answer from the last 30s. Peers that are not group members are left out.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4161,17 +4333,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Rejoin a previously joined group",
+                "summary": "Fetch the file lists of members a browse listed as slow",
                 "parameters": [
                     {
-                        "description": "Rejoin request",
+                        "description": "Group and members",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupHostJoinRequest"
+                            "$ref": "#/definitions/routes.docsBrowseRefreshRequest"
                         }
                     }
                 ],
@@ -4179,13 +4351,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.docsBrowseResponse"
                         }
                     }
                 }
             }
         },
-        "/api/groups/send": {
+        "/api/docs/delete": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4194,17 +4366,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Send a payload to a group (host broadcasts, member sends to host)",
+                "summary": "Delete a shared file (local access only)",
                 "parameters": [
                     {
-                        "description": "Send request",
+                        "description": "Delete request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupSendRequest"
+                            "$ref": "#/definitions/routes.docsDeleteRequest"
                         }
                     }
                 ],
@@ -4218,125 +4390,147 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/groups/set-default-role": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/api/docs/download": {
+            "get": {
+                "description": "From peers that speak /goop/docs/2.0.0 the file is fetched in chunks into a cache, reporting docs:download:\u003cfile\u003e:progress MQ events; a download that broke off resumes when requested again. Range requests are honored for such files.",
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Set the default role assigned to new members of a hosted group",
+                "summary": "Download a file (local store or proxied from remote peer)",
                 "parameters": [
                     {
-                        "description": "Set default role request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.groupSetDefaultRoleRequest"
-                        }
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filename",
+                        "name": "file",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Peer ID (empty = self)",
+                        "name": "peer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pass '1' for Content-Disposition: inline",
+                        "name": "inline",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "File content",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Fetch from the peer failed",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/groups/set-role": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/docs/groups": {
+            "get": {
+                "description": "Returns hosted, subscribed, and local file groups with per-group file lists.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
-                ],
-                "summary": "Set a member's role in a hosted group",
-                "parameters": [
-                    {
-                        "description": "Set role request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.groupSetRoleRequest"
-                        }
-                    }
+                    "docs"
                 ],
+                "summary": "List all file-sharing groups with their local files",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.docGroupsResponse"
                         }
                     }
                 }
             }
         },
-        "/api/groups/set-roles": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/docs/my": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Set the available roles for a hosted group",
+                "summary": "List my shared files for a group",
                 "parameters": [
                     {
-                        "description": "Set roles request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.groupSetRolesListRequest"
-                        }
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.docFileInfo"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/groups/subscriptions": {
-            "get": {
+        "/api/docs/upload": {
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
+                ],
+                "summary": "Upload a file to share with the group (multipart)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload (max 50 MB)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "List group subscriptions (member side) with host reachability",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.subscriptionsResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/groups/subscriptions/remove": {
+        "/api/docs/upload-local": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4345,17 +4539,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "docs"
                 ],
-                "summary": "Remove a stale subscription record",
+                "summary": "Upload a file from a local filesystem path to share with the group",
                 "parameters": [
                     {
-                        "description": "Remove request",
+                        "description": "Upload request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.groupHostJoinRequest"
+                            "$ref": "#/definitions/routes.docsUploadLocalRequest"
                         }
                     }
                 ],
@@ -4365,30 +4559,48 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
+                    },
+                    "400": {
+                        "description": "Missing group_id or path / Cannot read file",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/api/listen/close": {
-            "post": {
+        "/api/email/": {
+            "get": {
+                "tags": [
+                    "services"
+                ],
+                "summary": "Reverse proxy for email service",
+                "responses": {},
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/encryption/broadcast-key": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "encryption"
                 ],
-                "summary": "Host closes the listen group",
+                "summary": "Fetch sealed broadcast key for a peer",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/listen/control": {
+        "/api/encryption/keys": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4397,64 +4609,115 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
-                ],
-                "summary": "Playback control — play, pause, seek, next, prev, skip, remove",
-                "parameters": [
-                    {
-                        "description": "Control request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.listenControlRequest"
-                        }
-                    }
+                    "encryption"
                 ],
+                "summary": "Upload peer's public encryption key",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
-                }
+                },
+                "x-served-by": "rendezvous"
             }
         },
-        "/api/listen/create": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/encryption/keys/{peer_id}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "encryption"
                 ],
-                "summary": "Host creates a listen group",
+                "summary": "Fetch a peer's public encryption key",
                 "parameters": [
                     {
-                        "description": "Create request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.listenCreateRequest"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/executor-api.yaml": {
+            "get": {
+                "produces": [
+                    "text/yaml"
+                ],
+                "tags": [
+                    "rendezvous"
+                ],
+                "summary": "Executor OpenAPI specification (YAML)",
+                "responses": {
+                    "200": {
+                        "description": "OpenAPI YAML spec",
+                        "schema": {
+                            "type": "string"
                         }
                     }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/export/all": {
+            "get": {
+                "description": "A zip with one self-describing JSON file per table (columns and rows), the config file and manifest.json.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "export"
                 ],
+                "summary": "Download everything this peer stores (local only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.listenGroup"
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/api/listen/join": {
+        "/api/export/summary": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Row counts per data category and table (local only)",
+                "responses": {
+                    "200": {
+                        "description": "name, description, tables: [{table, rows}]",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/export/wipe": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4463,51 +4726,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "export"
                 ],
-                "summary": "Listener joins a group",
+                "summary": "Delete stored data by category, optionally for one peer only (local only)",
                 "parameters": [
                     {
-                        "description": "Join request",
+                        "description": "Categories and optional peer",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.listenJoinRequest"
+                            "$ref": "#/definitions/routes.dataWipeRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "deleted: {table: rows}",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "unknown category, or site data without a peer",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/listen/leave": {
-            "post": {
+        "/api/fs/browse": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "fs"
+                ],
+                "summary": "Browse the local filesystem (directories and files)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory to list (defaults to home directory)",
+                        "name": "dir",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Listener leaves the current group",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.statusOK"
+                            "$ref": "#/definitions/routes.fsBrowseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "cannot read directory",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/listen/load": {
+        "/api/graphql": {
             "post": {
+                "description": "Queries and mutations are auto-generated from ORM schemas with context: true. Supports filtering, ordering, pagination, insert, update, and delete.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4515,17 +4800,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "graphql"
                 ],
-                "summary": "Load MP3 file(s) as playlist (local access only)",
+                "summary": "Execute a GraphQL query or mutation",
                 "parameters": [
                     {
-                        "description": "Load request",
+                        "description": "GraphQL request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.listenLoadRequest"
+                            "$ref": "#/definitions/routes.graphqlRequest"
                         }
                     }
                 ],
@@ -4533,35 +4818,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.listenTrack"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/api/listen/queue/add": {
+        "/api/graphql/rebuild": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Forces a rebuild of the GraphQL schema. Called automatically when schemas change, but can be triggered manually.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
-                ],
-                "summary": "Append files to the playlist (local access only)",
-                "parameters": [
-                    {
-                        "description": "Queue add request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/routes.listenQueueAddRequest"
-                        }
-                    }
+                    "graphql"
                 ],
+                "summary": "Rebuild the GraphQL schema from current context tables",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -4572,119 +4845,146 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/listen/state": {
-            "get": {
+        "/api/graphql/schema": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "graphql"
+                ],
+                "summary": "Preview GraphQL SDL for a table schema",
+                "parameters": [
+                    {
+                        "description": "Table schema definition",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.schemaSaveRequest"
+                        }
+                    }
                 ],
-                "summary": "Current listen group state",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.listenStateResponse"
+                            "$ref": "#/definitions/routes.graphqlSchemaResponse"
                         }
                     }
                 }
             }
         },
-        "/api/listen/stream": {
+        "/api/graphql/status": {
             "get": {
-                "description": "Chunked streaming response (audio/mpeg). Connect an HTML audio element src directly to this URL.",
                 "produces": [
-                    "audio/mpeg"
+                    "application/json"
                 ],
                 "tags": [
-                    "listen"
+                    "graphql"
                 ],
-                "summary": "Live MP3 audio stream",
+                "summary": "Get GraphQL engine status and context tables",
                 "responses": {
                     "200": {
-                        "description": "Chunked audio stream",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.graphqlStatusResponse"
                         }
                     }
                 }
             }
         },
-        "/api/logs": {
+        "/api/groups": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "logs"
+                    "groups"
                 ],
-                "summary": "Snapshot of recent Go process log lines",
+                "summary": "List hosted groups with live member data",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object",
-                                "additionalProperties": {
-                                    "type": "string"
-                                }
+                                "$ref": "#/definitions/routes.hostedGroupInfo"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/api/logs/client": {
+            },
             "post": {
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "logs"
+                    "groups"
                 ],
-                "summary": "Sink for browser-side log messages",
+                "summary": "Create a new hosted group",
                 "parameters": [
                     {
-                        "description": "Log entry",
+                        "description": "Create request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.clientLogRequest"
+                            "$ref": "#/definitions/routes.groupCreateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Accepted"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupCreateResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/logs/stream": {
-            "get": {
+        "/api/groups/close": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "logs"
+                    "groups"
+                ],
+                "summary": "Close and delete a hosted group (broadcasts group:close via MQ to all members)",
+                "parameters": [
+                    {
+                        "description": "Group ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupIDRequest"
+                        }
+                    }
                 ],
-                "summary": "SSE stream of new Go process log lines",
                 "responses": {
                     "200": {
-                        "description": "SSE stream",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/logs/verbose": {
-            "get": {
-                "description": "GET returns current verbose status. POST enables or disables verbose logging.",
+        "/api/groups/cohost": {
+            "post": {
                 "consumes": [
                     "application/json"
                 ],
@@ -4692,16 +4992,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "logs"
+                    "groups"
                 ],
-                "summary": "Get or set verbose P2P logging",
+                "summary": "Authorize a member to co-host (relay) a hosted group",
                 "parameters": [
                     {
-                        "description": "Enable/disable verbose (POST only)",
+                        "description": "Co-host request",
                         "name": "body",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/routes.groupPeerRequest"
                         }
                     }
                 ],
@@ -4709,51 +5010,66 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "204": {
-                        "description": "No Content"
                     }
                 }
             }
         },
-        "/api/lua/content": {
-            "get": {
+        "/api/groups/cohost/remove": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "lua"
+                    "groups"
                 ],
-                "summary": "Fetch a Lua script's content",
+                "summary": "Withdraw a co-host's authorization to relay a hosted group",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Script name (without .lua)",
-                        "name": "name",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Set to 1 for data functions",
-                        "name": "func",
-                        "in": "query"
+                        "description": "Co-host request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupPeerRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/lua/prefabs/apply": {
+        "/api/groups/events": {
+            "get": {
+                "description": "Re-emits group events from the unified MQ stream in SSE wire format. Used by SDK templates that subscribe to group events without connecting to the full MQ SSE endpoint.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "SSE stream — group lifecycle events for SDK and templates",
+                "responses": {
+                    "200": {
+                        "description": "SSE stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/groups/invite": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4762,17 +5078,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "lua"
+                    "groups"
                 ],
-                "summary": "Install scripts from a prefab pack",
+                "summary": "Invite a peer to a hosted group (sends group.invite via MQ)",
                 "parameters": [
                     {
-                        "description": "Prefab install request",
+                        "description": "Invite request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.luaPrefabApplyRequest"
+                            "$ref": "#/definitions/routes.groupPeerRequest"
                         }
                     }
                 ],
@@ -4780,27 +5096,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.luaPrefabApplyResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "prefab name required / prefab not found / script not found in prefab",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "bad csrf",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/mq/ack": {
+        "/api/groups/join": {
             "post": {
-                "description": "Called by the browser after processing an incoming MQ message.\\nRelays an application-level ACK to the sender.\\nIf from_peer_id is empty (PublishLocal event) the ACK is silently dropped.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4808,17 +5111,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "mq"
+                    "groups"
                 ],
-                "summary": "Acknowledge a received MQ message",
+                "summary": "Join a remote group as a member (sends group:join via MQ)",
                 "parameters": [
                     {
-                        "description": "Ack request",
+                        "description": "Join request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.mqAckRequest"
+                            "$ref": "#/definitions/routes.groupHostJoinRequest"
                         }
                     }
                 ],
@@ -4832,29 +5135,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/mq/events": {
-            "get": {
-                "description": "THE single browser event stream. Every incoming P2P MQ message and every PublishLocal event arrives here.\\nEvent frames are SSE 'message' events with JSON body: {type, msg:{id,seq,topic,payload}, from}.\\nKeep-alive: the stream never closes unless the server shuts down or the browser disconnects.",
+        "/api/groups/join-own": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "mq"
+                    "groups"
+                ],
+                "summary": "Host joins own group as a member",
+                "parameters": [
+                    {
+                        "description": "Group ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupIDRequest"
+                        }
+                    }
                 ],
-                "summary": "SSE stream — incoming MQ messages and delivery receipts",
                 "responses": {
                     "200": {
-                        "description": "SSE stream",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/mq/send": {
+        "/api/groups/kick": {
             "post": {
-                "description": "Delivers the payload to the remote peer over the MQ P2P protocol.\\nBlocks until the peer sends a transport ACK (up to 4 s, one retry).\\nTopic convention: call:{channelId}, group:{groupId}:{type}, group.invite, chat, chat.broadcast, identity, identity.response",
                 "consumes": [
                     "application/json"
                 ],
@@ -4862,17 +5177,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "mq"
+                    "groups"
                 ],
-                "summary": "Send an MQ message to a peer",
+                "summary": "Kick a member from a hosted group",
                 "parameters": [
                     {
-                        "description": "Send request",
+                        "description": "Kick request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.mqSendRequest"
+                            "$ref": "#/definitions/routes.groupPeerRequest"
                         }
                     }
                 ],
@@ -4880,116 +5195,112 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.mqSendResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "missing peer_id or topic",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "504": {
-                        "description": "peer unreachable",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/my-balance": {
-            "get": {
-                "description": "Queries the credits service via the rendezvous server. Returns credits_active=false when no service is configured.",
+        "/api/groups/leave": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credits"
+                    "groups"
                 ],
-                "summary": "Get this peer's credit balance",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                "summary": "Leave a group as a member (sends group:leave via MQ)",
+                "parameters": [
+                    {
+                        "description": "Group ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.myBalanceResponse"
+                            "$ref": "#/definitions/routes.groupIDRequest"
                         }
                     }
-                }
-            }
-        },
-        "/api/openapi.json": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "logs"
                 ],
-                "summary": "This OpenAPI 3.0 spec (generated by swaggo/swag)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/peer/content": {
-            "get": {
+        "/api/groups/leave-own": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "groups"
                 ],
-                "summary": "Fetch a remote peer's site content (HTML string)",
+                "summary": "Host leaves own group",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Peer ID",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+                        "description": "Group ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupIDRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.peerContentResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/peers": {
-            "get": {
+        "/api/groups/max-members": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "groups"
+                ],
+                "summary": "Update max member limit for a hosted group",
+                "parameters": [
+                    {
+                        "description": "Max members request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupMaxMembersRequest"
+                        }
+                    }
                 ],
-                "summary": "List all known peers with metadata",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/peers/favorite": {
+        "/api/groups/meta": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -4998,17 +5309,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "groups"
                 ],
-                "summary": "Toggle favorite flag for a peer",
+                "summary": "Update group name and/or max_members (broadcasts group:meta via MQ)",
                 "parameters": [
                     {
-                        "description": "Favorite request",
+                        "description": "Meta request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.peerFavoriteRequest"
+                            "$ref": "#/definitions/routes.groupMetaRequest"
                         }
                     }
                 ],
@@ -5022,15 +5333,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/peers/probe": {
+        "/api/groups/ordered": {
             "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "groups"
+                ],
+                "summary": "Turn ordered delivery on or off for a hosted group",
+                "parameters": [
+                    {
+                        "description": "Ordered request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupOrderedRequest"
+                        }
+                    }
                 ],
-                "summary": "Probe all known peers for reachability",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -5041,20 +5366,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/pulse": {
-            "post": {
+        "/api/groups/ownership": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rendezvous"
+                    "groups"
                 ],
-                "summary": "Refresh relay reservation for a peer",
+                "summary": "Owner changes of a group, latest first",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Peer ID to refresh relay for",
-                        "name": "peer",
+                        "description": "Group ID",
+                        "name": "group_id",
                         "in": "query",
                         "required": true
                     }
@@ -5063,150 +5388,179 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.OwnershipRecord"
                             }
                         }
                     }
                 }
             }
         },
-        "/api/reg/": {
-            "get": {
-                "tags": [
-                    "services"
-                ],
-                "summary": "Reverse proxy for registration service",
-                "responses": {}
-            }
-        },
-        "/api/rendezvous/check": {
+        "/api/groups/presence": {
             "get": {
-                "description": "Fetches /api/capabilities from the given rendezvous URL and returns the capabilities map.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rendezvous"
+                    "groups"
                 ],
-                "summary": "Check a rendezvous server's capabilities",
+                "summary": "Online/offline status of every group member (separate from membership)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Rendezvous server URL",
-                        "name": "url",
+                        "description": "Group ID",
+                        "name": "group_id",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Capabilities map from remote server",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/group.MemberPresence"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/self": {
-            "get": {
+        "/api/groups/rejoin": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "groups"
+                ],
+                "summary": "Rejoin a previously joined group",
+                "parameters": [
+                    {
+                        "description": "Rejoin request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupHostJoinRequest"
+                        }
+                    }
                 ],
-                "summary": "This peer's own identity and metadata",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/services/check": {
-            "get": {
+        "/api/groups/send": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "groups"
                 ],
-                "summary": "Check a single service URL (pre-save validation)",
+                "summary": "Send a payload to a group (host broadcasts, member sends to host)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Service base URL",
-                        "name": "url",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Service type: registration, credits, email, templates",
-                        "name": "type",
-                        "in": "query"
+                        "description": "Send request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupSendRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.serviceHealthEntry"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/services/health": {
-            "get": {
+        "/api/groups/set-default-role": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "groups"
+                ],
+                "summary": "Set the default role assigned to new members of a hosted group",
+                "parameters": [
+                    {
+                        "description": "Set default role request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupSetDefaultRoleRequest"
+                        }
+                    }
                 ],
-                "summary": "Ping all configured external services",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.servicesHealthResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/services/logs": {
-            "get": {
+        "/api/groups/set-role": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rendezvous"
+                    "groups"
+                ],
+                "summary": "Set a member's role in a hosted group",
+                "parameters": [
+                    {
+                        "description": "Set role request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupSetRoleRequest"
+                        }
+                    }
                 ],
-                "summary": "Aggregate logs from all microservices (admin only)",
                 "responses": {
                     "200": {
-                        "description": "Array of {service, message} objects",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/settings/quick": {
+        "/api/groups/set-roles": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -5215,17 +5569,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "groups"
                 ],
-                "summary": "Partial settings update — only provided (non-null) fields are written",
+                "summary": "Set the available roles for a hosted group",
                 "parameters": [
                     {
-                        "description": "Partial settings",
+                        "description": "Set roles request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.quickSettingsRequest"
+                            "$ref": "#/definitions/routes.groupSetRolesListRequest"
                         }
                     }
                 ],
@@ -5239,58 +5593,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/settings/quick/get": {
+        "/api/groups/subscriptions": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "groups"
                 ],
-                "summary": "Read current quick settings (label, email, theme, device prefs, flags)",
+                "summary": "List group subscriptions (member side) with host reachability",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.quickSettingsResponse"
+                            "$ref": "#/definitions/routes.subscriptionsResponse"
                         }
                     }
                 }
             }
         },
-        "/api/site/content": {
-            "get": {
+        "/api/groups/subscriptions/remove": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "groups"
                 ],
-                "summary": "Fetch a site file's content and ETag",
+                "summary": "Remove a stale subscription record",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "File path relative to site root",
-                        "name": "path",
-                        "in": "query",
-                        "required": true
+                        "description": "Remove request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.groupHostJoinRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/site/delete": {
+        "/api/groups/transfer": {
             "post": {
+                "description": "The member becomes the owner once it confirms; the other members follow it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -5298,174 +5655,175 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "groups"
                 ],
-                "summary": "Delete a file from the site content store",
+                "summary": "Hand a hosted group over to one of its members",
                 "parameters": [
                     {
-                        "description": "File to delete",
+                        "description": "Transfer request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.siteDeleteRequest"
+                            "$ref": "#/definitions/routes.groupPeerRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status: deleted",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/routes.statusOK"
                         }
-                    },
-                    "400": {
-                        "description": "path required",
+                    }
+                }
+            }
+        },
+        "/api/groups/unmute": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Lift a flood-protection mute on a member of a hosted group",
+                "parameters": [
+                    {
+                        "description": "Unmute request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.groupPeerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/site/export": {
+        "/api/health": {
             "get": {
-                "description": "Exports manifest, schema, site files, and Lua scripts into a zip. The response is an attachment download.",
+                "description": "What the startup doctor found when this peer started: whether the previous run shut down cleanly, and per check (database, group-state, listen-queue, docs-cache) whether it was fine, repaired, failed or skipped. Slow checks only run after an unclean shutdown.",
                 "produces": [
-                    "application/zip"
+                    "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "settings"
                 ],
-                "summary": "Download the entire site as a zip archive",
+                "summary": "Startup checks",
                 "responses": {
                     "200": {
-                        "description": "Zip archive",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/routes.healthReport"
                         }
                     }
                 }
             }
         },
-        "/api/site/files": {
+        "/api/jobs": {
             "get": {
+                "description": "Counters of the queue that runs cache writes, identity requests, avatar warming and service registrations on a fixed set of workers, and the dead letters: jobs that failed their last attempt or were refused by a full queue, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "settings"
                 ],
-                "summary": "List site files as a flat tree",
+                "summary": "Background job queue",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.siteFileItem"
-                            }
+                            "$ref": "#/definitions/jobs.Report"
                         }
                     }
                 }
             }
         },
-        "/api/site/import": {
+        "/api/jobs/clear": {
             "post": {
-                "description": "Uploads a zip (manifest + schema + site/ + lua/) and applies it, replacing the current site and database.",
-                "consumes": [
-                    "multipart/form-data"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "site"
-                ],
-                "summary": "Import a site from a zip archive",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "CSRF token",
-                        "name": "csrf",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Zip archive to import",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "settings"
                 ],
+                "summary": "Empty the dead-letter list of the job queue",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.siteImportResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "file required / failed to extract zip",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.jobsClearResponse"
                         }
-                    },
-                    "403": {
-                        "description": "bad csrf",
+                    }
+                }
+            }
+        },
+        "/api/listen/close": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listen"
+                ],
+                "summary": "Host closes the listen group",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/site/upload": {
+        "/api/listen/control": {
             "post": {
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "listen"
                 ],
-                "summary": "Upload a file to the site content store",
+                "summary": "Playback control — play, pause, seek, next, prev, skip, remove",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Destination path relative to site root",
-                        "name": "path",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Control request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.listenControlRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.siteUploadResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "path required / file required",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/site/upload-local": {
+        "/api/listen/create": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -5474,17 +5832,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "site"
+                    "listen"
                 ],
-                "summary": "Upload a file from a local filesystem path to the site content store",
+                "summary": "Host creates a listen group",
                 "parameters": [
                     {
-                        "description": "Upload request",
+                        "description": "Create request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.siteUploadLocalRequest"
+                            "$ref": "#/definitions/routes.listenCreateRequest"
                         }
                     }
                 ],
@@ -5492,19 +5850,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.siteUploadResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "dest_path and src_path required / cannot read file",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.listenGroup"
                         }
                     }
                 }
             }
         },
-        "/api/split-prefs": {
+        "/api/listen/join": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -5513,17 +5865,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "listen"
                 ],
-                "summary": "Save a UI split pane preference (position 0-100)",
+                "summary": "Listener joins a group",
                 "parameters": [
                     {
-                        "description": "Split pane key and position",
+                        "description": "Join request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.splitPrefRequest"
+                            "$ref": "#/definitions/routes.listenJoinRequest"
                         }
                     }
                 ],
@@ -5537,51 +5889,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/template/settings": {
-            "get": {
-                "description": "Returns the full manifest of the currently applied template, including name, category, schemas, default_role, and require_email.",
+        "/api/listen/jukebox": {
+            "post": {
+                "description": "In jukebox mode requests the host can resolve are queued as they arrive; the others still wait for approval.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
+                ],
+                "summary": "Turn jukebox mode on or off (local access only)",
+                "parameters": [
+                    {
+                        "description": "Jukebox request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.listenJukeboxRequest"
+                        }
+                    }
                 ],
-                "summary": "Get active template manifest",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.templateSettingsResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/templates": {
-            "get": {
+        "/api/listen/leave": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "List available store templates",
+                "summary": "Listener leaves the current group",
                 "responses": {
                     "200": {
-                        "description": "Array of StoreMeta objects",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/templates/apply": {
+        "/api/listen/load": {
             "post": {
-                "description": "Resets the site and database, then applies the named built-in template.",
+                "description": "Accepts MP3, FLAC, Ogg Vorbis, AAC (ADTS) and M4A files, detected from their first bytes, and HTTP stream URLs.",
                 "consumes": [
                     "application/json"
                 ],
@@ -5589,17 +5952,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Apply a built-in template",
+                "summary": "Load audio file(s) as playlist (local access only)",
                 "parameters": [
                     {
-                        "description": "Template to apply",
+                        "description": "Load request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyRequest"
+                            "$ref": "#/definitions/routes.listenLoadRequest"
                         }
                     }
                 ],
@@ -5607,27 +5970,48 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyResponse"
+                            "$ref": "#/definitions/routes.listenTrack"
                         }
-                    },
-                    "400": {
-                        "description": "template name required / template not found",
+                    }
+                }
+            }
+        },
+        "/api/listen/queue/add": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listen"
+                ],
+                "summary": "Append files to the playlist (local access only)",
+                "parameters": [
+                    {
+                        "description": "Queue add request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.listenQueueAddRequest"
                         }
-                    },
-                    "403": {
-                        "description": "bad csrf",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     }
                 }
             }
         },
-        "/api/templates/apply-local": {
+        "/api/listen/report": {
             "post": {
-                "description": "Reads all files from the given directory (must contain manifest.json), resets site and database, then applies the template.",
+                "description": "elapsed is the seconds of audio played since the audio stream started. The host compares it with its own position and resyncs listeners that drifted too far.",
                 "consumes": [
                     "application/json"
                 ],
@@ -5635,17 +6019,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Apply a template from a local folder",
+                "summary": "Listener reports its playback position",
                 "parameters": [
                     {
-                        "description": "Folder path and CSRF",
+                        "description": "Playback position",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyLocalRequest"
+                            "$ref": "#/definitions/routes.listenReportRequest"
                         }
                     }
                 ],
@@ -5653,17 +6037,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "path required / invalid path / not a directory / manifest.json not found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "bad csrf",
+                        "description": "Invalid position or not a listener",
                         "schema": {
                             "type": "string"
                         }
@@ -5671,9 +6049,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/templates/apply-store": {
+        "/api/listen/request": {
             "post": {
-                "description": "Spends credits if required, downloads the template bundle from the rendezvous server, resets site and database, then applies the template.",
+                "description": "track is an audio file name (no path) or an http(s) stream URL. The host sees it under /api/listen/requests, or queues it at once in jukebox mode.",
                 "consumes": [
                     "application/json"
                 ],
@@ -5681,17 +6059,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Apply a store template (download, spend credits, apply)",
+                "summary": "Listener requests a track from the host",
                 "parameters": [
                     {
-                        "description": "Store template to apply",
+                        "description": "Track request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyStoreRequest"
+                            "$ref": "#/definitions/routes.listenTrackRequestBody"
                         }
                     }
                 ],
@@ -5699,29 +6077,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.templateApplyStoreResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "template name required",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "402": {
-                        "description": "insufficient credits",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "bad csrf",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "502": {
-                        "description": "failed to download template",
+                        "description": "Invalid track or not a listener",
                         "schema": {
                             "type": "string"
                         }
@@ -5729,32 +6089,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/templates/prices": {
+        "/api/listen/requests": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Get or update template pricing",
+                "summary": "Host's pending listener requests",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/routes.listenRequestsResponse"
                         }
                     }
                 }
             }
         },
-        "/api/templates/validate-local": {
+        "/api/listen/requests/approve": {
             "post": {
-                "description": "Reads manifest.json from the given absolute path and returns its metadata for preview.",
+                "description": "A stream URL is queued as is. A file name is matched in the folders of the queued tracks; file_path picks the file when it is not found there.",
                 "consumes": [
                     "application/json"
                 ],
@@ -5762,17 +6118,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Validate a local template folder",
+                "summary": "Queue a listener request (local access only)",
                 "parameters": [
                     {
-                        "description": "Folder path",
+                        "description": "Approve request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.templateValidateLocalRequest"
+                            "$ref": "#/definitions/routes.listenApproveRequest"
                         }
                     }
                 ],
@@ -5780,34 +6136,198 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.templateValidateLocalResponse"
+                            "$ref": "#/definitions/routes.statusOK"
                         }
                     },
                     "400": {
-                        "description": "path required / not a directory / manifest.json not found",
+                        "description": "No matching file",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
-            }
+                    },
+                    "404": {
+                        "description": "Unknown request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
         },
-        "/api/templates/{dir}": {
+        "/api/listen/requests/reject": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listen"
+                ],
+                "summary": "Drop a listener request",
+                "parameters": [
+                    {
+                        "description": "Reject request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.listenRejectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/listen/state": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "listen"
                 ],
-                "summary": "Fetch template bundle or manifest by directory name",
+                "summary": "Current listen group state",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.listenStateResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/listen/stream": {
+            "get": {
+                "description": "Chunked streaming response in the track's format (audio/mpeg, audio/flac, audio/ogg, audio/aac or audio/mp4). Connect an HTML audio element src directly to this URL.",
+                "produces": [
+                    "audio/mpeg",
+                    "audio/flac",
+                    "audio/ogg",
+                    "audio/aac",
+                    "audio/mp4"
+                ],
+                "tags": [
+                    "listen"
+                ],
+                "summary": "Live audio stream",
+                "responses": {
+                    "200": {
+                        "description": "Chunked audio stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Snapshot of recent Go process log lines",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/client": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Sink for browser-side log messages",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Template directory name",
-                        "name": "dir",
-                        "in": "path",
-                        "required": true
+                        "description": "Log entry",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.clientLogRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Accepted"
+                    }
+                }
+            }
+        },
+        "/api/logs/stream": {
+            "get": {
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "SSE stream of new Go process log lines",
+                "responses": {
+                    "200": {
+                        "description": "SSE stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/verbose": {
+            "get": {
+                "description": "GET returns current verbose status. POST enables or disables verbose logging.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Get or set verbose P2P logging",
+                "parameters": [
+                    {
+                        "description": "Enable/disable verbose (POST only)",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 ],
                 "responses": {
@@ -5816,95 +6336,4253 @@ const docTemplate = `{
                         "schema": {
                             "type": "object"
                         }
+                    },
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/api/topology": {
+        "/api/lua/content": {
             "get": {
-                "description": "Returns this peer's view of the network: self node, relay node (if configured), and all known peers with connection type (direct/relay/none).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "peers"
+                    "lua"
+                ],
+                "summary": "Fetch a Lua script's content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Script name (without .lua)",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to 1 for data functions",
+                        "name": "func",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Network topology graph data",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.topologyResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/lua/prefabs/apply": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lua"
+                ],
+                "summary": "Install scripts from a prefab pack",
+                "parameters": [
+                    {
+                        "description": "Prefab install request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.luaPrefabApplyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.luaPrefabApplyResponse"
                         }
                     },
-                    "503": {
-                        "description": "no p2p node",
+                    "400": {
+                        "description": "prefab name required / prefab not found / script not found in prefab",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "cluster.JobType": {
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "help": {
-                    "type": "string"
+        },
+        "/api/mq/ack": {
+            "post": {
+                "description": "Called by the browser after processing an incoming MQ message.\\nRelays an application-level ACK to the sender.\\nIf from_peer_id is empty (PublishLocal event) the ACK is silently dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "Acknowledge a received MQ message",
+                "parameters": [
+                    {
+                        "description": "Ack request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.mqAckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mq/events": {
+            "get": {
+                "description": "THE single browser event stream. Every incoming P2P MQ message and every PublishLocal event arrives here.\\nEvent frames are SSE 'message' events with JSON body: {type, msg:{id,seq,topic,payload}, from}.\\nKeep-alive: the stream never closes unless the server shuts down or the browser disconnects.\\nEach journaled frame carries an SSE id; reconnect with Last-Event-ID (or ?since=) to replay missed events. A {type:\"reset\"} frame means the journal no longer reaches back that far and the client should resync.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "SSE stream — incoming MQ messages and delivery receipts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Last event ID seen (resume)",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last event ID seen, for clients that cannot set headers",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated topic patterns; only matching messages are sent. + matches one :-separated segment, a final * the rest (call:*, group:+:state)",
+                        "name": "topics",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic pattern",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mq/metrics": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "MQ delivery metrics per topic family (sent, delivered, timeouts, ack latency) and priority lane queue depths",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mq.Metrics"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mq/outbox": {
+            "get": {
+                "description": "Store-and-forward outbox, oldest first. Entries are retried with backoff, sent as soon as their peer becomes reachable and dropped when expires_at passes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "Messages queued for peers that could not be reached",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this peer's messages",
+                        "name": "peer_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.mqOutboxEntry"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "outbox not enabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mq/outbox/purge": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "Drop queued messages",
+                "parameters": [
+                    {
+                        "description": "Peer to purge, empty for all",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.mqOutboxPurgeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.mqOutboxPurgeResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "outbox not enabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mq/send": {
+            "post": {
+                "description": "Delivers the payload to the remote peer over the MQ P2P protocol.\\nBlocks until the peer sends a transport ACK (up to 4 s, one retry).\\nWith queue set, a message for an unreachable peer (or one with older messages still queued) is stored in the outbox instead and status is \"queued\"; it is delivered when the peer becomes reachable.\\nTopic convention: call:{channelId}, group:{groupId}:{type}, group.invite, chat, chat.broadcast, identity, identity.response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mq"
+                ],
+                "summary": "Send an MQ message to a peer",
+                "parameters": [
+                    {
+                        "description": "Send request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.mqSendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.mqSendResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "missing peer_id or topic",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "504": {
+                        "description": "peer unreachable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/my-balance": {
+            "get": {
+                "description": "Queries the credits service via the rendezvous server. Returns credits_active=false when no service is configured.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Get this peer's credit balance",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.myBalanceResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/openapi.json": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "This OpenAPI 3.0 spec (generated by swaggo/swag)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "Pending pairing code, paired devices, guest sessions and the remote control URL (local only)",
+                "responses": {
+                    "200": {
+                        "description": "pending: pairing.Code, devices: []storage.PairedDevice, guests: []pairing.Guest, remote_url",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair/claim": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "Trade a pairing code for a device token",
+                "parameters": [
+                    {
+                        "description": "Code and device name",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.pairClaimRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token, device",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "wrong or expired code",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair/guest": {
+            "post": {
+                "description": "The guest link works on the remote control listener until it expires (default 30 minutes, at most 8 hours) or is revoked. Guests can only GET the peers list, avatars, listen state and stream, and site previews.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "Open a read-only guest session (local only)",
+                "parameters": [
+                    {
+                        "description": "Duration in minutes (0 = default)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.pairGuestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "guest: pairing.Guest, url",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "remote control addr not set",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair/guest/revoke": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "End a guest session (local only)",
+                "parameters": [
+                    {
+                        "description": "Guest session",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.pairRevokeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "no such guest session",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair/revoke": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "Unpair a device (local only)",
+                "parameters": [
+                    {
+                        "description": "Device",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.pairRevokeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "no such device",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pair/start": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pairing"
+                ],
+                "summary": "Issue a short-lived pairing code (local only)",
+                "parameters": [
+                    {
+                        "description": "Scopes to grant (empty = all)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.pairStartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "code, scopes, expires_at, remote_url",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peer/content": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Fetch a remote peer's site content (HTML string)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerContentResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers": {
+            "get": {
+                "description": "Each row's Link says how the peer is reached right now: direct-lan, direct-wan, holepunch (direct after a hole punch), relay or none. With limit the list is filtered, sorted and paged on the server and returned as {peers, offset, limit, summary}; summary counts total, online, favorites, verified and matched peers, plus the max_tracked_peers capacity and evictions so far.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "List all known peers with metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Presence namespace to list (default: the global list)",
+                        "name": "namespace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (0 = all, max 1000); turns on paging and summary counts",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the label (or of the ID with by=id)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name (default) or id",
+                        "name": "by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id (default), name or last_seen",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1 = favorites only",
+                        "name": "favorites",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1 = online peers only",
+                        "name": "online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1 = verified peers only",
+                        "name": "verified",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad sort or limit",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown namespace",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/clock": {
+            "get": {
+                "description": "Without peer, returns the cached estimates keyed by peer ID. With peer, measures the offset to that peer over /goop/time first. A peer's clock reads our clock plus offset_ms.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Clock offsets to other peers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID to measure now",
+                        "name": "peer",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/p2p.ClockEstimate"
+                        }
+                    },
+                    "502": {
+                        "description": "Peer unreachable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/favorite": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Toggle favorite flag for a peer",
+                "parameters": [
+                    {
+                        "description": "Favorite request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerFavoriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/follow": {
+            "post": {
+                "description": "Keeps a local copy of the peer's site over /goop/site-sync/1.0.0, shown with a \"last synced\" banner while the peer is offline. The first sync starts right away; after that the copy is synced whenever the peer announces a new site hash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Follow a peer's site (local only)",
+                "parameters": [
+                    {
+                        "description": "Peer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerFollowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.SiteFollow"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid peer ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/follows": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Followed sites and the state of their local copies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.SiteFollow"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/follows/sync": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Sync a followed site now (local only)",
+                "parameters": [
+                    {
+                        "description": "Peer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerFollowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.SiteFollow"
+                        }
+                    },
+                    "502": {
+                        "description": "sync failed; the previous copy is kept",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/forget": {
+            "post": {
+                "description": "Removes the peer from the peer table, drops its cached avatar and deletes its cached presence, favorite, note, followed site, consent decision, chat and call history and audit entries.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Delete everything stored about a peer (local only)",
+                "parameters": [
+                    {
+                        "description": "Peer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerForgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted: {table: rows}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/namespaces": {
+            "get": {
+                "description": "The communities from presence.namespaces, each with its number of known and online peers. The global list is not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "List joined presence namespaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.namespaceInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/notes": {
+            "get": {
+                "description": "Without peer, returns every note, most recently edited first. With peer, returns that peer's note, with an empty body if there is none.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Private notes about peers (local only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.PeerNote"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Markdown, up to 16 KiB. Notes never leave this peer. An empty body deletes the note.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Set the private note for a peer (local only)",
+                "parameters": [
+                    {
+                        "description": "Note",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.PeerNote"
+                        }
+                    },
+                    "400": {
+                        "description": "missing peer_id or note too long",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/probe": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Probe all known peers for reachability",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/reports": {
+            "get": {
+                "description": "Newest first. With peer, only the reports about that peer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Abuse reports I filed about peers (local only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.PeerReport"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Reports stay on this peer until they go out in a reputation record (see /api/peers/reputation). Notes are up to 500 characters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "File an abuse report about a peer (local only)",
+                "parameters": [
+                    {
+                        "description": "Report",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.PeerReport"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid peer ID, unknown reason or note too long",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/reports/delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Withdraw an abuse report (local only)",
+                "parameters": [
+                    {
+                        "description": "Report ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "report not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/reputation": {
+            "get": {
+                "description": "What this peer saw of another over the last days (default 30, at most 90): the streams it opened, how many were refused, its MQ messages and their peak per minute from the audit log, and the abuse reports filed about it. Signed with this peer's identity key, so it can be shared and checked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Signed reputation record about a peer (local only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peer",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Window in days",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rendezvous.ReputationRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid peer ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/reputation/submit": {
+            "post": {
+                "description": "Builds and signs the record as GET /api/peers/reputation does and posts it to every rendezvous, where moderators see it in the admin Reports tab. A newer record about the same peer replaces the older.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Submit a reputation record to the rendezvous moderation queue (local only)",
+                "parameters": [
+                    {
+                        "description": "Peer and window",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.reputationSubmitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "submitted: int, errors: []string (per rendezvous), record: rendezvous.ReputationRecord",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "no rendezvous configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "no rendezvous accepted the record",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/retention": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Peer retention pruning stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.Stats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/retention/run": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Forget peers past peer_retention_days now (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.Stats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/peers/unfollow": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Stop following a peer's site and delete its copy (local only)",
+                "parameters": [
+                    {
+                        "description": "Peer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.peerFollowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "removed: whether the peer was followed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/permalink": {
+            "post": {
+                "description": "Fetches the page, hashes it and returns goop://\u003cpeer\u003e/\u003chash\u003e/\u003cpath\u003e with its /permalink/ viewer path. With pin (local access only) a copy is kept so the link resolves after the page changes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Create a content-addressed permalink to a peer page",
+                "parameters": [
+                    {
+                        "description": "Page to link",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "502": {
+                        "description": "page could not be fetched",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/permalink/pins": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "List pinned page copies, newest first",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/permalink.Pin"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/permalink/resolve": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Check a permalink against the live page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "goop:// permalink",
+                        "name": "link",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.permalinkResolution"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/permalink/unpin": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Remove a pinned copy (local access only)",
+                "parameters": [
+                    {
+                        "description": "Content hash",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pulse": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rendezvous"
+                ],
+                "summary": "Refresh relay reservation for a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID to refresh relay for",
+                        "name": "peer",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/reg/": {
+            "get": {
+                "tags": [
+                    "services"
+                ],
+                "summary": "Reverse proxy for registration service",
+                "responses": {},
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/relay-report": {
+            "post": {
+                "description": "Peers using the relay post this every few minutes. Error classes: timeout, dial, refused, limit, no-circuit, other. Only peers that have published presence are recorded; the admin Relay tab shows the aggregate.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rendezvous"
+                ],
+                "summary": "Report a peer's relay reservation health",
+                "parameters": [
+                    {
+                        "description": "Relay report",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/rendezvous/check": {
+            "get": {
+                "description": "Fetches /api/capabilities from the given rendezvous URL and returns the capabilities map.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rendezvous"
+                ],
+                "summary": "Check a rendezvous server's capabilities",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rendezvous server URL",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Capabilities map from remote server",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Automation rules with their last run (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rules.Rule"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules/delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Delete an automation rule (local only)",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.ruleIDRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "no such rule",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules/save": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Create (no id) or update an automation rule (local only)",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rules.Rule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rules.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid trigger or action",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules/test": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Run a rule's action now, regardless of its trigger (local only)",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.ruleIDRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "no such rule",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "the action failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schedule": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Scheduled listen sessions and group events (local only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this group's events",
+                        "name": "group",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.ScheduledSession"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "id 0 creates. kind is listen or group; group events need a group you host. starts_at is Unix ms.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Create or update a scheduled session (local only)",
+                "parameters": [
+                    {
+                        "description": "Session",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/storage.ScheduledSession"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.ScheduledSession"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid session",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schedule/delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Delete a scheduled session (local only)",
+                "parameters": [
+                    {
+                        "description": "Session",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.scheduleDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schedule/feeds": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Issued calendar feed tokens (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.calendarFeedView"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "An empty group_id issues the feed of every scheduled session. Issuing again replaces the previous token, so old links stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Issue a calendar feed token (local only)",
+                "parameters": [
+                    {
+                        "description": "Feed",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.calendarFeedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.calendarFeedView"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schedule/feeds/revoke": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Revoke a calendar feed (local only)",
+                "parameters": [
+                    {
+                        "description": "Feed",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.calendarFeedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/network": {
+            "get": {
+                "description": "Sends the query to every reachable favorite or group peer over /goop/search and merges their hits by score. Each peer answers only from what it exposes in p2p.search_expose. peers lists every peer asked, with status ok, error or timeout.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Search reachable favorite and group peers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Keywords (max 200 characters); all must match",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum results (default 20, max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "missing or too long q",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/secrets": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "secrets"
+                ],
+                "summary": "Secrets by name and version, without values (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/secrets.Info"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/secrets/delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "secrets"
+                ],
+                "summary": "Delete a secret (local only)",
+                "parameters": [
+                    {
+                        "description": "Secret",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.secretNameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "404": {
+                        "description": "no such secret",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/secrets/rotate": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "secrets"
+                ],
+                "summary": "Replace a secret with a new random token (local only)",
+                "parameters": [
+                    {
+                        "description": "Secret",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.secretNameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the new value, shown only here",
+                        "schema": {
+                            "$ref": "#/definitions/routes.secretRotated"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid name",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/secrets/set": {
+            "post": {
+                "description": "Config values of the form \"secret:NAME\" refer to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "secrets"
+                ],
+                "summary": "Store a secret; replacing a value bumps its version (local only)",
+                "parameters": [
+                    {
+                        "description": "Secret",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.secretSetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/secrets.Info"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid name or empty value",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/security/audit": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "security"
+                ],
+                "summary": "Audit log of inbound P2P streams (newest first)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by remote peer ID",
+                        "name": "peer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by protocol ID",
+                        "name": "protocol",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by outcome (allowed, denied)",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only entries at or after this Unix ms",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only entries at or before this Unix ms",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum entries (default 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.AuditEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/security/consent": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "security"
+                ],
+                "summary": "Pending access prompts and remembered consent decisions",
+                "responses": {
+                    "200": {
+                        "description": "pending: []p2p.ConsentRequest, decisions: []storage.AccessConsent",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "security"
+                ],
+                "summary": "Answer a parked inbound docs/data access prompt",
+                "parameters": [
+                    {
+                        "description": "Decision",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.consentDecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/security/consent/forget": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "security"
+                ],
+                "summary": "Forget a remembered consent decision so the peer is prompted again",
+                "parameters": [
+                    {
+                        "description": "Peer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.consentForgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/self": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "This peer's own identity and metadata",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/services/check": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Check a single service URL (pre-save validation)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service base URL",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service type: registration, credits, email, templates",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.serviceHealthEntry"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/services/health": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Ping all configured external services",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.servicesHealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/services/logs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rendezvous"
+                ],
+                "summary": "Aggregate logs from all microservices (admin only)",
+                "responses": {
+                    "200": {
+                        "description": "Array of {service, message} objects",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/settings/quick": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Partial settings update — only provided (non-null) fields are written",
+                "parameters": [
+                    {
+                        "description": "Partial settings",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.quickSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/settings/quick/get": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Read current quick settings (label, email, theme, device prefs, flags)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.quickSettingsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/analytics": {
+            "get": {
+                "description": "Bytes and current rate spent serving the site and docs to other peers, overall and per requesting peer, with the p2p.serve_limit_kbps / serve_peer_limit_kbps caps and the time spent throttled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Site serving traffic",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/p2p.ServeStats"
+                        }
+                    },
+                    "503": {
+                        "description": "p2p node not available",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/assets": {
+            "get": {
+                "description": "Result of the last scan: images with variants, variant files, files encoded by the last pass and the bytes saved. All zero while assets.enabled is off.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Site asset pipeline stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/siteassets.Stats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/assets/rebuild": {
+            "post": {
+                "description": "Encodes variants of new or changed images and removes those of deleted ones, instead of waiting for the background pass.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Rescan the site for image variants now (local only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/siteassets.Stats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/content": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Fetch a site file's content and ETag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File path relative to site root",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Delete a file from the site content store",
+                "parameters": [
+                    {
+                        "description": "File to delete",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.siteDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status: deleted",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    },
+                    "400": {
+                        "description": "path required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/export": {
+            "get": {
+                "description": "Exports manifest, schema, site files, and Lua scripts into a zip. With format=static the zip instead holds the site as visitors see it (image variants and SDK files included, no Lua or database), ready for ordinary web hosting. The response is an attachment download.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Download the entire site as a zip archive",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "static for a hostable copy of the site",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Zip archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/files": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "List site files as a flat tree",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.siteFileItem"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/import": {
+            "post": {
+                "description": "Uploads a zip (manifest + schema + site/ + lua/) and applies it, replacing the current site and database.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Import a site from a zip archive",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "CSRF token",
+                        "name": "csrf",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Zip archive to import",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.siteImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "file required / failed to extract zip",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/publish": {
+            "post": {
+                "description": "Uploads the same bundle as GET /api/site/export?format=static to the SFTP server or S3 bucket set in publish.*. Files of the same name are overwritten; nothing is deleted on the target.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Publish a static copy of the site (local only)",
+                "parameters": [
+                    {
+                        "description": "CSRF token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.sitePublishRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.sitePublishResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "no publish target configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "publish failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/upload": {
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Upload a file to the site content store",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Destination path relative to site root",
+                        "name": "path",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.siteUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "path required / file required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/site/upload-local": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Upload a file from a local filesystem path to the site content store",
+                "parameters": [
+                    {
+                        "description": "Upload request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.siteUploadLocalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.siteUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "dest_path and src_path required / cannot read file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/split-prefs": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Save a UI split pane preference (position 0-100)",
+                "parameters": [
+                    {
+                        "description": "Split pane key and position",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.splitPrefRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.statusOK"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/startup": {
+            "get": {
+                "description": "The stages this process went through while starting (relay discovery, P2P node, database, services, viewer, and the rendezvous server when hosted), each with its start and finish time, duration and any error. A stage still running reports its duration so far.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "Startup stages",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.startupReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/template/settings": {
+            "get": {
+                "description": "Returns the full manifest of the currently applied template, including name, category, schemas, default_role, and require_email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Get active template manifest",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateSettingsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List available store templates",
+                "responses": {
+                    "200": {
+                        "description": "Array of StoreMeta objects",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/templates/apply": {
+            "post": {
+                "description": "Resets the site and database, then applies the named built-in template.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Apply a built-in template",
+                "parameters": [
+                    {
+                        "description": "Template to apply",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "template name required / template not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/apply-local": {
+            "post": {
+                "description": "Reads all files from the given directory (must contain manifest.json), resets site and database, then applies the template.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Apply a template from a local folder",
+                "parameters": [
+                    {
+                        "description": "Folder path and CSRF",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyLocalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "path required / invalid path / not a directory / manifest.json not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/apply-store": {
+            "post": {
+                "description": "Spends credits if required, downloads the template bundle from the rendezvous server, resets site and database, then applies the template. With a session from POST /api/templates/install, the download progress and the outcome are reported to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Apply a store template (download, spend credits, apply)",
+                "parameters": [
+                    {
+                        "description": "Store template to apply",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyStoreRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateApplyStoreResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "template name required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "402": {
+                        "description": "insufficient credits",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "failed to download template",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/install": {
+            "post": {
+                "description": "Opens a session on the rendezvous that reports the progress of the next apply-store of this template. Pass the session to GET /api/templates/install/events and to POST /api/templates/apply-store. session is empty when the rendezvous does not support install sessions; apply-store works without one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Open an install session for a store template",
+                "parameters": [
+                    {
+                        "description": "Store template to install",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateInstallRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateInstallResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/install/events": {
+            "get": {
+                "description": "Relays the install session's events from the rendezvous. Each event is a JSON rendezvous.InstallEvent with an SSE id; stage goes created → verifying → downloading (bytes of total) → downloaded (sha256) → applying → applied, or ends in failed. The stream closes after the event with done set.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "SSE stream — progress of a store template install",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Install session from POST /api/templates/install",
+                        "name": "session",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last event ID seen (resume)",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "session required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "rendezvous error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/prices": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Get or update template pricing",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/templates/publish": {
+            "post": {
+                "description": "Packs the folder (must contain manifest.json; its name becomes the template dir) into a bundle signed with this peer's key and publishes it to every rendezvous. Each result names the server and its error, if any.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Publish a local template folder to the template store",
+                "parameters": [
+                    {
+                        "description": "Folder path and CSRF",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templatePublishRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templatePublishResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "path required / invalid path / bad folder name / manifest.json not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "no rendezvous server configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/revert": {
+            "post": {
+                "description": "Restores the site files, template tables with their rows, schema files and template settings from the newest snapshot, and sets the active template back. The snapshot is consumed, so reverting again goes one apply further back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Revert the last template apply",
+                "parameters": [
+                    {
+                        "description": "CSRF token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateRevertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateRevertResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no snapshot to revert to",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/snapshots": {
+            "get": {
+                "description": "Snapshots taken before each template apply, newest first. reason is what was applied; template is the template active before it. viewer.template_snapshots sets how many are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List template snapshots",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.templateSnapshotEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/update": {
+            "get": {
+                "description": "Returns the installed version of the active template and the newest store version seen by the background update check (every 6 hours). available is true for store templates with a newer version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Update status of the active template",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateUpdateStatusResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Re-downloads the active store template and writes its files over the site. Files the template does not ship, existing tables and their rows are kept; tables new in this version are created. No seed runs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Update the active store template",
+                "parameters": [
+                    {
+                        "description": "CSRF token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateUpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "active template is not from the store",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "bad csrf",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "failed to download template",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/validate-local": {
+            "post": {
+                "description": "Reads manifest.json from the given absolute path and returns its metadata for preview.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Validate a local template folder",
+                "parameters": [
+                    {
+                        "description": "Folder path",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateValidateLocalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.templateValidateLocalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "path required / not a directory / manifest.json not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/{dir}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Fetch template bundle or manifest by directory name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template directory name",
+                        "name": "dir",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                },
+                "x-served-by": "rendezvous"
+            }
+        },
+        "/api/topology": {
+            "get": {
+                "description": "Returns this peer's view of the network: self node, relay node (if configured), and all known peers with connection type (direct/relay/none) and link quality (direct-lan, direct-wan, holepunch, relay, none).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Network topology graph data",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.topologyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "no p2p node",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/{token}.ics": {
+            "get": {
+                "description": "iCalendar feed of scheduled sessions. The token is the only credential; unknown or revoked tokens get 404. Also reachable through the remote listener without pairing.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "ICS calendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "VCALENDAR",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "actions.Action": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "client": {
+                    "description": "Client names the frontend handler for actions that need the browser\n(e.g. \"call.start\" needs the camera and mic). Not runnable server-side.",
+                    "type": "string"
+                },
+                "confirm": {
+                    "description": "Confirm marks destructive actions; palettes ask before running them.",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "Method and Path are the viewer route the action runs; empty for\nclient actions.",
+                    "type": "string"
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/actions.Param"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes lists the pairing scopes under which a paired device may call\nPath directly; filled in when listed.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "script": {
+                    "description": "Script allows goop.actions.run from Lua. Scripts run on behalf of\nremote peers, so only harmless actions set it.",
+                    "type": "boolean"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "actions.Param": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "enum": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "cluster.JobType": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "help": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.Issue": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "JSON path, e.g. \"p2p.listen_port\"",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "warning": {
+                    "description": "reported, but the config still loads",
+                    "type": "boolean"
+                }
+            }
+        },
+        "config.Preset": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "datasync.ReplicaStatus": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string"
+                },
+                "host_peer_id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "pending": {
+                    "description": "own changes not pushed yet",
+                    "type": "integer"
+                },
+                "synced_at": {
+                    "description": "Unix ms of the last complete sync, 0 = never",
+                    "type": "integer"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "files.ACL": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "type": "string"
+                },
+                "peers": {
+                    "description": "for \"peers\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "files.DocInfo": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "description": "\"group\", \"peers\" or \"public\"",
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "sha256:\u003chex\u003e",
+                    "type": "string"
+                },
+                "mod_time": {
+                    "description": "unix seconds",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "peers": {
+                    "description": "for \"peers\"; own listings only",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "group.MemberPresence": {
+            "type": "object",
+            "properties": {
+                "connected": {
+                    "description": "peer is joined to the live group session",
+                    "type": "boolean"
+                },
+                "last_seen": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "description": "peer is reachable on the network",
+                    "type": "boolean"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Dead": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Report": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "coalesced": {
+                    "description": "replaced by a newer job with their key",
+                    "type": "integer"
+                },
+                "dead": {
+                    "description": "newest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jobs.Dead"
+                    }
+                },
+                "done": {
+                    "description": "succeeded, at any attempt",
+                    "type": "integer"
+                },
+                "dropped": {
+                    "description": "refused by a full queue",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "failed their last attempt",
+                    "type": "integer"
+                },
+                "queued": {
+                    "description": "waiting for a worker",
+                    "type": "integer"
+                },
+                "retried": {
+                    "description": "failed attempts that were retried",
+                    "type": "integer"
+                },
+                "retrying": {
+                    "description": "waiting out a backoff",
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "mq.LaneDepth": {
+            "type": "object",
+            "properties": {
+                "in_flight": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "integer"
+                }
+            }
+        },
+        "mq.LatencyHistogram": {
+            "type": "object",
+            "properties": {
+                "bounds_ms": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "counts": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "max_ms": {
+                    "type": "integer"
+                },
+                "sum_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "mq.Metrics": {
+            "type": "object",
+            "properties": {
+                "by_via": {
+                    "description": "\"direct\" / \"relay\" ack latency",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/mq.LatencyHistogram"
+                    }
+                },
+                "queues": {
+                    "description": "Queues lists busy priority lanes: peerID → lane → depth.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "$ref": "#/definitions/mq.LaneDepth"
+                        }
+                    }
+                },
+                "since": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "topics": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/mq.TopicMetrics"
+                    }
+                }
+            }
+        },
+        "mq.TopicMetrics": {
+            "type": "object",
+            "properties": {
+                "ack_latency": {
+                    "$ref": "#/definitions/mq.LatencyHistogram"
+                },
+                "delivered": {
+                    "description": "transport ACK received",
+                    "type": "integer"
+                },
+                "failures": {
+                    "description": "unreachable or stream errors",
+                    "type": "integer"
+                },
+                "received": {
+                    "description": "inbound messages",
+                    "type": "integer"
+                },
+                "sent": {
+                    "description": "send attempts",
+                    "type": "integer"
+                },
+                "timeouts": {
+                    "description": "no ACK within timeouts.MQAck",
+                    "type": "integer"
+                }
+            }
+        },
+        "p2p.ClockEstimate": {
+            "type": "object",
+            "properties": {
+                "jitter_ms": {
+                    "description": "spread of the samples around the best one",
+                    "type": "number"
+                },
+                "measured_at": {
+                    "type": "string"
+                },
+                "offset_ms": {
+                    "type": "number"
+                },
+                "rtt_ms": {
+                    "description": "round trip of the best sample",
+                    "type": "number"
+                },
+                "samples": {
+                    "type": "integer"
+                }
+            }
+        },
+        "p2p.ServeLimits": {
+            "type": "object",
+            "properties": {
+                "per_peer_kbps": {
+                    "type": "integer"
+                },
+                "total_kbps": {
+                    "type": "integer"
+                }
+            }
+        },
+        "p2p.ServeStats": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "streams being served",
+                    "type": "integer"
+                },
+                "bytes": {
+                    "description": "served since start",
+                    "type": "integer"
+                },
+                "last_at": {
+                    "description": "Unix ms of the last write, 0 = never",
+                    "type": "integer"
+                },
+                "limits": {
+                    "$ref": "#/definitions/p2p.ServeLimits"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "peers": {
+                    "description": "by current rate, then bytes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/p2p.ServeUsage"
+                    }
+                },
+                "rate": {
+                    "description": "bytes/s over the last few seconds",
+                    "type": "integer"
+                },
+                "throttle_ms": {
+                    "description": "time spent held back by the limits",
+                    "type": "integer"
+                }
+            }
+        },
+        "p2p.ServeUsage": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "streams being served",
+                    "type": "integer"
+                },
+                "bytes": {
+                    "description": "served since start",
+                    "type": "integer"
+                },
+                "last_at": {
+                    "description": "Unix ms of the last write, 0 = never",
+                    "type": "integer"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "bytes/s over the last few seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "permalink.Pin": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "always starts with \"/\"",
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "pinned_at": {
+                    "description": "unix seconds",
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rendezvous.DigestStatus": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "favorites": {
+                    "type": "integer"
+                },
+                "frequency": {
+                    "type": "string"
+                },
+                "last_sent": {
+                    "description": "unix millis",
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "events waiting for the next digest",
+                    "type": "integer"
+                }
+            }
+        },
+        "rendezvous.ReputationRecord": {
+            "type": "object",
+            "properties": {
+                "denied": {
+                    "description": "of which refused by an access policy",
+                    "type": "integer"
+                },
+                "mq_messages": {
+                    "type": "integer"
+                },
+                "mq_peak_per_minute": {
+                    "type": "integer"
+                },
+                "refused": {
+                    "description": "of which refused by the protocol handler",
+                    "type": "integer"
+                },
+                "reporter": {
+                    "description": "the peer that observed it and signed",
+                    "type": "string"
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rendezvous.ReputationReport"
+                    }
+                },
+                "sig": {
+                    "type": "string",
+                    "format": "base64"
+                },
+                "since": {
+                    "description": "unix millis, start of the window",
+                    "type": "integer"
+                },
+                "streams": {
+                    "description": "inbound streams the subject opened",
+                    "type": "integer"
+                },
+                "subject": {
+                    "description": "the peer the record is about",
+                    "type": "string"
+                },
+                "until": {
+                    "description": "unix millis, when the record was made",
+                    "type": "integer"
+                }
+            }
+        },
+        "rendezvous.ReputationReport": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "unix millis",
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "spam, harassment, impersonation, illegal or other",
+                    "type": "string"
+                }
+            }
+        },
+        "retention.Stats": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "retention in days; 0 = keep forever",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_forgotten": {
+                    "description": "peers forgotten by the last run",
+                    "type": "integer"
+                },
+                "last_run": {
+                    "description": "Unix ms, 0 = never",
+                    "type": "integer"
+                },
+                "total_forgotten": {
+                    "description": "since start, including manual forgets",
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.actionRunRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "listen.pause"
+                },
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "routes.avatarUploadResponse": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                },
+                "ok": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "routes.browsePeer": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "\"unreachable\", or \"slow\" while still being fetched",
+                    "type": "string"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/files.DocInfo"
+                    }
+                },
+                "label": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "self": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "routes.calendarFeedRequest": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                }
+            }
+        },
+        "routes.calendarFeedView": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "/calendar/\u003ctoken\u003e.ics, relative to wherever the viewer is reached",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.callAudioProcessing": {
+            "type": "object",
+            "properties": {
+                "echo_cancellation": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "noise_suppression": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "routes.callCallback": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "integer"
+                },
+                "channel_id": {
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.callCaptionsRequest": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "nc-abc123"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
+        "routes.callChannelRequest": {
+            "type": "object",
+            "required": [
+                "channel_id"
+            ],
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "nc-abc123"
+                }
+            }
+        },
+        "routes.callChatRequest": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "nc-abc123"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "here's the link: https://example.com"
+                }
+            }
+        },
+        "routes.callDataMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "ts": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "chat"
+                }
+            }
+        },
+        "routes.callDebugResponse": {
+            "type": "object",
+            "properties": {
+                "session_count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.callSessionStatus"
+                    }
+                }
+            }
+        },
+        "routes.callDeviceRequest": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "nc-abc123"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "audio"
+                }
+            }
+        },
+        "routes.callDevices": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.callMediaDevice"
+                    }
                 },
-                "name": {
+                "preferred_cam": {
                     "type": "string"
                 },
-                "template": {
+                "preferred_mic": {
                     "type": "string"
                 }
             }
         },
-        "routes.avatarUploadResponse": {
+        "routes.callFileRequest": {
             "type": "object",
             "properties": {
-                "hash": {
+                "channel_id": {
                     "type": "string",
-                    "example": "a1b2c3d4"
+                    "example": "nc-abc123"
                 },
-                "ok": {
-                    "type": "boolean",
-                    "example": true
+                "data": {
+                    "type": "string",
+                    "format": "base64"
+                },
+                "mime": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "notes.pdf"
                 }
             }
         },
-        "routes.callChannelRequest": {
+        "routes.callHoldResponse": {
             "type": "object",
             "properties": {
-                "channel_id": {
-                    "type": "string",
-                    "example": "nc-abc123"
+                "on_hold": {
+                    "type": "boolean"
                 }
             }
         },
-        "routes.callDebugResponse": {
+        "routes.callMediaDevice": {
             "type": "object",
             "properties": {
-                "session_count": {
-                    "type": "integer",
-                    "example": 2
+                "id": {
+                    "type": "string"
                 },
-                "sessions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/routes.callSessionStatus"
-                    }
+                "kind": {
+                    "type": "string",
+                    "example": "video"
+                },
+                "label": {
+                    "type": "string",
+                    "example": "Integrated Camera"
                 }
             }
         },
         "routes.callModeResponse": {
             "type": "object",
             "properties": {
+                "captions": {
+                    "description": "Live captions can be enabled (native mode with viewer.caption_command set).",
+                    "type": "boolean"
+                },
                 "first": {
                     "type": "boolean"
                 },
@@ -5915,6 +10593,16 @@ const docTemplate = `{
                 "platform": {
                     "type": "string",
                     "example": "linux"
+                },
+                "stun_servers": {
+                    "description": "STUN URLs for ICE: the rendezvous's own when advertised, else a public default.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "stun:goop2.com:3478"
+                    ]
                 }
             }
         },
@@ -5932,6 +10620,13 @@ const docTemplate = `{
                 "audio_on": {
                     "type": "boolean"
                 },
+                "camera": {
+                    "type": "string"
+                },
+                "captions": {
+                    "type": "string",
+                    "example": "en"
+                },
                 "channel_id": {
                     "type": "string"
                 },
@@ -5941,9 +10636,18 @@ const docTemplate = `{
                 "is_origin": {
                     "type": "boolean"
                 },
+                "mic": {
+                    "type": "string"
+                },
+                "on_hold": {
+                    "type": "boolean"
+                },
                 "pc_state": {
                     "type": "string"
                 },
+                "remote_hold": {
+                    "type": "boolean"
+                },
                 "remote_peer": {
                     "type": "string"
                 },
@@ -5978,6 +10682,19 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.callTransferRequest": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "nc-abc123"
+                },
+                "target_peer": {
+                    "type": "string",
+                    "example": "12D3KooWYyy..."
+                }
+            }
+        },
         "routes.callVideoResponse": {
             "type": "object",
             "properties": {
@@ -5986,8 +10703,55 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.callbackDismissRequest": {
+            "type": "object",
+            "properties": {
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.chatCallMessageRequest": {
+            "type": "object",
+            "properties": {
+                "call_id": {
+                    "type": "string",
+                    "example": "mc-abc123"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "incoming": {
+                    "type": "boolean"
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.chatGroupBackfillRequest": {
+            "type": "object",
+            "required": [
+                "group_id"
+            ],
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "example": "1a2b3c4d5e6f"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
         "routes.chatRoomCreateRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "context": {
                     "type": "string",
@@ -6009,6 +10773,9 @@ const docTemplate = `{
         },
         "routes.chatRoomGroupIDRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -6119,6 +10886,9 @@ const docTemplate = `{
         },
         "routes.clientLogRequest": {
             "type": "object",
+            "required": [
+                "message"
+            ],
             "properties": {
                 "level": {
                     "type": "string",
@@ -6136,6 +10906,9 @@ const docTemplate = `{
         },
         "routes.clusterBinaryRequest": {
             "type": "object",
+            "required": [
+                "path"
+            ],
             "properties": {
                 "mode": {
                     "type": "string",
@@ -6166,6 +10939,9 @@ const docTemplate = `{
         },
         "routes.clusterCancelRequest": {
             "type": "object",
+            "required": [
+                "job_id"
+            ],
             "properties": {
                 "job_id": {
                     "type": "string",
@@ -6180,6 +10956,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "a1b2c3d4e5f6a1b2"
                 },
+                "max_members": {
+                    "type": "integer",
+                    "example": 8
+                },
                 "name": {
                     "type": "string",
                     "example": "My Cluster"
@@ -6201,6 +10981,9 @@ const docTemplate = `{
         },
         "routes.clusterDeleteRequest": {
             "type": "object",
+            "required": [
+                "job_id"
+            ],
             "properties": {
                 "job_id": {
                     "type": "string",
@@ -6354,6 +11137,9 @@ const docTemplate = `{
         },
         "routes.clusterSubmitRequest": {
             "type": "object",
+            "required": [
+                "type"
+            ],
             "properties": {
                 "max_retry": {
                     "type": "integer",
@@ -6439,6 +11225,63 @@ const docTemplate = `{
         },
         "routes.clusterWorkerPeerRequest": {
             "type": "object",
+            "required": [
+                "peer_id"
+            ],
+            "properties": {
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.configValidation": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.Issue"
+                    }
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.Issue"
+                    }
+                }
+            }
+        },
+        "routes.consentDecisionRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "decision": {
+                    "type": "string",
+                    "enum": [
+                        "once",
+                        "always",
+                        "block",
+                        "deny"
+                    ],
+                    "example": "always"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f2c4e1a7b3d5f60"
+                }
+            }
+        },
+        "routes.consentForgetRequest": {
+            "type": "object",
+            "required": [
+                "peer_id"
+            ],
             "properties": {
                 "peer_id": {
                     "type": "string",
@@ -6458,6 +11301,10 @@ const docTemplate = `{
         "routes.dataAggregateRequest": {
             "type": "object",
             "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
                 "expr": {
                     "type": "string",
                     "example": "SUM(score) as total, COUNT(*) as n"
@@ -6496,10 +11343,13 @@ const docTemplate = `{
         },
         "routes.dataDeleteRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
-                "filter": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "table": {
                     "type": "string",
@@ -6508,7 +11358,21 @@ const docTemplate = `{
             }
         },
         "routes.dataDeleteWhereRequest": {
-            "type": "object"
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "table": {
+                    "type": "string",
+                    "example": "cards"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "column_id = ?"
+                }
+            }
         },
         "routes.dataDescribeResponse": {
             "type": "object",
@@ -6528,6 +11392,10 @@ const docTemplate = `{
         "routes.dataDistinctRequest": {
             "type": "object",
             "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
                 "column": {
                     "type": "string",
                     "example": "category"
@@ -6550,16 +11418,99 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.dataFindOneRequest": {
+            "type": "object",
+            "required": [
+                "table"
+            ],
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "title",
+                        "slug"
+                    ]
+                },
+                "table": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "slug = ?"
+                }
+            }
+        },
         "routes.dataFindRequest": {
-            "type": "object"
+            "type": "object",
+            "required": [
+                "table"
+            ],
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "title",
+                        "slug"
+                    ]
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "order": {
+                    "type": "string",
+                    "example": "_id DESC"
+                },
+                "table": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "published = ?"
+                }
+            }
         },
         "routes.dataGetByRequest": {
-            "type": "object"
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "string",
+                    "example": "slug"
+                },
+                "table": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "value": {}
+            }
         },
         "routes.dataInsertRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
-                "row": {
+                "data": {
                     "type": "object",
                     "additionalProperties": {}
                 },
@@ -6584,6 +11535,9 @@ const docTemplate = `{
         },
         "routes.dataLuaCallRequest": {
             "type": "object",
+            "required": [
+                "function"
+            ],
             "properties": {
                 "function": {
                     "type": "string",
@@ -6622,6 +11576,10 @@ const docTemplate = `{
         "routes.dataPluckRequest": {
             "type": "object",
             "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
                 "column": {
                     "type": "string",
                     "example": "title"
@@ -6646,6 +11604,9 @@ const docTemplate = `{
         },
         "routes.dataPolicyRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
                 "policy": {
                     "description": "owner, open, group, local",
@@ -6660,18 +11621,35 @@ const docTemplate = `{
         },
         "routes.dataQueryRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
-                "filter": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "limit": {
                     "type": "integer",
                     "example": 100
                 },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
                 "table": {
                     "type": "string",
-                    "example": "my_table"
+                    "example": "my_table"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "published = ?"
                 }
             }
         },
@@ -6682,7 +11660,7 @@ const docTemplate = `{
                     "type": "string",
                     "example": "new_name"
                 },
-                "table": {
+                "old_name": {
                     "type": "string",
                     "example": "my_table"
                 }
@@ -6690,6 +11668,9 @@ const docTemplate = `{
         },
         "routes.dataRoleRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
                 "table": {
                     "type": "string",
@@ -6714,6 +11695,9 @@ const docTemplate = `{
         },
         "routes.dataTableCreateRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "columns": {
                     "type": "array",
@@ -6768,6 +11752,9 @@ const docTemplate = `{
         },
         "routes.dataTableRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
                 "table": {
                     "type": "string",
@@ -6777,14 +11764,17 @@ const docTemplate = `{
         },
         "routes.dataUpdateRequest": {
             "type": "object",
+            "required": [
+                "table"
+            ],
             "properties": {
-                "filter": {
+                "data": {
                     "type": "object",
                     "additionalProperties": {}
                 },
-                "set": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "table": {
                     "type": "string",
@@ -6793,7 +11783,25 @@ const docTemplate = `{
             }
         },
         "routes.dataUpdateWhereRequest": {
-            "type": "object"
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "table": {
+                    "type": "string",
+                    "example": "cards"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "column_id = ?"
+                }
+            }
         },
         "routes.dataUpsertRequest": {
             "type": "object",
@@ -6813,10 +11821,49 @@ const docTemplate = `{
             }
         },
         "routes.dataWhereRequest": {
-            "type": "object"
+            "type": "object",
+            "required": [
+                "table"
+            ],
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {}
+                },
+                "table": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "where": {
+                    "type": "string",
+                    "example": "published = ?"
+                }
+            }
+        },
+        "routes.dataWipeRequest": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "chat",
+                        "calls"
+                    ]
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
         },
         "routes.datafedGroupIDRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -6844,6 +11891,9 @@ const docTemplate = `{
         },
         "routes.datafedOfferRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -6908,9 +11958,32 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.digestRequest": {
+            "type": "object",
+            "properties": {
+                "favorites": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "frequency": {
+                    "type": "string",
+                    "example": "daily"
+                }
+            }
+        },
         "routes.docFileInfo": {
             "type": "object",
             "properties": {
+                "access": {
+                    "description": "Who may fetch the file; peers is only in your own listings.",
+                    "type": "string",
+                    "enum": [
+                        "group",
+                        "peers",
+                        "public"
+                    ],
+                    "example": "group"
+                },
                 "mod_time": {
                     "type": "string",
                     "example": "2026-03-08T12:00:00Z"
@@ -6919,6 +11992,12 @@ const docTemplate = `{
                     "type": "string",
                     "example": "notes.pdf"
                 },
+                "peers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "size": {
                     "type": "integer",
                     "example": 1048576
@@ -6957,6 +12036,73 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.docsACLRequest": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "type": "string",
+                    "enum": [
+                        "group",
+                        "peers",
+                        "public"
+                    ],
+                    "example": "peers"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "notes.pdf"
+                },
+                "group_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f6a1b2"
+                },
+                "peers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "12D3KooWXxx..."
+                    ]
+                }
+            }
+        },
+        "routes.docsBrowseRefreshRequest": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "example": "e933372f2147..."
+                },
+                "peer_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.docsBrowseResponse": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "string"
+                },
+                "peers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.browsePeer"
+                    }
+                },
+                "slow": {
+                    "description": "members still being fetched; ask /api/docs/browse/refresh",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "routes.docsDeleteRequest": {
             "type": "object",
             "properties": {
@@ -7082,6 +12228,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "My Group"
                 },
+                "ordered": {
+                    "description": "sequence-numbered delivery with resend",
+                    "type": "boolean"
+                },
                 "volatile": {
                     "type": "boolean"
                 }
@@ -7115,6 +12265,9 @@ const docTemplate = `{
         },
         "routes.groupIDRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -7124,6 +12277,9 @@ const docTemplate = `{
         },
         "routes.groupMaxMembersRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -7154,6 +12310,10 @@ const docTemplate = `{
         },
         "routes.groupMetaRequest": {
             "type": "object",
+            "required": [
+                "group_id",
+                "name"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -7169,6 +12329,22 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.groupOrderedRequest": {
+            "type": "object",
+            "required": [
+                "group_id"
+            ],
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f6a1b2"
+                },
+                "ordered": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
         "routes.groupPeerRequest": {
             "type": "object",
             "properties": {
@@ -7184,6 +12360,9 @@ const docTemplate = `{
         },
         "routes.groupSendRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -7224,6 +12403,9 @@ const docTemplate = `{
         },
         "routes.groupSetRolesListRequest": {
             "type": "object",
+            "required": [
+                "group_id"
+            ],
             "properties": {
                 "group_id": {
                     "type": "string",
@@ -7241,6 +12423,59 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.healthCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "group-state"
+                },
+                "repaired": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "description": "ok, repaired, failed or skipped",
+                    "type": "string",
+                    "example": "repaired"
+                }
+            }
+        },
+        "routes.healthReport": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "integer"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.healthCheck"
+                    }
+                },
+                "elapsed_ms": {
+                    "type": "integer"
+                },
+                "first_start": {
+                    "type": "boolean"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "repaired": {
+                    "type": "integer"
+                },
+                "unclean": {
+                    "type": "boolean"
+                }
+            }
+        },
         "routes.hostedGroupInfo": {
             "type": "object",
             "properties": {
@@ -7303,6 +12538,28 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.jobsClearResponse": {
+            "type": "object",
+            "properties": {
+                "cleared": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "routes.listenApproveRequest": {
+            "type": "object",
+            "properties": {
+                "file_path": {
+                    "type": "string",
+                    "example": "/home/me/Music/song.mp3"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f6"
+                }
+            }
+        },
         "routes.listenControlRequest": {
             "type": "object",
             "properties": {
@@ -7332,10 +12589,21 @@ const docTemplate = `{
         "routes.listenGroup": {
             "type": "object",
             "properties": {
+                "drift": {
+                    "description": "host: seconds each listener is ahead (+) or behind",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
                 "id": {
                     "type": "string",
                     "example": "listen-a1b2c3d4e5f6"
                 },
+                "jukebox": {
+                    "type": "boolean"
+                },
                 "listeners": {
                     "type": "array",
                     "items": {
@@ -7346,6 +12614,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "My Station"
                 },
+                "pending_requests": {
+                    "type": "integer",
+                    "example": 2
+                },
                 "play_state": {
                     "$ref": "#/definitions/routes.listenPlayState"
                 },
@@ -7371,6 +12643,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "host"
                 },
+                "stream_gen": {
+                    "description": "listener: bumped when the host resyncs us",
+                    "type": "integer"
+                },
                 "track": {
                     "$ref": "#/definitions/routes.listenTrack"
                 }
@@ -7389,6 +12665,15 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.listenJukeboxRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
         "routes.listenLoadRequest": {
             "type": "object",
             "properties": {
@@ -7431,6 +12716,44 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.listenRejectRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f6"
+                }
+            }
+        },
+        "routes.listenReportRequest": {
+            "type": "object",
+            "properties": {
+                "elapsed": {
+                    "type": "number",
+                    "example": 42.5
+                }
+            }
+        },
+        "routes.listenRequestsResponse": {
+            "type": "object",
+            "properties": {
+                "jukebox": {
+                    "type": "boolean"
+                },
+                "peer_names": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.listenTrackRequest"
+                    }
+                }
+            }
+        },
         "routes.listenStateResponse": {
             "type": "object",
             "properties": {
@@ -7458,6 +12781,14 @@ const docTemplate = `{
                 },
                 "format": {
                     "type": "string",
+                    "enum": [
+                        "mp3",
+                        "flac",
+                        "ogg",
+                        "aac",
+                        "m4a",
+                        "stream"
+                    ],
                     "example": "mp3"
                 },
                 "is_stream": {
@@ -7470,6 +12801,40 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.listenTrackRequest": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "integer",
+                    "example": 1709136000000
+                },
+                "id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f6"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "song.mp3"
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                },
+                "track": {
+                    "type": "string",
+                    "example": "song.mp3"
+                }
+            }
+        },
+        "routes.listenTrackRequestBody": {
+            "type": "object",
+            "properties": {
+                "track": {
+                    "type": "string",
+                    "example": "song.mp3"
+                }
+            }
+        },
         "routes.loopbackICERequest": {
             "type": "object",
             "properties": {
@@ -7502,6 +12867,9 @@ const docTemplate = `{
         },
         "routes.luaPrefabApplyRequest": {
             "type": "object",
+            "required": [
+                "prefab"
+            ],
             "properties": {
                 "csrf": {
                     "type": "string",
@@ -7532,6 +12900,9 @@ const docTemplate = `{
         },
         "routes.mqAckRequest": {
             "type": "object",
+            "required": [
+                "msg_id"
+            ],
             "properties": {
                 "from_peer_id": {
                     "type": "string",
@@ -7543,6 +12914,63 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.mqOutboxEntry": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "created_at": {
+                    "type": "integer",
+                    "example": 1711234567890
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "example": 1711839367890
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "peer unreachable"
+                },
+                "next_try": {
+                    "type": "integer",
+                    "example": 1711234627890
+                },
+                "payload": {},
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "chat"
+                }
+            }
+        },
+        "routes.mqOutboxPurgeRequest": {
+            "type": "object",
+            "properties": {
+                "peer_id": {
+                    "description": "Peer whose queued messages are dropped; empty drops all.",
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.mqOutboxPurgeResponse": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
         "routes.mqSendRequest": {
             "type": "object",
             "properties": {
@@ -7555,9 +12983,28 @@ const docTemplate = `{
                     "type": "string",
                     "example": "12D3KooWXxx..."
                 },
+                "priority": {
+                    "description": "Delivery lane; defaults from topic (call:/group: interactive) and payload size.",
+                    "type": "string",
+                    "enum": [
+                        "interactive",
+                        "normal",
+                        "bulk"
+                    ],
+                    "example": "interactive"
+                },
+                "queue": {
+                    "description": "Store and forward: if the peer is unreachable, keep the message in the outbox and deliver it later.",
+                    "type": "boolean"
+                },
                 "topic": {
                     "type": "string",
                     "example": "call:nc-abc123"
+                },
+                "ttl_sec": {
+                    "description": "How long a queued message waits for the peer; default 7 days.",
+                    "type": "integer",
+                    "example": 86400
                 }
             }
         },
@@ -7568,8 +13015,17 @@ const docTemplate = `{
                     "type": "string",
                     "example": "a1b2c3d4-..."
                 },
+                "outbox_id": {
+                    "description": "Outbox entry of a queued message.",
+                    "type": "integer",
+                    "example": 12
+                },
                 "status": {
                     "type": "string",
+                    "enum": [
+                        "delivered",
+                        "queued"
+                    ],
                     "example": "delivered"
                 }
             }
@@ -7587,6 +13043,20 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.namespaceInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "type": "integer"
+                },
+                "peers": {
+                    "type": "integer"
+                }
+            }
+        },
         "routes.ormAccess": {
             "type": "object",
             "properties": {
@@ -7696,6 +13166,55 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.pairClaimRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "482913"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Pixel 8"
+                }
+            }
+        },
+        "routes.pairGuestRequest": {
+            "type": "object",
+            "properties": {
+                "minutes": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "routes.pairRevokeRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "3fa1c09b72d4e815"
+                }
+            }
+        },
+        "routes.pairStartRequest": {
+            "type": "object",
+            "properties": {
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notify",
+                        "call"
+                    ]
+                }
+            }
+        },
         "routes.peerContentResponse": {
             "type": "object",
             "properties": {
@@ -7720,9 +13239,109 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.peerFollowRequest": {
+            "type": "object",
+            "required": [
+                "peer_id"
+            ],
+            "properties": {
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.peerForgetRequest": {
+            "type": "object",
+            "required": [
+                "peer_id"
+            ],
+            "properties": {
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.peerNoteRequest": {
+            "type": "object",
+            "required": [
+                "peer_id"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "Met at the meetup, hosts the jazz station"
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.peerReportRequest": {
+            "type": "object",
+            "required": [
+                "peer_id",
+                "reason"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Floods the chat room with links"
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "spam",
+                        "harassment",
+                        "impersonation",
+                        "illegal",
+                        "other"
+                    ],
+                    "example": "spam"
+                }
+            }
+        },
+        "routes.permalinkResolution": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "live_url": {
+                    "type": "string"
+                },
+                "mirror_url": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "pinned_at": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
         "routes.quickSettingsRequest": {
             "type": "object",
             "properties": {
+                "bridge_mode": {
+                    "type": "boolean"
+                },
+                "bridge_token": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -7787,6 +13406,40 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.reputationSubmitRequest": {
+            "type": "object",
+            "required": [
+                "peer_id"
+            ],
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "peer_id": {
+                    "type": "string",
+                    "example": "12D3KooWXxx..."
+                }
+            }
+        },
+        "routes.ruleIDRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "9c2e41d07a5b3f68"
+                }
+            }
+        },
+        "routes.scheduleDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
         "routes.schemaColumn": {
             "type": "object",
             "properties": {
@@ -7860,6 +13513,9 @@ const docTemplate = `{
         },
         "routes.schemaNameRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
                     "type": "string",
@@ -7870,55 +13526,120 @@ const docTemplate = `{
         "routes.schemaSaveRequest": {
             "type": "object",
             "properties": {
+                "access": {
+                    "$ref": "#/definitions/routes.ormAccess"
+                },
                 "columns": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/routes.schemaColumn"
                     }
                 },
+                "context": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "orders"
+                },
+                "roles": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/routes.ormSchemaRoles"
+                    }
+                },
+                "system_key": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "routes.schemaSetAccessRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "access": {
+                    "$ref": "#/definitions/routes.ormAccess"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "orders"
+                }
+            }
+        },
+        "routes.schemaSetContextRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "context": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "name": {
                     "type": "string",
                     "example": "orders"
                 }
             }
         },
-        "routes.schemaSetAccessRequest": {
+        "routes.schemaSetRolesRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "roles": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/routes.ormSchemaRoles"
+                    }
+                }
+            }
+        },
+        "routes.secretNameRequest": {
             "type": "object",
             "properties": {
-                "access": {
-                    "$ref": "#/definitions/routes.ormAccess"
-                },
                 "name": {
                     "type": "string",
-                    "example": "orders"
+                    "example": "credits-admin"
                 }
             }
         },
-        "routes.schemaSetContextRequest": {
+        "routes.secretRotated": {
             "type": "object",
             "properties": {
-                "context": {
-                    "type": "boolean",
-                    "example": true
-                },
                 "name": {
-                    "type": "string",
-                    "example": "orders"
+                    "type": "string"
+                },
+                "updated": {
+                    "description": "unix millis",
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "1 when set, +1 per rotation",
+                    "type": "integer"
                 }
             }
         },
-        "routes.schemaSetRolesRequest": {
+        "routes.secretSetRequest": {
             "type": "object",
             "properties": {
                 "name": {
                     "type": "string",
-                    "example": "posts"
+                    "example": "credits-admin"
                 },
-                "roles": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/routes.ormSchemaRoles"
-                    }
+                "value": {
+                    "type": "string",
+                    "example": "tok_4f1c0e"
                 }
             }
         },
@@ -7959,6 +13680,9 @@ const docTemplate = `{
         },
         "routes.siteDeleteRequest": {
             "type": "object",
+            "required": [
+                "path"
+            ],
             "properties": {
                 "path": {
                     "type": "string",
@@ -7992,6 +13716,40 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.sitePublishRequest": {
+            "type": "object",
+            "properties": {
+                "csrf": {
+                    "type": "string",
+                    "example": "token123"
+                }
+            }
+        },
+        "routes.sitePublishResponse": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer",
+                    "example": 1830211
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 2150
+                },
+                "files": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "published"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "sftp"
+                }
+            }
+        },
         "routes.siteUploadLocalRequest": {
             "type": "object",
             "properties": {
@@ -8024,6 +13782,9 @@ const docTemplate = `{
         },
         "routes.splitPrefRequest": {
             "type": "object",
+            "required": [
+                "key"
+            ],
             "properties": {
                 "key": {
                     "type": "string",
@@ -8035,6 +13796,71 @@ const docTemplate = `{
                 }
             }
         },
+        "routes.startupReport": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "boolean"
+                },
+                "finished_at": {
+                    "type": "integer"
+                },
+                "stages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.startupStage"
+                    }
+                },
+                "started_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.startupStage": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "no relay"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string",
+                    "example": "Discovering relay"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "relay"
+                },
+                "started_at": {
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "running, done or failed",
+                    "type": "string",
+                    "example": "done"
+                },
+                "step": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
         "routes.statusOK": {
             "type": "object",
             "properties": {
@@ -8114,6 +13940,9 @@ const docTemplate = `{
         },
         "routes.templateApplyLocalRequest": {
             "type": "object",
+            "required": [
+                "path"
+            ],
             "properties": {
                 "csrf": {
                     "type": "string",
@@ -8127,6 +13956,9 @@ const docTemplate = `{
         },
         "routes.templateApplyRequest": {
             "type": "object",
+            "required": [
+                "template"
+            ],
             "properties": {
                 "csrf": {
                     "type": "string",
@@ -8152,76 +13984,254 @@ const docTemplate = `{
             }
         },
         "routes.templateApplyStoreRequest": {
+            "type": "object",
+            "required": [
+                "template"
+            ],
+            "properties": {
+                "csrf": {
+                    "type": "string",
+                    "example": "token123"
+                },
+                "session": {
+                    "description": "install session to report progress to",
+                    "type": "string",
+                    "example": "9f2c4e1ab07d45c3a1e6f0b2d8c97a13"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "kanban"
+                }
+            }
+        },
+        "routes.templateApplyStoreResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer",
+                    "example": 450
+                },
+                "status": {
+                    "type": "string",
+                    "example": "applied"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "kanban"
+                }
+            }
+        },
+        "routes.templateInstallRequest": {
+            "type": "object",
+            "required": [
+                "template"
+            ],
+            "properties": {
+                "csrf": {
+                    "type": "string",
+                    "example": "token123"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "kanban"
+                }
+            }
+        },
+        "routes.templateInstallResponse": {
+            "type": "object",
+            "properties": {
+                "session": {
+                    "type": "string",
+                    "example": "9f2c4e1ab07d45c3a1e6f0b2d8c97a13"
+                }
+            }
+        },
+        "routes.templatePublishRequest": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "csrf": {
+                    "type": "string",
+                    "example": "token123"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/home/user/my-template"
+                }
+            }
+        },
+        "routes.templatePublishResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.templatePublishResult"
+                    }
+                },
+                "template": {
+                    "type": "string",
+                    "example": "my-template"
+                }
+            }
+        },
+        "routes.templatePublishResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "publish template: 409 Conflict: a template with this name belongs to someone else"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "https://rv.example.org"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "routes.templateRevertRequest": {
             "type": "object",
             "properties": {
                 "csrf": {
                     "type": "string",
                     "example": "token123"
+                }
+            }
+        },
+        "routes.templateRevertResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "reverted"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "blog"
+                }
+            }
+        },
+        "routes.templateSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "content"
+                },
+                "default_role": {
+                    "type": "string",
+                    "example": "coauthor"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "A personal blog"
+                },
+                "icon": {
+                    "type": "string",
+                    "example": "📝"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Blog"
+                },
+                "require_email": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "[\"posts\"",
+                        "\"blog_config\"]"
+                    ]
+                }
+            }
+        },
+        "routes.templateSnapshotEntry": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 1760700000000
+                },
+                "id": {
+                    "type": "string",
+                    "example": "ltq3k8w1p0"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "kanban"
                 },
                 "template": {
                     "type": "string",
-                    "example": "kanban"
+                    "example": "blog"
+                }
+            }
+        },
+        "routes.templateUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "csrf": {
+                    "type": "string",
+                    "example": "token123"
                 }
             }
         },
-        "routes.templateApplyStoreResponse": {
+        "routes.templateUpdateResponse": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "integer",
-                    "example": 450
-                },
                 "status": {
                     "type": "string",
-                    "example": "applied"
+                    "example": "updated"
                 },
                 "template": {
                     "type": "string",
                     "example": "kanban"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.3.0"
                 }
             }
         },
-        "routes.templateSettingsResponse": {
+        "routes.templateUpdateStatusResponse": {
             "type": "object",
             "properties": {
-                "category": {
-                    "type": "string",
-                    "example": "content"
+                "available": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "default_role": {
+                "installed": {
                     "type": "string",
-                    "example": "coauthor"
+                    "example": "1.2.0"
                 },
-                "description": {
+                "latest": {
                     "type": "string",
-                    "example": "A personal blog"
+                    "example": "1.3.0"
                 },
-                "icon": {
+                "source": {
                     "type": "string",
-                    "example": "📝"
+                    "example": "store"
                 },
-                "name": {
+                "template": {
                     "type": "string",
-                    "example": "Blog"
-                },
-                "require_email": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "schemas": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "[\"posts\"",
-                        "\"blog_config\"]"
-                    ]
+                    "example": "kanban"
                 }
             }
         },
         "routes.templateValidateLocalRequest": {
             "type": "object",
+            "required": [
+                "path"
+            ],
             "properties": {
                 "path": {
                     "type": "string",
@@ -8294,6 +14304,17 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Roadwarrior"
                 },
+                "quality": {
+                    "type": "string",
+                    "enum": [
+                        "direct-lan",
+                        "direct-wan",
+                        "holepunch",
+                        "relay",
+                        "none"
+                    ],
+                    "example": "direct-lan"
+                },
                 "reachable": {
                     "type": "boolean",
                     "example": true
@@ -8344,6 +14365,9 @@ const docTemplate = `{
         },
         "routes.transformExecuteRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "args": {
                     "type": "array",
@@ -8438,6 +14462,9 @@ const docTemplate = `{
         },
         "routes.transformNameRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
                     "type": "string",
@@ -8447,6 +14474,9 @@ const docTemplate = `{
         },
         "routes.transformPreviewRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
                     "type": "string",
@@ -8486,9 +14516,168 @@ const docTemplate = `{
                 }
             }
         },
+        "rules.Action": {
+            "type": "object",
+            "properties": {
+                "action_id": {
+                    "type": "string"
+                },
+                "function": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "webhook: name of the secret sent as bearer token",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "rules.Rule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/rules.Action"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "$ref": "#/definitions/rules.Trigger"
+                }
+            }
+        },
+        "rules.Trigger": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "word": {
+                    "type": "string"
+                }
+            }
+        },
+        "secrets.Info": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "updated": {
+                    "description": "unix millis",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "1 when set, +1 per rotation",
+                    "type": "integer"
+                }
+            }
+        },
+        "siteassets.Stats": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "generated": {
+                    "description": "variants (re)encoded by the last build",
+                    "type": "integer"
+                },
+                "images": {
+                    "description": "source images with at least one variant",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "description": "Unix ms, 0 = never",
+                    "type": "integer"
+                },
+                "saved_bytes": {
+                    "description": "source bytes minus smallest variant, summed",
+                    "type": "integer"
+                },
+                "variants": {
+                    "description": "variant files in the cache",
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "bytes_in": {
+                    "type": "integer"
+                },
+                "bytes_out": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "outcome": {
+                    "description": "\"allowed\" or \"denied\"",
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "protocol": {
+                    "type": "string"
+                },
+                "ts": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                }
+            }
+        },
         "storage.ChatMessage": {
             "type": "object",
             "properties": {
+                "call_id": {
+                    "description": "set for messages exchanged during a call",
+                    "type": "string"
+                },
                 "content": {
                     "type": "string"
                 },
@@ -8499,6 +14688,132 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "storage.OwnershipRecord": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "unix millis",
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "\"transfer\" or \"election\"",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.PeerNote": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.PeerReport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.ScheduledSession": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.SiteFollow": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "followed_at": {
+                    "description": "Unix ms",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "peer_id": {
+                    "type": "string"
+                },
+                "site_hash": {
+                    "description": "hash of the copy, as announced by the peer",
+                    "type": "string"
+                },
+                "synced_at": {
+                    "description": "Unix ms of the last complete sync, 0 = never",
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
@@ -8510,7 +14825,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{"http"},
 	Title:            "goop2 Viewer API",
-	Description:      "All HTTP + MQ endpoints exposed by the goop2 viewer.\\n\\nAll peer-to-peer signaling travels through MQ (POST /api/mq/send → P2P → /api/mq/events SSE).\\nSee x-mq-topics in the raw spec (/api/openapi.json) for the full topic contract.",
+	Description:      "All HTTP + MQ endpoints exposed by the goop2 viewer.\\n\\nAll peer-to-peer signaling travels through MQ (POST /api/mq/send → P2P → /api/mq/events SSE).\\nSee x-mq-topics in the raw spec (/api/openapi.json) for the full topic contract.\\n\\nErrors share one JSON envelope: {\"error\": {\"code\": \"missing_field\", \"message\": \"group_id is required\", \"field\": \"group_id\"}}. POST bodies are validated against the schemas below before the handler runs.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -318,6 +318,23 @@ async function renderLauncher(host) {
     const info = findPeer(peers, selected);
     const isRV = info && info.rendezvous_only;
 
+    // Passphrase-protected key files must be unlocked before the peer starts.
+    try {
+      if (await window.go.main.App.PeerKeysLocked(selected)) {
+        const pass = await window.Goop.dialog.prompt({
+          title: "Unlock keys",
+          message: `The keys of "${selected}" are protected. Enter the passphrase.`,
+          type: "password",
+          okText: "Unlock",
+        });
+        if (pass === null) return;
+        await window.go.main.App.UnlockPeer(selected, pass);
+      }
+    } catch (e) {
+      err.textContent = String(e);
+      return;
+    }
+
     start.disabled = true;
     del.disabled = true;
     err.textContent = "";
@@ -338,20 +355,37 @@ async function renderLauncher(host) {
       footerInner.appendChild(progressWrap);
     }
 
-    const onProgress = (data) => {
-      if (!data) return;
-      const pct = Math.round((data.step / data.total) * 100);
-      progressFill.style.width = pct + "%";
-      progressLabel.textContent = data.label || "";
+    // Stages arrive as they start and end; a stage that runs long shows
+    // how long it has been going so a slow relay or database is visible.
+    let current = null;
+    const showStage = () => {
+      if (!current) return;
+      let text = current.label || "";
+      const secs = Math.floor((Date.now() - current.started_at) / 1000);
+      if (current.state === "running" && secs >= 2) text += ` (${secs}s)`;
+      progressLabel.textContent = text;
+    };
+    const ticker = setInterval(showStage, 1000);
+
+    const onStage = (st) => {
+      if (!st || !st.total) return;
+      const done = st.state === "running" ? st.step - 1 : st.step;
+      progressFill.style.width = Math.round((done / st.total) * 100) + "%";
+      current = st;
+      showStage();
+      if (st.state === "failed") {
+        err.textContent = `${st.label} failed: ${st.error || "unknown error"}`;
+      }
     };
 
     if (window.runtime) {
-      window.runtime.EventsOn("startup:progress", onProgress);
+      window.runtime.EventsOn("startup:stage", onStage);
     }
 
     const cleanup = () => {
+      clearInterval(ticker);
       if (window.runtime) {
-        window.runtime.EventsOff("startup:progress");
+        window.runtime.EventsOff("startup:stage");
       }
       if (progressWrap.parentNode) progressWrap.remove();
     };
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/content"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/sitetemplates"
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/util"
+	"github.com/petervdpas/goop2/internal/viewer/routes"
+)
+
+// runInit creates a peer directory: goop.json built from a preset, the
+// identity key, the site folder and optionally a starter template. On a
+// terminal it asks for what the flags leave out. The directory may come
+// before or after the flags.
+func runInit(args []string) {
+	fset := flag.NewFlagSet("init", flag.ExitOnError)
+	preset := fset.String("preset", "desktop", "Deployment preset to start from")
+	label := fset.String("label", "", "Peer label (default: the directory name)")
+	rv := fset.String("rendezvous", "", "WAN rendezvous URL to join")
+	port := fset.Int("port", -1, "libp2p listen port (0 = random; default: the preset's)")
+	tmpl := fset.String("template", "", "Built-in or store template to apply to the site")
+	yes := fset.Bool("yes", false, "Do not ask; use the flags and defaults")
+	list := fset.Bool("list", false, "List the presets and exit")
+
+	var dir string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		dir, args = args[0], args[1:]
+	}
+	fset.Parse(args)
+	if dir == "" {
+		dir = fset.Arg(0)
+	}
+
+	if *list {
+		for _, p := range config.Presets() {
+			fmt.Printf("  %-18s %s\n", p.Name, p.Description)
+		}
+		return
+	}
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: init command requires directory path")
+		fmt.Fprintln(os.Stderr, "Usage: goop2 init <peer-directory> [-preset name] [-label text] [-rendezvous url] [-port n] [-template name] [-yes]")
+		os.Exit(1)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		log.Fatalf("Invalid peer directory: %v", err)
+	}
+	cfgPath := filepath.Join(absDir, "goop.json")
+	if _, err := os.Stat(cfgPath); err == nil {
+		log.Fatalf("%s already exists; apply a preset to it from the settings page or /api/config/preset", cfgPath)
+	}
+
+	// Check the preset before asking anything.
+	probe := config.Default()
+	if err := config.ApplyPreset(&probe, *preset); err != nil {
+		log.Fatal(err)
+	}
+	if *label == "" {
+		*label = filepath.Base(absDir)
+	}
+	if *port < 0 {
+		*port = probe.P2P.ListenPort
+	}
+
+	set := map[string]bool{}
+	fset.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !*yes && isTerminal(os.Stdin) {
+		in := bufio.NewReader(os.Stdin)
+		if !set["label"] {
+			*label = ask(in, "Peer label", *label)
+		}
+		if !probe.Presence.RendezvousOnly {
+			if !set["rendezvous"] {
+				*rv = ask(in, "WAN rendezvous URL (empty for LAN only)", *rv)
+			}
+			if !set["port"] {
+				for {
+					n, err := strconv.Atoi(ask(in, "Listen port (0 = random)", strconv.Itoa(*port)))
+					if err == nil && n >= 0 && n <= 65535 {
+						*port = n
+						break
+					}
+					fmt.Println("  enter a port between 0 and 65535")
+				}
+			}
+			if !set["template"] {
+				var names []string
+				if metas, err := sitetemplates.List(); err == nil {
+					for _, m := range metas {
+						names = append(names, m.Dir)
+					}
+				}
+				*tmpl = ask(in, "Starter template ("+strings.Join(names, ", ")+", a store template, or empty)", *tmpl)
+			}
+		}
+	}
+
+	cfg, created, err := config.Ensure(cfgPath)
+	if err != nil {
+		log.Fatalf("Failed to create config: %v", err)
+	}
+	if !created {
+		log.Fatalf("%s already exists", cfgPath)
+	}
+	if err := config.ApplyPreset(&cfg, *preset); err != nil {
+		os.Remove(cfgPath)
+		log.Fatal(err)
+	}
+	cfg.Profile.Label = strings.TrimSpace(*label)
+	cfg.P2P.ListenPort = *port
+	if *rv != "" {
+		cfg.Presence.RendezvousWAN = strings.TrimSpace(*rv)
+	}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		os.Remove(cfgPath)
+		log.Fatalf("Invalid config: %v", err)
+	}
+
+	peerID, err := p2p.PeerIDFromKeyFile(util.ResolvePath(absDir, cfg.Identity.KeyFile))
+	if err != nil {
+		log.Fatalf("Failed to create identity key: %v", err)
+	}
+
+	if !cfg.Presence.RendezvousOnly {
+		siteDir := cfg.Paths.SiteRoot
+		if !filepath.IsAbs(siteDir) {
+			siteDir = filepath.Join(absDir, siteDir)
+		}
+		if err := ensureDefaultPeerSite(siteDir); err != nil {
+			log.Fatalf("Failed to create site: %v", err)
+		}
+	}
+
+	fmt.Printf("Created %s (preset %s)\n", cfgPath, *preset)
+	fmt.Printf("Peer ID: %s\n", peerID)
+
+	if t := strings.TrimSpace(*tmpl); t != "" && !cfg.Presence.RendezvousOnly {
+		if err := initTemplate(absDir, cfgPath, cfg, t, peerID); err != nil {
+			fmt.Fprintf(os.Stderr, "Template %q not applied: %v\n", t, err)
+			fmt.Fprintln(os.Stderr, "The peer directory is ready; apply a template from the Templates page instead.")
+		}
+	}
+
+	if is, err := config.Check(cfgPath); err == nil {
+		for _, i := range is {
+			fmt.Printf("  warning: %s\n", i.Message)
+		}
+	}
+	cmd := "peer"
+	if cfg.Presence.RendezvousOnly {
+		cmd = "rendezvous"
+	}
+	fmt.Printf("Start it with: goop2 %s %s\n", cmd, dir)
+}
+
+// initTemplate applies a starter template to the new peer's site and
+// database. Store templates come from the WAN rendezvous.
+func initTemplate(peerDir, cfgPath string, cfg config.Config, template, peerID string) error {
+	db, err := storage.Open(peerDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store, err := content.NewStore(peerDir, cfg.Paths.SiteRoot)
+	if err != nil {
+		return err
+	}
+	d := routes.Deps{DB: db, Content: store, PeerDir: peerDir, CfgPath: cfgPath}
+	if wan := strings.TrimSpace(cfg.Presence.RendezvousWAN); wan != "" {
+		d.RVClients = []*rendezvous.Client{rendezvous.NewClient(util.NormalizeURL(wan))}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), routes.TemplateBundleTimeout)
+	defer cancel()
+	res, err := routes.ApplyTemplateOffline(ctx, d, template, peerID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %s template %q\n", res.Source, res.Name)
+	if res.SeedSkipped {
+		fmt.Println("  its sample data is added when you apply it again from the running peer")
+	}
+	if res.GroupSkipped {
+		fmt.Println("  its co-author group is created when you apply it again from the running peer")
+	}
+	return nil
+}
+
+// ask prints question with its default and returns the answer, or def
+// when the answer is empty.
+func ask(in *bufio.Reader, question, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	line, _ := in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
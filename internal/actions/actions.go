@@ -0,0 +1,172 @@
+// Package actions is the catalog of user-facing operations (create a group,
+// call a peer, play/pause the listen room, apply a template, ...) with their
+// parameters and permissions.
+//
+// Most actions are a thin description of an existing viewer route, so the
+// command palette, bots and Lua scripts can list and invoke features by ID
+// instead of hardcoding paths and body shapes. Actions that only make sense
+// in a browser (placing a call, navigating) name a client handler instead.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Parameter types.
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+	TypePeer   = "peer"  // peer ID; palettes offer a peer picker
+	TypeGroup  = "group" // group ID; palettes offer a group picker
+)
+
+// Param describes one action argument.
+type Param struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Action is one invocable operation.
+type Action struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Category    string  `json:"category"`
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params"`
+
+	// Method and Path are the viewer route the action runs; empty for
+	// client actions.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Client names the frontend handler for actions that need the browser
+	// (e.g. "call.start" needs the camera and mic). Not runnable server-side.
+	Client string `json:"client,omitempty"`
+
+	// Confirm marks destructive actions; palettes ask before running them.
+	Confirm bool `json:"confirm,omitempty"`
+	// Script allows goop.actions.run from Lua. Scripts run on behalf of
+	// remote peers, so only harmless actions set it.
+	Script bool `json:"script,omitempty"`
+	// Scopes lists the pairing scopes under which a paired device may call
+	// Path directly; filled in when listed.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// fixed body fields merged under the caller's params (e.g. the listen
+	// control verb).
+	fixed map[string]any
+	// csrf: the route wants the viewer's CSRF token in the body.
+	csrf bool
+}
+
+// NeedsCSRF reports whether the route expects the viewer's CSRF token; Body
+// passes a "csrf" param through for these.
+func (a Action) NeedsCSRF() bool { return a.csrf }
+
+// All returns the catalog in display order.
+func All() []Action {
+	return slices.Clone(catalog)
+}
+
+// Get returns the action with the given ID.
+func Get(id string) (Action, bool) {
+	for _, a := range catalog {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// Body checks params against the action and returns the request body:
+// the fixed fields plus every declared param that was given. Unknown params
+// are dropped.
+func (a Action) Body(params map[string]any) (map[string]any, error) {
+	body := make(map[string]any, len(a.fixed)+len(a.Params))
+	for _, p := range a.Params {
+		v, ok := params[p.Name]
+		if !ok || v == nil || v == "" {
+			if p.Required {
+				return nil, fmt.Errorf("missing %s", p.Name)
+			}
+			continue
+		}
+		if err := checkType(p, v); err != nil {
+			return nil, err
+		}
+		body[p.Name] = v
+	}
+	for k, v := range a.fixed {
+		body[k] = v
+	}
+	if a.csrf {
+		if tok, ok := params["csrf"].(string); ok {
+			body["csrf"] = tok
+		}
+	}
+	return body, nil
+}
+
+func checkType(p Param, v any) error {
+	var ok bool
+	switch p.Type {
+	case TypeNumber:
+		_, ok = v.(float64)
+		if !ok {
+			_, ok = v.(int)
+		}
+	case TypeBool:
+		_, ok = v.(bool)
+	default:
+		var s string
+		s, ok = v.(string)
+		if ok && len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+			return fmt.Errorf("%s: %q is not one of %v", p.Name, s, p.Enum)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%s: want %s", p.Name, p.Type)
+	}
+	return nil
+}
+
+// NewRequest builds the route request for a server-side action.
+func NewRequest(ctx context.Context, a Action, params map[string]any) (*http.Request, error) {
+	if a.Path == "" {
+		return nil, fmt.Errorf("action %s runs in the browser (%s)", a.ID, a.Client)
+	}
+	body, err := a.Body(params)
+	if err != nil {
+		return nil, err
+	}
+	if a.Method == http.MethodGet {
+		req, err := http.NewRequestWithContext(ctx, a.Method, a.Path, nil)
+		if err != nil {
+			return nil, err
+		}
+		q := req.URL.Query()
+		for k, v := range body {
+			q.Set(k, fmt.Sprint(v))
+		}
+		req.URL.RawQuery = q.Encode()
+		return req, nil
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, a.Method, a.Path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
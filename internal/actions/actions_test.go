@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestCatalogIDsUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, a := range All() {
+		if seen[a.ID] {
+			t.Errorf("duplicate action %s", a.ID)
+		}
+		seen[a.ID] = true
+		if (a.Path == "") == (a.Client == "") {
+			t.Errorf("%s: needs exactly one of Path or Client", a.ID)
+		}
+	}
+}
+
+func TestBody(t *testing.T) {
+	a, _ := Get("listen.pause")
+	body, err := a.Body(map[string]any{"action": "play", "extra": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["action"] != "pause" || body["extra"] != nil {
+		t.Fatalf("body = %v", body)
+	}
+
+	fav, _ := Get("peers.favorite")
+	if _, err := fav.Body(map[string]any{"peer_id": "p1"}); err == nil {
+		t.Error("missing required param accepted")
+	}
+	if _, err := fav.Body(map[string]any{"peer_id": "p1", "favorite": "yes"}); err == nil {
+		t.Error("string accepted for bool param")
+	}
+
+	theme, _ := Get("settings.theme")
+	if _, err := theme.Body(map[string]any{"theme": "blue"}); err == nil {
+		t.Error("value outside enum accepted")
+	}
+}
+
+func TestNewRequestClientAction(t *testing.T) {
+	a, _ := Get("call.start")
+	if _, err := NewRequest(context.Background(), a, map[string]any{"peer_id": "p1"}); err == nil {
+		t.Error("client action built a server request")
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	d := NewDispatcher()
+	if _, err := d.Run(context.Background(), "listen.play", nil); err != ErrNotReady {
+		t.Fatalf("err = %v, want ErrNotReady", err)
+	}
+
+	var got map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/listen/control", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/api/peers/favorite", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "peer_id required", http.StatusBadRequest)
+	})
+	d.SetHandler(mux)
+
+	out, err := d.Run(context.Background(), "listen.play", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["action"] != "play" || out.(map[string]any)["status"] != "ok" {
+		t.Fatalf("sent %v, got %v", got, out)
+	}
+
+	if _, err := d.Run(context.Background(), "peers.favorite", map[string]any{"peer_id": "p", "favorite": true}); err == nil {
+		t.Error("route error not returned")
+	}
+	if _, err := d.Run(context.Background(), "templates.apply", map[string]any{"template": "blog"}); err == nil {
+		t.Error("CSRF-protected action ran outside the viewer")
+	}
+}
@@ -0,0 +1,133 @@
+package actions
+
+import "net/http"
+
+// catalog is every action, in the order palettes show them. Each entry
+// mirrors the body its route decodes; keep them in step when a route changes.
+var catalog = []Action{
+	// ── Calls ──
+	{
+		ID: "call.start", Title: "Call peer", Category: "call", Client: "call.start",
+		Description: "Start a call with the browser or native call stack, whichever is active.",
+		Params: []Param{
+			{Name: "peer_id", Type: TypePeer, Required: true},
+			{Name: "video", Type: TypeBool, Description: "Video call; audio only when false"},
+		},
+	},
+	{
+		ID: "call.history.seen", Title: "Mark missed calls seen", Category: "call",
+		Method: http.MethodPost, Path: "/api/call/history/seen",
+	},
+
+	// ── Peers ──
+	{
+		ID: "peers.probe", Title: "Probe all peers", Category: "peers",
+		Description: "Check which peers are reachable right now.",
+		Method:      http.MethodPost, Path: "/api/peers/probe", Script: true,
+	},
+	{
+		ID: "peers.favorite", Title: "Favorite peer", Category: "peers",
+		Method: http.MethodPost, Path: "/api/peers/favorite", Script: true,
+		Params: []Param{
+			{Name: "peer_id", Type: TypePeer, Required: true},
+			{Name: "favorite", Type: TypeBool, Required: true},
+		},
+	},
+	{
+		ID: "peers.forget", Title: "Forget peer", Category: "peers", Confirm: true,
+		Description: "Delete everything stored about a peer: cached presence, avatar, chat and call history.",
+		Method:      http.MethodPost, Path: "/api/peers/forget",
+		Params: []Param{{Name: "peer_id", Type: TypePeer, Required: true}},
+	},
+
+	// ── Groups ──
+	{
+		ID: "groups.create", Title: "Create group", Category: "groups",
+		Method: http.MethodPost, Path: "/api/groups",
+		Params: []Param{
+			{Name: "name", Type: TypeString, Required: true},
+			{Name: "group_type", Type: TypeString, Description: "Registered group type, e.g. files, cluster, listen"},
+			{Name: "group_context", Type: TypeString},
+			{Name: "max_members", Type: TypeNumber, Description: "0 = unlimited"},
+		},
+	},
+	{
+		ID: "groups.invite", Title: "Invite peer to group", Category: "groups",
+		Method: http.MethodPost, Path: "/api/groups/invite",
+		Params: []Param{
+			{Name: "group_id", Type: TypeGroup, Required: true},
+			{Name: "peer_id", Type: TypePeer, Required: true},
+		},
+	},
+	{
+		ID: "groups.leave", Title: "Leave group", Category: "groups",
+		Method: http.MethodPost, Path: "/api/groups/leave",
+		Params: []Param{{Name: "group_id", Type: TypeGroup, Required: true}},
+	},
+	{
+		ID: "groups.close", Title: "Close hosted group", Category: "groups", Confirm: true,
+		Method: http.MethodPost, Path: "/api/groups/close",
+		Params: []Param{{Name: "group_id", Type: TypeGroup, Required: true}},
+	},
+
+	// ── Chat rooms ──
+	{
+		ID: "chat.rooms.create", Title: "Create chat room", Category: "chat",
+		Method: http.MethodPost, Path: "/api/chat/rooms/create",
+		Params: []Param{
+			{Name: "name", Type: TypeString, Required: true},
+			{Name: "description", Type: TypeString},
+			{Name: "max_members", Type: TypeNumber, Description: "0 = unlimited"},
+		},
+	},
+	{
+		ID: "chat.rooms.send", Title: "Send to chat room", Category: "chat",
+		Method: http.MethodPost, Path: "/api/chat/rooms/send",
+		Params: []Param{
+			{Name: "group_id", Type: TypeGroup, Required: true},
+			{Name: "text", Type: TypeString, Required: true},
+		},
+	},
+
+	// ── Listen ──
+	{
+		ID: "listen.create", Title: "Open listening room", Category: "listen",
+		Method: http.MethodPost, Path: "/api/listen/create",
+		Params: []Param{{Name: "name", Type: TypeString}},
+	},
+	{
+		ID: "listen.play", Title: "Play", Category: "listen", Script: true,
+		Method: http.MethodPost, Path: "/api/listen/control", fixed: map[string]any{"action": "play"},
+	},
+	{
+		ID: "listen.pause", Title: "Pause", Category: "listen", Script: true,
+		Method: http.MethodPost, Path: "/api/listen/control", fixed: map[string]any{"action": "pause"},
+	},
+	{
+		ID: "listen.next", Title: "Next track", Category: "listen", Script: true,
+		Method: http.MethodPost, Path: "/api/listen/control", fixed: map[string]any{"action": "next"},
+	},
+	{
+		ID: "listen.prev", Title: "Previous track", Category: "listen", Script: true,
+		Method: http.MethodPost, Path: "/api/listen/control", fixed: map[string]any{"action": "prev"},
+	},
+	{
+		ID: "listen.close", Title: "Close listening room", Category: "listen", Confirm: true,
+		Method: http.MethodPost, Path: "/api/listen/close",
+	},
+
+	// ── Site ──
+	{
+		ID: "templates.apply", Title: "Apply template", Category: "site", Confirm: true,
+		Description: "Replace the site and its database with a built-in template.",
+		Method:      http.MethodPost, Path: "/api/templates/apply", csrf: true,
+		Params: []Param{{Name: "template", Type: TypeString, Required: true, Description: "Built-in template directory name"}},
+	},
+
+	// ── Settings ──
+	{
+		ID: "settings.theme", Title: "Switch theme", Category: "settings",
+		Method: http.MethodPost, Path: "/api/settings/quick",
+		Params: []Param{{Name: "theme", Type: TypeString, Required: true, Enum: []string{"dark", "light"}}},
+	},
+}
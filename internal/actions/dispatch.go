@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNotReady is returned by Run before the viewer has handed over its routes.
+var ErrNotReady = errors.New("actions: viewer not started")
+
+// Dispatcher runs actions in-process against the viewer's routes, as the
+// local user. It exists before the viewer does (Lua is wired up first), so
+// the handler is set once the mux is built.
+type Dispatcher struct {
+	mu sync.RWMutex
+	h  http.Handler
+}
+
+// NewDispatcher creates a dispatcher with no handler yet.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// SetHandler gives the dispatcher the viewer mux.
+func (d *Dispatcher) SetHandler(h http.Handler) {
+	d.mu.Lock()
+	d.h = h
+	d.mu.Unlock()
+}
+
+// Run invokes action id with params and returns its decoded JSON response
+// (nil for an empty body). Route errors come back as errors carrying the
+// route's message.
+func (d *Dispatcher) Run(ctx context.Context, id string, params map[string]any) (any, error) {
+	d.mu.RLock()
+	h := d.h
+	d.mu.RUnlock()
+	if h == nil {
+		return nil, ErrNotReady
+	}
+	a, ok := Get(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q", id)
+	}
+	if a.csrf {
+		return nil, fmt.Errorf("action %s can only run from the viewer", id)
+	}
+	req, err := NewRequest(ctx, a, params)
+	if err != nil {
+		return nil, err
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+
+	rec := &recorder{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(rec, req)
+	if rec.status >= 400 {
+		return nil, fmt.Errorf("%s: %s", id, strings.TrimSpace(rec.body.String()))
+	}
+	if rec.body.Len() == 0 {
+		return nil, nil
+	}
+	var out any
+	if err := json.Unmarshal(rec.body.Bytes(), &out); err != nil {
+		return rec.body.String(), nil
+	}
+	return out, nil
+}
+
+// recorder is a minimal in-memory http.ResponseWriter.
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status, r.wroteHeader = status, true
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
@@ -0,0 +1,83 @@
+package control
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app/localapi"
+)
+
+// clientTimeout covers the slowest operation, a group join.
+const clientTimeout = 2 * time.Minute
+
+// Client talks to a running peer's control API.
+type Client struct {
+	api *localapi.Client
+}
+
+// NewClient connects to the peer in peerDir: over addr when it is set,
+// otherwise over the peer's control socket. The token is read from the
+// peer's token file.
+func NewClient(peerDir, addr string) (*Client, error) {
+	b, err := os.ReadFile(TokenPath(peerDir))
+	if err != nil {
+		return nil, fmt.Errorf("control token (is control.enabled set and the peer started?): %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	return &Client{api: localapi.NewClient("peer", SocketPath(peerDir), addr, token, clientTimeout)}, nil
+}
+
+func (c *Client) do(method, path string, in, out any) error {
+	return c.api.Do(method, path, in, out)
+}
+
+// Status describes the peer.
+func (c *Client) Status() (Status, error) {
+	var out Status
+	return out, c.do("GET", "/v1/status", nil, &out)
+}
+
+// Peers lists the peers seen.
+func (c *Client) Peers() ([]PeerInfo, error) {
+	var out []PeerInfo
+	return out, c.do("GET", "/v1/peers", nil, &out)
+}
+
+// Groups lists hosted and joined groups.
+func (c *Client) Groups() ([]GroupInfo, error) {
+	var out []GroupInfo
+	return out, c.do("GET", "/v1/groups", nil, &out)
+}
+
+// JoinGroup joins a group hosted by another peer.
+func (c *Client) JoinGroup(hostPeerID, groupID string) error {
+	return c.do("POST", "/v1/groups/join", JoinRequest{HostPeerID: hostPeerID, GroupID: groupID}, nil)
+}
+
+// LeaveGroup leaves a joined group.
+func (c *Client) LeaveGroup(groupID string) error {
+	return c.do("POST", "/v1/groups/leave", GroupRequest{GroupID: groupID}, nil)
+}
+
+// SendGroup sends payload to a hosted or joined group.
+func (c *Client) SendGroup(groupID string, payload any) error {
+	return c.do("POST", "/v1/groups/send", GroupSendRequest{GroupID: groupID, Payload: payload}, nil)
+}
+
+// SendChat sends a direct chat message.
+func (c *Client) SendChat(peerID, text string) error {
+	return c.do("POST", "/v1/chat/send", ChatRequest{PeerID: peerID, Text: text}, nil)
+}
+
+// SendMQ sends an MQ message and waits for its delivery.
+func (c *Client) SendMQ(peerID, topic string, payload any) (MQResult, error) {
+	var out MQResult
+	return out, c.do("POST", "/v1/mq/send", MQRequest{PeerID: peerID, Topic: topic, Payload: payload}, &out)
+}
+
+// Publish announces the peer's presence now.
+func (c *Client) Publish() error {
+	return c.do("POST", "/v1/presence/publish", struct{}{}, nil)
+}
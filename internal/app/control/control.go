@@ -0,0 +1,315 @@
+// Package control is the control API of a peer: a small, versioned JSON
+// API for scripting a headless peer (goop2 ctl), apart from the viewer's
+// browser API. Requests and responses are plain structs defined here, so
+// they stay stable when the viewer changes.
+//
+// It listens on data/control.sock in the peer directory and, when
+// configured, on a loopback TCP address. Every request carries the bearer
+// token kept in data/control.token, which is created on first start and
+// readable only by the peer's user.
+//
+//	GET  /v1/status            the peer itself
+//	GET  /v1/peers             peers seen
+//	GET  /v1/groups            hosted and joined groups
+//	POST /v1/groups/join       {host_peer_id, group_id}
+//	POST /v1/groups/leave      {group_id}
+//	POST /v1/groups/send       {group_id, payload}
+//	POST /v1/chat/send         {peer_id, text}
+//	POST /v1/mq/send           {peer_id, topic, payload}
+//	POST /v1/presence/publish  announce presence now
+package control
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/app/localapi"
+)
+
+const (
+	// SocketName and TokenName live in the peer's data directory.
+	SocketName = "control.sock"
+	TokenName  = "control.token"
+
+	tokenPrefix = "goopctl_" // makes tokens recognisable in logs and secret scanners
+
+	maxBody = 1 << 20
+)
+
+// SocketPath is the control socket of the peer in peerDir.
+func SocketPath(peerDir string) string { return filepath.Join(peerDir, "data", SocketName) }
+
+// TokenPath is the control token file of the peer in peerDir.
+func TokenPath(peerDir string) string { return filepath.Join(peerDir, "data", TokenName) }
+
+// Status describes the peer.
+type Status struct {
+	PeerID      string   `json:"peer_id"`
+	Label       string   `json:"label"`
+	Version     string   `json:"version"`
+	Addrs       []string `json:"addrs"`
+	PeersOnline int      `json:"peers_online"`
+	Started     int64    `json:"started"` // unix millis
+}
+
+// PeerInfo is a peer this peer has seen.
+type PeerInfo struct {
+	PeerID    string `json:"peer_id"`
+	Label     string `json:"label"`
+	Reachable bool   `json:"reachable"`
+	Online    bool   `json:"online"`
+	Verified  bool   `json:"verified"`
+	Favorite  bool   `json:"favorite"`
+	LastSeen  int64  `json:"last_seen"` // unix millis
+}
+
+// GroupInfo is a group this peer hosts or has joined.
+type GroupInfo struct {
+	GroupID    string `json:"group_id"`
+	Name       string `json:"name,omitempty"`
+	HostPeerID string `json:"host_peer_id"`
+	GroupType  string `json:"group_type"`
+	Hosted     bool   `json:"hosted"`
+	Connected  bool   `json:"connected"` // joined and currently connected
+}
+
+// JoinRequest is the body of POST /v1/groups/join.
+type JoinRequest struct {
+	HostPeerID string `json:"host_peer_id"`
+	GroupID    string `json:"group_id"`
+}
+
+// GroupRequest is the body of POST /v1/groups/leave.
+type GroupRequest struct {
+	GroupID string `json:"group_id"`
+}
+
+// GroupSendRequest is the body of POST /v1/groups/send.
+type GroupSendRequest struct {
+	GroupID string `json:"group_id"`
+	Payload any    `json:"payload"`
+}
+
+// ChatRequest is the body of POST /v1/chat/send.
+type ChatRequest struct {
+	PeerID string `json:"peer_id"`
+	Text   string `json:"text"`
+}
+
+// MQRequest is the body of POST /v1/mq/send.
+type MQRequest struct {
+	PeerID  string `json:"peer_id"`
+	Topic   string `json:"topic"`
+	Payload any    `json:"payload"`
+}
+
+// MQResult is the answer to POST /v1/mq/send: the message ID, delivered.
+type MQResult struct {
+	ID string `json:"id"`
+}
+
+// OK is the answer of actions without a result.
+type OK struct {
+	OK bool `json:"ok"`
+}
+
+// Error is the body of every failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Ops are the peer operations behind the API; the run mode wires them to
+// the running peer. Nil operations answer 501.
+type Ops struct {
+	Status     func() Status
+	Peers      func() []PeerInfo
+	Groups     func() ([]GroupInfo, error)
+	JoinGroup  func(ctx context.Context, hostPeerID, groupID string) error
+	LeaveGroup func(groupID string) error
+	SendGroup  func(groupID string, payload any) error
+	SendChat   func(ctx context.Context, peerID, text string) error
+	SendMQ     func(ctx context.Context, peerID, topic string, payload any) (string, error)
+	Publish    func(ctx context.Context)
+}
+
+// errBadRequest marks a request the client got wrong.
+type errBadRequest string
+
+func (e errBadRequest) Error() string { return string(e) }
+
+var errNotWired = errors.New("not available on this peer")
+
+// Handler serves the API with ops, behind the bearer token.
+func Handler(ops Ops, token string) http.Handler {
+	mux := http.NewServeMux()
+	get := func(path string, fn func() (any, error)) {
+		mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+			v, err := fn()
+			writeResult(w, v, err)
+		})
+	}
+	get("/v1/status", func() (any, error) {
+		if ops.Status == nil {
+			return nil, errNotWired
+		}
+		return ops.Status(), nil
+	})
+	get("/v1/peers", func() (any, error) {
+		if ops.Peers == nil {
+			return nil, errNotWired
+		}
+		return ops.Peers(), nil
+	})
+	get("/v1/groups", func() (any, error) {
+		if ops.Groups == nil {
+			return nil, errNotWired
+		}
+		return ops.Groups()
+	})
+
+	post(mux, "/v1/groups/join", func(ctx context.Context, req JoinRequest) (any, error) {
+		if req.HostPeerID == "" || req.GroupID == "" {
+			return nil, errBadRequest("host_peer_id and group_id are required")
+		}
+		if ops.JoinGroup == nil {
+			return nil, errNotWired
+		}
+		return OK{true}, ops.JoinGroup(ctx, req.HostPeerID, req.GroupID)
+	})
+	post(mux, "/v1/groups/leave", func(ctx context.Context, req GroupRequest) (any, error) {
+		if req.GroupID == "" {
+			return nil, errBadRequest("group_id is required")
+		}
+		if ops.LeaveGroup == nil {
+			return nil, errNotWired
+		}
+		return OK{true}, ops.LeaveGroup(req.GroupID)
+	})
+	post(mux, "/v1/groups/send", func(ctx context.Context, req GroupSendRequest) (any, error) {
+		if req.GroupID == "" {
+			return nil, errBadRequest("group_id is required")
+		}
+		if ops.SendGroup == nil {
+			return nil, errNotWired
+		}
+		return OK{true}, ops.SendGroup(req.GroupID, req.Payload)
+	})
+	post(mux, "/v1/chat/send", func(ctx context.Context, req ChatRequest) (any, error) {
+		if req.PeerID == "" || strings.TrimSpace(req.Text) == "" {
+			return nil, errBadRequest("peer_id and text are required")
+		}
+		if ops.SendChat == nil {
+			return nil, errNotWired
+		}
+		return OK{true}, ops.SendChat(ctx, req.PeerID, req.Text)
+	})
+	post(mux, "/v1/mq/send", func(ctx context.Context, req MQRequest) (any, error) {
+		if req.PeerID == "" || req.Topic == "" {
+			return nil, errBadRequest("peer_id and topic are required")
+		}
+		if ops.SendMQ == nil {
+			return nil, errNotWired
+		}
+		id, err := ops.SendMQ(ctx, req.PeerID, req.Topic, req.Payload)
+		return MQResult{ID: id}, err
+	})
+	post(mux, "/v1/presence/publish", func(ctx context.Context, _ struct{}) (any, error) {
+		if ops.Publish == nil {
+			return nil, errNotWired
+		}
+		ops.Publish(ctx)
+		return OK{true}, nil
+	})
+	return localapi.RequireToken(token, mux, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(Error{"unauthorized"})
+	})
+}
+
+// post registers a POST handler that decodes a JSON body into Req.
+func post[Req any](mux *http.ServeMux, path string, fn func(context.Context, Req) (any, error)) {
+	mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&req); err != nil {
+				writeResult(w, nil, errBadRequest("invalid JSON body: "+err.Error()))
+				return
+			}
+		}
+		v, err := fn(r.Context(), req)
+		writeResult(w, v, err)
+	})
+}
+
+func writeResult(w http.ResponseWriter, v any, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	var bad errBadRequest
+	switch {
+	case errors.As(err, &bad):
+		w.WriteHeader(http.StatusBadRequest)
+		v = Error{err.Error()}
+	case errors.Is(err, errNotWired):
+		w.WriteHeader(http.StatusNotImplemented)
+		v = Error{err.Error()}
+	case err != nil:
+		w.WriteHeader(http.StatusBadGateway)
+		v = Error{err.Error()}
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// LoadOrCreateToken returns the peer's control token, creating the token
+// file on first use.
+func LoadOrCreateToken(peerDir string) (string, error) {
+	path := TokenPath(peerDir)
+	b, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(b)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(secret)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Listen serves ops on the peer's control socket and, when addr is set, on
+// that loopback TCP address.
+func Listen(peerDir, addr string, ops Ops) (*localapi.Server, error) {
+	token, err := LoadOrCreateToken(peerDir)
+	if err != nil {
+		return nil, fmt.Errorf("control token: %w", err)
+	}
+	h := Handler(ops, token)
+	s, err := localapi.Listen("CONTROL: API", "peer", SocketPath(peerDir), h)
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if addr != "" {
+		if err := s.ListenTCP(addr, h); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("control addr: %w", err)
+		}
+	}
+	return s, nil
+}
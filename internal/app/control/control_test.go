@@ -0,0 +1,74 @@
+package control
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app/localapi"
+)
+
+func TestControlAPI(t *testing.T) {
+	dir := t.TempDir()
+	var chat []string
+	srv, err := Listen(dir, "127.0.0.1:0", Ops{
+		Status: func() Status { return Status{PeerID: "self", Label: "bot"} },
+		SendChat: func(_ context.Context, peerID, text string) error {
+			chat = append(chat, peerID+"|"+text)
+			return nil
+		},
+		SendMQ: func(_ context.Context, peerID, topic string, payload any) (string, error) {
+			return "m1", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	if st, err := os.Stat(TokenPath(dir)); err != nil || st.Mode().Perm() != 0o600 {
+		t.Fatalf("token file: %v, %v", st, err)
+	}
+
+	c, err := NewClient(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err := c.Status(); err != nil || st.PeerID != "self" {
+		t.Fatalf("Status = %+v, %v", st, err)
+	}
+	if err := c.SendChat("p1", "hello"); err != nil || len(chat) != 1 || chat[0] != "p1|hello" {
+		t.Fatalf("SendChat: %v, %v", err, chat)
+	}
+	if err := c.SendChat("p1", " "); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Fatalf("empty chat: %v", err)
+	}
+	if res, err := c.SendMQ("p1", "bot.ping", map[string]any{"n": 1}); err != nil || res.ID != "m1" {
+		t.Fatalf("SendMQ = %+v, %v", res, err)
+	}
+	if err := c.JoinGroup("host", "g1"); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("unwired join: %v", err)
+	}
+
+	// A wrong token is refused, on the socket and on TCP alike.
+	bad := &Client{api: localapi.NewClient("peer", SocketPath(dir), "", "goopctl_wrong", time.Second)}
+	if _, err := bad.Status(); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("wrong token: %v", err)
+	}
+	resp, err := http.Get("http://" + srv.Addr() + "/v1/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("TCP without token: %s", resp.Status)
+	}
+
+	// A second peer on the same directory is refused.
+	if _, err := Listen(dir, "", Ops{}); err == nil {
+		t.Fatal("second listener on a live socket")
+	}
+}
@@ -0,0 +1,120 @@
+// Package doctor checks a peer's state on disk when it starts, before the
+// services that read it, and repairs what it safely can: leftovers of a run
+// that was killed or crashed, or of one interrupted halfway through a
+// change. After an unclean shutdown it also runs the slower checks.
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Check statuses.
+const (
+	StatusOK       = "ok"       // nothing to do
+	StatusRepaired = "repaired" // found problems and fixed them
+	StatusFailed   = "failed"   // found a problem it could not fix, or could not check
+	StatusSkipped  = "skipped"  // only runs after an unclean shutdown
+)
+
+// ErrSkipped is returned by a check that did not run.
+var ErrSkipped = errors.New("skipped")
+
+// Check inspects one kind of state. deep is set after an unclean shutdown;
+// slow checks return ErrSkipped without it. A check returns how many items
+// it repaired and a short description of what it found. An error means the
+// state is still not right.
+type Check func(deep bool) (repaired int, detail string, err error)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Repaired   int    `json:"repaired,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report describes a finished Run.
+type Report struct {
+	At         int64    `json:"at"`          // Unix ms
+	FirstStart bool     `json:"first_start"` // no shutdown marker: a new peer directory
+	Unclean    bool     `json:"unclean"`     // the previous run did not finish its shutdown
+	Healthy    bool     `json:"healthy"`     // no check failed
+	Repaired   int      `json:"repaired"`    // items repaired by all checks
+	ElapsedMs  int64    `json:"elapsed_ms"`
+	Checks     []Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Doctor runs the startup checks in the order they were added.
+type Doctor struct {
+	checks []namedCheck
+}
+
+// New creates a doctor without checks.
+func New() *Doctor {
+	return &Doctor{}
+}
+
+// Add registers a check under name.
+func (d *Doctor) Add(name string, c Check) {
+	d.checks = append(d.checks, namedCheck{name: name, check: c})
+}
+
+// Run executes every check; deep checks run when the previous shutdown was
+// unclean. A panicking check is reported as failed.
+func (d *Doctor) Run(firstStart, unclean bool) Report {
+	start := time.Now()
+	rep := Report{At: start.UnixMilli(), FirstStart: firstStart, Unclean: unclean, Healthy: true}
+	for _, c := range d.checks {
+		res := run(c, unclean)
+		switch res.Status {
+		case StatusFailed:
+			rep.Healthy = false
+			log.Printf("DOCTOR: %s: %s", res.Name, res.Detail)
+		case StatusRepaired:
+			log.Printf("DOCTOR: %s: repaired %d (%s)", res.Name, res.Repaired, res.Detail)
+		}
+		rep.Repaired += res.Repaired
+		rep.Checks = append(rep.Checks, res)
+	}
+	rep.ElapsedMs = time.Since(start).Milliseconds()
+	return rep
+}
+
+func run(c namedCheck, deep bool) (res Result) {
+	res.Name = c.name
+	t0 := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			res.Status, res.Detail = StatusFailed, fmt.Sprintf("panic: %v", r)
+		}
+		res.DurationMs = time.Since(t0).Milliseconds()
+	}()
+
+	n, detail, err := c.check(deep)
+	res.Repaired, res.Detail = n, detail
+	switch {
+	case errors.Is(err, ErrSkipped):
+		res.Status = StatusSkipped
+	case err != nil:
+		res.Status = StatusFailed
+		if detail == "" {
+			res.Detail = err.Error()
+		} else {
+			res.Detail = detail + ": " + err.Error()
+		}
+	case n > 0:
+		res.Status = StatusRepaired
+	default:
+		res.Status = StatusOK
+	}
+	return res
+}
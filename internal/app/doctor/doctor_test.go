@@ -0,0 +1,57 @@
+package doctor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	d := New()
+	var deepSeen bool
+	d.Add("fine", func(bool) (int, string, error) { return 0, "", nil })
+	d.Add("fixed", func(bool) (int, string, error) { return 3, "3 stale rows", nil })
+	d.Add("slow", func(deep bool) (int, string, error) {
+		deepSeen = deep
+		if !deep {
+			return 0, "", ErrSkipped
+		}
+		return 0, "", nil
+	})
+
+	rep := d.Run(false, false)
+	if !rep.Healthy || rep.Repaired != 3 || deepSeen {
+		t.Fatalf("report = %+v", rep)
+	}
+	want := []string{StatusOK, StatusRepaired, StatusSkipped}
+	for i, c := range rep.Checks {
+		if c.Status != want[i] {
+			t.Errorf("%s = %s, want %s", c.Name, c.Status, want[i])
+		}
+	}
+
+	rep = d.Run(false, true)
+	if !deepSeen || rep.Checks[2].Status != StatusOK || !rep.Unclean {
+		t.Errorf("deep run = %+v", rep)
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	d := New()
+	d.Add("broken", func(bool) (int, string, error) { return 0, "integrity check", errors.New("page 4") })
+	d.Add("panics", func(bool) (int, string, error) { panic("boom") })
+	d.Add("after", func(bool) (int, string, error) { return 0, "", nil })
+
+	rep := d.Run(true, false)
+	if rep.Healthy || !rep.FirstStart || len(rep.Checks) != 3 {
+		t.Fatalf("report = %+v", rep)
+	}
+	if c := rep.Checks[0]; c.Status != StatusFailed || c.Detail != "integrity check: page 4" {
+		t.Errorf("broken = %+v", c)
+	}
+	if c := rep.Checks[1]; c.Status != StatusFailed || c.Detail != "panic: boom" {
+		t.Errorf("panics = %+v", c)
+	}
+	if rep.Checks[2].Status != StatusOK {
+		t.Errorf("after = %+v", rep.Checks[2])
+	}
+}
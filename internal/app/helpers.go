@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/secrets"
 	"github.com/petervdpas/goop2/internal/util"
 )
 
@@ -33,3 +37,31 @@ func logBanner(peerDir, cfgPath string) {
 	log.Println(" Different folder/config = different peer.")
 	log.Println("────────────────────────────────────────")
 }
+
+// KeyFiles returns the private key files of a peer: the identity key and,
+// when configured, the relay key. The files need not exist yet. The
+// secrets master key is listed once secrets are in use, and the database
+// key with passphrase storage encryption.
+func KeyFiles(peerDir string, cfg config.Config) []string {
+	files := []string{util.ResolvePath(peerDir, cfg.Identity.KeyFile)}
+	if cfg.Presence.RelayKeyFile != "" {
+		files = append(files, util.ResolvePath(peerDir, cfg.Presence.RelayKeyFile))
+	}
+	if key := secrets.KeyPath(SecretsDir(peerDir)); fileExists(key) {
+		files = append(files, key)
+	}
+	if cfg.Storage.Encryption == "passphrase" {
+		files = append(files, util.ResolvePath(peerDir, cfg.Storage.KeyFile))
+	}
+	return files
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// SecretsDir is where a peer keeps its secrets (see package secrets).
+func SecretsDir(peerDir string) string {
+	return filepath.Join(peerDir, "data")
+}
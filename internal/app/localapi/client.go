@@ -0,0 +1,82 @@
+package localapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client calls a local API over TCP or its Unix socket.
+type Client struct {
+	name  string // what answers, for errors: "peer", "daemon"
+	base  string
+	token string
+	http  *http.Client
+}
+
+// NewClient reaches name over addr when it is set, otherwise over socket.
+// An empty token sends no Authorization header.
+func NewClient(name, socket, addr, token string, timeout time.Duration) *Client {
+	c := &Client{name: name, token: token, http: &http.Client{Timeout: timeout}}
+	if addr != "" {
+		c.base = "http://" + addr
+		return c
+	}
+	c.base = "http://localapi"
+	c.http.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return c
+}
+
+// Do sends in as JSON (when not nil) and decodes a 200 answer into out
+// (when not nil). Other answers become an error carrying the "error" field
+// of a JSON body, or the body itself.
+func (c *Client) Do(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s not reachable: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(b, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(b))
+		}
+		return fmt.Errorf("%s: %s", c.name, e.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
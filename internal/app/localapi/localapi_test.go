@@ -0,0 +1,58 @@
+package localapi
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerAndClient(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"n":1}`)) })
+	mux.HandleFunc("GET /json-error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"bad thing"}`))
+	})
+	mux.HandleFunc("GET /text-error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "plain thing", http.StatusNotFound)
+	})
+	h := RequireToken("t0k", mux, nil)
+
+	s, err := Listen("TEST: API", "tester", socket, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.ListenTCP("127.0.0.1:0", h); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, addr := range []string{"", s.Addr()} {
+		c := NewClient("tester", socket, addr, "t0k", time.Second)
+		var out struct{ N int }
+		if err := c.Do("GET", "/ok", nil, &out); err != nil || out.N != 1 {
+			t.Errorf("addr %q: Do = %+v, %v", addr, out, err)
+		}
+		if err := c.Do("GET", "/json-error", nil, nil); err == nil || err.Error() != "tester: bad thing" {
+			t.Errorf("addr %q: JSON error = %v", addr, err)
+		}
+		if err := c.Do("GET", "/text-error", nil, nil); err == nil || err.Error() != "tester: plain thing" {
+			t.Errorf("addr %q: text error = %v", addr, err)
+		}
+		bad := NewClient("tester", socket, addr, "wrong", time.Second)
+		if err := bad.Do("GET", "/ok", nil, nil); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+			t.Errorf("addr %q: wrong token = %v", addr, err)
+		}
+	}
+
+	if _, err := Listen("TEST: API", "tester", socket, h); err == nil || !strings.Contains(err.Error(), "a tester is already running") {
+		t.Errorf("second listener = %v", err)
+	}
+	s.Close()
+	if err := NewClient("tester", socket, "", "t0k", time.Second).Do("GET", "/ok", nil, nil); err == nil || !strings.Contains(err.Error(), "tester not reachable") {
+		t.Errorf("after Close = %v", err)
+	}
+}
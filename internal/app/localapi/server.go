@@ -0,0 +1,110 @@
+// Package localapi is what the peer's control API and the daemon's admin
+// API have in common: a Unix socket readable only by its owner, an optional
+// loopback TCP listener behind a bearer token, and a client that reaches
+// either.
+package localapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Server is a running local API.
+type Server struct {
+	label   string // log prefix, e.g. "CONTROL: API"
+	servers []*http.Server
+	socket  string
+	addr    string // of the TCP listener, if any
+}
+
+// Listen serves h on a Unix socket of mode 0600. owner names what runs
+// behind it ("peer", "daemon") in the error when one already does.
+func Listen(label, owner, socket string, h http.Handler) (*Server, error) {
+	if err := RemoveStaleSocket(socket, owner); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Chmod(socket, 0o600)
+	s := &Server{label: label, socket: socket}
+	s.serve(ln, h)
+	log.Printf("%s on %s", label, socket)
+	return s, nil
+}
+
+// ListenTCP also serves h on the loopback address addr. On failure the
+// caller still owns s and should Close it.
+func (s *Server) ListenTCP(addr string, h http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.addr = ln.Addr().String()
+	s.serve(ln, h)
+	log.Printf("%s on http://%s", s.label, s.addr)
+	return nil
+}
+
+// Addr is the address of the TCP listener, or "" without one.
+func (s *Server) Addr() string { return s.addr }
+
+func (s *Server) serve(ln net.Listener, h http.Handler) {
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	s.servers = append(s.servers, srv)
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("%s: %v", s.label, err)
+		}
+	}()
+}
+
+// Close stops the listeners and removes the socket.
+func (s *Server) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, srv := range s.servers {
+		_ = srv.Shutdown(ctx)
+	}
+	if s.socket != "" {
+		_ = os.Remove(s.socket)
+	}
+}
+
+// RemoveStaleSocket removes a socket left behind by a process that did not
+// exit cleanly, and refuses to start when an owner still answers on it.
+func RemoveStaleSocket(path, owner string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("a %s is already running on %s", owner, path)
+	}
+	return os.Remove(path)
+}
+
+// RequireToken lets through requests carrying "Authorization: Bearer
+// token". Others get denied, or a plain 401 when denied is nil.
+func RequireToken(token string, next http.Handler, denied http.HandlerFunc) http.Handler {
+	want := []byte("Bearer " + token)
+	if denied == nil {
+		denied = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			denied(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/petervdpas/goop2/internal/app/shared"
+	"github.com/petervdpas/goop2/internal/app/startup"
 	"github.com/petervdpas/goop2/internal/avatar"
 	"github.com/petervdpas/goop2/internal/bridge"
 	"github.com/petervdpas/goop2/internal/config"
@@ -19,7 +20,7 @@ import (
 
 // RunBridge starts goop2 in thin-client mode. No libp2p, no entangler.
 // All traffic flows through the bridge service via WSS.
-func RunBridge(ctx context.Context, o shared.ModeOpts, cfg config.Config, selfContent, selfEmail func() string, progress func(int, int, string)) error {
+func RunBridge(ctx context.Context, o shared.ModeOpts, cfg config.Config, selfContent, selfEmail func() string, stages *startup.Reporter) error {
 	log.Printf("mode: thin-client (bridge)")
 
 	bridgeURL := cfg.Presence.BridgeURL
@@ -59,14 +60,15 @@ func RunBridge(ctx context.Context, o shared.ModeOpts, cfg config.Config, selfCo
 		switch pm.Type {
 		case proto.TypeOnline, proto.TypeUpdate:
 			existing, _ := peers.Get(pm.PeerID)
-			peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, existing.Verified, pm.GoopClientVersion)
+			peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, existing.Verified, pm.GoopClientVersion, p2p.VerifyPresence(pm))
+			peers.SetSiteHash(pm.PeerID, pm.SiteHash)
 			peers.SetReachable(pm.PeerID, true)
 		case proto.TypeOffline:
 			peers.MarkOffline(pm.PeerID)
 		}
 	})
 
-	progress(1, 2, "Starting viewer")
+	stages.Begin("viewer", "Starting viewer")
 
 	if cfg.Viewer.HTTPAddr != "" {
 		addr, url, _ := shared.NormalizeLocalViewer(cfg.Viewer.HTTPAddr)
@@ -78,9 +80,11 @@ func RunBridge(ctx context.Context, o shared.ModeOpts, cfg config.Config, selfCo
 			BaseURL:     url,
 			AvatarStore: avatarStore,
 			BridgeURL:   o.BridgeURL,
+			Startup:     func() any { return stages.Report() },
 		})
 		log.Printf("🌉 Thin-client viewer: %s (bridge: %s)", url, bridgeURL)
 	}
+	stages.Finish()
 
 	<-ctx.Done()
 	return nil
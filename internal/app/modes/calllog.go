@@ -0,0 +1,192 @@
+package modes
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// callLog records the call history from the signaling seen on the MQ bus.
+// Both call stacks (browser WebRTC and native Pion) exchange their signals
+// through mq.Manager, so watching inbound call:* messages and the outbound
+// send tap covers every call regardless of which side drives it.
+type callLog struct {
+	db *storage.DB
+	mq *mq.Manager
+
+	mu    sync.Mutex
+	calls map[string]*callLogState // channelID → call not yet ended
+}
+
+type callLogState struct {
+	entry storage.CallLogEntry
+	ring  *time.Timer
+}
+
+func newCallLog(db *storage.DB, mqMgr *mq.Manager) *callLog {
+	return &callLog{db: db, mq: mqMgr, calls: make(map[string]*callLogState)}
+}
+
+// start subscribes to inbound and outbound call signals.
+func (c *callLog) start() (stop func()) {
+	unsubIn := c.mq.SubscribeTopic(mq.TopicCallPrefix, func(from, topic string, payload any) {
+		c.observe(from, topic, payload, false)
+	})
+	unsubOut := c.mq.SubscribeSent(mq.TopicCallPrefix, func(to, topic string, payload any) {
+		c.observe(to, topic, payload, true)
+	})
+	return func() {
+		unsubIn()
+		unsubOut()
+	}
+}
+
+// observe applies one call signal. outbound is true for signals we sent.
+func (c *callLog) observe(peerID, topic string, payload any, outbound bool) {
+	if strings.HasPrefix(topic, mq.TopicCallLoopbackPrefix) {
+		return
+	}
+	channelID := strings.TrimPrefix(topic, mq.TopicCallPrefix)
+	p, _ := payload.(map[string]any)
+	typ, _ := p["type"].(string)
+
+	switch typ {
+	case mq.CallTypeRequest:
+		media, _ := p["mediaType"].(string)
+		c.ringing(channelID, peerID, media, outbound)
+	case mq.CallTypeAck:
+		c.answered(channelID)
+	case mq.CallTypeBusy:
+		// We sent busy: an incoming call we never got to answer.
+		if outbound {
+			c.end(channelID, storage.CallMissed)
+		} else {
+			c.end(channelID, storage.CallBusy)
+		}
+	case mq.CallTypeHangup:
+		c.hangup(channelID, outbound)
+	}
+}
+
+func (c *callLog) ringing(channelID, peerID, media string, outbound bool) {
+	if media == "" {
+		media = "video"
+	}
+	dir := storage.CallIncoming
+	if outbound {
+		dir = storage.CallOutgoing
+	}
+
+	c.mu.Lock()
+	if _, ok := c.calls[channelID]; ok {
+		c.mu.Unlock()
+		return
+	}
+	st := &callLogState{entry: storage.CallLogEntry{
+		ChannelID: channelID,
+		PeerID:    peerID,
+		Direction: dir,
+		Media:     media,
+		Outcome:   storage.CallRinging,
+		StartedAt: time.Now().UnixMilli(),
+	}}
+	timeout := storage.CallMissed
+	if outbound {
+		timeout = storage.CallCancelled
+	}
+	st.ring = time.AfterFunc(CallRingTimeout, func() { c.end(channelID, timeout) })
+	c.calls[channelID] = st
+	entry := st.entry
+	c.mu.Unlock()
+
+	c.save(entry)
+	if !outbound {
+		c.mq.PublishLocal(mq.TopicCallRinging, peerID, map[string]any{
+			"channel_id": channelID,
+			"peer_id":    peerID,
+			"media":      media,
+		})
+	}
+}
+
+func (c *callLog) answered(channelID string) {
+	c.mu.Lock()
+	st, ok := c.calls[channelID]
+	if !ok || st.entry.Outcome != storage.CallRinging {
+		c.mu.Unlock()
+		return
+	}
+	st.ring.Stop()
+	st.entry.Outcome = storage.CallAnswered
+	st.entry.AnsweredAt = time.Now().UnixMilli()
+	entry := st.entry
+	c.mu.Unlock()
+
+	c.save(entry)
+}
+
+// hangup ends a call. Before it was answered the outcome depends on who
+// hung up: the caller giving up is a missed call for the callee and a
+// cancelled one for the caller; the callee hanging up is a decline.
+func (c *callLog) hangup(channelID string, outbound bool) {
+	c.mu.Lock()
+	st, ok := c.calls[channelID]
+	var incoming bool
+	if ok {
+		incoming = st.entry.Direction == storage.CallIncoming
+	}
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	outcome := storage.CallDeclined
+	switch {
+	case incoming && !outbound:
+		outcome = storage.CallMissed
+	case !incoming && outbound:
+		outcome = storage.CallCancelled
+	}
+	c.end(channelID, outcome)
+}
+
+// end finalises a call. Answered calls keep their outcome; outcome applies
+// only to calls that were still ringing.
+func (c *callLog) end(channelID, outcome string) {
+	c.mu.Lock()
+	st, ok := c.calls[channelID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.calls, channelID)
+	st.ring.Stop()
+	if st.entry.Outcome == storage.CallRinging {
+		st.entry.Outcome = outcome
+	}
+	st.entry.EndedAt = time.Now().UnixMilli()
+	entry := st.entry
+	c.mu.Unlock()
+
+	c.save(entry)
+	if entry.Outcome == storage.CallMissed {
+		n, _ := c.db.UnseenMissedCalls()
+		c.mq.PublishLocal(mq.TopicCallMissed, entry.PeerID, map[string]any{
+			"channel_id": entry.ChannelID,
+			"peer_id":    entry.PeerID,
+			"media":      entry.Media,
+			"started_at": entry.StartedAt,
+			"missed":     n,
+		})
+	}
+}
+
+func (c *callLog) save(e storage.CallLogEntry) {
+	if err := c.db.SaveCallLog(e); err != nil {
+		log.Printf("call log: save %s: %v", e.ChannelID, err)
+	}
+}
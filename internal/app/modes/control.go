@@ -0,0 +1,114 @@
+package modes
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app/control"
+	"github.com/petervdpas/goop2/internal/directchat"
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// controlPeer is what the control API drives in a full peer.
+type controlPeer struct {
+	node        *p2p.Node
+	peers       *state.PeerTable
+	groups      *group.Manager
+	mq          *mq.Manager
+	chat        *directchat.Manager
+	selfContent func() string
+	publish     func(ctx context.Context, typ string)
+	version     string
+	started     time.Time
+}
+
+// ops maps the control API onto the peer's managers.
+func (c controlPeer) ops() control.Ops {
+	return control.Ops{
+		Status: func() control.Status {
+			online := 0
+			for _, sp := range c.peers.Snapshot() {
+				if sp.OfflineSince.IsZero() {
+					online++
+				}
+			}
+			return control.Status{
+				PeerID:      c.node.ID(),
+				Label:       c.selfContent(),
+				Version:     c.version,
+				Addrs:       c.node.WanAddrs(),
+				PeersOnline: online,
+				Started:     c.started.UnixMilli(),
+			}
+		},
+		Peers: func() []control.PeerInfo {
+			list := []control.PeerInfo{}
+			for id, sp := range c.peers.Snapshot() {
+				list = append(list, control.PeerInfo{
+					PeerID:    id,
+					Label:     sp.Content,
+					Reachable: sp.Reachable,
+					Online:    sp.OfflineSince.IsZero(),
+					Verified:  sp.Verified,
+					Favorite:  sp.Favorite,
+					LastSeen:  sp.LastSeen.UnixMilli(),
+				})
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].PeerID < list[j].PeerID })
+			return list
+		},
+		Groups: func() ([]control.GroupInfo, error) {
+			hosted, err := c.groups.ListHostedGroups()
+			if err != nil {
+				return nil, err
+			}
+			subs, err := c.groups.ListSubscriptions()
+			if err != nil {
+				return nil, err
+			}
+			list := []control.GroupInfo{}
+			for _, g := range hosted {
+				list = append(list, control.GroupInfo{
+					GroupID: g.ID, Name: g.Name, HostPeerID: c.node.ID(), GroupType: g.GroupType,
+					Hosted: true, Connected: g.HostJoined,
+				})
+			}
+			for _, s := range subs {
+				list = append(list, control.GroupInfo{
+					GroupID: s.GroupID, Name: s.GroupName, HostPeerID: s.HostPeerID, GroupType: s.GroupType,
+					Connected: c.groups.IsGroupConnected(s.GroupID),
+				})
+			}
+			return list, nil
+		},
+		JoinGroup: func(ctx context.Context, hostPeerID, groupID string) error {
+			ctx, cancel := context.WithTimeout(ctx, timeouts.Get().GroupJoin)
+			defer cancel()
+			return c.groups.JoinRemoteGroup(ctx, hostPeerID, groupID)
+		},
+		LeaveGroup: c.groups.LeaveGroup,
+		SendGroup: func(groupID string, payload any) error {
+			if c.groups.IsGroupHost(groupID) {
+				return c.groups.SendToGroupAsHost(groupID, payload)
+			}
+			return c.groups.SendToGroup(groupID, payload)
+		},
+		SendChat: func(ctx context.Context, peerID, text string) error {
+			if _, err := c.mq.Send(ctx, peerID, mq.TopicChat, map[string]any{"content": text}); err != nil {
+				return err
+			}
+			c.chat.PersistOutbound(peerID, text)
+			return nil
+		},
+		SendMQ: c.mq.Send,
+		Publish: func(ctx context.Context) {
+			c.publish(ctx, proto.TypeUpdate)
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package modes
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/keystore"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/util"
+)
+
+// openDatabase opens the peer database, encrypted when storage.encryption
+// asks for it.
+func openDatabase(peerDir string, cfg config.Storage, node *p2p.Node) (*storage.DB, error) {
+	switch cfg.Encryption {
+	case "identity":
+		raw, err := node.Host.Peerstore().PrivKey(node.Host.ID()).Raw()
+		if err != nil {
+			return nil, fmt.Errorf("identity key: %w", err)
+		}
+		return storage.OpenEncrypted(peerDir, storage.IdentityKey(raw))
+	case "passphrase":
+		key, err := databaseKey(util.ResolvePath(peerDir, cfg.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("database key: %w", err)
+		}
+		return storage.OpenEncrypted(peerDir, storage.FileKey(key))
+	}
+	return storage.Open(peerDir)
+}
+
+// databaseKey loads the database key from path through the keystore, so
+// it is unlocked like the other key files. The first start creates it,
+// never in the clear: sealed with the key passphrase or the OS keychain.
+func databaseKey(path string) ([]byte, error) {
+	key, err := keystore.Load(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return key, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	mode, err := keystore.Create(path, key)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("storage: created database key %s (%s)", path, mode)
+	return key, nil
+}
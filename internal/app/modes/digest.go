@@ -0,0 +1,51 @@
+package modes
+
+import (
+	"context"
+	"log"
+
+	"github.com/petervdpas/goop2/internal/group_types/listen"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+)
+
+// digestReports tells the rendezvous servers about events a peer missed
+// while offline — chat messages and group invites we could not deliver —
+// and about the stations we start, so subscribers get them in their email
+// digest. Servers without digests ignore the reports.
+type digestReports struct {
+	clients []*rendezvous.Client
+	selfID  string
+}
+
+// start hooks the MQ failure tap and the listen manager.
+func (d *digestReports) start(ctx context.Context, mqMgr *mq.Manager, listenMgr *listen.Manager) (stop func()) {
+	unsub := mqMgr.SubscribeFailed("", func(to, topic string, _ any) {
+		switch topic {
+		case mq.TopicChat:
+			d.report(ctx, rendezvous.DigestEvent{From: d.selfID, To: to, Kind: rendezvous.DigestKindChat})
+		case mq.TopicGroupInvite:
+			d.report(ctx, rendezvous.DigestEvent{From: d.selfID, To: to, Kind: rendezvous.DigestKindInvite})
+		}
+	})
+	listenMgr.SetOnStart(func(name string) {
+		d.report(ctx, rendezvous.DigestEvent{From: d.selfID, Kind: rendezvous.DigestKindStation, Name: name})
+	})
+	return func() {
+		unsub()
+		listenMgr.SetOnStart(nil)
+	}
+}
+
+// report posts ev in the background; callers are on hot paths.
+func (d *digestReports) report(ctx context.Context, ev rendezvous.DigestEvent) {
+	for _, c := range d.clients {
+		go func(c *rendezvous.Client) {
+			rctx, cancel := context.WithTimeout(ctx, DigestReportTimeout)
+			defer cancel()
+			if err := c.ReportDigestEvent(rctx, ev); err != nil {
+				log.Printf("digest: report to %s: %v", c.BaseURL, err)
+			}
+		}(c)
+	}
+}
@@ -0,0 +1,93 @@
+package modes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/app/doctor"
+	"github.com/petervdpas/goop2/internal/group_types/listen"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/viewer/routes"
+)
+
+// runDoctor checks the peer directory before the services that read it
+// start. unclean is set when the previous run did not finish its shutdown.
+func runDoctor(db *storage.DB, peerDir, selfID string, firstStart, unclean bool) doctor.Report {
+	d := doctor.New()
+
+	d.Add("database", func(deep bool) (int, string, error) {
+		if !deep {
+			return 0, "", doctor.ErrSkipped
+		}
+		err := db.QuickCheck()
+		if err == nil {
+			return 0, "", nil
+		}
+		if rerr := db.Reindex(); rerr != nil {
+			return 0, err.Error(), rerr
+		}
+		if err := db.QuickCheck(); err != nil {
+			return 0, "indexes rebuilt", err
+		}
+		return 1, "rebuilt damaged indexes", nil
+	})
+
+	d.Add("group-state", func(bool) (int, string, error) {
+		rep, err := db.RepairGroupState(selfID)
+		if err != nil {
+			return 0, "", err
+		}
+		n := rep.Subscriptions + rep.Members
+		if n == 0 {
+			return 0, "", nil
+		}
+		return n, fmt.Sprintf("%d stale subscriptions, %d orphaned member rows", rep.Subscriptions, rep.Members), nil
+	})
+
+	d.Add("listen-queue", func(bool) (int, string, error) {
+		groups, err := db.ListGroups()
+		if err != nil {
+			return 0, "", err
+		}
+		var hosted []string
+		for _, g := range groups {
+			if strings.HasPrefix(g.ID, "listen-") {
+				hosted = append(hosted, g.ID)
+			}
+		}
+		n, err := listen.RepairQueue(peerDir, hosted)
+		if err != nil || n == 0 {
+			return 0, "", err
+		}
+		return n, fmt.Sprintf("removed %d queue entries", n), nil
+	})
+
+	// Finished downloads are only re-hashed after an unclean shutdown.
+	d.Add("docs-cache", func(deep bool) (int, string, error) {
+		n, err := p2p.RepairDocCache(filepath.Join(peerDir, "cache", "docs"), routes.DocCacheMaxAge, deep)
+		if err != nil || n == 0 {
+			return n, "", err
+		}
+		return n, fmt.Sprintf("removed %d partial or damaged downloads", n), nil
+	})
+
+	return d.Run(firstStart, unclean)
+}
+
+// publishHealth tells the first browser to connect what the doctor did,
+// when there is anything to tell.
+func publishHealth(m *mq.Manager, rep doctor.Report) {
+	if !rep.Unclean && rep.Healthy && rep.Repaired == 0 {
+		return
+	}
+	p := mq.HealthStartupPayload{Unclean: rep.Unclean, Healthy: rep.Healthy, Repaired: rep.Repaired}
+	for _, c := range rep.Checks {
+		if c.Status == doctor.StatusFailed {
+			p.Failed = append(p.Failed, c.Name)
+		}
+	}
+	m.PublishHealthStartup(p)
+}
@@ -0,0 +1,253 @@
+package modes
+
+// echopeer.go — a second peer inside the same process, for developing
+// templates and features on one machine. It has its own identity and
+// database under <peer dir>/echo-peer, connects straight to the real
+// peer and:
+//
+//   - sends every direct chat message back to its sender
+//   - accepts group invites and joins the group
+//   - joins listen groups and keeps pulling the audio stream
+//   - answers calls after a ring, sending a test pattern (Linux)
+//
+// Started with the -echo-peer flag; never in normal use.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/call"
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/group_types/chat"
+	"github.com/petervdpas/goop2/internal/group_types/listen"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// EchoPeerLabel is the echo peer's display name.
+const EchoPeerLabel = "Echo peer"
+
+// autoJoinedTypes are the group types the group manager joins by itself
+// when invited; the echo peer joins the rest.
+var autoJoinedTypes = map[string]bool{"realtime": true, "template": true, "files": true, "chat": true}
+
+type echoPeer struct {
+	node   *p2p.Node
+	mq     *mq.Manager
+	db     *storage.DB
+	grp    *group.Manager
+	listen *listen.Manager
+	rooms  *chat.Manager
+	call   *call.Manager // nil when the echo peer cannot answer calls
+}
+
+// startEchoPeer starts the echo peer next to main and returns the function
+// that stops it.
+func startEchoPeer(ctx context.Context, peerDir string, main *p2p.Node, heartbeat, ttl time.Duration) (func(), error) {
+	dir := filepath.Join(peerDir, "echo-peer")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("echo peer: %w", err)
+	}
+
+	peers := state.NewPeerTable()
+	label := func() string { return EchoPeerLabel }
+	empty := func() string { return "" }
+	node, err := p2p.New(ctx, 0, filepath.Join(dir, "identity.key"), peers, label, empty, func() bool { return false }, empty, empty, nil, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("echo peer: %w", err)
+	}
+	db, err := storage.Open(dir)
+	if err != nil {
+		node.Close()
+		return nil, fmt.Errorf("echo peer: open database: %w", err)
+	}
+
+	e := &echoPeer{node: node, mq: mq.New(node.Host), db: db}
+	resolvePeer := func(id string) state.PeerIdentityPayload {
+		if id == node.ID() {
+			return state.PeerIdentityPayload{PeerID: id, Content: EchoPeerLabel, Known: true}
+		}
+		if sp, ok := peers.Get(id); ok {
+			return state.FromSeenPeer(sp)
+		}
+		return state.PeerIdentityPayload{}
+	}
+	e.grp = group.New(node.Host, db, e.mq, resolvePeer)
+	e.listen = listen.New(node.Host, e.grp, e.mq, node.ID(), dir)
+	e.grp.RegisterType("listen", e.listen)
+	e.rooms = chat.New(e.grp, e.mq, node.ID(), resolvePeer)
+	if call.NativeCapture {
+		e.call = call.New(&mqSignalerAdapter{mq: e.mq, peers: make(map[string]string)}, node.ID(), nil, runtime.GOOS)
+		e.call.UseTestPattern()
+	}
+
+	e.mq.SubscribeTopic(mq.TopicIdentity, func(from, topic string, _ any) {
+		if topic == mq.TopicIdentity {
+			e.send(ctx, from, mq.TopicIdentityResponse, mq.PeerAnnouncePayload{PeerID: node.ID(), Content: EchoPeerLabel, Reachable: true})
+		}
+	})
+	e.mq.SubscribeTopic(mq.TopicChat, e.echoChat(ctx))
+	e.mq.SubscribeTopic(mq.TopicGroupInvite, e.acceptInvite)
+	if e.call != nil {
+		e.mq.SubscribeTopic(mq.TopicCallPrefix, e.answerCall(ctx))
+	}
+
+	node.RunPresenceLoop(ctx, nil)
+	if err := node.Host.Connect(ctx, peer.AddrInfo{ID: main.Host.ID(), Addrs: main.Host.Addrs()}); err != nil {
+		log.Printf("ECHO: connect to %s failed: %v", main.ID(), err)
+	}
+	go e.announce(ctx, heartbeat)
+	go e.pullListenAudio(ctx)
+
+	log.Printf("ECHO: echo peer %s running", node.ID())
+	return e.close, nil
+}
+
+func (e *echoPeer) send(ctx context.Context, peerID, topic string, payload any) {
+	sendCtx, cancel := context.WithTimeout(ctx, MQCallSignalTimeout)
+	defer cancel()
+	if _, err := e.mq.Send(sendCtx, peerID, topic, payload); err != nil {
+		log.Printf("ECHO: send %s to %s failed: %v", topic, peerID, err)
+	}
+}
+
+// announce publishes the echo peer's presence on the heartbeat. The first
+// announcement waits for gossipsub to mesh with the real peer.
+func (e *echoPeer) announce(ctx context.Context, heartbeat time.Duration) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(EchoAnnounceDelay):
+	}
+	e.node.Publish(ctx, proto.TypeOnline)
+	t := time.NewTicker(heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			e.node.Publish(ctx, proto.TypeUpdate)
+		}
+	}
+}
+
+// echoChat sends direct chat messages back unchanged.
+func (e *echoPeer) echoChat(ctx context.Context) func(from, topic string, payload any) {
+	return func(from, topic string, payload any) {
+		if topic != mq.TopicChat {
+			return
+		}
+		m, _ := payload.(map[string]any)
+		content, _ := m["content"].(string)
+		if content == "" {
+			return
+		}
+		go e.send(ctx, from, mq.TopicChat, map[string]any{"content": content})
+	}
+}
+
+// acceptInvite joins every group the echo peer is invited to.
+func (e *echoPeer) acceptInvite(from, _ string, payload any) {
+	var inv struct {
+		GroupID   string `json:"group_id"`
+		GroupType string `json:"group_type"`
+	}
+	b, _ := json.Marshal(payload)
+	if json.Unmarshal(b, &inv) != nil || inv.GroupID == "" || autoJoinedTypes[inv.GroupType] {
+		return
+	}
+	go func() {
+		var err error
+		if inv.GroupType == "listen" {
+			err = e.listen.JoinGroup(from, inv.GroupID)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), EchoJoinTimeout)
+			defer cancel()
+			err = e.grp.JoinRemoteGroup(ctx, from, inv.GroupID)
+		}
+		if err != nil {
+			log.Printf("ECHO: join %s group %s failed: %v", inv.GroupType, inv.GroupID, err)
+			return
+		}
+		log.Printf("ECHO: joined %s group %s", inv.GroupType, inv.GroupID)
+	}()
+}
+
+// answerCall accepts incoming calls after EchoAnswerDelay, so the caller
+// sees the call ring first.
+func (e *echoPeer) answerCall(ctx context.Context) func(from, topic string, payload any) {
+	return func(from, topic string, payload any) {
+		m, _ := payload.(map[string]any)
+		if typ, _ := m["type"].(string); typ != "call-request" {
+			return
+		}
+		channelID := topic[len(mq.TopicCallPrefix):]
+		go func() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(EchoAnswerDelay):
+			}
+			if _, err := e.call.AcceptCall(ctx, channelID, from); err != nil {
+				log.Printf("ECHO: answer call %s failed: %v", channelID, err)
+			}
+		}()
+	}
+}
+
+// pullListenAudio keeps an audio stream open while the listen group the
+// echo peer is in plays, like a listener with the player open.
+func (e *echoPeer) pullListenAudio(ctx context.Context) {
+	t := time.NewTicker(EchoListenPoll)
+	defer t.Stop()
+	done := make(chan struct{}, 1)
+	pulling := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			pulling = false
+		case <-t.C:
+			g := e.listen.GetGroup()
+			if pulling || g == nil || g.Role != "listener" || g.PlayState == nil || !g.PlayState.Playing {
+				continue
+			}
+			r, _, err := e.listen.AudioReader()
+			if err != nil {
+				continue
+			}
+			pulling = true
+			go func() {
+				_, _ = io.Copy(io.Discard, r)
+				r.Close()
+				done <- struct{}{}
+			}()
+		}
+	}
+}
+
+func (e *echoPeer) close() {
+	e.node.Publish(context.Background(), proto.TypeOffline)
+	if e.call != nil {
+		e.call.Close()
+	}
+	e.rooms.Close()
+	e.listen.Close()
+	e.grp.Close()
+	e.db.Close()
+	e.node.Close()
+}
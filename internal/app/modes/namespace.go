@@ -0,0 +1,40 @@
+package modes
+
+// namespace.go — presence from the rendezvous of a presence namespace
+// (presence.namespaces). Gossip on the namespace topic is handled by the
+// node itself; this feeds the namespace's peer table from its rendezvous.
+
+import (
+	"context"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+)
+
+// namespacePresence returns a rendezvous message handler that keeps table
+// up to date and dials the peers it announces.
+func namespacePresence(ctx context.Context, node *p2p.Node, table *state.PeerTable) func(proto.PresenceMsg) {
+	return func(pm proto.PresenceMsg) {
+		if pm.PeerID == node.ID() {
+			return
+		}
+		switch pm.Type {
+		case proto.TypeOnline, proto.TypeUpdate:
+			_, known := table.Get(pm.PeerID)
+			table.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, pm.Verified, pm.GoopClientVersion, p2p.VerifyPresence(pm))
+			table.SetSiteHash(pm.PeerID, pm.SiteHash)
+			node.AddPeerAddrs(pm.PeerID, pm.Addrs)
+			if !known {
+				go node.ProbePeer(ctx, pm.PeerID)
+			}
+		case proto.TypePunch:
+			if pm.Target == node.ID() {
+				node.AddPeerAddrs(pm.PeerID, pm.Addrs)
+				go node.ProbePeer(ctx, pm.PeerID)
+			}
+		case proto.TypeOffline:
+			table.MarkOffline(pm.PeerID)
+		}
+	}
+}
@@ -4,18 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/actions"
+	"github.com/petervdpas/goop2/internal/app/control"
 	"github.com/petervdpas/goop2/internal/app/shared"
+	"github.com/petervdpas/goop2/internal/app/shutdown"
+	"github.com/petervdpas/goop2/internal/app/startup"
 	"github.com/petervdpas/goop2/internal/avatar"
 	"github.com/petervdpas/goop2/internal/call"
 	"github.com/petervdpas/goop2/internal/directchat"
 	"github.com/petervdpas/goop2/internal/config"
 	"github.com/petervdpas/goop2/internal/content"
 	goopCrypto "github.com/petervdpas/goop2/internal/crypto"
+	"github.com/petervdpas/goop2/internal/datasync"
 	"github.com/petervdpas/goop2/internal/group"
 	clusterType "github.com/petervdpas/goop2/internal/group_types/cluster"
 	"github.com/petervdpas/goop2/internal/group_types/datafed"
@@ -24,11 +32,19 @@ import (
 	"github.com/petervdpas/goop2/internal/group_types/listen"
 	"github.com/petervdpas/goop2/internal/group_types/chat"
 	templateType "github.com/petervdpas/goop2/internal/group_types/template"
+	"github.com/petervdpas/goop2/internal/jobs"
 	luapkg "github.com/petervdpas/goop2/internal/lua"
 	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/prom"
+	"github.com/petervdpas/goop2/internal/pairing"
 	"github.com/petervdpas/goop2/internal/proto"
 	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/retention"
+	"github.com/petervdpas/goop2/internal/rules"
+	"github.com/petervdpas/goop2/internal/search"
+	"github.com/petervdpas/goop2/internal/siteassets"
+	"github.com/petervdpas/goop2/internal/sitesync"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
 	"github.com/petervdpas/goop2/internal/util"
@@ -45,9 +61,7 @@ type PeerParams struct {
 	SelfActiveTemplate    func() string
 	SelfPublicKey         func() string
 	SelfVerificationToken func() string
-	Progress              func(int, int, string)
-	Step                  int
-	Total                 int
+	Stages                *startup.Reporter
 }
 
 func RunPeer(p PeerParams) error {
@@ -60,9 +74,7 @@ func RunPeer(p PeerParams) error {
 	selfActiveTemplate := p.SelfActiveTemplate
 	selfPublicKey := p.SelfPublicKey
 	selfVerificationToken := p.SelfVerificationToken
-	progress := p.Progress
-	step := p.Step
-	total := p.Total
+	stages := p.Stages
 
 	// ── Rendezvous bridges
 	var rvClients []*rendezvous.Client
@@ -70,17 +82,28 @@ func RunPeer(p PeerParams) error {
 		rvClients = append(rvClients,
 			rendezvous.NewClient(fmt.Sprintf("http://127.0.0.1:%d", cfg.Presence.RendezvousPort)))
 	}
+	// Relay info is only trusted when signed by the pinned relay, see RelayPins.
+	relayPins := rendezvous.NewRelayPins(o.PeerDir)
+	for _, c := range rvClients {
+		c.SetRelayPin("", relayPins)
+	}
 	if strings.TrimSpace(cfg.Presence.RendezvousWAN) != "" {
-		rvClients = append(rvClients,
-			rendezvous.NewClient(util.NormalizeURL(cfg.Presence.RendezvousWAN)))
+		wan := rendezvous.NewClient(util.NormalizeURL(cfg.Presence.RendezvousWAN))
+		wan.SetRelayPin(strings.TrimSpace(cfg.Presence.RendezvousRelayID), relayPins)
+		rvClients = append(rvClients, wan)
+	}
+	// Store listings and bundles survive restarts and outages, see TemplateCache.
+	templateCache := rendezvous.NewTemplateCache(o.PeerDir)
+	for _, c := range rvClients {
+		c.SetTemplateCache(templateCache)
 	}
 
 	peers := state.NewPeerTable()
+	peers.SetCapacity(cfg.Viewer.MaxTrackedPeers)
 
 	// Fetch relay info from WAN rendezvous (if available) so we can enable
 	// circuit relay transport and hole-punching for NAT traversal.
-	step++
-	progress(step, total, "Discovering relay")
+	stages.Begin("relay", "Discovering relay")
 
 	var reachableClients []*rendezvous.Client
 	for _, c := range rvClients {
@@ -92,9 +115,11 @@ func RunPeer(p PeerParams) error {
 	rvClients = reachableClients
 
 	var relayInfo *rendezvous.RelayInfo
+	var relayClient *rendezvous.Client // the rendezvous running relayInfo
 	if len(rvClients) > 0 {
 		type relayResult struct {
-			info *rendezvous.RelayInfo
+			info   *rendezvous.RelayInfo
+			client *rendezvous.Client
 		}
 		ch := make(chan relayResult, len(rvClients))
 		for _, c := range rvClients {
@@ -107,20 +132,26 @@ func RunPeer(p PeerParams) error {
 					log.Printf("relay: %s has no relay configured", c.BaseURL)
 					ch <- relayResult{}
 				} else {
-					ch <- relayResult{info: ri}
+					ch <- relayResult{info: ri, client: c}
 				}
 			}(c)
 		}
 		for range rvClients {
 			if r := <-ch; r.info != nil && relayInfo == nil {
 				relayInfo = r.info
+				relayClient = r.client
 				log.Printf("relay: discovered relay peer %s (%d addrs)", r.info.PeerID, len(r.info.Addrs))
 			}
 		}
 	}
 
-	step++
-	progress(step, total, "Creating P2P node")
+	if relayInfo != nil {
+		stages.Note("relay " + relayInfo.PeerID + " via " + relayClient.BaseURL)
+	} else {
+		stages.Note("no relay")
+	}
+
+	stages.Begin("p2p", "Creating P2P node")
 
 	keyPath := util.ResolvePath(o.PeerDir, cfg.Identity.KeyFile)
 	node, err := p2p.New(ctx, cfg.P2P.ListenPort, keyPath, peers, selfContent, selfEmail, selfVideoDisabled, selfActiveTemplate, selfPublicKey, relayInfo, time.Duration(cfg.Presence.TTLSec)*time.Second)
@@ -133,10 +164,51 @@ func RunPeer(p PeerParams) error {
 	// (which can happen inside p2p.New) mark peers reachable right away.
 	node.SubscribeConnectionEvents(ctx, nil)
 
+	node.SetDiagAccess(cfg.P2P.DiagAccess)
+	node.SetServeLimits(p2p.ServeLimits{TotalKBps: cfg.P2P.ServeLimitKBps, PerPeerKBps: cfg.P2P.ServePeerLimitKBps})
+
+	searchIdx := &search.Index{SiteRoot: util.ResolvePath(o.PeerDir, cfg.Paths.SiteRoot)}
+	searchIdx.SetSources(cfg.P2P.SearchExpose)
+
+	// Settings that apply live follow every config write, whichever
+	// subsystem made it.
+	stopConfigWatch := config.Watch(func(c config.Change) {
+		if c.Path != o.CfgPath {
+			return
+		}
+		if c.Has("p2p.diag_access") {
+			node.SetDiagAccess(c.Config.P2P.DiagAccess)
+		}
+		if c.Has("p2p.serve_limit_kbps", "p2p.serve_peer_limit_kbps") {
+			node.SetServeLimits(p2p.ServeLimits{TotalKBps: c.Config.P2P.ServeLimitKBps, PerPeerKBps: c.Config.P2P.ServePeerLimitKBps})
+		}
+		if c.Has("p2p.search_expose") {
+			searchIdx.SetSources(c.Config.P2P.SearchExpose)
+		}
+		if c.Has("viewer.preferred_cam", "viewer.preferred_mic") {
+			// Next native call opens these; calls in progress are switched
+			// by the browser through /api/call/device.
+			call.SetPreferredDevices(c.Config.Viewer.PreferredCam, c.Config.Viewer.PreferredMic)
+		}
+	})
+	defer stopConfigWatch()
+
+	for pid, ap := range cfg.P2P.AccessPolicies {
+		if err := node.Gate().SetPolicy(pid, ap); err != nil {
+			log.Printf("WARNING: Invalid access policy for %s: %v", pid, err)
+		}
+	}
+
 	// Register all stream handlers immediately after the host is created,
 	// before any peer can connect and run Identify.
 	mqMgr := mq.New(node.Host)
 	log.Printf("📨 MQ enabled: message queue via /goop/mq/1.0.0")
+	node.AddDiagSection("mq_metrics", func() any { return mqMgr.Metrics() })
+
+	// Consent prompts for protocols using the "consent" access mode.
+	node.Consent().SetNotifier(func(req p2p.ConsentRequest) {
+		mqMgr.PublishLocal(mq.TopicSecurityConsent, "", req)
+	})
 
 	// ── Wire E2E encryption (NaCl box) to all protocol layers
 	// sealKeyFor: only encrypt for peers that advertise EncryptionSupported.
@@ -181,24 +253,97 @@ func RunPeer(p PeerParams) error {
 	}
 
 	node.EnableSite(util.ResolvePath(o.PeerDir, cfg.Paths.SiteRoot))
+	node.EnableSiteSync()
 
 	// ── Avatar store
 	avatarStore := avatar.NewStore(o.PeerDir)
 	avatarCache := avatar.NewCache(o.PeerDir)
 	node.EnableAvatar(avatarStore)
 
-	step++
-	progress(step, total, "Opening database")
+	stages.Begin("database", "Opening database")
 
 	// Initialize SQLite database for peer data (unconditionally — needed for P2P data protocol)
-	db, err := storage.Open(o.PeerDir)
+	db, err := openDatabase(o.PeerDir, cfg.Storage, node)
 	if err != nil {
 		return fmt.Errorf("open database: %w", err)
 	}
 
+	// The marker in the peer directory tells whether the previous run got
+	// through its shutdown. The startup doctor then repairs what a run can
+	// leave half-written, and checks more thoroughly when it did not.
+	started := time.Now()
+	prevRun, prevFound, prevClean, err := shutdown.Begin(o.PeerDir)
+	if err != nil {
+		log.Printf("SHUTDOWN: marker: %v", err)
+	} else if prevFound && !prevClean {
+		log.Printf("SHUTDOWN: previous run (pid %d, started %s) did not shut down cleanly",
+			prevRun.PID, time.UnixMilli(prevRun.Started).Format(time.RFC3339))
+	}
+	health := runDoctor(db, o.PeerDir, node.ID(), !prevFound && prevClean, !prevClean)
+	publishHealth(mqMgr, health)
+	node.AddDiagSection("last_shutdown", func() any {
+		return map[string]any{"found": prevFound, "clean": prevClean, "previous": prevRun}
+	})
+	node.AddDiagSection("startup_doctor", func() any { return health })
+
+	// Teardown runs as ordered phases once ctx is done, see shutdown.Coordinator.
+	shut := shutdown.New()
+	defer shut.Run(DefaultShutdownTimeout) // early error returns; no-op after a normal stop
+	shut.Add(shutdown.Checkpoint, "database", func(context.Context) error {
+		if err := db.Checkpoint(); err != nil {
+			db.Close()
+			return err
+		}
+		return db.Close()
+	})
+
+	// Background work (peer cache writes, identity requests, avatar
+	// fetches, key registration) runs on the job queue; shutdown runs what
+	// is left before the database closes.
+	jobQueue := jobs.New(0, 0)
+	shut.Add(shutdown.FlushQueues, "jobs", func(ctx context.Context) error {
+		err := jobQueue.Drain(ctx)
+		jobQueue.Close()
+		return err
+	})
+	// cacheWrite queues a peer cache write. key names the row, so only the
+	// latest of several pending writes to it runs.
+	cacheWrite := func(key string, fn func() error) {
+		jobQueue.Submit("peer-cache", key, jobs.DBWrite, func(context.Context) error { return fn() })
+	}
+
 	node.EnableData(db)
 	log.Printf("peer id: %s", node.ID())
 
+	node.Consent().SetStore(db)
+
+	// Store-and-forward: messages sent with queue=true wait in the DB for
+	// peers that are offline and go out once they are reachable again.
+	mqMgr.EnableOutbox(ctx, db)
+	shut.Add(shutdown.FlushQueues, "outbox", mqMgr.DrainOutbox)
+
+	// Audit every inbound goop stream to the local rolling audit log. The
+	// writer batches the inserts off the stream path.
+	auditLog := db.StartAuditWriter()
+	shut.Add(shutdown.FlushQueues, "audit", func(context.Context) error {
+		auditLog.Close()
+		return nil
+	})
+	node.Gate().SetAuditor(func(a p2p.StreamAudit) {
+		auditLog.Add(storage.AuditEntry{
+			Timestamp:  a.Start.UnixMilli(),
+			Protocol:   a.Protocol,
+			PeerID:     a.PeerID,
+			Outcome:    a.Outcome,
+			BytesIn:    a.BytesIn,
+			BytesOut:   a.BytesOut,
+			DurationMs: a.Duration.Milliseconds(),
+		})
+	})
+
+	if n, err := db.TrimPeerCache(cfg.Viewer.MaxTrackedPeers); err == nil && n > 0 {
+		log.Printf("peer cache: trimmed %d peers over max_tracked_peers", n)
+	}
 	if cachedPeers, err := db.ListCachedPeers(); err == nil {
 		for _, cp := range cachedPeers {
 			peers.Seed(cp.PeerID, cp.Content, cp.Email, cp.AvatarHash, cp.VideoDisabled, cp.ActiveTemplate, cp.PublicKey, cp.Verified, cp.Favorite)
@@ -244,11 +389,10 @@ func RunPeer(p PeerParams) error {
 		// Unknown peer — request identity over MQ. The response handler
 		// above will upsert into PeerTable asynchronously, so next lookup
 		// will have the data. Fire-and-forget: we don't block for the response.
-		go func() {
-			reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			defer cancel()
-			_, _ = mqMgr.Send(reqCtx, id, mq.TopicIdentity, nil)
-		}()
+		jobQueue.Submit("identity-request", "identity:"+id, jobs.Policy{Timeout: 2 * time.Second}, func(jctx context.Context) error {
+			_, err := mqMgr.Send(jctx, id, mq.TopicIdentity, nil)
+			return err
+		})
 		return state.PeerIdentityPayload{}
 	}
 
@@ -295,8 +439,10 @@ func RunPeer(p PeerParams) error {
 		encSupported, _ := pm["encryptionSupported"].(bool)
 		activeTemplate, _ := pm["activeTemplate"].(string)
 		videoDisabled, _ := pm["videoDisabled"].(bool)
+		// The response arrives on a stream authenticated as from, so its
+		// content is the peer's own.
 		if content != "" {
-			peers.Upsert(from, content, email, avatarHash, videoDisabled, activeTemplate, publicKey, encSupported, false, version)
+			peers.Upsert(from, content, email, avatarHash, videoDisabled, activeTemplate, publicKey, encSupported, false, version, true)
 		}
 	})
 
@@ -308,14 +454,13 @@ func RunPeer(p PeerParams) error {
 		if cached, _ := avatarCache.Get(peerID, hash); cached != nil {
 			return
 		}
-		go func() {
-			ctx2, cancel := context.WithTimeout(ctx, AvatarWarmTimeout)
-			defer cancel()
-			data, err := node.FetchAvatar(ctx2, peerID)
-			if err == nil && data != nil {
-				_ = avatarCache.Put(peerID, hash, data)
+		jobQueue.Submit("avatar-warm", "avatar:"+peerID, jobs.Policy{Timeout: AvatarWarmTimeout}, func(jctx context.Context) error {
+			data, err := node.FetchAvatar(jctx, peerID)
+			if err != nil || data == nil {
+				return err
 			}
-		}()
+			return avatarCache.Put(peerID, hash, data)
+		})
 	}
 
 	// Start rendezvous WS connections as early as possible so peer discovery
@@ -336,8 +481,9 @@ func RunPeer(p PeerParams) error {
 				}
 				log.Printf("[online] %s (%s) — %d addrs", pm.PeerID[:min(16, len(pm.PeerID))], name, len(pm.Addrs))
 			}
-			peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, pm.Verified, pm.GoopClientVersion)
-			go db.UpsertCachedPeer(storage.CachedPeer{
+			peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, pm.Verified, pm.GoopClientVersion, p2p.VerifyPresence(pm))
+			peers.SetSiteHash(pm.PeerID, pm.SiteHash)
+			cp := storage.CachedPeer{
 				PeerID:         pm.PeerID,
 				Content:        pm.Content,
 				Email:          pm.Email,
@@ -347,7 +493,8 @@ func RunPeer(p PeerParams) error {
 				PublicKey:      pm.PublicKey,
 				Verified:       pm.Verified,
 				Addrs:          pm.Addrs,
-			})
+			}
+			cacheWrite("peer:"+cp.PeerID, func() error { return db.UpsertCachedPeer(cp) })
 			node.AddPeerAddrs(pm.PeerID, pm.Addrs)
 			if !known {
 				go node.ProbePeer(ctx, pm.PeerID)
@@ -370,8 +517,27 @@ func RunPeer(p PeerParams) error {
 		go cc.ConnectWebSocket(ctx, node.ID(), rvOnMsg)
 	}
 
-	step++
-	progress(step, total, "Setting up services")
+	// ── Presence namespaces: each community has its own peer table, gossip
+	// topic and (optionally) rendezvous, see p2p/namespace.go.
+	nsPeers := map[string]*state.PeerTable{}
+	var nsClients []*rendezvous.Client
+	for _, ns := range cfg.Presence.Namespaces {
+		table := state.NewPeerTable()
+		table.SetCapacity(cfg.Viewer.MaxTrackedPeers)
+		if err := node.JoinNamespace(ctx, ns.Name, table); err != nil {
+			log.Printf("namespace %s: join failed: %v", ns.Name, err)
+			continue
+		}
+		nsPeers[ns.Name] = table
+		if rw := strings.TrimSpace(ns.RendezvousWAN); rw != "" {
+			c := rendezvous.NewClient(util.NormalizeURL(rw))
+			nsClients = append(nsClients, c)
+			go c.ConnectWebSocket(ctx, node.ID(), namespacePresence(ctx, node, table))
+		}
+		log.Printf("namespace %s: joined (rendezvous %q)", ns.Name, ns.RendezvousWAN)
+	}
+
+	stages.Begin("services", "Setting up services")
 
 	// Bridge: PeerTable → MQ so the browser's mq.js maintains a peer name cache.
 	// Every peer presence change (online/update/offline/prune) is forwarded as
@@ -400,16 +566,20 @@ func RunPeer(p PeerParams) error {
 						EncryptionSupported: evt.Peer.EncryptionSupported,
 						Verified:            evt.Peer.Verified,
 						GoopClientVersion:   evt.Peer.GoopClientVersion,
+						Signed:              evt.Peer.Signed,
 						Reachable:           evt.Peer.Reachable,
 						Offline:             !evt.Peer.OfflineSince.IsZero(),
 						LastSeen:            evt.Peer.LastSeen.UnixMilli(),
 						Favorite:            evt.Peer.Favorite,
 					})
+					if evt.Peer.Reachable && evt.Peer.OfflineSince.IsZero() {
+						mqMgr.FlushOutbox(ctx, evt.PeerID)
+					}
 				} else if evt.Type == "remove" && evt.PeerID != "" {
 					mqMgr.PublishPeerGone(evt.PeerID)
 					// Sync DB cache with in-memory prune: delete from _peer_cache.
 					// Favorites survive in _favorites; non-favorites are gone for good.
-					go db.DeleteCachedPeer(evt.PeerID)
+					cacheWrite("peer:"+evt.PeerID, func() error { return db.DeleteCachedPeer(evt.PeerID) })
 				}
 			}
 		}
@@ -419,7 +589,7 @@ func RunPeer(p PeerParams) error {
 	// This keeps the DB cache warm across restarts so peerSupportsMQ()
 	// can fast-fail for old clients without a dial attempt.
 	node.SubscribeIdentify(ctx, func(peerID string, protocols []string) {
-		go db.UpsertPeerProtocols(peerID, protocols)
+		cacheWrite("protocols:"+peerID, func() error { return db.UpsertPeerProtocols(peerID, protocols) })
 	})
 
 	// ── Chat manager
@@ -429,6 +599,8 @@ func RunPeer(p PeerParams) error {
 	// ── Lua scripting engine
 	var luaEngine *luapkg.Engine
 	var luaOnce sync.Once
+	// Lua and rules reach the actions registry through the viewer mux once it is up.
+	actionRunner := actions.NewDispatcher()
 
 	startLua := func() {
 		luaOnce.Do(func() {
@@ -445,6 +617,7 @@ func RunPeer(p PeerParams) error {
 				return
 			}
 			luaEngine.SetDB(db)
+			luaEngine.SetActions(actionRunner)
 			node.SetLuaDispatcher(luaEngine)
 			chatMgr.SetLuaDispatcher(luaEngine)
 		})
@@ -453,11 +626,6 @@ func RunPeer(p PeerParams) error {
 	if cfg.Lua.Enabled {
 		startLua()
 	}
-	defer func() {
-		if luaEngine != nil {
-			luaEngine.Close()
-		}
-	}()
 
 	// ensureLua is called by template apply when Lua files are detected.
 	// It enables Lua in config, starts the engine if needed, and rescans.
@@ -466,10 +634,11 @@ func RunPeer(p PeerParams) error {
 	var setLuaContent func()
 	var setLuaGroups func()
 	ensureLua := func() {
-		if c, err := config.Load(o.CfgPath); err == nil {
-			if !c.Lua.Enabled {
+		if c, err := config.Load(o.CfgPath); err == nil && !c.Lua.Enabled {
+			if _, err := config.Update(o.CfgPath, func(c *config.Config) error {
 				c.Lua.Enabled = true
-				config.Save(o.CfgPath, c)
+				return nil
+			}); err == nil {
 				log.Printf("LUA: auto-enabled in config (template with Lua functions applied)")
 			}
 		}
@@ -486,15 +655,85 @@ func RunPeer(p PeerParams) error {
 		node.RescanLuaFunctions()
 	}
 
+	// ── Automation rules ("when X then Y")
+	rulesEngine := rules.New(db, mqMgr, peers)
+	rulesEngine.SetChat(chatMgr)
+	rulesEngine.SetActions(actionRunner)
+	if o.Secrets != nil {
+		rulesEngine.SetSecrets(o.Secrets)
+	}
+	rulesEngine.SetLua(func(ctx context.Context, function string, params map[string]any) (any, error) {
+		if luaEngine == nil {
+			return nil, fmt.Errorf("lua engine not running")
+		}
+		return luaEngine.CallFunction(ctx, node.ID(), function, params)
+	})
+	rulesEngine.Start(ctx)
+
+	// ── Peer retention: forget non-favorites not heard from in peer_retention_days
+	pruner := retention.New(db, peers, avatarCache)
+	go pruner.Run(ctx, RetentionInterval, func() int {
+		if live, err := config.LoadPartial(o.CfgPath); err == nil {
+			return live.Viewer.PeerRetentionDays
+		}
+		return cfg.Viewer.PeerRetentionDays
+	})
+
+	// ── Site sync: local copies of followed peers' sites, shown while they are offline
+	siteSync := sitesync.New(db, peers, node, filepath.Join(o.PeerDir, "cache", "sites"))
+	go siteSync.Run(ctx, SiteSyncInterval)
+
+	// ── Site assets: image variants for visitors on slow relay paths
+	siteAssets := siteassets.New(util.ResolvePath(o.PeerDir, cfg.Paths.SiteRoot), filepath.Join(o.PeerDir, "cache", "site-assets"))
+	node.SetSiteAssets(siteAssets)
+	go siteAssets.Run(ctx, SiteAssetsInterval, func() siteassets.Options {
+		a := cfg.Assets
+		if live, err := config.LoadPartial(o.CfgPath); err == nil {
+			a = live.Assets
+		}
+		return siteassets.Options{Enabled: a.Enabled, Widths: a.Widths, Quality: a.Quality}
+	})
+
+	// ── Template updates: tell the browser when the store has a newer version
+	if len(rvClients) > 0 {
+		tu := &templateUpdates{db: db, mq: mqMgr, clients: rvClients, cfgPath: o.CfgPath, peerID: node.ID()}
+		go tu.run(ctx, TemplateUpdateInterval)
+	}
+
 	// ── Group manager
 	grpMgr := group.New(node.Host, db, mqMgr, resolvePeer)
 	log.Printf("👥 Group manager enabled (MQ transport)")
+	node.Gate().SetGroupChecker(grpMgr.SharesGroupWith)
+	peerClock := func(peerID string) (float64, bool) {
+		e, ok := node.ClockOffset(peerID)
+		return e.OffsetMs, ok
+	}
+	grpMgr.SetClock(peerClock)
+	grpMgr.SetTopics(node)
+	if len(cfg.P2P.GroupRateLimits) > 0 {
+		limits := make(map[string]group.RateLimit, len(cfg.P2P.GroupRateLimits))
+		for gt, rl := range cfg.P2P.GroupRateLimits {
+			limits[gt] = group.RateLimit{Rate: rl.MsgRate, Burst: rl.MsgBurst}
+		}
+		grpMgr.SetRateLimits(limits)
+	}
+
+	// ── Call history (observes call signaling in both directions)
+	stopCallLog := newCallLog(db, mqMgr).start()
+	defer stopCallLog()
 
-	// ── Native call manager (Go/Pion WebRTC — Linux only)
-	// Mode is determined by platform: Linux uses Go/Pion (WebKitGTK has no RTCPeerConnection),
-	// all other platforms use browser-native WebRTC. No config toggle needed.
+	// ── Native call manager (Go/Pion WebRTC)
+	// By default Linux uses Go/Pion (WebKitGTK has no RTCPeerConnection) and
+	// all other platforms use browser-native WebRTC; viewer.call_stack overrides.
+	// Tor mode has no calls: ICE would hand out the peer's IP.
+	if relayInfo != nil && len(relayInfo.STUNURLs) > 0 {
+		call.SetSTUNServers(relayInfo.STUNURLs)
+		log.Printf("📞 Using rendezvous STUN: %s", strings.Join(relayInfo.STUNURLs, ", "))
+	}
+	call.SetCaptionCommand(cfg.Viewer.CaptionCommand)
+	call.SetPreferredDevices(cfg.Viewer.PreferredCam, cfg.Viewer.PreferredMic)
 	var callMgr *call.Manager
-	if runtime.GOOS == "linux" {
+	if nativeCalls(cfg.Viewer.CallStack) && !cfg.P2P.Tor.Enabled {
 		sigAdapter := &mqSignalerAdapter{mq: mqMgr, peers: make(map[string]string)}
 		// callLogFn publishes structured log events from the call layer (e.g. hardware
 		// capture errors) to the MQ bus so they appear in the browser's Video log tab.
@@ -507,8 +746,30 @@ func RunPeer(p PeerParams) error {
 			})
 		}
 		callMgr = call.New(sigAdapter, node.ID(), callLogFn, runtime.GOOS)
-		defer callMgr.Close()
+		// In-call chat and file drops land in the normal chat history, tagged
+		// with the call's channel ID.
+		callMgr.OnData(func(channelID, peerID string, msg call.DataMessage, outbound bool) {
+			from := peerID
+			if outbound {
+				from = node.ID()
+			}
+			chatMgr.PersistCall(peerID, from, msg.Summary(), channelID)
+			if !outbound {
+				if msg.Type == "file" {
+					rulesEngine.Notify(rules.Event{Type: rules.TriggerFile, PeerID: peerID, File: msg.Name})
+				} else {
+					rulesEngine.Notify(rules.Event{Type: rules.TriggerChat, PeerID: peerID, Text: msg.Content})
+				}
+			}
+		})
+		shut.Add(shutdown.CloseGroups, "calls", func(context.Context) error {
+			callMgr.Close()
+			return nil
+		})
 		log.Printf("📞 Experimental native call stack enabled (Go/Pion WebRTC)")
+		if !call.NativeCapture {
+			log.Printf("📞 This build has no native camera/mic capture (build with -tags nativecall): calls are receive-only")
+		}
 	}
 
 	// ── Listen room (wraps group protocol + binary audio stream)
@@ -516,12 +777,24 @@ func RunPeer(p PeerParams) error {
 	if enc != nil {
 		listenMgr.SetEncryptor(enc)
 	}
-	defer listenMgr.Close()
+	listenMgr.SetClock(peerClock)
+	shut.Add(shutdown.CloseGroups, "listen", func(context.Context) error {
+		listenMgr.Close()
+		return nil
+	})
 	grpMgr.RegisterType("listen", listenMgr)
+	if len(rvClients) > 0 {
+		dr := &digestReports{clients: rvClients, selfID: node.ID()}
+		defer dr.start(ctx, mqMgr, listenMgr)()
+	}
 
 	// ── Chat group type (chat rooms)
 	chatRoomMgr := chat.New(grpMgr, mqMgr, node.ID(), resolvePeer)
-	defer chatRoomMgr.Close()
+	chatRoomMgr.SetStore(db)
+	shut.Add(shutdown.CloseGroups, "chat-rooms", func(context.Context) error {
+		chatRoomMgr.Close()
+		return nil
+	})
 
 	if luaEngine != nil {
 		luaEngine.SetListen(listenMgr)
@@ -551,7 +824,13 @@ func RunPeer(p PeerParams) error {
 	if cfg.Viewer.ClusterBinaryPath != "" {
 		clusterMgr.SetSavedBinary(cfg.Viewer.ClusterBinaryPath, cfg.Viewer.ClusterBinaryMode)
 	}
-	defer clusterMgr.Close()
+	shut.Add(shutdown.CloseGroups, "cluster", func(context.Context) error {
+		clusterMgr.Close()
+		return nil
+	})
+	shut.Add(shutdown.CloseGroups, "groups", func(context.Context) error {
+		return grpMgr.Close()
+	})
 	if hosted, err := grpMgr.ListHostedGroups(); err == nil {
 		for _, g := range hosted {
 			if g.GroupType == "cluster" {
@@ -574,20 +853,38 @@ func RunPeer(p PeerParams) error {
 		node.EnableDocs(docStore, grpMgr)
 		filesType.New(mqMgr, grpMgr, docStore)
 		log.Printf("📄 File sharing enabled: /goop/docs/1.0.0")
+		searchIdx.Docs = docStore
+		searchIdx.DocGroups = grpMgr.GroupsWithMember
 	}
 
+	// ── Search over what this peer exposes (p2p.search_expose)
+	node.EnableSearch(searchIdx)
+
 	// ── Data federation (GraphQL over P2P)
 	gqlEngine := gql.New(db, node.ID(), selfEmail)
 	_ = gqlEngine.Rebuild()
 	dataFedMgr := datafed.New(mqMgr, grpMgr, node.ID(), gqlEngine.ContextTables)
 	log.Printf("🔗 Data federation enabled (GraphQL)")
 
+	// ── Group table replication (template groups)
+	dataSync := datasync.New(db, node, grpMgr, mqMgr, filepath.Join(o.PeerDir, "replicas"))
+	node.EnableDataSync(dataSync.HostConflicts)
+	go dataSync.Run(ctx, DataSyncInterval)
+	log.Printf("🔁 Data sync enabled: /goop/data-sync/1.0.0")
+
 	// ── Template group type
 	tplHandler := templateType.New(grpMgr)
 	tplHandler.AddCleaner(chatRoomMgr)
 
 
 
+	selfPublicSite := func() bool {
+		if c, err := config.LoadPartial(o.CfgPath); err == nil {
+			return c.Presence.PublicSiteMirror
+		}
+		return cfg.Presence.PublicSiteMirror
+	}
+
 	publish := func(pctx context.Context, typ string) {
 		node.Publish(pctx, typ)
 		addrs := node.WanAddrs()
@@ -603,23 +900,30 @@ func RunPeer(p PeerParams) error {
 			EncryptionSupported: enc != nil,
 			VerificationToken:   selfVerificationToken(),
 			GoopClientVersion:   o.GoopClientVersion,
+			PublicSite:          selfPublicSite(),
+			SiteHash:            node.SiteHash(),
 			Addrs:               addrs,
 			TS:                  proto.NowMillis(),
 		}
-		for _, c := range rvClients {
+		node.SignPresence(&pm)
+		var wg sync.WaitGroup
+		for _, c := range slices.Concat(rvClients, nsClients) {
 			cc := c
+			wg.Add(1)
 			go func() {
+				defer wg.Done()
 				// Prefer WebSocket; fall back to HTTP POST
-				if cc.PublishWS(pm) {
-					return
-				}
 				ctx2, cancel := context.WithTimeout(pctx, util.ShortTimeout)
 				defer cancel()
-				if err := cc.Publish(ctx2, pm); err != nil {
+				if err := cc.PublishPresence(ctx2, pm); err != nil {
 					log.Printf("rendezvous: publish to %s failed: %v", cc.BaseURL, err)
 				}
 			}()
 		}
+		// Offline is the last thing a peer says; shutdown must not cut it off.
+		if typ == proto.TypeOffline {
+			wg.Wait()
+		}
 	}
 
 	// Publish immediately — announce ourselves as early as possible so peers
@@ -633,8 +937,7 @@ func RunPeer(p PeerParams) error {
 		})
 	}
 
-	step++
-	progress(step, total, "Starting viewer")
+	stages.Begin("viewer", "Starting viewer")
 
 	// ── Viewer
 	if cfg.Viewer.HTTPAddr != "" {
@@ -664,11 +967,44 @@ func RunPeer(p PeerParams) error {
 			}
 		})
 
+		// In-flight requests (a docs download, a form save) get
+		// ViewerDrainTimeout; streams that never go idle are then cut.
+		var serversMu sync.Mutex
+		var servers []*http.Server
+		shut.Add(shutdown.StopAccepting, "viewer", func(ctx context.Context) error {
+			serversMu.Lock()
+			list := slices.Clone(servers)
+			serversMu.Unlock()
+			dctx, cancel := context.WithTimeout(ctx, ViewerDrainTimeout)
+			defer cancel()
+			for _, srv := range list {
+				if err := srv.Shutdown(dctx); err != nil {
+					srv.Close()
+				}
+			}
+			return nil
+		})
+
+		var metrics http.Handler
+		if cfg.Viewer.Metrics {
+			metrics = prom.Handler(node, mqMgr, grpMgr, listenMgr, jobQueue)
+		}
+
 		go viewer.Start(addr, viewer.Viewer{
+			OnServer: func(srv *http.Server) {
+				serversMu.Lock()
+				servers = append(servers, srv)
+				serversMu.Unlock()
+			},
+			Health:      func() any { return health },
+			Startup:     func() any { return stages.Report() },
+			Metrics:     metrics,
+			Jobs:        jobQueue,
 			Node:        node,
 			SelfLabel:   selfContent,
 			SelfEmail:   selfEmail,
 			Peers:       peers,
+			Namespaces:  nsPeers,
 			ResolvePeer: resolvePeer,
 			CfgPath:     o.CfgPath,
 			Logs:        o.Logs,
@@ -694,6 +1030,17 @@ func RunPeer(p PeerParams) error {
 				return luaEngine.CallFunction(ctx, node.ID(), function, params)
 			},
 			Call: callMgr,
+			Pairing:         pairing.New(db),
+			Actions:         actionRunner,
+			Rules:           rulesEngine,
+			Secrets:         o.Secrets,
+			Retention:       pruner,
+			SiteSync:        siteSync,
+			DataSync:        dataSync,
+			Assets:          siteAssets,
+			RemoteAddr:      cfg.Viewer.RemoteAddr,
+			RemoteTLSCert:   cfg.Viewer.RemoteTLSCert,
+			RemoteTLSKey:    cfg.Viewer.RemoteTLSKey,
 			Cluster:         clusterMgr,
 			GQL:             gqlEngine,
 			DataFed:         dataFedMgr,
@@ -701,6 +1048,30 @@ func RunPeer(p PeerParams) error {
 		})
 	}
 
+	// ── Control API for scripting a headless peer (goop2 ctl)
+	if cfg.Control.Enabled {
+		ctl, err := control.Listen(o.PeerDir, cfg.Control.Addr, controlPeer{
+			node:        node,
+			peers:       peers,
+			groups:      grpMgr,
+			mq:          mqMgr,
+			chat:        chatMgr,
+			selfContent: selfContent,
+			publish:     publish,
+			version:     o.GoopClientVersion,
+			started:     started,
+		}.ops())
+		if err != nil {
+			log.Printf("CONTROL: not started: %v", err)
+		} else {
+			shut.Add(shutdown.StopAccepting, "control", func(context.Context) error {
+				ctl.Close()
+				return nil
+			})
+		}
+	}
+	stages.Finish()
+
 	// Track known peer content to suppress repetitive update logs.
 	seenContent := make(map[string]string)
 	node.RunPresenceLoop(ctx, func(m proto.PresenceMsg) {
@@ -711,7 +1082,7 @@ func RunPeer(p PeerParams) error {
 			// Use the peer table's Verified value — it is set exclusively by the
 			// rendezvous server and must not be overwritten by P2P gossip.
 			sp, _ := peers.Get(m.PeerID)
-			go db.UpsertCachedPeer(storage.CachedPeer{
+			cp := storage.CachedPeer{
 				PeerID:         m.PeerID,
 				Content:        m.Content,
 				Email:          m.Email,
@@ -721,7 +1092,8 @@ func RunPeer(p PeerParams) error {
 				PublicKey:      m.PublicKey,
 				Verified:       sp.Verified,
 				Addrs:          m.Addrs,
-			})
+			}
+			cacheWrite("peer:"+cp.PeerID, func() error { return db.UpsertCachedPeer(cp) })
 			go node.ProbePeer(ctx, m.PeerID)
 			warmAvatar(m.PeerID, m.AvatarHash)
 		case proto.TypeUpdate:
@@ -731,7 +1103,7 @@ func RunPeer(p PeerParams) error {
 				log.Printf("[%s] %s -> %q", m.Type, m.PeerID, m.Content)
 			}
 			sp, _ := peers.Get(m.PeerID)
-			go db.UpsertCachedPeer(storage.CachedPeer{
+			cp := storage.CachedPeer{
 				PeerID:         m.PeerID,
 				Content:        m.Content,
 				Email:          m.Email,
@@ -741,7 +1113,8 @@ func RunPeer(p PeerParams) error {
 				PublicKey:      m.PublicKey,
 				Verified:       sp.Verified,
 				Addrs:          m.Addrs,
-			})
+			}
+			cacheWrite("peer:"+cp.PeerID, func() error { return db.UpsertCachedPeer(cp) })
 			// If the peer is currently unreachable, their relay circuit may have
 			// just appeared — probe immediately rather than waiting for the next
 			// browser-triggered round (up to 5 s away).
@@ -754,6 +1127,16 @@ func RunPeer(p PeerParams) error {
 		}
 	})
 
+	// ── Echo peer (-echo-peer): a second peer to chat, group and call with
+	if o.EchoPeer {
+		stopEcho, err := startEchoPeer(ctx, o.PeerDir, node, time.Duration(cfg.Presence.HeartbeatSec)*time.Second, time.Duration(cfg.Presence.TTLSec)*time.Second)
+		if err != nil {
+			log.Printf("WARNING: %v", err)
+		} else {
+			defer stopEcho()
+		}
+	}
+
 	// Wire pulse function — when FetchSiteFile can't reach a peer, it asks
 	// the rendezvous to pulse the target peer's relay reservation.
 	if len(rvClients) > 0 {
@@ -772,17 +1155,18 @@ func RunPeer(p PeerParams) error {
 
 	// Register NaCl public key with encryption service(s) after first publish.
 	if cfg.P2P.NaClPublicKey != "" {
+		policy := jobs.Remote
+		policy.Timeout = EncryptionRegisterTimeout
 		for _, c := range rvClients {
 			cc := c
-			go func() {
-				regCtx, cancel := context.WithTimeout(ctx, EncryptionRegisterTimeout)
-				defer cancel()
-				if err := cc.RegisterEncryptionKey(regCtx, node.ID(), cfg.P2P.NaClPublicKey); err != nil {
-					log.Printf("encryption: key registration failed: %v", err)
-				} else {
-					log.Printf("encryption: public key registered via %s", cc.BaseURL)
+			jobQueue.Submit("encryption-register", cc.BaseURL, policy, func(jctx context.Context) error {
+				if err := cc.RegisterEncryptionKey(jctx, node.ID(), cfg.P2P.NaClPublicKey); err != nil {
+					log.Printf("encryption: key registration via %s failed: %v", cc.BaseURL, err)
+					return err
 				}
-			}()
+				log.Printf("encryption: public key registered via %s", cc.BaseURL)
+				return nil
+			})
 		}
 	}
 
@@ -814,6 +1198,7 @@ func RunPeer(p PeerParams) error {
 			refreshInterval = time.Duration(relayInfo.RefreshIntervalSec) * time.Second
 		}
 		node.StartRelayRefresh(ctx, refreshInterval)
+		node.StartRelayReports(ctx, RelayReportInterval, relayClient.ReportRelay)
 	}
 
 	go func() {
@@ -842,7 +1227,7 @@ func RunPeer(p PeerParams) error {
 			case <-ctx.Done():
 				return
 			case <-t.C:
-				// Re-read grace period from config once every 5 minutes.
+				// Re-read grace period and peer cap from config once every 5 minutes.
 				graceRefresh++
 				if graceRefresh >= ConfigRereadInterval {
 					graceRefresh = 0
@@ -851,21 +1236,51 @@ func RunPeer(p PeerParams) error {
 						if v >= 1 && v <= 60 {
 							graceMin = v
 						}
+						peers.SetCapacity(live.Viewer.MaxTrackedPeers)
+						for _, t := range nsPeers {
+							t.SetCapacity(live.Viewer.MaxTrackedPeers)
+						}
 					}
 				}
 				ttlCutoff := time.Now().Add(-time.Duration(cfg.Presence.TTLSec) * time.Second)
 				graceCutoff := time.Now().Add(-time.Duration(graceMin) * time.Minute)
 				peers.PruneStale(ttlCutoff, graceCutoff)
+				for _, t := range nsPeers {
+					t.PruneStale(ttlCutoff, graceCutoff)
+				}
 			}
 		}
 	}()
 
+	// Lua stops after the viewer, whose in-flight requests may call into it.
+	shut.Add(shutdown.StopAccepting, "lua", func(context.Context) error {
+		if luaEngine != nil {
+			luaEngine.Close()
+		}
+		return nil
+	})
+	shut.Add(shutdown.Announce, "offline", func(ctx context.Context) error {
+		publish(ctx, proto.TypeOffline)
+		return nil
+	})
+
 	<-ctx.Done()
 	log.Println("========================================")
-	log.Println("PEER: Context cancelled, sending offline message...")
+	log.Println("PEER: Context cancelled, shutting down...")
 	log.Println("========================================")
-	publish(context.Background(), proto.TypeOffline)
-	log.Println("PEER: Offline message sent")
+	deadline := DefaultShutdownTimeout
+	if live, err := config.LoadPartial(o.CfgPath); err == nil && live.P2P.ShutdownTimeoutSec > 0 {
+		deadline = time.Duration(live.P2P.ShutdownTimeoutSec) * time.Second
+	}
+	report := shut.Run(deadline)
 	avatarCache.Clear()
+	if err := shutdown.MarkClean(o.PeerDir, started, report); err != nil {
+		log.Printf("SHUTDOWN: marker: %v", err)
+	}
+	if report.Clean {
+		log.Printf("PEER: shutdown complete in %dms", report.ElapsedMs)
+	} else {
+		log.Printf("PEER: shutdown incomplete after %dms, next start will check the database", report.ElapsedMs)
+	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/petervdpas/goop2/internal/app/shared"
+	"github.com/petervdpas/goop2/internal/app/startup"
 	"github.com/petervdpas/goop2/internal/avatar"
 	"github.com/petervdpas/goop2/internal/config"
 	"github.com/petervdpas/goop2/internal/rendezvous"
@@ -13,10 +14,10 @@ import (
 
 // RunRendezvous starts goop2 in rendezvous-only mode.
 // No P2P node — just the rendezvous server and a minimal settings viewer.
-func RunRendezvous(ctx context.Context, o shared.ModeOpts, cfg config.Config, rv *rendezvous.Server, selfContent, selfEmail func() string, progress func(int, int, string)) error {
+func RunRendezvous(ctx context.Context, o shared.ModeOpts, cfg config.Config, rv *rendezvous.Server, selfContent, selfEmail func() string, stages *startup.Reporter) error {
 	log.Printf("mode: rendezvous-only")
 
-	progress(1, 2, "Starting viewer")
+	stages.Begin("viewer", "Starting viewer")
 
 	if cfg.Viewer.HTTPAddr != "" {
 		addr, url, _ := shared.NormalizeLocalViewer(cfg.Viewer.HTTPAddr)
@@ -38,10 +39,13 @@ func RunRendezvous(ctx context.Context, o shared.ModeOpts, cfg config.Config, rv
 			RendezvousURL: rvURL,
 			AvatarStore:   avatarStore,
 			BridgeURL:     o.BridgeURL,
+			Startup:       func() any { return stages.Report() },
 			TopologyFunc:  topoFn,
+			Secrets:       o.Secrets,
 		})
 		log.Printf("📋 Settings viewer: %s", url)
 	}
+	stages.Finish()
 
 	<-ctx.Done()
 	return nil
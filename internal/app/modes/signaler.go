@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -11,6 +12,19 @@ import (
 	"github.com/petervdpas/goop2/internal/mq"
 )
 
+// nativeCalls reports whether calls run on the Go/Pion stack rather than
+// the browser's WebRTC: stack "native" or "browser" decides, and "" or
+// "auto" picks native on Linux only.
+func nativeCalls(stack string) bool {
+	switch stack {
+	case "native":
+		return true
+	case "browser":
+		return false
+	}
+	return runtime.GOOS == "linux"
+}
+
 // mqSignalerAdapter bridges mq.Transport to call.Signaler for WebRTC signaling.
 // This is the only place that imports both packages — call knows nothing about mq.
 type mqSignalerAdapter struct {
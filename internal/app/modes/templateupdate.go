@@ -0,0 +1,91 @@
+package modes
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// templateUpdates periodically compares the installed store template with
+// the store's latest version and tells the browser once per new version.
+// State lives in _meta: template_source and template_manifest are written
+// on apply, template_latest_version and template_update_notified here.
+type templateUpdates struct {
+	db      *storage.DB
+	mq      *mq.Manager
+	clients []*rendezvous.Client
+	cfgPath string
+	peerID  string
+}
+
+func (t *templateUpdates) run(ctx context.Context, interval time.Duration) {
+	t.check(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.check(ctx)
+		}
+	}
+}
+
+func (t *templateUpdates) check(ctx context.Context) {
+	if t.db.GetMeta("template_source") != "store" {
+		return
+	}
+	cfg, err := config.LoadPartial(t.cfgPath)
+	if err != nil || cfg.Viewer.ActiveTemplate == "" {
+		return
+	}
+	dir := cfg.Viewer.ActiveTemplate
+
+	var installed rendezvous.StoreMeta
+	json.Unmarshal([]byte(t.db.GetMeta("template_manifest")), &installed)
+
+	latest, ok := t.latest(ctx, dir)
+	if !ok {
+		return
+	}
+	t.db.SetMeta("template_latest_version", latest.Version)
+	if !rendezvous.IsNewerVersion(latest.Version, installed.Version) {
+		return
+	}
+	if t.db.GetMeta("template_update_notified") == latest.Version {
+		return
+	}
+	log.Printf("template: update available for %q: %s → %s", dir, installed.Version, latest.Version)
+	t.mq.PublishTemplateUpdate(mq.TemplateUpdatePayload{
+		Template:  dir,
+		Name:      latest.Name,
+		Installed: installed.Version,
+		Latest:    latest.Version,
+	})
+	t.db.SetMeta("template_update_notified", latest.Version)
+}
+
+// latest returns the store entry for dir from the first rendezvous that lists it.
+func (t *templateUpdates) latest(ctx context.Context, dir string) (rendezvous.StoreMeta, bool) {
+	ctx, cancel := context.WithTimeout(ctx, TemplateUpdateTimeout)
+	defer cancel()
+	for _, c := range t.clients {
+		list, err := c.ListTemplates(ctx, t.peerID)
+		if err != nil {
+			continue
+		}
+		for _, m := range list {
+			if m.Dir == dir {
+				return m, true
+			}
+		}
+	}
+	return rendezvous.StoreMeta{}, false
+}
@@ -6,8 +6,23 @@ const (
 	PeerKeyFetchTimeout       = 2 * time.Second  // fetch peer's public key from rendezvous
 	EncryptionRegisterTimeout = 3 * time.Second  // register public key with rendezvous
 	DefaultRelayRefresh       = 90 * time.Second // periodic relay reservation health check
+	RelayReportInterval       = 5 * time.Minute  // relay health report to the rendezvous running the relay
 	PruneCheckInterval        = 1 * time.Second  // peer table prune tick
 	ConfigRereadInterval      = 300              // re-read config every N prune ticks (5 min at 1s)
 	MQCallSignalTimeout       = 2 * time.Second  // MQ send for call signaling messages
 	AvatarWarmTimeout         = 3 * time.Second  // background avatar cache warming
+	CallRingTimeout           = 60 * time.Second // unanswered call is logged as missed
+	RetentionInterval         = 1 * time.Hour    // forget peers past peer_retention_days
+	TemplateUpdateInterval    = 6 * time.Hour    // compare the installed store template with the store
+	TemplateUpdateTimeout     = 10 * time.Second // store listing for the update check
+	SiteAssetsInterval        = 2 * time.Minute  // rescan the site for new or changed images
+	SiteSyncInterval          = 1 * time.Minute  // sync followed sites whose announced hash changed
+	DataSyncInterval          = 5 * time.Second  // push and pull the group tables of joined template groups
+	DigestReportTimeout       = 5 * time.Second  // report a missed event for email digests
+	EchoAnnounceDelay         = 2 * time.Second  // echo peer: first presence, once gossipsub has meshed
+	EchoAnswerDelay           = 2 * time.Second  // echo peer: let an incoming call ring before answering
+	EchoJoinTimeout           = 5 * time.Second  // echo peer: join a group it was invited to
+	EchoListenPoll            = 2 * time.Second  // echo peer: reopen the listen audio stream while playing
+	DefaultShutdownTimeout    = 10 * time.Second // all shutdown phases, unless p2p.shutdown_timeout_sec is set
+	ViewerDrainTimeout        = 3 * time.Second  // shutdown: in-flight viewer requests before listeners close
 )
@@ -12,8 +12,12 @@ import (
 
 	"github.com/petervdpas/goop2/internal/app/modes"
 	"github.com/petervdpas/goop2/internal/app/shared"
+	"github.com/petervdpas/goop2/internal/app/startup"
 	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/p2p"
 	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/secrets"
+	"github.com/petervdpas/goop2/internal/timeouts"
 	"github.com/petervdpas/goop2/internal/util"
 	"github.com/petervdpas/goop2/internal/viewer"
 )
@@ -24,14 +28,24 @@ type Options struct {
 	Cfg               config.Config
 	BridgeURL         string
 	GoopClientVersion string
-	Progress          func(step, total int, label string)
+	EchoPeer          bool                // run an in-process echo peer for development
+	OnStage           func(startup.Stage) // called as each startup stage starts and ends
 }
 
 func Run(ctx context.Context, opt Options) error {
 	logBuf := viewer.NewLogBuffer(800)
 	log.SetOutput(logBuf)
 
+	// Secrets before anything logs a token: from here on log output is
+	// redacted.
+	sec, err := secrets.Open(SecretsDir(opt.PeerDir))
+	if err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+	log.SetOutput(sec.Writer(logBuf))
+
 	logBanner(opt.PeerDir, opt.CfgPath)
+	logConfigWarnings(opt.CfgPath)
 
 	mo := shared.ModeOpts{
 		PeerDir:           opt.PeerDir,
@@ -39,11 +53,16 @@ func Run(ctx context.Context, opt Options) error {
 		Logs:              logBuf,
 		BridgeURL:         opt.BridgeURL,
 		GoopClientVersion: opt.GoopClientVersion,
+		EchoPeer:          opt.EchoPeer,
+		Secrets:           sec,
 	}
-	return runPeer(ctx, mo, opt.Cfg, opt.Progress)
+	stages := startup.New(0, opt.OnStage)
+	err = runPeer(ctx, mo, opt.Cfg, stages)
+	stages.Fail(err)
+	return err
 }
 
-func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress func(int, int, string)) error {
+func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, stages *startup.Reporter) error {
 	if cfg.P2P.NaClPublicKey == "" || cfg.P2P.NaClPrivateKey == "" {
 		pub, priv, err := box.GenerateKey(rand.Reader)
 		if err != nil {
@@ -51,35 +70,39 @@ func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress
 		}
 		cfg.P2P.NaClPublicKey = base64.StdEncoding.EncodeToString(pub[:])
 		cfg.P2P.NaClPrivateKey = base64.StdEncoding.EncodeToString(priv[:])
-		if err := config.Save(o.CfgPath, cfg); err != nil {
+		if _, err := config.Update(o.CfgPath, func(c *config.Config) error {
+			c.P2P.NaClPublicKey, c.P2P.NaClPrivateKey = cfg.P2P.NaClPublicKey, cfg.P2P.NaClPrivateKey
+			return nil
+		}); err != nil {
 			return fmt.Errorf("save NaCl keypair: %w", err)
 		}
 		log.Printf("NaCl keypair generated and persisted")
 	}
 
-	emit := progress
-	if emit == nil {
-		emit = func(int, int, string) {}
-	}
-	progress = func(s, t int, label string) {
-		emit(s, t, label)
-		time.Sleep(ProgressEmitDelay)
+	// Network deadlines from the "timeouts" section, before anything dials.
+	timeouts.Use(cfg.Timeouts.Durations())
+
+	// Tor mode: from here on everything dials through the SOCKS5 proxy.
+	if tor := cfg.P2P.Tor; tor.Enabled {
+		if err := util.UseSOCKS5(tor.SOCKS()); err != nil {
+			return err
+		}
+		if err := p2p.UseTor(tor.OnionAddress, cfg.P2P.ListenPort); err != nil {
+			return err
+		}
+		log.Printf("tor: outbound connections go through %s; calls are off", tor.SOCKS())
 	}
 
-	// Calculate total steps based on config.
-	// Rendezvous-only: rendezvous + viewer = 2 steps.
-	// Full peer: rendezvous(opt) + relay discovery + p2p node + database + services + viewer.
-	step := 0
-	total := 6 // relay + p2p + db + services + viewer + online
-	if cfg.Presence.RendezvousHost {
-		total++ // rendezvous server
+	// Stages by mode: relay, p2p, database, services and viewer for a full
+	// peer; the viewer alone otherwise. Hosting a rendezvous adds one.
+	total := 5
+	if cfg.Presence.RendezvousOnly || cfg.P2P.BridgeMode {
+		total = 1
 	}
-	if cfg.Presence.RendezvousOnly {
-		total = 2 // rendezvous + viewer only
-		if !cfg.Presence.RendezvousHost {
-			total = 1 // viewer only
-		}
+	if cfg.Presence.RendezvousHost {
+		total++
 	}
+	stages.SetTotal(total)
 
 	// ── Rendezvous server (optional)
 	var rv *rendezvous.Server
@@ -107,44 +130,130 @@ func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress
 			RecoveryGraceSec:   cfg.Presence.RelayRecoveryGraceSec,
 		})
 
-		// Wire external services (credits + registration + email + templates)
+		rv.SetSTUNPort(cfg.Presence.STUNPort)
+		rv.SetRelayLimits(rendezvous.RelayLimitsConfig{
+			DailyQuotaMB: cfg.Presence.RelayDailyQuotaMB,
+		})
+		rv.SetRegisterLimits(rendezvous.RegisterLimitsConfig{
+			PoWBits:  cfg.Presence.RegisterPoWBits,
+			MaxPerIP: cfg.Presence.RegisterMaxPerIP,
+		})
+		if len(cfg.Presence.Federation) > 0 {
+			keyFile := cfg.Presence.FederationKeyFile
+			if keyFile == "" {
+				keyFile = "data/federation.key"
+			}
+			fc := rendezvous.FederationConfig{KeyFile: util.ResolvePath(o.PeerDir, keyFile)}
+			for _, fp := range cfg.Presence.Federation {
+				fc.Peers = append(fc.Peers, rendezvous.FederationPeer{URL: fp.URL, ServerID: fp.ServerID})
+			}
+			if err := rv.SetFederation(fc); err != nil {
+				return fmt.Errorf("federation: %w", err)
+			}
+		}
+		if cfg.Presence.Metrics {
+			rv.EnableMetrics()
+		}
+		if cfg.Presence.Aggregator {
+			rv.SetAggregator(rendezvous.AggregatorConfig{
+				Upstream:      util.NormalizeURL(cfg.Presence.RendezvousWAN),
+				BatchInterval: time.Duration(cfg.Presence.AggregatorBatchSec) * time.Second,
+			})
+		}
+		if cfg.Presence.PublicSites {
+			rv.SetPublicSites(rendezvous.PublicSiteOptions{
+				MaxBytes:      int64(cfg.Presence.PublicSiteMaxMB) << 20,
+				MaxFiles:      cfg.Presence.PublicSiteMaxFiles,
+				MaxTotalBytes: int64(cfg.Presence.PublicSitesMaxTotalMB) << 20,
+			})
+		}
+		if err := rv.SetContentPolicy(rendezvous.ContentPolicy{
+			Action:         cfg.Presence.LabelPolicy,
+			MaxLabelLen:    cfg.Presence.LabelMaxLen,
+			BannedWords:    cfg.Presence.LabelBannedWords,
+			BannedPatterns: cfg.Presence.LabelBannedPatterns,
+			StripURLs:      cfg.Presence.LabelStripURLs,
+			MaxEmoji:       cfg.Presence.LabelMaxEmoji,
+		}); err != nil {
+			return fmt.Errorf("label policy: %w", err)
+		}
+		brand := cfg.Presence.Branding
+		branding := rendezvous.Branding{Title: brand.Title, Colors: brand.Colors}
+		if brand.Logo != "" {
+			branding.LogoFile = util.ResolvePath(o.PeerDir, brand.Logo)
+		}
+		if brand.CSSFile != "" {
+			branding.CSSFile = util.ResolvePath(o.PeerDir, brand.CSSFile)
+		}
+		for _, l := range brand.FooterLinks {
+			branding.FooterLinks = append(branding.FooterLinks, rendezvous.BrandLink{Label: l.Label, URL: l.URL})
+		}
+		if err := rv.SetBranding(branding); err != nil {
+			return fmt.Errorf("branding: %w", err)
+		}
+		if cfg.Presence.DocsDir != "" {
+			if err := rv.SetDocsDir(util.ResolvePath(o.PeerDir, cfg.Presence.DocsDir)); err != nil {
+				return fmt.Errorf("docs dir: %w", err)
+			}
+		}
+
+		// Wire external services (credits + registration + email + templates).
+		// Admin tokens may name a secret ("secret:NAME"); the providers then
+		// look it up per request, so a rotation needs no restart.
 		if cfg.Presence.UseServices {
+			token := func(service, value string) func() string {
+				if _, err := o.Secrets.Resolve(value); err != nil {
+					log.Printf("WARNING: %s admin token: %v", service, err)
+				}
+				return o.Secrets.Ref(value)
+			}
 			setupMicroService("Credits", cfg.Presence.CreditsURL, func() {
-				rv.SetCreditProvider(rendezvous.NewRemoteCreditProvider(
-					cfg.Presence.CreditsURL, rv.GetEmailForPeer, rv.GetTokenForPeer, cfg.Presence.CreditsAdminToken))
+				p := rendezvous.NewRemoteCreditProvider(
+					cfg.Presence.CreditsURL, rv.GetEmailForPeer, rv.GetTokenForPeer, "")
+				p.SetAdminTokenSource(token("Credits", cfg.Presence.CreditsAdminToken))
+				rv.SetCreditProvider(p)
+				rv.SetAuthorSharePct(cfg.Presence.TemplateAuthorSharePct)
 			})
 			setupMicroService("Registration", cfg.Presence.RegistrationURL, func() {
-				rv.SetRegistrationProvider(rendezvous.NewRemoteRegistrationProvider(
-					cfg.Presence.RegistrationURL, cfg.Presence.RegistrationAdminToken))
+				p := rendezvous.NewRemoteRegistrationProvider(cfg.Presence.RegistrationURL, "")
+				p.SetAdminTokenSource(token("Registration", cfg.Presence.RegistrationAdminToken))
+				rv.SetRegistrationProvider(p)
 			})
 			setupMicroService("Email", cfg.Presence.EmailURL, func() {
 				rv.SetEmailProvider(rendezvous.NewRemoteEmailProvider(cfg.Presence.EmailURL))
 			})
 			setupMicroService("Templates", cfg.Presence.TemplatesURL, func() {
-				rv.SetTemplatesProvider(rendezvous.NewRemoteTemplatesProvider(
-					cfg.Presence.TemplatesURL, cfg.Presence.TemplatesAdminToken))
+				p := rendezvous.NewRemoteTemplatesProvider(cfg.Presence.TemplatesURL, "")
+				p.SetAdminTokenSource(token("Templates", cfg.Presence.TemplatesAdminToken))
+				rv.SetTemplatesProvider(p)
 			})
 			setupMicroService("Bridge", cfg.Presence.BridgeURL, func() {
-				rv.SetBridgeProvider(rendezvous.NewRemoteBridgeProvider(
-					cfg.Presence.BridgeURL, cfg.Presence.BridgeAdminToken))
+				p := rendezvous.NewRemoteBridgeProvider(cfg.Presence.BridgeURL, "")
+				p.SetAdminTokenSource(token("Bridge", cfg.Presence.BridgeAdminToken))
+				rv.SetBridgeProvider(p)
 			})
 			setupMicroService("Encryption", cfg.Presence.EncryptionURL, func() {
-				rv.SetEncryptionProvider(rendezvous.NewRemoteEncryptionProvider(
-					cfg.Presence.EncryptionURL, cfg.Presence.EncryptionAdminToken))
+				p := rendezvous.NewRemoteEncryptionProvider(cfg.Presence.EncryptionURL, "")
+				p.SetAdminTokenSource(token("Encryption", cfg.Presence.EncryptionAdminToken))
+				rv.SetEncryptionProvider(p)
 			})
 		}
 
 		// Local template store fallback (works with or without services)
 		if cfg.Presence.TemplatesDir != "" && (cfg.Presence.TemplatesURL == "" || !cfg.Presence.UseServices) {
 			dir := util.ResolvePath(o.PeerDir, cfg.Presence.TemplatesDir)
-			if store := rendezvous.NewLocalTemplateStore(dir); store != nil {
+			store := rendezvous.NewLocalTemplateStore(dir)
+			if cfg.Presence.TemplatePublish {
+				store = rendezvous.NewPublishingTemplateStore(dir, int64(cfg.Presence.TemplatePublishMaxKB)<<10)
+				log.Printf("Template publishing: on (max %d KB per bundle)", cfg.Presence.TemplatePublishMaxKB)
+			}
+			if store != nil {
 				log.Printf("Local template store: %s (%d templates)", dir, store.Count())
 				rv.SetLocalTemplateStore(store)
 			}
 		}
 
-		step++
-		progress(step, total, "Starting rendezvous server")
+		stages.Begin("rendezvous", "Starting rendezvous server")
 
 		if err := rv.Start(ctx); err != nil {
 			return err
@@ -167,7 +276,7 @@ func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress
 	}
 
 	selfVideoDisabled := func() bool {
-		return cfg.Viewer.VideoDisabled
+		return cfg.CallsDisabled()
 	}
 
 	selfActiveTemplate := func() string {
@@ -185,11 +294,11 @@ func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress
 	}
 
 	if cfg.Presence.RendezvousOnly {
-		return modes.RunRendezvous(ctx, o, cfg, rv, selfContent, selfEmail, progress)
+		return modes.RunRendezvous(ctx, o, cfg, rv, selfContent, selfEmail, stages)
 	}
 
 	if cfg.P2P.BridgeMode {
-		return modes.RunBridge(ctx, o, cfg, selfContent, selfEmail, progress)
+		return modes.RunBridge(ctx, o, cfg, selfContent, selfEmail, stages)
 	}
 
 	selfPublicKey := func() string { return cfg.P2P.NaClPublicKey }
@@ -204,8 +313,20 @@ func runPeer(ctx context.Context, o shared.ModeOpts, cfg config.Config, progress
 		SelfActiveTemplate:    selfActiveTemplate,
 		SelfPublicKey:         selfPublicKey,
 		SelfVerificationToken: selfVerificationToken,
-		Progress:              progress,
-		Step:                  step,
-		Total:                 total,
+		Stages:                stages,
 	})
 }
+
+// logConfigWarnings logs what config.Check flags in a config that loaded,
+// such as misspelled keys, which would otherwise be ignored silently.
+func logConfigWarnings(cfgPath string) {
+	issues, err := config.Check(cfgPath)
+	if err != nil {
+		return
+	}
+	for _, is := range issues {
+		if is.Warning {
+			log.Printf("config: %s", is.Message)
+		}
+	}
+}
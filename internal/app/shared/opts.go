@@ -3,6 +3,7 @@ package shared
 import (
 	"strings"
 
+	"github.com/petervdpas/goop2/internal/secrets"
 	"github.com/petervdpas/goop2/internal/viewer"
 )
 
@@ -13,6 +14,8 @@ type ModeOpts struct {
 	Logs              *viewer.LogBuffer
 	BridgeURL         string
 	GoopClientVersion string
+	EchoPeer          bool // run an in-process echo peer for development
+	Secrets           *secrets.Store
 }
 
 // NormalizeLocalViewer ensures the viewer only binds to localhost
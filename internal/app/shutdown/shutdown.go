@@ -0,0 +1,224 @@
+// Package shutdown runs a peer's teardown as ordered phases under one
+// deadline, and records in the peer directory whether the last run got
+// through all of them. A run that is killed, crashes or overruns its
+// deadline leaves the "running" marker behind, which the next start sees.
+package shutdown
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Phase orders the shutdown steps. Phases run in the order declared here;
+// steps within a phase run in the order they were added.
+type Phase int
+
+const (
+	StopAccepting Phase = iota // listeners and engines that take new work
+	FlushQueues                // pending writes and queued outbound messages
+	Announce                   // offline presence
+	CloseGroups                // group types, calls and the group manager
+	Checkpoint                 // databases: checkpoint, then close
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"stop-accepting", "flush-queues", "announce", "close-groups", "checkpoint"}
+
+func (p Phase) String() string {
+	if p < 0 || p >= numPhases {
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// MarkerFile is the name of the marker kept in the peer directory.
+const MarkerFile = "shutdown.json"
+
+// Marker states.
+const (
+	StateRunning = "running"
+	StateClean   = "clean"
+)
+
+// StepResult is the outcome of one step.
+type StepResult struct {
+	Phase      string `json:"phase"`
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"` // deadline passed before it started
+}
+
+// Report describes a finished Run.
+type Report struct {
+	Clean     bool         `json:"clean"` // every step ran without error within the deadline
+	ElapsedMs int64        `json:"elapsed_ms"`
+	Steps     []StepResult `json:"steps"`
+}
+
+// Marker is what MarkerFile holds.
+type Marker struct {
+	State   string  `json:"state"`
+	PID     int     `json:"pid"`
+	Started int64   `json:"started"`           // Unix ms
+	Stopped int64   `json:"stopped,omitempty"` // Unix ms, set with StateClean
+	Report  *Report `json:"report,omitempty"`
+}
+
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Coordinator collects shutdown steps while services start and runs them
+// once, in phase order, when the peer stops.
+type Coordinator struct {
+	mu    sync.Mutex
+	steps [numPhases][]step
+	ran   bool
+}
+
+// New creates an empty coordinator.
+func New() *Coordinator {
+	return &Coordinator{}
+}
+
+// Add registers fn under phase. fn should return once ctx is done; a step
+// that does not is abandoned when the deadline passes.
+func (c *Coordinator) Add(phase Phase, name string, fn func(ctx context.Context) error) {
+	if phase < 0 || phase >= numPhases {
+		panic("shutdown: unknown phase " + phase.String())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps[phase] = append(c.steps[phase], step{name: name, fn: fn})
+}
+
+// Run executes the steps under a shared deadline. Once the deadline passes
+// the step in progress is abandoned and the remaining ones are skipped.
+// Run only executes once; later calls return an empty, unclean report.
+func (c *Coordinator) Run(deadline time.Duration) Report {
+	c.mu.Lock()
+	if c.ran {
+		c.mu.Unlock()
+		return Report{}
+	}
+	c.ran = true
+	steps := c.steps
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	start := time.Now()
+	rep := Report{Clean: true}
+	for phase, list := range steps {
+		for _, s := range list {
+			res := StepResult{Phase: Phase(phase).String(), Name: s.name}
+			if ctx.Err() != nil {
+				res.Skipped = true
+				rep.Clean = false
+				rep.Steps = append(rep.Steps, res)
+				continue
+			}
+			t0 := time.Now()
+			err := runStep(ctx, s.fn)
+			res.DurationMs = time.Since(t0).Milliseconds()
+			if err != nil {
+				res.Error = err.Error()
+				rep.Clean = false
+				log.Printf("SHUTDOWN: %s/%s: %v", res.Phase, s.name, err)
+			}
+			rep.Steps = append(rep.Steps, res)
+		}
+	}
+	rep.ElapsedMs = time.Since(start).Milliseconds()
+	return rep
+}
+
+// runStep runs fn and gives up on it when ctx is done.
+func runStep(ctx context.Context, fn func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("abandoned: %w", ctx.Err())
+	}
+}
+
+// Begin reads the marker left by the previous run and replaces it with a
+// "running" one for this run. found is false on the first start in dir;
+// clean reports whether the previous run finished its shutdown.
+func Begin(dir string) (prev Marker, found, clean bool, err error) {
+	data, rerr := os.ReadFile(filepath.Join(dir, MarkerFile))
+	switch {
+	case errors.Is(rerr, os.ErrNotExist):
+		clean = true
+	case rerr != nil:
+		return prev, false, false, rerr
+	default:
+		found = true
+		if json.Unmarshal(data, &prev) == nil && prev.State == StateClean {
+			clean = true
+		}
+	}
+	err = writeMarker(dir, Marker{State: StateRunning, PID: os.Getpid(), Started: time.Now().UnixMilli()})
+	return prev, found, clean, err
+}
+
+// MarkClean records a finished shutdown. It does nothing when rep is not
+// clean, so the "running" marker from Begin stays for the next start.
+func MarkClean(dir string, started time.Time, rep Report) error {
+	if !rep.Clean {
+		return nil
+	}
+	return writeMarker(dir, Marker{
+		State:   StateClean,
+		PID:     os.Getpid(),
+		Started: started.UnixMilli(),
+		Stopped: time.Now().UnixMilli(),
+		Report:  &rep,
+	})
+}
+
+func writeMarker(dir string, m Marker) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, MarkerFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, MarkerFile))
+}
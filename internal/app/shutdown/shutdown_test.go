@@ -0,0 +1,119 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_PhaseOrder(t *testing.T) {
+	c := New()
+	var got []string
+	add := func(p Phase, name string) {
+		c.Add(p, name, func(context.Context) error {
+			got = append(got, name)
+			return nil
+		})
+	}
+	add(Checkpoint, "db")
+	add(Announce, "offline")
+	add(StopAccepting, "viewer")
+	add(CloseGroups, "groups")
+	add(FlushQueues, "outbox")
+	add(StopAccepting, "lua")
+
+	rep := c.Run(time.Second)
+	if !rep.Clean {
+		t.Fatalf("report not clean: %+v", rep)
+	}
+	want := "viewer,lua,outbox,offline,groups,db"
+	if s := strings.Join(got, ","); s != want {
+		t.Errorf("order = %s, want %s", s, want)
+	}
+	if len(rep.Steps) != 6 || rep.Steps[0].Phase != "stop-accepting" {
+		t.Errorf("steps = %+v", rep.Steps)
+	}
+}
+
+func TestRun_DeadlineSkipsRemaining(t *testing.T) {
+	c := New()
+	c.Add(FlushQueues, "stuck", func(context.Context) error {
+		time.Sleep(time.Second) // ignores ctx on purpose
+		return nil
+	})
+	ran := false
+	c.Add(Checkpoint, "db", func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	start := time.Now()
+	rep := c.Run(50 * time.Millisecond)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Run waited %v for an abandoned step", time.Since(start))
+	}
+	if rep.Clean || ran {
+		t.Fatalf("clean=%v ran=%v, want an unclean run with db skipped", rep.Clean, ran)
+	}
+	if rep.Steps[0].Error == "" || !rep.Steps[1].Skipped {
+		t.Errorf("steps = %+v", rep.Steps)
+	}
+}
+
+func TestRun_ErrorMakesUnclean(t *testing.T) {
+	c := New()
+	c.Add(Checkpoint, "db", func(context.Context) error { return errors.New("disk full") })
+	c.Add(Checkpoint, "close", func(context.Context) error { return nil })
+	rep := c.Run(time.Second)
+	if rep.Clean || rep.Steps[0].Error != "disk full" || rep.Steps[1].Error != "" {
+		t.Errorf("report = %+v", rep)
+	}
+	if again := c.Run(time.Second); len(again.Steps) != 0 {
+		t.Errorf("second Run executed steps: %+v", again)
+	}
+}
+
+func TestMarker(t *testing.T) {
+	dir := t.TempDir()
+
+	// First start: no marker yet.
+	_, found, clean, err := Begin(dir)
+	if err != nil || found || !clean {
+		t.Fatalf("first Begin: found=%v clean=%v err=%v", found, clean, err)
+	}
+
+	// Killed run: the "running" marker is left behind.
+	prev, found, clean, err := Begin(dir)
+	if err != nil || !found || clean || prev.State != StateRunning {
+		t.Fatalf("after crash: prev=%+v found=%v clean=%v err=%v", prev, found, clean, err)
+	}
+
+	// Unclean report keeps the running marker.
+	if err := MarkClean(dir, time.Now(), Report{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, clean, _ := Begin(dir); clean {
+		t.Error("unclean report was recorded as clean")
+	}
+
+	started := time.Now()
+	if err := MarkClean(dir, started, Report{Clean: true}); err != nil {
+		t.Fatal(err)
+	}
+	prev, found, clean, err = Begin(dir)
+	if err != nil || !found || !clean || prev.Started != started.UnixMilli() || prev.Report == nil {
+		t.Fatalf("after clean stop: prev=%+v found=%v clean=%v err=%v", prev, found, clean, err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, MarkerFile+".*"))
+	if len(matches) != 0 {
+		t.Errorf("temp files left: %v", matches)
+	}
+	if _, err := os.Stat(filepath.Join(dir, MarkerFile)); err != nil {
+		t.Error(err)
+	}
+}
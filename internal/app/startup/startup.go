@@ -0,0 +1,198 @@
+// Package startup records the stages a peer goes through while it starts,
+// with their timings, so a slow or failed start can be traced to the stage
+// that held it up instead of a progress bar that stops moving.
+package startup
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Stage states.
+const (
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// SlowStage is how long a stage may take before it is logged.
+const SlowStage = 2 * time.Second
+
+// Stage is one step of the startup.
+type Stage struct {
+	Name       string `json:"name"`                  // stable key, e.g. "relay"
+	Label      string `json:"label"`                 // shown to the user
+	Step       int    `json:"step"`                  // 1-based position
+	Total      int    `json:"total"`                 // stages expected in this mode
+	State      string `json:"state"`                 // running, done or failed
+	StartedAt  int64  `json:"started_at"`            // Unix ms
+	FinishedAt int64  `json:"finished_at,omitempty"` // Unix ms
+	DurationMs int64  `json:"duration_ms"`           // so far, while running
+	Detail     string `json:"detail,omitempty"`      // what the stage found, e.g. no relay
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the whole startup so far.
+type Report struct {
+	StartedAt  int64   `json:"started_at"`            // Unix ms
+	FinishedAt int64   `json:"finished_at,omitempty"` // Unix ms, 0 while starting
+	Done       bool    `json:"done"`                  // all stages finished
+	Failed     bool    `json:"failed"`
+	Stages     []Stage `json:"stages"`
+}
+
+// Reporter collects stages. Its methods are safe for concurrent use and on a
+// nil Reporter, which records nothing.
+type Reporter struct {
+	mu       sync.Mutex
+	total    int
+	started  time.Time
+	finished time.Time
+	failed   bool
+	stages   []Stage
+	onChange func(Stage)
+	now      func() time.Time
+}
+
+// New creates a reporter expecting total stages. onChange, if set, is
+// called with every stage that starts or ends.
+func New(total int, onChange func(Stage)) *Reporter {
+	return &Reporter{total: total, started: time.Now(), onChange: onChange, now: time.Now}
+}
+
+// SetTotal changes the number of expected stages once the mode is known.
+func (r *Reporter) SetTotal(total int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.total = total
+	r.mu.Unlock()
+}
+
+// Begin ends the running stage and starts the next.
+func (r *Reporter) Begin(name, label string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	var changed []Stage
+	if st, ok := r.endLocked(StateDone, ""); ok {
+		changed = append(changed, st)
+	}
+	st := Stage{
+		Name:      name,
+		Label:     label,
+		Step:      len(r.stages) + 1,
+		Total:     max(r.total, len(r.stages)+1),
+		State:     StateRunning,
+		StartedAt: r.now().UnixMilli(),
+	}
+	r.stages = append(r.stages, st)
+	changed = append(changed, st)
+	r.mu.Unlock()
+	r.emit(changed...)
+}
+
+// Note sets the detail of the running stage.
+func (r *Reporter) Note(detail string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if n := len(r.stages); n > 0 && r.stages[n-1].State == StateRunning {
+		r.stages[n-1].Detail = detail
+	}
+	r.mu.Unlock()
+}
+
+// Finish ends the running stage; the startup is complete.
+func (r *Reporter) Finish() {
+	r.end(StateDone, "")
+}
+
+// Fail ends the running stage with err; the startup is over.
+func (r *Reporter) Fail(err error) {
+	if err == nil {
+		return
+	}
+	r.end(StateFailed, err.Error())
+}
+
+func (r *Reporter) end(state, errText string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.finished.IsZero() {
+		r.mu.Unlock()
+		return
+	}
+	st, ok := r.endLocked(state, errText)
+	r.finished = r.now()
+	r.failed = state == StateFailed
+	elapsed := r.finished.Sub(r.started)
+	r.mu.Unlock()
+
+	if ok {
+		r.emit(st)
+	}
+	if state == StateFailed {
+		log.Printf("STARTUP: failed after %s: %s", elapsed.Round(time.Millisecond), errText)
+	} else {
+		log.Printf("STARTUP: ready in %s", elapsed.Round(time.Millisecond))
+	}
+}
+
+// endLocked closes the running stage, if any.
+func (r *Reporter) endLocked(state, errText string) (Stage, bool) {
+	n := len(r.stages)
+	if n == 0 || r.stages[n-1].State != StateRunning {
+		return Stage{}, false
+	}
+	st := &r.stages[n-1]
+	st.State = state
+	st.Error = errText
+	st.FinishedAt = r.now().UnixMilli()
+	st.DurationMs = st.FinishedAt - st.StartedAt
+	if st.DurationMs >= SlowStage.Milliseconds() {
+		log.Printf("STARTUP: %s took %d ms", st.Name, st.DurationMs)
+	}
+	return *st, true
+}
+
+func (r *Reporter) emit(stages ...Stage) {
+	if r.onChange == nil {
+		return
+	}
+	for _, st := range stages {
+		r.onChange(st)
+	}
+}
+
+// Report returns a copy of the stages so far.
+func (r *Reporter) Report() Report {
+	if r == nil {
+		return Report{Stages: []Stage{}}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := Report{
+		StartedAt: r.started.UnixMilli(),
+		Done:      !r.finished.IsZero(),
+		Failed:    r.failed,
+		Stages:    make([]Stage, len(r.stages)),
+	}
+	if rep.Done {
+		rep.FinishedAt = r.finished.UnixMilli()
+	}
+	copy(rep.Stages, r.stages)
+	now := r.now().UnixMilli()
+	for i := range rep.Stages {
+		if rep.Stages[i].State == StateRunning {
+			rep.Stages[i].DurationMs = now - rep.Stages[i].StartedAt
+		}
+	}
+	return rep
+}
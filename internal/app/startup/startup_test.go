@@ -0,0 +1,70 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReporter(t *testing.T) {
+	var events []Stage
+	r := New(2, func(st Stage) { events = append(events, st) })
+	clock := time.UnixMilli(1000)
+	r.now = func() time.Time { return clock }
+
+	r.Begin("relay", "Discovering relay")
+	clock = clock.Add(300 * time.Millisecond)
+	r.Note("no relay")
+	r.Begin("viewer", "Starting viewer")
+	clock = clock.Add(50 * time.Millisecond)
+	r.Finish()
+
+	want := []string{"relay running", "relay done", "viewer running", "viewer done"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, st := range events {
+		if got := st.Name + " " + st.State; got != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	rep := r.Report()
+	if !rep.Done || rep.Failed || len(rep.Stages) != 2 {
+		t.Fatalf("report = %+v", rep)
+	}
+	relay := rep.Stages[0]
+	if relay.DurationMs != 300 || relay.Detail != "no relay" || relay.Step != 1 || relay.Total != 2 {
+		t.Errorf("relay stage = %+v", relay)
+	}
+
+	// Nothing changes after the startup is over.
+	r.Fail(errors.New("late"))
+	if r.Report().Failed {
+		t.Error("Fail after Finish marked the startup failed")
+	}
+}
+
+func TestReporter_Fail(t *testing.T) {
+	r := New(0, nil)
+	r.Begin("database", "Opening database")
+	r.Fail(errors.New("disk full"))
+
+	rep := r.Report()
+	if !rep.Failed || rep.Stages[0].State != StateFailed || rep.Stages[0].Error != "disk full" {
+		t.Errorf("report = %+v", rep)
+	}
+	if rep.Stages[0].Total != 1 {
+		t.Errorf("total = %d, want at least the stages seen", rep.Stages[0].Total)
+	}
+}
+
+func TestReporter_Nil(t *testing.T) {
+	var r *Reporter
+	r.Begin("viewer", "Starting viewer")
+	r.Note("x")
+	r.Finish()
+	if rep := r.Report(); rep.Stages == nil || len(rep.Stages) != 0 {
+		t.Errorf("nil report = %+v", rep)
+	}
+}
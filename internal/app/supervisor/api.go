@@ -0,0 +1,141 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app/localapi"
+)
+
+// Handler serves the admin API:
+//
+//	GET  /status               all peers
+//	GET  /peers/{name}         one peer, with its recent output
+//	POST /peers/{name}/start   start a stopped peer
+//	POST /peers/{name}/stop    stop a peer
+//	POST /peers/{name}/restart restart a peer
+//	POST /reload               reread the daemon config
+//	POST /shutdown             stop all peers and exit (when shutdown is set)
+func (s *Supervisor) Handler(shutdown func()) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Status())
+	})
+	mux.HandleFunc("GET /peers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		st, err := s.Peer(r.PathValue("name"))
+		writeResult(w, st, err)
+	})
+	actions := map[string]func(string) (PeerStatus, error){
+		"start":   s.StartPeer,
+		"stop":    s.StopPeer,
+		"restart": s.RestartPeer,
+	}
+	mux.HandleFunc("POST /peers/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		fn := actions[r.PathValue("action")]
+		if fn == nil {
+			http.NotFound(w, r)
+			return
+		}
+		st, err := fn(r.PathValue("name"))
+		writeResult(w, st, err)
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.Reload()
+		writeResult(w, res, err)
+	})
+	mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, r *http.Request) {
+		if shutdown == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]bool{"ok": true})
+		shutdown()
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeResult(w http.ResponseWriter, v any, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownPeer):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeJSON(w, v)
+	}
+}
+
+// listen serves the admin API on the config's socket and, when set, on its
+// loopback admin_addr. The TCP listener needs the admin token; the Unix
+// socket relies on its file mode.
+func (s *Supervisor) listen(shutdown func()) (*localapi.Server, error) {
+	cfg := s.Config()
+	h := s.Handler(shutdown)
+	a, err := localapi.Listen("DAEMON: admin API", "daemon", cfg.Socket, h)
+	if err != nil {
+		return nil, fmt.Errorf("admin socket: %w", err)
+	}
+	if cfg.AdminAddr != "" {
+		if err := a.ListenTCP(cfg.AdminAddr, localapi.RequireToken(cfg.AdminToken, h, nil)); err != nil {
+			a.Close()
+			return nil, fmt.Errorf("admin_addr: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Client talks to a running daemon.
+type Client struct {
+	api *localapi.Client
+}
+
+// NewClient connects to the daemon of cfg: over admin_addr when it is set,
+// otherwise over the Unix socket.
+func NewClient(cfg Config) *Client {
+	token := ""
+	if cfg.AdminAddr != "" {
+		token = cfg.AdminToken
+	}
+	return &Client{api: localapi.NewClient("daemon", cfg.Socket, cfg.AdminAddr, token, StopTimeout+30*time.Second)}
+}
+
+func (c *Client) do(method, path string, out any) error {
+	return c.api.Do(method, path, nil, out)
+}
+
+// Status lists the daemon's peers.
+func (c *Client) Status() ([]PeerStatus, error) {
+	var out []PeerStatus
+	return out, c.do("GET", "/status", &out)
+}
+
+// Peer reports one peer with its recent output.
+func (c *Client) Peer(name string) (PeerStatus, error) {
+	var out PeerStatus
+	return out, c.do("GET", "/peers/"+name, &out)
+}
+
+// PeerAction runs start, stop or restart on a peer.
+func (c *Client) PeerAction(name, action string) (PeerStatus, error) {
+	var out PeerStatus
+	return out, c.do("POST", "/peers/"+name+"/"+action, &out)
+}
+
+// Reload makes the daemon reread its config.
+func (c *Client) Reload() (ReloadResult, error) {
+	var out ReloadResult
+	return out, c.do("POST", "/reload", &out)
+}
+
+// Shutdown stops the daemon and its peers.
+func (c *Client) Shutdown() error {
+	return c.do("POST", "/shutdown", nil)
+}
@@ -0,0 +1,113 @@
+// Package supervisor runs several peer directories as child processes of one
+// daemon: it restarts peers that exit, reloads its config without stopping
+// peers whose entry did not change, and serves a local admin API.
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Peer modes: the goop2 command each peer directory is run with.
+const (
+	ModePeer       = "peer"
+	ModeRendezvous = "rendezvous"
+)
+
+// DefaultSocket is the admin socket name, next to the daemon config.
+const DefaultSocket = "goop2d.sock"
+
+// PeerSpec is one supervised peer directory.
+type PeerSpec struct {
+	Name     string `json:"name"`
+	Dir      string `json:"dir"`                // relative to the daemon config
+	Mode     string `json:"mode,omitempty"`     // "peer" (default) or "rendezvous"
+	Disabled bool   `json:"disabled,omitempty"` // listed, but not started
+}
+
+// Config is the daemon config file, goop2d.json by default.
+type Config struct {
+	Socket     string     `json:"socket,omitempty"`      // admin API Unix socket (default goop2d.sock)
+	AdminAddr  string     `json:"admin_addr,omitempty"`  // optional loopback TCP address for the admin API
+	AdminToken string     `json:"admin_token,omitempty"` // bearer token required on admin_addr
+	Peers      []PeerSpec `json:"peers"`
+}
+
+var peerNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// LoadConfig reads and checks a daemon config. Relative paths are resolved
+// against the directory of the config file.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	base := filepath.Dir(path)
+	if cfg.Socket == "" {
+		cfg.Socket = DefaultSocket
+	}
+	cfg.Socket = resolve(base, cfg.Socket)
+
+	if cfg.AdminAddr != "" {
+		host, _, err := net.SplitHostPort(cfg.AdminAddr)
+		if err != nil {
+			return cfg, fmt.Errorf("admin_addr: %w", err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return cfg, fmt.Errorf("admin_addr %q: only loopback addresses are allowed", cfg.AdminAddr)
+		}
+		if cfg.AdminToken == "" {
+			return cfg, fmt.Errorf("admin_addr needs an admin_token")
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := range cfg.Peers {
+		p := &cfg.Peers[i]
+		if !peerNameRe.MatchString(p.Name) {
+			return cfg, fmt.Errorf("peer %d: invalid name %q", i+1, p.Name)
+		}
+		if seen[p.Name] {
+			return cfg, fmt.Errorf("peer %q listed twice", p.Name)
+		}
+		seen[p.Name] = true
+
+		switch p.Mode {
+		case "":
+			p.Mode = ModePeer
+		case ModePeer, ModeRendezvous:
+		default:
+			return cfg, fmt.Errorf("peer %q: unknown mode %q", p.Name, p.Mode)
+		}
+
+		if p.Dir == "" {
+			return cfg, fmt.Errorf("peer %q: missing dir", p.Name)
+		}
+		p.Dir = resolve(base, p.Dir)
+		if st, err := os.Stat(p.Dir); err != nil || !st.IsDir() {
+			return cfg, fmt.Errorf("peer %q: directory %s does not exist", p.Name, p.Dir)
+		}
+		if p.Mode == ModePeer {
+			if _, err := os.Stat(filepath.Join(p.Dir, "goop.json")); err != nil {
+				return cfg, fmt.Errorf("peer %q: %s has no goop.json (run goop2 init first)", p.Name, p.Dir)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func resolve(base, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(base, p)
+}
@@ -0,0 +1,553 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/config"
+)
+
+// Peer states.
+const (
+	StateStopped = "stopped" // not running and not restarted
+	StateRunning = "running"
+	StateBackoff = "backoff" // exited; restarted after a delay
+)
+
+const (
+	RestartMinDelay = time.Second      // first restart delay after a crash
+	RestartMaxDelay = time.Minute      // restart delays double up to this
+	StableAfter     = 2 * time.Minute  // a run this long resets the delay
+	StopTimeout     = 15 * time.Second // graceful shutdown before the process is killed
+	outputLines     = 50               // output lines kept per peer
+)
+
+// PeerStatus is a supervised peer as the admin API reports it.
+type PeerStatus struct {
+	Name      string   `json:"name"`
+	Dir       string   `json:"dir"`
+	Mode      string   `json:"mode"`
+	State     string   `json:"state"`
+	PID       int      `json:"pid,omitempty"`
+	StartedAt int64    `json:"started_at,omitempty"` // unix seconds of the current run
+	Restarts  int      `json:"restarts"`
+	LastExit  string   `json:"last_exit,omitempty"`
+	URL       string   `json:"url,omitempty"`    // viewer or rendezvous URL from goop.json
+	Output    []string `json:"output,omitempty"` // last lines printed, for /peers/<name>
+}
+
+// ReloadResult lists what a reload changed.
+type ReloadResult struct {
+	Started   []string `json:"started"`
+	Stopped   []string `json:"stopped"`
+	Restarted []string `json:"restarted"`
+}
+
+// Supervisor starts the peers of a daemon config and keeps them running.
+type Supervisor struct {
+	cfgPath   string
+	childArgs []string
+
+	// command builds the process for a peer; tests replace it.
+	command func(spec PeerSpec) *exec.Cmd
+
+	restartMin, restartMax time.Duration
+
+	mu    sync.Mutex
+	cfg   Config
+	order []string
+	procs map[string]*proc
+}
+
+// New loads the daemon config at cfgPath. childArgs are global goop2 flags
+// passed to every peer, such as -key-passphrase-file.
+func New(cfgPath string, childArgs []string) (*Supervisor, error) {
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	s := &Supervisor{
+		cfgPath:    cfgPath,
+		childArgs:  childArgs,
+		restartMin: RestartMinDelay,
+		restartMax: RestartMaxDelay,
+		cfg:        cfg,
+		procs:      map[string]*proc{},
+	}
+	s.command = func(spec PeerSpec) *exec.Cmd {
+		args := append(slices.Clone(s.childArgs), spec.Mode, spec.Dir)
+		return exec.Command(exe, args...)
+	}
+	return s, nil
+}
+
+// Config returns the config the supervisor runs with.
+func (s *Supervisor) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// StartAll starts every enabled peer of the config.
+func (s *Supervisor) StartAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, spec := range s.cfg.Peers {
+		p := s.addLocked(spec)
+		if !spec.Disabled {
+			p.start()
+		}
+	}
+}
+
+// StopAll stops every peer and waits for the processes to exit.
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	procs := make([]*proc, 0, len(s.procs))
+	for _, p := range s.procs {
+		procs = append(procs, p)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.stop()
+		}()
+	}
+	wg.Wait()
+}
+
+// Status lists the peers in config order.
+func (s *Supervisor) Status() []PeerStatus {
+	s.mu.Lock()
+	procs := make([]*proc, 0, len(s.order))
+	for _, name := range s.order {
+		procs = append(procs, s.procs[name])
+	}
+	s.mu.Unlock()
+
+	out := make([]PeerStatus, 0, len(procs))
+	for _, p := range procs {
+		out = append(out, p.status(false))
+	}
+	return out
+}
+
+// Peer reports one peer, with its recent output.
+func (s *Supervisor) Peer(name string) (PeerStatus, error) {
+	p, err := s.lookup(name)
+	if err != nil {
+		return PeerStatus{}, err
+	}
+	return p.status(true), nil
+}
+
+// StartPeer starts a stopped peer, including one disabled in the config.
+func (s *Supervisor) StartPeer(name string) (PeerStatus, error) {
+	p, err := s.lookup(name)
+	if err != nil {
+		return PeerStatus{}, err
+	}
+	p.start()
+	return p.status(false), nil
+}
+
+// StopPeer stops a peer until it is started again or the daemon restarts.
+func (s *Supervisor) StopPeer(name string) (PeerStatus, error) {
+	p, err := s.lookup(name)
+	if err != nil {
+		return PeerStatus{}, err
+	}
+	p.stop()
+	return p.status(false), nil
+}
+
+// RestartPeer stops and starts a peer, so it rereads its goop.json.
+func (s *Supervisor) RestartPeer(name string) (PeerStatus, error) {
+	p, err := s.lookup(name)
+	if err != nil {
+		return PeerStatus{}, err
+	}
+	p.stop()
+	p.start()
+	return p.status(false), nil
+}
+
+// ErrUnknownPeer is returned for a name that is not in the config.
+var ErrUnknownPeer = errors.New("unknown peer")
+
+func (s *Supervisor) lookup(name string) (*proc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.procs[name]
+	if p == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownPeer, name)
+	}
+	return p, nil
+}
+
+// Reload rereads the daemon config. Removed peers are stopped and new ones
+// started. A peer is restarted when its entry changed, or when its goop.json
+// changed since it started. Peers stopped through the API stay stopped.
+// Socket and admin address changes take effect on the next daemon start.
+func (s *Supervisor) Reload() (ReloadResult, error) {
+	res := ReloadResult{Started: []string{}, Stopped: []string{}, Restarted: []string{}}
+	cfg, err := LoadConfig(s.cfgPath)
+	if err != nil {
+		return res, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	want := map[string]bool{}
+	for _, spec := range cfg.Peers {
+		want[spec.Name] = true
+	}
+	for _, name := range s.order {
+		if !want[name] {
+			p := s.procs[name]
+			if p.running() {
+				res.Stopped = append(res.Stopped, name)
+			}
+			p.stop()
+			delete(s.procs, name)
+		}
+	}
+
+	s.order = nil
+	for _, spec := range cfg.Peers {
+		p := s.procs[spec.Name]
+		switch {
+		case p == nil:
+			p = s.addLocked(spec)
+			if !spec.Disabled {
+				p.start()
+				res.Started = append(res.Started, spec.Name)
+			}
+			continue
+		case p.spec != spec:
+			wasRunning := p.running()
+			p.stop()
+			delete(s.procs, spec.Name)
+			p = s.addLocked(spec)
+			switch {
+			case !spec.Disabled && wasRunning:
+				p.start()
+				res.Restarted = append(res.Restarted, spec.Name)
+			case !spec.Disabled:
+				p.start()
+				res.Started = append(res.Started, spec.Name)
+			case wasRunning:
+				res.Stopped = append(res.Stopped, spec.Name)
+			}
+			continue
+		case p.running() && p.stamp != configStamp(spec):
+			p.stop()
+			p.start()
+			res.Restarted = append(res.Restarted, spec.Name)
+		}
+		s.order = append(s.order, spec.Name)
+	}
+	if cfg.Socket != s.cfg.Socket || cfg.AdminAddr != s.cfg.AdminAddr || cfg.AdminToken != s.cfg.AdminToken {
+		log.Printf("DAEMON: admin API settings changed; they apply on the next daemon start")
+		cfg.Socket, cfg.AdminAddr, cfg.AdminToken = s.cfg.Socket, s.cfg.AdminAddr, s.cfg.AdminToken
+	}
+	s.cfg = cfg
+	return res, nil
+}
+
+func (s *Supervisor) addLocked(spec PeerSpec) *proc {
+	p := &proc{s: s, spec: spec, state: StateStopped, output: &lineRing{max: outputLines}}
+	s.procs[spec.Name] = p
+	s.order = append(s.order, spec.Name)
+	return p
+}
+
+// configStamp identifies the version of a peer's goop.json.
+func configStamp(spec PeerSpec) string {
+	st, err := os.Stat(filepath.Join(spec.Dir, "goop.json"))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", st.ModTime().UnixNano(), st.Size())
+}
+
+// proc is one supervised peer. A goroutine runs the process while the peer
+// is wanted, restarting it with growing delays when it exits.
+type proc struct {
+	s    *Supervisor
+	spec PeerSpec
+
+	mu       sync.Mutex
+	state    string
+	stamp    string // configStamp at the last start
+	pid      int
+	started  time.Time
+	restarts int
+	lastExit string
+	output   *lineRing
+	quit     chan struct{} // closed to stop the run loop
+	done     chan struct{} // closed when the run loop returned
+}
+
+func (p *proc) running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.quit != nil
+}
+
+func (p *proc) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quit != nil {
+		return
+	}
+	p.quit, p.done = make(chan struct{}), make(chan struct{})
+	p.stamp = configStamp(p.spec)
+	go p.run(p.quit, p.done)
+}
+
+func (p *proc) stop() {
+	p.mu.Lock()
+	quit, done := p.quit, p.done
+	p.quit, p.done = nil, nil
+	p.mu.Unlock()
+	if quit == nil {
+		return
+	}
+	close(quit)
+	<-done
+}
+
+func (p *proc) run(quit, done chan struct{}) {
+	defer close(done)
+	delay := p.s.restartMin
+	for {
+		began := time.Now()
+		if exited := p.runOnce(quit); !exited {
+			p.setState(StateStopped)
+			return
+		}
+		if time.Since(began) >= StableAfter {
+			delay = p.s.restartMin
+		}
+		p.setState(StateBackoff)
+		select {
+		case <-quit:
+			p.setState(StateStopped)
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, p.s.restartMax)
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+// runOnce runs the process until it exits (true) or quit is closed (false).
+func (p *proc) runOnce(quit chan struct{}) bool {
+	cmd := p.s.command(p.spec)
+	out := &prefixWriter{name: p.spec.Name, ring: p.output}
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Start(); err != nil {
+		p.exited(err)
+		return true
+	}
+	p.mu.Lock()
+	p.state, p.pid, p.started = StateRunning, cmd.Process.Pid, time.Now()
+	p.mu.Unlock()
+	log.Printf("DAEMON: %s started (pid %d)", p.spec.Name, cmd.Process.Pid)
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+	select {
+	case err := <-waitErr:
+		out.flush()
+		p.exited(err)
+		return true
+	case <-quit:
+	}
+
+	// SIGTERM lets the peer shut down cleanly; Windows cannot deliver it.
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-waitErr:
+	case <-time.After(StopTimeout):
+		log.Printf("DAEMON: %s did not stop within %s; killing it", p.spec.Name, StopTimeout)
+		_ = cmd.Process.Kill()
+		<-waitErr
+	}
+	out.flush()
+	log.Printf("DAEMON: %s stopped", p.spec.Name)
+	p.mu.Lock()
+	p.pid = 0
+	p.mu.Unlock()
+	return false
+}
+
+func (p *proc) exited(err error) {
+	msg := "exited"
+	if err != nil {
+		msg = err.Error()
+	}
+	log.Printf("DAEMON: %s %s", p.spec.Name, msg)
+	p.mu.Lock()
+	p.pid, p.lastExit = 0, msg
+	p.mu.Unlock()
+}
+
+func (p *proc) setState(state string) {
+	p.mu.Lock()
+	p.state = state
+	p.mu.Unlock()
+}
+
+func (p *proc) status(withOutput bool) PeerStatus {
+	p.mu.Lock()
+	st := PeerStatus{
+		Name:     p.spec.Name,
+		Dir:      p.spec.Dir,
+		Mode:     p.spec.Mode,
+		State:    p.state,
+		PID:      p.pid,
+		Restarts: p.restarts,
+		LastExit: p.lastExit,
+	}
+	if p.state == StateRunning {
+		st.StartedAt = p.started.Unix()
+	}
+	p.mu.Unlock()
+	st.URL = peerURL(p.spec)
+	if withOutput {
+		st.Output = p.output.lines()
+	}
+	return st
+}
+
+// peerURL is where the peer can be reached, as its goop.json configures it.
+func peerURL(spec PeerSpec) string {
+	cfg, err := config.LoadPartial(filepath.Join(spec.Dir, "goop.json"))
+	if err != nil {
+		return ""
+	}
+	if spec.Mode == ModeRendezvous || cfg.Presence.RendezvousOnly {
+		if cfg.Presence.RendezvousPort == 0 {
+			return ""
+		}
+		return fmt.Sprintf("http://127.0.0.1:%d", cfg.Presence.RendezvousPort)
+	}
+	addr := strings.TrimSpace(cfg.Viewer.HTTPAddr)
+	if addr == "" {
+		return ""
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	return "http://" + addr
+}
+
+// lineRing keeps the last lines a peer printed.
+type lineRing struct {
+	mu  sync.Mutex
+	max int
+	buf []string
+}
+
+func (r *lineRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = append(r.buf, line)
+	if len(r.buf) > r.max {
+		r.buf = r.buf[len(r.buf)-r.max:]
+	}
+}
+
+func (r *lineRing) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.buf)
+}
+
+// prefixWriter logs a peer's output line by line, tagged with its name.
+type prefixWriter struct {
+	name    string
+	ring    *lineRing
+	mu      sync.Mutex
+	partial []byte
+}
+
+var _ io.Writer = (*prefixWriter)(nil)
+
+func (w *prefixWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, b...)
+	for {
+		i := slices.Index(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (w *prefixWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *prefixWriter) emit(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	w.ring.add(line)
+	log.Printf("[%s] %s", w.name, line)
+}
+
+// Run starts the peers and the admin API, and stops both when ctx ends or
+// the API asks for a shutdown.
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	api, err := s.listen(cancel)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	s.StartAll()
+	<-ctx.Done()
+	log.Printf("DAEMON: stopping peers")
+	s.StopAll()
+	return nil
+}
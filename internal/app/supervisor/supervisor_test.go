@@ -0,0 +1,248 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess stands in for a goop2 peer when run by the tests: it
+// prints a line and waits for SIGTERM, or exits at once when the peer
+// directory has a "crash" file.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GOOP2_SUPERVISOR_HELPER") != "1" {
+		return
+	}
+	dir := os.Args[len(os.Args)-1]
+	fmt.Printf("helper running %s\n", filepath.Base(dir))
+	if _, err := os.Stat(filepath.Join(dir, "crash")); err == nil {
+		os.Exit(3)
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+	select {
+	case <-sig:
+	case <-time.After(time.Minute):
+	}
+	os.Exit(0)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestSupervisor writes a daemon config for the named peers and returns a
+// supervisor that runs the helper process for each.
+func newTestSupervisor(t *testing.T, names ...string) (*Supervisor, string) {
+	t.Helper()
+	base := t.TempDir()
+	cfgPath := filepath.Join(base, "goop2d.json")
+	writeTestConfig(t, cfgPath, names...)
+
+	s, err := New(cfgPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.restartMin, s.restartMax = 20*time.Millisecond, 50*time.Millisecond
+	s.command = func(spec PeerSpec) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$", "--", spec.Mode, spec.Dir)
+		cmd.Env = append(os.Environ(), "GOOP2_SUPERVISOR_HELPER=1")
+		return cmd
+	}
+	t.Cleanup(s.StopAll)
+	return s, base
+}
+
+func writeTestConfig(t *testing.T, cfgPath string, names ...string) {
+	t.Helper()
+	base := filepath.Dir(cfgPath)
+	var cfg Config
+	for _, n := range names {
+		if _, err := os.Stat(filepath.Join(base, n, "goop.json")); err != nil {
+			writeFile(t, filepath.Join(base, n, "goop.json"), `{"viewer":{"http_addr":"127.0.0.1:0"}}`)
+		}
+		cfg.Peers = append(cfg.Peers, PeerSpec{Name: n, Dir: n})
+	}
+	b, _ := json.Marshal(cfg)
+	writeFile(t, cfgPath, string(b))
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func peerState(s *Supervisor, name string) PeerStatus {
+	st, _ := s.Peer(name)
+	return st
+}
+
+func TestLoadConfig(t *testing.T) {
+	base := t.TempDir()
+	writeFile(t, filepath.Join(base, "a", "goop.json"), "{}")
+	if err := os.Mkdir(filepath.Join(base, "rv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(base, "goop2d.json")
+
+	writeFile(t, cfgPath, `{"peers":[{"name":"a","dir":"a"},{"name":"rv","dir":"rv","mode":"rendezvous"}]}`)
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Socket != filepath.Join(base, DefaultSocket) {
+		t.Errorf("socket = %q", cfg.Socket)
+	}
+	if cfg.Peers[0].Dir != filepath.Join(base, "a") || cfg.Peers[0].Mode != ModePeer {
+		t.Errorf("peer a = %+v", cfg.Peers[0])
+	}
+
+	for _, bad := range []string{
+		`{"peers":[{"name":"a","dir":"a"},{"name":"a","dir":"a"}]}`,
+		`{"peers":[{"name":"../x","dir":"a"}]}`,
+		`{"peers":[{"name":"a","dir":"missing"}]}`,
+		`{"peers":[{"name":"rv","dir":"rv"}]}`,
+		`{"peers":[{"name":"a","dir":"a","mode":"bot"}]}`,
+		`{"admin_addr":"0.0.0.0:9000","admin_token":"t","peers":[]}`,
+		`{"admin_addr":"127.0.0.1:9000","peers":[]}`,
+	} {
+		writeFile(t, cfgPath, bad)
+		if _, err := LoadConfig(cfgPath); err == nil {
+			t.Errorf("LoadConfig(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestSupervisor_startStopThroughAPI(t *testing.T) {
+	s, _ := newTestSupervisor(t, "alpha", "beta")
+	s.StartAll()
+	waitFor(t, "peers to run", func() bool {
+		return peerState(s, "alpha").PID != 0 && peerState(s, "beta").PID != 0
+	})
+
+	srv := httptest.NewServer(s.Handler(nil))
+	defer srv.Close()
+	post := func(path string) int {
+		resp, err := http.Post(srv.URL+path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("/peers/alpha/stop"); code != http.StatusOK {
+		t.Fatalf("stop = %d", code)
+	}
+	if st := peerState(s, "alpha"); st.State != StateStopped || st.PID != 0 {
+		t.Errorf("alpha after stop = %+v", st)
+	}
+	if st := peerState(s, "beta"); st.State != StateRunning {
+		t.Errorf("beta = %+v, want running", st)
+	}
+	if code := post("/peers/nobody/stop"); code != http.StatusNotFound {
+		t.Errorf("unknown peer = %d, want 404", code)
+	}
+
+	resp, err := http.Get(srv.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status []PeerStatus
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if len(status) != 2 || status[0].Name != "alpha" || status[1].URL != "http://127.0.0.1:0" {
+		t.Errorf("status = %+v", status)
+	}
+
+	post("/peers/alpha/start")
+	waitFor(t, "alpha to run again", func() bool { return peerState(s, "alpha").PID != 0 })
+	waitFor(t, "alpha output", func() bool {
+		return slices.Contains(peerState(s, "alpha").Output, "helper running alpha")
+	})
+}
+
+func TestSupervisor_restartsCrashedPeer(t *testing.T) {
+	s, base := newTestSupervisor(t, "flaky")
+	writeFile(t, filepath.Join(base, "flaky", "crash"), "")
+	s.StartAll()
+	waitFor(t, "restarts", func() bool { return peerState(s, "flaky").Restarts >= 2 })
+	if st := peerState(s, "flaky"); !strings.Contains(st.LastExit, "exit status 3") {
+		t.Errorf("last exit = %q", st.LastExit)
+	}
+
+	// Once the peer stops crashing it stays up.
+	os.Remove(filepath.Join(base, "flaky", "crash"))
+	waitFor(t, "flaky to stay up", func() bool { return peerState(s, "flaky").State == StateRunning })
+}
+
+func TestSupervisor_reload(t *testing.T) {
+	s, base := newTestSupervisor(t, "keep", "drop", "touched", "parked")
+	s.StartAll()
+	waitFor(t, "peers to run", func() bool {
+		for _, st := range s.Status() {
+			if st.PID == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	keepPID := peerState(s, "keep").PID
+	s.StopPeer("parked")
+
+	// A changed goop.json restarts its peer; a parked peer stays stopped
+	// even when its goop.json changed.
+	future := time.Now().Add(time.Hour)
+	for _, n := range []string{"touched", "parked"} {
+		writeFile(t, filepath.Join(base, n, "goop.json"), `{"viewer":{"http_addr":"127.0.0.1:1"}}`)
+		os.Chtimes(filepath.Join(base, n, "goop.json"), future, future)
+	}
+	writeTestConfig(t, filepath.Join(base, "goop2d.json"), "keep", "touched", "parked", "added")
+
+	res, err := s.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(res.Started, []string{"added"}) || !slices.Equal(res.Stopped, []string{"drop"}) ||
+		!slices.Equal(res.Restarted, []string{"touched"}) {
+		t.Errorf("reload = %+v", res)
+	}
+	if _, err := s.Peer("drop"); err == nil {
+		t.Error("removed peer still listed")
+	}
+	if st := peerState(s, "keep"); st.PID != keepPID {
+		t.Errorf("unchanged peer was restarted (pid %d → %d)", keepPID, st.PID)
+	}
+	if st := peerState(s, "parked"); st.State != StateStopped {
+		t.Errorf("parked = %+v, want stopped", st)
+	}
+	var names []string
+	for _, st := range s.Status() {
+		names = append(names, st.Name)
+	}
+	if !slices.Equal(names, []string{"keep", "touched", "parked", "added"}) {
+		t.Errorf("status order = %v", names)
+	}
+}
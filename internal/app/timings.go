@@ -3,6 +3,6 @@ package app
 import "time"
 
 const (
-	ProgressEmitDelay     = 200 * time.Millisecond   // delay between startup progress steps
-	TCPDialAttemptTimeout = 200 * time.Millisecond   // per-attempt TCP dial in WaitTCP
+	TCPDialAttemptTimeout = 200 * time.Millisecond // per-attempt TCP dial in WaitTCP
+	ShutdownWaitMax       = 310 * time.Second      // desktop: wait for the peer to stop (p2p.shutdown_timeout_sec max + margin)
 )
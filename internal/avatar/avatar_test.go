@@ -248,6 +248,20 @@ func TestCache_GetAnyMissing(t *testing.T) {
 	}
 }
 
+func TestCache_Remove(t *testing.T) {
+	c := NewCache(t.TempDir())
+	c.Put("peer-1", "h", []byte("one"))
+	c.Put("peer-2", "h", []byte("two"))
+	c.Remove("peer-1")
+
+	if got, _ := c.GetAny("peer-1"); got != nil {
+		t.Error("expected nil after remove")
+	}
+	if got, _ := c.GetAny("peer-2"); string(got) != "two" {
+		t.Errorf("other peer's avatar = %q", got)
+	}
+}
+
 func TestCache_Clear(t *testing.T) {
 	c := NewCache(t.TempDir())
 	c.Put("peer-1", "h", []byte("data"))
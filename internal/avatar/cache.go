@@ -71,6 +71,14 @@ func (c *Cache) Put(peerID, hash string, data []byte) error {
 	return os.WriteFile(c.hashPath(peerID), []byte(hash), 0644)
 }
 
+// Remove drops one peer's cached avatar.
+func (c *Cache) Remove(peerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	os.Remove(c.filePath(peerID))
+	os.Remove(c.hashPath(peerID))
+}
+
 // Clear removes all cached files.
 func (c *Cache) Clear() {
 	c.mu.Lock()
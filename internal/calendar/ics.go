@@ -0,0 +1,82 @@
+// Package calendar writes iCalendar (RFC 5545) feeds so scheduled sessions
+// can be subscribed to from ordinary calendar apps.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of an ICS feed.
+const ContentType = "text/calendar; charset=utf-8"
+
+// Event is one VEVENT in a feed.
+type Event struct {
+	UID         string // stable across feed refreshes
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Updated     time.Time
+}
+
+// Write renders a VCALENDAR called name holding events.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) { writeFolded(bw, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//goop2//schedule//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(name))
+	for _, ev := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escape(ev.UID))
+		line("DTSTAMP:" + stamp(ev.Updated))
+		line("DTSTART:" + stamp(ev.Start))
+		line("DTEND:" + stamp(ev.End))
+		line("SUMMARY:" + escape(ev.Summary))
+		if ev.Description != "" {
+			line("DESCRIPTION:" + escape(ev.Description))
+		}
+		if ev.URL != "" {
+			line("URL:" + ev.URL)
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape quotes a TEXT value.
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes s as a content line, folding it at 75 octets without
+// splitting a UTF-8 sequence, and terminates it with CRLF.
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	n := 0
+	for i, r := range s {
+		size := len(string(r))
+		if i > 0 && n+size > limit {
+			w.WriteString("\r\n ")
+			n = 1
+		}
+		w.WriteRune(r)
+		n += size
+	}
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,52 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.FixedZone("CET", 3600))
+	var b strings.Builder
+	err := Write(&b, "Jazz club", []Event{{
+		UID:         "7@peer",
+		Summary:     "Jam; bring snacks, please",
+		Description: "Line one\nline two",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Updated:     start,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Jazz club\r\n",
+		"DTSTART:20260301T190000Z\r\n",
+		"DTEND:20260301T200000Z\r\n",
+		`SUMMARY:Jam\; bring snacks\, please` + "\r\n",
+		`DESCRIPTION:Line one\nline two` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteFolds(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, strings.Repeat("é", 60), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(l) > 75 {
+			t.Fatalf("line of %d octets: %q", len(l), l)
+		}
+	}
+	if !strings.Contains(b.String(), "\r\n é") {
+		t.Fatal("long line not folded")
+	}
+}
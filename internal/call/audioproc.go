@@ -0,0 +1,206 @@
+package call
+
+// Audio processing for native microphone capture. pion/mediadevices hands the
+// Opus encoder raw mic samples, so without this stage background hiss and the
+// remote voice coming out of laptop speakers go straight back to the peer.
+// audioProcessor sits between capture and encoding as an audio.TransformFunc
+// and is plain Go, so there is no RNNoise or WebRTC APM library to build:
+//
+//   - noise suppression is an adaptive noise gate: it tracks the background
+//     level and attenuates chunks that do not rise clearly above it;
+//   - echo cancellation is half-duplex suppression: while the remote peer is
+//     talking the mic is ducked, unless local speech is loud enough to be
+//     the user talking over them rather than the echo.
+//
+// Both are runtime toggles (SetAudioProcessing) read on every chunk, so a
+// change applies to calls already in progress.
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/wave"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+// AudioProcessing selects the processing applied to native mic capture.
+type AudioProcessing struct {
+	NoiseSuppression bool `json:"noise_suppression"`
+	EchoCancellation bool `json:"echo_cancellation"`
+}
+
+var (
+	audioProcMu sync.RWMutex
+	audioProc   = AudioProcessing{NoiseSuppression: true, EchoCancellation: true}
+)
+
+// SetAudioProcessing replaces the mic processing toggles for all calls.
+func SetAudioProcessing(ap AudioProcessing) {
+	audioProcMu.Lock()
+	audioProc = ap
+	audioProcMu.Unlock()
+}
+
+// CurrentAudioProcessing returns the mic processing toggles in effect.
+func CurrentAudioProcessing() AudioProcessing {
+	audioProcMu.RLock()
+	defer audioProcMu.RUnlock()
+	return audioProc
+}
+
+// audioLevelURI is the RFC 6464 header extension browsers use to report the
+// level of each audio packet they send.
+const audioLevelURI = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+
+// Levels are RMS relative to full scale (1.0).
+const (
+	noiseFloorMin   = 1e-4  // never track the floor below -80 dBFS
+	noiseFloorRise  = 1.005 // per-chunk upward drift of the floor estimate
+	noiseGateRatio  = 3.0   // chunk must be ~10 dB over the floor to pass
+	noiseGateGain   = 0.1   // -20 dB while the gate is closed
+	echoDuckGain    = 0.15  // -16 dB while the remote peer is talking
+	echoDoubleTalk  = 0.08  // local speech above -22 dBFS passes through echo ducking
+	gainRelease     = 0.2   // fraction of the way towards a lower gain per chunk
+	farEndLevelMax  = 45    // audio-level extension: -45 dBov or louder is speech
+	farEndSpeechLen = 40    // without the extension: Opus payloads this large are speech
+)
+
+// audioProcessor applies AudioProcessing to one session's mic track.
+type audioProcessor struct {
+	noiseFloor float64
+	gain       float64 // gain at the end of the previous chunk
+
+	farEnd atomic.Int64 // UnixNano of the last remote packet carrying speech
+}
+
+func newAudioProcessor() *audioProcessor {
+	return &audioProcessor{noiseFloor: noiseFloorMin, gain: 1}
+}
+
+// transform is the audio.TransformFunc installed on the mic track.
+func (p *audioProcessor) transform(r audio.Reader) audio.Reader {
+	return audio.ReaderFunc(func() (wave.Audio, func(), error) {
+		chunk, release, err := r.Read()
+		if err != nil {
+			return chunk, release, err
+		}
+		if ea, ok := chunk.(wave.EditableAudio); ok {
+			p.process(ea, CurrentAudioProcessing(), time.Now())
+		}
+		return chunk, release, nil
+	})
+}
+
+// remoteAudio notes far-end speech from one received Opus packet. levelExt
+// is the negotiated audio-level extension ID, or 0 when the remote does not
+// send it; packet size is the fallback since Opus spends few bytes on silence.
+func (p *audioProcessor) remoteAudio(pkt *rtp.Packet, levelExt uint8) {
+	speech := len(pkt.Payload) >= farEndSpeechLen
+	if levelExt != 0 {
+		if ext := pkt.GetExtension(levelExt); len(ext) > 0 {
+			speech = int(ext[0]&0x7f) <= farEndLevelMax
+		}
+	}
+	if speech {
+		p.farEnd.Store(time.Now().UnixNano())
+	}
+}
+
+// audioLevelExtID returns the negotiated audio-level extension ID, or 0.
+func audioLevelExtID(receiver *webrtc.RTPReceiver) uint8 {
+	if receiver == nil {
+		return 0
+	}
+	for _, ext := range receiver.GetParameters().HeaderExtensions {
+		if ext.URI == audioLevelURI {
+			return uint8(ext.ID)
+		}
+	}
+	return 0
+}
+
+func (p *audioProcessor) farEndActive(now time.Time) bool {
+	last := p.farEnd.Load()
+	return last != 0 && now.Sub(time.Unix(0, last)) < EchoHangover
+}
+
+// process scales one chunk in place.
+func (p *audioProcessor) process(a wave.EditableAudio, ap AudioProcessing, now time.Time) {
+	if !ap.NoiseSuppression && !ap.EchoCancellation {
+		p.gain = 1
+		return
+	}
+	rms := chunkRMS(a)
+
+	target := 1.0
+	if ap.NoiseSuppression {
+		p.trackNoise(rms)
+		if rms < p.noiseFloor*noiseGateRatio {
+			target = noiseGateGain
+		}
+	}
+	if ap.EchoCancellation && rms < echoDoubleTalk && p.farEndActive(now) {
+		target = min(target, echoDuckGain)
+	}
+
+	// Open immediately so word onsets are not clipped; close gradually so
+	// word tails are not chopped. Ramp across the chunk to avoid clicks.
+	next := target
+	if target < p.gain {
+		next = p.gain + (target-p.gain)*gainRelease
+	}
+	applyGainRamp(a, p.gain, next)
+	p.gain = next
+}
+
+// trackNoise follows the background level: down at once, up slowly, so the
+// floor settles on the quietest recent chunks rather than on speech.
+func (p *audioProcessor) trackNoise(rms float64) {
+	if rms < p.noiseFloor {
+		p.noiseFloor = rms
+	} else {
+		p.noiseFloor *= noiseFloorRise
+	}
+	p.noiseFloor = max(p.noiseFloor, noiseFloorMin)
+}
+
+// fullScale is the Sample.Int value of a full-scale 16-bit sample.
+const fullScale = float64(1 << 31)
+
+func chunkRMS(a wave.Audio) float64 {
+	info := a.ChunkInfo()
+	n := info.Len * info.Channels
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < info.Len; i++ {
+		for ch := 0; ch < info.Channels; ch++ {
+			v := float64(a.At(i, ch).Int()) / fullScale
+			sum += v * v
+		}
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// scaledSample is a Sample holding an already-scaled level.
+type scaledSample int64
+
+func (s scaledSample) Int() int64 { return int64(s) }
+
+func applyGainRamp(a wave.EditableAudio, from, to float64) {
+	if from == 1 && to == 1 {
+		return
+	}
+	info := a.ChunkInfo()
+	for i := 0; i < info.Len; i++ {
+		g := from + (to-from)*float64(i+1)/float64(info.Len)
+		for ch := 0; ch < info.Channels; ch++ {
+			a.Set(i, ch, scaledSample(float64(a.At(i, ch).Int())*g))
+		}
+	}
+}
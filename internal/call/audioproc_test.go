@@ -0,0 +1,114 @@
+package call
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/wave"
+	"github.com/pion/rtp"
+)
+
+// toneChunk is a 10 ms mono 48 kHz sine chunk at the given peak amplitude.
+func toneChunk(amp float64) *wave.Int16Interleaved {
+	a := wave.NewInt16Interleaved(wave.ChunkInfo{Len: 480, Channels: 1, SamplingRate: 48000})
+	for i := range a.Data {
+		a.Data[i] = int16(amp * 32767 * math.Sin(2*math.Pi*440*float64(i)/48000))
+	}
+	return a
+}
+
+func TestAudioProcessor_Off(t *testing.T) {
+	p := newAudioProcessor()
+	a := toneChunk(0.001)
+	want := append([]int16(nil), a.Data...)
+	p.process(a, AudioProcessing{}, time.Now())
+	for i := range want {
+		if a.Data[i] != want[i] {
+			t.Fatalf("sample %d changed with processing off", i)
+		}
+	}
+}
+
+func TestAudioProcessor_NoiseGate(t *testing.T) {
+	p := newAudioProcessor()
+	on := AudioProcessing{NoiseSuppression: true}
+	now := time.Now()
+
+	// Steady background hiss (6 s): the floor settles on it and the gate closes.
+	var hiss *wave.Int16Interleaved
+	for range 600 {
+		hiss = toneChunk(0.002)
+		p.process(hiss, on, now)
+	}
+	if r := chunkRMS(hiss) / chunkRMS(toneChunk(0.002)); r > 0.2 {
+		t.Errorf("background not attenuated: gain %.2f", r)
+	}
+
+	// Speech well above the floor opens the gate within one chunk.
+	p.process(toneChunk(0.3), on, now)
+	speech := toneChunk(0.3)
+	p.process(speech, on, now)
+	if r := chunkRMS(speech) / chunkRMS(toneChunk(0.3)); r < 0.99 {
+		t.Errorf("speech attenuated: gain %.2f", r)
+	}
+}
+
+func TestAudioProcessor_EchoDucking(t *testing.T) {
+	p := newAudioProcessor()
+	on := AudioProcessing{EchoCancellation: true}
+	now := time.Now()
+
+	// Quiet mic audio passes while the far end is silent.
+	a := toneChunk(0.05)
+	p.process(a, on, now)
+	if r := chunkRMS(a) / chunkRMS(toneChunk(0.05)); r < 0.99 {
+		t.Fatalf("mic ducked without far-end speech: gain %.2f", r)
+	}
+
+	// Far-end speech reported through the audio-level extension (-20 dBov).
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2}, Payload: []byte{1}}
+	if err := pkt.SetExtension(1, []byte{20}); err != nil {
+		t.Fatal(err)
+	}
+	p.remoteAudio(pkt, 1)
+	now = time.Now()
+	for range 30 {
+		a = toneChunk(0.05)
+		p.process(a, on, now)
+	}
+	if r := chunkRMS(a) / chunkRMS(toneChunk(0.05)); r > echoDuckGain+0.01 {
+		t.Errorf("echo not ducked: gain %.2f", r)
+	}
+
+	// Double talk: the local speaker is loud enough to pass through.
+	p.process(toneChunk(0.5), on, now)
+	loud := toneChunk(0.5)
+	p.process(loud, on, now)
+	if r := chunkRMS(loud) / chunkRMS(toneChunk(0.5)); r < 0.99 {
+		t.Errorf("double talk ducked: gain %.2f", r)
+	}
+
+	// After the hangover ducking stops.
+	if p.farEndActive(now.Add(EchoHangover + time.Millisecond)) {
+		t.Error("far end still active after hangover")
+	}
+}
+
+func TestAudioProcessor_RemoteSilence(t *testing.T) {
+	p := newAudioProcessor()
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2}, Payload: make([]byte, 100)}
+	if err := pkt.SetExtension(1, []byte{90}); err != nil { // -90 dBov
+		t.Fatal(err)
+	}
+	p.remoteAudio(pkt, 1)
+	if p.farEndActive(time.Now()) {
+		t.Error("silent packet counted as far-end speech")
+	}
+
+	// Without the extension a large payload counts as speech.
+	p.remoteAudio(&rtp.Packet{Payload: make([]byte, 100)}, 0)
+	if !p.farEndActive(time.Now()) {
+		t.Error("large payload not counted as far-end speech")
+	}
+}
@@ -0,0 +1,169 @@
+package call
+
+// Live captions. When a caption command is configured, received Opus audio
+// is cut into short Ogg segments and each segment is handed to the command
+// (typically a whisper.cpp CLI). Its stdout becomes a call-caption event for
+// the browser. Nothing runs unless the user enables captions for a call, and
+// audio never leaves the machine.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+)
+
+// ErrCaptionsUnavailable is returned when no caption command is configured.
+var ErrCaptionsUnavailable = errors.New("live captions are not configured")
+
+var (
+	captionMu  sync.RWMutex
+	captionCmd []string
+)
+
+// SetCaptionCommand configures the speech-to-text command line. "{input}" is
+// replaced by the path of an Ogg/Opus segment and "{lang}" by the caption
+// language ("auto" when unset). Arguments are split on whitespace; wrap
+// anything more involved in a script. An empty line disables captions.
+func SetCaptionCommand(cmdline string) {
+	captionMu.Lock()
+	captionCmd = strings.Fields(cmdline)
+	captionMu.Unlock()
+}
+
+// CaptionsAvailable reports whether a caption command is configured.
+func CaptionsAvailable() bool {
+	captionMu.RLock()
+	defer captionMu.RUnlock()
+	return len(captionCmd) > 0
+}
+
+func captionArgs(input, lang string) []string {
+	captionMu.RLock()
+	defer captionMu.RUnlock()
+	if len(captionCmd) == 0 {
+		return nil
+	}
+	r := strings.NewReplacer("{input}", input, "{lang}", lang)
+	args := make([]string, len(captionCmd))
+	for i, a := range captionCmd {
+		args[i] = r.Replace(a)
+	}
+	return args
+}
+
+// captioner segments one session's received audio and transcribes it.
+type captioner struct {
+	channelID string
+	lang      string
+	emit      func(text string)
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	ogg     *oggwriter.OggWriter
+	started time.Time
+
+	busy atomic.Bool // a segment is being transcribed; newer ones are dropped
+}
+
+// writeRTP appends one Opus packet and flushes a segment every CaptionSegment.
+func (c *captioner) writeRTP(pkt *rtp.Packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ogg == nil {
+		c.buf.Reset()
+		w, err := oggwriter.NewWith(&c.buf, 48000, 2)
+		if err != nil {
+			return
+		}
+		c.ogg = w
+		c.started = time.Now()
+	}
+	if err := c.ogg.WriteRTP(pkt); err != nil {
+		return
+	}
+	if time.Since(c.started) < CaptionSegment {
+		return
+	}
+	_ = c.ogg.Close()
+	c.ogg = nil
+	if !c.busy.CompareAndSwap(false, true) {
+		return // still transcribing the previous segment: stay live, drop this one
+	}
+	segment := append([]byte(nil), c.buf.Bytes()...)
+	go func() {
+		defer c.busy.Store(false)
+		if text := c.transcribe(segment); text != "" {
+			c.emit(text)
+		}
+	}()
+}
+
+func (c *captioner) transcribe(segment []byte) string {
+	f, err := os.CreateTemp("", "goop-caption-*.ogg")
+	if err != nil {
+		return ""
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(segment)
+	f.Close()
+	if err != nil {
+		return ""
+	}
+
+	args := captionArgs(f.Name(), c.lang)
+	if args == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CaptionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		log.Printf("CALL [%s]: caption command failed: %v", c.channelID, err)
+		return ""
+	}
+	return strings.Join(strings.Fields(string(out)), " ")
+}
+
+// SetCaptions turns live captions on or off for this call. lang is a
+// language code passed to the caption command ("" = auto-detect).
+func (s *Session) SetCaptions(enabled bool, lang string) error {
+	if enabled && !CaptionsAvailable() {
+		return ErrCaptionsUnavailable
+	}
+	if lang == "" {
+		lang = "auto"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hung {
+		return errors.New("call has ended")
+	}
+	if !enabled {
+		s.captions = nil
+		return nil
+	}
+	s.captions = &captioner{
+		channelID: s.channelID,
+		lang:      lang,
+		emit: func(text string) {
+			s.sig.PublishLocal(s.channelID, map[string]any{
+				"type": "call-caption",
+				"text": text,
+				"lang": lang,
+				"ts":   time.Now().UnixMilli(),
+			})
+		},
+	}
+	log.Printf("CALL [%s]: live captions on (%s)", s.channelID, lang)
+	return nil
+}
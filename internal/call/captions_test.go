@@ -0,0 +1,30 @@
+package call
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaptionArgs_Placeholders(t *testing.T) {
+	SetCaptionCommand("whisper-cli -m /models/base.bin -l {lang} -nt -f {input}")
+	defer SetCaptionCommand("")
+
+	if !CaptionsAvailable() {
+		t.Fatal("captions should be available once a command is set")
+	}
+	got := captionArgs("/tmp/seg.ogg", "nl")
+	want := []string{"whisper-cli", "-m", "/models/base.bin", "-l", "nl", "-nt", "-f", "/tmp/seg.ogg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("captionArgs = %q, want %q", got, want)
+	}
+}
+
+func TestCaptionArgs_Disabled(t *testing.T) {
+	SetCaptionCommand("   ")
+	if CaptionsAvailable() {
+		t.Fatal("blank command should disable captions")
+	}
+	if args := captionArgs("x", "auto"); args != nil {
+		t.Fatalf("captionArgs = %q, want nil", args)
+	}
+}
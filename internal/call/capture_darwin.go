@@ -0,0 +1,21 @@
+//go:build nativecall
+
+package call
+
+import (
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// captureBackend names the drivers behind pion/mediadevices, for logs.
+const captureBackend = "AVFoundation camera, malgo (Core Audio) microphone"
+
+// captureFormats are the raw camera formats AVFoundation may hand over.
+// Built-in cameras deliver NV12 or UYVY.
+var captureFormats = prop.FrameFormatOneOf{
+	frame.FormatNV12,
+	frame.FormatUYVY,
+	frame.FormatYUYV,
+	frame.FormatI420,
+	frame.FormatNV21,
+}
@@ -0,0 +1,20 @@
+package call
+
+import (
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// captureBackend names the drivers behind pion/mediadevices, for logs.
+const captureBackend = "V4L2 camera, malgo (ALSA/PulseAudio) microphone"
+
+// captureFormats are the raw camera formats V4L2 may hand over. MJPEG is
+// left out: some cameras expose an MJPEG node that produces malformed JPEG
+// frames, which poisons the VP8 encoder and causes SetRemoteDescription
+// to fail.
+var captureFormats = prop.FrameFormatOneOf{
+	frame.FormatYUYV,
+	frame.FormatI420,
+	frame.FormatI444,
+	frame.FormatRGBA,
+}
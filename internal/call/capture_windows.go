@@ -0,0 +1,17 @@
+//go:build nativecall
+
+package call
+
+import (
+	"github.com/pion/mediadevices/pkg/frame"
+	"github.com/pion/mediadevices/pkg/prop"
+)
+
+// captureBackend names the drivers behind pion/mediadevices, for logs.
+const captureBackend = "DirectShow camera, malgo (WASAPI) microphone"
+
+// captureFormats are the raw camera formats the DirectShow driver offers;
+// it converts every camera to YUY2.
+var captureFormats = prop.FrameFormatOneOf{
+	frame.FormatYUY2,
+}
@@ -0,0 +1,241 @@
+package call
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pion/webrtc/v4"
+)
+
+// In-call chat and file drops travel on one negotiated data channel that both
+// sides create with the same label and ID, so neither needs to wait for
+// OnDataChannel. Files are split into DataChunkSize pieces because browsers
+// cap SCTP messages (Safari at 64 KiB).
+const (
+	DataChannelLabel = "goop-call"
+	dataChannelID    = 0
+
+	// DataChunkSize is the raw byte size of one file chunk on the wire.
+	DataChunkSize = 16 * 1024
+
+	// MaxDataFileSize is the largest file accepted for an in-call drop.
+	MaxDataFileSize = 4 * 1024 * 1024
+)
+
+// ErrNoDataChannel is returned when the data channel is not open yet.
+var ErrNoDataChannel = errors.New("call data channel not open")
+
+// DataMessage is one in-call chat message or file drop. On the wire a file
+// is sent as Total chunks sharing ID; handlers only ever see the whole file.
+type DataMessage struct {
+	Type    string `json:"type"` // "chat" or "file"
+	ID      string `json:"id"`
+	Content string `json:"content,omitempty"` // chat text
+	Name    string `json:"name,omitempty"`    // file name
+	Mime    string `json:"mime,omitempty"`
+	Size    int    `json:"size,omitempty"`
+	Data    string `json:"data,omitempty"`  // base64 file bytes (one chunk on the wire)
+	Seq     int    `json:"seq,omitempty"`   // chunk index
+	Total   int    `json:"total,omitempty"` // chunk count
+	TS      int64  `json:"ts"`              // Unix ms
+}
+
+// Summary is the line recorded in chat history for the message. File bytes
+// are not persisted, only the name and size.
+func (m DataMessage) Summary() string {
+	if m.Type == "file" {
+		return fmt.Sprintf("📎 %s (%s)", m.Name, humanSize(m.Size))
+	}
+	return m.Content
+}
+
+func humanSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// DataHandler receives in-call chat and file drops for both directions.
+// outbound is true for messages this side sent.
+type DataHandler func(channelID, peerID string, msg DataMessage, outbound bool)
+
+// fileChunks splits data into wire messages sharing one ID.
+func fileChunks(id, name, mime string, data []byte, ts int64) []DataMessage {
+	total := (len(data) + DataChunkSize - 1) / DataChunkSize
+	if total == 0 {
+		total = 1
+	}
+	out := make([]DataMessage, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*DataChunkSize, len(data))
+		out = append(out, DataMessage{
+			Type:  "file",
+			ID:    id,
+			Name:  name,
+			Mime:  mime,
+			Size:  len(data),
+			Data:  base64.StdEncoding.EncodeToString(data[i*DataChunkSize : end]),
+			Seq:   i,
+			Total: total,
+			TS:    ts,
+		})
+	}
+	return out
+}
+
+// fileAssembler collects file chunks until a file is complete.
+type fileAssembler struct {
+	mu    sync.Mutex
+	parts map[string][][]byte // file ID → chunks by seq
+}
+
+// add stores one chunk and returns the whole file once every chunk is in.
+func (a *fileAssembler) add(msg DataMessage) (DataMessage, bool, error) {
+	if msg.Total <= 0 || msg.Seq < 0 || msg.Seq >= msg.Total || msg.Size > MaxDataFileSize ||
+		msg.Total > MaxDataFileSize/DataChunkSize+1 {
+		return DataMessage{}, false, fmt.Errorf("bad file chunk %d/%d", msg.Seq, msg.Total)
+	}
+	chunk, err := base64.StdEncoding.DecodeString(msg.Data)
+	if err != nil {
+		return DataMessage{}, false, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.parts == nil {
+		a.parts = make(map[string][][]byte)
+	}
+	parts := a.parts[msg.ID]
+	if parts == nil {
+		parts = make([][]byte, msg.Total)
+		a.parts[msg.ID] = parts
+	}
+	if len(parts) != msg.Total {
+		delete(a.parts, msg.ID)
+		return DataMessage{}, false, errors.New("file chunk count changed")
+	}
+	parts[msg.Seq] = chunk
+
+	var buf []byte
+	for _, p := range parts {
+		if p == nil {
+			return DataMessage{}, false, nil
+		}
+		buf = append(buf, p...)
+	}
+	delete(a.parts, msg.ID)
+	if len(buf) != msg.Size {
+		return DataMessage{}, false, fmt.Errorf("file size %d, want %d", len(buf), msg.Size)
+	}
+	msg.Data = base64.StdEncoding.EncodeToString(buf)
+	msg.Seq, msg.Total = 0, 0
+	return msg, true, nil
+}
+
+// initDataChannel opens the negotiated data channel on pc. Called from
+// initExternalPC before any offer is created so the SDP carries it.
+func (s *Session) initDataChannel(pc *webrtc.PeerConnection) {
+	negotiated := true
+	id := uint16(dataChannelID)
+	dc, err := pc.CreateDataChannel(DataChannelLabel, &webrtc.DataChannelInit{Negotiated: &negotiated, ID: &id})
+	if err != nil {
+		log.Printf("CALL [%s]: data channel create error: %v", s.channelID, err)
+		return
+	}
+	dc.OnMessage(func(m webrtc.DataChannelMessage) {
+		var msg DataMessage
+		if err := json.Unmarshal(m.Data, &msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "chat":
+			if msg.Content != "" {
+				s.deliverData(msg, false)
+			}
+		case "file":
+			whole, done, err := s.files.add(msg)
+			if err != nil {
+				log.Printf("CALL [%s]: file drop from %s dropped: %v", s.channelID, s.remotePeer, err)
+				return
+			}
+			if done {
+				s.deliverData(whole, false)
+			}
+		}
+	})
+
+	s.mu.Lock()
+	s.dc = dc
+	s.mu.Unlock()
+}
+
+// SendChat sends an in-call chat message to the remote peer.
+func (s *Session) SendChat(text string) (DataMessage, error) {
+	if len(text) > DataChunkSize {
+		return DataMessage{}, fmt.Errorf("message exceeds %d bytes", DataChunkSize)
+	}
+	msg := DataMessage{Type: "chat", ID: uuid.NewString(), Content: text, TS: time.Now().UnixMilli()}
+	if err := s.sendData(msg); err != nil {
+		return DataMessage{}, err
+	}
+	s.deliverData(msg, true)
+	return msg, nil
+}
+
+// SendFile sends a small file to the remote peer in chunks.
+func (s *Session) SendFile(name, mime string, data []byte) (DataMessage, error) {
+	if len(data) > MaxDataFileSize {
+		return DataMessage{}, fmt.Errorf("file exceeds %d bytes", MaxDataFileSize)
+	}
+	id := uuid.NewString()
+	ts := time.Now().UnixMilli()
+	for _, chunk := range fileChunks(id, name, mime, data, ts) {
+		if err := s.sendData(chunk); err != nil {
+			return DataMessage{}, err
+		}
+	}
+	msg := DataMessage{Type: "file", ID: id, Name: name, Mime: mime, Size: len(data),
+		Data: base64.StdEncoding.EncodeToString(data), TS: ts}
+	s.deliverData(msg, true)
+	return msg, nil
+}
+
+func (s *Session) sendData(msg DataMessage) error {
+	s.mu.Lock()
+	dc := s.dc
+	s.mu.Unlock()
+	if dc == nil || dc.ReadyState() != webrtc.DataChannelStateOpen {
+		return ErrNoDataChannel
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return dc.Send(raw)
+}
+
+// deliverData hands a complete message to the data handler and the browser.
+// The browser already has the bytes of files it sent, so they are not echoed.
+func (s *Session) deliverData(msg DataMessage, outbound bool) {
+	if s.dataFn != nil {
+		s.dataFn(s.channelID, s.remotePeer, msg, outbound)
+	}
+	if outbound {
+		msg.Data = ""
+	}
+	s.sig.PublishLocal(s.channelID, map[string]any{
+		"type":     "call-data",
+		"data":     msg,
+		"outbound": outbound,
+	})
+}
@@ -0,0 +1,66 @@
+package call
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestFileChunks_Reassemble(t *testing.T) {
+	data := bytes.Repeat([]byte("goop"), DataChunkSize) // 4 chunks
+	chunks := fileChunks("f1", "a.bin", "application/octet-stream", data, 1)
+	if len(chunks) != 4 {
+		t.Fatalf("chunks = %d, want 4", len(chunks))
+	}
+
+	var a fileAssembler
+	// Out of order: the last chunk first.
+	order := []int{3, 0, 2, 1}
+	for i, idx := range order {
+		msg, done, err := a.add(chunks[idx])
+		if err != nil {
+			t.Fatalf("add chunk %d: %v", idx, err)
+		}
+		if done != (i == len(order)-1) {
+			t.Fatalf("done = %v after %d chunks", done, i+1)
+		}
+		if !done {
+			continue
+		}
+		got, _ := base64.StdEncoding.DecodeString(msg.Data)
+		if !bytes.Equal(got, data) {
+			t.Fatal("reassembled file differs")
+		}
+		if msg.Name != "a.bin" || msg.Size != len(data) || msg.Total != 0 {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+	}
+	if len(a.parts) != 0 {
+		t.Fatal("assembler kept a finished file")
+	}
+}
+
+func TestFileChunks_EmptyFile(t *testing.T) {
+	chunks := fileChunks("f2", "empty.txt", "text/plain", nil, 1)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	var a fileAssembler
+	if _, done, err := a.add(chunks[0]); err != nil || !done {
+		t.Fatalf("done=%v err=%v", done, err)
+	}
+}
+
+func TestFileAssembler_RejectsBadChunks(t *testing.T) {
+	var a fileAssembler
+	for _, msg := range []DataMessage{
+		{Type: "file", ID: "x", Seq: 0, Total: 0},
+		{Type: "file", ID: "x", Seq: 2, Total: 2},
+		{Type: "file", ID: "x", Seq: 0, Total: 1, Size: MaxDataFileSize + 1},
+		{Type: "file", ID: "x", Seq: 0, Total: 1, Data: "%%%"},
+	} {
+		if _, _, err := a.add(msg); err == nil {
+			t.Errorf("add(%+v) accepted a bad chunk", msg)
+		}
+	}
+}
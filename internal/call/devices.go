@@ -0,0 +1,212 @@
+package call
+
+// Live capture device switching. A new capture track replaces the old one on
+// its RTPSender, which Pion allows without renegotiation as long as the codec
+// stays the same (it does: both come from the same VP8/Opus selector). When a
+// device is unplugged mid-call the session falls back to another device of
+// the same kind, or stops sending that kind if none is left.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// ErrDeviceSwitchUnsupported is returned where the native stack cannot
+// capture local media (builds without NativeCapture).
+var ErrDeviceSwitchUnsupported = errors.New("native device capture is not supported on this platform")
+
+// MediaDevice is a local capture device usable by the native call stack.
+type MediaDevice struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"` // "video" or "audio"
+	Label string `json:"label"`
+}
+
+// localTrack is a captured camera or mic track (a mediadevices.Track).
+type localTrack interface {
+	webrtc.TrackLocal
+	Close() error
+	OnEnded(func(error))
+}
+
+var (
+	deviceMu     sync.RWMutex
+	preferredCam string
+	preferredMic string
+)
+
+// SetPreferredDevices sets the camera and mic new calls open first
+// ("" = any device). Calls in progress keep their devices; use SwitchDevice.
+func SetPreferredDevices(cam, mic string) {
+	deviceMu.Lock()
+	preferredCam, preferredMic = cam, mic
+	deviceMu.Unlock()
+}
+
+// PreferredDevices returns the camera and mic set by SetPreferredDevices.
+func PreferredDevices() (cam, mic string) {
+	deviceMu.RLock()
+	defer deviceMu.RUnlock()
+	return preferredCam, preferredMic
+}
+
+// Devices lists the local capture devices. Empty where capture is unsupported.
+func Devices() []MediaDevice {
+	return listDevices()
+}
+
+func codecKind(kind string) (webrtc.RTPCodecType, error) {
+	switch kind {
+	case "video":
+		return webrtc.RTPCodecTypeVideo, nil
+	case "audio":
+		return webrtc.RTPCodecTypeAudio, nil
+	}
+	return 0, fmt.Errorf("unknown device kind %q", kind)
+}
+
+// senderFor returns the sending RTPSender of the given kind, or nil when the
+// call is receive-only for that kind.
+func senderFor(pc *webrtc.PeerConnection, kind webrtc.RTPCodecType) *webrtc.RTPSender {
+	for _, tr := range pc.GetTransceivers() {
+		if tr.Kind() != kind || tr.Sender() == nil {
+			continue
+		}
+		if tr.Direction() == webrtc.RTPTransceiverDirectionSendrecv ||
+			tr.Direction() == webrtc.RTPTransceiverDirectionSendonly {
+			return tr.Sender()
+		}
+	}
+	return nil
+}
+
+// SwitchDevice moves this call's camera ("video") or mic ("audio") to
+// deviceID without renegotiating. "" picks any available device.
+func (s *Session) SwitchDevice(kind, deviceID string) error {
+	k, err := codecKind(kind)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	pc, hung := s.externalPC, s.hung
+	s.mu.Unlock()
+	if hung || pc == nil {
+		return errors.New("call has no media connection")
+	}
+	sender := senderFor(pc, k)
+	if sender == nil {
+		return fmt.Errorf("call is not sending %s", kind)
+	}
+
+	t, err := openDevice(k, deviceID, s.audioProc)
+	if err != nil {
+		return fmt.Errorf("open %s device: %w", kind, err)
+	}
+	if err := s.useTrack(sender, t); err != nil {
+		_ = t.Close()
+		return err
+	}
+	log.Printf("CALL [%s]: %s switched to %q", s.channelID, kind, t.ID())
+	return nil
+}
+
+// useTrack puts t on sender and releases the track it replaces.
+func (s *Session) useTrack(sender *webrtc.RTPSender, t localTrack) error {
+	old := sender.Track()
+	if err := sender.ReplaceTrack(t); err != nil {
+		return fmt.Errorf("replace track: %w", err)
+	}
+	kind := t.Kind()
+	t.OnEnded(func(err error) {
+		if err != nil {
+			go s.deviceLost(t, err)
+		}
+	})
+	if lt, ok := old.(localTrack); ok {
+		_ = lt.Close()
+	}
+
+	s.mu.Lock()
+	s.localTracks[kind] = t
+	s.mu.Unlock()
+
+	if kind == webrtc.RTPCodecTypeVideo {
+		if src := newSelfView(t); src != nil {
+			go s.streamSelfVideoTrack(src)
+		}
+	}
+	s.sig.PublishLocal(s.channelID, map[string]any{
+		"type":      "call-device",
+		"kind":      kind.String(),
+		"device_id": t.ID(),
+		"state":     "active",
+	})
+	return nil
+}
+
+// deviceLost handles a local track that stopped with an error, typically an
+// unplugged device. Tracks already switched away from are ignored.
+func (s *Session) deviceLost(t localTrack, cause error) {
+	s.mu.Lock()
+	pc, hung := s.externalPC, s.hung
+	s.mu.Unlock()
+	if hung || pc == nil {
+		return
+	}
+	kind := t.Kind()
+	sender := senderFor(pc, kind)
+	if sender == nil || sender.Track() != webrtc.TrackLocal(t) {
+		return
+	}
+
+	msg := fmt.Sprintf("%s device %q lost: %v", kind, t.ID(), cause)
+	log.Printf("CALL [%s]: %s", s.channelID, msg)
+	if s.logFn != nil {
+		s.logFn("warn", msg)
+	}
+
+	for _, d := range listDevices() {
+		if d.Kind != kind.String() || d.ID == t.ID() {
+			continue
+		}
+		next, err := openDevice(kind, d.ID, s.audioProc)
+		if err != nil {
+			continue
+		}
+		if err := s.useTrack(sender, next); err != nil {
+			_ = next.Close()
+			continue
+		}
+		log.Printf("CALL [%s]: %s fell back to %q", s.channelID, kind, d.Label)
+		return
+	}
+
+	// Nothing else to capture from: keep the call up, stop sending this kind.
+	if err := sender.ReplaceTrack(nil); err != nil {
+		log.Printf("CALL [%s]: clear %s track: %v", s.channelID, kind, err)
+	}
+	_ = t.Close()
+	s.sig.PublishLocal(s.channelID, map[string]any{
+		"type":  "call-device",
+		"kind":  kind.String(),
+		"state": "lost",
+	})
+}
+
+// deviceIDs reports the devices currently sending, for SessionStatus.
+func deviceIDs(pc *webrtc.PeerConnection) (video, audio string) {
+	if pc == nil {
+		return "", ""
+	}
+	if snd := senderFor(pc, webrtc.RTPCodecTypeVideo); snd != nil && snd.Track() != nil {
+		video = snd.Track().ID()
+	}
+	if snd := senderFor(pc, webrtc.RTPCodecTypeAudio); snd != nil && snd.Track() != nil {
+		audio = snd.Track().ID()
+	}
+	return video, audio
+}
@@ -0,0 +1,58 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+)
+
+func TestCodecKind(t *testing.T) {
+	if k, err := codecKind("video"); err != nil || k != webrtc.RTPCodecTypeVideo {
+		t.Errorf("video → %v, %v", k, err)
+	}
+	if k, err := codecKind("audio"); err != nil || k != webrtc.RTPCodecTypeAudio {
+		t.Errorf("audio → %v, %v", k, err)
+	}
+	if _, err := codecKind("screen"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestSenderFor(t *testing.T) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	// Receive-only (capture failed): nothing to switch.
+	addRecvOnlyTransceivers("test", pc)
+	if senderFor(pc, webrtc.RTPCodecTypeVideo) != nil {
+		t.Error("recvonly video transceiver returned a sender")
+	}
+
+	mic, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "mic-1", "goop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pc.AddTrack(mic); err != nil {
+		t.Fatal(err)
+	}
+	snd := senderFor(pc, webrtc.RTPCodecTypeAudio)
+	if snd == nil || snd.Track() != webrtc.TrackLocal(mic) {
+		t.Fatalf("audio sender = %v", snd)
+	}
+
+	video, audio := deviceIDs(pc)
+	if video != "" || audio != "mic-1" {
+		t.Errorf("deviceIDs = %q, %q", video, audio)
+	}
+}
+
+func TestPreferredDevices(t *testing.T) {
+	SetPreferredDevices("cam-2", "")
+	defer SetPreferredDevices("", "")
+	if cam, mic := PreferredDevices(); cam != "cam-2" || mic != "" {
+		t.Errorf("PreferredDevices = %q, %q", cam, mic)
+	}
+}
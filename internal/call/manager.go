@@ -5,20 +5,37 @@ package call
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
+	"time"
 )
 
+// maxCallbacks bounds the callback-request list.
+const maxCallbacks = 50
+
+// CallbackRequest records a peer that found us busy and asked to be called back.
+type CallbackRequest struct {
+	PeerID    string `json:"peer_id"`
+	ChannelID string `json:"channel_id"`
+	At        int64  `json:"at"` // Unix ms
+}
+
 // Manager owns active call sessions and bridges realtime signaling to them.
 type Manager struct {
 	sig      Signaler
 	selfID   string
 	platform string              // runtime.GOOS — included in call-ack so the origin knows the constellation
 	logFn    func(level, msg string) // publishes structured log events to the browser
+	dataFn   DataHandler             // set via OnData before calls start; may be nil
+
+	testPattern bool // set via UseTestPattern before calls start
 
 	mu           sync.RWMutex
 	sessions     map[string]*Session
 	pendingCalls map[string]string // channelID → origin peerID (call-request received, not yet accepted)
+	callbacks    []CallbackRequest // newest last
 
 	done chan struct{}
 }
@@ -43,11 +60,25 @@ func New(sig Signaler, selfID string, logFn func(level, msg string), platform st
 	return m
 }
 
+// OnData registers the handler for in-call chat and file drops on every
+// session created afterwards. Call it right after New.
+func (m *Manager) OnData(fn DataHandler) {
+	m.dataFn = fn
+}
+
+// UseTestPattern makes every session created afterwards send generated
+// colour bars and a tone instead of the camera and mic. The echo peer
+// answers calls this way. On platforms without native capture the
+// session stays receive-only. Call it right after New.
+func (m *Manager) UseTestPattern() {
+	m.testPattern = true
+}
+
 // StartCall creates a new outbound call session on channelID to remotePeer.
 // The local peer is the origin (isOrigin=true).
 func (m *Manager) StartCall(ctx context.Context, channelID, remotePeer string) (*Session, error) {
 	m.sig.RegisterChannel(channelID, remotePeer)
-	sess := newSession(channelID, remotePeer, m.sig, true, m.logFn)
+	sess := newSession(channelID, remotePeer, m.sig, true, m.logFn, m.dataFn, m.testPattern)
 	m.mu.Lock()
 	m.sessions[channelID] = sess
 	m.mu.Unlock()
@@ -60,7 +91,7 @@ func (m *Manager) StartCall(ctx context.Context, channelID, remotePeer string) (
 // The local peer is the target (isOrigin=false).
 func (m *Manager) AcceptCall(ctx context.Context, channelID, remotePeer string) (*Session, error) {
 	m.sig.RegisterChannel(channelID, remotePeer)
-	sess := newSession(channelID, remotePeer, m.sig, false, m.logFn)
+	sess := newSession(channelID, remotePeer, m.sig, false, m.logFn, m.dataFn, m.testPattern)
 	m.mu.Lock()
 	m.sessions[channelID] = sess
 	delete(m.pendingCalls, channelID)
@@ -89,6 +120,26 @@ func (m *Manager) GetSession(channelID string) (*Session, bool) {
 	return s, ok
 }
 
+// SwitchDevice moves the camera ("video") or mic ("audio") of a call to
+// deviceID mid-call. An empty channelID switches every active call, which
+// is how a settings change reaches calls already in progress.
+func (m *Manager) SwitchDevice(channelID, kind, deviceID string) error {
+	if channelID != "" {
+		s, ok := m.GetSession(channelID)
+		if !ok {
+			return fmt.Errorf("session %s not found", channelID)
+		}
+		return s.SwitchDevice(kind, deviceID)
+	}
+	var errs []error
+	for _, s := range m.AllSessions() {
+		if err := s.SwitchDevice(kind, deviceID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.channelID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // AllSessions returns a snapshot of active, non-hung sessions.
 // Hung sessions are excluded so /api/call/active never causes the browser
 // to restore a call overlay for a call that has already ended.
@@ -104,6 +155,41 @@ func (m *Manager) AllSessions() []*Session {
 	return out
 }
 
+// inCall reports whether any session is live (not hung). Caller holds m.mu.
+func (m *Manager) inCall() bool {
+	for _, s := range m.sessions {
+		if !s.Status().Hung {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestCallback asks the peer on channelID (who answered call-busy) to call us back.
+func (m *Manager) RequestCallback(channelID string) error {
+	return m.sig.Send(channelID, map[string]any{"type": "call-callback"})
+}
+
+// Callbacks returns the pending callback requests, oldest first.
+func (m *Manager) Callbacks() []CallbackRequest {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]CallbackRequest(nil), m.callbacks...)
+}
+
+// DismissCallback removes callback requests from peerID ("" clears all).
+func (m *Manager) DismissCallback(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.callbacks[:0]
+	for _, c := range m.callbacks {
+		if peerID != "" && c.PeerID != peerID {
+			kept = append(kept, c)
+		}
+	}
+	m.callbacks = kept
+}
+
 // removeSession removes a session (and any pending call-request record) from tracking.
 func (m *Manager) removeSession(channelID string) {
 	m.mu.Lock()
@@ -169,12 +255,37 @@ func (m *Manager) dispatch(env *Envelope) {
 			log.Printf("CALL: duplicate call-request on channel %s — ignored", env.Channel)
 			return
 		}
+		if m.inCall() {
+			m.mu.Unlock()
+			// Already in a call: answer busy and let the caller leave a
+			// callback request. The browser sees the same call-request and
+			// does not ring while a call is active.
+			m.sig.RegisterChannel(env.Channel, env.From)
+			go func() {
+				if err := m.sig.Send(env.Channel, map[string]any{"type": "call-busy", "callback": true}); err != nil {
+					log.Printf("CALL: call-busy to %s failed: %v", env.From, err)
+				}
+			}()
+			log.Printf("CALL: busy — declined call-request on channel %s from %s", env.Channel, env.From)
+			return
+		}
 		m.pendingCalls[env.Channel] = env.From
 		m.mu.Unlock()
 		log.Printf("CALL: incoming call-request on channel %s from origin %s", env.Channel, env.From)
 		return
 	}
 
+	if msgType == "call-callback" {
+		m.mu.Lock()
+		m.callbacks = append(m.callbacks, CallbackRequest{PeerID: env.From, ChannelID: env.Channel, At: time.Now().UnixMilli()})
+		if len(m.callbacks) > maxCallbacks {
+			m.callbacks = m.callbacks[len(m.callbacks)-maxCallbacks:]
+		}
+		m.mu.Unlock()
+		log.Printf("CALL: %s asked to be called back", env.From)
+		return
+	}
+
 	// Route other signals (call-ack, offer, answer, ice-candidate, hangup) to existing session.
 	m.mu.RLock()
 	sess, ok := m.sessions[env.Channel]
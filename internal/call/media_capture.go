@@ -0,0 +1,360 @@
+//go:build linux || ((windows || darwin) && nativecall)
+
+package call
+
+import (
+	"errors"
+	"log"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/mediadevices"
+	"github.com/pion/mediadevices/pkg/codec/opus"
+	"github.com/pion/mediadevices/pkg/codec/vpx"
+	"github.com/pion/mediadevices/pkg/driver"
+	_ "github.com/pion/mediadevices/pkg/driver/camera"
+	_ "github.com/pion/mediadevices/pkg/driver/microphone"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/webrtc/v4"
+)
+
+// Local capture on Linux, and on Windows and macOS in builds with the
+// nativecall tag. Those need cgo with libvpx and libopus, and the camera
+// drivers need DirectShow (Windows) or AVFoundation (macOS). The camera
+// formats and driver names per platform are in capture_<os>.go.
+
+// NativeCapture reports whether this build can capture the local camera and
+// mic for native calls.
+const NativeCapture = true
+
+// vp8SelfView wraps a mediadevices VP8 EncodedReadCloser as a SelfViewSource.
+type vp8SelfView struct {
+	r mediadevices.EncodedReadCloser
+}
+
+func (s *vp8SelfView) ReadFrame() ([]byte, func(), error) {
+	buf, rel, err := s.r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]byte, len(buf.Data))
+	copy(data, buf.Data)
+	return data, rel, nil
+}
+
+func (s *vp8SelfView) Close() error { return s.r.Close() }
+
+// newSelfView creates an independent VP8 reader on a camera track for browser
+// self-view. pion/mediadevices broadcasts raw frames to multiple consumers;
+// this encoder runs in parallel to the one Pion uses for RTP. Returns nil
+// when the encoder cannot be created.
+func newSelfView(t localTrack) SelfViewSource {
+	mt, ok := t.(mediadevices.Track)
+	if !ok {
+		return nil
+	}
+	r, err := mt.NewEncodedReader(webrtc.MimeTypeVP8)
+	if err != nil {
+		return nil
+	}
+	return &vp8SelfView{r: r}
+}
+
+// newCodecSelector builds the VP8+Opus selector shared by the PC and by
+// tracks opened for a device switch, so a replacement track binds to the
+// codec already negotiated.
+func newCodecSelector() (*mediadevices.CodecSelector, error) {
+	vpxParams, err := vpx.NewVP8Params()
+	if err != nil {
+		return nil, err
+	}
+	vpxParams.BitRate = 1_500_000 // 1.5 Mbps
+
+	opusParams, err := opus.NewParams()
+	if err != nil {
+		return nil, err
+	}
+
+	return mediadevices.NewCodecSelector(
+		mediadevices.WithVideoEncoders(&vpxParams),
+		mediadevices.WithAudioEncoders(&opusParams),
+	), nil
+}
+
+// videoConstraints limits capture to the platform's raw formats
+// (captureFormats) at up to 640×480, pinned to deviceID when set.
+func videoConstraints(deviceID string) mediadevices.MediaOption {
+	return func(c *mediadevices.MediaTrackConstraints) {
+		c.FrameFormat = captureFormats
+		// Cap at 640×480 — higher resolutions increase VP8 encoding
+		// latency and can cause WebKitGTK MSE to stall on large frames.
+		c.Width = prop.IntRanged{Max: 640}
+		c.Height = prop.IntRanged{Max: 480}
+		if deviceID != "" {
+			c.DeviceID = prop.StringExact(deviceID)
+		}
+	}
+}
+
+func audioConstraints(deviceID string) mediadevices.MediaOption {
+	return func(c *mediadevices.MediaTrackConstraints) {
+		if deviceID != "" {
+			c.DeviceID = prop.StringExact(deviceID)
+		}
+	}
+}
+
+// listDevices returns the cameras and microphones pion/mediadevices can open.
+func listDevices() []MediaDevice {
+	var out []MediaDevice
+	for _, d := range mediadevices.EnumerateDevices() {
+		kind := ""
+		switch d.Kind {
+		case mediadevices.VideoInput:
+			if d.DeviceType == driver.Screen {
+				continue
+			}
+			kind = "video"
+		case mediadevices.AudioInput:
+			kind = "audio"
+		default:
+			continue
+		}
+		out = append(out, MediaDevice{ID: d.DeviceID, Kind: kind, Label: d.Label})
+	}
+	return out
+}
+
+// openDevice captures one device for a mid-call switch ("" = any device).
+func openDevice(kind webrtc.RTPCodecType, deviceID string, proc *audioProcessor) (localTrack, error) {
+	codecSelector, err := newCodecSelector()
+	if err != nil {
+		return nil, err
+	}
+	constraints := mediadevices.MediaStreamConstraints{Codec: codecSelector}
+	if kind == webrtc.RTPCodecTypeVideo {
+		constraints.Video = videoConstraints(deviceID)
+	} else {
+		constraints.Audio = audioConstraints(deviceID)
+	}
+	stream, err := mediadevices.GetUserMedia(constraints)
+	if err != nil {
+		return nil, err
+	}
+	tracks := stream.GetTracks()
+	if len(tracks) == 0 {
+		return nil, errors.New("no track captured")
+	}
+	if at, ok := tracks[0].(*mediadevices.AudioTrack); ok && proc != nil {
+		at.Transform(proc.transform)
+	}
+	return tracks[0], nil
+}
+
+// initMediaPC creates the ExternalPC with VP8+Opus codecs and attempts to
+// capture local camera/mic via pion/mediadevices (captureBackend).
+// Returns the PC, a cleanup func for local media (may be nil), a SelfViewSource
+// for browser self-preview (non-nil when video capture succeeded), and any error.
+// opts.logFn, if non-nil, is called with (level, msg) for hardware errors that
+// should appear in the browser's Video log tab via MQ. opts.audioProc is
+// installed on the captured mic track (noise/echo suppression).
+func initMediaPC(channelID string, opts mediaOptions) (*webrtc.PeerConnection, func(), SelfViewSource, error) {
+	logFn := opts.logFn
+
+	// ── Codec selector ───────────────────────────────────────────────────────
+
+	codecSelector, err := newCodecSelector()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// ── WebRTC API ───────────────────────────────────────────────────────────
+
+	mediaEngine := &webrtc.MediaEngine{}
+	codecSelector.Populate(mediaEngine)
+
+	// Ask for the remote's per-packet audio level; the audio processor uses
+	// it to tell when the far end is talking.
+	if err := mediaEngine.RegisterHeaderExtension(
+		webrtc.RTPHeaderExtensionCapability{URI: audioLevelURI}, webrtc.RTPCodecTypeAudio); err != nil {
+		return nil, nil, nil, err
+	}
+
+	interceptorRegistry := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(mediaEngine, interceptorRegistry); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Use generous ICE timeouts so a brief relay/NAT hiccup does not immediately
+	// terminate the call.  The default disconnectedTimeout is 5 s — far too short
+	// for relay paths that can have short outages during re-keying or failover.
+	// 30 s gives ICE time to recover without the user noticing a freeze.
+	se := webrtc.SettingEngine{}
+	se.SetICETimeouts(ICEDisconnectedTimeout, ICEFailedTimeout, ICECheckInterval)
+
+	api := webrtc.NewAPI(
+		webrtc.WithMediaEngine(mediaEngine),
+		webrtc.WithInterceptorRegistry(interceptorRegistry),
+		webrtc.WithSettingEngine(se),
+	)
+
+	pc, err := api.NewPeerConnection(webrtc.Configuration{
+		ICEServers: iceServers(),
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// ── Test pattern instead of the camera and mic (see UseTestPattern) ──────
+
+	if opts.testPattern {
+		tracks := testPatternTracks(codecSelector)
+		var selfSrc SelfViewSource
+		for _, track := range tracks {
+			if _, err := pc.AddTrack(track); err != nil {
+				log.Printf("CALL [%s]: AddTrack error: %v", channelID, err)
+			}
+			if track.Kind() == webrtc.RTPCodecTypeVideo {
+				selfSrc = newSelfView(track)
+			}
+		}
+		log.Printf("CALL [%s]: sending test pattern", channelID)
+		closeFn := func() {
+			for _, t := range tracks {
+				t.Close()
+			}
+		}
+		return pc, closeFn, selfSrc, nil
+	}
+
+	// ── Enumerate available media devices (diagnostics) ──────────────────────
+
+	devices := mediadevices.EnumerateDevices()
+	if len(devices) == 0 {
+		msg := "no media devices found by pion/mediadevices (" + captureBackend + ")"
+		log.Printf("CALL [%s]: %s", channelID, msg)
+		if logFn != nil {
+			logFn("warn", msg)
+		}
+	} else {
+		for _, d := range devices {
+			log.Printf("CALL [%s]: media device — kind=%v label=%q", channelID, d.Kind, d.Label)
+		}
+	}
+
+	// ── Capture local media with graceful fallback ───────────────────────────
+	//
+	// GetUserMedia fails as a unit if either track (video OR audio) can't be
+	// opened.  Try video+audio first, then video-only, then audio-only so that
+	// a missing/busy microphone doesn't prevent the camera from working and
+	// vice versa.
+
+	//
+	// The preferred devices (SetPreferredDevices) are tried first; if either
+	// is missing the regular attempts take whatever is available.
+
+	type attempt struct {
+		video     bool
+		audio     bool
+		preferred bool
+		label     string
+	}
+	attempts := []attempt{
+		{true, true, false, "video+audio"},
+		{true, false, false, "video-only"},
+		{false, true, false, "audio-only"},
+	}
+	prefCam, prefMic := PreferredDevices()
+	if prefCam != "" || prefMic != "" {
+		attempts = append([]attempt{{true, true, true, "preferred video+audio"}}, attempts...)
+	}
+	for _, a := range attempts {
+		var camID, micID string
+		if a.preferred {
+			camID, micID = prefCam, prefMic
+		}
+		constraints := mediadevices.MediaStreamConstraints{Codec: codecSelector}
+		if a.video {
+			constraints.Video = videoConstraints(camID)
+		}
+		if a.audio {
+			constraints.Audio = audioConstraints(micID)
+		}
+
+		stream, err := mediadevices.GetUserMedia(constraints)
+		if err != nil {
+			msg := "GetUserMedia (" + a.label + ") failed: " + err.Error()
+			log.Printf("CALL [%s]: %s", channelID, msg)
+			if logFn != nil {
+				logFn("warn", msg)
+			}
+			continue
+		}
+
+		tracks := stream.GetTracks()
+		var selfSrc SelfViewSource
+		brokenVideo := false
+		for _, track := range tracks {
+			track.OnEnded(func(err error) {
+				if err != nil {
+					log.Printf("CALL [%s]: local track ended: %v", channelID, err)
+					if opts.onEnded != nil {
+						opts.onEnded(track, err)
+					}
+				}
+			})
+			// Noise and echo suppression run between capture and the Opus
+			// encoder, so the transform must be in place before AddTrack.
+			if at, ok := track.(*mediadevices.AudioTrack); ok && opts.audioProc != nil {
+				at.Transform(opts.audioProc.transform)
+			}
+			if _, err := pc.AddTrack(track); err != nil {
+				log.Printf("CALL [%s]: AddTrack error: %v", channelID, err)
+			}
+			// Create an independent VP8 reader for browser self-view.
+			// pion/mediadevices broadcasts raw frames to multiple consumers;
+			// this encoder runs in parallel to the one Pion uses for RTP.
+			if track.Kind() == webrtc.RTPCodecTypeVideo {
+				r, err := track.NewEncodedReader(webrtc.MimeTypeVP8)
+				if err == nil {
+					selfSrc = &vp8SelfView{r: r}
+					log.Printf("CALL [%s]: self-view VP8 reader ready", channelID)
+				} else {
+					// Broken video encoder (e.g. malformed MJPEG from camera).
+					// Close all tracks and fall through to the next attempt —
+					// a poisoned VP8 encoder would cause SetRemoteDescription to
+					// fail and break SDP negotiation entirely.
+					msg := "video track broken, skipping attempt (" + a.label + "): " + err.Error()
+					log.Printf("CALL [%s]: %s", channelID, msg)
+					if logFn != nil {
+						logFn("warn", msg)
+					}
+					brokenVideo = true
+				}
+			}
+		}
+		if brokenVideo {
+			for _, t := range tracks {
+				t.Close()
+			}
+			continue
+		}
+
+		log.Printf("CALL [%s]: local media captured (%s) — %d tracks", channelID, a.label, len(tracks))
+		closeFn := func() {
+			for _, t := range tracks {
+				t.Close()
+			}
+		}
+		return pc, closeFn, selfSrc, nil
+	}
+
+	// All attempts failed — fall back to receive-only so the call can still
+	// receive remote media even without local camera/mic.
+	msg := "all media capture attempts failed — proceeding receive-only"
+	log.Printf("CALL [%s]: %s", channelID, msg)
+	if logFn != nil {
+		logFn("warn", msg)
+	}
+	addRecvOnlyTransceivers(channelID, pc)
+	return pc, nil, nil, nil
+}
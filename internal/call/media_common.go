@@ -2,12 +2,52 @@ package call
 
 import (
 	"log"
+	"sync"
 
 	"github.com/pion/webrtc/v4"
 )
 
+// defaultSTUNServers is used when the rendezvous does not advertise its own.
+var defaultSTUNServers = []string{"stun:stun.l.google.com:19302"}
+
+var (
+	stunMu      sync.RWMutex
+	stunServers []string
+)
+
+// SetSTUNServers sets the STUN URLs used for ICE (e.g. from RelayInfo).
+// An empty list restores the public default.
+func SetSTUNServers(urls []string) {
+	stunMu.Lock()
+	stunServers = append([]string(nil), urls...)
+	stunMu.Unlock()
+}
+
+// STUNServers returns the STUN URLs used for ICE.
+func STUNServers() []string {
+	stunMu.RLock()
+	defer stunMu.RUnlock()
+	if len(stunServers) == 0 {
+		return defaultSTUNServers
+	}
+	return append([]string(nil), stunServers...)
+}
+
+func iceServers() []webrtc.ICEServer {
+	return []webrtc.ICEServer{{URLs: STUNServers()}}
+}
+
+// mediaOptions carries the session hooks initMediaPC wires into local capture.
+type mediaOptions struct {
+	logFn     func(level, msg string) // hardware errors for the browser's Video log tab; may be nil
+	audioProc *audioProcessor         // installed on the mic track; may be nil
+	onEnded   func(localTrack, error) // a capture track stopped with an error (device unplugged)
+
+	testPattern bool // send generated colour bars and a tone instead of capturing (NativeCapture builds only)
+}
+
 // SelfViewSource provides encoded VP8 frames of the local camera for
-// self-view display in the browser.  Only non-nil on Linux when camera
+// self-view display in the browser.  Only non-nil with NativeCapture when camera
 // capture succeeded.  ReadFrame blocks until the next frame is ready.
 // Close must be called when the session ends.
 type SelfViewSource interface {
@@ -1,4 +1,4 @@
-//go:build !linux
+//go:build !linux && !((windows || darwin) && nativecall)
 
 package call
 
@@ -9,12 +9,17 @@ import (
 	"github.com/pion/webrtc/v4"
 )
 
-// initMediaPC creates a receive-only PeerConnection on non-Linux platforms.
-// Camera/mic capture via pion/mediadevices requires platform-specific drivers
-// (V4L2/malgo on Linux); on Windows/macOS the browser WebRTC path handles media.
-// logFn is unused on non-Linux — no hardware capture is attempted here.
-// SelfViewSource is always nil on non-Linux (no local camera capture).
-func initMediaPC(channelID string, _ func(level, msg string)) (*webrtc.PeerConnection, func(), SelfViewSource, error) {
+// NativeCapture reports whether this build can capture the local camera and
+// mic for native calls. Windows and macOS builds only can with the
+// nativecall build tag (see media_capture.go).
+const NativeCapture = false
+
+// initMediaPC creates a receive-only PeerConnection where this build has no
+// capture drivers; the browser WebRTC path normally handles media there.
+// logFn is unused — no hardware capture is attempted here.
+// SelfViewSource is always nil (no local camera capture), and there is no
+// mic for the audio processor to work on.
+func initMediaPC(channelID string, _ mediaOptions) (*webrtc.PeerConnection, func(), SelfViewSource, error) {
 	mediaEngine := &webrtc.MediaEngine{}
 	if err := mediaEngine.RegisterDefaultCodecs(); err != nil {
 		return nil, nil, nil, err
@@ -35,9 +40,7 @@ func initMediaPC(channelID string, _ func(level, msg string)) (*webrtc.PeerConne
 	)
 
 	pc, err := api.NewPeerConnection(webrtc.Configuration{
-		ICEServers: []webrtc.ICEServer{
-			{URLs: []string{"stun:stun.l.google.com:19302"}},
-		},
+		ICEServers: iceServers(),
 	})
 	if err != nil {
 		return nil, nil, nil, err
@@ -49,3 +52,12 @@ func initMediaPC(channelID string, _ func(level, msg string)) (*webrtc.PeerConne
 	log.Printf("CALL [%s]: ExternalPC ready (receive-only, no local media on this platform)", channelID)
 	return pc, nil, nil, nil
 }
+
+// listDevices returns nothing: there is no native capture on this platform.
+func listDevices() []MediaDevice { return nil }
+
+func openDevice(webrtc.RTPCodecType, string, *audioProcessor) (localTrack, error) {
+	return nil, ErrDeviceSwitchUnsupported
+}
+
+func newSelfView(localTrack) SelfViewSource { return nil }
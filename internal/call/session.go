@@ -1,6 +1,7 @@
 package call
 
 import (
+	"errors"
 	"log"
 	"strings"
 	"sync"
@@ -13,12 +14,13 @@ import (
 )
 
 // Session represents one active call between two peers.
-// Platform-specific media capture (camera/mic) is in media_linux.go /
+// Platform-specific media capture (camera/mic) is in media_capture.go /
 // media_other.go via the initMediaPC() function they each provide.
 //
 // Phase 3: ExternalPC exchanges media with the remote peer over a standard
-// WebRTC PeerConnection.  Local capture works on Linux (V4L2 + malgo);
-// on other platforms the PC is receive-only until Phase 5 adds more drivers.
+// WebRTC PeerConnection.  Local capture works on Linux (V4L2 + malgo), and
+// on Windows (DirectShow) and macOS (AVFoundation) in nativecall builds;
+// elsewhere the PC is receive-only.
 //
 // Phase 4: Remote tracks are relayed to the browser via a WebM stream served
 // over a WebSocket at /api/call/media/{channel}.  The browser's MSE API
@@ -30,17 +32,37 @@ type Session struct {
 	sig        Signaler
 	isOrigin   bool // true = created by StartCall (origin); false = created by AcceptCall (target)
 	logFn      func(level, msg string) // may be nil; publishes structured logs to browser
+	dataFn     DataHandler             // may be nil; persists in-call chat and file drops
+
+	testPattern bool // send the test pattern instead of local capture (see Manager.UseTestPattern)
 
 	mu         sync.Mutex
 	audioOn    bool
 	videoOn    bool
 	hung       bool
+	onHold     bool // we put the call on hold
+	remoteHold bool // the remote peer put the call on hold
 	hangupCh   chan struct{}
 	mediaClose func() // closes local media tracks; nil when no local media
 
 	// ExternalPC is the Pion PeerConnection to the remote peer.
 	externalPC *webrtc.PeerConnection
 
+	// dc carries in-call chat and file drops (see datachannel.go).
+	dc    *webrtc.DataChannel
+	files fileAssembler
+
+	// captions transcribes received audio when live captions are on (captions.go).
+	captions *captioner
+
+	// audioProc cleans up the local mic before encoding (audioproc.go).
+	// streamAudioTrack feeds it far-end activity for echo suppression.
+	audioProc *audioProcessor
+
+	// localTracks holds capture tracks opened by a device switch (devices.go);
+	// the tracks from initMediaPC are released by mediaClose.
+	localTracks map[webrtc.RTPCodecType]localTrack
+
 	// pcState tracks the most recent PeerConnectionState for /api/call/debug.
 	pcState webrtc.PeerConnectionState
 
@@ -58,8 +80,12 @@ type Session struct {
 	webm *webmSession
 
 	// selfWebm streams the locally-captured camera back to the browser
-	// for the self-view inset (Linux native mode only).
+	// for the self-view inset (native mode with NativeCapture only).
 	selfWebm *webmSession
+
+	// selfStart is the self-view timeline origin; a camera switch keeps it
+	// so the browser's MSE buffer sees continuous timestamps.
+	selfStart time.Time
 }
 
 // SessionStatus is the snapshot returned by /api/call/debug.
@@ -71,12 +97,18 @@ type SessionStatus struct {
 	AudioOn    bool   `json:"audio_on"`
 	VideoOn    bool   `json:"video_on"`
 	Hung       bool   `json:"hung"`
+	OnHold     bool   `json:"on_hold"`
+	RemoteHold bool   `json:"remote_hold"`
+	Captions   string `json:"captions,omitempty"` // caption language while captions are on
+	Camera     string `json:"camera,omitempty"`   // device ID of the camera being sent
+	Mic        string `json:"mic,omitempty"`      // device ID of the mic being sent
 }
 
 // Status returns a snapshot of the session for the debug endpoint.
 func (s *Session) Status() SessionStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	camera, mic := deviceIDs(s.externalPC)
 	return SessionStatus{
 		ChannelID:  s.channelID,
 		RemotePeer: s.remotePeer,
@@ -85,23 +117,40 @@ func (s *Session) Status() SessionStatus {
 		AudioOn:    s.audioOn,
 		VideoOn:    s.videoOn,
 		Hung:       s.hung,
+		OnHold:     s.onHold,
+		RemoteHold: s.remoteHold,
+		Captions:   captionLang(s.captions),
+		Camera:     camera,
+		Mic:        mic,
+	}
+}
+
+func captionLang(c *captioner) string {
+	if c == nil {
+		return ""
 	}
+	return c.lang
 }
 
 // newSession creates a Session and kicks off background PC + media initialisation.
-func newSession(channelID, remotePeer string, sig Signaler, isOrigin bool, logFn func(level, msg string)) *Session {
+func newSession(channelID, remotePeer string, sig Signaler, isOrigin bool, logFn func(level, msg string), dataFn DataHandler, testPattern bool) *Session {
 	s := &Session{
 		channelID:  channelID,
 		remotePeer: remotePeer,
 		sig:        sig,
 		isOrigin:   isOrigin,
 		logFn:      logFn,
+		dataFn:     dataFn,
 		audioOn:    true,
 		videoOn:    true,
 		hangupCh:   make(chan struct{}),
 		mediaReady: make(chan struct{}),
 		webm:       newWebmSession(channelID),
 		selfWebm:   newWebmSession(channelID + ":self"),
+		audioProc:  newAudioProcessor(),
+
+		localTracks: make(map[webrtc.RTPCodecType]localTrack),
+		testPattern: testPattern,
 	}
 	go s.initExternalPC()
 
@@ -138,8 +187,8 @@ func (s *Session) SubscribeMedia() (<-chan []byte, func()) {
 }
 
 // SelfSubscribeMedia returns a channel for the browser self-view WebM stream
-// (local camera, video-only).  Only produces data on Linux when camera capture
-// succeeded.  The caller must invoke the returned cancel function when done.
+// (local camera, video-only).  Only produces data with NativeCapture when camera
+// capture succeeded.  The caller must invoke the returned cancel function when done.
 func (s *Session) SelfSubscribeMedia() (<-chan []byte, func()) {
 	return s.selfWebm.subscribeMedia()
 }
@@ -149,7 +198,12 @@ func (s *Session) SelfSubscribeMedia() (<-chan []byte, func()) {
 func (s *Session) streamSelfVideoTrack(src SelfViewSource) {
 	defer src.Close()
 	log.Printf("CALL [%s]: self-view streaming started", s.channelID)
-	start := time.Now()
+	s.mu.Lock()
+	if s.selfStart.IsZero() {
+		s.selfStart = time.Now()
+	}
+	start := s.selfStart
+	s.mu.Unlock()
 	for {
 		select {
 		case <-s.hangupCh:
@@ -200,6 +254,67 @@ func (s *Session) ToggleVideo() bool {
 	return disabled
 }
 
+// Hold puts the call on hold and tells the remote peer. Like the toggles,
+// only the state is tracked; local tracks keep running until Pion muting lands.
+func (s *Session) Hold() error {
+	return s.setHold(true)
+}
+
+// Resume takes the call off hold and tells the remote peer.
+func (s *Session) Resume() error {
+	return s.setHold(false)
+}
+
+func (s *Session) setHold(on bool) error {
+	s.mu.Lock()
+	if s.hung {
+		s.mu.Unlock()
+		return errors.New("call has ended")
+	}
+	s.onHold = on
+	s.mu.Unlock()
+
+	msgType := "call-resume"
+	if on {
+		msgType = "call-hold"
+	}
+	log.Printf("CALL [%s]: %s → %s", s.channelID, msgType, s.remotePeer)
+	return s.sig.Send(s.channelID, map[string]any{"type": msgType})
+}
+
+// Transfer asks the remote peer to call targetPeer instead (blind transfer),
+// then hangs up this session.
+func (s *Session) Transfer(targetPeer string) error {
+	s.mu.Lock()
+	hung := s.hung
+	s.mu.Unlock()
+	if hung {
+		return errors.New("call has ended")
+	}
+	if err := s.sig.Send(s.channelID, map[string]any{"type": "call-transfer", "target": targetPeer}); err != nil {
+		return err
+	}
+	log.Printf("CALL [%s]: transferred %s → %s", s.channelID, s.remotePeer, targetPeer)
+	s.Hangup()
+	return nil
+}
+
+// endLocal marks the session hung without signaling the remote peer.
+// Returns false if it had already ended.
+func (s *Session) endLocal() bool {
+	s.mu.Lock()
+	alreadyHung := s.hung
+	if !s.hung {
+		s.hung = true
+		close(s.hangupCh)
+	}
+	s.mu.Unlock()
+	if !alreadyHung {
+		s.cleanup()
+	}
+	return !alreadyHung
+}
+
 // Hangup tears down this session and signals the remote peer. Idempotent.
 func (s *Session) Hangup() {
 	s.mu.Lock()
@@ -225,13 +340,20 @@ func (s *Session) cleanup() {
 	s.mu.Lock()
 	pc := s.externalPC
 	closeFn := s.mediaClose
+	tracks := s.localTracks
 	s.externalPC = nil
 	s.mediaClose = nil
+	s.localTracks = make(map[webrtc.RTPCodecType]localTrack)
+	s.dc = nil
+	s.captions = nil
 	s.mu.Unlock()
 
 	if closeFn != nil {
 		closeFn()
 	}
+	for _, t := range tracks {
+		_ = t.Close()
+	}
 	if pc != nil {
 		_ = pc.Close()
 	}
@@ -240,12 +362,18 @@ func (s *Session) cleanup() {
 // ── ExternalPC initialisation ──────────────────────────────────────────────────
 
 // initExternalPC builds the Pion PeerConnection using the platform-specific
-// initMediaPC() function (media_linux.go / media_other.go), wires up common
+// initMediaPC() function (media_capture.go / media_other.go), wires up common
 // callbacks, and closes s.mediaReady when done.
 func (s *Session) initExternalPC() {
 	defer close(s.mediaReady)
 
-	pc, closeFn, selfSrc, err := initMediaPC(s.channelID, s.logFn)
+	pc, closeFn, selfSrc, err := initMediaPC(s.channelID, mediaOptions{
+		logFn:     s.logFn,
+		audioProc: s.audioProc,
+		onEnded:   func(t localTrack, err error) { go s.deviceLost(t, err) },
+
+		testPattern: s.testPattern,
+	})
 	if err != nil {
 		log.Printf("CALL [%s]: PeerConnection create error: %v", s.channelID, err)
 		return
@@ -256,6 +384,8 @@ func (s *Session) initExternalPC() {
 	s.mediaClose = closeFn
 	s.mu.Unlock()
 
+	s.initDataChannel(pc)
+
 	// Do NOT call s.selfWebm.enableAudio() here.
 	//
 	// selfWebm is video-only — no Opus SimpleBlocks are ever sent.  If we
@@ -268,7 +398,7 @@ func (s *Session) initExternalPC() {
 	// "vp8").  A video-only init segment is valid inside a vp8+opus
 	// SourceBuffer — the MIME declares codec capability, not required tracks.
 
-	// Stream local camera to browser self-view (Linux native mode).
+	// Stream local camera to browser self-view (native mode with capture).
 	if selfSrc != nil {
 		go s.streamSelfVideoTrack(selfSrc)
 	}
@@ -320,14 +450,14 @@ func (s *Session) initExternalPC() {
 	// Unconditionally enabling audio caused GStreamer to stall indefinitely when
 	// the remote had no microphone — the init segment declared an audio track
 	// but no Opus SimpleBlocks ever arrived.
-	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+	pc.OnTrack(func(track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
 		log.Printf("CALL [%s]: remote track — kind=%s codec=%s ssrc=%d",
 			s.channelID, track.Kind(), track.Codec().MimeType, track.SSRC())
 		switch track.Kind() {
 		case webrtc.RTPCodecTypeVideo:
 			go s.streamVideoTrack(track)
 		case webrtc.RTPCodecTypeAudio:
-			go s.streamAudioTrack(track)
+			go s.streamAudioTrack(track, audioLevelExtID(receiver))
 		}
 	})
 }
@@ -422,7 +552,8 @@ func (s *Session) streamVideoTrack(track *webrtc.TrackRemote) {
 // streamAudioTrack reads incoming Opus RTP packets and feeds them to the
 // webmSession for Phase 4 browser audio via MSE.
 // ReadRTP blocks until a packet arrives or the PC closes (error → return).
-func (s *Session) streamAudioTrack(track *webrtc.TrackRemote) {
+// levelExt is the audio-level header extension ID (0 = not negotiated).
+func (s *Session) streamAudioTrack(track *webrtc.TrackRemote, levelExt uint8) {
 	codec := track.Codec().MimeType
 	log.Printf("CALL [%s]: audio streaming started (%s)", s.channelID, codec)
 
@@ -453,6 +584,14 @@ func (s *Session) streamAudioTrack(track *webrtc.TrackRemote) {
 		if len(pkt.Payload) == 0 {
 			continue
 		}
+		s.audioProc.remoteAudio(pkt, levelExt)
+		s.mu.Lock()
+		cc := s.captions
+		s.mu.Unlock()
+		if cc != nil {
+			cc.writeRTP(pkt)
+		}
+
 		// Opus RTP clock is 48 kHz; convert to milliseconds.
 		tsMs := int64(pkt.Timestamp) / 48
 		// Opus RTP payload is the raw Opus frame — no header to strip.
@@ -520,18 +659,28 @@ func (s *Session) handleSignal(msgType string, payload map[string]any) {
 		})
 
 	case "call-hangup":
-		s.mu.Lock()
-		alreadyHung := s.hung
-		if !s.hung {
-			s.hung = true
-			close(s.hangupCh)
-		}
-		s.mu.Unlock()
-		if !alreadyHung {
-			s.cleanup()
+		if s.endLocal() {
 			log.Printf("CALL [%s]: remote hangup from %s", s.channelID, s.remotePeer)
 		}
 
+	case "call-busy":
+		// Target is already in another call and declined automatically.
+		if s.endLocal() {
+			log.Printf("CALL [%s]: %s is busy", s.channelID, s.remotePeer)
+		}
+
+	case "call-hold", "call-resume":
+		s.mu.Lock()
+		s.remoteHold = msgType == "call-hold"
+		s.mu.Unlock()
+		log.Printf("CALL [%s]: %s from %s", s.channelID, msgType, s.remotePeer)
+
+	case "call-transfer":
+		// The browser dials the target (it receives the same signal over MQ);
+		// the transferring peer hangs up this session right after.
+		target, _ := payload["target"].(string)
+		log.Printf("CALL [%s]: %s transferred us to %s", s.channelID, s.remotePeer, target)
+
 	case "browser-offer", "browser-answer", "browser-ice":
 		// W2W browser-to-browser WebRTC signaling — handled entirely in JS.
 		// Go's dispatchLoop also receives these via SubscribeTopic; ignore silently.
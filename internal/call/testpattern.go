@@ -0,0 +1,142 @@
+//go:build linux || ((windows || darwin) && nativecall)
+
+package call
+
+import (
+	"image"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/mediadevices"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+// Test pattern dimensions and pacing.
+const (
+	patternWidth   = 640
+	patternHeight  = 480
+	patternFPS     = 15
+	patternRate    = 48000                 // audio sample rate
+	patternChunk   = 20 * time.Millisecond // audio chunk length
+	patternToneHz  = 440
+	patternBeepLen = 200 * time.Millisecond // tone at the start of every second
+)
+
+// patternBars are the colour bars in Y, Cb, Cr: white, yellow, cyan,
+// green, magenta, red, blue.
+var patternBars = [][3]byte{
+	{235, 128, 128},
+	{210, 16, 146},
+	{170, 166, 16},
+	{145, 54, 34},
+	{107, 202, 222},
+	{82, 90, 240},
+	{41, 240, 110},
+}
+
+// patternSource is the mediadevices.Source part of both pattern sources.
+type patternSource struct {
+	id     string
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPatternSource(id string) patternSource {
+	return patternSource{id: id, closed: make(chan struct{})}
+}
+
+func (p *patternSource) ID() string { return p.id }
+
+func (p *patternSource) Close() error {
+	p.once.Do(func() { close(p.closed) })
+	return nil
+}
+
+// patternVideo draws colour bars with a black stripe sweeping across
+// them, so a frozen picture is easy to tell from a live one.
+type patternVideo struct {
+	patternSource
+	tick  *time.Ticker
+	frame int
+	img   *image.YCbCr
+}
+
+func newPatternVideo() *patternVideo {
+	return &patternVideo{
+		patternSource: newPatternSource("testpattern-video"),
+		tick:          time.NewTicker(time.Second / patternFPS),
+		img:           image.NewYCbCr(image.Rect(0, 0, patternWidth, patternHeight), image.YCbCrSubsampleRatio420),
+	}
+}
+
+func (v *patternVideo) Read() (image.Image, func(), error) {
+	select {
+	case <-v.closed:
+		v.tick.Stop()
+		return nil, func() {}, io.EOF
+	case <-v.tick.C:
+	}
+	stripe := v.frame * 8 % patternWidth
+	v.frame++
+	img := v.img
+	for y := range patternHeight {
+		for x := range patternWidth {
+			c := patternBars[x*len(patternBars)/patternWidth]
+			luma := c[0]
+			if x >= stripe && x < stripe+16 {
+				luma = 16
+			}
+			img.Y[y*img.YStride+x] = luma
+			if y%2 == 0 && x%2 == 0 {
+				ci := img.COffset(x, y)
+				img.Cb[ci], img.Cr[ci] = c[1], c[2]
+			}
+		}
+	}
+	return img, func() {}, nil
+}
+
+// patternAudio plays a short tone at the start of every second.
+type patternAudio struct {
+	patternSource
+	next   time.Time
+	sample int
+}
+
+func newPatternAudio() *patternAudio {
+	return &patternAudio{patternSource: newPatternSource("testpattern-audio"), next: time.Now()}
+}
+
+func (a *patternAudio) Read() (wave.Audio, func(), error) {
+	select {
+	case <-a.closed:
+		return nil, func() {}, io.EOF
+	default:
+	}
+	time.Sleep(time.Until(a.next))
+	a.next = a.next.Add(patternChunk)
+
+	n := int(patternRate * patternChunk / time.Second)
+	chunk := wave.NewFloat32Interleaved(wave.ChunkInfo{Channels: 1, Len: n, SamplingRate: patternRate})
+	beep := int(patternRate * patternBeepLen / time.Second)
+	for i := range n {
+		var v float32
+		if a.sample%patternRate < beep {
+			v = float32(0.25 * math.Sin(2*math.Pi*patternToneHz*float64(a.sample)/patternRate))
+		}
+		chunk.SetFloat32(i, 0, wave.Float32Sample(v))
+		a.sample++
+	}
+	return chunk, func() {}, nil
+}
+
+// testPatternTracks returns a video and an audio track that send the test
+// pattern, encoded with the codecs in selector.
+func testPatternTracks(selector *mediadevices.CodecSelector) []mediadevices.Track {
+	return []mediadevices.Track{
+		mediadevices.NewVideoTrack(newPatternVideo(), selector),
+		mediadevices.NewAudioTrack(newPatternAudio(), selector),
+	}
+}
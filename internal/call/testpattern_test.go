@@ -0,0 +1,63 @@
+//go:build linux || ((windows || darwin) && nativecall)
+
+package call
+
+import (
+	"image"
+	"io"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+func TestPatternVideo_stripeMoves(t *testing.T) {
+	v := newPatternVideo()
+	lumaRow := func() []byte {
+		img, _, err := v.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		y := img.(*image.YCbCr)
+		return append([]byte(nil), y.Y[100*y.YStride:100*y.YStride+patternWidth]...)
+	}
+	first, second := lumaRow(), lumaRow()
+	if string(first) == string(second) {
+		t.Error("consecutive frames are identical")
+	}
+	if first[0] != 16 || first[patternWidth-1] != patternBars[len(patternBars)-1][0] {
+		t.Errorf("row edges = %d, %d", first[0], first[patternWidth-1])
+	}
+
+	v.Close()
+	if _, _, err := v.Read(); err != io.EOF {
+		t.Errorf("read after close = %v", err)
+	}
+}
+
+func TestPatternAudio_beepsOncePerSecond(t *testing.T) {
+	a := newPatternAudio()
+	chunks := int(1e9 / patternChunk) // one second
+	var loud []bool
+	for range chunks {
+		c, _, err := a.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := c.(*wave.Float32Interleaved)
+		peak := float32(0)
+		for _, s := range f.Data {
+			peak = max(peak, s)
+		}
+		loud = append(loud, peak > 0.2)
+	}
+	beepChunks := int(patternBeepLen / patternChunk)
+	for i, l := range loud {
+		if l != (i < beepChunks) {
+			t.Fatalf("chunk %d loud=%v, want a beep in the first %d chunks only", i, l, beepChunks)
+		}
+	}
+	a.Close()
+	if _, _, err := a.Read(); err != io.EOF {
+		t.Errorf("read after close = %v", err)
+	}
+}
@@ -4,11 +4,14 @@ import "time"
 
 // Native call stack (Pion WebRTC) timings.
 const (
-	ICEGatherTimeout       = 5 * time.Second   // wait for ICE candidate gathering
-	ICEDisconnectedTimeout = 30 * time.Second  // ICE disconnected before failure (relay recovery window)
-	ICEFailedTimeout       = 120 * time.Second // ICE failed — give up after this
-	ICECheckInterval       = 2 * time.Second   // ICE connectivity check interval
-	AudioCheckInterval     = 2 * time.Second   // poll audio track liveness
-	VideoCheckInterval     = 5 * time.Second   // poll video track liveness
-	SelfViewInterval       = 5 * time.Second   // self-view frame check
+	ICEGatherTimeout       = 5 * time.Second        // wait for ICE candidate gathering
+	ICEDisconnectedTimeout = 30 * time.Second       // ICE disconnected before failure (relay recovery window)
+	ICEFailedTimeout       = 120 * time.Second      // ICE failed — give up after this
+	ICECheckInterval       = 2 * time.Second        // ICE connectivity check interval
+	AudioCheckInterval     = 2 * time.Second        // poll audio track liveness
+	VideoCheckInterval     = 5 * time.Second        // poll video track liveness
+	SelfViewInterval       = 5 * time.Second        // self-view frame check
+	CaptionSegment         = 4 * time.Second        // received audio per caption transcription
+	CaptionTimeout         = 30 * time.Second       // caption command run limit per segment
+	EchoHangover           = 300 * time.Millisecond // keep ducking the mic this long after far-end speech
 )
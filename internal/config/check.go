@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Issue is one problem found in a config file.
+type Issue struct {
+	Field   string `json:"field,omitempty"` // JSON path, e.g. "p2p.listen_port"
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"` // reported, but the config still loads
+}
+
+func (i Issue) Error() string { return i.Message }
+
+type issues []Issue
+
+func (l *issues) add(field, msg string) {
+	*l = append(*l, Issue{Field: field, Message: msg})
+}
+
+func (l *issues) warn(field, msg string) {
+	*l = append(*l, Issue{Field: field, Message: msg, Warning: true})
+}
+
+// Problems is the error Load returns for a config that fails validation.
+type Problems []Issue
+
+func (p Problems) Error() string {
+	msgs := make([]string, len(p))
+	for i, is := range p {
+		msgs[i] = is.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Check reads the config at path and reports every problem in it: JSON
+// errors, invalid values, unknown keys and settings that load but are
+// likely a mistake. Only issues without Warning keep the config from
+// loading. The error is set when the file cannot be read at all.
+func Check(path string) ([]Issue, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b = stripBOM(b)
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return []Issue{jsonIssue(b, err)}, nil
+	}
+	merged, err := readMerged(path)
+	if err != nil {
+		return []Issue{{Message: filepath.Base(StateFile(path)) + ": " + err.Error()}}, nil
+	}
+	return CheckJSON(merged), nil
+}
+
+// CheckJSON reports the problems in a config document, as Check does for
+// a file.
+func CheckJSON(b []byte) []Issue {
+	cfg := Default()
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return []Issue{jsonIssue(b, err)}
+	}
+	var raw map[string]any
+	_ = json.Unmarshal(b, &raw)
+
+	out := issues(cfg.Problems())
+	unknownKeys(&out, raw, reflect.TypeOf(Config{}), "")
+	cfg.warnings(&out)
+	return out
+}
+
+// jsonIssue turns a JSON error into an Issue that names the field or
+// the line it is about.
+func jsonIssue(b []byte, err error) Issue {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		field := te.Field
+		return Issue{Field: field, Message: fmt.Sprintf("%s: expected %s, got %s", field, jsonKind(te.Type), te.Value)}
+	}
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		line, col := lineCol(b, se.Offset)
+		return Issue{Message: fmt.Sprintf("line %d, column %d: %s", line, col, se.Error())}
+	}
+	return Issue{Message: err.Error()}
+}
+
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "a whole number"
+	case reflect.Float64, reflect.Float32:
+		return "a number"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return t.String()
+}
+
+func lineCol(b []byte, off int64) (int, int) {
+	if off > int64(len(b)) {
+		off = int64(len(b))
+	}
+	before := b[:off]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(off) - bytes.LastIndexByte(before, '\n')
+	return line, col - 1
+}
+
+// unknownKeys flags keys the Config struct does not have, with the
+// closest known key as a suggestion.
+func unknownKeys(out *issues, raw map[string]any, t reflect.Type, prefix string) {
+	known := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		known[name] = f.Type
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ft, ok := known[k]
+		if !ok {
+			msg := "unknown key " + prefix + k
+			if s := closestKey(k, known); s != "" {
+				msg += " (did you mean " + prefix + s + "?)"
+			}
+			out.warn(prefix+k, msg)
+			continue
+		}
+		sub, isObj := raw[k].(map[string]any)
+		if !isObj {
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			unknownKeys(out, sub, ft, prefix+k+".")
+		case reflect.Map:
+			if ft.Elem().Kind() == reflect.Struct {
+				mkeys := make([]string, 0, len(sub))
+				for mk := range sub {
+					mkeys = append(mkeys, mk)
+				}
+				sort.Strings(mkeys)
+				for _, mk := range mkeys {
+					if m, ok := sub[mk].(map[string]any); ok {
+						unknownKeys(out, m, ft.Elem(), prefix+k+"."+mk+".")
+					}
+				}
+			}
+		}
+	}
+}
+
+// closestKey returns the known key within a small edit distance of k.
+func closestKey(k string, known map[string]reflect.Type) string {
+	best, bestD := "", len(k)/3+1
+	for name := range known {
+		if d := editDistance(strings.ToLower(k), name); d < bestD || d == bestD && best != "" && name < best {
+			best, bestD = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// warnings adds settings that load but are likely a mistake.
+func (c *Config) warnings(out *issues) {
+	if hb := c.Presence.HeartbeatSec; hb > 0 && hb < c.Presence.TTLSec && hb*2 > c.Presence.TTLSec {
+		out.warn("presence.heartbeat_seconds", "presence.heartbeat_seconds is more than half of ttl_seconds; one late heartbeat marks the peer offline")
+	}
+	if c.Presence.RendezvousHost && c.Presence.AdminPassword == "" {
+		if ip := net.ParseIP(c.Presence.RendezvousBind); ip != nil && !ip.IsLoopback() {
+			out.warn("presence.admin_password", "presence.admin_password is empty, so the admin pages of a rendezvous bound to "+c.Presence.RendezvousBind+" are disabled")
+		}
+	}
+}
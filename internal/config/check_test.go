@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProblems_ListsEveryError(t *testing.T) {
+	cfg := Default()
+	cfg.P2P.ListenPort = -1
+	cfg.Presence.TTLSec = 0
+	cfg.Assets.Enabled = true
+	cfg.Assets.Widths = []int{1, 2}
+
+	p := cfg.Problems()
+	fields := make([]string, len(p))
+	for i, is := range p {
+		fields[i] = is.Field
+	}
+	want := "p2p.listen_port presence.ttl_seconds presence.heartbeat_seconds assets.widths"
+	if strings.Join(fields, " ") != want {
+		t.Fatalf("fields = %v, want %s", fields, want)
+	}
+	if err := cfg.Validate(); err == nil || err.Error() != "p2p.listen_port must be 0..65535" {
+		t.Fatalf("Validate = %v", err)
+	}
+}
+
+func TestLoad_ReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	os.WriteFile(path, []byte(`{"p2p":{"listen_port":70000},"lua":{"enabled":true,"timeout_seconds":0}}`), 0o644)
+
+	_, err := Load(path)
+	var p Problems
+	if !errors.As(err, &p) || len(p) != 2 || !strings.Contains(err.Error(), "; lua.timeout_seconds") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestCheckJSON(t *testing.T) {
+	issues := CheckJSON([]byte(`{
+  "presence": {"ttl_second": 30, "heartbeat_seconds": 15},
+  "viewr": {},
+  "p2p": {"access_policies": {"/goop/docs/1.0.0": {"mode": "all", "peer": []}}}
+}`))
+	var msgs []string
+	for _, is := range issues {
+		if !is.Warning {
+			t.Errorf("unexpected error: %s", is.Message)
+		}
+		msgs = append(msgs, is.Message)
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		"unknown key presence.ttl_second (did you mean presence.ttl_seconds?)",
+		"unknown key viewr (did you mean viewer?)",
+		"unknown key p2p.access_policies./goop/docs/1.0.0.peer (did you mean p2p.access_policies./goop/docs/1.0.0.peers?)",
+		"presence.heartbeat_seconds is more than half of ttl_seconds",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+
+	if is := CheckJSON([]byte(`{"nonsense_entirely": 1}`)); len(is) != 1 || strings.Contains(is[0].Message, "did you mean") {
+		t.Fatalf("far-off key got a suggestion: %+v", is)
+	}
+}
+
+func TestCheckJSON_DecodeErrors(t *testing.T) {
+	is := CheckJSON([]byte(`{"presence": {"ttl_seconds": "20"}}`))
+	if len(is) != 1 || is[0].Field != "presence.ttl_seconds" || is[0].Message != "presence.ttl_seconds: expected a whole number, got string" {
+		t.Fatalf("type error: %+v", is)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	os.WriteFile(path, []byte("{\n  \"profile\": {\n    \"label\": \"x\",\n  }\n}"), 0o644)
+	is, err := Check(path)
+	if err != nil || len(is) != 1 || !strings.HasPrefix(is[0].Message, "line 4, column 3:") {
+		t.Fatalf("syntax error: %+v %v", is, err)
+	}
+	if _, err := Load(path); err == nil || !strings.HasPrefix(err.Error(), "line 4") {
+		t.Fatalf("Load = %v", err)
+	}
+}
@@ -4,23 +4,32 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/petervdpas/goop2/internal/util"
 	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 type Config struct {
 	Identity Identity `json:"identity"`
 	Paths    Paths    `json:"paths"`
+	Storage  Storage  `json:"storage"`
 	P2P      P2P      `json:"p2p"`
 	Presence Presence `json:"presence"`
 	Profile  Profile  `json:"profile"`
 	Viewer   Viewer   `json:"viewer"`
 	Lua      Lua      `json:"lua"`
+	Assets   Assets   `json:"assets"`
+	Publish  Publish  `json:"publish"`
+	Control  Control  `json:"control"`
+	Timeouts Timeouts `json:"timeouts"`
 }
 
 type Identity struct {
@@ -33,12 +42,153 @@ type Paths struct {
 	SiteStage  string `json:"site_stage"`
 }
 
+// Storage configures how the peer database is kept on disk.
+type Storage struct {
+	// Encryption is "" for a plain data.db, "identity" for data.db.enc
+	// with a key derived from the identity key, or "passphrase" for one
+	// derived from the random key in KeyFile, which is sealed with the key
+	// passphrase or the OS keychain like the other key files.
+	Encryption string `json:"encryption"`
+	KeyFile    string `json:"key_file"` // relative to the peer dir
+}
+
+// Timeouts overrides network deadlines, in seconds; 0 keeps the built-in
+// one (see internal/timeouts).
+type Timeouts struct {
+	MQAckSec        int `json:"mq_ack_sec,omitempty"`
+	MQSendSec       int `json:"mq_send_sec,omitempty"`
+	GroupSendSec    int `json:"group_send_sec,omitempty"`
+	GroupJoinSec    int `json:"group_join_sec,omitempty"`
+	ProbeSec        int `json:"probe_sec,omitempty"`
+	DocsListSec     int `json:"docs_list_sec,omitempty"`
+	DocsFetchSec    int `json:"docs_fetch_sec,omitempty"`
+	ListenStreamSec int `json:"listen_stream_sec,omitempty"`
+	SSEHeartbeatSec int `json:"sse_heartbeat_sec,omitempty"`
+}
+
+// MaxTimeoutSec caps every field of Timeouts.
+const MaxTimeoutSec = 600
+
+type timeoutField struct {
+	name string
+	sec  int
+}
+
+func (t Timeouts) fields() []timeoutField {
+	return []timeoutField{
+		{"mq_ack_sec", t.MQAckSec},
+		{"mq_send_sec", t.MQSendSec},
+		{"group_send_sec", t.GroupSendSec},
+		{"group_join_sec", t.GroupJoinSec},
+		{"probe_sec", t.ProbeSec},
+		{"docs_list_sec", t.DocsListSec},
+		{"docs_fetch_sec", t.DocsFetchSec},
+		{"listen_stream_sec", t.ListenStreamSec},
+		{"sse_heartbeat_sec", t.SSEHeartbeatSec},
+	}
+}
+
+// Durations converts t for timeouts.Use.
+func (t Timeouts) Durations() timeouts.Timeouts {
+	sec := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	return timeouts.Timeouts{
+		MQAck:        sec(t.MQAckSec),
+		MQSend:       sec(t.MQSendSec),
+		GroupSend:    sec(t.GroupSendSec),
+		GroupJoin:    sec(t.GroupJoinSec),
+		Probe:        sec(t.ProbeSec),
+		DocsList:     sec(t.DocsListSec),
+		DocsFetch:    sec(t.DocsFetchSec),
+		ListenStream: sec(t.ListenStreamSec),
+		SSEHeartbeat: sec(t.SSEHeartbeatSec),
+	}
+}
+
 type P2P struct {
 	ListenPort     int    `json:"listen_port"`
 	MdnsTag        string `json:"mdns_tag"`
 	BridgeMode     bool   `json:"bridge_mode"` // when true, use WebSocket via bridge instead of libp2p
 	NaClPublicKey  string `json:"nacl_public_key"`
 	NaClPrivateKey string `json:"nacl_private_key"`
+
+	// Per-protocol inbound access policies keyed by protocol ID
+	// (e.g. "/goop/docs/1.0.0"). Protocols not listed are open to all peers.
+	AccessPolicies map[string]p2p.AccessPolicy `json:"access_policies,omitempty"`
+
+	// Flood protection for member messages in the groups we host, keyed
+	// by group type (e.g. "chat", "template"). Types not listed keep the
+	// limits their handler sets.
+	GroupRateLimits map[string]GroupRateLimit `json:"group_rate_limits,omitempty"`
+
+	// Remote diagnostics the rendezvous admin may read over /goop/diag:
+	// "" or "off" (default), "connectivity", or "full" (includes logs).
+	DiagAccess string `json:"diag_access,omitempty"`
+
+	// Upstream bandwidth caps for serving site and docs files to other
+	// peers, in KB/s, overall and per requesting peer. 0 = unlimited.
+	ServeLimitKBps     int `json:"serve_limit_kbps,omitempty"`
+	ServePeerLimitKBps int `json:"serve_peer_limit_kbps,omitempty"`
+
+	// What other peers may find through /goop/search: "site" (page titles
+	// and text) and "docs" (names of shared files, only for peers in the
+	// same group). Empty = not searchable.
+	SearchExpose []string `json:"search_expose,omitempty"`
+
+	// How long an interrupted peer may spend on its shutdown steps (drain
+	// requests, publish offline, close groups, checkpoint the database)
+	// before it exits anyway. 0 = default (10s).
+	ShutdownTimeoutSec int `json:"shutdown_timeout_sec,omitempty"`
+
+	// Experimental: reach the rendezvous and other peers through Tor.
+	Tor Tor `json:"tor"`
+}
+
+// DefaultTorSOCKS is the SOCKS5 port of a local Tor daemon.
+const DefaultTorSOCKS = "127.0.0.1:9050"
+
+// Tor mode sends every outbound connection through a SOCKS5 proxy and
+// advertises an onion service instead of the peer's IP addresses. The
+// onion service is set up in torrc, forwarding to p2p.listen_port on
+// 127.0.0.1. Calls are off in Tor mode, as WebRTC would reveal the IP.
+type Tor struct {
+	Enabled   bool   `json:"enabled"`
+	SOCKSAddr string `json:"socks_addr,omitempty"` // empty = DefaultTorSOCKS
+
+	// "<56 chars>.onion", with ":port" when it differs from the listen
+	// port. Empty = reachable through the relay only.
+	OnionAddress string `json:"onion_address,omitempty"`
+}
+
+// SOCKS returns the proxy address, defaulted.
+func (t Tor) SOCKS() string {
+	if t.SOCKSAddr == "" {
+		return DefaultTorSOCKS
+	}
+	return t.SOCKSAddr
+}
+
+// CallsDisabled reports whether this peer takes no calls: video_disabled is
+// set, or Tor mode is on.
+func (c Config) CallsDisabled() bool {
+	return c.Viewer.VideoDisabled || c.P2P.Tor.Enabled
+}
+
+// DiagEnabled reports whether the rendezvous admin may query diagnostics.
+func (p P2P) DiagEnabled() bool {
+	return p.DiagAccess == "connectivity" || p.DiagAccess == "full"
+}
+
+// DiagLogs reports whether diagnostics include recent logs.
+func (p P2P) DiagLogs() bool {
+	return p.DiagAccess == "full"
+}
+
+// GroupRateLimit overrides the per-member message rate of a group type.
+// Zero fields keep the type's own value; a negative MsgRate turns
+// limiting off for the type.
+type GroupRateLimit struct {
+	MsgRate  float64 `json:"msg_rate,omitempty"`  // sustained messages per second
+	MsgBurst int     `json:"msg_burst,omitempty"` // messages allowed in a burst
 }
 
 type Presence struct {
@@ -60,6 +210,22 @@ type Presence struct {
 	// Example: https://rv.example.org  or  http://1.2.3.4:8787
 	RendezvousWAN string `json:"rendezvous_wan"`
 
+	// Expected relay peer ID of the WAN rendezvous. Relay info signed by any
+	// other key is refused. Empty = trust the first relay seen and pin it in
+	// data/relay_pins.json.
+	RendezvousRelayID string `json:"rendezvous_relay_id"`
+
+	// Aggregate the presence of the peers on this LAN toward RendezvousWAN:
+	// the local rendezvous relays WAN presence to them and sends their
+	// heartbeats upstream in one batch per AggregatorBatchSec (0 = 5).
+	// LAN peers set their rendezvous_wan to this peer's rendezvous.
+	Aggregator         bool `json:"aggregator,omitempty"`
+	AggregatorBatchSec int  `json:"aggregator_batch_sec,omitempty"`
+
+	// Communities joined besides the global one. Each has its own presence
+	// topic and peer list, and optionally its own rendezvous.
+	Namespaces []PresenceNamespace `json:"namespaces,omitempty"`
+
 	// If true: run ONLY rendezvous server; do NOT start libp2p peer node.
 	// This implies RendezvousHost=true and requires a valid RendezvousPort.
 	RendezvousOnly bool `json:"rendezvous_only"`
@@ -68,6 +234,10 @@ type Presence struct {
 	// Empty means admin panel is disabled (returns 403).
 	AdminPassword string `json:"admin_password"`
 
+	// Serve Prometheus metrics at /metrics on the rendezvous, to the viewer
+	// role. Requires AdminPassword or admin accounts in the peer DB.
+	Metrics bool `json:"metrics,omitempty"`
+
 	// Optional path to a SQLite database for persisting peer state across
 	// rendezvous server restarts and sharing state between multiple instances.
 	// Relative to the peer directory. Empty means in-memory only (default).
@@ -78,11 +248,22 @@ type Presence struct {
 	// Required for servers behind NAT or reverse proxies.
 	ExternalURL string `json:"external_url"`
 
+	// Other rendezvous servers to share peer presence with. Both sides must
+	// list each other. Presence is signed with the key in FederationKeyFile
+	// (default "data/federation.key"); its server ID is shown at
+	// /federation/info. Requires RendezvousHost=true.
+	Federation        []FederationPeer `json:"federation,omitempty"`
+	FederationKeyFile string           `json:"federation_key_file,omitempty"`
+
 	// Circuit relay v2 port. When > 0, a relay libp2p host is started on this
 	// TCP port alongside the rendezvous HTTP server. Requires RendezvousHost=true.
 	RelayPort   int `json:"relay_port"`
 	RelayWSPort int `json:"relay_ws_port"`
 
+	// STUN port (UDP). When > 0, the rendezvous answers STUN Binding requests
+	// on this port and advertises it to peers via /relay for call ICE.
+	STUNPort int `json:"stun_port"`
+
 	// Path to the relay identity key file. Default "data/relay.key".
 	RelayKeyFile string `json:"relay_key_file"`
 
@@ -94,6 +275,20 @@ type Presence struct {
 	RelayRefreshIntervalSec int `json:"relay_refresh_interval_sec"`
 	RelayRecoveryGraceSec   int `json:"relay_recovery_grace_sec"`
 
+	// Relayed traffic a peer may use per UTC day, in MB. A peer over the
+	// quota loses its reservation until the next day. 0 = unlimited.
+	RelayDailyQuotaMB int `json:"relay_daily_quota_mb,omitempty"`
+
+	// Label policy for presence published to the rendezvous, so public
+	// operators can keep the peer list presentable. All zero = off.
+	// LabelPolicy is "sanitize" (default: rewrite and relay) or "reject".
+	LabelPolicy         string   `json:"label_policy,omitempty"`
+	LabelMaxLen         int      `json:"label_max_len,omitempty"`         // characters, 0 = no limit
+	LabelBannedWords    []string `json:"label_banned_words,omitempty"`    // case-insensitive whole words
+	LabelBannedPatterns []string `json:"label_banned_patterns,omitempty"` // regular expressions
+	LabelStripURLs      bool     `json:"label_strip_urls,omitempty"`
+	LabelMaxEmoji       int      `json:"label_max_emoji,omitempty"` // 0 = no limit, -1 = no emoji
+
 	// When true, external microservices (credits, registration, email, templates)
 	// are wired up using the URLs below. When false, services are disabled even
 	// if URLs are set — useful for running a LAN-only server without microservices.
@@ -112,6 +307,15 @@ type Presence struct {
 	// Each subdirectory needs a manifest.json. Relative to peer dir.
 	TemplatesDir string `json:"templates_dir"`
 
+	// Let peers publish their own templates to the local store, into
+	// {templates_dir}/_published, with bundles of at most TemplatePublishMaxKB.
+	TemplatePublish      bool `json:"template_publish"`
+	TemplatePublishMaxKB int  `json:"template_publish_max_kb"`
+
+	// Directory of operator-authored Markdown pages added to the rendezvous
+	// /docs site and reloaded on change. Relative to peer dir.
+	DocsDir string `json:"docs_dir,omitempty"`
+
 	// Admin tokens for accessing admin-only endpoints on external services.
 	// Used when fetching data panels in the admin dashboard.
 	CreditsAdminToken      string `json:"credits_admin_token"`
@@ -120,8 +324,73 @@ type Presence struct {
 	BridgeAdminToken       string `json:"bridge_admin_token"`
 	EncryptionAdminToken   string `json:"encryption_admin_token"`
 
+	// Percentage of the credits collected per template that the admin sales
+	// report lists as the author's share. 0 = no revenue share.
+	TemplateAuthorSharePct int `json:"template_author_share_pct"`
+
+	// Abuse limits for /register: proof-of-work difficulty the form solves
+	// (leading zero bits, 0 = off) and registration attempts per client IP
+	// per UTC day (0 = unlimited).
+	RegisterPoWBits  int `json:"register_pow_bits"`
+	RegisterMaxPerIP int `json:"register_max_per_ip"`
+
+	// Public site mirror (rendezvous side): serve a read-only snapshot of
+	// opted-in, verified peers' sites at /p/<peerID>/. Needs the relay.
+	PublicSites           bool `json:"public_sites,omitempty"`
+	PublicSiteMaxMB       int  `json:"public_site_max_mb,omitempty"`
+	PublicSiteMaxFiles    int  `json:"public_site_max_files,omitempty"`
+	PublicSitesMaxTotalMB int  `json:"public_sites_max_total_mb,omitempty"`
+
+	// Peer side: let the rendezvous mirror this peer's site publicly.
+	PublicSiteMirror bool `json:"public_site_mirror,omitempty"`
+
+	// Branding of the rendezvous pages (index, store, docs, register, admin).
+	Branding RendezvousBranding `json:"branding"`
+}
+
+// RendezvousBranding restyles the rendezvous pages without rebuilding the
+// embedded assets. Files are relative to the peer directory and read once
+// at startup. Empty fields keep the built-in look.
+type RendezvousBranding struct {
+	Title       string            `json:"title,omitempty"`        // replaces "Goop²" in page titles and headers
+	Logo        string            `json:"logo,omitempty"`         // image file shown instead of the header emoji
+	Colors      map[string]string `json:"colors,omitempty"`       // style.css variables without "--", e.g. {"accent": "#e4572e"}
+	CSSFile     string            `json:"css_file,omitempty"`     // extra stylesheet loaded after the built-in one
+	FooterLinks []BrandLink       `json:"footer_links,omitempty"` // shown in every page footer
+}
+
+// BrandLink is a footer link on the rendezvous pages.
+type BrandLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
 }
 
+// PresenceNamespace is a community a peer joins besides the global one.
+// Peers see each other in a namespace when they join the same name, on the
+// LAN or through a shared rendezvous.
+type PresenceNamespace struct {
+	Name          string `json:"name"`                     // lowercase, e.g. "my-company"
+	RendezvousWAN string `json:"rendezvous_wan,omitempty"` // rendezvous of the community (optional)
+}
+
+// FederationPeer is another rendezvous server in the federation.
+type FederationPeer struct {
+	URL      string `json:"url"`
+	ServerID string `json:"server_id"` // from /federation/info on that server
+}
+
+// namespaceName is a presence namespace name; "global" is the default list.
+var namespaceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// brandColorName is a CSS custom property name without the leading "--".
+var brandColorName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// peerIDPattern matches a base58 libp2p peer ID (Ed25519 or RSA).
+var peerIDPattern = regexp.MustCompile(`^(12D3KooW|Qm)[1-9A-HJ-NP-Za-km-z]{40,50}$`)
+
+// onionAddress matches a v3 onion service address with an optional port.
+var onionAddress = regexp.MustCompile(`^[a-z2-7]{56}\.onion(:[0-9]{1,5})?$`)
+
 type Profile struct {
 	Label             string `json:"label"`
 	Email             string `json:"email"`
@@ -138,11 +407,65 @@ type Viewer struct {
 	VideoDisabled        bool   `json:"video_disabled"`        // Disable video/audio calls (e.g., Linux WebKitGTK limitation)
 	HideUnverified       bool   `json:"hide_unverified"`       // Hide unverified peers from the peer list
 	ActiveTemplate     string `json:"active_template"`     // dir name of currently applied template
+	TemplateSnapshots  int    `json:"template_snapshots"`  // pre-apply snapshots kept for revert (0–50); 0 = none
 	OpenSitesExternal  bool   `json:"open_sites_external"` // true = open peer sites in system browser, false = embedded tabs
 	Splash             string `json:"splash"`              // splash image filename for peers page
 	PeerOfflineGraceMin int   `json:"peer_offline_grace_min"` // minutes before an offline non-favorite is pruned (1–60)
+	PeerRetentionDays   int    `json:"peer_retention_days,omitempty"` // forget non-favorite peers not heard from in N days; 0 = keep forever
+	MaxTrackedPeers     int    `json:"max_tracked_peers,omitempty"`   // cap on non-favorite peers kept in the list, least recently seen evicted; 0 = unlimited
 	ClusterBinaryPath   string `json:"cluster_binary_path,omitempty"`
 	ClusterBinaryMode   string `json:"cluster_binary_mode,omitempty"`
+	CaptionCommand      string `json:"caption_command,omitempty"` // local speech-to-text for live call captions; {input}, {lang} placeholders
+	CallStack           string `json:"call_stack,omitempty"`      // "" or "auto" (native on Linux), "native" (Go/Pion), "browser" (browser WebRTC)
+	RemoteAddr          string `json:"remote_addr,omitempty"`     // LAN listener for paired phones (scoped remote control); empty = off
+	RemoteTLSCert       string `json:"remote_tls_cert,omitempty"` // serve remote_addr over HTTPS (needed for calls from a phone)
+	RemoteTLSKey        string `json:"remote_tls_key,omitempty"`
+	DocsWebDAV          string `json:"docs_webdav,omitempty"` // mount shared docs at /dav/docs/: "" or "off", "read", "write"
+	Metrics             bool   `json:"metrics,omitempty"`     // serve Prometheus metrics at /metrics on http_addr
+}
+
+// Assets configures the optional image pipeline for the served site:
+// resized, re-encoded variants offered to visitors through srcset.
+type Assets struct {
+	Enabled bool  `json:"enabled"`
+	Widths  []int `json:"widths"`  // variant widths in pixels
+	Quality int   `json:"quality"` // JPEG quality (1–100)
+}
+
+// Publish configures where POST /api/site/publish uploads the static
+// export of the site: "sftp", "s3" or "" (download only).
+type Publish struct {
+	Target string      `json:"target"`
+	SFTP   PublishSFTP `json:"sftp"`
+	S3     PublishS3   `json:"s3"`
+}
+
+type PublishSFTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"` // private key; relative to the peer dir
+	HostKey  string `json:"host_key,omitempty"` // SHA256 fingerprint; empty = ~/.ssh/known_hosts
+	Dir      string `json:"dir"`                // remote directory the site is written to
+}
+
+// PublishS3 works with any S3-compatible store (path-style requests).
+type PublishS3 struct {
+	Endpoint  string `json:"endpoint,omitempty"` // empty = AWS for the region
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix,omitempty"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// Control configures the control API for scripting a headless peer. It
+// always listens on data/control.sock when enabled; Addr adds a loopback
+// TCP listener for platforms or tools without Unix sockets.
+type Control struct {
+	Enabled bool   `json:"enabled"`
+	Addr    string `json:"addr,omitempty"` // e.g. 127.0.0.1:8790; loopback only
 }
 
 type Lua struct {
@@ -161,6 +484,9 @@ func Default() Config {
 		Identity: Identity{
 			KeyFile: "data/identity.key",
 		},
+		Storage: Storage{
+			KeyFile: "data/database.key",
+		},
 		Paths: Paths{
 			SiteRoot:   "site",
 			SiteSource: "site/src",
@@ -178,6 +504,7 @@ func Default() Config {
 			RendezvousPort:      8787,
 			RendezvousBind:      "127.0.0.1",
 			RendezvousWAN:       "",
+			RendezvousRelayID:   "",
 			RendezvousOnly:      false,
 			RelayPort:               0,
 			RelayKeyFile:            "data/relay.key",
@@ -186,6 +513,12 @@ func Default() Config {
 			RelayConnectTimeoutSec:  5,
 			RelayRefreshIntervalSec: 90,
 			RelayRecoveryGraceSec:   5,
+			PublicSiteMaxMB:         10,
+			PublicSiteMaxFiles:      300,
+			PublicSitesMaxTotalMB:   500,
+			RegisterPoWBits:         16,
+			RegisterMaxPerIP:        5,
+			TemplatePublishMaxKB:    5120,
 		},
 		Profile: Profile{
 			Label: "hello",
@@ -196,6 +529,7 @@ func Default() Config {
 			Theme:               "dark",
 			Splash:              "goop2-splash2.png",
 			PeerOfflineGraceMin: 15,
+			TemplateSnapshots:   5,
 		},
 		Lua: Lua{
 			Enabled:          false,
@@ -207,93 +541,258 @@ func Default() Config {
 			HTTPEnabled:      true,
 			KVEnabled:        true,
 		},
+		Assets: Assets{
+			Widths:  []int{480, 960, 1600},
+			Quality: 80,
+		},
+		Publish: Publish{
+			SFTP: PublishSFTP{Port: 22},
+			S3:   PublishS3{Region: "us-east-1"},
+		},
 	}
 }
 
+// Validate returns the first problem Problems finds, or nil.
 func (c *Config) Validate() error {
+	if p := c.Problems(); len(p) > 0 {
+		return p[0]
+	}
+	return nil
+}
+
+// Problems returns every validation error in the config, in a fixed order.
+func (c *Config) Problems() []Issue {
+	var v issues
+
 	// Identity
 	if strings.TrimSpace(c.Identity.KeyFile) == "" {
-		return errors.New("identity.key_file is required")
+		v.add("identity.key_file", "identity.key_file is required")
 	}
 
 	// Paths
 	if strings.TrimSpace(c.Paths.SiteRoot) == "" {
-		return errors.New("paths.site_root is required")
+		v.add("paths.site_root", "paths.site_root is required")
 	}
 	if strings.TrimSpace(c.Paths.SiteSource) == "" {
-		return errors.New("paths.site_source is required")
+		v.add("paths.site_source", "paths.site_source is required")
 	}
 	if strings.TrimSpace(c.Paths.SiteStage) == "" {
-		return errors.New("paths.site_stage is required")
+		v.add("paths.site_stage", "paths.site_stage is required")
 	}
 	if filepath.Clean(c.Paths.SiteSource) == filepath.Clean(c.Paths.SiteStage) {
-		return errors.New("paths.site_source and paths.site_stage must differ")
+		v.add("paths.site_source", "paths.site_source and paths.site_stage must differ")
+	}
+
+	// Storage
+	switch c.Storage.Encryption {
+	case "", "identity":
+	case "passphrase":
+		if strings.TrimSpace(c.Storage.KeyFile) == "" {
+			v.add("storage.key_file", "storage.key_file is required for passphrase encryption")
+		}
+	default:
+		v.add("storage.encryption", "storage.encryption must be identity, passphrase or empty")
+	}
+
+	// Timeouts
+	for _, f := range c.Timeouts.fields() {
+		if f.sec < 0 || f.sec > MaxTimeoutSec {
+			v.add("timeouts."+f.name, fmt.Sprintf("timeouts.%s must be 0 (default) or 1-%d", f.name, MaxTimeoutSec))
+		}
 	}
 
 	// P2P
 	if c.P2P.ListenPort < 0 || c.P2P.ListenPort > 65535 {
-		return errors.New("p2p.listen_port must be 0..65535")
+		v.add("p2p.listen_port", "p2p.listen_port must be 0..65535")
 	}
 	if strings.TrimSpace(c.P2P.MdnsTag) == "" {
-		return errors.New("p2p.mdns_tag is required")
+		v.add("p2p.mdns_tag", "p2p.mdns_tag is required")
+	}
+	switch c.P2P.DiagAccess {
+	case "", "off", "connectivity", "full":
+	default:
+		v.add("p2p.diag_access", "p2p.diag_access must be off, connectivity or full")
+	}
+	if c.P2P.ServeLimitKBps < 0 || c.P2P.ServePeerLimitKBps < 0 {
+		v.add("p2p.serve_limit_kbps", "p2p.serve_limit_kbps and p2p.serve_peer_limit_kbps must be >= 0")
+	}
+	if c.P2P.ShutdownTimeoutSec < 0 || c.P2P.ShutdownTimeoutSec > 300 {
+		v.add("p2p.shutdown_timeout_sec", "p2p.shutdown_timeout_sec must be 0 (default) or 1-300")
+	}
+	for _, src := range c.P2P.SearchExpose {
+		if src != "site" && src != "docs" {
+			v.add("p2p.search_expose", "p2p.search_expose entries must be site or docs")
+			break
+		}
+	}
+	if c.P2P.Tor.Enabled {
+		if _, _, err := net.SplitHostPort(c.P2P.Tor.SOCKS()); err != nil {
+			v.add("p2p.tor.socks_addr", "p2p.tor.socks_addr must be host:port")
+		}
+		if o := c.P2P.Tor.OnionAddress; o != "" && !onionAddress.MatchString(strings.ToLower(o)) {
+			v.add("p2p.tor.onion_address", "p2p.tor.onion_address must be a v3 onion address, <56 characters>.onion[:port]")
+		}
+	}
+	for pid, ap := range c.P2P.AccessPolicies {
+		if !p2p.ValidAccessMode(ap.Mode) {
+			v.add("p2p.access_policies."+pid+".mode", fmt.Sprintf("p2p.access_policies[%s].mode must be all, favorites, group, list or consent", pid))
+		}
+	}
+	for gt, rl := range c.P2P.GroupRateLimits {
+		if rl.MsgBurst < 0 {
+			v.add("p2p.group_rate_limits."+gt+".msg_burst", fmt.Sprintf("p2p.group_rate_limits[%s].msg_burst must be >= 0", gt))
+		}
+	}
+
+	// Viewer
+	if c.Viewer.TemplateSnapshots < 0 || c.Viewer.TemplateSnapshots > 50 {
+		v.add("viewer.template_snapshots", "viewer.template_snapshots must be 0-50")
+	}
+	if (c.Viewer.RemoteTLSCert == "") != (c.Viewer.RemoteTLSKey == "") {
+		v.add("viewer.remote_tls_cert", "viewer.remote_tls_cert and viewer.remote_tls_key must be set together")
+	}
+	if c.Viewer.MaxTrackedPeers != 0 && (c.Viewer.MaxTrackedPeers < 50 || c.Viewer.MaxTrackedPeers > 100000) {
+		v.add("viewer.max_tracked_peers", "viewer.max_tracked_peers must be 0 (unlimited) or 50-100000")
+	}
+	switch c.Viewer.DocsWebDAV {
+	case "", "off", "read", "write":
+	default:
+		v.add("viewer.docs_webdav", "viewer.docs_webdav must be off, read or write")
+	}
+	switch c.Viewer.CallStack {
+	case "", "auto", "native", "browser":
+	default:
+		v.add("viewer.call_stack", "viewer.call_stack must be auto, native or browser")
 	}
 
 	// Presence (general)
 	if strings.TrimSpace(c.Presence.Topic) == "" {
-		return errors.New("presence.topic is required")
+		v.add("presence.topic", "presence.topic is required")
 	}
 	if c.Presence.TTLSec <= 0 {
-		return errors.New("presence.ttl_seconds must be > 0")
+		v.add("presence.ttl_seconds", "presence.ttl_seconds must be > 0")
 	}
 	if c.Presence.HeartbeatSec <= 0 {
-		return errors.New("presence.heartbeat_seconds must be > 0")
+		v.add("presence.heartbeat_seconds", "presence.heartbeat_seconds must be > 0")
 	}
 	if c.Presence.HeartbeatSec >= c.Presence.TTLSec {
-		return errors.New("presence.heartbeat_seconds must be < presence.ttl_seconds")
+		v.add("presence.heartbeat_seconds", "presence.heartbeat_seconds must be < presence.ttl_seconds")
 	}
 
 	// Presence (rendezvous-only semantics)
 	if c.Presence.RendezvousOnly && !c.Presence.RendezvousHost {
-		return errors.New("presence.rendezvous_only requires presence.rendezvous_host=true")
+		v.add("presence.rendezvous_only", "presence.rendezvous_only requires presence.rendezvous_host=true")
 	}
 
 	// Rendezvous (local server)
 	if c.Presence.RendezvousHost {
 		if c.Presence.RendezvousPort <= 0 || c.Presence.RendezvousPort > 65535 {
-			return errors.New("presence.rendezvous_port must be 1..65535 when rendezvous_host is enabled")
+			v.add("presence.rendezvous_port", "presence.rendezvous_port must be 1..65535 when rendezvous_host is enabled")
 		}
 		if b := c.Presence.RendezvousBind; b != "" {
 			if net.ParseIP(b) == nil {
-				return errors.New("presence.rendezvous_bind must be a valid IP address")
+				v.add("presence.rendezvous_bind", "presence.rendezvous_bind must be a valid IP address")
 			}
 		}
 	}
 
+	// Label policy
+	switch c.Presence.LabelPolicy {
+	case "", "sanitize", "reject":
+	default:
+		v.add("presence.label_policy", "presence.label_policy must be sanitize or reject")
+	}
+	if c.Presence.LabelMaxLen < 0 {
+		v.add("presence.label_max_len", "presence.label_max_len must be >= 0")
+	}
+	for _, expr := range c.Presence.LabelBannedPatterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			v.add("presence.label_banned_patterns", "presence.label_banned_patterns: "+err.Error())
+		}
+	}
+
+	// Branding
+	for name, val := range c.Presence.Branding.Colors {
+		if !brandColorName.MatchString(name) || strings.ContainsAny(val, ";{}<>\"\\") || strings.TrimSpace(val) == "" {
+			v.add("presence.branding.colors", "presence.branding.colors: invalid entry "+strconv.Quote(name))
+		}
+	}
+	for _, l := range c.Presence.Branding.FooterLinks {
+		if l.Label == "" || !(strings.HasPrefix(l.URL, "https://") || strings.HasPrefix(l.URL, "http://") || (strings.HasPrefix(l.URL, "/") && !strings.HasPrefix(l.URL, "//"))) {
+			v.add("presence.branding.footer_links", "presence.branding.footer_links need a label and an http(s) or site-relative url")
+		}
+	}
+
+	if c.Presence.TemplateAuthorSharePct < 0 || c.Presence.TemplateAuthorSharePct > 100 {
+		v.add("presence.template_author_share_pct", "presence.template_author_share_pct must be 0..100")
+	}
+	if c.Presence.TemplatePublish {
+		if c.Presence.TemplatesDir == "" {
+			v.add("presence.template_publish", "presence.template_publish requires templates_dir")
+		}
+		if c.Presence.TemplatePublishMaxKB < 1 || c.Presence.TemplatePublishMaxKB > 65536 {
+			v.add("presence.template_publish_max_kb", "presence.template_publish_max_kb must be 1..65536")
+		}
+	}
+	if c.Presence.RegisterPoWBits < 0 || c.Presence.RegisterPoWBits > 24 {
+		v.add("presence.register_pow_bits", "presence.register_pow_bits must be 0..24")
+	}
+	if c.Presence.RegisterMaxPerIP < 0 {
+		v.add("presence.register_max_per_ip", "presence.register_max_per_ip must be >= 0")
+	}
+	if c.Presence.PublicSites {
+		if c.Presence.RelayPort <= 0 {
+			v.add("presence.public_sites", "presence.public_sites requires relay_port")
+		}
+		if c.Presence.PublicSiteMaxMB < 1 || c.Presence.PublicSiteMaxMB > 100 {
+			v.add("presence.public_site_max_mb", "presence.public_site_max_mb must be 1..100")
+		}
+		if c.Presence.PublicSiteMaxFiles < 1 || c.Presence.PublicSiteMaxFiles > 5000 {
+			v.add("presence.public_site_max_files", "presence.public_site_max_files must be 1..5000")
+		}
+		if c.Presence.PublicSitesMaxTotalMB < c.Presence.PublicSiteMaxMB {
+			v.add("presence.public_sites_max_total_mb", "presence.public_sites_max_total_mb must be >= public_site_max_mb")
+		}
+	}
+
+	// STUN
+	if c.Presence.STUNPort > 0 {
+		if !c.Presence.RendezvousHost {
+			v.add("presence.stun_port", "presence.stun_port requires presence.rendezvous_host=true")
+		}
+		if c.Presence.STUNPort > 65535 {
+			v.add("presence.stun_port", "presence.stun_port must be 1..65535")
+		}
+	}
+
 	// Relay
 	if c.Presence.RelayPort > 0 {
 		if !c.Presence.RendezvousHost {
-			return errors.New("presence.relay_port requires presence.rendezvous_host=true")
+			v.add("presence.relay_port", "presence.relay_port requires presence.rendezvous_host=true")
 		}
 		if c.Presence.RelayPort > 65535 {
-			return errors.New("presence.relay_port must be 1..65535")
+			v.add("presence.relay_port", "presence.relay_port must be 1..65535")
 		}
 		if c.Presence.RelayWSPort > 65535 {
-			return errors.New("presence.relay_ws_port must be 0..65535")
+			v.add("presence.relay_ws_port", "presence.relay_ws_port must be 0..65535")
 		}
 		if c.Presence.RelayCleanupDelaySec < 0 {
-			return errors.New("presence.relay_cleanup_delay_sec must be >= 0")
+			v.add("presence.relay_cleanup_delay_sec", "presence.relay_cleanup_delay_sec must be >= 0")
 		}
 		if c.Presence.RelayPollDeadlineSec < 0 {
-			return errors.New("presence.relay_poll_deadline_sec must be >= 0")
+			v.add("presence.relay_poll_deadline_sec", "presence.relay_poll_deadline_sec must be >= 0")
 		}
 		if c.Presence.RelayConnectTimeoutSec < 0 {
-			return errors.New("presence.relay_connect_timeout_sec must be >= 0")
+			v.add("presence.relay_connect_timeout_sec", "presence.relay_connect_timeout_sec must be >= 0")
 		}
 		if c.Presence.RelayRefreshIntervalSec < 0 {
-			return errors.New("presence.relay_refresh_interval_sec must be >= 0")
+			v.add("presence.relay_refresh_interval_sec", "presence.relay_refresh_interval_sec must be >= 0")
 		}
 		if c.Presence.RelayRecoveryGraceSec < 0 {
-			return errors.New("presence.relay_recovery_grace_sec must be >= 0")
+			v.add("presence.relay_recovery_grace_sec", "presence.relay_recovery_grace_sec must be >= 0")
+		}
+		if c.Presence.RelayDailyQuotaMB < 0 {
+			v.add("presence.relay_daily_quota_mb", "presence.relay_daily_quota_mb must be >= 0")
 		}
 	}
 
@@ -301,30 +800,123 @@ func (c *Config) Validate() error {
 	rw := strings.TrimSpace(c.Presence.RendezvousWAN)
 	if rw != "" {
 		if err := validateWANRendezvous(rw); err != nil {
-			return fmt.Errorf("presence.rendezvous_wan: %w", err)
+			v.add("presence.rendezvous_wan", "presence.rendezvous_wan: "+err.Error())
+		}
+	}
+	if c.Presence.Aggregator {
+		if !c.Presence.RendezvousHost || rw == "" {
+			v.add("presence.aggregator", "presence.aggregator requires rendezvous_host and rendezvous_wan")
+		}
+		if ip := net.ParseIP(c.Presence.RendezvousBind); ip == nil || ip.IsLoopback() {
+			v.add("presence.aggregator", "presence.aggregator requires a rendezvous_bind other peers can reach")
+		}
+	}
+	if c.Presence.AggregatorBatchSec < 0 || c.Presence.AggregatorBatchSec > 20 {
+		v.add("presence.aggregator_batch_sec", "presence.aggregator_batch_sec must be 0..20")
+	}
+	if len(c.Presence.Federation) > 0 && !c.Presence.RendezvousHost {
+		v.add("presence.federation", "presence.federation requires presence.rendezvous_host=true")
+	}
+	seenFed := map[string]bool{}
+	for _, fp := range c.Presence.Federation {
+		if err := validateWANRendezvous(strings.TrimSpace(fp.URL)); err != nil {
+			v.add("presence.federation", fmt.Sprintf("presence.federation %q: %v", fp.URL, err))
+		}
+		switch {
+		case !peerIDPattern.MatchString(fp.ServerID):
+			v.add("presence.federation", fmt.Sprintf("presence.federation %q: server_id must be a peer ID", fp.URL))
+		case seenFed[fp.ServerID]:
+			v.add("presence.federation", fmt.Sprintf("presence.federation: server %s is listed twice", fp.ServerID))
+		}
+		seenFed[fp.ServerID] = true
+	}
+	if c.Presence.Metrics && (!c.Presence.RendezvousHost || c.Presence.AdminPassword == "" && c.Presence.PeerDBPath == "") {
+		v.add("presence.metrics", "presence.metrics requires rendezvous_host and admin_password or admin accounts (peer_db_path)")
+	}
+	if id := strings.TrimSpace(c.Presence.RendezvousRelayID); id != "" && !peerIDPattern.MatchString(id) {
+		v.add("presence.rendezvous_relay_id", "presence.rendezvous_relay_id must be a peer ID")
+	}
+	seenNS := map[string]bool{}
+	for _, ns := range c.Presence.Namespaces {
+		switch {
+		case !namespaceName.MatchString(ns.Name) || ns.Name == "global":
+			v.add("presence.namespaces", fmt.Sprintf("presence.namespaces: invalid name %q (lowercase letters, digits, - and _, not \"global\")", ns.Name))
+		case seenNS[ns.Name]:
+			v.add("presence.namespaces", fmt.Sprintf("presence.namespaces: %q is listed twice", ns.Name))
+		}
+		seenNS[ns.Name] = true
+		if rw := strings.TrimSpace(ns.RendezvousWAN); rw != "" {
+			if err := validateWANRendezvous(rw); err != nil {
+				v.add("presence.namespaces", fmt.Sprintf("presence.namespaces %q: rendezvous_wan: %v", ns.Name, err))
+			}
 		}
 	}
 
 	// Lua
 	if c.Lua.Enabled {
 		if strings.TrimSpace(c.Lua.ScriptDir) == "" {
-			return errors.New("lua.script_dir is required when lua is enabled")
+			v.add("lua.script_dir", "lua.script_dir is required when lua is enabled")
 		}
 		if c.Lua.TimeoutSeconds < 1 || c.Lua.TimeoutSeconds > 60 {
-			return errors.New("lua.timeout_seconds must be 1..60")
+			v.add("lua.timeout_seconds", "lua.timeout_seconds must be 1..60")
 		}
 		if c.Lua.RateLimitPerPeer <= 0 {
-			return errors.New("lua.rate_limit_per_peer must be > 0")
+			v.add("lua.rate_limit_per_peer", "lua.rate_limit_per_peer must be > 0")
 		}
 		if c.Lua.RateLimitGlobal <= 0 {
-			return errors.New("lua.rate_limit_global must be > 0")
+			v.add("lua.rate_limit_global", "lua.rate_limit_global must be > 0")
 		}
 		if c.Lua.MaxMemoryMB < 1 || c.Lua.MaxMemoryMB > 1024 {
-			return errors.New("lua.max_memory_mb must be 1..1024")
+			v.add("lua.max_memory_mb", "lua.max_memory_mb must be 1..1024")
 		}
 	}
 
-	return nil
+	// Assets
+	if c.Assets.Enabled {
+		if c.Assets.Quality < 1 || c.Assets.Quality > 100 {
+			v.add("assets.quality", "assets.quality must be 1..100")
+		}
+		for _, w := range c.Assets.Widths {
+			if w < 16 || w > 8192 {
+				v.add("assets.widths", "assets.widths must each be 16..8192")
+				break
+			}
+		}
+	}
+
+	// Publish
+	switch c.Publish.Target {
+	case "":
+	case "sftp":
+		if strings.TrimSpace(c.Publish.SFTP.Host) == "" || strings.TrimSpace(c.Publish.SFTP.User) == "" {
+			v.add("publish.sftp.host", "publish.sftp.host and publish.sftp.user are required")
+		}
+		if c.Publish.SFTP.Port < 1 || c.Publish.SFTP.Port > 65535 {
+			v.add("publish.sftp.port", "publish.sftp.port must be 1..65535")
+		}
+	case "s3":
+		s3 := c.Publish.S3
+		if s3.Bucket == "" || s3.Region == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+			v.add("publish.s3", "publish.s3 needs bucket, region, access_key and secret_key")
+		}
+		if s3.Endpoint != "" {
+			if u, err := url.Parse(s3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.add("publish.s3.endpoint", "publish.s3.endpoint must be an http(s) URL")
+			}
+		}
+	default:
+		v.add("publish.target", "publish.target must be sftp, s3 or empty")
+	}
+
+	// Control
+	if c.Control.Addr != "" {
+		host, _, err := net.SplitHostPort(c.Control.Addr)
+		if ip := net.ParseIP(host); err != nil || (host != "localhost" && (ip == nil || !ip.IsLoopback())) {
+			v.add("control.addr", "control.addr must be a loopback host:port")
+		}
+	}
+
+	return v
 }
 
 func validateWANRendezvous(raw string) error {
@@ -367,22 +959,21 @@ func validateWANRendezvous(raw string) error {
 }
 
 func Load(path string) (Config, error) {
-	b, err := os.ReadFile(path)
+	// Settings with the state file laid over them; a UTF-8 BOM (common
+	// when editing JSON on Windows) is stripped.
+	b, err := readMerged(path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	// Strip UTF-8 BOM if present (common when editing JSON on Windows).
-	b = stripBOM(b)
-
 	// Start from defaults so missing JSON fields remain initialized.
 	cfg := Default()
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		return Config{}, err
+		return Config{}, jsonIssue(b, err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return Config{}, err
+	if p := cfg.Problems(); len(p) > 0 {
+		return Config{}, Problems(p)
 	}
 
 	return cfg, nil
@@ -391,13 +982,11 @@ func Load(path string) (Config, error) {
 // LoadPartial reads a config file without validation. Useful for reading
 // individual fields (like rendezvous_only) when full validation may fail.
 func LoadPartial(path string) (Config, error) {
-	b, err := os.ReadFile(path)
+	b, err := readMerged(path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	b = stripBOM(b)
-
 	cfg := Default()
 	if err := json.Unmarshal(b, &cfg); err != nil {
 		return Config{}, err
@@ -414,14 +1003,6 @@ func stripBOM(b []byte) []byte {
 	return b
 }
 
-func Save(path string, cfg Config) error {
-	if err := cfg.Validate(); err != nil {
-		return err
-	}
-
-	return util.WriteJSONFile(path, cfg)
-}
-
 // Ensure loads config if it exists; otherwise creates a default config file.
 // Returns (cfg, createdNew, err).
 func Ensure(path string) (Config, bool, error) {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefault_ValidatesCleanly(t *testing.T) {
@@ -117,6 +118,29 @@ func TestValidate_P2P(t *testing.T) {
 			t.Error("expected error")
 		}
 	})
+	t.Run("GroupRateLimits", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.P2P.GroupRateLimits = map[string]GroupRateLimit{"chat": {MsgRate: 5, MsgBurst: 10}, "template": {MsgRate: -1}}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("valid limits rejected: %v", err)
+		}
+		cfg.P2P.GroupRateLimits["chat"] = GroupRateLimit{MsgBurst: -1}
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for a negative burst")
+		}
+	})
+}
+
+func TestValidate_RemoteTLS(t *testing.T) {
+	cfg := validConfig()
+	cfg.Viewer.RemoteTLSCert = "cert.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for cert without key")
+	}
+	cfg.Viewer.RemoteTLSKey = "key.pem"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
 }
 
 func TestValidate_Presence(t *testing.T) {
@@ -183,6 +207,37 @@ func TestValidate_Rendezvous(t *testing.T) {
 			t.Error("expected error for invalid bind address")
 		}
 	})
+	t.Run("Branding", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Presence.Branding = RendezvousBranding{
+			Colors:      map[string]string{"accent": "#e4572e"},
+			FooterLinks: []BrandLink{{Label: "Forum", URL: "https://forum.example.org"}, {Label: "Docs", URL: "/docs"}},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		cfg.Presence.Branding.Colors["bg"] = "red;} body{display:none"
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for a color that breaks out of the rule")
+		}
+		delete(cfg.Presence.Branding.Colors, "bg")
+		cfg.Presence.Branding.FooterLinks = []BrandLink{{Label: "x", URL: "javascript:alert(1)"}}
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for a javascript: footer link")
+		}
+	})
+	t.Run("RegisterLimits", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Presence.RegisterPoWBits = 25
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for register_pow_bits 25")
+		}
+		cfg.Presence.RegisterPoWBits = 0
+		cfg.Presence.RegisterMaxPerIP = -1
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for negative register_max_per_ip")
+		}
+	})
 }
 
 func TestValidate_Relay(t *testing.T) {
@@ -224,6 +279,7 @@ func TestValidate_Relay(t *testing.T) {
 			{"ConnectTimeout", func(c *Config) { c.Presence.RelayConnectTimeoutSec = -1 }},
 			{"RefreshInterval", func(c *Config) { c.Presence.RelayRefreshIntervalSec = -1 }},
 			{"RecoveryGrace", func(c *Config) { c.Presence.RelayRecoveryGraceSec = -1 }},
+			{"DailyQuota", func(c *Config) { c.Presence.RelayDailyQuotaMB = -1 }},
 		}
 		for _, f := range fields {
 			t.Run(f.name, func(t *testing.T) {
@@ -340,6 +396,258 @@ func TestValidate_WANRendezvousIntegration(t *testing.T) {
 	}
 }
 
+func TestValidate_RendezvousRelayID(t *testing.T) {
+	for _, tc := range []struct {
+		id      string
+		wantErr bool
+	}{
+		{"", false},
+		{"12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf", false},
+		{"QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N", false},
+		{"not-a-peer-id", true},
+		{"12D3KooW0OIl", true},
+	} {
+		cfg := validConfig()
+		cfg.Presence.RendezvousRelayID = tc.id
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("rendezvous_relay_id %q: error=%v, wantErr=%v", tc.id, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_Aggregator(t *testing.T) {
+	cfg := validConfig()
+	cfg.Presence.Aggregator = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for aggregator without a rendezvous")
+	}
+	cfg.Presence.RendezvousHost = true
+	cfg.Presence.RendezvousPort = 8787
+	cfg.Presence.RendezvousWAN = "https://rv.example.org"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for aggregator bound to localhost")
+	}
+	cfg.Presence.RendezvousBind = "0.0.0.0"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cfg.Presence.AggregatorBatchSec = 30
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for aggregator_batch_sec 30")
+	}
+}
+
+func TestValidate_Federation(t *testing.T) {
+	const id = "12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf"
+	cfg := validConfig()
+	cfg.Presence.Federation = []FederationPeer{{URL: "https://rv2.example.org", ServerID: id}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for federation without rendezvous_host")
+	}
+	cfg.Presence.RendezvousHost = true
+	cfg.Presence.RendezvousPort = 8787
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cfg.Presence.Federation = append(cfg.Presence.Federation, FederationPeer{URL: "https://rv3.example.org", ServerID: id})
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a server listed twice")
+	}
+	cfg.Presence.Federation = []FederationPeer{{URL: "https://rv2.example.org", ServerID: "not-an-id"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a bad server_id")
+	}
+}
+
+func TestValidate_Metrics(t *testing.T) {
+	cfg := validConfig()
+	cfg.Viewer.Metrics = true
+	cfg.Presence.Metrics = true
+	cfg.Presence.RendezvousHost = true
+	cfg.Presence.RendezvousPort = 8787
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for rendezvous metrics without admin_password")
+	}
+	cfg.Presence.AdminPassword = "secret"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_TemplatePublish(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		dir     string
+		maxKB   int
+		wantErr bool
+	}{
+		{"valid", "templates", 5120, false},
+		{"no templates dir", "", 5120, true},
+		{"zero max", "templates", 0, true},
+		{"too large", "templates", 1 << 20, true},
+	} {
+		cfg := validConfig()
+		cfg.Presence.TemplatePublish = true
+		cfg.Presence.TemplatesDir = tc.dir
+		cfg.Presence.TemplatePublishMaxKB = tc.maxKB
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: error=%v, wantErr=%v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_PresenceNamespaces(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ns      []PresenceNamespace
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"valid", []PresenceNamespace{{Name: "my-company", RendezvousWAN: "https://rv.example.org"}, {Name: "gamers"}}, false},
+		{"uppercase", []PresenceNamespace{{Name: "Gamers"}}, true},
+		{"empty", []PresenceNamespace{{Name: ""}}, true},
+		{"global", []PresenceNamespace{{Name: "global"}}, true},
+		{"duplicate", []PresenceNamespace{{Name: "gamers"}, {Name: "gamers"}}, true},
+		{"bad url", []PresenceNamespace{{Name: "gamers", RendezvousWAN: "ftp://bad"}}, true},
+	} {
+		cfg := validConfig()
+		cfg.Presence.Namespaces = tc.ns
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: error=%v, wantErr=%v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_MaxTrackedPeers(t *testing.T) {
+	for _, tc := range []struct {
+		max     int
+		wantErr bool
+	}{
+		{0, false},
+		{50, false},
+		{5000, false},
+		{10, true},
+		{-1, true},
+		{1 << 20, true},
+	} {
+		cfg := validConfig()
+		cfg.Viewer.MaxTrackedPeers = tc.max
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%d: error=%v, wantErr=%v", tc.max, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_ShutdownTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		sec     int
+		wantErr bool
+	}{
+		{0, false},
+		{1, false},
+		{300, false},
+		{-1, true},
+		{301, true},
+	} {
+		cfg := validConfig()
+		cfg.P2P.ShutdownTimeoutSec = tc.sec
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%d: error=%v, wantErr=%v", tc.sec, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_Storage(t *testing.T) {
+	for _, tc := range []struct {
+		enc, file string
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"identity", "", false},
+		{"passphrase", "data/database.key", false},
+		{"passphrase", "", true},
+		{"sqlcipher", "", true},
+	} {
+		cfg := validConfig()
+		cfg.Storage = Storage{Encryption: tc.enc, KeyFile: tc.file}
+		if err := cfg.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%q/%q: error=%v, wantErr=%v", tc.enc, tc.file, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_Timeouts(t *testing.T) {
+	cfg := validConfig()
+	cfg.Timeouts = Timeouts{GroupJoinSec: 30, SSEHeartbeatSec: MaxTimeoutSec}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if d := cfg.Timeouts.Durations(); d.GroupJoin != 30*time.Second || d.MQAck != 0 {
+		t.Errorf("Durations = %+v", d)
+	}
+	cfg.Timeouts.ProbeSec = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative probe_sec")
+	}
+	cfg.Timeouts.ProbeSec = MaxTimeoutSec + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for probe_sec over the cap")
+	}
+}
+
+func TestValidate_Tor(t *testing.T) {
+	onion := "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion"
+	tests := []struct {
+		name    string
+		tor     Tor
+		wantErr bool
+	}{
+		{"off ignores fields", Tor{SOCKSAddr: "nonsense"}, false},
+		{"defaults", Tor{Enabled: true}, false},
+		{"onion with port", Tor{Enabled: true, SOCKSAddr: "127.0.0.1:9150", OnionAddress: onion + ":4001"}, false},
+		{"bad socks", Tor{Enabled: true, SOCKSAddr: "9050"}, true},
+		{"short onion", Tor{Enabled: true, OnionAddress: "abc.onion"}, true},
+		{"not onion", Tor{Enabled: true, OnionAddress: "peer.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.P2P.Tor = tt.tor
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("error=%v, wantErr=%v", err, tt.wantErr)
+			}
+		})
+	}
+	cfg := validConfig()
+	if cfg.P2P.Tor.SOCKS() != DefaultTorSOCKS || cfg.CallsDisabled() {
+		t.Error("defaults")
+	}
+	cfg.P2P.Tor.Enabled = true
+	if !cfg.CallsDisabled() {
+		t.Error("calls stay on in Tor mode")
+	}
+}
+
+func TestValidate_CallStack(t *testing.T) {
+	for stack, wantErr := range map[string]bool{"": false, "auto": false, "native": false, "browser": false, "pion": true} {
+		cfg := validConfig()
+		cfg.Viewer.CallStack = stack
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("%q: error=%v, wantErr=%v", stack, err, wantErr)
+		}
+	}
+}
+
+func TestValidate_Control(t *testing.T) {
+	for addr, wantErr := range map[string]bool{"": false, "127.0.0.1:8790": false, "[::1]:8790": false, "localhost:8790": false, "0.0.0.0:8790": true, "192.168.1.2:8790": true, "8790": true} {
+		cfg := validConfig()
+		cfg.Control.Addr = addr
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("%q: error=%v, wantErr=%v", addr, err, wantErr)
+		}
+	}
+}
+
 func TestStripBOM(t *testing.T) {
 	t.Run("WithBOM", func(t *testing.T) {
 		input := append([]byte{0xEF, 0xBB, 0xBF}, []byte(`{"identity":{}}`)...)
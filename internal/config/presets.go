@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Preset is a named combination of deployment settings: ports, discovery,
+// relay and services. Applying one only touches those settings; the
+// profile, keys, paths and everything else are kept.
+type Preset struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	apply       func(*Config)
+}
+
+var presets = []Preset{
+	{
+		Name:        "desktop",
+		Title:       "Desktop default",
+		Description: "A personal peer: random listen port, local viewer chosen at startup, no rendezvous server of its own.",
+		apply:       func(*Config) {},
+	},
+	{
+		Name:        "public-rendezvous",
+		Title:       "Public rendezvous",
+		Description: "A rendezvous server reachable from the internet with circuit relay, WebSocket relay, STUN and a persistent peer database. Does not run a peer node.",
+		apply: func(c *Config) {
+			c.Presence.RendezvousHost = true
+			c.Presence.RendezvousOnly = true
+			c.Presence.RendezvousBind = "0.0.0.0"
+			c.Presence.RelayPort = 4001
+			c.Presence.RelayWSPort = 4002
+			c.Presence.STUNPort = 3478
+			c.Presence.PeerDBPath = "data/peers.db"
+			c.Presence.LabelPolicy = "sanitize"
+			c.Presence.LabelMaxLen = 40
+			c.Presence.LabelStripURLs = true
+		},
+	},
+	{
+		Name:        "lan-kiosk",
+		Title:       "LAN-only kiosk",
+		Description: "A peer that only sees the local network: mDNS discovery on a fixed port, no WAN rendezvous, no external services, calls and diagnostics off.",
+		apply: func(c *Config) {
+			c.P2P.ListenPort = 4010
+			c.Presence.RendezvousWAN = ""
+			c.Presence.UseServices = false
+			c.Viewer.HTTPAddr = "127.0.0.1:8080"
+			c.Viewer.VideoDisabled = true
+		},
+	},
+	{
+		Name:        "headless-bot",
+		Title:       "Headless bot",
+		Description: "A peer without a desktop that answers with Lua scripts: Lua on, calls off, viewer API on a fixed local address, control API on for goop2 ctl.",
+		apply: func(c *Config) {
+			c.Viewer.HTTPAddr = "127.0.0.1:8080"
+			c.Viewer.VideoDisabled = true
+			c.Lua.Enabled = true
+			c.Control.Enabled = true
+		},
+	},
+}
+
+// Presets returns the available presets.
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+// ApplyPreset sets the deployment settings of cfg to the named preset.
+// Settings a preset does not mention are reset to their defaults first,
+// so switching from one preset to another leaves nothing behind.
+func ApplyPreset(cfg *Config, name string) error {
+	for _, p := range presets {
+		if p.Name != name {
+			continue
+		}
+		resetDeployment(cfg)
+		p.apply(cfg)
+		return nil
+	}
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return fmt.Errorf("unknown preset %q (want %s)", name, strings.Join(names, ", "))
+}
+
+// resetDeployment puts the settings presets control back to Default.
+func resetDeployment(c *Config) {
+	d := Default()
+	c.P2P.ListenPort = d.P2P.ListenPort
+	c.P2P.DiagAccess = d.P2P.DiagAccess
+
+	c.Presence.RendezvousHost = d.Presence.RendezvousHost
+	c.Presence.RendezvousOnly = d.Presence.RendezvousOnly
+	c.Presence.RendezvousPort = d.Presence.RendezvousPort
+	c.Presence.RendezvousBind = d.Presence.RendezvousBind
+	c.Presence.RelayPort = d.Presence.RelayPort
+	c.Presence.RelayWSPort = d.Presence.RelayWSPort
+	c.Presence.STUNPort = d.Presence.STUNPort
+	c.Presence.PeerDBPath = d.Presence.PeerDBPath
+	c.Presence.LabelPolicy = d.Presence.LabelPolicy
+	c.Presence.LabelMaxLen = d.Presence.LabelMaxLen
+	c.Presence.LabelStripURLs = d.Presence.LabelStripURLs
+
+	c.Viewer.HTTPAddr = d.Viewer.HTTPAddr
+	c.Viewer.VideoDisabled = d.Viewer.VideoDisabled
+	c.Lua.Enabled = d.Lua.Enabled
+	c.Control = d.Control
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestPresets_Validate(t *testing.T) {
+	for _, p := range Presets() {
+		cfg := Default()
+		if err := ApplyPreset(&cfg, p.Name); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
+
+func TestApplyPreset_Switching(t *testing.T) {
+	cfg := Default()
+	cfg.Profile.Label = "alice"
+	if err := ApplyPreset(&cfg, "public-rendezvous"); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Presence.RendezvousOnly || cfg.Presence.RelayPort == 0 {
+		t.Fatalf("public-rendezvous not applied: %+v", cfg.Presence)
+	}
+	if err := ApplyPreset(&cfg, "headless-bot"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Presence.RendezvousHost || cfg.Presence.RelayPort != 0 || cfg.Presence.STUNPort != 0 {
+		t.Fatal("rendezvous settings left behind")
+	}
+	if !cfg.Lua.Enabled || cfg.Profile.Label != "alice" {
+		t.Fatalf("lua=%v label=%q", cfg.Lua.Enabled, cfg.Profile.Label)
+	}
+	if err := ApplyPreset(&cfg, "nope"); err == nil {
+		t.Fatal("unknown preset accepted")
+	}
+}
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The config is kept in two files next to each other: goop.json holds the
+// user's settings, goop.state.json the state the peer writes on its own
+// (keys it generated, the applied template, remembered paths). Load reads
+// both; every write goes through Save, Update or SaveMerged, which hold a
+// file lock for the whole read-modify-write so concurrent writers in this
+// and other processes don't drop each other's changes.
+
+// stateFields are the JSON paths written to the state file.
+var stateFields = []string{
+	"p2p.nacl_public_key",
+	"p2p.nacl_private_key",
+	"viewer.active_template",
+	"viewer.cluster_binary_path",
+	"viewer.cluster_binary_mode",
+}
+
+// StateFile returns the machine state file that belongs to a config path.
+func StateFile(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".state.json"
+}
+
+// Change describes a write to a config file.
+type Change struct {
+	Path   string   // config path as passed to the writer
+	Fields []string // changed JSON paths, e.g. "p2p.diag_access"
+	Config Config   // the config as written
+}
+
+// Has reports whether any changed field is one of paths or lies below it.
+func (c Change) Has(paths ...string) bool {
+	for _, f := range c.Fields {
+		for _, p := range paths {
+			if f == p || strings.HasPrefix(f, p+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	writeMu sync.Mutex // serializes writers in this process
+
+	watchMu  sync.Mutex
+	watchers = map[int]func(Change){}
+	watchSeq int
+)
+
+// Watch calls fn after every write through this package that changes at
+// least one field. fn runs on the writer's goroutine and must not block.
+// Edits made to the files by hand or by other processes are not reported.
+func Watch(fn func(Change)) (stop func()) {
+	watchMu.Lock()
+	watchSeq++
+	id := watchSeq
+	watchers[id] = fn
+	watchMu.Unlock()
+	return func() {
+		watchMu.Lock()
+		delete(watchers, id)
+		watchMu.Unlock()
+	}
+}
+
+func notify(c Change) {
+	if len(c.Fields) == 0 {
+		return
+	}
+	watchMu.Lock()
+	fns := make([]func(Change), 0, len(watchers))
+	for _, fn := range watchers {
+		fns = append(fns, fn)
+	}
+	watchMu.Unlock()
+	for _, fn := range fns {
+		fn(c)
+	}
+}
+
+// Save validates cfg and writes it over the current config.
+func Save(path string, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return write(path, func(map[string]any) (Config, error) { return cfg, nil })
+}
+
+// Update applies fn to the current config on disk and saves the result,
+// holding the lock in between. Use it for changes to a few fields, such as
+// setting the active template.
+func Update(path string, fn func(*Config) error) (Config, error) {
+	var out Config
+	err := write(path, func(cur map[string]any) (Config, error) {
+		cfg, err := decode(cur)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := fn(&cfg); err != nil {
+			return Config{}, err
+		}
+		if err := cfg.Validate(); err != nil {
+			return Config{}, err
+		}
+		out = cfg
+		return cfg, nil
+	})
+	return out, err
+}
+
+// SaveMerged saves the fields that differ between base, the config as it
+// was loaded, and cfg onto the current file. Fields someone else changed
+// in the meantime are kept. It returns the config as written.
+func SaveMerged(path string, base, cfg Config) (Config, error) {
+	var out Config
+	err := write(path, func(cur map[string]any) (Config, error) {
+		b, err := toMap(base)
+		if err != nil {
+			return Config{}, err
+		}
+		m, err := toMap(cfg)
+		if err != nil {
+			return Config{}, err
+		}
+		merged := cloneMap(cur)
+		mergeChanges(merged, b, m)
+		res, err := decode(merged)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := res.Validate(); err != nil {
+			return Config{}, err
+		}
+		out = res
+		return res, nil
+	})
+	return out, err
+}
+
+// write runs build under the lock with the current file contents ({} if
+// there is no file yet) and stores the config it returns.
+func write(path string, build func(cur map[string]any) (Config, error)) error {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	writeMu.Lock()
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		writeMu.Unlock()
+		return err
+	}
+
+	cur, err := readMap(path)
+	if errors.Is(err, os.ErrNotExist) {
+		cur, err = map[string]any{}, nil
+	}
+	var c Change
+	if err == nil {
+		var cfg Config
+		if cfg, err = build(cur); err == nil {
+			c, err = store(path, cur, cfg)
+		}
+	}
+	unlock()
+	writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+	notify(c)
+	return nil
+}
+
+// store splits cfg into the settings and state files and writes both.
+func store(path string, old map[string]any, cfg Config) (Change, error) {
+	m, err := toMap(cfg)
+	if err != nil {
+		return Change{}, err
+	}
+	c := Change{Path: path, Config: cfg, Fields: diffFields(old, m, "")}
+
+	state := map[string]any{}
+	for _, f := range stateFields {
+		if v, ok := takeField(m, f); ok {
+			setField(state, f, v)
+		}
+	}
+	if err := writeFileAtomic(path, m, 0o644); err != nil {
+		return Change{}, err
+	}
+	// The state file holds private keys.
+	if err := writeFileAtomic(StateFile(path), state, 0o600); err != nil {
+		return Change{}, err
+	}
+	return c, nil
+}
+
+// readMerged returns the settings file with the state file laid over it.
+func readMerged(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b = stripBOM(b)
+	sb, err := os.ReadFile(StateFile(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var settings, state map[string]any
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return nil, jsonIssue(b, err)
+	}
+	if err := json.Unmarshal(stripBOM(sb), &state); err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	overlay(settings, state)
+	return json.Marshal(settings)
+}
+
+func readMap(path string) (map[string]any, error) {
+	b, err := readMerged(path)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+	return m, nil
+}
+
+// decode fills the defaults in under m, as Load does.
+func decode(m map[string]any) (Config, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := Default()
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func toMap(cfg Config) (map[string]any, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func writeFileAtomic(path string, v any, perm os.FileMode) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// overlay copies src into dst, descending into objects present in both.
+func overlay(dst, src map[string]any) {
+	for k, v := range src {
+		sm, sok := v.(map[string]any)
+		dm, dok := dst[k].(map[string]any)
+		if sok && dok {
+			overlay(dm, sm)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// mergeChanges applies the fields that differ between base and mine to
+// cur. Objects are merged key by key; anything else is replaced whole.
+func mergeChanges(cur, base, mine map[string]any) {
+	for k, mv := range mine {
+		bv, inBase := base[k]
+		if inBase && reflect.DeepEqual(bv, mv) {
+			continue
+		}
+		mm, mok := mv.(map[string]any)
+		bm, bok := bv.(map[string]any)
+		cm, cok := cur[k].(map[string]any)
+		if mok && bok && cok {
+			mergeChanges(cm, bm, mm)
+			continue
+		}
+		cur[k] = mv
+	}
+	for k := range base {
+		if _, ok := mine[k]; !ok {
+			delete(cur, k)
+		}
+	}
+}
+
+// diffFields lists the JSON paths of the leaves that differ between a and b.
+func diffFields(a, b map[string]any, prefix string) []string {
+	var out []string
+	seen := map[string]bool{}
+	check := func(k string) {
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		av, aok := a[k]
+		bv, bok := b[k]
+		if aok && bok && reflect.DeepEqual(av, bv) {
+			return
+		}
+		am, amok := av.(map[string]any)
+		bm, bmok := bv.(map[string]any)
+		switch {
+		case amok && bmok:
+			out = append(out, diffFields(am, bm, prefix+k+".")...)
+		case amok && !bok:
+			out = append(out, diffFields(am, nil, prefix+k+".")...)
+		case bmok && !aok:
+			out = append(out, diffFields(nil, bm, prefix+k+".")...)
+		default:
+			out = append(out, prefix+k)
+		}
+	}
+	for k := range a {
+		check(k)
+	}
+	for k := range b {
+		check(k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func cloneMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			v = cloneMap(sub)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// takeField removes a dotted path from m and returns its value.
+func takeField(m map[string]any, path string) (any, bool) {
+	parent, key := m, path
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		for _, p := range strings.Split(path[:i], ".") {
+			sub, ok := parent[p].(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			parent = sub
+		}
+		key = path[i+1:]
+	}
+	v, ok := parent[key]
+	delete(parent, key)
+	return v, ok
+}
+
+// setField stores v at a dotted path, creating objects on the way.
+func setField(m map[string]any, path string, v any) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		sub, ok := m[p].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[p] = sub
+		}
+		m = sub
+	}
+	m[parts[len(parts)-1]] = v
+}
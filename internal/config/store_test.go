@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSave_SplitsState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+
+	cfg := Default()
+	cfg.Profile.Label = "alice"
+	cfg.P2P.NaClPrivateKey = "priv-key"
+	cfg.Viewer.ActiveTemplate = "blog"
+	if err := Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, _ := os.ReadFile(path)
+	if strings.Contains(string(settings), "priv-key") || strings.Contains(string(settings), "active_template") {
+		t.Fatalf("state fields in settings file: %s", settings)
+	}
+	var state map[string]map[string]any
+	b, _ := os.ReadFile(StateFile(path))
+	if err := json.Unmarshal(b, &state); err != nil || state["p2p"]["nacl_private_key"] != "priv-key" || state["viewer"]["active_template"] != "blog" {
+		t.Fatalf("state file = %s (%v)", b, err)
+	}
+	if StateFile(path) != filepath.Join(dir, "goop.state.json") {
+		t.Fatalf("StateFile = %s", StateFile(path))
+	}
+
+	loaded, err := Load(path)
+	if err != nil || loaded.Profile.Label != "alice" || loaded.P2P.NaClPrivateKey != "priv-key" || loaded.Viewer.ActiveTemplate != "blog" {
+		t.Fatalf("Load = %+v, %v", loaded.Viewer, err)
+	}
+}
+
+func TestLoad_LegacyFileMigrates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	os.WriteFile(path, []byte(`{"profile":{"label":"old"},"p2p":{"nacl_private_key":"k"}}`), 0o644)
+
+	cfg, err := Load(path)
+	if err != nil || cfg.P2P.NaClPrivateKey != "k" {
+		t.Fatalf("legacy load: %v", err)
+	}
+	if _, err := Update(path, func(c *Config) error { c.Profile.Label = "new"; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := os.ReadFile(path)
+	if strings.Contains(string(settings), "nacl_private_key") {
+		t.Fatal("key left in the settings file")
+	}
+	if cfg, _ := Load(path); cfg.P2P.NaClPrivateKey != "k" || cfg.Profile.Label != "new" {
+		t.Fatalf("after migration: %+v", cfg.Profile)
+	}
+}
+
+func TestUpdate_ConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	if err := Save(path, Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Update(path, func(c *Config) error {
+				c.Presence.LabelBannedWords = append(c.Presence.LabelBannedWords, fmt.Sprint(i))
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cfg, _ := Load(path)
+	if len(cfg.Presence.LabelBannedWords) != 20 {
+		t.Fatalf("lost updates: %v", cfg.Presence.LabelBannedWords)
+	}
+}
+
+func TestSaveMerged_KeepsOtherChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	if err := Save(path, Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A settings form loads the config...
+	base, _ := Load(path)
+	form := base
+	form.Profile.Label = "from-form"
+	form.Viewer.Theme = "light"
+
+	// ...a template is applied meanwhile...
+	if _, err := Update(path, func(c *Config) error {
+		c.Viewer.ActiveTemplate = "blog"
+		c.Viewer.Theme = "dark"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ...and the form is saved.
+	got, err := SaveMerged(path, base, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Viewer.ActiveTemplate != "blog" || got.Profile.Label != "from-form" || got.Viewer.Theme != "light" {
+		t.Fatalf("merged = %q %q %q", got.Viewer.ActiveTemplate, got.Profile.Label, got.Viewer.Theme)
+	}
+
+	form = got
+	form.Identity.KeyFile = ""
+	if _, err := SaveMerged(path, got, form); err == nil {
+		t.Fatal("invalid merge result saved")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "goop.json")
+	if err := Save(path, Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Change
+	stop := Watch(func(c Change) { got = append(got, c) })
+	Update(path, func(c *Config) error { c.P2P.DiagAccess = "full"; return nil })
+	Update(path, func(c *Config) error { return nil }) // no change, no event
+	stop()
+	Update(path, func(c *Config) error { c.P2P.DiagAccess = "off"; return nil })
+
+	if len(got) != 1 {
+		t.Fatalf("%d changes, want 1", len(got))
+	}
+	c := got[0]
+	if c.Path != path || len(c.Fields) != 1 || c.Fields[0] != "p2p.diag_access" || c.Config.P2P.DiagAccess != "full" {
+		t.Fatalf("change = %+v", c.Fields)
+	}
+	if !c.Has("p2p") || !c.Has("p2p.diag_access") || c.Has("p2p.diag") || c.Has("viewer") {
+		t.Fatal("Has")
+	}
+}
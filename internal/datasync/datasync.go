@@ -0,0 +1,326 @@
+// Package datasync replicates the tables a site shares with its template
+// group, those with a "group" access policy, to the group's members.
+//
+// The host captures every change to a shared table (see storage's sync
+// tables). Each member keeps a replica of the host's shared tables under
+// replicas/<host peer ID>: on joining it pulls a snapshot over
+// /goop/data-sync, and from then on pushes its own changes and pulls
+// everyone else's every few seconds, with the host applying pushes under
+// the same access rules as remote data operations. Rows merge
+// last-writer-wins; concurrent edits are published on the local MQ topic
+// data:conflict, on the host and on the member that pushed them.
+//
+// While the host is offline, /api/p/<host>/data reads and writes go to
+// the replica; the writes are pushed once the host is back.
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// ConflictTopic is the local MQ topic conflicts are published on.
+const ConflictTopic = "data:conflict"
+
+// SyncTimeout bounds one sync with a host, push and pull.
+const SyncTimeout = time.Minute
+
+// Meta keys of a replica.
+const (
+	metaPulled   = "sync_pulled" // the host's sequence number pulled up to
+	metaPushed   = "sync_pushed" // the replica's sequence number pushed up to
+	metaGroup    = "sync_group"
+	metaSyncedAt = "sync_synced_at" // Unix ms of the last complete sync
+)
+
+// Client talks /goop/data-sync to hosts; *p2p.Node implements it.
+type Client interface {
+	ID() string
+	DataSync(ctx context.Context, hostPeerID string, req p2p.DataSyncRequest) (p2p.DataSyncResponse, error)
+}
+
+// Groups lists the groups this peer is a member of; *group.Manager
+// implements it.
+type Groups interface {
+	ActiveGroups() []group.ActiveGroupInfo
+}
+
+// Conflict is a conflict as published on ConflictTopic.
+type Conflict struct {
+	storage.SyncConflict
+	HostPeerID string `json:"host_peer_id"` // whose tables; our own ID for our own
+}
+
+// ReplicaStatus describes one replica.
+type ReplicaStatus struct {
+	HostPeerID string   `json:"host_peer_id"`
+	GroupID    string   `json:"group_id"`
+	Tables     []string `json:"tables"`
+	SyncedAt   int64    `json:"synced_at"` // Unix ms of the last complete sync, 0 = never
+	Pending    int      `json:"pending"`   // own changes not pushed yet
+	LastError  string   `json:"last_error,omitempty"`
+}
+
+// Syncer shares this peer's group tables and keeps replicas of the group
+// tables of the template groups it is a member of.
+type Syncer struct {
+	db     *storage.DB
+	node   Client
+	groups Groups
+	mq     mq.Transport
+	dir    string
+
+	mu       sync.Mutex
+	replicas map[string]*replica // by host peer ID
+}
+
+type replica struct {
+	db      *storage.DB
+	syncing sync.Mutex // one sync at a time
+	lastErr string     // guarded by Syncer.mu
+}
+
+// New creates a syncer sharing the tables of db and keeping replicas
+// under dir (replicas in the peer directory).
+func New(db *storage.DB, node Client, groups Groups, t mq.Transport, dir string) *Syncer {
+	db.SetSyncOrigin(node.ID())
+	return &Syncer{db: db, node: node, groups: groups, mq: t, dir: dir, replicas: map[string]*replica{}}
+}
+
+// Run shares tables and syncs replicas every interval until ctx is done.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	defer s.close()
+	for {
+		if err := s.Share(); err != nil {
+			log.Printf("datasync: share: %v", err)
+		}
+		for _, g := range s.groups.ActiveGroups() {
+			if g.GroupType != "template" || g.HostPeerID == s.node.ID() {
+				continue
+			}
+			if err := s.Sync(ctx, g.HostPeerID, g.GroupID); err != nil && ctx.Err() == nil {
+				log.Printf("datasync: sync with %s: %v", g.HostPeerID, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Share captures changes to every table with a group access policy and
+// stops capturing tables that lost theirs.
+func (s *Syncer) Share() error {
+	shared, err := s.db.SharedTables()
+	if err != nil {
+		return err
+	}
+	synced, err := s.db.SyncedTables()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, t := range shared {
+		if !slices.Contains(synced, t) {
+			errs = append(errs, s.db.EnableSync(t))
+		}
+	}
+	for _, t := range synced {
+		if !slices.Contains(shared, t) {
+			errs = append(errs, s.db.DisableSync(t))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HostConflicts publishes the conflicts of a push to our own tables; pass
+// it to p2p.Node.EnableDataSync.
+func (s *Syncer) HostConflicts(conflicts []storage.SyncConflict) {
+	s.publish(s.node.ID(), conflicts)
+}
+
+func (s *Syncer) publish(hostPeerID string, conflicts []storage.SyncConflict) {
+	for _, c := range conflicts {
+		log.Printf("datasync: conflict on %s/%s of %s: %s, %s won", c.Table, c.SyncID, hostPeerID, c.Reason, c.Winner.Origin)
+		s.mq.PublishLocal(ConflictTopic, "", Conflict{SyncConflict: c, HostPeerID: hostPeerID})
+	}
+}
+
+// Sync pushes our changes to the replica of hostPeerID's tables and
+// pulls the host's, creating the replica with a snapshot the first time.
+func (s *Syncer) Sync(ctx context.Context, hostPeerID, groupID string) error {
+	r, err := s.replica(hostPeerID, true)
+	if err != nil {
+		return err
+	}
+	r.syncing.Lock()
+	defer r.syncing.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, SyncTimeout)
+	defer cancel()
+	r.db.SetMeta(metaGroup, groupID)
+	err = s.push(ctx, hostPeerID, r.db)
+	if err == nil {
+		err = s.pull(ctx, hostPeerID, r.db)
+	}
+	s.mu.Lock()
+	r.lastErr = ""
+	if err != nil {
+		r.lastErr = err.Error()
+	}
+	s.mu.Unlock()
+	if err == nil {
+		r.db.SetMeta(metaSyncedAt, strconv.FormatInt(time.Now().UnixMilli(), 10))
+	}
+	return err
+}
+
+// push sends the replica's own changes. The host answers refused ones
+// with its version, which replaces ours.
+func (s *Syncer) push(ctx context.Context, host string, db *storage.DB) error {
+	pushed := metaInt(db, metaPushed)
+	for {
+		changes, next, more, err := db.SyncChanges(pushed, p2p.DataSyncPageSize, s.own)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			resp, err := s.node.DataSync(ctx, host, p2p.DataSyncRequest{Op: p2p.DataSyncPush, Changes: changes})
+			if err != nil {
+				return err
+			}
+			if _, err := db.ApplySync(resp.Changes, true); err != nil {
+				return err
+			}
+			s.publish(host, resp.Conflicts)
+		}
+		pushed = next
+		db.SetMeta(metaPushed, strconv.FormatInt(pushed, 10))
+		if !more {
+			return nil
+		}
+	}
+}
+
+func (s *Syncer) pull(ctx context.Context, host string, db *storage.DB) error {
+	pulled := metaInt(db, metaPulled)
+	for {
+		resp, err := s.node.DataSync(ctx, host, p2p.DataSyncRequest{Op: p2p.DataSyncPull, Since: pulled})
+		if err != nil {
+			return err
+		}
+		for _, def := range resp.Tables {
+			if err := db.EnsureSyncTable(def); err != nil {
+				return fmt.Errorf("table %s: %w", def.Name, err)
+			}
+		}
+		if resp.Reset {
+			log.Printf("datasync: %s restarted its change log, pulling a new snapshot", host)
+		}
+		conflicts, err := db.ApplySync(resp.Changes, false)
+		if err != nil {
+			return err
+		}
+		s.publish(host, conflicts)
+		pulled = resp.Next
+		db.SetMeta(metaPulled, strconv.FormatInt(pulled, 10))
+		if !resp.More {
+			return nil
+		}
+	}
+}
+
+func (s *Syncer) own(c storage.SyncChange) bool {
+	return c.Origin == s.node.ID()
+}
+
+// replica returns the replica of hostPeerID's tables, opening it if it
+// exists or, with create, creating it. It returns nil, nil when there is
+// none.
+func (s *Syncer) replica(hostPeerID string, create bool) (*replica, error) {
+	if _, err := peer.Decode(hostPeerID); err != nil {
+		return nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.replicas[hostPeerID]; r != nil {
+		return r, nil
+	}
+	dir := filepath.Join(s.dir, hostPeerID)
+	if _, err := os.Stat(dir); err != nil && !create {
+		return nil, nil
+	}
+	db, err := s.db.OpenReplica(dir)
+	if err != nil {
+		return nil, err
+	}
+	db.SetSyncOrigin(s.node.ID())
+	r := &replica{db: db}
+	s.replicas[hostPeerID] = r
+	return r, nil
+}
+
+func (s *Syncer) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, r := range s.replicas {
+		r.db.Close()
+		delete(s.replicas, id)
+	}
+}
+
+// List describes every replica, including those of groups no longer
+// joined.
+func (s *Syncer) List() ([]ReplicaStatus, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	out := []ReplicaStatus{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		r, err := s.replica(e.Name(), false)
+		if err != nil || r == nil {
+			continue
+		}
+		st := ReplicaStatus{HostPeerID: e.Name(), GroupID: r.db.GetMeta(metaGroup), SyncedAt: metaInt(r.db, metaSyncedAt)}
+		st.Tables, _ = r.db.SyncedTables()
+		if st.Tables == nil {
+			st.Tables = []string{}
+		}
+		st.Pending = r.db.SyncPending(metaInt(r.db, metaPushed), s.node.ID())
+		s.mu.Lock()
+		st.LastError = r.lastErr
+		s.mu.Unlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].HostPeerID < out[j].HostPeerID })
+	return out, nil
+}
+
+func metaInt(db *storage.DB, key string) int64 {
+	n, _ := strconv.ParseInt(db.GetMeta(key), 10, 64)
+	return n
+}
@@ -0,0 +1,72 @@
+package datasync
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Offline answers a data operation on hostPeerID's tables from its
+// replica, for when the host cannot be reached. Writes are made as our
+// own and pushed with the next sync, where the host may still refuse
+// them. ok is false when there is no replica of the table or the
+// operation has no offline form; syncedAt is the replica's last sync.
+func (s *Syncer) Offline(hostPeerID string, req p2p.DataRequest) (resp p2p.DataResponse, syncedAt time.Time, ok bool) {
+	r, err := s.replica(hostPeerID, false)
+	if err != nil || r == nil {
+		return resp, syncedAt, false
+	}
+	if tables, _ := r.db.SyncedTables(); req.Table == "" || !slices.Contains(tables, req.Table) {
+		return resp, syncedAt, false
+	}
+	ms, _ := strconv.ParseInt(r.db.GetMeta(metaSyncedAt), 10, 64)
+	syncedAt = time.UnixMilli(ms)
+
+	db := r.db
+	switch req.Op {
+	case "query":
+		cols := req.Columns
+		if len(cols) == 0 {
+			cols = req.Fields
+		}
+		rows, err := db.SelectPaged(storage.SelectOpts{
+			Table:   req.Table,
+			Columns: cols,
+			Where:   req.Where,
+			Args:    req.Args,
+			Order:   req.Order,
+			Limit:   req.Limit,
+			Offset:  req.Offset,
+		})
+		if err != nil {
+			return p2p.DataResponse{Error: err.Error()}, syncedAt, true
+		}
+		return p2p.DataResponse{OK: true, Data: rows}, syncedAt, true
+	case "insert":
+		id, err := db.Insert(req.Table, s.node.ID(), "", req.Data)
+		if err != nil {
+			return p2p.DataResponse{Error: err.Error()}, syncedAt, true
+		}
+		return p2p.DataResponse{OK: true, Data: map[string]any{"status": "inserted", "id": id}}, syncedAt, true
+	case "update":
+		if req.ID <= 0 {
+			return p2p.DataResponse{Error: "valid row id required"}, syncedAt, true
+		}
+		if err := db.UpdateRow(req.Table, req.ID, req.Data); err != nil {
+			return p2p.DataResponse{Error: err.Error()}, syncedAt, true
+		}
+		return p2p.DataResponse{OK: true, Data: map[string]string{"status": "updated"}}, syncedAt, true
+	case "delete":
+		if req.ID <= 0 {
+			return p2p.DataResponse{Error: "valid row id required"}, syncedAt, true
+		}
+		if err := db.DeleteRow(req.Table, req.ID); err != nil {
+			return p2p.DataResponse{Error: err.Error()}, syncedAt, true
+		}
+		return p2p.DataResponse{OK: true, Data: map[string]string{"status": "deleted"}}, syncedAt, true
+	}
+	return resp, syncedAt, false
+}
@@ -78,6 +78,17 @@ func (m *Manager) PersistOutbound(peerID, content string) {
 	}
 }
 
+// PersistCall stores a message exchanged on a call's data channel, tagged
+// with the call's channel ID so history can show it in context.
+func (m *Manager) PersistCall(peerID, fromID, content, callID string) {
+	if peerID == "" || content == "" {
+		return
+	}
+	if err := m.store.StoreCallChatMessage(peerID, fromID, content, callID, time.Now().UnixMilli()); err != nil {
+		log.Printf("CHAT: persist call message with %s failed: %v", peerID, err)
+	}
+}
+
 // RegisterHTTP registers the chat history endpoints on the given mux.
 func (m *Manager) RegisterHTTP(mux *http.ServeMux) {
 	mux.HandleFunc("/api/chat/history", func(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +115,35 @@ func (m *Manager) RegisterHTTP(mux *http.ServeMux) {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+
+	// POST /api/chat/call — browser-mode calls carry chat on the browser's own
+	// data channel, so the page reports each message here to persist it.
+	mux.HandleFunc("/api/chat/call", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			PeerID   string `json:"peer_id"`
+			CallID   string `json:"call_id"`
+			Content  string `json:"content"`
+			Incoming bool   `json:"incoming"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if req.PeerID == "" || req.CallID == "" || req.Content == "" {
+			http.Error(w, "missing peer_id, call_id or content", http.StatusBadRequest)
+			return
+		}
+		from := m.selfID
+		if req.Incoming {
+			from = req.PeerID
+		}
+		m.PersistCall(req.PeerID, from, req.Content, req.CallID)
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
 }
 
 func extractContent(payload any) string {
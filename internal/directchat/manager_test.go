@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -27,6 +28,13 @@ func (s *mockStore) StoreChatMessage(peerID, fromID, content string, ts int64) e
 	return nil
 }
 
+func (s *mockStore) StoreCallChatMessage(peerID, fromID, content, callID string, ts int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs[peerID] = append(s.msgs[peerID], Message{From: fromID, Content: content, Timestamp: ts, CallID: callID})
+	return nil
+}
+
 func (s *mockStore) GetChatHistory(peerID string, limit int) ([]Message, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -451,6 +459,54 @@ func TestHTTP_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestHTTP_PostCallMessage(t *testing.T) {
+	store := newMockStore()
+	mgr := New("self", store, &mockMQ{})
+	mux := http.NewServeMux()
+	mgr.RegisterHTTP(mux)
+
+	for _, body := range []string{
+		`{"peer_id":"peer1","call_id":"ch1","content":"here's the link","incoming":true}`,
+		`{"peer_id":"peer1","call_id":"ch1","content":"thanks"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/chat/call", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	}
+
+	msgs, _ := store.GetChatHistory("peer1", 0)
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].From != "peer1" || msgs[1].From != "self" {
+		t.Errorf("senders = %q, %q; want peer1, self", msgs[0].From, msgs[1].From)
+	}
+	if msgs[0].CallID != "ch1" || msgs[1].CallID != "ch1" {
+		t.Errorf("messages not tagged with the call ID: %+v", msgs)
+	}
+}
+
+func TestHTTP_PostCallMessage_MissingFields(t *testing.T) {
+	store := newMockStore()
+	mgr := New("self", store, &mockMQ{})
+	mux := http.NewServeMux()
+	mgr.RegisterHTTP(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/call", strings.NewReader(`{"peer_id":"peer1","content":"x"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if n := store.count("peer1"); n != 0 {
+		t.Fatalf("expected nothing stored, got %d", n)
+	}
+}
+
 // ── RegisterChat nil guard ──────────────────────────────────────────────────
 
 func TestNew_NilMQ_NoPanic(t *testing.T) {
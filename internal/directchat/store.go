@@ -7,11 +7,13 @@ type Message struct {
 	From      string `json:"from"`
 	Content   string `json:"content"`
 	Timestamp int64  `json:"timestamp"`
+	CallID    string `json:"call_id,omitempty"` // channel ID of the call it was sent in
 }
 
 // Store abstracts chat message persistence.
 type Store interface {
 	StoreChatMessage(peerID, fromID, content string, ts int64) error
+	StoreCallChatMessage(peerID, fromID, content, callID string, ts int64) error
 	GetChatHistory(peerID string, limit int) ([]Message, error)
 	ClearChatHistory(peerID string) error
 }
@@ -11,6 +11,10 @@ func (s *DBStore) StoreChatMessage(peerID, fromID, content string, ts int64) err
 	return s.db.StoreChatMessage(peerID, fromID, content, ts)
 }
 
+func (s *DBStore) StoreCallChatMessage(peerID, fromID, content, callID string, ts int64) error {
+	return s.db.StoreCallChatMessage(peerID, fromID, content, callID, ts)
+}
+
 func (s *DBStore) GetChatHistory(peerID string, limit int) ([]Message, error) {
 	rows, err := s.db.GetChatHistory(peerID, limit)
 	if err != nil {
@@ -18,7 +22,7 @@ func (s *DBStore) GetChatHistory(peerID string, limit int) ([]Message, error) {
 	}
 	msgs := make([]Message, len(rows))
 	for i, r := range rows {
-		msgs[i] = Message{From: r.From, Content: r.Content, Timestamp: r.Timestamp}
+		msgs[i] = Message{From: r.From, Content: r.Content, Timestamp: r.Timestamp, CallID: r.CallID}
 	}
 	return msgs, nil
 }
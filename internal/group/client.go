@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -33,10 +35,11 @@ func (m *Manager) JoinRemoteGroup(ctx context.Context, hostPeerID, groupID strin
 	m.mu.Unlock()
 
 	if old != nil {
-		leaveCtx, leaveCancel := context.WithTimeout(context.Background(), SendTimeout)
+		old.closeTopic()
+		leaveCtx, leaveCancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 		_, _ = m.mq.Send(leaveCtx, old.hostPeerID, "group:"+groupID+":"+TypeLeave, Message{Type: TypeLeave, Group: groupID})
 		leaveCancel()
-		m.db.RemoveSubscription(old.hostPeerID, old.groupID) //nolint:errcheck
+		m.db.RemoveSubscription(old.ownerID(), old.groupID) //nolint:errcheck
 		_ = m.db.DeleteGroupMembers(old.groupID)
 	}
 
@@ -60,7 +63,7 @@ func (m *Manager) JoinRemoteGroup(ctx context.Context, hostPeerID, groupID strin
 	}()
 
 	// Set a timeout for the entire join handshake
-	joinCtx, joinCancel := context.WithTimeout(ctx, JoinTimeout)
+	joinCtx, joinCancel := context.WithTimeout(ctx, timeouts.Get().GroupJoin)
 	defer joinCancel()
 
 	// Send join
@@ -82,17 +85,29 @@ func (m *Manager) JoinRemoteGroup(ctx context.Context, hostPeerID, groupID strin
 
 	vol := m.isVolatileType(wp.GroupType)
 
+	owner := wp.Owner
+	if owner == "" {
+		owner = hostPeerID
+	}
 	cc := &clientConn{
 		hostPeerID: hostPeerID,
 		groupID:    groupID,
 		groupType:  wp.GroupType,
+		groupContext: wp.GroupContext,
+		ordered:    wp.Ordered || m.isOrderedType(wp.GroupType),
 		members:    wp.Members,
+		owner:      owner,
+		relays:     wp.Relays,
 	}
 
 	m.mu.Lock()
 	m.activeConns[groupID] = cc
 	m.mu.Unlock()
 
+	if wp.Token != nil {
+		m.acceptToken(cc, *wp.Token)
+	}
+
 	// Persist member list for stable groups
 	if !vol && len(wp.Members) > 0 {
 		_ = m.db.UpsertGroupMembers(groupID, membersToStorage(wp.Members))
@@ -110,9 +125,11 @@ func (m *Manager) JoinRemoteGroup(ctx context.Context, hostPeerID, groupID strin
 		}
 	}
 
-	// Store subscription with full metadata
-	hostName := m.resolvePeerName(hostPeerID)
-	m.db.AddSubscription(hostPeerID, groupID, wp.GroupName, wp.GroupType, wp.MaxMembers, vol, "member", hostName) //nolint:errcheck
+	// Store subscription with full metadata, under the owner when we joined
+	// through a co-host
+	hostName := m.resolvePeerName(owner)
+	m.db.AddSubscription(owner, groupID, wp.GroupName, wp.GroupType, wp.MaxMembers, vol, "member", hostName) //nolint:errcheck
+	_ = m.db.SetSubscriptionRelays(owner, groupID, wp.Relays)
 
 	m.notifyListeners(&Event{Type: TypeWelcome, Group: groupID, From: hostPeerID, Payload: map[string]any{
 		"group_name":    wp.GroupName,
@@ -131,15 +148,24 @@ func (m *Manager) JoinRemoteGroup(ctx context.Context, hostPeerID, groupID strin
 func (m *Manager) SendToGroup(groupID string, payload any) error {
 	m.mu.RLock()
 	cc := m.activeConns[groupID]
+	ordered := cc != nil && cc.ordered
 	m.mu.RUnlock()
 
 	if cc == nil {
 		return fmt.Errorf("not connected to group %s", groupID)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), BroadcastTimeout)
+	wire := payload
+	if ordered {
+		wire = wrapReliable(m.rel.nextReliable(groupID, m.selfID, TypeMsg, payload))
+	}
+	if sent, err := m.sendViaTopic(cc, wire); sent {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 	defer cancel()
-	_, err := m.mq.Send(ctx, cc.hostPeerID, "group:"+groupID+":"+TypeMsg, payload)
+	_, err := m.mq.Send(ctx, cc.hostPeerID, "group:"+groupID+":"+TypeMsg, wire)
 	return err
 }
 
@@ -153,15 +179,24 @@ func (m *Manager) LeaveGroup(groupID string) error {
 	m.mu.Unlock()
 
 	if cc == nil {
+		m.mu.RLock()
+		hg := m.groups[groupID]
+		m.mu.RUnlock()
+		if hg != nil && hg.owner != "" {
+			return fmt.Errorf("co-hosting group %s; ask its owner to remove you as co-host", groupID)
+		}
 		return fmt.Errorf("not connected to group %s", groupID)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+	cc.closeTopic()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 	defer cancel()
 	_, _ = m.mq.Send(ctx, cc.hostPeerID, "group:"+groupID+":"+TypeLeave, Message{Type: TypeLeave, Group: groupID})
 
-	m.db.RemoveSubscription(cc.hostPeerID, cc.groupID) //nolint:errcheck
+	m.db.RemoveSubscription(cc.ownerID(), cc.groupID) //nolint:errcheck
 	_ = m.db.DeleteGroupMembers(cc.groupID)
+	m.rel.forget(cc.groupID)
 	m.notifyListeners(&Event{Type: TypeLeave, Group: cc.groupID})
 
 	log.Printf("GROUP: Left group %s", cc.groupID)
@@ -248,9 +283,15 @@ func (m *Manager) handlePeerAnnounce(payload any) {
 	}
 	reachable, _ := data["reachable"].(bool)
 	offline, _ := data["offline"].(bool)
+	m.handlePresenceChange(peerID, reachable && !offline)
 	if !reachable || offline {
+		if offline {
+			m.failoverFrom(peerID)
+			m.scheduleElections(peerID)
+		}
 		return
 	}
+	m.notifyFormerOwner(peerID)
 
 	// Update stored host name from the announce payload
 	content, _ := data["content"].(string)
@@ -264,19 +305,20 @@ func (m *Manager) handlePeerAnnounce(payload any) {
 	}
 
 	for _, s := range subs {
-		if s.HostPeerID != peerID {
+		if s.HostPeerID != peerID && !slices.Contains(s.Relays, peerID) {
 			continue
 		}
 		m.mu.RLock()
 		_, connected := m.activeConns[s.GroupID]
+		_, relaying := m.groups[s.GroupID]
 		m.mu.RUnlock()
-		if connected {
+		if connected || relaying {
 			continue
 		}
 
 		go func(sub storage.SubscriptionRow) {
-			ctx, cancel := context.WithTimeout(context.Background(), ReconnectTimeout)
-			err := m.RejoinSubscription(ctx, sub.HostPeerID, sub.GroupID)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
+			err := m.RejoinSubscription(ctx, peerID, sub.GroupID)
 			cancel()
 			if err != nil {
 				if isRejection(err) {
@@ -302,21 +344,22 @@ func (m *Manager) reconnectSubscriptions() {
 	}
 
 	// Reconnect all subscriptions in parallel — sequential reconnection
-	// blocks for N × ReconnectTimeout when multiple hosts are unreachable.
+	// blocks for N × the join timeout when multiple hosts are unreachable.
 	var wg sync.WaitGroup
 	for _, sub := range subs {
 		m.mu.RLock()
 		_, alreadyConnected := m.activeConns[sub.GroupID]
+		_, relaying := m.groups[sub.GroupID]
 		m.mu.RUnlock()
-		if alreadyConnected {
+		if alreadyConnected || relaying {
 			continue
 		}
 
 		wg.Add(1)
 		go func(s storage.SubscriptionRow) {
 			defer wg.Done()
-			ctx, cancel := context.WithTimeout(context.Background(), ReconnectTimeout)
-			err := m.RejoinSubscription(ctx, s.HostPeerID, s.GroupID)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin*time.Duration(max(1, len(s.Relays))))
+			err := m.rejoinSubscription(ctx, s)
 			cancel()
 
 			if err != nil {
@@ -0,0 +1,714 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Co-hosting: the owner of a group authorizes members to relay it as well.
+// Every relay (owner included) keeps its own member connections, forwards
+// member messages to the other relays, and tells them who is connected to
+// it, so all relays and members see one group. Members connect to whichever
+// relay answers first and fail over to another when theirs goes away, so the
+// group keeps working while the owner is offline.
+//
+// Only the owner changes the relay set or closes the group. Co-hosts are
+// promoted from current members and keep being members themselves.
+
+const maxCoHosts = 4 // relays besides the owner
+
+// canCoHost reports whether groups of this type may have co-hosts.
+func (m *Manager) canCoHost(groupType string) bool {
+	h := m.handlerForType(groupType)
+	return h == nil || h.Flags().CoHost
+}
+
+// AddCoHost authorizes a current member of a group we own to relay it.
+func (m *Manager) AddCoHost(groupID, peerID string) error {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	hg.mu.RLock()
+	groupType := hg.info.GroupType
+	hg.mu.RUnlock()
+	if !m.canCoHost(groupType) {
+		return fmt.Errorf("group type %q does not support co-hosts", groupType)
+	}
+
+	hg.mu.Lock()
+	switch {
+	case hg.owner != "":
+		hg.mu.Unlock()
+		return fmt.Errorf("only the owner can add co-hosts")
+	case peerID == m.selfID || slices.Contains(hg.info.CoHosts, peerID):
+		hg.mu.Unlock()
+		return fmt.Errorf("%s already relays group %s", shortID(peerID), groupID)
+	case hg.topic != nil:
+		hg.mu.Unlock()
+		return fmt.Errorf("group %s is large and spreads messages over its topic; it cannot be co-hosted", groupID)
+	case len(hg.info.CoHosts) >= maxCoHosts:
+		hg.mu.Unlock()
+		return fmt.Errorf("maximum of %d co-hosts reached", maxCoHosts)
+	case !slices.ContainsFunc(hg.memberList(m.selfID), func(mi MemberInfo) bool { return mi.PeerID == peerID }):
+		hg.mu.Unlock()
+		return fmt.Errorf("peer %s not in group %s", shortID(peerID), groupID)
+	}
+	hg.info.CoHosts = append(slices.Clone(hg.info.CoHosts), peerID)
+	hg.relays = hg.info.CoHosts
+	cohosts := hg.info.CoHosts
+	hg.mu.Unlock()
+
+	if err := m.db.SetGroupCoHosts(groupID, cohosts); err != nil {
+		return fmt.Errorf("save co-hosts: %w", err)
+	}
+	m.announceCoHosts(hg, groupID)
+
+	log.Printf("GROUP: %s is now a co-host of %s", shortID(peerID), groupID)
+	return nil
+}
+
+// RemoveCoHost withdraws a co-host's authorization. Its members move to the
+// remaining relays.
+func (m *Manager) RemoveCoHost(groupID, peerID string) error {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	hg.mu.Lock()
+	if hg.owner != "" {
+		hg.mu.Unlock()
+		return fmt.Errorf("only the owner can remove co-hosts")
+	}
+	i := slices.Index(hg.info.CoHosts, peerID)
+	if i < 0 {
+		hg.mu.Unlock()
+		return fmt.Errorf("%s is not a co-host of %s", shortID(peerID), groupID)
+	}
+	hg.info.CoHosts = slices.Delete(slices.Clone(hg.info.CoHosts), i, i+1)
+	hg.relays = hg.info.CoHosts
+	delete(hg.remote, peerID)
+	cohosts := hg.info.CoHosts
+	members := hg.memberList(m.selfID)
+	hg.mu.Unlock()
+
+	if err := m.db.SetGroupCoHosts(groupID, cohosts); err != nil {
+		return fmt.Errorf("save co-hosts: %w", err)
+	}
+	go m.sendGroup(peerID, groupID, TypeCoHostEnd, Message{Type: TypeCoHostEnd, Group: groupID})
+	m.announceCoHosts(hg, groupID)
+	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: members}})
+
+	log.Printf("GROUP: %s no longer co-hosts %s", shortID(peerID), groupID)
+	return nil
+}
+
+// CanCoHost reports whether a group we host may get co-hosts.
+func (m *Manager) CanCoHost(groupID string) bool {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return false
+	}
+	hg.mu.RLock()
+	groupType, owned := hg.info.GroupType, hg.owner == ""
+	hg.mu.RUnlock()
+	return owned && m.canCoHost(groupType)
+}
+
+// IsCoHosting reports whether we relay a group on behalf of its owner.
+func (m *Manager) IsCoHosting(groupID string) bool {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return false
+	}
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
+	return hg.owner != ""
+}
+
+// GroupRelays returns every peer relaying a group we host or co-host, owner
+// first, or nil when the group has no co-hosts.
+func (m *Manager) GroupRelays(groupID string) []string {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
+	return m.relayListLocked(hg)
+}
+
+// relayListLocked returns every relay of hg including us, owner first. nil
+// when the group has no co-hosts. Caller holds hg.mu.
+func (m *Manager) relayListLocked(hg *hostedGroup) []string {
+	if len(hg.relays) == 0 {
+		return nil
+	}
+	owner := hg.owner
+	if owner == "" {
+		owner = m.selfID
+	}
+	out := []string{owner}
+	for _, id := range append([]string{m.selfID}, hg.relays...) {
+		if id != owner {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// announceCoHosts sends the current relay set and group settings to every
+// co-host, and the relay set to our own members.
+func (m *Manager) announceCoHosts(hg *hostedGroup, groupID string) {
+	hg.mu.RLock()
+	relays := m.relayListLocked(hg)
+	if relays == nil {
+		relays = []string{m.selfID}
+	}
+	cp := CoHostPayload{
+		GroupName:    hg.info.Name,
+		GroupType:    hg.info.GroupType,
+		GroupContext: hg.info.GroupContext,
+		MaxMembers:   hg.info.MaxMembers,
+		DefaultRole:  hg.info.DefaultRole,
+		Ordered:      hg.info.Ordered,
+		Relays:       relays,
+	}
+	targets := slices.Clone(hg.relays)
+	hg.mu.RUnlock()
+
+	for _, p := range targets {
+		go m.sendGroup(p, groupID, TypeCoHost, cp)
+	}
+	rp := RelaysPayload{Owner: m.selfID, Relays: relays}
+	m.broadcastToGroup(hg, groupID, TypeRelays, rp, "")
+	m.notifyListeners(&Event{Type: TypeRelays, Group: groupID, Payload: rp})
+}
+
+// announceSettings sends changed group settings to the co-hosts of a group
+// we own.
+func (m *Manager) announceSettings(hg *hostedGroup, groupID string) {
+	hg.mu.RLock()
+	owned := hg.owner == "" && len(hg.relays) > 0
+	hg.mu.RUnlock()
+	if owned {
+		m.announceCoHosts(hg, groupID)
+	}
+}
+
+// forwardToRelays passes a member message on to the other relays.
+func (m *Manager) forwardToRelays(hg *hostedGroup, groupID, origin, msgType string, payload any) {
+	hg.mu.RLock()
+	targets := slices.Clone(hg.relays)
+	hg.mu.RUnlock()
+	for _, p := range targets {
+		go m.sendGroup(p, groupID, TypeRelay, RelayPayload{Origin: origin, Type: msgType, Payload: payload})
+	}
+}
+
+// syncRelayMembers tells the other relays who is connected to us. Called on
+// every membership change and on each ping tick, which also repairs lists
+// after a relay restarts.
+func (m *Manager) syncRelayMembers(hg *hostedGroup, groupID string) {
+	hg.mu.RLock()
+	targets := slices.Clone(hg.relays)
+	local := hg.localMembers(m.selfID)
+	hg.mu.RUnlock()
+	for _, p := range targets {
+		go func(p string) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+			defer cancel()
+			if _, err := m.mq.Send(ctx, p, "group:"+groupID+":"+TypeRelayMembers, MembersPayload{Members: local}); err != nil {
+				log.Printf("GROUP: relay %s of %s unreachable: %v", shortID(p), groupID, err)
+				m.dropRelayMembers(groupID, p)
+			}
+		}(p)
+	}
+}
+
+// dropRelayMembers forgets the members connected through an unreachable
+// relay; they fail over and show up again through another one.
+func (m *Manager) dropRelayMembers(groupID, relay string) {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return
+	}
+	hg.mu.Lock()
+	if len(hg.remote[relay]) == 0 {
+		hg.mu.Unlock()
+		return
+	}
+	delete(hg.remote, relay)
+	members := hg.memberList(m.selfID)
+	hg.mu.Unlock()
+
+	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: members}})
+}
+
+// handleRelayMessage handles the co-hosting messages a relay receives from
+// the owner and the other relays.
+func (m *Manager) handleRelayMessage(from string, hg *hostedGroup, groupID, msgType string, payload any) {
+	hg.mu.RLock()
+	isRelay := slices.Contains(hg.relays, from)
+	fromOwner := hg.owner != "" && from == hg.owner
+	hg.mu.RUnlock()
+
+	switch msgType {
+	case TypeRelay:
+		var rp RelayPayload
+		if !isRelay || !decodePayload(payload, &rp) || (rp.Type != TypeMsg && rp.Type != TypeState) {
+			return
+		}
+		m.broadcastToGroup(hg, groupID, rp.Type, rp.Payload, rp.Origin)
+		if env, ok := unwrapReliable(rp.Payload); ok {
+			m.rel.record(groupID, env)
+			m.notifyListeners(&Event{Type: env.Type, Group: groupID, From: env.Origin, Payload: env.Data, TS: m.localTime(env.Origin, env.TS)})
+			return
+		}
+		m.notifyListeners(&Event{Type: rp.Type, Group: groupID, From: rp.Origin, Payload: rp.Payload})
+
+	case TypeRelayMembers:
+		var mp MembersPayload
+		if !isRelay || !decodePayload(payload, &mp) {
+			return
+		}
+		slices.SortFunc(mp.Members, func(a, b MemberInfo) int { return strings.Compare(a.PeerID, b.PeerID) })
+		hg.mu.Lock()
+		if reflect.DeepEqual(hg.remote[from], mp.Members) {
+			hg.mu.Unlock()
+			return
+		}
+		if hg.remote == nil {
+			hg.remote = make(map[string][]MemberInfo)
+		}
+		hg.remote[from] = mp.Members
+		members := hg.memberList(m.selfID)
+		groupType := hg.info.GroupType
+		hg.mu.Unlock()
+
+		m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+		m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, From: from, Payload: MembersPayload{Members: members}})
+		if !m.isVolatileType(groupType) {
+			_ = m.db.UpsertGroupMembers(groupID, membersToStorage(members))
+		}
+
+	case TypeCoHost:
+		var cp CoHostPayload
+		if fromOwner && decodePayload(payload, &cp) {
+			m.applyCoHost(hg, groupID, cp)
+		}
+
+	case TypeCoHostEnd:
+		if fromOwner {
+			m.stopCoHosting(hg, groupID)
+		}
+
+	case TypeKick:
+		var kp KickPayload
+		if fromOwner && decodePayload(payload, &kp) {
+			_ = m.KickMember(groupID, kp.PeerID)
+		}
+
+	case TypeClose:
+		if fromOwner {
+			m.closeRelayedGroup(hg, groupID)
+		}
+	}
+}
+
+// becomeCoHost turns our membership of a group into a relay for it, after
+// the owner authorized us.
+func (m *Manager) becomeCoHost(cc *clientConn, cp CoHostPayload) {
+	if !slices.Contains(cp.Relays, m.selfID) || !m.canCoHost(cp.GroupType) {
+		return
+	}
+	groupID := cc.groupID
+	rg := storage.RelayedGroup{
+		GroupID:      groupID,
+		Owner:        cc.ownerID(),
+		Name:         cp.GroupName,
+		GroupType:    cp.GroupType,
+		GroupContext: cp.GroupContext,
+		MaxMembers:   cp.MaxMembers,
+		DefaultRole:  cp.DefaultRole,
+		Ordered:      cp.Ordered,
+		Relays:       cp.Relays,
+	}
+
+	m.mu.Lock()
+	if _, exists := m.groups[groupID]; exists {
+		m.mu.Unlock()
+		return
+	}
+	if m.activeConns[groupID] == cc {
+		delete(m.activeConns, groupID)
+	}
+	hg := m.startRelayLocked(rg)
+	m.mu.Unlock()
+
+	// We are now our own relay; the one we were connected to drops us and
+	// learns about us again from syncRelayMembers.
+	go m.sendGroup(cc.hostPeerID, groupID, TypeLeave, Message{Type: TypeLeave, Group: groupID})
+	if err := m.db.SaveRelayedGroup(rg); err != nil {
+		log.Printf("GROUP: save co-hosted group %s: %v", groupID, err)
+	}
+	m.syncRelayMembers(hg, groupID)
+	m.notifyListeners(&Event{Type: TypeCoHost, Group: groupID, From: cc.ownerID(), Payload: cp})
+
+	log.Printf("GROUP: Co-hosting group %s for %s", groupID, shortID(cc.ownerID()))
+}
+
+// startRelayLocked registers a group we relay for its owner and starts its
+// ping loop. Caller holds m.mu.
+func (m *Manager) startRelayLocked(rg storage.RelayedGroup) *hostedGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	hg := &hostedGroup{
+		info: storage.GroupRow{
+			ID:           rg.GroupID,
+			Name:         rg.Name,
+			Owner:        rg.Owner,
+			GroupType:    rg.GroupType,
+			GroupContext: rg.GroupContext,
+			MaxMembers:   rg.MaxMembers,
+			DefaultRole:  rg.DefaultRole,
+			Ordered:      rg.Ordered,
+		},
+		members:      make(map[string]*memberMeta),
+		hostJoined:   true,
+		hostJoinedAt: nowMillis(),
+		cancelPing:   cancel,
+		owner:        rg.Owner,
+		relays:       without(rg.Relays, m.selfID),
+	}
+	m.groups[rg.GroupID] = hg
+	if m.mq != nil {
+		go m.pingGroupLoop(ctx, rg.GroupID)
+	}
+	return hg
+}
+
+// restoreRelayedGroups resumes relaying the groups we co-host after a
+// restart, whether or not their owner is online.
+func (m *Manager) restoreRelayedGroups() {
+	relayed, err := m.db.ListRelayedGroups()
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rg := range relayed {
+		if _, exists := m.groups[rg.GroupID]; !exists {
+			m.startRelayLocked(rg)
+		}
+	}
+}
+
+// applyCoHost takes an updated relay set and group settings from the owner.
+func (m *Manager) applyCoHost(hg *hostedGroup, groupID string, cp CoHostPayload) {
+	hg.mu.Lock()
+	metaChanged := hg.info.Name != cp.GroupName || hg.info.MaxMembers != cp.MaxMembers || hg.info.Ordered != cp.Ordered
+	hg.info.Name = cp.GroupName
+	hg.info.MaxMembers = cp.MaxMembers
+	hg.info.DefaultRole = cp.DefaultRole
+	hg.info.Ordered = cp.Ordered
+	relaysChanged := !slices.Equal(m.relayListLocked(hg), cp.Relays)
+	hg.relays = without(cp.Relays, m.selfID)
+	for relay := range hg.remote {
+		if !slices.Contains(hg.relays, relay) {
+			delete(hg.remote, relay)
+		}
+	}
+	rg := storage.RelayedGroup{
+		GroupID:      groupID,
+		Owner:        hg.owner,
+		Name:         cp.GroupName,
+		GroupType:    hg.info.GroupType,
+		GroupContext: hg.info.GroupContext,
+		MaxMembers:   cp.MaxMembers,
+		DefaultRole:  cp.DefaultRole,
+		Ordered:      cp.Ordered,
+		Relays:       cp.Relays,
+	}
+	hg.mu.Unlock()
+
+	if err := m.db.SaveRelayedGroup(rg); err != nil {
+		log.Printf("GROUP: save co-hosted group %s: %v", groupID, err)
+	}
+	if metaChanged {
+		meta := MetaPayload{GroupName: cp.GroupName, GroupType: rg.GroupType, MaxMembers: cp.MaxMembers, Ordered: m.isOrderedGroup(hg)}
+		m.broadcastToGroup(hg, groupID, TypeMeta, meta, "")
+		m.notifyListeners(&Event{Type: TypeMeta, Group: groupID, Payload: meta})
+	}
+	if relaysChanged {
+		rp := RelaysPayload{Owner: rg.Owner, Relays: cp.Relays}
+		m.broadcastToGroup(hg, groupID, TypeRelays, rp, "")
+		m.notifyListeners(&Event{Type: TypeRelays, Group: groupID, Payload: rp})
+	}
+}
+
+// stopCoHosting ends our relay for a group after the owner removed us: our
+// members move to the remaining relays and we rejoin as a plain member.
+func (m *Manager) stopCoHosting(hg *hostedGroup, groupID string) {
+	m.mu.Lock()
+	if m.groups[groupID] == hg {
+		delete(m.groups, groupID)
+	}
+	m.mu.Unlock()
+
+	hg.mu.Lock()
+	if hg.cancelPing != nil {
+		hg.cancelPing()
+	}
+	owner := hg.owner
+	remaining := without(m.relayListLocked(hg), m.selfID)
+	if len(remaining) == 0 {
+		remaining = []string{owner}
+	}
+	rp := RelaysPayload{Owner: owner, Relays: remaining}
+	local := hg.localMembers(m.selfID)
+	hg.mu.Unlock()
+
+	for _, mi := range local {
+		if mi.PeerID != m.selfID {
+			go m.sendGroup(mi.PeerID, groupID, TypeRelays, rp)
+		}
+	}
+	_ = m.db.DeleteRelayedGroup(groupID)
+	m.notifyListeners(&Event{Type: TypeCoHostEnd, Group: groupID, From: owner})
+	log.Printf("GROUP: Stopped co-hosting group %s", groupID)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin*time.Duration(len(remaining)))
+		defer cancel()
+		if err := m.joinViaRelays(ctx, groupID, remaining); err != nil {
+			log.Printf("GROUP: Rejoin %s as member failed: %v", groupID, err)
+		}
+	}()
+}
+
+// closeRelayedGroup closes a co-hosted group after its owner closed it.
+func (m *Manager) closeRelayedGroup(hg *hostedGroup, groupID string) {
+	m.mu.Lock()
+	if m.groups[groupID] == hg {
+		delete(m.groups, groupID)
+	}
+	m.mu.Unlock()
+
+	hg.mu.Lock()
+	if hg.cancelPing != nil {
+		hg.cancelPing()
+	}
+	owner := hg.owner
+	local := hg.localMembers(m.selfID)
+	hg.mu.Unlock()
+
+	for _, mi := range local {
+		if mi.PeerID != m.selfID {
+			go m.sendGroup(mi.PeerID, groupID, TypeClose, Message{Type: TypeClose, Group: groupID})
+		}
+	}
+	_ = m.db.DeleteRelayedGroup(groupID)
+	_ = m.db.RemoveSubscription(owner, groupID)
+	_ = m.db.DeleteGroupMembers(groupID)
+	m.rel.forget(groupID)
+	m.notifyListeners(&Event{Type: TypeClose, Group: groupID})
+	log.Printf("GROUP: Co-hosted group %s closed by owner", groupID)
+}
+
+// ── Member side ─────────────────────────────────────────────────────────────
+
+// updateRelays records the relay set of a group we joined and moves to
+// another relay when ours is no longer one.
+func (m *Manager) updateRelays(cc *clientConn, payload any) {
+	var rp RelaysPayload
+	if !decodePayload(payload, &rp) || len(rp.Relays) == 0 {
+		return
+	}
+	cc.membersMu.Lock()
+	cc.relays = rp.Relays
+	cc.membersMu.Unlock()
+	_ = m.db.SetSubscriptionRelays(cc.ownerID(), cc.groupID, rp.Relays)
+	m.notifyListeners(&Event{Type: TypeRelays, Group: cc.groupID, From: cc.hostPeerID, Payload: rp})
+
+	if !slices.Contains(rp.Relays, cc.hostPeerID) {
+		go m.failover(cc)
+	}
+}
+
+// failover reconnects a co-hosted group through another relay after the one
+// we were connected to went away.
+func (m *Manager) failover(cc *clientConn) {
+	m.mu.Lock()
+	if m.activeConns[cc.groupID] != cc {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.activeConns, cc.groupID)
+	m.mu.Unlock()
+
+	cc.membersMu.RLock()
+	candidates := without(cc.relays, cc.hostPeerID)
+	cc.membersMu.RUnlock()
+	if len(candidates) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin*time.Duration(len(candidates)))
+	defer cancel()
+	if err := m.joinViaRelays(ctx, cc.groupID, candidates); err != nil {
+		log.Printf("GROUP: Failover for %s failed: %v", cc.groupID, err)
+		return
+	}
+	log.Printf("GROUP: Group %s moved from relay %s", cc.groupID, shortID(cc.hostPeerID))
+}
+
+// failoverFrom moves every co-hosted group we joined through peerID to
+// another relay, and drops the members connected through peerID from the
+// groups we relay.
+func (m *Manager) failoverFrom(peerID string) {
+	m.mu.RLock()
+	var conns []*clientConn
+	for _, cc := range m.activeConns {
+		cc.membersMu.RLock()
+		multi := len(cc.relays) > 1
+		cc.membersMu.RUnlock()
+		if cc.hostPeerID == peerID && multi {
+			conns = append(conns, cc)
+		}
+	}
+	var relayed []string
+	for gid, hg := range m.groups {
+		hg.mu.RLock()
+		if len(hg.remote[peerID]) > 0 {
+			relayed = append(relayed, gid)
+		}
+		hg.mu.RUnlock()
+	}
+	m.mu.RUnlock()
+
+	for _, cc := range conns {
+		go m.failover(cc)
+	}
+	for _, gid := range relayed {
+		m.dropRelayMembers(gid, peerID)
+	}
+}
+
+// joinViaRelays joins a group through the first relay that accepts,
+// trying the best-connected ones first.
+func (m *Manager) joinViaRelays(ctx context.Context, groupID string, relays []string) error {
+	err := fmt.Errorf("no relay for group %s", groupID)
+	for _, r := range m.orderRelays(relays) {
+		if r == m.selfID {
+			continue
+		}
+		if err = m.JoinRemoteGroup(ctx, r, groupID); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return err
+}
+
+// orderRelays sorts relays so that peers we are connected to come first,
+// lowest measured latency first among them.
+func (m *Manager) orderRelays(relays []string) []string {
+	out := slices.Clone(relays)
+	if m.host == nil {
+		return out
+	}
+	rank := func(id string) (bool, time.Duration) {
+		pid, err := peer.Decode(id)
+		if err != nil {
+			return false, 0
+		}
+		connected := m.host.Network().Connectedness(pid) == network.Connected
+		return connected, m.host.Peerstore().LatencyEWMA(pid)
+	}
+	slices.SortStableFunc(out, func(a, b string) int {
+		ca, la := rank(a)
+		cb, lb := rank(b)
+		switch {
+		case ca != cb:
+			if ca {
+				return -1
+			}
+			return 1
+		case la == lb:
+			return 0
+		case la == 0: // not measured yet
+			return 1
+		case lb == 0:
+			return -1
+		case la < lb:
+			return -1
+		}
+		return 1
+	})
+	return out
+}
+
+// rejoinSubscription reconnects a stored subscription, through any of its
+// relays when the group is co-hosted.
+func (m *Manager) rejoinSubscription(ctx context.Context, s storage.SubscriptionRow) error {
+	if len(s.Relays) == 0 {
+		return m.RejoinSubscription(ctx, s.HostPeerID, s.GroupID)
+	}
+	return m.joinViaRelays(ctx, s.GroupID, s.Relays)
+}
+
+// sendGroup sends one group protocol message, ignoring failures.
+func (m *Manager) sendGroup(peerID, groupID, msgType string, payload any) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+	defer cancel()
+	_, _ = m.mq.Send(ctx, peerID, "group:"+groupID+":"+msgType, payload)
+}
+
+// decodePayload converts a decoded JSON payload into v.
+func decodePayload(payload any, v any) bool {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+// without returns ids minus drop.
+func without(ids []string, drop string) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != drop {
+			out = append(out, id)
+		}
+	}
+	return out
+}
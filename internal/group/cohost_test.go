@@ -0,0 +1,168 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bus delivers group messages between in-process managers, JSON round-tripping
+// payloads like the real transport does.
+type bus struct {
+	mu    sync.Mutex
+	peers map[string]*Manager
+	local map[string][]string // peer → locally published topics
+}
+
+type busTransport struct {
+	b    *bus
+	self string
+}
+
+func (t busTransport) Send(_ context.Context, peerID, topic string, payload any) (string, error) {
+	t.b.mu.Lock()
+	target := t.b.peers[peerID]
+	t.b.mu.Unlock()
+	if target == nil {
+		return "", fmt.Errorf("peer %s unreachable", peerID)
+	}
+	parts := strings.SplitN(topic, ":", 3)
+	raw, _ := json.Marshal(payload)
+	var decoded any
+	_ = json.Unmarshal(raw, &decoded)
+	go target.handleMQMessage(t.self, parts[1], parts[2], decoded)
+	return "", nil
+}
+
+func (t busTransport) SubscribeTopic(string, func(from, topic string, payload any)) func() {
+	return func() {}
+}
+
+func (t busTransport) PublishLocal(topic, _ string, _ any) {
+	t.b.mu.Lock()
+	t.b.local[t.self] = append(t.b.local[t.self], topic)
+	t.b.mu.Unlock()
+}
+
+func (b *bus) join(t *testing.T, id string) *Manager {
+	t.Helper()
+	m := NewTestManager(openTestDB(t), id, TestManagerOpts{MQ: busTransport{b: b, self: id}})
+	t.Cleanup(func() { m.Close() })
+	b.mu.Lock()
+	b.peers[id] = m
+	b.mu.Unlock()
+	return m
+}
+
+func (b *bus) published(id, topic string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Contains(b.local[id], topic)
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func hasMember(members []MemberInfo, peerID, relay string) bool {
+	return slices.ContainsFunc(members, func(mi MemberInfo) bool { return mi.PeerID == peerID && mi.Relay == relay })
+}
+
+// ── Scenario: a co-host relays members and messages ────────────────────────
+
+func TestScenario_CoHostRelaysGroup(t *testing.T) {
+	// Given an owner hosting a group that relay-peer and alice joined
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	owner := b.join(t, "owner")
+	relay := b.join(t, "relay-peer")
+	b.join(t, "alice")
+	b.join(t, "bob")
+	if err := owner.CreateGroup("g1", "Jam", "template", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	owner.SimulateJoin("relay-peer", "g1")
+	owner.SimulateJoin("alice", "g1")
+	relay.SetActiveConn("g1", "owner", "template")
+
+	// When the owner makes relay-peer a co-host
+	if err := owner.AddCoHost("g1", "relay-peer"); err != nil {
+		t.Fatalf("add co-host: %v", err)
+	}
+
+	// Then relay-peer relays the group and persists that
+	eventually(t, "relay-peer to co-host", func() bool {
+		relays := relay.GroupRelays("g1")
+		return len(relays) == 2 && relays[0] == "owner"
+	})
+	if rg, _ := relay.db.ListRelayedGroups(); len(rg) != 1 || rg[0].Owner != "owner" {
+		t.Fatalf("relayed groups = %+v", rg)
+	}
+	if relay.IsGroupConnected("g1") {
+		t.Fatal("co-host should no longer be a plain member")
+	}
+	if g, _ := owner.db.GetGroup("g1"); !slices.Equal(g.CoHosts, []string{"relay-peer"}) {
+		t.Fatalf("co-hosts = %v", g.CoHosts)
+	}
+
+	// When bob joins through the co-host
+	relay.SimulateJoin("bob", "g1")
+
+	// Then the owner sees bob, connected through relay-peer
+	eventually(t, "owner to see bob", func() bool {
+		return hasMember(owner.HostedGroupMembers("g1"), "bob", "relay-peer")
+	})
+	// And the co-host sees alice, connected through the owner
+	eventually(t, "co-host to see alice", func() bool {
+		return hasMember(relay.HostedGroupMembers("g1"), "alice", "owner")
+	})
+
+	// When bob sends a message to his relay
+	relay.handleMQMessage("bob", "g1", TypeMsg, map[string]any{"text": "hi"})
+
+	// Then it reaches the owner as coming from bob
+	eventually(t, "message at owner", func() bool { return b.published("owner", "group:g1:"+TypeMsg) })
+
+	// When the owner removes the co-host
+	if err := owner.RemoveCoHost("g1", "relay-peer"); err != nil {
+		t.Fatalf("remove co-host: %v", err)
+	}
+
+	// Then relay-peer stops relaying and bob is gone from the owner's list
+	eventually(t, "co-host to stop", func() bool { return relay.GroupRelays("g1") == nil })
+	if rg, _ := relay.db.ListRelayedGroups(); len(rg) != 0 {
+		t.Fatalf("relayed groups after removal = %+v", rg)
+	}
+	if hasMember(owner.HostedGroupMembers("g1"), "bob", "relay-peer") {
+		t.Fatal("owner still lists bob through removed co-host")
+	}
+}
+
+// ── Scenario: only the owner manages co-hosts ──────────────────────────────
+
+func TestScenario_AddCoHostRules(t *testing.T) {
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	owner := b.join(t, "owner")
+	_ = owner.CreateGroup("g1", "Jam", "template", "", 0)
+
+	if err := owner.AddCoHost("g1", "stranger"); err == nil {
+		t.Fatal("non-member accepted as co-host")
+	}
+	if err := owner.AddCoHost("g1", "owner"); err == nil {
+		t.Fatal("owner accepted as its own co-host")
+	}
+	if err := owner.RemoveCoHost("g1", "stranger"); err == nil {
+		t.Fatal("removed a co-host that was never added")
+	}
+}
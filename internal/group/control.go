@@ -1,8 +1,12 @@
 package group
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 // SendControl sends a typed control message to all members of a group.
@@ -86,6 +90,42 @@ func ParseMembers(payload any, selfID string, previous []string) (members []stri
 	return members, hasNew
 }
 
+// ParseSnapshot extracts the state from a join-time snapshot (a "state" event
+// sent by the host to a new member) into dest. Returns false if the payload is
+// not a snapshot.
+func ParseSnapshot(payload any, dest any) bool {
+	mp, ok := payload.(map[string]any)
+	if !ok {
+		if sp, ok := payload.(SnapshotPayload); ok {
+			mp = map[string]any{"snapshot": sp.Snapshot, "state": sp.State}
+		} else {
+			return false
+		}
+	}
+	if snap, _ := mp["snapshot"].(bool); !snap {
+		return false
+	}
+	data, err := json.Marshal(mp["state"])
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// sendSnapshot asks the type handler for a state snapshot and sends it to a
+// newly joined member.
+func (m *Manager) sendSnapshot(sp StateProvider, groupID, peerID string) {
+	state := sp.SnapshotState(groupID)
+	if state == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+	defer cancel()
+	if _, err := m.mq.Send(ctx, peerID, "group:"+groupID+":"+TypeState, SnapshotPayload{Snapshot: true, State: state}); err != nil {
+		log.Printf("GROUP: Snapshot to %s in %s failed: %v", shortID(peerID), groupID, err)
+	}
+}
+
 // StateStore provides JSON persistence for group type state.
 // Each group type can use this to save/load its state across restarts.
 type StateStore struct {
@@ -124,3 +164,11 @@ func (s *StateStore) Load(key string, dest any) bool {
 	}
 	return json.Unmarshal(data, dest) == nil
 }
+
+// Delete removes the persisted state for key; a missing file is not an error.
+func (s *StateStore) Delete(key string) error {
+	if s == nil {
+		return nil
+	}
+	return removeFile(s.dir, key+".json")
+}
@@ -0,0 +1,427 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// Ownership handover: a group can change owner instead of dying with it.
+//
+// The owner hands a group to one of its members with TransferOwnership. The
+// successor creates the group on its side from the HandoverPayload and
+// confirms with TypeOwner; only then does the old owner drop its copy and
+// send TypeOwner to its members, which rejoin the new owner. When the old
+// owner had joined its own group it rejoins as a plain member.
+//
+// When the owner goes offline and stays offline for OwnerElectionDelay,
+// every member works out the same successor: the longest-joined member not
+// known to be offline, lowest peer ID on a tie. That member takes the group
+// over from what it knows as a member and sends TypeOwner to the others,
+// which accept it only from the successor they elected themselves. When the
+// old owner comes back, the new owner tells it, and it gives the group up.
+//
+// Co-hosted groups keep running on their relays while the owner is away and
+// are never elected over; they must drop their co-hosts before a handover.
+// Types whose handler does not allow co-hosts keep state on the owner and
+// cannot change owner at all.
+
+// canHandOverLocked reports why a group we own cannot change owner right
+// now, or nil. Caller holds hg.mu.
+func (m *Manager) canHandOverLocked(hg *hostedGroup) error {
+	switch {
+	case hg.owner != "":
+		return fmt.Errorf("only the owner can hand a group over")
+	case !m.canCoHost(hg.info.GroupType):
+		return fmt.Errorf("group type %q keeps its state on the owner and cannot be handed over", hg.info.GroupType)
+	case len(hg.relays) > 0:
+		return fmt.Errorf("remove the co-hosts of %s before handing it over", hg.info.ID)
+	}
+	return nil
+}
+
+// CanHandOver reports whether a group we host may be handed to a member.
+func (m *Manager) CanHandOver(groupID string) bool {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return false
+	}
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
+	return m.canHandOverLocked(hg) == nil
+}
+
+// TransferOwnership asks a member of a group we own to take it over. It
+// returns once the successor has the request; the group moves when the
+// successor confirms.
+func (m *Manager) TransferOwnership(groupID, peerID string) error {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	hg.mu.Lock()
+	if err := m.canHandOverLocked(hg); err != nil {
+		hg.mu.Unlock()
+		return err
+	}
+	if _, ok := hg.members[peerID]; !ok {
+		hg.mu.Unlock()
+		return fmt.Errorf("peer %s not in group %s", shortID(peerID), groupID)
+	}
+	hp := HandoverPayload{
+		GroupName:    hg.info.Name,
+		GroupType:    hg.info.GroupType,
+		GroupContext: hg.info.GroupContext,
+		MaxMembers:   hg.info.MaxMembers,
+		DefaultRole:  hg.info.DefaultRole,
+		Roles:        hg.info.Roles,
+		Ordered:      hg.info.Ordered,
+		Members:      hg.memberList(m.selfID),
+	}
+	hg.handingTo = peerID
+	hg.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+	defer cancel()
+	if _, err := m.mq.Send(ctx, peerID, "group:"+groupID+":"+TypeHandover, hp); err != nil {
+		hg.mu.Lock()
+		hg.handingTo = ""
+		hg.mu.Unlock()
+		return fmt.Errorf("hand over to %s: %w", shortID(peerID), err)
+	}
+
+	log.Printf("GROUP: Handing group %s over to %s", groupID, shortID(peerID))
+	return nil
+}
+
+// OwnershipHistory returns the owner changes of a group we know of, latest first.
+func (m *Manager) OwnershipHistory(groupID string) ([]storage.OwnershipRecord, error) {
+	return m.db.OwnershipHistory(groupID)
+}
+
+// takeOver turns our membership of a group into ownership, with the state
+// the previous owner handed over or, after an election, what we know of the
+// group as a member.
+func (m *Manager) takeOver(cc *clientConn, hp HandoverPayload, previous, reason string) {
+	groupID := cc.groupID
+	m.mu.Lock()
+	if _, exists := m.groups[groupID]; exists {
+		m.mu.Unlock()
+		return
+	}
+	if m.activeConns[groupID] == cc {
+		delete(m.activeConns, groupID)
+	}
+	m.mu.Unlock()
+	cc.closeTopic()
+
+	vol := m.isVolatileType(hp.GroupType)
+	if err := m.db.CreateGroup(groupID, hp.GroupName, m.selfID, hp.GroupType, hp.GroupContext, hp.MaxMembers, vol); err != nil {
+		log.Printf("GROUP: Take over %s: %v", groupID, err)
+		return
+	}
+	if hp.DefaultRole != "" {
+		_ = m.db.SetDefaultRole(groupID, hp.DefaultRole)
+	}
+	if len(hp.Roles) > 0 {
+		_ = m.db.SetGroupRoles(groupID, hp.Roles)
+	}
+	if hp.Ordered {
+		_ = m.db.SetGroupOrdered(groupID, true)
+	}
+	_ = m.db.SetHostJoined(groupID, true)
+	g, err := m.db.GetGroup(groupID)
+	if err != nil {
+		log.Printf("GROUP: Take over %s: %v", groupID, err)
+		return
+	}
+
+	// We stay the longest-joined member of the group.
+	joinedAt := nowMillis()
+	if i := slices.IndexFunc(hp.Members, func(mi MemberInfo) bool { return mi.PeerID == m.selfID }); i >= 0 && hp.Members[i].JoinedAt > 0 {
+		joinedAt = hp.Members[i].JoinedAt
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	hg := &hostedGroup{
+		info:         g,
+		members:      make(map[string]*memberMeta),
+		hostJoined:   true,
+		hostJoinedAt: joinedAt,
+		cancelPing:   cancel,
+	}
+	m.mu.Lock()
+	m.groups[groupID] = hg
+	m.mu.Unlock()
+	go m.pingGroupLoop(ctx, groupID)
+
+	if !vol {
+		_ = m.db.UpsertGroupMembers(groupID, membersToStorage(hp.Members))
+	}
+	_ = m.db.RemoveSubscription(previous, groupID)
+	m.recordOwnership(groupID, previous, m.selfID, reason)
+
+	// On a handover the previous owner moves its members once we confirm;
+	// after an election we tell them ourselves.
+	op := OwnerPayload{Owner: m.selfID, Previous: previous, Reason: reason}
+	targets := []string{previous}
+	if reason == OwnerElection {
+		targets = targets[:0]
+		for _, mi := range hp.Members {
+			if mi.PeerID != m.selfID && mi.PeerID != previous {
+				targets = append(targets, mi.PeerID)
+			}
+		}
+	}
+	for _, p := range targets {
+		go m.sendGroup(p, groupID, TypeOwner, op)
+	}
+	m.notifyListeners(&Event{Type: TypeOwner, Group: groupID, From: previous, Payload: op})
+
+	log.Printf("GROUP: Took over group %s from %s (%s)", groupID, shortID(previous), reason)
+}
+
+// handleOwnerChange handles TypeOwner: the successor confirming our
+// handover, the member elected while we were offline claiming a group we
+// own, or the owner of a group we joined announcing its successor.
+func (m *Manager) handleOwnerChange(from, groupID string, payload any) {
+	var op OwnerPayload
+	if !decodePayload(payload, &op) || op.Owner == "" || op.Owner == m.selfID {
+		return
+	}
+	m.mu.RLock()
+	hg := m.groups[groupID]
+	cc := m.activeConns[groupID]
+	m.mu.RUnlock()
+
+	switch {
+	case hg != nil:
+		hg.mu.RLock()
+		owned, handingTo := hg.owner == "", hg.handingTo
+		hg.mu.RUnlock()
+		confirmed := op.Reason == OwnerTransfer && from == op.Owner && from == handingTo
+		elected := op.Reason == OwnerElection && from == op.Owner && op.Previous == m.selfID && m.isStoredMember(groupID, from)
+		if owned && (confirmed || elected) {
+			m.releaseGroup(hg, groupID, op)
+		}
+
+	case cc != nil:
+		previous := cc.ownerID()
+		if op.Previous != previous {
+			return
+		}
+		fromOwner := from == previous
+		elected := op.Reason == OwnerElection && from == op.Owner && from == m.electedSuccessor(cc)
+		if fromOwner || elected {
+			m.followOwner(cc, op)
+		}
+	}
+}
+
+// releaseGroup drops a group we owned after it moved to op.Owner, sends our
+// members there and rejoins it as a member when we had joined it.
+func (m *Manager) releaseGroup(hg *hostedGroup, groupID string, op OwnerPayload) {
+	m.mu.Lock()
+	if m.groups[groupID] == hg {
+		delete(m.groups, groupID)
+	}
+	m.mu.Unlock()
+
+	hg.mu.Lock()
+	if hg.cancelPing != nil {
+		hg.cancelPing()
+	}
+	hg.closeTopicLocked()
+	local := hg.localMembers(m.selfID)
+	joined := hg.hostJoined
+	groupType := hg.info.GroupType
+	hg.mu.Unlock()
+
+	op.Previous = m.selfID
+	for _, mi := range local {
+		if mi.PeerID != m.selfID && mi.PeerID != op.Owner {
+			go m.sendGroup(mi.PeerID, groupID, TypeOwner, op)
+		}
+	}
+	if err := m.db.DeleteGroup(groupID); err != nil {
+		log.Printf("GROUP: Failed to delete group %s from DB: %v", groupID, err)
+	}
+	if !joined {
+		_ = m.db.DeleteGroupMembers(groupID)
+	}
+	m.rel.forget(groupID)
+	m.recordOwnership(groupID, m.selfID, op.Owner, op.Reason)
+	m.notifyListeners(&Event{Type: TypeOwner, Group: groupID, From: op.Owner, Payload: op})
+	if h := m.handlerForType(groupType); h != nil {
+		h.OnClose(groupID)
+	}
+	log.Printf("GROUP: Group %s now owned by %s (%s)", groupID, shortID(op.Owner), op.Reason)
+
+	if joined {
+		go m.joinNewOwner(groupID, op.Owner)
+	}
+}
+
+// followOwner moves our membership of a group to its new owner.
+func (m *Manager) followOwner(cc *clientConn, op OwnerPayload) {
+	groupID := cc.groupID
+	m.mu.Lock()
+	if m.activeConns[groupID] != cc {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.activeConns, groupID)
+	m.mu.Unlock()
+	cc.closeTopic()
+
+	// Keep the subscription, under the new owner, so we reconnect to it
+	// later if the first join fails.
+	previous := cc.ownerID()
+	if subs, err := m.db.ListSubscriptions(); err == nil {
+		for _, s := range subs {
+			if s.HostPeerID == previous && s.GroupID == groupID {
+				_ = m.db.RemoveSubscription(previous, groupID)
+				_ = m.db.AddSubscription(op.Owner, groupID, s.GroupName, s.GroupType, s.MaxMembers, s.Volatile, s.Role, m.resolvePeerName(op.Owner))
+			}
+		}
+	}
+	m.recordOwnership(groupID, previous, op.Owner, op.Reason)
+	m.notifyListeners(&Event{Type: TypeOwner, Group: groupID, From: op.Owner, Payload: op})
+	log.Printf("GROUP: Group %s moved from %s to %s (%s)", groupID, shortID(previous), shortID(op.Owner), op.Reason)
+
+	go m.joinNewOwner(groupID, op.Owner)
+}
+
+func (m *Manager) joinNewOwner(groupID, owner string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
+	defer cancel()
+	if err := m.JoinRemoteGroup(ctx, owner, groupID); err != nil {
+		log.Printf("GROUP: Join %s on new owner %s failed: %v", groupID, shortID(owner), err)
+	}
+}
+
+func (m *Manager) recordOwnership(groupID, from, to, reason string) {
+	if err := m.db.RecordOwnership(storage.OwnershipRecord{GroupID: groupID, From: from, To: to, Reason: reason, At: nowMillis()}); err != nil {
+		log.Printf("GROUP: %v", err)
+	}
+}
+
+// ── Election ────────────────────────────────────────────────────────────────
+
+// scheduleElections starts the owner election for the groups we joined from
+// peerID, to run when it has stayed offline for the election delay.
+func (m *Manager) scheduleElections(peerID string) {
+	m.mu.RLock()
+	var conns []*clientConn
+	for _, cc := range m.activeConns {
+		if cc.ownerID() == peerID {
+			conns = append(conns, cc)
+		}
+	}
+	delay := m.electionDelay
+	m.mu.RUnlock()
+
+	for _, cc := range conns {
+		time.AfterFunc(delay, func() { m.runElection(cc) })
+	}
+}
+
+// runElection takes a group over when its owner is still offline and we are
+// the member everyone elects.
+func (m *Manager) runElection(cc *clientConn) {
+	m.mu.RLock()
+	current := m.activeConns[cc.groupID] == cc
+	m.mu.RUnlock()
+	owner := cc.ownerID()
+	if !current || !m.knownOffline(owner) || !m.canCoHost(cc.groupType) {
+		return
+	}
+	cc.membersMu.RLock()
+	coHosted := len(cc.relays) > 1
+	members := slices.Clone(cc.members)
+	cc.membersMu.RUnlock()
+	if coHosted || m.electedSuccessor(cc) != m.selfID {
+		return
+	}
+
+	m.mu.RLock()
+	ordered := cc.ordered
+	m.mu.RUnlock()
+	hp := HandoverPayload{GroupType: cc.groupType, GroupContext: cc.groupContext, Ordered: ordered, Members: members}
+	if subs, err := m.db.ListSubscriptions(); err == nil {
+		for _, s := range subs {
+			if s.GroupID == cc.groupID && s.HostPeerID == owner {
+				hp.GroupName, hp.MaxMembers = s.GroupName, s.MaxMembers
+			}
+		}
+	}
+	if hp.GroupName == "" {
+		hp.GroupName = cc.groupID
+	}
+	log.Printf("GROUP: Owner %s of %s offline, taking the group over", shortID(owner), cc.groupID)
+	m.takeOver(cc, hp, owner, OwnerElection)
+}
+
+// electedSuccessor returns the member that takes a group over when its owner
+// is gone: the longest-joined member not known to be offline, lowest peer
+// ID on a tie.
+func (m *Manager) electedSuccessor(cc *clientConn) string {
+	owner := cc.ownerID()
+	cc.membersMu.RLock()
+	defer cc.membersMu.RUnlock()
+	var best *MemberInfo
+	for i, mi := range cc.members {
+		if mi.PeerID == owner || (mi.PeerID != m.selfID && m.knownOffline(mi.PeerID)) {
+			continue
+		}
+		if best == nil || mi.JoinedAt < best.JoinedAt || (mi.JoinedAt == best.JoinedAt && mi.PeerID < best.PeerID) {
+			best = &cc.members[i]
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.PeerID
+}
+
+// knownOffline reports whether the last announce for peerID said it was offline.
+func (m *Manager) knownOffline(peerID string) bool {
+	m.presenceMu.Lock()
+	defer m.presenceMu.Unlock()
+	online, known := m.presence[peerID]
+	return known && !online
+}
+
+// notifyFormerOwner tells a peer that comes back online about the groups we
+// took over from it by election, so it gives them up.
+func (m *Manager) notifyFormerOwner(peerID string) {
+	m.mu.RLock()
+	var owned []string
+	for gid, hg := range m.groups {
+		hg.mu.RLock()
+		if _, member := hg.members[peerID]; hg.owner == "" && !member {
+			owned = append(owned, gid)
+		}
+		hg.mu.RUnlock()
+	}
+	m.mu.RUnlock()
+
+	for _, gid := range owned {
+		history, err := m.db.OwnershipHistory(gid)
+		if err != nil || len(history) == 0 {
+			continue
+		}
+		if r := history[0]; r.From == peerID && r.To == m.selfID && r.Reason == OwnerElection {
+			go m.sendGroup(peerID, gid, TypeOwner, OwnerPayload{Owner: m.selfID, Previous: peerID, Reason: OwnerElection})
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package group
+
+import (
+	"testing"
+	"time"
+)
+
+func subscribedTo(m *Manager, host, groupID string) bool {
+	subs, _ := m.db.ListSubscriptions()
+	for _, s := range subs {
+		if s.HostPeerID == host && s.GroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+func ownedBy(m *Manager, groupID, owner string) bool {
+	g, err := m.db.GetGroup(groupID)
+	return err == nil && g.Owner == owner
+}
+
+// ── Scenario: the owner hands a group over ─────────────────────────────────
+
+func TestScenario_TransferOwnership(t *testing.T) {
+	// Given an owner hosting a group that heir and alice joined
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	owner := b.join(t, "owner")
+	heir := b.join(t, "heir")
+	alice := b.join(t, "alice")
+	if err := owner.CreateGroup("g1", "Jam", "template", "blog", 8); err != nil {
+		t.Fatal(err)
+	}
+	_ = owner.SetGroupRoles("g1", []string{"editor", "viewer"})
+	owner.SimulateJoin("heir", "g1")
+	owner.SimulateJoin("alice", "g1")
+	for _, m := range []*Manager{heir, alice} {
+		m.SetActiveConn("g1", "owner", "template")
+		_ = m.db.AddSubscription("owner", "g1", "Jam", "template", 8, false, "member", "")
+	}
+
+	if err := owner.TransferOwnership("g1", "stranger"); err == nil {
+		t.Fatal("handed over to a non-member")
+	}
+
+	// When the owner hands the group to heir
+	if err := owner.TransferOwnership("g1", "heir"); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+
+	// Then heir hosts it with the same settings and the owner drops it
+	eventually(t, "heir to own the group", func() bool { return ownedBy(heir, "g1", "heir") })
+	g, _ := heir.db.GetGroup("g1")
+	if g.Name != "Jam" || g.GroupContext != "blog" || g.MaxMembers != 8 || len(g.Roles) != 2 {
+		t.Fatalf("handed over group = %+v", g)
+	}
+	if !heir.HostInGroup("g1") || heir.IsGroupConnected("g1") {
+		t.Fatal("heir should host and be in the group, not be a plain member")
+	}
+	eventually(t, "owner to drop the group", func() bool { return !ownedBy(owner, "g1", "owner") })
+
+	// And alice follows the group to heir
+	eventually(t, "alice to follow", func() bool { return subscribedTo(alice, "heir", "g1") })
+	if subscribedTo(alice, "owner", "g1") {
+		t.Fatal("alice still subscribed to the old owner")
+	}
+
+	// And everyone recorded the change
+	for _, m := range []*Manager{owner, heir, alice} {
+		eventually(t, m.selfID+" to record the change", func() bool {
+			h, _ := m.OwnershipHistory("g1")
+			return len(h) == 1 && h[0].From == "owner" && h[0].To == "heir" && h[0].Reason == OwnerTransfer
+		})
+	}
+}
+
+func TestTransferOwnership_refusesCoHostedGroup(t *testing.T) {
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	owner := b.join(t, "owner")
+	b.join(t, "relay-peer").SetActiveConn("g1", "owner", "template")
+	_ = owner.CreateGroup("g1", "Jam", "template", "", 0)
+	owner.SimulateJoin("relay-peer", "g1")
+	if err := owner.AddCoHost("g1", "relay-peer"); err != nil {
+		t.Fatal(err)
+	}
+	if owner.CanHandOver("g1") || owner.TransferOwnership("g1", "relay-peer") == nil {
+		t.Fatal("co-hosted group handed over")
+	}
+}
+
+// ── Scenario: members elect a new owner ────────────────────────────────────
+
+func TestScenario_OwnerElection(t *testing.T) {
+	// Given a group whose owner hosts alice (joined first) and bob
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	owner := b.join(t, "owner")
+	alice := b.join(t, "alice")
+	bob := b.join(t, "bob")
+	_ = owner.CreateGroup("g1", "Jam", "template", "", 0)
+	owner.SimulateJoin("alice", "g1")
+	owner.SimulateJoin("bob", "g1")
+	members := []MemberInfo{
+		{PeerID: "alice", Role: "viewer", JoinedAt: 10},
+		{PeerID: "bob", Role: "viewer", JoinedAt: 20},
+	}
+	for _, m := range []*Manager{alice, bob} {
+		m.electionDelay = 10 * time.Millisecond
+		m.SetActiveConn("g1", "owner", "template")
+		m.SetActiveConnMembers("g1", members)
+		_ = m.db.AddSubscription("owner", "g1", "Jam", "template", 0, false, "member", "")
+	}
+	if got := bob.electedSuccessor(bob.activeConns["g1"]); got != "alice" {
+		t.Fatalf("elected %q, want alice", got)
+	}
+
+	// When the owner goes offline
+	b.mu.Lock()
+	delete(b.peers, "owner")
+	b.mu.Unlock()
+	offline := map[string]any{"peerID": "owner", "reachable": false, "offline": true}
+	alice.SimulatePeerAnnounce(offline)
+	bob.SimulatePeerAnnounce(offline)
+
+	// Then alice, the longest-joined member, takes the group over
+	eventually(t, "alice to own the group", func() bool { return ownedBy(alice, "g1", "alice") })
+	if ownedBy(bob, "g1", "bob") {
+		t.Fatal("bob took the group over too")
+	}
+	// And bob follows her
+	eventually(t, "bob to follow", func() bool {
+		h, _ := bob.OwnershipHistory("g1")
+		return subscribedTo(bob, "alice", "g1") && len(h) == 1 && h[0].Reason == OwnerElection
+	})
+
+	// When the old owner comes back
+	b.mu.Lock()
+	b.peers["owner"] = owner
+	b.mu.Unlock()
+	alice.SimulatePeerAnnounce(map[string]any{"peerID": "owner", "reachable": true})
+
+	// Then it gives the group up
+	eventually(t, "old owner to give up", func() bool {
+		h, _ := owner.OwnershipHistory("g1")
+		return !ownedBy(owner, "g1", "owner") && len(h) == 1 && h[0].To == "alice"
+	})
+}
+
+func TestOwnerElection_ignoresUnelectedClaim(t *testing.T) {
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	bob := b.join(t, "bob")
+	bob.SetActiveConn("g1", "owner", "template")
+	bob.SetActiveConnMembers("g1", []MemberInfo{
+		{PeerID: "alice", JoinedAt: 10},
+		{PeerID: "bob", JoinedAt: 20},
+		{PeerID: "mallory", JoinedAt: 30},
+	})
+
+	bob.handleMQMessage("mallory", "g1", TypeOwner, map[string]any{"owner": "mallory", "previous": "owner", "reason": OwnerElection})
+	if host, _ := bob.ActiveGroup("g1"); host != "owner" {
+		t.Fatalf("followed an unelected claim to %q", host)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 // CreateGroup creates a new hosted group. Source links the group to its creator
@@ -106,6 +108,7 @@ func (m *Manager) RestoreGroup(groupID string) error {
 		info:       g,
 		members:    make(map[string]*memberMeta),
 		cancelPing: cancel,
+		relays:     g.CoHosts,
 	}
 	m.groups[groupID] = hg
 	m.mu.Unlock()
@@ -124,6 +127,10 @@ func (m *Manager) CloseGroup(groupID string) error {
 		m.mu.Unlock()
 		return fmt.Errorf("group not found: %s", groupID)
 	}
+	if hg.owner != "" {
+		m.mu.Unlock()
+		return fmt.Errorf("only the owner can close a co-hosted group")
+	}
 	groupType := hg.info.GroupType
 	delete(m.groups, groupID)
 	m.mu.Unlock()
@@ -132,16 +139,19 @@ func (m *Manager) CloseGroup(groupID string) error {
 	if hg.cancelPing != nil {
 		hg.cancelPing()
 	}
-	members := hg.memberList(m.selfID)
+	hg.closeTopicLocked()
+	targets := slices.Clone(hg.relays)
+	for _, mi := range hg.localMembers(m.selfID) {
+		targets = append(targets, mi.PeerID)
+	}
 	hg.mu.Unlock()
 
-	for _, mi := range members {
-		if mi.PeerID == m.selfID {
+	for _, pid := range targets {
+		if pid == m.selfID {
 			continue
 		}
-		pid := mi.PeerID
 		go func(p string) {
-			ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 			defer cancel()
 			_, _ = m.mq.Send(ctx, p, "group:"+groupID+":"+TypeClose, Message{Type: TypeClose, Group: groupID})
 		}(pid)
@@ -151,6 +161,7 @@ func (m *Manager) CloseGroup(groupID string) error {
 		log.Printf("GROUP: Failed to delete group %s from DB: %v", groupID, err)
 	}
 	_ = m.db.DeleteGroupMembers(groupID)
+	m.rel.forget(groupID)
 
 	m.notifyListeners(&Event{Type: TypeClose, Group: groupID})
 
@@ -181,22 +192,38 @@ func (m *Manager) KickMember(groupID, peerID string) error {
 	_, ok := hg.members[peerID]
 	if ok {
 		delete(hg.members, peerID)
+		delete(hg.buckets, peerID)
+	}
+	relay := ""
+	if !ok && hg.owner == "" {
+		for r, list := range hg.remote {
+			if slices.ContainsFunc(list, func(mi MemberInfo) bool { return mi.PeerID == peerID }) {
+				relay = r
+			}
+		}
 	}
 	members := hg.memberList(m.selfID)
 	groupType := hg.info.GroupType
 	hg.mu.Unlock()
 
+	if relay != "" {
+		// Connected through a co-host: it does the kicking.
+		go m.sendGroup(relay, groupID, TypeKick, KickPayload{PeerID: peerID})
+		log.Printf("GROUP: Kicking %s from %s via %s", shortID(peerID), groupID, shortID(relay))
+		return nil
+	}
 	if !ok {
 		return fmt.Errorf("member not found: %s", peerID)
 	}
 
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 		defer cancel()
 		_, _ = m.mq.Send(ctx, peerID, "group:"+groupID+":"+TypeClose, Message{Type: TypeClose, Group: groupID})
 	}()
 
 	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+	m.syncRelayMembers(hg, groupID)
 	m.notifyListeners(&Event{Type: "leave", Group: groupID, From: peerID, Payload: MembersPayload{Members: members}})
 
 	if !m.isVolatileType(groupType) {
@@ -221,6 +248,7 @@ func (m *Manager) SetMaxMembers(groupID string, max int) error {
 	hg.info.MaxMembers = max
 	meta := MetaPayload{GroupName: hg.info.Name, GroupType: hg.info.GroupType, MaxMembers: max}
 	hg.mu.Unlock()
+	meta.Ordered = m.isOrderedGroup(hg)
 
 	if err := m.db.SetMaxMembers(groupID, max); err != nil {
 		return fmt.Errorf("update max members: %w", err)
@@ -228,6 +256,7 @@ func (m *Manager) SetMaxMembers(groupID string, max int) error {
 
 	m.broadcastToGroup(hg, groupID, TypeMeta, meta, "")
 	m.notifyListeners(&Event{Type: TypeMeta, Group: groupID, Payload: meta})
+	m.announceSettings(hg, groupID)
 
 	log.Printf("GROUP: Set max members for %s to %d", groupID, max)
 	return nil
@@ -256,9 +285,10 @@ func (m *Manager) UpdateGroupMeta(groupID, name string, maxMembers int) error {
 		return fmt.Errorf("update group: %w", err)
 	}
 
-	meta := MetaPayload{GroupName: name, GroupType: groupType, MaxMembers: maxMembers}
+	meta := MetaPayload{GroupName: name, GroupType: groupType, MaxMembers: maxMembers, Ordered: m.isOrderedGroup(hg)}
 	m.broadcastToGroup(hg, groupID, TypeMeta, meta, "")
 	m.notifyListeners(&Event{Type: TypeMeta, Group: groupID, Payload: meta})
+	m.announceSettings(hg, groupID)
 
 	log.Printf("GROUP: Updated meta for %s — name=%q maxMembers=%d", groupID, name, maxMembers)
 	return nil
@@ -297,6 +327,7 @@ func (m *Manager) SetDefaultRole(groupID, role string) error {
 	hg.mu.Lock()
 	hg.info.DefaultRole = role
 	hg.mu.Unlock()
+	m.announceSettings(hg, groupID)
 	return nil
 }
 
@@ -319,6 +350,35 @@ func (m *Manager) SetGroupRoles(groupID string, roles []string) error {
 	return nil
 }
 
+// SetOrdered turns ordered delivery on or off for a hosted group and tells
+// the members, who start or stop sequencing what they send. Groups whose
+// type is always ordered stay ordered.
+func (m *Manager) SetOrdered(groupID string, ordered bool) error {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	if err := m.db.SetGroupOrdered(groupID, ordered); err != nil {
+		return err
+	}
+
+	hg.mu.Lock()
+	hg.info.Ordered = ordered
+	meta := MetaPayload{GroupName: hg.info.Name, GroupType: hg.info.GroupType, MaxMembers: hg.info.MaxMembers}
+	hg.mu.Unlock()
+	meta.Ordered = m.isOrderedGroup(hg)
+
+	m.broadcastToGroup(hg, groupID, TypeMeta, meta, "")
+	m.notifyListeners(&Event{Type: TypeMeta, Group: groupID, Payload: meta})
+	m.announceSettings(hg, groupID)
+
+	log.Printf("GROUP: Set ordered delivery for %s to %v", groupID, meta.Ordered)
+	return nil
+}
+
 // SetMemberRole updates a member's role in a hosted group, persists it, and broadcasts the change.
 func (m *Manager) SetMemberRole(groupID, peerID, role string) error {
 	m.mu.RLock()
@@ -345,6 +405,7 @@ func (m *Manager) SetMemberRole(groupID, peerID, role string) error {
 	}
 
 	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+	m.syncRelayMembers(hg, groupID)
 	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: members}})
 
 	return nil
@@ -399,6 +460,7 @@ func (m *Manager) JoinOwnGroup(groupID string) error {
 	}
 
 	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: memberList}, "")
+	m.syncRelayMembers(hg, groupID)
 	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: memberList}})
 
 	if h := m.handlerForGroup(groupID); h != nil {
@@ -435,6 +497,7 @@ func (m *Manager) LeaveOwnGroup(groupID string) error {
 	_ = m.db.SetHostJoined(groupID, false)
 
 	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: memberList}, "")
+	m.syncRelayMembers(hg, groupID)
 	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: memberList}})
 
 	if h := m.handlerForGroup(groupID); h != nil {
@@ -468,15 +531,32 @@ func (m *Manager) SendToGroupAsHost(groupID string, payload any) error {
 		return fmt.Errorf("group not found: %s", groupID)
 	}
 
-	m.broadcastToGroup(hg, groupID, TypeMsg, payload, "")
+	wire := payload
+	if m.isOrderedGroup(hg) {
+		wire = wrapReliable(m.rel.nextReliable(groupID, m.selfID, TypeMsg, payload))
+	}
+
+	hg.mu.RLock()
+	gt := hg.topic
+	hg.mu.RUnlock()
+	if gt != nil {
+		if err := gt.send(TypeMsg, nil, wire); err != nil {
+			return err
+		}
+	} else {
+		m.broadcastToGroup(hg, groupID, TypeMsg, wire, "")
+		m.forwardToRelays(hg, groupID, m.selfID, TypeMsg, wire)
+	}
 	m.notifyListeners(&Event{Type: TypeMsg, Group: groupID, From: m.selfID, Payload: payload})
 	return nil
 }
 
 // broadcastToGroup sends a message to all members of a hosted group except excludePeerID.
+// Members connected through another relay of a co-hosted group get it from
+// that relay.
 func (m *Manager) broadcastToGroup(hg *hostedGroup, groupID, msgType string, payload any, excludePeerID string) {
 	hg.mu.RLock()
-	members := hg.memberList(m.selfID)
+	members := hg.localMembers(m.selfID)
 	hg.mu.RUnlock()
 
 	for _, mi := range members {
@@ -485,7 +565,7 @@ func (m *Manager) broadcastToGroup(hg *hostedGroup, groupID, msgType string, pay
 		}
 		pid := mi.PeerID
 		go func(p string) {
-			ctx, cancel := context.WithTimeout(context.Background(), BroadcastTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 			defer cancel()
 			if _, err := m.mq.Send(ctx, p, "group:"+groupID+":"+msgType, payload); err != nil {
 				log.Printf("GROUP: MQ send to %s failed: %v, removing from group", shortID(p), err)
@@ -507,6 +587,7 @@ func (m *Manager) removeMemberAndBroadcast(groupID, peerID string) {
 	_, wasMember := hg.members[peerID]
 	if wasMember {
 		delete(hg.members, peerID)
+		delete(hg.buckets, peerID)
 	}
 	members := hg.memberList(m.selfID)
 	groupType := hg.info.GroupType
@@ -517,6 +598,7 @@ func (m *Manager) removeMemberAndBroadcast(groupID, peerID string) {
 	}
 
 	m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+	m.syncRelayMembers(hg, groupID)
 	m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, From: peerID, Payload: MembersPayload{Members: members}})
 
 	if !m.isVolatileType(groupType) {
@@ -541,8 +623,11 @@ func (m *Manager) pingGroupLoop(ctx context.Context, groupID string) {
 			}
 
 			hg.mu.RLock()
-			members := hg.memberList(m.selfID)
+			members := hg.localMembers(m.selfID)
 			hg.mu.RUnlock()
+			m.syncRelayMembers(hg, groupID)
+			m.renewTopicTokens(hg, groupID)
+			ping := Message{Type: TypePing, Group: groupID, Payload: m.latestPayload(groupID)}
 
 			for _, mi := range members {
 				if mi.PeerID == m.selfID {
@@ -550,9 +635,9 @@ func (m *Manager) pingGroupLoop(ctx context.Context, groupID string) {
 				}
 				pid := mi.PeerID
 				go func(p string) {
-					sendCtx, cancel := context.WithTimeout(context.Background(), BroadcastTimeout)
+					sendCtx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 					defer cancel()
-					if _, err := m.mq.Send(sendCtx, p, "group:"+groupID+":"+TypePing, Message{Type: TypePing, Group: groupID}); err != nil {
+					if _, err := m.mq.Send(sendCtx, p, "group:"+groupID+":"+TypePing, ping); err != nil {
 						log.Printf("GROUP: Ping to %s failed: %v, removing from group", shortID(p), err)
 						m.removeMemberAndBroadcast(groupID, p)
 					}
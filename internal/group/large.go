@@ -0,0 +1,334 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// Large groups: once a group outgrows LargeGroupThreshold, member messages
+// stop going through the host and are published on a GossipSub topic for the
+// group instead, so the host no longer sends every message to every member.
+// Joins, member lists, pings and everything else keep using streams.
+//
+// The host admits members to the topic with an AdmissionToken signed by its
+// peer key. Every subscriber drops (and does not forward) messages without a
+// valid token for their author, so only members can post. Tokens expire after
+// AdmissionTokenTTL and are reissued while the member stays, which bounds how
+// long a kicked member can keep posting.
+//
+// Volatile and co-hosted groups always use streams.
+
+// TopicJoiner opens the GossipSub topic of a large group. validate runs for
+// every message before it is delivered or forwarded; deliver receives the
+// accepted ones, our own included. Implemented by p2p.Node.
+type TopicJoiner interface {
+	JoinGroupTopic(name string, validate func(from string, data []byte) bool, deliver func(from string, data []byte)) (publish func(ctx context.Context, data []byte) error, leave func(), err error)
+}
+
+// SetTopics enables large group mode. Without it every group uses streams.
+func (m *Manager) SetTopics(t TopicJoiner) {
+	m.topics = t
+}
+
+// AdmissionToken lets a peer publish on a large group's topic.
+type AdmissionToken struct {
+	Group   string `json:"group"`
+	Peer    string `json:"peer"`
+	Expires int64  `json:"expires"` // unix millis
+	Sig     []byte `json:"sig"`
+}
+
+func (t AdmissionToken) signedBytes() []byte {
+	return []byte("goop2-group-admission\n" + t.Group + "\n" + t.Peer + "\n" + strconv.FormatInt(t.Expires, 10))
+}
+
+// issueToken signs a token admitting peerID to groupID.
+func issueToken(key crypto.PrivKey, groupID, peerID string, now time.Time) (AdmissionToken, error) {
+	t := AdmissionToken{Group: groupID, Peer: peerID, Expires: now.Add(AdmissionTokenTTL).UnixMilli()}
+	sig, err := key.Sign(t.signedBytes())
+	if err != nil {
+		return AdmissionToken{}, err
+	}
+	t.Sig = sig
+	return t, nil
+}
+
+// verifyToken checks that t admits peerID to groupID, was signed by owner
+// and has not expired.
+func verifyToken(t AdmissionToken, owner, groupID, peerID string, now time.Time) bool {
+	if t.Group != groupID || t.Peer != peerID || now.UnixMilli() > t.Expires {
+		return false
+	}
+	pid, err := peer.Decode(owner)
+	if err != nil {
+		return false
+	}
+	pub, err := pid.ExtractPublicKey()
+	if err != nil {
+		return false
+	}
+	ok, err := pub.Verify(t.signedBytes(), t.Sig)
+	return err == nil && ok
+}
+
+// TopicPayload hands a member its (renewed) admission token for the group's
+// topic.
+type TopicPayload struct {
+	Token AdmissionToken `json:"token"`
+}
+
+// topicEnvelope is what goes over a large group's topic.
+type topicEnvelope struct {
+	Type    string          `json:"type"`
+	Token   *AdmissionToken `json:"token,omitempty"` // omitted by the host
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// groupTopic is an open subscription to a large group's topic.
+type groupTopic struct {
+	publish func(ctx context.Context, data []byte) error
+	leave   func()
+}
+
+func topicName(groupID string) string { return "goop.group." + groupID }
+
+// IsLargeGroup reports whether a group we host or joined uses its topic.
+func (m *Manager) IsLargeGroup(groupID string) bool {
+	m.mu.RLock()
+	hg := m.groups[groupID]
+	cc := m.activeConns[groupID]
+	m.mu.RUnlock()
+	if hg != nil {
+		hg.mu.RLock()
+		defer hg.mu.RUnlock()
+		return hg.topic != nil
+	}
+	if cc != nil {
+		cc.membersMu.RLock()
+		defer cc.membersMu.RUnlock()
+		return cc.topic != nil
+	}
+	return false
+}
+
+// openTopic subscribes to a group's topic, accepting messages from owner and
+// from peers holding a token signed by owner.
+func (m *Manager) openTopic(groupID, owner string) (*groupTopic, error) {
+	validate := func(from string, data []byte) bool {
+		if from == owner {
+			return true
+		}
+		var env topicEnvelope
+		if json.Unmarshal(data, &env) != nil || env.Token == nil {
+			return false
+		}
+		return verifyToken(*env.Token, owner, groupID, from, time.Now())
+	}
+	deliver := func(from string, data []byte) {
+		if from == m.selfID {
+			return
+		}
+		var env topicEnvelope
+		if json.Unmarshal(data, &env) == nil {
+			m.deliverTopicMessage(groupID, from, env)
+		}
+	}
+	publish, leave, err := m.topics.JoinGroupTopic(topicName(groupID), validate, deliver)
+	if err != nil {
+		return nil, err
+	}
+	return &groupTopic{publish: publish, leave: leave}, nil
+}
+
+// send publishes a message on a large group's topic.
+func (gt *groupTopic) send(msgType string, token *AdmissionToken, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(topicEnvelope{Type: msgType, Token: token, Payload: raw})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+	defer cancel()
+	return gt.publish(ctx, data)
+}
+
+// deliverTopicMessage hands a message from a group's topic to our
+// listeners, as if it had arrived from the host.
+func (m *Manager) deliverTopicMessage(groupID, from string, env topicEnvelope) {
+	if env.Type != TypeMsg && env.Type != TypeState {
+		return
+	}
+	var payload any
+	if len(env.Payload) > 0 && json.Unmarshal(env.Payload, &payload) != nil {
+		return
+	}
+	m.mu.RLock()
+	_, hosting := m.groups[groupID]
+	m.mu.RUnlock()
+
+	if rp, ok := unwrapReliable(payload); ok {
+		m.receiveReliable(from, groupID, rp, func(e ReliablePayload) {
+			if hosting {
+				m.rel.record(groupID, e)
+			}
+			m.notifyListeners(&Event{Type: e.Type, Group: groupID, From: e.Origin, Payload: e.Data, TS: m.localTime(e.Origin, e.TS)})
+		})
+		return
+	}
+	m.notifyListeners(&Event{Type: env.Type, Group: groupID, From: from, Payload: payload})
+}
+
+// ── Host side ───────────────────────────────────────────────────────────────
+
+// maybeGoLarge moves a hosted group onto its topic once it has more than
+// largeThreshold members. A group stays large until it closes or we restart.
+func (m *Manager) maybeGoLarge(hg *hostedGroup, groupID string) {
+	if m.topics == nil || m.signKey == nil {
+		return
+	}
+	hg.mu.RLock()
+	eligible := hg.topic == nil && hg.owner == "" && len(hg.relays) == 0 &&
+		len(hg.memberList(m.selfID)) > m.largeThreshold
+	groupType := hg.info.GroupType
+	hg.mu.RUnlock()
+	if !eligible || m.isVolatileType(groupType) {
+		return
+	}
+
+	gt, err := m.openTopic(groupID, m.selfID)
+	if err != nil {
+		log.Printf("GROUP: Large mode for %s unavailable: %v", groupID, err)
+		return
+	}
+	hg.mu.Lock()
+	if hg.topic != nil {
+		hg.mu.Unlock()
+		gt.leave()
+		return
+	}
+	hg.topic = gt
+	hg.mu.Unlock()
+
+	m.issueTopicTokens(hg, groupID)
+	log.Printf("GROUP: Group %s switched to large mode (topic %s)", groupID, topicName(groupID))
+}
+
+// issueTopicTokens sends every member connected to us a fresh token.
+func (m *Manager) issueTopicTokens(hg *hostedGroup, groupID string) {
+	now := time.Now()
+	hg.mu.Lock()
+	hg.tokensIssued = now
+	members := hg.localMembers(m.selfID)
+	hg.mu.Unlock()
+
+	for _, mi := range members {
+		if mi.PeerID == m.selfID {
+			continue
+		}
+		tok, err := issueToken(m.signKey, groupID, mi.PeerID, now)
+		if err != nil {
+			log.Printf("GROUP: Sign admission token: %v", err)
+			return
+		}
+		go m.sendGroup(mi.PeerID, groupID, TypeTopic, TopicPayload{Token: tok})
+	}
+}
+
+// renewTopicTokens reissues tokens before half their lifetime has passed.
+// Called from the ping loop.
+func (m *Manager) renewTopicTokens(hg *hostedGroup, groupID string) {
+	hg.mu.RLock()
+	due := hg.topic != nil && time.Since(hg.tokensIssued) > AdmissionTokenTTL/2
+	hg.mu.RUnlock()
+	if due {
+		m.issueTopicTokens(hg, groupID)
+	}
+}
+
+// welcomeTokenLocked returns a token for a member joining a large group, or nil
+// when the group uses streams. Caller holds hg.mu.
+func (m *Manager) welcomeTokenLocked(hg *hostedGroup, groupID, peerID string) *AdmissionToken {
+	if hg.topic == nil {
+		return nil
+	}
+	tok, err := issueToken(m.signKey, groupID, peerID, time.Now())
+	if err != nil {
+		return nil
+	}
+	return &tok
+}
+
+// closeTopicLocked leaves a hosted group's topic. Caller holds hg.mu.
+func (hg *hostedGroup) closeTopicLocked() {
+	if hg.topic != nil {
+		hg.topic.leave()
+		hg.topic = nil
+	}
+}
+
+// ── Member side ─────────────────────────────────────────────────────────────
+
+// acceptToken stores a token from the host and joins the topic if we are
+// not on it yet.
+func (m *Manager) acceptToken(cc *clientConn, tok AdmissionToken) {
+	if m.topics == nil || tok.Group != cc.groupID || tok.Peer != m.selfID {
+		return
+	}
+	cc.membersMu.Lock()
+	cc.token = &tok
+	joined := cc.topic != nil
+	cc.membersMu.Unlock()
+	if joined {
+		return
+	}
+
+	gt, err := m.openTopic(cc.groupID, cc.ownerID())
+	if err != nil {
+		log.Printf("GROUP: Join topic of %s failed, staying on streams: %v", cc.groupID, err)
+		return
+	}
+	cc.membersMu.Lock()
+	if cc.topic != nil {
+		cc.membersMu.Unlock()
+		gt.leave()
+		return
+	}
+	cc.topic = gt
+	cc.membersMu.Unlock()
+	log.Printf("GROUP: Group %s is large, using topic %s", cc.groupID, topicName(cc.groupID))
+}
+
+// sendViaTopic publishes a member message on the group's topic. It reports
+// false when the group uses streams or our token has run out, in which case
+// the message goes to the host as usual.
+func (m *Manager) sendViaTopic(cc *clientConn, wire any) (bool, error) {
+	cc.membersMu.RLock()
+	gt, tok := cc.topic, cc.token
+	cc.membersMu.RUnlock()
+	if gt == nil || tok == nil || time.Now().UnixMilli() > tok.Expires {
+		return false, nil
+	}
+	return true, gt.send(TypeMsg, tok, wire)
+}
+
+// closeTopic leaves a joined group's topic.
+func (cc *clientConn) closeTopic() {
+	cc.membersMu.Lock()
+	gt := cc.topic
+	cc.topic, cc.token = nil, nil
+	cc.membersMu.Unlock()
+	if gt != nil {
+		gt.leave()
+	}
+}
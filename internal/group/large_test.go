@@ -0,0 +1,170 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func testKey(t *testing.T) (crypto.PrivKey, string) {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id.String()
+}
+
+func TestAdmissionToken(t *testing.T) {
+	ownerKey, owner := testKey(t)
+	otherKey, _ := testKey(t)
+	now := time.Now()
+
+	tok, err := issueToken(ownerKey, "g1", "member-a", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyToken(tok, owner, "g1", "member-a", now) {
+		t.Fatal("valid token rejected")
+	}
+	if verifyToken(tok, owner, "g1", "member-b", now) {
+		t.Fatal("token accepted for another peer")
+	}
+	if verifyToken(tok, owner, "g2", "member-a", now) {
+		t.Fatal("token accepted for another group")
+	}
+	if verifyToken(tok, owner, "g1", "member-a", now.Add(AdmissionTokenTTL+time.Second)) {
+		t.Fatal("expired token accepted")
+	}
+	forged, _ := issueToken(otherKey, "g1", "member-a", now)
+	if verifyToken(forged, owner, "g1", "member-a", now) {
+		t.Fatal("token signed by someone else accepted")
+	}
+}
+
+// topicHub is an in-memory GossipSub: every message is validated and
+// delivered by each subscriber, like pubsub validators do.
+type topicHub struct {
+	mu   sync.Mutex
+	subs map[string][]*hubSub
+}
+
+type hubSub struct {
+	self     string
+	validate func(string, []byte) bool
+	deliver  func(string, []byte)
+}
+
+type hubJoiner struct {
+	hub  *topicHub
+	self string
+}
+
+func (j hubJoiner) JoinGroupTopic(name string, validate func(string, []byte) bool, deliver func(string, []byte)) (func(context.Context, []byte) error, func(), error) {
+	s := &hubSub{self: j.self, validate: validate, deliver: deliver}
+	j.hub.mu.Lock()
+	j.hub.subs[name] = append(j.hub.subs[name], s)
+	j.hub.mu.Unlock()
+	publish := func(_ context.Context, data []byte) error {
+		j.hub.mu.Lock()
+		subs := append([]*hubSub(nil), j.hub.subs[name]...)
+		j.hub.mu.Unlock()
+		for _, sub := range subs {
+			if sub.validate(j.self, data) {
+				sub.deliver(j.self, data)
+			}
+		}
+		return nil
+	}
+	leave := func() {
+		j.hub.mu.Lock()
+		defer j.hub.mu.Unlock()
+		list := j.hub.subs[name]
+		for i, sub := range list {
+			if sub == s {
+				j.hub.subs[name] = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+	}
+	return publish, leave, nil
+}
+
+// ── Scenario: a group moves to its topic once it outgrows the threshold ───
+
+func TestScenario_LargeGroupUsesTopic(t *testing.T) {
+	hub := &topicHub{subs: map[string][]*hubSub{}}
+	b := &bus{peers: map[string]*Manager{}, local: map[string][]string{}}
+	hostKey, hostID := testKey(t)
+	_, aliceID := testKey(t)
+
+	host := b.join(t, hostID)
+	host.signKey = hostKey
+	host.largeThreshold = 3
+	host.SetTopics(hubJoiner{hub: hub, self: hostID})
+	alice := b.join(t, aliceID)
+	alice.SetTopics(hubJoiner{hub: hub, self: aliceID})
+	for i := 0; i < 3; i++ {
+		b.join(t, fmt.Sprintf("peer-%d", i))
+	}
+	if err := host.CreateGroup("g1", "Big", "template", "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Given a group at the threshold, it still uses streams
+	alice.SetActiveConn("g1", hostID, "template")
+	host.SimulateJoin(aliceID, "g1")
+	host.SimulateJoin("peer-0", "g1")
+	host.SimulateJoin("peer-1", "g1")
+	if host.IsLargeGroup("g1") {
+		t.Fatal("group went large at the threshold")
+	}
+
+	// When one more member joins
+	host.SimulateJoin("peer-2", "g1")
+
+	// Then the host opens the topic and admits the members
+	if !host.IsLargeGroup("g1") {
+		t.Fatal("group did not go large past the threshold")
+	}
+	eventually(t, "alice to join the topic", func() bool { return alice.IsLargeGroup("g1") })
+
+	// When alice sends, the message goes over the topic and reaches the host
+	if err := alice.SendToGroup("g1", map[string]any{"text": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "message at host", func() bool { return b.published(hostID, "group:g1:"+TypeMsg) })
+
+	// And a peer without a token cannot post
+	intruder := hubJoiner{hub: hub, self: "intruder"}
+	publish, leave, _ := intruder.JoinGroupTopic(topicName("g1"), func(string, []byte) bool { return true }, func(string, []byte) {})
+	defer leave()
+	b.mu.Lock()
+	b.local[hostID] = nil
+	b.mu.Unlock()
+	_ = publish(context.Background(), []byte(`{"type":"msg","payload":{"text":"spam"}}`))
+	if b.published(hostID, "group:g1:"+TypeMsg) {
+		t.Fatal("host accepted a message without a token")
+	}
+
+	// When the host closes the group, it leaves the topic
+	if err := host.CloseGroup("g1"); err != nil {
+		t.Fatal(err)
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for _, s := range hub.subs[topicName("g1")] {
+		if s.self == hostID {
+			t.Fatal("host still subscribed after close")
+		}
+	}
+}
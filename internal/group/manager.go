@@ -3,13 +3,16 @@ package group
 import (
 	"context"
 	"log"
+	"math"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
 
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 )
 
@@ -19,6 +22,7 @@ type Event struct {
 	Group   string `json:"group"`
 	From    string `json:"from,omitempty"`
 	Payload any    `json:"payload,omitempty"`
+	TS      int64  `json:"ts,omitempty"` // when it happened on our clock, unix millis
 }
 
 
@@ -51,6 +55,30 @@ type Manager struct {
 	// Type-specific lifecycle handlers keyed by group_type.
 	handlers map[string]TypeHandler
 
+	// Configured flood protection keyed by group_type. See SetRateLimits.
+	rateCfg map[string]RateLimit
+
+	// Sequence bookkeeping for groups whose type sets Ordered.
+	rel *reliableState
+
+	// Optional clock offset to a peer in ms (theirs = ours + offset), used
+	// to place ordered messages on our timeline. See SetClock.
+	clock func(peerID string) (offsetMs float64, ok bool)
+
+	// Large group mode: GossipSub topics (see SetTopics), the key that signs
+	// admission tokens, and the member count above which a group goes large.
+	topics         TopicJoiner
+	signKey        crypto.PrivKey
+	largeThreshold int
+
+	// How long a group's owner must stay offline before its members elect
+	// a new one. See handover.go.
+	electionDelay time.Duration
+
+	// Last known online status per peer, used to emit presence changes.
+	presenceMu sync.Mutex
+	presence   map[string]bool
+
 	// MQ unsubscribe functions
 	unsubGroup  func()
 	unsubInvite func()
@@ -75,14 +103,48 @@ type hostedGroup struct {
 	hostJoinedAt int64
 	mu           sync.RWMutex
 	cancelPing   context.CancelFunc
+	buckets      map[string]*memberBucket // flood protection, keyed by peerID
+
+	// Co-hosting. owner is the peer that owns a group we relay for it, ""
+	// when the group is ours. relays are the other peers relaying the group
+	// and remote the members connected through each of them.
+	owner  string
+	relays []string
+	remote map[string][]MemberInfo
+
+	// Large group mode: the group's topic once it went large, and when
+	// members last got tokens.
+	topic        *groupTopic
+	tokensIssued time.Time
+
+	// handingTo is the successor we asked to take the group over, until it
+	// confirms.
+	handingTo string
 }
 
 type clientConn struct {
 	hostPeerID string
 	groupID    string
 	groupType  string
+	groupContext string
+	ordered    bool
 	membersMu  sync.RWMutex
 	members    []MemberInfo // last known member list from host
+
+	owner  string   // the group's owner; hostPeerID unless we joined through a co-host
+	relays []string // every peer relaying the group (guarded by membersMu)
+
+	topic *groupTopic     // large groups: the group's topic (guarded by membersMu)
+	token *AdmissionToken // large groups: our admission token (guarded by membersMu)
+}
+
+// ownerID returns the peer that owns the group, which is the peer we are
+// connected to unless it is a co-host.
+func (cc *clientConn) ownerID() string {
+	if cc.owner != "" {
+		return cc.owner
+	}
+	return cc.hostPeerID
 }
 
 const (
@@ -93,15 +155,20 @@ const (
 // New creates a new group manager and registers MQ subscriptions.
 func New(h host.Host, db *storage.DB, transport mq.Transport, resolvePeer func(string) state.PeerIdentityPayload) *Manager {
 	m := &Manager{
-		host:         h,
-		db:           db,
-		mq:           transport,
-		selfID:       h.ID().String(),
-		resolvePeer:  resolvePeer,
-		groups:       make(map[string]*hostedGroup),
-		activeConns:  make(map[string]*clientConn),
-		pendingJoins: make(map[string]chan joinResult),
-		handlers:     make(map[string]TypeHandler),
+		host:           h,
+		db:             db,
+		mq:             transport,
+		selfID:         h.ID().String(),
+		resolvePeer:    resolvePeer,
+		groups:         make(map[string]*hostedGroup),
+		activeConns:    make(map[string]*clientConn),
+		pendingJoins:   make(map[string]chan joinResult),
+		handlers:       make(map[string]TypeHandler),
+		rel:            newReliableState(),
+		presence:       make(map[string]bool),
+		signKey:        h.Peerstore().PrivKey(h.ID()),
+		largeThreshold: LargeGroupThreshold,
+		electionDelay:  OwnerElectionDelay,
 	}
 
 	// Load existing groups from DB into memory (restore host-joined state)
@@ -113,11 +180,13 @@ func New(h host.Host, db *storage.DB, transport mq.Transport, resolvePeer func(s
 				members:    make(map[string]*memberMeta),
 				hostJoined: g.HostJoined,
 				cancelPing: cancel,
+				relays:     g.CoHosts,
 			}
 			m.groups[g.ID] = hg
 			go m.pingGroupLoop(ctx, g.ID)
 		}
 	}
+	m.restoreRelayedGroups()
 
 	// Register MQ subscriptions
 	m.unsubGroup = transport.SubscribeTopic("group:", func(from, topic string, payload any) {
@@ -154,11 +223,20 @@ func (m *Manager) Close() error {
 		if hg.cancelPing != nil {
 			hg.cancelPing()
 		}
+		hg.closeTopicLocked()
 		hg.mu.Unlock()
 	}
+	conns := make([]*clientConn, 0, len(m.activeConns))
+	for _, cc := range m.activeConns {
+		conns = append(conns, cc)
+	}
 
 	m.mu.Unlock()
 
+	for _, cc := range conns {
+		cc.closeTopic()
+	}
+
 	// Unregister MQ subscriptions
 	if m.unsubGroup != nil {
 		m.unsubGroup()
@@ -178,6 +256,36 @@ func (m *Manager) SelfID() string {
 	return m.selfID
 }
 
+// SetClock sets the clock offset source used to convert the send time of
+// ordered messages to our clock. Without it, or without an estimate for
+// the author, events are stamped when they arrive.
+func (m *Manager) SetClock(fn func(peerID string) (offsetMs float64, ok bool)) {
+	m.clock = fn
+}
+
+// localTime converts a timestamp taken on peerID's clock to ours; 0 when
+// that cannot be done.
+func (m *Manager) localTime(peerID string, ts int64) int64 {
+	if ts == 0 {
+		return 0
+	}
+	if peerID == m.selfID {
+		return ts
+	}
+	if m.clock == nil {
+		return 0
+	}
+	offsetMs, ok := m.clock(peerID)
+	if !ok {
+		return 0
+	}
+	local := ts - int64(math.Round(offsetMs))
+	if now := nowMillis(); local > now {
+		return now
+	}
+	return local
+}
+
 // RegisterType registers a TypeHandler for the given group_type.
 func (m *Manager) RegisterType(groupType string, h TypeHandler) {
 	m.mu.Lock()
@@ -197,6 +305,9 @@ func (m *Manager) RegisteredTypes() []string {
 }
 
 func (m *Manager) notifyListeners(evt *Event) {
+	if evt.TS == 0 {
+		evt.TS = nowMillis()
+	}
 	if m.mq != nil {
 		m.mq.PublishLocal("group:"+evt.Group+":"+evt.Type, "", evt)
 	}
@@ -250,13 +361,48 @@ func shortID(id string) string {
 	return id
 }
 
+// memberList returns every member of the group: our own (localMembers)
+// followed by those connected through the other relays of a co-hosted group.
 func (g *hostedGroup) memberList(hostID string) []MemberInfo {
+	members := g.localMembers(hostID)
+	if len(g.remote) == 0 {
+		return members
+	}
+	seen := make(map[string]bool, len(members))
+	for _, mi := range members {
+		seen[mi.PeerID] = true
+	}
+	for relay, list := range g.remote {
+		for _, mi := range list {
+			if seen[mi.PeerID] {
+				continue
+			}
+			seen[mi.PeerID] = true
+			mi.Relay = relay
+			members = append(members, mi)
+		}
+	}
+	return members
+}
+
+// localMembers returns the members connected to us, including ourselves
+// when the host has joined. These are the peers we send to.
+func (g *hostedGroup) localMembers(hostID string) []MemberInfo {
+	relay := ""
+	if len(g.relays) > 0 {
+		relay = hostID
+	}
 	members := make([]MemberInfo, 0, len(g.members)+1)
 	if g.hostJoined {
+		role := "owner"
+		if g.owner != "" {
+			role = "cohost"
+		}
 		members = append(members, MemberInfo{
 			PeerID:   hostID,
-			Role:     "owner",
+			Role:     role,
 			JoinedAt: g.hostJoinedAt,
+			Relay:    relay,
 		})
 	}
 	for _, mm := range g.members {
@@ -264,6 +410,7 @@ func (g *hostedGroup) memberList(hostID string) []MemberInfo {
 			PeerID:   mm.peerID,
 			Role:     mm.role,
 			JoinedAt: mm.joinedAt,
+			Relay:    relay,
 		})
 	}
 	return members
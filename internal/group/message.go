@@ -15,6 +15,30 @@ const (
 	TypePing    = "ping"
 	TypePong    = "pong"
 	TypeMeta    = "meta"
+	TypeMuted   = "muted" // local-only: host muted a flooding member
+	TypeResend  = "resend"
+	TypeGap      = "gap"      // local-only: ordered group gave up on missing messages
+	TypePresence = "presence" // local-only: a member went online or offline
+
+	// Co-hosting: relays of the same group talk to each other with these.
+	TypeCoHost       = "cohost"        // owner → co-host: relay this group (also sent on every change)
+	TypeCoHostEnd    = "cohost-end"    // owner → co-host: stop relaying
+	TypeRelay        = "relay"         // relay → relay: a msg/state to pass on to local members
+	TypeRelayMembers = "relay-members" // relay → relay: the members connected through the sender
+	TypeRelays       = "relays"        // relay → member: the current set of relays
+	TypeKick         = "kick"          // owner → co-host: disconnect one of your members
+
+	TypeTopic = "topic" // host → member: admission token for a large group's topic
+
+	// Ownership handover (see handover.go).
+	TypeHandover = "handover" // owner → successor: take this group over
+	TypeOwner    = "owner"    // new owner → previous owner, or owner → members: the group has a new owner
+)
+
+// Reasons for an owner change, in OwnerPayload and storage.OwnershipRecord.
+const (
+	OwnerTransfer = "transfer" // the owner handed the group over
+	OwnerElection = "election" // the owner stayed offline and the longest-joined member took over
 )
 
 // Message is the JSON wire format for group protocol messages.
@@ -28,13 +52,17 @@ type Message struct {
 
 // WelcomePayload is sent to a new member after joining.
 type WelcomePayload struct {
-	GroupName    string         `json:"group_name,omitempty"`
-	GroupType    string         `json:"group_type,omitempty"`
-	GroupContext string         `json:"group_context,omitempty"`
-	MaxMembers   int            `json:"max_members"`
-	Volatile     bool           `json:"volatile"`
-	Members      []MemberInfo   `json:"members"`
-	State        map[string]any `json:"state,omitempty"`
+	GroupName    string          `json:"group_name,omitempty"`
+	GroupType    string          `json:"group_type,omitempty"`
+	GroupContext string          `json:"group_context,omitempty"`
+	MaxMembers   int             `json:"max_members"`
+	Volatile     bool            `json:"volatile"`
+	Ordered      bool            `json:"ordered,omitempty"`
+	Members      []MemberInfo    `json:"members"`
+	State        map[string]any  `json:"state,omitempty"`
+	Owner        string          `json:"owner,omitempty"`  // set when the group has co-hosts
+	Relays       []string        `json:"relays,omitempty"` // every peer relaying the group, owner first
+	Token        *AdmissionToken `json:"token,omitempty"`  // set when the group is large
 }
 
 // MembersPayload is broadcast when membership changes.
@@ -47,6 +75,7 @@ type MetaPayload struct {
 	GroupName  string `json:"group_name"`
 	GroupType    string `json:"group_type"`
 	MaxMembers int    `json:"max_members"`
+	Ordered    bool   `json:"ordered,omitempty"`
 }
 
 // MemberInfo describes a group member.
@@ -55,6 +84,56 @@ type MemberInfo struct {
 	Name     string `json:"name,omitempty"`
 	Role     string `json:"role"`
 	JoinedAt int64  `json:"joined_at"`
+	Relay    string `json:"relay,omitempty"` // co-hosted groups: the relay this member is connected through
+}
+
+// CoHostPayload authorizes a peer to relay a group, and carries what it
+// needs to serve members on the owner's behalf.
+type CoHostPayload struct {
+	GroupName    string   `json:"group_name"`
+	GroupType    string   `json:"group_type"`
+	GroupContext string   `json:"group_context,omitempty"`
+	MaxMembers   int      `json:"max_members"`
+	DefaultRole  string   `json:"default_role,omitempty"`
+	Ordered      bool     `json:"ordered,omitempty"`
+	Relays       []string `json:"relays"` // owner first
+}
+
+// RelayPayload wraps a member message passed between relays.
+type RelayPayload struct {
+	Origin  string `json:"origin"`
+	Type    string `json:"type"`
+	Payload any    `json:"payload,omitempty"`
+}
+
+// RelaysPayload tells members which peers relay a group.
+type RelaysPayload struct {
+	Owner  string   `json:"owner"`
+	Relays []string `json:"relays"`
+}
+
+// KickPayload asks a co-host to disconnect one of its members.
+type KickPayload struct {
+	PeerID string `json:"peer_id"`
+}
+
+// HandoverPayload carries what a successor needs to host a group.
+type HandoverPayload struct {
+	GroupName    string       `json:"group_name"`
+	GroupType    string       `json:"group_type"`
+	GroupContext string       `json:"group_context,omitempty"`
+	MaxMembers   int          `json:"max_members"`
+	DefaultRole  string       `json:"default_role,omitempty"`
+	Roles        []string     `json:"roles,omitempty"`
+	Ordered      bool         `json:"ordered,omitempty"`
+	Members      []MemberInfo `json:"members"`
+}
+
+// OwnerPayload announces a group's new owner.
+type OwnerPayload struct {
+	Owner    string `json:"owner"`
+	Previous string `json:"previous"`
+	Reason   string `json:"reason"` // OwnerTransfer or OwnerElection
 }
 
 // ErrorPayload is sent when an error occurs.
@@ -63,6 +142,20 @@ type ErrorPayload struct {
 	Message string `json:"message"`
 }
 
+// SnapshotPayload is sent as a TypeState message to a newly joined member
+// when the group type's handler implements StateProvider.
+type SnapshotPayload struct {
+	Snapshot bool `json:"snapshot"`
+	State    any  `json:"state"`
+}
+
+// MutedPayload is emitted to host listeners when a member is muted for flooding.
+type MutedPayload struct {
+	PeerID     string `json:"peer_id"`
+	MutedUntil int64  `json:"muted_until"`
+	Dropped    int    `json:"dropped"`
+}
+
 // GroupInfo describes a hosted group.
 type GroupInfo struct {
 	ID           string `json:"id"`
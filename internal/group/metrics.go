@@ -0,0 +1,38 @@
+package group
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// WriteMetrics writes member counts of the groups we host and the number
+// of groups we are connected to as a member, for GET /metrics.
+func (m *Manager) WriteMetrics(w *prom.Writer) {
+	type hosted struct {
+		id, typ string
+		members int
+	}
+	m.mu.RLock()
+	groups := make(map[string]*hostedGroup, len(m.groups))
+	maps.Copy(groups, m.groups)
+	joined := len(m.activeConns)
+	m.mu.RUnlock()
+
+	list := make([]hosted, 0, len(groups))
+	for id, hg := range groups {
+		hg.mu.RLock()
+		list = append(list, hosted{id: id, typ: hg.info.GroupType, members: len(hg.memberList(m.selfID))})
+		hg.mu.RUnlock()
+	}
+
+	slices.SortFunc(list, func(a, b hosted) int { return strings.Compare(a.id, b.id) })
+	w.Gauge("goop_groups_hosted", "Groups this peer hosts.", float64(len(list)))
+	w.Gauge("goop_groups_joined", "Groups this peer is connected to as a member.", float64(joined))
+	for _, g := range list {
+		w.Gauge("goop_group_members", "Members of a hosted group, including co-hosts' members and the host when joined.",
+			float64(g.members), "group", g.id, "type", g.typ)
+	}
+}
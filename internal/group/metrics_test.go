@@ -0,0 +1,37 @@
+package group
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// deliverTransport accepts every send, so members are not dropped as
+// unreachable while the test runs.
+type deliverTransport struct{ mq.NopTransport }
+
+func (deliverTransport) Send(context.Context, string, string, any) (string, error) { return "", nil }
+
+func TestWriteMetrics(t *testing.T) {
+	m := NewTestManager(openTestDB(t), "host-peer-id", TestManagerOpts{MQ: deliverTransport{}})
+	t.Cleanup(func() { m.Close() })
+	if err := m.CreateGroup("g1", "Jam", "listen", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	m.SimulateJoin("alice", "g1")
+	m.SimulateJoin("bob", "g1")
+
+	text := string(prom.Write(m))
+	for _, want := range []string{
+		"goop_groups_hosted 1\n",
+		"goop_groups_joined 0\n",
+		`goop_group_members{group="g1",type="listen"} 2` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("exposition lacks %q:\n%s", want, text)
+		}
+	}
+}
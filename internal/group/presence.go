@@ -0,0 +1,155 @@
+package group
+
+// MemberPresence describes a group member's online status, independent of
+// whether they are still a member. A member can be offline (peer gone) yet
+// remain in the group, or online but not currently connected to the session.
+type MemberPresence struct {
+	PeerID    string `json:"peer_id"`
+	Name      string `json:"name,omitempty"`
+	Role      string `json:"role"`
+	Online    bool   `json:"online"`    // peer is reachable on the network
+	Connected bool   `json:"connected"` // peer is joined to the live group session
+	LastSeen  int64  `json:"last_seen,omitempty"`
+}
+
+// PresencePayload is emitted to local listeners when a member's online status changes.
+type PresencePayload struct {
+	PeerID string `json:"peer_id"`
+	Online bool   `json:"online"`
+}
+
+// GroupPresence returns the presence of every known member of a group,
+// combining the persisted membership with the live session roster and the
+// peer table. Works for both hosted and joined groups.
+func (m *Manager) GroupPresence(groupID string) []MemberPresence {
+	m.mu.RLock()
+	hg := m.groups[groupID]
+	cc := m.activeConns[groupID]
+	m.mu.RUnlock()
+
+	var live []MemberInfo
+	switch {
+	case hg != nil:
+		hg.mu.RLock()
+		live = hg.memberList(m.selfID)
+		hg.mu.RUnlock()
+	case cc != nil:
+		cc.membersMu.RLock()
+		live = append(live, cc.members...)
+		cc.membersMu.RUnlock()
+	}
+
+	out := make([]MemberPresence, 0, len(live))
+	seen := make(map[string]bool, len(live))
+	for _, mi := range live {
+		seen[mi.PeerID] = true
+		out = append(out, m.memberPresence(mi.PeerID, mi.Role, true))
+	}
+
+	stored, _ := m.db.ListGroupMembers(groupID)
+	for _, gm := range stored {
+		if seen[gm.PeerID] {
+			continue
+		}
+		seen[gm.PeerID] = true
+		out = append(out, m.memberPresence(gm.PeerID, gm.Role, false))
+	}
+	return out
+}
+
+func (m *Manager) memberPresence(peerID, role string, connected bool) MemberPresence {
+	mp := MemberPresence{PeerID: peerID, Role: role, Connected: connected}
+	if peerID == m.selfID {
+		mp.Online = true
+		return mp
+	}
+	if m.resolvePeer != nil {
+		id := m.resolvePeer(peerID)
+		mp.Name = id.Name()
+		mp.LastSeen = id.LastSeen
+		mp.Online = connected || (id.Known && id.Reachable && !id.Offline)
+		return mp
+	}
+	mp.Name = m.db.GetPeerName(peerID)
+	mp.Online = connected
+	return mp
+}
+
+// handlePresenceChange notifies listeners of every group the peer belongs to
+// when its online status flips.
+func (m *Manager) handlePresenceChange(peerID string, online bool) {
+	m.presenceMu.Lock()
+	prev, known := m.presence[peerID]
+	m.presence[peerID] = online
+	m.presenceMu.Unlock()
+	if known && prev == online {
+		return
+	}
+	if !known && online {
+		// First sighting: nothing changed from the UI's point of view.
+		return
+	}
+
+	for _, groupID := range m.groupsWithMember(peerID) {
+		m.notifyListeners(&Event{Type: TypePresence, Group: groupID, From: peerID, Payload: PresencePayload{
+			PeerID: peerID,
+			Online: online,
+		}})
+	}
+}
+
+// groupsWithMember returns the IDs of hosted and joined groups that list peerID as a member.
+func (m *Manager) groupsWithMember(peerID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []string
+	for gid, hg := range m.groups {
+		hg.mu.RLock()
+		_, ok := hg.members[peerID]
+		hg.mu.RUnlock()
+		if ok || m.isStoredMember(gid, peerID) {
+			ids = append(ids, gid)
+		}
+	}
+	for gid, cc := range m.activeConns {
+		if _, hosted := m.groups[gid]; hosted {
+			continue
+		}
+		found := cc.hostPeerID == peerID
+		cc.membersMu.RLock()
+		for _, mi := range cc.members {
+			if mi.PeerID == peerID {
+				found = true
+				break
+			}
+		}
+		cc.membersMu.RUnlock()
+		if found {
+			ids = append(ids, gid)
+		}
+	}
+	return ids
+}
+
+func (m *Manager) isStoredMember(groupID, peerID string) bool {
+	stored, _ := m.db.ListGroupMembers(groupID)
+	for _, gm := range stored {
+		if gm.PeerID == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupsWithMember returns the IDs of the groups we host or have joined
+// that list peerID as a member.
+func (m *Manager) GroupsWithMember(peerID string) []string {
+	return m.groupsWithMember(peerID)
+}
+
+// SharesGroupWith reports whether peerID is a member of any group we host or
+// have joined. Used by the stream gatekeeper for group-only access policies.
+func (m *Manager) SharesGroupWith(peerID string) bool {
+	return len(m.groupsWithMember(peerID)) > 0
+}
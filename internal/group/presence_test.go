@@ -0,0 +1,66 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+type recordingTransport struct {
+	mq.NopTransport
+	local []string
+}
+
+func (r *recordingTransport) PublishLocal(topic, _ string, _ any) {
+	r.local = append(r.local, topic)
+}
+
+func TestScenario_PresenceSeparateFromMembership(t *testing.T) {
+	// Given a host with one stored member who is currently offline
+	db := openTestDB(t)
+	tr := &recordingTransport{}
+	offline := map[string]bool{"member-b": true}
+	host := NewTestManager(db, "host-peer-id", TestManagerOpts{
+		MQ: tr,
+		ResolvePeer: func(id string) state.PeerIdentityPayload {
+			return state.PeerIdentityPayload{PeerID: id, Content: id, Known: true, Reachable: !offline[id], Offline: offline[id]}
+		},
+	})
+	t.Cleanup(func() { host.Close() })
+	_ = host.CreateGroup("g1", "Team", "template", "", 0)
+	host.SimulateJoin("member-a", "g1")
+	_ = db.UpsertGroupMembers("g1", []storage.GroupMember{{PeerID: "member-a", Role: "viewer"}, {PeerID: "member-b", Role: "viewer"}})
+
+	// When presence is queried
+	byID := map[string]MemberPresence{}
+	for _, p := range host.GroupPresence("g1") {
+		byID[p.PeerID] = p
+	}
+
+	// Then the connected member is online and the stored one is offline but still listed
+	if a := byID["member-a"]; !a.Online || !a.Connected {
+		t.Fatalf("member-a should be online and connected: %+v", a)
+	}
+	b, ok := byID["member-b"]
+	if !ok {
+		t.Fatal("member-b should remain listed as a member")
+	}
+	if b.Online || b.Connected {
+		t.Fatalf("member-b should be offline: %+v", b)
+	}
+
+	// And a presence event fires when member-a goes offline
+	host.SimulatePeerAnnounce(map[string]any{"peerID": "member-a", "reachable": true})
+	host.SimulatePeerAnnounce(map[string]any{"peerID": "member-a", "reachable": false, "offline": true})
+	found := false
+	for _, topic := range tr.local {
+		if topic == "group:g1:"+TypePresence {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected presence event, got topics %v", tr.local)
+	}
+}
@@ -0,0 +1,152 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// memberBucket is a per-member token bucket for relayed msg/state traffic.
+type memberBucket struct {
+	tokens     float64
+	last       time.Time
+	mutedUntil time.Time
+	dropped    int
+}
+
+// RateLimit overrides the flood protection of a group type, from the peer
+// config. Zero fields keep the type's own value; a negative Rate disables
+// rate limiting for the type.
+type RateLimit struct {
+	Rate  float64 // sustained messages per second per member
+	Burst int     // bucket size
+}
+
+// SetRateLimits replaces the configured rate limits, keyed by group_type.
+func (m *Manager) SetRateLimits(limits map[string]RateLimit) {
+	m.mu.Lock()
+	m.rateCfg = limits
+	m.mu.Unlock()
+}
+
+// rateLimits resolves the effective rate and burst for a group type: the
+// configured limits over the handler's flags over the defaults.
+// Returns rate <= 0 when limiting is disabled.
+func (m *Manager) rateLimits(groupType string) (rate float64, burst int) {
+	rate, burst = DefaultMsgRate, DefaultMsgBurst
+	if h := m.handlerForType(groupType); h != nil {
+		f := h.Flags()
+		if f.MsgRate != 0 {
+			rate = f.MsgRate
+		}
+		if f.MsgBurst > 0 {
+			burst = f.MsgBurst
+		}
+	}
+	m.mu.RLock()
+	cfg, ok := m.rateCfg[groupType]
+	m.mu.RUnlock()
+	if ok {
+		if cfg.Rate != 0 {
+			rate = cfg.Rate
+		}
+		if cfg.Burst > 0 {
+			burst = cfg.Burst
+		}
+	}
+	return rate, burst
+}
+
+// allowMsg consumes a token for peerID. It reports whether the message may be
+// relayed and whether this call put the member into a mute. Caller holds g.mu.
+func (g *hostedGroup) allowMsg(peerID string, rate float64, burst int, now time.Time) (ok, justMuted bool) {
+	if rate <= 0 {
+		return true, false
+	}
+	if g.buckets == nil {
+		g.buckets = make(map[string]*memberBucket)
+	}
+	b := g.buckets[peerID]
+	if b == nil {
+		b = &memberBucket{tokens: float64(burst), last: now}
+		g.buckets[peerID] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+
+	if now.Before(b.mutedUntil) {
+		b.dropped++
+		return false, false
+	}
+	if b.tokens < 1 {
+		b.mutedUntil = now.Add(FloodMuteDuration)
+		b.dropped = 1
+		return false, true
+	}
+	b.tokens--
+	return true, false
+}
+
+// handleFlood notifies the offender and local listeners that a member was muted.
+func (m *Manager) handleFlood(groupID, peerID string, until time.Time) {
+	log.Printf("GROUP: %s muted in %s for flooding (until %s)", shortID(peerID), groupID, until.Format(time.TimeOnly))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+		defer cancel()
+		_, _ = m.mq.Send(ctx, peerID, "group:"+groupID+":"+TypeError,
+			Message{Type: TypeError, Group: groupID, Payload: ErrorPayload{
+				Code:    "rate_limited",
+				Message: fmt.Sprintf("muted for %s: too many messages", FloodMuteDuration),
+			}})
+	}()
+
+	m.notifyListeners(&Event{Type: TypeMuted, Group: groupID, From: peerID, Payload: MutedPayload{
+		PeerID:     peerID,
+		MutedUntil: until.UnixMilli(),
+		Dropped:    1,
+	}})
+}
+
+// UnmuteMember lifts a flood mute on a member of a hosted group and refills their bucket.
+func (m *Manager) UnmuteMember(groupID, peerID string) error {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	hg.mu.Lock()
+	delete(hg.buckets, peerID)
+	hg.mu.Unlock()
+	return nil
+}
+
+// MutedMembers returns the currently muted members of a hosted group.
+func (m *Manager) MutedMembers(groupID string) []MutedPayload {
+	m.mu.RLock()
+	hg, exists := m.groups[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	now := time.Now()
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
+	var out []MutedPayload
+	for pid, b := range hg.buckets {
+		if now.Before(b.mutedUntil) {
+			out = append(out, MutedPayload{PeerID: pid, MutedUntil: b.mutedUntil.UnixMilli(), Dropped: b.dropped})
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package group
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowMsg_BurstThenMute(t *testing.T) {
+	hg := &hostedGroup{members: make(map[string]*memberMeta)}
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		if ok, _ := hg.allowMsg("p1", 1, 5, now); !ok {
+			t.Fatalf("message %d within burst should be allowed", i)
+		}
+	}
+
+	ok, justMuted := hg.allowMsg("p1", 1, 5, now)
+	if ok || !justMuted {
+		t.Fatalf("expected mute after burst, got ok=%v justMuted=%v", ok, justMuted)
+	}
+
+	// Still muted even though tokens have refilled
+	later := now.Add(FloodMuteDuration / 2)
+	if ok, justMuted := hg.allowMsg("p1", 1, 5, later); ok || justMuted {
+		t.Fatalf("expected silent drop while muted, got ok=%v justMuted=%v", ok, justMuted)
+	}
+
+	// Other members are unaffected
+	if ok, _ := hg.allowMsg("p2", 1, 5, now); !ok {
+		t.Fatal("other member should not be limited")
+	}
+
+	// Mute expires
+	if ok, _ := hg.allowMsg("p1", 1, 5, now.Add(FloodMuteDuration+time.Second)); !ok {
+		t.Fatal("expected message allowed after mute expires")
+	}
+}
+
+func TestAllowMsg_DisabledByNegativeRate(t *testing.T) {
+	hg := &hostedGroup{}
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		if ok, _ := hg.allowMsg("p1", -1, 1, now); !ok {
+			t.Fatal("negative rate should disable limiting")
+		}
+	}
+}
+
+func TestRateLimits_ConfigOverridesType(t *testing.T) {
+	host := hostManager(t, openTestDB(t))
+	host.RegisterType("game", floodHandler{})
+
+	if rate, burst := host.rateLimits("game"); rate != 0.1 || burst != 2 {
+		t.Fatalf("type limits = %v/%d, want 0.1/2", rate, burst)
+	}
+	host.SetRateLimits(map[string]RateLimit{"game": {Burst: 50}, "chat": {Rate: -1}})
+	if rate, burst := host.rateLimits("game"); rate != 0.1 || burst != 50 {
+		t.Errorf("configured game = %v/%d, want 0.1/50", rate, burst)
+	}
+	if rate, _ := host.rateLimits("chat"); rate > 0 {
+		t.Errorf("configured chat rate = %v, want limiting off", rate)
+	}
+	if rate, burst := host.rateLimits("other"); rate != DefaultMsgRate || burst != DefaultMsgBurst {
+		t.Errorf("unlisted type = %v/%d, want the defaults", rate, burst)
+	}
+}
+
+func TestScenario_FloodingMemberIsMuted(t *testing.T) {
+	// Given a host with a member in a group using a tight rate limit
+	db := openTestDB(t)
+	host := hostManager(t, db)
+	host.RegisterType("game", floodHandler{})
+	_ = host.CreateGroup("g1", "Game", "game", "", 0)
+	host.SimulateJoin("member-a", "g1")
+
+	host.mu.RLock()
+	hg := host.groups["g1"]
+	host.mu.RUnlock()
+
+	// When the member sends more than the burst
+	for i := 0; i < 4; i++ {
+		host.handleHostMessage("member-a", hg, "g1", TypeMsg, map[string]any{"n": i})
+	}
+
+	// Then the member is reported as muted
+	muted := host.MutedMembers("g1")
+	if len(muted) != 1 || muted[0].PeerID != "member-a" {
+		t.Fatalf("expected member-a muted, got %+v", muted)
+	}
+
+	// And unmute lifts it
+	if err := host.UnmuteMember("g1", "member-a"); err != nil {
+		t.Fatal(err)
+	}
+	if muted := host.MutedMembers("g1"); len(muted) != 0 {
+		t.Fatalf("expected no muted members, got %+v", muted)
+	}
+}
+
+type floodHandler struct{}
+
+func (floodHandler) Flags() GroupTypeFlags {
+	return GroupTypeFlags{HostCanJoin: true, MsgRate: 0.1, MsgBurst: 2}
+}
+func (floodHandler) OnCreate(string, string, int) error { return nil }
+func (floodHandler) OnJoin(string, string, bool)        {}
+func (floodHandler) OnLeave(string, string, bool)       {}
+func (floodHandler) OnClose(string)                     {}
+func (floodHandler) OnEvent(*Event)                     {}
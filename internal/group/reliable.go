@@ -0,0 +1,423 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// reliableKey marks a msg/state payload as carrying a sequence envelope.
+const reliableKey = "_rel"
+
+// ReliablePayload is the sequence envelope wrapped around msg/state payloads
+// in ordered groups: those whose type sets GroupTypeFlags.Ordered and those
+// created or configured with ordered delivery (see SetOrdered).
+// Origin is the peer that authored the message; the host preserves it when relaying.
+type ReliablePayload struct {
+	Seq    uint64 `json:"seq"`
+	Origin string `json:"origin"`
+	Type   string `json:"type"`
+	Data   any    `json:"data,omitempty"`
+	TS     int64  `json:"ts,omitempty"` // author's clock when sent, unix millis
+}
+
+// ResendPayload asks the receiver to resend messages [From, To] authored by Origin.
+type ResendPayload struct {
+	Origin string `json:"origin"`
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+}
+
+// LatestPayload carries the newest sequence number of each ordered stream
+// the sender has sent or relayed in a group. It rides on pings and pongs so
+// a receiver that lost the last messages of a stream still asks for them.
+type LatestPayload struct {
+	Latest map[string]uint64 `json:"latest"` // origin -> seq
+}
+
+// GapPayload is emitted to local listeners when missing messages could not be recovered.
+type GapPayload struct {
+	Origin string `json:"origin"`
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+}
+
+// seqOut is the outbound history for one (group, origin) stream, used to
+// answer resend requests.
+type seqOut struct {
+	next    uint64
+	history map[uint64]ReliablePayload
+}
+
+// seqIn tracks receiver-side ordering for one (group, origin) stream.
+type seqIn struct {
+	expected  uint64
+	pending   map[uint64]ReliablePayload
+	gapSince  time.Time
+	requested bool
+}
+
+// reliableState holds all sequence bookkeeping for ordered groups.
+type reliableState struct {
+	mu  sync.Mutex
+	out map[string]*seqOut // groupID|origin -> history
+	in  map[string]*seqIn  // groupID|origin -> receive window
+}
+
+func newReliableState() *reliableState {
+	return &reliableState{
+		out: make(map[string]*seqOut),
+		in:  make(map[string]*seqIn),
+	}
+}
+
+func streamKey(groupID, origin string) string { return groupID + "|" + origin }
+
+// isOrderedType returns whether the given group type has the Ordered flag set.
+func (m *Manager) isOrderedType(groupType string) bool {
+	if h := m.handlerForType(groupType); h != nil {
+		return h.Flags().Ordered
+	}
+	return false
+}
+
+// isOrderedGroup returns whether a hosted group uses ordered delivery, either
+// because its type always does or because the group was set up that way.
+func (m *Manager) isOrderedGroup(hg *hostedGroup) bool {
+	hg.mu.RLock()
+	ordered, groupType := hg.info.Ordered, hg.info.GroupType
+	hg.mu.RUnlock()
+	return ordered || m.isOrderedType(groupType)
+}
+
+// nextReliable wraps a locally authored payload with the next sequence number
+// and records it for resends.
+func (r *reliableState) nextReliable(groupID, origin, msgType string, data any) ReliablePayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	so := r.out[streamKey(groupID, origin)]
+	if so == nil {
+		so = &seqOut{next: 1, history: make(map[uint64]ReliablePayload)}
+		r.out[streamKey(groupID, origin)] = so
+	}
+	env := ReliablePayload{Seq: so.next, Origin: origin, Type: msgType, Data: data, TS: nowMillis()}
+	so.next++
+	r.recordLocked(groupID, env)
+	return env
+}
+
+// record stores a relayed envelope so the host can answer resends for it.
+func (r *reliableState) record(groupID string, env ReliablePayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordLocked(groupID, env)
+}
+
+func (r *reliableState) recordLocked(groupID string, env ReliablePayload) {
+	key := streamKey(groupID, env.Origin)
+	so := r.out[key]
+	if so == nil {
+		so = &seqOut{next: env.Seq + 1, history: make(map[uint64]ReliablePayload)}
+		r.out[key] = so
+	}
+	so.history[env.Seq] = env
+	if env.Seq >= so.next {
+		so.next = env.Seq + 1
+	}
+	if env.Seq > ReliableHistorySize {
+		delete(so.history, env.Seq-ReliableHistorySize)
+	}
+}
+
+// history returns the stored envelopes for [from, to] that are still buffered.
+func (r *reliableState) history(groupID, origin string, from, to uint64) []ReliablePayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	so := r.out[streamKey(groupID, origin)]
+	if so == nil || to < from {
+		return nil
+	}
+	if to-from >= ReliableHistorySize {
+		from = to - ReliableHistorySize + 1
+	}
+	var out []ReliablePayload
+	for seq := from; seq <= to; seq++ {
+		if env, ok := so.history[seq]; ok {
+			out = append(out, env)
+		}
+	}
+	return out
+}
+
+// latest returns the newest sequence number of each stream sent or relayed
+// in a group, or nil when there is none.
+func (r *reliableState) latest(groupID string) map[string]uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out map[string]uint64
+	prefix := groupID + "|"
+	for k, so := range r.out {
+		if origin, ok := strings.CutPrefix(k, prefix); ok && so.next > 1 {
+			if out == nil {
+				out = make(map[string]uint64)
+			}
+			out[origin] = so.next - 1
+		}
+	}
+	return out
+}
+
+// checkLatest compares the streams we receive in a group with the newest
+// sequence numbers the sender reported. Missing trailing messages are
+// requested once; if they are still missing ReliableGapTimeout later, the
+// range is skipped and returned as a gap. Streams we have not seen yet are
+// left alone, as in accept.
+func (r *reliableState) checkLatest(groupID string, latest map[string]uint64, now time.Time) (resends []ResendPayload, gaps []GapPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for origin, seq := range latest {
+		si := r.in[streamKey(groupID, origin)]
+		if si == nil || seq < si.expected || len(si.pending) > 0 {
+			continue // unknown, complete, or a gap accept already handles
+		}
+		switch {
+		case !si.requested:
+			si.requested = true
+			si.gapSince = now
+			resends = append(resends, ResendPayload{Origin: origin, From: si.expected, To: seq})
+		case now.Sub(si.gapSince) >= ReliableGapTimeout:
+			gaps = append(gaps, GapPayload{Origin: origin, From: si.expected, To: seq})
+			si.expected = seq + 1
+			si.gapSince = time.Time{}
+			si.requested = false
+		}
+	}
+	return resends, gaps
+}
+
+// accept feeds an incoming envelope into the receive window. It returns the
+// envelopes that are now deliverable in order, a resend request if a new gap
+// was detected, and a skipped range if a gap timed out.
+func (r *reliableState) accept(groupID string, env ReliablePayload, now time.Time) (deliver []ReliablePayload, resend *ResendPayload, gap *GapPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := streamKey(groupID, env.Origin)
+	si := r.in[key]
+	if si == nil {
+		// First message seen from this origin: start the window here so late
+		// joiners don't request history from before they joined.
+		si = &seqIn{expected: env.Seq, pending: make(map[uint64]ReliablePayload)}
+		r.in[key] = si
+	}
+
+	if env.Seq < si.expected {
+		return nil, nil, nil // duplicate
+	}
+	si.pending[env.Seq] = env
+
+	if env.Seq > si.expected {
+		if si.gapSince.IsZero() {
+			si.gapSince = now
+		}
+		timedOut := now.Sub(si.gapSince) >= ReliableGapTimeout || len(si.pending) > ReliableHistorySize
+		if timedOut {
+			lowest := lowestSeq(si.pending)
+			gap = &GapPayload{Origin: env.Origin, From: si.expected, To: lowest - 1}
+			si.expected = lowest
+		} else if !si.requested {
+			si.requested = true
+			resend = &ResendPayload{Origin: env.Origin, From: si.expected, To: env.Seq - 1}
+		}
+	}
+
+	for {
+		next, ok := si.pending[si.expected]
+		if !ok {
+			break
+		}
+		deliver = append(deliver, next)
+		delete(si.pending, si.expected)
+		si.expected++
+	}
+	if len(si.pending) == 0 {
+		si.gapSince = time.Time{}
+		si.requested = false
+	} else if gap != nil {
+		si.gapSince = now
+		si.requested = false
+	}
+	return deliver, resend, gap
+}
+
+// forget drops all sequence state for a group (on close or leave).
+func (r *reliableState) forget(groupID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prefix := groupID + "|"
+	for k := range r.out {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.out, k)
+		}
+	}
+	for k := range r.in {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.in, k)
+		}
+	}
+}
+
+func lowestSeq(pending map[uint64]ReliablePayload) uint64 {
+	seqs := make([]uint64, 0, len(pending))
+	for s := range pending {
+		seqs = append(seqs, s)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs[0]
+}
+
+// wrapReliable returns the wire payload carrying a sequence envelope.
+func wrapReliable(env ReliablePayload) map[string]any {
+	return map[string]any{reliableKey: env}
+}
+
+// unwrapReliable extracts a sequence envelope from a wire payload.
+func unwrapReliable(payload any) (ReliablePayload, bool) {
+	mp, ok := payload.(map[string]any)
+	if !ok {
+		return ReliablePayload{}, false
+	}
+	raw, ok := mp[reliableKey]
+	if !ok {
+		return ReliablePayload{}, false
+	}
+	if env, ok := raw.(ReliablePayload); ok {
+		return env, true
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return ReliablePayload{}, false
+	}
+	var env ReliablePayload
+	if json.Unmarshal(b, &env) != nil || env.Origin == "" || env.Seq == 0 {
+		return ReliablePayload{}, false
+	}
+	return env, true
+}
+
+func parseResend(payload any) (ResendPayload, bool) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return ResendPayload{}, false
+	}
+	var rp ResendPayload
+	if json.Unmarshal(b, &rp) != nil || rp.Origin == "" {
+		return ResendPayload{}, false
+	}
+	return rp, true
+}
+
+// receiveReliable runs an incoming envelope through the receive window and
+// calls deliver for each message that is now in order. Gaps trigger a resend
+// request to from; unrecoverable gaps are reported to local listeners.
+func (m *Manager) receiveReliable(from, groupID string, env ReliablePayload, deliver func(ReliablePayload)) {
+	ready, resend, gap := m.rel.accept(groupID, env, time.Now())
+	if resend != nil {
+		log.Printf("GROUP: Gap in %s from %s (seq %d..%d), requesting resend", groupID, shortID(resend.Origin), resend.From, resend.To)
+		go m.sendResendRequest(from, groupID, *resend)
+	}
+	if gap != nil {
+		log.Printf("GROUP: Gave up on %s seq %d..%d from %s", groupID, gap.From, gap.To, shortID(gap.Origin))
+		m.notifyListeners(&Event{Type: TypeGap, Group: groupID, From: gap.Origin, Payload: *gap})
+	}
+	for _, e := range ready {
+		deliver(e)
+	}
+}
+
+// latestPayload returns what we report about our ordered streams in a ping
+// or pong, nil when there is nothing to report.
+func (m *Manager) latestPayload(groupID string) any {
+	if latest := m.rel.latest(groupID); latest != nil {
+		return LatestPayload{Latest: latest}
+	}
+	return nil
+}
+
+// checkLatest handles the sequence numbers from on a ping or pong: it asks
+// from for trailing messages we never got and reports those that did not
+// come after all.
+func (m *Manager) checkLatest(from, groupID string, payload any) {
+	var msg Message
+	var lp LatestPayload
+	if !decodePayload(payload, &msg) || msg.Payload == nil || !decodePayload(msg.Payload, &lp) {
+		return
+	}
+	resends, gaps := m.rel.checkLatest(groupID, lp.Latest, time.Now())
+	for _, rp := range resends {
+		log.Printf("GROUP: Missing the tail of %s from %s (seq %d..%d), requesting resend", groupID, shortID(rp.Origin), rp.From, rp.To)
+		go m.sendResendRequest(from, groupID, rp)
+	}
+	for _, gap := range gaps {
+		log.Printf("GROUP: Gave up on %s seq %d..%d from %s", groupID, gap.From, gap.To, shortID(gap.Origin))
+		m.notifyListeners(&Event{Type: TypeGap, Group: groupID, From: gap.Origin, Payload: gap})
+	}
+}
+
+func (m *Manager) sendResendRequest(to, groupID string, rp ResendPayload) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+	defer cancel()
+	_, _ = m.mq.Send(ctx, to, "group:"+groupID+":"+TypeResend, rp)
+}
+
+// mayResendTo reports whether a hosted group's history may go to peerID:
+// a member or a peer relaying the group.
+func (hg *hostedGroup) mayResendTo(peerID string) bool {
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
+	if _, ok := hg.members[peerID]; ok {
+		return true
+	}
+	return peerID == hg.owner || slices.Contains(hg.relays, peerID)
+}
+
+// mayResendTo reports whether our stream in a joined group may go to
+// peerID: the peer we are connected to, the owner or a relay.
+func (cc *clientConn) mayResendTo(peerID string) bool {
+	if peerID == cc.hostPeerID || peerID == cc.ownerID() {
+		return true
+	}
+	cc.membersMu.RLock()
+	defer cc.membersMu.RUnlock()
+	return slices.Contains(cc.relays, peerID)
+}
+
+// answerResend replays buffered envelopes to the requesting peer. Callers
+// check that the peer may see the group's history first.
+func (m *Manager) answerResend(to, groupID string, payload any) {
+	rp, ok := parseResend(payload)
+	if !ok {
+		return
+	}
+	envs := m.rel.history(groupID, rp.Origin, rp.From, rp.To)
+	if len(envs) == 0 {
+		return
+	}
+	go func() {
+		for _, env := range envs {
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
+			_, err := m.mq.Send(ctx, to, "group:"+groupID+":"+env.Type, wrapReliable(env))
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,261 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/mq"
+)
+
+func env(seq uint64) ReliablePayload {
+	return ReliablePayload{Seq: seq, Origin: "peer-a", Type: TypeMsg, Data: seq}
+}
+
+func TestReliableAccept_InOrder(t *testing.T) {
+	r := newReliableState()
+	now := time.Now()
+	for seq := uint64(1); seq <= 3; seq++ {
+		ready, resend, gap := r.accept("g1", env(seq), now)
+		if len(ready) != 1 || ready[0].Seq != seq || resend != nil || gap != nil {
+			t.Fatalf("seq %d: ready=%v resend=%v gap=%v", seq, ready, resend, gap)
+		}
+	}
+}
+
+func TestReliableAccept_GapRequestsResendAndReorders(t *testing.T) {
+	r := newReliableState()
+	now := time.Now()
+	r.accept("g1", env(1), now)
+
+	ready, resend, _ := r.accept("g1", env(4), now)
+	if len(ready) != 0 {
+		t.Fatalf("out-of-order message should be held, got %v", ready)
+	}
+	if resend == nil || resend.From != 2 || resend.To != 3 {
+		t.Fatalf("expected resend 2..3, got %+v", resend)
+	}
+
+	// A second out-of-order message must not re-request
+	if _, resend, _ := r.accept("g1", env(5), now); resend != nil {
+		t.Fatalf("unexpected duplicate resend request: %+v", resend)
+	}
+
+	r.accept("g1", env(2), now)
+	ready, _, _ = r.accept("g1", env(3), now)
+	if len(ready) != 3 || ready[0].Seq != 3 || ready[2].Seq != 5 {
+		t.Fatalf("expected 3,4,5 delivered in order, got %v", ready)
+	}
+
+	// Duplicates are dropped
+	if ready, _, _ := r.accept("g1", env(2), now); len(ready) != 0 {
+		t.Fatalf("duplicate should be dropped, got %v", ready)
+	}
+}
+
+func TestReliableAccept_GapTimeoutSkips(t *testing.T) {
+	r := newReliableState()
+	now := time.Now()
+	r.accept("g1", env(1), now)
+	r.accept("g1", env(3), now)
+
+	ready, _, gap := r.accept("g1", env(4), now.Add(ReliableGapTimeout))
+	if gap == nil || gap.From != 2 || gap.To != 2 {
+		t.Fatalf("expected gap 2..2, got %+v", gap)
+	}
+	if len(ready) != 2 || ready[0].Seq != 3 {
+		t.Fatalf("expected 3,4 delivered after skip, got %v", ready)
+	}
+}
+
+func TestReliableCheckLatest_RequestsLostTail(t *testing.T) {
+	sender := newReliableState()
+	for _, seq := range []uint64{1, 2, 3} {
+		sender.record("g1", env(seq))
+	}
+	latest := sender.latest("g1")
+	if latest["peer-a"] != 3 {
+		t.Fatalf("latest = %v, want peer-a at 3", latest)
+	}
+
+	// Only seq 1 arrived; the ping tells us about 2 and 3.
+	r := newReliableState()
+	now := time.Now()
+	r.accept("g1", env(1), now)
+	resends, gaps := r.checkLatest("g1", map[string]uint64{"peer-a": 3, "unseen": 9}, now)
+	if len(resends) != 1 || resends[0] != (ResendPayload{Origin: "peer-a", From: 2, To: 3}) || len(gaps) != 0 {
+		t.Fatalf("resends=%+v gaps=%+v, want one resend 2..3", resends, gaps)
+	}
+	if resends, _ := r.checkLatest("g1", latest, now); len(resends) != 0 {
+		t.Fatalf("tail requested twice: %+v", resends)
+	}
+
+	// Resent in time: delivered, and nothing left to ask for.
+	r.accept("g1", env(2), now)
+	if ready, _, _ := r.accept("g1", env(3), now); len(ready) != 1 || ready[0].Seq != 3 {
+		t.Fatalf("resent tail not delivered: %v", ready)
+	}
+	if resends, gaps := r.checkLatest("g1", latest, now); len(resends) != 0 || len(gaps) != 0 {
+		t.Fatalf("complete stream: resends=%+v gaps=%+v", resends, gaps)
+	}
+
+	// Never resent: skipped once the gap times out.
+	r.checkLatest("g1", map[string]uint64{"peer-a": 5}, now)
+	_, gaps = r.checkLatest("g1", map[string]uint64{"peer-a": 5}, now.Add(ReliableGapTimeout))
+	if len(gaps) != 1 || gaps[0].From != 4 || gaps[0].To != 5 {
+		t.Fatalf("gaps = %+v, want 4..5", gaps)
+	}
+	if ready, _, _ := r.accept("g1", env(6), now.Add(ReliableGapTimeout)); len(ready) != 1 {
+		t.Fatalf("stream stuck after the skipped tail: %v", ready)
+	}
+}
+
+func TestReliableHistoryAndWireRoundTrip(t *testing.T) {
+	r := newReliableState()
+	for i := 0; i < 3; i++ {
+		r.nextReliable("g1", "self", TypeMsg, map[string]any{"i": i})
+	}
+	hist := r.history("g1", "self", 2, 3)
+	if len(hist) != 2 || hist[0].Seq != 2 {
+		t.Fatalf("expected seq 2..3 in history, got %v", hist)
+	}
+
+	b, _ := json.Marshal(wrapReliable(hist[0]))
+	var wire any
+	_ = json.Unmarshal(b, &wire)
+	got, ok := unwrapReliable(wire)
+	if !ok || got.Seq != 2 || got.Origin != "self" {
+		t.Fatalf("round trip failed: ok=%v env=%+v", ok, got)
+	}
+
+	r.forget("g1")
+	if hist := r.history("g1", "self", 1, 3); len(hist) != 0 {
+		t.Fatalf("expected history cleared, got %v", hist)
+	}
+}
+
+func TestLocalTime_UsesAuthorClock(t *testing.T) {
+	m := &Manager{selfID: "self"}
+	now := time.Now().UnixMilli()
+	if got := m.localTime("peer-a", now); got != 0 {
+		t.Fatalf("no clock source: got %d, want 0", got)
+	}
+
+	// peer-a's clock runs 1.5s ahead of ours.
+	m.SetClock(func(peerID string) (float64, bool) { return 1500, peerID == "peer-a" })
+	if got := m.localTime("peer-a", now+1500-200); got != now-200 {
+		t.Fatalf("got %d, want %d", got, now-200)
+	}
+	if got := m.localTime("peer-b", now); got != 0 {
+		t.Fatalf("no estimate: got %d, want 0", got)
+	}
+	if got := m.localTime("self", now-50); got != now-50 {
+		t.Fatalf("own timestamp changed: %d", got)
+	}
+	if got := m.localTime("peer-a", now+60_000); got > time.Now().UnixMilli() {
+		t.Fatalf("future timestamp not clamped: %d", got)
+	}
+}
+
+// sendRecorder records the peers messages are sent to.
+type sendRecorder struct {
+	mq.NopTransport
+	mu sync.Mutex
+	to []string
+}
+
+func (r *sendRecorder) Send(_ context.Context, peerID, _ string, _ any) (string, error) {
+	r.mu.Lock()
+	r.to = append(r.to, peerID)
+	r.mu.Unlock()
+	return "", nil
+}
+
+func (r *sendRecorder) sentTo(peerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Contains(r.to, peerID)
+}
+
+type orderedHandler struct{ floodHandler }
+
+func (orderedHandler) Flags() GroupTypeFlags { return GroupTypeFlags{HostCanJoin: true, Ordered: true} }
+
+func TestHostDropsSpoofedOrigin(t *testing.T) {
+	host := hostManager(t, openTestDB(t))
+	host.RegisterType("game", orderedHandler{})
+	_ = host.CreateGroup("g1", "Game", "game", "", 0)
+	host.SimulateJoin("member-a", "g1")
+	host.SimulateJoin("member-b", "g1")
+	host.mu.RLock()
+	hg := host.groups["g1"]
+	host.mu.RUnlock()
+
+	spoofed := ReliablePayload{Seq: 1, Origin: "member-b", Type: TypeMsg, Data: "hi"}
+	host.handleHostMessage("member-a", hg, "g1", TypeMsg, wrapReliable(spoofed))
+	if h := host.rel.history("g1", "member-b", 1, 1); len(h) != 0 {
+		t.Fatalf("spoofed envelope recorded under member-b: %+v", h)
+	}
+
+	host.handleHostMessage("member-b", hg, "g1", TypeMsg, wrapReliable(spoofed))
+	if h := host.rel.history("g1", "member-b", 1, 1); len(h) != 1 {
+		t.Fatalf("envelope from its author not relayed: %+v", h)
+	}
+}
+
+func TestSetOrderedSequencesPlainType(t *testing.T) {
+	host := hostManager(t, openTestDB(t))
+	host.RegisterType("chat", floodHandler{})
+	_ = host.CreateGroup("g1", "Chat", "chat", "", 0)
+	host.SimulateJoin("member-a", "g1")
+	host.mu.RLock()
+	hg := host.groups["g1"]
+	host.mu.RUnlock()
+
+	sent := ReliablePayload{Seq: 1, Origin: "member-a", Type: TypeMsg, Data: "hi"}
+	host.handleHostMessage("member-a", hg, "g1", TypeMsg, wrapReliable(sent))
+	if h := host.rel.history("g1", "member-a", 1, 1); len(h) != 0 {
+		t.Fatalf("unordered group kept history: %+v", h)
+	}
+
+	if err := host.SetOrdered("g1", true); err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := host.db.GetGroup("g1"); !g.Ordered {
+		t.Error("ordered not persisted")
+	}
+	host.handleHostMessage("member-a", hg, "g1", TypeMsg, wrapReliable(sent))
+	if h := host.rel.history("g1", "member-a", 1, 1); len(h) != 1 {
+		t.Fatalf("ordered group did not sequence the message: %+v", h)
+	}
+}
+
+func TestResendOnlyToMembers(t *testing.T) {
+	tr := &sendRecorder{}
+	host := NewTestManager(openTestDB(t), "host-peer-id", TestManagerOpts{MQ: tr})
+	t.Cleanup(func() { host.Close() })
+	host.RegisterType("game", orderedHandler{})
+	_ = host.CreateGroup("g1", "Game", "game", "", 0)
+	host.SimulateJoin("member-a", "g1")
+	host.mu.RLock()
+	hg := host.groups["g1"]
+	host.mu.RUnlock()
+	host.rel.record("g1", ReliablePayload{Seq: 1, Origin: "member-a", Type: TypeMsg, Data: "secret"})
+
+	ask := ResendPayload{Origin: "member-a", From: 1, To: 1}
+	host.handleHostMessage("stranger", hg, "g1", TypeResend, ask)
+	host.handleHostMessage("member-a", hg, "g1", TypeResend, ask)
+	deadline := time.Now().Add(time.Second)
+	for !tr.sentTo("member-a") {
+		if time.Now().After(deadline) {
+			t.Fatal("no resend to the member")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if tr.sentTo("stranger") {
+		t.Fatal("history resent to a non-member")
+	}
+}
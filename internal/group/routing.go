@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 func (m *Manager) handleMQMessage(from, groupID, msgType string, payload any) {
@@ -17,6 +20,11 @@ func (m *Manager) handleMQMessage(from, groupID, msgType string, payload any) {
 	pendingCh := m.pendingJoins[groupID]
 	m.pendingJoinsMu.Unlock()
 
+	if msgType == TypeOwner {
+		m.handleOwnerChange(from, groupID, payload)
+		return
+	}
+
 	switch {
 	case hg != nil:
 		m.handleHostMessage(from, hg, groupID, msgType, payload)
@@ -35,13 +43,10 @@ func (m *Manager) handleHostMessage(from string, hg *hostedGroup, groupID, msgTy
 	switch msgType {
 	case TypeJoin:
 		hg.mu.Lock()
-		currentCount := len(hg.members)
-		if hg.hostJoined {
-			currentCount++
-		}
+		currentCount := len(hg.memberList(m.selfID))
 		if hg.info.MaxMembers > 0 && currentCount >= hg.info.MaxMembers {
 			hg.mu.Unlock()
-			ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 			defer cancel()
 			_, _ = m.mq.Send(ctx, from, "group:"+groupID+":"+TypeError,
 				Message{Type: TypeError, Group: groupID, Payload: ErrorPayload{Code: "full", Message: "group is full"}})
@@ -57,22 +62,35 @@ func (m *Manager) handleHostMessage(from string, hg *hostedGroup, groupID, msgTy
 		groupContext := hg.info.GroupContext
 		name := hg.info.Name
 		maxMembers := hg.info.MaxMembers
+		owner := hg.owner
+		if owner == "" {
+			owner = m.selfID
+		}
+		relays := m.relayListLocked(hg)
+		token := m.welcomeTokenLocked(hg, groupID, from)
 		hg.mu.Unlock()
+		ordered := m.isOrderedGroup(hg)
 
 		log.Printf("GROUP: %s joined group %s", shortID(from), groupID)
 
-		ctx, cancel := context.WithTimeout(context.Background(), BroadcastTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 		_, _ = m.mq.Send(ctx, from, "group:"+groupID+":"+TypeWelcome, WelcomePayload{
 			GroupName:    name,
 			GroupType:    groupType,
 			GroupContext: groupContext,
 			MaxMembers:  maxMembers,
 			Volatile:    m.isVolatileType(groupType),
+			Ordered:     ordered,
 			Members:     memberList,
+			Owner:       owner,
+			Relays:      relays,
+			Token:       token,
 		})
 		cancel()
 
 		m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: memberList}, from)
+		m.syncRelayMembers(hg, groupID)
+		m.maybeGoLarge(hg, groupID)
 		m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, Payload: MembersPayload{Members: memberList}})
 
 		if !m.isVolatileType(groupType) && len(memberList) > 0 {
@@ -83,11 +101,15 @@ func (m *Manager) handleHostMessage(from string, hg *hostedGroup, groupID, msgTy
 
 		if h := m.handlerForType(groupType); h != nil {
 			h.OnJoin(groupID, from, false)
+			if sp, ok := h.(StateProvider); ok {
+				go m.sendSnapshot(sp, groupID, from)
+			}
 		}
 
 	case TypeLeave:
 		hg.mu.Lock()
 		delete(hg.members, from)
+		delete(hg.buckets, from)
 		members := hg.memberList(m.selfID)
 		groupType := hg.info.GroupType
 		hg.mu.Unlock()
@@ -95,6 +117,7 @@ func (m *Manager) handleHostMessage(from string, hg *hostedGroup, groupID, msgTy
 		log.Printf("GROUP: %s left group %s", shortID(from), groupID)
 
 		m.broadcastToGroup(hg, groupID, TypeMembers, MembersPayload{Members: members}, "")
+		m.syncRelayMembers(hg, groupID)
 		m.notifyListeners(&Event{Type: TypeMembers, Group: groupID, From: from, Payload: MembersPayload{Members: members}})
 
 		if !m.isVolatileType(groupType) {
@@ -109,10 +132,50 @@ func (m *Manager) handleHostMessage(from string, hg *hostedGroup, groupID, msgTy
 
 	case TypePong:
 		log.Printf("GROUP: Pong from %s in group %s", shortID(from), groupID)
+		m.checkLatest(from, groupID, payload)
 
 	case TypeMsg, TypeState:
+		hg.mu.RLock()
+		groupType := hg.info.GroupType
+		hg.mu.RUnlock()
+		rate, burst := m.rateLimits(groupType)
+		now := time.Now()
+		hg.mu.Lock()
+		ok, justMuted := hg.allowMsg(from, rate, burst, now)
+		hg.mu.Unlock()
+		if !ok {
+			if justMuted {
+				m.handleFlood(groupID, from, now.Add(FloodMuteDuration))
+			}
+			return
+		}
+		env, reliable := unwrapReliable(payload)
+		if reliable && env.Origin != from {
+			// Only the author may send its stream; members trust the host
+			// to have checked this when it relays with the origin kept.
+			log.Printf("GROUP: Dropping %s in %s from %s claiming to be %s", msgType, groupID, shortID(from), shortID(env.Origin))
+			return
+		}
+		if reliable && m.isOrderedGroup(hg) {
+			m.receiveReliable(from, groupID, env, func(e ReliablePayload) {
+				m.rel.record(groupID, e)
+				m.broadcastToGroup(hg, groupID, e.Type, wrapReliable(e), from)
+				m.forwardToRelays(hg, groupID, from, e.Type, wrapReliable(e))
+				m.notifyListeners(&Event{Type: e.Type, Group: groupID, From: e.Origin, Payload: e.Data, TS: m.localTime(e.Origin, e.TS)})
+			})
+			return
+		}
 		m.broadcastToGroup(hg, groupID, msgType, payload, from)
+		m.forwardToRelays(hg, groupID, from, msgType, payload)
 		m.notifyListeners(&Event{Type: msgType, Group: groupID, From: from, Payload: payload})
+
+	case TypeResend:
+		if hg.mayResendTo(from) {
+			m.answerResend(from, groupID, payload)
+		}
+
+	case TypeRelay, TypeRelayMembers, TypeCoHost, TypeCoHostEnd, TypeKick, TypeClose:
+		m.handleRelayMessage(from, hg, groupID, msgType, payload)
 	}
 }
 
@@ -136,12 +199,14 @@ func (m *Manager) handleMemberMessage(from string, cc *clientConn, groupID, msgT
 
 	case TypeClose:
 		groupType := cc.groupType
+		cc.closeTopic()
 		m.mu.Lock()
 		if m.activeConns[groupID] == cc {
 			delete(m.activeConns, groupID)
 		}
 		m.mu.Unlock()
-		m.db.RemoveSubscription(cc.hostPeerID, groupID) //nolint:errcheck
+		m.db.RemoveSubscription(cc.ownerID(), groupID) //nolint:errcheck
+		m.rel.forget(groupID)
 		m.notifyListeners(&Event{Type: TypeClose, Group: groupID})
 		if h := m.handlerForType(groupType); h != nil {
 			h.OnClose(groupID)
@@ -149,10 +214,11 @@ func (m *Manager) handleMemberMessage(from string, cc *clientConn, groupID, msgT
 		log.Printf("GROUP: Group %s closed by host", groupID)
 
 	case TypePing:
+		m.checkLatest(from, groupID, payload)
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 			defer cancel()
-			_, _ = m.mq.Send(ctx, from, "group:"+groupID+":"+TypePong, Message{Type: TypePong, Group: groupID})
+			_, _ = m.mq.Send(ctx, from, "group:"+groupID+":"+TypePong, Message{Type: TypePong, Group: groupID, Payload: m.latestPayload(groupID)})
 		}()
 
 	case TypeMeta:
@@ -160,14 +226,53 @@ func (m *Manager) handleMemberMessage(from string, cc *clientConn, groupID, msgT
 			if b, err := json.Marshal(rawPayload); err == nil {
 				var mp MetaPayload
 				if json.Unmarshal(b, &mp) == nil && mp.GroupName != "" {
-					_ = m.db.AddSubscription(cc.hostPeerID, groupID, mp.GroupName, mp.GroupType, mp.MaxMembers, m.isVolatileType(cc.groupType), "member", m.resolvePeerName(cc.hostPeerID))
+					_ = m.db.AddSubscription(cc.ownerID(), groupID, mp.GroupName, mp.GroupType, mp.MaxMembers, m.isVolatileType(cc.groupType), "member", m.resolvePeerName(cc.ownerID()))
+					ordered := mp.Ordered || m.isOrderedType(cc.groupType)
+					m.mu.Lock()
+					cc.ordered = ordered
+					m.mu.Unlock()
 				}
 			}
 		}
 		m.notifyListeners(&Event{Type: TypeMeta, Group: groupID, Payload: payload})
 
-	case TypeMsg, TypeState, TypeError:
+	case TypeMsg, TypeState:
+		if env, ok := unwrapReliable(payload); ok {
+			m.receiveReliable(from, groupID, env, func(e ReliablePayload) {
+				m.notifyListeners(&Event{Type: e.Type, Group: groupID, From: e.Origin, Payload: e.Data, TS: m.localTime(e.Origin, e.TS)})
+			})
+			return
+		}
+		m.notifyListeners(&Event{Type: msgType, Group: groupID, From: from, Payload: payload})
+
+	case TypeError:
 		m.notifyListeners(&Event{Type: msgType, Group: groupID, From: from, Payload: payload})
+
+	case TypeResend:
+		if cc.mayResendTo(from) {
+			m.answerResend(from, groupID, payload)
+		}
+
+	case TypeCoHost:
+		var cp CoHostPayload
+		if from == cc.ownerID() && decodePayload(payload, &cp) {
+			m.becomeCoHost(cc, cp)
+		}
+
+	case TypeRelays:
+		m.updateRelays(cc, payload)
+
+	case TypeHandover:
+		var hp HandoverPayload
+		if from == cc.ownerID() && decodePayload(payload, &hp) {
+			m.takeOver(cc, hp, from, OwnerTransfer)
+		}
+
+	case TypeTopic:
+		var tp TopicPayload
+		if from == cc.hostPeerID && decodePayload(payload, &tp) {
+			m.acceptToken(cc, tp.Token)
+		}
 	}
 }
 
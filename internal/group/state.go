@@ -1,6 +1,7 @@
 package group
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 )
@@ -12,3 +13,11 @@ func writeFile(dir, name string, data []byte) error {
 func readFile(dir, name string) ([]byte, error) {
 	return os.ReadFile(filepath.Join(dir, name))
 }
+
+func removeFile(dir, name string) error {
+	err := os.Remove(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -17,12 +17,16 @@ type TestManagerOpts struct {
 // Pass TestManagerOpts to wire in an MQ sender or resolver.
 func NewTestManager(db *storage.DB, selfID string, opts ...TestManagerOpts) *Manager {
 	m := &Manager{
-		db:           db,
-		selfID:       selfID,
-		groups:       make(map[string]*hostedGroup),
-		activeConns:  make(map[string]*clientConn),
-		pendingJoins: make(map[string]chan joinResult),
-		handlers:     make(map[string]TypeHandler),
+		db:             db,
+		selfID:         selfID,
+		groups:         make(map[string]*hostedGroup),
+		activeConns:    make(map[string]*clientConn),
+		pendingJoins:   make(map[string]chan joinResult),
+		handlers:       make(map[string]TypeHandler),
+		rel:            newReliableState(),
+		presence:       make(map[string]bool),
+		largeThreshold: LargeGroupThreshold,
+		electionDelay:  OwnerElectionDelay,
 	}
 	if len(opts) > 0 {
 		m.resolvePeer = opts[0].ResolvePeer
@@ -69,6 +73,11 @@ func (m *Manager) SimulateHostClose(groupID string) {
 	}
 }
 
+// SimulatePeerAnnounce processes a peer:announce payload as if it arrived via MQ.
+func (m *Manager) SimulatePeerAnnounce(payload any) {
+	m.handlePeerAnnounce(payload)
+}
+
 // SetActiveConn sets up a fake client connection for testing.
 func (m *Manager) SetActiveConn(groupID, hostPeerID, groupType string) {
 	m.mu.Lock()
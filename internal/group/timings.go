@@ -2,13 +2,29 @@ package group
 
 import "time"
 
-// Group protocol timings.
+// Group protocol timings. Sends and joins use the tunable timeouts.GroupSend
+// and timeouts.GroupJoin.
 const (
 	PingInterval       = 60 * time.Second // host → member heartbeat
-	SendTimeout        = 5 * time.Second  // single MQ send (leave, close, kick, pong)
-	BroadcastTimeout   = 5 * time.Second  // MQ send for welcome, broadcast, ping
-	JoinTimeout        = 5 * time.Second  // full join handshake (send + wait for welcome)
-	ReconnectTimeout   = 5 * time.Second  // reconnect attempt per subscription
 	DiscoveryWait      = 3 * time.Second  // wait for mDNS/rendezvous before reconnecting
 	ClusterSendTimeout = 3 * time.Second  // cluster MQ send (tighter for job scheduling)
+	FloodMuteDuration  = 10 * time.Second // member mute after exceeding the message rate
+	ReliableGapTimeout = 3 * time.Second  // ordered groups: give up on a missing seq after this
+	OwnerElectionDelay = 2 * time.Minute  // owner offline this long before the longest-joined member takes over
+)
+
+// Large group mode (see large.go).
+const (
+	LargeGroupThreshold = 32               // members before a group moves to its GossipSub topic
+	AdmissionTokenTTL   = 10 * time.Minute // lifetime of a topic admission token; renewed at half-life
+)
+
+// ReliableHistorySize is the number of messages per sender kept for resends
+// in ordered groups.
+const ReliableHistorySize = 256
+
+// Default per-member flood protection thresholds (see GroupTypeFlags).
+const (
+	DefaultMsgRate  = 20.0 // messages per second
+	DefaultMsgBurst = 40   // messages
 )
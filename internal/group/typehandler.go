@@ -4,6 +4,19 @@ package group
 type GroupTypeFlags struct {
 	HostCanJoin bool // whether the host can join their own group as a member
 	Volatile    bool // ephemeral: no member persistence, excluded from group cap
+	Ordered     bool // sequence-numbered msg/state with gap detection and resend
+
+	// CoHost lets the owner add co-hosts that relay the group alongside it.
+	// Co-hosts call the same host-side hooks for their own members, so only
+	// set it for types whose hooks keep no state that must live on the owner.
+	// Types without a handler can always be co-hosted.
+	CoHost bool
+
+	// Flood protection for member msg/state traffic relayed by the host.
+	// Zero values fall back to DefaultMsgRate / DefaultMsgBurst; a negative
+	// MsgRate disables rate limiting for the type.
+	MsgRate  float64 // sustained messages per second per member
+	MsgBurst int     // bucket size (messages allowed in a burst)
 }
 
 // TypeHandler defines lifecycle hooks for a group group_type.
@@ -31,6 +44,15 @@ type TypeHandler interface {
 	OnEvent(evt *Event)
 }
 
+// StateProvider is an optional extension of TypeHandler. When the handler for
+// a group's type implements it, the host sends the returned snapshot to every
+// newly joined member as a TypeState message (wrapped in SnapshotPayload), so
+// apps like whiteboards, games and pads get catch-up for free.
+// Return nil to skip sending a snapshot.
+type StateProvider interface {
+	SnapshotState(groupID string) any
+}
+
 // GroupTypeFlagsForGroup returns the GroupTypeFlags for a group's group_type.
 // Returns default flags (all true) if no handler is registered.
 func (m *Manager) GroupTypeFlagsForGroup(groupID string) GroupTypeFlags {
@@ -13,9 +13,10 @@ import (
 const (
 	topicPrefix = mq.TopicChatRoomPrefix // "chat.room:"
 
-	subtopicMsg     = "msg"
-	subtopicHistory = "history"
-	subtopicMembers = "members"
+	subtopicMsg      = "msg"
+	subtopicHistory  = "history"
+	subtopicMembers  = "members"
+	subtopicBackfill = "backfill"
 
 	sendTimeout = 4 * time.Second
 )
@@ -25,6 +26,7 @@ type chatMsg struct {
 	Message  *Message  `json:"message,omitempty"`
 	Messages []Message `json:"messages,omitempty"`
 	Members  []Member  `json:"members,omitempty"`
+	Limit    int       `json:"limit,omitempty"` // backfill: how many messages
 }
 
 func topic(groupID, sub string) string {
@@ -112,7 +114,14 @@ func (m *Manager) handleIncoming(from, t string, payload any) {
 		rs.mu.Lock()
 		rs.history.Add(*msg.Message)
 		rs.mu.Unlock()
+		m.persist(groupID, *msg.Message)
 
 		m.broadcastToRoom(groupID, subtopicMsg, msg, from)
+
+	case subtopicBackfill:
+		m.handleBackfill(from, groupID, msg.Limit)
+
+	case subtopicHistory:
+		m.handleHistory(from, groupID, msg.Messages)
 	}
 }
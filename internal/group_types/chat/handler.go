@@ -13,14 +13,9 @@ func (m *Manager) Flags() group.GroupTypeFlags {
 }
 
 func (m *Manager) OnCreate(groupID, name string, _ int) error {
+	rs := m.newRoomState(groupID, name)
 	m.mu.Lock()
-	m.rooms[groupID] = &roomState{
-		info: Room{
-			ID:   groupID,
-			Name: name,
-		},
-		history: &RingBuffer{},
-	}
+	m.rooms[groupID] = rs
 	m.mu.Unlock()
 	log.Printf("CHAT: Room %s created (%s)", groupID, name)
 	return nil
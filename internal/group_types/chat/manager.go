@@ -23,6 +23,7 @@ type Manager struct {
 
 	mu    sync.RWMutex
 	rooms map[string]*roomState
+	store Store // nil = history only in memory
 
 	unsubMQ func()
 }
@@ -120,12 +121,10 @@ func (m *Manager) JoinRoom(ctx context.Context, hostPeerID, groupID string) erro
 			}
 		}
 	}
+	rs := m.newRoomState(groupID, name)
 	m.mu.Lock()
 	if _, exists := m.rooms[groupID]; !exists {
-		m.rooms[groupID] = &roomState{
-			info:    Room{ID: groupID, Name: name},
-			history: &RingBuffer{},
-		}
+		m.rooms[groupID] = rs
 	}
 	m.mu.Unlock()
 	return nil
@@ -146,7 +145,7 @@ func (m *Manager) SendMessage(groupID, fromPeerID, text string) error {
 	}
 
 	msg := Message{
-		ID:        fmt.Sprintf("%d-%s", time.Now().UnixMilli(), fromPeerID[:8]),
+		ID:        fmt.Sprintf("%d-%s", time.Now().UnixNano(), fromPeerID[:8]),
 		From:      fromPeerID,
 		FromName:  m.resolvePeer(fromPeerID).Name(),
 		Text:      text,
@@ -156,6 +155,7 @@ func (m *Manager) SendMessage(groupID, fromPeerID, text string) error {
 	rs.mu.Lock()
 	rs.history.Add(msg)
 	rs.mu.Unlock()
+	m.persist(groupID, msg)
 
 	cm := chatMsg{Action: subtopicMsg, Message: &msg}
 	m.broadcastToRoom(groupID, subtopicMsg, cm, "")
@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Room history outlives the in-memory ring buffer once a Store is set:
+// every message sent, received or backfilled is persisted per group, and
+// History and backfill replies read from it. A member that joined late
+// asks the host for the last N messages on the "backfill" subtopic; the
+// host answers on "history", which the member merges into what it has.
+
+// Store persists chat room messages; *storage.DB implements it.
+type Store interface {
+	StoreGroupChatMessage(groupID string, m storage.GroupChatMessage) error
+	GetGroupChatHistory(groupID string, limit int) ([]storage.GroupChatMessage, error)
+}
+
+// maxBackfill caps the messages a host sends for one backfill request.
+const maxBackfill = 500
+
+// SetStore persists room messages in s and seeds the rooms already open
+// from it.
+func (m *Manager) SetStore(s Store) {
+	m.mu.Lock()
+	m.store = s
+	rooms := make(map[string]*roomState, len(m.rooms))
+	maps.Copy(rooms, m.rooms)
+	m.mu.Unlock()
+
+	for id, rs := range rooms {
+		rs.mu.Lock()
+		if rs.history.size == 0 {
+			rs.history = m.loadHistory(id)
+		}
+		rs.mu.Unlock()
+	}
+}
+
+func (m *Manager) getStore() Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store
+}
+
+// newRoomState returns the state of a room, its ring seeded from the store.
+func (m *Manager) newRoomState(groupID, name string) *roomState {
+	return &roomState{
+		info:    Room{ID: groupID, Name: name},
+		history: m.loadHistory(groupID),
+	}
+}
+
+// loadHistory returns a ring buffer holding the latest stored messages.
+// Callers must not hold m.mu.
+func (m *Manager) loadHistory(groupID string) *RingBuffer {
+	r := &RingBuffer{}
+	s := m.getStore()
+	if s == nil {
+		return r
+	}
+	stored, err := s.GetGroupChatHistory(groupID, maxHistory)
+	if err != nil {
+		log.Printf("CHAT: load history of %s: %v", groupID, err)
+		return r
+	}
+	for _, sm := range stored {
+		r.Add(Message(sm))
+	}
+	return r
+}
+
+func (m *Manager) persist(groupID string, msgs ...Message) {
+	s := m.getStore()
+	if s == nil {
+		return
+	}
+	for _, msg := range msgs {
+		if err := s.StoreGroupChatMessage(groupID, storage.GroupChatMessage(msg)); err != nil {
+			log.Printf("CHAT: persist message in %s: %v", groupID, err)
+		}
+	}
+}
+
+// History returns the last limit messages of a room, oldest first. With a
+// store it also covers rooms that were left or closed.
+func (m *Manager) History(groupID string, limit int) ([]Message, error) {
+	if s := m.getStore(); s != nil {
+		stored, err := s.GetGroupChatHistory(groupID, limit)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]Message, len(stored))
+		for i, sm := range stored {
+			out[i] = Message(sm)
+		}
+		return out, nil
+	}
+
+	m.mu.RLock()
+	rs, exists := m.rooms[groupID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("room not found: %s", groupID)
+	}
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if limit <= 0 {
+		return rs.history.All(), nil
+	}
+	return rs.history.Recent(limit), nil
+}
+
+// RequestBackfill asks the host of a joined room for its last n messages.
+// They arrive on the "history" subtopic and are merged into the room.
+func (m *Manager) RequestBackfill(groupID string, n int) error {
+	host, connected := m.grp.ActiveGroup(groupID)
+	if !connected {
+		return fmt.Errorf("not connected to room %s", groupID)
+	}
+	if host == m.selfID {
+		return nil // the host has the full history
+	}
+	if n <= 0 || n > maxBackfill {
+		n = maxBackfill
+	}
+	m.sendToPeer(host, groupID, subtopicBackfill, chatMsg{Action: subtopicBackfill, Limit: n})
+	return nil
+}
+
+// handleBackfill answers a member's backfill request, on the host only.
+func (m *Manager) handleBackfill(from, groupID string, n int) {
+	if !slices.ContainsFunc(m.grp.HostedGroupMembers(groupID), func(mi group.MemberInfo) bool { return mi.PeerID == from }) {
+		return
+	}
+	if n <= 0 || n > maxBackfill {
+		n = maxBackfill
+	}
+	msgs, err := m.History(groupID, n)
+	if err != nil {
+		log.Printf("CHAT: backfill of %s for %s: %v", groupID, from[:8], err)
+		return
+	}
+	m.sendToPeer(from, groupID, subtopicHistory, chatMsg{Action: subtopicHistory, Messages: msgs})
+}
+
+// handleHistory merges history sent by the room's host into ours.
+func (m *Manager) handleHistory(from, groupID string, msgs []Message) {
+	if host, connected := m.grp.ActiveGroup(groupID); !connected || host != from {
+		return
+	}
+	m.mu.RLock()
+	rs, exists := m.rooms[groupID]
+	m.mu.RUnlock()
+	if !exists || len(msgs) == 0 {
+		return
+	}
+	m.persist(groupID, msgs...)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	merged := rs.history.All()
+	seen := make(map[string]bool, len(merged))
+	for _, msg := range merged {
+		seen[msg.ID] = true
+	}
+	for _, msg := range msgs {
+		if !seen[msg.ID] {
+			seen[msg.ID] = true
+			merged = append(merged, msg)
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b Message) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	rs.history = &RingBuffer{}
+	for _, msg := range merged {
+		rs.history.Add(msg)
+	}
+}
@@ -0,0 +1,148 @@
+package chat
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// sentMsg is one message a recordingTransport was asked to send.
+type sentMsg struct {
+	to, topic string
+	payload   any
+}
+
+type recordingTransport struct {
+	mq.NopTransport
+	mu   sync.Mutex
+	sent []sentMsg
+}
+
+func (r *recordingTransport) Send(_ context.Context, peerID, topic string, payload any) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sentMsg{peerID, topic, payload})
+	return "", nil
+}
+
+func (r *recordingTransport) sentTo(peerID, topic string) []sentMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []sentMsg
+	for _, s := range r.sent {
+		if s.to == peerID && s.topic == topic {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func storeManager(t *testing.T, db *storage.DB, selfID string) (*Manager, *group.Manager, *recordingTransport) {
+	t.Helper()
+	grpMgr := group.NewTestManager(db, selfID)
+	t.Cleanup(func() { grpMgr.Close() })
+	m := NewTestManager(grpMgr, selfID, func(id string) state.PeerIdentityPayload {
+		return state.PeerIdentityPayload{Content: id, Known: true}
+	})
+	rt := &recordingTransport{}
+	m.mq = rt
+	m.SetStore(db)
+	return m, grpMgr, rt
+}
+
+func openDB(t *testing.T) *storage.DB {
+	t.Helper()
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestHistory_survivesRestart(t *testing.T) {
+	db := openDB(t)
+	m, _, _ := storeManager(t, db, "host-peer-id")
+	_ = m.OnCreate("room1", "Room", 0)
+	_ = m.SendMessage("room1", "host-peer-id", "first")
+	_ = m.SendMessage("room1", "host-peer-id", "second")
+	m.OnClose("room1")
+
+	if msgs, err := m.History("room1", 0); err != nil || len(msgs) != 2 {
+		t.Fatalf("history of closed room = %+v, %v", msgs, err)
+	}
+
+	restarted, _, _ := storeManager(t, db, "host-peer-id")
+	_ = restarted.OnCreate("room1", "Room", 0)
+	if _, msgs, _ := restarted.GetState("room1"); len(msgs) != 2 || msgs[1].Text != "second" {
+		t.Fatalf("state after restart = %+v", msgs)
+	}
+}
+
+func TestBackfill_hostAnswersMembersOnly(t *testing.T) {
+	db := openDB(t)
+	m, grpMgr, rt := storeManager(t, db, "host-peer-id")
+	if err := grpMgr.CreateGroup("room1", "Room", GroupTypeName, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"a", "b", "c"} {
+		_ = m.SendMessage("room1", "host-peer-id", text)
+	}
+	grpMgr.SimulateJoin("member-peer-id", "room1")
+
+	history := topic("room1", subtopicHistory)
+	joinPush := len(rt.sentTo("member-peer-id", history))
+	m.handleIncoming("member-peer-id", topic("room1", subtopicBackfill), map[string]any{"action": subtopicBackfill, "limit": 2})
+	sent := rt.sentTo("member-peer-id", history)
+	if len(sent) != joinPush+1 {
+		t.Fatalf("backfill replies = %d", len(sent)-joinPush)
+	}
+	reply := sent[len(sent)-1].payload.(map[string]any)["messages"].([]any)
+	if len(reply) != 2 || reply[1].(map[string]any)["text"] != "c" {
+		t.Fatalf("backfill = %+v", reply)
+	}
+
+	m.handleIncoming("stranger-peer-id", topic("room1", subtopicBackfill), map[string]any{"action": subtopicBackfill})
+	if got := rt.sentTo("stranger-peer-id", history); len(got) != 0 {
+		t.Fatalf("answered a non-member: %+v", got)
+	}
+}
+
+func TestBackfill_memberMergesHostHistory(t *testing.T) {
+	db := openDB(t)
+	m, grpMgr, rt := storeManager(t, db, "joiner-peer-id")
+	grpMgr.SetActiveConn("room1", "host-peer-id", GroupTypeName)
+	m.RegisterJoinedRoom("room1", "Room")
+	_ = m.SendMessage("room1", "joiner-peer-id", "late hello")
+
+	if err := m.RequestBackfill("room1", 50); err != nil {
+		t.Fatal(err)
+	}
+	if got := rt.sentTo("host-peer-id", topic("room1", subtopicBackfill)); len(got) != 1 {
+		t.Fatalf("backfill requests = %+v", got)
+	}
+
+	older := []any{
+		map[string]any{"id": "1", "from": "host-peer-id", "text": "earlier", "timestamp": 1},
+		map[string]any{"id": "2", "from": "host-peer-id", "text": "earlier too", "timestamp": 2},
+	}
+	m.handleIncoming("stranger-peer-id", topic("room1", subtopicHistory), map[string]any{"action": subtopicHistory, "messages": older[:1]})
+	if msgs, _ := m.History("room1", 0); len(msgs) != 1 {
+		t.Fatalf("history from a non-host merged: %+v", msgs)
+	}
+
+	m.handleIncoming("host-peer-id", topic("room1", subtopicHistory), map[string]any{"action": subtopicHistory, "messages": older})
+	m.handleIncoming("host-peer-id", topic("room1", subtopicHistory), map[string]any{"action": subtopicHistory, "messages": older})
+	msgs, _ := m.History("room1", 0)
+	if len(msgs) != 3 || msgs[0].Text != "earlier" || msgs[2].Text != "late hello" {
+		t.Fatalf("merged history = %+v", msgs)
+	}
+	if _, state, _ := m.GetState("room1"); len(state) != 3 || state[0].Text != "earlier" {
+		t.Fatalf("room state = %+v", state)
+	}
+}
@@ -22,12 +22,10 @@ func NewTestManager(grpMgr *group.Manager, selfID string, resolvePeer func(strin
 // remotely. In production this should happen inside JoinRoom; this helper
 // exposes the gap so BDD tests can exercise the joiner path.
 func (m *Manager) RegisterJoinedRoom(groupID, name string) {
+	rs := m.newRoomState(groupID, name)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, exists := m.rooms[groupID]; !exists {
-		m.rooms[groupID] = &roomState{
-			info:    Room{ID: groupID, Name: name},
-			history: &RingBuffer{},
-		}
+		m.rooms[groupID] = rs
 	}
 }
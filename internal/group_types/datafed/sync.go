@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"log"
 
-	"github.com/petervdpas/goop2/internal/group"
 	"github.com/petervdpas/goop2/internal/orm/schema"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 type controlMsg struct {
@@ -48,7 +48,7 @@ func (m *Manager) publishSync(groupID string) {
 	}
 	fg.rwmu.RUnlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), group.BroadcastTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupSend)
 	defer cancel()
 	_ = m.grpMgr.SendControl(groupID, GroupTypeName, sync)
 	_ = ctx
@@ -0,0 +1,216 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Access levels of a shared file.
+const (
+	AccessGroup  = "group"  // members of the file's group; the default
+	AccessPeers  = "peers"  // only the listed peers, members or not
+	AccessPublic = "public" // any peer that asks
+)
+
+// aclFileName holds a group's per-file ACLs in the group's shared
+// directory. The leading dot keeps it out of listings and uploads.
+const aclFileName = ".goop-acl.json"
+
+// ErrBadACL is returned by SetACL for an ACL that makes no sense.
+var ErrBadACL = errors.New("invalid access")
+
+// ACL says which peers may fetch a shared file from this peer. Files
+// without one are AccessGroup.
+type ACL struct {
+	Access string   `json:"access"`
+	Peers  []string `json:"peers,omitempty"` // for "peers"
+}
+
+// Allows reports whether peerID may read the file; member says whether
+// it is a verified member of the file's group.
+func (a ACL) Allows(peerID string, member bool) bool {
+	switch a.Access {
+	case AccessPublic:
+		return true
+	case AccessPeers:
+		return slices.Contains(a.Peers, peerID)
+	default:
+		return member
+	}
+}
+
+func (a ACL) validate() error {
+	switch a.Access {
+	case AccessGroup, AccessPublic:
+		if len(a.Peers) > 0 {
+			return fmt.Errorf("%w: peers are only allowed with access %q", ErrBadACL, AccessPeers)
+		}
+	case AccessPeers:
+		if len(a.Peers) == 0 {
+			return fmt.Errorf("%w: access %q needs at least one peer", ErrBadACL, AccessPeers)
+		}
+	default:
+		return fmt.Errorf("%w: access must be %q, %q or %q", ErrBadACL, AccessGroup, AccessPeers, AccessPublic)
+	}
+	return nil
+}
+
+func (s *Store) aclPath(groupID string) string {
+	return filepath.Join(s.groupDir(groupID), aclFileName)
+}
+
+// acls reads a group's ACLs; a group without any yields an empty map.
+func (s *Store) acls(groupID string) (map[string]ACL, error) {
+	data, err := os.ReadFile(s.aclPath(groupID))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]ACL{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]ACL{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", aclFileName, err)
+	}
+	return m, nil
+}
+
+func (s *Store) saveACLs(groupID string, m map[string]ACL) error {
+	if len(m) == 0 {
+		if err := os.Remove(s.aclPath(groupID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.aclPath(groupID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.aclPath(groupID)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// ACL returns the ACL of a shared file. An unreadable ACL file yields
+// AccessPeers with no peers, so nobody else gets in by accident.
+func (s *Store) ACL(groupID, filename string) ACL {
+	s.aclMu.Lock()
+	defer s.aclMu.Unlock()
+	m, err := s.acls(groupID)
+	if err != nil {
+		return ACL{Access: AccessPeers}
+	}
+	if a, ok := m[filename]; ok {
+		return a
+	}
+	return ACL{Access: AccessGroup}
+}
+
+// SetACL sets the ACL of an existing shared file. AccessGroup removes an
+// explicit ACL. It fails with ErrBadACL, ErrBadName or ErrNotFound.
+func (s *Store) SetACL(groupID, filename string, acl ACL) error {
+	if err := validateFilename(filename); err != nil {
+		return err
+	}
+	if err := acl.validate(); err != nil {
+		return err
+	}
+	abs, err := s.cleanAbs(groupID, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	s.aclMu.Lock()
+	defer s.aclMu.Unlock()
+	m, err := s.acls(groupID)
+	if err != nil {
+		return err
+	}
+	if acl.Access == AccessGroup {
+		delete(m, filename)
+	} else {
+		acl.Peers = slices.Compact(slices.Sorted(slices.Values(acl.Peers)))
+		m[filename] = acl
+	}
+	return s.saveACLs(groupID, m)
+}
+
+// dropACL forgets the ACL of a removed file.
+func (s *Store) dropACL(groupID, filename string) error {
+	s.aclMu.Lock()
+	defer s.aclMu.Unlock()
+	m, err := s.acls(groupID)
+	if err != nil {
+		return err
+	}
+	if _, ok := m[filename]; !ok {
+		return nil
+	}
+	delete(m, filename)
+	return s.saveACLs(groupID, m)
+}
+
+// CanRead reports whether peerID may fetch a shared file; member says
+// whether it is a verified member of the group.
+func (s *Store) CanRead(groupID, filename, peerID string, member bool) bool {
+	return s.ACL(groupID, filename).Allows(peerID, member)
+}
+
+// ListJSONFor is ListJSON limited to the files peerID may fetch. Peer
+// lists are left out; they are the owner's business.
+func (s *Store) ListJSONFor(groupID, peerID string, member bool) ([]byte, error) {
+	list, err := s.List(groupID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DocInfo, 0, len(list))
+	for _, f := range list {
+		if (ACL{Access: f.Access, Peers: f.Peers}).Allows(peerID, member) {
+			f.Peers = nil
+			out = append(out, f)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// withACLs fills in the access of each listed file.
+func (s *Store) withACLs(groupID string, list []DocInfo) []DocInfo {
+	s.aclMu.Lock()
+	m, err := s.acls(groupID)
+	s.aclMu.Unlock()
+	for i := range list {
+		a, ok := m[list[i].Name]
+		switch {
+		case err != nil:
+			list[i].Access = AccessPeers
+		case ok:
+			list[i].Access, list[i].Peers = a.Access, a.Peers
+		default:
+			list[i].Access = AccessGroup
+		}
+	}
+	return list
+}
+
+// isMeta reports whether a directory entry is the store's own
+// bookkeeping rather than a shared file.
+func isMeta(name string) bool {
+	return strings.HasPrefix(name, ".goop-")
+}
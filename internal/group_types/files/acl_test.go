@@ -0,0 +1,78 @@
+package files
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestACL(t *testing.T) {
+	s := testStore(t)
+	for _, name := range []string{"open.txt", "plain.txt", "private.txt"} {
+		if _, err := s.Save("g1", name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetACL("g1", "open.txt", ACL{Access: AccessPublic}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetACL("g1", "private.txt", ACL{Access: AccessPeers, Peers: []string{"bob", "alice", "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		file, peer string
+		member     bool
+		want       bool
+	}{
+		{"open.txt", "stranger", false, true},
+		{"plain.txt", "member", true, true},
+		{"plain.txt", "stranger", false, false},
+		{"private.txt", "alice", false, true},
+		{"private.txt", "member", true, false},
+	} {
+		if got := s.CanRead("g1", tc.file, tc.peer, tc.member); got != tc.want {
+			t.Errorf("CanRead(%s, %s, member=%v) = %v, want %v", tc.file, tc.peer, tc.member, got, tc.want)
+		}
+	}
+	if got := s.ACL("g1", "private.txt").Peers; len(got) != 2 || got[0] != "alice" {
+		t.Errorf("peers = %v, want sorted without duplicates", got)
+	}
+
+	// Listings leave the ACL file out; peers see only what they may fetch.
+	list, _ := s.List("g1")
+	if len(list) != 3 {
+		t.Fatalf("list = %+v, want the 3 files", list)
+	}
+	var seen []DocInfo
+	b, _ := s.ListJSONFor("g1", "alice", false)
+	if err := json.Unmarshal(b, &seen); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0].Name != "open.txt" || seen[1].Name != "private.txt" || seen[1].Peers != nil {
+		t.Errorf("alice sees %+v, want open.txt and private.txt without peers", seen)
+	}
+
+	for _, bad := range []ACL{{Access: "friends"}, {Access: AccessPeers}, {Access: AccessPublic, Peers: []string{"x"}}} {
+		if err := s.SetACL("g1", "plain.txt", bad); err == nil {
+			t.Errorf("%+v accepted", bad)
+		}
+	}
+	if err := s.SetACL("g1", "missing.txt", ACL{Access: AccessPublic}); err != ErrNotFound {
+		t.Errorf("missing file: %v", err)
+	}
+
+	// Back to the default, and a deleted file takes its ACL along.
+	if err := s.SetACL("g1", "open.txt", ACL{Access: AccessGroup}); err != nil {
+		t.Fatal(err)
+	}
+	if s.CanRead("g1", "open.txt", "stranger", false) {
+		t.Error("open.txt still public")
+	}
+	if err := s.Delete("g1", "private.txt"); err != nil {
+		t.Fatal(err)
+	}
+	s.Save("g1", "private.txt", []byte("again"))
+	if got := s.ACL("g1", "private.txt"); got.Access != AccessGroup {
+		t.Errorf("recreated file has ACL %+v", got)
+	}
+}
@@ -0,0 +1,339 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// DavGroup is a group shown as a folder in the WebDAV view of the store.
+type DavGroup struct {
+	ID       string
+	Name     string // folder name; the ID when empty
+	Writable bool   // false for groups the user has left
+}
+
+// DavFS exposes the store as a webdav.FileSystem: one folder per group,
+// each holding that group's files. Groups lists the groups the user may
+// see and is called on every operation, so leaving a group hides or
+// freezes its folder right away. Folders cannot be created, removed or
+// renamed; files can be moved between writable folders.
+type DavFS struct {
+	Store    *Store
+	Groups   func() []DavGroup
+	ReadOnly bool
+
+	// Called after a file is written or removed, so the caller can tell
+	// the group as an upload through the API would.
+	OnAdded   func(groupID, name string, size int, hash string)
+	OnRemoved func(groupID, name string)
+}
+
+var _ webdav.FileSystem = (*DavFS)(nil)
+
+// folders maps folder names to groups. Groups with the same name get
+// the start of their ID appended.
+func (d *DavFS) folders() map[string]DavGroup {
+	groups := d.Groups()
+	count := map[string]int{}
+	for _, g := range groups {
+		count[folderName(g)]++
+	}
+	out := make(map[string]DavGroup, len(groups))
+	for _, g := range groups {
+		name := folderName(g)
+		if count[name] > 1 {
+			id := g.ID
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			name += " (" + id + ")"
+		}
+		out[name] = g
+	}
+	return out
+}
+
+func folderName(g DavGroup) string {
+	name := strings.TrimSpace(g.Name)
+	if name == "" {
+		name = g.ID
+	}
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+}
+
+// resolve splits a WebDAV path into its group and file name. An empty
+// file name means the folder itself; ok is false for the root.
+func (d *DavFS) resolve(name string) (g DavGroup, file string, ok bool, err error) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	if parts[0] == "" {
+		return DavGroup{}, "", false, nil
+	}
+	if len(parts) > 2 {
+		return DavGroup{}, "", false, os.ErrNotExist
+	}
+	g, found := d.folders()[parts[0]]
+	if !found {
+		return DavGroup{}, "", false, os.ErrNotExist
+	}
+	if len(parts) == 2 {
+		file = parts[1]
+		if validateFilename(file) != nil || strings.HasPrefix(file, ".goop-doc-") {
+			return DavGroup{}, "", false, os.ErrNotExist
+		}
+	}
+	return g, file, true, nil
+}
+
+func (d *DavFS) writable(g DavGroup) bool {
+	return !d.ReadOnly && g.Writable
+}
+
+func (d *DavFS) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	g, file, ok, err := d.resolve(name)
+	switch {
+	case err != nil:
+		return nil, err
+	case !ok:
+		return dirInfo{name: "/"}, nil
+	case file == "":
+		return d.folderInfo(name, g), nil
+	}
+	abs, err := d.Store.cleanAbs(g.ID, file)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return os.Stat(abs)
+}
+
+func (d *DavFS) folderInfo(name string, g DavGroup) dirInfo {
+	fi := dirInfo{name: strings.Trim(name, "/")}
+	if st, err := os.Stat(d.Store.groupDir(g.ID)); err == nil {
+		fi.mod = st.ModTime()
+	}
+	return fi
+}
+
+func (d *DavFS) OpenFile(_ context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	g, file, ok, err := d.resolve(name)
+	write := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+	if errors.Is(err, os.ErrNotExist) && write {
+		// New files with names the store refuses (".DS_Store") are
+		// turned away rather than reported missing.
+		return nil, os.ErrPermission
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || file == "" {
+		if write {
+			return nil, os.ErrPermission
+		}
+		return d.openDir(name, g, ok)
+	}
+
+	if write {
+		if !d.writable(g) {
+			return nil, os.ErrPermission
+		}
+		return &davWriter{fs: d, group: g.ID, name: file}, nil
+	}
+	abs, err := d.Store.cleanAbs(g.ID, file)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(abs)
+}
+
+func (d *DavFS) openDir(name string, g DavGroup, inGroup bool) (webdav.File, error) {
+	dir := &davDir{info: dirInfo{name: "/"}}
+	if !inGroup {
+		for folder, g := range d.folders() {
+			dir.entries = append(dir.entries, d.folderInfo(folder, g))
+		}
+	} else {
+		dir.info = d.folderInfo(name, g)
+		list, err := os.ReadDir(d.Store.groupDir(g.ID))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, e := range list {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if fi, err := e.Info(); err == nil {
+				dir.entries = append(dir.entries, fi)
+			}
+		}
+	}
+	sort.Slice(dir.entries, func(i, j int) bool { return dir.entries[i].Name() < dir.entries[j].Name() })
+	return dir, nil
+}
+
+func (d *DavFS) Mkdir(context.Context, string, os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (d *DavFS) RemoveAll(_ context.Context, name string) error {
+	g, file, ok, err := d.resolve(name)
+	if err != nil {
+		return err
+	}
+	if !ok || file == "" || !d.writable(g) {
+		return os.ErrPermission
+	}
+	if err := d.Store.Delete(g.ID, file); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return os.ErrNotExist
+		}
+		return err
+	}
+	if d.OnRemoved != nil {
+		d.OnRemoved(g.ID, file)
+	}
+	return nil
+}
+
+func (d *DavFS) Rename(_ context.Context, oldName, newName string) error {
+	from, oldFile, ok, err := d.resolve(oldName)
+	if err != nil {
+		return err
+	}
+	if !ok || oldFile == "" || !d.writable(from) {
+		return os.ErrPermission
+	}
+	to, newFile, ok, err := d.resolve(newName)
+	if err != nil || !ok || newFile == "" || !d.writable(to) {
+		return os.ErrPermission
+	}
+
+	data, _, err := d.Store.Read(from.ID, oldFile)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return os.ErrNotExist
+		}
+		return err
+	}
+	hash, err := d.Store.Save(to.ID, newFile, data)
+	if err != nil {
+		return err
+	}
+	if from.ID != to.ID || oldFile != newFile {
+		if err := d.Store.SetACL(to.ID, newFile, d.Store.ACL(from.ID, oldFile)); err != nil {
+			return err
+		}
+		if err := d.Store.Delete(from.ID, oldFile); err != nil {
+			return err
+		}
+		if d.OnRemoved != nil {
+			d.OnRemoved(from.ID, oldFile)
+		}
+	}
+	if d.OnAdded != nil {
+		d.OnAdded(to.ID, newFile, len(data), hash)
+	}
+	return nil
+}
+
+// davWriter buffers an upload and saves it through the store on Close,
+// so size limits and atomic replacement apply as for the upload API.
+type davWriter struct {
+	fs    *DavFS
+	group string
+	name  string
+	buf   bytes.Buffer
+	err   error
+}
+
+func (w *davWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > MaxFileSize {
+		w.err = ErrTooLarge
+		return 0, ErrTooLarge
+	}
+	return w.buf.Write(p)
+}
+
+func (w *davWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	hash, err := w.fs.Store.Save(w.group, w.name, w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if w.fs.OnAdded != nil {
+		w.fs.OnAdded(w.group, w.name, w.buf.Len(), hash)
+	}
+	return nil
+}
+
+func (w *davWriter) Stat() (os.FileInfo, error) {
+	return fileInfo{name: w.name, size: int64(w.buf.Len()), mod: time.Now()}, nil
+}
+
+func (w *davWriter) Read([]byte) (int, error)           { return 0, os.ErrInvalid }
+func (w *davWriter) Seek(int64, int) (int64, error)     { return 0, os.ErrInvalid }
+func (w *davWriter) Readdir(int) ([]fs.FileInfo, error) { return nil, os.ErrInvalid }
+
+// davDir is a listing of the root or of a group folder.
+type davDir struct {
+	info    dirInfo
+	entries []os.FileInfo
+	pos     int
+}
+
+func (d *davDir) Readdir(count int) ([]fs.FileInfo, error) {
+	rest := d.entries[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(rest) {
+		count = len(rest)
+	}
+	d.pos += count
+	return rest[:count], nil
+}
+
+func (d *davDir) Stat() (os.FileInfo, error)     { return d.info, nil }
+func (d *davDir) Close() error                   { return nil }
+func (d *davDir) Read([]byte) (int, error)       { return 0, os.ErrInvalid }
+func (d *davDir) Write([]byte) (int, error)      { return 0, os.ErrPermission }
+func (d *davDir) Seek(int64, int) (int64, error) { return 0, nil }
+
+type dirInfo struct {
+	name string
+	mod  time.Time
+}
+
+func (i dirInfo) Name() string       { return i.name }
+func (i dirInfo) Size() int64        { return 0 }
+func (i dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (i dirInfo) ModTime() time.Time { return i.mod }
+func (i dirInfo) IsDir() bool        { return true }
+func (i dirInfo) Sys() any           { return nil }
+
+type fileInfo struct {
+	name string
+	size int64
+	mod  time.Time
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i fileInfo) ModTime() time.Time { return i.mod }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() any           { return nil }
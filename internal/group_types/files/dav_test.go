@@ -0,0 +1,107 @@
+package files
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+func newDavServer(t *testing.T) (*Store, *httptest.Server, *[]string) {
+	t.Helper()
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Save("g-old", "left.txt", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	fsys := &DavFS{
+		Store: s,
+		Groups: func() []DavGroup {
+			return []DavGroup{
+				{ID: "g1", Name: "Photos", Writable: true},
+				{ID: "g2", Name: "Work", Writable: true},
+				{ID: "g-old"},
+			}
+		},
+		OnAdded:   func(g, name string, _ int, _ string) { events = append(events, "+"+g+"/"+name) },
+		OnRemoved: func(g, name string) { events = append(events, "-"+g+"/"+name) },
+	}
+	srv := httptest.NewServer(&webdav.Handler{Prefix: "/dav", FileSystem: fsys, LockSystem: webdav.NewMemLS()})
+	t.Cleanup(srv.Close)
+	return s, srv, &events
+}
+
+func davDo(t *testing.T, method, url, body string, hdr map[string]string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestDavFS_ListAndWrite(t *testing.T) {
+	s, srv, events := newDavServer(t)
+
+	code, body := davDo(t, "PROPFIND", srv.URL+"/dav/", "", map[string]string{"Depth": "1"})
+	if code != http.StatusMultiStatus || !strings.Contains(body, "/dav/Photos/") || !strings.Contains(body, "/dav/g-old/") {
+		t.Fatalf("PROPFIND root: %d %s", code, body)
+	}
+
+	if code, _ := davDo(t, http.MethodPut, srv.URL+"/dav/Photos/a.txt", "hello", nil); code != http.StatusCreated {
+		t.Fatalf("PUT = %d", code)
+	}
+	if data, _, err := s.Read("g1", "a.txt"); err != nil || string(data) != "hello" {
+		t.Fatalf("stored %q, %v", data, err)
+	}
+
+	code, _ = davDo(t, "MOVE", srv.URL+"/dav/Photos/a.txt", "", map[string]string{"Destination": srv.URL + "/dav/Work/b.txt"})
+	if code != http.StatusCreated {
+		t.Fatalf("MOVE = %d", code)
+	}
+	if _, _, err := s.Read("g1", "a.txt"); err == nil {
+		t.Fatal("source kept after move")
+	}
+	if code, body := davDo(t, http.MethodGet, srv.URL+"/dav/Work/b.txt", "", nil); code != http.StatusOK || body != "hello" {
+		t.Fatalf("GET moved = %d %q", code, body)
+	}
+
+	want := "+g1/a.txt -g1/a.txt +g2/b.txt"
+	if got := strings.Join(*events, " "); got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+}
+
+func TestDavFS_Refusals(t *testing.T) {
+	_, srv, events := newDavServer(t)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPut, "/dav/g-old/new.txt"}, // left group
+		{http.MethodDelete, "/dav/g-old/left.txt"},
+		{http.MethodPut, "/dav/Photos/.DS_Store"},
+		{"MKCOL", "/dav/Photos/sub"},
+		{http.MethodPut, "/dav/Nope/x.txt"},
+	} {
+		if code, _ := davDo(t, tc.method, srv.URL+tc.path, "x", nil); code < 400 {
+			t.Errorf("%s %s = %d", tc.method, tc.path, code)
+		}
+	}
+	if code, body := davDo(t, http.MethodGet, srv.URL+"/dav/g-old/left.txt", "", nil); code != http.StatusOK || body != "old" {
+		t.Fatalf("left group not readable: %d", code)
+	}
+	if len(*events) != 0 {
+		t.Fatalf("events = %v", *events)
+	}
+}
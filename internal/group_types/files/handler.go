@@ -23,7 +23,7 @@ func New(transport mq.Transport, grpMgr *group.Manager, store *Store) {
 }
 
 func (h *Handler) Flags() group.GroupTypeFlags {
-	return group.GroupTypeFlags{HostCanJoin: true}
+	return group.GroupTypeFlags{HostCanJoin: true, CoHost: true}
 }
 
 func (h *Handler) OnCreate(_, _ string, _ int) error { return nil }
@@ -5,11 +5,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 const MaxFileSize = 50 * 1024 * 1024 // 50 MB
@@ -27,11 +29,16 @@ type DocInfo struct {
 	Size    int64  `json:"size"`
 	ModTime int64  `json:"mod_time"` // unix seconds
 	Hash    string `json:"hash"`     // sha256:<hex>
+
+	Access string   `json:"access,omitempty"` // "group", "peers" or "public"
+	Peers  []string `json:"peers,omitempty"`  // for "peers"; own listings only
 }
 
 // Store manages the local shared documents directory.
 type Store struct {
 	root string // absolute path, e.g. <peer-dir>/shared
+
+	aclMu sync.Mutex // serializes reads and writes of the ACL files
 }
 
 // NewStore creates a new document store rooted at <peerDir>/shared.
@@ -116,6 +123,52 @@ func (s *Store) Read(groupID, filename string) ([]byte, string, error) {
 	return data, hashBytes(data), nil
 }
 
+// Stat returns the size and hash of a file without loading it whole.
+func (s *Store) Stat(groupID, filename string) (int64, string, error) {
+	f, err := s.open(groupID, filename)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	sum := sha256.New()
+	n, err := io.Copy(sum, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, "sha256:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// ReadRange returns up to n bytes of a file from offset off; fewer near the
+// end of the file, none past it.
+func (s *Store) ReadRange(groupID, filename string, off int64, n int) ([]byte, error) {
+	f, err := s.open(groupID, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	read, err := f.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+func (s *Store) open(groupID, filename string) (*os.File, error) {
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	abs, err := s.cleanAbs(groupID, filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(abs)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
 // Delete removes a file from the group's shared directory.
 func (s *Store) Delete(groupID, filename string) error {
 	if err := validateFilename(filename); err != nil {
@@ -131,7 +184,7 @@ func (s *Store) Delete(groupID, filename string) error {
 		}
 		return err
 	}
-	return nil
+	return s.dropACL(groupID, filename)
 }
 
 // List returns all files shared in a group.
@@ -150,8 +203,8 @@ func (s *Store) List(groupID string) ([]DocInfo, error) {
 		if e.IsDir() {
 			continue
 		}
-		// Skip temp files
-		if strings.HasPrefix(e.Name(), ".goop-doc-") {
+		// Skip temp files and ACLs
+		if isMeta(e.Name()) {
 			continue
 		}
 		info, err := e.Info()
@@ -171,7 +224,31 @@ func (s *Store) List(groupID string) ([]DocInfo, error) {
 			Hash:    hashBytes(data),
 		})
 	}
-	return out, nil
+	return s.withACLs(groupID, out), nil
+}
+
+// ListMeta is List without the hashes, for callers that only need names
+// and sizes and shouldn't read every file.
+func (s *Store) ListMeta(groupID string) ([]DocInfo, error) {
+	entries, err := os.ReadDir(s.groupDir(groupID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []DocInfo{}, nil
+		}
+		return nil, err
+	}
+	out := make([]DocInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || isMeta(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, DocInfo{Name: e.Name(), Size: info.Size(), ModTime: info.ModTime().Unix()})
+	}
+	return s.withACLs(groupID, out), nil
 }
 
 func (s *Store) groupDir(groupID string) string {
@@ -251,7 +328,7 @@ func (s *Store) HasFiles(groupID string) bool {
 		return false
 	}
 	for _, e := range entries {
-		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".goop-doc-") {
+		if !e.IsDir() && !isMeta(e.Name()) {
 			return true
 		}
 	}
@@ -14,6 +14,28 @@ func testStore(t *testing.T) *Store {
 	return s
 }
 
+func TestStatAndReadRange(t *testing.T) {
+	s := testStore(t)
+	hash, _ := s.Save("g1", "readme.txt", []byte("hello world"))
+
+	size, gotHash, err := s.Stat("g1", "readme.txt")
+	if err != nil || size != 11 || gotHash != hash {
+		t.Fatalf("stat = %d %q %v, want 11 %q", size, gotHash, err, hash)
+	}
+	if got, err := s.ReadRange("g1", "readme.txt", 6, 100); err != nil || string(got) != "world" {
+		t.Fatalf("range = %q, %v", got, err)
+	}
+	if got, err := s.ReadRange("g1", "readme.txt", 50, 10); err != nil || len(got) != 0 {
+		t.Fatalf("past the end = %q, %v", got, err)
+	}
+	if _, _, err := s.Stat("g1", "missing.txt"); err != ErrNotFound {
+		t.Fatalf("missing file: %v", err)
+	}
+	if _, err := s.ReadRange("g1", "../escape", 0, 1); err == nil {
+		t.Fatal("bad name accepted")
+	}
+}
+
 func TestSaveAndRead(t *testing.T) {
 	s := testStore(t)
 	data := []byte("hello world")
@@ -7,14 +7,16 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 
 	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 // JoinGroup joins a remote listening group.
@@ -36,7 +38,11 @@ func (m *Manager) JoinGroup(hostPeerID, groupID string) error {
 		m.mu.Unlock()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), ListenJoinTimeout)
+	if m.clock != nil {
+		m.clock(hostPeerID) // starts a measurement ahead of the first control message
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
 	defer cancel()
 	if err := m.grp.JoinRemoteGroup(ctx, hostPeerID, groupID); err != nil {
 		return err
@@ -75,18 +81,31 @@ func (m *Manager) LeaveGroup() error {
 	return m.grp.LeaveGroup(lg.ID)
 }
 
-// AudioReader returns an io.ReadCloser that streams audio from the host.
-func (m *Manager) AudioReader() (io.ReadCloser, error) {
+// AudioReader returns an io.ReadCloser that streams audio from the host,
+// and the format of the audio (a Track.Format, see ContentType).
+func (m *Manager) AudioReader() (io.ReadCloser, string, error) {
 	m.mu.RLock()
 	lg := m.group
+	format := ""
+	if lg != nil && lg.Track != nil {
+		format = lg.Track.Format
+	}
 	m.mu.RUnlock()
 
 	if lg == nil {
-		return nil, fmt.Errorf("not in a group")
+		return nil, "", fmt.Errorf("not in a group")
 	}
 
 	if lg.Role == "listener" {
-		return m.connectAudioStream()
+		rc, format, start, err := m.connectAudioStream()
+		if err != nil {
+			return nil, "", err
+		}
+		m.mu.Lock()
+		m.audio = rc
+		m.streamStart, m.startKnown = start, start >= 0
+		m.mu.Unlock()
+		return &countingReader{ReadCloser: rc, n: &m.bytesReceived}, format, nil
 	}
 
 	// Host can also listen to their own stream (local playback).
@@ -102,12 +121,13 @@ func (m *Manager) AudioReader() (io.ReadCloser, error) {
 	go func() {
 		m.mu.RLock()
 		playing := m.group != nil && !m.paused && m.filePath != "" && m.group.Track != nil
-		var filePath string
+		var filePath, format string
 		var bitrate int
 		var pos float64
 		var stopCh chan struct{}
 		if playing {
 			filePath = m.filePath
+			format = m.group.Track.Format
 			bitrate = m.group.Track.Bitrate
 			pos = m.currentPosition()
 			stopCh = m.stopCh
@@ -149,46 +169,46 @@ func (m *Manager) AudioReader() (io.ReadCloser, error) {
 			return
 		}
 
-		ff, err := os.Open(filePath)
+		ff, err := openAudio(filePath, format, bitrate, pos)
 		if err != nil {
 			return
 		}
 		defer ff.Close()
-		byteOffset := int64(pos * float64(bitrate) / 8.0)
-		if byteOffset > 0 {
-			ff.Seek(byteOffset, io.SeekStart) //nolint:errcheck
-		}
 		buf := make([]byte, 32*1024)
 		io.CopyBuffer(httpW, ff, buf) //nolint:errcheck
 	}()
 
-	return r, nil
+	return r, format, nil
 }
 
-func (m *Manager) connectAudioStream() (io.ReadCloser, error) {
+// connectAudioStream opens the audio stream to the host. The host answers
+// "OK <format> <bitrate> <duration> <start>" (EAOK when the audio is
+// encrypted); start, the track position the audio begins at, is -1 from
+// hosts that do not send it.
+func (m *Manager) connectAudioStream() (io.ReadCloser, string, float64, error) {
 	m.mu.RLock()
 	lg := m.group
 	m.mu.RUnlock()
 
 	if lg == nil || lg.Role != "listener" {
-		return nil, fmt.Errorf("not a listener")
+		return nil, "", -1, fmt.Errorf("not a listener")
 	}
 
 	hostPeerID, connected := m.grp.ActiveGroup(lg.ID)
 	if !connected {
-		return nil, fmt.Errorf("not connected to host")
+		return nil, "", -1, fmt.Errorf("not connected to host")
 	}
 
 	pid, err := peer.Decode(hostPeerID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid host peer ID: %w", err)
+		return nil, "", -1, fmt.Errorf("invalid host peer ID: %w", err)
 	}
 
-	sCtx, sCancel := context.WithTimeout(context.Background(), ListenStreamTimeout)
+	sCtx, sCancel := context.WithTimeout(context.Background(), timeouts.Get().ListenStream)
 	defer sCancel()
 	s, err := m.host.NewStream(network.WithAllowLimitedConn(sCtx, "relay"), pid, protocol.ID(proto.ListenProtoID))
 	if err != nil {
-		return nil, fmt.Errorf("open stream: %w", err)
+		return nil, "", -1, fmt.Errorf("open stream: %w", err)
 	}
 
 	fmt.Fprintf(s, "LISTEN %s\n", lg.ID)
@@ -200,7 +220,7 @@ func (m *Manager) connectAudioStream() (io.ReadCloser, error) {
 		_, err := s.Read(b)
 		if err != nil {
 			s.Close()
-			return nil, fmt.Errorf("read response: %w", err)
+			return nil, "", -1, fmt.Errorf("read response: %w", err)
 		}
 		if b[0] == '\n' {
 			break
@@ -212,19 +232,31 @@ func (m *Manager) connectAudioStream() (io.ReadCloser, error) {
 
 	if strings.HasPrefix(line, "ERR") {
 		s.Close()
-		return nil, fmt.Errorf("host: %s", line)
+		return nil, "", -1, fmt.Errorf("host: %s", line)
+	}
+
+	format := ""
+	start := -1.0
+	fields := strings.Fields(line)
+	if len(fields) > 1 {
+		format = fields[1]
+	}
+	if len(fields) > 4 {
+		if v, err := strconv.ParseFloat(fields[4], 64); err == nil && v >= 0 {
+			start = v
+		}
 	}
 
 	if strings.HasPrefix(line, "EAOK") && m.enc != nil {
-		return &decryptingReader{stream: s, enc: m.enc, peerID: hostPeerID}, nil
+		return &decryptingReader{stream: s, enc: m.enc, peerID: hostPeerID}, format, start, nil
 	}
 
 	if !strings.HasPrefix(line, "OK") {
 		s.Close()
-		return nil, fmt.Errorf("unexpected response: %s", line)
+		return nil, "", -1, fmt.Errorf("unexpected response: %s", line)
 	}
 
-	return s, nil
+	return s, format, start, nil
 }
 
 type decryptingReader struct {
@@ -271,3 +303,15 @@ func (r *decryptingReader) Read(p []byte) (int, error) {
 func (r *decryptingReader) Close() error {
 	return r.stream.Close()
 }
+
+// countingReader adds the bytes read to n.
+type countingReader struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
@@ -38,7 +38,7 @@ func TestHandleControlEventLoad(t *testing.T) {
 		"queue_index": 0,
 		"queue_total": 2,
 	})
-	m.handleControlEvent(payload)
+	m.handleControlEvent("", payload)
 
 	g := m.GetGroup()
 	if g.Track == nil {
@@ -59,7 +59,7 @@ func TestHandleControlEventPlay(t *testing.T) {
 	m := testManagerWithGroup(t)
 	m.group.PlayState = &PlayState{Playing: false, Position: 0, UpdatedAt: time.Now().UnixMilli()}
 
-	m.handleControlEvent(controlPayload("play", map[string]any{"position": 5.5}))
+	m.handleControlEvent("", controlPayload("play", map[string]any{"position": 5.5}))
 
 	g := m.GetGroup()
 	if !g.PlayState.Playing {
@@ -71,7 +71,7 @@ func TestHandleControlEventPause(t *testing.T) {
 	m := testManagerWithGroup(t)
 	m.group.PlayState = &PlayState{Playing: true, Position: 10.0, UpdatedAt: time.Now().UnixMilli()}
 
-	m.handleControlEvent(controlPayload("pause", map[string]any{"position": 15.0}))
+	m.handleControlEvent("", controlPayload("pause", map[string]any{"position": 15.0}))
 
 	g := m.GetGroup()
 	if g.PlayState.Playing {
@@ -86,7 +86,7 @@ func TestHandleControlEventSeek(t *testing.T) {
 	m := testManagerWithGroup(t)
 	m.group.PlayState = &PlayState{Playing: true, Position: 10.0, UpdatedAt: time.Now().UnixMilli()}
 
-	m.handleControlEvent(controlPayload("seek", map[string]any{"position": 30.0}))
+	m.handleControlEvent("", controlPayload("seek", map[string]any{"position": 30.0}))
 
 	g := m.GetGroup()
 	if !g.PlayState.Playing {
@@ -99,7 +99,7 @@ func TestHandleControlEventSync(t *testing.T) {
 	m.group.Track = &Track{Name: "old.mp3"}
 	m.group.PlayState = &PlayState{Playing: false, Position: 0, UpdatedAt: time.Now().UnixMilli()}
 
-	m.handleControlEvent(controlPayload("sync", map[string]any{
+	m.handleControlEvent("", controlPayload("sync", map[string]any{
 		"position": 42.0,
 		"track": map[string]any{
 			"name":     "new.mp3",
@@ -128,7 +128,7 @@ func TestHandleControlEventSync(t *testing.T) {
 func TestHandleControlEventClose(t *testing.T) {
 	m := testManagerWithGroup(t)
 
-	m.handleControlEvent(controlPayload("close", nil))
+	m.handleControlEvent("", controlPayload("close", nil))
 
 	if m.GetGroup() != nil {
 		t.Fatal("expected nil group after close control")
@@ -137,11 +137,35 @@ func TestHandleControlEventClose(t *testing.T) {
 
 func TestHandleControlEventNilGroup(t *testing.T) {
 	m := NewTestManagerOpts(TestManagerOpts{SelfID: "me"})
-	m.handleControlEvent(controlPayload("play", map[string]any{"position": 0.0}))
+	m.handleControlEvent("", controlPayload("play", map[string]any{"position": 0.0}))
 }
 
 func TestHandleControlEventInvalidPayload(t *testing.T) {
 	m := testManagerWithGroup(t)
-	m.handleControlEvent("not a map")
-	m.handleControlEvent(map[string]any{"wrong_key": "data"})
+	m.handleControlEvent("", "not a map")
+	m.handleControlEvent("", map[string]any{"wrong_key": "data"})
+}
+
+func TestHandleControlEventUsesHostClock(t *testing.T) {
+	m := testManagerWithGroup(t)
+	// Host clock runs 2s ahead of ours.
+	m.SetClock(func(peerID string) (float64, bool) { return 2000, peerID == "host" })
+
+	sent := time.Now().UnixMilli() + 2000 - 300 // sent 300ms ago, host time
+	m.handleControlEvent("host", controlPayload("play", map[string]any{"position": 10.0, "sent_at": sent}))
+	if got := time.Now().UnixMilli() - m.group.PlayState.UpdatedAt; got < 300 || got > 1000 {
+		t.Fatalf("play state is %dms old, want about 300", got)
+	}
+
+	// No estimate for this peer: arrival time.
+	m.handleControlEvent("other", controlPayload("play", map[string]any{"position": 10.0, "sent_at": sent}))
+	if got := time.Now().UnixMilli() - m.group.PlayState.UpdatedAt; got > 200 {
+		t.Fatalf("play state is %dms old without an estimate", got)
+	}
+
+	// A timestamp far in the past is a clock error, not a slow network.
+	m.handleControlEvent("host", controlPayload("play", map[string]any{"position": 10.0, "sent_at": sent - 60_000}))
+	if got := time.Now().UnixMilli() - m.group.PlayState.UpdatedAt; got > 200 {
+		t.Fatalf("implausible timestamp used: %dms", got)
+	}
 }
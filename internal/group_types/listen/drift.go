@@ -0,0 +1,131 @@
+package listen
+
+// drift.go — keeping listeners in step with the host. Audio goes out raw
+// and each listener's player buffers it at its own pace, so over a track
+// listeners slowly drift from the host's position; the "sync" pulse only
+// corrects the displayed position, not what is heard. Every few seconds a
+// listener reports where its player is. The host compares that with its
+// own position at the moment of the report and, once the two are more
+// than ListenDriftThreshold apart, sends that listener a "resync". The
+// listener drops its audio stream and reconnects, and the host opens the
+// new stream at the byte offset of its current position.
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+)
+
+// listenerDrift is the host's view of one listener.
+type listenerDrift struct {
+	Offset   float64 // seconds, positive when the listener is ahead
+	ResyncAt int64   // unix millis of the last resync sent, 0 = never
+}
+
+// ReportPosition sends the host our playback position, given as the
+// seconds of audio played since the current stream started. There is
+// nothing to report while paused or on a live stream.
+func (m *Manager) ReportPosition(elapsed float64) error {
+	if elapsed < 0 || math.IsNaN(elapsed) || math.IsInf(elapsed, 0) {
+		return fmt.Errorf("invalid position")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	g := m.group
+	if g == nil || g.Role != "listener" {
+		return fmt.Errorf("not in a listening group")
+	}
+	if g.Track == nil || g.Track.IsStream || g.PlayState == nil || !g.PlayState.Playing {
+		return nil
+	}
+	if !m.startKnown {
+		return fmt.Errorf("stream start unknown")
+	}
+	track := *g.Track
+	m.sendControl(ControlMsg{Action: "report", Track: &track, Position: m.streamStart + elapsed})
+	return nil
+}
+
+// handleReport records a listener's position report and resyncs the
+// listener when it drifted too far.
+func (m *Manager) handleReport(from string, ctrl ControlMsg) {
+	at := m.hostTime(from, ctrl.SentAt) // the report's send time on our clock
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pos, resync := m.noteReportLocked(from, ctrl, at, time.Now())
+	if !resync {
+		return
+	}
+	log.Printf("LISTEN: Listener %s is %+.1fs off, resyncing at %.1fs", from, m.drift[from].Offset, pos)
+	m.sendControl(ControlMsg{Action: "resync", Peer: from, Position: pos})
+	m.notifyBrowser()
+}
+
+// noteReportLocked stores the offset of a report made at our time at and
+// returns our current position and whether the listener needs a resync.
+// Reports about another track, or made before the last play or seek, are
+// ignored.
+func (m *Manager) noteReportLocked(from string, ctrl ControlMsg, at int64, now time.Time) (float64, bool) {
+	g := m.group
+	if g == nil || g.Role != "host" || !slices.Contains(g.Listeners, from) {
+		return 0, false
+	}
+	ps := g.PlayState
+	if g.Track == nil || g.Track.IsStream || ps == nil || !ps.Playing ||
+		ctrl.Track == nil || ctrl.Track.Name != g.Track.Name || at < ps.UpdatedAt {
+		return 0, false
+	}
+
+	expected := ps.Position + float64(at-ps.UpdatedAt)/1000.0
+	d := m.drift[from]
+	d.Offset = ctrl.Position - expected
+	resync := math.Abs(d.Offset) > ListenDriftThreshold.Seconds() &&
+		now.UnixMilli()-d.ResyncAt >= ListenResyncCooldown.Milliseconds()
+	if resync {
+		d.ResyncAt = now.UnixMilli()
+	}
+	if m.drift == nil {
+		m.drift = make(map[string]listenerDrift)
+	}
+	m.drift[from] = d
+	m.setDriftLocked()
+	return m.currentPosition(), resync
+}
+
+// forgetDriftLocked drops a listener that left.
+func (m *Manager) forgetDriftLocked(peerID string) {
+	if _, ok := m.drift[peerID]; ok {
+		delete(m.drift, peerID)
+		m.setDriftLocked()
+	}
+}
+
+// setDriftLocked copies the offsets into the group state. It makes a new
+// map each time, as notifyBrowser publishes the group as is.
+func (m *Manager) setDriftLocked() {
+	if m.group == nil {
+		return
+	}
+	offsets := make(map[string]float64, len(m.drift))
+	for id, ld := range m.drift {
+		offsets[id] = math.Round(ld.Offset*100) / 100
+	}
+	m.group.Drift = offsets
+}
+
+// restartAudioLocked closes our audio stream from the host and tells the
+// player to reconnect.
+func (m *Manager) restartAudioLocked() {
+	if m.audio != nil {
+		m.audio.Close()
+		m.audio = nil
+	}
+	m.startKnown = false
+	if m.group != nil {
+		m.group.StreamGen++
+	}
+}
@@ -0,0 +1,97 @@
+package listen
+
+import (
+	"testing"
+	"time"
+)
+
+func driftHost(t *testing.T) (*Manager, int64) {
+	t.Helper()
+	m := NewTestManagerOpts(TestManagerOpts{SelfID: "host"})
+	started := time.Now().Add(-time.Minute).UnixMilli()
+	m.SetTestGroupFull(&Group{
+		ID:        "listen-abc",
+		Role:      "host",
+		Listeners: []string{"host", "bob"},
+		Track:     &Track{Name: "song.mp3", Duration: 300, Format: "mp3"},
+		PlayState: &PlayState{Playing: true, Position: 10, UpdatedAt: started},
+	})
+	return m, started
+}
+
+func TestNoteReport(t *testing.T) {
+	m, started := driftHost(t)
+	now := time.Now()
+	at := started + 20_000 // the host was at 30s when the report was made
+	report := func(from, track string, pos float64, now time.Time) bool {
+		_, resync := m.noteReportLocked(from, ControlMsg{Action: "report", Track: &Track{Name: track}, Position: pos}, at, now)
+		return resync
+	}
+
+	if report("bob", "song.mp3", 31, now) {
+		t.Fatal("resync within the threshold")
+	}
+	if got := m.group.Drift["bob"]; got != 1 {
+		t.Fatalf("drift = %v, want 1", got)
+	}
+	if !report("bob", "song.mp3", 26, now) {
+		t.Fatal("no resync beyond the threshold")
+	}
+	if got := m.group.Drift["bob"]; got != -4 {
+		t.Fatalf("drift = %v, want -4", got)
+	}
+	if report("bob", "song.mp3", 26, now.Add(time.Second)) {
+		t.Fatal("resync during the cooldown")
+	}
+	if !report("bob", "song.mp3", 26, now.Add(ListenResyncCooldown)) {
+		t.Fatal("no resync after the cooldown")
+	}
+
+	if report("mallory", "song.mp3", 0, now.Add(time.Hour)) || m.group.Drift["mallory"] != 0 {
+		t.Fatal("report from a non-member counted")
+	}
+	if report("bob", "other.mp3", 0, now.Add(time.Hour)) || m.group.Drift["bob"] != -4 {
+		t.Fatal("report about another track counted")
+	}
+
+	m.forgetDriftLocked("bob")
+	if _, ok := m.group.Drift["bob"]; ok {
+		t.Fatal("drift kept after leaving")
+	}
+}
+
+func TestNoteReportPaused(t *testing.T) {
+	m, started := driftHost(t)
+	m.group.PlayState.Playing = false
+	if _, resync := m.noteReportLocked("bob", ControlMsg{Track: &Track{Name: "song.mp3"}, Position: 100}, started, time.Now()); resync {
+		t.Fatal("resync while paused")
+	}
+}
+
+type closeRecorder struct{ closed bool }
+
+func (c *closeRecorder) Close() error { c.closed = true; return nil }
+
+func TestHandleControlEventResync(t *testing.T) {
+	m := testManagerWithGroup(t)
+	audio := &closeRecorder{}
+	m.audio, m.startKnown = audio, true
+
+	m.handleControlEvent("", controlPayload("resync", map[string]any{"peer": "someone-else", "position": 50.0}))
+	if audio.closed || m.GetGroup().StreamGen != 0 {
+		t.Fatal("acted on another listener's resync")
+	}
+
+	m.handleControlEvent("", controlPayload("resync", map[string]any{"peer": "me", "position": 50.0}))
+	g := m.GetGroup()
+	if !audio.closed || m.audio != nil || m.startKnown {
+		t.Fatal("audio stream not dropped")
+	}
+	if g.StreamGen != 1 {
+		t.Fatalf("stream_gen = %d, want 1", g.StreamGen)
+	}
+	// GetGroup advances a playing position by the time since the resync.
+	if g.PlayState == nil || !g.PlayState.Playing || g.PlayState.Position < 50 || g.PlayState.Position > 51 {
+		t.Fatalf("play state = %+v", g.PlayState)
+	}
+}
@@ -3,6 +3,7 @@ package listen
 import (
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/group"
@@ -12,9 +13,30 @@ func (m *Manager) sendControl(msg ControlMsg) {
 	if m.group == nil {
 		return
 	}
+	msg.SentAt = time.Now().UnixMilli()
 	_ = m.grp.SendControl(m.group.ID, "listen", msg)
 }
 
+// hostTime converts the host's send time of a control message to our
+// clock, so playback position counts from when the host sent it rather
+// than from when it arrived. Without a clock estimate, or when the
+// result is implausible, it is the arrival time.
+func (m *Manager) hostTime(hostID string, sentAt int64) int64 {
+	now := time.Now().UnixMilli()
+	if sentAt == 0 || hostID == "" || m.clock == nil {
+		return now
+	}
+	offsetMs, ok := m.clock(hostID)
+	if !ok {
+		return now
+	}
+	at := sentAt - int64(math.Round(offsetMs))
+	if at > now || now-at > ListenMaxControlDelay.Milliseconds() {
+		return now
+	}
+	return at
+}
+
 func (m *Manager) Flags() group.GroupTypeFlags {
 	return group.GroupTypeFlags{HostCanJoin: true}
 }
@@ -38,6 +60,9 @@ func (m *Manager) OnCreate(groupID, name string, _ int) error {
 
 	log.Printf("LISTEN: Initialized host state for group %s (%s)", groupID, name)
 	m.notifyBrowser()
+	if m.onStart != nil {
+		m.onStart(name)
+	}
 	return nil
 }
 
@@ -48,6 +73,9 @@ func (m *Manager) OnJoin(_ string, peerID string, _ bool) {
 
 // OnLeave is called on the host when a member leaves the listen group.
 func (m *Manager) OnLeave(_ string, peerID string, _ bool) {
+	m.mu.Lock()
+	m.forgetDriftLocked(peerID)
+	m.mu.Unlock()
 	log.Printf("LISTEN: Listener %s left", peerID)
 }
 
@@ -61,6 +89,8 @@ func (m *Manager) OnClose(groupID string) {
 		m.filePath = ""
 		m.queue = nil
 		m.queueIdx = 0
+		m.requests = nil
+		m.drift = nil
 	}
 	m.mu.Unlock()
 	m.saveQueueToDisk()
@@ -127,7 +157,7 @@ func (m *Manager) OnEvent(evt *group.Event) {
 			m.notifyBrowserLocked()
 		}
 	case "msg":
-		m.handleControlEvent(evt.Payload)
+		m.handleControlEvent(evt.From, evt.Payload)
 	case "members":
 		if lg.Role == "host" {
 			m.handleMembersEvent(evt)
@@ -150,8 +180,9 @@ func (m *Manager) handleMembersEvent(evt *group.Event) {
 	var syncQueueTypes []string
 	var syncQueueIdx, syncQueueTotal int
 	var syncPos float64
-	var syncPlaying bool
+	var syncPlaying, syncJukebox bool
 	if hasNewListeners && m.group.Track != nil {
+		syncJukebox = m.group.Jukebox
 		syncTrack = m.group.Track
 		syncQueue = append([]string(nil), m.group.Queue...)
 		syncQueueTypes = append([]string(nil), m.group.QueueTypes...)
@@ -182,6 +213,7 @@ func (m *Manager) handleMembersEvent(evt *group.Event) {
 			QueueTypes: syncQueueTypes,
 			QueueIndex: syncQueueIdx,
 			QueueTotal: syncQueueTotal,
+			Jukebox:    syncJukebox,
 		})
 		if syncPlaying {
 			m.sendControl(ControlMsg{Action: "play", Position: syncPos})
@@ -191,11 +223,21 @@ func (m *Manager) handleMembersEvent(evt *group.Event) {
 	}
 }
 
-func (m *Manager) handleControlEvent(payload any) {
+func (m *Manager) handleControlEvent(from string, payload any) {
 	var ctrl ControlMsg
 	if !group.ParseControl(payload, "listen", &ctrl) {
 		return
 	}
+	switch ctrl.Action {
+	case "request":
+		// Only the host acts on requests and reports; listeners see them relayed.
+		m.handleRequest(from, ctrl.Request)
+		return
+	case "report":
+		m.handleReport(from, ctrl)
+		return
+	}
+	sentAt := m.hostTime(from, ctrl.SentAt)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -218,13 +260,14 @@ func (m *Manager) handleControlEvent(payload any) {
 			m.group.QueueIndex = ctrl.QueueIndex
 			m.group.QueueTotal = ctrl.QueueTotal
 		}
+		m.group.Jukebox = ctrl.Jukebox
 		log.Printf("LISTEN: Host loaded track: %s", ctrl.Track.Name)
 
 	case "play":
 		m.group.PlayState = &PlayState{
 			Playing:   true,
 			Position:  ctrl.Position,
-			UpdatedAt: time.Now().UnixMilli(),
+			UpdatedAt: sentAt,
 		}
 		log.Printf("LISTEN: Host started playback at %.1fs", ctrl.Position)
 
@@ -232,7 +275,7 @@ func (m *Manager) handleControlEvent(payload any) {
 		m.group.PlayState = &PlayState{
 			Playing:   false,
 			Position:  ctrl.Position,
-			UpdatedAt: time.Now().UnixMilli(),
+			UpdatedAt: sentAt,
 		}
 		log.Printf("LISTEN: Host paused at %.1fs", ctrl.Position)
 
@@ -241,7 +284,7 @@ func (m *Manager) handleControlEvent(payload any) {
 		m.group.PlayState = &PlayState{
 			Playing:   wasPlaying,
 			Position:  ctrl.Position,
-			UpdatedAt: time.Now().UnixMilli(),
+			UpdatedAt: sentAt,
 		}
 		m.closeHTTPPipeLocked()
 		log.Printf("LISTEN: Host seeked to %.1fs", ctrl.Position)
@@ -259,8 +302,23 @@ func (m *Manager) handleControlEvent(payload any) {
 		m.group.PlayState = &PlayState{
 			Playing:   true,
 			Position:  ctrl.Position,
-			UpdatedAt: time.Now().UnixMilli(),
+			UpdatedAt: sentAt,
+		}
+
+	case "jukebox":
+		m.group.Jukebox = ctrl.Jukebox
+
+	case "resync":
+		if ctrl.Peer != m.selfID || m.group.Role != "listener" {
+			return
+		}
+		m.group.PlayState = &PlayState{
+			Playing:   true,
+			Position:  ctrl.Position,
+			UpdatedAt: sentAt,
 		}
+		m.restartAudioLocked()
+		log.Printf("LISTEN: Host resynced us to %.1fs", ctrl.Position)
 
 	case "close":
 		m.closeHTTPPipeLocked()
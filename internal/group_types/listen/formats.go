@@ -0,0 +1,505 @@
+package listen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// audioFormat describes a file format the host can load into the queue.
+// The audio is never decoded: the host streams the file's bytes and the
+// listener's browser plays them, so a format only needs a probe for
+// bitrate and duration and enough knowledge of its framing to start a
+// stream part-way through the file.
+type audioFormat struct {
+	name        string   // Track.Format, sent in the OK handshake and control messages
+	contentType string   // Content-Type of /api/listen/stream
+	exts        []string // used when the first bytes do not identify the file
+	sniff       func(head []byte) bool
+	probe       func(path string) (*audioInfo, error)
+
+	// header returns the size of the header a decoder needs before any
+	// audio data (nil = no header). A stream that starts mid-file sends it
+	// first.
+	header func(f *os.File) (int64, error)
+
+	// sync returns the index of the first frame or page start in b, or -1.
+	// A mid-file stream resumes there. nil = any offset will do.
+	sync func(b []byte) int
+
+	// seekable is false when the file cannot be played from the middle;
+	// such tracks always stream from the start.
+	seekable bool
+}
+
+// audioFormats lists the supported formats. mp3 comes first so files
+// whose first bytes match nothing fall back to the original behaviour.
+var audioFormats = []*audioFormat{
+	{
+		name:        "mp3",
+		contentType: "audio/mpeg",
+		exts:        []string{".mp3"},
+		sniff: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("ID3")) ||
+				len(h) > 1 && h[0] == 0xFF && h[1]&0xE0 == 0xE0 && h[1]&0x06 != 0
+		},
+		probe:    probeMP3,
+		seekable: true,
+	},
+	{
+		name:        "flac",
+		contentType: "audio/flac",
+		exts:        []string{".flac"},
+		sniff:       func(h []byte) bool { return bytes.HasPrefix(h, []byte("fLaC")) },
+		probe:       probeFLAC,
+		header:      flacHeaderSize,
+		sync:        flacSync,
+		seekable:    true,
+	},
+	{
+		name:        "ogg",
+		contentType: "audio/ogg",
+		exts:        []string{".ogg", ".oga"},
+		sniff:       func(h []byte) bool { return bytes.HasPrefix(h, []byte("OggS")) },
+		probe:       probeOgg,
+		header:      oggHeaderSize,
+		sync:        oggSync,
+		seekable:    true,
+	},
+	{
+		name:        "aac",
+		contentType: "audio/aac",
+		exts:        []string{".aac"},
+		sniff:       func(h []byte) bool { return adtsSync(h) == 0 },
+		probe:       probeAAC,
+		sync:        adtsSync,
+		seekable:    true,
+	},
+	{
+		name:        "m4a",
+		contentType: "audio/mp4",
+		exts:        []string{".m4a", ".mp4"},
+		sniff:       func(h []byte) bool { return len(h) >= 8 && string(h[4:8]) == "ftyp" },
+		probe:       probeM4A,
+		seekable:    false, // sample tables address the whole file
+	},
+}
+
+// ContentType returns the Content-Type for a track format.
+func ContentType(format string) string {
+	if af := formatByName(format); af != nil {
+		return af.contentType
+	}
+	return "audio/mpeg" // live streams and tracks from older hosts
+}
+
+func formatByName(name string) *audioFormat {
+	for _, af := range audioFormats {
+		if af.name == name {
+			return af
+		}
+	}
+	return nil
+}
+
+// detectFormat identifies a file by its first bytes, then by extension.
+func detectFormat(path string) (*audioFormat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, 16)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+
+	for _, af := range audioFormats {
+		if af.sniff(head) {
+			return af, nil
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, af := range audioFormats {
+		for _, e := range af.exts {
+			if e == ext {
+				return af, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported audio format")
+}
+
+// probeAudio detects the format of a file and reads its bitrate and duration.
+func probeAudio(path string) (*audioFormat, *audioInfo, error) {
+	af, err := detectFormat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := af.probe(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", af.name, err)
+	}
+	return af, info, nil
+}
+
+// openAudio opens a queued file for streaming from pos seconds in. The
+// byte offset is estimated from the average bitrate; formats with a header
+// get it first, and the data after it resumes at a frame boundary so the
+// listener's decoder can pick up mid-file.
+func openAudio(path, format string, bitrate int, pos float64) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	af := formatByName(format)
+	offset := int64(pos * float64(bitrate) / 8.0)
+	if offset <= 0 || af != nil && !af.seekable {
+		return f, nil
+	}
+	if af == nil || af.header == nil && af.sync == nil {
+		f.Seek(offset, io.SeekStart) //nolint:errcheck
+		return f, nil
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var hdr int64
+	if af.header != nil {
+		if hdr, err = af.header(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	offset += hdr
+	if af.sync != nil {
+		buf := make([]byte, 64*1024)
+		n, _ := f.ReadAt(buf, offset)
+		if i := af.sync(buf[:n]); i >= 0 {
+			offset += int64(i)
+		}
+	}
+	offset = min(offset, stat.Size())
+	return &audioReader{
+		Reader: io.MultiReader(io.NewSectionReader(f, 0, hdr), io.NewSectionReader(f, offset, stat.Size()-offset)),
+		Closer: f,
+	}, nil
+}
+
+type audioReader struct {
+	io.Reader
+	io.Closer
+}
+
+// ── FLAC ─────────────────────────────────────────────────────────────────────
+
+// flacHeaderSize returns the size of the "fLaC" marker and metadata blocks.
+func flacHeaderSize(f *os.File) (int64, error) {
+	var magic [4]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil || string(magic[:]) != "fLaC" {
+		return 0, errors.New("missing fLaC marker")
+	}
+	off := int64(4)
+	for {
+		var bh [4]byte
+		if _, err := f.ReadAt(bh[:], off); err != nil {
+			return 0, fmt.Errorf("read metadata block: %w", err)
+		}
+		off += 4 + (int64(bh[1])<<16 | int64(bh[2])<<8 | int64(bh[3]))
+		if bh[0]&0x80 != 0 { // last metadata block
+			return off, nil
+		}
+	}
+}
+
+func flacSync(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == 0xFF && b[i+1]&0xFE == 0xF8 {
+			return i
+		}
+	}
+	return -1
+}
+
+// probeFLAC reads the sample rate and sample count from STREAMINFO.
+func probeFLAC(path string) (*audioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hdr, err := flacHeaderSize(f)
+	if err != nil {
+		return nil, err
+	}
+	var si [4 + 34]byte // block header + STREAMINFO
+	if _, err := f.ReadAt(si[:], 4); err != nil || si[0]&0x7F != 0 {
+		return nil, errors.New("missing STREAMINFO")
+	}
+	v := binary.BigEndian.Uint64(si[4+10 : 4+18])
+	sampleRate := v >> 44
+	samples := v & (1<<36 - 1)
+	if sampleRate == 0 || samples == 0 {
+		return nil, errors.New("unknown stream length")
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	duration := float64(samples) / float64(sampleRate)
+	return &audioInfo{
+		Bitrate:  int(float64((stat.Size()-hdr)*8) / duration),
+		Duration: duration,
+	}, nil
+}
+
+// ── Ogg Vorbis ───────────────────────────────────────────────────────────────
+
+// oggPage reads the page header at off and returns the granule position
+// and the size of the whole page.
+func oggPage(f *os.File, off int64) (granule uint64, size int64, err error) {
+	var ph [27]byte
+	if _, err := f.ReadAt(ph[:], off); err != nil {
+		return 0, 0, err
+	}
+	if string(ph[:4]) != "OggS" {
+		return 0, 0, errors.New("lost page sync")
+	}
+	segs := make([]byte, ph[26])
+	if _, err := f.ReadAt(segs, off+27); err != nil {
+		return 0, 0, err
+	}
+	size = 27 + int64(len(segs))
+	for _, s := range segs {
+		size += int64(s)
+	}
+	return binary.LittleEndian.Uint64(ph[6:14]), size, nil
+}
+
+// oggHeaderSize returns the size of the pages holding the Vorbis headers:
+// everything before the first page with a granule position.
+func oggHeaderSize(f *os.File) (int64, error) {
+	off := int64(0)
+	for range 64 {
+		granule, size, err := oggPage(f, off)
+		if err != nil {
+			return 0, err
+		}
+		if granule != 0 && granule != ^uint64(0) {
+			return off, nil
+		}
+		off += size
+	}
+	return 0, errors.New("no audio pages")
+}
+
+func oggSync(b []byte) int {
+	return bytes.Index(b, []byte("OggS\x00"))
+}
+
+// probeOgg reads the sample rate from the Vorbis identification header and
+// the length from the granule position of the last page.
+func probeOgg(path string) (*audioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ph [27]byte
+	if _, err := f.ReadAt(ph[:], 0); err != nil || string(ph[:4]) != "OggS" {
+		return nil, errors.New("missing OggS page")
+	}
+	id := make([]byte, 24)
+	if _, err := f.ReadAt(id, 27+int64(ph[26])); err != nil || string(id[:7]) != "\x01vorbis" {
+		return nil, errors.New("not a Vorbis stream")
+	}
+	sampleRate := binary.LittleEndian.Uint32(id[12:16])
+	nominal := int32(binary.LittleEndian.Uint32(id[20:24]))
+	if sampleRate == 0 {
+		return nil, errors.New("bad sample rate")
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	tailSize := min(stat.Size(), 64*1024)
+	tail := make([]byte, tailSize)
+	if _, err := f.ReadAt(tail, stat.Size()-tailSize); err != nil {
+		return nil, err
+	}
+	last := bytes.LastIndex(tail, []byte("OggS\x00"))
+	if last < 0 || last+14 > len(tail) {
+		return nil, errors.New("no final page")
+	}
+	duration := float64(binary.LittleEndian.Uint64(tail[last+6:last+14])) / float64(sampleRate)
+	if duration <= 0 {
+		return nil, errors.New("unknown stream length")
+	}
+	bitrate := int(float64(stat.Size()*8) / duration)
+	if bitrate <= 0 && nominal > 0 {
+		bitrate = int(nominal)
+	}
+	return &audioInfo{Bitrate: bitrate, Duration: duration}, nil
+}
+
+// ── AAC (ADTS) ───────────────────────────────────────────────────────────────
+
+var adtsSampleRates = [...]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+func adtsSync(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == 0xFF && b[i+1]&0xF6 == 0xF0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// probeAAC averages the bitrate over the first frames of an ADTS stream.
+func probeAAC(path string) (*audioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 64*1024)
+	n, _ := f.ReadAt(buf, 0)
+	buf = buf[:n]
+	start := adtsSync(buf)
+	if start < 0 {
+		return nil, errors.New("no ADTS frame found")
+	}
+
+	var bytesRead, samples, sampleRate int
+	for off := start; off+7 <= len(buf) && samples < 50*1024; {
+		h := buf[off:]
+		if h[0] != 0xFF || h[1]&0xF6 != 0xF0 {
+			break
+		}
+		sr := int(h[2]>>2) & 0x0F
+		frameLen := int(h[3]&0x03)<<11 | int(h[4])<<3 | int(h[5])>>5
+		if sr >= len(adtsSampleRates) || frameLen < 7 {
+			break
+		}
+		sampleRate = adtsSampleRates[sr]
+		bytesRead += frameLen
+		samples += 1024 * (int(h[6]&0x03) + 1)
+		off += frameLen
+	}
+	if samples == 0 {
+		return nil, errors.New("no valid ADTS frame")
+	}
+	bitrate := int(float64(bytesRead*8) * float64(sampleRate) / float64(samples))
+	return &audioInfo{
+		Bitrate:  bitrate,
+		Duration: float64((stat.Size()-int64(start))*8) / float64(bitrate),
+	}, nil
+}
+
+// ── MP4 / M4A ────────────────────────────────────────────────────────────────
+
+// mp4Box reads the box header at off and returns its type, header size and
+// total size (to the end of the file for a size of 0).
+func mp4Box(f *os.File, off, fileSize int64) (typ string, hdr, size int64, err error) {
+	var bh [16]byte
+	if _, err := f.ReadAt(bh[:8], off); err != nil {
+		return "", 0, 0, err
+	}
+	typ = string(bh[4:8])
+	size, hdr = int64(binary.BigEndian.Uint32(bh[:4])), 8
+	switch size {
+	case 0:
+		size = fileSize - off
+	case 1:
+		if _, err := f.ReadAt(bh[8:16], off+8); err != nil {
+			return "", 0, 0, err
+		}
+		size, hdr = int64(binary.BigEndian.Uint64(bh[8:16])), 16
+	}
+	if size < hdr || off+size > fileSize {
+		return "", 0, 0, fmt.Errorf("bad %q box", typ)
+	}
+	return typ, hdr, size, nil
+}
+
+// probeM4A reads the duration from the movie header and the bitrate from
+// the size of the media data. Browsers can only play an MP4 as it arrives
+// when the movie box comes first, so files without it are refused.
+func probeM4A(path string) (*audioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var duration float64
+	var mdat int64
+	for off := int64(0); off < stat.Size(); {
+		typ, hdr, size, err := mp4Box(f, off, stat.Size())
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case "moov":
+			if duration, err = mp4Duration(f, off+hdr, off+size, stat.Size()); err != nil {
+				return nil, err
+			}
+		case "mdat":
+			if duration == 0 {
+				return nil, errors.New("media data before the movie box; remux with faststart")
+			}
+			mdat += size - hdr
+		}
+		off += size
+	}
+	if duration <= 0 || mdat == 0 {
+		return nil, errors.New("no movie header or media data")
+	}
+	return &audioInfo{Bitrate: int(float64(mdat*8) / duration), Duration: duration}, nil
+}
+
+// mp4Duration finds mvhd among the children of moov, in [off, end).
+func mp4Duration(f *os.File, off, end, fileSize int64) (float64, error) {
+	for off < end {
+		typ, hdr, size, err := mp4Box(f, off, fileSize)
+		if err != nil {
+			return 0, err
+		}
+		if typ == "mvhd" {
+			var b [32]byte
+			if _, err := f.ReadAt(b[:], off+hdr); err != nil {
+				return 0, err
+			}
+			var timescale, units uint64
+			if b[0] == 1 { // version 1: 64-bit times
+				timescale = uint64(binary.BigEndian.Uint32(b[20:24]))
+				units = binary.BigEndian.Uint64(b[24:32])
+			} else {
+				timescale = uint64(binary.BigEndian.Uint32(b[12:16]))
+				units = uint64(binary.BigEndian.Uint32(b[16:20]))
+			}
+			if timescale == 0 {
+				return 0, errors.New("bad timescale")
+			}
+			return float64(units) / float64(timescale), nil
+		}
+		off += size
+	}
+	return 0, errors.New("no movie header")
+}
@@ -0,0 +1,206 @@
+package listen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The writers build files with 100,000 bytes of audio data: 10 seconds
+// of FLAC, Ogg and M4A, and 250 ADTS frames (5.8 s) of AAC.
+
+func writeFLAC(t *testing.T, dir string) (path string, header int) {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{0x00, 0, 0, 34}) // STREAMINFO, not last
+	si := make([]byte, 34)
+	binary.BigEndian.PutUint64(si[10:18], 44100<<44|2<<41|15<<36|441000)
+	b.Write(si)
+	b.Write([]byte{0x84, 0, 0, 8}) // VORBIS_COMMENT, last
+	b.Write(make([]byte, 8))
+	header = b.Len()
+	for range 100 {
+		frame := make([]byte, 1000)
+		frame[0], frame[1] = 0xFF, 0xF8
+		b.Write(frame)
+	}
+	path = filepath.Join(dir, "track.flac")
+	os.WriteFile(path, b.Bytes(), 0o644)
+	return path, header
+}
+
+func oggPageBytes(granule uint64, payload []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("OggS\x00\x00")
+	binary.Write(&b, binary.LittleEndian, granule)
+	b.Write(make([]byte, 12)) // serial, sequence, CRC
+	var segs []byte
+	for n := len(payload); ; n -= 255 {
+		if n < 255 {
+			segs = append(segs, byte(n))
+			break
+		}
+		segs = append(segs, 255)
+	}
+	b.WriteByte(byte(len(segs)))
+	b.Write(segs)
+	b.Write(payload)
+	return b.Bytes()
+}
+
+func writeOgg(t *testing.T, dir string) (path string, header int) {
+	t.Helper()
+	ident := make([]byte, 30)
+	copy(ident, "\x01vorbis")
+	ident[11] = 2
+	binary.LittleEndian.PutUint32(ident[12:], 44100)
+	binary.LittleEndian.PutUint32(ident[20:], 128000)
+	var b bytes.Buffer
+	b.Write(oggPageBytes(0, ident))
+	b.Write(oggPageBytes(0, append([]byte("\x03vorbis"), make([]byte, 500)...)))
+	header = b.Len()
+	for i := range 100 {
+		b.Write(oggPageBytes(uint64(i+1)*4410, make([]byte, 900)))
+	}
+	path = filepath.Join(dir, "track.ogg")
+	os.WriteFile(path, b.Bytes(), 0o644)
+	return path, header
+}
+
+func writeAAC(t *testing.T, dir string) string {
+	t.Helper()
+	const frameLen = 400
+	var b bytes.Buffer
+	for range 250 {
+		frame := make([]byte, frameLen)
+		copy(frame, []byte{0xFF, 0xF1, 0x50, 0x80 | frameLen>>11, frameLen >> 3 & 0xFF, frameLen&7<<5 | 0x1F, 0xFC})
+		b.Write(frame)
+	}
+	path := filepath.Join(dir, "track.aac")
+	os.WriteFile(path, b.Bytes(), 0o644)
+	return path
+}
+
+func mp4BoxBytes(typ string, payload []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	return append(append(b, typ...), payload...)
+}
+
+func writeM4A(t *testing.T, dir string, faststart bool) string {
+	t.Helper()
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 10000)
+	moov := mp4BoxBytes("moov", mp4BoxBytes("mvhd", mvhd))
+	mdat := mp4BoxBytes("mdat", make([]byte, 100000))
+	file := mp4BoxBytes("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	if faststart {
+		file = append(append(file, moov...), mdat...)
+	} else {
+		file = append(append(file, mdat...), moov...)
+	}
+	path := filepath.Join(dir, "track.m4a")
+	os.WriteFile(path, file, 0o644)
+	return path
+}
+
+func TestProbeAudio_formats(t *testing.T) {
+	dir := t.TempDir()
+	flac, _ := writeFLAC(t, dir)
+	ogg, _ := writeOgg(t, dir)
+
+	cases := []struct {
+		path, format string
+		bitrate      int
+		duration     float64
+	}{
+		{flac, "flac", 80000, 10},
+		{ogg, "ogg", 0, 10}, // bitrate includes page overhead
+		{writeAAC(t, dir), "aac", 137812, 5.80},
+		{writeM4A(t, dir, true), "m4a", 80000, 10},
+		{writeMinimalMP3(t, t.TempDir()), "mp3", 0, 0},
+	}
+	for _, c := range cases {
+		af, info, err := probeAudio(c.path)
+		if err != nil {
+			t.Errorf("%s: %v", filepath.Base(c.path), err)
+			continue
+		}
+		if af.name != c.format {
+			t.Errorf("%s: format %s, want %s", filepath.Base(c.path), af.name, c.format)
+		}
+		if c.bitrate != 0 && info.Bitrate != c.bitrate || c.duration != 0 && math.Abs(info.Duration-c.duration) > 0.01 {
+			t.Errorf("%s: %d bps, %.2fs; want %d bps, %.2fs", c.format, info.Bitrate, info.Duration, c.bitrate, c.duration)
+		}
+		if ContentType(af.name) == "audio/mpeg" && af.name != "mp3" {
+			t.Errorf("%s: content type %s", af.name, ContentType(af.name))
+		}
+	}
+
+	if _, _, err := probeAudio(writeM4A(t, t.TempDir(), false)); err == nil || !strings.Contains(err.Error(), "faststart") {
+		t.Errorf("m4a without faststart: %v", err)
+	}
+	text := filepath.Join(dir, "notes.txt")
+	os.WriteFile(text, []byte("not audio at all"), 0o644)
+	if _, _, err := probeAudio(text); err == nil {
+		t.Error("text file accepted")
+	}
+	if ContentType("stream") != "audio/mpeg" {
+		t.Errorf("stream content type = %s", ContentType("stream"))
+	}
+}
+
+func TestOpenAudio_resumesMidFile(t *testing.T) {
+	dir := t.TempDir()
+	flac, flacHeader := writeFLAC(t, dir)
+	ogg, oggHeader := writeOgg(t, dir)
+
+	for _, c := range []struct {
+		path, format string
+		header       int
+		sync         string
+	}{
+		{flac, "flac", flacHeader, "\xFF\xF8"},
+		{ogg, "ogg", oggHeader, "OggS"},
+	} {
+		_, info, err := probeAudio(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		whole, _ := os.ReadFile(c.path)
+
+		rc, err := openAudio(c.path, c.format, info.Bitrate, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if !bytes.Equal(got[:c.header], whole[:c.header]) {
+			t.Errorf("%s: stream does not start with the header", c.format)
+		}
+		if !bytes.HasPrefix(got[c.header:], []byte(c.sync)) {
+			t.Errorf("%s: data resumes mid-frame: % x", c.format, got[c.header:c.header+4])
+		}
+		if rest := len(got) - c.header; rest < len(whole)/3 || rest > len(whole)*2/3 {
+			t.Errorf("%s: %d of %d bytes after the header at 5 of 10 s", c.format, rest, len(whole))
+		}
+	}
+
+	// M4A cannot start mid-file: it streams whole.
+	m4a := writeM4A(t, dir, true)
+	rc, err := openAudio(m4a, "m4a", 80000, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if whole, _ := os.ReadFile(m4a); !bytes.Equal(got, whole) {
+		t.Error("m4a stream is not the whole file")
+	}
+}
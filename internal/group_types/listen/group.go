@@ -18,6 +18,17 @@ type Group struct {
 	QueueTypes []string `json:"queue_types,omitempty"` // "file" or "stream" for each track
 	QueueIndex int      `json:"queue_index"`           // 0-based index of current track
 	QueueTotal int      `json:"queue_total"`           // total tracks in queue (0 = no queue)
+
+	// Listener requests. Jukebox queues them as they arrive; the pending
+	// count is only known to the host.
+	Jukebox         bool `json:"jukebox,omitempty"`
+	PendingRequests int  `json:"pending_requests,omitempty"`
+
+	// Drift correction (see drift.go). The host keeps each listener's last
+	// measured offset from its own position in seconds, positive when the
+	// listener is ahead; a listener counts the restarts the host asked for.
+	Drift     map[string]float64 `json:"drift,omitempty"`
+	StreamGen int                `json:"stream_gen,omitempty"`
 }
 
 // Track describes the currently loaded audio track.
@@ -25,7 +36,7 @@ type Track struct {
 	Name     string  `json:"name"`
 	Duration float64 `json:"duration"` // seconds
 	Bitrate  int     `json:"bitrate"`  // bits per second
-	Format   string  `json:"format"`   // "mp3" or "stream"
+	Format   string  `json:"format"`   // "mp3", "flac", "ogg", "aac", "m4a" or "stream"
 	IsStream bool    `json:"is_stream"` // true for HTTP/HTTPS streams
 }
 
@@ -38,11 +49,15 @@ type PlayState struct {
 
 // ControlMsg is the envelope sent over the group protocol for listen events.
 type ControlMsg struct {
-	Action     string   `json:"action"`              // load, play, pause, seek, sync, close
-	Track      *Track   `json:"track,omitempty"`     // set on "load"
-	Position   float64  `json:"position,omitempty"`  // set on "seek", "sync", "play"
+	Action     string   `json:"action"`              // load, play, pause, seek, sync, close, request, jukebox, report, resync
+	Track      *Track   `json:"track,omitempty"`     // set on "load", "sync" and "report"
+	Position   float64  `json:"position,omitempty"`  // set on "seek", "sync", "play", "report", "resync"
 	Queue      []string `json:"queue,omitempty"`     // track names; set on "load"
 	QueueTypes []string `json:"queue_types,omitempty"` // "file" or "stream"; set on "load"
 	QueueIndex int      `json:"queue_index"`         // current track index; set on "load"
 	QueueTotal int      `json:"queue_total"`         // total tracks; set on "load"
+	SentAt     int64    `json:"sent_at,omitempty"`   // sender's clock when sent, unix millis
+	Request    string   `json:"request,omitempty"`   // track name or stream URL; set on "request" (listener to host)
+	Jukebox    bool     `json:"jukebox,omitempty"`   // set on "jukebox" and "load"
+	Peer       string   `json:"peer,omitempty"`      // the listener a "resync" is for
 }
@@ -7,7 +7,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -47,7 +46,11 @@ func New(h libhost.Host, grp *group.Manager, transport mq.Transport, selfID, dat
 				m.stopCh = make(chan struct{})
 				log.Printf("LISTEN: Recovered group %s (%s) from previous session", g.ID, g.Name)
 
-				if qs := m.loadQueueFromDisk(); qs != nil && len(qs.Paths) > 0 {
+				qs := m.loadQueueFromDisk()
+				if qs != nil && qs.GroupID == g.ID {
+					m.group.Jukebox = qs.Jukebox
+				}
+				if qs != nil && len(qs.Paths) > 0 {
 					m.queue = qs.Paths
 					m.queueIdx = qs.Index
 					if m.queueIdx >= len(m.queue) {
@@ -85,12 +88,13 @@ func (m *Manager) CreateGroup(name string) (*Group, error) {
 	return m.GetGroup(), nil
 }
 
-// LoadTrack loads a single MP3 file, replacing any existing queue.
+// LoadTrack loads a single audio file, replacing any existing queue.
 func (m *Manager) LoadTrack(filePath string) (*Track, error) {
 	return m.LoadQueue([]string{filePath})
 }
 
-// LoadQueue loads one or more MP3 files as a playlist.
+// LoadQueue loads one or more audio files (MP3, FLAC, Ogg Vorbis, AAC or
+// M4A) or stream URLs as a playlist.
 func (m *Manager) LoadQueue(paths []string) (*Track, error) {
 	if len(paths) == 0 {
 		return nil, fmt.Errorf("no paths provided")
@@ -124,7 +128,10 @@ func (m *Manager) AddToQueue(paths []string) error {
 	if m.group == nil || m.group.Role != "host" {
 		return fmt.Errorf("not hosting a group")
 	}
+	return m.addToQueueLocked(paths)
+}
 
+func (m *Manager) addToQueueLocked(paths []string) error {
 	if len(m.queue) == 0 {
 		m.stopPlaybackLocked()
 		m.queue = paths
@@ -173,6 +180,7 @@ func (m *Manager) loadTrackAtLocked(idx int) (*Track, error) {
 			QueueTypes: m.group.QueueTypes,
 			QueueIndex: m.group.QueueIndex,
 			QueueTotal: m.group.QueueTotal,
+			Jukebox:    m.group.Jukebox,
 		})
 
 		log.Printf("LISTEN: Loaded stream %s [%d/%d]", track.Name, idx+1, len(m.queue))
@@ -180,9 +188,9 @@ func (m *Manager) loadTrackAtLocked(idx int) (*Track, error) {
 		return track, nil
 	}
 
-	info, err := probeMP3(filePath)
+	format, info, err := probeAudio(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("probe mp3: %w", err)
+		return nil, fmt.Errorf("probe %s: %w", filepath.Base(filePath), err)
 	}
 
 	m.filePath = filePath
@@ -192,7 +200,7 @@ func (m *Manager) loadTrackAtLocked(idx int) (*Track, error) {
 		Name:     filepath.Base(filePath),
 		Duration: info.Duration,
 		Bitrate:  info.Bitrate,
-		Format:   "mp3",
+		Format:   format.name,
 		IsStream: false,
 	}
 	m.group.Track = track
@@ -210,10 +218,11 @@ func (m *Manager) loadTrackAtLocked(idx int) (*Track, error) {
 		QueueTypes: m.group.QueueTypes,
 		QueueIndex: m.group.QueueIndex,
 		QueueTotal: m.group.QueueTotal,
+		Jukebox:    m.group.Jukebox,
 	})
 
-	log.Printf("LISTEN: Loaded track %s (%d kbps, %.1fs) [%d/%d]",
-		track.Name, track.Bitrate/1000, track.Duration, idx+1, len(m.queue))
+	log.Printf("LISTEN: Loaded track %s (%s, %d kbps, %.1fs) [%d/%d]",
+		track.Name, track.Format, track.Bitrate/1000, track.Duration, idx+1, len(m.queue))
 	m.notifyBrowser()
 	return track, nil
 }
@@ -666,8 +675,10 @@ func (m *Manager) writeAudioChunk(s network.Stream, peerID string, encrypted boo
 			if _, err := s.Write(header); err != nil {
 				return err
 			}
+			m.bytesSent.Add(int64(len(header)))
 			for len(sealedBytes) > 0 {
 				nw, err := s.Write(sealedBytes)
+				m.bytesSent.Add(int64(nw))
 				if err != nil {
 					return err
 				}
@@ -678,6 +689,7 @@ func (m *Manager) writeAudioChunk(s network.Stream, peerID string, encrypted boo
 	}
 	for len(data) > 0 {
 		nw, err := s.Write(data)
+		m.bytesSent.Add(int64(nw))
 		if err != nil {
 			return err
 		}
@@ -732,14 +744,6 @@ func (m *Manager) handleAudioStream(s network.Stream) {
 			encrypted = true
 		}
 	}
-	if encrypted {
-		fmt.Fprintf(s, "EAOK %s %d %.2f\n", lg.Track.Format, lg.Track.Bitrate, lg.Track.Duration)
-	} else {
-		fmt.Fprintf(s, "OK %s %d %.2f\n", lg.Track.Format, lg.Track.Bitrate, lg.Track.Duration)
-	}
-
-	log.Printf("LISTEN: Audio stream started for %s", remotePeer)
-
 	m.mu.RLock()
 	pos := 0.0
 	if lg.PlayState != nil {
@@ -753,6 +757,16 @@ func (m *Manager) handleAudioStream(s network.Stream) {
 	paused := m.paused
 	m.mu.RUnlock()
 
+	// The last field is the position the audio starts at, which listeners
+	// add their playback time to when they report it (see drift.go).
+	if encrypted {
+		fmt.Fprintf(s, "EAOK %s %d %.2f %.3f\n", lg.Track.Format, lg.Track.Bitrate, lg.Track.Duration, pos)
+	} else {
+		fmt.Fprintf(s, "OK %s %d %.2f %.3f\n", lg.Track.Format, lg.Track.Bitrate, lg.Track.Duration, pos)
+	}
+
+	log.Printf("LISTEN: Audio stream started for %s at %.1fs", remotePeer, pos)
+
 	if paused {
 		fmt.Fprintf(s, "")
 		return
@@ -809,18 +823,13 @@ func (m *Manager) handleAudioStream(s network.Stream) {
 		}
 	}
 
-	f, err := os.Open(filePath)
+	f, err := openAudio(filePath, lg.Track.Format, lg.Track.Bitrate, pos)
 	if err != nil {
 		log.Printf("LISTEN: Failed to open file for streaming: %v", err)
 		return
 	}
 	defer f.Close()
 
-	byteOffset := int64(pos * float64(lg.Track.Bitrate) / 8.0)
-	if byteOffset > 0 {
-		f.Seek(byteOffset, io.SeekStart) //nolint:errcheck
-	}
-
 	audioBuffer := make([]byte, 64*1024)
 	checkCounter := 0
 	for {
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/group"
@@ -28,7 +29,7 @@ type Manager struct {
 	group *Group
 
 	// Host-side state
-	filePath string // path to the loaded MP3
+	filePath string // path or stream URL of the loaded track
 	paused   bool
 	stopCh   chan struct{} // closed to stop streaming goroutines
 	seekGen  int64        // incremented on seek to signal reconnect
@@ -37,6 +38,18 @@ type Manager struct {
 	queue    []string // file paths for the playlist
 	queueIdx int      // current index
 
+	// Pending listener requests, oldest first (see requests.go)
+	requests []TrackRequest
+
+	// Host: the last report of each listener (see drift.go)
+	drift map[string]listenerDrift
+
+	// Listener: the audio stream from the host and the host position it
+	// started at, for reporting our playback position (see drift.go)
+	audio       io.Closer
+	streamStart float64
+	startKnown  bool // false until a stream starts, or with a host that does not send it
+
 	// Per-listener audio pipes (listener peerID -> pipe)
 	pipesMu sync.RWMutex
 	pipes   map[string]*listenerPipe
@@ -48,6 +61,16 @@ type Manager struct {
 
 	// Optional encryptor for audio stream chunks.
 	enc ListenEncryptor
+
+	// Optional clock offset to the host in ms (host = ours + offset).
+	clock func(peerID string) (offsetMs float64, ok bool)
+
+	// Optional hook called when we start hosting a station.
+	onStart func(name string)
+
+	// Audio bytes streamed to listeners and received from a host.
+	bytesSent     atomic.Int64
+	bytesReceived atomic.Int64
 }
 
 // ListenEncryptor encrypts and decrypts audio stream chunks.
@@ -61,6 +84,18 @@ func (m *Manager) SetEncryptor(e ListenEncryptor) {
 	m.enc = e
 }
 
+// SetClock sets the clock offset source used to place the host's
+// control messages on our timeline.
+func (m *Manager) SetClock(fn func(peerID string) (offsetMs float64, ok bool)) {
+	m.clock = fn
+}
+
+// SetOnStart sets a hook called with the station name whenever we start
+// hosting one. It runs under the manager lock and must not block.
+func (m *Manager) SetOnStart(fn func(name string)) {
+	m.onStart = fn
+}
+
 type listenerPipe struct {
 	w      io.WriteCloser
 	cancel func()
@@ -71,6 +106,7 @@ type queueState struct {
 	GroupID string   `json:"group_id"`
 	Paths   []string `json:"paths"`
 	Index   int      `json:"index"`
+	Jukebox bool     `json:"jukebox,omitempty"`
 }
 
 func isStreamURL(s string) bool {
@@ -196,4 +232,5 @@ func (m *Manager) Close() {
 	m.closeHTTPPipeLocked()
 
 	m.group = nil
+	m.requests = nil
 }
@@ -0,0 +1,10 @@
+package listen
+
+import "github.com/petervdpas/goop2/internal/prom"
+
+// WriteMetrics writes the audio byte counters for GET /metrics.
+func (m *Manager) WriteMetrics(w *prom.Writer) {
+	const help = "Listen group audio bytes, sent to listeners or received from the host."
+	w.Counter("goop_listen_stream_bytes_total", help, float64(m.bytesSent.Load()), "direction", "sent")
+	w.Counter("goop_listen_stream_bytes_total", help, float64(m.bytesReceived.Load()), "direction", "received")
+}
@@ -3,6 +3,8 @@ package listen
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"os"
+	"slices"
 
 	"github.com/petervdpas/goop2/internal/group"
 )
@@ -12,13 +14,16 @@ func (m *Manager) saveQueueToDisk() {
 		return
 	}
 	groupID := ""
+	jukebox := false
 	if m.group != nil {
 		groupID = m.group.ID
+		jukebox = m.group.Jukebox
 	}
 	_ = m.store.Save("listen-queue", &queueState{
 		GroupID: groupID,
 		Paths:   m.queue,
 		Index:   m.queueIdx,
+		Jukebox: jukebox,
 	})
 }
 
@@ -33,6 +38,45 @@ func (m *Manager) loadQueueFromDisk() *queueState {
 	return &qs
 }
 
+// RepairQueue reconciles the saved queue in dataDir with the listen groups
+// still hosted, before New restores it. A queue saved for a group that is
+// gone is removed; tracks whose files have disappeared are dropped, so the
+// rest of the queue still restores. It returns how many entries it removed,
+// counting a removed queue as one.
+func RepairQueue(dataDir string, hosted []string) (int, error) {
+	store := newStateStore(dataDir)
+	var qs queueState
+	if !store.Load("listen-queue", &qs) {
+		return 0, nil
+	}
+	if len(hosted) == 0 || (qs.GroupID != "" && !slices.Contains(hosted, qs.GroupID)) {
+		return 1, store.Delete("listen-queue")
+	}
+
+	kept := qs.Paths[:0:0]
+	index := qs.Index
+	for i, p := range qs.Paths {
+		if isStreamURL(p) {
+			kept = append(kept, p)
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			if i < qs.Index {
+				index--
+			}
+			continue
+		}
+		kept = append(kept, p)
+	}
+	dropped := len(qs.Paths) - len(kept)
+	if dropped == 0 {
+		return 0, nil
+	}
+	qs.Paths = kept
+	qs.Index = max(0, min(index, len(kept)-1))
+	return dropped, store.Save("listen-queue", &qs)
+}
+
 func newStateStore(dataDir string) *group.StateStore {
 	return group.NewStateStore(dataDir)
 }
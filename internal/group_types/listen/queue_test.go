@@ -1,6 +1,8 @@
 package listen
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -52,3 +54,41 @@ func TestGenerateListenID(t *testing.T) {
 		t.Fatal("two generated IDs should differ")
 	}
 }
+
+func TestRepairQueue(t *testing.T) {
+	dir := t.TempDir()
+	store := newStateStore(dir)
+	track := func(name string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a, c := track("a.mp3"), track("c.mp3")
+	gone := filepath.Join(dir, "b.mp3")
+	stream := "https://radio.example/live"
+	store.Save("listen-queue", &queueState{GroupID: "listen-1", Paths: []string{gone, a, stream, c}, Index: 3})
+
+	n, err := RepairQueue(dir, []string{"listen-1"})
+	if err != nil || n != 1 {
+		t.Fatalf("repair = %d, %v; want 1 dropped", n, err)
+	}
+	var qs queueState
+	store.Load("listen-queue", &qs)
+	if len(qs.Paths) != 3 || qs.Paths[0] != a || qs.Index != 2 {
+		t.Fatalf("queue = %+v, want c still current", qs)
+	}
+	if n, _ := RepairQueue(dir, []string{"listen-1"}); n != 0 {
+		t.Errorf("second repair dropped %d", n)
+	}
+
+	// The group was closed while the peer was down.
+	n, err = RepairQueue(dir, []string{"listen-2"})
+	if err != nil || n != 1 {
+		t.Fatalf("orphan = %d, %v", n, err)
+	}
+	if store.Load("listen-queue", &qs) {
+		t.Error("orphaned queue still on disk")
+	}
+}
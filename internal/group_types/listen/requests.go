@@ -0,0 +1,260 @@
+package listen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Limits on listener requests, enforced by the host.
+const (
+	maxRequestLen      = 512 // bytes of a track name or stream URL
+	maxPendingRequests = 50  // pending on the host, all listeners together
+	maxRequestsPerPeer = 3   // pending per listener
+)
+
+// ErrRequestNotFound is returned for an unknown or already handled request.
+var ErrRequestNotFound = errors.New("request not found")
+
+// TrackRequest is a listener's request for a track, pending on the host
+// until it approves or rejects it.
+type TrackRequest struct {
+	ID     string `json:"id"`
+	PeerID string `json:"peer_id"`
+	Track  string `json:"track"` // file name or stream URL as the listener sent it
+	Name   string `json:"name"`  // display name
+	At     int64  `json:"at"`    // unix millis
+}
+
+// validateRequest accepts an http(s) stream URL or a bare audio file name.
+// Listeners cannot see the host's disk, so a name never carries a path.
+func validateRequest(track string) error {
+	switch {
+	case track == "":
+		return fmt.Errorf("missing track")
+	case len(track) > maxRequestLen:
+		return fmt.Errorf("track longer than %d bytes", maxRequestLen)
+	case isStreamURL(track):
+		return nil
+	case strings.ContainsAny(track, `/\`) || track == "." || track == "..":
+		return fmt.Errorf("track must be a file name or a stream URL")
+	}
+	ext := strings.ToLower(filepath.Ext(track))
+	for _, af := range audioFormats {
+		for _, e := range af.exts {
+			if e == ext {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unsupported audio format %q", ext)
+}
+
+// RequestTrack asks the host of the group we listen to for a track: a file
+// name from the host's library or a stream URL.
+func (m *Manager) RequestTrack(track string) error {
+	track = strings.TrimSpace(track)
+	if err := validateRequest(track); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.group == nil || m.group.Role != "listener" {
+		return fmt.Errorf("not in a listening group")
+	}
+	m.sendControl(ControlMsg{Action: "request", Request: track})
+	log.Printf("LISTEN: Requested %s", requestDisplayName(track))
+	return nil
+}
+
+// Requests returns the pending requests, oldest first.
+func (m *Manager) Requests() []TrackRequest {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]TrackRequest{}, m.requests...)
+}
+
+// ApproveRequest appends a pending request to the queue. filePath picks
+// the file for a name request; when empty the name is looked up next to
+// the tracks already in the queue.
+func (m *Manager) ApproveRequest(id, filePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.group == nil || m.group.Role != "host" {
+		return fmt.Errorf("not hosting a group")
+	}
+	idx := m.requestIndexLocked(id)
+	if idx < 0 {
+		return ErrRequestNotFound
+	}
+	req := m.requests[idx]
+	path := filePath
+	if path == "" {
+		if path = m.resolveRequestLocked(req.Track); path == "" {
+			return fmt.Errorf("no file named %q next to the queued tracks; choose one", req.Track)
+		}
+	}
+	if err := m.addToQueueLocked([]string{path}); err != nil {
+		return err
+	}
+	m.removeRequestLocked(idx)
+	log.Printf("LISTEN: Approved request %s from %s", req.Name, req.PeerID)
+	m.notifyBrowser()
+	return nil
+}
+
+// RejectRequest drops a pending request.
+func (m *Manager) RejectRequest(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.group == nil || m.group.Role != "host" {
+		return fmt.Errorf("not hosting a group")
+	}
+	idx := m.requestIndexLocked(id)
+	if idx < 0 {
+		return ErrRequestNotFound
+	}
+	m.removeRequestLocked(idx)
+	m.notifyBrowser()
+	return nil
+}
+
+// SetJukebox turns jukebox mode on or off. In jukebox mode requests are
+// queued as they arrive when the host can resolve them; the rest still
+// wait for approval.
+func (m *Manager) SetJukebox(on bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.group == nil || m.group.Role != "host" {
+		return fmt.Errorf("not hosting a group")
+	}
+	m.group.Jukebox = on
+	m.sendControl(ControlMsg{Action: "jukebox", Jukebox: on})
+	m.saveQueueToDisk()
+	m.notifyBrowser()
+	log.Printf("LISTEN: Jukebox mode %v", on)
+	return nil
+}
+
+// handleRequest records a listener's request on the host, or queues it
+// straight away in jukebox mode.
+func (m *Manager) handleRequest(from, track string) {
+	track = strings.TrimSpace(track)
+	if err := validateRequest(track); err != nil {
+		log.Printf("LISTEN: Ignored request from %s: %v", from, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.group == nil || m.group.Role != "host" || from == "" {
+		return
+	}
+	mine := 0
+	for _, r := range m.requests {
+		if r.PeerID == from {
+			if r.Track == track {
+				return
+			}
+			mine++
+		}
+	}
+	if mine >= maxRequestsPerPeer || len(m.requests) >= maxPendingRequests {
+		log.Printf("LISTEN: Dropped request from %s: too many pending", from)
+		return
+	}
+
+	req := TrackRequest{
+		ID:     newRequestID(),
+		PeerID: from,
+		Track:  track,
+		Name:   requestDisplayName(track),
+		At:     time.Now().UnixMilli(),
+	}
+	if m.group.Jukebox {
+		if path := m.resolveRequestLocked(track); path != "" {
+			err := m.addToQueueLocked([]string{path})
+			if err == nil {
+				log.Printf("LISTEN: Jukebox queued %s for %s", req.Name, from)
+				m.notifyBrowser()
+				return
+			}
+			log.Printf("LISTEN: Jukebox could not queue %s: %v", req.Name, err)
+		}
+	}
+	m.requests = append(m.requests, req)
+	m.group.PendingRequests = len(m.requests)
+	log.Printf("LISTEN: %s requested %s", from, req.Name)
+	m.notifyBrowser()
+}
+
+// resolveRequestLocked maps a request to something the host can queue: a
+// stream URL as is, a name to a file of that name in the folder of a track
+// in the queue. It returns "" when nothing matches.
+func (m *Manager) resolveRequestLocked(track string) string {
+	if isStreamURL(track) {
+		return track
+	}
+	seen := map[string]bool{}
+	for _, p := range append([]string{m.filePath}, m.queue...) {
+		if p == "" || isStreamURL(p) {
+			continue
+		}
+		dir := filepath.Dir(p)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() && strings.EqualFold(e.Name(), track) {
+				return filepath.Join(dir, e.Name())
+			}
+		}
+	}
+	return ""
+}
+
+func (m *Manager) requestIndexLocked(id string) int {
+	for i, r := range m.requests {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Manager) removeRequestLocked(idx int) {
+	m.requests = slices.Delete(m.requests, idx, idx+1)
+	if m.group != nil {
+		m.group.PendingRequests = len(m.requests)
+	}
+}
+
+func requestDisplayName(track string) string {
+	if isStreamURL(track) {
+		return streamDisplayName(track)
+	}
+	return track
+}
+
+func newRequestID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,119 @@
+package listen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func hostWithQueue(t *testing.T) (*Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"first.mp3", "Wanted.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewTestManagerOpts(TestManagerOpts{SelfID: "host"})
+	m.SetTestGroupFull(&Group{ID: "listen-abc", Name: "Room", Role: "host"})
+	m.SetTestQueue([]string{filepath.Join(dir, "first.mp3")}, 0)
+	return m, dir
+}
+
+func TestValidateRequest(t *testing.T) {
+	for track, ok := range map[string]bool{
+		"song.mp3":                   true,
+		"Song.FLAC":                  true,
+		"https://radio.example/live": true,
+		"":                           false,
+		"../secret.mp3":              false,
+		`dir\song.mp3`:               false,
+		"notes.txt":                  false,
+	} {
+		if err := validateRequest(track); (err == nil) != ok {
+			t.Errorf("validateRequest(%q) = %v, want ok=%v", track, err, ok)
+		}
+	}
+}
+
+func TestHandleRequest_pendingThenApprove(t *testing.T) {
+	m, dir := hostWithQueue(t)
+
+	m.handleControlEvent("peer1", controlPayload("request", map[string]any{"request": "wanted.mp3"}))
+	m.handleControlEvent("peer1", controlPayload("request", map[string]any{"request": "wanted.mp3"})) // duplicate
+	m.handleControlEvent("peer2", controlPayload("request", map[string]any{"request": "https://radio.example/live"}))
+
+	reqs := m.Requests()
+	if len(reqs) != 2 || reqs[0].PeerID != "peer1" || reqs[1].Name != "radio.example/live" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	if g := m.GetGroup(); g.PendingRequests != 2 {
+		t.Fatalf("pending_requests = %d, want 2", g.PendingRequests)
+	}
+
+	// The name resolves case-insensitively next to the queued track.
+	if err := m.ApproveRequest(reqs[0].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ApproveRequest(reqs[1].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "first.mp3"), filepath.Join(dir, "Wanted.mp3"), "https://radio.example/live"}
+	if len(m.queue) != 3 || m.queue[1] != want[1] || m.queue[2] != want[2] {
+		t.Fatalf("queue = %v, want %v", m.queue, want)
+	}
+	if len(m.Requests()) != 0 || m.GetGroup().PendingRequests != 0 {
+		t.Fatal("approved requests still pending")
+	}
+	if err := m.ApproveRequest(reqs[0].ID, ""); err != ErrRequestNotFound {
+		t.Errorf("approve twice: %v", err)
+	}
+}
+
+func TestHandleRequest_limitsAndReject(t *testing.T) {
+	m, _ := hostWithQueue(t)
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"} {
+		m.handleRequest("peer1", name)
+	}
+	reqs := m.Requests()
+	if len(reqs) != maxRequestsPerPeer {
+		t.Fatalf("pending = %d, want %d", len(reqs), maxRequestsPerPeer)
+	}
+	if err := m.ApproveRequest(reqs[0].ID, ""); err == nil {
+		t.Error("approved a name with no matching file")
+	}
+	if err := m.RejectRequest(reqs[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Requests()) != maxRequestsPerPeer-1 {
+		t.Errorf("pending after reject = %d", len(m.Requests()))
+	}
+}
+
+func TestHandleRequest_jukebox(t *testing.T) {
+	m, dir := hostWithQueue(t)
+	m.group.Jukebox = true // SetJukebox also tells the listeners
+
+	m.handleRequest("peer1", "WANTED.mp3")
+	m.handleRequest("peer1", "missing.mp3")
+
+	if len(m.queue) != 2 || m.queue[1] != filepath.Join(dir, "Wanted.mp3") {
+		t.Fatalf("queue = %v", m.queue)
+	}
+	if reqs := m.Requests(); len(reqs) != 1 || reqs[0].Track != "missing.mp3" {
+		t.Fatalf("unresolved request should stay pending: %+v", reqs)
+	}
+}
+
+func TestHandleRequest_ignoredByListener(t *testing.T) {
+	m := testManagerWithGroup(t)
+	m.handleControlEvent("peer1", controlPayload("request", map[string]any{"request": "song.mp3"}))
+	if len(m.Requests()) != 0 {
+		t.Fatal("listener recorded a request")
+	}
+
+	m.handleControlEvent("host", controlPayload("jukebox", map[string]any{"jukebox": true}))
+	if !m.GetGroup().Jukebox {
+		t.Fatal("listener did not pick up jukebox mode")
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"os"
 )
 
-// mp3Info holds bitrate and duration extracted from an MP3 file header.
-type mp3Info struct {
+// audioInfo holds the bitrate and duration probed from an audio file.
+type audioInfo struct {
 	Bitrate  int     // bits per second
 	Duration float64 // seconds
 }
@@ -43,7 +43,7 @@ var sampleRateTable = [3][4]int{
 
 // probeMP3 reads the first few KB of an MP3 file to determine bitrate,
 // then uses file size to estimate duration.
-func probeMP3(path string) (*mp3Info, error) {
+func probeMP3(path string) (*audioInfo, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -142,7 +142,7 @@ func probeMP3(path string) (*mp3Info, error) {
 		audioSize := fileSize - offset
 		duration := float64(audioSize*8) / float64(bitrate)
 
-		return &mp3Info{
+		return &audioInfo{
 			Bitrate:  bitrate,
 			Duration: duration,
 		}, nil
@@ -151,7 +151,7 @@ func probeMP3(path string) (*mp3Info, error) {
 	return nil, fmt.Errorf("no valid MPEG frame found")
 }
 
-// ratePacer writes MP3 data to w without rate limiting.
+// ratePacer writes audio data to w without rate limiting.
 // The browser's audio buffer provides natural rate control via TCP flow control.
 // It reads from the file and stops when done, the context is cancelled (via the done channel), or an error occurs.
 type ratePacer struct {
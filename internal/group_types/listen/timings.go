@@ -2,9 +2,11 @@ package listen
 
 import "time"
 
-// Listen group type timings.
+// Listen group type timings. Joins and opening the audio stream use the
+// tunable timeouts.GroupJoin and timeouts.ListenStream.
 const (
-	StreamPollInterval  = 500 * time.Millisecond // pause/stop check during audio streaming
-	ListenJoinTimeout   = 5 * time.Second        // join/rejoin remote listen group
-	ListenStreamTimeout = 5 * time.Second        // open audio stream to host
+	StreamPollInterval    = 500 * time.Millisecond // pause/stop check during audio streaming
+	ListenMaxControlDelay = 5 * time.Second        // older host timestamps are treated as clock errors
+	ListenDriftThreshold  = 2 * time.Second        // listeners further off the host's position are resynced
+	ListenResyncCooldown  = 30 * time.Second       // at most one resync per listener this often
 )
@@ -31,8 +31,10 @@ func (h *Handler) AddCleaner(c ContextCleaner) {
 	h.cleaners = append(h.cleaners, c)
 }
 
+// Flags makes template groups ordered: games and pads built on them keep
+// state in the messages, so a lost or reordered one breaks the app.
 func (h *Handler) Flags() group.GroupTypeFlags {
-	return group.GroupTypeFlags{HostCanJoin: true}
+	return group.GroupTypeFlags{HostCanJoin: true, Ordered: true}
 }
 
 func (h *Handler) OnCreate(_, _ string, _ int) error { return nil }
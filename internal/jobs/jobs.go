@@ -0,0 +1,393 @@
+// Package jobs runs background work, such as cache writes, announcements
+// and registrations, on a fixed number of workers instead of a goroutine
+// per task. The queue is bounded: when it is full a job is refused rather
+// than piling up. A failed job is retried with exponential backoff as its
+// Policy allows; one that fails its last attempt, or is refused, goes to
+// a dead-letter list shown by GET /api/jobs.
+//
+// A job may carry a key. Jobs with the same key never run at the same time
+// and run in the order they were submitted, and a job still waiting in the
+// queue is replaced by a newer one with its key, so a burst of writes to
+// the same row costs one write.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// Queue sizes used when New is given 0.
+const (
+	DefaultWorkers  = 8
+	DefaultCapacity = 10000
+)
+
+const (
+	DefaultTimeout = 30 * time.Second // one attempt, when the policy sets none
+	DeadLetterMax  = 100              // dead jobs kept, newest first
+)
+
+// ErrFull is the dead-letter error of a job refused by a full queue.
+var ErrFull = errors.New("queue full")
+
+// Policy says how often a job is tried and how long it waits in between.
+type Policy struct {
+	Attempts   int           // tries in all; 0 means 1
+	Backoff    time.Duration // wait before the second try, doubling after each failure
+	MaxBackoff time.Duration // cap on the wait; 0 = no cap
+	Timeout    time.Duration // per try; 0 = DefaultTimeout
+}
+
+// Common policies.
+var (
+	// Once runs a job a single time: for work that is redone anyway,
+	// such as a request that the next heartbeat repeats.
+	Once = Policy{Attempts: 1}
+	// DBWrite retries local database writes that hit a busy database.
+	DBWrite = Policy{Attempts: 3, Backoff: 200 * time.Millisecond, MaxBackoff: 2 * time.Second, Timeout: 10 * time.Second}
+	// Remote retries calls to other peers and services over minutes.
+	Remote = Policy{Attempts: 5, Backoff: 5 * time.Second, MaxBackoff: 2 * time.Minute}
+)
+
+func (p Policy) attempts() int {
+	return max(p.Attempts, 1)
+}
+
+func (p Policy) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return DefaultTimeout
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (p Policy) backoff(failed int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < failed && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
+
+// Stats describes the queue.
+type Stats struct {
+	Workers   int   `json:"workers"`
+	Capacity  int   `json:"capacity"`
+	Queued    int   `json:"queued"`   // waiting for a worker
+	Retrying  int   `json:"retrying"` // waiting out a backoff
+	Running   int   `json:"running"`
+	Done      int64 `json:"done"`      // succeeded, at any attempt
+	Retried   int64 `json:"retried"`   // failed attempts that were retried
+	Failed    int64 `json:"failed"`    // failed their last attempt
+	Dropped   int64 `json:"dropped"`   // refused by a full queue
+	Coalesced int64 `json:"coalesced"` // replaced by a newer job with their key
+}
+
+// Dead is a job that failed its last attempt or was refused.
+type Dead struct {
+	Kind     string `json:"kind"`
+	Key      string `json:"key,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+	FailedAt int64  `json:"failed_at"` // Unix ms
+}
+
+// Report is the body of GET /api/jobs.
+type Report struct {
+	Stats
+	Dead []Dead `json:"dead"` // newest first
+}
+
+type job struct {
+	kind, key string
+	policy    Policy
+	fn        func(context.Context) error
+	failed    int         // attempts so far that failed
+	timer     *time.Timer // set while waiting out a backoff
+}
+
+// Queue is a bounded job queue with a fixed pool of workers.
+type Queue struct {
+	workers, capacity int
+
+	ctx    context.Context // cancelled by Close; every attempt runs under it
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	cond    *sync.Cond // signalled when jobs become ready or finish
+	ready   []*job
+	waiting map[*job]bool   // jobs waiting out a backoff
+	pending map[string]*job // keyed jobs queued or waiting, by key
+	running map[string]bool // keys of running jobs
+	busy    int             // jobs running
+	closed  bool
+	stats   Stats
+	dead    []Dead
+}
+
+// New starts a queue with the given number of workers, holding at most
+// capacity jobs that wait to run. 0 picks the defaults.
+func New(workers, capacity int) *Queue {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	q := &Queue{
+		workers:  workers,
+		capacity: capacity,
+		waiting:  map[*job]bool{},
+		pending:  map[string]*job{},
+		running:  map[string]bool{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.wg.Add(workers)
+	for range workers {
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues fn. key, when not empty, orders it after earlier jobs with
+// the same key and replaces one of them that has not started yet. Submit
+// reports false when the job was refused because the queue is full or
+// closed; a refused job is dead-lettered.
+func (q *Queue) Submit(kind, key string, p Policy, fn func(context.Context) error) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	if key != "" {
+		if j := q.pending[key]; j != nil {
+			j.kind, j.policy, j.fn, j.failed = kind, p, fn, 0
+			q.stats.Coalesced++
+			return true
+		}
+	}
+	if len(q.ready)+len(q.waiting) >= q.capacity {
+		q.stats.Dropped++
+		q.buryLocked(&job{kind: kind, key: key}, ErrFull)
+		return false
+	}
+	j := &job{kind: kind, key: key, policy: p, fn: fn}
+	if key != "" {
+		q.pending[key] = j
+	}
+	q.ready = append(q.ready, j)
+	q.cond.Signal()
+	return true
+}
+
+// Go queues fn to run once without a key, for fire-and-forget work.
+func (q *Queue) Go(kind string, fn func(context.Context)) bool {
+	return q.Submit(kind, "", Once, func(ctx context.Context) error {
+		fn(ctx)
+		return nil
+	})
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		j := q.nextLocked()
+		for j == nil && !q.closed {
+			q.cond.Wait()
+			j = q.nextLocked()
+		}
+		if j == nil {
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+
+		err := q.run(j)
+
+		q.mu.Lock()
+		q.busy--
+		if j.key != "" {
+			delete(q.running, j.key)
+		}
+		q.finishLocked(j, err)
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// nextLocked takes the first ready job whose key is not running.
+func (q *Queue) nextLocked() *job {
+	for i, j := range q.ready {
+		if j.key != "" && q.running[j.key] {
+			continue
+		}
+		q.ready = append(q.ready[:i], q.ready[i+1:]...)
+		if j.key != "" {
+			delete(q.pending, j.key)
+			q.running[j.key] = true
+		}
+		q.busy++
+		return j
+	}
+	return nil
+}
+
+func (q *Queue) run(j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("jobs: %s panicked: %v", j.kind, r)
+			err = errors.New("panic")
+		}
+	}()
+	ctx, cancel := context.WithTimeout(q.ctx, j.policy.timeout())
+	defer cancel()
+	return j.fn(ctx)
+}
+
+func (q *Queue) finishLocked(j *job, err error) {
+	if err == nil {
+		q.stats.Done++
+		return
+	}
+	j.failed++
+	// A newer job with the key supersedes this one's retries.
+	if j.key != "" && q.pending[j.key] != nil {
+		return
+	}
+	if j.failed >= j.policy.attempts() || q.closed {
+		q.stats.Failed++
+		if j.policy.attempts() > 1 {
+			log.Printf("jobs: %s %s gave up after %d attempts: %v", j.kind, j.key, j.failed, err)
+		}
+		q.buryLocked(j, err)
+		return
+	}
+	q.stats.Retried++
+	if j.key != "" {
+		q.pending[j.key] = j
+	}
+	q.waiting[j] = true
+	j.timer = time.AfterFunc(j.policy.backoff(j.failed), func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.wakeLocked(j)
+	})
+}
+
+// wakeLocked moves a job that waited out its backoff to the ready list.
+func (q *Queue) wakeLocked(j *job) {
+	if !q.waiting[j] {
+		return
+	}
+	delete(q.waiting, j)
+	j.timer = nil
+	q.ready = append(q.ready, j)
+	q.cond.Signal()
+}
+
+func (q *Queue) buryLocked(j *job, err error) {
+	d := Dead{Kind: j.kind, Key: j.key, Attempts: j.failed, Error: err.Error(), FailedAt: time.Now().UnixMilli()}
+	q.dead = append([]Dead{d}, q.dead...)
+	if len(q.dead) > DeadLetterMax {
+		q.dead = q.dead[:DeadLetterMax]
+	}
+}
+
+// Drain runs every queued job, including those waiting out a backoff,
+// and returns once none is left or ctx is done. New jobs are still
+// accepted meanwhile.
+func (q *Queue) Drain(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for j := range q.waiting {
+			j.timer.Stop()
+			q.wakeLocked(j)
+		}
+		if len(q.ready) == 0 && len(q.waiting) == 0 && q.busy == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.cond.Wait()
+	}
+}
+
+// Close refuses new jobs, cancels running ones and stops the workers.
+// Jobs still queued are dropped; call Drain first to run them.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	for j := range q.waiting {
+		j.timer.Stop()
+	}
+	q.ready, q.waiting, q.pending = nil, map[*job]bool{}, map[string]*job{}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.cancel()
+	q.wg.Wait()
+}
+
+// Stats returns the current counters.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.statsLocked()
+}
+
+func (q *Queue) statsLocked() Stats {
+	s := q.stats
+	s.Workers, s.Capacity = q.workers, q.capacity
+	s.Queued, s.Retrying, s.Running = len(q.ready), len(q.waiting), q.busy
+	return s
+}
+
+// Report returns the counters and the dead-letter list.
+func (q *Queue) Report() Report {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Report{Stats: q.statsLocked(), Dead: append([]Dead{}, q.dead...)}
+}
+
+// ClearDead empties the dead-letter list and returns how many it held.
+func (q *Queue) ClearDead() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.dead)
+	q.dead = nil
+	return n
+}
+
+// WriteMetrics writes the queue's gauges and counters for GET /metrics.
+func (q *Queue) WriteMetrics(w *prom.Writer) {
+	s := q.Stats()
+	w.Gauge("goop_jobs_queued", "Background jobs waiting for a worker or a retry.", float64(s.Queued+s.Retrying))
+	w.Gauge("goop_jobs_running", "Background jobs running.", float64(s.Running))
+	w.Counter("goop_jobs_done_total", "Background jobs that succeeded.", float64(s.Done))
+	w.Counter("goop_jobs_retried_total", "Failed background job attempts that were retried.", float64(s.Retried))
+	w.Counter("goop_jobs_failed_total", "Background jobs that failed their last attempt.", float64(s.Failed))
+	w.Counter("goop_jobs_dropped_total", "Background jobs refused by a full queue.", float64(s.Dropped))
+}
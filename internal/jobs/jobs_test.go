@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+func drain(t *testing.T, q *Queue) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+}
+
+func TestRetryThenDeadLetter(t *testing.T) {
+	q := New(2, 10)
+	defer q.Close()
+	p := Policy{Attempts: 3, Backoff: time.Millisecond}
+
+	var flaky atomic.Int32
+	q.Submit("flaky", "", p, func(context.Context) error {
+		if flaky.Add(1) < 3 {
+			return errors.New("busy")
+		}
+		return nil
+	})
+	var broken atomic.Int32
+	q.Submit("broken", "peer-1", p, func(context.Context) error {
+		broken.Add(1)
+		return errors.New("no route")
+	})
+	drain(t, q)
+
+	if flaky.Load() != 3 || broken.Load() != 3 {
+		t.Fatalf("attempts = %d, %d", flaky.Load(), broken.Load())
+	}
+	r := q.Report()
+	if r.Done != 1 || r.Failed != 1 || r.Retried != 4 {
+		t.Fatalf("stats = %+v", r.Stats)
+	}
+	if len(r.Dead) != 1 || r.Dead[0].Kind != "broken" || r.Dead[0].Key != "peer-1" || r.Dead[0].Attempts != 3 || r.Dead[0].Error != "no route" {
+		t.Fatalf("dead = %+v", r.Dead)
+	}
+	if n := q.ClearDead(); n != 1 || len(q.Report().Dead) != 0 {
+		t.Fatalf("ClearDead = %d", n)
+	}
+}
+
+func TestDrainSkipsBackoff(t *testing.T) {
+	q := New(1, 10)
+	defer q.Close()
+	var n atomic.Int32
+	q.Submit("slow-retry", "", Policy{Attempts: 2, Backoff: time.Hour}, func(context.Context) error {
+		if n.Add(1) == 1 {
+			return errors.New("busy")
+		}
+		return nil
+	})
+	drain(t, q)
+	if n.Load() != 2 {
+		t.Fatalf("attempts = %d", n.Load())
+	}
+}
+
+func TestKeyedJobs(t *testing.T) {
+	q := New(4, 100)
+	defer q.Close()
+
+	// Hold the key so later jobs with it queue up behind the running one.
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	q.Submit("write", "k", Once, func(context.Context) error {
+		close(started)
+		<-release
+		mu.Lock()
+		order = append(order, "first")
+		mu.Unlock()
+		return nil
+	})
+	<-started
+	for _, v := range []string{"second", "third", "fourth"} {
+		q.Submit("write", "k", Once, func(context.Context) error {
+			mu.Lock()
+			order = append(order, v)
+			mu.Unlock()
+			return nil
+		})
+	}
+	close(release)
+	drain(t, q)
+
+	// The queued jobs were coalesced into the newest; it ran after the first.
+	if strings.Join(order, ",") != "first,fourth" {
+		t.Fatalf("order = %v", order)
+	}
+	if s := q.Stats(); s.Coalesced != 2 || s.Done != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestFullQueue(t *testing.T) {
+	q := New(1, 2)
+	defer q.Close()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	q.Go("block", func(context.Context) {
+		close(started)
+		<-block
+	})
+	<-started
+	if !q.Go("a", func(context.Context) {}) || !q.Go("b", func(context.Context) {}) {
+		t.Fatal("refused a job below capacity")
+	}
+	if q.Go("c", func(context.Context) {}) {
+		t.Fatal("accepted a job over capacity")
+	}
+	close(block)
+	drain(t, q)
+	r := q.Report()
+	if r.Dropped != 1 || r.Done != 3 || len(r.Dead) != 1 || r.Dead[0].Error != ErrFull.Error() {
+		t.Fatalf("report = %+v", r)
+	}
+}
+
+func TestCloseCancelsRunning(t *testing.T) {
+	q := New(1, 10)
+	started := make(chan struct{})
+	q.Submit("wait", "", Policy{Attempts: 5, Backoff: time.Millisecond}, func(ctx context.Context) error {
+		select {
+		case <-started:
+		default:
+			close(started)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	q.Close()
+	if q.Go("late", func(context.Context) {}) {
+		t.Fatal("closed queue accepted a job")
+	}
+	if s := q.Stats(); s.Failed != 1 || s.Retried != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestPanicFailsJob(t *testing.T) {
+	q := New(1, 10)
+	defer q.Close()
+	q.Go("boom", func(context.Context) { panic("oops") })
+	drain(t, q)
+	if s := q.Stats(); s.Failed != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for failed, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.backoff(failed); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failed, got, want)
+		}
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	q := New(1, 10)
+	defer q.Close()
+	q.Go("x", func(context.Context) {})
+	drain(t, q)
+	text := string(prom.Write(q))
+	if !strings.Contains(text, "goop_jobs_done_total 1\n") || !strings.Contains(text, "goop_jobs_queued 0\n") {
+		t.Fatalf("exposition:\n%s", text)
+	}
+}
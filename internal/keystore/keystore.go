@@ -0,0 +1,357 @@
+// Package keystore reads and writes private key files (the peer identity key
+// and the relay key), optionally encrypted at rest.
+//
+// A plain key file holds the libp2p-marshaled key as before. A protected
+// file is a small JSON envelope holding the key sealed with AES-256-GCM,
+// where the AES key is either derived from a passphrase (scrypt) or a random
+// key kept in the OS keychain (Secret Service, macOS Keychain, Windows
+// Credential Manager). Plain files keep working unchanged, so protection is
+// opt-in per file.
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Mode is how a key file is stored.
+type Mode string
+
+const (
+	ModePlain      Mode = "plain"
+	ModePassphrase Mode = "passphrase"
+	ModeKeychain   Mode = "keychain"
+)
+
+// PassphraseEnv is the environment variable the CLI reads the key passphrase from.
+const PassphraseEnv = "GOOP2_KEY_PASSPHRASE"
+
+// keychainService is the service name under which wrapping keys are stored
+// in the OS keychain.
+const keychainService = "goop2"
+
+var (
+	// ErrLocked is returned when a passphrase-protected key is loaded
+	// without a passphrase.
+	ErrLocked = errors.New("key file is passphrase protected; passphrase required")
+	// ErrWrongPassphrase is returned when the passphrase does not open the key.
+	ErrWrongPassphrase = errors.New("wrong passphrase for key file")
+)
+
+// scrypt parameters for new envelopes. Stored in the envelope, so they can
+// be raised later without breaking existing files.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// envelope is the on-disk format of a protected key file.
+type envelope struct {
+	Format  int    `json:"goop_key"`
+	Mode    Mode   `json:"mode"`
+	Account string `json:"account,omitempty"` // keychain entry holding the wrapping key
+	N       int    `json:"n,omitempty"`       // scrypt parameters
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	Salt    []byte `json:"salt,omitempty"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+var (
+	mu         sync.Mutex
+	passphrase string                // applies to every file, set by the CLI
+	unlocked   = map[string]string{} // per file, set by Unlock
+)
+
+// SetPassphrase sets the passphrase used for every passphrase-protected key
+// file that was not unlocked individually.
+func SetPassphrase(p string) {
+	mu.Lock()
+	passphrase = p
+	mu.Unlock()
+}
+
+func passphraseFor(path string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	if p, ok := unlocked[absPath(path)]; ok {
+		return p
+	}
+	return passphrase
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// parse returns the envelope of a protected file, or nil for a plain one.
+func parse(data []byte) *envelope {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil
+	}
+	var env envelope
+	if json.Unmarshal(data, &env) != nil || env.Format != 1 {
+		return nil
+	}
+	return &env
+}
+
+// Inspect reports how a key file is stored.
+func Inspect(path string) (Mode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if env := parse(data); env != nil {
+		return env.Mode, nil
+	}
+	return ModePlain, nil
+}
+
+// Locked reports whether a key file is passphrase protected and no
+// passphrase has been provided for it. Missing files are not locked.
+func Locked(path string) bool {
+	mode, err := Inspect(path)
+	return err == nil && mode == ModePassphrase && passphraseFor(path) == ""
+}
+
+// Unlock checks the passphrase against a protected key file and remembers
+// it for later loads of that file.
+func Unlock(path, pass string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	env := parse(data)
+	if env == nil || env.Mode != ModePassphrase {
+		return nil
+	}
+	if _, err := openPassphrase(env, pass); err != nil {
+		return err
+	}
+	mu.Lock()
+	unlocked[absPath(path)] = pass
+	mu.Unlock()
+	return nil
+}
+
+// Load returns the key bytes stored in path, decrypting them if the file is
+// protected. A missing file yields an error matching fs.ErrNotExist.
+func Load(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env := parse(data)
+	if env == nil {
+		return data, nil
+	}
+	switch env.Mode {
+	case ModePassphrase:
+		pass := passphraseFor(path)
+		if pass == "" {
+			return nil, ErrLocked
+		}
+		return openPassphrase(env, pass)
+	case ModeKeychain:
+		key, err := keychainKey(env.Account)
+		if err != nil {
+			return nil, err
+		}
+		return open(key, env)
+	default:
+		return nil, fmt.Errorf("unknown key file mode %q", env.Mode)
+	}
+}
+
+// Save writes key bytes to path unprotected, creating the directory if needed.
+func Save(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("create key directory: %w", err)
+		}
+	}
+	return writeFile(path, data)
+}
+
+// Create writes a new key file that is protected from the start: sealed
+// with the passphrase in effect for path (see SetPassphrase and Unlock),
+// or else with a wrapping key kept in the OS keychain. It returns the mode
+// used.
+func Create(path string, data []byte) (Mode, error) {
+	mode := ModeKeychain
+	var out []byte
+	var err error
+	if pass := passphraseFor(path); pass != "" {
+		mode = ModePassphrase
+		out, err = sealPassphrase(data, pass)
+	} else {
+		out, err = sealKeychain(data)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := Save(path, out); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
+
+// Protect re-encrypts an existing key file in the given mode. pass is the
+// new passphrase for ModePassphrase and ignored otherwise. ModePlain removes
+// the protection.
+func Protect(path string, mode Mode, pass string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	old := parse(data)
+	key, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	switch mode {
+	case ModePlain:
+		out = key
+	case ModePassphrase:
+		if pass == "" {
+			return errors.New("passphrase required")
+		}
+		out, err = sealPassphrase(key, pass)
+	case ModeKeychain:
+		out, err = sealKeychain(key)
+	default:
+		return fmt.Errorf("unknown key file mode %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, out); err != nil {
+		return err
+	}
+
+	// A passphrase remembered for the old file no longer applies.
+	mu.Lock()
+	delete(unlocked, absPath(path))
+	mu.Unlock()
+
+	// The old wrapping key is useless once the file is rewritten.
+	if old != nil && old.Mode == ModeKeychain {
+		_ = keyring.Delete(keychainService, old.Account)
+	}
+	return nil
+}
+
+func sealPassphrase(key []byte, pass string) ([]byte, error) {
+	env := &envelope{Format: 1, Mode: ModePassphrase, N: scryptN, R: scryptR, P: scryptP}
+	env.Salt = make([]byte, 16)
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, err
+	}
+	aesKey, err := scrypt.Key([]byte(pass), env.Salt, env.N, env.R, env.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aesKey, key, env)
+}
+
+func openPassphrase(env *envelope, pass string) ([]byte, error) {
+	aesKey, err := scrypt.Key([]byte(pass), env.Salt, env.N, env.R, env.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	data, err := open(aesKey, env)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
+
+func sealKeychain(key []byte) ([]byte, error) {
+	wrap := make([]byte, 32)
+	account := make([]byte, 12)
+	if _, err := rand.Read(wrap); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(account); err != nil {
+		return nil, err
+	}
+	env := &envelope{Format: 1, Mode: ModeKeychain, Account: "key-" + base64.RawURLEncoding.EncodeToString(account)}
+	if err := keyring.Set(keychainService, env.Account, base64.StdEncoding.EncodeToString(wrap)); err != nil {
+		return nil, fmt.Errorf("store key in OS keychain: %w", err)
+	}
+	return seal(wrap, key, env)
+}
+
+func keychainKey(account string) ([]byte, error) {
+	s, err := keyring.Get(keychainService, account)
+	if err != nil {
+		return nil, fmt.Errorf("read key from OS keychain: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func seal(aesKey, key []byte, env *envelope) ([]byte, error) {
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Data = gcm.Seal(nil, env.Nonce, key, nil)
+	return json.MarshalIndent(env, "", "  ")
+}
+
+func open(aesKey []byte, env *envelope) ([]byte, error) {
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("malformed key file")
+	}
+	return gcm.Open(nil, env.Nonce, env.Data, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFile replaces path atomically so an interrupted write never leaves a
+// half-written key behind.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
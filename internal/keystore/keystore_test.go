@@ -0,0 +1,169 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func reset() {
+	mu.Lock()
+	passphrase = ""
+	unlocked = map[string]string{}
+	mu.Unlock()
+}
+
+func writeKey(t *testing.T) (string, []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data", "identity.key")
+	key := []byte{0x08, 0x01, 0x12, 0x40, 1, 2, 3, 4, 5}
+	if err := Save(path, key); err != nil {
+		t.Fatal(err)
+	}
+	return path, key
+}
+
+func TestPlainRoundTrip(t *testing.T) {
+	reset()
+	path, key := writeKey(t)
+	got, err := Load(path)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+	if mode, _ := Inspect(path); mode != ModePlain {
+		t.Fatalf("mode = %s, want plain", mode)
+	}
+	if Locked(path) {
+		t.Fatal("plain file reported locked")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	reset()
+	_, err := Load(filepath.Join(t.TempDir(), "nope.key"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v, want ErrNotExist", err)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	reset()
+	path, key := writeKey(t)
+	if err := Protect(path, ModePassphrase, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, key) {
+		t.Fatal("key stored in the clear")
+	}
+	if mode, _ := Inspect(path); mode != ModePassphrase {
+		t.Fatalf("mode = %s", mode)
+	}
+
+	reset()
+	if !Locked(path) {
+		t.Fatal("expected locked without passphrase")
+	}
+	if _, err := Load(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("err = %v, want ErrLocked", err)
+	}
+	if err := Unlock(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("err = %v, want ErrWrongPassphrase", err)
+	}
+	SetPassphrase("wrong")
+	if _, err := Load(path); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("err = %v, want ErrWrongPassphrase", err)
+	}
+	if err := Unlock(path, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if Locked(path) {
+		t.Fatal("still locked after Unlock")
+	}
+	got, err := Load(path)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+
+	if err := Protect(path, ModePlain, ""); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = os.ReadFile(path)
+	if !bytes.Equal(raw, key) {
+		t.Fatal("unprotect did not restore the plain key")
+	}
+}
+
+func TestPassphraseRequired(t *testing.T) {
+	reset()
+	path, _ := writeKey(t)
+	if err := Protect(path, ModePassphrase, ""); err == nil {
+		t.Fatal("expected error for empty passphrase")
+	}
+}
+
+func TestKeychain(t *testing.T) {
+	reset()
+	keyring.MockInit()
+	path, key := writeKey(t)
+	if err := Protect(path, ModeKeychain, ""); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ := Inspect(path); mode != ModeKeychain {
+		t.Fatalf("mode = %s", mode)
+	}
+	if Locked(path) {
+		t.Fatal("keychain file reported locked")
+	}
+	got, err := Load(path)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	account := parse(raw).Account
+	if err := Protect(path, ModePassphrase, "pw"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.Get(keychainService, account); err == nil {
+		t.Fatal("keychain entry not removed after switching mode")
+	}
+	SetPassphrase("pw")
+	got, err = Load(path)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	reset()
+	keyring.MockInit()
+	key := []byte("random database key")
+	path := filepath.Join(t.TempDir(), "data", "database.key")
+
+	// Without a passphrase the wrapping key goes to the keychain.
+	if mode, err := Create(path, key); err != nil || mode != ModeKeychain {
+		t.Fatalf("Create = %s, %v; want keychain", mode, err)
+	}
+	if got, err := Load(path); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+
+	SetPassphrase("hunter2")
+	if mode, err := Create(path, key); err != nil || mode != ModePassphrase {
+		t.Fatalf("Create = %s, %v; want passphrase", mode, err)
+	}
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, key) {
+		t.Fatal("key stored in the clear")
+	}
+	reset()
+	if !Locked(path) {
+		t.Fatal("passphrase-sealed key not locked without the passphrase")
+	}
+}
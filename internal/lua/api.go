@@ -17,6 +17,7 @@ import (
 
 	"github.com/petervdpas/goop2/internal/orm/schema"
 	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/util"
 
 	lua "github.com/yuin/gopher-lua"
 )
@@ -161,6 +162,10 @@ func doHTTPRequest(inv *invocationCtx, method, rawURL, payload string) (string,
 // ssrfSafeTransport returns an http.Transport with a custom dialer that
 // resolves DNS and validates the IP before connecting, preventing DNS
 // rebinding attacks (TOCTOU between lookup and connect).
+//
+// Behind a SOCKS5 proxy (Tor mode) nothing is resolved locally, as that
+// would leak the lookup: literal addresses are checked, and host names go
+// to the proxy, whose exits refuse private addresses themselves.
 func ssrfSafeTransport() *http.Transport {
 	return &http.Transport{
 		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -169,6 +174,17 @@ func ssrfSafeTransport() *http.Transport {
 				return nil, fmt.Errorf("invalid address: %w", err)
 			}
 
+			if util.Proxied() {
+				if ip := net.ParseIP(host); ip != nil {
+					if err := checkIP(ip); err != nil {
+						return nil, err
+					}
+				} else if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+					return nil, fmt.Errorf("request to private/loopback address blocked")
+				}
+				return util.Dial(ctx, network, addr)
+			}
+
 			// Resolve DNS
 			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 			if err != nil {
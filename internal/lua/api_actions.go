@@ -0,0 +1,70 @@
+package lua
+
+import (
+	"github.com/petervdpas/goop2/internal/actions"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// actionsListFn returns the actions scripts may run, as {id, title, category, params}.
+//
+//	list = goop.actions.list()
+func actionsListFn() lua.LGFunction {
+	return func(L *lua.LState) int {
+		tbl := L.NewTable()
+		for _, a := range actions.All() {
+			if !a.Script {
+				continue
+			}
+			params := make([]any, 0, len(a.Params))
+			for _, p := range a.Params {
+				params = append(params, map[string]any{
+					"name":     p.Name,
+					"type":     p.Type,
+					"required": p.Required,
+				})
+			}
+			tbl.Append(goToLua(L, map[string]any{
+				"id":       a.ID,
+				"title":    a.Title,
+				"category": a.Category,
+				"params":   params,
+			}))
+		}
+		L.Push(tbl)
+		return 1
+	}
+}
+
+// actionsRunFn runs a script-safe action by ID.
+//
+//	result, err = goop.actions.run("listen.pause")
+//	result, err = goop.actions.run("peers.favorite", {peer_id = id, favorite = true})
+func actionsRunFn(inv *invocationCtx, engine *Engine) lua.LGFunction {
+	return func(L *lua.LState) int {
+		id := L.CheckString(1)
+		var params map[string]any
+		if tbl := L.OptTable(2, nil); tbl != nil {
+			params = luaTableToMap(tbl)
+		}
+		if engine.actions == nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString("actions not available"))
+			return 2
+		}
+		if a, ok := actions.Get(id); !ok || !a.Script {
+			L.Push(lua.LNil)
+			L.Push(lua.LString("action not available to scripts: " + id))
+			return 2
+		}
+		result, err := engine.actions.Run(inv.ctx, id, params)
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+		L.Push(goToLua(L, result))
+		L.Push(lua.LNil)
+		return 2
+	}
+}
@@ -82,6 +82,11 @@ type GroupManager interface {
 	RegisteredTypes() []string
 }
 
+// ActionRunner invokes registry actions (see package actions) for goop.actions.
+type ActionRunner interface {
+	Run(ctx context.Context, id string, params map[string]any) (any, error)
+}
+
 // Engine manages Lua scripts, hot reload, and command dispatch.
 type Engine struct {
 	mu           sync.RWMutex
@@ -97,6 +102,7 @@ type Engine struct {
 	groups       GroupChecker
 	groupMgr     GroupManager
 	mqSub        MQSubscriber
+	actions      ActionRunner
 	mqUnsub      func()
 	watcher      *fsnotify.Watcher
 	limiter      *rateLimiter
@@ -493,6 +499,11 @@ func (e *Engine) SetGroupManager(gm GroupManager) {
 	e.groupMgr = gm
 }
 
+// SetActions sets the runner behind goop.actions.run.
+func (e *Engine) SetActions(ar ActionRunner) {
+	e.actions = ar
+}
+
 func (e *Engine) SetMQ(mq MQSubscriber) {
 	if e.mqUnsub != nil {
 		e.mqUnsub()
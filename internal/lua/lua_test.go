@@ -899,3 +899,38 @@ func TestCheckIPBlocksPrivateAddresses(t *testing.T) {
 		}
 	}
 }
+
+// ── goop.actions ──
+
+type fakeActions struct{ ran []string }
+
+func (f *fakeActions) Run(_ context.Context, id string, _ map[string]any) (any, error) {
+	f.ran = append(f.ran, id)
+	return map[string]any{"status": "ok"}, nil
+}
+
+func TestGoopActionsRun(t *testing.T) {
+	e := setupEngine(t, map[string]string{
+		"functions/act.lua": `function call(request)
+			local r, err = goop.actions.run(request.params.id)
+			return { status = r and r.status, err = err, n = #goop.actions.list() }
+		end`,
+	})
+	fa := &fakeActions{}
+	e.SetActions(fa)
+
+	result, err := e.CallFunction(context.Background(), "peer1", "act", map[string]any{"id": "listen.pause"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := result.(map[string]interface{})
+	if m["status"] != "ok" || len(fa.ran) != 1 || m["n"].(float64) == 0 {
+		t.Fatalf("result = %v, ran %v", m, fa.ran)
+	}
+
+	// Actions not marked script-safe never reach the runner.
+	result, _ = e.CallFunction(context.Background(), "peer1", "act", map[string]any{"id": "templates.apply"})
+	if m := result.(map[string]interface{}); m["err"] == nil || len(fa.ran) != 1 {
+		t.Fatalf("templates.apply from Lua: %v, ran %v", m, fa.ran)
+	}
+}
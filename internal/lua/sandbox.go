@@ -181,6 +181,12 @@ func injectGoopTable(L *lua.LState, inv *invocationCtx, kv *kvStore, engine *Eng
 	listenTbl.RawSetString("seek", L.NewFunction(listenSeekFn(engine)))
 	goop.RawSetString("listen", listenTbl)
 
+	// goop.actions
+	actionsTbl := L.NewTable()
+	actionsTbl.RawSetString("list", L.NewFunction(actionsListFn()))
+	actionsTbl.RawSetString("run", L.NewFunction(actionsRunFn(inv, engine)))
+	goop.RawSetString("actions", actionsTbl)
+
 	L.SetGlobal("goop", goop)
 }
 
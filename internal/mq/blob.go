@@ -0,0 +1,225 @@
+package mq
+
+// Oversized payloads. A message whose marshaled payload exceeds
+// MaxInlinePayload is not written on the MQ stream; the sender keeps the
+// bytes in a short-lived blob store and sends a BlobRef instead. The receiver
+// ACKs the message, fetches the bytes over /goop/mqblob/1.0.0, checks the
+// hash and only then dispatches it, so one giant JSON document never holds
+// up the line-delimited MQ protocol.
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+const (
+	// MaxInlinePayload is the largest marshaled payload sent inline on the
+	// MQ stream. Larger payloads are spilled to the blob side channel.
+	MaxInlinePayload = 64 * 1024
+
+	// MaxPayloadBytes is the largest payload Send accepts at all.
+	MaxPayloadBytes = 16 * 1024 * 1024
+
+	// maxWireBytes bounds one inbound MQ line: an inline payload plus the
+	// envelope and base64 growth from encryption.
+	maxWireBytes = MaxInlinePayload*2 + 4096
+
+	// blobStoreCap bounds the bytes held for peers that never fetch.
+	blobStoreCap = 64 * 1024 * 1024
+)
+
+// ErrPayloadTooLarge is returned by Send when the payload exceeds MaxPayloadBytes.
+var ErrPayloadTooLarge = fmt.Errorf("mq: payload exceeds %d bytes", MaxPayloadBytes)
+
+// errWireTooLarge is returned by capReader when an inbound line exceeds maxWireBytes.
+var errWireTooLarge = errors.New("message exceeds inline limit")
+
+// BlobRef points at a spilled payload held by the sender.
+type BlobRef struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// blobRequest / blobResponse are the header lines of a /goop/mqblob stream.
+// A successful response header is followed by exactly Size raw bytes.
+type blobRequest struct {
+	SHA256 string `json:"sha256"`
+}
+
+type blobResponse struct {
+	Size  int64  `json:"size,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type blobEntry struct {
+	data    []byte
+	peerID  string // only this peer may fetch it
+	expires time.Time
+}
+
+// blobStore holds outbound spilled payloads until they expire.
+type blobStore struct {
+	mu      sync.Mutex
+	entries map[string]*blobEntry // sha256 hex → entry
+	total   int
+}
+
+// put stores data for peerID and returns its reference. Expired entries are
+// pruned first; if the store is still over capacity the oldest are dropped.
+func (b *blobStore) put(peerID string, data []byte) BlobRef {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entries == nil {
+		b.entries = make(map[string]*blobEntry)
+	}
+	now := time.Now()
+	for k, e := range b.entries {
+		if now.After(e.expires) {
+			b.total -= len(e.data)
+			delete(b.entries, k)
+		}
+	}
+	if old, ok := b.entries[key]; ok {
+		b.total -= len(old.data)
+	}
+	b.entries[key] = &blobEntry{data: data, peerID: peerID, expires: now.Add(BlobTTL)}
+	b.total += len(data)
+
+	for b.total > blobStoreCap {
+		var oldest string
+		for k, e := range b.entries {
+			if k != key && (oldest == "" || e.expires.Before(b.entries[oldest].expires)) {
+				oldest = k
+			}
+		}
+		if oldest == "" {
+			break
+		}
+		b.total -= len(b.entries[oldest].data)
+		delete(b.entries, oldest)
+	}
+	return BlobRef{SHA256: key, Size: int64(len(data))}
+}
+
+// get returns the blob for key if peerID is its intended recipient.
+func (b *blobStore) get(key, peerID string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[key]
+	if !ok || e.peerID != peerID || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.data, true
+}
+
+// handleBlob serves spilled payloads to the peer they were addressed to.
+func (m *Manager) handleBlob(stream network.Stream) {
+	defer stream.Close()
+	remotePeer := stream.Conn().RemotePeer().String()
+
+	_ = stream.SetReadDeadline(time.Now().Add(ReadDeadline))
+	var req blobRequest
+	if err := json.NewDecoder(io.LimitReader(stream, 1024)).Decode(&req); err != nil {
+		return
+	}
+
+	enc := json.NewEncoder(stream)
+	data, ok := m.blobs.get(req.SHA256, remotePeer)
+	if !ok {
+		_ = stream.SetWriteDeadline(time.Now().Add(WriteDeadline))
+		_ = enc.Encode(blobResponse{Error: "not found"})
+		return
+	}
+	_ = stream.SetWriteDeadline(time.Now().Add(BlobFetchTimeout))
+	if err := enc.Encode(blobResponse{Size: int64(len(data))}); err != nil {
+		return
+	}
+	if _, err := stream.Write(data); err != nil {
+		log.Printf("MQ: blob write to %s failed: %v", remotePeer[:8], err)
+	}
+}
+
+// fetchBlob retrieves a spilled payload from peerID and verifies its hash.
+func (m *Manager) fetchBlob(ctx context.Context, peerID string, ref BlobRef) ([]byte, error) {
+	if ref.Size < 0 || ref.Size > MaxPayloadBytes {
+		return nil, fmt.Errorf("blob size %d out of range", ref.Size)
+	}
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, BlobFetchTimeout)
+	defer cancel()
+
+	stream, err := m.host.NewStream(network.WithAllowLimitedConn(ctx, "mq"), pid, protocol.ID(proto.MQBlobProtoID))
+	if err != nil {
+		return nil, fmt.Errorf("open blob stream: %w", err)
+	}
+	defer stream.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(dl)
+	}
+
+	if err := json.NewEncoder(stream).Encode(blobRequest{SHA256: ref.SHA256}); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(stream)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read blob header: %w", err)
+	}
+	var resp blobResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("decode blob header: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	if resp.Size != ref.Size {
+		return nil, fmt.Errorf("blob size %d, want %d", resp.Size, ref.Size)
+	}
+	data := make([]byte, ref.Size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("read blob: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != ref.SHA256 {
+		return nil, errors.New("blob hash mismatch")
+	}
+	return data, nil
+}
+
+// capReader fails with errWireTooLarge once more than n bytes are read.
+type capReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		return 0, errWireTooLarge
+	}
+	if len(p) > c.n {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= n
+	return n, err
+}
@@ -0,0 +1,65 @@
+package mq
+
+import "strings"
+
+// journalCap is the number of recent SSE events kept for replay when a
+// browser reconnects with Last-Event-ID (e.g. after a webview reload).
+const journalCap = 1000
+
+// journaled reports whether an event is recorded in the replay journal.
+// MQ log entries are high-volume diagnostics and are not worth replaying.
+func journaled(evt mqEvent) bool {
+	if evt.Msg != nil && strings.HasPrefix(evt.Msg.Topic, "log:") {
+		return false
+	}
+	return true
+}
+
+// record assigns the next journal sequence to evt and appends it to the
+// replay journal. Returns the stamped event.
+func (m *Manager) record(evt mqEvent) mqEvent {
+	if !journaled(evt) {
+		return evt
+	}
+	m.journalMu.Lock()
+	m.jseq++
+	evt.JSeq = m.jseq
+	if len(m.journal) >= journalCap {
+		m.journal = append(m.journal[:0:0], m.journal[1:]...)
+	}
+	m.journal = append(m.journal, evt)
+	m.journalMu.Unlock()
+	return evt
+}
+
+// replaySince returns journaled events with a sequence greater than since.
+// truncated is true when events after since have already been evicted, so
+// the caller cannot resume without a full resync. The journal lives in
+// memory and restarts at 0 with the process, so a since beyond the latest
+// sequence comes from before a restart and is truncated too.
+func (m *Manager) replaySince(since int64) (events []mqEvent, truncated bool) {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+	if since > m.jseq {
+		return nil, true
+	}
+	if since == m.jseq {
+		return nil, false
+	}
+	if len(m.journal) == 0 || m.journal[0].JSeq > since+1 {
+		truncated = true
+	}
+	for _, evt := range m.journal {
+		if evt.JSeq > since {
+			events = append(events, evt)
+		}
+	}
+	return events, truncated
+}
+
+// LastEventID returns the sequence of the most recent journaled event.
+func (m *Manager) LastEventID() int64 {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+	return m.jseq
+}
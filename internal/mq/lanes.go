@@ -0,0 +1,186 @@
+package mq
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// Priority is the delivery class of an outbound message. Each peer has one
+// lane per class with its own concurrency limit, so interactive traffic
+// (call signaling, game moves) never waits behind bulk payloads.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota
+	PriorityNormal
+	PriorityBulk
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBulk:
+		return "bulk"
+	}
+	return "normal"
+}
+
+// ParsePriority maps "interactive", "normal" or "bulk" to a Priority.
+// ok is false for any other value.
+func ParsePriority(s string) (p Priority, ok bool) {
+	switch s {
+	case "interactive":
+		return PriorityInteractive, true
+	case "normal":
+		return PriorityNormal, true
+	case "bulk":
+		return PriorityBulk, true
+	}
+	return PriorityNormal, false
+}
+
+// laneLimits is the number of concurrent sends per peer for each class.
+// Bulk is serialized so large payloads don't compete for the connection.
+var laneLimits = [numPriorities]int{8, 4, 1}
+
+// bulkPayloadThreshold is the encoded payload size above which a message
+// is sent on the bulk lane regardless of topic (call signaling excepted).
+const bulkPayloadThreshold = 16 * 1024
+
+// defaultTopicPriorities maps topic prefixes to a class. Longest match wins.
+var defaultTopicPriorities = map[string]Priority{
+	TopicCallPrefix:  PriorityInteractive,
+	TopicGroupPrefix: PriorityInteractive,
+}
+
+type lane struct {
+	sem      chan struct{}
+	queued   atomic.Int64
+	inflight atomic.Int64
+}
+
+// peerLanes are the lanes to one peer. They exist while a send to the peer
+// waits for or holds a slot; users counts those sends under lanesMu, and
+// the last one out drops the entry so idle peers don't pile up.
+type peerLanes struct {
+	lanes [numPriorities]*lane
+	users int
+}
+
+func newPeerLanes() *peerLanes {
+	pl := &peerLanes{}
+	for i := range pl.lanes {
+		pl.lanes[i] = &lane{sem: make(chan struct{}, laneLimits[i])}
+	}
+	return pl
+}
+
+// LaneDepth is the queue state of one priority lane for a peer.
+type LaneDepth struct {
+	Queued   int64 `json:"queued"`
+	InFlight int64 `json:"in_flight"`
+}
+
+// SetTopicPriority assigns a priority class to all topics with the given prefix.
+func (m *Manager) SetTopicPriority(prefix string, p Priority) {
+	m.lanesMu.Lock()
+	defer m.lanesMu.Unlock()
+	if m.topicPrio == nil {
+		m.topicPrio = make(map[string]Priority)
+	}
+	m.topicPrio[prefix] = p
+}
+
+// classify picks the lane for a message from its topic and the size of its
+// encoded payload.
+func (m *Manager) classify(topic string, size int) Priority {
+	prio, matched := PriorityNormal, ""
+	m.lanesMu.Lock()
+	for _, table := range []map[string]Priority{defaultTopicPriorities, m.topicPrio} {
+		for prefix, p := range table {
+			if strings.HasPrefix(topic, prefix) && len(prefix) >= len(matched) {
+				prio, matched = p, prefix
+			}
+		}
+	}
+	m.lanesMu.Unlock()
+
+	if prio == PriorityBulk || strings.HasPrefix(topic, TopicCallPrefix) {
+		return prio
+	}
+	if size > bulkPayloadThreshold {
+		return PriorityBulk
+	}
+	return prio
+}
+
+// acquire waits for a slot on peerID's lane for prio. The returned release
+// must be called once the message is written; it is safe to call twice.
+func (m *Manager) acquire(ctx context.Context, peerID string, prio Priority) (func(), error) {
+	m.lanesMu.Lock()
+	if m.lanes == nil {
+		m.lanes = make(map[string]*peerLanes)
+	}
+	pl := m.lanes[peerID]
+	if pl == nil {
+		pl = newPeerLanes()
+		m.lanes[peerID] = pl
+	}
+	pl.users++
+	m.lanesMu.Unlock()
+
+	l := pl.lanes[prio]
+	l.queued.Add(1)
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		l.queued.Add(-1)
+		m.leaveLanes(peerID, pl)
+		return nil, ctx.Err()
+	}
+	l.queued.Add(-1)
+	l.inflight.Add(1)
+	var released atomic.Bool
+	return func() {
+		if released.Swap(true) {
+			return
+		}
+		l.inflight.Add(-1)
+		<-l.sem
+		m.leaveLanes(peerID, pl)
+	}, nil
+}
+
+// leaveLanes ends one send's use of pl and forgets the peer's lanes when
+// no send is left.
+func (m *Manager) leaveLanes(peerID string, pl *peerLanes) {
+	m.lanesMu.Lock()
+	defer m.lanesMu.Unlock()
+	pl.users--
+	if pl.users == 0 && m.lanes[peerID] == pl {
+		delete(m.lanes, peerID)
+	}
+}
+
+// laneDepths returns the non-idle lanes per peer for the metrics endpoint.
+func (m *Manager) laneDepths() map[string]map[string]LaneDepth {
+	m.lanesMu.Lock()
+	defer m.lanesMu.Unlock()
+	out := make(map[string]map[string]LaneDepth)
+	for peerID, pl := range m.lanes {
+		for i, l := range pl.lanes {
+			d := LaneDepth{Queued: l.queued.Load(), InFlight: l.inflight.Load()}
+			if d.Queued == 0 && d.InFlight == 0 {
+				continue
+			}
+			if out[peerID] == nil {
+				out[peerID] = make(map[string]LaneDepth)
+			}
+			out[peerID][Priority(i).String()] = d
+		}
+	}
+	return out
+}
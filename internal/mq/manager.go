@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 const (
@@ -28,8 +30,6 @@ const (
 	// Sized for ICE-candidate bursts (20-30 msgs) plus concurrent peer/group
 	// traffic with plenty of headroom.
 	listenerCap = 256
-
-	ackTimeout = AckTimeout
 )
 
 // Manager owns the MQ P2P handler, per-peer in-memory inbox, and SSE listeners.
@@ -50,24 +50,55 @@ type Manager struct {
 	// SSE listeners: browser connects one channel per /api/mq/events connection.
 	listenerMu sync.RWMutex
 	listeners  map[chan mqEvent]struct{}
+	filters    map[chan mqEvent]*TopicFilter // listeners that want only some topics
 
-	// Topic subscribers (for call.Signaler adapter).
+	// Topic subscribers (for call.Signaler adapter) and outbound observers.
 	topicMu   sync.RWMutex
 	topicSubs []topicSub
+	sentSubs  []topicSub
+	failSubs  []topicSub
 
 	// Optional encryptor for payload encryption.
 	enc MQEncryptor
+
+	// Replay journal of recent SSE events, keyed by a monotonic event ID.
+	journalMu sync.Mutex
+	journal   []mqEvent
+	jseq      int64
+
+	// Per-topic delivery counters and ack latency.
+	metrics *metrics
+
+	// Per-peer outbound priority lanes (see lanes.go).
+	lanesMu   sync.Mutex
+	lanes     map[string]*peerLanes
+	topicPrio map[string]Priority
+
+	// Oversized outbound payloads awaiting fetch by the recipient (see blob.go).
+	blobs blobStore
+
+	// Store-and-forward outbox, nil until EnableOutbox (see outbox.go).
+	outbox *outbox
 }
 
 type topicSub struct {
 	prefix string
+	filter *TopicFilter // matched instead of prefix when set
 	fn     func(from, topic string, payload any)
 }
 
+func (s topicSub) matches(topic string) bool {
+	if s.filter != nil {
+		return s.filter.Match(topic)
+	}
+	return strings.HasPrefix(topic, s.prefix)
+}
+
 // inboxEntry pairs a buffered message with the peer that sent it.
 type inboxEntry struct {
 	Msg  MQMsg
 	From string
+	JSeq int64 // journal sequence, 0 if not journaled
 }
 
 // New creates a new MQ Manager and registers the /goop/mq/1.0.0 stream handler.
@@ -78,8 +109,10 @@ func New(h host.Host) *Manager {
 		inbox:     make(map[string][]inboxEntry),
 		pending:   make(map[string]chan struct{}),
 		listeners: make(map[chan mqEvent]struct{}),
+		metrics:   newMetrics(),
 	}
 	h.SetStreamHandler(protocol.ID(proto.MQProtoID), m.handleIncoming)
+	h.SetStreamHandler(protocol.ID(proto.MQBlobProtoID), m.handleBlob)
 	log.Printf("MQ: registered handler for %s", proto.MQProtoID)
 	return m
 }
@@ -92,30 +125,89 @@ func (m *Manager) SetEncryptor(e MQEncryptor) {
 }
 
 // Send opens (or reuses) a stream to peerID, writes a message with the given
-// topic and payload, and waits up to ackTimeout for a transport ACK.
+// topic and payload, and waits up to the MQAck timeout for a transport ACK.
 // On transient failure it retries once after a short pause so a momentary
 // relay-circuit blip does not permanently drop a call signal.
 // Returns the message ID and nil on success, or an error if both attempts fail.
+// The message travels on the priority lane chosen from its topic and size.
 func (m *Manager) Send(ctx context.Context, peerID, topic string, payload any) (string, error) {
-	id, err := m.sendOnce(ctx, peerID, topic, payload)
-	if err == nil {
-		return id, nil
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("mq: encode payload: %w", err)
 	}
-	// Retry once if the caller context still has budget.
-	select {
-	case <-ctx.Done():
-		return "", err
-	case <-time.After(RetryDelay):
+	return m.send(ctx, peerID, topic, payload, raw, m.classify(topic, len(raw)))
+}
+
+// SendPriority is Send with an explicit priority class.
+// Payloads over MaxPayloadBytes are rejected with ErrPayloadTooLarge.
+func (m *Manager) SendPriority(ctx context.Context, peerID, topic string, payload any, prio Priority) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("mq: encode payload: %w", err)
+	}
+	return m.send(ctx, peerID, topic, payload, raw, prio)
+}
+
+// send delivers payload, already encoded as raw, with one retry.
+func (m *Manager) send(ctx context.Context, peerID, topic string, payload any, raw json.RawMessage, prio Priority) (string, error) {
+	if len(raw) > MaxPayloadBytes {
+		return "", ErrPayloadTooLarge
+	}
+	id, err := m.sendOnce(ctx, peerID, topic, raw, prio)
+	if err != nil {
+		// Retry once if the caller context still has budget.
+		select {
+		case <-ctx.Done():
+			m.notifyFailed(peerID, topic, payload)
+			return "", err
+		case <-time.After(RetryDelay):
+		}
+		if id, err = m.sendOnce(ctx, peerID, topic, raw, prio); err != nil {
+			m.notifyFailed(peerID, topic, payload)
+			return "", err
+		}
 	}
-	return m.sendOnce(ctx, peerID, topic, payload)
+	m.notifySent(peerID, topic, payload)
+	return id, nil
 }
 
-// sendOnce is a single send attempt without retry logic.
-func (m *Manager) sendOnce(ctx context.Context, peerID, topic string, payload any) (string, error) {
+// notifySent runs SubscribeSent observers for a delivered message. They run
+// synchronously so they see our messages in send order.
+func (m *Manager) notifySent(peerID, topic string, payload any) {
+	m.topicMu.RLock()
+	defer m.topicMu.RUnlock()
+	for _, sub := range m.sentSubs {
+		if sub.matches(topic) {
+			sub.fn(peerID, topic, payload)
+		}
+	}
+}
+
+// notifyFailed runs SubscribeFailed observers for a message that could
+// not be delivered.
+func (m *Manager) notifyFailed(peerID, topic string, payload any) {
+	m.topicMu.RLock()
+	defer m.topicMu.RUnlock()
+	for _, sub := range m.failSubs {
+		if sub.matches(topic) {
+			sub.fn(peerID, topic, payload)
+		}
+	}
+}
+
+// sendOnce is a single send attempt without retry logic. It holds a slot
+// on the prio lane only while it writes; waiting for the ACK does not hold
+// up the next message.
+func (m *Manager) sendOnce(ctx context.Context, peerID, topic string, raw json.RawMessage, prio Priority) (string, error) {
 	pid, err := peer.Decode(peerID)
 	if err != nil {
 		return "", fmt.Errorf("mq: invalid peer id %q: %w", peerID, err)
 	}
+	release, err := m.acquire(ctx, peerID, prio)
+	if err != nil {
+		return "", fmt.Errorf("mq: %s lane to %s: %w", prio, peerID, err)
+	}
+	defer release()
 
 	msgID := uuid.NewString()
 	seq := atomic.AddInt64(&m.seq, 1)
@@ -125,7 +217,7 @@ func (m *Manager) sendOnce(ctx context.Context, peerID, topic string, payload an
 		ID:      msgID,
 		Seq:     seq,
 		Topic:   topic,
-		Payload: payload,
+		Payload: raw,
 	}
 
 	// Register ACK channel before opening the stream so we don't miss it.
@@ -140,46 +232,63 @@ func (m *Manager) sendOnce(ctx context.Context, peerID, topic string, payload an
 		m.ackMu.Unlock()
 	}()
 
+	m.metrics.sent(topic)
+	start := time.Now()
+
 	// Open a new stream (libp2p reuses the underlying muxed connection).
-	dialCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	dialCtx, cancel := context.WithTimeout(ctx, timeouts.Get().MQAck)
 	defer cancel()
 	dialCtx = network.WithAllowLimitedConn(dialCtx, "mq")
 
 	stream, err := m.host.NewStream(dialCtx, pid, protocol.ID(proto.MQProtoID))
 	if err != nil {
 		go m.logMQEvent("error", topic, peerID, "unreachable", "", false)
+		m.metrics.failed(topic, err)
 		return "", fmt.Errorf("mq: open stream to %s: %w", peerID, err)
 	}
 	defer stream.Close()
 
 	// Encrypt payload if encryptor is available and peer has a public key.
 	encrypted := false
+	inline := []byte(raw)
 	if m.enc != nil {
-		if payloadJSON, err := json.Marshal(msg.Payload); err == nil {
-			if sealed, err := m.enc.Seal(peerID, payloadJSON); err == nil {
-				msg.Payload = map[string]string{"enc": sealed}
-				encrypted = true
-			}
-			// ErrNoKey → leave payload as plaintext (backward compat)
+		if sealed, err := m.enc.Seal(peerID, raw); err == nil {
+			msg.Payload = map[string]string{"enc": sealed}
+			inline, _ = json.Marshal(msg.Payload)
+			encrypted = true
 		}
+		// ErrNoKey → leave payload as plaintext (backward compat)
+	}
+
+	// Spill oversized payloads to the blob side channel.
+	if len(inline) > MaxInlinePayload {
+		ref := m.blobs.put(peerID, inline)
+		msg.Payload = nil
+		msg.Blob = &ref
 	}
 
 	// Write the message as newline-delimited JSON.
 	wireEnc := json.NewEncoder(stream)
 	if err := wireEnc.Encode(msg); err != nil {
+		m.metrics.failed(topic, err)
 		return "", fmt.Errorf("mq: encode msg: %w", err)
 	}
+	release()
 
 	// Read the transport ACK from the stream (remote writes it back synchronously).
 	var ack MQAck
 	dec := json.NewDecoder(bufio.NewReader(stream))
-	_ = stream.SetReadDeadline(time.Now().Add(ackTimeout))
+	_ = stream.SetReadDeadline(time.Now().Add(timeouts.Get().MQAck))
 	if err := dec.Decode(&ack); err != nil {
+		m.metrics.failed(topic, err)
 		return "", fmt.Errorf("mq: waiting for ack from %s: %w", peerID, err)
 	}
 	if ack.ID != msgID {
+		m.metrics.failed(topic, nil)
 		return "", fmt.Errorf("mq: ack id mismatch (got %s, want %s)", ack.ID, msgID)
 	}
+	via := connVia(stream)
+	m.metrics.delivered(topic, via, time.Since(start))
 
 	// Also signal the in-process pending channel (used by SubscribeTopic callers).
 	select {
@@ -192,7 +301,7 @@ func (m *Manager) sendOnce(ctx context.Context, peerID, topic string, payload an
 		encLabel = "e2e"
 	}
 	log.Printf("MQ: sent msg %s (topic=%s) to %s [enc=%s]", msgID[:8], topic, peerID[:8], encLabel)
-	go m.logMQEvent("send", topic, peerID, "", connVia(stream), encrypted)
+	go m.logMQEvent("send", topic, peerID, "", via, encrypted)
 	return msgID, nil
 }
 
@@ -206,11 +315,18 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 	_ = stream.SetReadDeadline(time.Now().Add(ReadDeadline))
 
 	var msg MQMsg
-	if err := json.NewDecoder(bufio.NewReader(stream)).Decode(&msg); err != nil {
+	if err := json.NewDecoder(bufio.NewReader(&capReader{r: stream, n: maxWireBytes})).Decode(&msg); err != nil {
+		if errors.Is(err, errWireTooLarge) {
+			log.Printf("MQ: message from %s exceeds %d bytes, dropping", remotePeer[:8], maxWireBytes)
+			_ = stream.Reset()
+			return
+		}
 		log.Printf("MQ: decode error from %s: %v", remotePeer[:8], err)
 		return
 	}
 
+	m.metrics.received(msg.Topic)
+
 	// Validate sender.
 	if remotePeer != stream.Conn().RemotePeer().String() {
 		log.Printf("MQ: peer mismatch, dropping")
@@ -225,6 +341,21 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 		// Continue dispatching even if ACK write failed.
 	}
 
+	// Spilled payload: fetch it from the sender before dispatching.
+	if msg.Blob != nil {
+		data, err := m.fetchBlob(context.Background(), remotePeer, *msg.Blob)
+		if err != nil {
+			log.Printf("MQ: blob fetch for msg %s from %s failed: %v", msg.ID[:8], remotePeer[:8], err)
+			return
+		}
+		msg.Payload = nil
+		if err := json.Unmarshal(data, &msg.Payload); err != nil {
+			log.Printf("MQ: blob for msg %s from %s is not JSON: %v", msg.ID[:8], remotePeer[:8], err)
+			return
+		}
+		msg.Blob = nil
+	}
+
 	// Decrypt payload if it's an encrypted envelope: {"enc":"base64..."}
 	decrypted := false
 	if m.enc != nil {
@@ -266,7 +397,7 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 	// Dispatch to topic subscribers (call.Signaler adapter etc.)
 	m.topicMu.RLock()
 	for _, sub := range m.topicSubs {
-		if strings.HasPrefix(msg.Topic, sub.prefix) {
+		if sub.matches(msg.Topic) {
 			go sub.fn(remotePeer, msg.Topic, msg.Payload)
 		}
 	}
@@ -281,17 +412,21 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 		return
 	}
 
-	evt := mqEvent{
+	evt := m.record(mqEvent{
 		Type: "message",
 		Msg:  &msg,
 		From: remotePeer,
-	}
+	})
 
 	// Deliver to SSE listeners. Track whether any listener's channel was full.
 	m.listenerMu.RLock()
-	n := len(m.listeners)
+	n := 0
 	anyDropped := false
 	for ch := range m.listeners {
+		if !m.wantsLocked(ch, evt) {
+			continue
+		}
+		n++
 		select {
 		case ch <- evt:
 		default:
@@ -301,7 +436,7 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 	}
 	m.listenerMu.RUnlock()
 
-	// Buffer to inbox when no listener is connected OR when any listener
+	// Buffer to inbox when no listener wants the message OR when any listener
 	// dropped the message. On the next Subscribe() (browser reconnect) the
 	// inbox is replayed, so no message is permanently lost.
 	if n == 0 || anyDropped {
@@ -310,7 +445,7 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 		if len(buf) >= inboxCap {
 			buf = buf[1:] // drop oldest
 		}
-		m.inbox[remotePeer] = append(buf, inboxEntry{Msg: msg, From: remotePeer})
+		m.inbox[remotePeer] = append(buf, inboxEntry{Msg: msg, From: remotePeer, JSeq: evt.JSeq})
 		m.inboxMu.Unlock()
 	}
 
@@ -320,9 +455,12 @@ func (m *Manager) handleIncoming(stream network.Stream) {
 // NotifyDelivered dispatches a "delivered" event to SSE listeners.
 // Called by the /api/mq/ack HTTP handler after it sends the p2p ack back.
 func (m *Manager) NotifyDelivered(msgID string) {
-	evt := mqEvent{Type: "delivered", MsgID: msgID}
+	evt := m.record(mqEvent{Type: "delivered", MsgID: msgID})
 	m.listenerMu.RLock()
 	for ch := range m.listeners {
+		if !m.wantsLocked(ch, evt) {
+			continue
+		}
 		select {
 		case ch <- evt:
 		default:
@@ -335,24 +473,82 @@ func (m *Manager) NotifyDelivered(msgID string) {
 // On subscribe, all buffered inbox messages (across all peers) are replayed
 // immediately so the browser never misses a message.
 func (m *Manager) Subscribe() (<-chan mqEvent, func()) {
+	return m.SubscribeFrom(0)
+}
+
+// SubscribeFrom is Subscribe with resume support. When since > 0 (the last
+// event ID the browser saw, from the SSE Last-Event-ID header), journaled
+// events after since are replayed first. If the journal no longer reaches
+// back that far, or since is from before a restart, a "reset" event is sent
+// so the browser can resync its state.
+func (m *Manager) SubscribeFrom(since int64) (<-chan mqEvent, func()) {
+	return m.SubscribeFiltered(since, nil)
+}
+
+// SubscribeFiltered is SubscribeFrom for a listener that only wants the
+// messages whose topic matches f; receipts and resets still reach it. A nil
+// f takes everything. Buffered inbox messages that do not match stay in the
+// inbox for the next listener.
+func (m *Manager) SubscribeFiltered(since int64, f *TopicFilter) (<-chan mqEvent, func()) {
 	ch := make(chan mqEvent, listenerCap)
 
 	m.listenerMu.Lock()
 	m.listeners[ch] = struct{}{}
+	if f != nil {
+		if m.filters == nil {
+			m.filters = make(map[chan mqEvent]*TopicFilter)
+		}
+		m.filters[ch] = f
+	}
 	m.listenerMu.Unlock()
 
+	replayed := make(map[int64]bool)
+	if since > 0 {
+		events, truncated := m.replaySince(since)
+		if truncated {
+			select {
+			case ch <- mqEvent{Type: "reset"}:
+			default:
+			}
+		}
+		for _, evt := range events {
+			if !f.takes(evt) {
+				continue
+			}
+			replayed[evt.JSeq] = true
+			select {
+			case ch <- evt:
+			default:
+			}
+		}
+	}
+
 	// Replay buffered inbox.
 	m.inboxMu.Lock()
 	var buffered []inboxEntry
-	for _, entries := range m.inbox {
-		buffered = append(buffered, entries...)
+	for from, entries := range m.inbox {
+		var kept []inboxEntry
+		for _, e := range entries {
+			if f.takes(mqEvent{Msg: &e.Msg}) {
+				buffered = append(buffered, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) > 0 {
+			m.inbox[from] = kept
+		} else {
+			delete(m.inbox, from) // clear after replay
+		}
 	}
-	m.inbox = make(map[string][]inboxEntry) // clear after replay
 	m.inboxMu.Unlock()
 
 	for i := range buffered {
+		if buffered[i].JSeq > 0 && replayed[buffered[i].JSeq] {
+			continue
+		}
 		select {
-		case ch <- mqEvent{Type: "message", Msg: &buffered[i].Msg, From: buffered[i].From}:
+		case ch <- mqEvent{Type: "message", Msg: &buffered[i].Msg, From: buffered[i].From, JSeq: buffered[i].JSeq}:
 		default:
 		}
 	}
@@ -361,6 +557,7 @@ func (m *Manager) Subscribe() (<-chan mqEvent, func()) {
 		m.listenerMu.Lock()
 		if _, ok := m.listeners[ch]; ok {
 			delete(m.listeners, ch)
+			delete(m.filters, ch)
 			close(ch)
 		}
 		m.listenerMu.Unlock()
@@ -380,10 +577,13 @@ func (m *Manager) PublishLocal(topic, from string, payload any) {
 		Topic:   topic,
 		Payload: payload,
 	}
-	evt := mqEvent{Type: "message", Msg: &msg, From: from}
+	evt := m.record(mqEvent{Type: "message", Msg: &msg, From: from})
 	m.listenerMu.RLock()
 	defer m.listenerMu.RUnlock()
 	for ch := range m.listeners {
+		if !m.wantsLocked(ch, evt) {
+			continue
+		}
 		select {
 		case ch <- evt:
 		default:
@@ -392,6 +592,40 @@ func (m *Manager) PublishLocal(topic, from string, payload any) {
 	}
 }
 
+// PublishLocalHeld is PublishLocal for messages the browser must not miss
+// when none is connected yet: without an SSE listener the message is
+// buffered in the inbox and replayed to the next one that subscribes.
+func (m *Manager) PublishLocalHeld(topic, from string, payload any) {
+	probe := mqEvent{Msg: &MQMsg{Topic: topic}}
+	n := 0
+	m.listenerMu.RLock()
+	for ch := range m.listeners {
+		if m.wantsLocked(ch, probe) {
+			n++
+		}
+	}
+	m.listenerMu.RUnlock()
+	if n > 0 {
+		m.PublishLocal(topic, from, payload)
+		return
+	}
+	msg := MQMsg{
+		Type:    MsgTypeMsg,
+		ID:      uuid.NewString(),
+		Seq:     atomic.AddInt64(&m.seq, 1),
+		Topic:   topic,
+		Payload: payload,
+	}
+	evt := m.record(mqEvent{Type: "message", Msg: &msg, From: from})
+	m.inboxMu.Lock()
+	buf := m.inbox[from]
+	if len(buf) >= inboxCap {
+		buf = buf[1:]
+	}
+	m.inbox[from] = append(buf, inboxEntry{Msg: msg, From: from, JSeq: evt.JSeq})
+	m.inboxMu.Unlock()
+}
+
 // connVia returns "relay:<relayID8>" if the stream is routed through a circuit
 // relay (with the first 8 chars of the relay peer ID), or "direct" otherwise.
 func connVia(s network.Stream) string {
@@ -437,11 +671,68 @@ func (m *Manager) logMQEvent(dir, topic, peerID, errMsg, via string, encrypted b
 	m.PublishLocal("log:mq", "", entry)
 }
 
+// SubscribeSent registers a callback for outbound messages whose topic has the
+// given prefix, called with the recipient peer ID after the transport ACK.
+// fn runs on the sender's goroutine and must not block or send.
+// Returns an unsubscribe function.
+func (m *Manager) SubscribeSent(prefix string, fn func(to, topic string, payload any)) func() {
+	sub := topicSub{prefix: prefix, fn: fn}
+
+	m.topicMu.Lock()
+	m.sentSubs = append(m.sentSubs, sub)
+	idx := len(m.sentSubs) - 1
+	m.topicMu.Unlock()
+
+	return func() {
+		m.topicMu.Lock()
+		defer m.topicMu.Unlock()
+		if idx < len(m.sentSubs) {
+			m.sentSubs[idx] = m.sentSubs[len(m.sentSubs)-1]
+			m.sentSubs = m.sentSubs[:len(m.sentSubs)-1]
+		}
+	}
+}
+
+// SubscribeFailed registers a callback for outbound messages whose topic has
+// the given prefix and that failed after the retry, typically because the
+// recipient is offline. fn runs on the sender's goroutine and must not block
+// or send. Returns an unsubscribe function.
+func (m *Manager) SubscribeFailed(prefix string, fn func(to, topic string, payload any)) func() {
+	sub := topicSub{prefix: prefix, fn: fn}
+
+	m.topicMu.Lock()
+	m.failSubs = append(m.failSubs, sub)
+	idx := len(m.failSubs) - 1
+	m.topicMu.Unlock()
+
+	return func() {
+		m.topicMu.Lock()
+		defer m.topicMu.Unlock()
+		if idx < len(m.failSubs) {
+			m.failSubs[idx] = m.failSubs[len(m.failSubs)-1]
+			m.failSubs = m.failSubs[:len(m.failSubs)-1]
+		}
+	}
+}
+
+// SubscribePattern registers a callback for messages whose topic matches
+// one of the comma-separated topic patterns (see TopicFilter). Returns an
+// unsubscribe function.
+func (m *Manager) SubscribePattern(patterns string, fn func(from, topic string, payload any)) (func(), error) {
+	f, err := ParseTopicFilter(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return m.addTopicSub(topicSub{filter: f, fn: fn}), nil
+}
+
 // SubscribeTopic registers a callback for messages whose topic has the given prefix.
 // Returns an unsubscribe function.
 func (m *Manager) SubscribeTopic(prefix string, fn func(from, topic string, payload any)) func() {
-	sub := topicSub{prefix: prefix, fn: fn}
+	return m.addTopicSub(topicSub{prefix: prefix, fn: fn})
+}
 
+func (m *Manager) addTopicSub(sub topicSub) func() {
 	m.topicMu.Lock()
 	m.topicSubs = append(m.topicSubs, sub)
 	idx := len(m.topicSubs) - 1
@@ -457,3 +748,8 @@ func (m *Manager) SubscribeTopic(prefix string, fn func(from, topic string, payl
 		}
 	}
 }
+
+// wantsLocked reports whether listener ch takes evt. Called with listenerMu held.
+func (m *Manager) wantsLocked(ch chan mqEvent, evt mqEvent) bool {
+	return m.filters[ch].takes(evt)
+}
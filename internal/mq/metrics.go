@@ -0,0 +1,253 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// latencyBounds are the upper bounds (ms) of the ack latency histogram
+// buckets. A final overflow bucket catches everything above the last bound.
+var latencyBounds = []int64{10, 25, 50, 100, 250, 500, 1000, 2000}
+
+// LatencyHistogram is a fixed-bucket histogram of transport ACK latency.
+// Counts has one entry per bound plus an overflow bucket.
+type LatencyHistogram struct {
+	BoundsMs []int64 `json:"bounds_ms"`
+	Counts   []int64 `json:"counts"`
+	Count    int64   `json:"count"`
+	SumMs    int64   `json:"sum_ms"`
+	MaxMs    int64   `json:"max_ms"`
+}
+
+func newHistogram() LatencyHistogram {
+	return LatencyHistogram{BoundsMs: latencyBounds, Counts: make([]int64, len(latencyBounds)+1)}
+}
+
+func (h *LatencyHistogram) observe(d time.Duration) {
+	ms := d.Milliseconds()
+	i := 0
+	for i < len(h.BoundsMs) && ms > h.BoundsMs[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.SumMs += ms
+	if ms > h.MaxMs {
+		h.MaxMs = ms
+	}
+}
+
+func (h LatencyHistogram) clone() LatencyHistogram {
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+// TopicMetrics counts MQ traffic for one topic family (see metricsKey).
+type TopicMetrics struct {
+	Sent       int64            `json:"sent"`      // send attempts
+	Delivered  int64            `json:"delivered"` // transport ACK received
+	Timeouts   int64            `json:"timeouts"`  // no ACK within timeouts.MQAck
+	Failures   int64            `json:"failures"`  // unreachable or stream errors
+	Received   int64            `json:"received"`  // inbound messages
+	AckLatency LatencyHistogram `json:"ack_latency"`
+}
+
+// Metrics is a snapshot of MQ delivery metrics since startup.
+type Metrics struct {
+	Since  int64                       `json:"since"` // Unix ms
+	Topics map[string]TopicMetrics     `json:"topics"`
+	ByVia  map[string]LatencyHistogram `json:"by_via"` // "direct" / "relay" ack latency
+
+	// Queues lists busy priority lanes: peerID → lane → depth.
+	Queues map[string]map[string]LaneDepth `json:"queues"`
+}
+
+// metrics is the mutable counter set behind Manager.Metrics. A nil *metrics
+// is valid and records nothing (managers built without New in tests).
+type metrics struct {
+	mu     sync.Mutex
+	since  time.Time
+	topics map[string]*TopicMetrics
+	byVia  map[string]*LatencyHistogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		since:  time.Now(),
+		topics: make(map[string]*TopicMetrics),
+		byVia:  make(map[string]*LatencyHistogram),
+	}
+}
+
+// metricsKey collapses a topic to its family so per-channel and per-group
+// IDs don't explode the metric set: "group:abc:msg" → "group", "chat" → "chat".
+func metricsKey(topic string) string {
+	if i := strings.IndexByte(topic, ':'); i > 0 {
+		return topic[:i]
+	}
+	return topic
+}
+
+func (x *metrics) topic(topic string) *TopicMetrics {
+	key := metricsKey(topic)
+	t := x.topics[key]
+	if t == nil {
+		t = &TopicMetrics{AckLatency: newHistogram()}
+		x.topics[key] = t
+	}
+	return t
+}
+
+func (x *metrics) sent(topic string) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	x.topic(topic).Sent++
+	x.mu.Unlock()
+}
+
+func (x *metrics) received(topic string) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	x.topic(topic).Received++
+	x.mu.Unlock()
+}
+
+// failed records a failed attempt; deadline errors count as timeouts.
+func (x *metrics) failed(topic string, err error) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if isTimeout(err) {
+		x.topic(topic).Timeouts++
+		return
+	}
+	x.topic(topic).Failures++
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func (x *metrics) delivered(topic, via string, latency time.Duration) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	t := x.topic(topic)
+	t.Delivered++
+	t.AckLatency.observe(latency)
+
+	if strings.HasPrefix(via, "relay") {
+		via = "relay"
+	}
+	h := x.byVia[via]
+	if h == nil {
+		nh := newHistogram()
+		h = &nh
+		x.byVia[via] = h
+	}
+	h.observe(latency)
+}
+
+func (x *metrics) snapshot() Metrics {
+	if x == nil {
+		return Metrics{Topics: map[string]TopicMetrics{}, ByVia: map[string]LatencyHistogram{}}
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	out := Metrics{
+		Since:  x.since.UnixMilli(),
+		Topics: make(map[string]TopicMetrics, len(x.topics)),
+		ByVia:  make(map[string]LatencyHistogram, len(x.byVia)),
+	}
+	for k, t := range x.topics {
+		c := *t
+		c.AckLatency = t.AckLatency.clone()
+		out.Topics[k] = c
+	}
+	for k, h := range x.byVia {
+		out.ByVia[k] = h.clone()
+	}
+	return out
+}
+
+// Metrics returns a snapshot of per-topic delivery metrics and lane queue depths.
+func (m *Manager) Metrics() Metrics {
+	out := m.metrics.snapshot()
+	out.Queues = m.laneDepths()
+	return out
+}
+
+func (h LatencyHistogram) seconds() prom.Histogram {
+	bounds := make([]float64, len(h.BoundsMs))
+	for i, b := range h.BoundsMs {
+		bounds[i] = float64(b) / 1000
+	}
+	return prom.Histogram{Bounds: bounds, Counts: h.Counts, Count: h.Count, Sum: float64(h.SumMs) / 1000}
+}
+
+// WriteMetrics writes the delivery metrics for GET /metrics, per topic
+// family and with queue depths summed per lane.
+func (m *Manager) WriteMetrics(w *prom.Writer) {
+	snap := m.Metrics()
+	topics := slices.Sorted(maps.Keys(snap.Topics))
+	counters := []struct {
+		name, help string
+		get        func(TopicMetrics) int64
+	}{
+		{"goop_mq_sent_total", "MQ send attempts.", func(t TopicMetrics) int64 { return t.Sent }},
+		{"goop_mq_delivered_total", "MQ messages acknowledged by the receiving peer.", func(t TopicMetrics) int64 { return t.Delivered }},
+		{"goop_mq_timeouts_total", "MQ sends without an acknowledgement in time.", func(t TopicMetrics) int64 { return t.Timeouts }},
+		{"goop_mq_failures_total", "MQ sends that failed (unreachable peer or stream error).", func(t TopicMetrics) int64 { return t.Failures }},
+		{"goop_mq_received_total", "MQ messages received.", func(t TopicMetrics) int64 { return t.Received }},
+	}
+	for _, c := range counters {
+		for _, topic := range topics {
+			w.Counter(c.name, c.help, float64(c.get(snap.Topics[topic])), "topic", topic)
+		}
+	}
+	for _, topic := range topics {
+		w.Histogram("goop_mq_send_latency_seconds", "Time from MQ send to acknowledgement, by topic family.",
+			snap.Topics[topic].AckLatency.seconds(), "topic", topic)
+	}
+	for _, via := range slices.Sorted(maps.Keys(snap.ByVia)) {
+		w.Histogram("goop_mq_send_latency_by_path_seconds", "Time from MQ send to acknowledgement, direct or relayed.",
+			snap.ByVia[via].seconds(), "path", via)
+	}
+
+	depth := map[string]LaneDepth{}
+	for _, lanes := range snap.Queues {
+		for lane, d := range lanes {
+			sum := depth[lane]
+			sum.Queued += d.Queued
+			sum.InFlight += d.InFlight
+			depth[lane] = sum
+		}
+	}
+	for p := range numPriorities {
+		w.Gauge("goop_mq_queued", "MQ messages waiting to be sent, by priority lane.", float64(depth[p.String()].Queued), "lane", p.String())
+	}
+	for p := range numPriorities {
+		w.Gauge("goop_mq_in_flight", "MQ messages sent and awaiting acknowledgement, by priority lane.", float64(depth[p.String()].InFlight), "lane", p.String())
+	}
+}
@@ -1,7 +1,10 @@
 package mq
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestTopicSubscribe_PrefixMatch(t *testing.T) {
@@ -211,6 +214,37 @@ func TestPublishLocal_NoListeners_NoPanic(t *testing.T) {
 	m.PublishLocal("test:topic", "", "payload")
 }
 
+func TestPublishLocalHeld_ReplayedToFirstSubscriber(t *testing.T) {
+	m := &Manager{
+		inbox:     make(map[string][]inboxEntry),
+		pending:   make(map[string]chan struct{}),
+		listeners: make(map[chan mqEvent]struct{}),
+		selfID:    "self",
+	}
+
+	m.PublishLocalHeld(TopicHealthStartup, "", HealthStartupPayload{Unclean: true})
+
+	ch, cancel := m.Subscribe()
+	defer cancel()
+	select {
+	case evt := <-ch:
+		if evt.Msg == nil || evt.Msg.Topic != TopicHealthStartup {
+			t.Fatalf("first event = %+v", evt)
+		}
+	default:
+		t.Fatal("held message was not replayed")
+	}
+
+	// Only the first subscriber gets it.
+	ch2, cancel2 := m.Subscribe()
+	defer cancel2()
+	select {
+	case evt := <-ch2:
+		t.Fatalf("second subscriber got %+v", evt)
+	default:
+	}
+}
+
 func TestSubscribe_Cancel(t *testing.T) {
 	m := &Manager{
 		inbox:     make(map[string][]inboxEntry),
@@ -315,3 +349,170 @@ done:
 		t.Fatalf("expected 3 replayed messages from 2 peers, got %d", count)
 	}
 }
+
+func TestSubscribeFrom_ReplaysJournalAfterReconnect(t *testing.T) {
+	m := &Manager{
+		inbox:     make(map[string][]inboxEntry),
+		listeners: make(map[chan mqEvent]struct{}),
+	}
+	ch, cancel := m.Subscribe()
+	m.PublishLocal("group:g1:members", "", "a")
+	m.PublishLocal("group:g1:close", "", "b")
+	first := <-ch
+	<-ch
+	cancel()
+
+	// Missed while the browser was reloading
+	m.PublishLocal("group:g1:leave", "", "c")
+
+	ch2, cancel2 := m.SubscribeFrom(first.JSeq)
+	defer cancel2()
+	var topics []string
+	for i := 0; i < 2; i++ {
+		evt := <-ch2
+		topics = append(topics, evt.Msg.Topic)
+	}
+	if topics[0] != "group:g1:close" || topics[1] != "group:g1:leave" {
+		t.Fatalf("unexpected replay order: %v", topics)
+	}
+}
+
+func TestSubscribeFrom_TruncatedJournalSendsReset(t *testing.T) {
+	m := &Manager{
+		inbox:     make(map[string][]inboxEntry),
+		listeners: make(map[chan mqEvent]struct{}),
+	}
+	for i := 0; i < journalCap+10; i++ {
+		m.PublishLocal("t", "", i)
+	}
+
+	ch, cancel := m.SubscribeFrom(1)
+	defer cancel()
+	if evt := <-ch; evt.Type != "reset" {
+		t.Fatalf("expected reset event, got %q", evt.Type)
+	}
+}
+
+func TestSubscribeFrom_IDFromBeforeRestartSendsReset(t *testing.T) {
+	m := &Manager{
+		inbox:     make(map[string][]inboxEntry),
+		listeners: make(map[chan mqEvent]struct{}),
+	}
+	m.PublishLocal("t", "", 1)
+
+	// The browser saw event 500 from the previous process.
+	ch, cancel := m.SubscribeFrom(500)
+	defer cancel()
+	if evt := <-ch; evt.Type != "reset" {
+		t.Fatalf("expected reset event, got %q", evt.Type)
+	}
+}
+
+func TestJournal_SkipsLogTopics(t *testing.T) {
+	m := &Manager{listeners: make(map[chan mqEvent]struct{})}
+	m.PublishLocal(TopicLogMQ, "", "noise")
+	if m.LastEventID() != 0 {
+		t.Fatalf("log topics should not be journaled, got id %d", m.LastEventID())
+	}
+}
+
+func TestLatencyHistogram_Buckets(t *testing.T) {
+	h := newHistogram()
+	h.observe(5 * time.Millisecond)
+	h.observe(30 * time.Millisecond)
+	h.observe(5 * time.Second)
+
+	if h.Counts[0] != 1 || h.Counts[2] != 1 || h.Counts[len(h.Counts)-1] != 1 {
+		t.Fatalf("unexpected bucket counts %v", h.Counts)
+	}
+	if h.Count != 3 || h.MaxMs != 5000 {
+		t.Fatalf("count=%d max=%d", h.Count, h.MaxMs)
+	}
+	if metricsKey("call:loopback:abc") != "call" || metricsKey("chat") != "chat" {
+		t.Fatal("unexpected metricsKey collapse")
+	}
+}
+
+func TestClassify_PriorityLanes(t *testing.T) {
+	m := &Manager{}
+	big := map[string]string{"blob": string(make([]byte, bulkPayloadThreshold+1))}
+
+	cases := []struct {
+		topic   string
+		payload any
+		want    Priority
+	}{
+		{"call:abc", map[string]string{"type": "ice-candidate"}, PriorityInteractive},
+		{"call:abc", big, PriorityInteractive}, // signaling is never demoted
+		{"group:g1:msg", map[string]int{"move": 3}, PriorityInteractive},
+		{"group:g1:msg", big, PriorityBulk},
+		{"chat", map[string]string{"content": "hi"}, PriorityNormal},
+	}
+	for _, c := range cases {
+		b, _ := json.Marshal(c.payload)
+		if got := m.classify(c.topic, len(b)); got != c.want {
+			t.Errorf("classify(%s) = %s, want %s", c.topic, got, c.want)
+		}
+	}
+
+	m.SetTopicPriority("chat", PriorityBulk)
+	if got := m.classify("chat", 4); got != PriorityBulk {
+		t.Errorf("override: got %s", got)
+	}
+}
+
+func TestAcquire_BulkLaneDoesNotBlockInteractive(t *testing.T) {
+	m := &Manager{}
+	ctx := context.Background()
+
+	// Occupy the single bulk slot.
+	releaseBulk, err := m.acquire(ctx, "p1", PriorityBulk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second bulk send queues...
+	queued := make(chan struct{})
+	go func() {
+		rel, err := m.acquire(ctx, "p1", PriorityBulk)
+		if err == nil {
+			rel()
+		}
+		close(queued)
+	}()
+
+	// ...while interactive sends go straight through.
+	done := make(chan struct{})
+	go func() {
+		rel, err := m.acquire(ctx, "p1", PriorityInteractive)
+		if err == nil {
+			rel()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("interactive lane blocked by bulk")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for m.laneDepths()["p1"]["bulk"].Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected one queued bulk send, got %+v", m.laneDepths())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	releaseBulk()
+	releaseBulk() // a second release is a no-op
+	<-queued
+	if d := m.laneDepths(); len(d) != 0 {
+		t.Fatalf("expected idle lanes, got %+v", d)
+	}
+	m.lanesMu.Lock()
+	defer m.lanesMu.Unlock()
+	if len(m.lanes) != 0 {
+		t.Fatalf("idle peer still has lanes: %v", m.lanes)
+	}
+}
@@ -0,0 +1,325 @@
+package mq
+
+// outbox.go — store-and-forward delivery. SendQueued falls back to a
+// persistent outbox when the peer cannot be reached. Queued messages are
+// retried with exponential backoff, flushed as soon as the peer becomes
+// reachable again (FlushOutbox) and dropped when their TTL runs out.
+// Messages to one peer are delivered in the order they were queued.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// OutboxStore persists the outbox. *storage.DB implements it.
+type OutboxStore interface {
+	EnqueueOutbox(e storage.OutboxEntry) (int64, error)
+	OutboxEntries(peerID string) ([]storage.OutboxEntry, error)
+	OutboxPeers() ([]string, error)
+	RecordOutboxFailure(id, nextTry int64, lastErr string) error
+	DeleteOutbox(id int64) error
+	PurgeOutbox(peerID string) (int64, error)
+	ExpireOutbox(now int64) (int64, error)
+}
+
+// ErrNoOutbox is returned by the outbox methods before EnableOutbox.
+var ErrNoOutbox = errors.New("mq: store-and-forward outbox not enabled")
+
+type outbox struct {
+	store OutboxStore
+
+	mu       sync.Mutex
+	pending  map[string]bool // peers with queued messages
+	flushing map[string]bool // peers being flushed right now
+}
+
+// EnableOutbox turns on store-and-forward delivery backed by store, and
+// retries queued messages until ctx is done.
+func (m *Manager) EnableOutbox(ctx context.Context, store OutboxStore) {
+	ob := &outbox{store: store, pending: map[string]bool{}, flushing: map[string]bool{}}
+	peers, err := store.OutboxPeers()
+	if err != nil {
+		log.Printf("MQ: outbox: %v", err)
+	}
+	for _, p := range peers {
+		ob.pending[p] = true
+	}
+	m.outbox = ob
+	go m.runOutbox(ctx)
+}
+
+// SendQueued sends like Send. If the peer cannot be reached, or older
+// messages to it are still queued, the message goes into the outbox for
+// later delivery instead; outboxID is then non-zero and err nil. ttl <= 0
+// means OutboxDefaultTTL.
+func (m *Manager) SendQueued(ctx context.Context, peerID, topic string, payload any, ttl time.Duration) (msgID string, outboxID int64, err error) {
+	ob := m.outbox
+	if ob == nil {
+		msgID, err = m.Send(ctx, peerID, topic, payload)
+		return msgID, 0, err
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(raw) > MaxPayloadBytes {
+		return "", 0, ErrPayloadTooLarge
+	}
+
+	var sendErr error
+	if !ob.hasPending(peerID) {
+		if msgID, sendErr = m.Send(ctx, peerID, topic, payload); sendErr == nil {
+			return msgID, 0, nil
+		}
+	}
+
+	if ttl <= 0 {
+		ttl = OutboxDefaultTTL
+	}
+	now := time.Now()
+	e := storage.OutboxEntry{
+		PeerID:    peerID,
+		Topic:     topic,
+		Payload:   raw,
+		CreatedAt: now.UnixMilli(),
+		ExpiresAt: now.Add(ttl).UnixMilli(),
+	}
+	if sendErr != nil {
+		e.NextTry = now.Add(outboxBackoff(1)).UnixMilli()
+		e.LastError = sendErr.Error()
+	}
+	if outboxID, err = ob.store.EnqueueOutbox(e); err != nil {
+		return "", 0, err
+	}
+	if sendErr != nil {
+		// The failed first attempt counts toward the backoff.
+		_ = ob.store.RecordOutboxFailure(outboxID, e.NextTry, e.LastError)
+	}
+	ob.mu.Lock()
+	ob.pending[peerID] = true
+	ob.mu.Unlock()
+	log.Printf("MQ: queued %s for %s in the outbox (#%d)", topic, shortID(peerID), outboxID)
+	return "", outboxID, nil
+}
+
+// FlushOutbox delivers the messages queued for peerID now, regardless of
+// their retry time. Called when the peer becomes reachable.
+func (m *Manager) FlushOutbox(ctx context.Context, peerID string) {
+	if ob := m.outbox; ob != nil && ob.hasPending(peerID) {
+		go m.flushPeer(ctx, peerID, true)
+	}
+}
+
+// DrainOutbox is for shutdown: it waits for deliveries in progress, then
+// makes one last attempt for peers that are connected right now. Messages
+// it cannot deliver stay in the store for the next start.
+func (m *Manager) DrainOutbox(ctx context.Context) error {
+	ob := m.outbox
+	if ob == nil {
+		return nil
+	}
+	for ob.busy() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(OutboxDrainPoll):
+		}
+	}
+	ob.mu.Lock()
+	peers := make([]string, 0, len(ob.pending))
+	for p := range ob.pending {
+		peers = append(peers, p)
+	}
+	ob.mu.Unlock()
+	for _, p := range peers {
+		if !m.connected(p) {
+			continue
+		}
+		m.flushPeer(ctx, p, true)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Outbox lists the queued messages, oldest first, optionally for one peer.
+func (m *Manager) Outbox(peerID string) ([]storage.OutboxEntry, error) {
+	if m.outbox == nil {
+		return nil, ErrNoOutbox
+	}
+	return m.outbox.store.OutboxEntries(peerID)
+}
+
+// PurgeOutbox drops the queued messages for peerID, or all of them when
+// peerID is "". Returns the number dropped.
+func (m *Manager) PurgeOutbox(peerID string) (int64, error) {
+	ob := m.outbox
+	if ob == nil {
+		return 0, ErrNoOutbox
+	}
+	n, err := ob.store.PurgeOutbox(peerID)
+	if err != nil {
+		return 0, err
+	}
+	ob.mu.Lock()
+	if peerID == "" {
+		clear(ob.pending)
+	} else {
+		delete(ob.pending, peerID)
+	}
+	ob.mu.Unlock()
+	return n, nil
+}
+
+// busy reports whether any peer is being flushed.
+func (ob *outbox) busy() bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return len(ob.flushing) > 0
+}
+
+func (ob *outbox) hasPending(peerID string) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return ob.pending[peerID]
+}
+
+// runOutbox drops expired messages and retries peers whose oldest message
+// is due, every OutboxRetryInterval.
+func (m *Manager) runOutbox(ctx context.Context) {
+	t := time.NewTicker(OutboxRetryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		ob := m.outbox
+		if n, err := ob.store.ExpireOutbox(time.Now().UnixMilli()); err == nil && n > 0 {
+			log.Printf("MQ: outbox: dropped %d expired message(s)", n)
+			ob.resync()
+		}
+		ob.mu.Lock()
+		peers := make([]string, 0, len(ob.pending))
+		for p := range ob.pending {
+			peers = append(peers, p)
+		}
+		ob.mu.Unlock()
+		for _, p := range peers {
+			go m.flushPeer(ctx, p, false)
+		}
+	}
+}
+
+// resync reloads the pending peer set after rows were removed in bulk.
+func (ob *outbox) resync() {
+	peers, err := ob.store.OutboxPeers()
+	if err != nil {
+		return
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	clear(ob.pending)
+	for _, p := range peers {
+		ob.pending[p] = true
+	}
+}
+
+// flushPeer sends peerID's queued messages in order and stops at the first
+// failure. Unless force is set it waits for the oldest message's retry time.
+func (m *Manager) flushPeer(ctx context.Context, peerID string, force bool) {
+	ob := m.outbox
+	ob.mu.Lock()
+	if ob.flushing[peerID] {
+		ob.mu.Unlock()
+		return
+	}
+	ob.flushing[peerID] = true
+	ob.mu.Unlock()
+	defer func() {
+		ob.mu.Lock()
+		delete(ob.flushing, peerID)
+		ob.mu.Unlock()
+	}()
+
+	entries, err := ob.store.OutboxEntries(peerID)
+	if err != nil {
+		log.Printf("MQ: outbox: %v", err)
+		return
+	}
+	now := time.Now().UnixMilli()
+	if len(entries) > 0 && !force && entries[0].NextTry > now {
+		return
+	}
+	sent := 0
+	for _, e := range entries {
+		if e.ExpiresAt <= now {
+			_ = ob.store.DeleteOutbox(e.ID)
+			continue
+		}
+		// Decoded, so observers see the same payload types as for Send.
+		var payload any
+		_ = json.Unmarshal(e.Payload, &payload)
+		sendCtx, cancel := context.WithTimeout(ctx, timeouts.Get().MQSend)
+		_, err := m.Send(sendCtx, peerID, e.Topic, payload)
+		cancel()
+		if err != nil {
+			next := time.Now().Add(outboxBackoff(e.Attempts + 1)).UnixMilli()
+			_ = ob.store.RecordOutboxFailure(e.ID, next, err.Error())
+			if sent > 0 {
+				log.Printf("MQ: outbox: delivered %d message(s) to %s, rest still queued", sent, shortID(peerID))
+			}
+			return
+		}
+		_ = ob.store.DeleteOutbox(e.ID)
+		sent++
+	}
+	if sent > 0 {
+		log.Printf("MQ: outbox: delivered %d queued message(s) to %s", sent, shortID(peerID))
+	}
+
+	// Drop the peer from the pending set unless SendQueued added one meanwhile.
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if rest, err := ob.store.OutboxEntries(peerID); err == nil && len(rest) == 0 {
+		delete(ob.pending, peerID)
+	}
+}
+
+// outboxBackoff is the wait after the given number of failed attempts:
+// OutboxRetryBase, doubling up to OutboxRetryMax.
+func outboxBackoff(attempts int) time.Duration {
+	d := OutboxRetryBase
+	for i := 1; i < attempts && d < OutboxRetryMax; i++ {
+		d *= 2
+	}
+	return min(d, OutboxRetryMax)
+}
+
+// connected reports whether the host has a live connection to peerID.
+func (m *Manager) connected(peerID string) bool {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return false
+	}
+	return m.host.Network().Connectedness(pid) == network.Connected
+}
+
+func shortID(peerID string) string {
+	if len(peerID) > 8 {
+		return peerID[:8]
+	}
+	return peerID
+}
@@ -0,0 +1,153 @@
+package mq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func TestSendQueued_deliversInOrderWhenReachable(t *testing.T) {
+	sender := newTestHost(t)
+	receiver := newTestHost(t)
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender.EnableOutbox(ctx, db)
+
+	// The sender knows no address for the receiver, so it is unreachable.
+	to := receiver.host.ID().String()
+	for _, text := range []string{"first", "second"} {
+		sendCtx, done := context.WithTimeout(ctx, time.Second)
+		msgID, outboxID, err := sender.SendQueued(sendCtx, to, "chat", map[string]string{"text": text}, 0)
+		done()
+		if err != nil || msgID != "" || outboxID == 0 {
+			t.Fatalf("SendQueued(%s) = %q, %d, %v; want it queued", text, msgID, outboxID, err)
+		}
+	}
+	queued, _ := sender.Outbox(to)
+	if len(queued) != 2 || queued[0].Attempts != 1 || queued[1].Attempts != 0 || queued[0].LastError == "" {
+		t.Fatalf("outbox = %+v", queued)
+	}
+
+	ch, unsub := receiver.Subscribe()
+	defer unsub()
+	connectManagers(t, sender, receiver)
+	sender.FlushOutbox(ctx, to)
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case evt := <-nextMessage(ch):
+			if p, _ := evt.Msg.Payload.(map[string]any); p["text"] != want {
+				t.Fatalf("got %v, want %s", evt.Msg.Payload, want)
+			}
+		case <-time.After(5 * time.Second):
+			q, _ := sender.Outbox(to)
+			t.Fatalf("%s message not delivered; outbox %+v", want, q)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for sender.outbox.hasPending(to) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if left, _ := sender.Outbox(""); len(left) != 0 || sender.outbox.hasPending(to) {
+		t.Errorf("outbox after delivery = %+v", left)
+	}
+
+	// Reachable and nothing queued: sent straight away.
+	if msgID, outboxID, err := sender.SendQueued(ctx, to, "chat", "direct", 0); err != nil || msgID == "" || outboxID != 0 {
+		t.Errorf("direct SendQueued = %q, %d, %v", msgID, outboxID, err)
+	}
+}
+
+// nextMessage skips the log events on ch and yields the next message.
+func nextMessage(ch <-chan mqEvent) <-chan mqEvent {
+	out := make(chan mqEvent, 1)
+	go func() {
+		for evt := range ch {
+			if evt.Type == "message" && evt.Msg.Topic == "chat" {
+				out <- evt
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestOutbox_purgeAndBackoff(t *testing.T) {
+	m := newTestHost(t)
+	if _, err := m.Outbox(""); err != ErrNoOutbox {
+		t.Errorf("Outbox before EnableOutbox: %v", err)
+	}
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.EnqueueOutbox(storage.OutboxEntry{PeerID: "p1", Topic: "chat", ExpiresAt: time.Now().Add(time.Hour).UnixMilli()})
+	db.EnqueueOutbox(storage.OutboxEntry{PeerID: "p2", Topic: "chat", ExpiresAt: time.Now().Add(time.Hour).UnixMilli()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.EnableOutbox(ctx, db)
+	if !m.outbox.hasPending("p1") || !m.outbox.hasPending("p2") {
+		t.Fatal("queued peers not loaded from the store")
+	}
+	if n, err := m.PurgeOutbox("p1"); n != 1 || err != nil || m.outbox.hasPending("p1") {
+		t.Errorf("purge p1 = %d, %v", n, err)
+	}
+	if n, _ := m.PurgeOutbox(""); n != 1 {
+		t.Errorf("purge all = %d", n)
+	}
+
+	for attempts, want := range map[int]time.Duration{1: OutboxRetryBase, 2: 2 * OutboxRetryBase, 30: OutboxRetryMax} {
+		if got := outboxBackoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestDrainOutbox_deliversToConnectedPeers(t *testing.T) {
+	sender := newTestHost(t)
+	receiver := newTestHost(t)
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.EnqueueOutbox(storage.OutboxEntry{PeerID: "12D3KooWoffline", Topic: "chat", ExpiresAt: time.Now().Add(time.Hour).UnixMilli()})
+
+	// The app context is already gone when shutdown drains the outbox.
+	appCtx, cancel := context.WithCancel(context.Background())
+	sender.EnableOutbox(appCtx, db)
+	to := receiver.host.ID().String()
+	sendCtx, done := context.WithTimeout(appCtx, time.Second)
+	if _, outboxID, err := sender.SendQueued(sendCtx, to, "chat", map[string]string{"text": "bye"}, 0); err != nil || outboxID == 0 {
+		t.Fatalf("SendQueued = %d, %v; want it queued", outboxID, err)
+	}
+	done()
+	cancel()
+
+	ch, unsub := receiver.Subscribe()
+	defer unsub()
+	connectManagers(t, sender, receiver)
+
+	drainCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	if err := sender.DrainOutbox(drainCtx); err != nil {
+		t.Fatalf("DrainOutbox: %v", err)
+	}
+	select {
+	case <-nextMessage(ch):
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued message not delivered on drain")
+	}
+	if left, _ := sender.Outbox(""); len(left) != 1 || left[0].PeerID != "12D3KooWoffline" {
+		t.Errorf("outbox after drain = %+v, want only the unconnected peer", left)
+	}
+}
@@ -10,11 +10,12 @@ const (
 
 // MQMsg is the wire type for a message sent over the MQ protocol.
 type MQMsg struct {
-	Type    string `json:"type"`    // "msg"
-	ID      string `json:"id"`      // uuid4
-	Seq     int64  `json:"seq"`     // monotonic counter per sender
-	Topic   string `json:"topic"`   // e.g. "chat", "call:channelID"
-	Payload any    `json:"payload"` // arbitrary JSON
+	Type    string   `json:"type"`           // "msg"
+	ID      string   `json:"id"`             // uuid4
+	Seq     int64    `json:"seq"`            // monotonic counter per sender
+	Topic   string   `json:"topic"`          // e.g. "chat", "call:channelID"
+	Payload any      `json:"payload"`        // arbitrary JSON
+	Blob    *BlobRef `json:"blob,omitempty"` // set instead of Payload when spilled (see blob.go)
 }
 
 // MQAck is the wire type for a transport ACK.
@@ -33,8 +34,9 @@ type MQEncryptor interface {
 
 // mqEvent is delivered to SSE subscribers (/api/mq/events).
 type mqEvent struct {
-	Type  string `json:"type"`            // "message" | "delivered"
+	Type  string `json:"type"`            // "message" | "delivered" | "reset"
 	Msg   *MQMsg `json:"msg,omitempty"`   // set when Type="message"
 	MsgID string `json:"msg_id,omitempty"` // set when Type="delivered"
 	From  string `json:"from,omitempty"`
+	JSeq  int64  `json:"-"` // journal sequence, sent as the SSE event id
 }
@@ -2,11 +2,16 @@ package mq
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	libp2p "github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/prom"
 )
 
 func newTestHost(t *testing.T) *Manager {
@@ -174,10 +179,16 @@ func TestSend_UnreachablePeer(t *testing.T) {
 	ctx, done := context.WithTimeout(context.Background(), 3*time.Second)
 	defer done()
 
+	var failed []string
+	sender.SubscribeFailed("chat", func(to, topic string, _ any) { failed = append(failed, topic+">"+to) })
+
 	_, err := sender.Send(ctx, unreachable.host.ID().String(), "chat", nil)
 	if err == nil {
 		t.Fatal("Send to unconnected peer should fail")
 	}
+	if want := "chat>" + unreachable.host.ID().String(); len(failed) != 1 || failed[0] != want {
+		t.Fatalf("failed observers = %v, want [%s]", failed, want)
+	}
 }
 
 func TestSend_MultipleMessages_SequenceIncreases(t *testing.T) {
@@ -253,3 +264,94 @@ func TestSend_InboxBuffering_NoListener(t *testing.T) {
 		t.Fatal("subscribe should replay buffered message")
 	}
 }
+
+func TestSend_RecordsMetrics(t *testing.T) {
+	sender := newTestHost(t)
+	receiver := newTestHost(t)
+	connectManagers(t, sender, receiver)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+
+	for i := 0; i < 3; i++ {
+		if _, err := sender.Send(ctx, receiver.host.ID().String(), "group:g1:msg", map[string]int{"n": i}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	out := sender.Metrics().Topics["group"]
+	if out.Sent != 3 || out.Delivered != 3 || out.AckLatency.Count != 3 {
+		t.Fatalf("sender metrics = %+v", out)
+	}
+	if sender.Metrics().ByVia["direct"].Count != 3 {
+		t.Fatalf("expected direct latency samples, got %+v", sender.Metrics().ByVia)
+	}
+	if in := receiver.Metrics().Topics["group"]; in.Received != 3 {
+		t.Fatalf("receiver metrics = %+v", in)
+	}
+
+	text := string(prom.Write(sender))
+	for _, want := range []string{
+		`goop_mq_sent_total{topic="group"} 3`,
+		`goop_mq_send_latency_seconds_count{topic="group"} 3`,
+		`goop_mq_send_latency_by_path_seconds_bucket{path="direct",le="+Inf"} 3`,
+		`goop_mq_queued{lane="bulk"} 0`,
+	} {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("exposition lacks %s:\n%s", want, text)
+		}
+	}
+}
+
+func TestSend_OversizedPayloadSpillsToBlob(t *testing.T) {
+	sender := newTestHost(t)
+	receiver := newTestHost(t)
+	connectManagers(t, sender, receiver)
+
+	ch, cancel := receiver.Subscribe()
+	defer cancel()
+
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+
+	big := strings.Repeat("x", MaxInlinePayload*3)
+	if _, err := sender.Send(ctx, receiver.host.ID().String(), "chat", map[string]string{"text": big}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	evt, ok := drainUntilTopic(ch, "chat", time.After(5*time.Second))
+	if !ok {
+		t.Fatal("timed out waiting for spilled message")
+	}
+	if evt.Msg.Blob != nil {
+		t.Fatal("blob reference leaked to listener")
+	}
+	payload, _ := evt.Msg.Payload.(map[string]any)
+	if payload["text"] != big {
+		t.Fatalf("payload mismatch: got %d bytes", len(fmt.Sprint(payload["text"])))
+	}
+}
+
+func TestSend_PayloadTooLarge(t *testing.T) {
+	sender := newTestHost(t)
+	receiver := newTestHost(t)
+
+	huge := strings.Repeat("x", MaxPayloadBytes+1)
+	_, err := sender.Send(context.Background(), receiver.host.ID().String(), "chat", huge)
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+	}
+}
+
+func TestBlobStore_OnlyRecipientMayFetch(t *testing.T) {
+	var b blobStore
+	ref := b.put("peerA", []byte(`{"k":"v"}`))
+
+	if _, ok := b.get(ref.SHA256, "peerB"); ok {
+		t.Fatal("other peer fetched blob")
+	}
+	data, ok := b.get(ref.SHA256, "peerA")
+	if !ok || string(data) != `{"k":"v"}` {
+		t.Fatalf("recipient fetch = %q, %v", data, ok)
+	}
+}
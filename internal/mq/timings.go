@@ -2,10 +2,20 @@ package mq
 
 import "time"
 
-// MQ protocol timings — P2P message delivery.
+// MQ protocol timings — P2P message delivery. The ACK wait and the budget
+// of a queued message's delivery attempt are tunable (timeouts.MQAck,
+// timeouts.MQSend).
 const (
-	AckTimeout    = 2 * time.Second        // transport ACK wait per send attempt
 	RetryDelay    = 300 * time.Millisecond // delay between send retry
 	ReadDeadline  = 3 * time.Second        // incoming stream read deadline
 	WriteDeadline = 3 * time.Second        // outgoing response write deadline
+
+	BlobTTL          = 2 * time.Minute  // how long a spilled payload stays fetchable
+	BlobFetchTimeout = 30 * time.Second // receiver budget to fetch a spilled payload
+
+	OutboxDefaultTTL    = 7 * 24 * time.Hour    // how long a queued message waits for its peer
+	OutboxRetryInterval = 15 * time.Second      // how often queued messages are checked for a retry
+	OutboxRetryBase     = 30 * time.Second      // wait after the first failed attempt, doubling
+	OutboxRetryMax      = 30 * time.Minute      // longest wait between attempts
+	OutboxDrainPoll     = 50 * time.Millisecond // shutdown: check for deliveries still in progress
 )
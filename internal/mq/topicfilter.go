@@ -0,0 +1,83 @@
+package mq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Topic patterns select topics by their ":"-separated segments. A "+"
+// segment matches any one segment and a final "*" matches the rest of the
+// topic, one segment or more: "call:*" matches every call signal and
+// "group:+:state" the state of every group. Without wildcards a pattern
+// matches only the topic itself.
+const (
+	maxTopicPatterns   = 32
+	maxTopicPatternLen = 256
+)
+
+// TopicFilter is a set of topic patterns; a topic matches when any of them
+// does.
+type TopicFilter struct {
+	patterns [][]string
+}
+
+// ParseTopicFilter parses a comma-separated list of topic patterns.
+func ParseTopicFilter(list string) (*TopicFilter, error) {
+	f := &TopicFilter{}
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(p) > maxTopicPatternLen {
+			return nil, fmt.Errorf("topic pattern longer than %d characters", maxTopicPatternLen)
+		}
+		segs := strings.Split(p, ":")
+		for i, s := range segs {
+			if s == "*" && i != len(segs)-1 {
+				return nil, fmt.Errorf("topic pattern %q: * must be the last segment", p)
+			}
+			if s != "*" && s != "+" && strings.ContainsAny(s, "*+") {
+				return nil, fmt.Errorf("topic pattern %q: wildcards must be whole segments", p)
+			}
+		}
+		f.patterns = append(f.patterns, segs)
+	}
+	if len(f.patterns) == 0 {
+		return nil, fmt.Errorf("no topic patterns")
+	}
+	if len(f.patterns) > maxTopicPatterns {
+		return nil, fmt.Errorf("more than %d topic patterns", maxTopicPatterns)
+	}
+	return f, nil
+}
+
+// Match reports whether topic matches one of the patterns.
+func (f *TopicFilter) Match(topic string) bool {
+	segs := strings.Split(topic, ":")
+	for _, p := range f.patterns {
+		if matchSegments(p, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// takes reports whether a listener with filter f gets evt: events that are
+// not messages, like receipts and resets, go to every listener, and a nil
+// filter takes everything.
+func (f *TopicFilter) takes(evt mqEvent) bool {
+	return f == nil || evt.Msg == nil || f.Match(evt.Msg.Topic)
+}
+
+func matchSegments(pattern, topic []string) bool {
+	for i, s := range pattern {
+		if s == "*" {
+			return len(topic) > i
+		}
+		if i >= len(topic) || s != "+" && s != topic[i] {
+			return false
+		}
+	}
+	return len(topic) == len(pattern)
+}
@@ -0,0 +1,84 @@
+package mq
+
+import "testing"
+
+func TestTopicFilter_Match(t *testing.T) {
+	f, err := ParseTopicFilter("call:*, group:+:state,peer:announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for topic, want := range map[string]bool{
+		"call:abc:offer":     true,
+		"call:loopback:abc":  true,
+		"call":               false,
+		"call.ringing":       false,
+		"group:g1:state":     true,
+		"group:g1:members":   false,
+		"group:g1:state:old": false,
+		"peer:announce":      true,
+		"peer:gone":          false,
+	} {
+		if got := f.Match(topic); got != want {
+			t.Errorf("Match(%q) = %v, want %v", topic, got, want)
+		}
+	}
+}
+
+func TestParseTopicFilter_Invalid(t *testing.T) {
+	for _, list := range []string{"", " , ", "call:*:offer", "call*", "group:g+:state"} {
+		if _, err := ParseTopicFilter(list); err == nil {
+			t.Errorf("ParseTopicFilter(%q) accepted", list)
+		}
+	}
+}
+
+func TestSubscribeFiltered(t *testing.T) {
+	m := &Manager{
+		inbox:     make(map[string][]inboxEntry),
+		pending:   make(map[string]chan struct{}),
+		listeners: make(map[chan mqEvent]struct{}),
+		selfID:    "self",
+	}
+	f, _ := ParseTopicFilter("listen:*")
+
+	// Held messages the filter does not take stay for the next listener.
+	m.PublishLocalHeld("listen:g1:state", "", "a")
+	m.PublishLocalHeld(TopicHealthStartup, "", "b")
+	ch, cancel := m.SubscribeFiltered(0, f)
+	defer cancel()
+	if evt := <-ch; evt.Msg.Topic != "listen:g1:state" {
+		t.Fatalf("replayed %q", evt.Msg.Topic)
+	}
+	m.inboxMu.Lock()
+	left := len(m.inbox[""])
+	m.inboxMu.Unlock()
+	if left != 1 {
+		t.Fatalf("inbox has %d messages, want 1", left)
+	}
+
+	// With only a filtered listener, other topics are held too.
+	m.PublishLocalHeld(TopicPeerGone, "", "c")
+	m.PublishLocal("listen:g1:state", "", "d")
+	m.NotifyDelivered("msg-1")
+	if evt := <-ch; evt.Msg == nil || evt.Msg.Payload != "d" {
+		t.Fatalf("got %+v, want the listen message", evt)
+	}
+	if evt := <-ch; evt.Type != "delivered" {
+		t.Fatalf("got %+v, want the receipt", evt)
+	}
+	select {
+	case evt := <-ch:
+		t.Fatalf("filtered listener got %+v", evt)
+	default:
+	}
+
+	all, cancelAll := m.Subscribe()
+	defer cancelAll()
+	got := map[string]bool{}
+	for len(all) > 0 {
+		got[(<-all).Msg.Topic] = true
+	}
+	if !got[TopicHealthStartup] || !got[TopicPeerGone] || len(got) != 2 {
+		t.Fatalf("unfiltered listener replayed %v", got)
+	}
+}
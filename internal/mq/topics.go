@@ -41,6 +41,25 @@ const (
 
 	// Internal MQ event log — published locally by mq.logMQEvent.
 	TopicLogMQ = "log:mq"
+
+	// Inbound access consent prompt — published locally by the p2p consent broker.
+	TopicSecurityConsent = "security.consent"
+
+	// Call history events — published locally by the call log tracker.
+	TopicCallRinging = "call.ringing" // incoming call started ringing
+	TopicCallMissed  = "call.missed"  // incoming call went unanswered
+
+	// Template updates — published locally when the store has a newer
+	// version of the installed template.
+	TopicTemplateUpdate = "template:update-available"
+
+	// Shared file downloads — published locally while the viewer fetches a
+	// file from a peer over the chunked docs protocol.
+	TopicDocsDownloadPrefix = "docs:download:" // + filename + ":progress"
+
+	// Startup checks — published once per start, held for the first browser
+	// to connect, when the startup doctor repaired or failed something.
+	TopicHealthStartup = "health:startup"
 )
 
 // ── Call signal type constants ─────────────────────────────────────────────────
@@ -53,6 +72,14 @@ const (
 	CallTypeAnswer     = "call-answer"   // callee → caller: SDP answer
 	CallTypeICE        = "ice-candidate" // either → other: trickle ICE candidate
 	CallTypeHangup     = "call-hangup"   // either side: end the call
+	CallTypeBusy       = "call-busy"     // callee → caller: already in a call
+	CallTypeCallback   = "call-callback" // caller → callee: please call back
+	CallTypeHold       = "call-hold"     // either side: put on hold
+	CallTypeResume     = "call-resume"   // either side: take off hold
+	CallTypeTransfer   = "call-transfer" // either side: receiver dials payload target
+	CallTypeData       = "call-data"     // Go → browser: in-call chat / file drop (native mode)
+	CallTypeCaption    = "call-caption"  // Go → browser: live caption text (native mode)
+	CallTypeDevice     = "call-device"   // Go → browser: capture device switched or lost (native mode)
 	CallTypeLoopbackICE = "loopback-ice" // Go → browser: LocalPC ICE candidate (Phase 4)
 )
 
@@ -146,6 +173,35 @@ type CallHangupPayload struct {
 	ChannelID string `json:"channel_id"`
 }
 
+// TemplateUpdatePayload is the payload for TopicTemplateUpdate.
+type TemplateUpdatePayload struct {
+	Template  string `json:"template"`  // store dir of the installed template
+	Name      string `json:"name"`      // display name from the store
+	Installed string `json:"installed"` // installed version
+	Latest    string `json:"latest"`    // newest version in the store
+}
+
+// DocsDownloadProgressPayload is the payload for
+// TopicDocsDownloadPrefix + filename + ":progress".
+type DocsDownloadProgressPayload struct {
+	PeerID  string `json:"peer_id"`
+	GroupID string `json:"group_id"`
+	File    string `json:"file"`
+	Done    int64  `json:"done"`  // bytes on disk, including a resumed part
+	Total   int64  `json:"total"` // file size
+	State   string `json:"state"` // "progress", "done" or "error"
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthStartupPayload is the payload for TopicHealthStartup; the full
+// report is at GET /api/health.
+type HealthStartupPayload struct {
+	Unclean  bool     `json:"unclean"`          // the previous run did not shut down cleanly
+	Healthy  bool     `json:"healthy"`          // no check failed
+	Repaired int      `json:"repaired"`         // items repaired by all checks
+	Failed   []string `json:"failed,omitempty"` // names of the failed checks
+}
+
 // ── Typed publish helpers ─────────────────────────────────────────────────────
 
 // PublishPeerAnnounce pushes a peer metadata update to the browser via MQ SSE.
@@ -158,6 +214,23 @@ func (m *Manager) PublishPeerGone(peerID string) {
 	m.PublishLocal(TopicPeerGone, "", PeerGonePayload{PeerID: peerID})
 }
 
+// PublishTemplateUpdate tells the browser a newer version of the installed
+// template is available in the store.
+func (m *Manager) PublishTemplateUpdate(p TemplateUpdatePayload) {
+	m.PublishLocal(TopicTemplateUpdate, "", p)
+}
+
+// PublishDocsDownloadProgress reports how far a shared file download got.
+func (m *Manager) PublishDocsDownloadProgress(p DocsDownloadProgressPayload) {
+	m.PublishLocal(TopicDocsDownloadPrefix+p.File+":progress", "", p)
+}
+
+// PublishHealthStartup reports the startup checks. It is held until a
+// browser connects, since it is published before any can.
+func (m *Manager) PublishHealthStartup(p HealthStartupPayload) {
+	m.PublishLocalHeld(TopicHealthStartup, "", p)
+}
+
 // PublishCallHangup notifies the browser that a native call session has ended.
 // Called by routes/call.go watchHangup() when sess.HangupCh() fires.
 func (m *Manager) PublishCallHangup(channelID string) {
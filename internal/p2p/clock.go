@@ -0,0 +1,205 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// ClockEstimate is how far a peer's clock is from ours, measured with a
+// few NTP-style exchanges over /goop/time: their time ≈ our time + Offset.
+type ClockEstimate struct {
+	OffsetMs   float64   `json:"offset_ms"`
+	JitterMs   float64   `json:"jitter_ms"` // spread of the samples around the best one
+	RTTMs      float64   `json:"rtt_ms"`    // round trip of the best sample
+	Samples    int       `json:"samples"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// ToLocal converts a Unix millisecond timestamp taken on the peer's clock
+// to ours.
+func (e ClockEstimate) ToLocal(peerMs int64) int64 {
+	return peerMs - int64(math.Round(e.OffsetMs))
+}
+
+// clockTable caches estimates per peer.
+type clockTable struct {
+	mu        sync.Mutex
+	estimates map[string]ClockEstimate
+	measuring map[string]bool
+}
+
+func (t *clockTable) get(peerID string) (ClockEstimate, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.estimates[peerID]
+	return e, ok
+}
+
+func (t *clockTable) put(peerID string, e ClockEstimate) {
+	t.mu.Lock()
+	if t.estimates == nil {
+		t.estimates = map[string]ClockEstimate{}
+	}
+	t.estimates[peerID] = e
+	t.mu.Unlock()
+}
+
+// begin marks a measurement for peerID as running; false if one already is.
+func (t *clockTable) begin(peerID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.measuring[peerID] {
+		return false
+	}
+	if t.measuring == nil {
+		t.measuring = map[string]bool{}
+	}
+	t.measuring[peerID] = true
+	return true
+}
+
+func (t *clockTable) end(peerID string) {
+	t.mu.Lock()
+	delete(t.measuring, peerID)
+	t.mu.Unlock()
+}
+
+// handleTimeStream answers up to ClockSamples exchanges on one stream.
+func (n *Node) handleTimeStream(s network.Stream) {
+	defer s.Close()
+	r := bufio.NewReader(s)
+	enc := json.NewEncoder(s)
+	for i := 0; i < ClockSamples; i++ {
+		_ = s.SetReadDeadline(time.Now().Add(ClockExchangeTimeout))
+		line, err := r.ReadBytes('\n')
+		t1 := time.Now().UnixMicro()
+		if err != nil {
+			return
+		}
+		var ts proto.TimeSample
+		if json.Unmarshal(line, &ts) != nil {
+			return
+		}
+		ts.T1 = t1
+		ts.T2 = time.Now().UnixMicro()
+		if enc.Encode(ts) != nil {
+			return
+		}
+	}
+}
+
+// MeasureClock runs ClockSamples exchanges with a peer and caches the
+// estimate from the sample with the shortest round trip, the one least
+// skewed by queueing.
+func (n *Node) MeasureClock(ctx context.Context, peerID string) (ClockEstimate, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return ClockEstimate{}, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, ClockMeasureTimeout)
+	defer cancel()
+
+	st, err := n.Host.NewStream(network.WithAllowLimitedConn(ctx, "relay"), pid, protocol.ID(proto.TimeProtoID))
+	if err != nil {
+		return ClockEstimate{}, err
+	}
+	defer st.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = st.SetDeadline(dl)
+	}
+
+	r := bufio.NewReader(st)
+	enc := json.NewEncoder(st)
+	var offsets, delays []float64
+	for i := 0; i < ClockSamples; i++ {
+		t0 := time.Now().UnixMicro()
+		if err := enc.Encode(proto.TimeSample{T0: t0}); err != nil {
+			break
+		}
+		line, err := r.ReadBytes('\n')
+		t3 := time.Now().UnixMicro()
+		if err != nil {
+			break
+		}
+		var ts proto.TimeSample
+		if json.Unmarshal(line, &ts) != nil || ts.T0 != t0 || ts.T1 == 0 {
+			break
+		}
+		offsets = append(offsets, float64((ts.T1-t0)+(ts.T2-t3))/2)
+		delays = append(delays, float64((t3-t0)-(ts.T2-ts.T1)))
+	}
+	if len(offsets) == 0 {
+		return ClockEstimate{}, errors.New("no clock samples")
+	}
+
+	e := estimateClock(offsets, delays)
+	e.MeasuredAt = time.Now()
+	n.clocks.put(peerID, e)
+	return e, nil
+}
+
+// estimateClock picks the sample with the smallest delay; jitter is the
+// RMS distance of the other offsets from it. Inputs are microseconds.
+func estimateClock(offsets, delays []float64) ClockEstimate {
+	best := 0
+	for i := range delays {
+		if delays[i] < delays[best] {
+			best = i
+		}
+	}
+	var sq float64
+	for _, o := range offsets {
+		d := o - offsets[best]
+		sq += d * d
+	}
+	jitter := 0.0
+	if len(offsets) > 1 {
+		jitter = math.Sqrt(sq / float64(len(offsets)-1))
+	}
+	return ClockEstimate{
+		OffsetMs: offsets[best] / 1000,
+		JitterMs: jitter / 1000,
+		RTTMs:    math.Max(delays[best], 0) / 1000,
+		Samples:  len(offsets),
+	}
+}
+
+// ClockOffset returns the cached estimate for a peer. When it is missing
+// or older than ClockEstimateTTL a new measurement starts in the
+// background, so callers on hot paths never wait; the stale value, if
+// any, is still returned.
+func (n *Node) ClockOffset(peerID string) (ClockEstimate, bool) {
+	e, ok := n.clocks.get(peerID)
+	if (!ok || time.Since(e.MeasuredAt) > ClockEstimateTTL) && peerID != n.ID() && n.clocks.begin(peerID) {
+		go func() {
+			defer n.clocks.end(peerID)
+			if _, err := n.MeasureClock(context.Background(), peerID); err != nil {
+				n.diag("clock: measuring %s failed: %v", shortPeer(peerID), err)
+			}
+		}()
+	}
+	return e, ok
+}
+
+// ClockEstimates returns every cached estimate by peer ID.
+func (n *Node) ClockEstimates() map[string]ClockEstimate {
+	n.clocks.mu.Lock()
+	defer n.clocks.mu.Unlock()
+	out := make(map[string]ClockEstimate, len(n.clocks.estimates))
+	for id, e := range n.clocks.estimates {
+		out[id] = e
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestEstimateClock_PicksFastestSample(t *testing.T) {
+	// Offsets and delays in microseconds; the 2ms-delay sample wins.
+	e := estimateClock([]float64{9000, 5000, 7000}, []float64{20000, 2000, 8000})
+	if e.OffsetMs != 5 || e.RTTMs != 2 || e.Samples != 3 {
+		t.Fatalf("estimate = %+v", e)
+	}
+	if want := math.Sqrt((16e6+0+4e6)/2) / 1000; math.Abs(e.JitterMs-want) > 1e-9 {
+		t.Fatalf("jitter = %v, want %v", e.JitterMs, want)
+	}
+	if got := e.ToLocal(10_005); got != 10_000 {
+		t.Fatalf("ToLocal = %d", got)
+	}
+}
+
+func TestMeasureClock_RoundTrip(t *testing.T) {
+	newNode := func() *Node {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return &Node{Host: h}
+	}
+	server, client := newNode(), newNode()
+	// A peer whose clock is 3s ahead.
+	server.Host.SetStreamHandler(protocol.ID(proto.TimeProtoID), func(s network.Stream) {
+		server.handleTimeStream(&skewStream{Stream: s})
+	})
+	if err := client.Host.Connect(context.Background(), peer.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := client.MeasureClock(context.Background(), server.Host.ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Samples != ClockSamples {
+		t.Fatalf("samples = %d", e.Samples)
+	}
+	if math.Abs(e.OffsetMs-3000) > 50 {
+		t.Fatalf("offset = %.1fms, want about 3000", e.OffsetMs)
+	}
+	if got, ok := client.ClockOffset(server.Host.ID().String()); !ok || got.OffsetMs != e.OffsetMs {
+		t.Fatalf("estimate not cached: %+v %v", got, ok)
+	}
+}
+
+// skewStream shifts the T1/T2 stamps written by handleTimeStream by 3s.
+type skewStream struct{ network.Stream }
+
+func (s *skewStream) Write(p []byte) (int, error) {
+	var ts proto.TimeSample
+	if err := json.Unmarshal(p, &ts); err == nil && ts.T1 != 0 {
+		ts.T1 += int64(3 * time.Second / time.Microsecond)
+		ts.T2 += int64(3 * time.Second / time.Microsecond)
+		b, _ := json.Marshal(ts)
+		if _, err := s.Stream.Write(append(b, '\n')); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return s.Stream.Write(p)
+}
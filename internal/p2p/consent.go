@@ -0,0 +1,185 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Consent decisions. "once" and "deny" apply to the parked request only;
+// "always" and "block" are persisted for the peer.
+const (
+	ConsentOnce   = "once"
+	ConsentAlways = "always"
+	ConsentBlock  = "block"
+	ConsentDeny   = "deny"
+)
+
+// ConsentRequest is an inbound request parked until the user decides.
+type ConsentRequest struct {
+	ID       string `json:"id"`
+	PeerID   string `json:"peer_id"`
+	Protocol string `json:"protocol"`
+	Created  int64  `json:"created"` // Unix ms
+}
+
+// ConsentStore persists "always" / "block" decisions per peer.
+type ConsentStore interface {
+	GetAccessConsent(peerID string) string
+	SetAccessConsent(peerID, decision string) error
+}
+
+type consentWait struct {
+	req     ConsentRequest
+	done    chan struct{}
+	allowed bool
+}
+
+// ConsentBroker parks first-time inbound requests from unknown peers and
+// asks the user to allow once, always, or block. Concurrent requests from
+// the same peer share one prompt.
+type ConsentBroker struct {
+	mu      sync.Mutex
+	store   ConsentStore
+	notify  func(ConsentRequest)
+	pending map[string]*consentWait // peerID -> wait
+	timeout time.Duration
+}
+
+// NewConsentBroker creates a broker with the default prompt timeout.
+func NewConsentBroker() *ConsentBroker {
+	return &ConsentBroker{
+		pending: make(map[string]*consentWait),
+		timeout: ConsentPromptTimeout,
+	}
+}
+
+// SetStore sets where persistent decisions are kept.
+func (b *ConsentBroker) SetStore(s ConsentStore) {
+	b.mu.Lock()
+	b.store = s
+	b.mu.Unlock()
+}
+
+// SetNotifier sets the callback that surfaces a new prompt to the user.
+func (b *ConsentBroker) SetNotifier(fn func(ConsentRequest)) {
+	b.mu.Lock()
+	b.notify = fn
+	b.mu.Unlock()
+}
+
+// Ask blocks until the user decides on peerID's access or the prompt times
+// out (treated as deny). Persisted decisions answer immediately.
+func (b *ConsentBroker) Ask(protoID, peerID string) bool {
+	b.mu.Lock()
+	store, notify := b.store, b.notify
+	b.mu.Unlock()
+
+	if store != nil {
+		switch store.GetAccessConsent(peerID) {
+		case ConsentAlways:
+			return true
+		case ConsentBlock:
+			return false
+		}
+	}
+	if notify == nil {
+		// Nobody to ask: fail closed.
+		return false
+	}
+
+	b.mu.Lock()
+	w, ok := b.pending[peerID]
+	if !ok {
+		w = &consentWait{
+			req: ConsentRequest{
+				ID:       newConsentID(),
+				PeerID:   peerID,
+				Protocol: protoID,
+				Created:  time.Now().UnixMilli(),
+			},
+			done: make(chan struct{}),
+		}
+		b.pending[peerID] = w
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		log.Printf("CONSENT: %s requested %s, waiting for user", shortPeer(peerID), protoID)
+		notify(w.req)
+	}
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	select {
+	case <-w.done:
+		return w.allowed
+	case <-timer.C:
+		b.mu.Lock()
+		if b.pending[peerID] == w {
+			delete(b.pending, peerID)
+			close(w.done)
+		}
+		b.mu.Unlock()
+		<-w.done // Decide may have claimed it just before the timeout
+		return w.allowed
+	}
+}
+
+// Decide resolves a parked request by ID.
+func (b *ConsentBroker) Decide(id, decision string) error {
+	switch decision {
+	case ConsentOnce, ConsentAlways, ConsentBlock, ConsentDeny:
+	default:
+		return errors.New("decision must be once, always, block or deny")
+	}
+
+	b.mu.Lock()
+	var w *consentWait
+	for peerID, pw := range b.pending {
+		if pw.req.ID == id {
+			w = pw
+			delete(b.pending, peerID)
+			break
+		}
+	}
+	store := b.store
+	b.mu.Unlock()
+	if w == nil {
+		return errors.New("no pending request with that id")
+	}
+
+	if store != nil && (decision == ConsentAlways || decision == ConsentBlock) {
+		if err := store.SetAccessConsent(w.req.PeerID, decision); err != nil {
+			log.Printf("CONSENT: persist decision for %s: %v", shortPeer(w.req.PeerID), err)
+		}
+	}
+	w.allowed = decision == ConsentOnce || decision == ConsentAlways
+	close(w.done)
+	return nil
+}
+
+// Pending returns the requests currently awaiting a decision.
+func (b *ConsentBroker) Pending() []ConsentRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]ConsentRequest, 0, len(b.pending))
+	for _, w := range b.pending {
+		out = append(out, w.req)
+	}
+	return out
+}
+
+// Consent returns the node's consent broker.
+func (n *Node) Consent() *ConsentBroker {
+	return n.consent
+}
+
+func newConsentID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type memConsentStore struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (s *memConsentStore) GetAccessConsent(peerID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[peerID]
+}
+
+func (s *memConsentStore) SetAccessConsent(peerID, decision string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[peerID] = decision
+	return nil
+}
+
+func TestConsentBroker_AlwaysIsPersisted(t *testing.T) {
+	b := NewConsentBroker()
+	store := &memConsentStore{m: map[string]string{}}
+	b.SetStore(store)
+	prompts := make(chan ConsentRequest, 4)
+	b.SetNotifier(func(r ConsentRequest) { prompts <- r })
+
+	result := make(chan bool, 2)
+	go func() { result <- b.Ask("/goop/docs/1.0.0", "peer-a") }()
+	req := <-prompts
+
+	// A second request from the same peer joins the same prompt.
+	go func() { result <- b.Ask("/goop/data/1.0.0", "peer-a") }()
+	time.Sleep(20 * time.Millisecond)
+	if n := len(b.Pending()); n != 1 {
+		t.Fatalf("expected 1 pending prompt, got %d", n)
+	}
+
+	if err := b.Decide(req.ID, ConsentAlways); err != nil {
+		t.Fatal(err)
+	}
+	if !<-result || !<-result {
+		t.Fatal("expected both parked requests allowed")
+	}
+	if store.m["peer-a"] != ConsentAlways {
+		t.Fatalf("decision not persisted: %q", store.m["peer-a"])
+	}
+
+	// Next access is answered without prompting.
+	if !b.Ask("/goop/docs/1.0.0", "peer-a") {
+		t.Fatal("expected persisted allow")
+	}
+	select {
+	case <-prompts:
+		t.Fatal("unexpected prompt for remembered peer")
+	default:
+	}
+}
+
+func TestConsentBroker_TimeoutDenies(t *testing.T) {
+	b := NewConsentBroker()
+	b.timeout = 20 * time.Millisecond
+	b.SetNotifier(func(ConsentRequest) {})
+	if b.Ask("/goop/docs/1.0.0", "peer-b") {
+		t.Fatal("expected deny on timeout")
+	}
+	if len(b.Pending()) != 0 {
+		t.Fatal("expected prompt cleared after timeout")
+	}
+}
+
+func TestConsentBroker_NoNotifierFailsClosed(t *testing.T) {
+	if NewConsentBroker().Ask("/goop/docs/1.0.0", "peer-c") {
+		t.Fatal("expected deny without a notifier")
+	}
+}
@@ -0,0 +1,283 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Operations of a /goop/data-sync request.
+const (
+	DataSyncPull = "pull" // changes after a cursor; cursor 0 is the snapshot
+	DataSyncPush = "push" // the member's own changes
+)
+
+const (
+	DataSyncPageSize   = 500              // most changes in one pull answer or push
+	DataSyncMaxMessage = 16 * 1024 * 1024 // one request or answer line
+)
+
+// DataSyncRequest is the line a template group member writes on a
+// /goop/data-sync stream to the group's host.
+type DataSyncRequest struct {
+	Op      string               `json:"op"`
+	Since   int64                `json:"since,omitempty"`   // pull: the host's sequence number to continue after
+	Changes []storage.SyncChange `json:"changes,omitempty"` // push
+}
+
+// DataSyncResponse is the host's answer line.
+type DataSyncResponse struct {
+	Tables []storage.SyncTableDef `json:"tables,omitempty"` // pull: the tables the member may read
+	// Pull: changes after Since, not made by the member. Push: the host's
+	// version of each refused change, for the member to apply as is.
+	Changes   []storage.SyncChange   `json:"changes,omitempty"`
+	Next      int64                  `json:"next,omitempty"`      // pull: the cursor for the next pull
+	More      bool                   `json:"more,omitempty"`      // pull: changes are left after Next
+	Reset     bool                   `json:"reset,omitempty"`     // pull: Since was past the host's log, this is a new snapshot
+	Conflicts []storage.SyncConflict `json:"conflicts,omitempty"` // push: concurrent and refused changes
+	Error     string                 `json:"error,omitempty"`
+}
+
+// EnableDataSync registers the data sync handler, so members of the
+// template group can replicate the tables shared with it. Needs
+// EnableData and EnableDocs, for the database and the group checker.
+// The gate turns away everyone else. onConflict is called with the
+// conflicts of every push.
+func (n *Node) EnableDataSync(onConflict func([]storage.SyncConflict)) {
+	n.syncConflicts = onConflict
+	n.gate.Require(proto.DataSyncProtoID, func(peerID string) bool {
+		return n.groupChecker != nil && n.groupChecker.TemplateMemberRole(peerID) != ""
+	})
+	n.Host.SetStreamHandler(protocol.ID(proto.DataSyncProtoID), n.handleDataSyncStream)
+}
+
+func (n *Node) handleDataSyncStream(s network.Stream) {
+	defer s.Close()
+
+	reply := func(resp DataSyncResponse) {
+		_ = json.NewEncoder(s).Encode(resp)
+	}
+
+	_ = s.SetReadDeadline(time.Now().Add(DataSyncRequestTimeout))
+	line, err := bufio.NewReader(io.LimitReader(s, DataSyncMaxMessage)).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		reply(DataSyncResponse{Error: "bad request"})
+		return
+	}
+	_ = s.SetReadDeadline(time.Time{})
+
+	var req DataSyncRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		reply(DataSyncResponse{Error: "bad request"})
+		return
+	}
+	callerID := s.Conn().RemotePeer().String()
+	if n.db == nil {
+		reply(DataSyncResponse{Error: "data sync not available"})
+		return
+	}
+
+	var resp DataSyncResponse
+	switch req.Op {
+	case DataSyncPull:
+		resp = n.dataSyncPull(callerID, req.Since)
+		setStreamOutcome(s, fmt.Sprintf("pull:%d", len(resp.Changes)))
+	case DataSyncPush:
+		if len(req.Changes) > DataSyncPageSize {
+			resp = DataSyncResponse{Error: "too many changes"}
+			break
+		}
+		resp = n.dataSyncPush(callerID, req.Changes)
+		setStreamOutcome(s, fmt.Sprintf("push:%d", len(req.Changes)))
+	default:
+		resp = DataSyncResponse{Error: "bad request"}
+	}
+	reply(resp)
+}
+
+// dataSyncReadable returns, per synced table the caller may read, whether
+// it reads every row rather than only its own.
+func (n *Node) dataSyncReadable(callerID string) (map[string]bool, []storage.SyncTableDef, error) {
+	defs, err := n.db.SyncTableDefs()
+	if err != nil {
+		return nil, nil, err
+	}
+	readable := map[string]bool{}
+	var out []storage.SyncTableDef
+	for _, def := range defs {
+		switch n.getAccess(def.Name).Read {
+		case "local":
+			continue
+		case "open":
+			readable[def.Name] = true
+		case "group":
+			if n.checkGroupAccess(callerID, def.Name, "read") != "" {
+				continue
+			}
+			readable[def.Name] = true
+		default:
+			// "owner": the caller's own rows, as a remote query gets them
+			readable[def.Name] = false
+		}
+		out = append(out, def)
+	}
+	return readable, out, nil
+}
+
+func (n *Node) dataSyncPull(callerID string, since int64) DataSyncResponse {
+	readable, defs, err := n.dataSyncReadable(callerID)
+	if err != nil {
+		return DataSyncResponse{Error: err.Error()}
+	}
+	resp := DataSyncResponse{Tables: defs}
+	if since > n.db.SyncSeq() {
+		since, resp.Reset = 0, true
+	}
+	resp.Changes, resp.Next, resp.More, err = n.db.SyncChanges(since, DataSyncPageSize, func(c storage.SyncChange) bool {
+		all, ok := readable[c.Table]
+		if !ok || c.Origin == callerID {
+			return false
+		}
+		return all || c.Deleted || c.Row["_owner"] == callerID
+	})
+	if err != nil {
+		return DataSyncResponse{Error: err.Error()}
+	}
+	return resp
+}
+
+// dataSyncPush applies a member's own changes under the same rules as
+// its remote data operations: the table's policy for the operation, and
+// for updates and deletes that are not open, only rows the member owns.
+func (n *Node) dataSyncPush(callerID string, changes []storage.SyncChange) DataSyncResponse {
+	synced, err := n.db.SyncedTables()
+	if err != nil {
+		return DataSyncResponse{Error: err.Error()}
+	}
+	var (
+		resp     DataSyncResponse
+		accepted []storage.SyncChange
+	)
+	for _, c := range changes {
+		cur, row, found, err := n.db.SyncRowVersion(c.Table, c.SyncID)
+		if err == nil {
+			err = n.dataSyncAllowed(callerID, synced, &c, row)
+		}
+		if err != nil {
+			resp.Conflicts = append(resp.Conflicts, storage.SyncConflict{
+				Table: c.Table, SyncID: c.SyncID, Winner: cur, Loser: c.SyncVersion, Lost: c.Row, Reason: err.Error(),
+			})
+			back := storage.SyncChange{Table: c.Table, SyncID: c.SyncID, SyncVersion: cur, Row: row}
+			if !found {
+				back.SyncVersion = storage.SyncVersion{TS: c.TS, Origin: n.ID(), Deleted: true}
+			}
+			resp.Changes = append(resp.Changes, back)
+			continue
+		}
+		accepted = append(accepted, c)
+	}
+	conflicts, err := n.db.ApplySync(accepted, false)
+	if err != nil {
+		return DataSyncResponse{Error: err.Error()}
+	}
+	resp.Conflicts = append(resp.Conflicts, conflicts...)
+	if len(resp.Conflicts) > 0 && n.syncConflicts != nil {
+		n.syncConflicts(resp.Conflicts)
+	}
+	return resp
+}
+
+// dataSyncAllowed checks one pushed change and pins its owner columns:
+// the caller for a new row, the current owner otherwise.
+func (n *Node) dataSyncAllowed(callerID string, synced []string, c *storage.SyncChange, row map[string]any) error {
+	if c.Origin != callerID {
+		return errors.New("change not made by the caller")
+	}
+	if !slices.Contains(synced, c.Table) {
+		return errors.New("table not shared")
+	}
+	access := n.getAccess(c.Table)
+	op, policy := "insert", access.Insert
+	switch {
+	case row != nil && c.Deleted:
+		op, policy = "delete", access.Delete
+	case row != nil:
+		op, policy = "update", access.Update
+	case c.Deleted:
+		return nil // already gone here
+	}
+	switch policy {
+	case "open":
+	case "group":
+		if msg := n.checkGroupAccess(callerID, c.Table, op); msg != "" {
+			return errors.New(msg)
+		}
+	default:
+		return fmt.Errorf("%s not allowed: %s only", op, policy)
+	}
+	if row != nil && policy != "open" && row["_owner"] != callerID {
+		return fmt.Errorf("%s not allowed: row not owned by caller", op)
+	}
+	if c.Deleted {
+		return nil
+	}
+	c.Row = maps.Clone(c.Row)
+	if row == nil {
+		c.Row["_owner"] = callerID
+		c.Row["_owner_email"] = ""
+		if n.peers != nil {
+			if sp, ok := n.peers.Get(callerID); ok {
+				c.Row["_owner_email"] = sp.Email
+			}
+		}
+	} else {
+		c.Row["_owner"], c.Row["_owner_email"] = row["_owner"], row["_owner_email"]
+	}
+	return nil
+}
+
+// DataSync sends one data sync request to the template group's host.
+func (n *Node) DataSync(ctx context.Context, hostPeerID string, req DataSyncRequest) (DataSyncResponse, error) {
+	var resp DataSyncResponse
+	pid, err := peer.Decode(hostPeerID)
+	if err != nil {
+		return resp, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	st, err := n.Host.NewStream(network.WithAllowLimitedConn(ctx, "relay"), pid, protocol.ID(proto.DataSyncProtoID))
+	if err != nil {
+		return resp, err
+	}
+	defer st.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = st.SetDeadline(dl)
+	}
+	if err := json.NewEncoder(st).Encode(req); err != nil {
+		return resp, err
+	}
+	_ = st.CloseWrite()
+
+	line, err := bufio.NewReader(io.LimitReader(st, DataSyncMaxMessage)).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return resp, err
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return resp, err
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
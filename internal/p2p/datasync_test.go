@@ -0,0 +1,130 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/orm/schema"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// roleChecker gives fixed template group roles.
+type roleChecker map[string]string
+
+func (r roleChecker) IsGroupHost(string) bool            { return true }
+func (r roleChecker) IsPeerInGroup(p, _ string) bool     { return r[p] != "" }
+func (r roleChecker) IsKnownGroupPeer(p, _ string) bool  { return r[p] != "" }
+func (r roleChecker) IsTemplateMember(p string) bool     { return r[p] != "" }
+func (r roleChecker) TemplateMemberRole(p string) string { return r[p] }
+
+func TestDataSync_PullAndPush(t *testing.T) {
+	newNode := func() *Node {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		gate := NewGatekeeper()
+		return &Node{Host: &gatedHost{Host: h, gate: gate}, gate: gate}
+	}
+	host, member, stranger := newNode(), newNode(), newNode()
+	memberID := member.ID()
+
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetSyncOrigin(host.ID())
+	err = db.CreateTableORM(&schema.Table{
+		Name:    "posts",
+		Columns: []schema.Column{{Name: "title", Type: "text"}},
+		Access:  &schema.Access{Read: "group", Insert: "group", Update: "group", Delete: "owner"},
+		Roles:   map[string]schema.RoleAccess{"coauthor": {Read: true, Insert: true, Update: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnableSync("posts"); err != nil {
+		t.Fatal(err)
+	}
+	hostRowID, _ := db.Insert("posts", host.ID(), "", map[string]any{"title": "by host"})
+
+	var published []storage.SyncConflict
+	host.EnableData(db)
+	host.groupChecker = roleChecker{memberID: "coauthor"}
+	host.EnableDataSync(func(c []storage.SyncConflict) { published = append(published, c...) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, n := range []*Node{member, stranger} {
+		if err := n.Host.Connect(ctx, peer.AddrInfo{ID: host.Host.ID(), Addrs: host.Host.Addrs()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := stranger.DataSync(ctx, host.ID(), DataSyncRequest{Op: DataSyncPull}); err == nil {
+		t.Fatal("stranger pull was let through the gate")
+	}
+
+	snap, err := member.DataSync(ctx, host.ID(), DataSyncRequest{Op: DataSyncPull})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Tables) != 1 || snap.Tables[0].Schema == nil || len(snap.Changes) != 1 || snap.Changes[0].Row["title"] != "by host" {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	hostRow := snap.Changes[0]
+
+	now := time.Now().UnixMilli()
+	resp, err := member.DataSync(ctx, host.ID(), DataSyncRequest{Op: DataSyncPush, Changes: []storage.SyncChange{
+		// A new row, claiming another owner.
+		{Table: "posts", SyncID: "aa", SyncVersion: storage.SyncVersion{TS: now, Origin: memberID},
+			Row: map[string]any{"title": "by member", "_owner": host.ID()}},
+		// Deleting the host's row: the coauthor role has no delete.
+		{Table: "posts", SyncID: hostRow.SyncID, SyncVersion: storage.SyncVersion{TS: now + 1, Origin: memberID, Deleted: true},
+			BaseTS: hostRow.TS, BaseOrigin: hostRow.Origin},
+		// A change passed off as the host's.
+		{Table: "posts", SyncID: "bb", SyncVersion: storage.SyncVersion{TS: now, Origin: host.ID()}, Row: map[string]any{"title": "forged"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Conflicts) != 2 || len(resp.Changes) != 2 || len(published) != 2 {
+		t.Fatalf("push answer = %+v, published %d", resp, len(published))
+	}
+	// The refused delete comes back as the host's row, the forged insert
+	// as a tombstone.
+	if back := resp.Changes[0]; back.Deleted || back.Row["title"] != "by host" {
+		t.Fatalf("correction = %+v", back)
+	}
+	if back := resp.Changes[1]; !back.Deleted {
+		t.Fatalf("correction = %+v", back)
+	}
+
+	rows, _ := db.Select("posts", []string{"_id", "title", "_owner"}, "")
+	if len(rows) != 2 {
+		t.Fatalf("host rows = %v", rows)
+	}
+	for _, r := range rows {
+		if r["title"] == "by member" && r["_owner"] != memberID {
+			t.Fatalf("pushed row owned by %v", r["_owner"])
+		}
+		if r["title"] == "by host" && r["_id"] != hostRowID {
+			t.Fatalf("host row = %v", r)
+		}
+	}
+
+	// The member's own row is not pulled back.
+	next, err := member.DataSync(ctx, host.ID(), DataSyncRequest{Op: DataSyncPull, Since: snap.Next})
+	if err != nil || len(next.Changes) != 0 {
+		t.Fatalf("pull after push = %+v, %v", next, err)
+	}
+	if reset, _ := member.DataSync(ctx, host.ID(), DataSyncRequest{Op: DataSyncPull, Since: 1 << 40}); !reset.Reset || len(reset.Changes) != 1 {
+		t.Fatalf("pull past the log = %+v", reset)
+	}
+}
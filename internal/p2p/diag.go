@@ -0,0 +1,159 @@
+package p2p
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// diagAccess holds the peer's opt-in for rendezvous diagnostics, the
+// nonces of recently served requests (replay protection), and extra
+// snapshot sections registered by other subsystems.
+type diagAccess struct {
+	mu     sync.Mutex
+	scope  string               // "" = off, proto.DiagScopeConnectivity or proto.DiagScopeFull
+	nonces map[string]time.Time // nonce -> request time
+
+	sections map[string]func() any // extra DiagSnapshot sections by key
+}
+
+// AddDiagSection registers extra data included in DiagSnapshot under name
+// (e.g. MQ metrics, which live outside this package).
+func (n *Node) AddDiagSection(name string, fn func() any) {
+	n.diagAccess.mu.Lock()
+	defer n.diagAccess.mu.Unlock()
+	if n.diagAccess.sections == nil {
+		n.diagAccess.sections = make(map[string]func() any)
+	}
+	n.diagAccess.sections[name] = fn
+}
+
+func (n *Node) diagSections() map[string]func() any {
+	n.diagAccess.mu.Lock()
+	defer n.diagAccess.mu.Unlock()
+	out := make(map[string]func() any, len(n.diagAccess.sections))
+	for k, fn := range n.diagAccess.sections {
+		out[k] = fn
+	}
+	return out
+}
+
+// SetDiagAccess sets how much the rendezvous admin may read over /goop/diag.
+// "" or "off" disables remote diagnostics (the default).
+func (n *Node) SetDiagAccess(scope string) {
+	switch scope {
+	case proto.DiagScopeConnectivity, proto.DiagScopeFull:
+	default:
+		scope = ""
+	}
+	n.diagAccess.mu.Lock()
+	n.diagAccess.scope = scope
+	n.diagAccess.mu.Unlock()
+}
+
+// DiagAccess returns the current remote diagnostics scope ("" when off).
+func (n *Node) DiagAccess() string {
+	n.diagAccess.mu.Lock()
+	defer n.diagAccess.mu.Unlock()
+	return n.diagAccess.scope
+}
+
+// handleDiagStream serves a diagnostic snapshot to the rendezvous, but only
+// over the relay connection, with a valid signed request, and within the
+// scope the user granted. Every attempt lands in the audit log.
+func (n *Node) handleDiagStream(s network.Stream) {
+	defer s.Close()
+
+	scope, err := n.authorizeDiag(s)
+	if err != nil {
+		log.Printf("DIAG: refused request from %s: %v", shortPeer(s.Conn().RemotePeer().String()), err)
+		setStreamOutcome(s, "refused")
+		_ = json.NewEncoder(s).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	snap := n.DiagSnapshot()
+	if scope != proto.DiagScopeFull {
+		delete(snap, "logs")
+		delete(snap, "hostname")
+	}
+	snap["scope"] = scope
+	setStreamOutcome(s, "diag:"+scope)
+	_ = json.NewEncoder(s).Encode(snap)
+}
+
+// isRelayPeer reports whether peerID is our rendezvous relay, the only peer
+// the gate lets ask for diagnostics.
+func (n *Node) isRelayPeer(peerID string) bool {
+	return n.relayPeer != nil && n.relayPeer.ID.String() == peerID
+}
+
+// authorizeDiag reads and verifies the request line and returns the scope to serve.
+func (n *Node) authorizeDiag(s network.Stream) (string, error) {
+	granted := n.DiagAccess()
+	if granted == "" {
+		return "", errors.New("remote diagnostics disabled by peer")
+	}
+	_ = s.SetReadDeadline(time.Now().Add(DiagRequestTimeout))
+	line, err := bufio.NewReader(s).ReadBytes('\n')
+	if err != nil {
+		return "", errors.New("missing signed request")
+	}
+	_ = s.SetReadDeadline(time.Time{})
+
+	var req proto.DiagRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return "", errors.New("bad request")
+	}
+	if req.Peer != n.Host.ID().String() {
+		return "", errors.New("request addressed to another peer")
+	}
+	now := time.Now()
+	if d := now.Sub(time.UnixMilli(req.TS)); d > DiagRequestMaxSkew || d < -DiagRequestMaxSkew {
+		return "", errors.New("request expired")
+	}
+	pub, err := s.Conn().RemotePeer().ExtractPublicKey()
+	if err != nil {
+		return "", errors.New("cannot verify relay key")
+	}
+	if ok, err := pub.Verify(req.SigningBytes(), req.Sig); err != nil || !ok {
+		return "", errors.New("invalid signature")
+	}
+	if !n.diagAccess.claimNonce(req.Nonce, now) {
+		return "", errors.New("replayed request")
+	}
+
+	if req.Scope == proto.DiagScopeFull && granted == proto.DiagScopeFull {
+		return proto.DiagScopeFull, nil
+	}
+	return proto.DiagScopeConnectivity, nil
+}
+
+// claimNonce records a nonce and reports false if it was already used.
+func (d *diagAccess) claimNonce(nonce string, now time.Time) bool {
+	if nonce == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.nonces == nil {
+		d.nonces = make(map[string]time.Time)
+	}
+	for k, t := range d.nonces {
+		if now.Sub(t) > 2*DiagRequestMaxSkew {
+			delete(d.nonces, k)
+		}
+	}
+	if _, seen := d.nonces[nonce]; seen {
+		return false
+	}
+	d.nonces[nonce] = now
+	return true
+}
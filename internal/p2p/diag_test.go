@@ -0,0 +1,120 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func newDiagPair(t *testing.T) (*Node, host.Host) {
+	t.Helper()
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	relayH, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { relayH.Close() })
+
+	gate := NewGatekeeper()
+	n := &Node{Host: &gatedHost{Host: h, gate: gate}, gate: gate, relayPeer: &peer.AddrInfo{ID: relayH.ID(), Addrs: relayH.Addrs()}, startTime: time.Now()}
+	n.diagLogs = []string{"secret log line"}
+	gate.Require(proto.DiagProtoID, n.isRelayPeer)
+	n.Host.SetStreamHandler(protocol.ID(proto.DiagProtoID), n.handleDiagStream)
+
+	if err := relayH.Connect(context.Background(), peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	return n, relayH
+}
+
+func queryDiag(t *testing.T, from host.Host, to peer.ID, req proto.DiagRequest, sign bool) map[string]any {
+	t.Helper()
+	if sign {
+		sig, err := from.Peerstore().PrivKey(from.ID()).Sign(req.SigningBytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Sig = sig
+	}
+	s, err := from.NewStream(context.Background(), to, protocol.ID(proto.DiagProtoID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_ = json.NewEncoder(s).Encode(req)
+	var out map[string]any
+	if err := json.NewDecoder(s).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDiag_OptInAndScope(t *testing.T) {
+	n, relayH := newDiagPair(t)
+	req := func(nonce, scope string) proto.DiagRequest {
+		return proto.DiagRequest{Peer: n.Host.ID().String(), Scope: scope, TS: time.Now().UnixMilli(), Nonce: nonce}
+	}
+
+	// Off by default.
+	if out := queryDiag(t, relayH, n.Host.ID(), req("n1", proto.DiagScopeFull), true); out["error"] == nil {
+		t.Fatalf("expected refusal while disabled, got %v", out)
+	}
+
+	// Connectivity grant: full request is downgraded and logs are withheld.
+	n.SetDiagAccess(proto.DiagScopeConnectivity)
+	out := queryDiag(t, relayH, n.Host.ID(), req("n2", proto.DiagScopeFull), true)
+	if out["scope"] != proto.DiagScopeConnectivity || out["logs"] != nil {
+		t.Fatalf("expected connectivity-only snapshot, got scope=%v logs=%v", out["scope"], out["logs"])
+	}
+
+	// Full grant includes logs.
+	n.SetDiagAccess(proto.DiagScopeFull)
+	out = queryDiag(t, relayH, n.Host.ID(), req("n3", proto.DiagScopeFull), true)
+	if out["scope"] != proto.DiagScopeFull || out["logs"] == nil {
+		t.Fatalf("expected full snapshot, got %v", out)
+	}
+
+	// Replayed nonce and unsigned requests are refused.
+	if out := queryDiag(t, relayH, n.Host.ID(), req("n3", proto.DiagScopeFull), true); out["error"] == nil {
+		t.Fatal("expected replayed request refused")
+	}
+	if out := queryDiag(t, relayH, n.Host.ID(), req("n4", proto.DiagScopeFull), false); out["error"] == nil {
+		t.Fatal("expected unsigned request refused")
+	}
+}
+
+func TestDiag_RefusesNonRelayPeer(t *testing.T) {
+	n, _ := newDiagPair(t)
+	n.SetDiagAccess(proto.DiagScopeFull)
+
+	other, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := other.Connect(context.Background(), peer.AddrInfo{ID: n.Host.ID(), Addrs: n.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := other.NewStream(context.Background(), n.Host.ID(), protocol.ID(proto.DiagProtoID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_ = json.NewEncoder(s).Encode(proto.DiagRequest{Peer: n.Host.ID().String(), Scope: proto.DiagScopeFull, TS: time.Now().UnixMilli(), Nonce: "x"})
+	var out map[string]any
+	if err := json.NewDecoder(s).Decode(&out); err == nil {
+		t.Fatalf("non-relay peer got past the gate: %v", out)
+	}
+}
@@ -31,10 +31,19 @@ type DocStore interface {
 	Read(groupID, filename string) ([]byte, string, error)
 }
 
+// DocACLStore is a DocStore with per-file access control. With one, a
+// peer outside the group can list and fetch the files shared with it.
+type DocACLStore interface {
+	DocStore
+	CanRead(groupID, filename, peerID string, member bool) bool
+	ListJSONFor(groupID, peerID string, member bool) ([]byte, error)
+}
+
 // GroupChecker is the interface used to verify group membership.
 type GroupChecker interface {
 	IsGroupHost(groupID string) bool
 	IsPeerInGroup(peerID, groupID string) bool
+	IsKnownGroupPeer(peerID, groupID string) bool
 	IsTemplateMember(peerID string) bool
 	TemplateMemberRole(peerID string) string
 }
@@ -44,6 +53,9 @@ func (n *Node) EnableDocs(store DocStore, gc GroupChecker) {
 	n.docsStore = store
 	n.groupChecker = gc
 	n.Host.SetStreamHandler(protocol.ID(proto.DocsProtoID), n.handleDocsStream)
+	if _, ok := store.(DocRangeStore); ok {
+		n.Host.SetStreamHandler(protocol.ID(proto.DocsChunkProtoID), n.handleDocsChunkStream)
+	}
 }
 
 // docsRequest is the wire format for incoming doc requests.
@@ -70,12 +82,29 @@ func (n *Node) handleDocsStream(s network.Stream) {
 
 	remotePeer := s.Conn().RemotePeer().String()
 
-	// Read request — may be plaintext JSON or encrypted ENC: line
+	var req docsRequest
+	if !n.readDocsRequest(s, remotePeer, &req) || !n.docsAccessAllowed(s, remotePeer, req.GroupID, req.File) {
+		return
+	}
+
+	switch req.Op {
+	case "list":
+		n.handleDocsList(s, remotePeer, req)
+	case "get":
+		n.handleDocsGet(s, remotePeer, req)
+	default:
+		writeDocsError(s, "unknown op: "+req.Op)
+	}
+}
+
+// readDocsRequest reads the request line, plaintext JSON or an encrypted
+// ENC: line, into req. On failure it answers with an error and returns false.
+func (n *Node) readDocsRequest(s network.Stream, remotePeer string, req any) bool {
 	rd := bufio.NewReader(s)
 	line, err := rd.ReadBytes('\n')
 	if err != nil && err != io.EOF {
 		writeDocsError(s, "bad request")
-		return
+		return false
 	}
 
 	jsonLine := line
@@ -84,44 +113,58 @@ func (n *Node) handleDocsStream(s network.Stream) {
 		plaintext, err := n.enc.Open(remotePeer, trimmed)
 		if err != nil {
 			writeDocsError(s, "decrypt error")
-			return
+			return false
 		}
 		jsonLine = plaintext
 	}
 
-	var req docsRequest
-	if err := json.Unmarshal(jsonLine, &req); err != nil {
+	if err := json.Unmarshal(jsonLine, req); err != nil {
 		writeDocsError(s, "bad request")
-		return
+		return false
 	}
+	return true
+}
 
-	if req.GroupID == "" {
+// docsMember reports whether remotePeer is a member of groupID, as far as
+// this peer knows: the host checks its member list, other members the
+// list the host sent them. Without a group checker everybody is.
+func (n *Node) docsMember(remotePeer, groupID string) bool {
+	return n.groupChecker == nil || n.groupChecker.IsKnownGroupPeer(remotePeer, groupID)
+}
+
+// docsAccessAllowed checks a request for file in groupID, or for the
+// group's list when file is empty. With per-file ACLs a list request is
+// always let through and filtered; otherwise only members get in.
+func (n *Node) docsAccessAllowed(s network.Stream, remotePeer, groupID, file string) bool {
+	if groupID == "" {
 		writeDocsError(s, "missing group_id")
-		return
+		return false
 	}
-
-	// Access control: only the host has an authoritative member list.
-	// Non-host peers cannot verify membership so they serve openly.
-	if n.groupChecker != nil && n.groupChecker.IsGroupHost(req.GroupID) {
-		if !n.groupChecker.IsPeerInGroup(remotePeer, req.GroupID) {
-			log.Printf("DOCS: Access denied for %s on group %s", remotePeer, req.GroupID)
-			writeDocsError(s, "access denied: not a group member")
-			return
+	member := n.docsMember(remotePeer, groupID)
+	if acl, ok := n.docsStore.(DocACLStore); ok {
+		if file == "" || acl.CanRead(groupID, file, remotePeer, member) {
+			return true
 		}
+		log.Printf("DOCS: Access denied for %s on %s in group %s", remotePeer, file, groupID)
+		writeDocsError(s, "access denied")
+		return false
 	}
-
-	switch req.Op {
-	case "list":
-		n.handleDocsList(s, remotePeer, req)
-	case "get":
-		n.handleDocsGet(s, remotePeer, req)
-	default:
-		writeDocsError(s, "unknown op: "+req.Op)
+	if !member {
+		log.Printf("DOCS: Access denied for %s on group %s", remotePeer, groupID)
+		writeDocsError(s, "access denied: not a group member")
+		return false
 	}
+	return true
 }
 
 func (n *Node) handleDocsList(s network.Stream, remotePeer string, req docsRequest) {
-	filesJSON, err := n.docsStore.ListJSON(req.GroupID)
+	var filesJSON []byte
+	var err error
+	if acl, ok := n.docsStore.(DocACLStore); ok {
+		filesJSON, err = acl.ListJSONFor(req.GroupID, remotePeer, n.docsMember(remotePeer, req.GroupID))
+	} else {
+		filesJSON, err = n.docsStore.ListJSON(req.GroupID)
+	}
 	if err != nil {
 		writeDocsError(s, "list failed: "+err.Error())
 		return
@@ -129,14 +172,16 @@ func (n *Node) handleDocsList(s network.Stream, remotePeer string, req docsReque
 
 	resp := docsListResponse{OK: true, Files: json.RawMessage(filesJSON)}
 	b, _ := json.Marshal(resp)
+	out, done := n.serving.begin(s, remotePeer)
+	defer done()
 	if n.enc != nil {
 		if sealed, err := n.enc.Seal(remotePeer, b); err == nil {
-			s.Write([]byte("ENC:" + sealed + "\n"))
+			out.Write([]byte("ENC:" + sealed + "\n"))
 			return
 		}
 	}
 	b = append(b, '\n')
-	s.Write(b)
+	out.Write(b)
 }
 
 func (n *Node) handleDocsGet(s network.Stream, remotePeer string, req docsRequest) {
@@ -162,17 +207,19 @@ func (n *Node) handleDocsGet(s network.Stream, remotePeer string, req docsReques
 	}
 
 	// Encrypt binary response if possible
+	out, done := n.serving.begin(s, remotePeer)
+	defer done()
 	if n.enc != nil {
 		if sealed, err := n.enc.Seal(remotePeer, data); err == nil {
 			sealedBytes := []byte(sealed)
 			fmt.Fprintf(s, "EOK %s %d\n", mt, len(sealedBytes))
-			s.Write(sealedBytes)
+			out.Write(sealedBytes)
 			return
 		}
 	}
 
 	fmt.Fprintf(s, "OK %s %d\n", mt, len(data))
-	s.Write(data)
+	out.Write(data)
 }
 
 func writeDocsError(s network.Stream, msg string) {
@@ -0,0 +1,442 @@
+// Chunked, resumable variant of the docs protocol (/goop/docs/2.0.0).
+// A client asks for a file's size and content hash ("stat"), then fetches
+// it in ranges ("range"), each on its own stream, so a dropped relay
+// connection only costs the chunk in flight. Downloads go to a partial file
+// named by the content hash, which a later attempt picks up where it left
+// off; the finished file is verified against the hash.
+
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/timeouts"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	docChunkSize     = 1 << 20 // bytes asked for per range request
+	docChunkMax      = 4 << 20 // largest range a server sends
+	docChunkAttempts = 5       // tries per range before a download gives up
+)
+
+var (
+	// ErrDocsChunkUnsupported means the peer only speaks /goop/docs/1.0.0.
+	ErrDocsChunkUnsupported = errors.New("peer does not support chunked docs transfers")
+	// ErrDocHashMismatch means a finished download does not match the hash
+	// the peer announced, usually because the file changed meanwhile.
+	ErrDocHashMismatch = errors.New("downloaded file does not match its hash")
+	// errDocsRemote wraps errors reported by the serving peer; they are
+	// not retried.
+	errDocsRemote = errors.New("remote error")
+)
+
+// docPartLocks keeps two downloads of the same content from writing one
+// partial file at the same time; keyed by the final path.
+var docPartLocks sync.Map
+
+// DocRangeStore is a DocStore that can serve byte ranges, which the chunked
+// protocol needs. EnableDocs registers /goop/docs/2.0.0 only for one.
+type DocRangeStore interface {
+	DocStore
+	Stat(groupID, filename string) (size int64, hash string, err error)
+	ReadRange(groupID, filename string, off int64, n int) ([]byte, error)
+}
+
+// DocStat describes a remote file: its size, "sha256:<hex>" hash and MIME type.
+type DocStat struct {
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+	Mime string `json:"mime"`
+}
+
+type docsChunkRequest struct {
+	Op      string `json:"op"` // "stat" or "range"
+	GroupID string `json:"group_id"`
+	File    string `json:"file"`
+	Offset  int64  `json:"offset,omitempty"`
+	Length  int    `json:"length,omitempty"`
+}
+
+type docsStatResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	DocStat
+}
+
+func (n *Node) handleDocsChunkStream(s network.Stream) {
+	defer s.Close()
+
+	store, ok := n.docsStore.(DocRangeStore)
+	if !ok {
+		writeDocsError(s, "docs not enabled")
+		return
+	}
+	remotePeer := s.Conn().RemotePeer().String()
+
+	var req docsChunkRequest
+	if !n.readDocsRequest(s, remotePeer, &req) {
+		return
+	}
+	if req.File == "" {
+		writeDocsError(s, "missing file")
+		return
+	}
+	if !n.docsAccessAllowed(s, remotePeer, req.GroupID, req.File) {
+		return
+	}
+
+	switch req.Op {
+	case "stat":
+		size, hash, err := store.Stat(req.GroupID, req.File)
+		if err != nil {
+			writeDocsError(s, "not found")
+			return
+		}
+		if size > docMaxFileSize {
+			writeDocsError(s, "file too large")
+			return
+		}
+		mt := mime.TypeByExtension(filepath.Ext(req.File))
+		if mt == "" {
+			head, _ := store.ReadRange(req.GroupID, req.File, 0, 512)
+			mt = http.DetectContentType(head)
+		}
+		b, _ := json.Marshal(docsStatResponse{OK: true, DocStat: DocStat{Size: size, Hash: hash, Mime: mt}})
+		out, done := n.serving.begin(s, remotePeer)
+		defer done()
+		n.writeDocsLine(out, remotePeer, b)
+	case "range":
+		if req.Offset < 0 || req.Length <= 0 || req.Length > docChunkMax {
+			writeDocsError(s, "bad range")
+			return
+		}
+		data, err := store.ReadRange(req.GroupID, req.File, req.Offset, req.Length)
+		if err != nil {
+			writeDocsError(s, "not found")
+			return
+		}
+		out, done := n.serving.begin(s, remotePeer)
+		defer done()
+		if n.enc != nil {
+			if sealed, err := n.enc.Seal(remotePeer, data); err == nil {
+				fmt.Fprintf(s, "EOK %d\n", len(sealed))
+				io.WriteString(out, sealed)
+				return
+			}
+		}
+		fmt.Fprintf(s, "OK %d\n", len(data))
+		out.Write(data)
+	default:
+		writeDocsError(s, "unknown op: "+req.Op)
+	}
+}
+
+// writeDocsLine writes a JSON line, sealed as an ENC: line when possible.
+func (n *Node) writeDocsLine(w io.Writer, remotePeer string, b []byte) {
+	if n.enc != nil {
+		if sealed, err := n.enc.Seal(remotePeer, b); err == nil {
+			io.WriteString(w, "ENC:"+sealed+"\n")
+			return
+		}
+	}
+	w.Write(append(b, '\n'))
+}
+
+// docsChunkCall opens a /goop/docs/2.0.0 stream to peerID and sends req.
+func (n *Node) docsChunkCall(ctx context.Context, peerID string, req docsChunkRequest) (network.Stream, *bufio.Reader, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	_ = n.Host.Connect(ctx, peer.AddrInfo{ID: pid})
+
+	st, err := n.Host.NewStream(network.WithAllowLimitedConn(ctx, "relay"), pid, protocol.ID(proto.DocsChunkProtoID))
+	if err != nil {
+		// Once identify has run, a peer without 2.0.0 is an older client.
+		if protos, _ := n.Host.Peerstore().GetProtocols(pid); len(protos) > 0 && !slices.Contains(protos, protocol.ID(proto.DocsChunkProtoID)) {
+			return nil, nil, ErrDocsChunkUnsupported
+		}
+		return nil, nil, fmt.Errorf("failed to open docs stream: %w", err)
+	}
+
+	reqJSON, _ := json.Marshal(req)
+	if n.enc != nil {
+		if sealed, err := n.enc.Seal(peerID, reqJSON); err == nil {
+			reqJSON = []byte("ENC:" + sealed)
+		}
+	}
+	if _, err := st.Write(append(reqJSON, '\n')); err != nil {
+		st.Reset()
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if closer, ok := st.(interface{ CloseWrite() error }); ok {
+		closer.CloseWrite()
+	}
+	return st, bufio.NewReader(st), nil
+}
+
+// StatDocFile asks a peer for the size, hash and MIME type of a file.
+func (n *Node) StatDocFile(ctx context.Context, peerID, groupID, filename string) (DocStat, error) {
+	st, rd, err := n.docsChunkCall(ctx, peerID, docsChunkRequest{Op: "stat", GroupID: groupID, File: filename})
+	if err != nil {
+		return DocStat{}, err
+	}
+	defer st.Close()
+
+	line, err := rd.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return DocStat{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if n.enc != nil && len(line) > 4 && string(line[:4]) == "ENC:" {
+		plaintext, err := n.enc.Open(peerID, strings.TrimSpace(string(line[4:])))
+		if err != nil {
+			return DocStat{}, fmt.Errorf("decrypt response: %w", err)
+		}
+		line = plaintext
+	}
+	var resp docsStatResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return DocStat{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !resp.OK {
+		return DocStat{}, fmt.Errorf("%w: %s", errDocsRemote, resp.Error)
+	}
+	return resp.DocStat, nil
+}
+
+// FetchDocRange downloads length bytes of a file from offset. Near the end
+// of the file fewer bytes come back.
+func (n *Node) FetchDocRange(ctx context.Context, peerID, groupID, filename string, offset int64, length int) ([]byte, error) {
+	st, rd, err := n.docsChunkCall(ctx, peerID, docsChunkRequest{Op: "range", GroupID: groupID, File: filename, Offset: offset, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	if d, ok := ctx.Deadline(); ok {
+		st.SetReadDeadline(d)
+	}
+
+	h, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if strings.HasPrefix(h, "{") {
+		var resp docsListResponse
+		if err := json.Unmarshal([]byte(h), &resp); err != nil {
+			return nil, fmt.Errorf("bad response: %q", h)
+		}
+		return nil, fmt.Errorf("%w: %s", errDocsRemote, resp.Error)
+	}
+
+	sealed, sizeStr, ok := strings.Cut(strings.TrimSpace(h), " ")
+	if !ok || (sealed != "OK" && sealed != "EOK") {
+		return nil, fmt.Errorf("bad response: %q", h)
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size < 0 || size > docChunkMax*2 {
+		return nil, fmt.Errorf("bad size %q", sizeStr)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(rd, data); err != nil {
+		return nil, fmt.Errorf("read range: %w", err)
+	}
+	if sealed == "OK" {
+		return data, nil
+	}
+	if n.enc == nil {
+		return nil, fmt.Errorf("encrypted response but no decryptor")
+	}
+	return n.enc.Open(peerID, string(data))
+}
+
+// fetchDocRangeRetry is FetchDocRange with a timeout per attempt and a few
+// retries, each on a fresh stream.
+func (n *Node) fetchDocRangeRetry(ctx context.Context, peerID, groupID, filename string, offset int64, length int) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= docChunkAttempts; attempt++ {
+		actx, cancel := context.WithTimeout(ctx, timeouts.Get().DocsFetch)
+		data, err := n.FetchDocRange(actx, peerID, groupID, filename, offset, length)
+		cancel()
+		if err == nil {
+			if len(data) == 0 {
+				return nil, fmt.Errorf("empty range at %d", offset)
+			}
+			return data, nil
+		}
+		if errors.Is(err, errDocsRemote) || errors.Is(err, ErrDocsChunkUnsupported) {
+			return nil, err
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(DocChunkRetryBackoff * time.Duration(attempt)):
+		}
+	}
+	return nil, lastErr
+}
+
+// DownloadDocFile fetches a file from a peer into dir over the chunked
+// protocol and returns the path of the verified copy, named by its hash.
+// A partial download of the same content left in dir by an earlier attempt
+// is resumed. progress, when set, is called with the bytes on disk after
+// each chunk.
+func (n *Node) DownloadDocFile(ctx context.Context, peerID, groupID, filename, dir string, progress func(done, total int64)) (string, DocStat, error) {
+	if progress == nil {
+		progress = func(int64, int64) {}
+	}
+	sctx, cancel := context.WithTimeout(ctx, timeouts.Get().DocsFetch)
+	stat, err := n.StatDocFile(sctx, peerID, groupID, filename)
+	cancel()
+	if err != nil {
+		return "", stat, err
+	}
+	hexHash, ok := strings.CutPrefix(stat.Hash, "sha256:")
+	if _, err := hex.DecodeString(hexHash); !ok || err != nil || len(hexHash) != 2*sha256.Size {
+		return "", stat, fmt.Errorf("bad hash %q", stat.Hash)
+	}
+	if stat.Size < 0 || stat.Size > docMaxFileSize {
+		return "", stat, fmt.Errorf("refusing size %d", stat.Size)
+	}
+
+	final := filepath.Join(dir, hexHash)
+	lock, _ := docPartLocks.LoadOrStore(final, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if fi, err := os.Stat(final); err == nil && fi.Size() == stat.Size {
+		progress(stat.Size, stat.Size)
+		return final, stat, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", stat, err
+	}
+	part := final + ".part"
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return "", stat, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", stat, err
+	}
+	done := fi.Size()
+	if done > stat.Size {
+		if err := f.Truncate(0); err != nil {
+			return "", stat, err
+		}
+		done = 0
+	}
+	progress(done, stat.Size)
+
+	for done < stat.Size {
+		length := int(min(docChunkSize, stat.Size-done))
+		data, err := n.fetchDocRangeRetry(ctx, peerID, groupID, filename, done, length)
+		if err != nil {
+			return "", stat, err // the partial file stays for the next attempt
+		}
+		if _, err := f.WriteAt(data, done); err != nil {
+			return "", stat, err
+		}
+		done += int64(len(data))
+		progress(done, stat.Size)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", stat, err
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(sum, io.LimitReader(f, stat.Size)); err != nil {
+		return "", stat, err
+	}
+	f.Close()
+	if hex.EncodeToString(sum.Sum(nil)) != hexHash {
+		os.Remove(part)
+		return "", stat, ErrDocHashMismatch
+	}
+	if err := os.Rename(part, final); err != nil {
+		return "", stat, err
+	}
+	return final, stat, nil
+}
+
+// RepairDocCache cleans a DownloadDocFile directory: it removes partial
+// files whose download has finished or that are older than maxAge and, with
+// verify, finished files whose content no longer matches their name. It
+// returns how many files it removed. It must not run alongside downloads
+// into dir.
+func RepairDocCache(dir string, maxAge time.Duration, verify bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		name := e.Name()
+		path := filepath.Join(dir, name)
+		stale := false
+		if final, ok := strings.CutSuffix(name, ".part"); ok {
+			_, err := os.Stat(filepath.Join(dir, final))
+			info, ierr := e.Info()
+			stale = err == nil || (ierr == nil && info.ModTime().Before(cutoff))
+		} else if verify {
+			stale = !docFileMatches(path, name)
+		}
+		if !stale {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// docFileMatches reports whether the file at path hashes to hexHash.
+// Files that are not named by a hash are left alone.
+func docFileMatches(path, hexHash string) bool {
+	if _, err := hex.DecodeString(hexHash); err != nil || len(hexHash) != 2*sha256.Size {
+		return true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(sum.Sum(nil)) == hexHash
+}
@@ -0,0 +1,179 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type memDocStore struct {
+	files map[string][]byte
+	reads int
+}
+
+func (m *memDocStore) ListJSON(string) ([]byte, error) { return []byte("[]"), nil }
+
+func (m *memDocStore) Read(_, name string) ([]byte, string, error) {
+	data, ok := m.files[name]
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+	return data, "", nil
+}
+
+func (m *memDocStore) Stat(_, name string) (int64, string, error) {
+	data, ok := m.files[name]
+	if !ok {
+		return 0, "", errors.New("not found")
+	}
+	sum := sha256.Sum256(data)
+	return int64(len(data)), "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (m *memDocStore) ReadRange(_, name string, off int64, n int) ([]byte, error) {
+	data, ok := m.files[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	m.reads++
+	off = min(off, int64(len(data)))
+	return data[off:min(off+int64(n), int64(len(data)))], nil
+}
+
+// docsPair returns a client node connected to a server node serving store.
+func docsPair(t *testing.T, store DocStore) (server, client *Node) {
+	t.Helper()
+	newNode := func() *Node {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return &Node{Host: h}
+	}
+	server, client = newNode(), newNode()
+	server.EnableDocs(store, nil)
+	if err := client.Host.Connect(context.Background(), peer.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+func TestDownloadDocFile_resumes(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), (docChunkSize*5/2)/16)
+	store := &memDocStore{files: map[string][]byte{"big.bin": data}}
+	server, client := docsPair(t, store)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+
+	// An earlier attempt got the first chunk before the connection dropped.
+	sum := sha256.Sum256(data)
+	part := filepath.Join(dir, hex.EncodeToString(sum[:])+".part")
+	if err := os.WriteFile(part, data[:docChunkSize], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []int64
+	path, stat, err := client.DownloadDocFile(ctx, server.Host.ID().String(), "g1", "big.bin", dir, func(done, total int64) {
+		calls = append(calls, done)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Fatal("downloaded content differs")
+	}
+	if stat.Size != int64(len(data)) || stat.Mime == "" {
+		t.Fatalf("stat = %+v", stat)
+	}
+	if store.reads != 2 {
+		t.Errorf("range reads = %d, want 2 (first chunk resumed)", store.reads)
+	}
+	if len(calls) != 3 || calls[0] != docChunkSize || calls[2] != int64(len(data)) {
+		t.Errorf("progress = %v", calls)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Error("partial file left behind")
+	}
+
+	// A finished copy is reused without another transfer.
+	if _, _, err := client.DownloadDocFile(ctx, server.Host.ID().String(), "g1", "big.bin", dir, nil); err != nil || store.reads != 2 {
+		t.Fatalf("second download: %v, reads %d", err, store.reads)
+	}
+}
+
+func TestDownloadDocFile_errors(t *testing.T) {
+	server, client := docsPair(t, &memDocStore{files: map[string][]byte{}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, _, err := client.DownloadDocFile(ctx, server.Host.ID().String(), "g1", "missing.txt", t.TempDir(), nil); !errors.Is(err, errDocsRemote) {
+		t.Fatalf("missing file: %v", err)
+	}
+}
+
+type listOnlyStore struct{}
+
+func (listOnlyStore) ListJSON(string) ([]byte, error) { return []byte("[]"), nil }
+func (listOnlyStore) Read(string, string) ([]byte, string, error) {
+	return nil, "", errors.New("not found")
+}
+
+func TestDownloadDocFile_oldPeer(t *testing.T) {
+	server, client := docsPair(t, listOnlyStore{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, _, err := client.DownloadDocFile(ctx, server.Host.ID().String(), "g1", "a.txt", t.TempDir(), nil); !errors.Is(err, ErrDocsChunkUnsupported) {
+		t.Fatalf("old peer: %v", err)
+	}
+}
+
+func TestRepairDocCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	hashOf := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	good, bad, partial := hashOf("good"), hashOf("bad"), hashOf("partial")
+	write(good, "good")
+	write(good+".part", "go") // finished meanwhile
+	write(bad, "corrupted")
+	write(partial+".part", "par")
+	old := write(hashOf("old")+".part", "o")
+	past := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(old, past, past)
+	write("notes.txt", "not ours")
+
+	n, err := RepairDocCache(dir, 24*time.Hour, false)
+	if err != nil || n != 2 {
+		t.Fatalf("repair = %d, %v; want 2 partial files removed", n, err)
+	}
+	n, err = RepairDocCache(dir, 24*time.Hour, true)
+	if err != nil || n != 1 {
+		t.Fatalf("verify = %d, %v; want the corrupted file removed", n, err)
+	}
+	for _, name := range []string{good, partial + ".part", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if n, err := RepairDocCache(filepath.Join(dir, "missing"), time.Hour, true); n != 0 || err != nil {
+		t.Errorf("missing dir = %d, %v", n, err)
+	}
+}
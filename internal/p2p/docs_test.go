@@ -0,0 +1,69 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// aclDocStore shares the files in public with everybody, the rest with
+// members only.
+type aclDocStore struct {
+	memDocStore
+	public map[string]bool
+}
+
+func (a *aclDocStore) CanRead(_, name, _ string, member bool) bool {
+	return member || a.public[name]
+}
+
+func (a *aclDocStore) ListJSONFor(_, _ string, member bool) ([]byte, error) {
+	var names []string
+	for name := range a.files {
+		if a.CanRead("", name, "", member) {
+			names = append(names, name)
+		}
+	}
+	return json.Marshal(names)
+}
+
+func TestDocsAccess(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Without ACLs a stranger gets nothing.
+	server, client := docsPair(t, &memDocStore{files: map[string][]byte{"a.txt": []byte("a")}})
+	server.groupChecker = roleChecker{}
+	if _, err := client.FetchDocList(ctx, server.ID(), "g1"); err == nil || !strings.Contains(err.Error(), "not a group member") {
+		t.Errorf("list by a stranger: %v", err)
+	}
+
+	// With ACLs it sees and fetches the public files only.
+	store := &aclDocStore{
+		memDocStore: memDocStore{files: map[string][]byte{"open.txt": []byte("open"), "members.txt": []byte("members")}},
+		public:      map[string]bool{"open.txt": true},
+	}
+	server, client = docsPair(t, store)
+	server.groupChecker = roleChecker{}
+	list, err := client.FetchDocList(ctx, server.ID(), "g1")
+	if err != nil || string(list) != `["open.txt"]` {
+		t.Errorf("list = %s, %v; want only open.txt", list, err)
+	}
+	if _, data, err := client.FetchDocFile(ctx, server.ID(), "g1", "open.txt"); err != nil || string(data) != "open" {
+		t.Errorf("public file = %q, %v", data, err)
+	}
+	if _, _, err := client.FetchDocFile(ctx, server.ID(), "g1", "members.txt"); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("members-only file: %v", err)
+	}
+	if _, err := client.StatDocFile(ctx, server.ID(), "g1", "members.txt"); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("chunked stat of a members-only file: %v", err)
+	}
+
+	// Members get everything.
+	server.groupChecker = roleChecker{client.ID(): "viewer"}
+	if _, data, err := client.FetchDocFile(ctx, server.ID(), "g1", "members.txt"); err != nil || string(data) != "members" {
+		t.Errorf("member fetch = %q, %v", data, err)
+	}
+}
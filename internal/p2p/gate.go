@@ -0,0 +1,272 @@
+// Per-protocol access policies for inbound libp2p streams.
+// Every SetStreamHandler registration on Node.Host goes through the
+// gatekeeper, so policy is enforced in one place instead of per handler.
+
+package p2p
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Access modes for an AccessPolicy.
+const (
+	AccessAll       = "all"       // any peer (default)
+	AccessFavorites = "favorites" // peers marked as favorite
+	AccessGroup     = "group"     // peers sharing at least one group with us
+	AccessList      = "list"      // only peers in AccessPolicy.Peers
+	AccessConsent   = "consent"   // favorites pass; others are prompted on first access
+)
+
+// AccessPolicy controls which remote peers may open streams on a protocol.
+type AccessPolicy struct {
+	Mode  string   `json:"mode"`
+	Peers []string `json:"peers,omitempty"` // for AccessList
+}
+
+// ValidAccessMode reports whether mode is a known access mode ("" means all).
+func ValidAccessMode(mode string) bool {
+	switch mode {
+	case "", AccessAll, AccessFavorites, AccessGroup, AccessList, AccessConsent:
+		return true
+	}
+	return false
+}
+
+// Gatekeeper evaluates AccessPolicy per protocol ID. Protocols without a
+// policy are open to everyone, apart from what they Require.
+type Gatekeeper struct {
+	mu          sync.RWMutex
+	policies    map[string]AccessPolicy
+	required    map[string]func(peerID string) bool
+	isFavorite  func(peerID string) bool
+	sharesGroup func(peerID string) bool
+	consent     func(protoID, peerID string) bool
+	auditor     func(StreamAudit)
+}
+
+// StreamAudit describes one inbound stream after it finished (or was denied).
+type StreamAudit struct {
+	Protocol string
+	PeerID   string
+	Outcome  string // "allowed", "denied", "limited" (over a stream cap) or set by the handler
+	BytesIn  int64
+	BytesOut int64
+	Start    time.Time
+	Duration time.Duration
+}
+
+// NewGatekeeper creates an empty gatekeeper (all protocols open).
+func NewGatekeeper() *Gatekeeper {
+	return &Gatekeeper{policies: make(map[string]AccessPolicy), required: make(map[string]func(string) bool)}
+}
+
+// Require restricts protoID to the peers check accepts, whatever policy is
+// configured; a policy can only narrow it further. Protocols that only serve
+// certain peers declare that here rather than checking in their handler.
+func (g *Gatekeeper) Require(protoID string, check func(peerID string) bool) {
+	g.mu.Lock()
+	g.required[protoID] = check
+	g.mu.Unlock()
+}
+
+// SetPolicy sets (or with Mode "" / "all", clears) the policy for a protocol ID.
+func (g *Gatekeeper) SetPolicy(protoID string, p AccessPolicy) error {
+	if !ValidAccessMode(p.Mode) {
+		return fmt.Errorf("unknown access mode %q", p.Mode)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p.Mode == "" || p.Mode == AccessAll {
+		delete(g.policies, protoID)
+		return nil
+	}
+	g.policies[protoID] = p
+	return nil
+}
+
+// Policies returns a copy of the configured policies keyed by protocol ID.
+func (g *Gatekeeper) Policies() map[string]AccessPolicy {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make(map[string]AccessPolicy, len(g.policies))
+	for k, v := range g.policies {
+		out[k] = v
+	}
+	return out
+}
+
+// SetFavoriteChecker sets the function used by AccessFavorites.
+func (g *Gatekeeper) SetFavoriteChecker(fn func(peerID string) bool) {
+	g.mu.Lock()
+	g.isFavorite = fn
+	g.mu.Unlock()
+}
+
+// SetGroupChecker sets the function used by AccessGroup.
+func (g *Gatekeeper) SetGroupChecker(fn func(peerID string) bool) {
+	g.mu.Lock()
+	g.sharesGroup = fn
+	g.mu.Unlock()
+}
+
+// SetConsentChecker sets the function used by AccessConsent. It may block
+// while the user is asked.
+func (g *Gatekeeper) SetConsentChecker(fn func(protoID, peerID string) bool) {
+	g.mu.Lock()
+	g.consent = fn
+	g.mu.Unlock()
+}
+
+// SetAuditor sets a callback invoked once per inbound /goop/ stream.
+func (g *Gatekeeper) SetAuditor(fn func(StreamAudit)) {
+	g.mu.Lock()
+	g.auditor = fn
+	g.mu.Unlock()
+}
+
+// audit passes a to the auditor if one is set. Only goop protocols are
+// recorded; libp2p internals such as pubsub would drown out the useful rows.
+func (g *Gatekeeper) audit(a StreamAudit) {
+	g.mu.RLock()
+	fn := g.auditor
+	g.mu.RUnlock()
+	if fn != nil && strings.HasPrefix(a.Protocol, "/goop/") {
+		fn(a)
+	}
+}
+
+// policyAliases maps newer versions of a protocol to the ID their policy
+// is configured under, so a policy keeps covering the protocol.
+var policyAliases = map[string]string{
+	proto.DocsChunkProtoID: proto.DocsProtoID,
+}
+
+// Allow reports whether peerID may open a stream on protoID.
+func (g *Gatekeeper) Allow(protoID, peerID string) bool {
+	g.mu.RLock()
+	required := g.required[protoID]
+	p, ok := g.policies[protoID]
+	if alias, aliased := policyAliases[protoID]; !ok && aliased {
+		p, ok = g.policies[alias]
+	}
+	isFavorite := g.isFavorite
+	sharesGroup := g.sharesGroup
+	consent := g.consent
+	g.mu.RUnlock()
+	if required != nil && !required(peerID) {
+		return false
+	}
+	if !ok {
+		return true
+	}
+
+	switch p.Mode {
+	case AccessFavorites:
+		return isFavorite != nil && isFavorite(peerID)
+	case AccessGroup:
+		return sharesGroup != nil && sharesGroup(peerID)
+	case AccessList:
+		for _, id := range p.Peers {
+			if id == peerID {
+				return true
+			}
+		}
+		return false
+	case AccessConsent:
+		if isFavorite != nil && isFavorite(peerID) {
+			return true
+		}
+		return consent != nil && consent(protoID, peerID)
+	}
+	return true
+}
+
+// wrap returns a stream handler that enforces the policy for protoID before
+// calling next. Denied streams are reset without a response.
+func (g *Gatekeeper) wrap(protoID string, next network.StreamHandler) network.StreamHandler {
+	return func(s network.Stream) {
+		start := time.Now()
+		remote := s.Conn().RemotePeer().String()
+		if !g.Allow(protoID, remote) {
+			log.Printf("GATE: denied %s for %s", protoID, shortPeer(remote))
+			_ = s.Reset()
+			g.audit(StreamAudit{Protocol: protoID, PeerID: remote, Outcome: "denied", Start: start})
+			return
+		}
+		cs := &countingStream{Stream: s, outcome: "allowed"}
+		next(cs)
+		g.audit(StreamAudit{
+			Protocol: protoID,
+			PeerID:   remote,
+			Outcome:  cs.outcome,
+			BytesIn:  cs.in.Load(),
+			BytesOut: cs.out.Load(),
+			Start:    start,
+			Duration: time.Since(start),
+		})
+	}
+}
+
+// countingStream tallies bytes read from and written to a stream.
+type countingStream struct {
+	network.Stream
+	in, out atomic.Int64
+	outcome string // set by the handler goroutine only
+}
+
+// setStreamOutcome lets a handler refine the audit outcome of a gated stream
+// (e.g. a request refused at the application level).
+func setStreamOutcome(s network.Stream, outcome string) {
+	if cs, ok := s.(*countingStream); ok {
+		cs.outcome = outcome
+	}
+}
+
+func (c *countingStream) Read(p []byte) (int, error) {
+	n, err := c.Stream.Read(p)
+	c.in.Add(int64(n))
+	return n, err
+}
+
+func (c *countingStream) Write(p []byte) (int, error) {
+	n, err := c.Stream.Write(p)
+	c.out.Add(int64(n))
+	return n, err
+}
+
+// gatedHost routes every SetStreamHandler registration through a Gatekeeper.
+type gatedHost struct {
+	host.Host
+	gate *Gatekeeper
+}
+
+func (h *gatedHost) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	h.Host.SetStreamHandler(pid, h.gate.wrap(string(pid), handler))
+}
+
+func (h *gatedHost) SetStreamHandlerMatch(pid protocol.ID, match func(protocol.ID) bool, handler network.StreamHandler) {
+	h.Host.SetStreamHandlerMatch(pid, match, h.gate.wrap(string(pid), handler))
+}
+
+// Gate returns the node's gatekeeper.
+func (n *Node) Gate() *Gatekeeper {
+	return n.gate
+}
+
+func shortPeer(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,64 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestGatekeeper_Policies(t *testing.T) {
+	g := NewGatekeeper()
+	g.SetFavoriteChecker(func(id string) bool { return id == "fav" })
+	g.SetGroupChecker(func(id string) bool { return id == "member" })
+
+	_ = g.SetPolicy("/fav", AccessPolicy{Mode: AccessFavorites})
+	_ = g.SetPolicy("/grp", AccessPolicy{Mode: AccessGroup})
+	_ = g.SetPolicy("/list", AccessPolicy{Mode: AccessList, Peers: []string{"alice"}})
+
+	cases := []struct {
+		proto, peer string
+		want        bool
+	}{
+		{"/open", "anyone", true},
+		{"/fav", "fav", true},
+		{"/fav", "member", false},
+		{"/grp", "member", true},
+		{"/grp", "fav", false},
+		{"/list", "alice", true},
+		{"/list", "bob", false},
+	}
+	for _, c := range cases {
+		if got := g.Allow(c.proto, c.peer); got != c.want {
+			t.Errorf("Allow(%s, %s) = %v, want %v", c.proto, c.peer, got, c.want)
+		}
+	}
+
+	// A policy on docs 1.0.0 also covers its chunked successor.
+	_ = g.SetPolicy(proto.DocsProtoID, AccessPolicy{Mode: AccessFavorites})
+	if g.Allow(proto.DocsChunkProtoID, "member") || !g.Allow(proto.DocsChunkProtoID, "fav") {
+		t.Error("docs policy not applied to /goop/docs/2.0.0")
+	}
+
+	// Setting "all" clears the policy.
+	_ = g.SetPolicy("/list", AccessPolicy{Mode: AccessAll})
+	if !g.Allow("/list", "bob") {
+		t.Error("expected policy cleared by mode all")
+	}
+	if err := g.SetPolicy("/x", AccessPolicy{Mode: "bogus"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+
+	// A requirement holds with or without a policy, and policies narrow it.
+	g.Require("/sync", func(id string) bool { return id == "member" || id == "fav" })
+	if !g.Allow("/sync", "member") || g.Allow("/sync", "anyone") {
+		t.Error("requirement not enforced without a policy")
+	}
+	_ = g.SetPolicy("/sync", AccessPolicy{Mode: AccessFavorites})
+	if g.Allow("/sync", "member") || !g.Allow("/sync", "fav") {
+		t.Error("policy did not narrow the requirement")
+	}
+	_ = g.SetPolicy("/sync", AccessPolicy{Mode: AccessAll})
+	if g.Allow("/sync", "anyone") {
+		t.Error("clearing the policy lifted the requirement")
+	}
+}
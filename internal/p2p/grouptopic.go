@@ -0,0 +1,55 @@
+package p2p
+
+import (
+	"context"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// JoinGroupTopic subscribes to the GossipSub topic of a large group.
+// validate runs for every message before it is delivered or forwarded to
+// other peers; from is the message author, whose signature pubsub already
+// checked. deliver receives every accepted message, our own included.
+// Implements group.TopicJoiner.
+func (n *Node) JoinGroupTopic(name string, validate func(from string, data []byte) bool, deliver func(from string, data []byte)) (func(ctx context.Context, data []byte) error, func(), error) {
+	err := n.ps.RegisterTopicValidator(name, func(_ context.Context, _ peer.ID, msg *pubsub.Message) bool {
+		return validate(msg.GetFrom().String(), msg.Data)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	topic, err := n.ps.Join(name)
+	if err != nil {
+		_ = n.ps.UnregisterTopicValidator(name)
+		return nil, nil, err
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		_ = topic.Close()
+		_ = n.ps.UnregisterTopicValidator(name)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			msg, err := sub.Next(ctx)
+			if err != nil {
+				return
+			}
+			deliver(msg.GetFrom().String(), msg.Data)
+		}
+	}()
+
+	publish := func(ctx context.Context, data []byte) error {
+		return topic.Publish(ctx, data)
+	}
+	leave := func() {
+		cancel()
+		sub.Cancel()
+		_ = topic.Close()
+		_ = n.ps.UnregisterTopicValidator(name)
+	}
+	return publish, leave, nil
+}
@@ -0,0 +1,112 @@
+package p2p
+
+// link.go — how each connected peer is actually reached. A peer on the
+// LAN, a peer dialed straight over the internet, one reached through a
+// hole punch and one still going through the relay all show as
+// "connected"; LinkQuality tells them apart for the peer list and the
+// topology graph.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Link qualities, best first.
+const (
+	LinkDirectLAN = "direct-lan" // direct connection to a private or loopback address
+	LinkDirectWAN = "direct-wan" // direct connection to a public address
+	LinkHolePunch = "holepunch"  // direct connection opened by a DCUtR hole punch
+	LinkRelayed   = "relay"      // only a /p2p-circuit connection through the relay
+	LinkNone      = "none"       // not connected
+)
+
+var linkRank = map[string]int{
+	LinkDirectLAN: 4,
+	LinkDirectWAN: 3,
+	LinkHolePunch: 2,
+	LinkRelayed:   1,
+	LinkNone:      0,
+}
+
+// punchLog remembers when hole punches to each peer succeeded, so the
+// direct connection they opened can be told from an ordinary dial.
+// It is the hole punching service's event tracer.
+type punchLog struct {
+	mu      sync.Mutex
+	windows map[peer.ID]punchWindow
+}
+
+type punchWindow struct {
+	start, end time.Time
+}
+
+func newPunchLog() *punchLog {
+	return &punchLog{windows: map[peer.ID]punchWindow{}}
+}
+
+// Trace implements holepunch.EventTracer.
+func (pl *punchLog) Trace(evt *holepunch.Event) {
+	end, ok := evt.Evt.(*holepunch.EndHolePunchEvt)
+	if !ok || !end.Success {
+		return
+	}
+	at := time.Unix(0, evt.Timestamp)
+	pl.mu.Lock()
+	pl.windows[evt.Remote] = punchWindow{start: at.Add(-end.EllapsedTime), end: at}
+	pl.mu.Unlock()
+}
+
+// punched reports whether a connection to p opened during a successful
+// hole punch.
+func (pl *punchLog) punched(p peer.ID, opened time.Time) bool {
+	if pl == nil {
+		return false
+	}
+	pl.mu.Lock()
+	w, ok := pl.windows[p]
+	pl.mu.Unlock()
+	return ok && !opened.Before(w.start.Add(-PunchMatchSlack)) && !opened.After(w.end.Add(PunchMatchSlack))
+}
+
+// classifyConn returns the link quality of one connection.
+func classifyConn(c network.Conn, punches *punchLog) string {
+	addr := c.RemoteMultiaddr()
+	switch {
+	case isCircuitAddr(addr):
+		return LinkRelayed
+	case punches.punched(c.RemotePeer(), c.Stat().Opened):
+		return LinkHolePunch
+	case manet.IsPrivateAddr(addr) || manet.IsIPLoopback(addr):
+		return LinkDirectLAN
+	default:
+		return LinkDirectWAN
+	}
+}
+
+// bestLink returns the best link quality among conns and the connection
+// that has it.
+func bestLink(conns []network.Conn, punches *punchLog) (string, network.Conn) {
+	best, bestConn := LinkNone, network.Conn(nil)
+	for _, c := range conns {
+		if q := classifyConn(c, punches); linkRank[q] > linkRank[best] {
+			best, bestConn = q, c
+		}
+	}
+	return best, bestConn
+}
+
+// LinkQuality returns how the peer is currently reached: one of the Link
+// constants.
+func (n *Node) LinkQuality(peerID string) string {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return LinkNone
+	}
+	q, _ := bestLink(n.Host.Network().ConnsToPeer(pid), n.punches)
+	return q
+}
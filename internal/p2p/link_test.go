@@ -0,0 +1,68 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type fakeConn struct {
+	network.Conn
+	addr   ma.Multiaddr
+	remote peer.ID
+	opened time.Time
+}
+
+func (c *fakeConn) RemoteMultiaddr() ma.Multiaddr { return c.addr }
+func (c *fakeConn) RemotePeer() peer.ID           { return c.remote }
+func (c *fakeConn) Stat() network.ConnStats {
+	return network.ConnStats{Stats: network.Stats{Opened: c.opened}}
+}
+
+func TestBestLink_classifiesConnections(t *testing.T) {
+	const remote = peer.ID("remote")
+	now := time.Now()
+	conn := func(addr string, opened time.Time) network.Conn {
+		return &fakeConn{addr: ma.StringCast(addr), remote: remote, opened: opened}
+	}
+	relayed := conn("/ip4/1.2.3.4/tcp/4001/p2p/12D3KooWLRPJAA5o6Z6ERmV2NkUZsTLpWeCJDyNGxMMUtTbNRNHH/p2p-circuit", now.Add(-time.Minute))
+	lan := conn("/ip4/192.168.1.42/tcp/4001", now.Add(-time.Minute))
+	wan := conn("/ip4/5.6.7.8/tcp/4001", now.Add(-time.Minute))
+	punchedWAN := conn("/ip4/5.6.7.8/udp/4001/quic-v1", now.Add(-2*time.Second))
+
+	punches := newPunchLog()
+	punches.Trace(&holepunch.Event{Remote: "other", Type: holepunch.EndHolePunchEvtT, Timestamp: now.UnixNano(),
+		Evt: &holepunch.EndHolePunchEvt{Success: true, EllapsedTime: 3 * time.Second}})
+	punches.Trace(&holepunch.Event{Remote: remote, Type: holepunch.EndHolePunchEvtT, Timestamp: now.UnixNano(),
+		Evt: &holepunch.EndHolePunchEvt{Success: false, EllapsedTime: 3 * time.Second}})
+
+	cases := []struct {
+		conns []network.Conn
+		want  string
+	}{
+		{nil, LinkNone},
+		{[]network.Conn{relayed}, LinkRelayed},
+		{[]network.Conn{relayed, wan}, LinkDirectWAN},
+		{[]network.Conn{wan, lan, relayed}, LinkDirectLAN},
+		{[]network.Conn{relayed, punchedWAN}, LinkDirectWAN}, // the punch failed
+	}
+	for i, c := range cases {
+		if got, _ := bestLink(c.conns, punches); got != c.want {
+			t.Errorf("case %d: %s, want %s", i, got, c.want)
+		}
+	}
+
+	punches.Trace(&holepunch.Event{Remote: remote, Type: holepunch.EndHolePunchEvtT, Timestamp: now.UnixNano(),
+		Evt: &holepunch.EndHolePunchEvt{Success: true, EllapsedTime: 3 * time.Second}})
+	if got, best := bestLink([]network.Conn{relayed, punchedWAN}, punches); got != LinkHolePunch || best != punchedWAN {
+		t.Errorf("punched = %s", got)
+	}
+	// A direct dial long before the punch is not attributed to it.
+	if got, _ := bestLink([]network.Conn{wan}, punches); got != LinkDirectWAN {
+		t.Errorf("earlier dial = %s", got)
+	}
+}
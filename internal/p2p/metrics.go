@@ -0,0 +1,42 @@
+package p2p
+
+import (
+	"slices"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// WriteMetrics writes the connection count and libp2p bandwidth for
+// GET /metrics. Per-protocol bytes only cover streams; the totals also
+// include connection overhead.
+func (n *Node) WriteMetrics(w *prom.Writer) {
+	w.Gauge("goop_p2p_connected_peers", "Peers with an open libp2p connection.", float64(len(n.Host.Network().Peers())))
+	if n.bw == nil {
+		return
+	}
+
+	tot := n.bw.GetBandwidthTotals()
+	w.Counter("goop_p2p_bytes_total", "Bytes through the libp2p host.", float64(tot.TotalIn), "direction", "in")
+	w.Counter("goop_p2p_bytes_total", "Bytes through the libp2p host.", float64(tot.TotalOut), "direction", "out")
+	w.Gauge("goop_p2p_bytes_per_second", "Current libp2p transfer rate.", tot.RateIn, "direction", "in")
+	w.Gauge("goop_p2p_bytes_per_second", "Current libp2p transfer rate.", tot.RateOut, "direction", "out")
+
+	byProto := n.bw.GetBandwidthByProtocol()
+	protos := make([]protocol.ID, 0, len(byProto))
+	for p := range byProto {
+		protos = append(protos, p)
+	}
+	slices.Sort(protos)
+	for _, dir := range []string{"in", "out"} {
+		for _, p := range protos {
+			st := byProto[p]
+			v := st.TotalIn
+			if dir == "out" {
+				v = st.TotalOut
+			}
+			w.Counter("goop_p2p_protocol_bytes_total", "Stream bytes through the libp2p host, by protocol.", float64(v), "protocol", string(p), "direction", dir)
+		}
+	}
+}
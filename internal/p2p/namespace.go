@@ -0,0 +1,102 @@
+package p2p
+
+import (
+	"context"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// Presence namespaces: besides the global presence topic a node can join
+// communities ("my-company", "gamers"), each with its own gossip topic and
+// peer table, so the peers of one community stay out of the others' lists.
+// Presence is published to every joined topic; probing and reachability
+// work across all tables.
+
+type namespace struct {
+	name  string
+	topic *pubsub.Topic
+	peers *state.PeerTable
+}
+
+// JoinNamespace subscribes to the presence topic of a namespace and keeps
+// peers up to date from it until ctx ends.
+func (n *Node) JoinNamespace(ctx context.Context, name string, peers *state.PeerTable) error {
+	topic, err := n.ps.Join(proto.PresenceTopicFor(name))
+	if err != nil {
+		return err
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		_ = topic.Close()
+		return err
+	}
+	n.nsMu.Lock()
+	n.namespaces = append(n.namespaces, &namespace{name: name, topic: topic, peers: peers})
+	n.nsMu.Unlock()
+	go n.presenceLoop(ctx, sub, peers, nil)
+	return nil
+}
+
+// Namespaces returns the names of the joined namespaces.
+func (n *Node) Namespaces() []string {
+	n.nsMu.RLock()
+	defer n.nsMu.RUnlock()
+	names := make([]string, len(n.namespaces))
+	for i, ns := range n.namespaces {
+		names[i] = ns.name
+	}
+	return names
+}
+
+func (n *Node) publishNamespaces(ctx context.Context, b []byte) {
+	n.nsMu.RLock()
+	defer n.nsMu.RUnlock()
+	for _, ns := range n.namespaces {
+		_ = ns.topic.Publish(ctx, b)
+	}
+}
+
+// tables returns the global peer table followed by the namespace tables.
+func (n *Node) tables() []*state.PeerTable {
+	n.nsMu.RLock()
+	defer n.nsMu.RUnlock()
+	out := []*state.PeerTable{n.peers}
+	for _, ns := range n.namespaces {
+		out = append(out, ns.peers)
+	}
+	return out
+}
+
+// lookupPeer finds a peer in any table, the global one first.
+func (n *Node) lookupPeer(id string) (state.SeenPeer, bool) {
+	for _, t := range n.tables() {
+		if sp, ok := t.Get(id); ok {
+			return sp, true
+		}
+	}
+	return state.SeenPeer{}, false
+}
+
+func (n *Node) setReachable(id string, reachable bool) {
+	for _, t := range n.tables() {
+		t.SetReachable(id, reachable)
+	}
+}
+
+// knownPeerIDs returns the IDs in all tables, without duplicates.
+func (n *Node) knownPeerIDs() []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, t := range n.tables() {
+		for _, id := range t.IDs() {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
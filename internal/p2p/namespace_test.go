@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+)
+
+// gossipNode is a Node with just a host, gossipsub and a global table.
+func gossipNode(t *testing.T, ctx context.Context) *Node {
+	t.Helper()
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	ps, err := pubsub.NewGossipSub(ctx, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Node{Host: h, ps: ps, peers: state.NewPeerTable()}
+}
+
+func TestJoinNamespace_keepsPeerListsApart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := gossipNode(t, ctx), gossipNode(t, ctx)
+	if err := a.Host.Connect(ctx, peer.AddrInfo{ID: b.Host.ID(), Addrs: b.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	aGamers, bGamers, bWork := state.NewPeerTable(), state.NewPeerTable(), state.NewPeerTable()
+	for _, j := range []struct {
+		n     *Node
+		name  string
+		table *state.PeerTable
+	}{{a, "gamers", aGamers}, {b, "gamers", bGamers}, {b, "work", bWork}} {
+		if err := j.n.JoinNamespace(ctx, j.name, j.table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := b.Namespaces(); len(got) != 2 || got[0] != "gamers" || got[1] != "work" {
+		t.Fatalf("namespaces = %v", got)
+	}
+
+	pm, _ := json.Marshal(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: a.ID(), Content: "Alice", TS: proto.NowMillis()})
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		a.publishNamespaces(ctx, pm)
+		if _, ok := bGamers.Get(a.ID()); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("presence did not reach the shared namespace")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, ok := b.peers.Get(a.ID()); ok {
+		t.Error("namespace presence leaked into the global list")
+	}
+	if _, ok := bWork.Get(a.ID()); ok {
+		t.Error("namespace presence leaked into another namespace")
+	}
+
+	// Reachability is tracked across all tables.
+	b.setReachable(a.ID(), true)
+	if sp, _ := bGamers.Get(a.ID()); !sp.Reachable {
+		t.Error("namespace table not marked reachable")
+	}
+	if ids := b.knownPeerIDs(); len(ids) != 1 || ids[0] != a.ID() {
+		t.Errorf("known peers = %v", ids)
+	}
+}
@@ -5,21 +5,24 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/avatar"
+	"github.com/petervdpas/goop2/internal/keystore"
 	"github.com/petervdpas/goop2/internal/proto"
 	"github.com/petervdpas/goop2/internal/rendezvous"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
 	"github.com/petervdpas/goop2/internal/util"
 
 	logging "github.com/ipfs/go-log/v2"
@@ -28,6 +31,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -35,6 +39,7 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
 	ymux "github.com/libp2p/go-libp2p/p2p/muxer/yamux"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
 	yamux "github.com/libp2p/go-yamux/v4"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
@@ -79,6 +84,10 @@ type Node struct {
 	topic *pubsub.Topic
 	sub   *pubsub.Subscription
 
+	// Joined presence namespaces, see namespace.go.
+	nsMu       sync.RWMutex
+	namespaces []*namespace
+
 	selfContent        func() string
 	selfEmail          func() string
 	selfVideoDisabled  func() bool
@@ -86,20 +95,41 @@ type Node struct {
 	selfPublicKey      func() string
 	peers              *state.PeerTable
 
+	// Per-protocol access policy for inbound streams.
+	gate    *Gatekeeper
+	consent *ConsentBroker
+
+	// Inbound streams refused by the per-peer caps (see streamlimits.go).
+	streamLimits *streamLimits
+
 	// Presence TTL for direct peer addresses; circuit addresses use 10x this.
 	presenceTTL time.Duration
 
 	// Relay peer info for recovery after connection drops.
 	relayPeer *peer.AddrInfo
 
+	// Successful hole punches, see link.go.
+	punches *punchLog
+
+	// Bytes through the host, in total and per protocol; see metrics.go.
+	bw *metrics.BandwidthCounter
+
 	// Relay timing (from rendezvous server config).
 	relayCleanupDelay   time.Duration
 	relayPollDeadline   time.Duration
 	relayConnectTimeout time.Duration
 	relayRecoveryGrace  time.Duration
 
+	// Reservation failures since the last relay report, see relay.go.
+	relayHealthMu  sync.Mutex
+	relayFailures  int
+	relayLastErr   string
+	relayLastErrAt time.Time
+
 	// Set by EnableSite in site.go
-	siteRoot string
+	siteRoot   string
+	siteAssets SiteAssets // optional, set by SetSiteAssets
+	siteHash   siteHasher // see sitehash.go
 
 	// Set by EnableData in data.go
 	db *storage.DB
@@ -114,11 +144,26 @@ type Node struct {
 	docsStore    DocStore
 	groupChecker GroupChecker
 
+	// Set by EnableSearch in search.go
+	searcher Searcher
+
+	// Set by EnableDataSync in datasync.go
+	syncConflicts func([]storage.SyncConflict)
+
+	// Clock offsets to other peers, see clock.go
+	clocks clockTable
+
 	// Diagnostic ring buffer for relay operations.
 	diagMu   sync.Mutex
 	diagLogs []string
 	diagMax  int
 
+	// Opt-in for rendezvous access to DiagSnapshot (see diag.go).
+	diagAccess diagAccess
+
+	// Bandwidth limits and usage for site/docs serving (see serving.go).
+	serving serveMeter
+
 	// Guards relay recovery operations so recoverRelay and
 	// forceRelayRecovery don't run concurrently and sabotage each other.
 	relayRecoveryMu sync.Mutex
@@ -176,13 +221,16 @@ func (n *mdnsNotifee) HandlePeerFound(pi peer.AddrInfo) {
 // loadOrCreateKey loads a persistent identity key from disk,
 // or generates a new Ed25519 key and saves it on first run.
 func loadOrCreateKey(keyFile string) (crypto.PrivKey, bool, error) {
-	data, err := os.ReadFile(keyFile)
+	data, err := keystore.Load(keyFile)
 	if err == nil {
 		priv, err := crypto.UnmarshalPrivateKey(data)
 		if err == nil {
 			return priv, false, nil
 		}
 		log.Printf("WARNING: corrupt identity key at %s: %v (generating new key)", keyFile, err)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		// Locked or unreadable: never replace a key we merely cannot open.
+		return nil, false, fmt.Errorf("identity key %s: %w", keyFile, err)
 	}
 
 	priv, _, err := crypto.GenerateEd25519Key(nil)
@@ -195,13 +243,7 @@ func loadOrCreateKey(keyFile string) (crypto.PrivKey, bool, error) {
 		return nil, false, fmt.Errorf("marshal identity key: %w", err)
 	}
 
-	if dir := filepath.Dir(keyFile); dir != "" {
-		if err := os.MkdirAll(dir, 0700); err != nil {
-			return nil, false, fmt.Errorf("create key directory: %w", err)
-		}
-	}
-
-	if err := os.WriteFile(keyFile, raw, 0600); err != nil {
+	if err := keystore.Save(keyFile, raw); err != nil {
 		return nil, false, fmt.Errorf("save identity key: %w", err)
 	}
 
@@ -237,21 +279,51 @@ func New(ctx context.Context, listenPort int, keyFile string, peers *state.PeerT
 	ymuxCfg.KeepAliveInterval = YamuxKeepAlive
 	ymuxCfg.LogOutput = io.Discard
 
+	limits := newStreamLimits()
+	rm, err := newResourceManager(limits)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []libp2p.Option{
 		libp2p.Identity(priv),
-		libp2p.ListenAddrStrings(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", listenPort)),
 		libp2p.Muxer(ymux.ID, (*ymux.Transport)(ymuxCfg)),
-		libp2p.DefaultTransports,
+		libp2p.ResourceManager(rm),
+	}
+	if torEnabled {
+		// Only the Tor transport: QUIC and WebRTC would go around the proxy.
+		opts = append(opts,
+			libp2p.ListenAddrStrings(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", listenPort)),
+			libp2p.Transport(newTorTransport),
+			libp2p.AddrsFactory(torAddrs),
+		)
+		if torOnion != nil {
+			log.Printf("tor: dialing through the SOCKS5 proxy, advertising %s", torOnion)
+		} else {
+			log.Printf("tor: dialing through the SOCKS5 proxy, reachable through the relay only")
+		}
+	} else {
+		opts = append(opts,
+			libp2p.ListenAddrStrings(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", listenPort)),
+			libp2p.DefaultTransports,
+		)
 	}
 
 	// When a relay is available, enable circuit relay transport, hole-punching,
 	// and auto-relay so the peer gets a public relay address.
+	bw := metrics.NewBandwidthCounter()
+	opts = append(opts, libp2p.BandwidthReporter(bw))
+
+	punches := newPunchLog()
 	if relayInfo != nil {
 		ri, err := relayInfoToAddrInfo(relayInfo)
 		if err == nil {
+			opts = append(opts, libp2p.EnableRelay())
+			// A hole punch would hand our IP to the other side.
+			if !torEnabled {
+				opts = append(opts, libp2p.EnableHolePunching(holepunch.WithTracer(punches)))
+			}
 			opts = append(opts,
-				libp2p.EnableRelay(),
-				libp2p.EnableHolePunching(),
 				libp2p.EnableAutoRelayWithStaticRelays([]peer.AddrInfo{*ri},
 					autorelay.WithBootDelay(0),
 					autorelay.WithBackoff(AutoRelayBackoff),
@@ -264,11 +336,24 @@ func New(ctx context.Context, listenPort int, keyFile string, peers *state.PeerT
 		}
 	}
 
-	h, err := libp2p.New(opts...)
+	rawHost, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	// All stream handlers registered on the host pass through the gatekeeper.
+	gate := NewGatekeeper()
+	if peers != nil {
+		gate.SetFavoriteChecker(func(peerID string) bool {
+			sp, ok := peers.Get(peerID)
+			return ok && sp.Favorite
+		})
+	}
+	consent := NewConsentBroker()
+	gate.SetConsentChecker(consent.Ask)
+	limits.gate.Store(gate)
+	var h host.Host = &gatedHost{Host: rawHost, gate: gate}
+
 	// Every node is a server: serve content over stream protocol
 	h.SetStreamHandler(protocol.ID(proto.ContentProtoID), func(s network.Stream) {
 		defer s.Close()
@@ -283,10 +368,13 @@ func New(ctx context.Context, listenPort int, keyFile string, peers *state.PeerT
 	if s, ok := h.Network().(*swarm.Swarm); ok {
 		mdnsSw = s
 	}
-	md := mdns.NewMdnsService(h, proto.MdnsTag, &mdnsNotifee{h: h, sw: mdnsSw})
-	if err := md.Start(); err != nil {
-		_ = h.Close()
-		return nil, err
+	// mDNS announces the peer to the LAN, which Tor mode is meant to hide.
+	if !torEnabled {
+		md := mdns.NewMdnsService(h, proto.MdnsTag, &mdnsNotifee{h: h, sw: mdnsSw})
+		if err := md.Start(); err != nil {
+			_ = h.Close()
+			return nil, err
+		}
 	}
 
 	// Let relay and direct connections coexist. libp2p prefers direct
@@ -323,11 +411,16 @@ func New(ctx context.Context, listenPort int, keyFile string, peers *state.PeerT
 		selfActiveTemplate: selfActiveTemplate,
 		selfPublicKey:      selfPublicKey,
 		peers:              peers,
+		gate:               gate,
+		consent:            consent,
+		streamLimits:       limits,
 		presenceTTL:        presenceTTL,
 		diagLogs:           make([]string, 0, 200),
 		diagMax:            200,
 		startTime:          time.Now(),
 		probeLastFail:      make(map[string]time.Time),
+		punches:            punches,
+		bw:                 bw,
 	}
 
 	// Store relay peer info for recovery after connection drops.
@@ -344,11 +437,13 @@ func New(ctx context.Context, listenPort int, keyFile string, peers *state.PeerT
 
 	// Diagnostic protocol — the rendezvous server queries this via
 	// the relay host connection to get relay health info from any peer.
-	h.SetStreamHandler(protocol.ID("/goop/diag/1.0.0"), func(s network.Stream) {
-		defer s.Close()
-		snap := n.DiagSnapshot()
-		_ = json.NewEncoder(s).Encode(snap)
-	})
+	// Opt-in only; see SetDiagAccess.
+	gate.Require(proto.DiagProtoID, n.isRelayPeer)
+	h.SetStreamHandler(protocol.ID(proto.DiagProtoID), n.handleDiagStream)
+
+	// Clock offset protocol — peers estimate each other's clock so
+	// timestamps from sync-sensitive apps (listen rooms, games) compare.
+	h.SetStreamHandler(protocol.ID(proto.TimeProtoID), n.handleTimeStream)
 
 	// Relay-refresh protocol — the rendezvous server sends this to
 	// tell a peer to refresh its relay reservation. Triggered when
@@ -563,6 +658,12 @@ func (n *Node) DiagSnapshot() map[string]any {
 	if len(connectedPeerDetails) > 0 {
 		result["connected_peer_details"] = connectedPeerDetails
 	}
+	if n.streamLimits != nil {
+		result["stream_limits"] = n.streamLimits.snapshot()
+	}
+	for name, fn := range n.diagSections() {
+		result[name] = fn()
+	}
 
 	return result
 }
@@ -585,6 +686,7 @@ type TopologyPeer struct {
 	Label               string `json:"label"`
 	Reachable           bool   `json:"reachable"`
 	Connection          string `json:"connection"` // "direct", "relay", or "none"
+	Quality             string `json:"quality"`    // one of the Link constants, see link.go
 	Addr                string `json:"addr"`
 	Age                 string `json:"age"`
 	Streams             int    `json:"streams"`
@@ -665,25 +767,21 @@ func (n *Node) Topology() TopologyData {
 		seen[pid] = true
 
 		conns := n.Host.Network().ConnsToPeer(pid)
+		quality, best := bestLink(conns, n.punches)
 		connType := "none"
 		bestAddr := ""
 		var bestAge time.Duration
+		if best != nil {
+			connType = "direct"
+			if quality == LinkRelayed {
+				connType = "relay"
+			}
+			bestAddr = best.RemoteMultiaddr().String()
+			bestAge = now.Sub(best.Stat().Opened)
+		}
 		totalStreams := 0
 		for _, c := range conns {
-			addr := c.RemoteMultiaddr()
-			age := now.Sub(c.Stat().Opened)
 			totalStreams += len(c.GetStreams())
-			if isCircuitAddr(addr) {
-				if connType != "direct" {
-					connType = "relay"
-					bestAddr = addr.String()
-					bestAge = age
-				}
-			} else {
-				connType = "direct"
-				bestAddr = addr.String()
-				bestAge = age
-			}
 		}
 
 		sp := snapshot[pid.String()]
@@ -697,6 +795,7 @@ func (n *Node) Topology() TopologyData {
 			Label:               label,
 			Reachable:           sp.Reachable,
 			Connection:          connType,
+			Quality:             quality,
 			Addr:                bestAddr,
 			Age:                 bestAge.Truncate(time.Second).String(),
 			Streams:             totalStreams,
@@ -719,6 +818,7 @@ func (n *Node) Topology() TopologyData {
 			Label:               label,
 			Reachable:           sp.Reachable,
 			Connection:          "none",
+			Quality:             LinkNone,
 			EncryptionSupported: sp.EncryptionSupported,
 		})
 	}
@@ -818,13 +918,16 @@ func (n *Node) Publish(ctx context.Context, typ string) {
 		msg.AvatarHash = n.AvatarHash()
 		msg.VideoDisabled = n.selfVideoDisabled()
 		msg.ActiveTemplate = n.selfActiveTemplate()
+		msg.SiteHash = n.SiteHash()
 		msg.EncryptionSupported = n.enc != nil
 		msg.GoopClientVersion = n.goopClientVersion
 		msg.Addrs = n.WanAddrs()
 	}
+	n.SignPresence(&msg)
 
 	b, _ := json.Marshal(msg)
 	_ = n.topic.Publish(ctx, b)
+	n.publishNamespaces(ctx, b)
 }
 
 // WanAddrs returns the host's multiaddresses filtered to exclude loopback
@@ -833,8 +936,9 @@ func (n *Node) Publish(ctx context.Context, typ string) {
 func (n *Node) WanAddrs() []string {
 	var out []string
 	for _, a := range n.Host.Addrs() {
-		// Always include circuit relay addresses — they're public relay paths.
-		if isCircuitAddr(a) {
+		// Always include circuit relay addresses — they're public relay paths,
+		// and onion addresses, which have no IP.
+		if isCircuitAddr(a) || isOnionAddr(a) {
 			out = append(out, a.String())
 			continue
 		}
@@ -932,40 +1036,44 @@ func (n *Node) AddPeerAddrs(peerID string, addrs []string) {
 }
 
 func (n *Node) RunPresenceLoop(ctx context.Context, onEvent func(msg proto.PresenceMsg)) {
-	go func() {
-		for {
-			m, err := n.sub.Next(ctx)
-			if err != nil {
-				return
-			}
+	go n.presenceLoop(ctx, n.sub, n.peers, onEvent)
+}
 
-			var pm proto.PresenceMsg
-			if err := json.Unmarshal(m.Data, &pm); err != nil {
-				continue
-			}
-			if pm.PeerID == "" || pm.Type == "" {
-				continue
-			}
-			if pm.PeerID == n.ID() {
-				continue
-			}
+// presenceLoop applies the presence messages of one topic to peers.
+func (n *Node) presenceLoop(ctx context.Context, sub *pubsub.Subscription, peers *state.PeerTable, onEvent func(msg proto.PresenceMsg)) {
+	for {
+		m, err := sub.Next(ctx)
+		if err != nil {
+			return
+		}
 
-			switch pm.Type {
-			case proto.TypeOnline, proto.TypeUpdate:
-				// Preserve the Verified flag set by the rendezvous server — P2P gossip
-				// is not an authority on email verification.
-				existing, _ := n.peers.Get(pm.PeerID)
-				n.peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, existing.Verified, pm.GoopClientVersion)
-				n.AddPeerAddrs(pm.PeerID, pm.Addrs)
-			case proto.TypeOffline:
-				n.peers.MarkOffline(pm.PeerID)
-			}
+		var pm proto.PresenceMsg
+		if err := json.Unmarshal(m.Data, &pm); err != nil {
+			continue
+		}
+		if pm.PeerID == "" || pm.Type == "" {
+			continue
+		}
+		if pm.PeerID == n.ID() {
+			continue
+		}
 
-			if onEvent != nil {
-				onEvent(pm)
-			}
+		switch pm.Type {
+		case proto.TypeOnline, proto.TypeUpdate:
+			// Preserve the Verified flag set by the rendezvous server — P2P gossip
+			// is not an authority on email verification.
+			existing, _ := peers.Get(pm.PeerID)
+			peers.Upsert(pm.PeerID, pm.Content, pm.Email, pm.AvatarHash, pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey, pm.EncryptionSupported, existing.Verified, pm.GoopClientVersion, VerifyPresence(pm))
+			peers.SetSiteHash(pm.PeerID, pm.SiteHash)
+			n.AddPeerAddrs(pm.PeerID, pm.Addrs)
+		case proto.TypeOffline:
+			peers.MarkOffline(pm.PeerID)
 		}
-	}()
+
+		if onEvent != nil {
+			onEvent(pm)
+		}
+	}
 }
 
 // ProbePeer tests whether we can open a direct/relay stream to the
@@ -978,10 +1086,10 @@ func (n *Node) markReachable(rawID, source string) {
 	n.probeMu.Lock()
 	delete(n.probeLastFail, rawID)
 	n.probeMu.Unlock()
-	if sp, ok := n.peers.Get(rawID); ok && !sp.Reachable {
+	if sp, ok := n.lookupPeer(rawID); ok && !sp.Reachable {
 		log.Printf("probe %s: REACHABLE (%s)", rawID[:16], source)
 	}
-	n.peers.SetReachable(rawID, true)
+	n.setReachable(rawID, true)
 }
 
 func (n *Node) ProbePeer(ctx context.Context, rawID string) {
@@ -1002,7 +1110,7 @@ func (n *Node) ProbePeer(ctx context.Context, rawID string) {
 	if sw, ok := n.Host.Network().(*swarm.Swarm); ok {
 		sw.Backoff().Clear(pid)
 	}
-	probeCtx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	probeCtx, cancel := context.WithTimeout(ctx, timeouts.Get().Probe)
 	defer cancel()
 	s, err := n.Host.NewStream(network.WithAllowLimitedConn(probeCtx, "relay"), pid, protocol.ID(proto.ContentProtoID))
 	if err != nil {
@@ -1016,10 +1124,10 @@ func (n *Node) ProbePeer(ctx context.Context, rawID string) {
 		n.probeLastFail[rawID] = time.Now()
 		n.probeMu.Unlock()
 		// Only log when transitioning from reachable → unreachable.
-		if sp, ok := n.peers.Get(rawID); ok && sp.Reachable {
+		if sp, ok := n.lookupPeer(rawID); ok && sp.Reachable {
 			log.Printf("probe %s: UNREACHABLE err=%v", rawID[:16], err)
 		}
-		n.peers.SetReachable(rawID, false)
+		n.setReachable(rawID, false)
 		return
 	}
 	s.Close()
@@ -1067,7 +1175,7 @@ func (n *Node) SubscribeConnectionEvents(ctx context.Context, onConnect func(pee
 					continue
 				}
 				rawID := e.Peer.String()
-				if sp, known := n.peers.Get(rawID); known && !sp.Reachable {
+				if sp, known := n.lookupPeer(rawID); known && !sp.Reachable {
 					n.markReachable(rawID, "connection")
 				}
 				if onConnect != nil {
@@ -1078,9 +1186,10 @@ func (n *Node) SubscribeConnectionEvents(ctx context.Context, onConnect func(pee
 	}()
 }
 
-// ProbeAllPeers probes every known peer in parallel and blocks until done.
+// ProbeAllPeers probes every known peer, in all namespaces, in parallel and
+// blocks until done.
 func (n *Node) ProbeAllPeers(ctx context.Context) {
-	ids := n.peers.IDs()
+	ids := n.knownPeerIDs()
 	if len(ids) == 0 {
 		return
 	}
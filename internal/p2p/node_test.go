@@ -1,9 +1,15 @@
 package p2p
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/petervdpas/goop2/internal/keystore"
+
 	libp2p "github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/network"
 	ymux "github.com/libp2p/go-libp2p/p2p/muxer/yamux"
@@ -67,3 +73,34 @@ func TestWithAllowLimitedConn_ContextHasFlag(t *testing.T) {
 		t.Fatalf("expected reason 'relay', got %q", reason)
 	}
 }
+
+func TestLoadOrCreateKey_ProtectedNeverRegenerated(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "data", "identity.key")
+	priv, isNew, err := loadOrCreateKey(keyFile)
+	if err != nil || !isNew {
+		t.Fatalf("create: isNew=%v err=%v", isNew, err)
+	}
+	if err := keystore.Protect(keyFile, keystore.ModePassphrase, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(keyFile)
+
+	keystore.SetPassphrase("wrong")
+	defer keystore.SetPassphrase("")
+	_, err = PeerIDFromKeyFile(keyFile)
+	if !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Fatalf("err = %v, want ErrWrongPassphrase", err)
+	}
+	after, _ := os.ReadFile(keyFile)
+	if !bytes.Equal(before, after) {
+		t.Fatal("protected key file was replaced")
+	}
+
+	if err := keystore.Unlock(keyFile, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	got, isNew, err := loadOrCreateKey(keyFile)
+	if err != nil || isNew || !got.Equals(priv) {
+		t.Fatalf("reload: isNew=%v err=%v", isNew, err)
+	}
+}
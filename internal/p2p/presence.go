@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"errors"
+	"log"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// SignPresence signs a presence message with this node's identity key so
+// receivers can tell the label, avatar and template really came from us and
+// were not rewritten by a rendezvous on the way.
+func (n *Node) SignPresence(pm *proto.PresenceMsg) {
+	priv := n.Host.Peerstore().PrivKey(n.Host.ID())
+	if priv == nil {
+		return
+	}
+	sig, err := priv.Sign(pm.SigningBytes())
+	if err != nil {
+		log.Printf("presence: sign: %v", err)
+		return
+	}
+	pm.Sig = sig
+}
+
+// Sign signs data with this node's identity key.
+func (n *Node) Sign(data []byte) ([]byte, error) {
+	priv := n.Host.Peerstore().PrivKey(n.Host.ID())
+	if priv == nil {
+		return nil, errors.New("no identity key")
+	}
+	return priv.Sign(data)
+}
+
+// VerifyPresence reports whether a presence message carries a valid
+// signature by the peer it claims to be from. Unsigned messages (older
+// clients, bridge peers) and tampered ones both return false.
+func VerifyPresence(pm proto.PresenceMsg) bool {
+	if len(pm.Sig) == 0 {
+		return false
+	}
+	pid, err := peer.Decode(pm.PeerID)
+	if err != nil {
+		return false
+	}
+	pub, err := pid.ExtractPublicKey()
+	if err != nil {
+		return false
+	}
+	ok, err := pub.Verify(pm.SigningBytes(), pm.Sig)
+	return err == nil && ok
+}
@@ -0,0 +1,59 @@
+package p2p
+
+import (
+	"testing"
+
+	libp2p "github.com/libp2p/go-libp2p"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestPresenceSignature(t *testing.T) {
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	n := &Node{Host: h}
+
+	pm := proto.PresenceMsg{
+		Type:           proto.TypeOnline,
+		PeerID:         n.ID(),
+		Content:        "Alice",
+		Email:          "alice@example.com",
+		AvatarHash:     "abc",
+		ActiveTemplate: "blog",
+		TS:             proto.NowMillis(),
+	}
+	if VerifyPresence(pm) {
+		t.Fatal("unsigned message verified")
+	}
+	n.SignPresence(&pm)
+	if !VerifyPresence(pm) {
+		t.Fatal("signed message did not verify")
+	}
+
+	// Fields outside the signature may change in transit.
+	relayed := pm
+	relayed.Verified = true
+	relayed.Addrs = []string{"/ip4/1.2.3.4/tcp/4001"}
+	if !VerifyPresence(relayed) {
+		t.Fatal("rendezvous-set fields broke the signature")
+	}
+
+	for name, tamper := range map[string]func(*proto.PresenceMsg){
+		"label":    func(m *proto.PresenceMsg) { m.Content = "Mallory" },
+		"email":    func(m *proto.PresenceMsg) { m.Email = "mallory@example.com" },
+		"avatar":   func(m *proto.PresenceMsg) { m.AvatarHash = "def" },
+		"template": func(m *proto.PresenceMsg) { m.ActiveTemplate = "shop" },
+		"site":     func(m *proto.PresenceMsg) { m.SiteHash = "stale" },
+		"ts":       func(m *proto.PresenceMsg) { m.TS++ },
+		"peer":     func(m *proto.PresenceMsg) { m.PeerID = "12D3KooWBogus" },
+	} {
+		m := pm
+		tamper(&m)
+		if VerifyPresence(m) {
+			t.Errorf("tampered %s still verified", name)
+		}
+	}
+}
@@ -7,8 +7,10 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"strings"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
 	relayv2client "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	pbv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/pb"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
 	"github.com/petervdpas/goop2/internal/rendezvous"
@@ -168,6 +172,7 @@ func (n *Node) refreshRelay(ctx context.Context, label string) bool {
 	defer cancel()
 	if err := n.Host.Connect(connCtx, *n.relayPeer); err != nil {
 		n.diag("relay [%s]: connect failed: %v", label, err)
+		n.noteRelayFailure(relayErrClass(err))
 		return false
 	}
 
@@ -180,6 +185,7 @@ func (n *Node) refreshRelay(ctx context.Context, label string) bool {
 	resCancel()
 	if resErr != nil {
 		n.diag("relay [%s]: direct Reserve failed: %v", label, resErr)
+		n.noteRelayFailure(relayErrClass(resErr))
 	} else {
 		n.diag("relay [%s]: direct Reserve OK, expires %s, %d addrs",
 			label, rsvp.Expiration.Format("15:04:05"), len(rsvp.Addrs))
@@ -192,6 +198,9 @@ func (n *Node) refreshRelay(ctx context.Context, label string) bool {
 		select {
 		case <-deadline:
 			n.diag("relay [%s]: reservation NOT restored after %s", label, n.relayPollDeadline)
+			if resErr == nil {
+				n.noteRelayFailure(rendezvous.RelayErrNoCircuit)
+			}
 			log.Printf("relay [%s]: recovery FAILED after %s", label, time.Since(start).Truncate(time.Millisecond))
 			return false
 		case <-tick.C:
@@ -341,6 +350,7 @@ func (n *Node) nudgeRelay() {
 		defer cancel()
 		if err := n.Host.Connect(ctx, *n.relayPeer); err != nil {
 			n.diag("relay [nudge]: connect failed: %v", err)
+			n.noteRelayFailure(relayErrClass(err))
 		}
 	} else {
 		n.diag("relay [nudge]: %d connections exist, cleared backoff + refreshed addrs", len(conns))
@@ -359,6 +369,95 @@ func (n *Node) ensureRelayReservation(ctx context.Context) {
 	n.refreshRelay(ctx, "refresh")
 }
 
+// noteRelayFailure records a failed reservation attempt for the next
+// relay report.
+func (n *Node) noteRelayFailure(class string) {
+	n.relayHealthMu.Lock()
+	n.relayFailures++
+	n.relayLastErr = class
+	n.relayLastErrAt = time.Now()
+	n.relayHealthMu.Unlock()
+}
+
+// relayErrClass sorts a dial or reservation error into one of the
+// classes the rendezvous aggregates on.
+func relayErrClass(err error) string {
+	var re relayv2client.ReservationError
+	if errors.As(err, &re) {
+		switch re.Status {
+		case pbv2.Status_RESERVATION_REFUSED, pbv2.Status_PERMISSION_DENIED:
+			return rendezvous.RelayErrRefused
+		case pbv2.Status_RESOURCE_LIMIT_EXCEEDED:
+			return rendezvous.RelayErrLimit
+		}
+	}
+	var ne net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return rendezvous.RelayErrTimeout
+	case errors.Is(err, swarm.ErrDialBackoff), errors.Is(err, swarm.ErrNoAddresses), errors.As(err, new(*swarm.DialError)):
+		return rendezvous.RelayErrDial
+	}
+	return rendezvous.RelayErrOther
+}
+
+// RelayReport measures the round trip to the relay and returns the
+// reservation state with the failures seen since the previous call.
+// ok is false when the node has no relay.
+func (n *Node) RelayReport(ctx context.Context) (rep rendezvous.RelayReport, ok bool) {
+	if n.relayPeer == nil {
+		return rep, false
+	}
+	rep.PeerID = n.ID()
+	rep.ReservationOK = n.hasCircuitAddr()
+
+	if len(n.Host.Network().ConnsToPeer(n.relayPeer.ID)) > 0 {
+		pctx, cancel := context.WithTimeout(ctx, RelayPingTimeout)
+		if res := <-ping.Ping(pctx, n.Host, n.relayPeer.ID); res.Error == nil {
+			rep.RTTMs = res.RTT.Milliseconds()
+		}
+		cancel()
+	}
+
+	n.relayHealthMu.Lock()
+	rep.Failures = n.relayFailures
+	n.relayFailures = 0
+	if n.relayLastErr != "" {
+		rep.ErrorClass = n.relayLastErr
+		rep.ErrorAgeSec = int64(time.Since(n.relayLastErrAt).Seconds())
+	}
+	n.relayHealthMu.Unlock()
+	return rep, true
+}
+
+// StartRelayReports calls send with a RelayReport every interval, so the
+// rendezvous running the relay can spot failures shared by many peers.
+func (n *Node) StartRelayReports(ctx context.Context, interval time.Duration, send func(context.Context, rendezvous.RelayReport) error) {
+	if n.relayPeer == nil {
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				rep, ok := n.RelayReport(ctx)
+				if !ok {
+					return
+				}
+				sctx, cancel := context.WithTimeout(ctx, RelayReportTimeout)
+				if err := send(sctx, rep); err != nil {
+					n.diag("relay: health report failed: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
 // addRelayAddrForPeer constructs a circuit relay address for a target peer
 // and adds it to the peerstore. This allows dialing a peer through the relay
 // even if the peer never published a circuit address in its presence.
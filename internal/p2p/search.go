@@ -0,0 +1,108 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Limits on a remote search query.
+const (
+	SearchMaxQuery = 200
+	SearchMaxLimit = 50
+)
+
+// Searcher answers keyword queries from other peers over /goop/search.
+// It decides what peerID may find; the node only carries the query.
+type Searcher interface {
+	Search(peerID, query string, limit int) []proto.SearchHit
+}
+
+// EnableSearch registers the search stream handler. Access policies for
+// proto.SearchProtoID apply as for any other protocol.
+func (n *Node) EnableSearch(s Searcher) {
+	n.searcher = s
+	n.Host.SetStreamHandler(protocol.ID(proto.SearchProtoID), n.handleSearchStream)
+}
+
+func (n *Node) handleSearchStream(s network.Stream) {
+	defer s.Close()
+
+	reply := func(resp proto.SearchResponse) {
+		if resp.Hits == nil {
+			resp.Hits = []proto.SearchHit{}
+		}
+		_ = json.NewEncoder(s).Encode(resp)
+	}
+
+	_ = s.SetReadDeadline(time.Now().Add(SearchRequestTimeout))
+	line, err := bufio.NewReader(s).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		reply(proto.SearchResponse{Error: "bad request"})
+		return
+	}
+	_ = s.SetReadDeadline(time.Time{})
+
+	var req proto.SearchRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		reply(proto.SearchResponse{Error: "bad request"})
+		return
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" || len(req.Query) > SearchMaxQuery {
+		reply(proto.SearchResponse{Error: "bad query"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > SearchMaxLimit {
+		req.Limit = SearchMaxLimit
+	}
+	if n.searcher == nil {
+		reply(proto.SearchResponse{Error: "search not enabled"})
+		return
+	}
+
+	hits := n.searcher.Search(s.Conn().RemotePeer().String(), req.Query, req.Limit)
+	setStreamOutcome(s, fmt.Sprintf("search:%d", len(hits)))
+	reply(proto.SearchResponse{Hits: hits})
+}
+
+// SearchPeer sends a query to a remote peer and returns its hits.
+func (n *Node) SearchPeer(ctx context.Context, peerID string, req proto.SearchRequest) ([]proto.SearchHit, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+
+	st, err := n.Host.NewStream(network.WithAllowLimitedConn(ctx, "relay"), pid, protocol.ID(proto.SearchProtoID))
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = st.SetDeadline(dl)
+	}
+
+	if err := json.NewEncoder(st).Encode(req); err != nil {
+		return nil, err
+	}
+	_ = st.CloseWrite()
+
+	var resp proto.SearchResponse
+	if err := json.NewDecoder(st).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Hits, nil
+}
@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+type fakeSearcher struct{ gotPeer, gotQuery string }
+
+func (f *fakeSearcher) Search(peerID, query string, limit int) []proto.SearchHit {
+	f.gotPeer, f.gotQuery = peerID, query
+	return []proto.SearchHit{{Kind: "page", Title: "Hello", Path: "/index.html", Score: 1}}
+}
+
+func TestSearch_RoundTrip(t *testing.T) {
+	newNode := func() *Node {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return &Node{Host: h}
+	}
+	server, client := newNode(), newNode()
+	fs := &fakeSearcher{}
+	server.EnableSearch(fs)
+	if err := client.Host.Connect(context.Background(), peer.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hits, err := client.SearchPeer(ctx, server.Host.ID().String(), proto.SearchRequest{Query: "  hello  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Path != "/index.html" {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if fs.gotPeer != client.Host.ID().String() || fs.gotQuery != "hello" {
+		t.Fatalf("searcher saw %q %q", fs.gotPeer, fs.gotQuery)
+	}
+
+	if _, err := client.SearchPeer(ctx, server.Host.ID().String(), proto.SearchRequest{Query: " "}); err == nil || err.Error() != "bad query" {
+		t.Fatalf("empty query: %v", err)
+	}
+}
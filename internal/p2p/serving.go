@@ -0,0 +1,230 @@
+package p2p
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	serveChunk      = 4 * 1024 // bytes written between throttle checks
+	serveRateWindow = 5        // seconds the current rate is averaged over
+	servePeerIdle   = 10 * time.Minute
+	servePeersMax   = 256 // idle requesters are dropped past this many
+)
+
+// ServeLimits caps the upstream bandwidth spent serving site and docs
+// files to other peers, in KB/s. Zero means unlimited.
+type ServeLimits struct {
+	TotalKBps   int `json:"total_kbps"`
+	PerPeerKBps int `json:"per_peer_kbps"`
+}
+
+// ServeUsage is the serving traffic of everyone or of one requester.
+type ServeUsage struct {
+	PeerID string `json:"peer_id,omitempty"`
+	Bytes  int64  `json:"bytes"`   // served since start
+	Rate   int64  `json:"rate"`    // bytes/s over the last few seconds
+	Active int    `json:"active"`  // streams being served
+	LastAt int64  `json:"last_at"` // Unix ms of the last write, 0 = never
+}
+
+// ServeStats is the snapshot returned by ServeStats.
+type ServeStats struct {
+	Limits     ServeLimits  `json:"limits"`
+	ServeUsage              // totals
+	ThrottleMs int64        `json:"throttle_ms"` // time spent held back by the limits
+	Peers      []ServeUsage `json:"peers"`       // by current rate, then bytes
+}
+
+// tokenBucket paces writes to a rate with one second of burst. Tokens go
+// negative when a write overdraws them; the debt is what the writer waits.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) reserve(rate float64, n int, now time.Time) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens = min(rate, b.tokens+now.Sub(b.last).Seconds()*rate)
+	} else {
+		b.tokens = rate
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// usage counts bytes in per-second slots for the current rate.
+type usage struct {
+	bucket tokenBucket
+	bytes  int64
+	active int
+	lastAt time.Time
+	slots  [serveRateWindow]int64
+	slotAt [serveRateWindow]int64 // Unix second each slot counts
+}
+
+func (u *usage) add(n int, now time.Time) {
+	u.bytes += int64(n)
+	u.lastAt = now
+	sec := now.Unix()
+	i := sec % serveRateWindow
+	if u.slotAt[i] != sec {
+		u.slotAt[i], u.slots[i] = sec, 0
+	}
+	u.slots[i] += int64(n)
+}
+
+func (u *usage) snapshot(peerID string, now time.Time) ServeUsage {
+	var sum int64
+	for i := range u.slots {
+		if now.Unix()-u.slotAt[i] < serveRateWindow {
+			sum += u.slots[i]
+		}
+	}
+	s := ServeUsage{PeerID: peerID, Bytes: u.bytes, Rate: sum / serveRateWindow, Active: u.active}
+	if !u.lastAt.IsZero() {
+		s.LastAt = u.lastAt.UnixMilli()
+	}
+	return s
+}
+
+// serveMeter applies the serving limits and keeps the usage figures. The
+// zero value is ready to use, with no limits.
+type serveMeter struct {
+	mu       sync.Mutex
+	limits   ServeLimits
+	total    usage
+	peers    map[string]*usage
+	throttle time.Duration
+}
+
+func (m *serveMeter) setLimits(l ServeLimits) {
+	m.mu.Lock()
+	m.limits = ServeLimits{TotalKBps: max(0, l.TotalKBps), PerPeerKBps: max(0, l.PerPeerKBps)}
+	m.mu.Unlock()
+}
+
+// begin registers a stream served to peerID and returns the writer for
+// its response. Call the returned func when the stream is done.
+func (m *serveMeter) begin(w io.Writer, peerID string) (io.Writer, func()) {
+	m.mu.Lock()
+	if m.peers == nil {
+		m.peers = make(map[string]*usage)
+	}
+	u, ok := m.peers[peerID]
+	if !ok {
+		if len(m.peers) >= servePeersMax {
+			m.pruneLocked(time.Now())
+		}
+		u = &usage{}
+		m.peers[peerID] = u
+	}
+	u.active++
+	m.total.active++
+	m.mu.Unlock()
+
+	done := func() {
+		m.mu.Lock()
+		u.active--
+		m.total.active--
+		m.mu.Unlock()
+	}
+	return &throttledWriter{w: w, m: m, u: u}, done
+}
+
+// pruneLocked drops requesters that are idle and have been for a while.
+func (m *serveMeter) pruneLocked(now time.Time) {
+	for id, u := range m.peers {
+		if u.active == 0 && now.Sub(u.lastAt) > servePeerIdle {
+			delete(m.peers, id)
+		}
+	}
+}
+
+// reserve takes n bytes from the global and the requester's budget and
+// returns how long to wait before writing them.
+func (m *serveMeter) reserve(u *usage, n int) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	wait := max(
+		m.total.bucket.reserve(float64(m.limits.TotalKBps)*1024, n, now),
+		u.bucket.reserve(float64(m.limits.PerPeerKBps)*1024, n, now),
+	)
+	m.throttle += wait
+	return wait
+}
+
+func (m *serveMeter) count(u *usage, n int) {
+	m.mu.Lock()
+	now := time.Now()
+	m.total.add(n, now)
+	u.add(n, now)
+	m.mu.Unlock()
+}
+
+func (m *serveMeter) stats() ServeStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	st := ServeStats{
+		Limits:     m.limits,
+		ServeUsage: m.total.snapshot("", now),
+		ThrottleMs: m.throttle.Milliseconds(),
+		Peers:      make([]ServeUsage, 0, len(m.peers)),
+	}
+	for id, u := range m.peers {
+		st.Peers = append(st.Peers, u.snapshot(id, now))
+	}
+	sort.Slice(st.Peers, func(i, j int) bool {
+		if st.Peers[i].Rate != st.Peers[j].Rate {
+			return st.Peers[i].Rate > st.Peers[j].Rate
+		}
+		return st.Peers[i].Bytes > st.Peers[j].Bytes
+	})
+	return st
+}
+
+// throttledWriter writes in small chunks, sleeping as the limits require.
+type throttledWriter struct {
+	w io.Writer
+	m *serveMeter
+	u *usage
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := min(len(p), serveChunk)
+		if wait := t.m.reserve(t.u, chunk); wait > 0 {
+			time.Sleep(wait)
+		}
+		n, err := t.w.Write(p[:chunk])
+		written += n
+		t.m.count(t.u, n)
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
+
+// SetServeLimits sets the bandwidth caps for serving site and docs files.
+func (n *Node) SetServeLimits(l ServeLimits) {
+	n.serving.setLimits(l)
+}
+
+// ServeStats returns the current serving traffic and limits.
+func (n *Node) ServeStats() ServeStats {
+	return n.serving.stats()
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	var b tokenBucket
+	now := time.Unix(1000, 0)
+
+	if wait := b.reserve(0, 1<<20, now); wait != 0 {
+		t.Fatalf("unlimited bucket waited %v", wait)
+	}
+	// One second of burst is free, the next second's worth must wait.
+	if wait := b.reserve(1000, 1000, now); wait != 0 {
+		t.Fatalf("burst waited %v", wait)
+	}
+	if wait := b.reserve(1000, 500, now); wait != 500*time.Millisecond {
+		t.Fatalf("overdraw waited %v, want 500ms", wait)
+	}
+	// The debt is paid off as time passes.
+	if wait := b.reserve(1000, 500, now.Add(time.Second)); wait != 0 {
+		t.Fatalf("after refill waited %v", wait)
+	}
+}
+
+func TestServeMeterThrottlesAndCounts(t *testing.T) {
+	var m serveMeter
+	m.setLimits(ServeLimits{PerPeerKBps: 16})
+
+	var buf bytes.Buffer
+	w, done := m.begin(&buf, "peerA")
+	if st := m.stats(); st.Active != 1 || len(st.Peers) != 1 || st.Peers[0].Active != 1 {
+		t.Fatalf("active stream not counted: %+v", st)
+	}
+
+	// 16 KB is the burst; 4 KB more takes about a quarter second.
+	payload := bytes.Repeat([]byte("x"), 20*1024)
+	start := time.Now()
+	if n, err := w.Write(payload); err != nil || n != len(payload) {
+		t.Fatalf("write = %d, %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("write took %v, expected throttling", elapsed)
+	}
+	done()
+
+	if !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatal("payload corrupted")
+	}
+	st := m.stats()
+	if st.Bytes != int64(len(payload)) || st.Active != 0 || st.ThrottleMs == 0 {
+		t.Fatalf("totals = %+v", st.ServeUsage)
+	}
+	if p := st.Peers[0]; p.PeerID != "peerA" || p.Bytes != int64(len(payload)) || p.Rate == 0 || p.LastAt == 0 {
+		t.Fatalf("peer usage = %+v", p)
+	}
+
+	// Another requester has its own per-peer budget.
+	w2, done2 := m.begin(&bytes.Buffer{}, "peerB")
+	start = time.Now()
+	w2.Write(payload[:8*1024])
+	done2()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("second peer throttled by the first: %v", elapsed)
+	}
+}
+
+func TestServeMeterUnlimited(t *testing.T) {
+	var m serveMeter
+	var buf bytes.Buffer
+	w, done := m.begin(&buf, "p")
+	defer done()
+	w.Write(make([]byte, 1<<20))
+	if st := m.stats(); st.ThrottleMs != 0 || st.Bytes != 1<<20 {
+		t.Fatalf("unlimited meter = %+v", st)
+	}
+}
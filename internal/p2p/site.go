@@ -31,6 +31,18 @@ func (n *Node) EnableSite(rootDir string) {
 	n.Host.SetStreamHandler(protocol.ID(proto.SiteProtoID), n.handleSiteStream)
 }
 
+// SiteAssets serves processed site assets: optimized image variants and
+// HTML rewritten to reference them.
+type SiteAssets interface {
+	Variant(rel string) ([]byte, bool)
+	RewriteHTML(rel string, html []byte) []byte
+}
+
+// SetSiteAssets enables the asset pipeline for site requests.
+func (n *Node) SetSiteAssets(a SiteAssets) {
+	n.siteAssets = a
+}
+
 func (n *Node) handleSiteStream(s network.Stream) {
 	defer s.Close()
 
@@ -82,10 +94,19 @@ func (n *Node) handleSiteStream(s network.Stream) {
 		return
 	}
 
-	b, err := os.ReadFile(full)
-	if err != nil {
-		_, _ = io.WriteString(s, "ERR not found\n")
-		return
+	rel := filepath.ToSlash(clean)
+	var b []byte
+	if n.siteAssets != nil {
+		b, _ = n.siteAssets.Variant(rel)
+	}
+	if b == nil {
+		if b, err = os.ReadFile(full); err != nil {
+			_, _ = io.WriteString(s, "ERR not found\n")
+			return
+		}
+		if n.siteAssets != nil && strings.EqualFold(filepath.Ext(full), ".html") {
+			b = n.siteAssets.RewriteHTML(rel, b)
+		}
 	}
 
 	mt := mime.TypeByExtension(filepath.Ext(full))
@@ -95,17 +116,19 @@ func (n *Node) handleSiteStream(s network.Stream) {
 
 	// Encrypt binary response if possible
 	remotePeer := s.Conn().RemotePeer().String()
+	out, done := n.serving.begin(s, remotePeer)
+	defer done()
 	if n.enc != nil {
 		if sealed, err := n.enc.Seal(remotePeer, b); err == nil {
 			sealedBytes := []byte(sealed)
 			_, _ = fmt.Fprintf(s, "EOK %s %d\n", mt, len(sealedBytes))
-			_, _ = s.Write(sealedBytes)
+			_, _ = out.Write(sealedBytes)
 			return
 		}
 	}
 
 	_, _ = fmt.Fprintf(s, "OK %s %d\n", mt, len(b))
-	_, _ = s.Write(b)
+	_, _ = out.Write(b)
 }
 
 // dialAndOpenStream connects to a peer and opens a SITE protocol stream.
@@ -0,0 +1,170 @@
+package p2p
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// siteHasher keeps a content hash of the served site. Files are only
+// re-read when their size or modification time changes, so a rescan of an
+// unchanged site is a directory walk.
+type siteHasher struct {
+	mu    sync.Mutex
+	root  string
+	hash  string
+	at    time.Time
+	files map[string]fileDigest
+}
+
+type fileDigest struct {
+	size int64
+	mod  time.Time
+	sum  [sha256.Size]byte
+}
+
+// current returns the hash of root, rescanning at most once per
+// SiteHashRescan.
+func (h *siteHasher) current(root string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.refreshLocked(root)
+}
+
+// manifest returns the hash of root and its files sorted by path, as of
+// the same scan.
+func (h *siteHasher) manifest(root string) (string, []proto.SiteSyncEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hash := h.refreshLocked(root)
+	files := make([]proto.SiteSyncEntry, 0, len(h.files))
+	for rel, fd := range h.files {
+		files = append(files, proto.SiteSyncEntry{Path: rel, Size: fd.size, SHA256: hex.EncodeToString(fd.sum[:])})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return hash, files
+}
+
+// has reports whether rel is one of root's hashed files, i.e. a file a
+// manifest may list.
+func (h *siteHasher) has(root, rel string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refreshLocked(root)
+	_, ok := h.files[rel]
+	return ok
+}
+
+func (h *siteHasher) refreshLocked(root string) string {
+	if root == h.root && time.Since(h.at) < SiteHashRescan {
+		return h.hash
+	}
+	if root != h.root {
+		h.files = nil
+	}
+	h.root = root
+	h.hash = h.scanLocked()
+	h.at = time.Now()
+	return h.hash
+}
+
+// invalidate forces the next call to current to rescan.
+func (h *siteHasher) invalidate() {
+	h.mu.Lock()
+	h.at = time.Time{}
+	h.mu.Unlock()
+}
+
+func (h *siteHasher) scanLocked() string {
+	if h.root == "" {
+		return ""
+	}
+	seen := make(map[string]fileDigest, len(h.files))
+	_ = filepath.WalkDir(h.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, rerr := filepath.Rel(h.root, p)
+		if rerr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		// lua/ is never served, so edits there don't make copies stale.
+		if d.IsDir() {
+			if rel == "lua" || strings.HasPrefix(d.Name(), ".") && rel != "." {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, ierr := d.Info()
+		if ierr != nil {
+			return nil
+		}
+		if fd, ok := h.files[rel]; ok && fd.size == info.Size() && fd.mod.Equal(info.ModTime()) {
+			seen[rel] = fd
+			return nil
+		}
+		sum, serr := hashFile(p)
+		if serr != nil {
+			return nil
+		}
+		seen[rel] = fileDigest{size: info.Size(), mod: info.ModTime(), sum: sum}
+		return nil
+	})
+	h.files = seen
+
+	paths := make([]string, 0, len(seen))
+	for rel := range seen {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	tree := sha256.New()
+	for _, rel := range paths {
+		sum := seen[rel].sum
+		tree.Write([]byte(rel))
+		tree.Write([]byte{0})
+		tree.Write(sum[:])
+	}
+	return hex.EncodeToString(tree.Sum(nil)[:16])
+}
+
+func hashFile(p string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(p)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// SiteHash returns a content hash of the served site, or "" when the site
+// is disabled. It changes whenever a servable file is added,
+// removed or edited, and is announced in presence so peers and the
+// rendezvous can tell when their cached copies are stale.
+func (n *Node) SiteHash() string {
+	return n.siteHash.current(n.siteRoot)
+}
+
+// InvalidateSiteHash makes the next SiteHash call rescan the site right
+// away, for callers that just changed it.
+func (n *Node) InvalidateSiteHash() {
+	n.siteHash.invalidate()
+}
@@ -0,0 +1,68 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSiteHasher(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, data string) {
+		t.Helper()
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", "<p>hi</p>")
+	write("css/site.css", "p{}")
+
+	var h siteHasher
+	if (&siteHasher{}).current("") != "" {
+		t.Fatal("disabled site must have no hash")
+	}
+	first := h.current(root)
+	if len(first) != 32 {
+		t.Fatalf("hash = %q", first)
+	}
+
+	// Within the rescan interval the cached hash is returned.
+	write("index.html", "<p>hello</p>")
+	if got := h.current(root); got != first {
+		t.Fatal("rescanned before SiteHashRescan")
+	}
+	h.invalidate()
+	second := h.current(root)
+	if second == first {
+		t.Fatal("content change not picked up")
+	}
+
+	// Files that are never served don't change the hash.
+	write("lua/functions/x.lua", "return 1")
+	write(".hidden/x", "x")
+	h.invalidate()
+	if got := h.current(root); got != second {
+		t.Fatal("lua/ or hidden files changed the hash")
+	}
+
+	// Same size, new content and mtime: re-read.
+	write("css/site.css", "a{}")
+	future := time.Now().Add(time.Hour)
+	_ = os.Chtimes(filepath.Join(root, "css/site.css"), future, future)
+	h.invalidate()
+	if got := h.current(root); got == second {
+		t.Fatal("same-size edit not picked up")
+	}
+
+	// A fresh hasher agrees.
+	var h2 siteHasher
+	h.invalidate()
+	if h2.current(root) != h.current(root) {
+		t.Fatal("hash is not deterministic")
+	}
+}
@@ -0,0 +1,154 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// SiteSyncMaxFile is the largest site file served or accepted over
+// /goop/site-sync, the same cap as a site request.
+const SiteSyncMaxFile = 50 * 1024 * 1024
+
+// EnableSiteSync registers the site sync handler, so followers can keep a
+// copy of the site EnableSite serves. Files go out as stored on disk,
+// without the asset pipeline, so followers can check them against the
+// manifest; lua/ and dotfiles are never listed or served.
+func (n *Node) EnableSiteSync() {
+	n.Host.SetStreamHandler(protocol.ID(proto.SiteSyncProtoID), n.handleSiteSyncStream)
+}
+
+func (n *Node) handleSiteSyncStream(s network.Stream) {
+	defer s.Close()
+
+	reply := func(resp proto.SiteSyncResponse) {
+		_ = json.NewEncoder(s).Encode(resp)
+	}
+
+	_ = s.SetReadDeadline(time.Now().Add(SiteSyncRequestTimeout))
+	line, err := bufio.NewReader(s).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		reply(proto.SiteSyncResponse{Error: "bad request"})
+		return
+	}
+	_ = s.SetReadDeadline(time.Time{})
+
+	var req proto.SiteSyncRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		reply(proto.SiteSyncResponse{Error: "bad request"})
+		return
+	}
+	if n.siteRoot == "" {
+		reply(proto.SiteSyncResponse{Error: "site disabled"})
+		return
+	}
+
+	switch req.Op {
+	case proto.SiteSyncManifest:
+		hash, files := n.siteHash.manifest(n.siteRoot)
+		setStreamOutcome(s, fmt.Sprintf("manifest:%d", len(files)))
+		reply(proto.SiteSyncResponse{Hash: hash, Files: files})
+
+	case proto.SiteSyncFile:
+		// Only files of the manifest are served, which keeps out lua/,
+		// dotfiles and anything outside the root.
+		if !n.siteHash.has(n.siteRoot, req.Path) {
+			reply(proto.SiteSyncResponse{Error: "not found"})
+			return
+		}
+		b, err := os.ReadFile(filepath.Join(n.siteRoot, filepath.FromSlash(req.Path)))
+		if err != nil {
+			reply(proto.SiteSyncResponse{Error: "not found"})
+			return
+		}
+		if len(b) > SiteSyncMaxFile {
+			reply(proto.SiteSyncResponse{Error: "file too large"})
+			return
+		}
+		out, done := n.serving.begin(s, s.Conn().RemotePeer().String())
+		defer done()
+		setStreamOutcome(s, "file")
+		reply(proto.SiteSyncResponse{Size: int64(len(b))})
+		_, _ = out.Write(b)
+
+	default:
+		reply(proto.SiteSyncResponse{Error: "bad request"})
+	}
+}
+
+// siteSyncRequest sends req to peerID and reads the response line. The
+// caller reads any file content from the returned reader and closes st.
+func (n *Node) siteSyncRequest(ctx context.Context, peerID string, req proto.SiteSyncRequest) (resp proto.SiteSyncResponse, r *bufio.Reader, st network.Stream, err error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return resp, nil, nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	st, err = n.Host.NewStream(network.WithAllowLimitedConn(ctx, "relay"), pid, protocol.ID(proto.SiteSyncProtoID))
+	if err != nil {
+		return resp, nil, nil, err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = st.SetDeadline(dl)
+	}
+	if err := json.NewEncoder(st).Encode(req); err != nil {
+		st.Close()
+		return resp, nil, nil, err
+	}
+	_ = st.CloseWrite()
+
+	r = bufio.NewReader(st)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		st.Close()
+		return resp, nil, nil, err
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		st.Close()
+		return resp, nil, nil, err
+	}
+	if resp.Error != "" {
+		st.Close()
+		return resp, nil, nil, errors.New(resp.Error)
+	}
+	return resp, r, st, nil
+}
+
+// SiteManifest asks peerID for the hash and file list of its site.
+func (n *Node) SiteManifest(ctx context.Context, peerID string) (proto.SiteSyncResponse, error) {
+	resp, _, st, err := n.siteSyncRequest(ctx, peerID, proto.SiteSyncRequest{Op: proto.SiteSyncManifest})
+	if err != nil {
+		return resp, err
+	}
+	st.Close()
+	return resp, nil
+}
+
+// FetchSiteSyncFile fetches one file of peerID's site manifest, as stored
+// on the peer's disk.
+func (n *Node) FetchSiteSyncFile(ctx context.Context, peerID, path string) ([]byte, error) {
+	resp, r, st, err := n.siteSyncRequest(ctx, peerID, proto.SiteSyncRequest{Op: proto.SiteSyncFile, Path: path})
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	if resp.Size < 0 || resp.Size > SiteSyncMaxFile {
+		return nil, fmt.Errorf("refusing size %d", resp.Size)
+	}
+	data := make([]byte, resp.Size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return data, nil
+}
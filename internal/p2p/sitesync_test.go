@@ -0,0 +1,70 @@
+package p2p
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestSiteSync_RoundTrip(t *testing.T) {
+	newNode := func() *Node {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return &Node{Host: h}
+	}
+	server, client := newNode(), newNode()
+
+	root := t.TempDir()
+	for rel, data := range map[string]string{
+		"index.html":     "<p>hi</p>",
+		"css/site.css":   "p{}",
+		"lua/secret.lua": "return 1",
+	} {
+		p := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.siteRoot = root
+	server.EnableSiteSync()
+	if err := client.Host.Connect(context.Background(), peer.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id := server.Host.ID().String()
+
+	m, err := client.SiteManifest(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Hash != server.SiteHash() || len(m.Files) != 2 || m.Files[0].Path != "css/site.css" || m.Files[1].Path != "index.html" {
+		t.Fatalf("manifest = %+v", m)
+	}
+	sum := sha256.Sum256([]byte("<p>hi</p>"))
+	if m.Files[1].SHA256 != hex.EncodeToString(sum[:]) || m.Files[1].Size != 9 {
+		t.Fatalf("index entry = %+v", m.Files[1])
+	}
+
+	b, err := client.FetchSiteSyncFile(ctx, id, "index.html")
+	if err != nil || string(b) != "<p>hi</p>" {
+		t.Fatalf("file = %q, %v", b, err)
+	}
+	for _, p := range []string{"lua/secret.lua", "../etc/passwd", "missing.html"} {
+		if _, err := client.FetchSiteSyncFile(ctx, id, p); err == nil || err.Error() != "not found" {
+			t.Errorf("%s: err = %v", p, err)
+		}
+	}
+}
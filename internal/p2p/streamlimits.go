@@ -0,0 +1,164 @@
+package p2p
+
+// streamlimits.go — caps on the streams one remote peer may hold open at
+// once, so a peer cannot exhaust us by opening streams it never closes.
+// The libp2p resource manager enforces them before a stream reaches the
+// gatekeeper: a stream over a cap is reset by libp2p, and we only hear
+// about it through the resource manager's metrics hooks, which count it
+// for DiagSnapshot and, when the protocol is known, the audit log.
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// Concurrent inbound streams one peer may have open. Protocols libp2p sets
+// its own limits for (identify, ping, relay, hole punching) keep those.
+const (
+	MaxInboundStreamsPerPeer     = 64 // across all protocols
+	MaxInboundStreamsPerProtocol = 16 // on one protocol
+)
+
+// streamLimitAuditEvery throttles audit entries for refused streams to one
+// per peer and protocol, so a flood does not become a flood of writes.
+const streamLimitAuditEvery = 10 * time.Second
+
+// streamLimitMaxPeers bounds how many refused peers are counted one by one.
+const streamLimitMaxPeers = 1000
+
+// newResourceManager builds libp2p's default resource manager with our
+// per-peer stream caps, reporting refusals to sl.
+func newResourceManager(sl *streamLimits) (network.ResourceManager, error) {
+	limits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&limits)
+	caps := rcmgr.PartialLimitConfig{
+		PeerDefault:         rcmgr.ResourceLimits{StreamsInbound: MaxInboundStreamsPerPeer},
+		ProtocolPeerDefault: rcmgr.ResourceLimits{StreamsInbound: MaxInboundStreamsPerProtocol},
+	}
+	return rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(caps.Build(limits.AutoScale())), rcmgr.WithMetrics(sl))
+}
+
+// streamLimits counts the inbound streams the resource manager refused.
+// It implements rcmgr.MetricsReporter.
+type streamLimits struct {
+	gate atomic.Pointer[Gatekeeper] // set once the host exists
+
+	mu        sync.Mutex
+	total     int
+	byPeer    map[peer.ID]int
+	byProto   map[protocol.ID]int
+	audited   map[string]time.Time // peer + protocol -> last audit entry
+	lastBlock time.Time
+}
+
+func newStreamLimits() *streamLimits {
+	return &streamLimits{
+		byPeer:  make(map[peer.ID]int),
+		byProto: make(map[protocol.ID]int),
+		audited: make(map[string]time.Time),
+	}
+}
+
+// BlockStream is called when a stream is over a peer (or system) cap. The
+// protocol is not negotiated yet at this point.
+func (sl *streamLimits) BlockStream(p peer.ID, dir network.Direction) {
+	if dir != network.DirInbound {
+		return
+	}
+	sl.mu.Lock()
+	sl.countLocked(p, "")
+	sl.mu.Unlock()
+}
+
+// BlockProtocolPeer is called when a peer is over the cap of a protocol.
+func (sl *streamLimits) BlockProtocolPeer(proto protocol.ID, p peer.ID) {
+	now := time.Now()
+	sl.mu.Lock()
+	sl.countLocked(p, proto)
+	key := p.String() + " " + string(proto)
+	audit := now.Sub(sl.audited[key]) >= streamLimitAuditEvery
+	if audit {
+		for k, at := range sl.audited {
+			if now.Sub(at) >= streamLimitAuditEvery {
+				delete(sl.audited, k)
+			}
+		}
+		sl.audited[key] = now
+	}
+	sl.mu.Unlock()
+
+	if g := sl.gate.Load(); audit && g != nil {
+		// The resource manager holds its locks here; the audit writes to disk.
+		go g.audit(StreamAudit{Protocol: string(proto), PeerID: p.String(), Outcome: "limited", Start: now})
+	}
+}
+
+func (sl *streamLimits) countLocked(p peer.ID, proto protocol.ID) {
+	sl.total++
+	if _, seen := sl.byPeer[p]; seen || len(sl.byPeer) < streamLimitMaxPeers {
+		sl.byPeer[p]++
+	}
+	if proto != "" {
+		sl.byProto[proto]++
+	}
+	sl.lastBlock = time.Now()
+}
+
+// snapshot describes the caps and refusals for DiagSnapshot, with the ten
+// peers refused most.
+func (sl *streamLimits) snapshot() map[string]any {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	type peerCount struct {
+		PeerID  string `json:"peer_id"`
+		Refused int    `json:"refused"`
+	}
+	peers := make([]peerCount, 0, len(sl.byPeer))
+	for p, n := range sl.byPeer {
+		peers = append(peers, peerCount{PeerID: p.String(), Refused: n})
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Refused > peers[j].Refused })
+	if len(peers) > 10 {
+		peers = peers[:10]
+	}
+	protos := make(map[string]int, len(sl.byProto))
+	for p, n := range sl.byProto {
+		protos[string(p)] = n
+	}
+
+	out := map[string]any{
+		"max_inbound_per_peer":     MaxInboundStreamsPerPeer,
+		"max_inbound_per_protocol": MaxInboundStreamsPerProtocol,
+		"refused":                  sl.total,
+	}
+	if sl.total > 0 {
+		out["refused_peers"] = peers
+		out["refused_protocols"] = protos
+		out["last_refused"] = sl.lastBlock.Format("2006-01-02 15:04:05")
+	}
+	return out
+}
+
+// The remaining rcmgr.MetricsReporter hooks are not needed.
+
+func (sl *streamLimits) AllowConn(network.Direction, bool)      {}
+func (sl *streamLimits) BlockConn(network.Direction, bool)      {}
+func (sl *streamLimits) AllowStream(peer.ID, network.Direction) {}
+func (sl *streamLimits) AllowPeer(peer.ID)                      {}
+func (sl *streamLimits) BlockPeer(peer.ID)                      {}
+func (sl *streamLimits) AllowProtocol(protocol.ID)              {}
+func (sl *streamLimits) BlockProtocol(protocol.ID)              {}
+func (sl *streamLimits) AllowService(string)                    {}
+func (sl *streamLimits) BlockService(string)                    {}
+func (sl *streamLimits) BlockServicePeer(string, peer.ID)       {}
+func (sl *streamLimits) AllowMemory(int)                        {}
+func (sl *streamLimits) BlockMemory(int)                        {}
@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+func TestStreamLimits(t *testing.T) {
+	sl := newStreamLimits()
+	audits := make(chan StreamAudit, 4)
+	gate := NewGatekeeper()
+	gate.SetAuditor(func(a StreamAudit) { audits <- a })
+	sl.gate.Store(gate)
+
+	rm, err := newResourceManager(sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rm.Close()
+
+	p := peer.ID("greedy")
+	proto := protocol.ID("/goop/mq/1.0.0")
+	var open []network.StreamManagementScope
+	defer func() {
+		for _, s := range open {
+			s.Done()
+		}
+	}()
+	openOn := func(pid protocol.ID) error {
+		s, err := rm.OpenStream(p, network.DirInbound)
+		if err != nil {
+			return err
+		}
+		open = append(open, s)
+		return s.SetProtocol(pid)
+	}
+
+	for i := 0; i < MaxInboundStreamsPerProtocol; i++ {
+		if err := openOn(proto); err != nil {
+			t.Fatalf("stream %d refused: %v", i, err)
+		}
+	}
+	if err := openOn(proto); err == nil {
+		t.Fatal("stream over the protocol cap allowed")
+	}
+	select {
+	case a := <-audits:
+		if a.Outcome != "limited" || a.Protocol != string(proto) || a.PeerID != p.String() {
+			t.Fatalf("audit = %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refusal not audited")
+	}
+
+	// Streams on other protocols fill up to the peer cap; the refused one
+	// above still counts until it is closed.
+	for i := 0; len(open) < MaxInboundStreamsPerPeer; i++ {
+		if err := openOn(protocol.ID(fmt.Sprintf("/goop/test/%d", i))); err != nil {
+			t.Fatalf("stream %d refused: %v", len(open), err)
+		}
+	}
+	if _, err := rm.OpenStream(p, network.DirInbound); err == nil {
+		t.Fatal("stream over the peer cap allowed")
+	}
+
+	snap := sl.snapshot()
+	if snap["refused"].(int) != 2 {
+		t.Fatalf("refused = %v, want 2", snap["refused"])
+	}
+	if got := snap["refused_protocols"].(map[string]int)[string(proto)]; got != 1 {
+		t.Fatalf("refused on %s = %d, want 1", proto, got)
+	}
+}
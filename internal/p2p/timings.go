@@ -2,6 +2,8 @@ package p2p
 
 import "time"
 
+// Reachability probes and docs range requests use the tunable
+// timeouts.Probe and timeouts.DocsFetch.
 const (
 	YamuxKeepAlive         = 5 * time.Second
 	RelayWaitPoll          = 250 * time.Millisecond
@@ -11,7 +13,6 @@ const (
 	RelayRecoveryGrace     = 2 * time.Second
 	RelayReserveTimeout    = 3 * time.Second
 	AutoRelayBackoff       = 500 * time.Millisecond
-	ProbeTimeout           = 2 * time.Second
 	ProbeCooldown          = 500 * time.Millisecond
 	AddrTTLMin             = 2 * time.Minute
 	PeerstoreAddrTTL       = 10 * time.Minute
@@ -20,7 +21,24 @@ const (
 	SiteRelayRetryTotal    = 15 * time.Second
 	SiteRelayAttemptTimeout = 5 * time.Second
 	DataLuaCallTimeout     = 30 * time.Second
+	ConsentPromptTimeout   = 60 * time.Second
+	DiagRequestTimeout     = 3 * time.Second
+	DiagRequestMaxSkew     = 2 * time.Minute
+	SiteHashRescan         = 5 * time.Second
+	SearchRequestTimeout   = 3 * time.Second
+	SiteSyncRequestTimeout = 3 * time.Second
+	DataSyncRequestTimeout = 10 * time.Second
+	RelayPingTimeout       = 3 * time.Second
+	RelayReportTimeout     = 5 * time.Second
+	ClockExchangeTimeout   = 2 * time.Second
+	PunchMatchSlack        = time.Second // a conn opened this close to a successful hole punch came from it
+	ClockMeasureTimeout    = 5 * time.Second
+	ClockEstimateTTL       = 10 * time.Minute
+	DocChunkRetryBackoff   = time.Second      // times the attempt number, between range retries
 )
 
+// ClockSamples is the number of exchanges per clock measurement.
+const ClockSamples = 8
+
 // RelayRetryDelays defines the backoff between relay recovery attempts.
 var RelayRetryDelays = []time.Duration{0, 1 * time.Second, 3 * time.Second, 6 * time.Second}
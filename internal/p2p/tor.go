@@ -0,0 +1,226 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/util"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Tor mode: the host dials every peer and relay through the SOCKS5 proxy
+// set with util.UseSOCKS5, listens on loopback only, and advertises an
+// onion service address plus its relay circuit addresses instead of IPs.
+// The onion service itself is run by Tor (HiddenServicePort in torrc,
+// forwarding to the listen port). Peers without Tor reach a Tor peer
+// through the relay, whose reservation is made over Tor as well.
+//
+// Hole punching and mDNS are off in Tor mode: both reveal the peer's
+// addresses to whoever is on the other end.
+
+// Set by UseTor. torOnion is nil for a peer without an onion service.
+var (
+	torEnabled bool
+	torOnion   ma.Multiaddr
+)
+
+// UseTor switches nodes made by New to Tor mode. onion is the onion
+// service address, "<56 chars>.onion" with an optional ":port" that
+// defaults to listenPort; empty means the peer dials out over Tor but is
+// only reachable through the relay. Call util.UseSOCKS5 first.
+func UseTor(onion string, listenPort int) error {
+	torEnabled = true
+	torOnion = nil
+	if onion == "" {
+		return nil
+	}
+	a, err := OnionMultiaddr(onion, listenPort)
+	if err != nil {
+		return err
+	}
+	torOnion = a
+	return nil
+}
+
+// TorMode reports whether UseTor was called.
+func TorMode() bool {
+	return torEnabled
+}
+
+// OnionMultiaddr turns "<56 chars>.onion[:port]" into an /onion3 address,
+// using port when none is given.
+func OnionMultiaddr(onion string, port int) (ma.Multiaddr, error) {
+	host := onion
+	if h, p, err := net.SplitHostPort(onion); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("onion address %q: bad port", onion)
+		}
+		host, port = h, n
+	}
+	id, ok := strings.CutSuffix(strings.ToLower(host), ".onion")
+	if !ok || len(id) != 56 {
+		return nil, fmt.Errorf("onion address %q: want a v3 address, <56 characters>.onion", onion)
+	}
+	a, err := ma.NewMultiaddr(fmt.Sprintf("/onion3/%s:%d", id, port))
+	if err != nil {
+		return nil, fmt.Errorf("onion address %q: %w", onion, err)
+	}
+	return a, nil
+}
+
+func isOnionAddr(a ma.Multiaddr) bool {
+	_, err := a.ValueForProtocol(ma.P_ONION3)
+	return err == nil
+}
+
+// torAddrs is the AddrsFactory of a Tor mode host: the onion address and
+// relay circuit addresses, never the loopback listener or observed IPs.
+func torAddrs(addrs []ma.Multiaddr) []ma.Multiaddr {
+	var out []ma.Multiaddr
+	if torOnion != nil {
+		out = append(out, torOnion)
+	}
+	for _, a := range addrs {
+		if isCircuitAddr(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// torTransport dials /onion3 and public /ip or /dns TCP addresses through
+// the SOCKS5 proxy. Listening is left to a plain TCP transport, which Tor
+// forwards the onion service to.
+type torTransport struct {
+	tcp      *tcp.TcpTransport // for Listen only; its dialer bypasses the proxy
+	upgrader transport.Upgrader
+	rcmgr    network.ResourceManager
+}
+
+var (
+	_ transport.Transport    = (*torTransport)(nil)
+	_ transport.SkipResolver = (*torTransport)(nil)
+)
+
+func newTorTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*torTransport, error) {
+	if rcmgr == nil {
+		rcmgr = &network.NullResourceManager{}
+	}
+	t, err := tcp.NewTCPTransport(upgrader, rcmgr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &torTransport{tcp: t, upgrader: upgrader, rcmgr: rcmgr}, nil
+}
+
+// torTarget returns the host:port the proxy is asked to connect to, or ""
+// when a is not an address Tor can reach.
+func torTarget(a ma.Multiaddr) string {
+	protos := a.Protocols()
+	if len(protos) == 1 && protos[0].Code == ma.P_ONION3 {
+		v, _ := a.ValueForProtocol(ma.P_ONION3)
+		id, port, _ := strings.Cut(v, ":")
+		return net.JoinHostPort(id+".onion", port)
+	}
+	if len(protos) != 2 || protos[1].Code != ma.P_TCP {
+		return ""
+	}
+	switch protos[0].Code {
+	case ma.P_IP4, ma.P_IP6:
+		// Tor exits do not reach private networks.
+		if manet.IsPrivateAddr(a) || manet.IsIPLoopback(a) || manet.IsIPUnspecified(a) {
+			return ""
+		}
+	case ma.P_DNS, ma.P_DNS4, ma.P_DNS6:
+	default:
+		return ""
+	}
+	host, _ := a.ValueForProtocol(protos[0].Code)
+	port, _ := a.ValueForProtocol(ma.P_TCP)
+	return net.JoinHostPort(host, port)
+}
+
+func (t *torTransport) CanDial(a ma.Multiaddr) bool {
+	return torTarget(a) != ""
+}
+
+// SkipResolve keeps the swarm from resolving /dns addresses itself; the
+// proxy resolves them.
+func (t *torTransport) SkipResolve(context.Context, ma.Multiaddr) bool {
+	return true
+}
+
+func (t *torTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	target := torTarget(raddr)
+	if target == "" {
+		return nil, fmt.Errorf("tor: cannot dial %s", raddr)
+	}
+	if !util.Proxied() {
+		return nil, errors.New("tor: no SOCKS5 proxy in use")
+	}
+	scope, err := t.rcmgr.OpenConnection(network.DirOutbound, true, raddr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := t.dial(ctx, raddr, target, p, scope)
+	if err != nil {
+		scope.Done()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *torTransport) dial(ctx context.Context, raddr ma.Multiaddr, target string, p peer.ID, scope network.ConnManagementScope) (transport.CapableConn, error) {
+	if err := scope.SetPeer(p); err != nil {
+		return nil, err
+	}
+	conn, err := util.Dial(ctx, "tcp", target)
+	if err != nil {
+		return nil, err
+	}
+	return t.upgrader.Upgrade(ctx, t, &torConn{Conn: conn, raddr: raddr}, network.DirOutbound, p, scope)
+}
+
+// Listen listens on a loopback address, where the onion service forwards
+// to; anything else would take connections around Tor.
+func (t *torTransport) Listen(laddr ma.Multiaddr) (transport.Listener, error) {
+	if !manet.IsIPLoopback(laddr) {
+		return nil, fmt.Errorf("tor: refusing to listen on %s, only loopback", laddr)
+	}
+	return t.tcp.Listen(laddr)
+}
+
+func (t *torTransport) Protocols() []int {
+	return []int{ma.P_TCP, ma.P_ONION3}
+}
+
+func (t *torTransport) Proxy() bool {
+	return false
+}
+
+func (t *torTransport) String() string {
+	return "Tor"
+}
+
+// torConn is a connection through the proxy, known by the address that
+// was dialed rather than the proxy's.
+type torConn struct {
+	net.Conn
+	raddr ma.Multiaddr
+}
+
+var torLocalAddr = ma.StringCast("/ip4/127.0.0.1/tcp/0")
+
+func (c *torConn) LocalMultiaddr() ma.Multiaddr  { return torLocalAddr }
+func (c *torConn) RemoteMultiaddr() ma.Multiaddr { return c.raddr }
@@ -0,0 +1,162 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/util"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+const testOnionID = "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd"
+
+func TestOnionMultiaddr(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{testOnionID + ".onion", "/onion3/" + testOnionID + ":4001"},
+		{strings.ToUpper(testOnionID) + ".onion:80", "/onion3/" + testOnionID + ":80"},
+		{"example.com", ""},
+		{"abc.onion", ""},
+		{testOnionID + ".onion:x", ""},
+	}
+	for _, c := range cases {
+		a, err := OnionMultiaddr(c.in, 4001)
+		if c.want == "" {
+			if err == nil {
+				t.Errorf("OnionMultiaddr(%q) = %s, want an error", c.in, a)
+			}
+			continue
+		}
+		if err != nil || a.String() != c.want {
+			t.Errorf("OnionMultiaddr(%q) = %v, %v; want %s", c.in, a, err, c.want)
+		}
+	}
+}
+
+func TestTorTarget(t *testing.T) {
+	cases := map[string]string{
+		"/onion3/" + testOnionID + ":4001":    testOnionID + ".onion:4001",
+		"/ip4/93.184.216.34/tcp/4001":         "93.184.216.34:4001",
+		"/dns4/relay.example.com/tcp/4001":    "relay.example.com:4001",
+		"/ip4/192.168.1.10/tcp/4001":          "", // private: no exit reaches it
+		"/ip4/127.0.0.1/tcp/4001":             "",
+		"/ip4/93.184.216.34/udp/4001/quic-v1": "",
+		"/ip4/93.184.216.34/tcp/443/ws":       "",
+	}
+	for s, want := range cases {
+		if got := torTarget(ma.StringCast(s)); got != want {
+			t.Errorf("torTarget(%s) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+// forwardingSOCKS5 is a SOCKS5 proxy that connects every CONNECT for a
+// host name to target, and reports the requested address on the channel.
+func forwardingSOCKS5(t *testing.T, target string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	requested := make(chan string, 4)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				buf := make([]byte, 262)
+				if _, err := io.ReadFull(c, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(c, buf[:buf[1]])
+				c.Write([]byte{5, 0})
+				if _, err := io.ReadFull(c, buf[:5]); err != nil || buf[3] != 3 {
+					return
+				}
+				n := int(buf[4])
+				io.ReadFull(c, buf[:n+2])
+				requested <- net.JoinHostPort(string(buf[:n]), strconv.Itoa(int(buf[n])<<8|int(buf[n+1])))
+				up, err := net.Dial("tcp", target)
+				if err != nil {
+					c.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer up.Close()
+				c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(up, c)
+				io.Copy(c, up)
+			}()
+		}
+	}()
+	return ln.Addr().String(), requested
+}
+
+func TestTorMode_DialsOnionThroughProxy(t *testing.T) {
+	target, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	targetAddr, err := manet.ToNetAddr(target.Addrs()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyAddr, requested := forwardingSOCKS5(t, targetAddr.String())
+	if err := util.UseSOCKS5(proxyAddr); err != nil {
+		t.Fatal(err)
+	}
+	if err := UseTor(testOnionID+".onion:4001", 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		util.UseSOCKS5("")
+		torEnabled, torOnion = false, nil
+	})
+
+	empty := func() string { return "" }
+	n, err := New(t.Context(), 0, filepath.Join(t.TempDir(), "identity.key"), nil, empty, empty, func() bool { return false }, empty, empty, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	if addrs := n.WanAddrs(); len(addrs) != 1 || addrs[0] != "/onion3/"+testOnionID+":4001" {
+		t.Fatalf("advertised %v, want only the onion address", addrs)
+	}
+	for _, a := range n.Host.Network().ListenAddresses() {
+		if !isCircuitAddr(a) && !manet.IsIPLoopback(a) {
+			t.Fatalf("listening on %s", a)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	// The target's own loopback address cannot be dialed around the proxy.
+	if err := n.Host.Connect(ctx, peer.AddrInfo{ID: target.ID(), Addrs: target.Addrs()}); err == nil {
+		t.Fatal("dialed a loopback address directly")
+	}
+	onion := ma.StringCast("/onion3/" + testOnionID + ":4001")
+	if err := n.Host.Connect(ctx, peer.AddrInfo{ID: target.ID(), Addrs: []ma.Multiaddr{onion}}); err != nil {
+		t.Fatalf("connect over the proxy: %v", err)
+	}
+	if got := <-requested; got != testOnionID+".onion:4001" {
+		t.Fatalf("proxy asked for %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package pairing
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// rule grants one scope access to a route. A path ending in "/" matches
+// everything below it; method "" matches any method.
+type rule struct {
+	scope  string
+	method string
+	path   string
+}
+
+// rules is the whole remote-control surface. Anything not listed here is
+// refused for paired devices, however it is scoped.
+var rules = []rule{
+	{ScopeNotify, http.MethodGet, "/api/mq/events"},
+	{ScopeNotify, http.MethodPost, "/api/mq/ack"},
+	{ScopeNotify, http.MethodGet, "/api/peers"},
+	{ScopeNotify, http.MethodGet, "/api/avatar/peer/"},
+
+	// Calls run in browser mode on the phone: signaling goes over MQ (topic
+	// checked by SendAllowed), media never touches the native call stack.
+	// Incoming calls ring through the event stream, so it is open here too.
+	{ScopeCall, http.MethodGet, "/api/mq/events"},
+	{ScopeCall, http.MethodPost, "/api/mq/ack"},
+	{ScopeCall, http.MethodGet, "/api/call/mode"},
+	{ScopeCall, http.MethodGet, "/api/call/history"},
+	{ScopeCall, http.MethodPost, "/api/mq/send"},
+	{ScopeCall, http.MethodPost, "/api/chat/call"},
+
+	{ScopeConsent, "", "/api/security/consent"},
+
+	{ScopeListen, "", "/api/listen/"},
+
+	// Guests only watch: every rule is GET, so nothing they can reach
+	// changes state. No event stream either, since it carries chat.
+	{ScopeGuest, http.MethodGet, "/peers"},
+	{ScopeGuest, http.MethodGet, "/api/peers"},
+	{ScopeGuest, http.MethodGet, "/api/self"},
+	{ScopeGuest, http.MethodGet, "/api/avatar"},
+	{ScopeGuest, http.MethodGet, "/api/avatar/peer/"},
+	{ScopeGuest, http.MethodGet, "/api/listen/state"},
+	{ScopeGuest, http.MethodGet, "/api/listen/stream"},
+	{ScopeGuest, http.MethodGet, "/p/"},
+}
+
+// Allowed reports whether dev may call method on path.
+func Allowed(dev storage.PairedDevice, method, path string) bool {
+	for _, r := range rules {
+		if r.method != "" && r.method != method {
+			continue
+		}
+		if !matchPath(r.path, path) {
+			continue
+		}
+		if slices.Contains(dev.Scopes, r.scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGuest reports whether dev is a guest session rather than a paired device.
+func IsGuest(dev storage.PairedDevice) bool {
+	return slices.Equal(dev.Scopes, []string{ScopeGuest})
+}
+
+// ScopesFor lists the scopes that open method on path to paired devices.
+func ScopesFor(method, path string) []string {
+	var out []string
+	for _, r := range rules {
+		if (r.method == "" || r.method == method) && matchPath(r.path, path) && !slices.Contains(out, r.scope) {
+			out = append(out, r.scope)
+		}
+	}
+	return out
+}
+
+// SendAllowed reports whether dev may send an MQ message on topic. Only call
+// signaling is open to paired devices; chat and the rest stay local.
+func SendAllowed(dev storage.PairedDevice, topic string) bool {
+	return slices.Contains(dev.Scopes, ScopeCall) && strings.HasPrefix(topic, mq.TopicCallPrefix)
+}
+
+func matchPath(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return path == pattern
+}
+
+// CookieName holds the device token for browser sessions on the remote
+// listener; other clients send "Authorization: Bearer <token>".
+const CookieName = "goop_remote"
+
+// TokenFromRequest returns the device token carried by r, or "".
+func TokenFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if c, err := r.Cookie(CookieName); err == nil {
+		return c.Value
+	}
+	return ""
+}
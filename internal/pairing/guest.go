@@ -0,0 +1,111 @@
+package pairing
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+const (
+	// GuestDefaultTTL is how long a guest session lasts when no duration
+	// is asked for.
+	GuestDefaultTTL = 30 * time.Minute
+	// GuestMaxTTL caps a guest session.
+	GuestMaxTTL = 8 * time.Hour
+)
+
+// ErrNoGuest is returned when revoking an unknown or expired guest session.
+var ErrNoGuest = errors.New("no such guest session")
+
+// Guest is a time-limited, read-only viewer session, for letting someone
+// on the LAN watch the UI (peers, what is playing, a site preview) while
+// it is being shown. Guest sessions live in memory only: a restart ends
+// them all.
+type Guest struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"created_at"` // Unix ms
+	ExpiresAt int64  `json:"expires_at"` // Unix ms
+}
+
+func (g Guest) device() storage.PairedDevice {
+	return storage.PairedDevice{
+		ID:        g.ID,
+		Name:      "Guest",
+		Scopes:    []string{ScopeGuest},
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+// StartGuest opens a guest session lasting ttl (0 = GuestDefaultTTL,
+// capped at GuestMaxTTL). The returned token is the only copy.
+func (m *Manager) StartGuest(ttl time.Duration) (string, Guest, error) {
+	if ttl <= 0 {
+		ttl = GuestDefaultTTL
+	}
+	ttl = min(ttl, GuestMaxTTL)
+	token, err := randomHex(32)
+	if err != nil {
+		return "", Guest{}, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", Guest{}, err
+	}
+	now := time.Now()
+	g := Guest{ID: id, CreatedAt: now.UnixMilli(), ExpiresAt: now.Add(ttl).UnixMilli()}
+	m.mu.Lock()
+	m.pruneGuestsLocked(now)
+	m.guests[hashToken(token)] = g
+	m.mu.Unlock()
+	return token, g, nil
+}
+
+// Guests lists the guest sessions that have not expired, newest first.
+func (m *Manager) Guests() []Guest {
+	m.mu.Lock()
+	m.pruneGuestsLocked(time.Now())
+	out := make([]Guest, 0, len(m.guests))
+	for _, g := range m.guests {
+		out = append(out, g)
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+// RevokeGuest ends a guest session at once.
+func (m *Manager) RevokeGuest(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for hash, g := range m.guests {
+		if g.ID == id {
+			delete(m.guests, hash)
+			return nil
+		}
+	}
+	return ErrNoGuest
+}
+
+func (m *Manager) guest(hash string) (Guest, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.guests[hash]
+	if !ok {
+		return Guest{}, false
+	}
+	if time.Now().UnixMilli() > g.ExpiresAt {
+		delete(m.guests, hash)
+		return Guest{}, false
+	}
+	return g, true
+}
+
+func (m *Manager) pruneGuestsLocked(now time.Time) {
+	for hash, g := range m.guests {
+		if now.UnixMilli() > g.ExpiresAt {
+			delete(m.guests, hash)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package pairing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGuestSession(t *testing.T) {
+	m := testManager(t)
+	token, g, err := m.StartGuest(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Duration(g.ExpiresAt-g.CreatedAt) * time.Millisecond; ttl != GuestMaxTTL {
+		t.Fatalf("ttl = %v, want capped at %v", ttl, GuestMaxTTL)
+	}
+
+	dev, ok := m.Authenticate(token)
+	if !ok || !IsGuest(dev) || dev.ID != g.ID {
+		t.Fatalf("Authenticate = %+v, %v", dev, ok)
+	}
+	if !Allowed(dev, http.MethodGet, "/api/listen/state") || Allowed(dev, http.MethodPost, "/api/listen/control") {
+		t.Fatal("guest scope is not read-only")
+	}
+	if Allowed(dev, http.MethodGet, "/api/mq/events") {
+		t.Fatal("guest can read the event stream")
+	}
+
+	if err := m.RevokeGuest(g.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Authenticate(token); ok {
+		t.Fatal("revoked guest still authenticates")
+	}
+	if err := m.RevokeGuest(g.ID); err != ErrNoGuest {
+		t.Fatalf("second revoke = %v", err)
+	}
+}
+
+func TestGuestSession_Expires(t *testing.T) {
+	m := testManager(t)
+	token, g, err := m.StartGuest(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	for h := range m.guests {
+		g.ExpiresAt = time.Now().Add(-time.Second).UnixMilli()
+		m.guests[h] = g
+	}
+	m.mu.Unlock()
+
+	if _, ok := m.Authenticate(token); ok {
+		t.Fatal("expired guest authenticates")
+	}
+	if len(m.Guests()) != 0 {
+		t.Fatal("expired guest listed")
+	}
+}
@@ -0,0 +1,226 @@
+// Package pairing lets a phone (or any browser on the LAN) control this peer
+// through a scoped bearer token instead of the full, localhost-only viewer.
+//
+// The local UI asks for a short-lived numeric code; the phone submits it once
+// and gets a long random token back. Only a hash of the token is stored. Each
+// token carries scopes that decide which viewer routes the device may use
+// (see Allowed).
+package pairing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Scopes a paired device can hold.
+const (
+	ScopeNotify  = "notify"  // event stream: notifications, incoming calls, prompts
+	ScopeCall    = "call"    // accept and place calls with the phone's own camera/mic
+	ScopeConsent = "consent" // answer inbound access prompts (docs/data requests)
+	ScopeListen  = "listen"  // control the listen room queue and playback
+
+	// ScopeGuest is read-only viewing for a guest session (see StartGuest).
+	// Pairing codes never grant it.
+	ScopeGuest = "guest"
+)
+
+// AllScopes is what a pairing code grants when no scopes are requested.
+var AllScopes = []string{ScopeNotify, ScopeCall, ScopeConsent, ScopeListen}
+
+const (
+	// CodeTTL is how long a pairing code can be claimed.
+	CodeTTL = 2 * time.Minute
+	// maxClaimFailures wrong codes discard the pending code, so a 6-digit
+	// code cannot be brute-forced within its lifetime.
+	maxClaimFailures = 5
+	codeDigits       = 6
+	// touchInterval throttles last_seen writes for busy devices.
+	touchInterval = time.Minute
+)
+
+var (
+	ErrNoCode  = errors.New("no pairing code pending")
+	ErrBadCode = errors.New("wrong pairing code")
+)
+
+// Code is a pending pairing code as shown in the local UI.
+type Code struct {
+	Code      string   `json:"code"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expires_at"` // Unix ms
+}
+
+// Manager issues pairing codes and authenticates paired devices.
+type Manager struct {
+	db *storage.DB
+
+	mu       sync.Mutex
+	pending  *Code
+	failures int
+	touched  map[string]time.Time // device ID -> last TouchPairedDevice
+	guests   map[string]Guest     // token hash -> guest session
+}
+
+// New creates a manager that keeps paired devices in db.
+func New(db *storage.DB) *Manager {
+	return &Manager{db: db, touched: make(map[string]time.Time), guests: make(map[string]Guest)}
+}
+
+// Start issues a new pairing code for scopes (nil = AllScopes), replacing
+// any code still pending.
+func (m *Manager) Start(scopes []string) (Code, error) {
+	if len(scopes) == 0 {
+		scopes = AllScopes
+	}
+	for _, s := range scopes {
+		if !slices.Contains(AllScopes, s) {
+			return Code{}, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return Code{}, err
+	}
+	c := Code{
+		Code:      fmt.Sprintf("%0*d", codeDigits, n.Int64()),
+		Scopes:    slices.Clone(scopes),
+		ExpiresAt: time.Now().Add(CodeTTL).UnixMilli(),
+	}
+	m.mu.Lock()
+	m.pending, m.failures = &c, 0
+	m.mu.Unlock()
+	return c, nil
+}
+
+// Pending returns the code waiting to be claimed, if any.
+func (m *Manager) Pending() (Code, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending == nil || time.Now().UnixMilli() > m.pending.ExpiresAt {
+		return Code{}, false
+	}
+	return *m.pending, true
+}
+
+// Claim exchanges the pending code for a device token. The code is single
+// use; the returned token is the only copy and cannot be recovered later.
+func (m *Manager) Claim(code, name string) (string, storage.PairedDevice, error) {
+	m.mu.Lock()
+	p := m.pending
+	if p == nil || time.Now().UnixMilli() > p.ExpiresAt {
+		m.pending = nil
+		m.mu.Unlock()
+		return "", storage.PairedDevice{}, ErrNoCode
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(code)), []byte(p.Code)) != 1 {
+		m.failures++
+		if m.failures >= maxClaimFailures {
+			m.pending = nil
+		}
+		m.mu.Unlock()
+		return "", storage.PairedDevice{}, ErrBadCode
+	}
+	m.pending = nil
+	m.mu.Unlock()
+
+	token, err := randomHex(32)
+	if err != nil {
+		return "", storage.PairedDevice{}, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", storage.PairedDevice{}, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Remote device"
+	}
+	dev := storage.PairedDevice{
+		ID:        id,
+		Name:      name,
+		Scopes:    p.Scopes,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := m.db.AddPairedDevice(dev, hashToken(token)); err != nil {
+		return "", storage.PairedDevice{}, err
+	}
+	return token, dev, nil
+}
+
+// Authenticate returns the device a token belongs to. Guest sessions come
+// back as a device holding only ScopeGuest.
+func (m *Manager) Authenticate(token string) (storage.PairedDevice, bool) {
+	if token == "" {
+		return storage.PairedDevice{}, false
+	}
+	hash := hashToken(token)
+	if g, ok := m.guest(hash); ok {
+		return g.device(), true
+	}
+	dev, ok := m.db.PairedDeviceByToken(hash)
+	if !ok {
+		return dev, false
+	}
+	m.mu.Lock()
+	stale := time.Since(m.touched[dev.ID]) > touchInterval
+	if stale {
+		m.touched[dev.ID] = time.Now()
+	}
+	m.mu.Unlock()
+	if stale {
+		_ = m.db.TouchPairedDevice(dev.ID)
+	}
+	return dev, true
+}
+
+// Devices lists the paired devices.
+func (m *Manager) Devices() ([]storage.PairedDevice, error) {
+	return m.db.ListPairedDevices()
+}
+
+// Revoke unpairs a device.
+func (m *Manager) Revoke(id string) error {
+	m.mu.Lock()
+	delete(m.touched, id)
+	m.mu.Unlock()
+	return m.db.DeletePairedDevice(id)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type ctxKey struct{}
+
+// WithDevice marks a request context as coming from a paired device.
+func WithDevice(ctx context.Context, dev storage.PairedDevice) context.Context {
+	return context.WithValue(ctx, ctxKey{}, dev)
+}
+
+// FromContext returns the paired device behind a request, or ok=false for
+// the local UI.
+func FromContext(ctx context.Context) (storage.PairedDevice, bool) {
+	dev, ok := ctx.Value(ctxKey{}).(storage.PairedDevice)
+	return dev, ok
+}
@@ -0,0 +1,112 @@
+package pairing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func testManager(t *testing.T) *Manager {
+	t.Helper()
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db)
+}
+
+func TestClaimRoundTrip(t *testing.T) {
+	m := testManager(t)
+	c, err := m.Start([]string{ScopeNotify, ScopeCall})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Code) != codeDigits {
+		t.Fatalf("code = %q", c.Code)
+	}
+
+	token, dev, err := m.Claim(c.Code, "Phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Claim(c.Code, "Phone"); err != ErrNoCode {
+		t.Errorf("second claim err = %v, want ErrNoCode", err)
+	}
+
+	got, ok := m.Authenticate(token)
+	if !ok || got.ID != dev.ID || len(got.Scopes) != 2 {
+		t.Fatalf("Authenticate = %+v, %v", got, ok)
+	}
+	if _, ok := m.Authenticate(token + "x"); ok {
+		t.Error("tampered token authenticated")
+	}
+
+	if err := m.Revoke(dev.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Authenticate(token); ok {
+		t.Error("revoked token still authenticates")
+	}
+}
+
+func TestClaimFailuresDiscardCode(t *testing.T) {
+	m := testManager(t)
+	c, err := m.Start(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if c.Code == wrong {
+		wrong = "111111"
+	}
+	for range maxClaimFailures {
+		if _, _, err := m.Claim(wrong, ""); err != ErrBadCode {
+			t.Fatalf("err = %v, want ErrBadCode", err)
+		}
+	}
+	if _, _, err := m.Claim(c.Code, ""); err != ErrNoCode {
+		t.Errorf("right code after too many failures: err = %v, want ErrNoCode", err)
+	}
+}
+
+func TestStartRejectsUnknownScope(t *testing.T) {
+	if _, err := testManager(t).Start([]string{"admin"}); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	dev := storage.PairedDevice{Scopes: []string{ScopeNotify, ScopeListen}}
+	cases := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodGet, "/api/mq/events", true},
+		{http.MethodPost, "/api/mq/events", false},
+		{http.MethodPost, "/api/listen/control", true},
+		{http.MethodGet, "/api/call/mode", false},         // no call scope
+		{http.MethodPost, "/api/security/consent", false}, // no consent scope
+		{http.MethodPost, "/api/settings/quick", false},   // never remote
+		{http.MethodGet, "/api/peers/favorite", false},    // exact match only
+	}
+	for _, c := range cases {
+		if got := Allowed(dev, c.method, c.path); got != c.want {
+			t.Errorf("Allowed(%s %s) = %v, want %v", c.method, c.path, got, c.want)
+		}
+	}
+}
+
+func TestSendAllowed(t *testing.T) {
+	caller := storage.PairedDevice{Scopes: []string{ScopeCall}}
+	if !SendAllowed(caller, "call:abc") {
+		t.Error("call signaling refused")
+	}
+	if SendAllowed(caller, "chat") {
+		t.Error("chat allowed for call scope")
+	}
+	if SendAllowed(storage.PairedDevice{Scopes: []string{ScopeNotify}}, "call:abc") {
+		t.Error("call signaling allowed without call scope")
+	}
+}
@@ -0,0 +1,200 @@
+// Package permalink builds content-addressed links to peer pages,
+// goop://<peerID>/<hash>/<path>, and keeps pinned copies of the pages
+// they point at so a link still resolves after the peer changes the
+// page or goes offline.
+package permalink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scheme is the URL scheme of a permalink.
+const Scheme = "goop://"
+
+// Hash returns the content hash used in permalinks: the first 16 bytes of
+// the SHA-256 of the page, hex-encoded.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Link is a parsed permalink.
+type Link struct {
+	PeerID string `json:"peer_id"`
+	Hash   string `json:"hash"`
+	Path   string `json:"path"` // always starts with "/"
+}
+
+func (l Link) String() string {
+	return Scheme + l.PeerID + "/" + l.Hash + l.Path
+}
+
+// ViewerPath is where the viewer resolves the link.
+func (l Link) ViewerPath() string {
+	return "/permalink/" + l.PeerID + "/" + l.Hash + l.Path
+}
+
+// Parse reads a goop:// permalink, or its /permalink/ viewer form.
+func Parse(s string) (Link, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, Scheme):
+		s = strings.TrimPrefix(s, Scheme)
+	case strings.HasPrefix(s, "/permalink/"):
+		s = strings.TrimPrefix(s, "/permalink/")
+	default:
+		return Link{}, errors.New("not a goop:// permalink")
+	}
+	parts := strings.SplitN(s, "/", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return Link{}, errors.New("permalink needs a peer ID and a hash")
+	}
+	l := Link{PeerID: parts[0], Hash: strings.ToLower(parts[1]), Path: "/index.html"}
+	if !validHash(l.Hash) {
+		return Link{}, fmt.Errorf("bad content hash %q", parts[1])
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		l.Path = CleanPath(parts[2])
+	}
+	return l, nil
+}
+
+// CleanPath normalizes a site path to "/a/b.html"; "" and "/" mean the
+// index page.
+func CleanPath(p string) string {
+	p = path.Clean("/" + strings.TrimPrefix(p, "/"))
+	if p == "/" {
+		return "/index.html"
+	}
+	return p
+}
+
+func validHash(h string) bool {
+	if len(h) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(h)
+	return err == nil
+}
+
+// Pin is the metadata of a pinned copy.
+type Pin struct {
+	Link
+	MIME     string `json:"mime"`
+	Size     int    `json:"size"`
+	PinnedAt int64  `json:"pinned_at"` // unix seconds
+}
+
+// Store keeps pinned copies on disk, one content file and one metadata
+// file per hash.
+type Store struct {
+	dir string
+}
+
+// NewStore opens the pin store in dir, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put pins data under l. data must hash to l.Hash.
+func (s *Store) Put(l Link, mime string, data []byte) (Pin, error) {
+	if Hash(data) != l.Hash {
+		return Pin{}, errors.New("content does not match the permalink hash")
+	}
+	p := Pin{Link: l, MIME: mime, Size: len(data), PinnedAt: time.Now().Unix()}
+	meta, err := json.Marshal(p)
+	if err != nil {
+		return Pin{}, err
+	}
+	if err := os.WriteFile(s.file(l.Hash, ".bin"), data, 0o644); err != nil {
+		return Pin{}, err
+	}
+	if err := os.WriteFile(s.file(l.Hash, ".json"), meta, 0o644); err != nil {
+		return Pin{}, err
+	}
+	return p, nil
+}
+
+// Get returns a pinned copy. The error wraps os.ErrNotExist when hash is
+// not pinned.
+func (s *Store) Get(hash string) (Pin, []byte, error) {
+	if !validHash(hash) {
+		return Pin{}, nil, os.ErrNotExist
+	}
+	meta, err := os.ReadFile(s.file(hash, ".json"))
+	if err != nil {
+		return Pin{}, nil, err
+	}
+	var p Pin
+	if err := json.Unmarshal(meta, &p); err != nil {
+		return Pin{}, nil, err
+	}
+	data, err := os.ReadFile(s.file(hash, ".bin"))
+	if err != nil {
+		return Pin{}, nil, err
+	}
+	return p, data, nil
+}
+
+// Has reports whether hash is pinned.
+func (s *Store) Has(hash string) bool {
+	if !validHash(hash) {
+		return false
+	}
+	_, err := os.Stat(s.file(hash, ".json"))
+	return err == nil
+}
+
+// Remove unpins hash.
+func (s *Store) Remove(hash string) error {
+	if !validHash(hash) {
+		return os.ErrNotExist
+	}
+	if err := os.Remove(s.file(hash, ".json")); err != nil {
+		return err
+	}
+	_ = os.Remove(s.file(hash, ".bin"))
+	return nil
+}
+
+// List returns all pins, newest first.
+func (s *Store) List() ([]Pin, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []Pin{}
+	for _, e := range entries {
+		hash, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !validHash(hash) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var p Pin
+		if json.Unmarshal(b, &p) == nil {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PinnedAt > out[j].PinnedAt })
+	return out, nil
+}
+
+func (s *Store) file(hash, ext string) string {
+	return filepath.Join(s.dir, hash+ext)
+}
@@ -0,0 +1,60 @@
+package permalink
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	h := Hash([]byte("page"))
+	for in, want := range map[string]Link{
+		"goop://12D3Koo/" + h + "/blog/post.html":  {PeerID: "12D3Koo", Hash: h, Path: "/blog/post.html"},
+		"goop://12D3Koo/" + h:                      {PeerID: "12D3Koo", Hash: h, Path: "/index.html"},
+		"/permalink/12D3Koo/" + h + "/a/../b.html": {PeerID: "12D3Koo", Hash: h, Path: "/b.html"},
+	} {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %+v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"https://x/y", "goop://peer", "goop://peer/nothex/x", "goop:///" + h} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted", bad)
+		}
+	}
+
+	l := Link{PeerID: "p", Hash: h, Path: "/x.html"}
+	if back, _ := Parse(l.String()); back != l {
+		t.Fatalf("round trip = %+v", back)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("<h1>v1</h1>")
+	l := Link{PeerID: "p", Hash: Hash(data), Path: "/index.html"}
+
+	if _, err := s.Put(Link{PeerID: "p", Hash: Hash([]byte("other")), Path: "/"}, "text/html", data); err == nil {
+		t.Fatal("pinned content under the wrong hash")
+	}
+	if _, err := s.Put(l, "text/html", data); err != nil {
+		t.Fatal(err)
+	}
+	p, got, err := s.Get(l.Hash)
+	if err != nil || string(got) != string(data) || p.Path != "/index.html" || p.MIME != "text/html" {
+		t.Fatalf("Get = %+v %q %v", p, got, err)
+	}
+	if list, _ := s.List(); len(list) != 1 || !s.Has(l.Hash) {
+		t.Fatalf("List = %+v", list)
+	}
+	if err := s.Remove(l.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(l.Hash); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("after Remove: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Package prom writes metrics in the Prometheus text exposition format.
+// Components keep their own counters and write them on each scrape through
+// a Collector, so nothing is registered globally and a component that is
+// not running simply contributes no metrics.
+package prom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector writes its metrics on each scrape.
+type Collector interface {
+	WriteMetrics(w *Writer)
+}
+
+// CollectorFunc adapts a function to Collector.
+type CollectorFunc func(w *Writer)
+
+func (f CollectorFunc) WriteMetrics(w *Writer) { f(w) }
+
+// Writer formats samples. Samples of one metric must be written one after
+// another; HELP and TYPE go out before the first.
+type Writer struct {
+	w    *bufio.Writer
+	seen map[string]bool
+}
+
+func newWriter(buf *bytes.Buffer) *Writer {
+	return &Writer{w: bufio.NewWriter(buf), seen: make(map[string]bool)}
+}
+
+// Counter writes a monotonically increasing value. labels are name/value pairs.
+func (w *Writer) Counter(name, help string, v float64, labels ...string) {
+	w.header(name, help, "counter")
+	w.sample(name, v, labels)
+}
+
+// Gauge writes a value that can go up and down.
+func (w *Writer) Gauge(name, help string, v float64, labels ...string) {
+	w.header(name, help, "gauge")
+	w.sample(name, v, labels)
+}
+
+// Histogram is a bucketed distribution. Counts has one entry per bound
+// (not cumulative) plus a final overflow bucket.
+type Histogram struct {
+	Bounds []float64
+	Counts []int64
+	Count  int64
+	Sum    float64
+}
+
+// Histogram writes h as cumulative buckets with _sum and _count.
+func (w *Writer) Histogram(name, help string, h Histogram, labels ...string) {
+	w.header(name, help, "histogram")
+	var cum int64
+	for i, le := range h.Bounds {
+		if i < len(h.Counts) {
+			cum += h.Counts[i]
+		}
+		w.sample(name+"_bucket", float64(cum), append(labels[:len(labels):len(labels)], "le", formatFloat(le)))
+	}
+	w.sample(name+"_bucket", float64(h.Count), append(labels[:len(labels):len(labels)], "le", "+Inf"))
+	w.sample(name+"_sum", h.Sum, labels)
+	w.sample(name+"_count", float64(h.Count), labels)
+}
+
+func (w *Writer) header(name, help, typ string) {
+	if w.seen[name] {
+		return
+	}
+	w.seen[name] = true
+	fmt.Fprintf(w.w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+func (w *Writer) sample(name string, v float64, labels []string) {
+	w.w.WriteString(name)
+	if len(labels) > 1 {
+		w.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.w.WriteByte(',')
+			}
+			fmt.Fprintf(w.w, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		w.w.WriteByte('}')
+	}
+	w.w.WriteByte(' ')
+	w.w.WriteString(formatFloat(v))
+	w.w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// Write runs the collectors and returns the exposition.
+func Write(cs ...Collector) []byte {
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	for _, c := range cs {
+		if c != nil {
+			c.WriteMetrics(w)
+		}
+	}
+	w.w.Flush()
+	return buf.Bytes()
+}
+
+// Handler serves the collectors' metrics.
+func Handler(cs ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body := Write(cs...)
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})
+}
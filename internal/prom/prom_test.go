@@ -0,0 +1,64 @@
+package prom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	got := string(Write(CollectorFunc(func(w *Writer) {
+		w.Gauge("goop_peers", "Peers seen.", 3)
+		w.Counter("goop_bytes_total", "Bytes by direction.", 10, "direction", "in")
+		w.Counter("goop_bytes_total", "Bytes by direction.", 20, "direction", "out")
+		w.Gauge("goop_label", "Escaping.", 1, "name", "a\"b\\c\nd")
+		w.Histogram("goop_latency_seconds", "Latency.", Histogram{
+			Bounds: []float64{0.01, 0.1},
+			Counts: []int64{2, 1, 1},
+			Count:  4,
+			Sum:    0.5,
+		}, "topic", "chat")
+	})))
+
+	want := `# HELP goop_peers Peers seen.
+# TYPE goop_peers gauge
+goop_peers 3
+# HELP goop_bytes_total Bytes by direction.
+# TYPE goop_bytes_total counter
+goop_bytes_total{direction="in"} 10
+goop_bytes_total{direction="out"} 20
+# HELP goop_label Escaping.
+# TYPE goop_label gauge
+goop_label{name="a\"b\\c\nd"} 1
+# HELP goop_latency_seconds Latency.
+# TYPE goop_latency_seconds histogram
+goop_latency_seconds_bucket{topic="chat",le="0.01"} 2
+goop_latency_seconds_bucket{topic="chat",le="0.1"} 3
+goop_latency_seconds_bucket{topic="chat",le="+Inf"} 4
+goop_latency_seconds_sum{topic="chat"} 0.5
+goop_latency_seconds_count{topic="chat"} 4
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(CollectorFunc(func(w *Writer) { w.Gauge("goop_up", "Up.", 1) }), nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "goop_up 1\n") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status %d", rec.Code)
+	}
+}
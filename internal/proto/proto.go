@@ -1,7 +1,11 @@
 
 package proto
 
-import "time"
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
 
 const (
 	PresenceTopic = "goop.presence.v1"
@@ -22,14 +26,118 @@ const (
 	// libp2p stream protocol ID for group document sharing
 	DocsProtoID = "/goop/docs/1.0.0"
 
+	// libp2p stream protocol ID for chunked, resumable document transfers
+	DocsChunkProtoID = "/goop/docs/2.0.0"
+
 	// libp2p stream protocol ID for listening room audio streaming
 	ListenProtoID = "/goop/listen/1.0.0"
 
 	// libp2p stream protocol ID for the message queue transport
 	MQProtoID = "/goop/mq/1.0.0"
 
+	// libp2p stream protocol ID the rendezvous uses to query peer diagnostics
+	DiagProtoID = "/goop/diag/1.0.0"
+
+	// libp2p stream protocol ID for fetching oversized MQ payloads by hash
+	MQBlobProtoID = "/goop/mqblob/1.0.0"
+
+	// libp2p stream protocol ID for keyword search over what a peer exposes
+	SearchProtoID = "/goop/search/1.0.0"
+
+	// libp2p stream protocol ID for estimating the clock offset to a peer
+	TimeProtoID = "/goop/time/1.0.0"
+
+	// libp2p stream protocol ID followers use to keep a copy of a peer's site
+	SiteSyncProtoID = "/goop/site-sync/1.0.0"
+
+	// libp2p stream protocol ID template group members use to replicate the
+	// host's group-policy tables
+	DataSyncProtoID = "/goop/data-sync/1.0.0"
+
+)
+
+// Diagnostic access scopes a peer can grant the rendezvous admin.
+const (
+	DiagScopeConnectivity = "connectivity" // addresses, relay and connection state
+	DiagScopeFull         = "full"         // connectivity plus recent diag logs and host info
+)
+
+// DiagRequest is the first line the rendezvous writes on a /goop/diag stream.
+// Sig is the relay host key's signature over SigningBytes, so the peer can
+// check the request came from its rendezvous after an admin asked for it.
+type DiagRequest struct {
+	Peer  string `json:"peer"`  // target peer ID
+	Scope string `json:"scope"` // DiagScopeConnectivity or DiagScopeFull
+	TS    int64  `json:"ts"`    // Unix ms
+	Nonce string `json:"nonce"`
+	Sig   []byte `json:"sig"`
+}
+
+// SigningBytes returns the canonical bytes covered by Sig.
+func (r DiagRequest) SigningBytes() []byte {
+	return []byte("goop-diag|" + r.Peer + "|" + r.Scope + "|" + strconv.FormatInt(r.TS, 10) + "|" + r.Nonce)
+}
+
+// TimeSample is one NTP-style exchange on a /goop/time stream, in Unix
+// microseconds. The asking peer sends T0, its send time; the answering
+// peer echoes it with T1 (received) and T2 (replied).
+type TimeSample struct {
+	T0 int64 `json:"t0"`
+	T1 int64 `json:"t1,omitempty"`
+	T2 int64 `json:"t2,omitempty"`
+}
+
+// SearchRequest is the line a peer writes on a /goop/search stream.
+type SearchRequest struct {
+	Query string `json:"q"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// SearchHit is one result of a search: a site page or a shared document.
+type SearchHit struct {
+	Kind    string  `json:"kind"` // "page" or "doc"
+	Title   string  `json:"title"`
+	Path    string  `json:"path"` // site path for pages, file name for docs
+	Snippet string  `json:"snippet,omitempty"`
+	Group   string  `json:"group,omitempty"` // docs: group ID
+	Size    int64   `json:"size,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// SearchResponse is the reply on a /goop/search stream.
+type SearchResponse struct {
+	Hits  []SearchHit `json:"hits"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Operations of a /goop/site-sync request.
+const (
+	SiteSyncManifest = "manifest" // the site hash and every servable file
+	SiteSyncFile     = "file"     // one file of the manifest, as stored on disk
 )
 
+// SiteSyncRequest is the line a follower writes on a /goop/site-sync stream.
+type SiteSyncRequest struct {
+	Op   string `json:"op"`
+	Path string `json:"path,omitempty"` // file: slash-separated, relative to the site root
+}
+
+// SiteSyncEntry is one file in a site manifest.
+type SiteSyncEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"` // hex
+}
+
+// SiteSyncResponse is the JSON line answering a site sync request. The
+// answer to a file request is followed by Size bytes of file content.
+type SiteSyncResponse struct {
+	Hash  string          `json:"hash,omitempty"` // manifest: the hash announced in presence
+	Files []SiteSyncEntry `json:"files,omitempty"`
+	Size  int64           `json:"size,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
 const (
 	TypeOnline  = "online"
 	TypeUpdate  = "update"
@@ -51,8 +159,28 @@ type PresenceMsg struct {
 	PublicKey            string   `json:"publicKey,omitempty"`            // NaCl public key for peer-to-peer encryption
 	EncryptionSupported  bool     `json:"encryptionSupported,omitempty"` // Peer supports E2E encrypted protocols
 	GoopClientVersion   string   `json:"goopClientVersion,omitempty"`
+	PublicSite           bool     `json:"publicSite,omitempty"` // Opt-in: the rendezvous may mirror the site publicly
+	SiteHash             string   `json:"siteHash,omitempty"`   // Content hash of the served site; changes when cached copies go stale
 	TS                   int64    `json:"ts"`
 	Verified          bool     `json:"verified,omitempty"` // Set by rendezvous server (email verified)
+	Sig               []byte   `json:"sig,omitempty"`      // Sender's identity key signature over SigningBytes
+}
+
+// SigningBytes returns the canonical bytes covered by Sig: the fields a
+// rendezvous could rewrite to impersonate a peer. JSON-encoded rather than
+// joined, since labels and emails are free text.
+func (m PresenceMsg) SigningBytes() []byte {
+	b, _ := json.Marshal([]any{"goop-presence", m.Type, m.PeerID, m.Content, m.Email, m.AvatarHash, m.ActiveTemplate, m.SiteHash, m.TS})
+	return b
+}
+
+// PresenceTopicFor returns the gossip topic of a presence namespace; the
+// empty name is the global PresenceTopic.
+func PresenceTopicFor(namespace string) string {
+	if namespace == "" {
+		return PresenceTopic
+	}
+	return PresenceTopic + "/" + namespace
 }
 
 func NowMillis() int64 { return time.Now().UnixMilli() }
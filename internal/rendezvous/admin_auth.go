@@ -0,0 +1,380 @@
+package rendezvous
+
+// admin_auth.go — admin accounts, roles and API tokens.
+//
+// The admin password from config logs in as "admin" with the operator
+// role. Operators add named accounts (bcrypt-hashed) and API tokens for
+// automation (SHA-256 of the token) in the peer DB. Every admin endpoint
+// asks for the lowest role that may use it:
+//
+//   - viewer: admin page, peers, logs, relay status and usage, public sites
+//   - moderator: registrations and credit accounts (people's data)
+//   - operator: peer diagnostics, sales, template prices, accounts, tokens
+//
+// Failed logins are throttled per client IP, see throttle.go.
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type adminRole int
+
+const (
+	roleNone adminRole = iota
+	roleViewer
+	roleModerator
+	roleOperator
+)
+
+var adminRoleNames = [...]string{"", "viewer", "moderator", "operator"}
+
+func (r adminRole) String() string { return adminRoleNames[r] }
+
+// parseAdminRole returns roleNone for unknown names.
+func parseAdminRole(s string) adminRole {
+	for i, name := range adminRoleNames {
+		if i > 0 && name == s {
+			return adminRole(i)
+		}
+	}
+	return roleNone
+}
+
+const (
+	adminConfigUser     = "admin"    // user name for the config admin password
+	adminTokenPrefix    = "goopadm_" // makes tokens recognisable in logs and secret scanners
+	adminMinPasswordLen = 8
+)
+
+// adminUserRow is a named admin account in the peer DB.
+type adminUserRow struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	PassHash string `json:"-"`
+	Created  int64  `json:"created"` // unix millis
+}
+
+// adminTokenRow is an API token in the peer DB. Only its hash is stored.
+type adminTokenRow struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Role     string `json:"role"`
+	Hash     string `json:"-"`
+	Created  int64  `json:"created"`   // unix millis
+	LastUsed int64  `json:"last_used"` // unix millis, 0 = never
+}
+
+// adminAuth caches verified account passwords, so the admin page polling
+// every few seconds does not run bcrypt on each request.
+type adminAuth struct {
+	mu    sync.Mutex
+	cache map[[32]byte]adminCacheEntry
+}
+
+type adminCacheEntry struct {
+	role    adminRole
+	expires time.Time
+}
+
+func newAdminAuth() *adminAuth {
+	return &adminAuth{cache: map[[32]byte]adminCacheEntry{}}
+}
+
+func (a *adminAuth) lookup(key [32]byte) adminRole {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e, ok := a.cache[key]
+	if !ok || time.Now().After(e.expires) {
+		delete(a.cache, key)
+		return roleNone
+	}
+	return e.role
+}
+
+func (a *adminAuth) store(key [32]byte, role adminRole) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache[key] = adminCacheEntry{role: role, expires: time.Now().Add(AdminAuthCacheTTL)}
+}
+
+// reset forgets all cached logins after an account changes.
+func (a *adminAuth) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	clear(a.cache)
+}
+
+func hashAdminToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// adminEnabled reports whether anyone can log in to the admin pages.
+func (s *Server) adminEnabled() bool {
+	return s.adminPassword != "" || s.peerDB != nil && s.peerDB.countAdminUsers() > 0
+}
+
+// adminIdentity returns who the request authenticates as and their role:
+// a Bearer API token, the config admin password, or a named account.
+func (s *Server) adminIdentity(r *http.Request) (string, adminRole) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if s.peerDB == nil {
+			return "", roleNone
+		}
+		row, ok := s.peerDB.lookupAdminToken(hashAdminToken(strings.TrimSpace(token)))
+		if !ok {
+			return "", roleNone
+		}
+		if now := time.Now().UnixMilli(); now-row.LastUsed > AdminTokenTouchInterval.Milliseconds() {
+			s.peerDB.touchAdminToken(row.ID, now)
+		}
+		return "token " + row.ID, parseAdminRole(row.Role)
+	}
+
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return "", roleNone
+	}
+	if user == adminConfigUser && s.adminPassword != "" &&
+		subtle.ConstantTimeCompare([]byte(pass), []byte(s.adminPassword)) == 1 {
+		return user, roleOperator
+	}
+	if s.peerDB == nil {
+		return "", roleNone
+	}
+	key := sha256.Sum256([]byte(user + "\x00" + pass))
+	if role := s.adminAuth.lookup(key); role != roleNone {
+		return user, role
+	}
+	row, ok := s.peerDB.lookupAdminUser(user)
+	if !ok || bcrypt.CompareHashAndPassword([]byte(row.PassHash), []byte(pass)) != nil {
+		return "", roleNone
+	}
+	role := parseAdminRole(row.Role)
+	s.adminAuth.store(key, role)
+	return user, role
+}
+
+// authenticate is adminIdentity behind the login throttle. Requests from a
+// locked-out IP get roleNone and the time left; wrong credentials count
+// toward a lockout.
+func (s *Server) authenticate(r *http.Request) (adminRole, time.Duration) {
+	ip := extractIP(r.RemoteAddr)
+	if d := s.loginThrottle.locked(ip); d > 0 {
+		return roleNone, d
+	}
+	if _, role := s.adminIdentity(r); role != roleNone {
+		s.loginThrottle.succeed(ip)
+		return role, 0
+	}
+	if r.Header.Get("Authorization") == "" {
+		return roleNone, 0
+	}
+	if d := s.loginThrottle.fail(ip); d > 0 {
+		s.addLog(fmt.Sprintf("admin: %s locked out for %s after repeated failed logins", ip, d))
+		return roleNone, d
+	}
+	return roleNone, 0
+}
+
+// isAdmin returns true if the request carries any valid admin credentials.
+func (s *Server) isAdmin(r *http.Request) bool {
+	role, _ := s.authenticate(r)
+	return role >= roleViewer
+}
+
+// requireRole checks the request's admin credentials against need. Returns
+// true if authorized.
+func (s *Server) requireRole(w http.ResponseWriter, r *http.Request, need adminRole) bool {
+	if !s.adminEnabled() {
+		http.Error(w, "admin panel disabled", http.StatusForbidden)
+		return false
+	}
+	role, locked := s.authenticate(r)
+	if locked > 0 {
+		writeLockedOut(w, locked)
+		return false
+	}
+	if role == roleNone {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Goop2 Admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if role < need {
+		http.Error(w, need.String()+" role required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// requireAdminDB is requireRole(operator) for the account and token
+// endpoints, which also need the peer DB.
+func (s *Server) requireAdminDB(w http.ResponseWriter, r *http.Request) (who string, ok bool) {
+	if !s.requireRole(w, r, roleOperator) {
+		return "", false
+	}
+	if s.peerDB == nil {
+		http.Error(w, "admin accounts need presence.peer_db_path", http.StatusServiceUnavailable)
+		return "", false
+	}
+	who, _ = s.adminIdentity(r)
+	return who, true
+}
+
+// handleAdminUsersJSON lists (GET), adds or updates (POST) and removes
+// (DELETE ?name=) named admin accounts.
+func (s *Server) handleAdminUsersJSON(w http.ResponseWriter, r *http.Request) {
+	who, ok := s.requireAdminDB(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		users, err := s.peerDB.loadAdminUsers()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": users})
+
+	case http.MethodPost:
+		var req struct {
+			Name     string `json:"name"`
+			Role     string `json:"role"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if err := validateAdminUser(req.Name, req.Role, req.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		row := adminUserRow{Name: req.Name, Role: req.Role, PassHash: string(hash), Created: time.Now().UnixMilli()}
+		if err := s.peerDB.upsertAdminUser(row); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.adminAuth.reset()
+		log.Printf("admin: %s saved account %q (%s)", who, row.Name, row.Role)
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(row)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if !s.peerDB.deleteAdminUser(name) {
+			http.Error(w, "no such account", http.StatusNotFound)
+			return
+		}
+		s.adminAuth.reset()
+		log.Printf("admin: %s removed account %q", who, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func validateAdminUser(name, role, password string) error {
+	switch {
+	case name == "" || len(name) > 64 || strings.ContainsAny(name, ": \t"):
+		return errors.New("name must be 1-64 characters without spaces or colons")
+	case name == adminConfigUser:
+		return errors.New(`"admin" is the config admin password's account`)
+	case parseAdminRole(role) == roleNone:
+		return errors.New("role must be viewer, moderator or operator")
+	case len(password) < adminMinPasswordLen:
+		return errors.New("password must be at least 8 characters")
+	}
+	return nil
+}
+
+// handleAPITokensJSON lists (GET), creates (POST) and revokes (DELETE ?id=)
+// API tokens. The token itself is only in the POST response.
+func (s *Server) handleAPITokensJSON(w http.ResponseWriter, r *http.Request) {
+	who, ok := s.requireAdminDB(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		tokens, err := s.peerDB.loadAdminTokens()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]any{"tokens": tokens})
+
+	case http.MethodPost:
+		var req struct {
+			Label string `json:"label"`
+			Role  string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if parseAdminRole(req.Role) == roleNone {
+			http.Error(w, "role must be viewer, moderator or operator", http.StatusBadRequest)
+			return
+		}
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		token := adminTokenPrefix + hex.EncodeToString(secret)
+		hash := hashAdminToken(token)
+		row := adminTokenRow{
+			ID:      hash[:12],
+			Label:   strings.TrimSpace(req.Label),
+			Role:    req.Role,
+			Hash:    hash,
+			Created: time.Now().UnixMilli(),
+		}
+		if err := s.peerDB.insertAdminToken(row); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("admin: %s created %s token %s (%q)", who, row.Role, row.ID, row.Label)
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(struct {
+			adminTokenRow
+			Token string `json:"token"`
+		}{row, token})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if !s.peerDB.deleteAdminToken(id) {
+			http.Error(w, "no such token", http.StatusNotFound)
+			return
+		}
+		log.Printf("admin: %s revoked token %s", who, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
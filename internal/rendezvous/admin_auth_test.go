@@ -0,0 +1,145 @@
+package rendezvous
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newAdminTestServer(t *testing.T, password string) *Server {
+	t.Helper()
+	s := New("127.0.0.1:0", filepath.Join(t.TempDir(), "peers.db"), password, "", 0, 0, "", RelayTimingConfig{})
+	if s.peerDB == nil {
+		t.Fatal("peer DB not opened")
+	}
+	t.Cleanup(func() { s.peerDB.close() })
+	return s
+}
+
+// adminDo runs handler with auth applied to the request and returns the recorder.
+func adminDo(handler http.HandlerFunc, method, target, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if auth != nil {
+		auth(r)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func basic(user, pass string) func(*http.Request) {
+	return func(r *http.Request) { r.SetBasicAuth(user, pass) }
+}
+
+func bearer(token string) func(*http.Request) {
+	return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+}
+
+func TestAdminAuth_rolesPerEndpoint(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	root := basic("admin", "secret")
+
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "wrong")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: %d", w.Code)
+	}
+	if w := adminDo(s.handleAdminUsersJSON, "POST", "/admin-users.json", `{"name":"alice","role":"viewer","password":"alicepass"}`, root); w.Code != http.StatusOK {
+		t.Fatalf("add account: %d %s", w.Code, w.Body)
+	}
+
+	alice := basic("alice", "alicepass")
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", alice); w.Code != http.StatusOK {
+		t.Errorf("viewer on peers: %d", w.Code)
+	}
+	for name, h := range map[string]http.HandlerFunc{"diag": s.handleDiagPeer, "users": s.handleAdminUsersJSON, "tokens": s.handleAPITokensJSON} {
+		if w := adminDo(h, "GET", "/", "", alice); w.Code != http.StatusForbidden {
+			t.Errorf("viewer on %s: %d", name, w.Code)
+		}
+	}
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("alice", "alicepass2")); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong account password: %d", w.Code)
+	}
+
+	// Promoting the account takes effect despite the cached login.
+	adminDo(s.handleAdminUsersJSON, "POST", "/admin-users.json", `{"name":"alice","role":"operator","password":"alicepass"}`, root)
+	if w := adminDo(s.handleAdminUsersJSON, "GET", "/admin-users.json", "", alice); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"role":"operator"`) {
+		t.Errorf("promoted account: %d %s", w.Code, w.Body)
+	}
+	if list := adminDo(s.handleAdminUsersJSON, "GET", "/admin-users.json", "", root).Body.String(); strings.Contains(list, "pass") {
+		t.Error("account list exposes the password hash")
+	}
+
+	if w := adminDo(s.handleAdminUsersJSON, "DELETE", "/admin-users.json?name=alice", "", root); w.Code != http.StatusNoContent {
+		t.Fatalf("remove account: %d", w.Code)
+	}
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", alice); w.Code != http.StatusUnauthorized {
+		t.Errorf("removed account: %d", w.Code)
+	}
+}
+
+func TestAdminAuth_validatesAccounts(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	for _, body := range []string{
+		`{"name":"admin","role":"viewer","password":"longenough"}`,
+		`{"name":"bob","role":"root","password":"longenough"}`,
+		`{"name":"bob","role":"viewer","password":"short"}`,
+		`{"name":"bo:b","role":"viewer","password":"longenough"}`,
+	} {
+		if rec := adminDo(s.handleAdminUsersJSON, "POST", "/admin-users.json", body, basic("admin", "secret")); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d", body, rec.Code)
+		}
+	}
+}
+
+func TestAdminAuth_apiTokens(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	rec := adminDo(s.handleAPITokensJSON, "POST", "/api-tokens.json", `{"label":"monitoring","role":"moderator"}`, basic("admin", "secret"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create token: %d %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.Token, adminTokenPrefix) || created.ID == "" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	tok := bearer(created.Token)
+	if rec := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", tok); rec.Code != http.StatusOK {
+		t.Errorf("token on peers: %d", rec.Code)
+	}
+	if rec := adminDo(s.handleDiagPeer, "GET", "/diag", "", tok); rec.Code != http.StatusForbidden {
+		t.Errorf("moderator token on diag: %d", rec.Code)
+	}
+	if rec := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", bearer(created.Token+"x")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad token: %d", rec.Code)
+	}
+
+	list := adminDo(s.handleAPITokensJSON, "GET", "/api-tokens.json", "", basic("admin", "secret")).Body.String()
+	if strings.Contains(list, created.Token) || !strings.Contains(list, `"last_used":`) || strings.Contains(list, `"last_used":0`) {
+		t.Errorf("token list = %s", list)
+	}
+
+	adminDo(s.handleAPITokensJSON, "DELETE", "/api-tokens.json?id="+created.ID, "", basic("admin", "secret"))
+	if rec := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", tok); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: %d", rec.Code)
+	}
+}
+
+func TestAdminAuth_accountsWithoutConfigPassword(t *testing.T) {
+	s := newAdminTestServer(t, "")
+	if rec := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "")); rec.Code != http.StatusForbidden {
+		t.Fatalf("no admin configured: %d", rec.Code)
+	}
+	s.peerDB.upsertAdminUser(adminUserRow{Name: "ops", Role: "operator", PassHash: "$2a$10$invalid"})
+	if !s.adminEnabled() {
+		t.Error("admin disabled with an account in the peer DB")
+	}
+	if rec := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty config password accepted: %d", rec.Code)
+	}
+}
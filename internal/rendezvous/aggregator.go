@@ -0,0 +1,332 @@
+package rendezvous
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Presence aggregation: a rendezvous on a site's LAN stands in for the peers
+// that publish to it on an upstream (WAN) rendezvous. Their heartbeats go up
+// as one batch per interval instead of one connection and frame per peer,
+// and the upstream's presence comes down over a single event stream and is
+// rebroadcast to the local peers. LAN peers use the aggregator as their
+// rendezvous_wan and need no other change.
+
+// AggregatorConfig points a rendezvous at its upstream.
+type AggregatorConfig struct {
+	Upstream      string        // base URL of the WAN rendezvous
+	BatchInterval time.Duration // how often heartbeats go upstream, 0 = AggregatorBatchInterval
+}
+
+// PresenceBatch is the body of POST /publish/batch: presence of several
+// peers, each message as its peer signed it.
+type PresenceBatch struct {
+	Messages []proto.PresenceMsg `json:"messages"`
+}
+
+// PresenceBatchResult is the response to POST /publish/batch.
+type PresenceBatchResult struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// aggregator queues local presence for the upstream and tracks which peers
+// are local, so their presence coming back down is not relayed twice.
+type aggregator struct {
+	client   *Client
+	interval time.Duration
+	urgent   chan struct{} // online and offline go up without waiting
+
+	mu       sync.Mutex
+	pending  map[string]proto.PresenceMsg // latest unsent presence per local peer
+	local    map[string]time.Time         // local peer → last presence
+	relay    *RelayInfo                   // upstream relay, nil = none or not fetched yet
+	sent     int64                        // messages delivered upstream
+	batches  int64
+	received int64 // upstream messages relayed to local peers
+	lastErr  string
+	lastSent time.Time
+}
+
+// SetAggregator makes the server aggregate presence toward cfg.Upstream.
+// Call before Start.
+func (s *Server) SetAggregator(cfg AggregatorConfig) {
+	interval := cfg.BatchInterval
+	if interval <= 0 {
+		interval = AggregatorBatchInterval
+	}
+	s.agg = &aggregator{
+		client:   NewClient(cfg.Upstream),
+		interval: interval,
+		urgent:   make(chan struct{}, 1),
+		pending:  make(map[string]proto.PresenceMsg),
+		local:    make(map[string]time.Time),
+	}
+}
+
+// queue records presence a local peer published here. Later messages of the
+// same peer replace earlier unsent ones.
+func (a *aggregator) queue(pm proto.PresenceMsg) {
+	a.mu.Lock()
+	a.pending[pm.PeerID] = pm
+	if pm.Type == proto.TypeOffline {
+		delete(a.local, pm.PeerID)
+	} else {
+		a.local[pm.PeerID] = time.Now()
+	}
+	a.mu.Unlock()
+
+	if pm.Type != proto.TypeUpdate {
+		select {
+		case a.urgent <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *aggregator) isLocal(peerID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.local[peerID]
+	return ok
+}
+
+func (a *aggregator) relayInfo() *RelayInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.relay
+}
+
+// take empties the queue, forgetting local peers that went quiet.
+func (a *aggregator) take() []proto.PresenceMsg {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cutoff := time.Now().Add(-AggregatorLocalTTL)
+	for id, seen := range a.local {
+		if seen.Before(cutoff) {
+			delete(a.local, id)
+		}
+	}
+	if len(a.pending) == 0 {
+		return nil
+	}
+	msgs := make([]proto.PresenceMsg, 0, len(a.pending))
+	for _, pm := range a.pending {
+		msgs = append(msgs, pm)
+	}
+	clear(a.pending)
+	return msgs
+}
+
+// requeue puts back messages that did not go out, unless a newer message
+// of the same peer arrived meanwhile.
+func (a *aggregator) requeue(msgs []proto.PresenceMsg) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, pm := range msgs {
+		if _, newer := a.pending[pm.PeerID]; !newer {
+			a.pending[pm.PeerID] = pm
+		}
+	}
+}
+
+// flush sends the queued presence upstream in batches of at most
+// AggregatorMaxBatch messages.
+func (a *aggregator) flush(ctx context.Context) error {
+	msgs := a.take()
+	for len(msgs) > 0 {
+		n := min(len(msgs), AggregatorMaxBatch)
+		if err := a.client.PublishBatch(ctx, msgs[:n]); err != nil {
+			a.requeue(msgs)
+			a.mu.Lock()
+			a.lastErr = err.Error()
+			a.mu.Unlock()
+			return err
+		}
+		a.mu.Lock()
+		a.sent += int64(n)
+		a.batches++
+		a.lastErr = ""
+		a.lastSent = time.Now()
+		a.mu.Unlock()
+		msgs = msgs[n:]
+	}
+	return nil
+}
+
+// runAggregator relays upstream presence to local peers and sends theirs
+// upstream until ctx ends.
+func (s *Server) runAggregator(ctx context.Context) {
+	a := s.agg
+	log.Printf("rendezvous: aggregating presence toward %s every %s", a.client.BaseURL, a.interval)
+
+	go a.client.SubscribeEvents(ctx, s.fromUpstream)
+	go a.refreshRelay(ctx)
+
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Say goodbye for peers whose offline is still queued.
+			fctx, cancel := context.WithTimeout(context.Background(), HTTPClientTimeout)
+			_ = a.flush(fctx)
+			cancel()
+			return
+		case <-t.C:
+		case <-a.urgent:
+		}
+		if err := a.flush(ctx); err != nil && ctx.Err() == nil {
+			s.addLog(fmt.Sprintf("Aggregator: upstream publish failed: %v", err))
+		}
+	}
+}
+
+// fromUpstream applies presence from the upstream rendezvous locally. Local
+// peers' own presence echoed back is dropped: they are already here, first hand.
+func (s *Server) fromUpstream(pm proto.PresenceMsg) {
+	if pm.Type == proto.TypePunch || s.agg.isLocal(pm.PeerID) {
+		return
+	}
+	s.agg.mu.Lock()
+	s.agg.received++
+	s.agg.mu.Unlock()
+
+	b, _ := json.Marshal(pm)
+	addrsChanged := s.upsertPeer(pm, int64(len(b)), pm.Verified, "", s.agg.client.BaseURL)
+	s.broadcast(b)
+	if pm.Type == proto.TypeOnline || pm.Type == proto.TypeUpdate {
+		s.emitPunchHints(pm, addrsChanged)
+	}
+}
+
+// refreshRelay keeps a copy of the upstream relay info, so LAN peers that
+// only know the aggregator still get a WAN relay. The copy is served as the
+// upstream signed it.
+func (a *aggregator) refreshRelay(ctx context.Context) {
+	for {
+		var info RelayInfo
+		found, err := a.client.getJSON(ctx, a.client.BaseURL+"/relay", &info)
+		if err == nil {
+			a.mu.Lock()
+			if found {
+				a.relay = &info
+			} else {
+				a.relay = nil
+			}
+			a.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(AggregatorRelayRefresh):
+		}
+	}
+}
+
+// status describes the aggregator for the topology view.
+func (a *aggregator) status() map[string]any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := map[string]any{
+		"upstream":    a.client.BaseURL,
+		"local_peers": len(a.local),
+		"batches":     a.batches,
+		"sent":        a.sent,
+		"received":    a.received,
+		"has_relay":   a.relay != nil,
+	}
+	if !a.lastSent.IsZero() {
+		st["last_sent"] = a.lastSent.UnixMilli()
+	}
+	if a.lastErr != "" {
+		st["error"] = a.lastErr
+	}
+	return st
+}
+
+// handlePublishBatch accepts presence of several peers from an aggregator.
+// Each message is checked as if its peer had published it; bad ones are
+// counted and skipped rather than failing the batch.
+func (s *Server) handlePublishBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.bans.ipBanned(extractIP(r.RemoteAddr)) {
+		http.Error(w, "banned", http.StatusForbidden)
+		return
+	}
+	if !s.allowPublish(extractIP(r.RemoteAddr)) {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	var batch PresenceBatch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, AggregatorMaxBatchBytes)).Decode(&batch); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	if len(batch.Messages) > AggregatorMaxBatch {
+		http.Error(w, fmt.Sprintf("batch too large (max %d)", AggregatorMaxBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var res PresenceBatchResult
+	for _, pm := range batch.Messages {
+		if _, err := s.checkPresence(&pm); err != nil {
+			res.Rejected++
+			continue
+		}
+		s.acceptPresence(pm)
+		res.Accepted++
+	}
+	if res.Accepted > 0 {
+		s.addLog(fmt.Sprintf("Received batch of %d from %s (%d rejected)", res.Accepted, extractIP(r.RemoteAddr), res.Rejected))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// PublishBatch sends presence of several peers in one request. An upstream
+// without /publish/batch gets them one by one.
+func (c *Client) PublishBatch(ctx context.Context, msgs []proto.PresenceMsg) error {
+	if c.BaseURL == "" || len(msgs) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(PresenceBatch{Messages: msgs})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/publish/batch", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		for _, pm := range msgs {
+			if err := c.Publish(ctx, pm); err != nil {
+				return err
+			}
+		}
+		return nil
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("publish batch status %s", resp.Status)
+	}
+	return nil
+}
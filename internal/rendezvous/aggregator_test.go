@@ -0,0 +1,111 @@
+package rendezvous
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func hasPeer(s *Server, peerID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.peers[peerID]
+	return ok
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAggregatorRelaysBothWays(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := New("127.0.0.1:18792", "", "", "", 0, 0, "", RelayTimingConfig{})
+	if err := up.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	agg := New("127.0.0.1:18793", "", "", "", 0, 0, "", RelayTimingConfig{})
+	agg.SetAggregator(AggregatorConfig{Upstream: up.URL(), BatchInterval: 100 * time.Millisecond})
+	if err := agg.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	publishPeer(t, agg.URL(), "lan-1")
+	publishPeer(t, agg.URL(), "lan-2")
+	waitFor(t, "LAN peers upstream", func() bool { return hasPeer(up, "lan-1") && hasPeer(up, "lan-2") })
+
+	publishPeer(t, up.URL(), "wan-1")
+	waitFor(t, "WAN peer on the aggregator", func() bool { return hasPeer(agg, "wan-1") })
+
+	st := agg.agg.status()
+	if st["sent"].(int64) < 2 {
+		t.Errorf("sent = %v, want >= 2", st["sent"])
+	}
+	if st["local_peers"].(int) != 2 {
+		t.Errorf("local_peers = %v, want 2 (echoes of LAN peers must not count as remote)", st["local_peers"])
+	}
+}
+
+func TestPublishBatchRejectsBadMessages(t *testing.T) {
+	base, cancel := startTestServer(t)
+	defer cancel()
+
+	batch := PresenceBatch{Messages: []proto.PresenceMsg{
+		{Type: proto.TypeOnline, PeerID: "batch-ok", TS: proto.NowMillis()},
+		{Type: "bogus", PeerID: "batch-bad"},
+		{Type: proto.TypePunch, PeerID: "batch-punch", Target: "batch-ok"},
+	}}
+	b, _ := json.Marshal(batch)
+	resp, err := http.Post(base+"/publish/batch", "application/json", strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var res PresenceBatchResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Accepted != 1 || res.Rejected != 2 {
+		t.Errorf("result = %+v, want 1 accepted, 2 rejected", res)
+	}
+}
+
+func TestAggregatorQueueKeepsLatest(t *testing.T) {
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	s.SetAggregator(AggregatorConfig{Upstream: "http://127.0.0.1:1"})
+	a := s.agg
+
+	a.queue(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "p", TS: 1})
+	a.queue(proto.PresenceMsg{Type: proto.TypeUpdate, PeerID: "p", TS: 2})
+	msgs := a.take()
+	if len(msgs) != 1 || msgs[0].TS != 2 {
+		t.Fatalf("take = %+v, want only the latest message", msgs)
+	}
+
+	// A failed send must not overwrite presence that arrived meanwhile.
+	a.queue(proto.PresenceMsg{Type: proto.TypeOffline, PeerID: "p", TS: 3})
+	a.requeue(msgs)
+	if got := a.take(); len(got) != 1 || got[0].Type != proto.TypeOffline {
+		t.Fatalf("after requeue = %+v, want the offline", got)
+	}
+	if a.isLocal("p") {
+		t.Error("peer still local after offline")
+	}
+}
@@ -0,0 +1,50 @@
+/* Goop² rendezvous status widget.
+ *
+ *   <script src="https://rv.example.org/widget.js" async></script>
+ *
+ * Renders "<name>: 12 peers online · 3 public sites" in a <span
+ * class="goop2-status"> right after the script tag, or inside the element
+ * named by data-target. Style it from the embedding page. Refreshes every
+ * minute from /status.json on the same server.
+ */
+(function () {
+  var script = document.currentScript;
+  if (!script || !script.src) return;
+  var base = script.src.replace(/\/widget\.js(\?.*)?$/, '');
+
+  var el = script.getAttribute('data-target') && document.getElementById(script.getAttribute('data-target'));
+  if (!el) {
+    el = document.createElement('span');
+    script.parentNode.insertBefore(el, script.nextSibling);
+  }
+  el.className = (el.className ? el.className + ' ' : '') + 'goop2-status';
+
+  function plural(n, word) {
+    return n + ' ' + word + (n === 1 ? '' : 's');
+  }
+
+  function render(st) {
+    var parts = [plural(st.peers_online, 'peer') + ' online'];
+    if (st.public_sites) parts.push(plural(st.public_sites, 'public site'));
+    if (st.templates) parts.push(plural(st.templates, 'template'));
+
+    el.textContent = '';
+    var link = document.createElement('a');
+    link.href = st.url || base;
+    link.target = '_blank';
+    link.rel = 'noopener';
+    link.textContent = st.name;
+    el.appendChild(link);
+    el.appendChild(document.createTextNode(': ' + parts.join(' · ')));
+  }
+
+  function load() {
+    fetch(base + '/status.json')
+      .then(function (r) { return r.ok ? r.json() : Promise.reject(r.status); })
+      .then(render)
+      .catch(function () { if (!el.textContent) el.textContent = 'Goop² status unavailable'; });
+  }
+
+  load();
+  setInterval(load, 60000);
+})();
@@ -0,0 +1,257 @@
+package rendezvous
+
+// bans.go — peers and addresses moderators have banned. A banned peer ID
+// or IP address is refused on /publish, /publish/batch, the WebSocket and
+// /events, and by the relay ACL; a banned peer's presence relayed by a
+// federated server is dropped too. Banning also drops what it has open: its
+// presence, its WebSocket and its relay connections. An open /events
+// stream from a banned IP ends at its next message or keep-alive.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Kinds of ban.
+const (
+	banPeer = "peer"
+	banIP   = "ip"
+)
+
+type banRow struct {
+	Target  string `json:"target"` // peer ID or IP address
+	Kind    string `json:"kind"`   // "peer" or "ip"
+	Reason  string `json:"reason,omitempty"`
+	By      string `json:"by,omitempty"` // the admin who banned it
+	Created int64  `json:"created"`      // unix millis
+}
+
+// banList holds the bans by target.
+type banList struct {
+	mu   sync.RWMutex
+	rows map[string]banRow
+}
+
+func newBanList() *banList {
+	return &banList{rows: map[string]banRow{}}
+}
+
+func (b *banList) load(rows []banRow) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, row := range rows {
+		b.rows[row.Target] = row
+	}
+}
+
+func (b *banList) add(row banRow) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rows[row.Target] = row
+}
+
+// remove lifts the ban on target and reports whether there was one.
+func (b *banList) remove(target string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.rows[target]
+	delete(b.rows, target)
+	return ok
+}
+
+// list returns the bans, newest first.
+func (b *banList) list() []banRow {
+	b.mu.RLock()
+	out := make([]banRow, 0, len(b.rows))
+	for _, row := range b.rows {
+		out = append(out, row)
+	}
+	b.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Created > out[j].Created })
+	return out
+}
+
+// peerBanned reports whether the peer ID is banned.
+func (b *banList) peerBanned(peerID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	row, ok := b.rows[peerID]
+	return ok && row.Kind == banPeer
+}
+
+// ipBanned reports whether the IP address, as extractIP returns it, is banned.
+func (b *banList) ipBanned(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	row, ok := b.rows[parsed.String()]
+	return ok && row.Kind == banIP
+}
+
+// relayBanned reports whether a relay peer, or the address it connects
+// from, is banned. addr may be nil.
+func (b *banList) relayBanned(p peer.ID, addr ma.Multiaddr) bool {
+	if b.peerBanned(p.String()) {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	ip, err := manet.ToIP(addr)
+	return err == nil && b.ipBanned(ip.String())
+}
+
+// parseBanTarget tells a peer ID from an IP address and normalises it.
+func parseBanTarget(target string) (string, string, error) {
+	target = strings.TrimSpace(target)
+	if ip := net.ParseIP(target); ip != nil {
+		return ip.String(), banIP, nil
+	}
+	if pid, err := peer.Decode(target); err == nil {
+		return pid.String(), banPeer, nil
+	}
+	return "", "", errors.New("target must be a peer ID or an IP address")
+}
+
+// disconnectBanned drops the presence, WebSocket and relay connections of
+// a target that was just banned.
+func (s *Server) disconnectBanned(row banRow) {
+	var closing []*wsClient
+	s.wsClientsMu.RLock()
+	for peerID, wsc := range s.wsClients {
+		if (row.Kind == banPeer && peerID == row.Target) ||
+			(row.Kind == banIP && s.bans.ipBanned(extractIP(wsc.conn.RemoteAddr().String()))) {
+			closing = append(closing, wsc)
+		}
+	}
+	s.wsClientsMu.RUnlock()
+	// The read pump sees the closed connection and marks the peer offline.
+	for _, wsc := range closing {
+		wsc.conn.Close()
+	}
+
+	if row.Kind == banPeer {
+		s.mu.Lock()
+		_, online := s.peers[row.Target]
+		if online {
+			delete(s.peers, row.Target)
+			s.peersDirty = true
+		}
+		s.mu.Unlock()
+		if online {
+			s.persist("peer:"+row.Target, func(db *peerDB) error { return db.remove(row.Target) })
+			offMsg := proto.PresenceMsg{Type: proto.TypeOffline, PeerID: row.Target, TS: proto.NowMillis()}
+			if b, err := json.Marshal(offMsg); err == nil {
+				s.broadcast(b)
+			}
+		}
+	}
+
+	if s.relayUsage.closeBanned != nil {
+		s.relayUsage.closeBanned()
+	}
+}
+
+// handleBan bans a peer ID or IP address (POST {target, reason}).
+func (s *Server) handleBan(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleModerator) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Target string `json:"target"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	target, kind, err := parseBanTarget(req.Target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	who, _ := s.adminIdentity(r)
+	row := banRow{Target: target, Kind: kind, Reason: strings.TrimSpace(req.Reason), By: who, Created: time.Now().UnixMilli()}
+	if s.peerDB != nil {
+		if err := s.peerDB.upsertBan(row); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.bans.add(row)
+	s.disconnectBanned(row)
+	log.Printf("admin: %s banned %s %s", who, kind, target)
+	s.addLog(fmt.Sprintf("Banned %s %s", kind, target))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnban lifts a ban (POST {target}).
+func (s *Server) handleUnban(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleModerator) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	target, kind, err := parseBanTarget(req.Target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.peerDB != nil {
+		if err := s.peerDB.deleteBan(target); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !s.bans.remove(target) {
+		http.Error(w, "not banned", http.StatusNotFound)
+		return
+	}
+	who, _ := s.adminIdentity(r)
+	log.Printf("admin: %s unbanned %s %s", who, kind, target)
+	s.addLog(fmt.Sprintf("Unbanned %s %s", kind, target))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleBansJSON lists the bans for the admin page.
+func (s *Server) handleBansJSON(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleModerator) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(s.bans.list())
+}
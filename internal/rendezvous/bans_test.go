@@ -0,0 +1,76 @@
+package rendezvous
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestBans(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	root := basic("admin", "secret")
+	_, id := newIdentity(t)
+	pid, _ := peer.Decode(id)
+	s.peers[id] = peerRow{PeerID: id, Type: proto.TypeOnline}
+
+	if w := adminDo(s.handleBan, "POST", "/admin/ban", `{"target":"not-a-peer"}`, root); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad target: %d", w.Code)
+	}
+	if w := adminDo(s.handleBan, "POST", "/admin/ban", `{"target":"`+id+`","reason":"spam"}`, root); w.Code != http.StatusNoContent {
+		t.Fatalf("ban peer: %d %s", w.Code, w.Body)
+	}
+	if w := adminDo(s.handleBan, "POST", "/admin/ban", `{"target":" 203.0.113.7 "}`, root); w.Code != http.StatusNoContent {
+		t.Fatalf("ban ip: %d %s", w.Code, w.Body)
+	}
+
+	if _, online := s.peers[id]; online {
+		t.Fatal("banned peer still listed")
+	}
+	pm := proto.PresenceMsg{Type: proto.TypeOnline, PeerID: id}
+	if status, err := s.checkPresence(&pm); status != http.StatusForbidden || err == nil {
+		t.Fatalf("presence of a banned peer: %d %v", status, err)
+	}
+	if !s.bans.ipBanned("203.0.113.7") || s.bans.ipBanned("203.0.113.8") || s.bans.peerBanned("203.0.113.7") {
+		t.Fatal("ip ban not applied as expected")
+	}
+
+	_, other := newIdentity(t)
+	otherID, _ := peer.Decode(other)
+	if s.relayUsage.AllowReserve(pid, nil) || s.relayUsage.AllowConnect(otherID, nil, pid) {
+		t.Fatal("relay allows the banned peer")
+	}
+	if s.relayUsage.AllowReserve(otherID, ma.StringCast("/ip4/203.0.113.7/tcp/4001")) {
+		t.Fatal("relay allows the banned address")
+	}
+	if !s.relayUsage.AllowReserve(otherID, ma.StringCast("/ip4/198.51.100.1/tcp/4001")) {
+		t.Fatal("relay refuses an unbanned peer")
+	}
+
+	w := adminDo(s.handleBansJSON, "GET", "/bans.json", "", root)
+	var list []banRow
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("bans.json = %s", w.Body)
+	}
+	stored, err := s.peerDB.loadBans()
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("stored bans = %+v, %v", stored, err)
+	}
+
+	if w := adminDo(s.handleUnban, "POST", "/admin/unban", `{"target":"`+id+`"}`, root); w.Code != http.StatusNoContent {
+		t.Fatalf("unban: %d %s", w.Code, w.Body)
+	}
+	if w := adminDo(s.handleUnban, "POST", "/admin/unban", `{"target":"`+id+`"}`, root); w.Code != http.StatusNotFound {
+		t.Fatalf("unban twice: %d", w.Code)
+	}
+	if _, err := s.checkPresence(&pm); err != nil {
+		t.Fatalf("presence after unban: %v", err)
+	}
+	if stored, _ := s.peerDB.loadBans(); len(stored) != 1 || stored[0].Kind != banIP {
+		t.Fatalf("stored bans after unban = %+v", stored)
+	}
+}
@@ -0,0 +1,215 @@
+package rendezvous
+
+// branding.go — operator branding of the rendezvous pages: a title, a
+// logo, colour overrides for style.css variables, an extra stylesheet and
+// footer links. Everything is read once by SetBranding; the templates pick
+// it up through the brand* template functions.
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	brandMaxLogoBytes = 1 << 20
+	brandMaxCSSBytes  = 256 << 10
+)
+
+// brandColorName is a style.css custom property name without the "--".
+var brandColorName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Branding is the operator's look for the rendezvous pages. The zero value
+// keeps the built-in look.
+type Branding struct {
+	Title       string            // replaces "Goop²" in page titles and headers
+	LogoFile    string            // image shown instead of the header emoji
+	Colors      map[string]string // style.css variables without "--", applied to both themes
+	CSSFile     string            // extra stylesheet loaded after style.css
+	FooterLinks []BrandLink
+}
+
+// BrandLink is a footer link. URL is http(s) or site-relative.
+type BrandLink struct {
+	Label string
+	URL   string
+}
+
+// brand is a loaded Branding.
+type brand struct {
+	title    string
+	logo     []byte
+	logoType string
+	css      []byte
+	vars     string // ":root[data-theme]{--x:y;...}" or ""
+	links    []BrandLink
+}
+
+func loadBrand(b Branding) (*brand, error) {
+	out := &brand{title: strings.TrimSpace(b.Title)}
+
+	if b.LogoFile != "" {
+		data, err := readLimited(b.LogoFile, brandMaxLogoBytes)
+		if err != nil {
+			return nil, fmt.Errorf("logo: %w", err)
+		}
+		out.logo = data
+		out.logoType = mime.TypeByExtension(strings.ToLower(filepath.Ext(b.LogoFile)))
+		if !strings.HasPrefix(out.logoType, "image/") {
+			out.logoType = http.DetectContentType(data)
+		}
+		if !strings.HasPrefix(out.logoType, "image/") {
+			return nil, fmt.Errorf("logo: %s is not an image", b.LogoFile)
+		}
+	}
+
+	if b.CSSFile != "" {
+		data, err := readLimited(b.CSSFile, brandMaxCSSBytes)
+		if err != nil {
+			return nil, fmt.Errorf("css file: %w", err)
+		}
+		out.css = minifyCSS(data)
+	}
+
+	if len(b.Colors) > 0 {
+		names := make([]string, 0, len(b.Colors))
+		for name := range b.Colors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var sb strings.Builder
+		sb.WriteString(":root[data-theme]{")
+		for _, name := range names {
+			val := strings.TrimSpace(b.Colors[name])
+			if !brandColorName.MatchString(name) || val == "" || strings.ContainsAny(val, ";{}<>\"\\") {
+				return nil, fmt.Errorf("color %q: invalid name or value", name)
+			}
+			fmt.Fprintf(&sb, "--%s:%s;", name, val)
+		}
+		sb.WriteString("}")
+		out.vars = sb.String()
+	}
+
+	for _, l := range b.FooterLinks {
+		if l.Label == "" || !brandLinkURL(l.URL) {
+			return nil, fmt.Errorf("footer link %q: needs a label and an http(s) or site-relative url", l.Label)
+		}
+		out.links = append(out.links, l)
+	}
+	return out, nil
+}
+
+func brandLinkURL(u string) bool {
+	return strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://") ||
+		(strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//"))
+}
+
+func readLimited(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, max)
+	}
+	return data, nil
+}
+
+// brandFuncs are the template functions the pages use for branding. b may
+// be nil, which renders the built-in look.
+func brandFuncs(b *brand) template.FuncMap {
+	return template.FuncMap{
+		"brandTitle": func(def string) string {
+			if b == nil || b.title == "" {
+				return def
+			}
+			return b.title
+		},
+		"brandHead": func() template.HTML {
+			if b == nil {
+				return ""
+			}
+			var out string
+			if b.vars != "" {
+				out += "<style>" + b.vars + "</style>"
+			}
+			if b.css != nil {
+				out += `<link rel="stylesheet" href="/assets/brand.css" />`
+			}
+			return template.HTML(out)
+		},
+		"brandLogo": func(def string) template.HTML {
+			if b == nil || b.logo == nil {
+				return template.HTML(template.HTMLEscapeString(def))
+			}
+			return `<img class="brand-logo" src="/assets/brand-logo" alt="" />`
+		},
+		"brandLinks": func() template.HTML {
+			if b == nil || len(b.links) == 0 {
+				return ""
+			}
+			parts := make([]string, len(b.links))
+			for i, l := range b.links {
+				parts[i] = `<a href="` + template.HTMLEscapeString(l.URL) + `">` + template.HTMLEscapeString(l.Label) + `</a>`
+			}
+			return template.HTML(`<p class="brand-links">` + strings.Join(parts, " &middot; ") + `</p>`)
+		},
+	}
+}
+
+// SetBranding loads the operator's branding and applies it to every page.
+// Must be called before Start. The zero Branding restores the built-in look.
+func (s *Server) SetBranding(b Branding) error {
+	loaded, err := loadBrand(b)
+	if err != nil {
+		return err
+	}
+	funcs := brandFuncs(loaded)
+	for _, t := range []*template.Template{s.tmpl, s.adminTmpl, s.docsTmpl, s.storeTmpl, s.registerTmpl} {
+		if t != nil {
+			t.Funcs(funcs)
+		}
+	}
+	s.brand = loaded
+	return nil
+}
+
+// brandTitle is the operator's title, or def without branding.
+func (s *Server) brandTitle(def string) string {
+	if s.brand == nil || s.brand.title == "" {
+		return def
+	}
+	return s.brand.title
+}
+
+func (s *Server) handleBrandLogo(w http.ResponseWriter, r *http.Request) {
+	if s.brand == nil || s.brand.logo == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("content-type", s.brand.logoType)
+	w.Header().Set("x-content-type-options", "nosniff")
+	w.Header().Set("content-security-policy", "default-src 'none'; style-src 'unsafe-inline'")
+	_, _ = w.Write(s.brand.logo)
+}
+
+func (s *Server) handleBrandCSS(w http.ResponseWriter, r *http.Request) {
+	if s.brand == nil || s.brand.css == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("content-type", "text/css; charset=utf-8")
+	_, _ = w.Write(s.brand.css)
+}
@@ -0,0 +1,69 @@
+package rendezvous
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetBranding(t *testing.T) {
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.svg")
+	css := filepath.Join(dir, "brand.css")
+	os.WriteFile(logo, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0o644)
+	os.WriteFile(css, []byte(".hero { color: red; }"), 0o644)
+
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	err := s.SetBranding(Branding{
+		Title:       "Jazz Net",
+		LogoFile:    logo,
+		Colors:      map[string]string{"accent": "#e4572e"},
+		CSSFile:     css,
+		FooterLinks: []BrandLink{{Label: "Forum", URL: "https://forum.example.org"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleIndex(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<title>Jazz Net</title>",
+		"--accent:#e4572e;",
+		`href="/assets/brand.css"`,
+		`<a href="https://forum.example.org">Forum</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page lacks %s", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleBrandLogo(rec, httptest.NewRequest(http.MethodGet, "/assets/brand-logo", nil))
+	if ct := rec.Header().Get("content-type"); ct != "image/svg+xml" {
+		t.Errorf("logo content-type = %q", ct)
+	}
+}
+
+func TestSetBranding_Rejects(t *testing.T) {
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	for name, b := range map[string]Branding{
+		"css breakout":    {Colors: map[string]string{"bg": "red}</style><script>"}},
+		"javascript link": {FooterLinks: []BrandLink{{Label: "x", URL: "javascript:alert(1)"}}},
+		"missing logo":    {LogoFile: filepath.Join(t.TempDir(), "nope.png")},
+	} {
+		if err := s.SetBranding(b); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleIndex(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "<title>Goop² Rendezvous</title>") {
+		t.Error("unbranded title changed")
+	}
+}
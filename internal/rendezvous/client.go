@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -26,9 +28,17 @@ type Client struct {
 	dns     *util.DNSCache
 
 	// WebSocket state (set by ConnectWebSocket)
-	wsMu   sync.Mutex
-	wsConn *websocket.Conn
-	wsSend chan []byte // buffered send channel for write pump
+	wsMu         sync.Mutex
+	wsConn       *websocket.Conn
+	wsSend       chan []byte // buffered send channel for write pump
+	lastPresence []byte      // sent first on each WS connection (PublishPresence)
+
+	templates *TemplateCache // nil = store listings and bundles are not cached
+
+	relayPin  string     // expected relay peer ID (SetRelayPin)
+	relayPins *RelayPins // trust-on-first-use pins when relayPin is empty
+
+	relayUnsignedLogged atomic.Bool // warned once about unsigned relay info
 }
 
 func NewClient(baseURL string) *Client {
@@ -54,6 +64,11 @@ func NewClient(baseURL string) *Client {
 	return c
 }
 
+// SetTemplateCache makes ListTemplates and DownloadTemplateBundle use tc.
+func (c *Client) SetTemplateCache(tc *TemplateCache) {
+	c.templates = tc
+}
+
 func (c *Client) WarmDNS(ctx context.Context) {
 	if _, err := c.dns.Resolve(ctx); err != nil {
 		log.Printf("rendezvous: %s unreachable (DNS failed: %v)", c.BaseURL, err)
@@ -95,7 +110,8 @@ func (c *Client) getJSON(ctx context.Context, url string, v any) (bool, error) {
 }
 
 // FetchRelayInfo fetches relay info from the rendezvous server.
-// Returns (nil, nil) if the server has no relay enabled.
+// Returns (nil, nil) if the server has no relay enabled, and an error if
+// the info fails the check set up by SetRelayPin.
 func (c *Client) FetchRelayInfo(ctx context.Context) (*RelayInfo, error) {
 	if c.BaseURL == "" {
 		return nil, nil
@@ -105,6 +121,9 @@ func (c *Client) FetchRelayInfo(ctx context.Context) (*RelayInfo, error) {
 	if !found || err != nil {
 		return nil, err
 	}
+	if err := c.checkRelayInfo(&info); err != nil {
+		return nil, err
+	}
 	return &info, nil
 }
 
@@ -138,6 +157,8 @@ func (c *Client) Publish(ctx context.Context, pm proto.PresenceMsg) error {
 }
 
 // ListTemplates fetches the template store listing from the rendezvous server.
+// With a template cache the listing is revalidated by ETag, and the cached
+// listing is returned when the server cannot be reached.
 // peerID is sent so the server can gate access based on registration status.
 // Returns nil (not an error) if the server has no template store.
 func (c *Client) ListTemplates(ctx context.Context, peerID string) ([]StoreMeta, error) {
@@ -152,14 +173,30 @@ func (c *Client) ListTemplates(ctx context.Context, peerID string) ([]StoreMeta,
 	if peerID != "" {
 		req.Header.Set("X-Goop-Peer-ID", peerID)
 	}
+	var cachedETag string
+	var cached []StoreMeta
+	haveCached := false
+	if c.templates != nil {
+		cachedETag, cached, haveCached = c.templates.Listing(c.BaseURL)
+		if cachedETag != "" {
+			req.Header.Set("If-None-Match", cachedETag)
+		}
+	}
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
+		if haveCached {
+			log.Printf("rendezvous: %s unreachable, using cached template listing: %v", c.BaseURL, err)
+			return cached, nil
+		}
 		return nil, err
 	}
 	defer func() {
 		io.Copy(io.Discard, resp.Body)
 		resp.Body.Close()
 	}()
+	if resp.StatusCode == http.StatusNotModified && haveCached {
+		return cached, nil
+	}
 	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadGateway {
 		return nil, nil
 	}
@@ -179,6 +216,11 @@ func (c *Client) ListTemplates(ctx context.Context, peerID string) ([]StoreMeta,
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return nil, err
 	}
+	if c.templates != nil {
+		if err := c.templates.PutListing(c.BaseURL, resp.Header.Get("ETag"), out); err != nil {
+			log.Printf("rendezvous: cache template listing: %v", err)
+		}
+	}
 	return out, nil
 }
 
@@ -300,34 +342,207 @@ func (c *Client) SpendCredits(ctx context.Context, templateDir, peerID string) (
 
 // DownloadTemplateBundle fetches the tar.gz bundle for a store template.
 // peerID is sent as X-Goop-Peer-ID so the server can verify registration.
+// A non-empty install session (see StartInstall) lets the server report the
+// download's progress to the session.
+// With a template cache, a bundle whose hash the cached listing names is
+// returned without asking the server, the last bundle of the template is
+// revalidated by ETag, and it is also returned when the server cannot be
+// reached. Downloaded bundles that carry an ETag are added to the cache.
 // Caller must close the returned ReadCloser.
-func (c *Client) DownloadTemplateBundle(ctx context.Context, dir, peerID string) (io.ReadCloser, error) {
+func (c *Client) DownloadTemplateBundle(ctx context.Context, dir, peerID, install string) (io.ReadCloser, error) {
 	if c.BaseURL == "" {
 		return nil, fmt.Errorf("no base url")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/templates/"+dir+"/bundle", nil)
+	var last string
+	if c.templates != nil {
+		if b := c.templates.Bundle(c.listedHash(dir)); b != nil {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
+		last = c.templates.LastBundle(dir)
+	}
+
+	u := c.BaseURL + "/api/templates/" + dir + "/bundle"
+	if install != "" {
+		u += "?install=" + url.QueryEscape(install)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 	if peerID != "" {
 		req.Header.Set("X-Goop-Peer-ID", peerID)
 	}
+	if last != "" {
+		req.Header.Set("If-None-Match", `"`+last+`"`)
+	}
 
 	resp, err := (&http.Client{}).Do(req)
 	if err != nil {
+		if last != "" {
+			if b := c.templates.Bundle(last); b != nil {
+				log.Printf("rendezvous: %s unreachable, using cached bundle of %q: %v", c.BaseURL, dir, err)
+				return io.NopCloser(bytes.NewReader(b)), nil
+			}
+		}
 		return nil, err
 	}
 
+	if resp.StatusCode == http.StatusNotModified && last != "" {
+		resp.Body.Close()
+		if b := c.templates.Bundle(last); b != nil {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
+		return nil, fmt.Errorf("download bundle: not modified, but the cached bundle is gone")
+	}
 	if resp.StatusCode/100 != 2 {
 		io.Copy(io.Discard, resp.Body)
 		resp.Body.Close()
 		return nil, fmt.Errorf("download bundle: status %s", resp.Status)
 	}
 
+	hash := strings.Trim(strings.TrimPrefix(resp.Header.Get("ETag"), "W/"), `"`)
+	if c.templates == nil || hash == "" {
+		return resp.Body, nil
+	}
+	return &cachingBody{ReadCloser: resp.Body, cache: c.templates, dir: dir, hash: hash}, nil
+}
+
+// TemplateCached reports whether a bundle of the template is in the cache,
+// so it can be installed without the server.
+func (c *Client) TemplateCached(dir string) bool {
+	if c.templates == nil {
+		return false
+	}
+	for _, hash := range []string{c.listedHash(dir), c.templates.LastBundle(dir)} {
+		if hash != "" && c.templates.Bundle(hash) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// listedHash returns the content hash the cached listing gives for dir.
+func (c *Client) listedHash(dir string) string {
+	_, list, _ := c.templates.Listing(c.BaseURL)
+	for _, m := range list {
+		if m.Dir == dir {
+			return m.Hash
+		}
+	}
+	return ""
+}
+
+// cachingBody adds a bundle to the template cache once it was read whole.
+type cachingBody struct {
+	io.ReadCloser
+	cache *TemplateCache
+	dir   string
+	hash  string
+	buf   bytes.Buffer
+	done  bool
+}
+
+func (cb *cachingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.buf.Write(p[:n])
+	if err == io.EOF && !cb.done {
+		cb.done = true
+		if perr := cb.cache.PutBundle(cb.dir, cb.hash, cb.buf.Bytes()); perr != nil {
+			log.Printf("rendezvous: cache bundle of %q: %v", cb.dir, perr)
+		}
+		cb.buf = bytes.Buffer{}
+	}
+	return n, err
+}
+
+// StartInstall opens an install session for a store template and returns
+// its ID. Servers that predate install sessions answer 404, which gives an
+// empty ID and no error: the install then runs without progress.
+func (c *Client) StartInstall(ctx context.Context, dir, peerID string) (string, error) {
+	if c.BaseURL == "" {
+		return "", nil
+	}
+	body, _ := json.Marshal(map[string]string{"template": dir, "peer_id": peerID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/templates/installs", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("start install: status %s", resp.Status)
+	}
+	var out struct {
+		Session string `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("start install: %w", err)
+	}
+	return out.Session, nil
+}
+
+// InstallEvents opens the SSE stream of an install session. The stream ends
+// after the event that finishes the session. Caller must close it.
+func (c *Client) InstallEvents(ctx context.Context, session, lastEventID string) (io.ReadCloser, error) {
+	if c.BaseURL == "" {
+		return nil, fmt.Errorf("no base url")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/templates/installs/"+url.PathEscape(session)+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	// No client timeout: the stream lasts as long as the install.
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("install events: status %s", resp.Status)
+	}
 	return resp.Body, nil
 }
 
+// ReportInstall tells the server how applying a downloaded bundle went:
+// stage is InstallApplying, InstallApplied or InstallFailed.
+func (c *Client) ReportInstall(ctx context.Context, session string, st InstallStatus) error {
+	if c.BaseURL == "" || session == "" {
+		return nil
+	}
+	body, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/templates/installs/"+url.PathEscape(session)+"/status", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("report install: status %s", resp.Status)
+	}
+	return nil
+}
+
 // RegisterEncryptionKey registers the peer's NaCl public key with the
 // encryption service via the rendezvous proxy.
 func (c *Client) RegisterEncryptionKey(ctx context.Context, peerID, publicKey string) error {
@@ -397,6 +612,135 @@ func (c *Client) PulsePeer(ctx context.Context, peerID string) error {
 	return nil
 }
 
+// ReportRelay posts the peer's relay health to the rendezvous. Servers
+// that predate /api/relay-report answer 404, which is not an error.
+func (c *Client) ReportRelay(ctx context.Context, rep RelayReport) error {
+	if c.BaseURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/relay-report", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("relay report: status %s", resp.Status)
+	}
+	return nil
+}
+
+// SubmitReputation posts a signed reputation record to the rendezvous
+// moderation queue.
+func (c *Client) SubmitReputation(ctx context.Context, rec ReputationRecord) error {
+	if c.BaseURL == "" {
+		return errors.New("no rendezvous")
+	}
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/reputation", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errReputationUnsupported
+	case resp.StatusCode/100 != 2:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("reputation: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// DigestStatus fetches this peer's email digest subscription.
+func (c *Client) DigestStatus(ctx context.Context, peerID, token string) (DigestStatus, error) {
+	var st DigestStatus
+	if c.BaseURL == "" {
+		return st, errors.New("no rendezvous")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/digest/prefs?peer="+url.QueryEscape(peerID), nil)
+	if err != nil {
+		return st, err
+	}
+	req.Header.Set("X-Goop-Token", token)
+	return st, c.doDigest(req, &st)
+}
+
+// SetDigestPrefs subscribes to, changes or cancels email digests.
+func (c *Client) SetDigestPrefs(ctx context.Context, p DigestPrefs) (DigestStatus, error) {
+	var st DigestStatus
+	if c.BaseURL == "" {
+		return st, errors.New("no rendezvous")
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return st, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/digest/prefs", bytes.NewReader(body))
+	if err != nil {
+		return st, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return st, c.doDigest(req, &st)
+}
+
+func (c *Client) doDigest(req *http.Request, v any) error {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("digest: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// ReportDigestEvent tells the rendezvous about something an offline peer
+// would want in its digest. Servers without digests answer 404 or 204.
+func (c *Client) ReportDigestEvent(ctx context.Context, ev DigestEvent) error {
+	if c.BaseURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/digest/event", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("digest event: status %s", resp.Status)
+	}
+	return nil
+}
+
 // SubscribeEvents connects to /events and calls onMsg for each "data: <json>" message.
 // It reconnects automatically with a small backoff until ctx is cancelled.
 func (c *Client) SubscribeEvents(ctx context.Context, onMsg func(proto.PresenceMsg)) {
@@ -508,6 +852,7 @@ func (c *Client) ConnectWebSocket(ctx context.Context, peerID string, onMsg func
 
 		if err != nil {
 			if isWSTooEarly(err) {
+				// Servers before WS admission want a /publish first.
 				log.Printf("rendezvous ws: server says publish first (425), retrying in %v", WSBackoff)
 				select {
 				case <-ctx.Done():
@@ -635,7 +980,12 @@ func (c *Client) connectWSOnce(ctx context.Context, peerID string, onMsg func(pr
 
 	sendCh := make(chan []byte, 64)
 
+	// The last presence goes first: it admits a peer the server has not
+	// seen over HTTP, and restores its entry after a reconnect.
 	c.wsMu.Lock()
+	if c.lastPresence != nil {
+		sendCh <- c.lastPresence
+	}
 	c.wsConn = conn
 	c.wsSend = sendCh
 	c.wsMu.Unlock()
@@ -724,6 +1074,28 @@ func (c *Client) PublishWS(pm proto.PresenceMsg) bool {
 	}
 }
 
+// PublishPresence sends pm over the WebSocket when one is connected and
+// over HTTP otherwise. An online or update presence is also kept as the
+// first frame of the next WebSocket connection.
+func (c *Client) PublishPresence(ctx context.Context, pm proto.PresenceMsg) error {
+	b, err := json.Marshal(pm)
+	if err != nil {
+		return err
+	}
+	c.wsMu.Lock()
+	if pm.Type == proto.TypeOffline {
+		c.lastPresence = nil
+	} else {
+		c.lastPresence = b
+	}
+	c.wsMu.Unlock()
+
+	if c.PublishWS(pm) {
+		return nil
+	}
+	return c.Publish(ctx, pm)
+}
+
 // IsWebSocketConnected returns true if this client has an active WebSocket connection.
 func (c *Client) IsWebSocketConnected() bool {
 	c.wsMu.Lock()
@@ -247,6 +247,69 @@ func TestSubscribeEvents_ReconnectsOnFailure(t *testing.T) {
 	mu.Unlock()
 }
 
+// With no WebSocket the presence goes over HTTP, and the next connection
+// opens with it, so the server can admit the peer from the first frame.
+func TestPublishPresence_SentFirstOnConnect(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	posted := make(chan proto.PresenceMsg, 1)
+	first := make(chan proto.PresenceMsg, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/publish":
+			var pm proto.PresenceMsg
+			json.NewDecoder(r.Body).Decode(&pm)
+			posted <- pm
+			w.WriteHeader(http.StatusNoContent)
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, b, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var pm proto.PresenceMsg
+			json.Unmarshal(b, &pm)
+			first <- pm
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	pm := proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "local-peer", Content: "hello"}
+	if err := c.PublishPresence(ctx, pm); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-posted; got.Content != "hello" {
+		t.Errorf("posted %+v", got)
+	}
+
+	go c.ConnectWebSocket(ctx, "local-peer", nil)
+	select {
+	case got := <-first:
+		if got.Type != proto.TypeOnline || got.Content != "hello" {
+			t.Errorf("first frame = %+v", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("no presence on the new connection")
+	}
+
+	// Going offline clears it, so a later reconnect does not resurrect the peer.
+	c.PublishPresence(ctx, proto.PresenceMsg{Type: proto.TypeOffline, PeerID: "local-peer"})
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.lastPresence != nil {
+		t.Error("offline presence kept for the next connection")
+	}
+}
+
 func TestConnectWebSocket_ReceivesMessages(t *testing.T) {
 	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,476 @@
+package rendezvous
+
+// digest.go — email digests for peers that were offline. Senders tell the
+// rendezvous when a chat message or group invite could not be delivered,
+// and hosts announce new listen stations; the rendezvous counts these per
+// subscribed peer and mails a summary through the email service at the
+// peer's chosen frequency. Only peers with a verified email can subscribe,
+// and every digest carries a one-click unsubscribe link.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Digest event kinds.
+const (
+	DigestKindChat    = "chat"         // a direct message the recipient missed
+	DigestKindInvite  = "group_invite" // a group invite the recipient missed
+	DigestKindStation = "station"      // From started a listen station
+)
+
+const (
+	digestMaxFavorites = 500
+	digestMaxStations  = 10
+)
+
+// DigestPrefs is a peer's digest subscription. Token is the peer's
+// verification token, which proves it owns the verified email.
+type DigestPrefs struct {
+	PeerID    string   `json:"peer_id"`
+	Token     string   `json:"token,omitempty"`
+	Frequency string   `json:"frequency"`
+	Favorites []string `json:"favorites,omitempty"` // peers whose new stations are included
+}
+
+// DigestStatus is what a peer sees of its own subscription.
+type DigestStatus struct {
+	Frequency string `json:"frequency"`
+	Email     string `json:"email"`
+	Favorites int    `json:"favorites"`
+	Pending   int    `json:"pending"`             // events waiting for the next digest
+	LastSent  int64  `json:"last_sent,omitempty"` // unix millis
+}
+
+// DigestEvent is reported by the peer it originates from.
+type DigestEvent struct {
+	From string `json:"from"`
+	To   string `json:"to,omitempty"` // empty for DigestKindStation
+	Kind string `json:"kind"`
+	Name string `json:"name,omitempty"` // station name
+}
+
+type digestSub struct {
+	peerID     string
+	email      string
+	frequency  string
+	favorites  map[string]bool
+	unsubToken string
+	lastSent   time.Time
+
+	chats    int
+	invites  int
+	stations []digestStation
+}
+
+type digestStation struct {
+	peer string // label, or short peer ID
+	name string
+}
+
+func (d *digestSub) pending() int {
+	return d.chats + d.invites + len(d.stations)
+}
+
+func (d *digestSub) clearPending() {
+	d.chats, d.invites, d.stations = 0, 0, nil
+}
+
+// items lists the pending events, e.g. "3 chat messages".
+func (d *digestSub) items() []string {
+	var out []string
+	if d.chats > 0 {
+		out = append(out, plural(d.chats, "chat message", "chat messages"))
+	}
+	if d.invites > 0 {
+		out = append(out, plural(d.invites, "group invite", "group invites"))
+	}
+	for _, st := range d.stations {
+		line := "your favorite peer " + st.peer + " started a listen station"
+		if st.name != "" {
+			line += " (" + st.name + ")"
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+func digestPeriod(freq string) time.Duration {
+	if freq == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digests holds every subscription, keyed by peer ID. Pending events live
+// in memory only; subscriptions are also kept in the peer DB when enabled.
+type digests struct {
+	mu      sync.Mutex
+	subs    map[string]*digestSub
+	byToken map[string]string // unsubscribe token -> peer ID
+}
+
+func newDigests() *digests {
+	return &digests{subs: map[string]*digestSub{}, byToken: map[string]string{}}
+}
+
+func (ds *digests) add(d *digestSub) {
+	ds.subs[d.peerID] = d
+	ds.byToken[d.unsubToken] = d.peerID
+}
+
+// set stores prefs for a peer whose verified email is email.
+func (ds *digests) set(p DigestPrefs, email string) digestRow {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	d, ok := ds.subs[p.PeerID]
+	if !ok {
+		d = &digestSub{peerID: p.PeerID, unsubToken: newDigestToken()}
+		ds.add(d)
+	}
+	d.email = email
+	d.frequency = p.Frequency
+	d.favorites = map[string]bool{}
+	for i, id := range p.Favorites {
+		if i >= digestMaxFavorites {
+			break
+		}
+		d.favorites[id] = true
+	}
+	if d.frequency == DigestOff {
+		d.clearPending()
+	}
+	return d.row()
+}
+
+// record counts ev for the subscribers it concerns. Events for peers that
+// are online are dropped: they see them live.
+func (ds *digests) record(ev DigestEvent, fromLabel string, online func(string) bool) int {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	touched := 0
+	switch ev.Kind {
+	case DigestKindChat, DigestKindInvite:
+		d, ok := ds.subs[ev.To]
+		if !ok || d.frequency == DigestOff || online(ev.To) {
+			return 0
+		}
+		if ev.Kind == DigestKindChat {
+			d.chats++
+		} else {
+			d.invites++
+		}
+		touched++
+	case DigestKindStation:
+		for _, d := range ds.subs {
+			if d.frequency == DigestOff || !d.favorites[ev.From] || len(d.stations) >= digestMaxStations || online(d.peerID) {
+				continue
+			}
+			d.stations = append(d.stations, digestStation{peer: fromLabel, name: ev.Name})
+			touched++
+		}
+	}
+	return touched
+}
+
+// unsubscribe turns the subscription with token off.
+func (ds *digests) unsubscribe(token string) (digestRow, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	d, ok := ds.subs[ds.byToken[token]]
+	if !ok || token == "" {
+		return digestRow{}, false
+	}
+	d.frequency = DigestOff
+	d.clearPending()
+	return d.row(), true
+}
+
+// due returns copies of the subscriptions whose digest should go out now.
+func (ds *digests) due(now time.Time, online func(string) bool) []digestSub {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	var out []digestSub
+	for _, d := range ds.subs {
+		if d.frequency == DigestOff || d.pending() == 0 || online(d.peerID) {
+			continue
+		}
+		if now.Sub(d.lastSent) < digestPeriod(d.frequency) {
+			continue
+		}
+		c := *d
+		c.stations = append([]digestStation(nil), d.stations...)
+		out = append(out, c)
+	}
+	return out
+}
+
+// sent clears the events a digest just covered.
+func (ds *digests) sent(peerID string, now time.Time) (digestRow, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	d, ok := ds.subs[peerID]
+	if !ok {
+		return digestRow{}, false
+	}
+	d.clearPending()
+	d.lastSent = now
+	return d.row(), true
+}
+
+func (ds *digests) status(peerID string) DigestStatus {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	d, ok := ds.subs[peerID]
+	if !ok {
+		return DigestStatus{Frequency: DigestOff}
+	}
+	st := DigestStatus{Frequency: d.frequency, Email: d.email, Favorites: len(d.favorites), Pending: d.pending()}
+	if !d.lastSent.IsZero() {
+		st.LastSent = d.lastSent.UnixMilli()
+	}
+	return st
+}
+
+// digestRow is the persisted part of a subscription.
+type digestRow struct {
+	PeerID     string
+	Email      string
+	Frequency  string
+	Favorites  []string
+	UnsubToken string
+	LastSent   int64 // unix millis
+}
+
+func (d *digestSub) row() digestRow {
+	r := digestRow{PeerID: d.peerID, Email: d.email, Frequency: d.frequency, UnsubToken: d.unsubToken}
+	for id := range d.favorites {
+		r.Favorites = append(r.Favorites, id)
+	}
+	if !d.lastSent.IsZero() {
+		r.LastSent = d.lastSent.UnixMilli()
+	}
+	return r
+}
+
+func (ds *digests) load(rows []digestRow) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	for _, r := range rows {
+		d := &digestSub{peerID: r.PeerID, email: r.Email, frequency: r.Frequency, unsubToken: r.UnsubToken, favorites: map[string]bool{}}
+		for _, id := range r.Favorites {
+			d.favorites[id] = true
+		}
+		if r.LastSent > 0 {
+			d.lastSent = time.UnixMilli(r.LastSent)
+		}
+		ds.add(d)
+	}
+}
+
+func newDigestToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// digestsEnabled reports whether digests can be sent: they need the email
+// service and a public URL for the unsubscribe link.
+func (s *Server) digestsEnabled() bool {
+	return s.email != nil && s.externalURL != ""
+}
+
+func (s *Server) peerOnline(peerID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.peers[peerID]
+	return ok && p.Type != proto.TypeOffline
+}
+
+func (s *Server) saveDigest(r digestRow) {
+	if s.peerDB != nil {
+		s.peerDB.upsertDigest(r)
+	}
+}
+
+// digestAuth returns the verified email of peerID when token is its
+// verification token.
+func (s *Server) digestAuth(peerID, token string) (string, bool) {
+	want := s.GetTokenForPeer(peerID)
+	if want == "" || subtle.ConstantTimeCompare([]byte(want), []byte(token)) != 1 {
+		return "", false
+	}
+	email := s.GetEmailForPeer(peerID)
+	return email, email != ""
+}
+
+// handleDigestPrefs reads (GET, token in X-Goop-Token) or sets (POST) a
+// peer's digest subscription.
+func (s *Server) handleDigestPrefs(w http.ResponseWriter, r *http.Request) {
+	if !s.digestsEnabled() {
+		http.Error(w, "digests not available", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		peerID := r.URL.Query().Get("peer")
+		if _, ok := s.digestAuth(peerID, r.Header.Get("X-Goop-Token")); !ok {
+			http.Error(w, "verified email required", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(s.digests.status(peerID))
+	case http.MethodPost:
+		var p DigestPrefs
+		if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&p); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		switch p.Frequency {
+		case DigestOff, DigestDaily, DigestWeekly:
+		default:
+			http.Error(w, "frequency must be off, daily or weekly", http.StatusBadRequest)
+			return
+		}
+		email, ok := s.digestAuth(p.PeerID, p.Token)
+		if !ok {
+			http.Error(w, "verified email required", http.StatusForbidden)
+			return
+		}
+		s.saveDigest(s.digests.set(p, email))
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(s.digests.status(p.PeerID))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDigestEvent records an event reported by the peer it comes from.
+// The sender must be online here; events are rate limited like /publish.
+func (s *Server) handleDigestEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.digestsEnabled() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !s.allowPublish(extractIP(r.RemoteAddr)) {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+	var ev DigestEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&ev); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	if _, err := peer.Decode(ev.From); err != nil {
+		http.Error(w, "invalid peer ID", http.StatusBadRequest)
+		return
+	}
+	switch ev.Kind {
+	case DigestKindChat, DigestKindInvite, DigestKindStation:
+	default:
+		http.Error(w, "unknown kind", http.StatusBadRequest)
+		return
+	}
+	if len(ev.Name) > 80 {
+		ev.Name = ev.Name[:80]
+	}
+
+	s.mu.Lock()
+	from, known := s.peers[ev.From]
+	s.mu.Unlock()
+	if !known || from.Type == proto.TypeOffline {
+		http.Error(w, "unknown peer", http.StatusNotFound)
+		return
+	}
+	label := from.Content
+	if label == "" {
+		label = shortID(ev.From)
+	}
+	s.digests.record(ev, label, s.peerOnline)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDigestUnsubscribe is the one-click link in every digest.
+func (s *Server) handleDigestUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	row, ok := s.digests.unsubscribe(r.URL.Query().Get("token"))
+	if !ok {
+		http.Error(w, "unknown or expired link", http.StatusNotFound)
+		return
+	}
+	s.saveDigest(row)
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!doctype html><meta charset="utf-8"><title>Unsubscribed</title>`+
+		`<p>%s will not send you digests any more. You can turn them back on in your peer's settings.</p>`,
+		template.HTMLEscapeString(s.brandTitle("Goop²")))
+}
+
+// runDigests sends due digests every DigestCheckInterval.
+func (s *Server) runDigests(ctx context.Context) {
+	t := time.NewTicker(DigestCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.sendDueDigests(ctx, time.Now())
+		}
+	}
+}
+
+func (s *Server) sendDueDigests(ctx context.Context, now time.Time) {
+	site := s.brandTitle("Goop²")
+	for _, d := range s.digests.due(now, s.peerOnline) {
+		items := d.items()
+		data := map[string]any{
+			"site":            site,
+			"summary":         "While you were offline: " + strings.Join(items, ", ") + ".",
+			"items":           items,
+			"unsubscribe_url": s.externalURL + "/digest/unsubscribe?token=" + url.QueryEscape(d.unsubToken),
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, PresenceClientTimeout)
+		err := s.email.Send(sendCtx, d.email, "digest", site+": while you were offline", data)
+		cancel()
+		if err != nil {
+			log.Printf("digest: sending to %s failed: %v", shortID(d.peerID), err)
+			continue
+		}
+		if row, ok := s.digests.sent(d.peerID, now); ok {
+			s.saveDigest(row)
+		}
+	}
+}
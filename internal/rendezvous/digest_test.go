@@ -0,0 +1,124 @@
+package rendezvous
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func newTestPeerID(t *testing.T) string {
+	t.Helper()
+	_, pub, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.String()
+}
+
+func TestDigests_RecordAndDue(t *testing.T) {
+	ds := newDigests()
+	ds.set(DigestPrefs{PeerID: "alice", Frequency: DigestDaily, Favorites: []string{"bob"}}, "alice@example.org")
+	ds.set(DigestPrefs{PeerID: "carol", Frequency: DigestOff}, "carol@example.org")
+
+	offline := func(string) bool { return false }
+	ds.record(DigestEvent{From: "bob", To: "alice", Kind: DigestKindChat}, "Bob", offline)
+	ds.record(DigestEvent{From: "bob", To: "alice", Kind: DigestKindChat}, "Bob", offline)
+	ds.record(DigestEvent{From: "dave", To: "alice", Kind: DigestKindInvite}, "Dave", offline)
+	ds.record(DigestEvent{From: "bob", Kind: DigestKindStation, Name: "Jazz"}, "Bob", offline)
+	ds.record(DigestEvent{From: "dave", Kind: DigestKindStation}, "Dave", offline) // not a favorite
+	if n := ds.record(DigestEvent{From: "bob", To: "carol", Kind: DigestKindChat}, "Bob", offline); n != 0 {
+		t.Fatal("event recorded for an unsubscribed peer")
+	}
+	if n := ds.record(DigestEvent{From: "bob", To: "alice", Kind: DigestKindChat}, "Bob", func(string) bool { return true }); n != 0 {
+		t.Fatal("event recorded for an online peer")
+	}
+
+	now := time.Now()
+	due := ds.due(now, offline)
+	if len(due) != 1 {
+		t.Fatalf("due = %d", len(due))
+	}
+	want := "2 chat messages, 1 group invite, your favorite peer Bob started a listen station (Jazz)"
+	if got := strings.Join(due[0].items(), ", "); got != want {
+		t.Fatalf("items = %q", got)
+	}
+
+	ds.sent("alice", now)
+	ds.record(DigestEvent{From: "bob", To: "alice", Kind: DigestKindChat}, "Bob", offline)
+	if len(ds.due(now.Add(time.Hour), offline)) != 0 {
+		t.Fatal("daily digest due again within the day")
+	}
+	if len(ds.due(now.Add(25*time.Hour), offline)) != 1 {
+		t.Fatal("daily digest not due after a day")
+	}
+}
+
+func TestDigest_SubscribeSendUnsubscribe(t *testing.T) {
+	var sent []map[string]any
+	mail := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body)
+	}))
+	defer mail.Close()
+
+	s := New("127.0.0.1:0", "", "", "https://rv.example.org", 0, 0, "", RelayTimingConfig{})
+	s.SetEmailProvider(NewRemoteEmailProvider(mail.URL))
+	alice, bob := newTestPeerID(t), newTestPeerID(t)
+	s.mu.Lock()
+	s.peers[alice] = peerRow{PeerID: alice, Type: proto.TypeOnline, Email: "alice@example.org", Verified: true, verificationToken: "tok"}
+	s.peers[bob] = peerRow{PeerID: bob, Type: proto.TypeOnline, Content: "Bob"}
+	s.mu.Unlock()
+
+	post := func(h http.HandlerFunc, path string, v any) int {
+		b, _ := json.Marshal(v)
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(b))))
+		return rec.Code
+	}
+	if code := post(s.handleDigestPrefs, "/api/digest/prefs", DigestPrefs{PeerID: alice, Token: "wrong", Frequency: DigestDaily}); code != http.StatusForbidden {
+		t.Fatalf("wrong token = %d", code)
+	}
+	if code := post(s.handleDigestPrefs, "/api/digest/prefs", DigestPrefs{PeerID: alice, Token: "tok", Frequency: DigestDaily}); code != http.StatusOK {
+		t.Fatalf("subscribe = %d", code)
+	}
+
+	// Alice goes offline; Bob's message to her fails and he reports it.
+	s.mu.Lock()
+	p := s.peers[alice]
+	p.Type = proto.TypeOffline
+	s.peers[alice] = p
+	s.mu.Unlock()
+	if code := post(s.handleDigestEvent, "/api/digest/event", DigestEvent{From: bob, To: alice, Kind: DigestKindChat}); code != http.StatusNoContent {
+		t.Fatalf("event = %d", code)
+	}
+
+	s.sendDueDigests(t.Context(), time.Now())
+	if len(sent) != 1 || sent[0]["to"] != "alice@example.org" || sent[0]["template"] != "digest" {
+		t.Fatalf("sent = %v", sent)
+	}
+	data := sent[0]["data"].(map[string]any)
+	unsub, _ := data["unsubscribe_url"].(string)
+	if !strings.HasPrefix(unsub, "https://rv.example.org/digest/unsubscribe?token=") || !strings.Contains(data["summary"].(string), "1 chat message") {
+		t.Fatalf("data = %v", data)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleDigestUnsubscribe(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(unsub, "https://rv.example.org"), nil))
+	if rec.Code != http.StatusOK || s.digests.status(alice).Frequency != DigestOff {
+		t.Fatalf("unsubscribe = %d, %+v", rec.Code, s.digests.status(alice))
+	}
+}
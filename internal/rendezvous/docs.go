@@ -48,11 +48,9 @@ type DocSite struct {
 	BySlug map[string]*DocPage
 }
 
-// newDocSite reads all doc pages from the centralized docs package,
-// renders them with goldmark, and returns a DocSite ordered by
-// the defined page sequence. All rendering happens once at startup.
-func newDocSite() *DocSite {
-	md := goldmark.New(
+// newDocMarkdown returns the goldmark renderer used for every doc page.
+func newDocMarkdown() goldmark.Markdown {
+	return goldmark.New(
 		goldmark.WithExtensions(
 			extension.Table,
 			highlighting.NewHighlighting(
@@ -63,6 +61,24 @@ func newDocSite() *DocSite {
 		),
 		goldmark.WithRendererOptions(html.WithUnsafe()),
 	)
+}
+
+// docTitle returns the text of the first "# " heading, or def.
+func docTitle(data []byte, def string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimPrefix(line, "# ")
+		}
+	}
+	return def
+}
+
+// newDocSite reads all doc pages from the centralized docs package,
+// renders them with goldmark, and returns a DocSite ordered by
+// the defined page sequence. All rendering happens once at startup.
+func newDocSite() *DocSite {
+	md := newDocMarkdown()
 
 	site := &DocSite{BySlug: map[string]*DocPage{}}
 
@@ -72,29 +88,27 @@ func newDocSite() *DocSite {
 			continue
 		}
 
-		title := entry.Slug
-		for _, line := range strings.Split(string(data), "\n") {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "# ") {
-				title = strings.TrimPrefix(line, "# ")
-				break
-			}
-		}
-
 		var buf bytes.Buffer
 		if err := md.Convert(data, &buf); err != nil {
 			continue
 		}
 
-		page := DocPage{
+		site.Pages = append(site.Pages, DocPage{
 			Slug:  entry.Slug,
-			Title: title,
+			Title: docTitle(data, entry.Slug),
 			Order: i,
 			HTML:  template.HTML(buf.String()),
-		}
-		site.Pages = append(site.Pages, page)
-		site.BySlug[entry.Slug] = &site.Pages[len(site.Pages)-1]
+		})
 	}
+	site.index()
 
 	return site
 }
+
+// index rebuilds BySlug from Pages.
+func (d *DocSite) index() {
+	d.BySlug = make(map[string]*DocPage, len(d.Pages))
+	for i := range d.Pages {
+		d.BySlug[d.Pages[i].Slug] = &d.Pages[i]
+	}
+}
@@ -0,0 +1,226 @@
+package rendezvous
+
+// docs_dir.go — operator-authored documentation. SetDocsDir adds the
+// Markdown files of a local directory to the /docs site, next to the
+// embedded pages, and Start watches the directory so edits show up
+// without a restart.
+//
+// A page may start with front matter:
+//
+//	---
+//	title: Pricing
+//	slug: pricing
+//	order: 10
+//	---
+//
+// title defaults to the first "# " heading, slug to the file name. Pages
+// follow the embedded ones, sorted by order and then slug; a page whose
+// slug matches an embedded page replaces it in place.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/yuin/goldmark"
+)
+
+const docsMaxPageBytes = 1 << 20
+
+var docSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// parseDocFrontMatter splits a leading "---" block of "key: value" lines
+// from the Markdown body. Without one, meta is nil and body is data.
+func parseDocFrontMatter(data []byte) (meta map[string]string, body []byte) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, data
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "---" {
+			continue
+		}
+		meta = map[string]string{}
+		for _, line := range lines[1:i] {
+			key, val, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			meta[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(val), `"'`)
+		}
+		return meta, []byte(strings.Join(lines[i+1:], "\n"))
+	}
+	return nil, data
+}
+
+// loadDocsDir renders every *.md file in dir. Files that cannot be used
+// are logged and skipped so one bad page does not hide the others.
+func loadDocsDir(dir string, md goldmark.Markdown) ([]DocPage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var pages []DocPage
+	seen := map[string]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(strings.ToLower(name), ".md") {
+			continue
+		}
+		data, err := readLimited(filepath.Join(dir, name), docsMaxPageBytes)
+		if err != nil {
+			log.Printf("docs: skipping %s: %v", name, err)
+			continue
+		}
+		meta, body := parseDocFrontMatter(data)
+
+		slug := meta["slug"]
+		if slug == "" {
+			slug = strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		}
+		if !docSlugRe.MatchString(slug) {
+			log.Printf("docs: skipping %s: invalid slug %q", name, slug)
+			continue
+		}
+		if prev, dup := seen[slug]; dup {
+			log.Printf("docs: skipping %s: slug %q already used by %s", name, slug, prev)
+			continue
+		}
+		order := 0
+		if v := meta["order"]; v != "" {
+			if order, err = strconv.Atoi(v); err != nil {
+				log.Printf("docs: skipping %s: order %q is not a number", name, v)
+				continue
+			}
+		}
+		title := meta["title"]
+		if title == "" {
+			title = docTitle(body, slug)
+		}
+
+		var buf bytes.Buffer
+		if err := md.Convert(body, &buf); err != nil {
+			log.Printf("docs: skipping %s: %v", name, err)
+			continue
+		}
+		seen[slug] = name
+		pages = append(pages, DocPage{Slug: slug, Title: title, Order: order, HTML: template.HTML(buf.String())})
+	}
+	return pages, nil
+}
+
+// mergeDocSite returns base with the operator's pages applied: same-slug
+// pages replace embedded ones, the rest are appended by order, then slug.
+func mergeDocSite(base *DocSite, extra []DocPage) *DocSite {
+	site := &DocSite{Pages: append([]DocPage(nil), base.Pages...)}
+	var added []DocPage
+	for _, p := range extra {
+		if old, ok := base.BySlug[p.Slug]; ok {
+			p.Order = old.Order
+			for i := range site.Pages {
+				if site.Pages[i].Slug == p.Slug {
+					site.Pages[i] = p
+				}
+			}
+			continue
+		}
+		added = append(added, p)
+	}
+	sort.SliceStable(added, func(i, j int) bool {
+		if added[i].Order != added[j].Order {
+			return added[i].Order < added[j].Order
+		}
+		return added[i].Slug < added[j].Slug
+	})
+	for _, p := range added {
+		p.Order = len(site.Pages)
+		site.Pages = append(site.Pages, p)
+	}
+	site.index()
+	return site
+}
+
+// SetDocsDir adds the Markdown pages in dir to /docs. Must be called before
+// Start; Start then reloads the pages whenever the directory changes.
+func (s *Server) SetDocsDir(dir string) error {
+	pages, err := loadDocsDir(dir, newDocMarkdown())
+	if err != nil {
+		return err
+	}
+	s.docsMu.Lock()
+	s.docsDir = dir
+	s.docsSite = mergeDocSite(newDocSite(), pages)
+	s.docsMu.Unlock()
+	return nil
+}
+
+// docs returns the current doc site.
+func (s *Server) docs() *DocSite {
+	s.docsMu.RLock()
+	defer s.docsMu.RUnlock()
+	return s.docsSite
+}
+
+// reloadDocs re-reads the docs directory. On error the current pages stay.
+func (s *Server) reloadDocs() error {
+	pages, err := loadDocsDir(s.docsDir, newDocMarkdown())
+	if err != nil {
+		return err
+	}
+	site := mergeDocSite(newDocSite(), pages)
+	s.docsMu.Lock()
+	s.docsSite = site
+	s.docsMu.Unlock()
+	return nil
+}
+
+// watchDocsDir reloads the docs directory after changes settle for
+// DocsReloadDelay.
+func (s *Server) watchDocsDir(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create fsnotify watcher: %w", err)
+	}
+	if err := w.Add(s.docsDir); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("watch %s: %w", s.docsDir, err)
+	}
+	go func() {
+		defer w.Close()
+		var pending <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if strings.HasSuffix(strings.ToLower(ev.Name), ".md") {
+					pending = time.After(DocsReloadDelay)
+				}
+			case <-pending:
+				pending = nil
+				if err := s.reloadDocs(); err != nil {
+					log.Printf("docs: reload %s failed: %v", s.docsDir, err)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("docs: watcher error: %v", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,69 @@
+package rendezvous
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDocFrontMatter(t *testing.T) {
+	meta, body := parseDocFrontMatter([]byte("---\r\ntitle: \"House rules\"\r\norder: 2\r\n---\r\n# Rules\r\nBe nice.\r\n"))
+	if meta["title"] != "House rules" || meta["order"] != "2" {
+		t.Fatalf("meta = %v", meta)
+	}
+	if !strings.HasPrefix(string(body), "# Rules") {
+		t.Fatalf("body = %q", body)
+	}
+	if meta, _ := parseDocFrontMatter([]byte("# No front matter\n---\n")); meta != nil {
+		t.Fatalf("meta without front matter = %v", meta)
+	}
+}
+
+func TestSetDocsDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pricing.md", "---\norder: 2\n---\n# Pricing\nFree.\n")
+	write("rules.md", "---\ntitle: House rules\norder: 1\n---\nBe nice.\n")
+	write("faq.md", "# Our FAQ\nAsk the operator.\n")
+	write("Bad Name.md", "# Skipped\n")
+	write("notes.txt", "not markdown")
+
+	s := &Server{docsSite: newDocSite()}
+	if err := s.SetDocsDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	site := s.docs()
+	n := len(pageOrder)
+	if len(site.Pages) != n+2 {
+		t.Fatalf("pages = %d, want %d", len(site.Pages), n+2)
+	}
+	if site.Pages[n].Slug != "rules" || site.Pages[n].Title != "House rules" || site.Pages[n+1].Slug != "pricing" {
+		t.Fatalf("operator pages = %q, %q", site.Pages[n].Slug, site.Pages[n+1].Slug)
+	}
+	faq := site.BySlug["faq"]
+	if faq == nil || faq.Title != "Our FAQ" || site.Pages[faq.Order].Slug != "faq" {
+		t.Fatalf("faq override = %+v", faq)
+	}
+
+	write("pricing.md", "# Pricing\nNow paid.\n")
+	if err := os.Remove(filepath.Join(dir, "rules.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.reloadDocs(); err != nil {
+		t.Fatal(err)
+	}
+	site = s.docs()
+	if _, ok := site.BySlug["rules"]; ok || !strings.Contains(string(site.BySlug["pricing"].HTML), "Now paid") {
+		t.Fatal("reload did not pick up changes")
+	}
+
+	if err := s.SetDocsDir(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("missing dir accepted")
+	}
+}
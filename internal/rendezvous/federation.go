@@ -0,0 +1,380 @@
+package rendezvous
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/util"
+)
+
+// Federation: rendezvous servers that list each other share the presence of
+// their peers, so a peer on one sees the peers on all of them. Each server
+// has its own identity key and signs what it forwards; a server only takes
+// presence from the servers it lists. Every message carries the ID of the
+// peer's home server and of the servers it passed, so it never comes back
+// around and stops after FederationMaxHops.
+
+// FederationPeer is another rendezvous to share presence with.
+type FederationPeer struct {
+	URL      string // base URL
+	ServerID string // its federation identity, from /federation/info there
+}
+
+// FederationConfig sets up federation with SetFederation.
+type FederationConfig struct {
+	KeyFile string // this server's identity key, created when missing
+	Peers   []FederationPeer
+}
+
+// FederatedPresence is one presence message forwarded between servers.
+type FederatedPresence struct {
+	Origin    string            `json:"origin"`     // server ID of the peer's home server
+	OriginURL string            `json:"origin_url"` // URL of the home server
+	Via       []string          `json:"via"`        // servers the message passed, origin first
+	Msg       proto.PresenceMsg `json:"msg"`
+}
+
+// FederationBatch is the body of POST /federation/presence.
+type FederationBatch struct {
+	From     string              `json:"from"` // sending server ID
+	TS       int64               `json:"ts"`   // Unix ms
+	Messages []FederatedPresence `json:"messages"`
+	Sig      []byte              `json:"sig"` // sender's signature over SigningBytes
+}
+
+// SigningBytes returns the canonical bytes covered by Sig.
+func (b FederationBatch) SigningBytes() []byte {
+	out, _ := json.Marshal([]any{"goop-federation", b.From, b.TS, b.Messages})
+	return out
+}
+
+// FederationInfo is served at GET /federation/info.
+type FederationInfo struct {
+	ServerID string `json:"server_id"`
+	URL      string `json:"url"`
+}
+
+type fedLink struct {
+	FederationPeer
+	pub crypto.PubKey
+
+	// guarded by federation.mu
+	sent     int64
+	lastOK   time.Time
+	lastErr  string
+	received int64
+}
+
+type federation struct {
+	key    crypto.PrivKey
+	id     string
+	links  []*fedLink
+	byID   map[string]*fedLink
+	http   *http.Client
+	urgent chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]FederatedPresence // latest presence per peer to forward
+}
+
+// SetFederation loads the server's federation key and the servers to share
+// presence with. Call before Start.
+func (s *Server) SetFederation(cfg FederationConfig) error {
+	key, err := loadOrCreateKey(cfg.KeyFile, "federation")
+	if err != nil {
+		return err
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	f := &federation{
+		key:     key,
+		id:      id.String(),
+		byID:    make(map[string]*fedLink),
+		http:    &http.Client{Timeout: HTTPClientTimeout},
+		urgent:  make(chan struct{}, 1),
+		pending: make(map[string]FederatedPresence),
+	}
+	for _, p := range cfg.Peers {
+		pid, err := peer.Decode(p.ServerID)
+		if err != nil {
+			return fmt.Errorf("server ID of %s: %w", p.URL, err)
+		}
+		pub, err := pid.ExtractPublicKey()
+		if err != nil {
+			return fmt.Errorf("server ID of %s: %w", p.URL, err)
+		}
+		l := &fedLink{FederationPeer: FederationPeer{URL: util.NormalizeURL(p.URL), ServerID: p.ServerID}, pub: pub}
+		f.links = append(f.links, l)
+		f.byID[p.ServerID] = l
+	}
+	s.fed = f
+	return nil
+}
+
+// FederationID returns the server's federation identity, "" when off.
+func (s *Server) FederationID() string {
+	if s.fed == nil {
+		return ""
+	}
+	return s.fed.id
+}
+
+// federateLocal queues presence a peer published here for the federation.
+func (s *Server) federateLocal(pm proto.PresenceMsg) {
+	s.fed.queue(FederatedPresence{Origin: s.fed.id, OriginURL: s.URL(), Via: []string{s.fed.id}, Msg: pm})
+}
+
+func (f *federation) queue(fp FederatedPresence) {
+	f.mu.Lock()
+	f.pending[fp.Msg.PeerID] = fp
+	f.mu.Unlock()
+	if fp.Msg.Type != proto.TypeUpdate {
+		select {
+		case f.urgent <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// runFederation forwards queued presence every FederationBatchInterval, and
+// right away when a peer comes online or goes offline.
+func (s *Server) runFederation(ctx context.Context) {
+	f := s.fed
+	log.Printf("rendezvous: federation as %s with %d servers", f.id, len(f.links))
+	t := time.NewTicker(FederationBatchInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-f.urgent:
+		}
+		f.mu.Lock()
+		msgs := make([]FederatedPresence, 0, len(f.pending))
+		for _, fp := range f.pending {
+			msgs = append(msgs, fp)
+		}
+		clear(f.pending)
+		f.mu.Unlock()
+		if len(msgs) == 0 {
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for _, l := range f.links {
+			// Never send a message back to a server it already passed.
+			var out []FederatedPresence
+			for _, fp := range msgs {
+				if !slices.Contains(fp.Via, l.ServerID) {
+					out = append(out, fp)
+				}
+			}
+			if len(out) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := f.send(ctx, l, out)
+				f.mu.Lock()
+				if err != nil {
+					l.lastErr = err.Error()
+				} else {
+					l.lastErr, l.lastOK = "", time.Now()
+					l.sent += int64(len(out))
+				}
+				f.mu.Unlock()
+				if err != nil && ctx.Err() == nil {
+					s.addLog(fmt.Sprintf("Federation: forward to %s failed: %v", l.URL, err))
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// send signs msgs and posts them to l in batches of FederationMaxBatch.
+func (f *federation) send(ctx context.Context, l *fedLink, msgs []FederatedPresence) error {
+	for len(msgs) > 0 {
+		n := min(len(msgs), FederationMaxBatch)
+		batch := FederationBatch{From: f.id, TS: proto.NowMillis(), Messages: msgs[:n]}
+		sig, err := f.key.Sign(batch.SigningBytes())
+		if err != nil {
+			return err
+		}
+		batch.Sig = sig
+		b, _ := json.Marshal(batch)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.URL+"/federation/presence", bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("content-type", "application/json")
+		resp, err := f.http.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		msgs = msgs[n:]
+	}
+	return nil
+}
+
+// handleFederationPresence takes presence forwarded by a listed server.
+func (s *Server) handleFederationPresence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.fed == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var batch FederationBatch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, FederationMaxBatchBytes)).Decode(&batch); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	status, err := s.fed.check(batch)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var res PresenceBatchResult
+	for _, fp := range batch.Messages {
+		if s.acceptFederated(batch.From, fp) {
+			res.Accepted++
+		} else {
+			res.Rejected++
+		}
+	}
+	s.fed.mu.Lock()
+	s.fed.byID[batch.From].received += int64(res.Accepted)
+	s.fed.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// check verifies that batch comes from a listed server and is fresh.
+func (f *federation) check(batch FederationBatch) (int, error) {
+	l, ok := f.byID[batch.From]
+	if !ok {
+		return http.StatusForbidden, fmt.Errorf("unknown server %q", batch.From)
+	}
+	if ok, err := l.pub.Verify(batch.SigningBytes(), batch.Sig); err != nil || !ok {
+		return http.StatusForbidden, fmt.Errorf("bad signature")
+	}
+	if skew := time.Since(time.UnixMilli(batch.TS)); skew > FederationMaxSkew || skew < -FederationMaxSkew {
+		return http.StatusBadRequest, fmt.Errorf("stale batch")
+	}
+	if len(batch.Messages) > FederationMaxBatch {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("batch too large (max %d)", FederationMaxBatch)
+	}
+	return 0, nil
+}
+
+// acceptFederated applies one forwarded message and queues it for the
+// servers it has not passed yet. A peer that also publishes here directly
+// keeps its local row.
+func (s *Server) acceptFederated(from string, fp FederatedPresence) bool {
+	n := len(fp.Via)
+	if n == 0 || fp.Via[0] != fp.Origin || fp.Via[n-1] != from || n > FederationMaxHops {
+		return false
+	}
+	if slices.Contains(fp.Via, s.fed.id) {
+		return false // came around
+	}
+	pm := fp.Msg
+	if _, err := s.screenPresence(&pm); err != nil {
+		return false // not stored, broadcast or forwarded
+	}
+	pm.VerificationToken = ""
+
+	s.mu.Lock()
+	existing, exists := s.peers[pm.PeerID]
+	s.mu.Unlock()
+	if exists && existing.Home == "" {
+		return true
+	}
+
+	b, _ := json.Marshal(pm)
+	addrsChanged := s.upsertPeer(pm, int64(len(b)), pm.Verified, "", fp.OriginURL)
+	s.broadcast(b)
+	if pm.Type == proto.TypeOnline || pm.Type == proto.TypeUpdate {
+		s.emitPunchHints(pm, addrsChanged)
+	}
+
+	if n < FederationMaxHops {
+		fp.Msg = pm
+		fp.Via = append(slices.Clone(fp.Via), s.fed.id)
+		s.fed.queue(fp)
+	}
+	return true
+}
+
+// handleFederationInfo tells other operators what to put in their config.
+func (s *Server) handleFederationInfo(w http.ResponseWriter, r *http.Request) {
+	if s.fed == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(FederationInfo{ServerID: s.fed.id, URL: s.URL()})
+}
+
+type federationLinkJSON struct {
+	URL      string `json:"url"`
+	ServerID string `json:"server_id"`
+	Sent     int64  `json:"sent"`
+	Received int64  `json:"received"`
+	LastOK   int64  `json:"last_ok,omitempty"` // Unix ms
+	Error    string `json:"error,omitempty"`
+}
+
+// handleFederationJSON lists the federated servers for the admin page.
+func (s *Server) handleFederationJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireRole(w, r, roleViewer) {
+		return
+	}
+	out := struct {
+		ServerID string               `json:"server_id,omitempty"`
+		Servers  []federationLinkJSON `json:"servers"`
+	}{Servers: []federationLinkJSON{}}
+	if f := s.fed; f != nil {
+		out.ServerID = f.id
+		f.mu.Lock()
+		for _, l := range f.links {
+			row := federationLinkJSON{URL: l.URL, ServerID: l.ServerID, Sent: l.sent, Received: l.received, Error: l.lastErr}
+			if !l.lastOK.IsZero() {
+				row.LastOK = l.lastOK.UnixMilli()
+			}
+			out.Servers = append(out.Servers, row)
+		}
+		f.mu.Unlock()
+	}
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(out)
+}
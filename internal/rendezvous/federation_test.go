@@ -0,0 +1,145 @@
+package rendezvous
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func federationID(t *testing.T, keyFile string) string {
+	t.Helper()
+	key, err := loadOrCreateKey(keyFile, "federation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.String()
+}
+
+func peerHome(s *Server, peerID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.peers[peerID]
+	return row.Home, ok
+}
+
+func TestFederationSharesPresence(t *testing.T) {
+	dir := t.TempDir()
+	keyA, keyB := filepath.Join(dir, "a.key"), filepath.Join(dir, "b.key")
+	idA, idB := federationID(t, keyA), federationID(t, keyB)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := New("127.0.0.1:18794", "", "", "", 0, 0, "", RelayTimingConfig{})
+	b := New("127.0.0.1:18795", "", "", "", 0, 0, "", RelayTimingConfig{})
+	if err := a.SetFederation(FederationConfig{KeyFile: keyA, Peers: []FederationPeer{{URL: b.URL(), ServerID: idB}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetFederation(FederationConfig{KeyFile: keyB, Peers: []FederationPeer{{URL: a.URL(), ServerID: idA}}}); err != nil {
+		t.Fatal(err)
+	}
+	if a.FederationID() != idA {
+		t.Fatalf("FederationID = %s, want %s", a.FederationID(), idA)
+	}
+	for _, s := range []*Server{a, b} {
+		if err := s.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	publishPeer(t, a.URL(), "fed-a")
+	publishPeer(t, b.URL(), "fed-b")
+	waitFor(t, "fed-a on b", func() bool { return hasPeer(b, "fed-a") })
+	waitFor(t, "fed-b on a", func() bool { return hasPeer(a, "fed-b") })
+
+	if home, _ := peerHome(b, "fed-a"); home != a.URL() {
+		t.Errorf("home of fed-a on b = %q, want %q", home, a.URL())
+	}
+	// Give b time to (wrongly) forward fed-a back.
+	time.Sleep(200 * time.Millisecond)
+	if home, _ := peerHome(a, "fed-a"); home != "" {
+		t.Errorf("home of fed-a on a = %q, want local", home)
+	}
+}
+
+func TestFederationRejectsUnknownServer(t *testing.T) {
+	dir := t.TempDir()
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	if err := s.SetFederation(FederationConfig{KeyFile: filepath.Join(dir, "s.key")}); err != nil {
+		t.Fatal(err)
+	}
+	other := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	if err := other.SetFederation(FederationConfig{KeyFile: filepath.Join(dir, "o.key")}); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := FederationBatch{From: other.fed.id, TS: proto.NowMillis(), Messages: []FederatedPresence{{
+		Origin: other.fed.id, Via: []string{other.fed.id},
+		Msg: proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "x", TS: proto.NowMillis()},
+	}}}
+	batch.Sig, _ = other.fed.key.Sign(batch.SigningBytes())
+	if status, err := s.fed.check(batch); err == nil || status != http.StatusForbidden {
+		t.Errorf("check unknown server = %d, %v; want 403", status, err)
+	}
+
+	// Once listed, a tampered batch still fails.
+	s.fed.byID[other.fed.id] = &fedLink{FederationPeer: FederationPeer{ServerID: other.fed.id}, pub: other.fed.key.GetPublic()}
+	if _, err := s.fed.check(batch); err != nil {
+		t.Fatalf("check signed batch: %v", err)
+	}
+	batch.Messages[0].Msg.Content = "rewritten"
+	if status, err := s.fed.check(batch); err == nil || status != http.StatusForbidden {
+		t.Errorf("check tampered batch = %d, %v; want 403", status, err)
+	}
+}
+
+func TestFederationDropsLoops(t *testing.T) {
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	if err := s.SetFederation(FederationConfig{KeyFile: filepath.Join(t.TempDir(), "s.key")}); err != nil {
+		t.Fatal(err)
+	}
+	pm := proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "loop", TS: proto.NowMillis()}
+	self := s.fed.id
+	for name, fp := range map[string]FederatedPresence{
+		"own message back": {Origin: self, Via: []string{self, "B"}, Msg: pm},
+		"passed us before": {Origin: "A", Via: []string{"A", self, "B"}, Msg: pm},
+		"via not origin":   {Origin: "A", Via: []string{"C", "B"}, Msg: pm},
+		"sender not last":  {Origin: "A", Via: []string{"A", "C"}, Msg: pm},
+	} {
+		if s.acceptFederated("B", fp) {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	banned := proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "banned", TS: proto.NowMillis()}
+	s.bans.add(banRow{Target: "banned", Kind: banPeer})
+	if s.acceptFederated("B", FederatedPresence{Origin: "A", Via: []string{"A", "B"}, Msg: banned}) {
+		t.Error("banned peer accepted")
+	}
+	if _, ok := s.fed.pending["banned"]; ok {
+		t.Error("banned peer queued for federation")
+	}
+	if !s.acceptFederated("B", FederatedPresence{Origin: "A", OriginURL: "https://a", Via: []string{"A", "B"}, Msg: pm}) {
+		t.Fatal("valid message rejected")
+	}
+	queued := s.fed.pending["loop"]
+	if got := strings.Join(queued.Via, ","); got != "A,B,"+self {
+		t.Errorf("forwarded via = %s, want A,B,%s", got, self)
+	}
+	b, _ := json.Marshal(s.snapshotPeers())
+	if !strings.Contains(string(b), `"home":"https://a"`) {
+		t.Errorf("peers.json lacks the home server: %s", b)
+	}
+}
@@ -0,0 +1,440 @@
+package rendezvous
+
+// install_sessions.go — progress of store template installs. The peer opens
+// an install session before it downloads a bundle and passes the session ID
+// along with the download (?install=<id>). The rendezvous streams what it
+// sees of the proxied bundle — access checks, bytes sent, the checksum of a
+// complete bundle — to SSE subscribers of the session, and the peer reports
+// how applying the bundle went, so the store page can follow one install
+// from the click to the applied site.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// Install stages, in the order a session goes through them. InstallApplied
+// and InstallFailed end the session.
+const (
+	InstallCreated     = "created"
+	InstallVerifying   = "verifying"   // bundle request arrived; registration and credit checks
+	InstallDownloading = "downloading" // Bytes of Total sent so far
+	InstallDownloaded  = "downloaded"  // whole bundle sent, SHA256 is its checksum
+	InstallApplying    = "applying"    // reported by the peer
+	InstallApplied     = "applied"     // reported by the peer
+	InstallFailed      = "failed"
+)
+
+// InstallEvent is one step of an install session, as sent over SSE.
+type InstallEvent struct {
+	Seq      int    `json:"seq"`
+	Stage    string `json:"stage"`
+	Template string `json:"template"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Total    int64  `json:"total,omitempty"` // 0 when the bundle size is not known up front
+	SHA256   string `json:"sha256,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Done     bool   `json:"done,omitempty"`
+	TS       int64  `json:"ts"` // unix millis
+}
+
+// InstallStatus is what a peer reports about applying a downloaded bundle.
+type InstallStatus struct {
+	PeerID string `json:"peer_id"`
+	Stage  string `json:"stage"` // applying, applied or failed
+	Error  string `json:"error,omitempty"`
+}
+
+type installSession struct {
+	peerID   string
+	template string
+	created  time.Time
+	events   []InstallEvent // history for late subscribers; progress is kept as the latest event only
+	subs     map[chan InstallEvent]struct{}
+	done     bool
+}
+
+// installSessions holds the sessions of the last InstallSessionTTL.
+type installSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*installSession
+}
+
+func newInstallSessions() *installSessions {
+	return &installSessions{sessions: map[string]*installSession{}}
+}
+
+// create opens a session, or returns "" when too many are open.
+func (is *installSessions) create(peerID, template string, now time.Time) string {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.pruneLocked(now)
+	if len(is.sessions) >= InstallMaxSessions {
+		return ""
+	}
+	id := newDigestToken()
+	sess := &installSession{peerID: peerID, template: template, created: now, subs: map[chan InstallEvent]struct{}{}}
+	is.sessions[id] = sess
+	is.emitLocked(sess, InstallEvent{Stage: InstallCreated}, now)
+	return id
+}
+
+func (is *installSessions) pruneLocked(now time.Time) {
+	for id, sess := range is.sessions {
+		if now.Sub(sess.created) > InstallSessionTTL {
+			for ch := range sess.subs {
+				close(ch)
+			}
+			delete(is.sessions, id)
+		}
+	}
+}
+
+// get returns the template and peer of a session that is still open.
+func (is *installSessions) get(id string) (template, peerID string, ok bool) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	sess, ok := is.sessions[id]
+	if !ok || sess.done {
+		return "", "", false
+	}
+	return sess.template, sess.peerID, true
+}
+
+// emit adds ev to a session. Events for unknown or finished sessions are
+// dropped and reported as false.
+func (is *installSessions) emit(id string, ev InstallEvent) bool {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	sess, ok := is.sessions[id]
+	if !ok || sess.done {
+		return false
+	}
+	is.emitLocked(sess, ev, time.Now())
+	return true
+}
+
+func (is *installSessions) emitLocked(sess *installSession, ev InstallEvent, now time.Time) {
+	ev.Template = sess.template
+	ev.TS = now.UnixMilli()
+	ev.Done = ev.Stage == InstallApplied || ev.Stage == InstallFailed
+	if n := len(sess.events); n > 0 {
+		ev.Seq = sess.events[n-1].Seq + 1
+		if ev.Stage == InstallDownloading && sess.events[n-1].Stage == InstallDownloading {
+			sess.events = sess.events[:n-1]
+		}
+	}
+	sess.events = append(sess.events, ev)
+	for ch := range sess.subs {
+		select {
+		case ch <- ev:
+		default: // slow subscriber; it catches up from the history on close
+		}
+	}
+	if ev.Done {
+		sess.done = true
+		for ch := range sess.subs {
+			close(ch)
+		}
+		sess.subs = map[chan InstallEvent]struct{}{}
+	}
+}
+
+// subscribe returns the events so far and a channel for the ones to come,
+// closed when the session ends. The channel is nil for a finished session.
+func (is *installSessions) subscribe(id string) (history []InstallEvent, ch chan InstallEvent, ok bool) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	sess, ok := is.sessions[id]
+	if !ok {
+		return nil, nil, false
+	}
+	history = append([]InstallEvent(nil), sess.events...)
+	if !sess.done {
+		ch = make(chan InstallEvent, 32)
+		sess.subs[ch] = struct{}{}
+	}
+	return history, ch, true
+}
+
+func (is *installSessions) unsubscribe(id string, ch chan InstallEvent) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if sess, ok := is.sessions[id]; ok {
+		if _, subscribed := sess.subs[ch]; subscribed {
+			delete(sess.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// since returns the events after seq.
+func (is *installSessions) since(id string, seq int) []InstallEvent {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	sess, ok := is.sessions[id]
+	if !ok {
+		return nil
+	}
+	var out []InstallEvent
+	for _, ev := range sess.events {
+		if ev.Seq > seq {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// handleInstallCreate opens an install session: POST {template, peer_id},
+// answered with {"session": id}.
+func (s *Server) handleInstallCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowPublish(extractIP(r.RemoteAddr)) {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+	var req struct {
+		Template string `json:"template"`
+		PeerID   string `json:"peer_id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	if req.Template == "" || strings.ContainsAny(req.Template, "/?#") {
+		http.Error(w, "invalid template", http.StatusBadRequest)
+		return
+	}
+	if _, err := peer.Decode(req.PeerID); err != nil {
+		http.Error(w, "invalid peer ID", http.StatusBadRequest)
+		return
+	}
+	if s.localTemplates != nil && s.templates == nil {
+		if _, ok := s.localTemplates.GetManifest(req.Template); !ok {
+			// Not 404: clients read that as a server without install sessions.
+			http.Error(w, "unknown template", http.StatusBadRequest)
+			return
+		}
+	}
+	id := s.installs.create(req.PeerID, req.Template, time.Now())
+	if id == "" {
+		http.Error(w, "too many installs in progress", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"session": id})
+}
+
+// handleInstallSession serves /api/templates/installs/<id>/events (GET, SSE)
+// and /api/templates/installs/<id>/status (POST, from the installing peer).
+func (s *Server) handleInstallSession(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/templates/installs/"), "/")
+	switch action {
+	case "events":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.streamInstall(w, r, id)
+	case "status":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var st InstallStatus
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&st); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		switch st.Stage {
+		case InstallApplying, InstallApplied, InstallFailed:
+		default:
+			http.Error(w, "stage must be applying, applied or failed", http.StatusBadRequest)
+			return
+		}
+		if _, owner, ok := s.installs.get(id); !ok || owner != st.PeerID {
+			http.Error(w, "no such install", http.StatusNotFound)
+			return
+		}
+		if len(st.Error) > 200 {
+			st.Error = st.Error[:200]
+		}
+		if !s.installs.emit(id, InstallEvent{Stage: st.Stage, Error: st.Error}) {
+			http.Error(w, "no such install", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// streamInstall replays a session's events and follows it until it ends.
+// Each event carries its Seq as the SSE id.
+func (s *Server) streamInstall(w http.ResponseWriter, r *http.Request, id string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	history, ch, ok := s.installs.subscribe(id)
+	if !ok {
+		http.Error(w, "no such install", http.StatusNotFound)
+		return
+	}
+	if ch != nil {
+		defer s.installs.unsubscribe(id, ch)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	last := -1
+	if v, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		last = v
+	}
+	send := func(ev InstallEvent) {
+		if ev.Seq <= last {
+			return
+		}
+		last = ev.Seq
+		b, _ := json.Marshal(ev)
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.Seq, b)
+	}
+	for _, ev := range history {
+		send(ev)
+	}
+	flusher.Flush()
+	if ch == nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(timeouts.Get().SSEHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = w.Write([]byte(": ping\n\n"))
+			flusher.Flush()
+		case ev, open := <-ch:
+			if !open {
+				// Ended: send whatever a full channel made us miss.
+				for _, ev := range s.installs.since(id, last) {
+					send(ev)
+				}
+				flusher.Flush()
+				return
+			}
+			send(ev)
+			flusher.Flush()
+		}
+	}
+}
+
+// trackInstall starts following a bundle download that names an install
+// session with ?install=. It returns the writer to serve the bundle through
+// and a function to call once the response is complete; without a session
+// both leave the response alone.
+func (s *Server) trackInstall(w http.ResponseWriter, r *http.Request, dir string) (http.ResponseWriter, func()) {
+	id := r.URL.Query().Get("install")
+	if id == "" {
+		return w, func() {}
+	}
+	template, _, ok := s.installs.get(id)
+	if !ok || template != dir {
+		return w, func() {}
+	}
+	s.installs.emit(id, InstallEvent{Stage: InstallVerifying})
+	pw := &installWriter{ResponseWriter: w, installs: s.installs, id: id, hash: sha256.New()}
+	return pw, pw.finish
+}
+
+// installWriter reports the bytes of a bundle response as they are written.
+type installWriter struct {
+	http.ResponseWriter
+	installs *installSessions
+	id       string
+	status   int
+	total    int64
+	written  int64
+	hash     hash.Hash
+	lastEmit time.Time
+	err      error
+}
+
+func (iw *installWriter) WriteHeader(status int) {
+	if iw.status == 0 {
+		iw.status = status
+		iw.total, _ = strconv.ParseInt(iw.Header().Get("Content-Length"), 10, 64)
+	}
+	iw.ResponseWriter.WriteHeader(status)
+}
+
+func (iw *installWriter) Write(b []byte) (int, error) {
+	if iw.status == 0 {
+		iw.WriteHeader(http.StatusOK)
+	}
+	n, err := iw.ResponseWriter.Write(b)
+	if iw.status/100 != 2 {
+		return n, err
+	}
+	iw.hash.Write(b[:n])
+	iw.written += int64(n)
+	if err != nil && iw.err == nil {
+		iw.err = err
+	}
+	if now := time.Now(); now.Sub(iw.lastEmit) >= InstallProgressInterval {
+		iw.lastEmit = now
+		iw.installs.emit(iw.id, InstallEvent{Stage: InstallDownloading, Bytes: iw.written, Total: iw.total})
+	}
+	return n, err
+}
+
+func (iw *installWriter) Flush() {
+	if f, ok := iw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (iw *installWriter) Unwrap() http.ResponseWriter {
+	return iw.ResponseWriter
+}
+
+// finish reports the outcome of the download: the checksum of a complete
+// bundle, or why it failed.
+func (iw *installWriter) finish() {
+	switch {
+	case iw.status/100 != 2:
+		iw.installs.emit(iw.id, InstallEvent{Stage: InstallFailed,
+			Error: fmt.Sprintf("bundle download refused: %d %s", iw.status, http.StatusText(iw.status))})
+	case iw.err != nil:
+		iw.installs.emit(iw.id, InstallEvent{Stage: InstallFailed, Bytes: iw.written, Total: iw.total,
+			Error: "bundle download interrupted: " + iw.err.Error()})
+	case iw.total > 0 && iw.written != iw.total:
+		iw.installs.emit(iw.id, InstallEvent{Stage: InstallFailed, Bytes: iw.written, Total: iw.total,
+			Error: fmt.Sprintf("bundle truncated at %d of %d bytes", iw.written, iw.total)})
+	default:
+		iw.installs.emit(iw.id, InstallEvent{Stage: InstallDownloaded, Bytes: iw.written, Total: iw.total,
+			SHA256: hex.EncodeToString(iw.hash.Sum(nil))})
+	}
+}
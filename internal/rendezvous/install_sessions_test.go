@@ -0,0 +1,161 @@
+package rendezvous
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newInstallTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "quiz"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "quiz", "manifest.json"), []byte(`{"name":"Quiz"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "quiz", "index.html"), []byte(strings.Repeat("<p>quiz</p>", 500)), 0o644)
+
+	srv := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	srv.SetLocalTemplateStore(NewLocalTemplateStore(dir))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/templates/", srv.handleLocalTemplateRoutes)
+	mux.HandleFunc("/api/templates/installs", srv.handleInstallCreate)
+	mux.HandleFunc("/api/templates/installs/", srv.handleInstallSession)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func readInstallEvents(t *testing.T, r io.Reader) []InstallEvent {
+	t.Helper()
+	var out []InstallEvent
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev InstallEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Errorf("event %q: %v", data, err)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+func TestInstallSession_followsDownloadAndApply(t *testing.T) {
+	ts := newInstallTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client := NewClient(ts.URL)
+	peerID := newTestPeerID(t)
+
+	if _, err := client.StartInstall(ctx, "nope", peerID); err == nil {
+		t.Error("session opened for an unknown template")
+	}
+	session, err := client.StartInstall(ctx, "quiz", peerID)
+	if err != nil || session == "" {
+		t.Fatalf("StartInstall = %q, %v", session, err)
+	}
+
+	stream, err := client.InstallEvents(ctx, session, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	got := make(chan []InstallEvent, 1)
+	go func() { got <- readInstallEvents(t, stream) }()
+
+	rc, err := client.DownloadTemplateBundle(ctx, "quiz", peerID, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, _ := io.ReadAll(rc)
+	rc.Close()
+	sum := sha256.Sum256(bundle)
+
+	if err := client.ReportInstall(ctx, session, InstallStatus{PeerID: newTestPeerID(t), Stage: InstallApplied}); err == nil {
+		t.Error("another peer reported on the session")
+	}
+	for _, stage := range []string{InstallApplying, InstallApplied} {
+		if err := client.ReportInstall(ctx, session, InstallStatus{PeerID: peerID, Stage: stage}); err != nil {
+			t.Fatalf("report %s: %v", stage, err)
+		}
+	}
+	if err := client.ReportInstall(ctx, session, InstallStatus{PeerID: peerID, Stage: InstallFailed}); err == nil {
+		t.Error("report accepted after the session ended")
+	}
+
+	var events []InstallEvent
+	select {
+	case events = <-got:
+	case <-ctx.Done():
+		t.Fatal("event stream did not end with the session")
+	}
+	var stages []string
+	for i, ev := range events {
+		if ev.Seq != i || ev.Template != "quiz" {
+			t.Errorf("event %d = %+v", i, ev)
+		}
+		if len(stages) == 0 || stages[len(stages)-1] != ev.Stage {
+			stages = append(stages, ev.Stage)
+		}
+		if ev.Stage == InstallDownloaded && (ev.SHA256 != hex.EncodeToString(sum[:]) || ev.Bytes != int64(len(bundle))) {
+			t.Errorf("downloaded = %+v, bundle is %d bytes", ev, len(bundle))
+		}
+	}
+	want := "created verifying downloading downloaded applying applied"
+	if strings.Join(stages, " ") != want {
+		t.Errorf("stages = %v, want %s", stages, want)
+	}
+	if last := events[len(events)-1]; !last.Done {
+		t.Errorf("last event not done: %+v", last)
+	}
+
+	// A late subscriber gets the whole history of a finished session.
+	late, err := client.InstallEvents(ctx, session, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := readInstallEvents(t, late)
+	late.Close()
+	if len(replay) != len(events)-3 || replay[0].Seq != 3 {
+		t.Errorf("replay after seq 2 = %+v", replay)
+	}
+}
+
+func TestInstallWriter_reportsTruncatedBundle(t *testing.T) {
+	is := newInstallSessions()
+	id := is.create("peer", "quiz", time.Now())
+	s := &Server{installs: is}
+
+	r := httptest.NewRequest("GET", "/api/templates/quiz/bundle?install="+id, nil)
+	w, done := s.trackInstall(httptest.NewRecorder(), r, "quiz")
+	w.Header().Set("Content-Length", "100")
+	w.Write(make([]byte, 40))
+	done()
+
+	history, _, _ := is.subscribe(id)
+	last := history[len(history)-1]
+	if last.Stage != InstallFailed || !last.Done || last.Bytes != 40 || last.Total != 100 {
+		t.Errorf("last event = %+v", last)
+	}
+
+	// A download for another template is not attributed to the session.
+	id2 := is.create("peer", "quiz", time.Now())
+	rec := httptest.NewRecorder()
+	if w, _ := s.trackInstall(rec, httptest.NewRequest("GET", "/api/templates/chess/bundle?install="+id2, nil), "chess"); w != http.ResponseWriter(rec) {
+		t.Error("bundle of another template tracked")
+	}
+}
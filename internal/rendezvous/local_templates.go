@@ -3,21 +3,29 @@ package rendezvous
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"sync"
 )
 
 // LocalTemplateStore loads templates from a directory on disk and serves them
 // directly from the rendezvous server — no microservice needed.
 // All templates are free (no pricing, no credits, no registration gating).
+// With publishing on, peers can add their own, see template_publish.go.
 type LocalTemplateStore struct {
 	mu        sync.RWMutex
 	templates map[string]localTpl // dir name -> cached template
+
+	publishDir string // {dir}/_published; empty = peers cannot publish
+	maxBundle  int64  // largest published bundle in bytes
 }
 
 type localTpl struct {
@@ -70,25 +78,61 @@ func (ts *LocalTemplateStore) loadDisk(dir string) {
 		meta.Dir = e.Name()
 		meta.Source = "store"
 
-		files := make(map[string][]byte)
-		filepath.WalkDir(tplDir, func(p string, d os.DirEntry, err error) error {
-			if err != nil || d.IsDir() {
-				return err
-			}
-			rel, _ := filepath.Rel(tplDir, p)
-			data, err := os.ReadFile(p)
-			if err != nil {
-				return nil
-			}
-			files[rel] = data
-			return nil
-		})
-
+		files := readTemplateFiles(tplDir)
+		meta.Hash = contentHash(files)
 		ts.templates[e.Name()] = localTpl{meta: meta, files: files}
 		log.Printf("local templates: loaded %q from %s (%d files)", e.Name(), dir, len(files))
 	}
 }
 
+// readTemplateFiles reads every file under tplDir, keyed by relative path.
+func readTemplateFiles(tplDir string) map[string][]byte {
+	files := make(map[string][]byte)
+	filepath.WalkDir(tplDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(tplDir, p)
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		files[rel] = data
+		return nil
+	})
+	return files
+}
+
+// contentHash identifies a template's files, independent of the order a
+// bundle writes them in.
+func contentHash(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for rel := range files {
+		names = append(names, filepath.ToSlash(rel))
+	}
+	slices.Sort(names)
+	h := sha256.New()
+	for _, rel := range names {
+		data := files[filepath.FromSlash(rel)]
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// ListETag is the validator of the listing: it changes when any template does.
+func (ts *LocalTemplateStore) ListETag() string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	hashes := make([]string, 0, len(ts.templates))
+	for _, t := range ts.templates {
+		hashes = append(hashes, t.meta.Hash)
+	}
+	slices.Sort(hashes)
+	sum := sha256.Sum256([]byte(fmt.Sprint(hashes)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
 // List returns metadata for all cached templates.
 func (ts *LocalTemplateStore) List() []StoreMeta {
 	ts.mu.RLock()
@@ -96,7 +140,9 @@ func (ts *LocalTemplateStore) List() []StoreMeta {
 
 	out := make([]StoreMeta, 0, len(ts.templates))
 	for _, t := range ts.templates {
-		out = append(out, t.meta)
+		if t.meta.Moderation != ModerationHidden {
+			out = append(out, t.meta)
+		}
 	}
 	return out
 }
@@ -107,7 +153,7 @@ func (ts *LocalTemplateStore) GetManifest(dir string) (StoreMeta, bool) {
 	defer ts.mu.RUnlock()
 
 	t, ok := ts.templates[dir]
-	if !ok {
+	if !ok || t.meta.Moderation == ModerationHidden {
 		return StoreMeta{}, false
 	}
 	return t.meta, true
@@ -119,7 +165,7 @@ func (ts *LocalTemplateStore) WriteBundle(w io.Writer, dir string) error {
 	t, ok := ts.templates[dir]
 	ts.mu.RUnlock()
 
-	if !ok {
+	if !ok || t.meta.Moderation == ModerationHidden {
 		return os.ErrNotExist
 	}
 
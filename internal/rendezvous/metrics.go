@@ -0,0 +1,47 @@
+package rendezvous
+
+import (
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/prom"
+)
+
+// EnableMetrics serves GET /metrics in the Prometheus text format, to the
+// viewer role. Call before Start.
+func (s *Server) EnableMetrics() {
+	s.metricsOn = true
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleViewer) {
+		return
+	}
+	prom.Handler(s).ServeHTTP(w, r)
+}
+
+// WriteMetrics writes presence, connection and relay metrics.
+func (s *Server) WriteMetrics(w *prom.Writer) {
+	s.mu.Lock()
+	peers, sse := len(s.peers), len(s.clients)
+	s.mu.Unlock()
+	s.wsClientsMu.RLock()
+	ws := len(s.wsClients)
+	s.wsClientsMu.RUnlock()
+	s.rateMu.Lock()
+	rejected := s.rateRejected
+	s.rateMu.Unlock()
+
+	w.Gauge("goop_rendezvous_peers", "Peers known to the rendezvous.", float64(peers))
+	w.Gauge("goop_rendezvous_sse_clients", "Open server-sent event streams.", float64(sse))
+	w.Gauge("goop_rendezvous_ws_clients", "Peers connected over WebSocket.", float64(ws))
+	w.Counter("goop_rendezvous_rate_limited_total", "Publish requests rejected by the per-IP rate limit.", float64(rejected))
+
+	if t := s.relayUsage.tracer; t != nil {
+		w.Gauge("goop_relay_reservations", "Active circuit relay reservations.", float64(t.reservations.Load()))
+		w.Gauge("goop_relay_circuits", "Open relayed connections.", float64(t.openCircuits.Load()))
+		w.Counter("goop_relay_bytes_total", "Bytes relayed between peers.", float64(t.bytesRelayed.Load()))
+	}
+	if s.dbJobs != nil {
+		s.dbJobs.WriteMetrics(w)
+	}
+}
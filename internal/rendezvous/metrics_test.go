@@ -0,0 +1,39 @@
+package rendezvous
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	s.EnableMetrics()
+	s.upsertPeer(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "m1", TS: proto.NowMillis()}, 100, false, "", "")
+	for range rateBucketCap + 2 {
+		s.allowPublish("10.0.0.1")
+	}
+
+	if w := adminDo(s.handleMetrics, "GET", "/metrics", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous scrape: %d", w.Code)
+	}
+	w := adminDo(s.handleMetrics, "GET", "/metrics", "", basic("admin", "secret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape: %d %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"goop_rendezvous_peers 1\n",
+		"goop_rendezvous_sse_clients 0\n",
+		"goop_rendezvous_rate_limited_total 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "goop_relay_") {
+		t.Error("relay metrics without a relay")
+	}
+}
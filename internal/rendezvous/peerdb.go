@@ -1,13 +1,20 @@
 package rendezvous
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
 	"sync"
 
+	"github.com/petervdpas/goop2/internal/jobs"
+
 	_ "modernc.org/sqlite"
 )
 
+// PeerDBWorkers is how many jobs write the peer DB at once.
+const PeerDBWorkers = 2
+
 // peerDB provides optional SQLite-backed peer persistence for multi-instance
 // rendezvous deployments. When multiple instances share the same database file,
 // each instance can see peers registered by the others.
@@ -53,12 +60,74 @@ func openPeerDB(path string) (*peerDB, error) {
 
 	// Migration: add verified column to existing databases (ignore error if already exists)
 	db.Exec(`ALTER TABLE peers ADD COLUMN verified INTEGER DEFAULT 0`)
+	db.Exec(`ALTER TABLE peers ADD COLUMN home TEXT DEFAULT ''`)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS digest_subs (
+		peer_id     TEXT PRIMARY KEY,
+		email       TEXT NOT NULL DEFAULT '',
+		frequency   TEXT NOT NULL DEFAULT 'off',
+		favorites   TEXT NOT NULL DEFAULT '[]',
+		unsub_token TEXT NOT NULL UNIQUE,
+		last_sent   INTEGER DEFAULT 0
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS reputation_records (
+		subject  TEXT NOT NULL,
+		reporter TEXT NOT NULL,
+		record   TEXT NOT NULL,
+		received INTEGER DEFAULT 0,
+		PRIMARY KEY (subject, reporter)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS bans (
+		target  TEXT PRIMARY KEY,
+		kind    TEXT NOT NULL,
+		reason  TEXT NOT NULL DEFAULT '',
+		by      TEXT NOT NULL DEFAULT '',
+		created INTEGER DEFAULT 0
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS admin_users (
+		name      TEXT PRIMARY KEY,
+		role      TEXT NOT NULL,
+		pass_hash TEXT NOT NULL,
+		created   INTEGER DEFAULT 0
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS admin_tokens (
+		id         TEXT PRIMARY KEY,
+		label      TEXT NOT NULL DEFAULT '',
+		role       TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created    INTEGER DEFAULT 0,
+		last_used  INTEGER DEFAULT 0
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return &peerDB{db: db}, nil
 }
 
 // upsert writes a peer row to SQLite.
-func (p *peerDB) upsert(row peerRow) {
+func (p *peerDB) upsert(row peerRow) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -66,8 +135,8 @@ func (p *peerDB) upsert(row peerRow) {
 	if row.Verified {
 		verified = 1
 	}
-	_, err := p.db.Exec(`INSERT INTO peers (peer_id, type, content, email, avatar_hash, ts, last_seen, bytes_sent, bytes_received, verified)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	_, err := p.db.Exec(`INSERT INTO peers (peer_id, type, content, email, avatar_hash, ts, last_seen, bytes_sent, bytes_received, verified, home)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(peer_id) DO UPDATE SET
 			type=excluded.type,
 			content=excluded.content,
@@ -77,26 +146,37 @@ func (p *peerDB) upsert(row peerRow) {
 			last_seen=excluded.last_seen,
 			bytes_sent=excluded.bytes_sent,
 			bytes_received=excluded.bytes_received,
-			verified=excluded.verified`,
+			verified=excluded.verified,
+			home=excluded.home`,
 		row.PeerID, row.Type, row.Content, row.Email, row.AvatarHash,
-		row.TS, row.LastSeen, row.BytesSent, row.BytesReceived, verified)
-	if err != nil {
-		log.Printf("peerdb: upsert error: %v", err)
-	}
+		row.TS, row.LastSeen, row.BytesSent, row.BytesReceived, verified, row.Home)
+	return err
 }
 
 // remove deletes a peer from SQLite.
-func (p *peerDB) remove(peerID string) {
+func (p *peerDB) remove(peerID string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	_, _ = p.db.Exec(`DELETE FROM peers WHERE peer_id = ?`, peerID)
+	_, err := p.db.Exec(`DELETE FROM peers WHERE peer_id = ?`, peerID)
+	return err
 }
 
 // cleanupStale removes peers older than the given threshold (unix millis).
-func (p *peerDB) cleanupStale(thresholdMillis int64) {
+func (p *peerDB) cleanupStale(thresholdMillis int64) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	_, _ = p.db.Exec(`DELETE FROM peers WHERE last_seen < ?`, thresholdMillis)
+	_, err := p.db.Exec(`DELETE FROM peers WHERE last_seen < ?`, thresholdMillis)
+	return err
+}
+
+// persist queues a peer DB write on the server's job queue; it does
+// nothing when persistence is disabled. Writes with the same key run in
+// order, and of several waiting ones only the latest runs.
+func (s *Server) persist(key string, fn func(*peerDB) error) {
+	if s.peerDB == nil {
+		return
+	}
+	s.dbJobs.Submit("peer-db", key, jobs.DBWrite, func(context.Context) error { return fn(s.peerDB) })
 }
 
 // maxLastSeenAndCount returns the maximum last_seen value and peer count.
@@ -119,7 +199,7 @@ func (p *peerDB) loadAll() ([]peerRow, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	rows, err := p.db.Query(`SELECT peer_id, type, content, email, avatar_hash, ts, last_seen, bytes_sent, bytes_received, verified FROM peers`)
+	rows, err := p.db.Query(`SELECT peer_id, type, content, email, avatar_hash, ts, last_seen, bytes_sent, bytes_received, verified, home FROM peers`)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +210,7 @@ func (p *peerDB) loadAll() ([]peerRow, error) {
 		var r peerRow
 		var verified int
 		if err := rows.Scan(&r.PeerID, &r.Type, &r.Content, &r.Email, &r.AvatarHash,
-			&r.TS, &r.LastSeen, &r.BytesSent, &r.BytesReceived, &verified); err != nil {
+			&r.TS, &r.LastSeen, &r.BytesSent, &r.BytesReceived, &verified, &r.Home); err != nil {
 			return nil, err
 		}
 		r.Verified = verified != 0
@@ -152,6 +232,280 @@ func (p *peerDB) lookupEmail(peerID string) string {
 	return email
 }
 
+// upsertDigest writes a digest subscription to SQLite.
+func (p *peerDB) upsertDigest(r digestRow) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	favs, _ := json.Marshal(r.Favorites)
+	_, err := p.db.Exec(`INSERT INTO digest_subs (peer_id, email, frequency, favorites, unsub_token, last_sent)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET
+			email=excluded.email,
+			frequency=excluded.frequency,
+			favorites=excluded.favorites,
+			last_sent=excluded.last_sent`,
+		r.PeerID, r.Email, r.Frequency, string(favs), r.UnsubToken, r.LastSent)
+	if err != nil {
+		log.Printf("peerdb: digest upsert error: %v", err)
+	}
+}
+
+// loadDigests returns all digest subscriptions from SQLite.
+func (p *peerDB) loadDigests() ([]digestRow, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows, err := p.db.Query(`SELECT peer_id, email, frequency, favorites, unsub_token, last_sent FROM digest_subs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []digestRow
+	for rows.Next() {
+		var r digestRow
+		var favs string
+		if err := rows.Scan(&r.PeerID, &r.Email, &r.Frequency, &favs, &r.UnsubToken, &r.LastSent); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(favs), &r.Favorites)
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// upsertReputation writes a reputation record to SQLite.
+func (p *peerDB) upsertReputation(r reputationRow) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rec, _ := json.Marshal(r.ReputationRecord)
+	_, err := p.db.Exec(`INSERT INTO reputation_records (subject, reporter, record, received)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(subject, reporter) DO UPDATE SET
+			record=excluded.record,
+			received=excluded.received`,
+		r.Subject, r.Reporter, string(rec), r.Received)
+	if err != nil {
+		log.Printf("peerdb: reputation upsert error: %v", err)
+	}
+}
+
+// deleteReputation deletes the record of reporter about subject, or every
+// record about subject when reporter is "".
+func (p *peerDB) deleteReputation(subject, reporter string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := p.db.Exec(`DELETE FROM reputation_records WHERE subject = ? AND (? = '' OR reporter = ?)`, subject, reporter, reporter)
+	if err != nil {
+		log.Printf("peerdb: reputation delete error: %v", err)
+	}
+}
+
+// loadReputation returns all reputation records from SQLite.
+func (p *peerDB) loadReputation() ([]reputationRow, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows, err := p.db.Query(`SELECT record, received FROM reputation_records`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []reputationRow
+	for rows.Next() {
+		var r reputationRow
+		var rec string
+		if err := rows.Scan(&rec, &r.Received); err != nil {
+			return nil, err
+		}
+		if json.Unmarshal([]byte(rec), &r.ReputationRecord) != nil {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// upsertBan adds a ban or replaces its reason.
+func (p *peerDB) upsertBan(b banRow) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.db.Exec(`INSERT INTO bans (target, kind, reason, by, created)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(target) DO UPDATE SET
+			reason=excluded.reason,
+			by=excluded.by,
+			created=excluded.created`,
+		b.Target, b.Kind, b.Reason, b.By, b.Created)
+	return err
+}
+
+// deleteBan lifts the ban on target.
+func (p *peerDB) deleteBan(target string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.db.Exec(`DELETE FROM bans WHERE target = ?`, target)
+	return err
+}
+
+// loadBans returns all bans from SQLite.
+func (p *peerDB) loadBans() ([]banRow, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows, err := p.db.Query(`SELECT target, kind, reason, by, created FROM bans`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []banRow
+	for rows.Next() {
+		var b banRow
+		if err := rows.Scan(&b.Target, &b.Kind, &b.Reason, &b.By, &b.Created); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, rows.Err()
+}
+
+// upsertAdminUser adds an admin account or replaces its role and password.
+func (p *peerDB) upsertAdminUser(u adminUserRow) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.db.Exec(`INSERT INTO admin_users (name, role, pass_hash, created)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			role=excluded.role,
+			pass_hash=excluded.pass_hash`,
+		u.Name, u.Role, u.PassHash, u.Created)
+	return err
+}
+
+// lookupAdminUser returns the admin account with the given name.
+func (p *peerDB) lookupAdminUser(name string) (adminUserRow, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	u := adminUserRow{Name: name}
+	err := p.db.QueryRow(`SELECT role, pass_hash, created FROM admin_users WHERE name = ?`, name).
+		Scan(&u.Role, &u.PassHash, &u.Created)
+	return u, err == nil
+}
+
+// loadAdminUsers returns all admin accounts by name.
+func (p *peerDB) loadAdminUsers() ([]adminUserRow, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows, err := p.db.Query(`SELECT name, role, created FROM admin_users ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []adminUserRow{}
+	for rows.Next() {
+		var u adminUserRow
+		if err := rows.Scan(&u.Name, &u.Role, &u.Created); err != nil {
+			return nil, err
+		}
+		result = append(result, u)
+	}
+	return result, rows.Err()
+}
+
+// countAdminUsers returns the number of admin accounts.
+func (p *peerDB) countAdminUsers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	_ = p.db.QueryRow(`SELECT COUNT(*) FROM admin_users`).Scan(&n)
+	return n
+}
+
+// deleteAdminUser removes an admin account. Returns false if there was none.
+func (p *peerDB) deleteAdminUser(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res, err := p.db.Exec(`DELETE FROM admin_users WHERE name = ?`, name)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// insertAdminToken stores a new API token.
+func (p *peerDB) insertAdminToken(t adminTokenRow) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.db.Exec(`INSERT INTO admin_tokens (id, label, role, token_hash, created, last_used)
+		VALUES (?, ?, ?, ?, ?, 0)`,
+		t.ID, t.Label, t.Role, t.Hash, t.Created)
+	return err
+}
+
+// lookupAdminToken returns the API token with the given hash.
+func (p *peerDB) lookupAdminToken(hash string) (adminTokenRow, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := adminTokenRow{Hash: hash}
+	err := p.db.QueryRow(`SELECT id, label, role, created, last_used FROM admin_tokens WHERE token_hash = ?`, hash).
+		Scan(&t.ID, &t.Label, &t.Role, &t.Created, &t.LastUsed)
+	return t, err == nil
+}
+
+// touchAdminToken records when an API token was last used.
+func (p *peerDB) touchAdminToken(id string, millis int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = p.db.Exec(`UPDATE admin_tokens SET last_used = ? WHERE id = ?`, millis, id)
+}
+
+// loadAdminTokens returns all API tokens, newest first.
+func (p *peerDB) loadAdminTokens() ([]adminTokenRow, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows, err := p.db.Query(`SELECT id, label, role, created, last_used FROM admin_tokens ORDER BY created DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []adminTokenRow{}
+	for rows.Next() {
+		var t adminTokenRow
+		if err := rows.Scan(&t.ID, &t.Label, &t.Role, &t.Created, &t.LastUsed); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+// deleteAdminToken revokes an API token. Returns false if there was none.
+func (p *peerDB) deleteAdminToken(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res, err := p.db.Exec(`DELETE FROM admin_tokens WHERE id = ?`, id)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
 // close closes the database.
 func (p *peerDB) close() error {
 	return p.db.Close()
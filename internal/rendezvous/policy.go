@@ -0,0 +1,183 @@
+package rendezvous
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Content policy actions.
+const (
+	PolicySanitize = "sanitize" // rewrite the label and relay it
+	PolicyReject   = "reject"   // drop the presence message
+)
+
+// ContentPolicy is the operator's rule set for peer labels published to this
+// rendezvous. The zero value applies no rules.
+type ContentPolicy struct {
+	Action         string   // PolicySanitize (default) or PolicyReject
+	MaxLabelLen    int      // characters; 0 = no limit
+	BannedWords    []string // case-insensitive whole words
+	BannedPatterns []string // regular expressions
+	StripURLs      bool     // remove links from labels
+	MaxEmoji       int      // 0 = no limit, < 0 = no emoji at all
+}
+
+func (p ContentPolicy) empty() bool {
+	return p.MaxLabelLen == 0 && len(p.BannedWords) == 0 && len(p.BannedPatterns) == 0 && !p.StripURLs && p.MaxEmoji == 0
+}
+
+// labelPolicy is a ContentPolicy with its expressions compiled.
+type labelPolicy struct {
+	ContentPolicy
+	words    *regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+var urlRe = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+`)
+var spaceRe = regexp.MustCompile(`\s{2,}`)
+
+func newLabelPolicy(p ContentPolicy) (*labelPolicy, error) {
+	switch p.Action {
+	case "", PolicySanitize, PolicyReject:
+	default:
+		return nil, fmt.Errorf("unknown policy action %q", p.Action)
+	}
+	lp := &labelPolicy{ContentPolicy: p}
+	var quoted []string
+	for _, w := range p.BannedWords {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) > 0 {
+		lp.words = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	for _, expr := range p.BannedPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("banned pattern %q: %w", expr, err)
+		}
+		lp.patterns = append(lp.patterns, re)
+	}
+	return lp, nil
+}
+
+// apply checks a label against the policy and returns the sanitized label
+// with the rules it broke, if any.
+func (p *labelPolicy) apply(label string) (string, []string) {
+	var violations []string
+	out := label
+
+	if p.StripURLs && urlRe.MatchString(out) {
+		out = urlRe.ReplaceAllString(out, "")
+		violations = append(violations, "link")
+	}
+	if p.words != nil && p.words.MatchString(out) {
+		out = p.words.ReplaceAllStringFunc(out, mask)
+		violations = append(violations, "banned word")
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(out) {
+			out = re.ReplaceAllStringFunc(out, mask)
+			violations = append(violations, "banned pattern")
+			break
+		}
+	}
+	if p.MaxEmoji != 0 {
+		limit := max(p.MaxEmoji, 0)
+		if trimmed, n := limitEmoji(out, limit); n > limit {
+			out = trimmed
+			violations = append(violations, "too many emoji")
+		}
+	}
+	out = strings.TrimSpace(spaceRe.ReplaceAllString(out, " "))
+	if p.MaxLabelLen > 0 && utf8.RuneCountInString(out) > p.MaxLabelLen {
+		out = strings.TrimSpace(string([]rune(out)[:p.MaxLabelLen]))
+		violations = append(violations, "too long")
+	}
+	return out, violations
+}
+
+func mask(s string) string {
+	return strings.Repeat("*", utf8.RuneCountInString(s))
+}
+
+// limitEmoji keeps the first limit emoji in s and drops the rest, together
+// with the joiners and modifiers attached to them. It returns the result and
+// the number of emoji found.
+func limitEmoji(s string, limit int) (string, int) {
+	var b strings.Builder
+	n := 0
+	dropping := false
+	for _, r := range s {
+		switch {
+		case isEmojiPart(r):
+			if !dropping {
+				b.WriteRune(r)
+			}
+		case isEmoji(r):
+			n++
+			dropping = n > limit
+			if !dropping {
+				b.WriteRune(r)
+			}
+		default:
+			dropping = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), n
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF) || (r >= 0x2B00 && r <= 0x2BFF)
+}
+
+// isEmojiPart reports runes that only modify the emoji before them:
+// variation selector, zero-width joiner and skin tones.
+func isEmojiPart(r rune) bool {
+	return r == 0xFE0F || r == 0x200D || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// SetContentPolicy installs the label policy for /publish and WebSocket
+// presence. An empty policy turns it off.
+func (s *Server) SetContentPolicy(p ContentPolicy) error {
+	if p.empty() {
+		s.policy = nil
+		return nil
+	}
+	lp, err := newLabelPolicy(p)
+	if err != nil {
+		return err
+	}
+	s.policy = lp
+	return nil
+}
+
+// applyContentPolicy enforces the label policy on an online or update
+// message. It returns an error when the message must be dropped; otherwise
+// pm may have been rewritten. A rewritten label no longer matches the
+// peer's signature, so the signature is removed and receivers show the
+// label as unsigned.
+func (s *Server) applyContentPolicy(pm *proto.PresenceMsg) error {
+	if s.policy == nil || (pm.Type != proto.TypeOnline && pm.Type != proto.TypeUpdate) {
+		return nil
+	}
+	out, violations := s.policy.apply(pm.Content)
+	if len(violations) == 0 {
+		return nil
+	}
+	reason := strings.Join(violations, ", ")
+	if s.policy.Action == PolicyReject {
+		s.addLog(fmt.Sprintf("Policy: rejected %s from %s: %q (%s)", pm.Type, pm.PeerID, pm.Content, reason))
+		return fmt.Errorf("label violates content policy: %s", reason)
+	}
+	s.addLog(fmt.Sprintf("Policy: sanitized label of %s: %q -> %q (%s)", pm.PeerID, pm.Content, out, reason))
+	pm.Content = out
+	pm.Sig = nil
+	return nil
+}
@@ -0,0 +1,86 @@
+package rendezvous
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestLabelPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     ContentPolicy
+		label      string
+		want       string
+		violations []string
+	}{
+		{"clean", ContentPolicy{MaxLabelLen: 20}, "Alice", "Alice", nil},
+		{"too long", ContentPolicy{MaxLabelLen: 5}, "Alice in Wonderland", "Alice", []string{"too long"}},
+		{"banned word", ContentPolicy{BannedWords: []string{"spam"}}, "Buy SPAM now", "Buy **** now", []string{"banned word"}},
+		{"word boundary", ContentPolicy{BannedWords: []string{"ass"}}, "Bass player", "Bass player", nil},
+		{"pattern", ContentPolicy{BannedPatterns: []string{`(?i)free\s+crypto`}}, "Free  crypto here", "************ here", []string{"banned pattern"}},
+		{"url", ContentPolicy{StripURLs: true}, "Bob https://example.com site", "Bob site", []string{"link"}},
+		{"www", ContentPolicy{StripURLs: true}, "Bob www.example.com", "Bob", []string{"link"}},
+		{"emoji limit", ContentPolicy{MaxEmoji: 1}, "Hi 😀😀😀", "Hi 😀", []string{"too many emoji"}},
+		{"no emoji", ContentPolicy{MaxEmoji: -1}, "Hi 👍🏽 there", "Hi there", []string{"too many emoji"}},
+		{"emoji within limit", ContentPolicy{MaxEmoji: 2}, "Hi 😀", "Hi 😀", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lp, err := newLabelPolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, violations := lp.apply(tt.label)
+			if got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(violations, tt.violations) {
+				t.Errorf("violations = %v, want %v", violations, tt.violations)
+			}
+		})
+	}
+}
+
+func TestSetContentPolicy_InvalidPattern(t *testing.T) {
+	s := &Server{}
+	if err := s.SetContentPolicy(ContentPolicy{BannedPatterns: []string{"("}}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	if err := s.SetContentPolicy(ContentPolicy{Action: "shout", MaxLabelLen: 5}); err == nil {
+		t.Fatal("expected error for unknown action")
+	}
+}
+
+func TestApplyContentPolicy(t *testing.T) {
+	s := &Server{}
+	if err := s.SetContentPolicy(ContentPolicy{MaxLabelLen: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "p1", Content: "Alice", Sig: []byte{1}}
+	if err := s.applyContentPolicy(&pm); err != nil {
+		t.Fatal(err)
+	}
+	if pm.Content != "Ali" || pm.Sig != nil {
+		t.Errorf("sanitize: content=%q sig=%v", pm.Content, pm.Sig)
+	}
+
+	off := proto.PresenceMsg{Type: proto.TypeOffline, PeerID: "p1", Content: "Alice"}
+	if err := s.applyContentPolicy(&off); err != nil || off.Content != "Alice" {
+		t.Errorf("offline messages must pass untouched: %v %q", err, off.Content)
+	}
+
+	if err := s.SetContentPolicy(ContentPolicy{Action: PolicyReject, MaxLabelLen: 3}); err != nil {
+		t.Fatal(err)
+	}
+	pm = proto.PresenceMsg{Type: proto.TypeUpdate, PeerID: "p1", Content: "Alice"}
+	if err := s.applyContentPolicy(&pm); err == nil {
+		t.Error("reject: expected error")
+	}
+
+	if err := s.SetContentPolicy(ContentPolicy{}); err != nil || s.policy != nil {
+		t.Errorf("empty policy should turn it off: %v", err)
+	}
+}
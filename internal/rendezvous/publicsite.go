@@ -0,0 +1,497 @@
+package rendezvous
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/sdk"
+)
+
+// PublicSiteOptions enables the public site mirror: peers that opt in
+// (PresenceMsg.PublicSite) and are verified get a read-only snapshot of
+// their site served at /p/<peerID>/, for people who don't run goop2.
+type PublicSiteOptions struct {
+	MaxBytes      int64 `json:"max_bytes"`       // per site
+	MaxFiles      int   `json:"max_files"`       // per site
+	MaxTotalBytes int64 `json:"max_total_bytes"` // all mirrored sites together
+}
+
+// siteFetchFunc fetches one file of a peer's site: MIME type and body.
+type siteFetchFunc func(ctx context.Context, peerID, path string) (string, []byte, error)
+
+type publicFile struct {
+	mime string
+	data []byte
+}
+
+// publicSite is the mirrored snapshot of one peer's site.
+type publicSite struct {
+	PeerID    string `json:"peer_id"`
+	Label     string `json:"label"`
+	Template  string `json:"template,omitempty"`
+	Hash      string `json:"hash,omitempty"` // site hash announced when the crawl started
+	Files     int    `json:"files"`
+	Bytes     int64  `json:"bytes"`
+	Truncated bool   `json:"truncated,omitempty"` // hit a size limit
+	FetchedAt int64  `json:"fetched_at"`
+	Error     string `json:"error,omitempty"` // last refresh, snapshot kept
+	SeenAt    int64  `json:"seen_at"`         // last presence from the peer
+
+	files map[string]publicFile
+}
+
+type publicSites struct {
+	opts  PublicSiteOptions
+	fetch siteFetchFunc
+
+	mu         sync.RWMutex
+	sites      map[string]*publicSite
+	refreshing map[string]bool
+}
+
+// SetPublicSites enables the public site mirror with the given limits.
+// Snapshots are pulled from peers over the relay, so it needs the relay.
+func (s *Server) SetPublicSites(opts PublicSiteOptions) {
+	s.publicSites = &publicSites{
+		opts:       opts,
+		sites:      map[string]*publicSite{},
+		refreshing: map[string]bool{},
+	}
+	s.publicSites.fetch = s.fetchSiteFile
+}
+
+// fetchSiteFile reads a file from a relay-connected peer's site stream.
+// The request is sent in the clear: the relay host has no NaCl key, so
+// the peer answers in plaintext too.
+func (s *Server) fetchSiteFile(ctx context.Context, peerID, p string) (string, []byte, error) {
+	if s.relayHost == nil {
+		return "", nil, errors.New("relay not enabled")
+	}
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(s.relayHost.Network().ConnsToPeer(pid)) == 0 {
+		return "", nil, errors.New("peer not connected to relay")
+	}
+	st, err := s.relayHost.NewStream(ctx, pid, protocol.ID(proto.SiteProtoID))
+	if err != nil {
+		return "", nil, err
+	}
+	defer st.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = st.SetDeadline(dl)
+	}
+	if _, err := io.WriteString(st, "GET /"+p+"\n"); err != nil {
+		return "", nil, err
+	}
+	return readSiteResponse(bufio.NewReader(st), s.publicSites.opts.MaxBytes)
+}
+
+// readSiteResponse parses "OK <mime> <len>\n<body>" or "ERR <msg>\n".
+func readSiteResponse(r *bufio.Reader, max int64) (string, []byte, error) {
+	h, err := r.ReadString('\n')
+	if err != nil {
+		return "", nil, err
+	}
+	h = strings.TrimSpace(h)
+	if after, ok := strings.CutPrefix(h, "ERR "); ok {
+		return "", nil, errors.New(after)
+	}
+	rest, ok := strings.CutPrefix(h, "OK ")
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected response %q", h)
+	}
+	sp := strings.LastIndexByte(rest, ' ')
+	if sp < 0 {
+		return "", nil, fmt.Errorf("bad response header %q", h)
+	}
+	n, err := strconv.ParseInt(rest[sp+1:], 10, 64)
+	if err != nil || n < 0 {
+		return "", nil, fmt.Errorf("bad length in %q", h)
+	}
+	if n > max {
+		return "", nil, errTooLarge
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, err
+	}
+	return rest[:sp], data, nil
+}
+
+var errTooLarge = errors.New("file exceeds the size limit")
+
+// notePublicSite is called for every accepted presence message. It drops
+// the snapshot of peers that opted out and refreshes stale ones.
+func (s *Server) notePublicSite(pm proto.PresenceMsg, verified bool) {
+	ps := s.publicSites
+	if ps == nil || pm.Type == proto.TypeOffline || pm.Type == proto.TypePunch {
+		return
+	}
+	if !pm.PublicSite || !verified {
+		ps.mu.Lock()
+		if _, ok := ps.sites[pm.PeerID]; ok {
+			delete(ps.sites, pm.PeerID)
+			s.addLog(fmt.Sprintf("Public site: removed mirror of %s (opted out)", pm.PeerID))
+		}
+		ps.mu.Unlock()
+		return
+	}
+
+	ps.mu.Lock()
+	cur := ps.sites[pm.PeerID]
+	stale := cur == nil || publicSiteStale(cur, pm)
+	if cur != nil {
+		cur.Label = pm.Content
+		cur.SeenAt = time.Now().UnixMilli()
+	}
+	if !stale || ps.refreshing[pm.PeerID] {
+		ps.mu.Unlock()
+		return
+	}
+	ps.refreshing[pm.PeerID] = true
+	ps.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), PublicSiteFetchTimeout)
+		defer cancel()
+		s.refreshPublicSite(ctx, pm.PeerID, pm.Content, pm.ActiveTemplate, pm.SiteHash)
+	}()
+}
+
+// publicSiteStale reports whether a snapshot should be pulled again. Peers
+// that announce a site hash are refetched only when it changes; for older
+// clients the template and the snapshot's age decide.
+func publicSiteStale(cur *publicSite, pm proto.PresenceMsg) bool {
+	age := time.Since(time.UnixMilli(cur.FetchedAt))
+	if pm.SiteHash == "" {
+		return cur.Template != pm.ActiveTemplate || age > PublicSiteRefreshInterval
+	}
+	if cur.Hash == pm.SiteHash {
+		return false
+	}
+	// A failed crawl waits the full interval, a busy editor the minimum.
+	if cur.Error != "" {
+		return age > PublicSiteRefreshInterval
+	}
+	return age > PublicSiteMinRefresh
+}
+
+// refreshPublicSite crawls the peer's site from index.html and replaces
+// its snapshot. A failed crawl keeps the previous snapshot.
+func (s *Server) refreshPublicSite(ctx context.Context, peerID, label, template, hash string) {
+	ps := s.publicSites
+	defer func() {
+		ps.mu.Lock()
+		delete(ps.refreshing, peerID)
+		ps.mu.Unlock()
+	}()
+
+	site, err := ps.crawl(ctx, peerID)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.pruneLocked()
+	if err == nil && !ps.fitsLocked(peerID, site.Bytes) {
+		err = errors.New("mirror storage full")
+	}
+	if err != nil {
+		if cur := ps.sites[peerID]; cur != nil {
+			cur.Error = err.Error()
+			cur.FetchedAt = time.Now().UnixMilli() // retry after the interval
+		}
+		log.Printf("public site: %s: %v", peerID, err)
+		return
+	}
+	site.PeerID, site.Label, site.Template, site.Hash = peerID, label, template, hash
+	site.SeenAt = site.FetchedAt
+	ps.sites[peerID] = site
+	s.addLog(fmt.Sprintf("Public site: mirrored %s (%d files, %d bytes)", peerID, site.Files, site.Bytes))
+}
+
+// pruneLocked drops the mirrors of peers gone for PublicSiteMaxAge.
+func (ps *publicSites) pruneLocked() {
+	for id, site := range ps.sites {
+		if time.Since(time.UnixMilli(site.SeenAt)) > PublicSiteMaxAge {
+			delete(ps.sites, id)
+		}
+	}
+}
+
+// fitsLocked reports whether a snapshot of n bytes for peerID stays
+// within the total budget, counting the snapshot it replaces as freed.
+func (ps *publicSites) fitsLocked(peerID string, n int64) bool {
+	if ps.opts.MaxTotalBytes <= 0 {
+		return true
+	}
+	var total int64
+	for id, site := range ps.sites {
+		if id != peerID {
+			total += site.Bytes
+		}
+	}
+	return total+n <= ps.opts.MaxTotalBytes
+}
+
+var (
+	htmlRefRE   = regexp.MustCompile(`(?i)\b(?:src|href|poster)\s*=\s*["']([^"']+)["']`)
+	srcsetRE    = regexp.MustCompile(`(?i)\bsrcset\s*=\s*["']([^"']+)["']`)
+	cssURLRE    = regexp.MustCompile(`url\(\s*["']?([^"')]+)["']?\s*\)`)
+	cssImportRE = regexp.MustCompile(`@import\s+["']([^"']+)["']`)
+)
+
+// crawl fetches index.html and everything reachable from it through
+// relative links, within the size limits.
+func (ps *publicSites) crawl(ctx context.Context, peerID string) (*publicSite, error) {
+	site := &publicSite{files: map[string]publicFile{}, FetchedAt: time.Now().UnixMilli()}
+	queue := []string{"index.html"}
+	seen := map[string]bool{"index.html": true}
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p := queue[0]
+		queue = queue[1:]
+		if len(site.files) >= ps.opts.MaxFiles {
+			site.Truncated = true
+			break
+		}
+
+		mt, data, err := ps.fetch(ctx, peerID, p)
+		if errors.Is(err, errTooLarge) || (err == nil && site.Bytes+int64(len(data)) > ps.opts.MaxBytes) {
+			site.Truncated = true
+			continue
+		}
+		if err != nil {
+			if p == "index.html" {
+				return nil, err
+			}
+			continue // broken link on the site
+		}
+		if mt == "" || strings.HasPrefix(mt, "application/octet-stream") {
+			if byExt := mime.TypeByExtension(path.Ext(p)); byExt != "" {
+				mt = byExt
+			}
+		}
+		site.files[p] = publicFile{mime: mt, data: data}
+		site.Bytes += int64(len(data))
+
+		for _, ref := range siteRefs(p, mt, data) {
+			if !seen[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+	site.Files = len(site.files)
+	return site, nil
+}
+
+// siteRefs returns the site paths an HTML or CSS file refers to.
+func siteRefs(from, mt string, data []byte) []string {
+	var raw []string
+	switch {
+	case strings.HasPrefix(mt, "text/html"):
+		for _, m := range htmlRefRE.FindAllSubmatch(data, -1) {
+			raw = append(raw, string(m[1]))
+		}
+		for _, m := range srcsetRE.FindAllSubmatch(data, -1) {
+			for _, cand := range strings.Split(string(m[1]), ",") {
+				if f := strings.Fields(cand); len(f) > 0 {
+					raw = append(raw, f[0])
+				}
+			}
+		}
+		fallthrough
+	case strings.HasPrefix(mt, "text/css"):
+		for _, re := range []*regexp.Regexp{cssURLRE, cssImportRE} {
+			for _, m := range re.FindAllSubmatch(data, -1) {
+				raw = append(raw, string(m[1]))
+			}
+		}
+	}
+
+	var out []string
+	for _, ref := range raw {
+		if p, ok := resolveSiteRef(from, ref); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// resolveSiteRef resolves a relative reference against the file it is in.
+// External URLs, absolute paths and anything leaving the site are skipped.
+func resolveSiteRef(from, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if ref == "" || strings.HasPrefix(ref, "/") || strings.Contains(ref, ":") {
+		return "", false
+	}
+	p := path.Join(path.Dir(from), ref)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") || p == "lua" || strings.HasPrefix(p, "lua/") {
+		return "", false
+	}
+	if strings.HasSuffix(ref, "/") {
+		p += "/index.html"
+	}
+	return p, true
+}
+
+// registerPublicSiteRoutes mounts the mirror, the SDK its pages load and
+// the sitemap.
+func (s *Server) registerPublicSiteRoutes(mux *http.ServeMux) {
+	if s.publicSites == nil {
+		return
+	}
+	mux.HandleFunc("/p/", s.handlePublicSite)
+	mux.Handle("/sdk/", http.StripPrefix("/sdk/", sdk.Handler()))
+	mux.HandleFunc("/sitemap.xml", s.handleSitemap)
+	mux.HandleFunc("/public-sites.json", s.handlePublicSitesJSON)
+}
+
+// handlePublicSite serves GET /p/<peerID>/<path> from the snapshot.
+func (s *Server) handlePublicSite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	peerID, rest, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/p/"), "/")
+	if !found {
+		http.Redirect(w, r, "/p/"+peerID+"/", http.StatusMovedPermanently)
+		return
+	}
+	rel := path.Clean("/" + rest)[1:]
+	if rel == "" {
+		rel = "index.html"
+	} else if strings.HasSuffix(rest, "/") {
+		rel += "/index.html"
+	}
+
+	ps := s.publicSites
+	ps.mu.RLock()
+	site := ps.sites[peerID]
+	var f publicFile
+	var ok bool
+	if site != nil {
+		f, ok = site.files[rel]
+	}
+	ps.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Sandboxed: the pages run on an opaque origin, away from the
+	// rendezvous' own pages and admin session.
+	w.Header().Set("Content-Security-Policy",
+		"sandbox allow-scripts allow-popups; default-src 'self' data:; "+
+			"style-src 'self' 'unsafe-inline'; connect-src 'none'; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Last-Modified", time.UnixMilli(site.FetchedAt).UTC().Format(http.TimeFormat))
+	if site.Hash != "" {
+		etag := `"` + site.Hash + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", f.mime)
+	w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(f.data)
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// handleSitemap lists the pages of every mirrored site.
+func (s *Server) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	base := strings.TrimRight(s.externalURL, "/")
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	var urls []sitemapURL
+	ps := s.publicSites
+	ps.mu.RLock()
+	for id, site := range ps.sites {
+		mod := time.UnixMilli(site.FetchedAt).UTC().Format("2006-01-02")
+		for p := range site.files {
+			if !strings.HasSuffix(p, ".html") {
+				continue
+			}
+			loc := base + "/p/" + id + "/"
+			if p != "index.html" {
+				loc += p
+			}
+			urls = append(urls, sitemapURL{Loc: loc, LastMod: mod})
+		}
+	}
+	ps.mu.RUnlock()
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Loc < urls[j].Loc })
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name     `xml:"urlset"`
+		NS      string       `xml:"xmlns,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}{NS: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: urls})
+}
+
+// handlePublicSitesJSON lists the mirrored sites for the admin.
+func (s *Server) handlePublicSitesJSON(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleViewer) {
+		return
+	}
+	ps := s.publicSites
+	ps.mu.RLock()
+	list := make([]publicSite, 0, len(ps.sites))
+	var total int64
+	for _, site := range ps.sites {
+		list = append(list, *site)
+		total += site.Bytes
+	}
+	ps.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Label < list[j].Label })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sites":       list,
+		"total_bytes": total,
+		"limits":      ps.opts,
+	})
+}
@@ -0,0 +1,212 @@
+package rendezvous
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestReadSiteResponse(t *testing.T) {
+	mt, data, err := readSiteResponse(bufio.NewReader(strings.NewReader("OK text/html; charset=utf-8 5\nhello")), 100)
+	if err != nil || mt != "text/html; charset=utf-8" || string(data) != "hello" {
+		t.Fatalf("got %q %q %v", mt, data, err)
+	}
+	if _, _, err := readSiteResponse(bufio.NewReader(strings.NewReader("ERR not found\n")), 100); err == nil || err.Error() != "not found" {
+		t.Fatalf("err = %v", err)
+	}
+	if _, _, err := readSiteResponse(bufio.NewReader(strings.NewReader("OK image/png 500\n")), 100); !errors.Is(err, errTooLarge) {
+		t.Fatalf("err = %v, want errTooLarge", err)
+	}
+}
+
+func TestResolveSiteRef(t *testing.T) {
+	tests := []struct {
+		from, ref, want string
+		ok              bool
+	}{
+		{"index.html", "css/site.css", "css/site.css", true},
+		{"pages/a.html", "../img/x.png?v=2", "img/x.png", true},
+		{"pages/a.html", "sub/", "pages/sub/index.html", true},
+		{"index.html", "../etc/passwd", "", false},
+		{"index.html", "/sdk/goop-data.js", "", false},
+		{"index.html", "https://example.com/x.js", "", false},
+		{"index.html", "mailto:a@b.c", "", false},
+		{"index.html", "#top", "", false},
+		{"index.html", "lua/functions/x.lua", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := resolveSiteRef(tt.from, tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("resolveSiteRef(%q, %q) = %q, %v; want %q, %v", tt.from, tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func newPublicSiteServer(t *testing.T, opts PublicSiteOptions, files map[string]string) *Server {
+	t.Helper()
+	s := New("127.0.0.1:0", "", "", "https://rv.example.org", 0, 0, "", RelayTimingConfig{})
+	s.SetPublicSites(opts)
+	s.publicSites.fetch = func(_ context.Context, _ string, p string) (string, []byte, error) {
+		body, ok := files[p]
+		if !ok {
+			return "", nil, errors.New("not found")
+		}
+		mt := "application/octet-stream"
+		switch {
+		case strings.HasSuffix(p, ".html"):
+			mt = "text/html; charset=utf-8"
+		case strings.HasSuffix(p, ".css"):
+			mt = "text/css; charset=utf-8"
+		}
+		return mt, []byte(body), nil
+	}
+	return s
+}
+
+func TestPublicSiteMirror(t *testing.T) {
+	files := map[string]string{
+		"index.html":       `<link href="css/site.css" rel="stylesheet"><a href="about/">About</a><img srcset="img/a.png 1x, img/b.png 2x"><script src="/sdk/goop-data.js"></script><a href="https://x.org">x</a>`,
+		"css/site.css":     `body{background:url("../img/bg.png")}`,
+		"about/index.html": `<a href="../index.html">home</a><img src="missing.png">`,
+		"img/a.png":        "A",
+		"img/b.png":        "B",
+		"img/bg.png":       "BG",
+		"secret.txt":       "not linked",
+	}
+	s := newPublicSiteServer(t, PublicSiteOptions{MaxBytes: 1 << 20, MaxFiles: 100, MaxTotalBytes: 1 << 20}, files)
+
+	s.refreshPublicSite(context.Background(), "peerA", "Alice", "blog", "")
+	site := s.publicSites.sites["peerA"]
+	if site == nil || site.Files != 6 || site.Truncated {
+		t.Fatalf("site = %+v", site)
+	}
+	if _, ok := site.files["secret.txt"]; ok {
+		t.Fatal("unlinked files must not be mirrored")
+	}
+
+	mux := http.NewServeMux()
+	s.registerPublicSiteRoutes(mux)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := get("/p/peerA/"); rec.Code != 200 || !strings.Contains(rec.Body.String(), "css/site.css") ||
+		!strings.Contains(rec.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Fatalf("index: %d %s", rec.Code, rec.Header())
+	}
+	if rec := get("/p/peerA/about/"); rec.Code != 200 {
+		t.Fatalf("about: %d", rec.Code)
+	}
+	if rec := get("/p/peerA/img/bg.png"); rec.Code != 200 || rec.Body.String() != "BG" {
+		t.Fatalf("bg: %d", rec.Code)
+	}
+	if rec := get("/p/peerA/../peerB/index.html"); rec.Code == 200 {
+		t.Fatal("path escaped the snapshot")
+	}
+	if rec := get("/p/peerA"); rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("bare peer path: %d", rec.Code)
+	}
+	if rec := get("/sdk/goop-data.js"); rec.Code != 200 {
+		t.Fatalf("sdk: %d", rec.Code)
+	}
+	sm := get("/sitemap.xml").Body.String()
+	if !strings.Contains(sm, "<loc>https://rv.example.org/p/peerA/</loc>") ||
+		!strings.Contains(sm, "<loc>https://rv.example.org/p/peerA/about/index.html</loc>") ||
+		strings.Contains(sm, "bg.png") {
+		t.Fatalf("sitemap = %s", sm)
+	}
+
+	// Opting out drops the mirror right away.
+	s.notePublicSite(proto.PresenceMsg{Type: proto.TypeUpdate, PeerID: "peerA"}, true)
+	if rec := get("/p/peerA/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("after opt-out: %d", rec.Code)
+	}
+}
+
+func TestPublicSiteLimits(t *testing.T) {
+	files := map[string]string{
+		"index.html": `<img src="big.png"><img src="small.png">`,
+		"big.png":    strings.Repeat("x", 200),
+		"small.png":  "s",
+	}
+	s := newPublicSiteServer(t, PublicSiteOptions{MaxBytes: 100, MaxFiles: 10, MaxTotalBytes: 100}, files)
+	s.refreshPublicSite(context.Background(), "peerA", "A", "", "")
+	site := s.publicSites.sites["peerA"]
+	if site == nil || !site.Truncated || site.Files != 2 {
+		t.Fatalf("site = %+v, want index and small.png, truncated", site)
+	}
+
+	// A second site that does not fit the total budget is not mirrored.
+	files["index.html"] = strings.Repeat("y", 90)
+	s.refreshPublicSite(context.Background(), "peerB", "B", "", "")
+	if s.publicSites.sites["peerB"] != nil {
+		t.Fatal("mirror over the total budget")
+	}
+
+	// Unverified peers are not mirrored; fresh mirrors are not pulled again.
+	s.notePublicSite(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "peerC", PublicSite: true}, false)
+	s.notePublicSite(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "peerA", PublicSite: true, Content: "Alice"}, true)
+	time.Sleep(20 * time.Millisecond)
+	s.publicSites.mu.RLock()
+	defer s.publicSites.mu.RUnlock()
+	if len(s.publicSites.refreshing) != 0 || s.publicSites.sites["peerC"] != nil {
+		t.Fatal("unexpected refresh")
+	}
+	if s.publicSites.sites["peerA"].Label != "Alice" {
+		t.Fatal("label not updated from presence")
+	}
+}
+
+func TestPublicSiteStale(t *testing.T) {
+	old := time.Now().Add(-time.Hour).UnixMilli()
+	recent := time.Now().Add(-2 * PublicSiteMinRefresh).UnixMilli()
+	fresh := time.Now().UnixMilli()
+	tests := []struct {
+		name string
+		cur  publicSite
+		pm   proto.PresenceMsg
+		want bool
+	}{
+		{"no hash, fresh", publicSite{FetchedAt: fresh}, proto.PresenceMsg{}, false},
+		{"no hash, old", publicSite{FetchedAt: old}, proto.PresenceMsg{}, true},
+		{"no hash, new template", publicSite{FetchedAt: fresh}, proto.PresenceMsg{ActiveTemplate: "blog"}, true},
+		{"same hash, old", publicSite{Hash: "h1", FetchedAt: old}, proto.PresenceMsg{SiteHash: "h1", ActiveTemplate: "blog"}, false},
+		{"new hash", publicSite{Hash: "h1", FetchedAt: recent}, proto.PresenceMsg{SiteHash: "h2"}, true},
+		{"new hash, just fetched", publicSite{Hash: "h1", FetchedAt: fresh}, proto.PresenceMsg{SiteHash: "h2"}, false},
+		{"new hash, last crawl failed", publicSite{Hash: "h1", FetchedAt: recent, Error: "x"}, proto.PresenceMsg{SiteHash: "h2"}, false},
+	}
+	for _, tt := range tests {
+		if got := publicSiteStale(&tt.cur, tt.pm); got != tt.want {
+			t.Errorf("%s: stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPublicSiteETag(t *testing.T) {
+	s := newPublicSiteServer(t, PublicSiteOptions{MaxBytes: 1 << 20, MaxFiles: 10, MaxTotalBytes: 1 << 20},
+		map[string]string{"index.html": "<p>hi</p>"})
+	s.refreshPublicSite(context.Background(), "peerA", "A", "", "h1")
+	mux := http.NewServeMux()
+	s.registerPublicSiteRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/p/peerA/", nil))
+	if rec.Code != 200 || rec.Header().Get("ETag") != `"h1"` {
+		t.Fatalf("got %d, etag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	req := httptest.NewRequest(http.MethodGet, "/p/peerA/", nil)
+	req.Header.Set("If-None-Match", `"h1"`)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("revalidation: %d", rec.Code)
+	}
+}
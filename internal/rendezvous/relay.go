@@ -2,20 +2,23 @@ package rendezvous
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"sync/atomic"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/keystore"
+
 	libp2p "github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	ymux "github.com/libp2p/go-libp2p/p2p/muxer/yamux"
 	pbv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/pb"
 	relayv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
@@ -28,18 +31,51 @@ type RelayInfo struct {
 	PeerID string   `json:"peer_id"`
 	Addrs  []string `json:"addrs"`
 
+	// STUN URLs served by the rendezvous itself (empty when not configured).
+	STUNURLs []string `json:"stun_urls,omitempty"`
+
 	// Timing values pushed from the server config.
 	CleanupDelaySec    int `json:"cleanup_delay_sec"`
 	PollDeadlineSec    int `json:"poll_deadline_sec"`
 	ConnectTimeoutSec  int `json:"connect_timeout_sec"`
 	RefreshIntervalSec int `json:"refresh_interval_sec"`
 	RecoveryGraceSec   int `json:"recovery_grace_sec"`
+
+	// Relay host key's signature over SigningBytes, see relay_pin.go.
+	Sig []byte `json:"sig,omitempty"`
+}
+
+// RelayReport is a peer's periodic view of its reservation on the relay,
+// posted to /api/relay-report so the operator can tell a failing relay
+// from one failing peer.
+type RelayReport struct {
+	PeerID        string `json:"peer_id"`
+	ReservationOK bool   `json:"reservation_ok"`
+
+	// Class of the most recent failure (one of the RelayErr constants)
+	// and how long ago it happened; empty when there has been none.
+	ErrorClass  string `json:"error_class,omitempty"`
+	ErrorAgeSec int64  `json:"error_age_sec,omitempty"`
+
+	Failures int   `json:"failures"`         // failed attempts since the previous report
+	RTTMs    int64 `json:"rtt_ms,omitempty"` // ping round trip; 0 when not measured
 }
 
+// Relay failure classes used in RelayReport.ErrorClass.
+const (
+	RelayErrTimeout   = "timeout"    // dial or reservation timed out
+	RelayErrDial      = "dial"       // could not connect to the relay
+	RelayErrRefused   = "refused"    // relay rejected the reservation
+	RelayErrLimit     = "limit"      // relay is out of reservation slots
+	RelayErrNoCircuit = "no-circuit" // reserved, but no circuit address appeared
+	RelayErrOther     = "other"
+)
+
 // relayTracer logs circuit relay events to the relay log via a callback.
 type relayTracer struct {
 	logFn          func(string)
 	openCircuits   atomic.Int32
+	reservations   atomic.Int32
 	bytesRelayed   atomic.Int64
 }
 
@@ -71,11 +107,13 @@ func (t *relayTracer) ReservationAllowed(isRenewal bool) {
 	if isRenewal {
 		t.logFn("reservation renewed")
 	} else {
+		t.reservations.Add(1)
 		t.logFn("reservation created")
 	}
 }
 
 func (t *relayTracer) ReservationClosed(cnt int) {
+	t.reservations.Add(-int32(cnt))
 	if cnt > 0 {
 		t.logFn(fmt.Sprintf("reservation closed (%d expired)", cnt))
 	}
@@ -115,9 +153,10 @@ func statusString(s pbv2.Status) string {
 // StartRelay creates a libp2p host that acts as a circuit relay v2 server.
 // externalURL, if set, is used to derive the public IP so WAN peers get a
 // reachable address (e.g. /ip4/<public>/tcp/<port>/p2p/<id>).
+// usage, if set, meters relayed traffic per peer and enforces its quota.
 // logFn is called for circuit events (nil = log.Printf).
-func StartRelay(port int, wsPort int, keyFile string, externalURL string, logFn func(string)) (host.Host, *RelayInfo, error) {
-	priv, err := loadOrCreateRelayKey(keyFile)
+func StartRelay(port int, wsPort int, keyFile string, externalURL string, usage *relayUsage, logFn func(string)) (host.Host, *RelayInfo, error) {
+	priv, err := loadOrCreateKey(keyFile, "relay")
 	if err != nil {
 		return nil, nil, fmt.Errorf("relay key: %w", err)
 	}
@@ -137,6 +176,9 @@ func StartRelay(port int, wsPort int, keyFile string, externalURL string, logFn
 		libp2p.DisableRelay(),
 		libp2p.Muxer(ymux.ID, (*ymux.Transport)(ymuxCfg)),
 	}
+	if usage != nil {
+		opts = append(opts, libp2p.BandwidthReporter(usage))
+	}
 
 	if externalURL != "" {
 		extAddrs := buildExternalAddrs(externalURL, port, wsPort)
@@ -164,7 +206,21 @@ func StartRelay(port int, wsPort int, keyFile string, externalURL string, logFn
 		logFn = func(msg string) { log.Print(msg) }
 	}
 	tracer := &relayTracer{logFn: logFn}
-	if _, err := relayv2.New(h, relayv2.WithResources(relayv2.Resources{
+	relayOpts := []relayv2.Option{relayv2.WithMetricsTracer(tracer)}
+	if usage != nil {
+		usage.tracer = tracer
+		usage.logFn = logFn
+		usage.closePeer = func(p peer.ID) { _ = h.Network().ClosePeer(p) }
+		usage.closeBanned = func() {
+			for _, c := range h.Network().Conns() {
+				if usage.isBanned(c.RemotePeer(), c.RemoteMultiaddr()) {
+					_ = c.Close()
+				}
+			}
+		}
+		relayOpts = append(relayOpts, relayv2.WithACL(usage))
+	}
+	if _, err := relayv2.New(h, append(relayOpts, relayv2.WithResources(relayv2.Resources{
 		Limit: &relayv2.RelayLimit{
 			Duration: RelayDuration,
 			Data:     1 << 24, // 16 MB
@@ -176,7 +232,7 @@ func StartRelay(port int, wsPort int, keyFile string, externalURL string, logFn
 		MaxReservationsPerPeer: RelayMaxPerPeer,
 		MaxReservationsPerIP:   RelayMaxPerIP,
 		MaxReservationsPerASN:  RelayMaxPerASN,
-	}), relayv2.WithMetricsTracer(tracer)); err != nil {
+	}))...); err != nil {
 		_ = h.Close()
 		return nil, nil, fmt.Errorf("relay service: %w", err)
 	}
@@ -312,15 +368,18 @@ func buildWSSAddr(externalURL string, peerID string) string {
 	return fmt.Sprintf("/ip4/%s/tcp/443/tls/sni/%s/ws/p2p/%s", ip.String(), hostname, peerID)
 }
 
-// loadOrCreateRelayKey loads an Ed25519 key from disk, or creates one.
-func loadOrCreateRelayKey(keyFile string) (crypto.PrivKey, error) {
-	data, err := os.ReadFile(keyFile)
+// loadOrCreateKey loads an Ed25519 key from disk, or creates one. what names
+// the key in errors and logs.
+func loadOrCreateKey(keyFile, what string) (crypto.PrivKey, error) {
+	data, err := keystore.Load(keyFile)
 	if err == nil {
 		priv, err := crypto.UnmarshalPrivateKey(data)
 		if err == nil {
 			return priv, nil
 		}
-		log.Printf("WARNING: corrupt relay key at %s: %v (generating new key)", keyFile, err)
+		log.Printf("WARNING: corrupt %s key at %s: %v (generating new key)", what, keyFile, err)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s key %s: %w", what, keyFile, err)
 	}
 
 	priv, _, err := crypto.GenerateEd25519Key(nil)
@@ -330,20 +389,14 @@ func loadOrCreateRelayKey(keyFile string) (crypto.PrivKey, error) {
 
 	raw, err := crypto.MarshalPrivateKey(priv)
 	if err != nil {
-		return nil, fmt.Errorf("marshal relay key: %w", err)
-	}
-
-	if dir := filepath.Dir(keyFile); dir != "" {
-		if err := os.MkdirAll(dir, 0700); err != nil {
-			return nil, fmt.Errorf("create relay key directory: %w", err)
-		}
+		return nil, fmt.Errorf("marshal %s key: %w", what, err)
 	}
 
-	if err := os.WriteFile(keyFile, raw, 0600); err != nil {
-		return nil, fmt.Errorf("save relay key: %w", err)
+	if err := keystore.Save(keyFile, raw); err != nil {
+		return nil, fmt.Errorf("save %s key: %w", what, err)
 	}
 
-	log.Printf("relay: generated new identity key: %s", keyFile)
+	log.Printf("%s: generated new identity key: %s", what, keyFile)
 	return priv, nil
 }
 
@@ -0,0 +1,148 @@
+package rendezvous
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Relay info travels over plain HTTP, so a peer cannot take the peer ID in
+// it on trust: an on-path attacker could swap in its own relay. The
+// rendezvous signs what it serves on /relay with the relay host key, whose
+// public half is embedded in the relay peer ID, and the Client checks both
+// the signature and that the peer ID is the one it expects: the ID pinned
+// in the config, or else the one it saw the first time (trust on first use).
+// A rendezvous that predates signing serves unsigned info; that is accepted
+// with a warning as long as nothing is pinned for it, so its peers keep
+// their relay.
+
+var (
+	errRelayUnsigned = errors.New("relay info is not signed")
+	errRelayBadSig   = errors.New("relay info signature is invalid")
+)
+
+// SigningBytes returns the canonical bytes covered by Sig.
+func (ri RelayInfo) SigningBytes() []byte {
+	b, _ := json.Marshal([]any{"goop-relay", ri.PeerID, ri.Addrs, ri.STUNURLs,
+		ri.CleanupDelaySec, ri.PollDeadlineSec, ri.ConnectTimeoutSec, ri.RefreshIntervalSec, ri.RecoveryGraceSec})
+	return b
+}
+
+// signedRelayInfo returns a copy of the relay info signed with the relay
+// host key, or nil when the relay is not enabled.
+func (s *Server) signedRelayInfo() *RelayInfo {
+	if s.relayInfo == nil {
+		return nil
+	}
+	ri := *s.relayInfo
+	if key := s.relayHost.Peerstore().PrivKey(s.relayHost.ID()); key != nil {
+		ri.Sig, _ = key.Sign(ri.SigningBytes())
+	}
+	return &ri
+}
+
+// verify checks that Sig was made by the key of PeerID.
+func (ri *RelayInfo) verify() error {
+	if len(ri.Sig) == 0 {
+		return errRelayUnsigned
+	}
+	pid, err := peer.Decode(ri.PeerID)
+	if err != nil {
+		return fmt.Errorf("relay peer ID: %w", err)
+	}
+	pub, err := pid.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("relay peer ID: %w", err)
+	}
+	if ok, err := pub.Verify(ri.SigningBytes(), ri.Sig); err != nil || !ok {
+		return errRelayBadSig
+	}
+	return nil
+}
+
+// RelayPins remembers the relay peer ID each rendezvous served first, in
+// {peerDir}/data/relay_pins.json. Delete an entry (or the file) after the
+// operator of a rendezvous replaced its relay key.
+type RelayPins struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewRelayPins opens the relay pin store in {peerDir}/data.
+func NewRelayPins(peerDir string) *RelayPins {
+	dir := filepath.Join(peerDir, "data")
+	_ = os.MkdirAll(dir, 0755)
+	return &RelayPins{path: filepath.Join(dir, "relay_pins.json")}
+}
+
+func (p *RelayPins) load() map[string]string {
+	pins := map[string]string{}
+	if b, err := os.ReadFile(p.path); err == nil {
+		_ = json.Unmarshal(b, &pins)
+	}
+	return pins
+}
+
+// Get returns the relay peer ID pinned for a rendezvous, "" if none.
+func (p *RelayPins) Get(baseURL string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load()[baseURL]
+}
+
+// Put pins the relay peer ID of a rendezvous.
+func (p *RelayPins) Put(baseURL, peerID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pins := p.load()
+	pins[baseURL] = peerID
+	b, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.path, b)
+}
+
+// SetRelayPin makes FetchRelayInfo accept only relay info signed by
+// peerID. With peerID empty, the first signed relay seen is pinned in pins
+// instead, and unsigned info is accepted until then; with both unset,
+// relay info is not checked at all.
+func (c *Client) SetRelayPin(peerID string, pins *RelayPins) {
+	c.relayPin = peerID
+	c.relayPins = pins
+}
+
+// checkRelayInfo verifies fetched relay info against the pinned identity.
+func (c *Client) checkRelayInfo(ri *RelayInfo) error {
+	want := c.relayPin
+	if want == "" && c.relayPins != nil {
+		want = c.relayPins.Get(c.BaseURL)
+	}
+	if want == "" && c.relayPins == nil {
+		return nil
+	}
+	if want == "" && len(ri.Sig) == 0 {
+		if !c.relayUnsignedLogged.Swap(true) {
+			log.Printf("relay: %s serves unsigned relay info; using it unverified until a relay is pinned", c.BaseURL)
+		}
+		return nil
+	}
+	if err := ri.verify(); err != nil {
+		return err
+	}
+	switch {
+	case want == "":
+		if err := c.relayPins.Put(c.BaseURL, ri.PeerID); err != nil {
+			return fmt.Errorf("pin relay: %w", err)
+		}
+	case ri.PeerID != want:
+		return fmt.Errorf("relay peer %s does not match pinned %s", ri.PeerID, want)
+	}
+	return nil
+}
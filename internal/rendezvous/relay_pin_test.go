@@ -0,0 +1,108 @@
+package rendezvous
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// relayServer serves the relay info returned by info on /relay.
+func relayServer(t *testing.T, info func() *RelayInfo) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleRelayInfo(w, r, info())
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL)
+}
+
+func signedInfo(t *testing.T) (*RelayInfo, crypto.PrivKey) {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := peer.IDFromPrivateKey(priv)
+	ri := &RelayInfo{PeerID: id.String(), Addrs: []string{"/ip4/203.0.113.1/tcp/4001"}, ConnectTimeoutSec: 5}
+	ri.Sig, _ = priv.Sign(ri.SigningBytes())
+	return ri, priv
+}
+
+func TestFetchRelayInfo_pinsFirstRelay(t *testing.T) {
+	good, _ := signedInfo(t)
+	unsigned := *good
+	unsigned.Sig = nil
+	served := &unsigned
+	c := relayServer(t, func() *RelayInfo { return served })
+	pins := NewRelayPins(t.TempDir())
+	c.SetRelayPin("", pins)
+	ctx := context.Background()
+
+	// A rendezvous that does not sign yet keeps working, unpinned.
+	if ri, err := c.FetchRelayInfo(ctx); err != nil || ri.PeerID != good.PeerID {
+		t.Fatalf("unsigned before pinning: %v, %v", ri, err)
+	}
+	if got := pins.Get(c.BaseURL); got != "" {
+		t.Fatalf("unsigned relay pinned as %q", got)
+	}
+
+	served = good
+	if ri, err := c.FetchRelayInfo(ctx); err != nil || ri.PeerID != good.PeerID {
+		t.Fatalf("first fetch: %v, %v", ri, err)
+	}
+	if got := pins.Get(c.BaseURL); got != good.PeerID {
+		t.Fatalf("pinned %q", got)
+	}
+
+	// A different, validly signed relay is refused once pinned.
+	served, _ = signedInfo(t)
+	if _, err := c.FetchRelayInfo(ctx); err == nil || !strings.Contains(err.Error(), "pinned") {
+		t.Fatalf("other relay: %v", err)
+	}
+
+	// So is the pinned relay with its addresses swapped, or unsigned.
+	tampered := *good
+	tampered.Addrs = []string{"/ip4/198.51.100.9/tcp/4001"}
+	served = &tampered
+	if _, err := c.FetchRelayInfo(ctx); err != errRelayBadSig {
+		t.Fatalf("tampered: %v", err)
+	}
+	served = &unsigned
+	if _, err := c.FetchRelayInfo(ctx); err != errRelayUnsigned {
+		t.Fatalf("unsigned: %v", err)
+	}
+}
+
+func TestFetchRelayInfo_configuredPin(t *testing.T) {
+	want, _ := signedInfo(t)
+	other, _ := signedInfo(t)
+	c := relayServer(t, func() *RelayInfo { return other })
+	pins := NewRelayPins(t.TempDir())
+	c.SetRelayPin(want.PeerID, pins)
+
+	if _, err := c.FetchRelayInfo(context.Background()); err == nil {
+		t.Fatal("relay other than the configured one accepted")
+	}
+	unsigned := *want
+	unsigned.Sig = nil
+	other = &unsigned
+	if _, err := c.FetchRelayInfo(context.Background()); err != errRelayUnsigned {
+		t.Fatalf("unsigned info with a configured pin: %v", err)
+	}
+	if got := pins.Get(c.BaseURL); got != "" {
+		t.Fatalf("configured pin overridden by %q", got)
+	}
+}
+
+func TestFetchRelayInfo_unpinnedClientSkipsCheck(t *testing.T) {
+	c := relayServer(t, func() *RelayInfo { return &RelayInfo{PeerID: "12D3KooWTest"} })
+	if ri, err := c.FetchRelayInfo(context.Background()); err != nil || ri == nil {
+		t.Fatalf("fetch: %v, %v", ri, err)
+	}
+}
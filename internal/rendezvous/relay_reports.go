@@ -0,0 +1,187 @@
+package rendezvous
+
+// relay_reports.go — relay health as seen by peers. Each peer using the
+// relay posts a RelayReport every few minutes; the admin Relay tab shows
+// the latest one per peer and flags failures that hit many peers at once,
+// which point at the relay rather than at a peer's network.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// relayReports keeps the latest report per peer.
+type relayReports struct {
+	mu   sync.Mutex
+	rows map[string]relayReportRow
+}
+
+type relayReportRow struct {
+	RelayReport
+	Name     string `json:"name,omitempty"`
+	Received int64  `json:"received"` // unix millis
+}
+
+// relayHealthJSON summarises the reports that are still fresh.
+type relayHealthJSON struct {
+	Reporting   int            `json:"reporting"`
+	OK          int            `json:"ok"`
+	Failing     int            `json:"failing"`
+	ByClass     map[string]int `json:"by_class,omitempty"` // failing peers per error class
+	MedianRTTMs int64          `json:"median_rtt_ms,omitempty"`
+	Systemic    bool           `json:"systemic"`
+}
+
+func newRelayReports() *relayReports {
+	return &relayReports{rows: map[string]relayReportRow{}}
+}
+
+// put stores rep and reports whether the peer's reservation state changed.
+func (rr *relayReports) put(rep RelayReport, name string, now time.Time) (changed bool) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	prev, seen := rr.rows[rep.PeerID]
+	if len(rr.rows) >= RelayReportMaxPeers && !seen {
+		rr.pruneLocked(now)
+		if len(rr.rows) >= RelayReportMaxPeers {
+			return false
+		}
+	}
+	rr.rows[rep.PeerID] = relayReportRow{RelayReport: rep, Name: name, Received: now.UnixMilli()}
+	return seen && prev.ReservationOK != rep.ReservationOK
+}
+
+func (rr *relayReports) pruneLocked(now time.Time) {
+	cutoff := now.Add(-RelayReportMaxAge).UnixMilli()
+	for id, row := range rr.rows {
+		if row.Received < cutoff {
+			delete(rr.rows, id)
+		}
+	}
+}
+
+// snapshot returns the fresh reports, failing peers first, and their summary.
+func (rr *relayReports) snapshot(now time.Time) ([]relayReportRow, relayHealthJSON) {
+	rr.mu.Lock()
+	rr.pruneLocked(now)
+	rows := make([]relayReportRow, 0, len(rr.rows))
+	for _, row := range rr.rows {
+		rows = append(rows, row)
+	}
+	rr.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ReservationOK != rows[j].ReservationOK {
+			return !rows[i].ReservationOK
+		}
+		return rows[i].PeerID < rows[j].PeerID
+	})
+
+	h := relayHealthJSON{Reporting: len(rows)}
+	var rtts []int64
+	for _, row := range rows {
+		if row.ReservationOK {
+			h.OK++
+		} else {
+			h.Failing++
+			if h.ByClass == nil {
+				h.ByClass = map[string]int{}
+			}
+			class := row.ErrorClass
+			if class == "" {
+				class = RelayErrOther
+			}
+			h.ByClass[class]++
+		}
+		if row.RTTMs > 0 {
+			rtts = append(rtts, row.RTTMs)
+		}
+	}
+	if len(rtts) > 0 {
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		h.MedianRTTMs = rtts[len(rtts)/2]
+	}
+	// One peer failing is usually its own network; several at once,
+	// making up half of those reporting, is the relay.
+	h.Systemic = h.Failing >= RelaySystemicMinPeers && h.Failing*2 >= h.Reporting
+	return rows, h
+}
+
+// validRelayErrClass maps unknown classes to RelayErrOther so a peer
+// cannot fill the admin page with free text.
+func validRelayErrClass(class string) string {
+	switch class {
+	case "", RelayErrTimeout, RelayErrDial, RelayErrRefused, RelayErrLimit, RelayErrNoCircuit:
+		return class
+	}
+	return RelayErrOther
+}
+
+// handleRelayReport accepts a RelayReport from a peer. Only peers that
+// have published presence are recorded; reports are rate limited like
+// /publish.
+func (s *Server) handleRelayReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.relayHost == nil {
+		http.Error(w, "relay not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	if !s.allowPublish(extractIP(r.RemoteAddr)) {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	var rep RelayReport
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&rep); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	if _, err := peer.Decode(rep.PeerID); err != nil {
+		http.Error(w, "invalid peer ID", http.StatusBadRequest)
+		return
+	}
+	rep.ErrorClass = validRelayErrClass(rep.ErrorClass)
+	if rep.Failures < 0 {
+		rep.Failures = 0
+	}
+	if rep.RTTMs < 0 {
+		rep.RTTMs = 0
+	}
+
+	s.mu.Lock()
+	p, known := s.peers[rep.PeerID]
+	s.mu.Unlock()
+	if !known {
+		http.Error(w, "unknown peer", http.StatusNotFound)
+		return
+	}
+
+	if s.relayReports.put(rep, p.Content, time.Now()) {
+		state := "ok"
+		if !rep.ReservationOK {
+			state = "failing"
+			if rep.ErrorClass != "" {
+				state += " (" + rep.ErrorClass + ")"
+			}
+		}
+		s.relayAddLog(fmt.Sprintf("report: %s reservation %s", shortID(rep.PeerID), state))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func shortID(id string) string {
+	if len(id) > 16 {
+		return id[:16] + "..."
+	}
+	return id
+}
@@ -0,0 +1,51 @@
+package rendezvous
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelayReports_Summary(t *testing.T) {
+	rr := newRelayReports()
+	now := time.Now()
+
+	rr.put(RelayReport{PeerID: "a", ReservationOK: true, RTTMs: 40}, "alice", now)
+	rr.put(RelayReport{PeerID: "b", ErrorClass: RelayErrLimit, RTTMs: 80}, "", now)
+	if changed := rr.put(RelayReport{PeerID: "a", ReservationOK: true, RTTMs: 50}, "alice", now); changed {
+		t.Fatal("unchanged state reported as changed")
+	}
+
+	rows, h := rr.snapshot(now)
+	if len(rows) != 2 || rows[0].PeerID != "b" {
+		t.Fatalf("rows = %+v, want failing peer first", rows)
+	}
+	if h.OK != 1 || h.Failing != 1 || h.ByClass[RelayErrLimit] != 1 || h.Systemic {
+		t.Fatalf("health = %+v", h)
+	}
+	if h.MedianRTTMs != 80 {
+		t.Fatalf("median rtt = %d", h.MedianRTTMs)
+	}
+
+	for _, id := range []string{"c", "d"} {
+		rr.put(RelayReport{PeerID: id, ErrorClass: RelayErrTimeout}, "", now)
+	}
+	if _, h := rr.snapshot(now); !h.Systemic {
+		t.Fatalf("3 of 4 failing not flagged: %+v", h)
+	}
+
+	if !rr.put(RelayReport{PeerID: "b", ReservationOK: true}, "", now) {
+		t.Fatal("recovery not reported as a change")
+	}
+	if rows, _ := rr.snapshot(now.Add(RelayReportMaxAge + time.Minute)); len(rows) != 0 {
+		t.Fatalf("stale reports kept: %d", len(rows))
+	}
+}
+
+func TestValidRelayErrClass(t *testing.T) {
+	if got := validRelayErrClass("<b>nope</b>"); got != RelayErrOther {
+		t.Fatalf("got %q", got)
+	}
+	if got := validRelayErrClass(RelayErrNoCircuit); got != RelayErrNoCircuit {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,206 @@
+package rendezvous
+
+// relay_selftest.go — an end-to-end check of our own circuit relay. Two
+// throwaway peers are started in this process; both connect to the relay
+// and reserve a slot, the first dials the second through the circuit, and
+// they send each other an MQ message the way real peers do. Every step is
+// timed, so after a config change the operator sees whether, and where,
+// relaying breaks without needing two machines.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/mq"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// relaySelfTestTopic is the MQ topic of the self-test pings.
+const relaySelfTestTopic = "relay.selftest"
+
+// relaySelfTestStep is one timed step of a self-test.
+type relaySelfTestStep struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Millis int64  `json:"ms"`
+	Detail string `json:"detail,omitempty"` // what was used, or the error
+}
+
+// relaySelfTestResult is the outcome of a self-test. Steps stop at the
+// first that failed.
+type relaySelfTestResult struct {
+	OK      bool                `json:"ok"`
+	Started int64               `json:"started"` // unix millis
+	Millis  int64               `json:"ms"`
+	Steps   []relaySelfTestStep `json:"steps"`
+}
+
+// handleRelaySelfTest runs a self-test (POST) and returns its result. Only
+// one runs at a time.
+func (s *Server) handleRelaySelfTest(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleOperator) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.relayInfo == nil {
+		http.Error(w, "relay not enabled", http.StatusNotFound)
+		return
+	}
+	if !s.relaySelfTest.TryLock() {
+		http.Error(w, "a self-test is already running", http.StatusConflict)
+		return
+	}
+	defer s.relaySelfTest.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), RelaySelfTestTimeout)
+	defer cancel()
+	res := runRelaySelfTest(ctx, s.relayInfo)
+
+	if res.OK {
+		s.relayAddLog(fmt.Sprintf("self-test passed in %d ms", res.Millis))
+	} else {
+		last := res.Steps[len(res.Steps)-1]
+		s.relayAddLog(fmt.Sprintf("self-test failed at %s: %s", last.Name, last.Detail))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// runRelaySelfTest checks the relay described by info end to end.
+func runRelaySelfTest(ctx context.Context, info *RelayInfo) relaySelfTestResult {
+	begin := time.Now()
+	res := relaySelfTestResult{OK: true, Started: begin.UnixMilli(), Steps: []relaySelfTestStep{}}
+	step := func(name string, fn func() (string, error)) bool {
+		start := time.Now()
+		detail, err := fn()
+		st := relaySelfTestStep{Name: name, OK: err == nil, Millis: time.Since(start).Milliseconds(), Detail: detail}
+		if err != nil {
+			st.Detail = err.Error()
+			res.OK = false
+		}
+		res.Steps = append(res.Steps, st)
+		return err == nil
+	}
+	var relay peer.AddrInfo
+	var a, b host.Host
+	var amq, bmq *mq.Manager
+	defer func() {
+		for _, h := range []host.Host{a, b} {
+			if h != nil {
+				_ = h.Close()
+			}
+		}
+	}()
+
+	ok := step("start peers", func() (string, error) {
+		var err error
+		if relay, err = relayAddrInfo(info); err != nil {
+			return "", err
+		}
+		if a, amq, err = newSelfTestPeer(); err != nil {
+			return "", err
+		}
+		if b, bmq, err = newSelfTestPeer(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s, %s", a.ID().ShortString(), b.ID().ShortString()), nil
+	})
+	for _, p := range []struct {
+		name string
+		h    host.Host
+	}{{"a", a}, {"b", b}} {
+		ok = ok && step("connect "+p.name, func() (string, error) {
+			if err := p.h.Connect(ctx, relay); err != nil {
+				return "", err
+			}
+			for _, c := range p.h.Network().ConnsToPeer(relay.ID) {
+				return c.RemoteMultiaddr().String(), nil
+			}
+			return "", fmt.Errorf("no connection")
+		})
+		ok = ok && step("reserve "+p.name, func() (string, error) {
+			rsv, err := client.Reserve(ctx, p.h, relay)
+			if err != nil {
+				return "", err
+			}
+			return "expires in " + time.Until(rsv.Expiration).Round(time.Minute).String(), nil
+		})
+	}
+	ok = ok && step("circuit", func() (string, error) {
+		circuit, err := ma.NewMultiaddr(fmt.Sprintf("/p2p/%s/p2p-circuit", relay.ID))
+		if err != nil {
+			return "", err
+		}
+		if err := a.Connect(ctx, peer.AddrInfo{ID: b.ID(), Addrs: []ma.Multiaddr{circuit}}); err != nil {
+			return "", err
+		}
+		for _, c := range a.Network().ConnsToPeer(b.ID()) {
+			if !c.Stat().Limited {
+				return "", fmt.Errorf("connected directly, not through the relay")
+			}
+			return c.RemoteMultiaddr().String(), nil
+		}
+		return "", fmt.Errorf("no connection")
+	})
+	for _, p := range []struct {
+		name string
+		from *mq.Manager
+		to   host.Host
+	}{{"ping a→b", amq, b}, {"ping b→a", bmq, a}} {
+		ok = ok && step(p.name, func() (string, error) {
+			_, err := p.from.Send(ctx, p.to.ID().String(), relaySelfTestTopic, map[string]any{"sent": time.Now().UnixMilli()})
+			return "", err
+		})
+	}
+	res.Millis = time.Since(begin).Milliseconds()
+	return res
+}
+
+// newSelfTestPeer starts a throwaway peer that listens on loopback only and
+// speaks MQ.
+func newSelfTestPeer() (host.Host, *mq.Manager, error) {
+	h, err := libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.EnableRelay(),
+		libp2p.DisableMetrics(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, mq.New(h), nil
+}
+
+// relayAddrInfo turns the relay's advertised addresses into an AddrInfo.
+func relayAddrInfo(info *RelayInfo) (peer.AddrInfo, error) {
+	id, err := peer.Decode(info.PeerID)
+	if err != nil {
+		return peer.AddrInfo{}, fmt.Errorf("relay peer ID: %w", err)
+	}
+	ai := peer.AddrInfo{ID: id}
+	for _, s := range info.Addrs {
+		addr, err := ma.NewMultiaddr(s)
+		if err != nil {
+			log.Printf("relay self-test: skipping address %s: %v", s, err)
+			continue
+		}
+		if transport, _ := peer.SplitAddr(addr); transport != nil {
+			ai.Addrs = append(ai.Addrs, transport)
+		}
+	}
+	if len(ai.Addrs) == 0 {
+		return ai, fmt.Errorf("relay has no addresses")
+	}
+	return ai, nil
+}
@@ -0,0 +1,41 @@
+package rendezvous
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunRelaySelfTest(t *testing.T) {
+	h, info, err := StartRelay(0, 0, filepath.Join(t.TempDir(), "relay.key"), "", nil, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), RelaySelfTestTimeout)
+	defer cancel()
+	res := runRelaySelfTest(ctx, info)
+	if !res.OK {
+		t.Fatalf("self-test failed: %+v", res.Steps)
+	}
+	want := []string{"start peers", "connect a", "reserve a", "connect b", "reserve b", "circuit", "ping a→b", "ping b→a"}
+	if len(res.Steps) != len(want) {
+		t.Fatalf("steps = %+v", res.Steps)
+	}
+	for i, st := range res.Steps {
+		if st.Name != want[i] || !st.OK {
+			t.Fatalf("step %d = %+v, want %s", i, st, want[i])
+		}
+	}
+
+	// A relay that is gone fails at the first connect.
+	h.Close()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel2()
+	res = runRelaySelfTest(ctx2, info)
+	if res.OK || res.Steps[len(res.Steps)-1].Name != "connect a" {
+		t.Fatalf("against a closed relay: %+v", res.Steps)
+	}
+}
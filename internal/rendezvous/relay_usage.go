@@ -0,0 +1,230 @@
+package rendezvous
+
+// relay_usage.go — per-peer metering of relayed traffic. The relay host
+// reports every byte its hop and stop streams carry; relayUsage adds them
+// up per peer and UTC day, and once a peer uses up its daily quota the
+// reservation is revoked: the peer's relay connections are closed and the
+// relay ACL refuses new reservations and circuits until the day rolls over.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	circuitproto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// RelayLimitsConfig holds relay bandwidth limits from the config file.
+// Zero values mean "no limit".
+type RelayLimitsConfig struct {
+	DailyQuotaMB int // relayed traffic per peer per UTC day, both directions
+}
+
+// relayUsage meters relayed bytes per peer. It is the relay host's
+// bandwidth reporter and its relay ACL at the same time.
+type relayUsage struct {
+	*metrics.BandwidthCounter
+
+	now       func() time.Time
+	closePeer func(peer.ID) // drops the peer's relay connections
+	// banned refuses banned peers and addresses; closeBanned drops their
+	// relay connections. Set by the server and by StartRelay.
+	banned      func(peer.ID, ma.Multiaddr) bool
+	closeBanned func()
+	logFn       func(string)
+	tracer      *relayTracer // circuit and reservation counts, set by StartRelay
+
+	mu     sync.Mutex
+	quota  int64 // bytes per day, 0 = unlimited
+	day    string
+	peers  map[peer.ID]*peerUsage
+	totals peerUsage
+}
+
+type peerUsage struct {
+	In      int64
+	Out     int64
+	Revoked time.Time
+}
+
+func newRelayUsage() *relayUsage {
+	return &relayUsage{
+		BandwidthCounter: metrics.NewBandwidthCounter(),
+		now:              time.Now,
+		logFn:            func(string) {},
+		peers:            map[peer.ID]*peerUsage{},
+	}
+}
+
+// SetRelayLimits sets the per-peer relay quotas. Call before Start.
+func (s *Server) SetRelayLimits(limits RelayLimitsConfig) {
+	s.relayUsage.mu.Lock()
+	s.relayUsage.quota = int64(limits.DailyQuotaMB) << 20
+	s.relayUsage.mu.Unlock()
+}
+
+func isRelayProtocol(p protocol.ID) bool {
+	return p == circuitproto.ProtoIDv2Hop || p == circuitproto.ProtoIDv2Stop
+}
+
+func (u *relayUsage) LogRecvMessageStream(n int64, proto protocol.ID, p peer.ID) {
+	u.BandwidthCounter.LogRecvMessageStream(n, proto, p)
+	if isRelayProtocol(proto) {
+		u.add(p, n, 0)
+	}
+}
+
+func (u *relayUsage) LogSentMessageStream(n int64, proto protocol.ID, p peer.ID) {
+	u.BandwidthCounter.LogSentMessageStream(n, proto, p)
+	if isRelayProtocol(proto) {
+		u.add(p, 0, n)
+	}
+}
+
+// rolloverLocked starts a new day of metering when the UTC date changed.
+func (u *relayUsage) rolloverLocked() {
+	if day := u.now().UTC().Format(time.DateOnly); day != u.day {
+		u.day = day
+		u.peers = map[peer.ID]*peerUsage{}
+		u.totals = peerUsage{}
+	}
+}
+
+func (u *relayUsage) add(p peer.ID, in, out int64) {
+	u.mu.Lock()
+	u.rolloverLocked()
+	pu := u.peers[p]
+	if pu == nil {
+		if len(u.peers) >= RelayUsageMaxPeers {
+			u.mu.Unlock()
+			return
+		}
+		pu = &peerUsage{}
+		u.peers[p] = pu
+	}
+	pu.In += in
+	pu.Out += out
+	u.totals.In += in
+	u.totals.Out += out
+	revoke := u.quota > 0 && pu.Revoked.IsZero() && pu.In+pu.Out >= u.quota
+	if revoke {
+		pu.Revoked = u.now()
+	}
+	quota := u.quota
+	u.mu.Unlock()
+
+	if revoke {
+		u.logFn(fmt.Sprintf("QUOTA: %s used %d MB today, reservation revoked", p, quota>>20))
+		if u.closePeer != nil {
+			// Called from inside a stream read or write: close elsewhere.
+			go u.closePeer(p)
+		}
+	}
+}
+
+// exhausted reports whether the peer used up today's quota.
+func (u *relayUsage) exhausted(p peer.ID) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rolloverLocked()
+	pu := u.peers[p]
+	return pu != nil && !pu.Revoked.IsZero()
+}
+
+// isBanned reports whether the peer, or the address it connects from, is
+// banned. addr may be nil.
+func (u *relayUsage) isBanned(p peer.ID, addr ma.Multiaddr) bool {
+	return u.banned != nil && u.banned(p, addr)
+}
+
+// AllowReserve implements relayv2.ACLFilter.
+func (u *relayUsage) AllowReserve(p peer.ID, addr ma.Multiaddr) bool {
+	return !u.exhausted(p) && !u.isBanned(p, addr)
+}
+
+// AllowConnect implements relayv2.ACLFilter.
+func (u *relayUsage) AllowConnect(src peer.ID, srcAddr ma.Multiaddr, dest peer.ID) bool {
+	return !u.exhausted(src) && !u.exhausted(dest) && !u.isBanned(src, srcAddr) && !u.isBanned(dest, nil)
+}
+
+type relayUsagePeerJSON struct {
+	PeerID   string `json:"peer_id"`
+	Name     string `json:"name,omitempty"`
+	BytesIn  int64  `json:"bytes_in"`
+	BytesOut int64  `json:"bytes_out"`
+	Bytes    int64  `json:"bytes"`
+	Revoked  int64  `json:"revoked,omitempty"` // unix millis; 0 while under quota
+}
+
+type relayUsageJSON struct {
+	Day        string               `json:"day"` // UTC date the counters cover
+	QuotaBytes int64                `json:"quota_bytes"`
+	BytesIn    int64                `json:"bytes_in"`
+	BytesOut   int64                `json:"bytes_out"`
+	Revoked    int                  `json:"revoked"`
+	Peers      []relayUsagePeerJSON `json:"peers"` // heaviest users first
+}
+
+// snapshot returns today's usage, heaviest users first.
+func (u *relayUsage) snapshot() relayUsageJSON {
+	u.mu.Lock()
+	u.rolloverLocked()
+	out := relayUsageJSON{
+		Day:        u.day,
+		QuotaBytes: u.quota,
+		BytesIn:    u.totals.In,
+		BytesOut:   u.totals.Out,
+		Peers:      make([]relayUsagePeerJSON, 0, len(u.peers)),
+	}
+	for p, pu := range u.peers {
+		row := relayUsagePeerJSON{PeerID: p.String(), BytesIn: pu.In, BytesOut: pu.Out, Bytes: pu.In + pu.Out}
+		if !pu.Revoked.IsZero() {
+			row.Revoked = pu.Revoked.UnixMilli()
+			out.Revoked++
+		}
+		out.Peers = append(out.Peers, row)
+	}
+	u.mu.Unlock()
+
+	sort.Slice(out.Peers, func(i, j int) bool {
+		if out.Peers[i].Bytes != out.Peers[j].Bytes {
+			return out.Peers[i].Bytes > out.Peers[j].Bytes
+		}
+		return out.Peers[i].PeerID < out.Peers[j].PeerID
+	})
+	return out
+}
+
+// handleRelayUsageJSON serves today's relay usage per peer to the admin page.
+func (s *Server) handleRelayUsageJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireRole(w, r, roleViewer) {
+		return
+	}
+	if s.relayHost == nil {
+		http.Error(w, "relay not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	result := s.relayUsage.snapshot()
+	s.mu.Lock()
+	for i, row := range result.Peers {
+		if p, ok := s.peers[row.PeerID]; ok {
+			result.Peers[i].Name = p.Content
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,71 @@
+package rendezvous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	circuitproto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+)
+
+func TestRelayUsage_revokesOverQuota(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	u := newRelayUsage()
+	u.now = func() time.Time { return now }
+	u.quota = 1 << 20
+	closed := make(chan peer.ID, 2)
+	u.closePeer = func(p peer.ID) { closed <- p }
+
+	a, _ := peer.Decode(newTestPeerID(t))
+	b, _ := peer.Decode(newTestPeerID(t))
+
+	u.LogRecvMessageStream(600<<10, circuitproto.ProtoIDv2Hop, a)
+	u.LogSentMessageStream(600<<10, circuitproto.ProtoIDv2Stop, b)
+	u.LogRecvMessageStream(1<<20, "/ipfs/ping/1.0.0", a) // not relayed
+	if !u.AllowReserve(a, nil) || !u.AllowConnect(a, nil, b) {
+		t.Fatal("peers under quota refused")
+	}
+
+	u.LogSentMessageStream(500<<10, circuitproto.ProtoIDv2Hop, a)
+	select {
+	case p := <-closed:
+		if p != a {
+			t.Errorf("closed %s, want %s", p, a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer over quota not disconnected")
+	}
+	if u.AllowReserve(a, nil) || u.AllowConnect(b, nil, a) {
+		t.Error("peer over quota allowed")
+	}
+	if !u.AllowReserve(b, nil) {
+		t.Error("peer under quota refused")
+	}
+
+	u.LogSentMessageStream(1<<20, circuitproto.ProtoIDv2Hop, a)
+	select {
+	case <-closed:
+		t.Error("revoked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	snap := u.snapshot()
+	if len(snap.Peers) != 2 || snap.Peers[0].PeerID != a.String() || snap.Revoked != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if top := snap.Peers[0]; top.BytesIn != 600<<10 || top.Bytes != 600<<10+500<<10+1<<20 || top.Revoked == 0 {
+		t.Errorf("top = %+v", top)
+	}
+	if snap.BytesIn != 600<<10 || snap.BytesOut != 600<<10+500<<10+1<<20 {
+		t.Errorf("totals = %d in, %d out", snap.BytesIn, snap.BytesOut)
+	}
+
+	// A new UTC day starts from zero.
+	now = now.Add(2 * time.Hour)
+	if !u.AllowReserve(a, nil) {
+		t.Error("quota not reset at midnight")
+	}
+	if snap := u.snapshot(); snap.Day != "2026-03-02" || len(snap.Peers) != 0 {
+		t.Errorf("next day = %+v", snap)
+	}
+}
@@ -13,6 +13,7 @@ import (
 type remoteBase struct {
 	baseURL    string
 	adminToken string
+	tokenFn    func() string // overrides adminToken; see SetAdminTokenSource
 	client     *http.Client
 
 	// status cache — populated by fetchFn
@@ -32,6 +33,21 @@ func newRemoteBase(baseURL, adminToken string) remoteBase {
 	}
 }
 
+// SetAdminTokenSource makes the provider ask fn for its admin token on
+// every request instead of using the one it was built with, so a rotated
+// secret takes effect without a restart.
+func (b *remoteBase) SetAdminTokenSource(fn func() string) {
+	b.tokenFn = fn
+}
+
+// token returns the admin token to send.
+func (b *remoteBase) token() string {
+	if b.tokenFn != nil {
+		return b.tokenFn()
+	}
+	return b.adminToken
+}
+
 func (b *remoteBase) fetchStatus() {
 	if b.fetchFn != nil {
 		b.fetchFn()
@@ -81,7 +81,7 @@ func (p *RemoteBridgeProvider) RegisterRoutes(mux *http.ServeMux, registration *
 			return
 		}
 		proxyReq.Header.Set("Content-Type", "application/json")
-		setAuthHeader(proxyReq, p.adminToken)
+		setAuthHeader(proxyReq, p.token())
 
 		resp, err := p.client.Do(proxyReq)
 		if err != nil {
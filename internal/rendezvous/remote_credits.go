@@ -365,7 +365,7 @@ func (p *RemoteCreditProvider) FetchAccounts() (json.RawMessage, error) {
 	if err != nil {
 		return nil, err
 	}
-	setAuthHeader(req, p.adminToken)
+	setAuthHeader(req, p.token())
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("credits service: %w", err)
@@ -381,6 +381,39 @@ func (p *RemoteCreditProvider) FetchAccounts() (json.RawMessage, error) {
 	return json.RawMessage(body), nil
 }
 
+// creditPurchase is one template purchase as recorded by the credits service.
+type creditPurchase struct {
+	ID       int64  `json:"id"`
+	Email    string `json:"email"`
+	Template string `json:"template"`
+	Amount   int    `json:"amount"`
+	TS       int64  `json:"ts"` // unix millis
+}
+
+// FetchPurchases fetches template purchases with an ID greater than sinceID
+// from the credits service, oldest first.
+func (p *RemoteCreditProvider) FetchPurchases(sinceID int64) ([]creditPurchase, error) {
+	reqURL := fmt.Sprintf("%s/api/credits/purchases?since=%d", p.baseURL, sinceID)
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAuthHeader(req, p.token())
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("credits service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("credits service returned %d", resp.StatusCode)
+	}
+	var out []creditPurchase
+	if err := readJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // forwardResponse copies the status code, content-type, and body from the
 // credits service response to the client.
 func forwardResponse(w http.ResponseWriter, resp *http.Response) {
@@ -1,6 +1,11 @@
 package rendezvous
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httputil"
@@ -44,3 +49,26 @@ func (p *RemoteEmailProvider) RegisterRoutes(mux *http.ServeMux) {
 		proxy.ServeHTTP(w, r)
 	})
 }
+
+// Send asks the email service to send one templated email.
+func (p *RemoteEmailProvider) Send(ctx context.Context, to, template, subject string, data any) error {
+	body, err := json.Marshal(map[string]any{"to": to, "template": template, "subject": subject, "data": data})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/email/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("email send: status %s", resp.Status)
+	}
+	return nil
+}
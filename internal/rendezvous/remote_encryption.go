@@ -63,7 +63,7 @@ func (p *RemoteEncryptionProvider) RegisterRoutes(mux *http.ServeMux) {
 		if peerID := r.Header.Get("X-Goop-PeerID"); peerID != "" {
 			proxyReq.Header.Set("X-Goop-PeerID", peerID)
 		}
-		setAuthHeader(proxyReq, p.adminToken)
+		setAuthHeader(proxyReq, p.token())
 
 		resp, err := p.client.Do(proxyReq)
 		if err != nil {
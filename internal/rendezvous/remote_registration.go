@@ -51,8 +51,13 @@ func (p *RemoteRegistrationProvider) RegisterRoutes(mux *http.ServeMux) {
 	}
 	proxy := httputil.NewSingleHostReverseProxy(target)
 
-	// Proxy all /api/reg/* directly
+	// Proxy all /api/reg/* directly, except registering: that goes through
+	// /register, which applies the proof-of-work and per-IP limits.
 	mux.HandleFunc("/api/reg/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/reg/register" {
+			http.Error(w, "register at /register", http.StatusForbidden)
+			return
+		}
 		proxy.ServeHTTP(w, r)
 	})
 }
@@ -147,7 +152,7 @@ func (p *RemoteRegistrationProvider) FetchRegistrations() (json.RawMessage, erro
 	if err != nil {
 		return nil, err
 	}
-	setAuthHeader(req, p.adminToken)
+	setAuthHeader(req, p.token())
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("registration service: %w", err)
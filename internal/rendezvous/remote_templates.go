@@ -48,8 +48,14 @@ func NewRemoteTemplatesProvider(baseURL, adminToken string) *RemoteTemplatesProv
 
 // RegisterRoutes registers /api/templates/prices handlers that inject the
 // admin token for POST requests before proxying to the templates service.
-func (p *RemoteTemplatesProvider) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/templates/prices", p.handlePrices)
+// authorize checks the caller of a POST.
+func (p *RemoteTemplatesProvider) RegisterRoutes(mux *http.ServeMux, authorize func(http.ResponseWriter, *http.Request) bool) {
+	mux.HandleFunc("/api/templates/prices", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && !authorize(w, r) {
+			return
+		}
+		p.handlePrices(w, r)
+	})
 }
 
 func (p *RemoteTemplatesProvider) handlePrices(w http.ResponseWriter, r *http.Request) {
@@ -71,7 +77,7 @@ func (p *RemoteTemplatesProvider) handlePrices(w http.ResponseWriter, r *http.Re
 			return
 		}
 		req.Header.Set("Content-Type", "application/json")
-		setAuthHeader(req, p.adminToken)
+		setAuthHeader(req, p.token())
 		resp, err := p.client.Do(req)
 		if err != nil {
 			log.Printf("templates: price save error: %v", err)
@@ -0,0 +1,314 @@
+package rendezvous
+
+// reputation.go — peers' signed accounts of other peers, for moderation.
+// A peer submits a ReputationRecord about a peer it had trouble with: what
+// its audit log saw of it and the abuse reports it filed. The admin Reports
+// tab lists the reported peers with how many distinct peers reported them,
+// so an operator acting on a report has evidence from more than one side.
+// A reporter has one record per subject; a newer one replaces it.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ReputationReport is one abuse report inside a ReputationRecord.
+type ReputationReport struct {
+	Reason string `json:"reason"` // spam, harassment, impersonation, illegal or other
+	Note   string `json:"note,omitempty"`
+	At     int64  `json:"at"` // unix millis
+}
+
+// ReputationRecord is what one peer observed of another over a window,
+// signed with the reporter's identity key. Stored records keep their
+// signature, so anyone can check a record with its Verify.
+type ReputationRecord struct {
+	Subject         string             `json:"subject"`  // the peer the record is about
+	Reporter        string             `json:"reporter"` // the peer that observed it and signed
+	Since           int64              `json:"since"`    // unix millis, start of the window
+	Until           int64              `json:"until"`    // unix millis, when the record was made
+	Streams         int                `json:"streams"`  // inbound streams the subject opened
+	Denied          int                `json:"denied"`   // of which refused by an access policy
+	Refused         int                `json:"refused"`  // of which refused by the protocol handler
+	MQMessages      int                `json:"mq_messages"`
+	MQPeakPerMinute int                `json:"mq_peak_per_minute"`
+	Reports         []ReputationReport `json:"reports,omitempty"`
+	Sig             []byte             `json:"sig,omitempty" swaggertype:"string" format:"base64"`
+}
+
+// SigningBytes returns the canonical bytes covered by Sig.
+func (r ReputationRecord) SigningBytes() []byte {
+	b, _ := json.Marshal([]any{"goop-reputation", r.Subject, r.Reporter, r.Since, r.Until,
+		r.Streams, r.Denied, r.Refused, r.MQMessages, r.MQPeakPerMinute, r.Reports})
+	return b
+}
+
+// Verify checks that the record is signed by its reporter.
+func (r ReputationRecord) Verify() error {
+	pid, err := peer.Decode(r.Reporter)
+	if err != nil {
+		return fmt.Errorf("invalid reporter: %w", err)
+	}
+	pub, err := pid.ExtractPublicKey()
+	if err != nil {
+		return err
+	}
+	if ok, err := pub.Verify(r.SigningBytes(), r.Sig); err != nil || !ok {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+var errReputationUnsupported = errors.New("rendezvous does not accept reputation records")
+
+func validReportReason(reason string) bool {
+	switch reason {
+	case "spam", "harassment", "impersonation", "illegal", "other":
+		return true
+	}
+	return false
+}
+
+// reputationRow is a stored record.
+type reputationRow struct {
+	ReputationRecord
+	Received int64 `json:"received"` // unix millis
+}
+
+// reputationSubject summarises the records about one peer for the admin page.
+type reputationSubject struct {
+	Subject         string          `json:"subject"`
+	Name            string          `json:"name,omitempty"`
+	Reporters       int             `json:"reporters"`
+	Reports         int             `json:"reports"`
+	Reasons         map[string]int  `json:"reasons,omitempty"`
+	Denied          int             `json:"denied"`
+	Refused         int             `json:"refused"`
+	MQPeakPerMinute int             `json:"mq_peak_per_minute"` // highest seen by any reporter
+	Latest          int64           `json:"latest"`             // unix millis
+	Records         []reputationRow `json:"records"`
+}
+
+// reputationQueue keeps the latest record per subject and reporter.
+type reputationQueue struct {
+	mu   sync.Mutex
+	rows map[string]reputationRow // subject + "\n" + reporter
+}
+
+func newReputationQueue() *reputationQueue {
+	return &reputationQueue{rows: map[string]reputationRow{}}
+}
+
+func reputationKey(subject, reporter string) string { return subject + "\n" + reporter }
+
+func (q *reputationQueue) load(rows []reputationRow) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, row := range rows {
+		q.rows[reputationKey(row.Subject, row.Reporter)] = row
+	}
+}
+
+// put stores row, dropping expired records and, when the queue is full,
+// the oldest one. It returns the records dropped.
+func (q *reputationQueue) put(row reputationRow, now time.Time) (dropped []reputationRow) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := reputationKey(row.Subject, row.Reporter)
+	cutoff := now.Add(-ReputationMaxAge).UnixMilli()
+	for k, r := range q.rows {
+		if r.Received < cutoff {
+			dropped = append(dropped, r)
+			delete(q.rows, k)
+		}
+	}
+	if _, seen := q.rows[key]; !seen && len(q.rows) >= ReputationMaxRecords {
+		var oldest string
+		for k, r := range q.rows {
+			if oldest == "" || r.Received < q.rows[oldest].Received {
+				oldest = k
+			}
+		}
+		dropped = append(dropped, q.rows[oldest])
+		delete(q.rows, oldest)
+	}
+	q.rows[key] = row
+	return dropped
+}
+
+// dismiss drops every record about subject and returns how many there were.
+func (q *reputationQueue) dismiss(subject string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for k, r := range q.rows {
+		if r.Subject == subject {
+			delete(q.rows, k)
+			n++
+		}
+	}
+	return n
+}
+
+// subjects groups the records by subject, most reporters first.
+func (q *reputationQueue) subjects(name func(string) string) []reputationSubject {
+	q.mu.Lock()
+	by := map[string]*reputationSubject{}
+	for _, r := range q.rows {
+		s := by[r.Subject]
+		if s == nil {
+			s = &reputationSubject{Subject: r.Subject}
+			by[r.Subject] = s
+		}
+		s.Records = append(s.Records, r)
+	}
+	q.mu.Unlock()
+
+	out := make([]reputationSubject, 0, len(by))
+	for _, s := range by {
+		s.Name = name(s.Subject)
+		s.Reporters = len(s.Records)
+		for _, r := range s.Records {
+			s.Reports += len(r.Reports)
+			for _, rep := range r.Reports {
+				if s.Reasons == nil {
+					s.Reasons = map[string]int{}
+				}
+				s.Reasons[rep.Reason]++
+			}
+			s.Denied += r.Denied
+			s.Refused += r.Refused
+			s.MQPeakPerMinute = max(s.MQPeakPerMinute, r.MQPeakPerMinute)
+			s.Latest = max(s.Latest, r.Received)
+		}
+		sort.Slice(s.Records, func(i, j int) bool { return s.Records[i].Received > s.Records[j].Received })
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Reporters != out[j].Reporters {
+			return out[i].Reporters > out[j].Reporters
+		}
+		return out[i].Latest > out[j].Latest
+	})
+	return out
+}
+
+// checkReputation checks a submitted record. Oversized ones are refused
+// rather than trimmed, so a stored record still verifies.
+func checkReputation(rec ReputationRecord, now time.Time) error {
+	if _, err := peer.Decode(rec.Subject); err != nil {
+		return errors.New("invalid subject")
+	}
+	if rec.Subject == rec.Reporter {
+		return errors.New("a peer cannot report itself")
+	}
+	if err := rec.Verify(); err != nil {
+		return err
+	}
+	if rec.Until > now.Add(ReputationClockSkew).UnixMilli() || rec.Since > rec.Until {
+		return errors.New("bad time window")
+	}
+	if rec.Streams < 0 || rec.Denied < 0 || rec.Refused < 0 || rec.MQMessages < 0 || rec.MQPeakPerMinute < 0 {
+		return errors.New("negative count")
+	}
+	if len(rec.Reports) > ReputationMaxReports {
+		return fmt.Errorf("more than %d reports", ReputationMaxReports)
+	}
+	for _, r := range rec.Reports {
+		if !validReportReason(r.Reason) {
+			return fmt.Errorf("unknown report reason %q", r.Reason)
+		}
+		if !utf8.ValidString(r.Note) || utf8.RuneCountInString(r.Note) > ReputationMaxNote {
+			return fmt.Errorf("report note longer than %d characters or not UTF-8", ReputationMaxNote)
+		}
+	}
+	return nil
+}
+
+// handleReputation accepts a ReputationRecord from a peer. The reporter
+// must be connected to this rendezvous; records are rate limited like
+// /publish.
+func (s *Server) handleReputation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowPublish(extractIP(r.RemoteAddr)) {
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+	var rec ReputationRecord
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&rec); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if err := checkReputation(rec, now); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	_, known := s.peers[rec.Reporter]
+	s.mu.Unlock()
+	if !known {
+		http.Error(w, "reporter not connected", http.StatusForbidden)
+		return
+	}
+
+	row := reputationRow{ReputationRecord: rec, Received: now.UnixMilli()}
+	dropped := s.reputation.put(row, now)
+	if s.peerDB != nil {
+		s.peerDB.upsertReputation(row)
+		for _, d := range dropped {
+			s.peerDB.deleteReputation(d.Subject, d.Reporter)
+		}
+	}
+	s.addLog(fmt.Sprintf("reputation: %s reported %s (%d reports)", shortID(rec.Reporter), shortID(rec.Subject), len(rec.Reports)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleReputationJSON lists the reported peers (GET) and dismisses the
+// records about one (DELETE ?subject=).
+func (s *Server) handleReputationJSON(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleModerator) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		names := make(map[string]string, len(s.peers))
+		for id, p := range s.peers {
+			names[id] = p.Content
+		}
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(s.reputation.subjects(func(id string) string { return names[id] }))
+
+	case http.MethodDelete:
+		subject := r.URL.Query().Get("subject")
+		n := s.reputation.dismiss(subject)
+		if n == 0 {
+			http.Error(w, "no records about this peer", http.StatusNotFound)
+			return
+		}
+		if s.peerDB != nil {
+			s.peerDB.deleteReputation(subject, "")
+		}
+		who, _ := s.adminIdentity(r)
+		log.Printf("admin: %s dismissed %d reputation records about %s", who, n, subject)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
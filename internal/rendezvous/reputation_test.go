@@ -0,0 +1,104 @@
+package rendezvous
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func newIdentity(t *testing.T) (crypto.PrivKey, string) {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id.String()
+}
+
+func signedRecord(t *testing.T, priv crypto.PrivKey, rec ReputationRecord) ReputationRecord {
+	t.Helper()
+	sig, err := priv.Sign(rec.SigningBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Sig = sig
+	return rec
+}
+
+func TestCheckReputation(t *testing.T) {
+	priv, reporter := newIdentity(t)
+	_, subject := newIdentity(t)
+	now := time.Now()
+	base := ReputationRecord{
+		Subject: subject, Reporter: reporter, Since: now.Add(-time.Hour).UnixMilli(), Until: now.UnixMilli(),
+		Streams: 40, MQMessages: 35, MQPeakPerMinute: 30,
+		Reports: []ReputationReport{{Reason: "spam", Note: "floods the chat room", At: now.UnixMilli()}},
+	}
+	if err := checkReputation(signedRecord(t, priv, base), now); err != nil {
+		t.Fatalf("valid record: %v", err)
+	}
+
+	tampered := signedRecord(t, priv, base)
+	tampered.MQPeakPerMinute = 300
+	if err := checkReputation(tampered, now); err == nil {
+		t.Fatal("tampered record accepted")
+	}
+
+	for name, edit := range map[string]func(*ReputationRecord){
+		"self report": func(r *ReputationRecord) { r.Subject = reporter },
+		"future":      func(r *ReputationRecord) { r.Until = now.Add(time.Hour).UnixMilli() },
+		"bad reason":  func(r *ReputationRecord) { r.Reports = []ReputationReport{{Reason: "<b>"}} },
+		"long note": func(r *ReputationRecord) {
+			r.Reports = []ReputationReport{{Reason: "other", Note: strings.Repeat("x", ReputationMaxNote+1)}}
+		},
+		"negative":     func(r *ReputationRecord) { r.Denied = -1 },
+		"many reports": func(r *ReputationRecord) { r.Reports = make([]ReputationReport, ReputationMaxReports+1) },
+	} {
+		rec := base
+		edit(&rec)
+		if err := checkReputation(signedRecord(t, priv, rec), now); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestReputationQueue(t *testing.T) {
+	q := newReputationQueue()
+	now := time.Now()
+	at := func(d time.Duration) int64 { return now.Add(d).UnixMilli() }
+
+	q.put(reputationRow{ReputationRecord: ReputationRecord{Subject: "s1", Reporter: "a", Denied: 2,
+		Reports: []ReputationReport{{Reason: "spam"}}}, Received: at(-time.Hour)}, now)
+	q.put(reputationRow{ReputationRecord: ReputationRecord{Subject: "s1", Reporter: "b", MQPeakPerMinute: 90,
+		Reports: []ReputationReport{{Reason: "spam"}, {Reason: "harassment"}}}, Received: at(0)}, now)
+	q.put(reputationRow{ReputationRecord: ReputationRecord{Subject: "s2", Reporter: "a"}, Received: at(0)}, now)
+	// A newer record from the same reporter replaces the older.
+	q.put(reputationRow{ReputationRecord: ReputationRecord{Subject: "s1", Reporter: "a", Denied: 5,
+		Reports: []ReputationReport{{Reason: "spam"}}}, Received: at(0)}, now)
+
+	subjects := q.subjects(func(id string) string { return map[string]string{"s1": "Spammer"}[id] })
+	if len(subjects) != 2 || subjects[0].Subject != "s1" {
+		t.Fatalf("subjects = %+v", subjects)
+	}
+	s1 := subjects[0]
+	if s1.Name != "Spammer" || s1.Reporters != 2 || s1.Reports != 3 || s1.Reasons["spam"] != 2 || s1.Denied != 5 || s1.MQPeakPerMinute != 90 {
+		t.Fatalf("s1 = %+v", s1)
+	}
+
+	if n := q.dismiss("s1"); n != 2 {
+		t.Fatalf("dismissed %d", n)
+	}
+	dropped := q.put(reputationRow{ReputationRecord: ReputationRecord{Subject: "s3", Reporter: "a"}, Received: at(ReputationMaxAge)},
+		now.Add(ReputationMaxAge+time.Minute))
+	if len(dropped) != 1 || dropped[0].Subject != "s2" {
+		t.Fatalf("dropped = %+v", dropped)
+	}
+}
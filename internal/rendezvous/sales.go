@@ -0,0 +1,233 @@
+package rendezvous
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// salesLedger mirrors the template purchases recorded by the credits
+// service. It is filled by the reconciliation job and only ever grows;
+// the credits service stays the source of truth.
+type salesLedger struct {
+	mu        sync.RWMutex
+	purchases []creditPurchase
+	lastID    int64
+	syncedAt  time.Time
+}
+
+// add appends purchases not seen before and returns how many were new.
+func (l *salesLedger) add(ps []creditPurchase) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, p := range ps {
+		if p.ID <= l.lastID {
+			continue
+		}
+		l.purchases = append(l.purchases, p)
+		l.lastID = p.ID
+		n++
+	}
+	l.syncedAt = time.Now()
+	return n
+}
+
+func (l *salesLedger) since() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lastID
+}
+
+// salesRow is one line of the sales report: a template's sales in a period.
+type salesRow struct {
+	Period      string `json:"period"`
+	Template    string `json:"template"`
+	Author      string `json:"author"`
+	Sales       int    `json:"sales"`
+	Credits     int    `json:"credits"`
+	AuthorShare int    `json:"author_share"`
+}
+
+// salesPeriod formats a purchase time as its reporting period.
+func salesPeriod(ts int64, by string) string {
+	t := time.UnixMilli(ts).UTC()
+	switch by {
+	case "day":
+		return t.Format("2006-01-02")
+	case "year":
+		return t.Format("2006")
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+// report aggregates purchases in [from, to) per period and template.
+// Zero bounds are open. authors maps template dir to author; sharePct is
+// the author's percentage of the credits collected.
+func (l *salesLedger) report(by string, from, to time.Time, template string, authors map[string]string, sharePct int) []salesRow {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	type key struct{ period, template string }
+	rows := map[key]*salesRow{}
+	for _, p := range l.purchases {
+		t := time.UnixMilli(p.TS)
+		if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && !t.Before(to)) {
+			continue
+		}
+		if template != "" && p.Template != template {
+			continue
+		}
+		k := key{salesPeriod(p.TS, by), p.Template}
+		r, ok := rows[k]
+		if !ok {
+			r = &salesRow{Period: k.period, Template: p.Template, Author: authors[p.Template]}
+			rows[k] = r
+		}
+		r.Sales++
+		r.Credits += p.Amount
+	}
+
+	out := make([]salesRow, 0, len(rows))
+	for _, r := range rows {
+		r.AuthorShare = r.Credits * sharePct / 100
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Period != out[j].Period {
+			return out[i].Period > out[j].Period
+		}
+		if out[i].Credits != out[j].Credits {
+			return out[i].Credits > out[j].Credits
+		}
+		return out[i].Template < out[j].Template
+	})
+	return out
+}
+
+// SetAuthorSharePct sets the percentage of credits collected that is
+// reported as owed to a template's author.
+func (s *Server) SetAuthorSharePct(pct int) {
+	s.authorSharePct = pct
+}
+
+// salesEnabled reports whether sales reporting is available: it needs the
+// credits service with an admin token.
+func (s *Server) salesEnabled() bool {
+	cp, ok := s.credits.(*RemoteCreditProvider)
+	return ok && cp.token() != ""
+}
+
+// reconcileSales periodically pulls new purchases from the credits service
+// into the sales ledger.
+func (s *Server) reconcileSales(ctx context.Context) {
+	cp := s.credits.(*RemoteCreditProvider)
+	pull := func() {
+		ps, err := cp.FetchPurchases(s.sales.since())
+		if err != nil {
+			log.Printf("sales: reconcile: %v", err)
+			return
+		}
+		if n := s.sales.add(ps); n > 0 {
+			s.addLog(fmt.Sprintf("Sales: reconciled %d new template purchase(s)", n))
+		}
+	}
+
+	pull()
+	ticker := time.NewTicker(SalesReconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pull()
+		}
+	}
+}
+
+// templateAuthors maps template dirs to their authors from the store.
+func (s *Server) templateAuthors() map[string]string {
+	var list []StoreMeta
+	switch {
+	case s.templates != nil:
+		list, _ = s.templates.FetchTemplates()
+	case s.localTemplates != nil:
+		list = s.localTemplates.List()
+	}
+	authors := make(map[string]string, len(list))
+	for _, m := range list {
+		authors[m.Dir] = m.Author
+	}
+	return authors
+}
+
+// handleSales serves the admin sales report as JSON (/sales.json) or CSV
+// (/sales.csv). Query: by=day|month|year, from/to=YYYY-MM-DD, template=dir.
+func (s *Server) handleSales(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireRole(w, r, roleOperator) {
+		return
+	}
+	if !s.salesEnabled() {
+		http.Error(w, "sales reporting needs the credits service", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "bad from date", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "bad to date", http.StatusBadRequest)
+			return
+		}
+		to = to.AddDate(0, 0, 1) // inclusive
+	}
+
+	rows := s.sales.report(q.Get("by"), from, to, q.Get("template"), s.templateAuthors(), s.authorSharePct)
+
+	if r.URL.Path == "/sales.csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="template-sales.csv"`)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"period", "template", "author", "sales", "credits", "author_share"})
+		for _, row := range rows {
+			_ = cw.Write([]string{row.Period, row.Template, row.Author,
+				strconv.Itoa(row.Sales), strconv.Itoa(row.Credits), strconv.Itoa(row.AuthorShare)})
+		}
+		cw.Flush()
+		return
+	}
+
+	s.sales.mu.RLock()
+	syncedAt := s.sales.syncedAt
+	s.sales.mu.RUnlock()
+	var synced int64
+	if !syncedAt.IsZero() {
+		synced = syncedAt.UnixMilli()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"rows":             rows,
+		"author_share_pct": s.authorSharePct,
+		"synced_at":        synced,
+	})
+}
@@ -0,0 +1,56 @@
+package rendezvous
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSalesLedger_AddSkipsSeen(t *testing.T) {
+	var l salesLedger
+	if n := l.add([]creditPurchase{{ID: 1}, {ID: 2}}); n != 2 {
+		t.Fatalf("add = %d, want 2", n)
+	}
+	if n := l.add([]creditPurchase{{ID: 2}, {ID: 3}}); n != 1 {
+		t.Fatalf("add = %d, want 1", n)
+	}
+	if l.since() != 3 {
+		t.Fatalf("since = %d, want 3", l.since())
+	}
+}
+
+func TestSalesLedger_Report(t *testing.T) {
+	jan := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC).UnixMilli()
+	feb := time.Date(2026, 2, 3, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+	var l salesLedger
+	l.add([]creditPurchase{
+		{ID: 1, Template: "blog", Amount: 100, TS: jan},
+		{ID: 2, Template: "blog", Amount: 100, TS: jan},
+		{ID: 3, Template: "shop", Amount: 50, TS: jan},
+		{ID: 4, Template: "blog", Amount: 100, TS: feb},
+	})
+	authors := map[string]string{"blog": "alice"}
+
+	rows := l.report("month", time.Time{}, time.Time{}, "", authors, 70)
+	want := []salesRow{
+		{Period: "2026-02", Template: "blog", Author: "alice", Sales: 1, Credits: 100, AuthorShare: 70},
+		{Period: "2026-01", Template: "blog", Author: "alice", Sales: 2, Credits: 200, AuthorShare: 140},
+		{Period: "2026-01", Template: "shop", Sales: 1, Credits: 50, AuthorShare: 35},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if rows := l.report("year", from, time.Time{}, "", nil, 0); len(rows) != 1 || rows[0].Credits != 100 {
+		t.Errorf("from filter: %+v", rows)
+	}
+	if rows := l.report("month", time.Time{}, time.Time{}, "shop", nil, 0); len(rows) != 1 || rows[0].Template != "shop" {
+		t.Errorf("template filter: %+v", rows)
+	}
+}
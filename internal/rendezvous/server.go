@@ -15,7 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/jobs"
 	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/timeouts"
 	"github.com/petervdpas/goop2/internal/util"
 
 	// Import the generated docs so swag's init() registers the spec.
@@ -74,6 +76,31 @@ type Server struct {
 	relayLogs    []string
 	maxRelayLogs int
 
+	// latest relay health report per peer, see relay_reports.go
+	relayReports *relayReports
+
+	// relayed bytes per peer and day, see relay_usage.go
+	relayUsage *relayUsage
+
+	// email digest subscriptions, see digest.go
+	digests *digests
+
+	// Reputation records submitted by peers, for moderators
+	reputation *reputationQueue
+
+	// banned peer IDs and IP addresses, see bans.go
+	bans *banList
+
+	// store template install progress, see install_sessions.go
+	installs *installSessions
+
+	// cached admin account logins, see admin_auth.go
+	adminAuth *adminAuth
+
+	// failed admin logins per IP and /register limits, see throttle.go
+	loginThrottle *loginThrottle
+	register      *registerGuard
+
 	tmpl         *template.Template
 	adminTmpl    *template.Template
 	docsTmpl     *template.Template
@@ -83,9 +110,12 @@ type Server struct {
 	docsCSS      []byte
 	favicon      []byte
 	splash       []byte
-	docsSite     *DocSite
+	docsMu       sync.RWMutex
+	docsSite     *DocSite // guarded by docsMu; swapped on docs dir reload
+	docsDir      string   // operator Markdown pages, "" = embedded docs only
 
 	peerDB         *peerDB                     // nil when persistence is disabled
+	dbJobs         *jobs.Queue                 // peer DB writes; nil with peerDB
 	credits        CreditProvider              // default: NoCredits{}
 	registration   *RemoteRegistrationProvider // nil = use built-in registration
 	email          *RemoteEmailProvider        // nil = email service not configured
@@ -99,16 +129,43 @@ type Server struct {
 	encryption *RemoteEncryptionProvider // nil = encryption service not configured
 
 	// Circuit relay v2
-	relayHost    host.Host  // nil when relay is disabled
-	relayInfo    *RelayInfo // nil when relay is disabled
-	relayPort    int
-	relayWSPort  int
-	relayKeyFile string
-	relayTiming  RelayTimingConfig
+	relayHost     host.Host  // nil when relay is disabled
+	relayInfo     *RelayInfo // nil when relay is disabled
+	relaySelfTest sync.Mutex // one relay self-test at a time
+	relayPort     int
+	relayWSPort   int
+	relayKeyFile  string
+	relayTiming   RelayTimingConfig
+
+	// STUN listener (UDP), 0 = disabled
+	stunPort int
+
+	// Label policy for published presence, nil = off
+	policy *labelPolicy
+
+	// Public site mirror at /p/<peerID>/, nil = off
+	publicSites *publicSites
+
+	// Operator branding of the pages, nil = built-in look
+	brand *brand
+
+	// Template sales mirrored from the credits service for the admin report
+	sales          salesLedger
+	authorSharePct int
 
 	// per-IP rate limiter for /publish
-	rateMu     sync.Mutex
-	rateWindow map[string]*rateBucket
+	rateMu       sync.Mutex
+	rateWindow   map[string]*rateBucket
+	rateRejected int64 // requests turned away, for /metrics
+
+	// serve GET /metrics, see metrics.go
+	metricsOn bool
+
+	// presence aggregation toward an upstream rendezvous, see aggregator.go; nil = off
+	agg *aggregator
+
+	// presence shared with other rendezvous servers, see federation.go; nil = off
+	fed *federation
 
 	// punch hint cooldowns: prevents spamming hole-punch attempts for the same peer pair
 	punchCooldowns map[[2]string]time.Time
@@ -144,6 +201,7 @@ type peerRow struct {
 	BytesReceived       int64    `json:"bytes_received"`
 	Verified            bool     `json:"verified"`
 	WSConnected         bool     `json:"ws_connected,omitempty"`
+	Home                string   `json:"home,omitempty"` // URL of the peer's home server when federated or aggregated, "" = here
 
 	// Internal-only: stored server-side, never broadcast to peers.
 	verificationToken string
@@ -218,12 +276,19 @@ type adminServiceRow struct {
 
 type adminVM struct {
 	Title            string
+	User             string // who is logged in
+	Role             string
+	CanModerate      bool
+	CanOperate       bool
+	HasAccess        bool // operator with a peer DB: accounts and API tokens
 	PeerCount        int
 	Peers            []peerRow
 	Now              string
 	HasCredits       bool
 	HasRegistrations bool
 	HasAccounts      bool
+	HasSales         bool
+	HasPublishing    bool
 	HasRelay         bool
 	RelayPeerID      string
 	RelayPort        int
@@ -277,6 +342,10 @@ func New(addr string, peerDBPath string, adminPassword string, externalURL strin
 		},
 	}
 
+	for name, fn := range brandFuncs(nil) {
+		funcs[name] = fn
+	}
+
 	tmpl, err := template.New("index.html").Funcs(funcs).ParseFS(embedded, "assets/index.html")
 	if err != nil {
 		panic(err)
@@ -337,6 +406,15 @@ func New(addr string, peerDBPath string, adminPassword string, externalURL strin
 		maxLogs:        500,
 		relayLogs:      make([]string, 0, 500),
 		maxRelayLogs:   500,
+		relayReports:   newRelayReports(),
+		relayUsage:     newRelayUsage(),
+		digests:        newDigests(),
+		reputation:     newReputationQueue(),
+		bans:           newBanList(),
+		installs:       newInstallSessions(),
+		adminAuth:      newAdminAuth(),
+		loginThrottle:  newLoginThrottle(),
+		register:       newRegisterGuard(),
 		tmpl:           tmpl,
 		adminTmpl:      adminTmpl,
 		docsTmpl:       docsTmpl,
@@ -355,6 +433,7 @@ func New(addr string, peerDBPath string, adminPassword string, externalURL strin
 		punchCooldowns: map[[2]string]time.Time{},
 		wsClients:      map[string]*wsClient{},
 	}
+	s.relayUsage.banned = s.bans.relayBanned
 
 	// Open peer DB if path provided (for multi-instance persistence)
 	if peerDBPath != "" {
@@ -363,6 +442,7 @@ func New(addr string, peerDBPath string, adminPassword string, externalURL strin
 			log.Printf("WARNING: peer DB open failed: %v (running in-memory only)", err)
 		} else {
 			s.peerDB = db
+			s.dbJobs = jobs.New(PeerDBWorkers, 0)
 		}
 	}
 
@@ -468,10 +548,16 @@ func (s *Server) SetEncryptionProvider(ep *RemoteEncryptionProvider) {
 	s.encryption = ep
 }
 
+// SetSTUNPort enables the built-in STUN listener on the given UDP port.
+// Its URL is advertised to peers through /relay when the relay is enabled.
+func (s *Server) SetSTUNPort(port int) {
+	s.stunPort = port
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	// Start circuit relay v2 host if configured
 	if s.relayPort > 0 {
-		rh, ri, err := StartRelay(s.relayPort, s.relayWSPort, s.relayKeyFile, s.externalURL, s.relayAddLog)
+		rh, ri, err := StartRelay(s.relayPort, s.relayWSPort, s.relayKeyFile, s.externalURL, s.relayUsage, s.relayAddLog)
 		if err != nil {
 			return fmt.Errorf("start relay: %w", err)
 		}
@@ -558,6 +644,23 @@ func (s *Server) Start(ctx context.Context) error {
 		}()
 	}
 
+	// Start STUN listener if configured
+	if s.stunPort > 0 {
+		if _, err := StartSTUN(ctx, s.stunPort); err != nil {
+			return fmt.Errorf("start stun: %w", err)
+		}
+		if s.relayInfo != nil {
+			s.relayInfo.STUNURLs = stunURLs(s.externalURL, s.stunPort)
+		}
+	}
+
+	// Reload operator docs when their directory changes
+	if s.docsDir != "" {
+		if err := s.watchDocsDir(ctx); err != nil {
+			log.Printf("docs: %v (hot reload disabled)", err)
+		}
+	}
+
 	// Load existing peers from SQLite on startup
 	if s.peerDB != nil {
 		s.loadPeersFromDB()
@@ -571,6 +674,51 @@ func (s *Server) Start(ctx context.Context) error {
 		go s.syncFromDB(ctx)
 	}
 
+	// Email digests for offline peers
+	if s.peerDB != nil {
+		if rows, err := s.peerDB.loadDigests(); err != nil {
+			log.Printf("digest: load subscriptions: %v", err)
+		} else {
+			s.digests.load(rows)
+		}
+	}
+	if s.digestsEnabled() {
+		go s.runDigests(ctx)
+	}
+
+	// Bans survive restarts when the peer DB is enabled
+	if s.peerDB != nil {
+		if rows, err := s.peerDB.loadBans(); err != nil {
+			log.Printf("bans: load: %v", err)
+		} else {
+			s.bans.load(rows)
+		}
+	}
+
+	// Reputation records survive restarts when the peer DB is enabled
+	if s.peerDB != nil {
+		if rows, err := s.peerDB.loadReputation(); err != nil {
+			log.Printf("reputation: load records: %v", err)
+		} else {
+			s.reputation.load(rows)
+		}
+	}
+
+	// Presence aggregation toward an upstream rendezvous
+	if s.agg != nil {
+		go s.runAggregator(ctx)
+	}
+
+	// Presence federation with other rendezvous servers
+	if s.fed != nil {
+		go s.runFederation(ctx)
+	}
+
+	// Reconcile template sales against the credits service
+	if s.salesEnabled() {
+		go s.reconcileSales(ctx)
+	}
+
 	mux := http.NewServeMux()
 
 	// Public endpoints
@@ -578,6 +726,8 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.HandleFunc("/assets/style.css", s.handleStyle)
 	mux.HandleFunc("/assets/docs.css", s.handleDocsCSS)
 	mux.HandleFunc("/favicon.ico", s.handleFavicon)
+	mux.HandleFunc("/assets/brand-logo", s.handleBrandLogo)
+	mux.HandleFunc("/assets/brand.css", s.handleBrandCSS)
 	mux.HandleFunc("/assets/goop2-splash.jpg", s.handleSplash)
 	if vendorFS, err := fs.Sub(embedded, "assets/vendor"); err == nil {
 		mux.Handle("/assets/vendor/", http.StripPrefix("/assets/vendor/", http.FileServerFS(vendorFS)))
@@ -601,14 +751,20 @@ func (s *Server) Start(ctx context.Context) error {
 			"templates":    s.templates != nil,
 			"bridge":       s.bridge != nil,
 			"relay":        s.relayHost != nil,
+			"public_sites": s.publicSites != nil && s.relayHost != nil,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(caps)
 	})
 
 	// Relay info endpoint (returns 404 when relay is disabled)
+	// An aggregator without a relay of its own hands out the upstream's.
 	mux.HandleFunc("/relay", func(w http.ResponseWriter, r *http.Request) {
-		handleRelayInfo(w, r, s.relayInfo)
+		info := s.signedRelayInfo()
+		if info == nil && s.agg != nil {
+			info = s.agg.relayInfo()
+		}
+		handleRelayInfo(w, r, info)
 	})
 
 	// Bridge endpoints (proxied to bridge service)
@@ -626,11 +782,33 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.HandleFunc("/peers.json", s.handlePeersJSON)
 	mux.HandleFunc("/logs.json", s.handleLogsJSON)
 	mux.HandleFunc("/relay-status.json", s.handleRelayStatusJSON)
+	mux.HandleFunc("/relay-usage.json", s.handleRelayUsageJSON)
+	mux.HandleFunc("/admin/relay-selftest", s.handleRelaySelfTest)
+	mux.HandleFunc("/federation.json", s.handleFederationJSON)
+	if s.metricsOn {
+		mux.HandleFunc("/metrics", s.handleMetrics)
+	}
 	mux.HandleFunc("/registrations.json", s.handleRegistrationsJSON)
 	mux.HandleFunc("/accounts.json", s.handleAccountsJSON)
+	mux.HandleFunc("/sales.json", s.handleSales)
+	mux.HandleFunc("/sales.csv", s.handleSales)
 	mux.HandleFunc("/api/services/logs", s.handleServiceLogs)
 	mux.HandleFunc("/diag", s.handleDiagPeer)
+	mux.HandleFunc("/admin-users.json", s.handleAdminUsersJSON)
+	mux.HandleFunc("/api-tokens.json", s.handleAPITokensJSON)
+	mux.HandleFunc("/published-templates.json", s.handlePublishedTemplatesJSON)
 	mux.HandleFunc("/api/pulse", s.handlePulse)
+	mux.HandleFunc("/api/relay-report", s.handleRelayReport)
+	mux.HandleFunc("/api/reputation", s.handleReputation)
+	mux.HandleFunc("/reputation.json", s.handleReputationJSON)
+	mux.HandleFunc("/admin/ban", s.handleBan)
+	mux.HandleFunc("/admin/unban", s.handleUnban)
+	mux.HandleFunc("/bans.json", s.handleBansJSON)
+	mux.HandleFunc("/status.json", s.handlePublicStatus)
+	mux.HandleFunc("/api/digest/prefs", s.handleDigestPrefs)
+	mux.HandleFunc("/api/digest/event", s.handleDigestEvent)
+	mux.HandleFunc("/digest/unsubscribe", s.handleDigestUnsubscribe)
+	mux.HandleFunc("/widget.js", s.handleWidget)
 
 	// Registration endpoints
 	if s.registration != nil {
@@ -689,6 +867,10 @@ func (s *Server) Start(ctx context.Context) error {
 
 		ch := make(chan []byte, 64)
 		remoteIP := extractIP(r.RemoteAddr)
+		if s.bans.ipBanned(remoteIP) {
+			http.Error(w, "banned", http.StatusForbidden)
+			return
+		}
 		if err := s.addClient(ch, remoteIP); err != nil {
 			http.Error(w, err.Error(), http.StatusTooManyRequests)
 			return
@@ -699,7 +881,7 @@ func (s *Server) Start(ctx context.Context) error {
 		_, _ = w.Write([]byte(": ok\n\n"))
 		flusher.Flush()
 
-		heartbeat := time.NewTicker(WSHeartbeatInterval)
+		heartbeat := time.NewTicker(timeouts.Get().SSEHeartbeat)
 		defer heartbeat.Stop()
 
 		for {
@@ -709,10 +891,16 @@ func (s *Server) Start(ctx context.Context) error {
 			case <-ctx.Done():
 				return
 			case <-heartbeat.C:
+				if s.bans.ipBanned(remoteIP) {
+					return
+				}
 				// keep-alive comment
 				_, _ = w.Write([]byte(": ping\n\n"))
 				flusher.Flush()
 			case b := <-ch:
+				if s.bans.ipBanned(remoteIP) {
+					return
+				}
 				// SSE "data:" line(s)
 				_, _ = w.Write([]byte("data: "))
 				_, _ = w.Write(b)
@@ -731,6 +919,10 @@ func (s *Server) Start(ctx context.Context) error {
 
 		// Per-IP rate limiting: 60 requests per minute
 		ip := extractIP(r.RemoteAddr)
+		if s.bans.ipBanned(ip) {
+			http.Error(w, "banned", http.StatusForbidden)
+			return
+		}
 		if !s.allowPublish(ip) {
 			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
 			return
@@ -743,50 +935,26 @@ func (s *Server) Start(ctx context.Context) error {
 			return
 		}
 
-		if err := validatePresence(pm); err != nil {
-			http.Error(w, "bad message: "+err.Error(), http.StatusBadRequest)
+		if status, err := s.checkPresence(&pm); err != nil {
+			http.Error(w, err.Error(), status)
 			return
 		}
-
-		// Check registration if the registration service requires it
-		isRegistered := true
-		if s.registration != nil && s.registration.RegistrationRequired() {
-			if pm.Email == "" || pm.VerificationToken == "" {
-				isRegistered = false
-			} else {
-				isRegistered = s.registration.IsEmailTokenValid(pm.Email, pm.VerificationToken)
-			}
-		}
-
-		// Save token server-side before stripping from broadcast message
-		peerToken := pm.VerificationToken
-		pm.VerificationToken = ""
-
-		// normalize timestamp if caller didn't set it
-		if pm.TS == 0 {
-			pm.TS = proto.NowMillis()
-		}
-
-		// Annotate with server-side verification status
-		pm.Verified = isRegistered
-
-		// Calculate message size for tracking
-		b, _ := json.Marshal(pm)
-		msgSize := int64(len(b))
-
-		// update peer snapshot for / and /peers.json
-		// Always store and broadcast — mark unverified peers
-		addrsChanged := s.upsertPeer(pm, msgSize, isRegistered, peerToken)
+		isRegistered := s.acceptPresence(pm)
 		s.addLog(fmt.Sprintf("Received %s from %s: %q (verified=%v)", pm.Type, pm.PeerID, pm.Content, isRegistered))
-		s.broadcast(b)
-
-		if pm.Type == proto.TypeOnline || pm.Type == proto.TypeUpdate {
-			s.emitPunchHints(pm, addrsChanged)
-		}
 
 		w.WriteHeader(http.StatusNoContent)
 	})
 
+	// Presence of several peers at once, from an aggregator
+	mux.HandleFunc("/publish/batch", s.handlePublishBatch)
+
+	// Federation: presence forwarded by other rendezvous servers
+	mux.HandleFunc("/federation/presence", s.handleFederationPresence)
+	mux.HandleFunc("/federation/info", s.handleFederationInfo)
+
+	// Public site mirror (opt-in per peer)
+	s.registerPublicSiteRoutes(mux)
+
 	// Store page
 	mux.HandleFunc("/store", s.handleStore)
 
@@ -800,7 +968,9 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Template store API — proxy to remote templates service
 	if s.templates != nil {
-		s.templates.RegisterRoutes(mux) // /api/templates/prices (exact match, with auth)
+		s.templates.RegisterRoutes(mux, func(w http.ResponseWriter, r *http.Request) bool {
+			return s.requireRole(w, r, roleOperator)
+		}) // /api/templates/prices (exact match, with auth)
 		proxy := s.templates.Proxy()
 		mux.HandleFunc("/api/templates", func(w http.ResponseWriter, r *http.Request) {
 			// Gate listing: require verified email when registration is enabled.
@@ -827,6 +997,13 @@ func (s *Server) Start(ctx context.Context) error {
 		// Local template store — no pricing, no registration gating
 		mux.HandleFunc("/api/templates", s.handleLocalTemplateList)
 		mux.HandleFunc("/api/templates/", s.handleLocalTemplateRoutes)
+		if s.localTemplates.Publishing() {
+			mux.HandleFunc("/api/templates/publish", s.handleTemplatePublish)
+		}
+	}
+	if s.templates != nil || s.localTemplates != nil {
+		mux.HandleFunc("/api/templates/installs", s.handleInstallCreate)
+		mux.HandleFunc("/api/templates/installs/", s.handleInstallSession)
 	}
 
 	s.srv = &http.Server{
@@ -842,6 +1019,8 @@ func (s *Server) Start(ctx context.Context) error {
 		defer cancel()
 		_ = s.srv.Shutdown(shctx)
 		if s.peerDB != nil {
+			_ = s.dbJobs.Drain(shctx)
+			s.dbJobs.Close()
 			_ = s.peerDB.close()
 		}
 	}()
@@ -994,30 +1173,6 @@ func (s *Server) relayAddLog(msg string) {
 	log.Printf("relay: %s", msg)
 }
 
-// isAdmin returns true if the request carries valid admin Basic Auth credentials.
-func (s *Server) isAdmin(r *http.Request) bool {
-	if s.adminPassword == "" {
-		return false
-	}
-	user, pass, ok := r.BasicAuth()
-	return ok && user == "admin" && pass == s.adminPassword
-}
-
-// requireAdmin checks HTTP Basic Auth. Returns true if authorized.
-func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
-	if s.adminPassword == "" {
-		http.Error(w, "admin panel disabled", http.StatusForbidden)
-		return false
-	}
-	user, pass, ok := r.BasicAuth()
-	if !ok || user != "admin" || pass != s.adminPassword {
-		w.Header().Set("WWW-Authenticate", `Basic realm="Goop2 Admin"`)
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return false
-	}
-	return true
-}
-
 // allowPublish checks the per-IP sliding window rate limit (60 req/min).
 func (s *Server) allowPublish(ip string) bool {
 	window := PublishRateLimitWindow
@@ -1044,6 +1199,7 @@ func (s *Server) allowPublish(ip string) bool {
 	}
 
 	if bucket.count >= rateBucketCap {
+		s.rateRejected++
 		return false
 	}
 
@@ -2,6 +2,8 @@ package rendezvous
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/proto"
 	"github.com/petervdpas/goop2/internal/util"
 
 	"github.com/libp2p/go-libp2p/core/network"
@@ -22,7 +25,7 @@ func (s *Server) handlePeersJSON(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleViewer) {
 		return
 	}
 	w.Header().Set("content-type", "application/json; charset=utf-8")
@@ -34,7 +37,7 @@ func (s *Server) handleLogsJSON(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleViewer) {
 		return
 	}
 	s.logMu.Lock()
@@ -65,8 +68,10 @@ func dirString(d network.Direction) string {
 }
 
 type relayStatusJSON struct {
-	Peers []relayPeerJSON `json:"peers"`
-	Logs  []string        `json:"logs"`
+	Peers   []relayPeerJSON  `json:"peers"`
+	Logs    []string         `json:"logs"`
+	Reports []relayReportRow `json:"reports"`
+	Health  relayHealthJSON  `json:"health"`
 }
 
 func (s *Server) handleRelayStatusJSON(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +79,7 @@ func (s *Server) handleRelayStatusJSON(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleViewer) {
 		return
 	}
 
@@ -104,6 +109,8 @@ func (s *Server) handleRelayStatusJSON(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	result.Reports, result.Health = s.relayReports.snapshot(time.Now())
+
 	s.relayLogMu.Lock()
 	result.Logs = make([]string, len(s.relayLogs))
 	copy(result.Logs, s.relayLogs)
@@ -114,14 +121,15 @@ func (s *Server) handleRelayStatusJSON(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleDiagPeer queries a peer's diagnostic info via the relay host connection.
-// Admin-only. The relay host opens a /goop/diag/1.0.0 stream to the peer and
-// returns the diagnostic snapshot.
+// Admin-only. The relay host opens a /goop/diag/1.0.0 stream to the peer,
+// writes a request signed with the relay key, and returns the snapshot.
+// Peers must opt in; ?scope=full asks for logs, the default is connectivity.
 func (s *Server) handleDiagPeer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleOperator) {
 		return
 	}
 	if s.relayHost == nil {
@@ -172,7 +180,17 @@ func (s *Server) handleDiagPeer(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), DiagStreamTimeout)
 	defer cancel()
 
-	stream, err := s.relayHost.NewStream(ctx, pid, protocol.ID("/goop/diag/1.0.0"))
+	scope := r.URL.Query().Get("scope")
+	if scope != proto.DiagScopeFull {
+		scope = proto.DiagScopeConnectivity
+	}
+	req, err := s.signDiagRequest(peerIDStr, scope)
+	if err != nil {
+		http.Error(w, "sign request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stream, err := s.relayHost.NewStream(ctx, pid, protocol.ID(proto.DiagProtoID))
 	if err != nil {
 		// Peer doesn't support the protocol or stream failed.
 		// Return what we know from the relay side.
@@ -188,6 +206,8 @@ func (s *Server) handleDiagPeer(w http.ResponseWriter, r *http.Request) {
 	}
 	defer stream.Close()
 
+	_ = json.NewEncoder(stream).Encode(req)
+
 	// Read the peer's diagnostic snapshot.
 	var peerDiag map[string]any
 	if err := json.NewDecoder(io.LimitReader(stream, 64*1024)).Decode(&peerDiag); err != nil {
@@ -202,6 +222,28 @@ func (s *Server) handleDiagPeer(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(peerDiag)
 }
 
+// signDiagRequest builds a /goop/diag request for peerID signed with the relay host key.
+func (s *Server) signDiagRequest(peerID, scope string) (proto.DiagRequest, error) {
+	nonce := make([]byte, 12)
+	_, _ = rand.Read(nonce)
+	req := proto.DiagRequest{
+		Peer:  peerID,
+		Scope: scope,
+		TS:    time.Now().UnixMilli(),
+		Nonce: hex.EncodeToString(nonce),
+	}
+	key := s.relayHost.Peerstore().PrivKey(s.relayHost.ID())
+	if key == nil {
+		return req, fmt.Errorf("relay key unavailable")
+	}
+	sig, err := key.Sign(req.SigningBytes())
+	if err != nil {
+		return req, err
+	}
+	req.Sig = sig
+	return req, nil
+}
+
 // handlePulse tells a target peer to refresh its relay reservation.
 // Any peer can request this — when they can't reach a target peer, they
 // call POST /api/pulse?peer=<id> and the rendezvous opens a relay-refresh
@@ -277,7 +319,7 @@ func (s *Server) handleServiceLogs(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleViewer) {
 		return
 	}
 
@@ -81,11 +81,12 @@ func (s *Server) handleExecutorAPISpec(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDocsRedirect(w http.ResponseWriter, r *http.Request) {
-	if len(s.docsSite.Pages) == 0 {
+	site := s.docs()
+	if len(site.Pages) == 0 {
 		http.NotFound(w, r)
 		return
 	}
-	http.Redirect(w, r, "/docs/"+s.docsSite.Pages[0].Slug, http.StatusFound)
+	http.Redirect(w, r, "/docs/"+site.Pages[0].Slug, http.StatusFound)
 }
 
 func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
@@ -100,7 +101,8 @@ func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	page, ok := s.docsSite.BySlug[slug]
+	site := s.docs()
+	page, ok := site.BySlug[slug]
 	if !ok {
 		http.NotFound(w, r)
 		return
@@ -108,13 +110,13 @@ func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
 
 	// Find prev/next pages.
 	var prev, next *DocPage
-	for i, p := range s.docsSite.Pages {
+	for i, p := range site.Pages {
 		if p.Slug == slug {
 			if i > 0 {
-				prev = &s.docsSite.Pages[i-1]
+				prev = &site.Pages[i-1]
 			}
-			if i < len(s.docsSite.Pages)-1 {
-				next = &s.docsSite.Pages[i+1]
+			if i < len(site.Pages)-1 {
+				next = &site.Pages[i+1]
 			}
 			break
 		}
@@ -123,7 +125,7 @@ func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", "text/html; charset=utf-8")
 	_ = s.docsTmpl.Execute(w, docsVM{
 		Title:   page.Title,
-		Pages:   s.docsSite.Pages,
+		Pages:   site.Pages,
 		Current: page,
 		Prev:    prev,
 		Next:    next,
@@ -159,12 +161,12 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("content-type", "text/html; charset=utf-8")
 	_ = s.tmpl.Execute(w, indexVM{
-		Title:                "Goop² Rendezvous",
+		Title:                s.brandTitle("Goop² Rendezvous"),
 		Endpoint:             s.URL(),
 		ConnectURLs:          s.connectURLs(),
 		HasStore:             hasStore,
 		StoreCount:           storeCount,
-		HasAdmin:             s.adminPassword != "",
+		HasAdmin:             s.adminEnabled(),
 		RegistrationRequired: regRequired,
 		HasCredits:           hasCredits,
 		RegistrationCredits:  s.grantAmount(),
@@ -221,10 +223,10 @@ func (s *Server) handleStore(w http.ResponseWriter, r *http.Request) {
 	_, hasCredits := s.credits.(*RemoteCreditProvider)
 
 	_ = s.storeTmpl.Execute(w, storeVM{
-		Title:                "Template Store — Goop²",
+		Title:                "Template Store — " + s.brandTitle("Goop²"),
 		Templates:            templates,
 		CreditData:           s.credits.StorePageData(r),
-		HasAdmin:             s.adminPassword != "",
+		HasAdmin:             s.adminEnabled(),
 		HasCredits:           hasCredits,
 		RegistrationRequired: regRequired,
 		RegistrationCredits:  s.grantAmount(),
@@ -236,7 +238,7 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleViewer) {
 		return
 	}
 
@@ -347,12 +349,14 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Only show data panels when the provider is configured AND has an admin token
-	hasRegistrations := s.registration != nil && s.registration.adminToken != ""
+	hasRegistrations := s.registration != nil && s.registration.token() != ""
 	hasAccounts := false
 	if cp, ok := s.credits.(*RemoteCreditProvider); ok {
-		hasAccounts = cp.adminToken != ""
+		hasAccounts = cp.token() != ""
 	}
 
+	user, role := s.adminIdentity(r)
+
 	w.Header().Set("content-type", "text/html; charset=utf-8")
 	relayPeerID := ""
 	if s.relayInfo != nil {
@@ -360,13 +364,20 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_ = s.adminTmpl.Execute(w, adminVM{
-		Title:            "Goop² Admin",
+		Title:            s.brandTitle("Goop²") + " Admin",
+		User:             user,
+		Role:             role.String(),
+		CanModerate:      role >= roleModerator,
+		CanOperate:       role >= roleOperator,
+		HasAccess:        role >= roleOperator && s.peerDB != nil,
 		PeerCount:        len(peers),
 		Peers:            peers,
 		Now:              time.Now().Format("2006-01-02 15:04:05"),
 		HasCredits:       hasCredits,
 		HasRegistrations: hasRegistrations,
 		HasAccounts:      hasAccounts,
+		HasSales:         s.salesEnabled(),
+		HasPublishing:    s.localTemplates != nil && s.localTemplates.Publishing(),
 		HasRelay:         s.relayHost != nil,
 		RelayPeerID:      relayPeerID,
 		RelayPort:        s.relayPort,
@@ -94,9 +94,10 @@ func (s *Server) emitPunchHints(arriving proto.PresenceMsg, addrsChanged bool) {
 	}
 }
 
-// upsertPeer updates the in-memory peer map and persists to peerDB.
+// upsertPeer updates the in-memory peer map and persists to peerDB. home is
+// the URL of the server the peer publishes to, "" for this one.
 // Returns true if the peer is new or its addresses changed (used to gate punch hints).
-func (s *Server) upsertPeer(pm proto.PresenceMsg, msgSize int64, verified bool, verificationToken string) bool {
+func (s *Server) upsertPeer(pm proto.PresenceMsg, msgSize int64, verified bool, verificationToken, home string) bool {
 	now := time.Now().UnixMilli()
 
 	s.mu.Lock()
@@ -107,9 +108,7 @@ func (s *Server) upsertPeer(pm proto.PresenceMsg, msgSize int64, verified bool,
 		delete(s.peers, pm.PeerID)
 		s.peersDirty = true
 		s.addLog(fmt.Sprintf("Peer went offline and removed: %s", pm.PeerID))
-		if s.peerDB != nil {
-			go s.peerDB.remove(pm.PeerID)
-		}
+		s.persist("peer:"+pm.PeerID, func(db *peerDB) error { return db.remove(pm.PeerID) })
 		return false
 	}
 
@@ -138,14 +137,13 @@ func (s *Server) upsertPeer(pm proto.PresenceMsg, msgSize int64, verified bool,
 		BytesSent:           bytesSent,
 		BytesReceived:       bytesReceived,
 		Verified:            verified,
+		Home:                home,
 		verificationToken:   verificationToken,
 	}
 	s.peers[pm.PeerID] = row
 	s.peersDirty = true
 
-	if s.peerDB != nil {
-		go s.peerDB.upsert(row)
-	}
+	s.persist("peer:"+pm.PeerID, func(db *peerDB) error { return db.upsert(row) })
 	return addrsChanged
 }
 
@@ -272,6 +270,10 @@ func (s *Server) Topology() map[string]any {
 		"peers": peerList,
 	}
 
+	if s.agg != nil {
+		result["aggregator"] = s.agg.status()
+	}
+
 	if s.relayInfo != nil {
 		result["relay"] = map[string]any{
 			"id":    s.relayInfo.PeerID,
@@ -322,9 +324,7 @@ func (s *Server) cleanupStalePeers(ctx context.Context) {
 				}
 			}
 
-			if s.peerDB != nil {
-				go s.peerDB.cleanupStale(staleThreshold)
-			}
+			s.persist("cleanup", func(db *peerDB) error { return db.cleanupStale(staleThreshold) })
 
 			// Clean up stale punch cooldown entries (older than 5 minutes)
 			punchCutoff := time.Now().Add(-PunchCutoffAge)
@@ -338,6 +338,7 @@ func (s *Server) cleanupStalePeers(ctx context.Context) {
 
 			// Clean up stale rate limiter entries
 			s.cleanupRateLimiter()
+			s.loginThrottle.cleanup()
 		}
 	}
 }
@@ -2,6 +2,7 @@ package rendezvous
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -16,6 +17,10 @@ type registerVM struct {
 	Success     bool
 	Verified    bool
 	NotRequired bool
+
+	// Proof-of-work the form solves before submitting, see throttle.go.
+	PoWChallenge string
+	PoWBits      int
 }
 
 // handleRegisterRemote serves /register when a remote registration service is configured.
@@ -26,7 +31,7 @@ func (s *Server) handleRegisterRemote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vm := registerVM{Title: "Register — Goop² Rendezvous"}
+	vm := registerVM{Title: "Register — " + s.brandTitle("Goop² Rendezvous")}
 
 	if r.Method == http.MethodGet {
 		if !s.registration.RegistrationRequired() {
@@ -55,6 +60,22 @@ func (s *Server) handleRegisterRemote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := extractIP(r.RemoteAddr)
+	err := s.register.verify(r.FormValue("pow_challenge"), r.FormValue("pow_nonce"))
+	if err == nil {
+		err = s.register.allow(ip)
+	}
+	if err != nil {
+		if err == errRegisterIP {
+			s.addLog(fmt.Sprintf("registration: daily cap reached for %s", ip))
+		}
+		msg := err.Error()
+		vm.Error = strings.ToUpper(msg[:1]) + msg[1:]
+		vm.Email = email
+		s.renderRegister(w, vm)
+		return
+	}
+
 	// Call registration service POST /api/reg/register
 	// Send as form-encoded data (matching the original reverse-proxy behaviour).
 	regURL := s.registration.baseURL + "/api/reg/register"
@@ -117,6 +138,9 @@ func (s *Server) handleRegisterRemote(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) renderRegister(w http.ResponseWriter, vm registerVM) {
+	if !vm.Success && !vm.NotRequired {
+		vm.PoWChallenge, vm.PoWBits = s.register.challenge()
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := s.registerTmpl.Execute(w, vm); err != nil {
 		log.Printf("register template error: %v", err)
@@ -128,7 +152,7 @@ func (s *Server) handleRegistrationsJSON(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleModerator) {
 		return
 	}
 
@@ -153,7 +177,7 @@ func (s *Server) handleAccountsJSON(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !s.requireAdmin(w, r) {
+	if !s.requireRole(w, r, roleModerator) {
 		return
 	}
 
@@ -180,6 +204,12 @@ func (s *Server) handleLocalTemplateList(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	etag := s.localTemplates.ListETag()
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	_ = json.NewEncoder(w).Encode(s.localTemplates.List())
 }
@@ -214,6 +244,18 @@ func (s *Server) handleLocalTemplateRoutes(w http.ResponseWriter, r *http.Reques
 		_ = json.NewEncoder(w).Encode(meta)
 
 	case "bundle":
+		// The ETag is the template's content hash, so a peer holding the
+		// bundle in its cache gets a 304 instead of the archive.
+		if meta, ok := s.localTemplates.GetManifest(dir); ok {
+			etag := `"` + meta.Hash + `"`
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w, done := s.trackInstall(w, r, dir)
+		defer done()
 		w.Header().Set("Content-Type", "application/gzip")
 		if err := s.localTemplates.WriteBundle(w, dir); err != nil {
 			http.NotFound(w, r)
@@ -240,6 +282,9 @@ func (s *Server) handleTemplateRoutesRemote(w http.ResponseWriter, r *http.Reque
 
 	if len(parts) == 2 && parts[1] == "bundle" {
 		dir := parts[0]
+		var done func()
+		w, done = s.trackInstall(w, r, dir)
+		defer done()
 		// Registration gate: require verified email for template downloads
 		peerID := getPeerID(r)
 		if s.registration != nil && s.registration.RegistrationRequired() {
@@ -64,20 +64,20 @@ func (s *Server) handleWS(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Only allow peers that have published presence.
-	// New peers hit /publish first (HTTP POST), then connect WS.
-	// If a peer races and WS arrives before publish, the WS reconnect
-	// loop retries after 250ms — by which time publish has completed.
+	// Peers that published over HTTP are admitted at once. Any other peer
+	// must send its own presence as the first frame (readWSHello), so a peer
+	// behind a proxy that throttles POSTs can join over the WebSocket alone.
 	s.mu.Lock()
 	_, knownPeer := s.peers[peerID]
 	s.mu.Unlock()
-	if !knownPeer {
-		http.Error(w, "unknown peer — publish first", http.StatusTooEarly)
+
+	remoteIP := extractIP(r.RemoteAddr)
+	if s.bans.peerBanned(peerID) || s.bans.ipBanned(remoteIP) {
+		http.Error(w, "banned", http.StatusForbidden)
 		return
 	}
 
 	// Per-IP WebSocket connection limit.
-	remoteIP := extractIP(r.RemoteAddr)
 	s.wsClientsMu.RLock()
 	ipCount := 0
 	for _, wsc := range s.wsClients {
@@ -97,6 +97,16 @@ func (s *Server) handleWS(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if !knownPeer {
+		if err := s.readWSHello(conn, peerID); err != nil {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+				time.Now().Add(WSWriteDeadline))
+			conn.Close()
+			return
+		}
+	}
+
 	wsc := &wsClient{
 		conn:   conn,
 		send:   make(chan []byte, 128),
@@ -175,8 +185,8 @@ func (s *Server) handleWS(ctx context.Context, w http.ResponseWriter, r *http.Re
 		}
 		s.mu.Unlock()
 
-		if stillOnline && s.peerDB != nil {
-			go s.peerDB.remove(peerID)
+		if stillOnline {
+			s.persist("peer:"+peerID, func(db *peerDB) error { return db.remove(peerID) })
 		}
 
 		if stillOnline {
@@ -201,46 +211,119 @@ func (s *Server) handleWS(ctx context.Context, w http.ResponseWriter, r *http.Re
 		// Reset read deadline on any message
 		conn.SetReadDeadline(time.Now().Add(WSReadDeadline))
 
-		var pm proto.PresenceMsg
-		if err := json.Unmarshal(message, &pm); err != nil {
-			continue
-		}
+		_ = s.wsPresence(peerID, message)
+	}
+}
 
-		if pm.PeerID == "" {
-			pm.PeerID = peerID
-		}
+// readWSHello admits a peer that connected without publishing first. Its
+// first frame must arrive within WSHelloTimeout and be its own online or
+// update presence. Like later frames it is not rate limited; the per-IP
+// connection limit applies instead.
+func (s *Server) readWSHello(conn *websocket.Conn, peerID string) error {
+	conn.SetReadDeadline(time.Now().Add(WSHelloTimeout))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("presence required")
+	}
+	var hello struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(message, &hello) != nil || (hello.Type != proto.TypeOnline && hello.Type != proto.TypeUpdate) {
+		return fmt.Errorf("first frame must be online or update presence")
+	}
+	if err := s.wsPresence(peerID, message); err != nil {
+		return err
+	}
+	s.addLog(fmt.Sprintf("WS admitted %s on its first presence", peerID))
+	return nil
+}
 
-		if err := validatePresence(pm); err != nil {
-			continue
-		}
+// wsPresence applies a presence frame from the peer on a WebSocket, the
+// same way /publish does. Frames about another peer are dropped.
+func (s *Server) wsPresence(peerID string, message []byte) error {
+	var pm proto.PresenceMsg
+	if err := json.Unmarshal(message, &pm); err != nil {
+		return fmt.Errorf("bad json")
+	}
+	if pm.PeerID == "" {
+		pm.PeerID = peerID
+	}
+	if pm.PeerID != peerID {
+		return fmt.Errorf("presence for another peer")
+	}
+	if _, err := s.checkPresence(&pm); err != nil {
+		return err
+	}
+	s.acceptPresence(pm)
+	return nil
+}
 
-		// Same logic as /publish handler
-		isRegistered := true
-		if s.registration != nil && s.registration.RegistrationRequired() {
-			if pm.Email == "" || pm.VerificationToken == "" {
-				isRegistered = false
-			} else {
-				isRegistered = s.registration.IsEmailTokenValid(pm.Email, pm.VerificationToken)
-			}
-		}
+// checkPresence validates a presence message a peer published and applies
+// the label policy. On an aggregator the message is queued for the upstream
+// as the peer sent it, so its signature still holds there. The returned
+// status is the HTTP status for err.
+func (s *Server) checkPresence(pm *proto.PresenceMsg) (int, error) {
+	orig := *pm
+	if status, err := s.screenPresence(pm); err != nil {
+		return status, err
+	}
+	if s.agg != nil {
+		s.agg.queue(orig)
+	}
+	return 0, nil
+}
 
-		peerToken := pm.VerificationToken
-		pm.VerificationToken = ""
-		if pm.TS == 0 {
-			pm.TS = proto.NowMillis()
+// screenPresence validates a presence message, refuses banned peers and
+// applies the label policy. Presence from federated servers goes through
+// it as well, so a ban here holds whichever server the peer uses.
+func (s *Server) screenPresence(pm *proto.PresenceMsg) (int, error) {
+	if err := validatePresence(*pm); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("bad message: %w", err)
+	}
+	if s.bans.peerBanned(pm.PeerID) {
+		return http.StatusForbidden, fmt.Errorf("peer is banned")
+	}
+	if err := s.applyContentPolicy(pm); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+	return 0, nil
+}
+
+// acceptPresence checks the peer's registration, stores the message and
+// broadcasts it without the verification token. It reports whether the
+// peer is registered.
+func (s *Server) acceptPresence(pm proto.PresenceMsg) bool {
+	isRegistered := true
+	if s.registration != nil && s.registration.RegistrationRequired() {
+		if pm.Email == "" || pm.VerificationToken == "" {
+			isRegistered = false
+		} else {
+			isRegistered = s.registration.IsEmailTokenValid(pm.Email, pm.VerificationToken)
 		}
-		pm.Verified = isRegistered
+	}
 
-		b, _ := json.Marshal(pm)
-		msgSize := int64(len(b))
+	// Save token server-side before stripping from broadcast message
+	peerToken := pm.VerificationToken
+	pm.VerificationToken = ""
+	if pm.TS == 0 {
+		pm.TS = proto.NowMillis()
+	}
+	pm.Verified = isRegistered
 
-		addrsChanged := s.upsertPeer(pm, msgSize, isRegistered, peerToken)
-		s.broadcast(b)
+	b, _ := json.Marshal(pm)
+	msgSize := int64(len(b))
 
-		if pm.Type == proto.TypeOnline || pm.Type == proto.TypeUpdate {
-			s.emitPunchHints(pm, addrsChanged)
-		}
+	addrsChanged := s.upsertPeer(pm, msgSize, isRegistered, peerToken, "")
+	s.broadcast(b)
+	s.notePublicSite(pm, isRegistered)
+
+	if pm.Type == proto.TypeOnline || pm.Type == proto.TypeUpdate {
+		s.emitPunchHints(pm, addrsChanged)
+	}
+	if s.fed != nil {
+		s.federateLocal(pm)
 	}
+	return isRegistered
 }
 
 func (s *Server) broadcast(b []byte) {
@@ -202,14 +202,61 @@ func TestWSRaceConditions(t *testing.T) {
 		conn.Close()
 	})
 
-	t.Run("UnknownPeerRejected", func(t *testing.T) {
-		wsURL := "ws" + strings.TrimPrefix(base, "http") + "/ws?peer_id=unknown-peer"
-		_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
-		if err == nil {
-			t.Fatal("expected dial to fail for unknown peer")
+	clearRate()
+	t.Run("UnknownPeerJoinsWithFirstPresence", func(t *testing.T) {
+		publishPeer(t, base, "ws-watcher")
+		watcher := dialWS(t, base, "ws-watcher")
+		defer watcher.Close()
+
+		conn := dialWS(t, base, "ws-only")
+		defer conn.Close()
+		b, _ := json.Marshal(proto.PresenceMsg{Type: proto.TypeOnline, PeerID: "ws-only", Content: "hi", TS: proto.NowMillis()})
+		if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
+			t.Fatal(err)
+		}
+
+		watcher.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			_, msg, err := watcher.ReadMessage()
+			if err != nil {
+				t.Fatalf("ws-only presence not broadcast: %v", err)
+			}
+			var pm proto.PresenceMsg
+			if json.Unmarshal(msg, &pm) == nil && pm.PeerID == "ws-only" && pm.Type == proto.TypeOnline {
+				break
+			}
+		}
+		srv.mu.Lock()
+		_, known := srv.peers["ws-only"]
+		srv.mu.Unlock()
+		if !known {
+			t.Error("ws-only peer not registered")
 		}
-		if resp != nil && resp.StatusCode != http.StatusTooEarly {
-			t.Fatalf("expected 425, got %d", resp.StatusCode)
+	})
+
+	t.Run("UnknownPeerNeedsOwnPresenceFirst", func(t *testing.T) {
+		for name, first := range map[string]proto.PresenceMsg{
+			"offline":    {Type: proto.TypeOffline, PeerID: "ws-bad"},
+			"other peer": {Type: proto.TypeOnline, PeerID: "stpad-receiver"},
+		} {
+			conn := dialWS(t, base, "ws-bad")
+			b, _ := json.Marshal(first)
+			conn.WriteMessage(websocket.TextMessage, b)
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			var err error
+			for err == nil {
+				_, _, err = conn.ReadMessage()
+			}
+			conn.Close()
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("%s: connection ended with %v, want a policy close", name, err)
+			}
+		}
+		srv.mu.Lock()
+		_, known := srv.peers["ws-bad"]
+		srv.mu.Unlock()
+		if known {
+			t.Error("rejected peer registered")
 		}
 	})
 }
@@ -0,0 +1,95 @@
+package rendezvous
+
+// status_widget.go — public counts for embedding on another site:
+// /status.json for scripts and badges, /widget.js for a drop-in
+// "N peers online" line. Both are CORS-open and cacheable; neither
+// reveals anything about individual peers.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// publicStatusJSON is the body of /status.json. The rendezvous only knows
+// presence, so listen rooms and groups, which peers never publish, are
+// not counted.
+type publicStatusJSON struct {
+	Name          string `json:"name"`
+	URL           string `json:"url,omitempty"`
+	PeersOnline   int    `json:"peers_online"`
+	PeersVerified int    `json:"peers_verified"`
+	PublicSites   int    `json:"public_sites"`
+	Templates     int    `json:"templates"`
+	UpdatedAt     int64  `json:"updated_at"` // unix millis
+}
+
+func (s *Server) publicStatus() publicStatusJSON {
+	st := publicStatusJSON{
+		Name:      s.brandTitle("Goop² Rendezvous"),
+		URL:       s.externalURL,
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+	for _, p := range s.snapshotPeers() {
+		if p.Type == proto.TypeOffline {
+			continue
+		}
+		st.PeersOnline++
+		if p.Verified {
+			st.PeersVerified++
+		}
+	}
+	if ps := s.publicSites; ps != nil {
+		ps.mu.RLock()
+		st.PublicSites = len(ps.sites)
+		ps.mu.RUnlock()
+	}
+	if s.templates != nil {
+		st.Templates = s.templates.TemplateCount()
+	} else if s.localTemplates != nil {
+		st.Templates = s.localTemplates.Count()
+	}
+	return st
+}
+
+// publicCORS opens a public GET endpoint to any origin and answers
+// preflights. Returns false when the request has been handled.
+func publicCORS(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+	return false
+}
+
+func (s *Server) handlePublicStatus(w http.ResponseWriter, r *http.Request) {
+	if !publicCORS(w, r) {
+		return
+	}
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(PublicStatusMaxAge.Seconds())))
+	_ = json.NewEncoder(w).Encode(s.publicStatus())
+}
+
+func (s *Server) handleWidget(w http.ResponseWriter, r *http.Request) {
+	if !publicCORS(w, r) {
+		return
+	}
+	js, err := embedded.ReadFile("assets/widget.js")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("content-type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(WidgetMaxAge.Seconds())))
+	_, _ = w.Write(js)
+}
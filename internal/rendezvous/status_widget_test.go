@@ -0,0 +1,50 @@
+package rendezvous
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+func TestPublicStatus(t *testing.T) {
+	s := New("127.0.0.1:0", "", "", "https://rv.example.org", 0, 0, "", RelayTimingConfig{})
+	s.mu.Lock()
+	s.peers["a"] = peerRow{PeerID: "a", Type: proto.TypeOnline, Verified: true, Email: "a@example.org"}
+	s.peers["b"] = peerRow{PeerID: "b", Type: proto.TypeUpdate}
+	s.peers["c"] = peerRow{PeerID: "c", Type: proto.TypeOffline}
+	s.peersDirty = true
+	s.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	s.handlePublicStatus(rec, httptest.NewRequest(http.MethodGet, "/status.json", nil))
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Cache-Control") == "" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	var st publicStatusJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.PeersOnline != 2 || st.PeersVerified != 1 || st.URL != "https://rv.example.org" {
+		t.Fatalf("status = %+v", st)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handlePublicStatus(rec, httptest.NewRequest(http.MethodOptions, "/status.json", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.handlePublicStatus(rec, httptest.NewRequest(http.MethodPost, "/status.json", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleWidget(rec, httptest.NewRequest(http.MethodGet, "/widget.js", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("content-type") != "text/javascript; charset=utf-8" || rec.Body.Len() == 0 {
+		t.Fatalf("widget = %d %q", rec.Code, rec.Header().Get("content-type"))
+	}
+}
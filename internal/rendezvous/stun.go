@@ -0,0 +1,82 @@
+package rendezvous
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+
+	"github.com/pion/stun/v3"
+)
+
+// StartSTUN starts a minimal STUN server (RFC 5389 Binding only) on the given
+// UDP port. It answers each Binding request with the sender's reflexive
+// address, which is all ICE needs to gather server-reflexive candidates.
+// The listener is closed when ctx ends.
+func StartSTUN(ctx context.Context, port int) (*net.UDPConn, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		return nil, fmt.Errorf("stun listen: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	go serveSTUN(conn)
+	log.Printf("stun: listening on udp port %d", port)
+	return conn, nil
+}
+
+func serveSTUN(conn *net.UDPConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		resp, ok := stunBindingResponse(buf[:n], addr)
+		if !ok {
+			continue
+		}
+		_, _ = conn.WriteToUDP(resp, addr)
+	}
+}
+
+// stunBindingResponse builds the Binding success response for a request
+// packet from addr. ok is false for anything that isn't a Binding request.
+func stunBindingResponse(pkt []byte, addr *net.UDPAddr) ([]byte, bool) {
+	if !stun.IsMessage(pkt) {
+		return nil, false
+	}
+	req := &stun.Message{Raw: append([]byte(nil), pkt...)}
+	if err := req.Decode(); err != nil || req.Type != stun.BindingRequest {
+		return nil, false
+	}
+	resp, err := stun.Build(
+		stun.NewTransactionIDSetter(req.TransactionID),
+		stun.BindingSuccess,
+		&stun.XORMappedAddress{IP: addr.IP, Port: addr.Port},
+		stun.NewSoftware("goop2-rendezvous"),
+		stun.Fingerprint,
+	)
+	if err != nil {
+		return nil, false
+	}
+	return resp.Raw, true
+}
+
+// stunURLs returns the ICE URLs peers should use for the STUN listener,
+// derived from the public hostname in externalURL. Without an external URL
+// there is nothing useful to advertise: STUN only matters across NAT.
+func stunURLs(externalURL string, port int) []string {
+	u, err := url.Parse(externalURL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("stun:%s", net.JoinHostPort(u.Hostname(), fmt.Sprint(port)))}
+}
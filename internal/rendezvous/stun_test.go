@@ -0,0 +1,69 @@
+package rendezvous
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v3"
+)
+
+func TestSTUN_BindingReturnsReflexiveAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, err := StartSTUN(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := srv.LocalAddr().(*net.UDPAddr).Port
+
+	c, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	req := stun.MustBuild(stun.TransactionID, stun.BindingRequest)
+	if _, err := c.Write(req.Raw); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1500)
+	n, err := c.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &stun.Message{Raw: buf[:n]}
+	if err := resp.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Type != stun.BindingSuccess || resp.TransactionID != req.TransactionID {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	var xor stun.XORMappedAddress
+	if err := xor.GetFrom(resp); err != nil {
+		t.Fatal(err)
+	}
+	local := c.LocalAddr().(*net.UDPAddr)
+	if xor.Port != local.Port || !xor.IP.Equal(local.IP) {
+		t.Fatalf("mapped %s:%d, want %s", xor.IP, xor.Port, local)
+	}
+}
+
+func TestSTUN_IgnoresNonSTUN(t *testing.T) {
+	if _, ok := stunBindingResponse([]byte("hello"), &net.UDPAddr{}); ok {
+		t.Fatal("answered a non-STUN packet")
+	}
+}
+
+func TestSTUNURLs(t *testing.T) {
+	if got := stunURLs("https://goop2.com", 3478); len(got) != 1 || got[0] != "stun:goop2.com:3478" {
+		t.Fatalf("got %v", got)
+	}
+	if got := stunURLs("", 3478); got != nil {
+		t.Fatalf("expected none without external URL, got %v", got)
+	}
+}
@@ -0,0 +1,171 @@
+package rendezvous
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TemplateCacheMaxBundles is the number of bundles a TemplateCache keeps.
+const TemplateCacheMaxBundles = 20
+
+// TemplateCache keeps what a peer fetched from template stores on disk in
+// {peerDir}/cache/templates: the last listing of each rendezvous with its
+// ETag, and downloaded bundles keyed by the template's content hash
+// (StoreMeta.Hash). A Client with a cache revalidates the listing instead
+// of refetching it, installs a cached bundle without downloading it again,
+// and falls back to both when the rendezvous cannot be reached.
+type TemplateCache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+type cachedListing struct {
+	ETag      string      `json:"etag,omitempty"`
+	Templates []StoreMeta `json:"templates"`
+}
+
+// validHash keeps cache keys safe to use as file names.
+var validHash = regexp.MustCompile(`^[0-9A-Za-z_-]{8,128}$`)
+
+// NewTemplateCache creates a template cache in {peerDir}/cache/templates.
+func NewTemplateCache(peerDir string) *TemplateCache {
+	dir := filepath.Join(peerDir, "cache", "templates")
+	_ = os.MkdirAll(filepath.Join(dir, "bundles"), 0755)
+	return &TemplateCache{dir: dir}
+}
+
+func (tc *TemplateCache) listingPath(baseURL string) string {
+	sum := sha256.Sum256([]byte(baseURL))
+	return filepath.Join(tc.dir, "listing-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+func (tc *TemplateCache) bundlePath(hash string) string {
+	return filepath.Join(tc.dir, "bundles", hash+".tar.gz")
+}
+
+// indexPath maps template dirs to the hash of their last downloaded bundle,
+// for when the listing is not available.
+func (tc *TemplateCache) indexPath() string {
+	return filepath.Join(tc.dir, "bundles", "index.json")
+}
+
+// Listing returns the cached listing of a rendezvous and its ETag.
+func (tc *TemplateCache) Listing(baseURL string) (etag string, list []StoreMeta, ok bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	var l cachedListing
+	b, err := os.ReadFile(tc.listingPath(baseURL))
+	if err != nil || json.Unmarshal(b, &l) != nil {
+		return "", nil, false
+	}
+	return l.ETag, l.Templates, true
+}
+
+// PutListing stores the listing of a rendezvous.
+func (tc *TemplateCache) PutListing(baseURL, etag string, list []StoreMeta) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	b, err := json.Marshal(cachedListing{ETag: etag, Templates: list})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(tc.listingPath(baseURL), b)
+}
+
+// Bundle returns the cached bundle with the given content hash, or nil.
+func (tc *TemplateCache) Bundle(hash string) []byte {
+	if !validHash.MatchString(hash) {
+		return nil
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	b, err := os.ReadFile(tc.bundlePath(hash))
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	_ = os.Chtimes(tc.bundlePath(hash), now, now) // eviction goes by last use
+	return b
+}
+
+// LastBundle returns the hash of the bundle last stored for a template dir.
+func (tc *TemplateCache) LastBundle(dir string) string {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.readIndex()[dir]
+}
+
+// PutBundle stores a template bundle under its content hash and drops the
+// least recently used bundles beyond TemplateCacheMaxBundles.
+func (tc *TemplateCache) PutBundle(dir, hash string, data []byte) error {
+	if !validHash.MatchString(hash) {
+		return nil
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if err := writeFileAtomic(tc.bundlePath(hash), data); err != nil {
+		return err
+	}
+	idx := tc.readIndex()
+	idx[dir] = hash
+	tc.evictLocked(idx)
+	b, _ := json.Marshal(idx)
+	return writeFileAtomic(tc.indexPath(), b)
+}
+
+func (tc *TemplateCache) readIndex() map[string]string {
+	idx := map[string]string{}
+	if b, err := os.ReadFile(tc.indexPath()); err == nil {
+		_ = json.Unmarshal(b, &idx)
+	}
+	return idx
+}
+
+func (tc *TemplateCache) evictLocked(idx map[string]string) {
+	entries, err := os.ReadDir(filepath.Join(tc.dir, "bundles"))
+	if err != nil {
+		return
+	}
+	type bundle struct {
+		hash string
+		used int64
+	}
+	var bundles []bundle
+	for _, e := range entries {
+		hash, ok := strings.CutSuffix(e.Name(), ".tar.gz")
+		if !ok {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			bundles = append(bundles, bundle{hash, info.ModTime().UnixNano()})
+		}
+	}
+	if len(bundles) <= TemplateCacheMaxBundles {
+		return
+	}
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].used > bundles[j].used })
+	for _, b := range bundles[TemplateCacheMaxBundles:] {
+		os.Remove(tc.bundlePath(b.hash))
+		for dir, h := range idx {
+			if h == b.hash {
+				delete(idx, dir)
+			}
+		}
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
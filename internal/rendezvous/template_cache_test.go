@@ -0,0 +1,168 @@
+package rendezvous
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusLog records the status of every response a test server sends.
+type statusLog struct {
+	mu       sync.Mutex
+	statuses []int
+}
+
+func (sl *statusLog) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		sl.mu.Lock()
+		sl.statuses = append(sl.statuses, rec.Code)
+		sl.mu.Unlock()
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	})
+}
+
+func (sl *statusLog) take() []int {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	out := sl.statuses
+	sl.statuses = nil
+	return out
+}
+
+func newCachingTestStore(t *testing.T) (*httptest.Server, *statusLog) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, files := range map[string]map[string]string{
+		"quiz":  {"manifest.json": `{"name":"Quiz","version":"1.0"}`, "index.html": "<h1>Quiz</h1>"},
+		"chess": {"manifest.json": `{"name":"Chess"}`, "index.html": "<h1>Chess</h1>"},
+	} {
+		os.MkdirAll(filepath.Join(dir, name), 0o755)
+		for rel, content := range files {
+			os.WriteFile(filepath.Join(dir, name, rel), []byte(content), 0o644)
+		}
+	}
+	srv := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	srv.SetLocalTemplateStore(NewLocalTemplateStore(dir))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/templates", srv.handleLocalTemplateList)
+	mux.HandleFunc("/api/templates/", srv.handleLocalTemplateRoutes)
+	log := &statusLog{}
+	ts := httptest.NewServer(log.wrap(mux))
+	t.Cleanup(ts.Close)
+	return ts, log
+}
+
+func download(t *testing.T, c *Client, dir string) map[string][]byte {
+	t.Helper()
+	rc, err := c.DownloadTemplateBundle(context.Background(), dir, "", "")
+	if err != nil {
+		t.Fatalf("download %s: %v", dir, err)
+	}
+	defer rc.Close()
+	files, err := testExtractTarGz(rc)
+	if err != nil {
+		t.Fatalf("extract %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestTemplateCache_listingAndBundles(t *testing.T) {
+	ts, log := newCachingTestStore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := NewClient(ts.URL)
+	c.SetTemplateCache(NewTemplateCache(t.TempDir()))
+
+	list, err := c.ListTemplates(ctx, "")
+	if err != nil || len(list) != 2 || list[0].Hash == "" {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if again, _ := c.ListTemplates(ctx, ""); len(again) != 2 {
+		t.Errorf("revalidated list = %+v", again)
+	}
+	if got := log.take(); len(got) != 2 || got[0] != http.StatusOK || got[1] != http.StatusNotModified {
+		t.Errorf("listing statuses = %v, want 200 then 304", got)
+	}
+
+	if files := download(t, c, "quiz"); string(files["index.html"]) != "<h1>Quiz</h1>" {
+		t.Fatalf("quiz = %q", files["index.html"])
+	}
+	// The listing names the cached bundle's hash: no request at all.
+	download(t, c, "quiz")
+	if got := log.take(); len(got) != 1 {
+		t.Errorf("bundle requests = %v, want one", got)
+	}
+
+	// Offline, both come from the cache.
+	ts.Close()
+	if list, err := c.ListTemplates(ctx, ""); err != nil || len(list) != 2 {
+		t.Errorf("offline list = %+v, %v", list, err)
+	}
+	if files := download(t, c, "quiz"); files["manifest.json"] == nil {
+		t.Error("offline bundle missing manifest")
+	}
+	if !c.TemplateCached("quiz") || c.TemplateCached("chess") {
+		t.Error("TemplateCached wrong")
+	}
+	if _, err := c.DownloadTemplateBundle(ctx, "chess", "", ""); err == nil {
+		t.Error("uncached bundle served offline")
+	}
+}
+
+func TestTemplateCache_revalidatesWithoutListing(t *testing.T) {
+	ts, log := newCachingTestStore(t)
+	c := NewClient(ts.URL)
+	c.SetTemplateCache(NewTemplateCache(t.TempDir()))
+
+	download(t, c, "chess")
+	if files := download(t, c, "chess"); string(files["index.html"]) != "<h1>Chess</h1>" {
+		t.Fatalf("chess = %q", files["index.html"])
+	}
+	if got := log.take(); len(got) != 2 || got[1] != http.StatusNotModified {
+		t.Errorf("statuses = %v, want 200 then 304", got)
+	}
+
+	// A bundle read only partly is not cached.
+	c2 := NewClient(ts.URL)
+	c2.SetTemplateCache(NewTemplateCache(t.TempDir()))
+	rc, err := c2.DownloadTemplateBundle(context.Background(), "quiz", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.CopyN(io.Discard, rc, 10)
+	rc.Close()
+	if c2.TemplateCached("quiz") {
+		t.Error("partial bundle cached")
+	}
+}
+
+func TestTemplateCache_evictsLeastRecentlyUsed(t *testing.T) {
+	tc := NewTemplateCache(t.TempDir())
+	old := time.Now().Add(-time.Hour)
+	for i := range TemplateCacheMaxBundles {
+		hash := "hash" + string(rune('a'+i)) + "0000000"
+		tc.PutBundle("t"+hash, hash, []byte(hash))
+		os.Chtimes(tc.bundlePath(hash), old.Add(time.Duration(i)*time.Second), old.Add(time.Duration(i)*time.Second))
+	}
+	tc.Bundle("hasha0000000") // oldest, but used now
+	tc.PutBundle("new", "newbundle000", []byte("x"))
+
+	if tc.Bundle("hasha0000000") == nil || tc.Bundle("newbundle000") == nil {
+		t.Error("recently used bundle evicted")
+	}
+	if tc.Bundle("hashb0000000") != nil || tc.LastBundle("thashb0000000") != "" {
+		t.Error("least recently used bundle kept")
+	}
+}
@@ -1,20 +1,72 @@
 package rendezvous
 
+import (
+	"strconv"
+	"strings"
+)
+
 // StoreMeta holds metadata for a template (built-in, store, or local).
 type StoreMeta struct {
 	Name         string                 `json:"name"`
 	Description  string                 `json:"description"`
 	Category     string                 `json:"category"`
 	Icon         string                 `json:"icon"`
+	Author       string                 `json:"author,omitempty"`
+	Version      string                 `json:"version,omitempty"`
 	Dir          string                 `json:"dir"`
 	Source       string                 `json:"source"`
 	Tables       map[string]TablePolicy `json:"tables,omitempty"`  // legacy
 	Schemas      []string               `json:"schemas,omitempty"` // ORM table names owned by this template
 	RequireEmail bool                   `json:"require_email,omitempty"`
 	DefaultRole  string                 `json:"default_role,omitempty"`
+	Hash         string                 `json:"hash,omitempty"` // content hash of the bundle, set by the store; also its ETag
+
+	// Set by the store for templates published by a peer, see template_publish.go.
+	Publisher   string `json:"publisher,omitempty"`    // peer ID of the publisher
+	PublishedAt int64  `json:"published_at,omitempty"` // Unix ms of the last publish
+	Moderation  string `json:"moderation,omitempty"`   // "", ModerationFlagged or ModerationHidden
 }
 
 // TablePolicy holds per-table configuration from a template manifest (legacy).
 type TablePolicy struct {
 	InsertPolicy string `json:"insert_policy"`
 }
+
+// IsNewerVersion reports whether version a is newer than b. Versions are
+// dot-separated numbers ("1.2.10"), an optional "v" prefix is ignored.
+// Non-numeric parts compare as strings; an empty version is never newer.
+func IsNewerVersion(a, b string) bool {
+	if a == "" {
+		return false
+	}
+	if b == "" {
+		return true
+	}
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var ap, bp string
+		if i < len(as) {
+			ap = as[i]
+		}
+		if i < len(bs) {
+			bp = bs[i]
+		}
+		if ap == bp {
+			continue
+		}
+		an, aErr := strconv.Atoi(ap)
+		bn, bErr := strconv.Atoi(bp)
+		switch {
+		case ap == "":
+			return false
+		case bp == "":
+			return true
+		case aErr == nil && bErr == nil:
+			return an > bn
+		default:
+			return ap > bp
+		}
+	}
+	return false
+}
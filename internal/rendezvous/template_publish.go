@@ -0,0 +1,468 @@
+package rendezvous
+
+// template_publish.go — templates published by peers. With
+// presence.template_publish on, a peer posts a tar.gz bundle of a template
+// folder to POST /api/templates/publish, signed with its identity key. The
+// store keeps it in {templates_dir}/_published/<dir>, next to the operator's
+// templates, with the publisher's peer ID as attribution. Only the
+// publisher can replace a template; moderators can flag, hide or remove it
+// from the admin panel (/published-templates.json).
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Moderation states of a published template.
+const (
+	ModerationFlagged = "flagged" // listed with a warning
+	ModerationHidden  = "hidden"  // neither listed nor downloadable
+)
+
+// Headers of a publish request, besides X-Goop-Peer-ID.
+const (
+	HeaderPublishTS  = "X-Goop-Timestamp" // Unix ms
+	HeaderPublishSig = "X-Goop-Signature" // base64 signature over TemplatePublishSigningBytes
+)
+
+// publishedSidecar holds the attribution of a published template in its folder.
+const publishedSidecar = ".publish.json"
+
+var (
+	ErrTemplateTaken  = errors.New("a template with this name belongs to someone else")
+	errNotPublishing  = errors.New("this store does not accept published templates")
+	errNotPublished   = errors.New("not a published template")
+	errBadBundle      = errors.New("invalid template bundle")
+	errBadModeration  = errors.New("moderation must be empty, flagged or hidden")
+	publishDirPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+)
+
+type publishedMeta struct {
+	Publisher   string `json:"publisher"`
+	PublishedAt int64  `json:"published_at"`
+	Moderation  string `json:"moderation,omitempty"`
+}
+
+// TemplatePublishSigningBytes returns the bytes a peer signs to publish bundle.
+func TemplatePublishSigningBytes(peerID string, ts int64, bundle []byte) []byte {
+	sum := sha256.Sum256(bundle)
+	return []byte("goop-template-publish|" + peerID + "|" + strconv.FormatInt(ts, 10) + "|" + hex.EncodeToString(sum[:]))
+}
+
+// ValidPublishDir reports whether dir can name a published template.
+func ValidPublishDir(dir string) bool {
+	return publishDirPattern.MatchString(dir)
+}
+
+// NewPublishingTemplateStore is NewLocalTemplateStore with publishing on:
+// it also loads {dir}/_published and returns a store even when there are
+// no templates yet. Bundles over maxBundle bytes are refused.
+func NewPublishingTemplateStore(dir string, maxBundle int64) *LocalTemplateStore {
+	ts := &LocalTemplateStore{
+		templates:  make(map[string]localTpl),
+		publishDir: filepath.Join(dir, "_published"),
+		maxBundle:  maxBundle,
+	}
+	ts.loadDisk(dir)
+	ts.loadPublished()
+	return ts
+}
+
+// Publishing reports whether peers can publish to the store.
+func (ts *LocalTemplateStore) Publishing() bool {
+	return ts.publishDir != ""
+}
+
+// MaxBundle is the largest bundle Publish accepts, in bytes.
+func (ts *LocalTemplateStore) MaxBundle() int64 {
+	return ts.maxBundle
+}
+
+func (ts *LocalTemplateStore) loadPublished() {
+	entries, err := os.ReadDir(ts.publishDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !ValidPublishDir(e.Name()) {
+			continue
+		}
+		if _, taken := ts.templates[e.Name()]; taken {
+			log.Printf("local templates: published %q hidden by a template of the same name", e.Name())
+			continue
+		}
+		tplDir := filepath.Join(ts.publishDir, e.Name())
+		files := readTemplateFiles(tplDir)
+		var pm publishedMeta
+		if err := json.Unmarshal(files[publishedSidecar], &pm); err != nil || pm.Publisher == "" {
+			continue
+		}
+		delete(files, publishedSidecar)
+		meta, err := publishedStoreMeta(e.Name(), files, pm)
+		if err != nil {
+			log.Printf("local templates: published %q: %v", e.Name(), err)
+			continue
+		}
+		ts.templates[e.Name()] = localTpl{meta: meta, files: files}
+	}
+}
+
+func publishedStoreMeta(dir string, files map[string][]byte, pm publishedMeta) (StoreMeta, error) {
+	var meta StoreMeta
+	if err := json.Unmarshal(files["manifest.json"], &meta); err != nil {
+		return meta, fmt.Errorf("%w: manifest.json: %v", errBadBundle, err)
+	}
+	if strings.TrimSpace(meta.Name) == "" {
+		return meta, fmt.Errorf("%w: manifest.json has no name", errBadBundle)
+	}
+	meta.Dir = dir
+	meta.Source = "store"
+	meta.Hash = contentHash(files)
+	meta.Publisher = pm.Publisher
+	meta.PublishedAt = pm.PublishedAt
+	meta.Moderation = pm.Moderation
+	return meta, nil
+}
+
+// readBundle unpacks a tar.gz bundle whose files all sit in one top folder,
+// as WriteBundle writes them, and returns that folder and the files.
+func readBundle(bundle []byte) (string, map[string][]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errBadBundle, err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	files := map[string][]byte{}
+	var dir string
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", errBadBundle, err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return "", nil, fmt.Errorf("%w: %s is not a regular file", errBadBundle, hdr.Name)
+		}
+		name := path.Clean(hdr.Name)
+		top, rel, ok := strings.Cut(name, "/")
+		if !ok || rel == "" || path.IsAbs(name) || strings.HasPrefix(name, "../") || rel == publishedSidecar {
+			return "", nil, fmt.Errorf("%w: bad path %s", errBadBundle, hdr.Name)
+		}
+		if dir == "" {
+			dir = top
+		} else if top != dir {
+			return "", nil, fmt.Errorf("%w: files outside %s/", errBadBundle, dir)
+		}
+		if len(files) >= TemplatePublishMaxFiles {
+			return "", nil, fmt.Errorf("%w: more than %d files", errBadBundle, TemplatePublishMaxFiles)
+		}
+		total += hdr.Size
+		if total > TemplatePublishMaxUnpacked {
+			return "", nil, fmt.Errorf("%w: more than %d MB unpacked", errBadBundle, TemplatePublishMaxUnpacked>>20)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", errBadBundle, err)
+		}
+		files[filepath.FromSlash(rel)] = data
+	}
+	if !ValidPublishDir(dir) {
+		return "", nil, fmt.Errorf("%w: folder name %q must be lowercase letters, digits, - or _", errBadBundle, dir)
+	}
+	return dir, files, nil
+}
+
+// Publish stores a bundle published by peerID. A template of the same name
+// can only be replaced by its publisher; a moderation flag survives the update.
+func (ts *LocalTemplateStore) Publish(peerID string, bundle []byte) (StoreMeta, error) {
+	if !ts.Publishing() {
+		return StoreMeta{}, errNotPublishing
+	}
+	dir, files, err := readBundle(bundle)
+	if err != nil {
+		return StoreMeta{}, err
+	}
+	pm := publishedMeta{Publisher: peerID, PublishedAt: time.Now().UnixMilli()}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if old, ok := ts.templates[dir]; ok {
+		if old.meta.Publisher != peerID {
+			return StoreMeta{}, ErrTemplateTaken
+		}
+		pm.Moderation = old.meta.Moderation
+	}
+	meta, err := publishedStoreMeta(dir, files, pm)
+	if err != nil {
+		return StoreMeta{}, err
+	}
+	if err := ts.writePublished(dir, files, pm); err != nil {
+		return StoreMeta{}, err
+	}
+	ts.templates[dir] = localTpl{meta: meta, files: files}
+	return meta, nil
+}
+
+// writePublished replaces the folder of a published template.
+func (ts *LocalTemplateStore) writePublished(dir string, files map[string][]byte, pm publishedMeta) error {
+	tmp := filepath.Join(ts.publishDir, ".tmp-"+dir)
+	_ = os.RemoveAll(tmp)
+	for rel, data := range files {
+		p := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return err
+		}
+	}
+	b, _ := json.Marshal(pm)
+	if err := os.WriteFile(filepath.Join(tmp, publishedSidecar), b, 0644); err != nil {
+		return err
+	}
+	final := filepath.Join(ts.publishDir, dir)
+	_ = os.RemoveAll(final)
+	return os.Rename(tmp, final)
+}
+
+// Published returns the published templates, hidden ones included, newest first.
+func (ts *LocalTemplateStore) Published() []StoreMeta {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	out := []StoreMeta{}
+	for _, t := range ts.templates {
+		if t.meta.Publisher != "" {
+			out = append(out, t.meta)
+		}
+	}
+	slices.SortFunc(out, func(a, b StoreMeta) int { return int(b.PublishedAt - a.PublishedAt) })
+	return out
+}
+
+// Moderate sets the moderation state of a published template.
+func (ts *LocalTemplateStore) Moderate(dir, moderation string) error {
+	if moderation != "" && moderation != ModerationFlagged && moderation != ModerationHidden {
+		return errBadModeration
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t, ok := ts.templates[dir]
+	if !ok || t.meta.Publisher == "" {
+		return errNotPublished
+	}
+	pm := publishedMeta{Publisher: t.meta.Publisher, PublishedAt: t.meta.PublishedAt, Moderation: moderation}
+	b, _ := json.Marshal(pm)
+	if err := writeFileAtomic(filepath.Join(ts.publishDir, dir, publishedSidecar), b); err != nil {
+		return err
+	}
+	t.meta.Moderation = moderation
+	ts.templates[dir] = t
+	return nil
+}
+
+// RemovePublished deletes a published template.
+func (ts *LocalTemplateStore) RemovePublished(dir string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t, ok := ts.templates[dir]
+	if !ok || t.meta.Publisher == "" {
+		return errNotPublished
+	}
+	if err := os.RemoveAll(filepath.Join(ts.publishDir, dir)); err != nil {
+		return err
+	}
+	delete(ts.templates, dir)
+	return nil
+}
+
+// handleTemplatePublish accepts a signed bundle from a peer that is online
+// on this rendezvous, and verified when registration is required.
+func (s *Server) handleTemplatePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	peerID := r.Header.Get("X-Goop-Peer-ID")
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderPublishTS), 10, 64)
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(HeaderPublishSig))
+	if peerID == "" || ts == 0 || err != nil || len(sig) == 0 {
+		http.Error(w, "missing peer ID, timestamp or signature", http.StatusBadRequest)
+		return
+	}
+	if d := time.Since(time.UnixMilli(ts)); d > TemplatePublishMaxSkew || d < -TemplatePublishMaxSkew {
+		http.Error(w, "timestamp too far off, check your clock", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	p, known := s.peers[peerID]
+	s.mu.Unlock()
+	if !known {
+		http.Error(w, "only peers online on this rendezvous can publish", http.StatusForbidden)
+		return
+	}
+	if s.registration != nil && s.registration.RegistrationRequired() && !p.Verified {
+		http.Error(w, "register and verify your email to publish templates", http.StatusForbidden)
+		return
+	}
+
+	bundle, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.localTemplates.MaxBundle()))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("bundle larger than %d KB", s.localTemplates.MaxBundle()>>10), http.StatusRequestEntityTooLarge)
+		return
+	}
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		http.Error(w, "bad peer ID", http.StatusBadRequest)
+		return
+	}
+	pub, err := pid.ExtractPublicKey()
+	if err != nil {
+		http.Error(w, "bad peer ID", http.StatusBadRequest)
+		return
+	}
+	if ok, err := pub.Verify(TemplatePublishSigningBytes(peerID, ts, bundle), sig); err != nil || !ok {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+
+	meta, err := s.localTemplates.Publish(peerID, bundle)
+	switch {
+	case errors.Is(err, ErrTemplateTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errBadBundle):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("templates: publish from %s: %v", peerID, err)
+		http.Error(w, "could not store the template", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("templates: %s published %q %s", peerID, meta.Dir, meta.Version)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(meta)
+}
+
+// handlePublishedTemplatesJSON lists (GET), moderates (POST {dir, moderation})
+// and removes (DELETE ?dir=) published templates.
+func (s *Server) handlePublishedTemplatesJSON(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, roleModerator) {
+		return
+	}
+	if s.localTemplates == nil || !s.localTemplates.Publishing() {
+		http.Error(w, "template publishing is not enabled", http.StatusNotFound)
+		return
+	}
+	who, _ := s.adminIdentity(r)
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(s.localTemplates.Published())
+
+	case http.MethodPost:
+		var req struct {
+			Dir        string `json:"dir"`
+			Moderation string `json:"moderation"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if err := s.localTemplates.Moderate(req.Dir, req.Moderation); err != nil {
+			http.Error(w, err.Error(), moderationStatus(err))
+			return
+		}
+		log.Printf("admin: %s set template %q to %q", who, req.Dir, req.Moderation)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		dir := r.URL.Query().Get("dir")
+		if err := s.localTemplates.RemovePublished(dir); err != nil {
+			http.Error(w, err.Error(), moderationStatus(err))
+			return
+		}
+		log.Printf("admin: %s removed published template %q", who, dir)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func moderationStatus(err error) int {
+	switch {
+	case errors.Is(err, errNotPublished):
+		return http.StatusNotFound
+	case errors.Is(err, errBadModeration):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// PublishTemplate posts a template bundle, as WriteBundle writes it, to the
+// store of this rendezvous. sign signs with the key of peerID, see
+// TemplatePublishSigningBytes. The error carries the server's reason.
+func (c *Client) PublishTemplate(ctx context.Context, peerID string, bundle []byte, sign func([]byte) ([]byte, error)) (StoreMeta, error) {
+	var meta StoreMeta
+	if c.BaseURL == "" {
+		return meta, fmt.Errorf("no base url")
+	}
+	ts := time.Now().UnixMilli()
+	sig, err := sign(TemplatePublishSigningBytes(peerID, ts, bundle))
+	if err != nil {
+		return meta, fmt.Errorf("sign bundle: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/templates/publish", bytes.NewReader(bundle))
+	if err != nil {
+		return meta, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("X-Goop-Peer-ID", peerID)
+	req.Header.Set(HeaderPublishTS, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderPublishSig, base64.StdEncoding.EncodeToString(sig))
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return meta, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return meta, fmt.Errorf("this rendezvous does not accept published templates")
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return meta, fmt.Errorf("publish template: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return meta, fmt.Errorf("publish template: %w", err)
+	}
+	return meta, nil
+}
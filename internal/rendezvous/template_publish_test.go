@@ -0,0 +1,149 @@
+package rendezvous
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func testBundle(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, data := range files {
+		writeFile(tw, name, []byte(data))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+type testPublisher struct {
+	id   string
+	priv crypto.PrivKey
+}
+
+func newTestPublisher(t *testing.T) testPublisher {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := peer.IDFromPrivateKey(priv)
+	return testPublisher{id: id.String(), priv: priv}
+}
+
+func newPublishTestServer(t *testing.T, dir string, online ...testPublisher) (*Server, *Client) {
+	t.Helper()
+	s := New("127.0.0.1:0", "", "", "", 0, 0, "", RelayTimingConfig{})
+	s.SetLocalTemplateStore(NewPublishingTemplateStore(dir, 1<<20))
+	for _, p := range online {
+		s.peers[p.id] = peerRow{PeerID: p.id}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/templates/publish", s.handleTemplatePublish)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return s, NewClient(ts.URL)
+}
+
+func TestPublishTemplate(t *testing.T) {
+	dir := t.TempDir()
+	alice, bob := newTestPublisher(t), newTestPublisher(t)
+	s, c := newPublishTestServer(t, dir, alice, bob)
+	ctx := context.Background()
+	bundle := testBundle(t, map[string]string{
+		"quiz/manifest.json": `{"name":"Quiz","version":"1.0"}`,
+		"quiz/index.html":    "<p>quiz</p>",
+	})
+
+	meta, err := c.PublishTemplate(ctx, alice.id, bundle, alice.priv.Sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Dir != "quiz" || meta.Publisher != alice.id || meta.PublishedAt == 0 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if got, ok := s.localTemplates.GetManifest("quiz"); !ok || got.Publisher != alice.id {
+		t.Fatalf("not in store: %+v", got)
+	}
+
+	// Only the publisher can replace it.
+	if _, err := c.PublishTemplate(ctx, bob.id, bundle, bob.priv.Sign); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Fatalf("other publisher: %v", err)
+	}
+	// A signature by another key is refused.
+	if _, err := c.PublishTemplate(ctx, alice.id, bundle, bob.priv.Sign); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("forged signature: %v", err)
+	}
+	// So is a peer that is not online here.
+	carol := newTestPublisher(t)
+	if _, err := c.PublishTemplate(ctx, carol.id, bundle, carol.priv.Sign); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("unknown peer: %v", err)
+	}
+	// And a bundle that escapes its folder.
+	evil := testBundle(t, map[string]string{"quiz/manifest.json": `{"name":"Quiz"}`, "../etc/passwd": "x"})
+	if _, err := c.PublishTemplate(ctx, alice.id, evil, alice.priv.Sign); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("bad path: %v", err)
+	}
+}
+
+func TestPublishedTemplate_moderation(t *testing.T) {
+	dir := t.TempDir()
+	alice := newTestPublisher(t)
+	ts := NewPublishingTemplateStore(dir, 1<<20)
+	bundle := testBundle(t, map[string]string{"quiz/manifest.json": `{"name":"Quiz"}`})
+	if _, err := ts.Publish(alice.id, bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.Moderate("quiz", ModerationHidden); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.List()) != 0 {
+		t.Error("hidden template listed")
+	}
+	if _, ok := ts.GetManifest("quiz"); ok {
+		t.Error("hidden template downloadable")
+	}
+	if got := ts.Published(); len(got) != 1 || got[0].Moderation != ModerationHidden {
+		t.Fatalf("published = %+v", got)
+	}
+
+	// A republish keeps the moderation state, and so does a restart.
+	if _, err := ts.Publish(alice.id, bundle); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewPublishingTemplateStore(dir, 1<<20)
+	if got := reloaded.Published(); len(got) != 1 || got[0].Moderation != ModerationHidden || got[0].Publisher != alice.id {
+		t.Fatalf("after reload = %+v", got)
+	}
+
+	if err := reloaded.RemovePublished("quiz"); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewPublishingTemplateStore(dir, 1<<20).Published(); len(got) != 0 {
+		t.Fatalf("removed template reloaded: %+v", got)
+	}
+}
+
+func TestPublishTemplate_operatorTemplateWins(t *testing.T) {
+	dir := t.TempDir()
+	alice := newTestPublisher(t)
+	s, c := newPublishTestServer(t, dir, alice)
+	s.localTemplates.templates["quiz"] = localTpl{meta: StoreMeta{Name: "Quiz", Dir: "quiz"}}
+	bundle := testBundle(t, map[string]string{"quiz/manifest.json": `{"name":"Mine"}`})
+	if _, err := c.PublishTemplate(context.Background(), alice.id, bundle, alice.priv.Sign); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Fatalf("took over operator template: %v", err)
+	}
+}
@@ -215,7 +215,7 @@ func TestRemoteTemplatesIntegration(t *testing.T) {
 
 	t.Run("client download and extract", func(t *testing.T) {
 		client := NewClient(baseURL)
-		rc, err := client.DownloadTemplateBundle(ctx, "quiz", "")
+		rc, err := client.DownloadTemplateBundle(ctx, "quiz", "", "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -319,3 +319,25 @@ func testExtractTarGz(r io.Reader) (map[string][]byte, error) {
 	}
 	return files, nil
 }
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.1", "1.0", true},
+		{"1.0", "1.1", false},
+		{"1.10", "1.9", true},
+		{"v2.0", "1.9.9", true},
+		{"1.0.1", "1.0", true},
+		{"1.0", "1.0.0", false},
+		{"1.0", "1.0", false},
+		{"1.0", "", true},
+		{"", "1.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewerVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("IsNewerVersion(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
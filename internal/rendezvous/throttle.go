@@ -0,0 +1,240 @@
+package rendezvous
+
+// throttle.go — brute-force and abuse protection for the public endpoints
+// that need more than the /publish limiter:
+//
+//   - admin logins: a client IP with AdminLoginMaxFailures failed logins in
+//     a row is locked out, first for AdminLockoutBase, doubling with every
+//     further lockout up to AdminLockoutMax
+//   - /register: each POST must carry a solved proof-of-work challenge
+//     from the form, and an IP may only register so many times a day
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loginThrottle tracks failed admin logins per client IP.
+type loginThrottle struct {
+	now func() time.Time
+
+	mu  sync.Mutex
+	ips map[string]*loginFailures
+}
+
+type loginFailures struct {
+	count       int // failures since the last success or lockout
+	lockouts    int // lockouts so far; each doubles the next one
+	last        time.Time
+	lockedUntil time.Time
+}
+
+func newLoginThrottle() *loginThrottle {
+	return &loginThrottle{now: time.Now, ips: map[string]*loginFailures{}}
+}
+
+// locked returns how long ip is still locked out, 0 if it is not.
+func (t *loginThrottle) locked(ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, ok := t.ips[ip]
+	if !ok {
+		return 0
+	}
+	return max(f.lockedUntil.Sub(t.now()), 0)
+}
+
+// fail records a failed login from ip and returns the lockout it caused, if any.
+func (t *loginThrottle) fail(ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	f, ok := t.ips[ip]
+	if !ok {
+		f = &loginFailures{}
+		t.ips[ip] = f
+	}
+	if now.Sub(f.last) > AdminLockoutMax {
+		*f = loginFailures{}
+	}
+	f.last = now
+	f.count++
+	if f.count < AdminLoginMaxFailures {
+		return 0
+	}
+	d := AdminLockoutBase << min(f.lockouts, 16)
+	d = min(d, AdminLockoutMax)
+	f.count = 0
+	f.lockouts++
+	f.lockedUntil = now.Add(d)
+	return d
+}
+
+// succeed forgets ip's failures.
+func (t *loginThrottle) succeed(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.ips, ip)
+}
+
+// cleanup drops IPs whose last failure is older than AdminLockoutMax.
+func (t *loginThrottle) cleanup() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cutoff := t.now().Add(-AdminLockoutMax)
+	for ip, f := range t.ips {
+		if f.last.Before(cutoff) && f.lockedUntil.Before(t.now()) {
+			delete(t.ips, ip)
+		}
+	}
+}
+
+// RegisterLimitsConfig holds the /register abuse limits from the config file.
+// Zero values mean "off".
+type RegisterLimitsConfig struct {
+	PoWBits  int // leading zero bits of the proof-of-work hash
+	MaxPerIP int // registration attempts per client IP per UTC day
+}
+
+// SetRegisterLimits sets the proof-of-work difficulty and per-IP cap of /register.
+func (s *Server) SetRegisterLimits(limits RegisterLimitsConfig) {
+	s.register.mu.Lock()
+	defer s.register.mu.Unlock()
+	s.register.powBits = limits.PoWBits
+	s.register.maxPerIP = limits.MaxPerIP
+}
+
+// registerGuard issues and checks /register proof-of-work challenges and
+// counts registrations per IP.
+//
+// A challenge is "<unix seconds>.<random>.<bits>.<mac>", signed with a key
+// that lives as long as the process. The browser finds a nonce such that
+// sha256(challenge + ":" + nonce) starts with bits zero bits.
+type registerGuard struct {
+	key [32]byte
+	now func() time.Time
+
+	mu       sync.Mutex
+	powBits  int
+	maxPerIP int
+	spent    map[string]time.Time // used challenges until they expire
+	day      string               // UTC day perIP counts
+	perIP    map[string]int
+}
+
+var (
+	errPoWMissing = errors.New("please enable JavaScript to register")
+	errPoWInvalid = errors.New("the form expired, please try again")
+	errRegisterIP = errors.New("too many registrations from your network today, please try again tomorrow")
+)
+
+func newRegisterGuard() *registerGuard {
+	g := &registerGuard{now: time.Now, spent: map[string]time.Time{}, perIP: map[string]int{}}
+	_, _ = rand.Read(g.key[:])
+	return g
+}
+
+// challenge returns a new challenge and its difficulty, or "" when
+// proof-of-work is off.
+func (g *registerGuard) challenge() (string, int) {
+	g.mu.Lock()
+	n := g.powBits
+	g.mu.Unlock()
+	if n <= 0 {
+		return "", 0
+	}
+	var rnd [8]byte
+	_, _ = rand.Read(rnd[:])
+	body := fmt.Sprintf("%d.%s.%d", g.now().Unix(), hex.EncodeToString(rnd[:]), n)
+	return body + "." + g.mac(body), n
+}
+
+func (g *registerGuard) mac(body string) string {
+	m := hmac.New(sha256.New, g.key[:])
+	m.Write([]byte(body))
+	return hex.EncodeToString(m.Sum(nil)[:16])
+}
+
+// verify checks a solved challenge and spends it.
+func (g *registerGuard) verify(challenge, nonce string) error {
+	g.mu.Lock()
+	need := g.powBits
+	g.mu.Unlock()
+	if need <= 0 {
+		return nil
+	}
+	if challenge == "" || nonce == "" {
+		return errPoWMissing
+	}
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 || !hmac.Equal([]byte(g.mac(strings.Join(parts[:3], "."))), []byte(parts[3])) {
+		return errPoWInvalid
+	}
+	issued, err1 := strconv.ParseInt(parts[0], 10, 64)
+	n, err2 := strconv.Atoi(parts[2])
+	now := g.now()
+	if err1 != nil || err2 != nil || n < need || now.Sub(time.Unix(issued, 0)) > RegisterPoWMaxAge {
+		return errPoWInvalid
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(challenge+":"+nonce))) < n {
+		return errPoWInvalid
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, used := g.spent[challenge]; used {
+		return errPoWInvalid
+	}
+	for c, exp := range g.spent {
+		if now.After(exp) {
+			delete(g.spent, c)
+		}
+	}
+	g.spent[challenge] = time.Unix(issued, 0).Add(RegisterPoWMaxAge)
+	return nil
+}
+
+// allow counts a registration attempt from ip against the daily cap.
+func (g *registerGuard) allow(ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.maxPerIP <= 0 {
+		return nil
+	}
+	if day := g.now().UTC().Format(time.DateOnly); day != g.day {
+		g.day = day
+		clear(g.perIP)
+	}
+	if g.perIP[ip] >= g.maxPerIP {
+		return errRegisterIP
+	}
+	g.perIP[ip]++
+	return nil
+}
+
+func leadingZeroBits(sum [32]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// writeLockedOut answers a request from a locked-out IP.
+func writeLockedOut(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(d.Seconds())+1))
+	http.Error(w, "too many failed logins, try again later", http.StatusTooManyRequests)
+}
@@ -0,0 +1,152 @@
+package rendezvous
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle_locksOutRepeatedFailures(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	now := time.Unix(1_700_000_000, 0)
+	s.loginThrottle.now = func() time.Time { return now }
+
+	for i := 1; i < AdminLoginMaxFailures; i++ {
+		if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "guess"+strconv.Itoa(i))); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: %d", i, w.Code)
+		}
+	}
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "guess")); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("failure %d: %d, want lockout", AdminLoginMaxFailures, w.Code)
+	}
+	w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "secret"))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("right password while locked out: %d", w.Code)
+	}
+
+	// The next lockout lasts twice as long.
+	now = now.Add(AdminLockoutBase + time.Second)
+	for range AdminLoginMaxFailures {
+		adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "guess"))
+	}
+	if d := s.loginThrottle.locked("192.0.2.1"); d != 2*AdminLockoutBase {
+		t.Errorf("second lockout = %v", d)
+	}
+
+	now = now.Add(2*AdminLockoutBase + time.Second)
+	if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", basic("admin", "secret")); w.Code != http.StatusOK {
+		t.Fatalf("after lockout: %d", w.Code)
+	}
+	if d := s.loginThrottle.fail("192.0.2.1"); d != 0 {
+		t.Errorf("success did not reset the failures: lockout %v", d)
+	}
+}
+
+func TestLoginThrottle_ignoresRequestsWithoutCredentials(t *testing.T) {
+	s := newAdminTestServer(t, "secret")
+	for range AdminLoginMaxFailures + 1 {
+		if w := adminDo(s.handlePeersJSON, "GET", "/peers.json", "", nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("no credentials: %d", w.Code)
+		}
+	}
+}
+
+// solvePoW finds a nonce for challenge like the register page does.
+func solvePoW(challenge string, bits int) string {
+	for n := 0; ; n++ {
+		nonce := strconv.Itoa(n)
+		if leadingZeroBits(sha256.Sum256([]byte(challenge+":"+nonce))) >= bits {
+			return nonce
+		}
+	}
+}
+
+func TestRegisterGuard_proofOfWork(t *testing.T) {
+	g := newRegisterGuard()
+	now := time.Unix(1_700_000_000, 0)
+	g.now = func() time.Time { return now }
+	g.powBits = 8
+
+	c, bits := g.challenge()
+	if bits != 8 {
+		t.Fatalf("bits = %d", bits)
+	}
+	nonce := solvePoW(c, bits)
+	bad := "x"
+	for leadingZeroBits(sha256.Sum256([]byte(c+":"+bad))) >= bits {
+		bad += "x"
+	}
+	if err := g.verify(c, bad); err != errPoWInvalid {
+		t.Errorf("wrong nonce: %v", err)
+	}
+	if err := g.verify(c, ""); err != errPoWMissing {
+		t.Errorf("no nonce: %v", err)
+	}
+	if err := g.verify(strings.Replace(c, ".8.", ".1.", 1), nonce); err != errPoWInvalid {
+		t.Errorf("tampered difficulty: %v", err)
+	}
+	if err := g.verify(c, nonce); err != nil {
+		t.Fatalf("solved challenge: %v", err)
+	}
+	if err := g.verify(c, nonce); err != errPoWInvalid {
+		t.Errorf("replayed challenge: %v", err)
+	}
+
+	c, _ = g.challenge()
+	nonce = solvePoW(c, bits)
+	now = now.Add(RegisterPoWMaxAge + time.Second)
+	if err := g.verify(c, nonce); err != errPoWInvalid {
+		t.Errorf("expired challenge: %v", err)
+	}
+
+	g.powBits = 0
+	if c, _ := g.challenge(); c != "" || g.verify("", "") != nil {
+		t.Error("proof-of-work not off with 0 bits")
+	}
+}
+
+func TestRegisterGuard_dailyCapPerIP(t *testing.T) {
+	g := newRegisterGuard()
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+	g.maxPerIP = 2
+
+	for i := range 2 {
+		if err := g.allow("203.0.113.5"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	if err := g.allow("203.0.113.5"); err != errRegisterIP {
+		t.Errorf("over the cap: %v", err)
+	}
+	if err := g.allow("203.0.113.6"); err != nil {
+		t.Errorf("other IP: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := g.allow("203.0.113.5"); err != nil {
+		t.Errorf("next day: %v", err)
+	}
+}
+
+func TestRegister_refusesUnsolvedForm(t *testing.T) {
+	s := newAdminTestServer(t, "")
+	s.registration = NewRemoteRegistrationProvider("http://127.0.0.1:1", "")
+	s.SetRegisterLimits(RegisterLimitsConfig{PoWBits: 8})
+
+	form := url.Values{"email": {"a@example.org"}}
+	r := httptest.NewRequest("POST", "/register", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.handleRegisterRemote(w, r)
+	if !strings.Contains(w.Body.String(), "enable JavaScript") {
+		t.Errorf("unsolved form was not refused:\n%s", w.Body)
+	}
+	if !strings.Contains(w.Body.String(), `name="pow_challenge"`) {
+		t.Error("form shown again without a new challenge")
+	}
+}
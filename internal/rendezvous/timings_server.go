@@ -3,9 +3,9 @@ package rendezvous
 import "time"
 
 // Server-side rendezvous timings — the rendezvous server process.
+// The keep-alive on event streams is the tunable timeouts.SSEHeartbeat.
 const (
 	PeerLogInterval       = 60 * time.Second  // log connected peer count
-	WSHeartbeatInterval   = 25 * time.Second  // WS entangler heartbeat to peers
 	ReadHeaderTimeout     = 5 * time.Second   // HTTP server read header timeout
 	StatusCacheTTL        = 30 * time.Second  // cache duration for /status proxied responses
 	HealthCheckTimeout    = 2 * time.Second   // health check HTTP client
@@ -15,9 +15,15 @@ const (
 	PunchCutoffAge        = 5 * time.Minute   // ignore punch hints older than this
 	RelayStatusInterval   = 3 * time.Second   // relay status broadcast tick
 	PresenceClientTimeout = 5 * time.Second   // HTTP client for remote presence fetch
+	SalesReconcileInterval = 5 * time.Minute  // pull new template purchases from the credits service
+	PublicSiteRefreshInterval = 10 * time.Minute   // min age before a public site mirror is pulled again
+	PublicSiteMinRefresh      = 30 * time.Second   // min age before a changed site hash triggers a pull
+	PublicSiteFetchTimeout    = 2 * time.Minute    // one crawl of a peer's site over the relay
+	PublicSiteMaxAge          = 30 * 24 * time.Hour // drop mirrors of peers not seen for this long
 	PublishRateLimitWindow = time.Minute            // per-IP sliding window for /publish
 	PunchCooldown         = 60 * time.Second        // punch hint cooldown per peer pair
 	WSBackoff             = 250 * time.Millisecond  // initial WS reconnect backoff
+	WSHelloTimeout        = 10 * time.Second        // first presence frame from a WS peer that did not publish
 	RelayDuration         = 30 * time.Minute  // max duration per relayed connection
 	RelayReservationTTL   = time.Hour         // how long a relay reservation stays valid
 	RelayMaxReservations  = 128               // total relay reservations
@@ -26,4 +32,40 @@ const (
 	RelayMaxPerIP         = 128               // per-IP reservation constraint window
 	RelayMaxPerASN        = 128               // per-ASN reservation constraint window
 	RelayYamuxKeepAlive   = 5 * time.Second   // yamux keepalive ping interval (must beat port-forward timeouts)
+	RelayReportMaxAge     = 15 * time.Minute  // drop peer relay reports older than this
+	RelayReportMaxPeers   = 4096              // peers tracked in the relay report table
+	RelaySystemicMinPeers = 3                 // failing peers before the admin page flags the relay
+	RelayUsageMaxPeers    = 4096              // peers metered per day
+	RelaySelfTestTimeout  = 30 * time.Second  // whole relay self-test, from starting its peers to the last ping
+	ReputationMaxAge      = 90 * 24 * time.Hour // drop reputation records older than this
+	ReputationMaxRecords  = 4096              // reputation records kept (one per subject and reporter)
+	ReputationMaxReports  = 50                // abuse reports kept per record
+	ReputationMaxNote     = 500               // characters kept of a report note
+	ReputationClockSkew   = 5 * time.Minute   // how far in the future a record may be dated
+	PublicStatusMaxAge    = 30 * time.Second  // browser/CDN cache for /status.json
+	WidgetMaxAge          = time.Hour         // browser/CDN cache for /widget.js
+	DigestCheckInterval   = 15 * time.Minute  // how often due email digests are sent
+	InstallSessionTTL     = 15 * time.Minute  // how long a template install session can be followed
+	InstallMaxSessions    = 1024              // install sessions kept at once
+	InstallProgressInterval = 250 * time.Millisecond // min gap between download progress events
+	DocsReloadDelay       = 500 * time.Millisecond // settle time before reloading an edited docs dir
+	AdminAuthCacheTTL     = 5 * time.Minute   // how long a verified admin account password is cached
+	AdminTokenTouchInterval = time.Minute     // min gap between last-used updates of an API token
+	AdminLoginMaxFailures = 5                 // failed admin logins from one IP before a lockout
+	AdminLockoutBase      = time.Minute       // first lockout; each further one doubles
+	AdminLockoutMax       = time.Hour         // longest lockout, and how long failures are remembered
+	RegisterPoWMaxAge     = 10 * time.Minute  // how long a /register proof-of-work challenge is valid
+	TemplatePublishMaxSkew     = 5 * time.Minute // max clock skew of a signed template publish
+	TemplatePublishMaxFiles    = 1000            // files in one published template bundle
+	TemplatePublishMaxUnpacked = 64 << 20        // bytes of a published template, unpacked
+	AggregatorBatchInterval = 5 * time.Second   // default gap between presence batches sent upstream
+	AggregatorLocalTTL      = 30 * time.Second  // forget a local peer after this long without presence
+	AggregatorRelayRefresh  = 5 * time.Minute   // re-fetch the upstream relay info
+	AggregatorMaxBatch      = 256               // presence messages in one /publish/batch
+	AggregatorMaxBatchBytes = 1 << 20           // body of one /publish/batch
+	FederationBatchInterval = 5 * time.Second   // gap between presence batches to federated servers
+	FederationMaxBatch      = 512               // presence messages in one /federation/presence
+	FederationMaxBatchBytes = 2 << 20           // body of one /federation/presence
+	FederationMaxSkew       = 2 * time.Minute   // max clock skew of a signed federation batch
+	FederationMaxHops       = 4                 // servers a forwarded message may pass, origin included
 )
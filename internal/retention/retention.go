@@ -0,0 +1,117 @@
+// Package retention forgets peers: on request ("forget this peer") or in the
+// background, for peers not heard from in a configured number of days that
+// are neither favorites, followed, nor have a note. Forgetting removes the peer from the peer table, drops its cached
+// avatar and deletes everything the database holds about it.
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/avatar"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Stats describes the background pruning.
+type Stats struct {
+	Days           int    `json:"days"`            // retention in days; 0 = keep forever
+	LastRun        int64  `json:"last_run"`        // Unix ms, 0 = never
+	LastForgotten  int    `json:"last_forgotten"`  // peers forgotten by the last run
+	TotalForgotten int    `json:"total_forgotten"` // since start, including manual forgets
+	LastError      string `json:"last_error,omitempty"`
+}
+
+// Pruner forgets peers.
+type Pruner struct {
+	db      *storage.DB
+	peers   *state.PeerTable
+	avatars *avatar.Cache
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New creates a pruner. avatars may be nil.
+func New(db *storage.DB, peers *state.PeerTable, avatars *avatar.Cache) *Pruner {
+	return &Pruner{db: db, peers: peers, avatars: avatars}
+}
+
+// Forget deletes everything stored about one peer and returns the rows
+// removed per table.
+func (p *Pruner) Forget(peerID string) (map[string]int64, error) {
+	deleted, err := p.db.ForgetPeer(peerID)
+	if err != nil {
+		return nil, err
+	}
+	p.peers.Remove(peerID)
+	if p.avatars != nil {
+		p.avatars.Remove(peerID)
+	}
+	p.mu.Lock()
+	p.stats.TotalForgotten++
+	p.mu.Unlock()
+	return deleted, nil
+}
+
+// Prune forgets every peer not heard from in days days, except favorites
+// and peers with a note.
+// Peers currently online are kept whatever the database says.
+func (p *Pruner) Prune(days int, now time.Time) (int, error) {
+	p.mu.Lock()
+	p.stats.Days = days
+	p.mu.Unlock()
+	if days <= 0 {
+		return 0, nil
+	}
+
+	stale, err := p.db.StalePeers(now.AddDate(0, 0, -days))
+	n := 0
+	for _, id := range stale {
+		if sp, ok := p.peers.Get(id); ok && sp.OfflineSince.IsZero() {
+			continue
+		}
+		if _, ferr := p.Forget(id); ferr != nil {
+			err = ferr
+			continue
+		}
+		n++
+	}
+
+	p.mu.Lock()
+	p.stats.LastRun = now.UnixMilli()
+	p.stats.LastForgotten = n
+	p.stats.LastError = ""
+	if err != nil {
+		p.stats.LastError = err.Error()
+	}
+	p.mu.Unlock()
+	if n > 0 {
+		log.Printf("retention: forgot %d peers unseen for %d days", n, days)
+	}
+	return n, err
+}
+
+// Stats returns the pruning statistics.
+func (p *Pruner) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Run prunes now and then every interval until ctx is done. days is read
+// on every run so config changes apply without a restart.
+func (p *Pruner) Run(ctx context.Context, interval time.Duration, days func() int) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		p.Prune(days(), time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
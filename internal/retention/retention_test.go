@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/avatar"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func newPruner(t *testing.T) (*Pruner, *storage.DB, *state.PeerTable, *avatar.Cache) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	peers := state.NewPeerTable()
+	avatars := avatar.NewCache(dir)
+	return New(db, peers, avatars), db, peers, avatars
+}
+
+func TestForget(t *testing.T) {
+	p, db, peers, avatars := newPruner(t)
+	peers.Seed("p1", "Alice", "", "h", false, "", "", false, false)
+	avatars.Put("p1", "h", []byte("png"))
+	db.StoreChatMessage("p1", "p1", "hi", 1000)
+
+	deleted, err := p.Forget("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted["_chat_messages"] != 1 {
+		t.Fatalf("deleted = %v", deleted)
+	}
+	if _, ok := peers.Get("p1"); ok {
+		t.Fatal("p1 should be removed from the peer table")
+	}
+	if got, _ := avatars.GetAny("p1"); got != nil {
+		t.Fatal("cached avatar should be dropped")
+	}
+	if p.Stats().TotalForgotten != 1 {
+		t.Fatalf("stats = %+v", p.Stats())
+	}
+}
+
+func TestPrune(t *testing.T) {
+	p, db, peers, _ := newPruner(t)
+	now := time.Now()
+	old := now.AddDate(0, 0, -45).UnixMilli()
+	db.StoreChatMessage("gone", "gone", "hi", old)
+	db.StoreChatMessage("online", "online", "hi", old)
+	peers.Upsert("online", "Bob", "", "", false, "", "", false, false, "", false)
+
+	if n, _ := p.Prune(0, now); n != 0 {
+		t.Fatalf("retention 0 should keep everything, forgot %d", n)
+	}
+	n, err := p.Prune(30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("forgot %d peers, want 1 (online peers are kept)", n)
+	}
+	st := p.Stats()
+	if st.Days != 30 || st.LastForgotten != 1 || st.LastRun != now.UnixMilli() {
+		t.Fatalf("stats = %+v", st)
+	}
+}
@@ -0,0 +1,470 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+const (
+	// minInterval stops a rule from firing more than once in this window,
+	// which also breaks reply loops between two peers' chat rules.
+	minInterval = 10 * time.Second
+	// actionTimeout bounds one action (webhook, Lua call, message send).
+	actionTimeout = 30 * time.Second
+	// clockTick is how often time triggers are checked.
+	clockTick   = 20 * time.Second
+	eventBuffer = 64
+)
+
+// ErrNoRule is returned for an unknown rule ID.
+var ErrNoRule = errors.New("no such rule")
+
+// MQ is the subset of mq.Manager the engine needs.
+type MQ interface {
+	Send(ctx context.Context, peerID, topic string, payload any) (string, error)
+	SubscribeTopic(prefix string, fn func(from, topic string, payload any)) func()
+}
+
+// ChatStore records messages the engine sends, so they show in chat history.
+type ChatStore interface {
+	PersistOutbound(peerID, content string)
+}
+
+// ActionRunner runs registry actions (actions.Dispatcher).
+type ActionRunner interface {
+	Run(ctx context.Context, id string, params map[string]any) (any, error)
+}
+
+// Secrets looks up the credentials webhooks send (secrets.Store).
+type Secrets interface {
+	Get(name string) (string, error)
+}
+
+// LuaCaller invokes a Lua data function.
+type LuaCaller func(ctx context.Context, function string, params map[string]any) (any, error)
+
+type job struct {
+	ev     Event
+	ruleID string     // set for a manual test run: fire this rule regardless of trigger
+	done   chan error // test runs only
+}
+
+// Engine stores rules and runs the worker that evaluates them.
+type Engine struct {
+	db    *storage.DB
+	mq    MQ
+	peers *state.PeerTable
+
+	chat    ChatStore
+	actions ActionRunner
+	lua     LuaCaller
+	secrets Secrets
+	client  *http.Client
+
+	mu       sync.Mutex
+	rules    []Rule
+	lastFire map[string]time.Time
+	lastDay  map[string]string // rule ID -> date a time trigger last fired
+
+	jobs chan job
+}
+
+// New creates an engine over db. Call Start to begin evaluating rules.
+func New(db *storage.DB, m MQ, peers *state.PeerTable) *Engine {
+	return &Engine{
+		db:       db,
+		mq:       m,
+		peers:    peers,
+		client:   &http.Client{Timeout: actionTimeout},
+		lastFire: make(map[string]time.Time),
+		lastDay:  make(map[string]string),
+		jobs:     make(chan job, eventBuffer),
+	}
+}
+
+// SetChat wires chat history for message actions.
+func (e *Engine) SetChat(c ChatStore) { e.chat = c }
+
+// SetActions wires the actions registry for "action" rules.
+func (e *Engine) SetActions(a ActionRunner) { e.actions = a }
+
+// SetLua wires Lua function calls for "lua" rules.
+func (e *Engine) SetLua(fn LuaCaller) { e.lua = fn }
+
+// SetSecrets wires the secrets store webhook credentials come from.
+func (e *Engine) SetSecrets(s Secrets) { e.secrets = s }
+
+// Start loads the rules and runs the worker and event sources until ctx ends.
+func (e *Engine) Start(ctx context.Context) {
+	if err := e.reload(); err != nil {
+		log.Printf("RULES: load failed: %v", err)
+	}
+	if e.mq != nil {
+		unsub := e.mq.SubscribeTopic(mq.TopicChat, func(from, topic string, payload any) {
+			if topic != mq.TopicChat && topic != mq.TopicChatBroadcast {
+				return
+			}
+			if p, ok := payload.(map[string]any); ok {
+				if text, _ := p["content"].(string); text != "" {
+					e.Notify(Event{Type: TriggerChat, PeerID: from, Text: text})
+				}
+			}
+		})
+		go func() { <-ctx.Done(); unsub() }()
+	}
+	if e.peers != nil {
+		go e.watchPeers(ctx)
+	}
+	go e.run(ctx)
+}
+
+// Notify hands an event to the worker. It never blocks; events are dropped
+// if the worker is far behind.
+func (e *Engine) Notify(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	select {
+	case e.jobs <- job{ev: ev}:
+	default:
+		log.Printf("RULES: worker busy, dropping %s event", ev.Type)
+	}
+}
+
+// List returns all rules with their latest run state.
+func (e *Engine) List() ([]Rule, error) {
+	rows, err := e.db.ListRules()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Rule, 0, len(rows))
+	for _, row := range rows {
+		r, err := fromRow(row)
+		if err != nil {
+			log.Printf("RULES: %v", err)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Save validates and stores r, assigning an ID to new rules.
+func (e *Engine) Save(r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+	if r.ID == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return r, err
+		}
+		r.ID = hex.EncodeToString(b)
+		r.CreatedAt = time.Now().UnixMilli()
+	}
+	if strings.TrimSpace(r.Name) == "" {
+		r.Name = r.Trigger.Type + " → " + r.Action.Type
+	}
+	row, err := toRow(r)
+	if err != nil {
+		return r, err
+	}
+	if err := e.db.SaveRule(row); err != nil {
+		return r, err
+	}
+	return r, e.reload()
+}
+
+// Delete removes a rule.
+func (e *Engine) Delete(id string) error {
+	if err := e.db.DeleteRule(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRule
+		}
+		return err
+	}
+	return e.reload()
+}
+
+// Test runs a rule's action now on the worker, with a sample event, and
+// returns its error.
+func (e *Engine) Test(ctx context.Context, id string) error {
+	r, ok := e.rule(id)
+	if !ok {
+		return ErrNoRule
+	}
+	ev := Event{Type: r.Trigger.Type, PeerID: r.Trigger.PeerID, Text: r.Trigger.Word, Time: time.Now()}
+	j := job{ev: ev, ruleID: id, done: make(chan error, 1)}
+	select {
+	case e.jobs <- j:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Engine) reload() error {
+	rules, err := e.List()
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+	return nil
+}
+
+// run is the worker: the only goroutine that executes actions.
+func (e *Engine) run(ctx context.Context) {
+	tick := time.NewTicker(clockTick)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-tick.C:
+			e.checkClock(ctx, now)
+		case j := <-e.jobs:
+			if j.ruleID != "" {
+				j.done <- e.fireByID(ctx, j.ruleID, j.ev)
+				continue
+			}
+			for _, r := range e.matching(j.ev) {
+				e.fire(ctx, r, j.ev)
+			}
+		}
+	}
+}
+
+// matching returns the enabled rules ev fires, skipping rules that fired
+// within minInterval.
+func (e *Engine) matching(ev Event) []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []Rule
+	for _, r := range e.rules {
+		if !r.Enabled || !r.Trigger.Matches(ev) {
+			continue
+		}
+		if ev.Time.Sub(e.lastFire[r.ID]) < minInterval {
+			continue
+		}
+		e.lastFire[r.ID] = ev.Time
+		out = append(out, r)
+	}
+	return out
+}
+
+func (e *Engine) checkClock(ctx context.Context, now time.Time) {
+	hhmm, day := now.Format("15:04"), now.Format("2006-01-02")
+	e.mu.Lock()
+	var due []Rule
+	for _, r := range e.rules {
+		if r.Enabled && r.Trigger.Type == TriggerTime && r.Trigger.At == hhmm && e.lastDay[r.ID] != day {
+			e.lastDay[r.ID] = day
+			due = append(due, r)
+		}
+	}
+	e.mu.Unlock()
+	for _, r := range due {
+		e.fire(ctx, r, Event{Type: TriggerTime, PeerID: r.Trigger.PeerID, Time: now})
+	}
+}
+
+func (e *Engine) fireByID(ctx context.Context, id string, ev Event) error {
+	r, ok := e.rule(id)
+	if !ok {
+		return ErrNoRule
+	}
+	return e.fire(ctx, r, ev)
+}
+
+func (e *Engine) rule(id string) (Rule, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range e.rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (e *Engine) fire(ctx context.Context, r Rule, ev Event) error {
+	actx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+	err := e.runAction(actx, r, ev)
+
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+		log.Printf("RULES: %q failed: %v", r.Name, err)
+	} else {
+		log.Printf("RULES: %q fired (%s)", r.Name, ev.Type)
+	}
+	if dbErr := e.db.RecordRuleRun(r.ID, time.Now().UnixMilli(), msg); dbErr != nil {
+		log.Printf("RULES: record run: %v", dbErr)
+	}
+	return err
+}
+
+func (e *Engine) runAction(ctx context.Context, r Rule, ev Event) error {
+	a := r.Action
+	switch a.Type {
+	case ActionMessage:
+		peer := a.PeerID
+		if peer == "" {
+			peer = ev.PeerID
+		}
+		if peer == "" {
+			return fmt.Errorf("no peer to message")
+		}
+		text := e.expand(a.Text, ev)
+		if _, err := e.mq.Send(ctx, peer, mq.TopicChat, map[string]any{"content": text}); err != nil {
+			return err
+		}
+		if e.chat != nil {
+			e.chat.PersistOutbound(peer, text)
+		}
+		return nil
+
+	case ActionLua:
+		if e.lua == nil {
+			return fmt.Errorf("lua is not running")
+		}
+		params := e.expandParams(a.Params, ev)
+		params["event"] = map[string]any{
+			"type":    ev.Type,
+			"peer_id": ev.PeerID,
+			"text":    ev.Text,
+			"file":    ev.File,
+			"time":    ev.Time.UnixMilli(),
+		}
+		_, err := e.lua(ctx, a.Function, params)
+		return err
+
+	case ActionRun:
+		if e.actions == nil {
+			return fmt.Errorf("actions are not available")
+		}
+		_, err := e.actions.Run(ctx, a.ActionID, e.expandParams(a.Params, ev))
+		return err
+
+	case ActionWebhook:
+		body, err := json.Marshal(map[string]any{
+			"rule":  map[string]string{"id": r.ID, "name": r.Name},
+			"event": ev,
+		})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if a.Secret != "" {
+			if e.secrets == nil {
+				return fmt.Errorf("webhook: secrets are not available")
+			}
+			token, err := e.secrets.Get(a.Secret)
+			if err != nil {
+				return fmt.Errorf("webhook: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := e.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook: %s", resp.Status)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", a.Type)
+}
+
+// expand fills {peer}, {peer_id}, {text} and {file} from ev.
+func (e *Engine) expand(s string, ev Event) string {
+	return strings.NewReplacer(
+		"{peer}", e.peerName(ev.PeerID),
+		"{peer_id}", ev.PeerID,
+		"{text}", ev.Text,
+		"{file}", ev.File,
+	).Replace(s)
+}
+
+func (e *Engine) expandParams(params map[string]any, ev Event) map[string]any {
+	out := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		if s, ok := v.(string); ok {
+			v = e.expand(s, ev)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (e *Engine) peerName(id string) string {
+	if e.peers != nil {
+		if sp, ok := e.peers.Get(id); ok && sp.Content != "" {
+			return sp.Content
+		}
+	}
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// watchPeers turns peer table updates into peer_online events. Peers that
+// are already online when the engine starts do not fire.
+func (e *Engine) watchPeers(ctx context.Context) {
+	online := make(map[string]bool)
+	for id, sp := range e.peers.Snapshot() {
+		if sp.Reachable {
+			online[id] = true
+		}
+	}
+	ch := e.peers.Subscribe()
+	defer e.peers.Unsubscribe(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch {
+			case evt.Type == "remove" || evt.Peer == nil || !evt.Peer.Reachable:
+				delete(online, evt.PeerID)
+			case !online[evt.PeerID]:
+				online[evt.PeerID] = true
+				e.Notify(Event{Type: TriggerPeerOnline, PeerID: evt.PeerID})
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package rules is a small "when X then Y" automation engine inside the
+// peer: a trigger (peer comes online, chat contains a word, file received,
+// time of day) fires an action (send a message, run a Lua function, run a
+// registry action such as listen.play, call a webhook).
+//
+// Rules live in storage and are evaluated by a single worker goroutine, so
+// actions never run concurrently and a slow webhook only delays later rules.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Trigger types.
+const (
+	TriggerPeerOnline = "peer_online"   // optional peer_id
+	TriggerChat       = "chat_contains" // word, optional peer_id
+	TriggerFile       = "file_received" // optional peer_id, optional word (matches the file name)
+	TriggerTime       = "time"          // at ("HH:MM", local time), daily
+)
+
+// Action types.
+const (
+	ActionMessage = "message" // text to peer_id (empty = the peer behind the event)
+	ActionLua     = "lua"     // function with params
+	ActionRun     = "action"  // registry action_id with params (see package actions)
+	ActionWebhook = "webhook" // POST the event as JSON to url, optionally with a secret as bearer token
+)
+
+// Trigger says when a rule fires.
+type Trigger struct {
+	Type   string `json:"type"`
+	PeerID string `json:"peer_id,omitempty"`
+	Word   string `json:"word,omitempty"`
+	At     string `json:"at,omitempty"`
+}
+
+// Action says what a rule does. Text and string params may use {peer},
+// {peer_id}, {text} and {file}, filled in from the event.
+type Action struct {
+	Type     string         `json:"type"`
+	PeerID   string         `json:"peer_id,omitempty"`
+	Text     string         `json:"text,omitempty"`
+	Function string         `json:"function,omitempty"`
+	ActionID string         `json:"action_id,omitempty"`
+	Params   map[string]any `json:"params,omitempty"`
+	URL      string         `json:"url,omitempty"`
+	Secret   string         `json:"secret,omitempty"` // webhook: name of the secret sent as bearer token
+}
+
+// Rule is one automation rule.
+type Rule struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Enabled   bool    `json:"enabled"`
+	Trigger   Trigger `json:"trigger"`
+	Action    Action  `json:"action"`
+	CreatedAt int64   `json:"created_at"`
+	LastRun   int64   `json:"last_run"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+// Event is something that happened which rules may react to.
+type Event struct {
+	Type   string    `json:"type"` // a Trigger type
+	PeerID string    `json:"peer_id,omitempty"`
+	Text   string    `json:"text,omitempty"` // chat message
+	File   string    `json:"file,omitempty"` // received file name
+	Time   time.Time `json:"time"`
+}
+
+// Validate checks that the rule is complete.
+func (r Rule) Validate() error {
+	t, a := r.Trigger, r.Action
+	switch t.Type {
+	case TriggerPeerOnline, TriggerFile:
+	case TriggerChat:
+		if strings.TrimSpace(t.Word) == "" {
+			return fmt.Errorf("chat trigger needs a word")
+		}
+	case TriggerTime:
+		if _, err := time.Parse("15:04", t.At); err != nil {
+			return fmt.Errorf("time trigger needs at as HH:MM")
+		}
+	default:
+		return fmt.Errorf("unknown trigger %q", t.Type)
+	}
+
+	switch a.Type {
+	case ActionMessage:
+		if strings.TrimSpace(a.Text) == "" {
+			return fmt.Errorf("message action needs text")
+		}
+		if a.PeerID == "" && t.Type == TriggerTime && t.PeerID == "" {
+			return fmt.Errorf("message action needs a peer for time triggers")
+		}
+	case ActionLua:
+		if a.Function == "" {
+			return fmt.Errorf("lua action needs a function")
+		}
+	case ActionRun:
+		if a.ActionID == "" {
+			return fmt.Errorf("action needs an action_id")
+		}
+	case ActionWebhook:
+		u, err := url.Parse(a.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook needs an http(s) url")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	return nil
+}
+
+// Matches reports whether ev fires the rule's trigger. Time triggers are
+// matched by the worker's clock, not by events.
+func (t Trigger) Matches(ev Event) bool {
+	if ev.Type != t.Type || t.Type == TriggerTime {
+		return false
+	}
+	if t.PeerID != "" && t.PeerID != ev.PeerID {
+		return false
+	}
+	switch t.Type {
+	case TriggerChat:
+		return containsFold(ev.Text, t.Word)
+	case TriggerFile:
+		return t.Word == "" || containsFold(ev.File, t.Word)
+	}
+	return true
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(sub)))
+}
+
+func fromRow(row storage.RuleRow) (Rule, error) {
+	r := Rule{
+		ID:        row.ID,
+		Name:      row.Name,
+		Enabled:   row.Enabled,
+		CreatedAt: row.CreatedAt,
+		LastRun:   row.LastRun,
+		LastError: row.LastError,
+	}
+	if err := json.Unmarshal([]byte(row.Trigger), &r.Trigger); err != nil {
+		return r, fmt.Errorf("rule %s: trigger: %w", row.ID, err)
+	}
+	if err := json.Unmarshal([]byte(row.Action), &r.Action); err != nil {
+		return r, fmt.Errorf("rule %s: action: %w", row.ID, err)
+	}
+	return r, nil
+}
+
+func toRow(r Rule) (storage.RuleRow, error) {
+	trig, err := json.Marshal(r.Trigger)
+	if err != nil {
+		return storage.RuleRow{}, err
+	}
+	act, err := json.Marshal(r.Action)
+	if err != nil {
+		return storage.RuleRow{}, err
+	}
+	return storage.RuleRow{
+		ID:        r.ID,
+		Name:      r.Name,
+		Enabled:   r.Enabled,
+		Trigger:   string(trig),
+		Action:    string(act),
+		CreatedAt: r.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+type fakeMQ struct {
+	mu   sync.Mutex
+	sent []string
+	sub  func(from, topic string, payload any)
+}
+
+func (f *fakeMQ) Send(_ context.Context, peerID, topic string, payload any) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, peerID+"|"+payload.(map[string]any)["content"].(string))
+	return "id", nil
+}
+
+func (f *fakeMQ) SubscribeTopic(_ string, fn func(from, topic string, payload any)) func() {
+	f.sub = fn
+	return func() {}
+}
+
+func (f *fakeMQ) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+func testEngine(t *testing.T) (*Engine, *fakeMQ) {
+	t.Helper()
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m := &fakeMQ{}
+	e := New(db, m, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	e.Start(ctx)
+	return e, m
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out")
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		r    Rule
+		ok   bool
+	}{
+		{"chat reply", Rule{Trigger: Trigger{Type: TriggerChat, Word: "ping"}, Action: Action{Type: ActionMessage, Text: "pong"}}, true},
+		{"chat without word", Rule{Trigger: Trigger{Type: TriggerChat}, Action: Action{Type: ActionMessage, Text: "x"}}, false},
+		{"bad time", Rule{Trigger: Trigger{Type: TriggerTime, At: "25:00"}, Action: Action{Type: ActionRun, ActionID: "listen.play"}}, false},
+		{"time message without peer", Rule{Trigger: Trigger{Type: TriggerTime, At: "08:00"}, Action: Action{Type: ActionMessage, Text: "hi"}}, false},
+		{"webhook scheme", Rule{Trigger: Trigger{Type: TriggerPeerOnline}, Action: Action{Type: ActionWebhook, URL: "file:///etc/passwd"}}, false},
+		{"unknown trigger", Rule{Trigger: Trigger{Type: "moon"}, Action: Action{Type: ActionLua, Function: "f"}}, false},
+	}
+	for _, tc := range cases {
+		if err := tc.r.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+}
+
+func TestTriggerMatches(t *testing.T) {
+	chat := Trigger{Type: TriggerChat, Word: "Ping", PeerID: "p1"}
+	if !chat.Matches(Event{Type: TriggerChat, PeerID: "p1", Text: "ping?"}) {
+		t.Error("case-insensitive word not matched")
+	}
+	if chat.Matches(Event{Type: TriggerChat, PeerID: "p2", Text: "ping"}) {
+		t.Error("other peer matched")
+	}
+	file := Trigger{Type: TriggerFile, Word: ".pdf"}
+	if !file.Matches(Event{Type: TriggerFile, PeerID: "p", File: "Report.PDF"}) || file.Matches(Event{Type: TriggerFile, File: "a.png"}) {
+		t.Error("file name filter")
+	}
+}
+
+func TestChatRuleRepliesOnce(t *testing.T) {
+	e, m := testEngine(t)
+	if _, err := e.Save(Rule{
+		Enabled: true,
+		Trigger: Trigger{Type: TriggerChat, Word: "ping"},
+		Action:  Action{Type: ActionMessage, Text: "pong to {peer_id}: {text}"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.sub("peer-a", "chat", map[string]any{"content": "ping"})
+	waitFor(t, func() bool { return len(m.messages()) == 1 })
+	if got := m.messages()[0]; got != "peer-a|pong to peer-a: ping" {
+		t.Fatalf("sent %q", got)
+	}
+
+	// A second match inside minInterval is dropped (reply-loop guard).
+	m.sub("peer-a", "chat", map[string]any{"content": "ping again"})
+	time.Sleep(50 * time.Millisecond)
+	if n := len(m.messages()); n != 1 {
+		t.Fatalf("sent %d messages, want 1", n)
+	}
+
+	list, _ := e.List()
+	if len(list) != 1 || list[0].LastRun == 0 || list[0].LastError != "" {
+		t.Fatalf("rules = %+v", list)
+	}
+}
+
+func TestWebhookAndTest(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	e, _ := testEngine(t)
+	r, err := e.Save(Rule{
+		Name:    "hook",
+		Trigger: Trigger{Type: TriggerFile},
+		Action:  Action{Type: ActionWebhook, URL: srv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabled rules ignore events but can still be tested.
+	e.Notify(Event{Type: TriggerFile, PeerID: "p", File: "x.txt"})
+	if err := e.Test(context.Background(), r.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got["rule"].(map[string]any)["name"] != "hook" || got["event"].(map[string]any)["type"] != TriggerFile {
+		t.Fatalf("webhook body = %v", got)
+	}
+
+	if err := e.Test(context.Background(), "nope"); err != ErrNoRule {
+		t.Fatalf("unknown rule: %v", err)
+	}
+	if err := e.Delete(r.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakeSecrets map[string]string
+
+func (f fakeSecrets) Get(name string) (string, error) {
+	if v, ok := f[name]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("no such secret: %s", name)
+}
+
+func TestWebhookSecret(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	e, _ := testEngine(t)
+	r, err := e.Save(Rule{
+		Name:    "hook",
+		Trigger: Trigger{Type: TriggerFile},
+		Action:  Action{Type: ActionWebhook, URL: srv.URL, Secret: "hook"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Test(context.Background(), r.ID); err == nil {
+		t.Fatal("webhook ran without its secret")
+	}
+	e.SetSecrets(fakeSecrets{"hook": "tok"})
+	if err := e.Test(context.Background(), r.ID); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer tok" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+}
+
+func TestCheckClockOncePerDay(t *testing.T) {
+	e, _ := testEngine(t)
+	fired := 0
+	e.SetActions(runnerFunc(func() { fired++ }))
+	if _, err := e.Save(Rule{
+		Enabled: true,
+		Trigger: Trigger{Type: TriggerTime, At: "07:30"},
+		Action:  Action{Type: ActionRun, ActionID: "listen.play"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 1, 2, 7, 30, 5, 0, time.Local)
+	e.checkClock(context.Background(), at)
+	e.checkClock(context.Background(), at.Add(20*time.Second))
+	e.checkClock(context.Background(), at.Add(24*time.Hour))
+	if fired != 2 {
+		t.Fatalf("fired %d times, want 2", fired)
+	}
+}
+
+type runnerFunc func()
+
+func (f runnerFunc) Run(context.Context, string, map[string]any) (any, error) {
+	f()
+	return nil, nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/group_types/chat"
+	"github.com/petervdpas/goop2/internal/group_types/listen"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+)
+
+// Payloads that are published as maps, mirrored here so they get types.
+// Keep them in sync with the publishers named in each comment.
+
+// callRinging mirrors callLog.ringing in internal/app/modes/calllog.go.
+type callRinging struct {
+	ChannelID string `json:"channel_id"`
+	PeerID    string `json:"peer_id"`
+	Media     string `json:"media"` // "audio" or "video"
+}
+
+// callMissed mirrors callLog.end in internal/app/modes/calllog.go.
+type callMissed struct {
+	ChannelID string `json:"channel_id"`
+	PeerID    string `json:"peer_id"`
+	Media     string `json:"media"`
+	StartedAt int64  `json:"started_at"` // unix millis
+	Missed    int    `json:"missed"`     // unseen missed calls
+}
+
+// mqLogEntry mirrors Manager.logMQEvent in internal/mq/manager.go.
+type mqLogEntry struct {
+	Dir       string `json:"dir"` // "recv", "send" or "error"
+	Topic     string `json:"topic"`
+	Peer      string `json:"peer"`
+	TS        int64  `json:"ts"`
+	Error     string `json:"error,omitempty"`
+	Via       string `json:"via,omitempty"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+}
+
+// listenState mirrors Manager.notifyBrowser in group_types/listen.
+type listenState struct {
+	Group *listen.Group `json:"group"`
+}
+
+// chatRoomEvent mirrors chatMsg in group_types/chat.
+type chatRoomEvent struct {
+	Action   string         `json:"action"`
+	Message  *chat.Message  `json:"message,omitempty"`
+	Messages []chat.Message `json:"messages,omitempty"`
+	Members  []chat.Member  `json:"members,omitempty"`
+}
+
+// callSignals are the payloads on call:{channelID}, tagged by their type.
+var callSignals = []struct {
+	typ reflect.Type
+	tag string
+}{
+	{reflect.TypeFor[mq.CallRequestPayload](), mq.CallTypeRequest},
+	{reflect.TypeFor[mq.CallAckPayload](), mq.CallTypeAck},
+	{reflect.TypeFor[mq.CallOfferPayload](), mq.CallTypeOffer},
+	{reflect.TypeFor[mq.CallAnswerPayload](), mq.CallTypeAnswer},
+	{reflect.TypeFor[mq.CallICEPayload](), mq.CallTypeICE},
+	{reflect.TypeFor[mq.CallHangupPayload](), mq.CallTypeHangup},
+}
+
+// topicTypes maps the known topics (see internal/mq/topics.go) to their
+// payloads. Patterns ending in * are prefixes, most specific first.
+var topicTypes = []struct {
+	topic string
+	typ   reflect.Type // nil: the CallSignal union
+}{
+	{mq.TopicPeerAnnounce, reflect.TypeFor[mq.PeerAnnouncePayload]()},
+	{mq.TopicPeerGone, reflect.TypeFor[mq.PeerGonePayload]()},
+	{mq.TopicIdentityResponse, reflect.TypeFor[mq.PeerAnnouncePayload]()},
+	{mq.TopicGroupInvite, reflect.TypeFor[group.Event]()},
+	{mq.TopicLogMQ, reflect.TypeFor[mqLogEntry]()},
+	{mq.TopicSecurityConsent, reflect.TypeFor[p2p.ConsentRequest]()},
+	{mq.TopicCallRinging, reflect.TypeFor[callRinging]()},
+	{mq.TopicCallMissed, reflect.TypeFor[callMissed]()},
+	{mq.TopicTemplateUpdate, reflect.TypeFor[mq.TemplateUpdatePayload]()},
+	{mq.TopicCallLoopbackPrefix + "*", reflect.TypeFor[mq.CallLoopbackICEPayload]()},
+	{mq.TopicCallPrefix + "*", nil},
+	{mq.TopicGroupPrefix + "*", reflect.TypeFor[group.Event]()},
+	{mq.TopicListenPrefix + "*", reflect.TypeFor[listenState]()},
+	{mq.TopicChatRoomPrefix + "*", reflect.TypeFor[chatRoomEvent]()},
+	{mq.TopicDocsDownloadPrefix + "*", reflect.TypeFor[mq.DocsDownloadProgressPayload]()},
+	{mq.TopicHealthStartup, reflect.TypeFor[mq.HealthStartupPayload]()},
+}
+
+// rename gives types from the group packages names that say where they
+// belong; everything else keeps its Go name.
+var rename = map[reflect.Type]string{
+	reflect.TypeFor[group.Event]():      "GroupEvent",
+	reflect.TypeFor[chat.Message]():     "ChatMessage",
+	reflect.TypeFor[chat.Member]():      "ChatMember",
+	reflect.TypeFor[listen.Group]():     "ListenGroup",
+	reflect.TypeFor[listen.Track]():     "ListenTrack",
+	reflect.TypeFor[listen.PlayState](): "ListenPlayState",
+	reflect.TypeFor[mqLogEntry]():       "MQLogEntry",
+}
+
+// eventTypes renders Go payload structs as TypeScript interfaces.
+type eventTypes struct {
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+	order []reflect.Type
+	tags  map[reflect.Type]string // literal for the "type" field
+}
+
+func newEventTypes() *eventTypes {
+	e := &eventTypes{names: map[reflect.Type]string{}, taken: map[string]reflect.Type{}, tags: map[reflect.Type]string{}}
+	for _, cs := range callSignals {
+		e.tags[cs.typ] = cs.tag
+		e.ref(cs.typ)
+	}
+	e.tags[reflect.TypeFor[mq.CallLoopbackICEPayload]()] = mq.CallTypeLoopbackICE
+	for _, tt := range topicTypes {
+		if tt.typ != nil {
+			e.ref(tt.typ)
+		}
+	}
+	return e
+}
+
+// ref registers a named struct and returns its TypeScript name.
+func (e *eventTypes) ref(t reflect.Type) string {
+	if name, ok := e.names[t]; ok {
+		return name
+	}
+	name := rename[t]
+	if name == "" {
+		name = upperFirst(t.Name())
+	}
+	if _, clash := e.taken[name]; clash {
+		name = upperFirst(t.PkgPath()[strings.LastIndexByte(t.PkgPath(), '/')+1:]) + name
+	}
+	e.names[t] = name
+	e.taken[name] = t
+	e.order = append(e.order, t)
+	return name
+}
+
+func (e *eventTypes) tsType(t reflect.Type, indent string) string {
+	if t == reflect.TypeFor[time.Time]() {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return e.tsType(t.Elem(), indent) + " | null"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+		el := e.tsType(t.Elem(), indent)
+		if strings.Contains(el, " ") {
+			el = "(" + el + ")"
+		}
+		return el + "[]"
+	case reflect.Map:
+		return "Record<string, " + e.tsType(t.Elem(), indent) + ">"
+	case reflect.Struct:
+		if t.Name() != "" {
+			return e.ref(t)
+		}
+		return e.fields(t, indent)
+	}
+	return "unknown"
+}
+
+// fields renders a struct body following encoding/json's rules for names,
+// omitempty and embedded structs.
+func (e *eventTypes) fields(t reflect.Type, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	e.writeFields(&b, t, indent)
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func (e *eventTypes) writeFields(b *strings.Builder, t reflect.Type, indent string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			e.writeFields(b, f.Type, indent)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		opt := ""
+		if strings.Contains(","+opts+",", ",omitempty,") {
+			opt = "?"
+		}
+		ft := f.Type
+		if opt == "?" && ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		ts := e.tsType(ft, indent+"  ")
+		if lit, ok := e.tags[t]; ok && name == "type" {
+			ts = fmt.Sprintf("%q", lit)
+		}
+		fmt.Fprintf(b, "%s  %s%s: %s;\n", indent, propName(name), opt, ts)
+	}
+}
+
+func (e *eventTypes) writeNamespace(b *bytes.Buffer, indent string) {
+	in := indent + "  "
+	b.WriteString(indent + "/** MQ event payloads, from the Go types that publish them. */\n")
+	b.WriteString(indent + "namespace Events {\n")
+
+	// Rendering a struct can register the structs it uses, so walk e.order
+	// while it grows and sort afterwards.
+	bodies := map[string]string{}
+	for i := 0; i < len(e.order); i++ {
+		t := e.order[i]
+		bodies[e.names[t]] = e.fields(t, in)
+	}
+	names := make([]string, 0, len(bodies))
+	for n := range bodies {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(b, "%sinterface %s %s\n\n", in, n, bodies[n])
+	}
+
+	var signals []string
+	for _, cs := range callSignals {
+		signals = append(signals, e.names[cs.typ])
+	}
+	fmt.Fprintf(b, "%s/** Signals on call:{channelID}. Native-mode call-data, call-caption and call-device signals are untyped. */\n", in)
+	fmt.Fprintf(b, "%stype CallSignal =\n%s  | %s\n%s  | { type: string; [key: string]: unknown };\n\n", in, in, strings.Join(signals, "\n"+in+"  | "), in)
+
+	fmt.Fprintf(b, "%s/** One message from /api/mq/events. */\n", in)
+	fmt.Fprintf(b, "%sinterface Event<P = unknown, T extends string = string> {\n", in)
+	fmt.Fprintf(b, "%s  topic: T;\n%s  from: string;\n%s  payload: P;\n", in, in, in)
+	fmt.Fprintf(b, "%s  /** Confirms delivery to the sender; unhandled messages are acked automatically. */\n", in)
+	fmt.Fprintf(b, "%s  ack(): void;\n%s}\n\n", in, in)
+
+	fmt.Fprintf(b, "%s/** Payloads of the topics with a fixed name. */\n", in)
+	fmt.Fprintf(b, "%sinterface Topics {\n", in)
+	var known []string
+	for _, tt := range topicTypes {
+		if !strings.HasSuffix(tt.topic, "*") {
+			fmt.Fprintf(b, "%s  %q: %s;\n", in, tt.topic, e.names[tt.typ])
+		}
+	}
+	fmt.Fprintf(b, "%s}\n\n", in)
+	known = append(known, "{ [K in keyof Topics]: Event<Topics[K], K> }[keyof Topics]")
+
+	var overloads []string
+	for _, tt := range topicTypes {
+		prefix, ok := strings.CutSuffix(tt.topic, "*")
+		if !ok {
+			continue
+		}
+		payload := "CallSignal"
+		if tt.typ != nil {
+			payload = e.names[tt.typ]
+		}
+		ev := fmt.Sprintf("Event<%s, `%s${string}`>", payload, prefix)
+		known = append(known, ev)
+		overloads = append(overloads, fmt.Sprintf("on(topic: `%s${string}`, fn: (e: %s) => void): () => void;", prefix, ev))
+	}
+	fmt.Fprintf(b, "%s/** Every known topic, discriminated by topic. */\n", in)
+	fmt.Fprintf(b, "%stype Known =\n%s  | %s;\n\n", in, in, strings.Join(known, "\n"+in+"  | "))
+
+	fmt.Fprintf(b, "%s/**\n%s * Client.events: subscribe to MQ topics (needs goop-mq.js). A topic ending\n", in, in)
+	fmt.Fprintf(b, "%s * in * matches every topic with that prefix. Both return an unsubscribe function.\n%s */\n", in, in)
+	fmt.Fprintf(b, "%sinterface Helper {\n", in)
+	for _, m := range []string{"on", "once"} {
+		fmt.Fprintf(b, "%s  %s<K extends keyof Topics>(topic: K, fn: (e: Event<Topics[K], K>) => void): () => void;\n", in, m)
+		for _, o := range overloads {
+			fmt.Fprintf(b, "%s  %s%s\n", in, m, strings.TrimPrefix(o, "on"))
+		}
+		fmt.Fprintf(b, "%s  %s(pattern: `${string}*`, fn: (e: Known) => void): () => void;\n", in, m)
+		fmt.Fprintf(b, "%s  %s(topic: string, fn: (e: Event) => void): () => void;\n", in, m)
+	}
+	fmt.Fprintf(b, "%s}\n", in)
+	b.WriteString(indent + "}\n")
+}
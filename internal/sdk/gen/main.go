@@ -0,0 +1,47 @@
+// Command gen writes the typed peer API client for templates,
+// goop-client.js and goop-client.d.ts, from docs/swagger.json and the MQ
+// topic payloads. Run it through go generate in internal/sdk after changing
+// route annotations (and regenerating the swagger docs) or topic payloads.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "../../docs/swagger.json", "swagger 2.0 spec generated by swag")
+	outDir := flag.String("out", ".", "directory to write goop-client.js and goop-client.d.ts to")
+	flag.Parse()
+
+	raw, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	js, dts, err := generate(raw)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, "goop-client.js"), js, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, "goop-client.d.ts"), dts, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate renders the client script and its type declarations.
+func generate(rawSpec []byte) (js, dts []byte, err error) {
+	s, err := parseSpec(rawSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	eps, err := s.endpoints()
+	if err != nil {
+		return nil, nil, err
+	}
+	ev := newEventTypes()
+	return renderJS(eps), renderDTS(s, eps, ev), nil
+}
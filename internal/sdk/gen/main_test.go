@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The committed client must match the committed swagger spec, or templates
+// drift from the API again.
+func TestGeneratedClientUpToDate(t *testing.T) {
+	spec, err := os.ReadFile("../../../docs/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, dts, err := generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string][]byte{"goop-client.js": js, "goop-client.d.ts": dts} {
+		got, err := os.ReadFile("../" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is stale; run go generate ./internal/sdk", name)
+		}
+	}
+}
+
+func TestOpName(t *testing.T) {
+	cases := []struct{ path, ns, name string }{
+		{"/api/groups", "groups", ""},
+		{"/api/groups/cohost/remove", "groups", "cohostRemove"},
+		{"/api/chat/rooms/state", "chat", "roomsState"},
+		{"/api/my-balance", "myBalance", ""},
+		{"/api/avatar/peer/{id}", "avatar", "peer"},
+		{"/api/call/loopback/{channel}/ice", "call", "loopbackIce"},
+	}
+	for _, c := range cases {
+		ns, name := opName(c.path)
+		if ns != c.ns || name != c.name {
+			t.Errorf("opName(%q) = %s.%s, want %s.%s", c.path, ns, name, c.ns, c.name)
+		}
+	}
+}
+
+func TestEndpointNamesAreUnique(t *testing.T) {
+	s, err := parseSpec([]byte(`{"paths": {
+		"/api/templates": {"get": {"responses": {"200": {}}}},
+		"/api/templates/{dir}": {"get": {"parameters": [{"name": "dir", "in": "path"}], "responses": {"200": {}}}},
+		"/api/enc/keys": {"post": {"responses": {"200": {}}}},
+		"/api/enc/keys/{peer_id}": {"get": {"parameters": [{"name": "peer_id", "in": "path"}], "responses": {"200": {}}}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps, err := s.endpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ep := range eps {
+		got = append(got, ep.NS+"."+ep.Name)
+	}
+	want := "enc.postKeys enc.keys templates.get templates.getByDir"
+	if strings.Join(got, " ") != want {
+		t.Errorf("names = %v, want %s", got, want)
+	}
+}
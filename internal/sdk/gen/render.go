@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const generatedNote = "Code generated by internal/sdk/gen from docs/swagger.json; DO NOT EDIT.\n" +
+	"Regenerate with: go generate ./internal/sdk"
+
+// clientRuntime is the hand-written part of goop-client.js; the endpoint
+// table is spliced in at %ENDPOINTS%.
+const clientRuntime = `(() => {
+  window.Goop = window.Goop || {};
+
+  function idempotencyKey() {
+    if (window.crypto && window.crypto.randomUUID) return window.crypto.randomUUID();
+    return Date.now().toString(36) + Math.random().toString(36).slice(2);
+  }
+
+  function request(method, url, kind, params) {
+    var init = { method: method, headers: {} };
+    var mutating = method !== "GET";
+    if (mutating) init.headers["Idempotency-Key"] = idempotencyKey();
+    if (kind === "body") {
+      init.headers["Content-Type"] = "application/json";
+      init.body = JSON.stringify(params || {});
+    } else if (kind === "query" && params) {
+      var qs = new URLSearchParams();
+      Object.keys(params).forEach(function(k) {
+        if (params[k] !== undefined && params[k] !== null) qs.append(k, params[k]);
+      });
+      if (qs.toString()) url += "?" + qs.toString();
+    } else if (kind === "form") {
+      var form = params instanceof FormData ? params : new FormData();
+      if (!(params instanceof FormData)) {
+        Object.keys(params || {}).forEach(function(k) { form.append(k, params[k]); });
+      }
+      init.body = form;
+    }
+    // A mutating request is sent again once after a network error; the
+    // server answers the retry from its idempotency cache if the first
+    // attempt did arrive.
+    var sent = fetch(url, init);
+    if (mutating) sent = sent.catch(function() { return fetch(url, init); });
+    return sent.then(function(r) {
+      if (!r.ok) {
+        return r.text().then(function(t) {
+          // Errors come as {"error": {code, message, field}}.
+          var err = new Error(t.trim() || r.statusText);
+          try {
+            var e = JSON.parse(t).error;
+            if (e && e.message) { err.message = e.message; err.code = e.code; err.field = e.field; }
+          } catch (_) {}
+          err.status = r.status;
+          throw err;
+        });
+      }
+      if (r.status === 204) return undefined;
+      if ((r.headers.get("Content-Type") || "").indexOf("application/json") >= 0) return r.json();
+      return r;
+    });
+  }
+
+  // Path parameters come first, in route order, then the params object.
+  function bind(method, path, kind) {
+    var names = path.match(/\{[^}]+\}/g) || [];
+    return function() {
+      var args = Array.prototype.slice.call(arguments);
+      var url = path;
+      names.forEach(function(n) { url = url.replace(n, encodeURIComponent(args.shift())); });
+      return request(method, url, kind, args[0]);
+    };
+  }
+
+  var endpoints = %ENDPOINTS%;
+
+  var client = {};
+  Object.keys(endpoints).forEach(function(ns) {
+    client[ns] = {};
+    Object.keys(endpoints[ns]).forEach(function(name) {
+      var e = endpoints[ns][name];
+      client[ns][name] = bind(e[0], e[1], e[2]);
+    });
+  });
+
+  // events wraps Goop.mq (goop-mq.js) so handlers get one event object.
+  client.events = {
+    on(topic, fn) {
+      if (!window.Goop.mq) throw new Error("goop-client.js: load /sdk/goop-mq.js first");
+      return window.Goop.mq.subscribe(topic, function(from, t, payload, ack) {
+        fn({ topic: t, from: from, payload: payload, ack: ack });
+      });
+    },
+
+    once(topic, fn) {
+      var off = client.events.on(topic, function(e) {
+        off();
+        fn(e);
+      });
+      return off;
+    },
+  };
+
+  window.Goop.client = client;
+})();
+`
+
+func renderJS(eps []endpoint) []byte {
+	var b bytes.Buffer
+	b.WriteString("//\n")
+	for _, line := range strings.Split(generatedNote, "\n") {
+		b.WriteString("// " + line + "\n")
+	}
+	b.WriteString(`//
+// Typed client for the peer API, so templates don't hand-roll fetch calls.
+// Every JSON route is a method grouped by its first path segment; path
+// parameters are positional, then one object with the JSON body, query or
+// form fields. Types are in /sdk/goop-client.d.ts.
+//
+// Usage:
+//
+//   <script src="/sdk/goop-mq.js"></script>      <!-- only for client.events -->
+//   <script src="/sdk/goop-client.js"></script>
+//
+//   var groups = await Goop.client.groups.get();
+//   await Goop.client.groups.cohost({ group_id: "g1", peer_id: "12D3..." });
+//   var avatar = await Goop.client.avatar.peer(peerId); // non-JSON: a Response
+//
+//   // POST, PUT and DELETE carry an Idempotency-Key and are retried once
+//   // after a network error; the peer replays its first answer.
+//
+//   // Failed requests reject with an Error carrying the HTTP status
+//   try { await Goop.client.groups.kick({ ... }); } catch (e) { e.status; }
+//
+//   // MQ events, one object per message
+//   var off = Goop.client.events.on("peer:gone", function(e) {
+//     console.log(e.from, e.payload.peerID);
+//   });
+//   Goop.client.events.on("group:*", function(e) { ... });
+//
+`)
+
+	var tbl bytes.Buffer
+	tbl.WriteString("{\n")
+	for i, ep := range eps {
+		if i == 0 || eps[i-1].NS != ep.NS {
+			if i > 0 {
+				tbl.WriteString("    },\n")
+			}
+			fmt.Fprintf(&tbl, "    %s: {\n", ep.NS)
+		}
+		fmt.Fprintf(&tbl, "      %s: [%q, %q, %q],\n", ep.Name, ep.Method, ep.Path, ep.Kind)
+	}
+	if len(eps) > 0 {
+		tbl.WriteString("    },\n")
+	}
+	tbl.WriteString("  }")
+
+	b.WriteString(strings.Replace(clientRuntime, "%ENDPOINTS%", tbl.String(), 1))
+	return b.Bytes()
+}
+
+func renderDTS(s *spec, eps []endpoint, ev *eventTypes) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.Split(generatedNote, "\n") {
+		b.WriteString("// " + line + "\n")
+	}
+	b.WriteString(`//
+// Types for /sdk/goop-client.js. Save this file next to your template
+// sources and reference it for editor completion and type checking:
+//
+//   /// <reference path="goop-client.d.ts" />
+
+declare namespace Goop {
+  /** Rejection of a failed request; the message is the response body. */
+  interface ClientError extends Error {
+    status: number;
+  }
+
+  /** Shapes from the route annotations. */
+  namespace Api {
+`)
+	keys := make([]string, 0, len(s.Definitions))
+	for k := range s.Definitions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.names[keys[i]] < s.names[keys[j]] })
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		s.writeDef(&b, key, "    ")
+	}
+	b.WriteString("  }\n\n")
+
+	ev.writeNamespace(&b, "  ")
+
+	b.WriteString("\n  interface Client {\n")
+	for i, ep := range eps {
+		if i == 0 || eps[i-1].NS != ep.NS {
+			if i > 0 {
+				b.WriteString("    };\n")
+			}
+			fmt.Fprintf(&b, "    %s: {\n", ep.NS)
+		}
+		s.writeMethod(&b, ep, "      ")
+	}
+	if len(eps) > 0 {
+		b.WriteString("    };\n")
+	}
+	b.WriteString("    events: Events.Helper;\n")
+	b.WriteString("  }\n\n  const client: Client;\n}\n")
+	return b.Bytes()
+}
+
+func (s *spec) writeDef(b *bytes.Buffer, key, indent string) {
+	def := s.Definitions[key]
+	name := s.names[key]
+	if def.Type != "object" || len(def.Properties) == 0 {
+		fmt.Fprintf(b, "%stype %s = %s;\n", indent, name, s.tsType(def, indent, ""))
+		return
+	}
+	fmt.Fprintf(b, "%sinterface %s %s\n", indent, name, s.objectType(def, s.input[key], indent, ""))
+}
+
+// objectType renders an object schema. Request bodies get optional fields,
+// since handlers fill in defaults; responses always carry every field
+// unless the schema lists the required ones.
+func (s *spec) objectType(sc *schema, optional bool, indent, ns string) string {
+	props := make([]string, 0, len(sc.Properties))
+	for p := range sc.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, p := range props {
+		ps := sc.Properties[p]
+		if ps.Description != "" {
+			writeDoc(&b, ps.Description, indent+"  ")
+		}
+		opt := ""
+		if (optional || len(sc.Required) > 0) && !slices.Contains(sc.Required, p) {
+			opt = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, propName(p), opt, s.tsType(ps, indent+"  ", ns))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// tsType renders a schema; ns qualifies definition names outside the Api
+// namespace.
+func (s *spec) tsType(sc *schema, indent, ns string) string {
+	if sc == nil {
+		return "unknown"
+	}
+	if sc.Ref != "" {
+		return ns + s.names[strings.TrimPrefix(sc.Ref, "#/definitions/")]
+	}
+	if len(sc.Enum) > 0 {
+		return enumType(sc.Enum)
+	}
+	switch sc.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "file":
+		return "Blob"
+	case "array":
+		el := s.tsType(sc.Items, indent, ns)
+		if strings.Contains(el, " ") {
+			el = "(" + el + ")"
+		}
+		return el + "[]"
+	case "object":
+		if len(sc.Properties) > 0 {
+			return s.objectType(sc, false, indent, ns)
+		}
+		return "Record<string, " + s.tsType(additional(sc), indent, ns) + ">"
+	}
+	return "unknown"
+}
+
+// additional returns the schema of an object's additionalProperties, or nil
+// when they are untyped ({} or true).
+func additional(sc *schema) *schema {
+	var v *schema
+	if len(sc.AdditionalProperties) == 0 || json.Unmarshal(sc.AdditionalProperties, &v) != nil {
+		return nil
+	}
+	if v != nil && v.Ref == "" && v.Type == "" {
+		return nil
+	}
+	return v
+}
+
+func enumType(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		raw, _ := json.Marshal(v)
+		parts[i] = string(raw)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (s *spec) writeMethod(b *bytes.Buffer, ep endpoint, indent string) {
+	if ep.Summary != "" {
+		writeDoc(b, ep.Summary, indent)
+	}
+	var args []string
+	for _, p := range ep.PathParams {
+		args = append(args, camel(p.Name)+": string")
+	}
+	switch ep.Kind {
+	case "body":
+		opt := ""
+		if !ep.Body.Required {
+			opt = "?"
+		}
+		args = append(args, "body"+opt+": "+s.tsType(ep.Body.Schema, indent, "Api."))
+	case "query", "form":
+		args = append(args, s.paramsArg(ep, indent))
+	}
+	fmt.Fprintf(b, "%s%s(%s): Promise<%s>;\n", indent, ep.Name, strings.Join(args, ", "), s.resultType(ep, indent))
+}
+
+// paramsArg renders query or form fields as one params object.
+func (s *spec) paramsArg(ep endpoint, indent string) string {
+	var fields []string
+	required := false
+	for _, p := range ep.Params {
+		opt := "?"
+		if p.Required {
+			opt, required = "", true
+		}
+		t := s.tsType(&schema{Type: p.Type, Items: p.Items, Enum: p.Enum}, indent, "Api.")
+		if ep.Kind == "query" && t != "string" {
+			t += " | string"
+		}
+		fields = append(fields, propName(p.Name)+opt+": "+t)
+	}
+	t := "{ " + strings.Join(fields, "; ") + " }"
+	if ep.Kind == "form" {
+		t = "FormData | " + t
+	}
+	if required {
+		return "params: " + t
+	}
+	return "params?: " + t
+}
+
+func (s *spec) resultType(ep endpoint, indent string) string {
+	switch {
+	case ep.NoContent:
+		return "void"
+	case !ep.JSON:
+		return "Response"
+	}
+	return s.tsType(ep.Result, indent, "Api.")
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// propName quotes property names that are not plain identifiers.
+func propName(p string) string {
+	for i := 0; i < len(p); i++ {
+		if !isIdent(p[i]) || (i == 0 && p[i] >= '0' && p[i] <= '9') {
+			return fmt.Sprintf("%q", p)
+		}
+	}
+	return p
+}
+
+func writeDoc(b interface{ WriteString(string) (int, error) }, text, indent string) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		b.WriteString(indent + "/** " + lines[0] + " */\n")
+		return
+	}
+	b.WriteString(indent + "/**\n")
+	for _, l := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+l, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+}
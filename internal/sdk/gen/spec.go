@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The subset of swagger 2.0 that swag emits for our routes.
+
+type spec struct {
+	Paths       map[string]map[string]*operation `json:"paths"`
+	Definitions map[string]*schema               `json:"definitions"`
+
+	names map[string]string // definition key → TypeScript name
+	input map[string]bool   // definitions reachable from request bodies
+}
+
+type operation struct {
+	Summary    string               `json:"summary"`
+	Produces   []string             `json:"produces"`
+	Parameters []parameter          `json:"parameters"`
+	Responses  map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+	Items       *schema `json:"items"`
+	Enum        []any   `json:"enum"`
+}
+
+type response struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Required             []string           `json:"required"`
+	Enum                 []any              `json:"enum"`
+}
+
+// endpoint is one client method, e.g. groups.cohostRemove.
+type endpoint struct {
+	NS, Name     string
+	Method, Path string
+	Kind         string // how params are sent: "body", "query", "form" or ""
+	Summary      string
+	PathParams   []parameter
+	Params       []parameter // query or form fields
+	Body         *parameter
+	Result       *schema
+	JSON         bool // the response is a JSON document
+	NoContent    bool // the response has no body
+}
+
+func parseSpec(raw []byte) (*spec, error) {
+	var s spec
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parse swagger spec: %w", err)
+	}
+	s.names = defNames(s.Definitions)
+	s.input = map[string]bool{}
+	for _, ops := range s.Paths {
+		for _, op := range ops {
+			for _, p := range op.Parameters {
+				if p.In == "body" {
+					s.markInput(p.Schema)
+				}
+			}
+		}
+	}
+	return &s, nil
+}
+
+// defNames strips the Go package from definition keys, keeping it only where
+// two packages define the same name (actions.Action, rules.Action).
+func defNames(defs map[string]*schema) map[string]string {
+	count := map[string]int{}
+	for key := range defs {
+		count[localName(key)]++
+	}
+	names := map[string]string{}
+	for key := range defs {
+		name := upperFirst(localName(key))
+		if count[localName(key)] > 1 {
+			pkg, _, _ := strings.Cut(key, ".")
+			name = upperFirst(pkg) + name
+		}
+		names[key] = name
+	}
+	return names
+}
+
+func localName(key string) string {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return key[i+1:]
+	}
+	return key
+}
+
+func (s *spec) markInput(sc *schema) {
+	if sc == nil {
+		return
+	}
+	if sc.Ref != "" {
+		key := strings.TrimPrefix(sc.Ref, "#/definitions/")
+		if s.input[key] {
+			return
+		}
+		s.input[key] = true
+		sc = s.Definitions[key]
+		if sc == nil {
+			return
+		}
+	}
+	s.markInput(sc.Items)
+	for _, p := range sc.Properties {
+		s.markInput(p)
+	}
+}
+
+var methodOrder = map[string]int{"get": 0, "post": 1, "put": 2, "patch": 3, "delete": 4}
+
+// endpoints lists the JSON API operations a template can call with fetch.
+// WebSockets, event streams and routes outside /api/ are left out; MQ events
+// have their own helper.
+func (s *spec) endpoints() ([]endpoint, error) {
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var eps []endpoint
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+		ops := s.Paths[path]
+		methods := make([]string, 0, len(ops))
+		for m := range ops {
+			methods = append(methods, m)
+		}
+		sort.Slice(methods, func(i, j int) bool { return methodOrder[methods[i]] < methodOrder[methods[j]] })
+
+		for _, method := range methods {
+			op := ops[method]
+			if len(op.Responses) == 0 || op.Responses["101"] != nil || slices.Contains(op.Produces, "text/event-stream") {
+				continue
+			}
+			ep := endpoint{Method: strings.ToUpper(method), Path: path, Summary: op.Summary}
+			ep.NS, ep.Name = opName(path)
+			for i := range op.Parameters {
+				p := op.Parameters[i]
+				switch p.In {
+				case "path":
+					ep.PathParams = append(ep.PathParams, p)
+				case "body":
+					ep.Body, ep.Kind = &p, "body"
+				case "query":
+					ep.Params, ep.Kind = append(ep.Params, p), "query"
+				case "formData":
+					ep.Params, ep.Kind = append(ep.Params, p), "form"
+				}
+			}
+			if ok := op.Responses["200"]; ok != nil {
+				ep.Result = ok.Schema
+			}
+			ep.JSON = slices.Contains(op.Produces, "application/json") || (len(op.Produces) == 0 && ep.Result != nil)
+			ep.NoContent = op.Responses["200"] == nil && op.Responses["204"] != nil
+			eps = append(eps, ep)
+		}
+	}
+
+	// Bare namespace routes are named after their HTTP method and path
+	// parameters (templates.getByDir), and so are non-GET routes whose name
+	// another route already has (chat.deleteHistory, encryption.postKeys).
+	count := map[string]int{}
+	for _, ep := range eps {
+		count[ep.NS+"."+ep.Name]++
+	}
+	seen := map[string]string{}
+	for i := range eps {
+		ep := &eps[i]
+		method := strings.ToLower(ep.Method)
+		switch {
+		case ep.Name == "":
+			ep.Name = method
+			for j, p := range ep.PathParams {
+				if j == 0 {
+					ep.Name += "By" + upperFirst(camel(p.Name))
+				} else {
+					ep.Name += "And" + upperFirst(camel(p.Name))
+				}
+			}
+		case count[ep.NS+"."+ep.Name] > 1 && method != "get":
+			ep.Name = method + upperFirst(ep.Name)
+		}
+		id := ep.NS + "." + ep.Name
+		if prev, dup := seen[id]; dup {
+			return nil, fmt.Errorf("%s %s and %s both map to %s", ep.Method, ep.Path, prev, id)
+		}
+		seen[id] = ep.Method + " " + ep.Path
+	}
+	sort.SliceStable(eps, func(i, j int) bool { return eps[i].NS < eps[j].NS })
+	return eps, nil
+}
+
+// opName derives the client method from the route: the first path segment
+// after /api/ is the namespace and the rest, minus path parameters, the
+// method name: /api/groups/cohost/remove becomes groups.cohostRemove.
+func opName(path string) (ns, name string) {
+	segs := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/"), "/"), "/")
+	ns = camel(segs[0])
+	var rest []string
+	for _, seg := range segs[1:] {
+		if seg != "" && !strings.HasPrefix(seg, "{") {
+			rest = append(rest, seg)
+		}
+	}
+	return ns, camel(strings.Join(rest, "-"))
+}
+
+// camel turns "chat-rooms/split_prefs.json" style words into chatRoomsSplitPrefsJson.
+func camel(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for i, w := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(w[:1]) + w[1:]
+		} else {
+			words[i] = upperFirst(w)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -16,6 +16,12 @@
 //   // get room state (members + recent messages)
 //   const state = await Goop.chatroom.state(groupId);
 //
+//   // stored history (also of rooms left or closed); last 50 messages
+//   const msgs = await Goop.chatroom.history(groupId, 50);
+//
+//   // joined late: ask the host for its last 200 messages ("history" event)
+//   await Goop.chatroom.backfill(groupId, 200);
+//
 //   // close a room (host only)
 //   await Goop.chatroom.close(groupId);
 //
@@ -32,13 +38,25 @@
 (() => {
   window.Goop = window.Goop || {};
 
+  // responseError builds an Error from a failed response: the message of the
+  // viewer's {"error": {code, message, field}} envelope, or the text as is.
+  function responseError(r, text) {
+    const err = new Error(text.trim() || r.statusText);
+    try {
+      const e = JSON.parse(text).error;
+      if (e && e.message) Object.assign(err, { message: e.message, code: e.code, field: e.field });
+    } catch (_) {}
+    err.status = r.status;
+    return err;
+  }
+
   function post(url, body) {
     return fetch(url, {
       method: "POST",
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify(body),
     }).then((r) => {
-      if (!r.ok) return r.text().then((t) => { throw new Error(t); });
+      if (!r.ok) return r.text().then((t) => { throw responseError(r, t); });
       return r.json();
     });
   }
@@ -83,6 +101,19 @@
         });
     },
 
+    history(groupId, limit) {
+      let url = "/api/chat/group/history?group_id=" + encodeURIComponent(groupId);
+      if (limit) url += "&limit=" + limit;
+      return fetch(url).then((r) => {
+        if (!r.ok) return r.text().then((t) => { throw responseError(r, t); });
+        return r.json();
+      });
+    },
+
+    backfill(groupId, limit) {
+      return post("/api/chat/group/backfill", { group_id: groupId, limit: limit || 0 });
+    },
+
     subscribe(callback) {
       if (!window.Goop || !window.Goop.mq) return function() {};
       var PREFIX = "chat.room:";
@@ -0,0 +1,2782 @@
+// Code generated by internal/sdk/gen from docs/swagger.json; DO NOT EDIT.
+// Regenerate with: go generate ./internal/sdk
+//
+// Types for /sdk/goop-client.js. Save this file next to your template
+// sources and reference it for editor completion and type checking:
+//
+//   /// <reference path="goop-client.d.ts" />
+
+declare namespace Goop {
+  /** Rejection of a failed request; the message is the response body. */
+  interface ClientError extends Error {
+    status: number;
+  }
+
+  /** Shapes from the route annotations. */
+  namespace Api {
+    interface ACL {
+      access: string;
+      /** for "peers" */
+      peers: string[];
+    }
+
+    interface ActionRunRequest {
+      id?: string;
+      params?: Record<string, unknown>;
+    }
+
+    interface ActionsAction {
+      category: string;
+      /**
+       * Client names the frontend handler for actions that need the browser
+       * (e.g. "call.start" needs the camera and mic). Not runnable server-side.
+       */
+      client: string;
+      /** Confirm marks destructive actions; palettes ask before running them. */
+      confirm: boolean;
+      description: string;
+      id: string;
+      /**
+       * Method and Path are the viewer route the action runs; empty for
+       * client actions.
+       */
+      method: string;
+      params: Param[];
+      path: string;
+      /**
+       * Scopes lists the pairing scopes under which a paired device may call
+       * Path directly; filled in when listed.
+       */
+      scopes: string[];
+      /**
+       * Script allows goop.actions.run from Lua. Scripts run on behalf of
+       * remote peers, so only harmless actions set it.
+       */
+      script: boolean;
+      title: string;
+    }
+
+    interface AuditEntry {
+      bytes_in: number;
+      bytes_out: number;
+      duration_ms: number;
+      id: number;
+      /** "allowed" or "denied" */
+      outcome: string;
+      peer_id: string;
+      protocol: string;
+      /** Unix ms */
+      ts: number;
+    }
+
+    interface AvatarUploadResponse {
+      hash: string;
+      ok: boolean;
+    }
+
+    interface BrowsePeer {
+      /** "unreachable", or "slow" while still being fetched */
+      error: string;
+      files: DocInfo[];
+      label: string;
+      latency_ms: number;
+      peer_id: string;
+      self: boolean;
+    }
+
+    interface CalendarFeedRequest {
+      group_id?: string;
+    }
+
+    interface CalendarFeedView {
+      /** Unix ms */
+      created_at: number;
+      group_id: string;
+      name: string;
+      /** /calendar/<token>.ics, relative to wherever the viewer is reached */
+      path: string;
+      token: string;
+    }
+
+    interface CallAudioProcessing {
+      echo_cancellation?: boolean;
+      noise_suppression?: boolean;
+    }
+
+    interface CallCallback {
+      at: number;
+      channel_id: string;
+      peer_id: string;
+    }
+
+    interface CallCaptionsRequest {
+      channel_id?: string;
+      enabled?: boolean;
+      language?: string;
+    }
+
+    interface CallChannelRequest {
+      channel_id: string;
+    }
+
+    interface CallChatRequest {
+      channel_id?: string;
+      content?: string;
+    }
+
+    interface CallDataMessage {
+      content: string;
+      id: string;
+      mime: string;
+      name: string;
+      size: number;
+      ts: number;
+      type: string;
+    }
+
+    interface CallDebugResponse {
+      session_count: number;
+      sessions: CallSessionStatus[];
+    }
+
+    interface CallDeviceRequest {
+      channel_id?: string;
+      device_id?: string;
+      kind?: string;
+    }
+
+    interface CallDevices {
+      devices: CallMediaDevice[];
+      preferred_cam: string;
+      preferred_mic: string;
+    }
+
+    interface CallFileRequest {
+      channel_id?: string;
+      data?: string;
+      mime?: string;
+      name?: string;
+    }
+
+    interface CallHoldResponse {
+      on_hold: boolean;
+    }
+
+    interface CallMediaDevice {
+      id: string;
+      kind: string;
+      label: string;
+    }
+
+    interface CallModeResponse {
+      /** Live captions can be enabled (native mode with viewer.caption_command set). */
+      captions: boolean;
+      first: boolean;
+      mode: string;
+      platform: string;
+      /** STUN URLs for ICE: the rendezvous's own when advertised, else a public default. */
+      stun_servers: string[];
+    }
+
+    interface CallMuteResponse {
+      muted: boolean;
+    }
+
+    interface CallSessionStatus {
+      audio_on: boolean;
+      camera: string;
+      captions: string;
+      channel_id: string;
+      hung: boolean;
+      is_origin: boolean;
+      mic: string;
+      on_hold: boolean;
+      pc_state: string;
+      remote_hold: boolean;
+      remote_peer: string;
+      video_on: boolean;
+    }
+
+    interface CallStartRequest {
+      channel_id?: string;
+      remote_peer?: string;
+    }
+
+    interface CallStartResponse {
+      channel_id: string;
+      status: string;
+    }
+
+    interface CallTransferRequest {
+      channel_id?: string;
+      target_peer?: string;
+    }
+
+    interface CallVideoResponse {
+      disabled: boolean;
+    }
+
+    interface CallbackDismissRequest {
+      peer_id?: string;
+    }
+
+    interface ChatCallMessageRequest {
+      call_id?: string;
+      content?: string;
+      incoming?: boolean;
+      peer_id?: string;
+    }
+
+    interface ChatGroupBackfillRequest {
+      group_id: string;
+      limit?: number;
+    }
+
+    interface ChatMessage {
+      /** set for messages exchanged during a call */
+      call_id: string;
+      content: string;
+      from: string;
+      timestamp: number;
+    }
+
+    interface ChatRoomCreateRequest {
+      context?: string;
+      description?: string;
+      max_members?: number;
+      name: string;
+    }
+
+    interface ChatRoomGroupIDRequest {
+      group_id: string;
+    }
+
+    interface ChatRoomInfo {
+      description: string;
+      id: string;
+      members: ChatRoomMember[];
+      name: string;
+    }
+
+    interface ChatRoomJoinRequest {
+      group_id?: string;
+      host_peer_id?: string;
+    }
+
+    interface ChatRoomMember {
+      name: string;
+      peer_id: string;
+    }
+
+    interface ChatRoomMessage {
+      from: string;
+      from_name: string;
+      id: string;
+      text: string;
+      timestamp: number;
+    }
+
+    interface ChatRoomSendRequest {
+      group_id?: string;
+      text?: string;
+    }
+
+    interface ChatRoomStateResponse {
+      messages: ChatRoomMessage[];
+      room: ChatRoomInfo;
+    }
+
+    interface ClientLogRequest {
+      level?: string;
+      message: string;
+      source?: string;
+    }
+
+    interface ClockEstimate {
+      /** spread of the samples around the best one */
+      jitter_ms: number;
+      measured_at: string;
+      offset_ms: number;
+      /** round trip of the best sample */
+      rtt_ms: number;
+      samples: number;
+    }
+
+    interface ClusterBinaryRequest {
+      mode?: string;
+      path: string;
+    }
+
+    interface ClusterBinaryResponse {
+      mode: string;
+      path: string;
+      status: string;
+    }
+
+    interface ClusterCancelRequest {
+      job_id: string;
+    }
+
+    interface ClusterCreateRequest {
+      group_id?: string;
+      max_members?: number;
+      name?: string;
+    }
+
+    interface ClusterCreateResponse {
+      group_id: string;
+      status: string;
+    }
+
+    interface ClusterDeleteRequest {
+      job_id: string;
+    }
+
+    interface ClusterJob {
+      id: string;
+      max_retry: number;
+      payload: Record<string, unknown>;
+      priority: number;
+      timeout_s: number;
+      type: string;
+    }
+
+    interface ClusterJobState {
+      created_at: string;
+      done_at: string;
+      elapsed_ms: number;
+      error: string;
+      job: ClusterJob;
+      progress: number;
+      progress_msg: string;
+      result: Record<string, unknown>;
+      retries: number;
+      started_at: string;
+      status: string;
+      worker_id: string;
+    }
+
+    interface ClusterJoinRequest {
+      group_id?: string;
+      host_peer_id?: string;
+    }
+
+    interface ClusterQueueStats {
+      completed: number;
+      failed: number;
+      pending: number;
+      running: number;
+      workers: number;
+    }
+
+    interface ClusterStatusResponse {
+      binary_mode: string;
+      binary_path: string;
+      group_id: string;
+      role: string;
+      stats: ClusterQueueStats;
+      worker_status: string;
+    }
+
+    interface ClusterSubmitRequest {
+      max_retry?: number;
+      mode?: string;
+      payload?: Record<string, unknown>;
+      priority?: number;
+      timeout_s?: number;
+      type: string;
+    }
+
+    interface ClusterSubmitResponse {
+      job_id: string;
+      status: string;
+    }
+
+    interface ClusterWorkerInfo {
+      binary_mode: string;
+      binary_path: string;
+      capacity: number;
+      job_types: string[];
+      last_seen: string;
+      peer_id: string;
+      running_jobs: number;
+      status: string;
+      verified: boolean;
+    }
+
+    interface ClusterWorkerPeerRequest {
+      peer_id: string;
+    }
+
+    interface ConfigValidation {
+      errors: Issue[];
+      valid: boolean;
+      warnings: Issue[];
+    }
+
+    interface ConsentDecisionRequest {
+      decision?: "once" | "always" | "block" | "deny";
+      id: string;
+    }
+
+    interface ConsentForgetRequest {
+      peer_id: string;
+    }
+
+    interface DataAffectedResponse {
+      affected: number;
+    }
+
+    interface DataAggregateRequest {
+      args?: unknown[];
+      expr?: string;
+      group_by?: string;
+      table?: string;
+      where?: string;
+    }
+
+    interface DataColumnRequest {
+      column?: unknown;
+      table?: string;
+    }
+
+    interface DataCountResponse {
+      count: number;
+    }
+
+    interface DataDeleteRequest {
+      id?: number;
+      table: string;
+    }
+
+    interface DataDeleteWhereRequest {
+      args?: unknown[];
+      table?: string;
+      where?: string;
+    }
+
+    interface DataDescribeResponse {
+      /** Classic: PRAGMA table_info array */
+      columns: unknown;
+      mode: string;
+      /** ORM: ormSchema object */
+      schema: unknown;
+    }
+
+    interface DataDistinctRequest {
+      args?: unknown[];
+      column?: string;
+      table?: string;
+      where?: string;
+    }
+
+    interface DataExistsResponse {
+      exists: boolean;
+    }
+
+    interface DataFindOneRequest {
+      args?: unknown[];
+      fields?: string[];
+      table: string;
+      where?: string;
+    }
+
+    interface DataFindRequest {
+      args?: unknown[];
+      fields?: string[];
+      limit?: number;
+      offset?: number;
+      order?: string;
+      table: string;
+      where?: string;
+    }
+
+    interface DataGetByRequest {
+      column?: string;
+      table?: string;
+      value?: unknown;
+    }
+
+    interface DataInsertRequest {
+      data?: Record<string, unknown>;
+      table: string;
+    }
+
+    interface DataInsertResponse {
+      id: number;
+      status: string;
+    }
+
+    interface DataLuaCallRequest {
+      function: string;
+      params?: Record<string, unknown>;
+    }
+
+    interface DataLuaFunctionInfo {
+      description: string;
+      name: string;
+    }
+
+    interface DataLuaListResponse {
+      functions: DataLuaFunctionInfo[];
+    }
+
+    interface DataPluckRequest {
+      args?: unknown[];
+      column?: string;
+      limit?: number;
+      order?: string;
+      table?: string;
+      where?: string;
+    }
+
+    interface DataPolicyRequest {
+      /** owner, open, group, local */
+      policy?: string;
+      table: string;
+    }
+
+    interface DataQueryRequest {
+      args?: unknown[];
+      columns?: string[];
+      limit?: number;
+      offset?: number;
+      table: string;
+      where?: string;
+    }
+
+    interface DataRenameRequest {
+      new_name?: string;
+      old_name?: string;
+    }
+
+    interface DataRoleRequest {
+      table: string;
+    }
+
+    interface DataRoleResponse {
+      permissions: Record<string, boolean>;
+      role: string;
+    }
+
+    interface DataTableCreateRequest {
+      columns?: unknown[];
+      name: string;
+    }
+
+    interface DataTableCreateResponse {
+      /** "orm" or "classic" */
+      mode: string;
+      status: string;
+      table: string;
+    }
+
+    interface DataTableListEntry {
+      created_at: string;
+      /** owner, open, group, local */
+      insert_policy: string;
+      /** "orm" or "classic" */
+      mode: string;
+      name: string;
+    }
+
+    interface DataTableRequest {
+      table: string;
+    }
+
+    interface DataUpdateRequest {
+      data?: Record<string, unknown>;
+      id?: number;
+      table: string;
+    }
+
+    interface DataUpdateWhereRequest {
+      args?: unknown[];
+      data?: Record<string, unknown>;
+      table?: string;
+      where?: string;
+    }
+
+    interface DataUpsertRequest {
+      data?: Record<string, unknown>;
+      key_col?: string;
+      table?: string;
+    }
+
+    interface DataWhereRequest {
+      args?: unknown[];
+      table: string;
+      where?: string;
+    }
+
+    interface DataWipeRequest {
+      categories?: string[];
+      peer_id?: string;
+    }
+
+    interface DatafedGroupIDRequest {
+      group_id: string;
+    }
+
+    interface DatafedGroupInfo {
+      contributions: Record<string, string[]>;
+      group_id: string;
+    }
+
+    interface DatafedOfferRequest {
+      group_id: string;
+      relationships?: DatafedRelationship[];
+      tables?: string[];
+    }
+
+    interface DatafedPeerContribution {
+      peer_id: string;
+      relationships: DatafedRelationship[];
+      tables: SchemaSaveRequest[];
+    }
+
+    interface DatafedRelationship {
+      from_column?: string;
+      from_table?: string;
+      to_column?: string;
+      to_table?: string;
+    }
+
+    interface Dead {
+      attempts: number;
+      error: string;
+      /** Unix ms */
+      failed_at: number;
+      key: string;
+      kind: string;
+    }
+
+    interface DigestRequest {
+      favorites?: boolean;
+      frequency?: string;
+    }
+
+    interface DigestStatus {
+      email: string;
+      favorites: number;
+      frequency: string;
+      /** unix millis */
+      last_sent: number;
+      /** events waiting for the next digest */
+      pending: number;
+    }
+
+    interface DocFileInfo {
+      /** Who may fetch the file; peers is only in your own listings. */
+      access: "group" | "peers" | "public";
+      mod_time: string;
+      name: string;
+      peers: string[];
+      size: number;
+    }
+
+    interface DocGroupItem {
+      files: unknown[];
+      group_id: string;
+      group_name: string;
+      source: string;
+    }
+
+    interface DocGroupsResponse {
+      groups: DocGroupItem[];
+    }
+
+    interface DocInfo {
+      /** "group", "peers" or "public" */
+      access: string;
+      /** sha256:<hex> */
+      hash: string;
+      /** unix seconds */
+      mod_time: number;
+      name: string;
+      /** for "peers"; own listings only */
+      peers: string[];
+      size: number;
+    }
+
+    interface DocsACLRequest {
+      access?: "group" | "peers" | "public";
+      filename?: string;
+      group_id?: string;
+      peers?: string[];
+    }
+
+    interface DocsBrowseRefreshRequest {
+      group_id?: string;
+      peer_ids?: string[];
+    }
+
+    interface DocsBrowseResponse {
+      group_id: string;
+      peers: BrowsePeer[];
+      /** members still being fetched; ask /api/docs/browse/refresh */
+      slow: string[];
+    }
+
+    interface DocsDeleteRequest {
+      filename?: string;
+      group_id?: string;
+    }
+
+    interface DocsUploadLocalRequest {
+      group_id?: string;
+      path?: string;
+    }
+
+    interface FsBrowseEntry {
+      is_dir: boolean;
+      name: string;
+      size: number;
+    }
+
+    interface FsBrowseResponse {
+      dir: string;
+      entries: FsBrowseEntry[];
+      parent: string;
+    }
+
+    interface GraphqlRequest {
+      operationName?: string;
+      query?: string;
+      variables?: Record<string, unknown>;
+    }
+
+    interface GraphqlSchemaResponse {
+      html: string;
+      sdl: string;
+    }
+
+    interface GraphqlStatusResponse {
+      enabled: boolean;
+      tables: string[];
+    }
+
+    interface GroupCreateRequest {
+      group_context?: string;
+      group_type?: string;
+      max_members?: number;
+      name?: string;
+      /** sequence-numbered delivery with resend */
+      ordered?: boolean;
+      volatile?: boolean;
+    }
+
+    interface GroupCreateResponse {
+      id: string;
+      status: string;
+    }
+
+    interface GroupHostJoinRequest {
+      group_id?: string;
+      host_peer_id?: string;
+    }
+
+    interface GroupIDRequest {
+      group_id: string;
+    }
+
+    interface GroupMaxMembersRequest {
+      group_id: string;
+      max_members?: number;
+    }
+
+    interface GroupMemberInfo {
+      joined_at: number;
+      name: string;
+      peer_id: string;
+    }
+
+    interface GroupMetaRequest {
+      group_id: string;
+      max_members?: number;
+      name: string;
+    }
+
+    interface GroupOrderedRequest {
+      group_id: string;
+      ordered?: boolean;
+    }
+
+    interface GroupPeerRequest {
+      group_id?: string;
+      peer_id?: string;
+    }
+
+    interface GroupSendRequest {
+      group_id: string;
+      payload?: unknown;
+    }
+
+    interface GroupSetDefaultRoleRequest {
+      default_role?: string;
+      group_id?: string;
+    }
+
+    interface GroupSetRoleRequest {
+      group_id?: string;
+      peer_id?: string;
+      role?: string;
+    }
+
+    interface GroupSetRolesListRequest {
+      group_id: string;
+      roles?: string[];
+    }
+
+    interface HealthCheck {
+      detail: string;
+      duration_ms: number;
+      name: string;
+      repaired: number;
+      /** ok, repaired, failed or skipped */
+      status: string;
+    }
+
+    interface HealthReport {
+      at: number;
+      checks: HealthCheck[];
+      elapsed_ms: number;
+      first_start: boolean;
+      healthy: boolean;
+      repaired: number;
+      unclean: boolean;
+    }
+
+    interface HostedGroupInfo {
+      created_at: string;
+      default_role: string;
+      group_context: string;
+      group_type: string;
+      host_in_group: boolean;
+      host_joined: boolean;
+      id: string;
+      max_members: number;
+      member_count: number;
+      members: GroupMemberInfo[];
+      name: string;
+      roles: string[];
+      volatile: boolean;
+    }
+
+    interface Info {
+      name: string;
+      /** unix millis */
+      updated: number;
+      /** 1 when set, +1 per rotation */
+      version: number;
+    }
+
+    interface Issue {
+      /** JSON path, e.g. "p2p.listen_port" */
+      field: string;
+      message: string;
+      /** reported, but the config still loads */
+      warning: boolean;
+    }
+
+    interface JobType {
+      description: string;
+      help: string;
+      name: string;
+      template: string;
+    }
+
+    interface JobsClearResponse {
+      cleared: number;
+    }
+
+    interface LaneDepth {
+      in_flight: number;
+      queued: number;
+    }
+
+    interface LatencyHistogram {
+      bounds_ms: number[];
+      count: number;
+      counts: number[];
+      max_ms: number;
+      sum_ms: number;
+    }
+
+    interface ListenApproveRequest {
+      file_path?: string;
+      id?: string;
+    }
+
+    interface ListenControlRequest {
+      action?: string;
+      index?: number;
+      position?: number;
+    }
+
+    interface ListenCreateRequest {
+      name?: string;
+    }
+
+    interface ListenGroup {
+      /** host: seconds each listener is ahead (+) or behind */
+      drift: Record<string, number>;
+      id: string;
+      jukebox: boolean;
+      listeners: string[];
+      name: string;
+      pending_requests: number;
+      play_state: ListenPlayState;
+      queue: string[];
+      queue_index: number;
+      queue_total: number;
+      queue_types: string[];
+      role: string;
+      /** listener: bumped when the host resyncs us */
+      stream_gen: number;
+      track: ListenTrack;
+    }
+
+    interface ListenJoinRequest {
+      group_id?: string;
+      host_peer_id?: string;
+    }
+
+    interface ListenJukeboxRequest {
+      enabled?: boolean;
+    }
+
+    interface ListenLoadRequest {
+      file_path?: string;
+      file_paths?: string[];
+    }
+
+    interface ListenPlayState {
+      playing: boolean;
+      position: number;
+      updated_at: number;
+    }
+
+    interface ListenQueueAddRequest {
+      file_paths?: string[];
+    }
+
+    interface ListenRejectRequest {
+      id?: string;
+    }
+
+    interface ListenReportRequest {
+      elapsed?: number;
+    }
+
+    interface ListenRequestsResponse {
+      jukebox: boolean;
+      peer_names: Record<string, string>;
+      requests: ListenTrackRequest[];
+    }
+
+    interface ListenStateResponse {
+      group: ListenGroup;
+      listener_names: Record<string, string>;
+    }
+
+    interface ListenTrack {
+      bitrate: number;
+      duration: number;
+      format: "mp3" | "flac" | "ogg" | "aac" | "m4a" | "stream";
+      is_stream: boolean;
+      name: string;
+    }
+
+    interface ListenTrackRequest {
+      at: number;
+      id: string;
+      name: string;
+      peer_id: string;
+      track: string;
+    }
+
+    interface ListenTrackRequestBody {
+      track?: string;
+    }
+
+    interface LoopbackICERequest {
+      candidate?: string;
+      sdpMLineIndex?: number;
+      sdpMid?: string;
+    }
+
+    interface LoopbackOfferRequest {
+      sdp?: string;
+    }
+
+    interface LoopbackOfferResponse {
+      sdp: string;
+    }
+
+    interface LuaPrefabApplyRequest {
+      csrf?: string;
+      prefab: string;
+      script?: string;
+    }
+
+    interface LuaPrefabApplyResponse {
+      prefab: string;
+      status: string;
+    }
+
+    interface MemberPresence {
+      /** peer is joined to the live group session */
+      connected: boolean;
+      last_seen: number;
+      name: string;
+      /** peer is reachable on the network */
+      online: boolean;
+      peer_id: string;
+      role: string;
+    }
+
+    interface Metrics {
+      /** "direct" / "relay" ack latency */
+      by_via: Record<string, LatencyHistogram>;
+      /** Queues lists busy priority lanes: peerID → lane → depth. */
+      queues: Record<string, Record<string, LaneDepth>>;
+      /** Unix ms */
+      since: number;
+      topics: Record<string, TopicMetrics>;
+    }
+
+    interface MqAckRequest {
+      from_peer_id?: string;
+      msg_id: string;
+    }
+
+    interface MqOutboxEntry {
+      attempts: number;
+      created_at: number;
+      expires_at: number;
+      id: number;
+      last_error: string;
+      next_try: number;
+      payload: unknown;
+      peer_id: string;
+      topic: string;
+    }
+
+    interface MqOutboxPurgeRequest {
+      /** Peer whose queued messages are dropped; empty drops all. */
+      peer_id?: string;
+    }
+
+    interface MqOutboxPurgeResponse {
+      purged: number;
+    }
+
+    interface MqSendRequest {
+      msg_id?: string;
+      payload?: unknown;
+      peer_id?: string;
+      /** Delivery lane; defaults from topic (call:/group: interactive) and payload size. */
+      priority?: "interactive" | "normal" | "bulk";
+      /** Store and forward: if the peer is unreachable, keep the message in the outbox and deliver it later. */
+      queue?: boolean;
+      topic?: string;
+      /** How long a queued message waits for the peer; default 7 days. */
+      ttl_sec?: number;
+    }
+
+    interface MqSendResponse {
+      msg_id: string;
+      /** Outbox entry of a queued message. */
+      outbox_id: number;
+      status: "delivered" | "queued";
+    }
+
+    interface MyBalanceResponse {
+      balance: number;
+      credits_active: boolean;
+    }
+
+    interface NamespaceInfo {
+      name: string;
+      online: number;
+      peers: number;
+    }
+
+    interface OrmAccess {
+      delete?: string;
+      insert?: string;
+      read?: string;
+      update?: string;
+    }
+
+    interface OrmEnumValue {
+      key: string;
+      label: string;
+    }
+
+    interface OrmSchema {
+      access: OrmAccess;
+      columns: OrmSchemaColumn[];
+      context: boolean;
+      name: string;
+      system_key: boolean;
+    }
+
+    interface OrmSchemaColumn {
+      auto: boolean;
+      default: unknown;
+      key: boolean;
+      name: string;
+      required: boolean;
+      type: string;
+      values: OrmEnumValue[];
+    }
+
+    interface OrmSchemaRoles {
+      delete: boolean;
+      insert: boolean;
+      read: boolean;
+      update: boolean;
+    }
+
+    interface OwnershipRecord {
+      /** unix millis */
+      at: number;
+      from: string;
+      group_id: string;
+      /** "transfer" or "election" */
+      reason: string;
+      to: string;
+    }
+
+    interface PairClaimRequest {
+      code?: string;
+      name?: string;
+    }
+
+    interface PairGuestRequest {
+      minutes?: number;
+    }
+
+    interface PairRevokeRequest {
+      id: string;
+    }
+
+    interface PairStartRequest {
+      scopes?: string[];
+    }
+
+    interface Param {
+      description: string;
+      enum: string[];
+      name: string;
+      required: boolean;
+      type: string;
+    }
+
+    interface PeerContentResponse {
+      content: string;
+      error: string;
+    }
+
+    interface PeerFavoriteRequest {
+      favorite?: boolean;
+      peer_id?: string;
+    }
+
+    interface PeerFollowRequest {
+      peer_id: string;
+    }
+
+    interface PeerForgetRequest {
+      peer_id: string;
+    }
+
+    interface PeerNote {
+      body: string;
+      /** Unix ms */
+      created_at: number;
+      peer_id: string;
+      /** Unix ms */
+      updated_at: number;
+    }
+
+    interface PeerNoteRequest {
+      body?: string;
+      peer_id: string;
+    }
+
+    interface PeerReport {
+      /** Unix ms */
+      created_at: number;
+      id: number;
+      note: string;
+      peer_id: string;
+      reason: string;
+    }
+
+    interface PeerReportRequest {
+      note?: string;
+      peer_id: string;
+      reason: "spam" | "harassment" | "impersonation" | "illegal" | "other";
+    }
+
+    interface PermalinkResolution {
+      error: string;
+      link: string;
+      live_url: string;
+      mirror_url: string;
+      pinned: boolean;
+      pinned_at: number;
+      status: string;
+    }
+
+    interface Pin {
+      hash: string;
+      mime: string;
+      /** always starts with "/" */
+      path: string;
+      peer_id: string;
+      /** unix seconds */
+      pinned_at: number;
+      size: number;
+    }
+
+    interface Preset {
+      description: string;
+      name: string;
+      title: string;
+    }
+
+    interface QuickSettingsRequest {
+      bridge_mode?: boolean;
+      bridge_token?: string;
+      email?: string;
+      hide_unverified?: boolean;
+      label?: string;
+      open_sites_external?: boolean;
+      preferred_cam?: string;
+      preferred_mic?: string;
+      theme?: string;
+      use_services?: boolean;
+      verification_token?: string;
+      video_disabled?: boolean;
+    }
+
+    interface QuickSettingsResponse {
+      email: string;
+      hide_unverified: boolean;
+      label: string;
+      open_sites_external: boolean;
+      preferred_cam: string;
+      preferred_mic: string;
+      theme: string;
+      verification_token: string;
+      video_disabled: boolean;
+    }
+
+    interface ReplicaStatus {
+      group_id: string;
+      host_peer_id: string;
+      last_error: string;
+      /** own changes not pushed yet */
+      pending: number;
+      /** Unix ms of the last complete sync, 0 = never */
+      synced_at: number;
+      tables: string[];
+    }
+
+    interface Report {
+      capacity: number;
+      /** replaced by a newer job with their key */
+      coalesced: number;
+      /** newest first */
+      dead: Dead[];
+      /** succeeded, at any attempt */
+      done: number;
+      /** refused by a full queue */
+      dropped: number;
+      /** failed their last attempt */
+      failed: number;
+      /** waiting for a worker */
+      queued: number;
+      /** failed attempts that were retried */
+      retried: number;
+      /** waiting out a backoff */
+      retrying: number;
+      running: number;
+      workers: number;
+    }
+
+    interface ReputationRecord {
+      /** of which refused by an access policy */
+      denied: number;
+      mq_messages: number;
+      mq_peak_per_minute: number;
+      /** of which refused by the protocol handler */
+      refused: number;
+      /** the peer that observed it and signed */
+      reporter: string;
+      reports: ReputationReport[];
+      sig: string;
+      /** unix millis, start of the window */
+      since: number;
+      /** inbound streams the subject opened */
+      streams: number;
+      /** the peer the record is about */
+      subject: string;
+      /** unix millis, when the record was made */
+      until: number;
+    }
+
+    interface ReputationReport {
+      /** unix millis */
+      at: number;
+      note: string;
+      /** spam, harassment, impersonation, illegal or other */
+      reason: string;
+    }
+
+    interface ReputationSubmitRequest {
+      days?: number;
+      peer_id: string;
+    }
+
+    interface RetentionStats {
+      /** retention in days; 0 = keep forever */
+      days: number;
+      last_error: string;
+      /** peers forgotten by the last run */
+      last_forgotten: number;
+      /** Unix ms, 0 = never */
+      last_run: number;
+      /** since start, including manual forgets */
+      total_forgotten: number;
+    }
+
+    interface Rule {
+      action?: RulesAction;
+      created_at?: number;
+      enabled?: boolean;
+      id?: string;
+      last_error?: string;
+      last_run?: number;
+      name?: string;
+      trigger?: Trigger;
+    }
+
+    interface RuleIDRequest {
+      id?: string;
+    }
+
+    interface RulesAction {
+      action_id?: string;
+      function?: string;
+      params?: Record<string, unknown>;
+      peer_id?: string;
+      /** webhook: name of the secret sent as bearer token */
+      secret?: string;
+      text?: string;
+      type?: string;
+      url?: string;
+    }
+
+    interface ScheduleDeleteRequest {
+      id?: number;
+    }
+
+    interface ScheduledSession {
+      /** Unix ms */
+      created_at?: number;
+      description?: string;
+      duration_min?: number;
+      group_id?: string;
+      id?: number;
+      kind?: string;
+      /** Unix ms */
+      starts_at?: number;
+      title?: string;
+      /** Unix ms */
+      updated_at?: number;
+    }
+
+    interface SchemaColumn {
+      auto?: boolean;
+      default?: unknown;
+      key?: boolean;
+      name?: string;
+      required?: boolean;
+      type?: string;
+      values?: SchemaEnumValue[];
+    }
+
+    interface SchemaDdlResponse {
+      ddl: string;
+    }
+
+    interface SchemaEnumValue {
+      key?: string;
+      label?: string;
+    }
+
+    interface SchemaListEntry {
+      columns: number;
+      context: boolean;
+      has_key: boolean;
+      name: string;
+    }
+
+    interface SchemaNameRequest {
+      name: string;
+    }
+
+    interface SchemaSaveRequest {
+      access?: OrmAccess;
+      columns?: SchemaColumn[];
+      context?: boolean;
+      name?: string;
+      roles?: Record<string, OrmSchemaRoles>;
+      system_key?: boolean;
+    }
+
+    interface SchemaSetAccessRequest {
+      access?: OrmAccess;
+      name: string;
+    }
+
+    interface SchemaSetContextRequest {
+      context?: boolean;
+      name: string;
+    }
+
+    interface SchemaSetRolesRequest {
+      name: string;
+      roles?: Record<string, OrmSchemaRoles>;
+    }
+
+    interface SecretNameRequest {
+      name?: string;
+    }
+
+    interface SecretRotated {
+      name: string;
+      /** unix millis */
+      updated: number;
+      value: string;
+      /** 1 when set, +1 per rotation */
+      version: number;
+    }
+
+    interface SecretSetRequest {
+      name?: string;
+      value?: string;
+    }
+
+    interface ServeLimits {
+      per_peer_kbps: number;
+      total_kbps: number;
+    }
+
+    interface ServeStats {
+      /** streams being served */
+      active: number;
+      /** served since start */
+      bytes: number;
+      /** Unix ms of the last write, 0 = never */
+      last_at: number;
+      limits: ServeLimits;
+      peer_id: string;
+      /** by current rate, then bytes */
+      peers: ServeUsage[];
+      /** bytes/s over the last few seconds */
+      rate: number;
+      /** time spent held back by the limits */
+      throttle_ms: number;
+    }
+
+    interface ServeUsage {
+      /** streams being served */
+      active: number;
+      /** served since start */
+      bytes: number;
+      /** Unix ms of the last write, 0 = never */
+      last_at: number;
+      peer_id: string;
+      /** bytes/s over the last few seconds */
+      rate: number;
+    }
+
+    interface ServiceHealthEntry {
+      error: string;
+      ok: boolean;
+      status: unknown;
+    }
+
+    interface ServicesHealthResponse {
+      bridge: ServiceHealthEntry;
+      credits: ServiceHealthEntry;
+      email: ServiceHealthEntry;
+      encryption: ServiceHealthEntry;
+      registration: ServiceHealthEntry;
+      templates: ServiceHealthEntry;
+    }
+
+    interface SiteDeleteRequest {
+      path: string;
+    }
+
+    interface SiteFileItem {
+      depth: number;
+      is_dir: boolean;
+      path: string;
+    }
+
+    interface SiteFollow {
+      bytes: number;
+      files: number;
+      /** Unix ms */
+      followed_at: number;
+      last_error: string;
+      peer_id: string;
+      /** hash of the copy, as announced by the peer */
+      site_hash: string;
+      /** Unix ms of the last complete sync, 0 = never */
+      synced_at: number;
+    }
+
+    interface SiteImportResponse {
+      status: string;
+    }
+
+    interface SitePublishRequest {
+      csrf?: string;
+    }
+
+    interface SitePublishResponse {
+      bytes: number;
+      duration_ms: number;
+      files: number;
+      status: string;
+      target: string;
+    }
+
+    interface SiteUploadLocalRequest {
+      dest_path?: string;
+      src_path?: string;
+    }
+
+    interface SiteUploadResponse {
+      etag: string;
+      path: string;
+      status: string;
+    }
+
+    interface SiteassetsStats {
+      enabled: boolean;
+      /** variants (re)encoded by the last build */
+      generated: number;
+      /** source images with at least one variant */
+      images: number;
+      last_error: string;
+      /** Unix ms, 0 = never */
+      last_run: number;
+      /** source bytes minus smallest variant, summed */
+      saved_bytes: number;
+      /** variant files in the cache */
+      variants: number;
+    }
+
+    interface SplitPrefRequest {
+      key: string;
+      value?: number;
+    }
+
+    interface StartupReport {
+      done: boolean;
+      failed: boolean;
+      finished_at: number;
+      stages: StartupStage[];
+      started_at: number;
+    }
+
+    interface StartupStage {
+      detail: string;
+      duration_ms: number;
+      error: string;
+      finished_at: number;
+      label: string;
+      name: string;
+      started_at: number;
+      /** running, done or failed */
+      state: string;
+      step: number;
+      total: number;
+    }
+
+    interface StatusOK {
+      status: string;
+    }
+
+    interface SubscriptionInfo {
+      group_context: string;
+      group_id: string;
+      group_name: string;
+      group_type: string;
+      host_name: string;
+      host_peer_id: string;
+      host_reachable: boolean;
+      max_members: number;
+      member_count: number;
+      role: string;
+      subscribed_at: string;
+      volatile: boolean;
+    }
+
+    interface SubscriptionsResponse {
+      active_groups: string[];
+      subscriptions: SubscriptionInfo[];
+    }
+
+    interface TemplateApplyLocalRequest {
+      csrf?: string;
+      path: string;
+    }
+
+    interface TemplateApplyRequest {
+      csrf?: string;
+      template: string;
+    }
+
+    interface TemplateApplyResponse {
+      status: string;
+      template: string;
+    }
+
+    interface TemplateApplyStoreRequest {
+      csrf?: string;
+      /** install session to report progress to */
+      session?: string;
+      template: string;
+    }
+
+    interface TemplateApplyStoreResponse {
+      balance: number;
+      status: string;
+      template: string;
+    }
+
+    interface TemplateInstallRequest {
+      csrf?: string;
+      template: string;
+    }
+
+    interface TemplateInstallResponse {
+      session: string;
+    }
+
+    interface TemplatePublishRequest {
+      csrf?: string;
+      path: string;
+    }
+
+    interface TemplatePublishResponse {
+      results: TemplatePublishResult[];
+      template: string;
+    }
+
+    interface TemplatePublishResult {
+      error: string;
+      server: string;
+      version: string;
+    }
+
+    interface TemplateRevertRequest {
+      csrf?: string;
+    }
+
+    interface TemplateRevertResponse {
+      status: string;
+      template: string;
+    }
+
+    interface TemplateSettingsResponse {
+      category: string;
+      default_role: string;
+      description: string;
+      icon: string;
+      name: string;
+      require_email: boolean;
+      schemas: string[];
+    }
+
+    interface TemplateSnapshotEntry {
+      created: number;
+      id: string;
+      reason: string;
+      template: string;
+    }
+
+    interface TemplateUpdateRequest {
+      csrf?: string;
+    }
+
+    interface TemplateUpdateResponse {
+      status: string;
+      template: string;
+      version: string;
+    }
+
+    interface TemplateUpdateStatusResponse {
+      available: boolean;
+      installed: string;
+      latest: string;
+      source: string;
+      template: string;
+    }
+
+    interface TemplateValidateLocalRequest {
+      path: string;
+    }
+
+    interface TemplateValidateLocalResponse {
+      category: string;
+      description: string;
+      icon: string;
+      name: string;
+    }
+
+    interface TopicMetrics {
+      ack_latency: LatencyHistogram;
+      /** transport ACK received */
+      delivered: number;
+      /** unreachable or stream errors */
+      failures: number;
+      /** inbound messages */
+      received: number;
+      /** send attempts */
+      sent: number;
+      /** no ACK within timeouts.MQAck */
+      timeouts: number;
+    }
+
+    interface TopologyNode {
+      addr: string;
+      has_circuit: boolean;
+      id: string;
+      label: string;
+    }
+
+    interface TopologyPeer {
+      addr: string;
+      age: string;
+      connection: string;
+      id: string;
+      label: string;
+      quality: "direct-lan" | "direct-wan" | "holepunch" | "relay" | "none";
+      reachable: boolean;
+      streams: number;
+    }
+
+    interface TopologyResponse {
+      peers: TopologyPeer[];
+      relay: TopologyNode;
+      self: TopologyNode;
+    }
+
+    interface TransformDataEndpoint {
+      name?: string;
+      path?: string;
+      type?: string;
+      url?: string;
+    }
+
+    interface TransformExecuteRequest {
+      args?: unknown[];
+      limit?: number;
+      name: string;
+      where?: string;
+    }
+
+    interface TransformExecuteResponse {
+      inserted: number;
+      status: string;
+    }
+
+    interface TransformField {
+      args?: unknown[];
+      constant?: unknown;
+      sources?: string[];
+      target?: string;
+      transform?: string;
+    }
+
+    interface TransformFileExistsRequest {
+      path?: string;
+    }
+
+    interface TransformListEntry {
+      description: string;
+      field_count: number;
+      name: string;
+      source_type: string;
+      target_type: string;
+    }
+
+    interface TransformNameRequest {
+      name: string;
+    }
+
+    interface TransformPreviewRequest {
+      name: string;
+      rows?: (Record<string, unknown>)[];
+    }
+
+    interface TransformSaveRequest {
+      description?: string;
+      fields?: TransformField[];
+      name?: string;
+      source?: TransformDataEndpoint;
+      target?: TransformDataEndpoint;
+    }
+
+    interface Trigger {
+      at?: string;
+      peer_id?: string;
+      type?: string;
+      word?: string;
+    }
+  }
+
+  /** MQ event payloads, from the Go types that publish them. */
+  namespace Events {
+    interface CallAckPayload {
+      type: "call-ack";
+    }
+
+    interface CallAnswerPayload {
+      type: "call-answer";
+      sdp: string;
+    }
+
+    interface CallHangupPayload {
+      type: "call-hangup";
+      channel_id: string;
+    }
+
+    interface CallICECandidateInit {
+      candidate: string;
+      sdpMid?: string;
+      sdpMLineIndex: number;
+    }
+
+    interface CallICEPayload {
+      type: "ice-candidate";
+      candidate: CallICECandidateInit;
+    }
+
+    interface CallLoopbackICEPayload {
+      type: "loopback-ice";
+      channel_id: string;
+      candidate: CallICECandidateInit;
+    }
+
+    interface CallMissed {
+      channel_id: string;
+      peer_id: string;
+      media: string;
+      started_at: number;
+      missed: number;
+    }
+
+    interface CallOfferPayload {
+      type: "call-offer";
+      sdp: string;
+    }
+
+    interface CallRequestPayload {
+      type: "call-request";
+      constraints?: unknown;
+    }
+
+    interface CallRinging {
+      channel_id: string;
+      peer_id: string;
+      media: string;
+    }
+
+    interface ChatMember {
+      peer_id: string;
+      name?: string;
+    }
+
+    interface ChatMessage {
+      id: string;
+      from: string;
+      from_name: string;
+      text: string;
+      timestamp: number;
+    }
+
+    interface ChatRoomEvent {
+      action: string;
+      message?: ChatMessage;
+      messages?: ChatMessage[];
+      members?: ChatMember[];
+    }
+
+    interface ConsentRequest {
+      id: string;
+      peer_id: string;
+      protocol: string;
+      created: number;
+    }
+
+    interface DocsDownloadProgressPayload {
+      peer_id: string;
+      group_id: string;
+      file: string;
+      done: number;
+      total: number;
+      state: string;
+      error?: string;
+    }
+
+    interface GroupEvent {
+      type: string;
+      group: string;
+      from?: string;
+      payload?: unknown;
+      ts?: number;
+    }
+
+    interface HealthStartupPayload {
+      unclean: boolean;
+      healthy: boolean;
+      repaired: number;
+      failed?: string[];
+    }
+
+    interface ListenGroup {
+      id: string;
+      name: string;
+      role: string;
+      track?: ListenTrack;
+      play_state?: ListenPlayState;
+      listeners?: string[];
+      queue?: string[];
+      queue_types?: string[];
+      queue_index: number;
+      queue_total: number;
+      jukebox?: boolean;
+      pending_requests?: number;
+      drift?: Record<string, number>;
+      stream_gen?: number;
+    }
+
+    interface ListenPlayState {
+      playing: boolean;
+      position: number;
+      updated_at: number;
+    }
+
+    interface ListenState {
+      group: ListenGroup | null;
+    }
+
+    interface ListenTrack {
+      name: string;
+      duration: number;
+      bitrate: number;
+      format: string;
+      is_stream: boolean;
+    }
+
+    interface MQLogEntry {
+      dir: string;
+      topic: string;
+      peer: string;
+      ts: number;
+      error?: string;
+      via?: string;
+      encrypted?: boolean;
+    }
+
+    interface PeerGonePayload {
+      peerID: string;
+    }
+
+    interface PeerIdentityPayload {
+      peerID: string;
+      content: string;
+      email?: string;
+      avatarHash?: string;
+      videoDisabled?: boolean;
+      activeTemplate?: string;
+      publicKey?: string;
+      encryptionSupported?: boolean;
+      verified?: boolean;
+      goopClientVersion?: string;
+      signed?: boolean;
+      reachable: boolean;
+      offline?: boolean;
+      lastSeen?: number;
+      favorite?: boolean;
+    }
+
+    interface TemplateUpdatePayload {
+      template: string;
+      name: string;
+      installed: string;
+      latest: string;
+    }
+
+    /** Signals on call:{channelID}. Native-mode call-data, call-caption and call-device signals are untyped. */
+    type CallSignal =
+      | CallRequestPayload
+      | CallAckPayload
+      | CallOfferPayload
+      | CallAnswerPayload
+      | CallICEPayload
+      | CallHangupPayload
+      | { type: string; [key: string]: unknown };
+
+    /** One message from /api/mq/events. */
+    interface Event<P = unknown, T extends string = string> {
+      topic: T;
+      from: string;
+      payload: P;
+      /** Confirms delivery to the sender; unhandled messages are acked automatically. */
+      ack(): void;
+    }
+
+    /** Payloads of the topics with a fixed name. */
+    interface Topics {
+      "peer:announce": PeerIdentityPayload;
+      "peer:gone": PeerGonePayload;
+      "identity.response": PeerIdentityPayload;
+      "group.invite": GroupEvent;
+      "log:mq": MQLogEntry;
+      "security.consent": ConsentRequest;
+      "call.ringing": CallRinging;
+      "call.missed": CallMissed;
+      "template:update-available": TemplateUpdatePayload;
+      "health:startup": HealthStartupPayload;
+    }
+
+    /** Every known topic, discriminated by topic. */
+    type Known =
+      | { [K in keyof Topics]: Event<Topics[K], K> }[keyof Topics]
+      | Event<CallLoopbackICEPayload, `call:loopback:${string}`>
+      | Event<CallSignal, `call:${string}`>
+      | Event<GroupEvent, `group:${string}`>
+      | Event<ListenState, `listen:${string}`>
+      | Event<ChatRoomEvent, `chat.room:${string}`>
+      | Event<DocsDownloadProgressPayload, `docs:download:${string}`>;
+
+    /**
+     * Client.events: subscribe to MQ topics (needs goop-mq.js). A topic ending
+     * in * matches every topic with that prefix. Both return an unsubscribe function.
+     */
+    interface Helper {
+      on<K extends keyof Topics>(topic: K, fn: (e: Event<Topics[K], K>) => void): () => void;
+      on(topic: `call:loopback:${string}`, fn: (e: Event<CallLoopbackICEPayload, `call:loopback:${string}`>) => void): () => void;
+      on(topic: `call:${string}`, fn: (e: Event<CallSignal, `call:${string}`>) => void): () => void;
+      on(topic: `group:${string}`, fn: (e: Event<GroupEvent, `group:${string}`>) => void): () => void;
+      on(topic: `listen:${string}`, fn: (e: Event<ListenState, `listen:${string}`>) => void): () => void;
+      on(topic: `chat.room:${string}`, fn: (e: Event<ChatRoomEvent, `chat.room:${string}`>) => void): () => void;
+      on(topic: `docs:download:${string}`, fn: (e: Event<DocsDownloadProgressPayload, `docs:download:${string}`>) => void): () => void;
+      on(pattern: `${string}*`, fn: (e: Known) => void): () => void;
+      on(topic: string, fn: (e: Event) => void): () => void;
+      once<K extends keyof Topics>(topic: K, fn: (e: Event<Topics[K], K>) => void): () => void;
+      once(topic: `call:loopback:${string}`, fn: (e: Event<CallLoopbackICEPayload, `call:loopback:${string}`>) => void): () => void;
+      once(topic: `call:${string}`, fn: (e: Event<CallSignal, `call:${string}`>) => void): () => void;
+      once(topic: `group:${string}`, fn: (e: Event<GroupEvent, `group:${string}`>) => void): () => void;
+      once(topic: `listen:${string}`, fn: (e: Event<ListenState, `listen:${string}`>) => void): () => void;
+      once(topic: `chat.room:${string}`, fn: (e: Event<ChatRoomEvent, `chat.room:${string}`>) => void): () => void;
+      once(topic: `docs:download:${string}`, fn: (e: Event<DocsDownloadProgressPayload, `docs:download:${string}`>) => void): () => void;
+      once(pattern: `${string}*`, fn: (e: Known) => void): () => void;
+      once(topic: string, fn: (e: Event) => void): () => void;
+    }
+  }
+
+  interface Client {
+    actions: {
+      /** Actions registry: every invocable operation with its parameters and permissions */
+      get(): Promise<Api.ActionsAction[]>;
+      /** Run a server-side action by ID (local only); returns the underlying route's response */
+      run(body: Api.ActionRunRequest): Promise<Record<string, unknown>>;
+    };
+    avatar: {
+      /** Get own avatar image */
+      get(): Promise<Response>;
+      /** Delete own avatar */
+      delete(): Promise<Api.StatusOK>;
+      /** Get a peer's avatar image */
+      peer(id: string): Promise<Response>;
+      /** Upload own avatar (multipart image) */
+      upload(params: FormData | { avatar: Blob }): Promise<Api.AvatarUploadResponse>;
+    };
+    bridge: {
+      /** Request a bridge token from the rendezvous server */
+      requestToken(): Promise<Record<string, string>>;
+    };
+    call: {
+      /** Accept an incoming Pion session (native mode, target) */
+      accept(body: Api.CallStartRequest): Promise<Api.CallStartResponse>;
+      /** List active Pion sessions (native mode) */
+      active(): Promise<Api.CallSessionStatus[]>;
+      /** Get mic noise and echo suppression state (native mode) */
+      audioProcessing(): Promise<Api.CallAudioProcessing>;
+      /** Toggle mic noise and echo suppression (native mode) */
+      postAudioProcessing(body: Api.CallAudioProcessing): Promise<Api.CallAudioProcessing>;
+      /** Ask a busy peer to call back (native mode) */
+      callback(body: Api.CallChannelRequest): Promise<Api.StatusOK>;
+      /** List callback requests (native mode) */
+      callbacks(): Promise<Api.CallCallback[]>;
+      /** Dismiss callback requests (native mode) */
+      callbacksDismiss(body: Api.CallbackDismissRequest): Promise<Api.StatusOK>;
+      /** Turn live captions on or off for a call (native mode) */
+      captions(body: Api.CallCaptionsRequest): Promise<Record<string, unknown>>;
+      /** Send an in-call chat message (native mode) */
+      chat(body: Api.CallChatRequest): Promise<Api.CallDataMessage>;
+      /** Debug dump of all active sessions */
+      debug(): Promise<Api.CallDebugResponse>;
+      /** Switch camera or mic mid-call (native mode) */
+      device(body: Api.CallDeviceRequest): Promise<Api.StatusOK>;
+      /** List local cameras and mics (native mode) */
+      devices(): Promise<Api.CallDevices>;
+      /** Drop a small file into the call (native mode) */
+      file(body: Api.CallFileRequest): Promise<Api.CallDataMessage>;
+      /** Hang up a Pion session */
+      hangup(body: Api.CallChannelRequest): Promise<Api.StatusOK>;
+      /** Call history (newest first) */
+      history(params?: { peer_id?: string; limit?: number | string }): Promise<Record<string, unknown>>;
+      /** Delete the call history */
+      historyClear(): Promise<Api.StatusOK>;
+      /** Mark all missed calls as seen */
+      historySeen(): Promise<Api.StatusOK>;
+      /** Put a call on hold (native mode) */
+      hold(body: Api.CallChannelRequest): Promise<Api.CallHoldResponse>;
+      /** Send browser ICE candidates to Go LocalPC (Phase 4 loopback) */
+      loopbackIce(channel: string, body: Api.LoopbackICERequest): Promise<Api.StatusOK>;
+      /** Send browser SDP offer to Go LocalPC (Phase 4 loopback) */
+      loopbackOffer(channel: string, body: Api.LoopbackOfferRequest): Promise<Api.LoopbackOfferResponse>;
+      /** Query call stack mode (native vs browser) */
+      mode(): Promise<Api.CallModeResponse>;
+      /** Resume a held call (native mode) */
+      resume(body: Api.CallChannelRequest): Promise<Api.CallHoldResponse>;
+      /** HTTP chunked WebM stream of local self-view (Linux native mode) */
+      selfvideo(channel: string): Promise<Response>;
+      /** Register a new outbound Pion session (native mode, origin) */
+      start(body: Api.CallStartRequest): Promise<Api.CallStartResponse>;
+      /** Toggle local audio track mute (native mode) */
+      toggleAudio(body: Api.CallChannelRequest): Promise<Api.CallMuteResponse>;
+      /** Toggle local video track (native mode) */
+      toggleVideo(body: Api.CallChannelRequest): Promise<Api.CallVideoResponse>;
+      /** Transfer a call to another peer (native mode) */
+      transfer(body: Api.CallTransferRequest): Promise<Api.StatusOK>;
+      /** HTTP chunked WebM stream of remote video/audio (Linux native mode) */
+      video(channel: string): Promise<Response>;
+    };
+    capabilities: {
+      /** Feature capabilities of the rendezvous server */
+      get(): Promise<Record<string, boolean>>;
+    };
+    chat: {
+      /** Record an in-call message (browser-mode calls) */
+      call(body: Api.ChatCallMessageRequest): Promise<Api.StatusOK>;
+      /** Ask the host of a joined chat room for its last messages */
+      groupBackfill(body: Api.ChatGroupBackfillRequest): Promise<Api.StatusOK>;
+      /** Get the stored messages of a chat room */
+      groupHistory(params: { group_id: string; limit?: number | string }): Promise<Api.ChatRoomMessage[]>;
+      /** Get chat history with a peer */
+      history(params: { peer_id: string }): Promise<Api.ChatMessage[]>;
+      /** Clear chat history with a peer */
+      deleteHistory(params: { peer_id: string }): Promise<Api.StatusOK>;
+      /** Close a chat room */
+      roomsClose(body: Api.ChatRoomGroupIDRequest): Promise<Api.StatusOK>;
+      /** Create a chat room */
+      roomsCreate(body: Api.ChatRoomCreateRequest): Promise<Api.ChatRoomInfo>;
+      /** Join a remote chat room */
+      roomsJoin(body: Api.ChatRoomJoinRequest): Promise<Api.StatusOK>;
+      /** Leave a chat room */
+      roomsLeave(body: Api.ChatRoomGroupIDRequest): Promise<Api.StatusOK>;
+      /** Send a message to a chat room */
+      roomsSend(body: Api.ChatRoomSendRequest): Promise<Api.StatusOK>;
+      /** Get chat room state (members + recent messages) */
+      roomsState(params: { group_id: string }): Promise<Api.ChatRoomStateResponse>;
+    };
+    cluster: {
+      /** Set the binary path for this worker (worker only) */
+      binary(body: Api.ClusterBinaryRequest): Promise<Api.ClusterBinaryResponse>;
+      /** Cancel a job (host only) */
+      cancel(body: Api.ClusterCancelRequest): Promise<Api.StatusOK>;
+      /** Clear the entire job queue (host only) */
+      clear(): Promise<Api.StatusOK>;
+      /** Create or activate a cluster (become host) */
+      create(body: Api.ClusterCreateRequest): Promise<Api.ClusterCreateResponse>;
+      /** Delete a terminal job from the queue (host only) */
+      delete(body: Api.ClusterDeleteRequest): Promise<Api.StatusOK>;
+      /** List all jobs in the queue (host only) */
+      jobs(): Promise<Api.ClusterJobState[]>;
+      /** Join an existing cluster as worker */
+      join(body: Api.ClusterJoinRequest): Promise<Api.StatusOK>;
+      /** Close the current cluster */
+      leave(): Promise<Api.StatusOK>;
+      /** Pause this worker (worker only) */
+      pause(): Promise<Api.StatusOK>;
+      /** Resume this worker (worker only) */
+      resume(): Promise<Api.StatusOK>;
+      /** Queue statistics (host only) */
+      stats(): Promise<Api.ClusterQueueStats>;
+      /** Current cluster role and group */
+      status(): Promise<Api.ClusterStatusResponse>;
+      /** Submit a job to the cluster queue (host only) */
+      submit(body: Api.ClusterSubmitRequest): Promise<Api.ClusterSubmitResponse>;
+      /** List predefined job types with payload templates */
+      types(): Promise<Api.JobType[]>;
+      /** Pause a remote worker (host only) */
+      workerPause(body: Api.ClusterWorkerPeerRequest): Promise<Api.StatusOK>;
+      /** Resume a remote worker (host only) */
+      workerResume(body: Api.ClusterWorkerPeerRequest): Promise<Api.StatusOK>;
+      /** List all workers (host only) */
+      workers(): Promise<Api.ClusterWorkerInfo[]>;
+    };
+    config: {
+      /** Apply a deployment preset */
+      preset(body: Record<string, unknown>): Promise<Record<string, unknown>>;
+      /** List deployment presets */
+      presets(): Promise<Api.Preset[]>;
+      /** Validate goop.json */
+      validate(): Promise<Api.ConfigValidation>;
+      /** Validate a config document */
+      postValidate(): Promise<Api.ConfigValidation>;
+    };
+    credits: {
+      /** Check template access for a peer */
+      access(params: { template_dir: string; peer_id?: string }): Promise<Record<string, boolean>>;
+      /** Fetch account credit balance */
+      balance(params?: { peer_id?: string }): Promise<Record<string, number>>;
+      /** Grant credits to an account */
+      grant(body: Record<string, unknown>): Promise<Record<string, unknown>>;
+      /** Spend credits on a template purchase */
+      spend(body: Record<string, unknown>): Promise<Record<string, unknown>>;
+      /** Fetch store page data (balance, email, credits active) */
+      storeData(params?: { peer_id?: string }): Promise<Record<string, unknown>>;
+      /** Fetch per-template pricing and ownership info */
+      templateInfo(params: { template_dir: string; peer_id?: string }): Promise<Record<string, unknown>>;
+    };
+    data: {
+      /** Run aggregate query (COUNT, SUM, MAX, MIN, AVG) with optional GROUP BY */
+      aggregate(body: Api.DataAggregateRequest): Promise<(Record<string, unknown>)[]>;
+      /** Count rows matching criteria */
+      count(body: Api.DataWhereRequest): Promise<Api.DataCountResponse>;
+      /** Delete a row by id */
+      delete(body: Api.DataDeleteRequest): Promise<Api.StatusOK>;
+      /** Delete rows matching a WHERE clause */
+      deleteWhere(body: Api.DataDeleteWhereRequest): Promise<Api.DataAffectedResponse>;
+      /** Get unique values for a column */
+      distinct(body: Api.DataDistinctRequest): Promise<unknown[]>;
+      /** Check if any rows match criteria */
+      exists(body: Api.DataWhereRequest): Promise<Api.DataExistsResponse>;
+      /** Find rows with filtering, ordering, and pagination */
+      find(body: Api.DataFindRequest): Promise<(Record<string, unknown>)[]>;
+      /** Find a single row matching criteria */
+      findOne(body: Api.DataFindOneRequest): Promise<Record<string, unknown>>;
+      /** Get a single row by any column value */
+      getBy(body: Api.DataGetByRequest): Promise<Record<string, unknown>>;
+      /** Insert a row into a table (ORM tables validate column types) */
+      insert(body: Api.DataInsertRequest): Promise<Api.DataInsertResponse>;
+      /** Call a Lua data function */
+      luaCall(body: Api.DataLuaCallRequest): Promise<Record<string, unknown>>;
+      /** List available Lua data functions */
+      luaList(): Promise<Api.DataLuaListResponse>;
+      /** Get all ORM schemas with full column info and access policies */
+      ormSchema(): Promise<Record<string, Api.OrmSchema>>;
+      /** Get flat array of a single column's values */
+      pluck(body: Api.DataPluckRequest): Promise<unknown[]>;
+      /** Query rows from a table */
+      query(body: Api.DataQueryRequest): Promise<(Record<string, unknown>)[]>;
+      /** Replicas of template group hosts' shared tables */
+      replicas(): Promise<Api.ReplicaStatus[]>;
+      /** Get caller's role and permissions for a schema */
+      role(body: Api.DataRoleRequest): Promise<Api.DataRoleResponse>;
+      /** List all stored schema definitions (JSON files) */
+      schemas(): Promise<Api.SchemaListEntry[]>;
+      /** Create an ORM table from a stored schema definition */
+      schemasApply(body: Api.SchemaNameRequest): Promise<Api.StatusOK>;
+      /** Preview the DDL (CREATE TABLE statement) for a schema */
+      schemasDdl(body: Api.SchemaSaveRequest): Promise<Api.SchemaDdlResponse>;
+      /** Delete a schema definition */
+      schemasDelete(body: Api.SchemaNameRequest): Promise<Api.StatusOK>;
+      /** Get a schema definition by name */
+      schemasGet(body: Api.SchemaNameRequest): Promise<Api.SchemaSaveRequest>;
+      /** Create or update a schema definition (saves as JSON file) */
+      schemasSave(body: Api.SchemaSaveRequest): Promise<Api.StatusOK>;
+      /** Update access policy for a stored schema */
+      schemasSetAccess(body: Api.SchemaSetAccessRequest): Promise<Api.StatusOK>;
+      /** Toggle whether a schema is included in the GraphQL context */
+      schemasSetContext(body: Api.SchemaSetContextRequest): Promise<Api.StatusOK>;
+      /** Update role access matrix for a stored schema */
+      schemasSetRoles(body: Api.SchemaSetRolesRequest): Promise<Api.StatusOK>;
+      /** List tables with schema, policies, and mode (orm/classic) */
+      tables(): Promise<Api.DataTableListEntry[]>;
+      /** Add a column to a table */
+      tablesAddColumn(body: Api.DataColumnRequest): Promise<Api.StatusOK>;
+      /** Create a new table (classic or ORM schema format, auto-detected) */
+      tablesCreate(body: Api.DataTableCreateRequest): Promise<Api.DataTableCreateResponse>;
+      /** Drop a table */
+      tablesDelete(body: Api.DataTableRequest): Promise<Api.StatusOK>;
+      /** Describe a table — ORM tables return typed JSON schema, classic returns PRAGMA columns */
+      tablesDescribe(body: Api.DataTableRequest): Promise<Api.DataDescribeResponse>;
+      /** Drop a column from a table */
+      tablesDropColumn(body: Api.DataColumnRequest): Promise<Api.StatusOK>;
+      /** Export table schema as portable JSON (ORM returns stored schema, classic reads PRAGMA) */
+      tablesExportSchema(body: Api.DataTableRequest): Promise<Api.OrmSchema>;
+      /** Rename a table */
+      tablesRename(body: Api.DataRenameRequest): Promise<Api.StatusOK>;
+      /** Set insert policy for a table (owner, open, group, local) */
+      tablesSetPolicy(body: Api.DataPolicyRequest): Promise<Api.StatusOK>;
+      /** List all stored transformation definitions */
+      transformations(): Promise<Api.TransformListEntry[]>;
+      /** Delete a transformation definition */
+      transformationsDelete(body: Api.TransformNameRequest): Promise<Api.StatusOK>;
+      /** Execute a transformation: read from source table, transform, insert into target table */
+      transformationsExecute(body: Api.TransformExecuteRequest): Promise<Api.TransformExecuteResponse>;
+      /** Check if a local file path exists */
+      transformationsFileExists(body: Api.TransformFileExistsRequest): Promise<Record<string, boolean>>;
+      /** Get a transformation definition by name */
+      transformationsGet(body: Api.TransformNameRequest): Promise<Api.TransformSaveRequest>;
+      /** Preview a transformation by applying it to sample rows (dry run) */
+      transformationsPreview(body: Api.TransformPreviewRequest): Promise<(Record<string, unknown>)[]>;
+      /** Create or update a transformation definition (saves as JSON file) */
+      transformationsSave(body: Api.TransformSaveRequest): Promise<Api.StatusOK>;
+      /** Discover column names from a data source (table or file) */
+      transformationsSourceFields(body: Api.TransformDataEndpoint): Promise<string[]>;
+      /** List available transform functions for transformation fields */
+      transformationsTransforms(): Promise<string[]>;
+      /** Update a row by id */
+      update(body: Api.DataUpdateRequest): Promise<Api.StatusOK>;
+      /** Update rows matching a WHERE clause */
+      updateWhere(body: Api.DataUpdateWhereRequest): Promise<Api.DataAffectedResponse>;
+      /** Insert or update a row by key column */
+      upsert(body: Api.DataUpsertRequest): Promise<Api.StatusOK>;
+    };
+    datafed: {
+      /** Get all peer contributions for a data-federation group */
+      contributions(body: Api.DatafedGroupIDRequest): Promise<Api.DatafedPeerContribution[]>;
+      /** List all active data-federation groups and their contributions */
+      groups(): Promise<Api.DatafedGroupInfo[]>;
+      /** Offer context tables to a data-federation group */
+      offer(body: Api.DatafedOfferRequest): Promise<Api.StatusOK>;
+      /** Withdraw all contributed tables from a data-federation group */
+      withdraw(body: Api.DatafedGroupIDRequest): Promise<Api.StatusOK>;
+    };
+    digest: {
+      /** Email digest subscription (local only) */
+      get(): Promise<Record<string, unknown>>;
+      /** Change the email digest subscription (local only) */
+      post(body: Api.DigestRequest): Promise<Api.DigestStatus>;
+    };
+    docs: {
+      /** Set who may fetch a shared file (local access only) */
+      acl(body: Api.DocsACLRequest): Promise<Api.ACL>;
+      /** Aggregate file lists from all group members */
+      browse(params: { group_id: string; stream?: string }): Promise<Api.DocsBrowseResponse>;
+      /** Fetch the file lists of members a browse listed as slow */
+      browseRefresh(body: Api.DocsBrowseRefreshRequest): Promise<Api.DocsBrowseResponse>;
+      /** Delete a shared file (local access only) */
+      delete(body: Api.DocsDeleteRequest): Promise<Api.StatusOK>;
+      /** Download a file (local store or proxied from remote peer) */
+      download(params: { group_id: string; file: string; peer_id?: string; inline?: string }): Promise<string>;
+      /** List all file-sharing groups with their local files */
+      groups(): Promise<Api.DocGroupsResponse>;
+      /** List my shared files for a group */
+      my(params: { group_id: string }): Promise<Api.DocFileInfo[]>;
+      /** Upload a file to share with the group (multipart) */
+      upload(params: FormData | { group_id: string; file: Blob }): Promise<Api.StatusOK>;
+      /** Upload a file from a local filesystem path to share with the group */
+      uploadLocal(body: Api.DocsUploadLocalRequest): Promise<Api.StatusOK>;
+    };
+    encryption: {
+      /** Fetch sealed broadcast key for a peer */
+      broadcastKey(): Promise<Record<string, unknown>>;
+      /** Upload peer's public encryption key */
+      postKeys(): Promise<Record<string, unknown>>;
+      /** Fetch a peer's public encryption key */
+      keys(peerId: string): Promise<Record<string, unknown>>;
+    };
+    executorApiYaml: {
+      /** Executor OpenAPI specification (YAML) */
+      get(): Promise<Response>;
+    };
+    export: {
+      /** Download everything this peer stores (local only) */
+      all(): Promise<Response>;
+      /** Row counts per data category and table (local only) */
+      summary(): Promise<(Record<string, unknown>)[]>;
+      /** Delete stored data by category, optionally for one peer only (local only) */
+      wipe(body: Api.DataWipeRequest): Promise<Record<string, unknown>>;
+    };
+    fs: {
+      /** Browse the local filesystem (directories and files) */
+      browse(params?: { dir?: string }): Promise<Api.FsBrowseResponse>;
+    };
+    graphql: {
+      /** Execute a GraphQL query or mutation */
+      post(body: Api.GraphqlRequest): Promise<Record<string, unknown>>;
+      /** Rebuild the GraphQL schema from current context tables */
+      rebuild(): Promise<Api.StatusOK>;
+      /** Preview GraphQL SDL for a table schema */
+      schema(body: Api.SchemaSaveRequest): Promise<Api.GraphqlSchemaResponse>;
+      /** Get GraphQL engine status and context tables */
+      status(): Promise<Api.GraphqlStatusResponse>;
+    };
+    groups: {
+      /** List hosted groups with live member data */
+      get(): Promise<Api.HostedGroupInfo[]>;
+      /** Create a new hosted group */
+      post(body: Api.GroupCreateRequest): Promise<Api.GroupCreateResponse>;
+      /** Close and delete a hosted group (broadcasts group:close via MQ to all members) */
+      close(body: Api.GroupIDRequest): Promise<Api.StatusOK>;
+      /** Authorize a member to co-host (relay) a hosted group */
+      cohost(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+      /** Withdraw a co-host's authorization to relay a hosted group */
+      cohostRemove(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+      /** Invite a peer to a hosted group (sends group.invite via MQ) */
+      invite(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+      /** Join a remote group as a member (sends group:join via MQ) */
+      join(body: Api.GroupHostJoinRequest): Promise<Api.StatusOK>;
+      /** Host joins own group as a member */
+      joinOwn(body: Api.GroupIDRequest): Promise<Api.StatusOK>;
+      /** Kick a member from a hosted group */
+      kick(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+      /** Leave a group as a member (sends group:leave via MQ) */
+      leave(body: Api.GroupIDRequest): Promise<Api.StatusOK>;
+      /** Host leaves own group */
+      leaveOwn(body: Api.GroupIDRequest): Promise<Api.StatusOK>;
+      /** Update max member limit for a hosted group */
+      maxMembers(body: Api.GroupMaxMembersRequest): Promise<Api.StatusOK>;
+      /** Update group name and/or max_members (broadcasts group:meta via MQ) */
+      meta(body: Api.GroupMetaRequest): Promise<Api.StatusOK>;
+      /** Turn ordered delivery on or off for a hosted group */
+      ordered(body: Api.GroupOrderedRequest): Promise<Api.StatusOK>;
+      /** Owner changes of a group, latest first */
+      ownership(params: { group_id: string }): Promise<Api.OwnershipRecord[]>;
+      /** Online/offline status of every group member (separate from membership) */
+      presence(params: { group_id: string }): Promise<Api.MemberPresence[]>;
+      /** Rejoin a previously joined group */
+      rejoin(body: Api.GroupHostJoinRequest): Promise<Api.StatusOK>;
+      /** Send a payload to a group (host broadcasts, member sends to host) */
+      send(body: Api.GroupSendRequest): Promise<Api.StatusOK>;
+      /** Set the default role assigned to new members of a hosted group */
+      setDefaultRole(body: Api.GroupSetDefaultRoleRequest): Promise<Api.StatusOK>;
+      /** Set a member's role in a hosted group */
+      setRole(body: Api.GroupSetRoleRequest): Promise<Api.StatusOK>;
+      /** Set the available roles for a hosted group */
+      setRoles(body: Api.GroupSetRolesListRequest): Promise<Api.StatusOK>;
+      /** List group subscriptions (member side) with host reachability */
+      subscriptions(): Promise<Api.SubscriptionsResponse>;
+      /** Remove a stale subscription record */
+      subscriptionsRemove(body: Api.GroupHostJoinRequest): Promise<Api.StatusOK>;
+      /** Hand a hosted group over to one of its members */
+      transfer(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+      /** Lift a flood-protection mute on a member of a hosted group */
+      unmute(body: Api.GroupPeerRequest): Promise<Api.StatusOK>;
+    };
+    health: {
+      /** Startup checks */
+      get(): Promise<Api.HealthReport>;
+    };
+    jobs: {
+      /** Background job queue */
+      get(): Promise<Api.Report>;
+      /** Empty the dead-letter list of the job queue */
+      clear(): Promise<Api.JobsClearResponse>;
+    };
+    listen: {
+      /** Host closes the listen group */
+      close(): Promise<Api.StatusOK>;
+      /** Playback control — play, pause, seek, next, prev, skip, remove */
+      control(body: Api.ListenControlRequest): Promise<Api.StatusOK>;
+      /** Host creates a listen group */
+      create(body: Api.ListenCreateRequest): Promise<Api.ListenGroup>;
+      /** Listener joins a group */
+      join(body: Api.ListenJoinRequest): Promise<Api.StatusOK>;
+      /** Turn jukebox mode on or off (local access only) */
+      jukebox(body: Api.ListenJukeboxRequest): Promise<Api.StatusOK>;
+      /** Listener leaves the current group */
+      leave(): Promise<Api.StatusOK>;
+      /** Load audio file(s) as playlist (local access only) */
+      load(body: Api.ListenLoadRequest): Promise<Api.ListenTrack>;
+      /** Append files to the playlist (local access only) */
+      queueAdd(body: Api.ListenQueueAddRequest): Promise<Api.StatusOK>;
+      /** Listener reports its playback position */
+      report(body: Api.ListenReportRequest): Promise<Api.StatusOK>;
+      /** Listener requests a track from the host */
+      request(body: Api.ListenTrackRequestBody): Promise<Api.StatusOK>;
+      /** Host's pending listener requests */
+      requests(): Promise<Api.ListenRequestsResponse>;
+      /** Queue a listener request (local access only) */
+      requestsApprove(body: Api.ListenApproveRequest): Promise<Api.StatusOK>;
+      /** Drop a listener request */
+      requestsReject(body: Api.ListenRejectRequest): Promise<Api.StatusOK>;
+      /** Current listen group state */
+      state(): Promise<Api.ListenStateResponse>;
+      /** Live audio stream */
+      stream(): Promise<Response>;
+    };
+    logs: {
+      /** Snapshot of recent Go process log lines */
+      get(): Promise<(Record<string, string>)[]>;
+      /** Sink for browser-side log messages */
+      client(body: Api.ClientLogRequest): Promise<void>;
+      /** Get or set verbose P2P logging */
+      verbose(body?: Record<string, unknown>): Promise<Record<string, unknown>>;
+    };
+    lua: {
+      /** Fetch a Lua script's content */
+      content(params: { name: string; func?: string }): Promise<Record<string, unknown>>;
+      /** Install scripts from a prefab pack */
+      prefabsApply(body: Api.LuaPrefabApplyRequest): Promise<Api.LuaPrefabApplyResponse>;
+    };
+    mq: {
+      /** Acknowledge a received MQ message */
+      ack(body: Api.MqAckRequest): Promise<Api.StatusOK>;
+      /** MQ delivery metrics per topic family (sent, delivered, timeouts, ack latency) and priority lane queue depths */
+      metrics(): Promise<Api.Metrics>;
+      /** Messages queued for peers that could not be reached */
+      outbox(params?: { peer_id?: string }): Promise<Api.MqOutboxEntry[]>;
+      /** Drop queued messages */
+      outboxPurge(body: Api.MqOutboxPurgeRequest): Promise<Api.MqOutboxPurgeResponse>;
+      /** Send an MQ message to a peer */
+      send(body: Api.MqSendRequest): Promise<Api.MqSendResponse>;
+    };
+    myBalance: {
+      /** Get this peer's credit balance */
+      get(): Promise<Api.MyBalanceResponse>;
+    };
+    openapiJson: {
+      /** This OpenAPI 3.0 spec (generated by swaggo/swag) */
+      get(): Promise<Record<string, unknown>>;
+    };
+    pair: {
+      /** Pending pairing code, paired devices, guest sessions and the remote control URL (local only) */
+      get(): Promise<Record<string, unknown>>;
+      /** Trade a pairing code for a device token */
+      claim(body: Api.PairClaimRequest): Promise<Record<string, unknown>>;
+      /** Open a read-only guest session (local only) */
+      guest(body: Api.PairGuestRequest): Promise<Record<string, unknown>>;
+      /** End a guest session (local only) */
+      guestRevoke(body: Api.PairRevokeRequest): Promise<Api.StatusOK>;
+      /** Unpair a device (local only) */
+      revoke(body: Api.PairRevokeRequest): Promise<Api.StatusOK>;
+      /** Issue a short-lived pairing code (local only) */
+      start(body: Api.PairStartRequest): Promise<Record<string, unknown>>;
+    };
+    peer: {
+      /** Fetch a remote peer's site content (HTML string) */
+      content(params: { id: string }): Promise<Api.PeerContentResponse>;
+    };
+    peers: {
+      /** List all known peers with metadata */
+      get(params?: { namespace?: string; limit?: number | string; offset?: number | string; q?: string; by?: string; sort?: string; favorites?: number | string; online?: number | string; verified?: number | string }): Promise<(Record<string, unknown>)[]>;
+      /** Clock offsets to other peers */
+      clock(params?: { peer?: string }): Promise<Api.ClockEstimate>;
+      /** Toggle favorite flag for a peer */
+      favorite(body: Api.PeerFavoriteRequest): Promise<Api.StatusOK>;
+      /** Follow a peer's site (local only) */
+      follow(body: Api.PeerFollowRequest): Promise<Api.SiteFollow>;
+      /** Followed sites and the state of their local copies */
+      follows(): Promise<Api.SiteFollow[]>;
+      /** Sync a followed site now (local only) */
+      followsSync(body: Api.PeerFollowRequest): Promise<Api.SiteFollow>;
+      /** Delete everything stored about a peer (local only) */
+      forget(body: Api.PeerForgetRequest): Promise<Record<string, unknown>>;
+      /** List joined presence namespaces */
+      namespaces(): Promise<Api.NamespaceInfo[]>;
+      /** Private notes about peers (local only) */
+      notes(params?: { peer?: string }): Promise<Api.PeerNote>;
+      /** Set the private note for a peer (local only) */
+      postNotes(body: Api.PeerNoteRequest): Promise<Api.PeerNote>;
+      /** Probe all known peers for reachability */
+      probe(): Promise<Api.StatusOK>;
+      /** Abuse reports I filed about peers (local only) */
+      reports(params?: { peer?: string }): Promise<Api.PeerReport[]>;
+      /** File an abuse report about a peer (local only) */
+      postReports(body: Api.PeerReportRequest): Promise<Api.PeerReport>;
+      /** Withdraw an abuse report (local only) */
+      reportsDelete(body: Record<string, unknown>): Promise<Record<string, boolean>>;
+      /** Signed reputation record about a peer (local only) */
+      reputation(params: { peer: string; days?: number | string }): Promise<Api.ReputationRecord>;
+      /** Submit a reputation record to the rendezvous moderation queue (local only) */
+      reputationSubmit(body: Api.ReputationSubmitRequest): Promise<Record<string, unknown>>;
+      /** Peer retention pruning stats */
+      retention(): Promise<Api.RetentionStats>;
+      /** Forget peers past peer_retention_days now (local only) */
+      retentionRun(): Promise<Api.RetentionStats>;
+      /** Stop following a peer's site and delete its copy (local only) */
+      unfollow(body: Api.PeerFollowRequest): Promise<Record<string, boolean>>;
+    };
+    permalink: {
+      /** Create a content-addressed permalink to a peer page */
+      post(body: Record<string, unknown>): Promise<Record<string, unknown>>;
+      /** List pinned page copies, newest first */
+      pins(): Promise<Api.Pin[]>;
+      /** Check a permalink against the live page */
+      resolve(params: { link: string }): Promise<Api.PermalinkResolution>;
+      /** Remove a pinned copy (local access only) */
+      unpin(body: Record<string, unknown>): Promise<Api.StatusOK>;
+    };
+    pulse: {
+      /** Refresh relay reservation for a peer */
+      post(params: { peer: string }): Promise<Record<string, boolean>>;
+    };
+    relayReport: {
+      /** Report a peer's relay reservation health */
+      post(body: Record<string, unknown>): Promise<void>;
+    };
+    rendezvous: {
+      /** Check a rendezvous server's capabilities */
+      check(params: { url: string }): Promise<Record<string, unknown>>;
+    };
+    rules: {
+      /** Automation rules with their last run (local only) */
+      get(): Promise<Api.Rule[]>;
+      /** Delete an automation rule (local only) */
+      delete(body: Api.RuleIDRequest): Promise<Api.StatusOK>;
+      /** Create (no id) or update an automation rule (local only) */
+      save(body: Api.Rule): Promise<Api.Rule>;
+      /** Run a rule's action now, regardless of its trigger (local only) */
+      test(body: Api.RuleIDRequest): Promise<Api.StatusOK>;
+    };
+    schedule: {
+      /** Scheduled listen sessions and group events (local only) */
+      get(params?: { group?: string }): Promise<Api.ScheduledSession[]>;
+      /** Create or update a scheduled session (local only) */
+      post(body: Api.ScheduledSession): Promise<Api.ScheduledSession>;
+      /** Delete a scheduled session (local only) */
+      delete(body: Api.ScheduleDeleteRequest): Promise<Record<string, boolean>>;
+      /** Issued calendar feed tokens (local only) */
+      feeds(): Promise<Api.CalendarFeedView[]>;
+      /** Issue a calendar feed token (local only) */
+      postFeeds(body: Api.CalendarFeedRequest): Promise<Api.CalendarFeedView>;
+      /** Revoke a calendar feed (local only) */
+      feedsRevoke(body: Api.CalendarFeedRequest): Promise<Record<string, boolean>>;
+    };
+    search: {
+      /** Search reachable favorite and group peers */
+      network(params: { q: string; limit?: number | string }): Promise<Record<string, unknown>>;
+    };
+    secrets: {
+      /** Secrets by name and version, without values (local only) */
+      get(): Promise<Api.Info[]>;
+      /** Delete a secret (local only) */
+      delete(body: Api.SecretNameRequest): Promise<Api.StatusOK>;
+      /** Replace a secret with a new random token (local only) */
+      rotate(body: Api.SecretNameRequest): Promise<Api.SecretRotated>;
+      /** Store a secret; replacing a value bumps its version (local only) */
+      set(body: Api.SecretSetRequest): Promise<Api.Info>;
+    };
+    security: {
+      /** Audit log of inbound P2P streams (newest first) */
+      audit(params?: { peer_id?: string; protocol?: string; outcome?: string; since?: number | string; until?: number | string; limit?: number | string }): Promise<Api.AuditEntry[]>;
+      /** Pending access prompts and remembered consent decisions */
+      consent(): Promise<Record<string, unknown>>;
+      /** Answer a parked inbound docs/data access prompt */
+      postConsent(body: Api.ConsentDecisionRequest): Promise<Api.StatusOK>;
+      /** Forget a remembered consent decision so the peer is prompted again */
+      consentForget(body: Api.ConsentForgetRequest): Promise<Api.StatusOK>;
+    };
+    self: {
+      /** This peer's own identity and metadata */
+      get(): Promise<Record<string, unknown>>;
+    };
+    services: {
+      /** Check a single service URL (pre-save validation) */
+      check(params: { url: string; type?: string }): Promise<Api.ServiceHealthEntry>;
+      /** Ping all configured external services */
+      health(): Promise<Api.ServicesHealthResponse>;
+      /** Aggregate logs from all microservices (admin only) */
+      logs(): Promise<(Record<string, unknown>)[]>;
+    };
+    settings: {
+      /** Partial settings update — only provided (non-null) fields are written */
+      quick(body: Api.QuickSettingsRequest): Promise<Api.StatusOK>;
+      /** Read current quick settings (label, email, theme, device prefs, flags) */
+      quickGet(): Promise<Api.QuickSettingsResponse>;
+    };
+    site: {
+      /** Site serving traffic */
+      analytics(): Promise<Api.ServeStats>;
+      /** Site asset pipeline stats */
+      assets(): Promise<Api.SiteassetsStats>;
+      /** Rescan the site for image variants now (local only) */
+      assetsRebuild(): Promise<Api.SiteassetsStats>;
+      /** Fetch a site file's content and ETag */
+      content(params: { path: string }): Promise<Record<string, string>>;
+      /** Delete a file from the site content store */
+      delete(body: Api.SiteDeleteRequest): Promise<Api.StatusOK>;
+      /** Download the entire site as a zip archive */
+      export(params?: { format?: string }): Promise<Response>;
+      /** List site files as a flat tree */
+      files(): Promise<Api.SiteFileItem[]>;
+      /** Import a site from a zip archive */
+      import(params: FormData | { csrf: string; file: Blob }): Promise<Api.SiteImportResponse>;
+      /** Publish a static copy of the site (local only) */
+      publish(body: Api.SitePublishRequest): Promise<Api.SitePublishResponse>;
+      /** Upload a file to the site content store */
+      upload(params: FormData | { path: string; file: Blob }): Promise<Api.SiteUploadResponse>;
+      /** Upload a file from a local filesystem path to the site content store */
+      uploadLocal(body: Api.SiteUploadLocalRequest): Promise<Api.SiteUploadResponse>;
+    };
+    splitPrefs: {
+      /** Save a UI split pane preference (position 0-100) */
+      post(body: Api.SplitPrefRequest): Promise<Api.StatusOK>;
+    };
+    startup: {
+      /** Startup stages */
+      get(): Promise<Api.StartupReport>;
+    };
+    template: {
+      /** Get active template manifest */
+      settings(): Promise<Api.TemplateSettingsResponse>;
+    };
+    templates: {
+      /** List available store templates */
+      get(): Promise<(Record<string, unknown>)[]>;
+      /** Apply a built-in template */
+      apply(body: Api.TemplateApplyRequest): Promise<Api.TemplateApplyResponse>;
+      /** Apply a template from a local folder */
+      applyLocal(body: Api.TemplateApplyLocalRequest): Promise<Api.TemplateApplyResponse>;
+      /** Apply a store template (download, spend credits, apply) */
+      applyStore(body: Api.TemplateApplyStoreRequest): Promise<Api.TemplateApplyStoreResponse>;
+      /** Open an install session for a store template */
+      install(body: Api.TemplateInstallRequest): Promise<Api.TemplateInstallResponse>;
+      /** Get or update template pricing */
+      prices(): Promise<Record<string, unknown>>;
+      /** Publish a local template folder to the template store */
+      publish(body: Api.TemplatePublishRequest): Promise<Api.TemplatePublishResponse>;
+      /** Revert the last template apply */
+      revert(body: Api.TemplateRevertRequest): Promise<Api.TemplateRevertResponse>;
+      /** List template snapshots */
+      snapshots(): Promise<Api.TemplateSnapshotEntry[]>;
+      /** Update status of the active template */
+      update(): Promise<Api.TemplateUpdateStatusResponse>;
+      /** Update the active store template */
+      postUpdate(body: Api.TemplateUpdateRequest): Promise<Api.TemplateUpdateResponse>;
+      /** Validate a local template folder */
+      validateLocal(body: Api.TemplateValidateLocalRequest): Promise<Api.TemplateValidateLocalResponse>;
+      /** Fetch template bundle or manifest by directory name */
+      getByDir(dir: string): Promise<Record<string, unknown>>;
+    };
+    topology: {
+      /** Network topology graph data */
+      get(): Promise<Api.TopologyResponse>;
+    };
+    events: Events.Helper;
+  }
+
+  const client: Client;
+}
@@ -331,17 +331,35 @@
   async function request(url, opts) {
     var res = await fetch(url, opts);
     if (!res.ok) {
+      // Errors come as {"error": {code, message, field}}; keep all three.
       var text = await res.text();
-      throw new Error(text || res.statusText);
+      var err = new Error(text || res.statusText);
+      try {
+        var e = JSON.parse(text).error;
+        if (e && e.message) { err.message = e.message; err.code = e.code; err.field = e.field; }
+      } catch (_) {}
+      err.status = res.status;
+      throw err;
     }
     return res.json();
   }
 
+  function idempotencyKey() {
+    if (window.crypto && window.crypto.randomUUID) return window.crypto.randomUUID();
+    return Date.now().toString(36) + Math.random().toString(36).slice(2);
+  }
+
+  // Writes carry an Idempotency-Key and are sent again once after a network
+  // error; the peer answers the retry from its cache instead of inserting twice.
   function post(url, body) {
-    return request(url, {
+    var opts = {
       method: "POST",
-      headers: { "Content-Type": "application/json" },
+      headers: { "Content-Type": "application/json", "Idempotency-Key": idempotencyKey() },
       body: JSON.stringify(body),
+    };
+    return request(url, opts).catch(function(err) {
+      if (!(err instanceof TypeError)) throw err;
+      return request(url, opts);
     });
   }
 
@@ -34,6 +34,18 @@
 (() => {
   window.Goop = window.Goop || {};
 
+  // responseError builds an Error from a failed response: the message of the
+  // viewer's {"error": {code, message, field}} envelope, or the text as is.
+  function responseError(r, text) {
+    const err = new Error(text.trim() || r.statusText);
+    try {
+      const e = JSON.parse(text).error;
+      if (e && e.message) Object.assign(err, { message: e.message, code: e.code, field: e.field });
+    } catch (_) {}
+    err.status = r.status;
+    return err;
+  }
+
   let sse = null;
 
   function post(url, body) {
@@ -42,7 +54,7 @@
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify(body),
     }).then((r) => {
-      if (!r.ok) return r.text().then((t) => { throw new Error(t); });
+      if (!r.ok) return r.text().then((t) => { throw responseError(r, t); });
       return r.json();
     });
   }
@@ -1,6 +1,18 @@
 (() => {
   window.Goop = window.Goop || {};
 
+  // responseError builds an Error from a failed response: the message of the
+  // viewer's {"error": {code, message, field}} envelope, or the text as is.
+  function responseError(r, text) {
+    const err = new Error(text.trim() || r.statusText);
+    try {
+      const e = JSON.parse(text).error;
+      if (e && e.message) Object.assign(err, { message: e.message, code: e.code, field: e.field });
+    } catch (_) {}
+    err.status = r.status;
+    return err;
+  }
+
   let sse = null;
   const subs = [];
 
@@ -75,7 +87,7 @@
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ peer_id: peerId, topic: topic, payload: payload }),
       }).then(function(r) {
-        if (!r.ok) return r.text().then(function(t) { throw new Error(t); });
+        if (!r.ok) return r.text().then(function(t) { throw responseError(r, t); });
         return r.json();
       });
     },
@@ -20,6 +20,10 @@
 //   //   LastSeen:       string   — RFC3339 timestamp of last activity
 //   // }
 //
+//   // one filtered, sorted page plus counts, for lists of thousands of peers
+//   const { peers, summary } = await Goop.peers.page({ limit: 50, offset: 0, q: "ali", sort: "name", online: 1 });
+//   // summary: { total, online, favorites, verified, matched, capacity, evicted }
+//
 //   // subscribe to live updates (polls /api/peers every 5 s by default)
 //   Goop.peers.subscribe({
 //     onSnapshot(peers)         { /* full list on first load, same shape as list() */ },
@@ -44,6 +48,23 @@
       });
     },
 
+    /**
+     * Fetch one page of the peer list, filtered and sorted on the server.
+     * opts: { limit, offset, q, by ("name"|"id"), sort ("id"|"name"|"last_seen"),
+     *         favorites, online, verified } — all optional; limit 0 = all.
+     * Resolves to { peers, offset, limit, summary }.
+     */
+    page(opts) {
+      const qs = new URLSearchParams({ limit: 0 });
+      for (const [k, v] of Object.entries(opts || {})) {
+        if (v !== undefined && v !== "") qs.set(k, v);
+      }
+      return fetch("/api/peers?" + qs).then((r) => {
+        if (!r.ok) throw new Error(r.statusText);
+        return r.json();
+      });
+    },
+
     /**
      * Subscribe to live peer updates via REST polling.
      * callbacks: { onSnapshot(peers), onUpdate(peer_id, peer), onRemove(peer_id) }
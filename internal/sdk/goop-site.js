@@ -23,6 +23,18 @@
 (() => {
   window.Goop = window.Goop || {};
 
+  // responseError builds an Error from a failed response: the message of the
+  // viewer's {"error": {code, message, field}} envelope, or the text as is.
+  function responseError(r, text) {
+    const err = new Error(text.trim() || r.statusText);
+    try {
+      const e = JSON.parse(text).error;
+      if (e && e.message) Object.assign(err, { message: e.message, code: e.code, field: e.field });
+    } catch (_) {}
+    err.status = r.status;
+    return err;
+  }
+
   window.Goop.site = {
     /** List all files in the peer's site directory. Returns [{Path, IsDir, Depth}] */
     files() {
@@ -64,10 +76,7 @@
       fd.append("path", path);
       fd.append("file", file);
       const res = await fetch("/api/site/upload", { method: "POST", body: fd });
-      if (!res.ok) {
-        const text = await res.text();
-        throw new Error(text || res.statusText);
-      }
+      if (!res.ok) throw responseError(res, await res.text());
       return res.json();
     },
 
@@ -82,10 +91,7 @@
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ path: path }),
       });
-      if (!res.ok) {
-        const text = await res.text();
-        throw new Error(text || res.statusText);
-      }
+      if (!res.ok) throw responseError(res, await res.text());
       return res.json();
     },
   };
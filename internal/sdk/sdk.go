@@ -1,7 +1,11 @@
 // Package sdk serves the public JavaScript and CSS SDK for site/template authors.
-// Files are available at /sdk/goop-*.js and /sdk/goop-*.css.
+// Files are available at /sdk/goop-*.js and /sdk/goop-*.css. The typed API
+// client, goop-client.js and goop-client.d.ts, is generated from the route
+// annotations by ./gen.
 package sdk
 
+//go:generate go run ./gen
+
 import (
 	"embed"
 	"io/fs"
@@ -15,7 +19,7 @@ import (
 	"github.com/tdewolff/minify/v2/js"
 )
 
-//go:embed *.js *.css
+//go:embed *.js *.css *.d.ts
 var rawFS embed.FS
 
 var minified map[string][]byte
@@ -38,6 +42,7 @@ func init() {
 			mime = "application/javascript"
 		case ".css":
 			mime = "text/css"
+		case ".ts": // type declarations are served as they are
 		default:
 			return nil
 		}
@@ -45,6 +50,10 @@ func init() {
 		if err != nil {
 			return nil
 		}
+		if mime == "" {
+			minified[path] = raw
+			return nil
+		}
 		out, err := m.Bytes(mime, raw)
 		if err != nil {
 			log.Printf("sdk: minify warning: %s: %v (using original)", path, err)
@@ -56,7 +65,15 @@ func init() {
 	})
 }
 
-// Handler returns an http.Handler that serves the SDK JS and CSS files.
+// File returns the served (minified) content of an SDK file such as
+// "goop-data.js".
+func File(name string) ([]byte, bool) {
+	data, ok := minified[name]
+	return data, ok
+}
+
+// Handler returns an http.Handler that serves the SDK JS, CSS and type
+// declaration files.
 // Mount it at /sdk/ with a StripPrefix.
 func Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +85,8 @@ func Handler() http.Handler {
 				w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
 			case ".css":
 				w.Header().Set("Content-Type", "text/css; charset=utf-8")
+			case ".ts":
+				w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
 			}
 			w.Write(data)
 			return
@@ -0,0 +1,292 @@
+// Package search answers keyword queries over what a peer chooses to
+// expose: the pages of its site and the names of its shared documents.
+package search
+
+import (
+	"html"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/petervdpas/goop2/internal/group_types/files"
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// Sources a peer can expose to search.
+const (
+	SourceSite = "site"
+	SourceDocs = "docs"
+)
+
+const (
+	maxPageBytes = 1 << 20 // larger files are skipped
+	maxPages     = 2000
+	snippetLen   = 160
+)
+
+// Index searches a site directory and a docs store. Pages are read once
+// and kept until their size or modification time changes.
+type Index struct {
+	SiteRoot string
+	Docs     *files.Store // nil = no documents
+
+	// DocGroups returns the groups whose documents peerID may find: the
+	// groups it shares with us.
+	DocGroups func(peerID string) []string
+
+	mu      sync.Mutex
+	sources []string
+	pages   map[string]*page
+}
+
+type page struct {
+	size  int64
+	mod   time.Time
+	title string
+	text  string // lower-cased words separated by single spaces
+	raw   string // text as written, for snippets
+}
+
+// SetSources sets what may be searched (SourceSite, SourceDocs). Nothing
+// is searchable until it is called.
+func (x *Index) SetSources(src []string) {
+	x.mu.Lock()
+	x.sources = append([]string(nil), src...)
+	x.mu.Unlock()
+}
+
+// Search returns the hits for query that peerID may see, best first.
+func (x *Index) Search(peerID, query string, limit int) []proto.SearchHit {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	x.mu.Lock()
+	sources := x.sources
+	x.mu.Unlock()
+
+	var hits []proto.SearchHit
+	for _, src := range sources {
+		switch src {
+		case SourceSite:
+			hits = append(hits, x.searchSite(terms)...)
+		case SourceDocs:
+			hits = append(hits, x.searchDocs(peerID, terms)...)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Terms splits a query into lower-case words.
+func Terms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (x *Index) searchSite(terms []string) []proto.SearchHit {
+	if x.SiteRoot == "" {
+		return nil
+	}
+	x.refresh()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var hits []proto.SearchHit
+	for rel, p := range x.pages {
+		title := " " + strings.Join(Terms(p.title), " ") + " "
+		score := 0.0
+		for _, t := range terms {
+			inTitle := strings.Count(title, " "+t+" ")
+			inText := strings.Count(p.text, " "+t+" ")
+			if inTitle+inText == 0 {
+				score = 0
+				break
+			}
+			score += 3*float64(inTitle) + math.Log1p(float64(inText))
+		}
+		if score == 0 {
+			continue
+		}
+		hits = append(hits, proto.SearchHit{
+			Kind:    "page",
+			Title:   p.title,
+			Path:    "/" + rel,
+			Snippet: snippet(p.raw, terms[0]),
+			Score:   math.Round(score*100) / 100,
+		})
+	}
+	return hits
+}
+
+func (x *Index) searchDocs(peerID string, terms []string) []proto.SearchHit {
+	if x.Docs == nil || x.DocGroups == nil {
+		return nil
+	}
+	var hits []proto.SearchHit
+	for _, gid := range x.DocGroups(peerID) {
+		list, err := x.Docs.ListMeta(gid)
+		if err != nil {
+			continue
+		}
+		for _, d := range list {
+			name := " " + strings.Join(Terms(d.Name), " ") + " "
+			score := 0.0
+			for _, t := range terms {
+				switch {
+				case strings.Contains(name, " "+t+" "):
+					score += 2
+				case strings.Contains(name, t):
+					score += 1
+				default:
+					score = 0
+				}
+				if score == 0 {
+					break
+				}
+			}
+			if score == 0 {
+				continue
+			}
+			hits = append(hits, proto.SearchHit{Kind: "doc", Title: d.Name, Path: d.Name, Group: gid, Size: d.Size, Score: score})
+		}
+	}
+	return hits
+}
+
+// refresh re-reads the pages that changed since the last query.
+func (x *Index) refresh() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	seen := make(map[string]*page, len(x.pages))
+	_ = filepath.WalkDir(x.SiteRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || len(seen) >= maxPages {
+			return nil
+		}
+		rel, rerr := filepath.Rel(x.SiteRoot, p)
+		if rerr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			// lua/ is never served, so it is never searched either.
+			if rel == "lua" || rel != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !searchable(d.Name()) {
+			return nil
+		}
+		info, ierr := d.Info()
+		if ierr != nil || info.Size() > maxPageBytes {
+			return nil
+		}
+		if old, ok := x.pages[rel]; ok && old.size == info.Size() && old.mod.Equal(info.ModTime()) {
+			seen[rel] = old
+			return nil
+		}
+		b, rerr := os.ReadFile(p)
+		if rerr != nil {
+			return nil
+		}
+		pg := parsePage(d.Name(), b)
+		pg.size, pg.mod = info.Size(), info.ModTime()
+		seen[rel] = pg
+		return nil
+	})
+	x.pages = seen
+}
+
+func searchable(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".md", ".txt":
+		return true
+	}
+	return false
+}
+
+var (
+	reTitle   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	reH1      = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	reNoText  = regexp.MustCompile(`(?is)<(script|style|template|head)[^>]*>.*?</(script|style|template|head)>`)
+	reTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+	reSpace   = regexp.MustCompile(`\s+`)
+	reMDTitle = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+)
+
+func parsePage(name string, b []byte) *page {
+	src := string(b)
+	var title, text string
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm":
+		if m := reTitle.FindStringSubmatch(src); m != nil {
+			title = m[1]
+		} else if m := reH1.FindStringSubmatch(src); m != nil {
+			title = m[1]
+		}
+		title = html.UnescapeString(reTag.ReplaceAllString(title, ""))
+		text = reNoText.ReplaceAllString(src, " ")
+		text = html.UnescapeString(reTag.ReplaceAllString(text, " "))
+	case ".md":
+		if m := reMDTitle.FindStringSubmatch(src); m != nil {
+			title = m[1]
+		}
+		text = src
+	default:
+		text = src
+	}
+	title = strings.TrimSpace(reSpace.ReplaceAllString(title, " "))
+	if title == "" {
+		title = strings.TrimSuffix(name, path.Ext(name))
+	}
+	raw := strings.TrimSpace(reSpace.ReplaceAllString(text, " "))
+	return &page{
+		title: title,
+		raw:   raw,
+		text:  " " + strings.Join(Terms(raw), " ") + " ",
+	}
+}
+
+// snippet returns the text around the first occurrence of term.
+func snippet(raw, term string) string {
+	lower := strings.ToLower(raw)
+	i := strings.Index(lower, term)
+	if i < 0 {
+		i = 0
+	}
+	start := max(0, i-snippetLen/3)
+	end := min(len(raw), start+snippetLen)
+	// Don't cut a UTF-8 sequence in half.
+	for start > 0 && !utf8Start(raw[start]) {
+		start--
+	}
+	for end < len(raw) && !utf8Start(raw[end]) {
+		end++
+	}
+	s := raw[start:end]
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(raw) {
+		s += "…"
+	}
+	return s
+}
+
+func utf8Start(b byte) bool { return b&0xC0 != 0x80 }
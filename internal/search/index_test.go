@@ -0,0 +1,88 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/group_types/files"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex_Site(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "index.html"), `<html><head><title>Garden notes</title><style>.tomato{}</style></head>
+<body><h1>Garden</h1><p>Planting tomato seeds in spring &amp; summer.</p></body></html>`)
+	writeFile(t, filepath.Join(root, "blog", "tomato.md"), "# Tomato varieties\n\nTomato, tomato, tomato.")
+	writeFile(t, filepath.Join(root, "lua", "secret.txt"), "tomato")
+	writeFile(t, filepath.Join(root, "style.css"), ".tomato{}")
+
+	x := &Index{SiteRoot: root}
+	if hits := x.Search("p", "tomato", 10); len(hits) != 0 {
+		t.Fatalf("searchable before SetSources: %+v", hits)
+	}
+	x.SetSources([]string{SourceSite})
+
+	hits := x.Search("p", "Tomato", 10)
+	if len(hits) != 2 {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if hits[0].Path != "/blog/tomato.md" || hits[0].Title != "Tomato varieties" {
+		t.Fatalf("best hit = %+v", hits[0])
+	}
+	if hits[1].Title != "Garden notes" || !strings.Contains(hits[1].Snippet, "tomato seeds in spring & summer") {
+		t.Fatalf("html hit = %+v", hits[1])
+	}
+
+	// All terms must match.
+	if hits := x.Search("p", "tomato spring", 10); len(hits) != 1 || hits[0].Path != "/index.html" {
+		t.Fatalf("and-query = %+v", hits)
+	}
+
+	// Edits are picked up.
+	later := time.Now().Add(time.Second)
+	writeFile(t, filepath.Join(root, "index.html"), "<title>Garden notes</title><p>cucumbers</p>")
+	os.Chtimes(filepath.Join(root, "index.html"), later, later)
+	if hits := x.Search("p", "cucumbers", 10); len(hits) != 1 {
+		t.Fatalf("after edit = %+v", hits)
+	}
+}
+
+func TestIndex_DocsOnlyForGroupPeers(t *testing.T) {
+	store, err := files.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Save("g1", "budget-2026.xlsx", []byte("x"))
+	store.Save("g2", "budget-old.pdf", []byte("x"))
+
+	x := &Index{
+		Docs: store,
+		DocGroups: func(peerID string) []string {
+			if peerID == "member" {
+				return []string{"g1"}
+			}
+			return nil
+		},
+	}
+	x.SetSources([]string{SourceDocs})
+
+	hits := x.Search("member", "budget", 10)
+	if len(hits) != 1 || hits[0].Kind != "doc" || hits[0].Group != "g1" || hits[0].Title != "budget-2026.xlsx" {
+		t.Fatalf("member hits = %+v", hits)
+	}
+	if hits := x.Search("stranger", "budget", 10); len(hits) != 0 {
+		t.Fatalf("stranger hits = %+v", hits)
+	}
+}
@@ -0,0 +1,338 @@
+// Package secrets keeps service tokens — the admin tokens of the rendezvous
+// service providers, webhook credentials — encrypted at rest, so goop.json
+// only names them.
+//
+// Values are sealed with AES-256-GCM under a random master key. The master
+// key is a key file handled by package keystore, so it is plain, passphrase
+// or keychain protected like the identity key, and "goop2 keys protect"
+// covers it too. A config value "secret:NAME" refers to a secret; Ref
+// resolves it on every use, so a rotation takes effect without a restart.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/keystore"
+)
+
+const (
+	// FileName holds the sealed secrets, KeyFileName the master key; both
+	// live in the peer's data directory.
+	FileName    = "secrets.json"
+	KeyFileName = "secrets.key"
+
+	// RefPrefix marks a config value that names a secret.
+	RefPrefix = "secret:"
+
+	// minRedactLen keeps very short values out of log redaction, where
+	// they would mangle unrelated words.
+	minRedactLen = 4
+)
+
+// ErrNotFound is returned for an unknown secret name.
+var ErrNotFound = errors.New("no such secret")
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Info describes a secret without its value.
+type Info struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"` // 1 when set, +1 per rotation
+	Updated int64  `json:"updated"` // unix millis
+}
+
+type entry struct {
+	Version int    `json:"version"`
+	Updated int64  `json:"updated"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+type file struct {
+	Secrets map[string]entry `json:"secrets"`
+}
+
+// Store is the secrets of one peer directory.
+type Store struct {
+	dir string
+
+	mu      sync.RWMutex
+	gcm     cipher.AEAD // nil until the master key is loaded or created
+	entries map[string]entry
+	values  map[string]string
+	modTime time.Time // of the secrets file when last read
+}
+
+// Open loads the secrets in dir. A directory without secrets yields an
+// empty store; the master key is created with the first secret.
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, entries: map[string]entry{}, values: map[string]string{}}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// KeyPath is the master key file of the secrets in dir.
+func KeyPath(dir string) string { return filepath.Join(dir, KeyFileName) }
+
+func (s *Store) path() string { return filepath.Join(s.dir, FileName) }
+
+// loadLocked reads the secrets file if it changed since the last read, so
+// values set by the CLI reach a running peer.
+func (s *Store) loadLocked() error {
+	st, err := os.Stat(s.path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if st.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(s.path())
+	if err != nil {
+		return err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%s: %w", FileName, err)
+	}
+	if err := s.keyLocked(false); err != nil {
+		return err
+	}
+	values := make(map[string]string, len(f.Secrets))
+	for name, e := range f.Secrets {
+		v, err := s.gcm.Open(nil, e.Nonce, e.Data, []byte(name))
+		if err != nil {
+			return fmt.Errorf("secret %s: cannot decrypt (wrong %s?)", name, KeyFileName)
+		}
+		values[name] = string(v)
+	}
+	if f.Secrets == nil {
+		f.Secrets = map[string]entry{}
+	}
+	s.entries, s.values, s.modTime = f.Secrets, values, st.ModTime()
+	return nil
+}
+
+// keyLocked loads the master key, creating it when create is set and
+// there is none yet.
+func (s *Store) keyLocked(create bool) error {
+	if s.gcm != nil {
+		return nil
+	}
+	key, err := keystore.Load(KeyPath(s.dir))
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		err = keystore.Save(KeyPath(s.dir), key)
+	}
+	if err != nil {
+		return fmt.Errorf("secrets key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("secrets key: %w", err)
+	}
+	s.gcm, err = cipher.NewGCM(block)
+	return err
+}
+
+// Get returns the value of a secret.
+func (s *Store) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return "", err
+	}
+	v, ok := s.values[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return v, nil
+}
+
+// List returns the secrets by name, without their values.
+func (s *Store) List() ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	list := make([]Info, 0, len(s.entries))
+	for name, e := range s.entries {
+		list = append(list, Info{Name: name, Version: e.Version, Updated: e.Updated})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Set stores value under name. Replacing an existing value counts as a
+// rotation and bumps its version.
+func (s *Store) Set(name, value string) (Info, error) {
+	if !nameRe.MatchString(name) {
+		return Info{}, fmt.Errorf("secret name must be 1-64 letters, digits, '_', '.' or '-'")
+	}
+	if value == "" {
+		return Info{}, fmt.Errorf("secret value is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return Info{}, err
+	}
+	if err := s.keyLocked(true); err != nil {
+		return Info{}, err
+	}
+	e := entry{Version: s.entries[name].Version + 1, Updated: time.Now().UnixMilli()}
+	e.Nonce = make([]byte, s.gcm.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return Info{}, err
+	}
+	e.Data = s.gcm.Seal(nil, e.Nonce, []byte(value), []byte(name))
+
+	entries := make(map[string]entry, len(s.entries)+1)
+	for k, v := range s.entries {
+		entries[k] = v
+	}
+	entries[name] = e
+	if err := s.saveLocked(entries); err != nil {
+		return Info{}, err
+	}
+	s.values[name] = value
+	return Info{Name: name, Version: e.Version, Updated: e.Updated}, nil
+}
+
+// Rotate replaces a secret with a new random token and returns it, for
+// tokens this peer issues itself. The new value is shown only here.
+func (s *Store) Rotate(name string) (string, Info, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", Info{}, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(b)
+	info, err := s.Set(name, value)
+	if err != nil {
+		return "", Info{}, err
+	}
+	return value, info, nil
+}
+
+// Delete removes a secret.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if _, ok := s.entries[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	entries := make(map[string]entry, len(s.entries))
+	for k, v := range s.entries {
+		if k != name {
+			entries[k] = v
+		}
+	}
+	if err := s.saveLocked(entries); err != nil {
+		return err
+	}
+	delete(s.values, name)
+	return nil
+}
+
+func (s *Store) saveLocked(entries map[string]entry) error {
+	data, err := json.MarshalIndent(file{Secrets: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp := s.path() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if st, err := os.Stat(s.path()); err == nil {
+		s.modTime = st.ModTime()
+	}
+	s.entries = entries
+	return nil
+}
+
+// IsRef reports whether a config value names a secret.
+func IsRef(value string) bool { return strings.HasPrefix(value, RefPrefix) }
+
+// Resolve returns a config value, looking it up when it names a secret.
+func (s *Store) Resolve(value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	return s.Get(strings.TrimPrefix(value, RefPrefix))
+}
+
+// Ref returns a function yielding the current value of a config value. A
+// secret that cannot be read yields "", so a request goes out without
+// credentials rather than with a stale or literal reference.
+func (s *Store) Ref(value string) func() string {
+	if !IsRef(value) {
+		return func() string { return value }
+	}
+	return func() string {
+		v, _ := s.Resolve(value)
+		return v
+	}
+}
+
+// Redact replaces every secret value in text with [secret:NAME].
+func (s *Store) Redact(text string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, v := range s.values {
+		if len(v) >= minRedactLen && strings.Contains(text, v) {
+			text = strings.ReplaceAll(text, v, "["+RefPrefix+name+"]")
+		}
+	}
+	return text
+}
+
+// Writer wraps w so that everything written through it is redacted. The
+// log package writes one entry per call, which is what it relies on.
+func (s *Store) Writer(w io.Writer) io.Writer { return redactWriter{s: s, w: w} }
+
+type redactWriter struct {
+	s *Store
+	w io.Writer
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, r.s.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
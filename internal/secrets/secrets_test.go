@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetRotateAndReopen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List(); len(list) != 0 {
+		t.Fatalf("new store lists %v", list)
+	}
+	if _, err := os.Stat(KeyPath(dir)); err == nil {
+		t.Fatal("key created before the first secret")
+	}
+
+	if _, err := s.Set("credits", "tok-one"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := s.Set("credits", "tok-two")
+	if err != nil || info.Version != 2 {
+		t.Fatalf("rotation = %+v, %v", info, err)
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, FileName))
+	if bytes.Contains(raw, []byte("tok-two")) {
+		t.Fatal("value stored in the clear")
+	}
+
+	again, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := again.Get("credits"); err != nil || v != "tok-two" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+
+	// A config reference follows a rotation made through another handle,
+	// the way the CLI rotates under a running peer.
+	ref := s.Ref("secret:credits")
+	time.Sleep(10 * time.Millisecond)
+	if _, err := again.Set("credits", "tok-three"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ref(); got != "tok-three" {
+		t.Fatalf("ref = %q after rotation", got)
+	}
+	if got := s.Ref("literal")(); got != "literal" {
+		t.Fatalf("literal ref = %q", got)
+	}
+
+	value, info, err := s.Rotate("hook")
+	if err != nil || len(value) < 40 || info.Version != 1 {
+		t.Fatalf("Rotate = %q, %+v, %v", value, info, err)
+	}
+	if err := s.Delete("hook"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("hook"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v", err)
+	}
+	if _, err := s.Set("bad name", "x"); err == nil {
+		t.Fatal("accepted a name with a space")
+	}
+}
+
+func TestRedactingWriter(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Set("bridge", "s3cr3t-token"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l := log.New(s.Writer(&buf), "", 0)
+	l.Printf("calling bridge with Bearer %s", "s3cr3t-token")
+	if got := buf.String(); strings.Contains(got, "s3cr3t") || !strings.Contains(got, "[secret:bridge]") {
+		t.Fatalf("log line = %q", got)
+	}
+}
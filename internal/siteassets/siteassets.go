@@ -0,0 +1,442 @@
+// Package siteassets is the optional asset pipeline for the served site. It
+// generates resized and re-encoded variants of site images into a cache
+// directory and rewrites HTML <img> tags to offer them through srcset, so
+// peers browsing the site over a slow relay path fetch fewer bytes.
+//
+// The site itself is never modified: variants live outside the site root
+// and HTML is rewritten as it is served.
+package siteassets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// Prefix is the site path under which variants are served.
+const Prefix = "_variants/"
+
+// keepRatio is the largest size, relative to the source, at which a
+// full-width re-encode is still worth serving instead of the original.
+const keepRatio = 0.9
+
+// Options configures the pipeline.
+type Options struct {
+	Enabled bool
+	Widths  []int // variant widths in pixels; wider than the source are skipped
+	Quality int   // JPEG quality, 1–100
+}
+
+// Stats describes the last build.
+type Stats struct {
+	Enabled    bool   `json:"enabled"`
+	LastRun    int64  `json:"last_run"`    // Unix ms, 0 = never
+	Images     int    `json:"images"`      // source images with at least one variant
+	Variants   int    `json:"variants"`    // variant files in the cache
+	Generated  int    `json:"generated"`   // variants (re)encoded by the last build
+	SavedBytes int64  `json:"saved_bytes"` // source bytes minus smallest variant, summed
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// Variant is one generated file.
+type Variant struct {
+	Width int
+	Rel   string // path under the cache dir, slash-separated
+	Size  int64
+}
+
+// entry is what the pipeline knows about one source image.
+type entry struct {
+	width    int
+	full     string    // full-width re-encode, "" when it saved too little
+	variants []Variant // narrower than the source, by width
+	cached   []string  // every file in the cache for it, used or not
+}
+
+// Pipeline builds and serves site image variants.
+type Pipeline struct {
+	root  string
+	cache string
+
+	buildMu sync.Mutex // one build at a time
+
+	mu    sync.RWMutex
+	index map[string]entry // source rel → variants
+	files map[string]bool  // variant rels, for Variant lookups
+	stats Stats
+}
+
+// New creates a pipeline for the site at root, writing variants to cacheDir.
+func New(root, cacheDir string) *Pipeline {
+	return &Pipeline{root: root, cache: cacheDir}
+}
+
+// Stats returns the statistics of the last build.
+func (p *Pipeline) Stats() Stats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.stats
+}
+
+// Run builds now and then every interval until ctx is done. opts is read
+// on every run so config changes apply without a restart.
+func (p *Pipeline) Run(ctx context.Context, interval time.Duration, opts func() Options) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := p.Build(opts()); err != nil {
+			log.Printf("siteassets: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Build brings the cache in line with the site: variants of new or changed
+// images are encoded, unchanged ones reused and orphans removed. With the
+// pipeline disabled the cache is dropped and nothing is rewritten.
+func (p *Pipeline) Build(opts Options) (Stats, error) {
+	p.buildMu.Lock()
+	defer p.buildMu.Unlock()
+
+	if !opts.Enabled {
+		p.mu.Lock()
+		p.index, p.files = nil, nil
+		p.stats = Stats{}
+		p.mu.Unlock()
+		return Stats{}, os.RemoveAll(p.cache)
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = jpeg.DefaultQuality
+	}
+
+	index := map[string]entry{}
+	files := map[string]bool{}
+	cached := map[string]bool{}
+	st := Stats{Enabled: true, LastRun: time.Now().UnixMilli()}
+
+	err := filepath.WalkDir(p.root, func(abs string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(p.root, abs)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == "lua" || (rel != "." && strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if imageFormat(rel) == "" {
+			return nil
+		}
+		e, generated, err := p.process(rel, opts)
+		if err != nil {
+			log.Printf("siteassets: %s: %v", rel, err)
+			return nil
+		}
+		st.Generated += generated
+		for _, c := range e.cached {
+			cached[c] = true
+		}
+		if e.full == "" && len(e.variants) == 0 {
+			return nil
+		}
+		index[rel] = e
+		st.Images++
+		if e.full != "" {
+			files[e.full] = true
+			st.Variants++
+		}
+		var srcSize int64
+		if info, err := d.Info(); err == nil {
+			srcSize = info.Size()
+		}
+		smallest := srcSize
+		for _, v := range e.variants {
+			files[v.Rel] = true
+			st.Variants++
+			smallest = min(smallest, v.Size)
+		}
+		st.SavedBytes += srcSize - smallest
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		st.LastError = err.Error()
+	} else {
+		err = nil
+		p.removeOrphans(cached)
+	}
+
+	p.mu.Lock()
+	p.index, p.files, p.stats = index, files, st
+	p.mu.Unlock()
+	if st.Generated > 0 {
+		log.Printf("siteassets: encoded %d variant(s) for %d image(s)", st.Generated, st.Images)
+	}
+	return st, err
+}
+
+// process makes sure the variants of one source image exist and are newer
+// than it, and returns them with the number of files it had to encode.
+func (p *Pipeline) process(rel string, opts Options) (entry, int, error) {
+	src := filepath.Join(p.root, filepath.FromSlash(rel))
+	info, err := os.Stat(src)
+	if err != nil {
+		return entry{}, 0, err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return entry{}, 0, err
+	}
+	cfg, _, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return entry{}, 0, err
+	}
+
+	e := entry{width: cfg.Width}
+	widths := []int{cfg.Width}
+	for _, w := range opts.Widths {
+		if w > 0 && w < cfg.Width {
+			widths = append(widths, w)
+		}
+	}
+	slices.Sort(widths[1:])
+	widths = slices.Compact(widths)
+
+	var img image.Image // decoded on first need
+	generated := 0
+	for _, w := range widths {
+		vrel := variantRel(rel, w)
+		dst := filepath.Join(p.cache, filepath.FromSlash(vrel))
+		size := int64(-1)
+		if vi, err := os.Stat(dst); err == nil && !vi.ModTime().Before(info.ModTime()) {
+			size = vi.Size()
+		} else {
+			if img == nil {
+				if img, err = decodeFile(src); err != nil {
+					return entry{}, generated, err
+				}
+			}
+			b, err := encode(resize(img, w), imageFormat(rel), opts.Quality)
+			if err != nil {
+				return entry{}, generated, err
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return entry{}, generated, err
+			}
+			if err := os.WriteFile(dst, b, 0o644); err != nil {
+				return entry{}, generated, err
+			}
+			size = int64(len(b))
+			generated++
+		}
+		e.cached = append(e.cached, vrel)
+
+		if w == cfg.Width {
+			if float64(size) < keepRatio*float64(info.Size()) {
+				e.full = vrel
+			}
+			continue
+		}
+		if size < info.Size() {
+			e.variants = append(e.variants, Variant{Width: w, Rel: vrel, Size: size})
+		}
+	}
+	return e, generated, nil
+}
+
+// removeOrphans deletes cached files that no longer belong to any image.
+func (p *Pipeline) removeOrphans(keep map[string]bool) {
+	filepath.WalkDir(p.cache, func(abs string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(p.cache, abs)
+		if !keep[filepath.ToSlash(rel)] {
+			os.Remove(abs)
+		}
+		return nil
+	})
+}
+
+// Variant returns a generated file by its site path (Prefix + cache rel).
+// Only files from the last build are served.
+func (p *Pipeline) Variant(rel string) ([]byte, bool) {
+	vrel, ok := strings.CutPrefix(rel, Prefix)
+	if !ok {
+		return nil, false
+	}
+	p.mu.RLock()
+	known := p.files[vrel]
+	p.mu.RUnlock()
+	if !known {
+		return nil, false
+	}
+	b, err := os.ReadFile(filepath.Join(p.cache, filepath.FromSlash(vrel)))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+var (
+	imgTagRe = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	srcRe    = regexp.MustCompile(`(?is)\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	srcsetRe = regexp.MustCompile(`(?i)\bsrcset\s*=`)
+	sizesRe  = regexp.MustCompile(`(?i)\bsizes\s*=`)
+)
+
+// RewriteHTML adds a srcset to every <img> in the page at rel whose source
+// has variants, and points src at the full-width re-encode when there is
+// one. Images with a srcset of their own, external images and sources with
+// a query are left alone.
+func (p *Pipeline) RewriteHTML(rel string, html []byte) []byte {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.index) == 0 {
+		return html
+	}
+	dir := path.Dir(rel)
+
+	return imgTagRe.ReplaceAllFunc(html, func(tag []byte) []byte {
+		if srcsetRe.Match(tag) {
+			return tag
+		}
+		m := srcRe.FindSubmatchIndex(tag)
+		if m == nil {
+			return tag
+		}
+		valStart, valEnd := m[2], m[3]
+		if valStart < 0 {
+			valStart, valEnd = m[4], m[5]
+		}
+		src := string(tag[valStart:valEnd])
+		if src == "" || strings.ContainsAny(src, ":?#, ") || strings.HasPrefix(src, "//") {
+			return tag
+		}
+
+		var source, base string
+		if after, ok := strings.CutPrefix(src, "/"); ok {
+			source, base = path.Clean(after), "/"
+		} else {
+			source, base = path.Clean(path.Join(dir, src)), relBase(dir)
+		}
+		e, ok := p.index[source]
+		if !ok {
+			return tag
+		}
+
+		newSrc := src
+		if e.full != "" {
+			newSrc = base + Prefix + e.full
+		}
+		var set []string
+		for _, v := range e.variants {
+			set = append(set, base+Prefix+v.Rel+" "+strconv.Itoa(v.Width)+"w")
+		}
+		set = append(set, newSrc+" "+strconv.Itoa(e.width)+"w")
+
+		attrs := ` srcset="` + strings.Join(set, ", ") + `"`
+		if !sizesRe.Match(tag) {
+			attrs += ` sizes="100vw"`
+		}
+
+		var out bytes.Buffer
+		out.Write(tag[:valStart])
+		out.WriteString(newSrc)
+		rest := tag[valEnd:]
+		end := len(rest) - 1 // the '>'
+		if end > 0 && rest[end-1] == '/' {
+			end--
+		}
+		out.Write(bytes.TrimRight(rest[:end], " \t\r\n"))
+		out.WriteString(attrs)
+		if rest[end] == '/' {
+			out.WriteString(" ")
+		}
+		out.Write(rest[end:])
+		return out.Bytes()
+	})
+}
+
+// relBase is the relative path from a page directory back to the site root.
+func relBase(dir string) string {
+	if dir == "." || dir == "" {
+		return ""
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1)
+}
+
+// variantRel names the variant of rel at width w: img/a.jpg → img/a@480w.jpg.
+func variantRel(rel string, w int) string {
+	ext := path.Ext(rel)
+	return strings.TrimSuffix(rel, ext) + "@" + strconv.Itoa(w) + "w" + ext
+}
+
+func imageFormat(rel string) string {
+	switch strings.ToLower(path.Ext(rel)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".png":
+		return "png"
+	}
+	return ""
+}
+
+func decodeFile(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// resize scales img to width w, keeping the aspect ratio.
+func resize(img image.Image, w int) image.Image {
+	b := img.Bounds()
+	if w >= b.Dx() {
+		return img
+	}
+	h := max(1, b.Dy()*w/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func encode(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "png":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&buf, img)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	return buf.Bytes(), err
+}
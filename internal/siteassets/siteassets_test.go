@@ -0,0 +1,125 @@
+package siteassets
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeNoisyJPEG writes a w×h JPEG at full quality, so re-encoding it at
+// the default quality always saves bytes.
+func writeNoisyJPEG(t *testing.T, name string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1664525 + 1013904223
+			img.Set(x, y, color.RGBA{uint8(seed >> 24), uint8(seed >> 16), uint8(x), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(name), 0o755)
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildAndRewrite(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "site")
+	cache := filepath.Join(dir, "cache")
+	writeNoisyJPEG(t, filepath.Join(root, "img", "photo.jpg"), 400, 200)
+	writeNoisyJPEG(t, filepath.Join(root, "lua", "skip.jpg"), 400, 200)
+
+	p := New(root, cache)
+	opts := Options{Enabled: true, Widths: []int{100, 200, 800}, Quality: 60}
+	st, err := p.Build(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Images != 1 || st.Variants != 3 || st.Generated != 3 {
+		t.Fatalf("stats = %+v, want 1 image with 3 variants", st)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "img", "photo@100w.jpg")); err != nil {
+		t.Fatal("100w variant missing")
+	}
+	if _, err := os.Stat(filepath.Join(cache, "img", "photo@800w.jpg")); err == nil {
+		t.Fatal("variants wider than the source must not be made")
+	}
+
+	b, ok := p.Variant("_variants/img/photo@200w.jpg")
+	if !ok {
+		t.Fatal("variant not served")
+	}
+	if cfg, err := jpeg.DecodeConfig(bytes.NewReader(b)); err != nil || cfg.Width != 200 || cfg.Height != 100 {
+		t.Fatalf("variant %dx%d (%v), want 200x100", cfg.Width, cfg.Height, err)
+	}
+	if _, ok := p.Variant("_variants/../site/img/photo.jpg"); ok {
+		t.Fatal("only built variants may be served")
+	}
+
+	html := `<p><img src="../img/photo.jpg" alt="x"><img src='/img/photo.jpg'/><img src="x.png"><img src="../img/photo.jpg" srcset="a 1x"></p>`
+	got := string(p.RewriteHTML("pages/about.html", []byte(html)))
+	want := `<p><img src="../_variants/img/photo@400w.jpg" alt="x" srcset="../_variants/img/photo@100w.jpg 100w, ../_variants/img/photo@200w.jpg 200w, ../_variants/img/photo@400w.jpg 400w" sizes="100vw">` +
+		`<img src='/_variants/img/photo@400w.jpg' srcset="/_variants/img/photo@100w.jpg 100w, /_variants/img/photo@200w.jpg 200w, /_variants/img/photo@400w.jpg 400w" sizes="100vw" />` +
+		`<img src="x.png"><img src="../img/photo.jpg" srcset="a 1x"></p>`
+	if got != want {
+		t.Fatalf("rewrite:\n got %s\nwant %s", got, want)
+	}
+
+	// Unchanged sources are not encoded again.
+	if st, _ := p.Build(opts); st.Generated != 0 || st.Variants != 3 {
+		t.Fatalf("second build = %+v, want nothing generated", st)
+	}
+
+	// A changed source is re-encoded.
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(root, "img", "photo.jpg"), later, later)
+	if st, _ := p.Build(opts); st.Generated != 3 {
+		t.Fatalf("after touch: generated %d, want 3", st.Generated)
+	}
+
+	// Removed sources lose their variants.
+	os.Remove(filepath.Join(root, "img", "photo.jpg"))
+	if st, _ := p.Build(opts); st.Images != 0 {
+		t.Fatalf("after delete = %+v", st)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "img", "photo@100w.jpg")); err == nil {
+		t.Fatal("orphaned variant should be removed")
+	}
+	if got := p.RewriteHTML("index.html", []byte(html)); !bytes.Equal(got, []byte(html)) {
+		t.Fatal("nothing to rewrite once the image is gone")
+	}
+}
+
+func TestBuildDisabledDropsCache(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "site")
+	cache := filepath.Join(dir, "cache")
+	writeNoisyJPEG(t, filepath.Join(root, "a.jpg"), 300, 300)
+
+	p := New(root, cache)
+	if _, err := p.Build(Options{Enabled: true, Widths: []int{100}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Build(Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Fatal("disabling should remove the cache")
+	}
+	html := `<img src="a.jpg">`
+	if got := string(p.RewriteHTML("index.html", []byte(html))); got != html || strings.Contains(got, "srcset") {
+		t.Fatalf("disabled pipeline rewrote %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package sitepublish
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Target is a bucket on S3 or an S3-compatible store. Objects are
+// written with path-style requests signed with AWS Signature V4.
+type S3Target struct {
+	Endpoint  string // empty = https://s3.<region>.amazonaws.com
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+}
+
+// PushS3 uploads the bundle under t.Prefix, overwriting objects of the
+// same key. Objects that are no longer in the bundle are left in place.
+func PushS3(ctx context.Context, t S3Target, files []File) error {
+	return pushS3(ctx, http.DefaultClient, t, files, time.Now)
+}
+
+func pushS3(ctx context.Context, hc *http.Client, t S3Target, files []File, now func() time.Time) error {
+	endpoint := strings.TrimRight(t.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + t.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	prefix := strings.Trim(t.Prefix, "/")
+
+	for _, f := range files {
+		key := path.Join(prefix, f.Path)
+		u := *base
+		u.Path = strings.TrimRight(base.Path, "/") + "/" + t.Bucket + "/" + key
+		u.RawPath = strings.TrimRight(base.EscapedPath(), "/") + "/" + s3Escape(t.Bucket) + "/" + s3Escape(key)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(f.Data))
+		if err != nil {
+			return err
+		}
+		ct := mime.TypeByExtension(path.Ext(f.Path))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		req.Header.Set("Content-Type", ct)
+		signS3(req, f.Data, t, now().UTC())
+
+		resp, err := hc.Do(req)
+		if err != nil {
+			return fmt.Errorf("put %s: %w", key, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("put %s: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+		}
+	}
+	return nil
+}
+
+// signS3 adds the Signature V4 headers for a request without a query.
+func signS3(req *http.Request, body []byte, t S3Target, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	sum := sha256.Sum256(body)
+	payload := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	const signed = "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payload,
+		"x-amz-date:" + amzDate,
+		"",
+		signed,
+		payload,
+	}, "\n")
+
+	scope := day + "/" + t.Region + "/s3/aws4_request"
+	crSum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+t.SecretKey), day)
+	key = hmacSHA256(key, t.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.AccessKey, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// s3Escape percent-encodes a key the way Signature V4 expects: everything
+// but unreserved characters and the slashes between segments.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package sitepublish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/petervdpas/goop2/internal/util"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPTarget is an SSH server the bundle is copied to.
+type SFTPTarget struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	KeyFile  string // private key file, used when set
+	HostKey  string // expected SHA256 fingerprint; empty = ~/.ssh/known_hosts
+	Dir      string
+}
+
+// PushSFTP uploads the bundle into t.Dir, overwriting files of the same
+// name. Files that are no longer in the bundle are left on the server.
+func PushSFTP(ctx context.Context, t SFTPTarget, files []File) error {
+	cfg := &ssh.ClientConfig{User: t.User}
+
+	if t.KeyFile != "" {
+		pem, err := os.ReadFile(t.KeyFile)
+		if err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return fmt.Errorf("parse key: %w", err)
+		}
+		cfg.Auth = append(cfg.Auth, ssh.PublicKeys(signer))
+	}
+	if t.Password != "" {
+		cfg.Auth = append(cfg.Auth, ssh.Password(t.Password))
+	}
+	if len(cfg.Auth) == 0 {
+		return errors.New("sftp: no password or key file configured")
+	}
+
+	if t.HostKey != "" {
+		cfg.HostKeyCallback = func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != t.HostKey {
+				return fmt.Errorf("sftp: host key %s does not match the configured %s", got, t.HostKey)
+			}
+			return nil
+		}
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("sftp: no host_key set and no home dir: %w", err)
+		}
+		cb, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+		if err != nil {
+			return fmt.Errorf("sftp: no host_key set and known_hosts unusable: %w", err)
+		}
+		cfg.HostKeyCallback = cb
+	}
+
+	addr := net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+	conn, err := util.Dial(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client := ssh.NewClient(sc, chans, reqs)
+	defer client.Close()
+
+	fc, err := sftp.NewClient(client)
+	if err != nil {
+		return err
+	}
+	defer fc.Close()
+	return uploadSFTP(ctx, fc, t.Dir, files)
+}
+
+func uploadSFTP(ctx context.Context, fc *sftp.Client, dir string, files []File) error {
+	if dir == "" {
+		dir = "."
+	}
+	made := map[string]bool{}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		remote := path.Join(dir, f.Path)
+		if parent := path.Dir(remote); !made[parent] {
+			if err := fc.MkdirAll(parent); err != nil {
+				return fmt.Errorf("mkdir %s: %w", parent, err)
+			}
+			made[parent] = true
+		}
+		w, err := fc.OpenFile(remote, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", remote, err)
+		}
+		_, err = w.Write(f.Data)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("write %s: %w", remote, err)
+		}
+	}
+	return nil
+}
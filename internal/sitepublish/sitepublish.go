@@ -0,0 +1,119 @@
+// Package sitepublish turns the site into a static bundle that ordinary
+// web hosting can serve while the peer is offline, and uploads it over
+// SFTP or to an S3-compatible bucket.
+//
+// The bundle holds the site as visitors get it: HTML goes through the
+// image pipeline, the variants it references are included, and SDK
+// scripts and styles are copied in next to the pages that load them.
+// Peer-only features (data, chat, groups) need the peer and stay dark in
+// the mirror.
+package sitepublish
+
+import (
+	"archive/zip"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File is one file of the bundle, by slash-separated path.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// Assets is the part of the image pipeline the bundle goes through.
+type Assets interface {
+	Variant(rel string) ([]byte, bool)
+	RewriteHTML(rel string, html []byte) []byte
+}
+
+// SDKFunc returns an SDK file by name ("goop-data.js").
+type SDKFunc func(name string) ([]byte, bool)
+
+var (
+	sdkRefRE     = regexp.MustCompile(`((?:src|href)\s*=\s*["'])/sdk/([A-Za-z0-9._-]+)`)
+	variantRefRE = regexp.MustCompile(`_variants/[^"'\s,<>)]+`)
+)
+
+// Build returns the static bundle for the given site files. Lua scripts
+// are left out; assets and sdk may be nil.
+func Build(site []File, assets Assets, sdk SDKFunc) []File {
+	out := make([]File, 0, len(site))
+	seen := map[string]bool{}
+	sdkFiles := map[string]bool{}
+	variants := map[string]bool{}
+
+	for _, f := range site {
+		rel := strings.TrimPrefix(f.Path, "/")
+		if rel == "lua" || strings.HasPrefix(rel, "lua/") || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		data := f.Data
+		if strings.HasSuffix(strings.ToLower(rel), ".html") {
+			if assets != nil {
+				data = assets.RewriteHTML(rel, data)
+				for _, v := range variantRefRE.FindAll(data, -1) {
+					variants[string(v)] = true
+				}
+			}
+			if sdk != nil {
+				data = relinkSDK(rel, data, sdkFiles)
+			}
+		}
+		out = append(out, File{Path: rel, Data: data})
+	}
+
+	for name := range sdkFiles {
+		if b, ok := sdk(name); ok && !seen["sdk/"+name] {
+			out = append(out, File{Path: "sdk/" + name, Data: b})
+		}
+	}
+	for rel := range variants {
+		if b, ok := assets.Variant(rel); ok {
+			out = append(out, File{Path: rel, Data: b})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// relinkSDK points absolute /sdk/ references at the bundled copies,
+// relative to the page, and records which files are used.
+func relinkSDK(rel string, html []byte, used map[string]bool) []byte {
+	up := strings.Repeat("../", strings.Count(rel, "/"))
+	return sdkRefRE.ReplaceAllFunc(html, func(m []byte) []byte {
+		sub := sdkRefRE.FindSubmatch(m)
+		used[string(sub[2])] = true
+		return []byte(string(sub[1]) + up + "sdk/" + string(sub[2]))
+	})
+}
+
+// Size returns the total size of the bundle in bytes.
+func Size(files []File) int64 {
+	var n int64
+	for _, f := range files {
+		n += int64(len(f.Data))
+	}
+	return n
+}
+
+// WriteZip writes the bundle as a zip archive with the site at its root.
+func WriteZip(w io.Writer, files []File) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,156 @@
+package sitepublish
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+type fakeAssets struct{}
+
+func (fakeAssets) Variant(rel string) ([]byte, bool) {
+	if rel == "_variants/img/a@100w.jpg" {
+		return []byte("small"), true
+	}
+	return nil, false
+}
+
+func (fakeAssets) RewriteHTML(rel string, html []byte) []byte {
+	return bytes.ReplaceAll(html, []byte(`src="../img/a.jpg"`), []byte(`src="../img/a.jpg" srcset="../_variants/img/a@100w.jpg 100w"`))
+}
+
+func fakeSDK(name string) ([]byte, bool) {
+	if name == "goop-data.js" {
+		return []byte("/*sdk*/"), true
+	}
+	return nil, false
+}
+
+func TestBuild(t *testing.T) {
+	site := []File{
+		{Path: "index.html", Data: []byte(`<script src="/sdk/goop-data.js"></script>`)},
+		{Path: "pages/about.html", Data: []byte(`<img src="../img/a.jpg"><script src='/sdk/goop-data.js'></script><script src="/sdk/missing.js"></script>`)},
+		{Path: "img/a.jpg", Data: []byte("full")},
+		{Path: "lua/functions/x.lua", Data: []byte("return 1")},
+	}
+	got := Build(site, fakeAssets{}, fakeSDK)
+
+	byPath := map[string]string{}
+	var paths []string
+	for _, f := range got {
+		byPath[f.Path] = string(f.Data)
+		paths = append(paths, f.Path)
+	}
+	want := "_variants/img/a@100w.jpg img/a.jpg index.html pages/about.html sdk/goop-data.js"
+	if strings.Join(paths, " ") != want {
+		t.Fatalf("paths = %v, want %s", paths, want)
+	}
+	if byPath["index.html"] != `<script src="sdk/goop-data.js"></script>` {
+		t.Fatalf("index.html = %s", byPath["index.html"])
+	}
+	if !strings.Contains(byPath["pages/about.html"], `srcset="../_variants/img/a@100w.jpg 100w"`) ||
+		!strings.Contains(byPath["pages/about.html"], `src='../sdk/goop-data.js'`) {
+		t.Fatalf("about.html = %s", byPath["pages/about.html"])
+	}
+	if byPath["sdk/goop-data.js"] != "/*sdk*/" {
+		t.Fatal("sdk file not bundled")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(zr.File) != len(got) || zr.File[2].Name != "index.html" {
+		t.Fatalf("zip: %v", err)
+	}
+}
+
+func TestUploadSFTP(t *testing.T) {
+	c1, c2 := net.Pipe()
+	srv := sftp.NewRequestServer(c2, sftp.InMemHandler())
+	go srv.Serve()
+	defer srv.Close()
+
+	fc, err := sftp.NewClientPipe(c1, c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fc.Close()
+
+	files := []File{{Path: "index.html", Data: []byte("hi")}, {Path: "a/b/c.css", Data: []byte("x{}")}}
+	if err := uploadSFTP(context.Background(), fc, "/www", files); err != nil {
+		t.Fatal(err)
+	}
+	// A second push overwrites.
+	files[0].Data = []byte("hello")
+	if err := uploadSFTP(context.Background(), fc, "/www", files); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		r, err := fc.Open("/www/" + f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		if string(b) != string(f.Data) {
+			t.Fatalf("%s = %q, want %q", f.Path, b, f.Data)
+		}
+	}
+}
+
+func TestPushS3(t *testing.T) {
+	type put struct{ path, ct, body, auth, sha string }
+	var puts []put
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		puts = append(puts, put{r.URL.EscapedPath(), r.Header.Get("Content-Type"), string(b),
+			r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256")})
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	target := S3Target{Endpoint: srv.URL, Region: "eu-west-1", Bucket: "site", Prefix: "/mirror/", AccessKey: "AK", SecretKey: "SK"}
+	files := []File{{Path: "index.html", Data: []byte("<p>")}, {Path: "img/my photo.jpg", Data: []byte("jpg")}}
+	at := func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	if err := pushS3(context.Background(), srv.Client(), target, files, at); err != nil {
+		t.Fatal(err)
+	}
+	if len(puts) != 2 {
+		t.Fatalf("%d puts", len(puts))
+	}
+	if puts[0].path != "/site/mirror/index.html" || puts[0].ct != "text/html; charset=utf-8" || puts[0].body != "<p>" {
+		t.Fatalf("put 0 = %+v", puts[0])
+	}
+	if puts[1].path != "/site/mirror/img/my%20photo.jpg" {
+		t.Fatalf("key not escaped: %s", puts[1].path)
+	}
+	if !strings.HasPrefix(puts[0].auth, "AWS4-HMAC-SHA256 Credential=AK/20260301/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Fatalf("auth = %s", puts[0].auth)
+	}
+	if puts[0].sha == "" || puts[0].auth == puts[1].auth {
+		t.Fatal("each object must be signed for its own payload")
+	}
+
+	// Errors from the store are surfaced.
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Code>AccessDenied</Code>", http.StatusForbidden)
+	}))
+	defer bad.Close()
+	target.Endpoint = bad.URL
+	if err := pushS3(context.Background(), bad.Client(), target, files, at); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("err = %v", err)
+	}
+}
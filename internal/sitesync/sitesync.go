@@ -0,0 +1,350 @@
+// Package sitesync keeps local copies of followed peers' sites, so the
+// viewer can still show a site while its peer is offline. A copy is
+// refreshed over /goop/site-sync: the peer's manifest lists every file with
+// its SHA-256, and only files whose hash changed are fetched. A new version
+// is staged and checked completely before it replaces the old copy, so a
+// sync that fails halfway leaves the previous copy intact.
+package sitesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Limits on one followed site.
+const (
+	MaxFiles = 5000
+	MaxBytes = 200 * 1024 * 1024
+)
+
+const (
+	SyncTimeout  = 5 * time.Minute  // one whole sync
+	StaleAfter   = 6 * time.Hour    // resync peers that announce no site hash this often
+	manifestName = ".manifest.json" // the synced manifest, inside the copy
+	stagingName  = ".incoming"      // files of a sync in progress, inside the copy
+)
+
+// Fetcher talks /goop/site-sync to other peers; *p2p.Node implements it.
+type Fetcher interface {
+	SiteManifest(ctx context.Context, peerID string) (proto.SiteSyncResponse, error)
+	FetchSiteSyncFile(ctx context.Context, peerID, path string) ([]byte, error)
+}
+
+// Syncer follows peers' sites and keeps their copies under dir, one
+// directory per peer.
+type Syncer struct {
+	db    *storage.DB
+	peers *state.PeerTable
+	fetch Fetcher
+	dir   string
+
+	mu      sync.Mutex
+	syncing map[string]bool
+}
+
+// New creates a syncer keeping copies under dir (cache/sites in the peer
+// directory).
+func New(db *storage.DB, peers *state.PeerTable, fetch Fetcher, dir string) *Syncer {
+	return &Syncer{db: db, peers: peers, fetch: fetch, dir: dir, syncing: map[string]bool{}}
+}
+
+// Follow starts following peerID's site. The first copy is made by the
+// next sync.
+func (s *Syncer) Follow(peerID string) (storage.SiteFollow, error) {
+	if _, err := peer.Decode(peerID); err != nil {
+		return storage.SiteFollow{}, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	return s.db.FollowSite(peerID)
+}
+
+// Unfollow stops following peerID's site and deletes its copy.
+func (s *Syncer) Unfollow(peerID string) (bool, error) {
+	ok, err := s.db.UnfollowSite(peerID)
+	if err != nil {
+		return false, err
+	}
+	if _, derr := peer.Decode(peerID); derr == nil {
+		_ = os.RemoveAll(filepath.Join(s.dir, peerID))
+	}
+	return ok, nil
+}
+
+// List returns every followed peer with the state of its copy.
+func (s *Syncer) List() ([]storage.SiteFollow, error) {
+	return s.db.ListSiteFollows()
+}
+
+// Sync brings the copy of peerID's site up to date. Only one sync per
+// peer runs at a time; a second call while one runs fails.
+func (s *Syncer) Sync(ctx context.Context, peerID string) (storage.SiteFollow, error) {
+	if _, ok := s.db.GetSiteFollow(peerID); !ok {
+		return storage.SiteFollow{}, errors.New("peer not followed")
+	}
+	s.mu.Lock()
+	if s.syncing[peerID] {
+		s.mu.Unlock()
+		return storage.SiteFollow{}, errors.New("sync already running")
+	}
+	s.syncing[peerID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncing, peerID)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, SyncTimeout)
+	defer cancel()
+	if err := s.sync(ctx, peerID); err != nil {
+		_ = s.db.SetSiteSyncError(peerID, err.Error())
+		f, _ := s.db.GetSiteFollow(peerID)
+		return f, err
+	}
+	f, _ := s.db.GetSiteFollow(peerID)
+	return f, nil
+}
+
+func (s *Syncer) sync(ctx context.Context, peerID string) error {
+	m, err := s.fetch.SiteManifest(ctx, peerID)
+	if err != nil {
+		return err
+	}
+	if len(m.Files) > MaxFiles {
+		return fmt.Errorf("site has %d files, more than %d", len(m.Files), MaxFiles)
+	}
+	var total int64
+	for _, e := range m.Files {
+		if !validPath(e.Path) {
+			return fmt.Errorf("bad path in manifest: %q", e.Path)
+		}
+		total += e.Size
+	}
+	if total > MaxBytes {
+		return fmt.Errorf("site is %d bytes, more than %d", total, MaxBytes)
+	}
+
+	root := filepath.Join(s.dir, peerID)
+	staging := filepath.Join(root, stagingName)
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	// Fetch what changed since the last copy into the staging directory.
+	have := readManifest(root)
+	var changed []string
+	for _, e := range m.Files {
+		if have[e.Path] == e.SHA256 {
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(e.Path))); err == nil {
+				continue
+			}
+		}
+		data, err := s.fetch.FetchSiteSyncFile(ctx, peerID, e.Path)
+		if err != nil {
+			return fmt.Errorf("fetch %s: %w", e.Path, err)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != e.Size || hex.EncodeToString(sum[:]) != e.SHA256 {
+			return fmt.Errorf("%s does not match the manifest", e.Path)
+		}
+		if err := writeFile(filepath.Join(staging, filepath.FromSlash(e.Path)), data); err != nil {
+			return err
+		}
+		changed = append(changed, e.Path)
+	}
+
+	// Everything checked out: move the new files in and drop removed ones.
+	for _, rel := range changed {
+		dst := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(rel)), dst); err != nil {
+			return err
+		}
+	}
+	keep := make(map[string]string, len(m.Files))
+	for _, e := range m.Files {
+		keep[e.Path] = e.SHA256
+	}
+	removeOthers(root, keep)
+	b, _ := json.Marshal(keep)
+	if err := writeFile(filepath.Join(root, manifestName), b); err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		log.Printf("sitesync: %s: %d of %d files updated", shortID(peerID), len(changed), len(m.Files))
+	}
+	return s.db.SetSiteSynced(peerID, m.Hash, len(m.Files), total, time.Now())
+}
+
+// ReadFile returns a file of the copy of peerID's site and the follow it
+// belongs to. "" and "/" mean index.html. It fails when the peer is not
+// followed, has never been synced or the file is not part of the copy.
+func (s *Syncer) ReadFile(peerID, path string) ([]byte, storage.SiteFollow, error) {
+	f, ok := s.db.GetSiteFollow(peerID)
+	if !ok || f.SyncedAt == 0 {
+		return nil, f, errors.New("no copy")
+	}
+	rel := strings.TrimPrefix(path, "/")
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		rel += "index.html"
+	}
+	if !validPath(rel) {
+		return nil, f, errors.New("not found")
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, peerID, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, f, errors.New("not found")
+	}
+	return data, f, nil
+}
+
+// due reports whether a copy needs a sync: the peer is online and
+// announces a site hash other than the copy's, or announces none and the
+// copy is older than StaleAfter.
+func (s *Syncer) due(f storage.SiteFollow, now time.Time) bool {
+	sp, ok := s.peers.Get(f.PeerID)
+	if !ok || !sp.OfflineSince.IsZero() {
+		return false
+	}
+	if sp.SiteHash != "" {
+		return sp.SiteHash != f.SiteHash || f.SyncedAt == 0
+	}
+	return now.Sub(time.UnixMilli(f.SyncedAt)) >= StaleAfter
+}
+
+// SyncDue syncs every followed peer that is due, one after another.
+func (s *Syncer) SyncDue(ctx context.Context) {
+	follows, err := s.db.ListSiteFollows()
+	if err != nil {
+		log.Printf("sitesync: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, f := range follows {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.due(f, now) {
+			continue
+		}
+		if _, err := s.Sync(ctx, f.PeerID); err != nil {
+			log.Printf("sitesync: %s: %v", shortID(f.PeerID), err)
+		}
+	}
+}
+
+// Run removes copies of peers no longer followed, then syncs due peers
+// every interval until ctx is done.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	s.removeUnfollowed()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.SyncDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// removeUnfollowed deletes copies left behind by peers that were
+// unfollowed or forgotten.
+func (s *Syncer) removeUnfollowed() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if _, ok := s.db.GetSiteFollow(e.Name()); !ok {
+			_ = os.RemoveAll(filepath.Join(s.dir, e.Name()))
+		}
+	}
+}
+
+// validPath accepts slash-separated paths inside the copy that don't
+// touch its bookkeeping or anything hidden.
+func validPath(rel string) bool {
+	if rel == "" || !filepath.IsLocal(filepath.FromSlash(rel)) || strings.Contains(rel, `\`) {
+		return false
+	}
+	for part := range strings.SplitSeq(rel, "/") {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// readManifest returns the path → SHA-256 map of the last complete sync.
+func readManifest(root string) map[string]string {
+	m := map[string]string{}
+	if b, err := os.ReadFile(filepath.Join(root, manifestName)); err == nil {
+		_ = json.Unmarshal(b, &m)
+	}
+	return m
+}
+
+func removeOthers(root string, keep map[string]string) {
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, rerr := filepath.Rel(root, p)
+		if rerr != nil {
+			return nil
+		}
+		if _, ok := keep[filepath.ToSlash(rel)]; !ok {
+			_ = os.Remove(p)
+		}
+		return nil
+	})
+}
+
+func writeFile(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,169 @@
+package sitesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// fakeSite answers site sync requests from a map and counts file fetches.
+type fakeSite struct {
+	hash    string
+	files   map[string]string
+	fetched []string
+	corrupt string // path served with the wrong content
+}
+
+func (f *fakeSite) SiteManifest(ctx context.Context, peerID string) (proto.SiteSyncResponse, error) {
+	resp := proto.SiteSyncResponse{Hash: f.hash}
+	for p, data := range f.files {
+		sum := sha256.Sum256([]byte(data))
+		resp.Files = append(resp.Files, proto.SiteSyncEntry{Path: p, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(resp.Files, func(i, j int) bool { return resp.Files[i].Path < resp.Files[j].Path })
+	return resp, nil
+}
+
+func (f *fakeSite) FetchSiteSyncFile(ctx context.Context, peerID, path string) ([]byte, error) {
+	f.fetched = append(f.fetched, path)
+	if path == f.corrupt {
+		return []byte("tampered"), nil
+	}
+	data, ok := f.files[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(data), nil
+}
+
+func newSyncer(t *testing.T, site *fakeSite) (*Syncer, *state.PeerTable, string) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	_, pub, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := peer.IDFromPublicKey(pub)
+	peers := state.NewPeerTable()
+	return New(db, peers, site, filepath.Join(dir, "cache", "sites")), peers, pid.String()
+}
+
+func TestSync(t *testing.T) {
+	site := &fakeSite{hash: "h1", files: map[string]string{"index.html": "<p>v1</p>", "css/site.css": "p{}"}}
+	s, _, id := newSyncer(t, site)
+
+	if _, err := s.Sync(context.Background(), id); err == nil {
+		t.Fatal("synced a peer that is not followed")
+	}
+	if _, err := s.Follow("not-a-peer"); err == nil {
+		t.Fatal("followed an invalid peer ID")
+	}
+	if _, err := s.Follow(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.ReadFile(id, "/"); err == nil {
+		t.Fatal("read a copy before the first sync")
+	}
+
+	f, err := s.Sync(context.Background(), id)
+	if err != nil || f.SiteHash != "h1" || f.Files != 2 || f.SyncedAt == 0 {
+		t.Fatalf("first sync = %+v, %v", f, err)
+	}
+	data, got, err := s.ReadFile(id, "/")
+	if err != nil || string(data) != "<p>v1</p>" || got.SyncedAt != f.SyncedAt {
+		t.Fatalf("ReadFile = %q, %+v, %v", data, got, err)
+	}
+
+	// Only the changed file is fetched; removed files go.
+	site.fetched = nil
+	site.hash = "h2"
+	site.files = map[string]string{"index.html": "<p>v2</p>"}
+	if _, err := s.Sync(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if len(site.fetched) != 1 || site.fetched[0] != "index.html" {
+		t.Fatalf("fetched = %v", site.fetched)
+	}
+	if _, _, err := s.ReadFile(id, "css/site.css"); err == nil {
+		t.Fatal("removed file still served")
+	}
+
+	// A file that doesn't match the manifest fails the sync and keeps
+	// the previous copy.
+	site.hash = "h3"
+	site.files = map[string]string{"index.html": "<p>v3</p>"}
+	site.corrupt = "index.html"
+	f, err = s.Sync(context.Background(), id)
+	if err == nil || f.SiteHash != "h2" || f.LastError == "" {
+		t.Fatalf("corrupt sync = %+v, %v", f, err)
+	}
+	if data, _, _ := s.ReadFile(id, "index.html"); string(data) != "<p>v2</p>" {
+		t.Fatalf("copy after failed sync = %q", data)
+	}
+
+	for _, p := range []string{"../x", ".manifest.json", "a/.incoming/b"} {
+		if _, _, err := s.ReadFile(id, p); err == nil {
+			t.Errorf("ReadFile(%q) succeeded", p)
+		}
+	}
+
+	if ok, err := s.Unfollow(id); !ok || err != nil {
+		t.Fatalf("Unfollow = %v, %v", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, id)); !os.IsNotExist(err) {
+		t.Fatal("copy not deleted on unfollow")
+	}
+}
+
+func TestSync_BadManifest(t *testing.T) {
+	site := &fakeSite{hash: "h1", files: map[string]string{"../escape.html": "x"}}
+	s, _, id := newSyncer(t, site)
+	s.Follow(id)
+	if _, err := s.Sync(context.Background(), id); err == nil {
+		t.Fatal("accepted a path outside the copy")
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, "escape.html")); !os.IsNotExist(err) {
+		t.Fatal("wrote outside the copy")
+	}
+}
+
+func TestSyncDue(t *testing.T) {
+	site := &fakeSite{hash: "h1", files: map[string]string{"index.html": "hi"}}
+	s, peers, id := newSyncer(t, site)
+	s.Follow(id)
+
+	// Offline peers are not synced.
+	s.SyncDue(context.Background())
+	if len(site.fetched) != 0 {
+		t.Fatalf("synced an unknown peer: %v", site.fetched)
+	}
+
+	peers.Upsert(id, "Alice", "", "", false, "", "", false, false, "", false)
+	peers.SetSiteHash(id, "h1")
+	s.SyncDue(context.Background())
+	if len(site.fetched) != 1 {
+		t.Fatalf("fetched = %v", site.fetched)
+	}
+	// Same announced hash: nothing to do.
+	s.SyncDue(context.Background())
+	if len(site.fetched) != 1 {
+		t.Fatalf("resynced an unchanged site: %v", site.fetched)
+	}
+}
@@ -17,6 +17,7 @@ type PeerIdentityPayload struct {
 	EncryptionSupported bool      `json:"encryptionSupported,omitempty"`
 	Verified            bool      `json:"verified,omitempty"`
 	GoopClientVersion   string    `json:"goopClientVersion,omitempty"`
+	Signed              bool      `json:"signed,omitempty"` // label/avatar/template signed by the peer's key
 	Reachable           bool      `json:"reachable"`
 	Offline             bool      `json:"offline,omitempty"`
 	LastSeen            int64     `json:"lastSeen,omitempty"`
@@ -39,6 +40,7 @@ func FromSeenPeer(sp SeenPeer) PeerIdentityPayload {
 		Reachable:           sp.Reachable,
 		Verified:            sp.Verified,
 		GoopClientVersion:   sp.GoopClientVersion,
+		Signed:              sp.Signed,
 		PublicKey:           sp.PublicKey,
 		EncryptionSupported: sp.EncryptionSupported,
 		ActiveTemplate:      sp.ActiveTemplate,
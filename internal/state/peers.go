@@ -16,6 +16,8 @@ type SeenPeer struct {
 	EncryptionSupported bool
 	Verified            bool
 	GoopClientVersion   string
+	Signed              bool // label, avatar and template carry a valid signature by the peer's key
+	SiteHash            string // content hash of the peer's site from its last presence, "" = unknown
 	Reachable      bool
 	LastSeen       time.Time
 	OfflineSince   time.Time
@@ -39,6 +41,8 @@ type PeerTable struct {
 	mu        sync.Mutex
 	peers     map[string]SeenPeer
 	listeners []chan PeerEvent
+	capacity  int // max tracked peers, favorites exempt; 0 = unlimited
+	evicted   int
 }
 
 func NewPeerTable() *PeerTable {
@@ -48,19 +52,21 @@ func NewPeerTable() *PeerTable {
 	}
 }
 
-func (t *PeerTable) Upsert(id, content, email, avatarHash string, videoDisabled bool, activeTemplate string, publicKey string, encryptionSupported bool, verified bool, goopClientVersion string) {
+func (t *PeerTable) Upsert(id, content, email, avatarHash string, videoDisabled bool, activeTemplate string, publicKey string, encryptionSupported bool, verified bool, goopClientVersion string, signed bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	reachable := false
 	favorite := false
 	var failStreak int
 	var lastFailAt time.Time
+	var siteHash string
 	if existing, ok := t.peers[id]; ok {
 		reachable = existing.Reachable
 		// Preserve local state across presence updates.
 		favorite = existing.Favorite
 		failStreak = existing.failStreak
 		lastFailAt = existing.lastFailAt
+		siteHash = existing.SiteHash
 		// Preserve public key if the incoming update doesn't carry one
 		// (e.g. P2P gossip doesn't include keys — they come over HTTP).
 		if publicKey == "" {
@@ -83,6 +89,8 @@ func (t *PeerTable) Upsert(id, content, email, avatarHash string, videoDisabled
 		EncryptionSupported: encryptionSupported,
 		Verified:            verified,
 		GoopClientVersion:   goopClientVersion,
+		Signed:              signed,
+		SiteHash:            siteHash,
 		Reachable:           reachable,
 		LastSeen:            time.Now(),
 		Favorite:            favorite,
@@ -91,6 +99,7 @@ func (t *PeerTable) Upsert(id, content, email, avatarHash string, videoDisabled
 	}
 	t.peers[id] = peer
 	t.notifyListeners(PeerEvent{Type: "update", PeerID: id, Peer: &peer})
+	t.evictLocked()
 }
 
 func (t *PeerTable) Seed(id, content, email, avatarHash string, videoDisabled bool, activeTemplate string, publicKey string, verified bool, favorite bool) {
@@ -99,6 +108,10 @@ func (t *PeerTable) Seed(id, content, email, avatarHash string, videoDisabled bo
 	if _, ok := t.peers[id]; ok {
 		return
 	}
+	// A full table keeps the peers it has; cached peers only fill free room.
+	if !favorite && t.capacity > 0 && t.countEvictableLocked() >= t.capacity {
+		return
+	}
 	sp := SeenPeer{
 		Content:        content,
 		Email:          email,
@@ -127,6 +140,19 @@ func (t *PeerTable) SetPublicKey(id, publicKey string) {
 	t.peers[id] = sp
 }
 
+// SetSiteHash records the site hash a peer announced; "" means the peer
+// does not announce one.
+func (t *PeerTable) SetSiteHash(id, hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sp, ok := t.peers[id]
+	if !ok || sp.SiteHash == hash {
+		return
+	}
+	sp.SiteHash = hash
+	t.peers[id] = sp
+}
+
 func (t *PeerTable) Touch(id string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -229,6 +255,7 @@ func (t *PeerTable) SetFavorite(id string, favorite bool) {
 	sp.Favorite = favorite
 	t.peers[id] = sp
 	t.notifyListeners(PeerEvent{Type: "update", PeerID: id, Peer: &sp})
+	t.evictLocked()
 }
 
 func (t *PeerTable) Snapshot() map[string]SeenPeer {
@@ -265,6 +292,59 @@ func (t *PeerTable) PruneStale(ttlCutoff, graceCutoff time.Time) {
 	}
 }
 
+// SetCapacity caps the number of non-favorite peers tracked; 0 removes the
+// cap. When over it, the least recently seen non-favorites are evicted.
+func (t *PeerTable) SetCapacity(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	t.capacity = n
+	t.evictLocked()
+}
+
+// Capacity returns the cap set by SetCapacity and how many peers it has
+// evicted so far.
+func (t *PeerTable) Capacity() (capacity, evicted int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.capacity, t.evicted
+}
+
+func (t *PeerTable) countEvictableLocked() int {
+	n := 0
+	for _, sp := range t.peers {
+		if !sp.Favorite {
+			n++
+		}
+	}
+	return n
+}
+
+// evictLocked removes the least recently seen non-favorites until the table
+// is within capacity. Listeners get a "remove" event for each.
+func (t *PeerTable) evictLocked() {
+	if t.capacity <= 0 {
+		return
+	}
+	for over := t.countEvictableLocked() - t.capacity; over > 0; over-- {
+		var oldestID string
+		var oldest time.Time
+		for id, sp := range t.peers {
+			if sp.Favorite {
+				continue
+			}
+			if oldestID == "" || sp.LastSeen.Before(oldest) {
+				oldestID, oldest = id, sp.LastSeen
+			}
+		}
+		delete(t.peers, oldestID)
+		t.evicted++
+		t.notifyListeners(PeerEvent{Type: "remove", PeerID: oldestID})
+	}
+}
+
 func (t *PeerTable) Subscribe() chan PeerEvent {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -7,7 +7,7 @@ import (
 
 func TestUpsert_NewPeer(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "alice@test.com", "hash1", false, "blog", "pk1", true, true, "2.4.0")
+	pt.Upsert("peer-1", "Alice", "alice@test.com", "hash1", false, "blog", "pk1", true, true, "2.4.0", false)
 
 	sp, ok := pt.Get("peer-1")
 	if !ok {
@@ -35,7 +35,7 @@ func TestUpsert_NewPeer(t *testing.T) {
 
 func TestUpsert_PreservesLocalState(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "pk1", true, false, "2.4.0")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "pk1", true, false, "2.4.0", false)
 	pt.SetReachable("peer-1", true)
 	pt.SetFavorite("peer-1", true)
 
@@ -47,7 +47,7 @@ func TestUpsert_PreservesLocalState(t *testing.T) {
 		t.Fatal("should be favorite after SetFavorite(true)")
 	}
 
-	pt.Upsert("peer-1", "Alice Updated", "alice@new.com", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice Updated", "alice@new.com", "", false, "", "", false, false, "", false)
 
 	sp, _ = pt.Get("peer-1")
 	if sp.Content != "Alice Updated" {
@@ -67,6 +67,23 @@ func TestUpsert_PreservesLocalState(t *testing.T) {
 	}
 }
 
+func TestSetSiteHash_SurvivesUpsert(t *testing.T) {
+	pt := NewPeerTable()
+	pt.SetSiteHash("peer-1", "abc") // unknown peer: ignored
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
+	pt.SetSiteHash("peer-1", "abc")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
+
+	sp, _ := pt.Get("peer-1")
+	if sp.SiteHash != "abc" {
+		t.Fatalf("expected SiteHash='abc' after upsert, got %q", sp.SiteHash)
+	}
+	pt.SetSiteHash("peer-1", "")
+	if sp, _ := pt.Get("peer-1"); sp.SiteHash != "" {
+		t.Fatalf("expected SiteHash cleared, got %q", sp.SiteHash)
+	}
+}
+
 func TestUpsert_ClearsOfflineSince(t *testing.T) {
 	pt := NewPeerTable()
 	pt.Seed("peer-1", "Alice", "", "", false, "", "", false, false)
@@ -76,7 +93,7 @@ func TestUpsert_ClearsOfflineSince(t *testing.T) {
 		t.Fatal("seeded peer should have OfflineSince set")
 	}
 
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 
 	sp, _ = pt.Get("peer-1")
 	if !sp.OfflineSince.IsZero() {
@@ -86,7 +103,7 @@ func TestUpsert_ClearsOfflineSince(t *testing.T) {
 
 func TestSeed_DoesNotOverwriteExisting(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "alice@real.com", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "alice@real.com", "", false, "", "", false, false, "", false)
 
 	pt.Seed("peer-1", "Old Alice", "old@email.com", "", false, "", "", false, false)
 
@@ -98,7 +115,7 @@ func TestSeed_DoesNotOverwriteExisting(t *testing.T) {
 
 func TestSetReachable_Success(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 
 	pt.SetReachable("peer-1", true)
 
@@ -110,7 +127,7 @@ func TestSetReachable_Success(t *testing.T) {
 
 func TestSetReachable_FailStreak(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 	pt.SetReachable("peer-1", true)
 
 	sp, _ := pt.Get("peer-1")
@@ -139,7 +156,7 @@ func TestSetReachable_FailStreak(t *testing.T) {
 
 func TestSetReachable_SuccessResetsStreak(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 	pt.SetReachable("peer-1", true)
 
 	pt.SetReachable("peer-1", false)
@@ -158,7 +175,7 @@ func TestSetReachable_SuccessResetsStreak(t *testing.T) {
 
 func TestMarkOffline(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 	pt.SetReachable("peer-1", true)
 
 	pt.MarkOffline("peer-1")
@@ -174,7 +191,7 @@ func TestMarkOffline(t *testing.T) {
 
 func TestPruneStale_TTLMovesToOffline(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 
 	pt.mu.Lock()
 	p := pt.peers["peer-1"]
@@ -197,7 +214,7 @@ func TestPruneStale_TTLMovesToOffline(t *testing.T) {
 
 func TestPruneStale_GraceRemovesPeer(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 	pt.MarkOffline("peer-1")
 
 	pt.mu.Lock()
@@ -221,7 +238,7 @@ func TestSubscribe_ReceivesEvents(t *testing.T) {
 	ch := pt.Subscribe()
 	defer pt.Unsubscribe(ch)
 
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 
 	select {
 	case evt := <-ch:
@@ -238,7 +255,7 @@ func TestSubscribe_ReceivesEvents(t *testing.T) {
 
 func TestRemove_BroadcastsEvent(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
 
 	ch := pt.Subscribe()
 	defer pt.Unsubscribe(ch)
@@ -262,8 +279,8 @@ func TestRemove_BroadcastsEvent(t *testing.T) {
 
 func TestSnapshot_ReturnsCopy(t *testing.T) {
 	pt := NewPeerTable()
-	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "")
-	pt.Upsert("peer-2", "Bob", "", "", false, "", "", false, false, "")
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", false)
+	pt.Upsert("peer-2", "Bob", "", "", false, "", "", false, false, "", false)
 
 	snap := pt.Snapshot()
 	if len(snap) != 2 {
@@ -277,3 +294,60 @@ func TestSnapshot_ReturnsCopy(t *testing.T) {
 		t.Fatal("deleting from snapshot should not affect the table")
 	}
 }
+
+func TestUpsert_Signed(t *testing.T) {
+	pt := NewPeerTable()
+	pt.Upsert("peer-1", "Alice", "", "", false, "", "", false, false, "", true)
+	if sp, _ := pt.Get("peer-1"); !sp.Signed {
+		t.Fatal("expected Signed=true")
+	}
+	// Each presence update carries its own signature; an unsigned one clears it.
+	pt.Upsert("peer-1", "Mallory", "", "", false, "", "", false, false, "", false)
+	if sp, _ := pt.Get("peer-1"); sp.Signed {
+		t.Fatal("expected Signed=false after unsigned update")
+	}
+}
+
+func TestSetCapacity_EvictsLeastRecentlySeen(t *testing.T) {
+	pt := NewPeerTable()
+	pt.Upsert("old", "Old", "", "", false, "", "", false, false, "", false)
+	pt.Upsert("fav", "Fav", "", "", false, "", "", false, false, "", false)
+	pt.SetFavorite("fav", true)
+	time.Sleep(time.Millisecond)
+	pt.Upsert("mid", "Mid", "", "", false, "", "", false, false, "", false)
+	ch := pt.Subscribe()
+	defer pt.Unsubscribe(ch)
+
+	pt.SetCapacity(1)
+	if _, ok := pt.Get("old"); ok {
+		t.Fatal("least recently seen peer should be evicted")
+	}
+	if evt := <-ch; evt.Type != "remove" || evt.PeerID != "old" {
+		t.Fatalf("event = %+v", evt)
+	}
+	if _, ok := pt.Get("fav"); !ok {
+		t.Fatal("favorites are exempt from eviction")
+	}
+
+	time.Sleep(time.Millisecond)
+	pt.Upsert("new", "New", "", "", false, "", "", false, false, "", false)
+	if _, ok := pt.Get("mid"); ok {
+		t.Fatal("mid should make room for new")
+	}
+	if _, ok := pt.Get("new"); !ok {
+		t.Fatal("new peer should be kept")
+	}
+	if c, evicted := pt.Capacity(); c != 1 || evicted != 2 {
+		t.Fatalf("capacity = %d, evicted = %d", c, evicted)
+	}
+
+	// Seeding from the cache never pushes a live peer out.
+	pt.Seed("cached", "Cached", "", "", false, "", "", false, false)
+	if _, ok := pt.Get("cached"); ok {
+		t.Fatal("seed into a full table should be skipped")
+	}
+	pt.Seed("cached-fav", "Cached", "", "", false, "", "", false, true)
+	if _, ok := pt.Get("cached-fav"); !ok {
+		t.Fatal("favorite seed should be kept")
+	}
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditEntry is one inbound P2P stream recorded in the audit log.
+type AuditEntry struct {
+	ID         int64  `json:"id"`
+	Timestamp  int64  `json:"ts"` // Unix ms
+	Protocol   string `json:"protocol"`
+	PeerID     string `json:"peer_id"`
+	Outcome    string `json:"outcome"` // "allowed" or "denied"
+	BytesIn    int64  `json:"bytes_in"`
+	BytesOut   int64  `json:"bytes_out"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AuditFilter narrows QueryAudit results. Zero values match everything.
+type AuditFilter struct {
+	PeerID   string
+	Protocol string
+	Outcome  string
+	Since    int64 // Unix ms, inclusive
+	Until    int64 // Unix ms, inclusive
+	Limit    int
+}
+
+const auditLogCap = 10000
+
+// Audit writer timing and sizes.
+const (
+	AuditFlushInterval = 1 * time.Second // write out queued audit entries
+	AuditPruneInterval = 1 * time.Minute // trim the log to auditLogCap rows
+	auditQueueSize     = 1024            // entries waiting for the writer; more are dropped
+	auditBatchMax      = 256             // entries written in one transaction
+)
+
+// InsertAuditEntry appends an entry. The log is trimmed by PruneAudit.
+func (d *DB) InsertAuditEntry(e AuditEntry) error {
+	return d.InsertAuditEntries([]AuditEntry{e})
+}
+
+// InsertAuditEntries appends entries in one transaction.
+func (d *DB) InsertAuditEntries(entries []AuditEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range entries {
+		if _, err := tx.Exec(
+			`INSERT INTO _audit_log (ts, protocol, peer_id, outcome, bytes_in, bytes_out, duration_ms) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			e.Timestamp, e.Protocol, e.PeerID, e.Outcome, e.BytesIn, e.BytesOut, e.DurationMs,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneAudit trims the log to the newest auditLogCap rows and returns how
+// many it dropped.
+func (d *DB) PruneAudit() (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Rolling window: ids are monotonic, so drop everything older than the cap.
+	res, err := d.db.Exec(`DELETE FROM _audit_log WHERE id <= (SELECT MAX(id) FROM _audit_log) - ?`, auditLogCap)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// AuditWriter records audit entries in the background, so a stream never
+// waits on the database. Entries are written in batches every
+// AuditFlushInterval and the log is pruned every AuditPruneInterval. When
+// the queue is full new entries are dropped and counted.
+type AuditWriter struct {
+	d         *DB
+	queue     chan AuditEntry
+	dropped   atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// StartAuditWriter starts a writer for d. Close it before closing d.
+func (d *DB) StartAuditWriter() *AuditWriter {
+	w := &AuditWriter{
+		d:     d,
+		queue: make(chan AuditEntry, auditQueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Add queues e without blocking.
+func (w *AuditWriter) Add(e AuditEntry) {
+	select {
+	case w.queue <- e:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Close writes out the queued entries and stops the writer. Only the
+// first call does anything.
+func (w *AuditWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
+
+func (w *AuditWriter) run() {
+	defer close(w.done)
+	flush := time.NewTicker(AuditFlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(AuditPruneInterval)
+	defer prune.Stop()
+
+	batch := make([]AuditEntry, 0, auditBatchMax)
+	write := func() {
+		if n := w.dropped.Swap(0); n > 0 {
+			log.Printf("storage: audit queue full, dropped %d entries", n)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.d.InsertAuditEntries(batch); err != nil {
+			log.Printf("storage: write audit log: %v", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e := <-w.queue:
+			if batch = append(batch, e); len(batch) >= auditBatchMax {
+				write()
+			}
+		case <-flush.C:
+			write()
+		case <-prune.C:
+			write()
+			if _, err := w.d.PruneAudit(); err != nil {
+				log.Printf("storage: prune audit log: %v", err)
+			}
+		case <-w.stop:
+			for {
+				select {
+				case e := <-w.queue:
+					if batch = append(batch, e); len(batch) >= auditBatchMax {
+						write()
+					}
+				default:
+					write()
+					return
+				}
+			}
+		}
+	}
+}
+
+// QueryAudit returns audit entries matching the filter, newest first.
+func (d *DB) QueryAudit(f AuditFilter) ([]AuditEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var where []string
+	var args []any
+	if f.PeerID != "" {
+		where = append(where, "peer_id = ?")
+		args = append(args, f.PeerID)
+	}
+	if f.Protocol != "" {
+		where = append(where, "protocol = ?")
+		args = append(args, f.Protocol)
+	}
+	if f.Outcome != "" {
+		where = append(where, "outcome = ?")
+		args = append(args, f.Outcome)
+	}
+	if f.Since > 0 {
+		where = append(where, "ts >= ?")
+		args = append(args, f.Since)
+	}
+	if f.Until > 0 {
+		where = append(where, "ts <= ?")
+		args = append(args, f.Until)
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > auditLogCap {
+		limit = 200
+	}
+
+	q := `SELECT id, ts, protocol, peer_id, outcome, bytes_in, bytes_out, duration_ms FROM _audit_log`
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Protocol, &e.PeerID, &e.Outcome, &e.BytesIn, &e.BytesOut, &e.DurationMs); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// AuditStats counts the audit log entries of one peer.
+type AuditStats struct {
+	Streams       int `json:"streams"`
+	Denied        int `json:"denied"`          // refused by an access policy
+	Refused       int `json:"refused"`         // refused by the protocol handler
+	PeakPerMinute int `json:"peak_per_minute"` // most streams in one clock minute
+}
+
+// PeerAuditStats counts peerID's streams since (Unix ms), on protocol or
+// on every protocol when it is "". The log is capped at auditLogCap
+// entries, so a busy peer's window may start later than since.
+func (d *DB) PeerAuditStats(peerID, protocol string, since int64) (AuditStats, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var s AuditStats
+	err := d.db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(outcome = 'denied'), 0),
+			COALESCE(SUM(outcome = 'refused'), 0)
+		FROM _audit_log
+		WHERE peer_id = ? AND (? = '' OR protocol = ?) AND ts >= ?`,
+		peerID, protocol, protocol, since,
+	).Scan(&s.Streams, &s.Denied, &s.Refused)
+	if err != nil {
+		return s, err
+	}
+	err = d.db.QueryRow(`
+		SELECT COALESCE(MAX(n), 0) FROM (
+			SELECT COUNT(*) AS n FROM _audit_log
+			WHERE peer_id = ? AND (? = '' OR protocol = ?) AND ts >= ?
+			GROUP BY ts / 60000
+		)`,
+		peerID, protocol, protocol, since,
+	).Scan(&s.PeakPerMinute)
+	return s, err
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditInsertAndQuery(t *testing.T) {
+	db := testDB(t)
+
+	entries := []AuditEntry{
+		{Timestamp: 1000, Protocol: "/goop/site/1.0.0", PeerID: "alice", Outcome: "allowed", BytesOut: 512},
+		{Timestamp: 2000, Protocol: "/goop/docs/1.0.0", PeerID: "bob", Outcome: "denied"},
+		{Timestamp: 3000, Protocol: "/goop/site/1.0.0", PeerID: "bob", Outcome: "allowed", DurationMs: 7},
+	}
+	for _, e := range entries {
+		if err := db.InsertAuditEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := db.QueryAudit(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Timestamp != 3000 {
+		t.Fatalf("expected 3 entries newest first, got %+v", all)
+	}
+
+	bob, _ := db.QueryAudit(AuditFilter{PeerID: "bob"})
+	if len(bob) != 2 {
+		t.Fatalf("peer filter: got %d entries", len(bob))
+	}
+
+	denied, _ := db.QueryAudit(AuditFilter{Outcome: "denied"})
+	if len(denied) != 1 || denied[0].Protocol != "/goop/docs/1.0.0" {
+		t.Fatalf("outcome filter: got %+v", denied)
+	}
+
+	site, _ := db.QueryAudit(AuditFilter{Protocol: "/goop/site/1.0.0", Since: 2000})
+	if len(site) != 1 || site[0].PeerID != "bob" {
+		t.Fatalf("protocol+since filter: got %+v", site)
+	}
+}
+
+func TestAuditRollingCap(t *testing.T) {
+	db := testDB(t)
+	for i := 0; i < auditLogCap+5; i++ {
+		if err := db.InsertAuditEntry(AuditEntry{Timestamp: int64(i), Protocol: "p", PeerID: "x", Outcome: "allowed"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := db.PruneAudit(); err != nil || n != 5 {
+		t.Fatalf("PruneAudit = %d, %v; want 5", n, err)
+	}
+	var n int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM _audit_log`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != auditLogCap {
+		t.Fatalf("expected %d rows, got %d", auditLogCap, n)
+	}
+}
+
+func TestAuditWriter(t *testing.T) {
+	db := testDB(t)
+	w := db.StartAuditWriter()
+	for i := 0; i < auditBatchMax+3; i++ {
+		w.Add(AuditEntry{Timestamp: int64(i), Protocol: "p", PeerID: "x", Outcome: "allowed"})
+	}
+
+	// A full batch is written without waiting for the flush tick.
+	deadline := time.Now().Add(AuditFlushInterval / 2)
+	for {
+		got, _ := db.QueryAudit(AuditFilter{Limit: auditLogCap})
+		if len(got) >= auditBatchMax {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("full batch not written, have %d entries", len(got))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Close writes out the rest.
+	w.Close()
+	w.Close()
+	got, err := db.QueryAudit(AuditFilter{Limit: auditLogCap})
+	if err != nil || len(got) != auditBatchMax+3 {
+		t.Fatalf("after Close: %d entries, %v", len(got), err)
+	}
+}
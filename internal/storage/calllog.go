@@ -0,0 +1,113 @@
+package storage
+
+// Call directions and outcomes for CallLogEntry.
+const (
+	CallIncoming = "in"
+	CallOutgoing = "out"
+
+	CallRinging   = "ringing"   // not yet answered or ended
+	CallAnswered  = "answered"  // connected (ended calls keep this outcome)
+	CallMissed    = "missed"    // incoming, caller gave up or ring timed out
+	CallDeclined  = "declined"  // rejected before answering (by either side)
+	CallBusy      = "busy"      // the callee was already in a call
+	CallCancelled = "cancelled" // outgoing, we hung up before it was answered
+)
+
+// CallLogEntry is one call in the history.
+type CallLogEntry struct {
+	ID         int64  `json:"id"`
+	ChannelID  string `json:"channel_id"`
+	PeerID     string `json:"peer_id"`
+	Direction  string `json:"direction"` // CallIncoming or CallOutgoing
+	Media      string `json:"media"`     // "audio" or "video"
+	Outcome    string `json:"outcome"`
+	StartedAt  int64  `json:"started_at"`  // Unix ms
+	AnsweredAt int64  `json:"answered_at"` // Unix ms, 0 if never answered
+	EndedAt    int64  `json:"ended_at"`    // Unix ms, 0 while in progress
+	DurationMs int64  `json:"duration_ms"` // answered → ended
+	Seen       bool   `json:"seen"`        // missed calls: acknowledged by the user
+}
+
+const callLogCap = 1000
+
+// SaveCallLog inserts or updates the entry for e.ChannelID and trims the log
+// to the newest callLogCap calls.
+func (d *DB) SaveCallLog(e CallLogEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT INTO _call_log (channel_id, peer_id, direction, media, outcome, started_at, answered_at, ended_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET
+			outcome = excluded.outcome, answered_at = excluded.answered_at, ended_at = excluded.ended_at`,
+		e.ChannelID, e.PeerID, e.Direction, e.Media, e.Outcome, e.StartedAt, e.AnsweredAt, e.EndedAt,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`DELETE FROM _call_log WHERE id <= (SELECT MAX(id) FROM _call_log) - ?`, callLogCap)
+	return err
+}
+
+// CallHistory returns calls newest first, optionally for a single peer.
+func (d *DB) CallHistory(peerID string, limit int) ([]CallLogEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if limit <= 0 || limit > callLogCap {
+		limit = 100
+	}
+	q := `SELECT id, channel_id, peer_id, direction, media, outcome, started_at, answered_at, ended_at, seen FROM _call_log`
+	args := []any{}
+	if peerID != "" {
+		q += ` WHERE peer_id = ?`
+		args = append(args, peerID)
+	}
+	q += ` ORDER BY started_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []CallLogEntry{}
+	for rows.Next() {
+		var e CallLogEntry
+		if err := rows.Scan(&e.ID, &e.ChannelID, &e.PeerID, &e.Direction, &e.Media, &e.Outcome, &e.StartedAt, &e.AnsweredAt, &e.EndedAt, &e.Seen); err != nil {
+			return nil, err
+		}
+		if e.AnsweredAt > 0 && e.EndedAt > e.AnsweredAt {
+			e.DurationMs = e.EndedAt - e.AnsweredAt
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// UnseenMissedCalls returns the number of missed calls not yet marked seen.
+func (d *DB) UnseenMissedCalls() (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var n int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM _call_log WHERE outcome = ? AND seen = 0`, CallMissed).Scan(&n)
+	return n, err
+}
+
+// MarkMissedCallsSeen clears the missed-call badge.
+func (d *DB) MarkMissedCallsSeen() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`UPDATE _call_log SET seen = 1 WHERE seen = 0`)
+	return err
+}
+
+// ClearCallHistory deletes all call log entries.
+func (d *DB) ClearCallHistory() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _call_log`)
+	return err
+}
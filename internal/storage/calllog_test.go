@@ -0,0 +1,59 @@
+package storage
+
+import "testing"
+
+func TestCallLogSaveAndHistory(t *testing.T) {
+	db := testDB(t)
+
+	calls := []CallLogEntry{
+		{ChannelID: "c1", PeerID: "alice", Direction: CallIncoming, Media: "video", Outcome: CallRinging, StartedAt: 1000},
+		{ChannelID: "c2", PeerID: "bob", Direction: CallOutgoing, Media: "audio", Outcome: CallRinging, StartedAt: 2000},
+	}
+	for _, c := range calls {
+		if err := db.SaveCallLog(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// c1 goes unanswered, c2 is answered and ends after 5s.
+	calls[0].Outcome, calls[0].EndedAt = CallMissed, 1500
+	calls[1].Outcome, calls[1].AnsweredAt, calls[1].EndedAt = CallAnswered, 3000, 8000
+	for _, c := range calls {
+		if err := db.SaveCallLog(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := db.CallHistory("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ChannelID != "c2" {
+		t.Fatalf("expected 2 calls newest first, got %+v", all)
+	}
+	if all[0].DurationMs != 5000 || all[0].Outcome != CallAnswered {
+		t.Fatalf("answered call = %+v", all[0])
+	}
+
+	alice, _ := db.CallHistory("alice", 0)
+	if len(alice) != 1 || alice[0].Outcome != CallMissed {
+		t.Fatalf("peer filter: got %+v", alice)
+	}
+
+	if n, _ := db.UnseenMissedCalls(); n != 1 {
+		t.Fatalf("unseen missed = %d, want 1", n)
+	}
+	if err := db.MarkMissedCallsSeen(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.UnseenMissedCalls(); n != 0 {
+		t.Fatalf("unseen missed after mark = %d", n)
+	}
+
+	if err := db.ClearCallHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := db.CallHistory("", 0); len(all) != 0 {
+		t.Fatalf("expected empty history, got %d", len(all))
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AccessConsent is a persisted consent decision for a remote peer.
+type AccessConsent struct {
+	PeerID    string `json:"peer_id"`
+	Decision  string `json:"decision"`   // "always" or "block"
+	UpdatedAt int64  `json:"updated_at"` // Unix ms
+}
+
+// GetAccessConsent returns the stored decision for a peer, or "" if none.
+func (d *DB) GetAccessConsent(peerID string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var decision string
+	err := d.db.QueryRow(`SELECT decision FROM _access_consent WHERE peer_id = ?`, peerID).Scan(&decision)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	return decision
+}
+
+// SetAccessConsent stores (or replaces) the decision for a peer.
+func (d *DB) SetAccessConsent(peerID, decision string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`
+		INSERT INTO _access_consent (peer_id, decision, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET decision = excluded.decision, updated_at = excluded.updated_at`,
+		peerID, decision, time.Now().UnixMilli(),
+	)
+	return err
+}
+
+// DeleteAccessConsent forgets the decision for a peer so they are prompted again.
+func (d *DB) DeleteAccessConsent(peerID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _access_consent WHERE peer_id = ?`, peerID)
+	return err
+}
+
+// ListAccessConsents returns all persisted decisions, most recent first.
+func (d *DB) ListAccessConsents() ([]AccessConsent, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`SELECT peer_id, decision, updated_at FROM _access_consent ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AccessConsent{}
+	for rows.Next() {
+		var c AccessConsent
+		if err := rows.Scan(&c.PeerID, &c.Decision, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,32 @@
+package storage
+
+import "testing"
+
+func TestAccessConsentRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	if got := db.GetAccessConsent("peer1"); got != "" {
+		t.Fatalf("expected no decision, got %q", got)
+	}
+	if err := db.SetAccessConsent("peer1", "always"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetAccessConsent("peer1", "block"); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.GetAccessConsent("peer1"); got != "block" {
+		t.Fatalf("decision = %q, want block", got)
+	}
+
+	list, err := db.ListAccessConsents()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+
+	if err := db.DeleteAccessConsent("peer1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.GetAccessConsent("peer1"); got != "" {
+		t.Fatalf("expected decision forgotten, got %q", got)
+	}
+}
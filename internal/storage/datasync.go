@@ -0,0 +1,642 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/orm/schema"
+)
+
+// Sync of tables shared with a template group. Triggers on a synced table
+// record every local change in _sync_rows: the row's version (ts, origin),
+// a local sequence number that pulls page by, and the base, the last
+// version from another peer the change was made on. Rows are matched
+// across peers by their _sync_id; _id is kept where it is free, so it can
+// differ between peers for rows inserted on two sides at once.
+//
+// Changes from other peers are applied last-writer-wins on (ts, origin).
+// A change whose base is not the version it meets was made concurrently
+// with that version: it is applied or dropped all the same, and reported
+// as a conflict.
+
+const syncOriginKey = "sync_origin"
+
+// Expressions used by the capture triggers.
+const (
+	syncNowSQL    = `CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)`
+	syncOriginSQL = `COALESCE((SELECT value FROM _meta WHERE key = 'sync_origin'), '')`
+)
+
+// SyncVersion identifies one version of a synced row.
+type SyncVersion struct {
+	TS      int64  `json:"ts"`     // Unix ms, increasing per row
+	Origin  string `json:"origin"` // peer ID of the peer that made the change
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Newer reports whether v wins over o: the later ts, the higher origin on
+// a tie.
+func (v SyncVersion) Newer(o SyncVersion) bool {
+	if v.TS != o.TS {
+		return v.TS > o.TS
+	}
+	return v.Origin > o.Origin
+}
+
+// SyncChange is the current version of a synced row.
+type SyncChange struct {
+	Table  string `json:"table"`
+	SyncID string `json:"sync_id"`
+	SyncVersion
+	BaseTS     int64          `json:"base_ts,omitempty"`
+	BaseOrigin string         `json:"base_origin,omitempty"`
+	Row        map[string]any `json:"row,omitempty"` // every column but _sync_id; nil when deleted
+	Seq        int64          `json:"-"`             // local sequence number
+}
+
+// SyncConflict is a change that met a version it was not made on.
+type SyncConflict struct {
+	Table  string         `json:"table"`
+	SyncID string         `json:"sync_id"`
+	Winner SyncVersion    `json:"winner"`
+	Loser  SyncVersion    `json:"loser"`
+	Lost   map[string]any `json:"lost,omitempty"` // the losing row; nil for a delete
+	Reason string         `json:"reason"`         // "concurrent", or why the change was not applied
+}
+
+// SyncTableDef describes a synced table well enough to create a replica.
+type SyncTableDef struct {
+	Name    string        `json:"name"`
+	Schema  *schema.Table `json:"schema,omitempty"`  // ORM tables
+	Columns []ColumnDef   `json:"columns,omitempty"` // classic tables
+}
+
+// SetSyncOrigin sets the origin recorded with local changes, the peer ID.
+func (d *DB) SetSyncOrigin(origin string) {
+	d.SetMeta(syncOriginKey, origin)
+}
+
+// SharedTables returns the tables with a "group" access policy for any
+// operation, the ones shared with the template group.
+func (d *DB) SharedTables() ([]string, error) {
+	tables, err := d.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, t := range tables {
+		if a := d.GetAccess(t.Name); a.UsesGroup() {
+			out = append(out, t.Name)
+		}
+	}
+	return out, nil
+}
+
+// SyncedTables returns the tables whose changes are captured.
+func (d *DB) SyncedTables() ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.syncedTablesLocked()
+}
+
+func (d *DB) syncedTablesLocked() ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT tbl_name FROM sqlite_master
+		WHERE type = 'trigger' AND name = '_sync_' || tbl_name || '_del'
+		ORDER BY tbl_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// syncVersionSQL records a local change of the row sid in a capture
+// trigger of table.
+func syncVersionSQL(table, sid string, deleted int) string {
+	return fmt.Sprintf(`
+		INSERT OR REPLACE INTO _sync_rows (tbl, sync_id, ts, origin, deleted, seq, base_ts, base_origin)
+		SELECT '%[1]s', s.sid, max(%[4]s, COALESCE(p.ts, 0) + 1), o.origin, %[3]d,
+			(SELECT COALESCE(MAX(seq), 0) + 1 FROM _sync_rows),
+			CASE WHEN p.origin = o.origin THEN p.base_ts ELSE COALESCE(p.ts, 0) END,
+			CASE WHEN p.origin = o.origin THEN p.base_origin ELSE COALESCE(p.origin, '') END
+		FROM (SELECT %[2]s AS sid) s
+		CROSS JOIN (SELECT %[5]s AS origin) o
+		LEFT JOIN _sync_rows p ON p.tbl = '%[1]s' AND p.sync_id = s.sid;`,
+		table, sid, deleted, syncNowSQL, syncOriginSQL)
+}
+
+// EnableSync starts capturing changes to table. Rows already in it get a
+// _sync_id and a first version. Enabling a synced table does nothing.
+func (d *DB) EnableSync(table string) error {
+	if !validIdent(table) {
+		return fmt.Errorf("invalid table name: %s", table)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var n int
+	d.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?`, "_sync_"+table+"_del").Scan(&n)
+	if n > 0 {
+		return nil
+	}
+	cols, err := tableColumns(d.db, table)
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("table %q not found", table)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmts := []string{
+		fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS _sync_%[1]s_id ON %[1]s(_sync_id)`, table),
+		fmt.Sprintf(`UPDATE %s SET _sync_id = lower(hex(randomblob(16))) WHERE _sync_id IS NULL`, table),
+		fmt.Sprintf(`
+			INSERT INTO _sync_rows (tbl, sync_id, ts, origin, seq)
+			SELECT '%[1]s', t._sync_id, %[2]s, %[3]s,
+				(SELECT COALESCE(MAX(seq), 0) FROM _sync_rows) + ROW_NUMBER() OVER (ORDER BY t._id)
+			FROM %[1]s t
+			WHERE NOT EXISTS (SELECT 1 FROM _sync_rows r WHERE r.tbl = '%[1]s' AND r.sync_id = t._sync_id)`,
+			table, syncNowSQL, syncOriginSQL),
+		fmt.Sprintf(`
+			CREATE TRIGGER _sync_%[1]s_ins AFTER INSERT ON %[1]s
+			WHEN (SELECT applying FROM _sync_state) = 0
+			BEGIN
+				UPDATE %[1]s SET _sync_id = lower(hex(randomblob(16))) WHERE _id = NEW._id AND _sync_id IS NULL;
+				%[2]s
+			END`, table, syncVersionSQL(table, fmt.Sprintf(`(SELECT _sync_id FROM %s WHERE _id = NEW._id)`, table), 0)),
+		// OLD._sync_id is NULL in the insert trigger's own update.
+		fmt.Sprintf(`
+			CREATE TRIGGER _sync_%[1]s_upd AFTER UPDATE ON %[1]s
+			WHEN (SELECT applying FROM _sync_state) = 0 AND OLD._sync_id IS NOT NULL
+			BEGIN
+				%[2]s
+			END`, table, syncVersionSQL(table, "NEW._sync_id", 0)),
+		fmt.Sprintf(`
+			CREATE TRIGGER _sync_%[1]s_del AFTER DELETE ON %[1]s
+			WHEN (SELECT applying FROM _sync_state) = 0 AND OLD._sync_id IS NOT NULL
+			BEGIN
+				%[2]s
+			END`, table, syncVersionSQL(table, "OLD._sync_id", 1)),
+	}
+	if !cols["_sync_id"] {
+		stmts = append([]string{fmt.Sprintf(`ALTER TABLE %s ADD COLUMN _sync_id TEXT`, table)}, stmts...)
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return fmt.Errorf("enable sync of %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DisableSync stops capturing changes to table and forgets its row
+// versions. The _sync_id column stays, so rows keep their identity if
+// the table is synced again.
+func (d *DB) DisableSync(table string) error {
+	if !validIdent(table) {
+		return fmt.Errorf("invalid table name: %s", table)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, op := range []string{"ins", "upd", "del"} {
+		if _, err := d.db.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS _sync_%s_%s`, table, op)); err != nil {
+			return err
+		}
+	}
+	_, err := d.db.Exec(`DELETE FROM _sync_rows WHERE tbl = ?`, table)
+	return err
+}
+
+// SyncSeq returns the sequence number of the latest local change.
+func (d *DB) SyncSeq() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var seq int64
+	d.db.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM _sync_rows`).Scan(&seq)
+	return seq
+}
+
+// SyncPending counts the changes made by origin after since.
+func (d *DB) SyncPending(since int64, origin string) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var n int
+	d.db.QueryRow(`SELECT COUNT(*) FROM _sync_rows WHERE seq > ? AND origin = ?`, since, origin).Scan(&n)
+	return n
+}
+
+// SyncChanges returns up to limit changes of synced tables recorded after
+// since, oldest first, for which keep returns true (nil keeps all); keep
+// sees the row. next
+// is the sequence number to continue from, past changes that were not
+// kept; more reports whether changes are left after it.
+func (d *DB) SyncChanges(since int64, limit int, keep func(SyncChange) bool) (changes []SyncChange, next int64, more bool, err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	synced, err := d.syncedTablesLocked()
+	if err != nil {
+		return nil, since, false, err
+	}
+	rows, err := d.db.Query(`
+		SELECT tbl, sync_id, ts, origin, deleted, seq, base_ts, base_origin
+		FROM _sync_rows WHERE seq > ? ORDER BY seq LIMIT ?`, since, limit+1)
+	if err != nil {
+		return nil, since, false, err
+	}
+	var all []SyncChange
+	for rows.Next() {
+		var c SyncChange
+		if err := rows.Scan(&c.Table, &c.SyncID, &c.TS, &c.Origin, &c.Deleted, &c.Seq, &c.BaseTS, &c.BaseOrigin); err != nil {
+			rows.Close()
+			return nil, since, false, err
+		}
+		all = append(all, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, since, false, err
+	}
+	if len(all) > limit {
+		all, more = all[:limit], true
+	}
+
+	next = since
+	for _, c := range all {
+		next = c.Seq
+		// Versions of tables no longer synced stay until the table is
+		// synced again; they are never sent.
+		if !slices.Contains(synced, c.Table) {
+			continue
+		}
+		if !c.Deleted {
+			row, err := syncRow(d.db, c.Table, c.SyncID)
+			if err != nil {
+				return nil, since, false, err
+			}
+			if row == nil {
+				continue // deleted since; its tombstone comes later
+			}
+			c.Row = row
+		}
+		if keep == nil || keep(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, next, more, nil
+}
+
+// ApplySync applies changes made by other peers, without capturing them
+// as local changes. A change applies when it is newer than the row's
+// version here, or always with force; the host's answer to a refused push
+// is forced. Conflicts are returned; with force there are none.
+func (d *DB) ApplySync(changes []SyncChange, force bool) ([]SyncConflict, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE _sync_state SET applying = 1`); err != nil {
+		return nil, err
+	}
+
+	var conflicts []SyncConflict
+	columns := map[string]map[string]bool{}
+	for _, c := range changes {
+		if !validIdent(c.Table) || c.SyncID == "" {
+			return nil, fmt.Errorf("invalid change %s/%s", c.Table, c.SyncID)
+		}
+		cols, ok := columns[c.Table]
+		if !ok {
+			if cols, err = tableColumns(tx, c.Table); err != nil {
+				return nil, err
+			}
+			columns[c.Table] = cols
+		}
+		if !cols["_sync_id"] {
+			continue // not synced here
+		}
+
+		cur, found, err := syncVersion(tx, c.Table, c.SyncID)
+		if err != nil {
+			return nil, err
+		}
+		if found && cur.TS == c.TS && cur.Origin == c.Origin {
+			continue // had it already
+		}
+		wins := force || !found || c.SyncVersion.Newer(cur)
+		if !force && found && cur.Origin != c.Origin && (cur.TS != c.BaseTS || cur.Origin != c.BaseOrigin) {
+			cf := SyncConflict{Table: c.Table, SyncID: c.SyncID, Winner: cur, Loser: c.SyncVersion, Lost: c.Row, Reason: "concurrent"}
+			if wins {
+				cf.Winner, cf.Loser = c.SyncVersion, cur
+				if cf.Lost, err = syncRow(tx, c.Table, c.SyncID); err != nil {
+					return nil, err
+				}
+			}
+			conflicts = append(conflicts, cf)
+		}
+		if !wins {
+			continue
+		}
+		// A row that does not fit, such as one missing a NOT NULL column,
+		// is reported and skipped rather than failing the batch.
+		if err := applySyncRow(tx, c, cols); err != nil {
+			conflicts = append(conflicts, SyncConflict{Table: c.Table, SyncID: c.SyncID, Winner: cur, Loser: c.SyncVersion, Lost: c.Row, Reason: err.Error()})
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO _sync_rows (tbl, sync_id, ts, origin, deleted, seq, base_ts, base_origin)
+			VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(seq), 0) + 1 FROM _sync_rows), ?, ?)`,
+			c.Table, c.SyncID, c.TS, c.Origin, c.Deleted, c.BaseTS, c.BaseOrigin,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE _sync_state SET applying = 0`); err != nil {
+		return nil, err
+	}
+	return conflicts, tx.Commit()
+}
+
+// SyncRowVersion returns the version of one synced row and its current
+// content, nil when deleted.
+func (d *DB) SyncRowVersion(table, syncID string) (v SyncVersion, row map[string]any, found bool, err error) {
+	if !validIdent(table) {
+		return v, nil, false, fmt.Errorf("invalid table name: %s", table)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if v, found, err = syncVersion(d.db, table, syncID); err != nil || !found {
+		return v, nil, found, err
+	}
+	if !v.Deleted {
+		row, err = syncRow(d.db, table, syncID)
+	}
+	return v, row, true, err
+}
+
+// SyncTableDefs describes every synced table.
+func (d *DB) SyncTableDefs() ([]SyncTableDef, error) {
+	tables, err := d.SyncedTables()
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]SyncTableDef, 0, len(tables))
+	for _, name := range tables {
+		def := SyncTableDef{Name: name}
+		if def.Schema, err = d.GetSchema(name); err != nil {
+			return nil, err
+		}
+		if def.Schema == nil {
+			cols, err := d.DescribeTable(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range cols {
+				if isSyncSystemColumn(c.Name) {
+					continue
+				}
+				cd := ColumnDef{Name: c.Name, Type: c.Type, NotNull: c.NotNull}
+				if c.Default != nil {
+					cd.Default = *c.Default
+				}
+				def.Columns = append(def.Columns, cd)
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// EnsureSyncTable creates a replica of a synced table described by def,
+// or adds the columns it lacks, and syncs it.
+func (d *DB) EnsureSyncTable(def SyncTableDef) error {
+	if !validIdent(def.Name) {
+		return fmt.Errorf("invalid table name: %s", def.Name)
+	}
+	if def.Schema != nil && def.Schema.Name != def.Name {
+		return fmt.Errorf("schema of %s names %s", def.Name, def.Schema.Name)
+	}
+	d.mu.RLock()
+	cols, err := tableColumns(d.db, def.Name)
+	d.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	want := def.Columns
+	if def.Schema != nil {
+		want = nil
+		for _, c := range def.Schema.Columns {
+			want = append(want, ColumnDef{Name: c.Name, Type: schema.SQLType(c.Type)})
+		}
+	}
+	switch {
+	case len(cols) == 0 && def.Schema != nil:
+		err = d.CreateTableORM(def.Schema)
+	case len(cols) == 0:
+		err = d.CreateTable(def.Name, def.Columns)
+	default:
+		for _, c := range want {
+			if !cols[c.Name] {
+				// Added columns take no NOT NULL: rows already here have no value.
+				if err = d.AddColumn(def.Name, ColumnDef{Name: c.Name, Type: c.Type}); err != nil {
+					break
+				}
+			}
+		}
+		if err == nil && def.Schema != nil {
+			d.mu.Lock()
+			err = storeSchema(d.db, def.Schema)
+			d.mu.Unlock()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return d.EnableSync(def.Name)
+}
+
+func storeSchema(db *sql.DB, tbl *schema.Table) error {
+	b, err := json.Marshal(tbl)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO _orm_schemas (table_name, schema_json) VALUES (?, ?)`, tbl.Name, string(b))
+	return err
+}
+
+func isSyncSystemColumn(name string) bool {
+	switch name {
+	case "_id", "_owner", "_owner_email", "_created_at", "_updated_at", "_sync_id":
+		return true
+	}
+	return false
+}
+
+// querier is a *sql.DB or *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// tableColumns returns the column names of table; none if it does not exist.
+func tableColumns(q querier, table string) (map[string]bool, error) {
+	rows, err := q.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			def              sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &def, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+func syncVersion(q querier, table, syncID string) (SyncVersion, bool, error) {
+	var v SyncVersion
+	err := q.QueryRow(`SELECT ts, origin, deleted FROM _sync_rows WHERE tbl = ? AND sync_id = ?`, table, syncID).
+		Scan(&v.TS, &v.Origin, &v.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v, false, nil
+	}
+	return v, err == nil, err
+}
+
+// syncRow returns the row with the given _sync_id without that column,
+// or nil if there is none.
+func syncRow(q querier, table, syncID string) (map[string]any, error) {
+	rows, err := q.Query(fmt.Sprintf(`SELECT * FROM %s WHERE _sync_id = ?`, table), syncID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(names))
+	ptrs := make([]any, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]any, len(names))
+	for i, name := range names {
+		if name == "_sync_id" {
+			continue
+		}
+		switch v := values[i].(type) {
+		case []byte:
+			row[name] = string(v)
+		case time.Time:
+			row[name] = v.UTC().Format("2006-01-02 15:04:05")
+		default:
+			row[name] = v
+		}
+	}
+	return row, nil
+}
+
+// applySyncRow writes the row of c, or deletes it. Columns this table
+// does not have are left out.
+func applySyncRow(tx *sql.Tx, c SyncChange, cols map[string]bool) error {
+	if c.Deleted {
+		_, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE _sync_id = ?`, c.Table), c.SyncID)
+		return err
+	}
+	var names []string
+	for k := range c.Row {
+		if cols[k] && k != "_id" && k != "_sync_id" {
+			names = append(names, k)
+		}
+	}
+	slices.Sort(names)
+	args := make([]any, 0, len(names)+2)
+	for _, k := range names {
+		args = append(args, c.Row[k])
+	}
+
+	var exists int
+	tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE _sync_id = ?`, c.Table), c.SyncID).Scan(&exists)
+	if exists > 0 {
+		if len(names) == 0 {
+			return nil
+		}
+		set := ""
+		for i, k := range names {
+			if i > 0 {
+				set += ", "
+			}
+			set += k + " = ?"
+		}
+		_, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s WHERE _sync_id = ?`, c.Table, set), append(args, c.SyncID)...)
+		return err
+	}
+
+	names = append(names, "_sync_id")
+	args = append(args, c.SyncID)
+	if id, ok := syncRowID(c.Row["_id"]); ok {
+		var taken int
+		tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE _id = ?`, c.Table), id).Scan(&taken)
+		if taken == 0 {
+			names = append(names, "_id")
+			args = append(args, id)
+		}
+	}
+	cs, ps := "", ""
+	for i, k := range names {
+		if i > 0 {
+			cs, ps = cs+", ", ps+", "
+		}
+		cs, ps = cs+k, ps+"?"
+	}
+	_, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, c.Table, cs, ps), args...)
+	return err
+}
+
+// syncRowID reads an _id that went through JSON.
+func syncRowID(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, n > 0
+	case float64:
+		return int64(n), n > 0 && n == float64(int64(n))
+	}
+	return 0, false
+}
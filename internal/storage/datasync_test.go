@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"testing"
+)
+
+// syncPair returns a host database with a synced "notes" table and a
+// replica of it.
+func syncPair(t *testing.T) (host, member *DB) {
+	t.Helper()
+	host, member = testDB(t), testDB(t)
+	host.SetSyncOrigin("host")
+	member.SetSyncOrigin("member")
+	if err := host.CreateTable("notes", []ColumnDef{{Name: "body", Type: "TEXT"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := host.Insert("notes", "host", "", map[string]any{"body": "before sync"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := host.EnableSync("notes"); err != nil {
+		t.Fatal(err)
+	}
+	defs, err := host.SyncTableDefs()
+	if err != nil || len(defs) != 1 {
+		t.Fatalf("defs = %+v, %v", defs, err)
+	}
+	if err := member.EnsureSyncTable(defs[0]); err != nil {
+		t.Fatal(err)
+	}
+	return host, member
+}
+
+// pull applies every change of from made after since to to, and returns
+// the new cursor.
+func pull(t *testing.T, from, to *DB, since int64, exclude string) ([]SyncConflict, int64) {
+	t.Helper()
+	changes, next, _, err := from.SyncChanges(since, 100, func(c SyncChange) bool { return c.Origin != exclude })
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts, err := to.ApplySync(changes, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conflicts, next
+}
+
+func bodies(t *testing.T, db *DB) map[string]bool {
+	t.Helper()
+	rows, err := db.Select("notes", []string{"body"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]bool{}
+	for _, r := range rows {
+		out[r["body"].(string)] = true
+	}
+	return out
+}
+
+func TestSync_ReplicatesInsertsUpdatesDeletes(t *testing.T) {
+	host, member := syncPair(t)
+
+	id, _ := host.Insert("notes", "host", "", map[string]any{"body": "second"})
+	_, cursor := pull(t, host, member, 0, "member")
+	if got := bodies(t, member); len(got) != 2 || !got["before sync"] || !got["second"] {
+		t.Fatalf("snapshot = %v", got)
+	}
+	// Applied changes are not captured as the member's own.
+	if own, _, _, _ := member.SyncChanges(0, 100, func(c SyncChange) bool { return c.Origin == "member" }); len(own) != 0 {
+		t.Fatalf("member recorded %d own changes", len(own))
+	}
+	rows, _ := member.Select("notes", []string{"_id"}, "body = ?", "second")
+	if len(rows) != 1 || rows[0]["_id"] != id {
+		t.Fatalf("replica row = %v, want _id %d", rows, id)
+	}
+
+	host.UpdateRow("notes", id, map[string]any{"body": "edited"})
+	host.DeleteWhere("notes", "body = ?", "before sync")
+	_, cursor = pull(t, host, member, cursor, "member")
+	if got := bodies(t, member); len(got) != 1 || !got["edited"] {
+		t.Fatalf("after update and delete = %v", got)
+	}
+
+	// The member's own change goes back to the host.
+	member.Insert("notes", "member", "", map[string]any{"body": "from member"})
+	pull(t, member, host, 0, "host")
+	if got := bodies(t, host); !got["from member"] {
+		t.Fatalf("host = %v", got)
+	}
+	// And is not pulled back to the member.
+	if changes, _, _, _ := host.SyncChanges(cursor, 100, func(c SyncChange) bool { return c.Origin != "member" }); len(changes) != 0 {
+		t.Fatalf("member would pull its own change back: %+v", changes)
+	}
+}
+
+func TestSync_ConcurrentEditsLastWriterWins(t *testing.T) {
+	host, member := syncPair(t)
+	_, cursor := pull(t, host, member, 0, "member")
+
+	// Both sides edit the same row before syncing; the member's edit is later.
+	host.UpdateWhere("notes", map[string]any{"body": "host edit"}, "1 = 1")
+	member.UpdateWhere("notes", map[string]any{"body": "member edit"}, "1 = 1")
+
+	conflicts, _ := pull(t, member, host, 0, "host")
+	if len(conflicts) != 1 || conflicts[0].Reason != "concurrent" || conflicts[0].Winner.Origin != "member" {
+		t.Fatalf("host conflicts = %+v", conflicts)
+	}
+	if conflicts[0].Lost["body"] != "host edit" {
+		t.Fatalf("lost row = %v", conflicts[0].Lost)
+	}
+	if got := bodies(t, host); !got["member edit"] {
+		t.Fatalf("host = %v", got)
+	}
+
+	// The host's own edit lost and is gone; the member pulls the row back
+	// as it forwarded it, which it already has.
+	conflicts, _ = pull(t, host, member, cursor, "member")
+	if len(conflicts) != 0 {
+		t.Fatalf("member conflicts = %+v", conflicts)
+	}
+	if got := bodies(t, member); !got["member edit"] {
+		t.Fatalf("member = %v", got)
+	}
+}
+
+func TestSync_SequentialEditsDoNotConflict(t *testing.T) {
+	host, member := syncPair(t)
+	_, cursor := pull(t, host, member, 0, "member")
+
+	member.UpdateWhere("notes", map[string]any{"body": "one"}, "1 = 1")
+	pull(t, member, host, 0, "host")
+	member.UpdateWhere("notes", map[string]any{"body": "two"}, "1 = 1")
+	if conflicts, _ := pull(t, member, host, 0, "host"); len(conflicts) != 0 {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+	host.UpdateWhere("notes", map[string]any{"body": "three"}, "1 = 1")
+	if conflicts, _ := pull(t, host, member, cursor, "member"); len(conflicts) != 0 {
+		t.Fatalf("member conflicts = %+v", conflicts)
+	}
+	if got := bodies(t, member); !got["three"] {
+		t.Fatalf("member = %v", got)
+	}
+}
+
+func TestSync_DisabledTableIsNotSent(t *testing.T) {
+	host, _ := syncPair(t)
+	if err := host.DisableSync("notes"); err != nil {
+		t.Fatal(err)
+	}
+	host.Insert("notes", "host", "", map[string]any{"body": "private"})
+	if changes, _, _, _ := host.SyncChanges(0, 100, nil); len(changes) != 0 {
+		t.Fatalf("changes of an unsynced table: %+v", changes)
+	}
+	if tables, _ := host.SyncedTables(); len(tables) != 0 {
+		t.Fatalf("synced = %v", tables)
+	}
+}
@@ -28,6 +28,7 @@ type DB struct {
 	db   *sql.DB
 	path string
 	mu   sync.RWMutex
+	enc  *encrypted // nil unless opened with OpenEncrypted
 }
 
 // Open opens or creates a SQLite database in the given directory
@@ -39,11 +40,20 @@ func Open(configDir string) (*DB, error) {
 		return nil, fmt.Errorf("create config dir: %w", err)
 	}
 
+	if _, err := os.Stat(filepath.Join(configDir, encryptedName)); err == nil {
+		return nil, fmt.Errorf("database is encrypted (%s): open it with storage encryption enabled", encryptedName)
+	}
+
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
+	return setup(db, dbPath)
+}
 
+// setup configures a freshly opened database and creates the system
+// tables. It closes db when it fails.
+func setup(db *sql.DB, dbPath string) (*DB, error) {
 	// Enable foreign keys and WAL mode for better concurrency
 	if _, err := db.Exec(`
 		PRAGMA foreign_keys = ON;
@@ -127,6 +137,10 @@ func Open(configDir string) (*DB, error) {
 	db.Exec(`ALTER TABLE _groups ADD COLUMN default_role TEXT DEFAULT 'viewer'`)
 	// Migration: add roles column — JSON array of available role names
 	db.Exec(`ALTER TABLE _groups ADD COLUMN roles TEXT DEFAULT '[]'`)
+	// Migration: add cohosts column — JSON array of peers authorized to relay the group
+	db.Exec(`ALTER TABLE _groups ADD COLUMN cohosts TEXT DEFAULT '[]'`)
+	// Migration: add ordered column — sequence-numbered delivery chosen per group
+	db.Exec(`ALTER TABLE _groups ADD COLUMN ordered INTEGER DEFAULT 0`)
 
 	// Create group subscriptions table
 	if _, err := db.Exec(`
@@ -154,6 +168,48 @@ func Open(configDir string) (*DB, error) {
 	if _, err := db.Exec(`ALTER TABLE _group_subscriptions RENAME COLUMN app_type TO group_type`); err == nil {
 		db.Exec(`DELETE FROM _group_subscriptions`)
 	}
+	// Migration: add relays to subscriptions — JSON array of every peer relaying a co-hosted group
+	db.Exec(`ALTER TABLE _group_subscriptions ADD COLUMN relays TEXT DEFAULT '[]'`)
+
+	// Groups this peer relays as a co-host for another peer. The owner's
+	// _groups row stays the source of truth; this is what we need to keep
+	// relaying while the owner is offline.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _relayed_groups (
+			group_id      TEXT PRIMARY KEY,
+			owner         TEXT NOT NULL,
+			name          TEXT DEFAULT '',
+			group_type    TEXT DEFAULT '',
+			group_context TEXT DEFAULT '',
+			max_members   INTEGER DEFAULT 0,
+			default_role  TEXT DEFAULT 'viewer',
+			relays        TEXT DEFAULT '[]',
+			created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create relayed groups table: %w", err)
+	}
+	// Migration: add ordered to relayed groups, copied from the owner's settings
+	db.Exec(`ALTER TABLE _relayed_groups ADD COLUMN ordered INTEGER DEFAULT 0`)
+
+	// Owner changes of groups we hosted, took over or are a member of: an
+	// explicit handover by the owner, or a takeover by the member elected
+	// while the owner was offline.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _group_ownership (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id  TEXT NOT NULL,
+			from_peer TEXT NOT NULL,
+			to_peer   TEXT NOT NULL,
+			reason    TEXT DEFAULT '',
+			at        INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_group_ownership_group ON _group_ownership(group_id, id);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create group ownership table: %w", err)
+	}
 
 	// Create cluster jobs table
 	if _, err := db.Exec(`
@@ -241,6 +297,237 @@ func Open(configDir string) (*DB, error) {
 		db.Close()
 		return nil, fmt.Errorf("create chat messages table: %w", err)
 	}
+	// Migration: tag messages sent in-call with the call's channel ID.
+	db.Exec(`ALTER TABLE _chat_messages ADD COLUMN call_id TEXT NOT NULL DEFAULT ''`)
+
+	// Inbound P2P stream audit log — one row per stream opened by a remote peer.
+	// ts = Unix ms; capped FIFO (see auditLogCap).
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _audit_log (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			ts          INTEGER NOT NULL,
+			protocol    TEXT    NOT NULL,
+			peer_id     TEXT    NOT NULL,
+			outcome     TEXT    NOT NULL,
+			bytes_in    INTEGER NOT NULL DEFAULT 0,
+			bytes_out   INTEGER NOT NULL DEFAULT 0,
+			duration_ms INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS _audit_log_ts ON _audit_log(ts DESC);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create audit log table: %w", err)
+	}
+
+	// Persisted consent decisions for inbound docs/data access ("always" or "block").
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _access_consent (
+			peer_id    TEXT PRIMARY KEY,
+			decision   TEXT    NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create access consent table: %w", err)
+	}
+
+	// Call history — one row per call channel, updated as the call progresses.
+	// Timestamps are Unix ms; capped FIFO (see callLogCap).
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _call_log (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id  TEXT    NOT NULL UNIQUE,
+			peer_id     TEXT    NOT NULL,
+			direction   TEXT    NOT NULL,
+			media       TEXT    NOT NULL DEFAULT '',
+			outcome     TEXT    NOT NULL,
+			started_at  INTEGER NOT NULL,
+			answered_at INTEGER NOT NULL DEFAULT 0,
+			ended_at    INTEGER NOT NULL DEFAULT 0,
+			seen        INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS _call_log_started ON _call_log(started_at DESC);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create call log table: %w", err)
+	}
+
+	// Automation rules ("when X then Y"). trigger and action are JSON
+	// objects owned by the rules package.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _rules (
+			id         TEXT PRIMARY KEY,
+			name       TEXT    NOT NULL DEFAULT '',
+			enabled    INTEGER NOT NULL DEFAULT 1,
+			trigger    TEXT    NOT NULL,
+			action     TEXT    NOT NULL,
+			created_at INTEGER NOT NULL,
+			last_run   INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT    NOT NULL DEFAULT ''
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create rules table: %w", err)
+	}
+
+	// Paired remote-control devices (phones). Only a SHA-256 of the bearer
+	// token is kept; scopes is a comma-separated list.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _paired_devices (
+			id         TEXT PRIMARY KEY,
+			name       TEXT    NOT NULL DEFAULT '',
+			token_hash TEXT    NOT NULL UNIQUE,
+			scopes     TEXT    NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			last_seen  INTEGER NOT NULL DEFAULT 0
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create paired devices table: %w", err)
+	}
+
+	// Private notes about other peers. Never sent anywhere; kept like
+	// favorites so a peer's note survives retention pruning.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _peer_notes (
+			peer_id    TEXT PRIMARY KEY,
+			body       TEXT    NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create peer notes table: %w", err)
+	}
+
+	// Abuse reports I filed about other peers. They leave this peer only
+	// inside a signed reputation record I choose to export or submit.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _peer_reports (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			peer_id    TEXT    NOT NULL,
+			reason     TEXT    NOT NULL,
+			note       TEXT    NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS _peer_reports_peer ON _peer_reports(peer_id);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create peer reports table: %w", err)
+	}
+
+	// Chat room messages, per group. msg_id is the sender's message ID, so a
+	// message that arrives twice (relayed, or again in a backfill) is kept once.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _group_chat_messages (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id  TEXT NOT NULL,
+			msg_id    TEXT NOT NULL,
+			from_id   TEXT NOT NULL,
+			from_name TEXT NOT NULL DEFAULT '',
+			text      TEXT NOT NULL,
+			ts        INTEGER NOT NULL,
+			UNIQUE(group_id, msg_id)
+		);
+		CREATE INDEX IF NOT EXISTS _group_chat_messages_group ON _group_chat_messages(group_id, ts);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create group chat messages table: %w", err)
+	}
+
+	// Scheduled listen sessions and group events, published as ICS feeds.
+	// group_id is empty for listen sessions.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _schedule (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind         TEXT    NOT NULL,
+			group_id     TEXT    NOT NULL DEFAULT '',
+			title        TEXT    NOT NULL,
+			description  TEXT    NOT NULL DEFAULT '',
+			starts_at    INTEGER NOT NULL,
+			duration_min INTEGER NOT NULL DEFAULT 60,
+			created_at   INTEGER NOT NULL,
+			updated_at   INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_schedule_starts ON _schedule(starts_at);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schedule table: %w", err)
+	}
+
+	// Access tokens for calendar feeds. group_id is empty for the feed of
+	// every scheduled session on this peer.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _calendar_feeds (
+			token      TEXT PRIMARY KEY,
+			group_id   TEXT    NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create calendar feeds table: %w", err)
+	}
+
+	// Store-and-forward outbox: MQ messages for peers that could not be
+	// reached, retried until delivered or expired. Timestamps are Unix ms.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _mq_outbox (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			peer_id    TEXT    NOT NULL,
+			topic      TEXT    NOT NULL,
+			payload    TEXT    NOT NULL DEFAULT 'null',
+			created_at INTEGER NOT NULL,
+			expires_at INTEGER NOT NULL,
+			attempts   INTEGER NOT NULL DEFAULT 0,
+			next_try   INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT    NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS _mq_outbox_peer ON _mq_outbox(peer_id, id);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create mq outbox table: %w", err)
+	}
+
+	// Peers whose site we keep a local copy of. The sync fields describe the
+	// copy in cache/sites/<peer_id>; synced_at is Unix ms, 0 = never.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _site_follows (
+			peer_id     TEXT PRIMARY KEY,
+			followed_at INTEGER NOT NULL,
+			synced_at   INTEGER NOT NULL DEFAULT 0,
+			site_hash   TEXT    NOT NULL DEFAULT '',
+			files       INTEGER NOT NULL DEFAULT 0,
+			bytes       INTEGER NOT NULL DEFAULT 0,
+			last_error  TEXT    NOT NULL DEFAULT ''
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create site follows table: %w", err)
+	}
+
+	// Row versions of tables shared with a template group, one per row ever
+	// synced (deleted rows stay as tombstones). seq orders local changes
+	// for pulls; _sync_state.applying turns the capture triggers off while
+	// changes from other peers are applied.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS _sync_rows (
+			tbl         TEXT    NOT NULL,
+			sync_id     TEXT    NOT NULL,
+			ts          INTEGER NOT NULL,
+			origin      TEXT    NOT NULL,
+			deleted     INTEGER NOT NULL DEFAULT 0,
+			seq         INTEGER NOT NULL,
+			base_ts     INTEGER NOT NULL DEFAULT 0,
+			base_origin TEXT    NOT NULL DEFAULT '',
+			PRIMARY KEY (tbl, sync_id)
+		);
+		CREATE INDEX IF NOT EXISTS _sync_rows_seq ON _sync_rows(seq);
+		CREATE TABLE IF NOT EXISTS _sync_state (applying INTEGER NOT NULL);
+		INSERT INTO _sync_state (applying) SELECT 0 WHERE NOT EXISTS (SELECT 1 FROM _sync_state);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create sync tables: %w", err)
+	}
 
 	// Separate table for favorites — stores favorite peers with their metadata.
 	// Favorites are never pruned by TTL, so metadata is always available even if peer goes offline.
@@ -271,9 +558,47 @@ func Open(configDir string) (*DB, error) {
 
 // Close closes the database
 func (d *DB) Close() error {
+	if d.enc != nil {
+		return d.enc.close(d)
+	}
 	return d.db.Close()
 }
 
+// Checkpoint copies the write-ahead log into the main database file and
+// truncates it, so the file on disk is complete without the -wal sidecar.
+// An encrypted database is written out to its file instead.
+func (d *DB) Checkpoint() error {
+	if d.enc != nil {
+		return d.enc.flush(false)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
+
+// QuickCheck runs SQLite's quick integrity check and returns an error
+// describing the first problem found.
+func (d *DB) QuickCheck() error {
+	var res string
+	if err := d.db.QueryRow(`PRAGMA quick_check`).Scan(&res); err != nil {
+		return err
+	}
+	if res != "ok" {
+		return fmt.Errorf("integrity check: %s", res)
+	}
+	return nil
+}
+
+// Reindex rebuilds every index. It repairs damaged indexes, the kind of
+// damage QuickCheck reports most often; damaged table pages it cannot fix.
+func (d *DB) Reindex() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`REINDEX`)
+	return err
+}
+
 // Path returns the database file path
 func (d *DB) Path() string {
 	return d.path
@@ -1294,6 +1619,7 @@ type ChatMessage struct {
 	From      string `json:"from"`
 	Content   string `json:"content"`
 	Timestamp int64  `json:"timestamp"`
+	CallID    string `json:"call_id,omitempty"` // set for messages exchanged during a call
 }
 
 const chatHistoryCap = 200
@@ -1301,12 +1627,18 @@ const chatHistoryCap = 200
 // StoreChatMessage persists one chat message.
 // peerID is the remote peer in the conversation; fromID is who sent it.
 func (d *DB) StoreChatMessage(peerID, fromID, content string, ts int64) error {
+	return d.StoreCallChatMessage(peerID, fromID, content, "", ts)
+}
+
+// StoreCallChatMessage persists one chat message tagged with the call it was
+// sent in. callID is the call's channel ID ("" for ordinary chat).
+func (d *DB) StoreCallChatMessage(peerID, fromID, content, callID string, ts int64) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	if _, err := d.db.Exec(
-		`INSERT INTO _chat_messages (peer_id, from_id, content, ts) VALUES (?, ?, ?, ?)`,
-		peerID, fromID, content, ts,
+		`INSERT INTO _chat_messages (peer_id, from_id, content, call_id, ts) VALUES (?, ?, ?, ?, ?)`,
+		peerID, fromID, content, callID, ts,
 	); err != nil {
 		return err
 	}
@@ -1331,8 +1663,8 @@ func (d *DB) GetChatHistory(peerID string, limit int) ([]ChatMessage, error) {
 	}
 
 	rows, err := d.db.Query(`
-		SELECT from_id, content, ts FROM (
-			SELECT from_id, content, ts FROM _chat_messages
+		SELECT from_id, content, call_id, ts FROM (
+			SELECT from_id, content, call_id, ts FROM _chat_messages
 			WHERE peer_id = ?
 			ORDER BY id DESC LIMIT ?
 		) ORDER BY ts ASC`, peerID, limit)
@@ -1344,7 +1676,7 @@ func (d *DB) GetChatHistory(peerID string, limit int) ([]ChatMessage, error) {
 	var msgs []ChatMessage
 	for rows.Next() {
 		var m ChatMessage
-		if err := rows.Scan(&m.From, &m.Content, &m.Timestamp); err != nil {
+		if err := rows.Scan(&m.From, &m.Content, &m.CallID, &m.Timestamp); err != nil {
 			return nil, err
 		}
 		msgs = append(msgs, m)
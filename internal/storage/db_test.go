@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"os"
 	"testing"
 )
 
@@ -18,6 +19,28 @@ func TestOpenAndClose(t *testing.T) {
 	}
 }
 
+func TestCheckpointAndQuickCheck(t *testing.T) {
+	db := testDB(t)
+	if err := db.CreateTable("items", []ColumnDef{{Name: "title", Type: "TEXT"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Insert("items", "peer1", "", map[string]any{"title": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if fi, err := os.Stat(db.Path() + "-wal"); err == nil && fi.Size() != 0 {
+		t.Errorf("wal size after checkpoint = %d, want 0", fi.Size())
+	}
+	if err := db.QuickCheck(); err != nil {
+		t.Errorf("QuickCheck: %v", err)
+	}
+	if err := db.Reindex(); err != nil {
+		t.Errorf("Reindex: %v", err)
+	}
+}
+
 func TestCreateTableAndList(t *testing.T) {
 	db := testDB(t)
 
@@ -0,0 +1,412 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+	sqlite "modernc.org/sqlite"
+	"modernc.org/sqlite/vfs"
+)
+
+// An encrypted database never touches the disk in the clear. It runs in
+// memory and is written to data.db.enc as one AES-256-GCM sealed image:
+//
+//	"GOOPDBE1" | salt (16) | nonce (12) | ciphertext
+//
+// The key comes from a KeyFunc and the salt, which is made once per file.
+// Every write picks a new nonce and replaces the file atomically, so a
+// crash leaves either the old or the new image.
+
+const (
+	encryptedName = "data.db.enc"
+	encMagic      = "GOOPDBE1"
+	encSaltLen    = 16
+)
+
+// FlushInterval is how often an encrypted database is written out when it
+// changed. Checkpoint and Close write it right away.
+const FlushInterval = 2 * time.Second
+
+// A KeyFunc derives the 32-byte key of an encrypted database from the salt
+// stored in its file.
+type KeyFunc func(salt []byte) ([]byte, error)
+
+// IdentityKey derives the database key from the peer's private identity
+// key, so the database opens without a passphrase but only together with
+// data/identity.key.
+func IdentityKey(priv []byte) KeyFunc {
+	return hkdfKey(priv, "empty identity key")
+}
+
+// FileKey derives the database key from the random key kept in a key file,
+// which package keystore protects with the key passphrase or the OS
+// keychain.
+func FileKey(secret []byte) KeyFunc {
+	return hkdfKey(secret, "empty database key")
+}
+
+func hkdfKey(secret []byte, emptyErr string) KeyFunc {
+	return func(salt []byte) ([]byte, error) {
+		if len(secret) == 0 {
+			return nil, errors.New(emptyErr)
+		}
+		key := make([]byte, 32)
+		_, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte("goop2 database key")), key)
+		return key, err
+	}
+}
+
+// encrypted is the part of a DB that keeps data.db.enc in step with the
+// in-memory database.
+type encrypted struct {
+	file   string
+	header []byte // magic and salt; also the additional data of every seal
+	aead   cipher.AEAD
+	key    KeyFunc // for OpenReplica
+
+	// pin holds the in-memory database open for as long as the DB lives.
+	// Nothing writes through it, so data_version on it changes with
+	// every commit.
+	pin *sql.Conn
+
+	mu      sync.Mutex // serializes flushes
+	version int64      // data_version at the last flush
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// OpenEncrypted opens or creates the encrypted database in configDir, with
+// the same tables as Open. A plain data.db found there is encrypted and
+// deleted on the first open. The whole database is held in memory.
+func OpenEncrypted(configDir string, key KeyFunc) (*DB, error) {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return nil, fmt.Errorf("create config dir: %w", err)
+	}
+	encPath := filepath.Join(configDir, encryptedName)
+	plainPath := filepath.Join(configDir, "data.db")
+
+	var image []byte
+	e := &encrypted{file: encPath, key: key, stop: make(chan struct{}), done: make(chan struct{})}
+	sealed, err := os.ReadFile(encPath)
+	switch {
+	case err == nil:
+		if image, err = e.unseal(sealed, key); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		salt := make([]byte, encSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		if err := e.init(salt, key); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("read encrypted database: %w", err)
+	}
+
+	migrate := false
+	if image == nil {
+		if image, err = readPlain(plainPath); err != nil {
+			return nil, err
+		}
+		migrate = image != nil
+	}
+
+	name := make([]byte, 8)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:/goop-"+hex.EncodeToString(name)+
+		"?vfs=memdb&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if e.pin, err = db.Conn(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if image != nil {
+		if err := restoreImage(e.pin, image); err != nil {
+			e.pin.Close()
+			db.Close()
+			return nil, fmt.Errorf("load database: %w", err)
+		}
+	}
+
+	d, err := setup(db, encPath)
+	if err != nil {
+		e.pin.Close()
+		return nil, err
+	}
+	d.enc = e
+
+	// A new or migrated database is written out before anything else, so
+	// the plain copy only goes once its encrypted one is on disk.
+	if sealed == nil {
+		if err := e.flush(true); err != nil {
+			d.Close()
+			return nil, fmt.Errorf("write encrypted database: %w", err)
+		}
+	}
+	if migrate {
+		for _, p := range []string{plainPath, plainPath + "-wal", plainPath + "-shm"} {
+			if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("storage: remove %s after encrypting it: %v", filepath.Base(p), err)
+			}
+		}
+		log.Printf("storage: encrypted data.db into %s", encryptedName)
+	}
+
+	go e.run()
+	return d, nil
+}
+
+// OpenReplica opens or creates another database in dir the way d was
+// opened: encrypted with the same key when d is, plain otherwise. Copies
+// of other peers' data go through it, so they are no less protected than
+// our own.
+func (d *DB) OpenReplica(dir string) (*DB, error) {
+	if d.enc == nil {
+		return Open(dir)
+	}
+	return OpenEncrypted(dir, d.enc.key)
+}
+
+// init sets up the cipher for salt.
+func (e *encrypted) init(salt []byte, key KeyFunc) error {
+	k, err := key(salt)
+	if err != nil {
+		return fmt.Errorf("database key: %w", err)
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return fmt.Errorf("database key: %w", err)
+	}
+	if e.aead, err = cipher.NewGCM(block); err != nil {
+		return err
+	}
+	e.header = append([]byte(encMagic), salt...)
+	return nil
+}
+
+// unseal sets up the cipher from the file's header and returns the
+// decrypted database image.
+func (e *encrypted) unseal(sealed []byte, key KeyFunc) ([]byte, error) {
+	hlen := len(encMagic) + encSaltLen
+	if len(sealed) < hlen+12 || string(sealed[:len(encMagic)]) != encMagic {
+		return nil, fmt.Errorf("%s is not an encrypted goop2 database", encryptedName)
+	}
+	if err := e.init(sealed[len(encMagic):hlen], key); err != nil {
+		return nil, err
+	}
+	nonce := sealed[hlen : hlen+e.aead.NonceSize()]
+	image, err := e.aead.Open(nil, nonce, sealed[hlen+len(nonce):], sealed[:hlen])
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: wrong key or damaged file", encryptedName)
+	}
+	return image, nil
+}
+
+// flush writes the database to the file when it changed since the last
+// flush, or always when force is set.
+func (e *encrypted) flush(force bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ctx := context.Background()
+
+	// The read transaction keeps writers from committing while the pages
+	// are copied, so the image is a consistent one.
+	if _, err := e.pin.ExecContext(ctx, `BEGIN`); err != nil {
+		return err
+	}
+	defer e.pin.ExecContext(ctx, `COMMIT`)
+	var n, version int64
+	if err := e.pin.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_schema`).Scan(&n); err != nil {
+		return err
+	}
+	if err := e.pin.QueryRowContext(ctx, `PRAGMA data_version`).Scan(&version); err != nil {
+		return err
+	}
+	if !force && version == e.version {
+		return nil
+	}
+	var image []byte
+	err := e.pin.Raw(func(dc any) error {
+		s, ok := dc.(interface{ Serialize() ([]byte, error) })
+		if !ok {
+			return errors.New("driver cannot serialize")
+		}
+		var err error
+		image, err = s.Serialize()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := make([]byte, 0, len(e.header)+len(nonce)+len(image)+e.aead.Overhead())
+	out = append(out, e.header...)
+	out = append(out, nonce...)
+	out = e.aead.Seal(out, nonce, image, e.header)
+	if err := writeAtomic(e.file, out); err != nil {
+		return err
+	}
+	e.version = version
+	return nil
+}
+
+func (e *encrypted) run() {
+	defer close(e.done)
+	t := time.NewTicker(FlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-t.C:
+			if err := e.flush(false); err != nil {
+				log.Printf("storage: write encrypted database: %v", err)
+			}
+		}
+	}
+}
+
+// close writes out the last changes and closes the database. Only the
+// first call does anything.
+func (e *encrypted) close(d *DB) error {
+	e.closeOnce.Do(func() {
+		close(e.stop)
+		<-e.done
+		e.closeErr = e.flush(false)
+		e.pin.Close()
+		if err := d.db.Close(); e.closeErr == nil {
+			e.closeErr = err
+		}
+	})
+	return e.closeErr
+}
+
+// readPlain returns the image of an unencrypted data.db, or nil when there
+// is none. The file leaves WAL mode first, which folds in the write-ahead
+// log and marks the image as one an in-memory database can open.
+func readPlain(path string) ([]byte, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open data.db: %w", err)
+	}
+	_, err = db.Exec(`PRAGMA journal_mode = DELETE`)
+	db.Close()
+	if err != nil {
+		return nil, fmt.Errorf("prepare data.db: %w", err)
+	}
+	return os.ReadFile(path)
+}
+
+// restoreImage copies a database image into the database of conn. The
+// image is read through a read-only VFS, because the driver's Deserialize
+// cannot be used on a database shared by a connection pool.
+func restoreImage(conn *sql.Conn, image []byte) error {
+	name, fsys, err := vfs.New(imageFS(image))
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+	return conn.Raw(func(dc any) error {
+		r, ok := dc.(interface {
+			NewRestore(string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errors.New("driver cannot restore")
+		}
+		b, err := r.NewRestore("file:data.db?vfs=" + name + "&immutable=1")
+		if err != nil {
+			return err
+		}
+		for {
+			more, err := b.Step(-1)
+			if err != nil {
+				b.Finish()
+				return err
+			}
+			if !more {
+				return b.Finish()
+			}
+		}
+	})
+}
+
+// imageFS is a file system with a database image as its only file,
+// data.db.
+type imageFS []byte
+
+func (f imageFS) Open(name string) (fs.File, error) {
+	if name != "data.db" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return imageFile{bytes.NewReader(f)}, nil
+}
+
+type imageFile struct{ *bytes.Reader }
+
+func (f imageFile) Stat() (fs.FileInfo, error) { return imageInfo(f.Size()), nil }
+func (f imageFile) Close() error               { return nil }
+
+type imageInfo int64
+
+func (i imageInfo) Name() string       { return "data.db" }
+func (i imageInfo) Size() int64        { return int64(i) }
+func (i imageInfo) Mode() fs.FileMode  { return 0o400 }
+func (i imageInfo) ModTime() time.Time { return time.Time{} }
+func (i imageInfo) IsDir() bool        { return false }
+func (i imageInfo) Sys() any           { return nil }
+
+// writeAtomic replaces path with data through a synced temporary file.
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOpenEncrypted_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	key := FileKey([]byte("correct horse"))
+
+	db, err := OpenEncrypted(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE notes (body TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	// Writers on several pool connections at once.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if _, err := db.Exec(`INSERT INTO notes VALUES ('top secret note')`); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "data.db")); !os.IsNotExist(err) {
+		t.Fatal("plain data.db written")
+	}
+	sealed, err := os.ReadFile(filepath.Join(dir, encryptedName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("top secret")) || bytes.Contains(sealed, []byte("SQLite format")) {
+		t.Fatal("database file is readable")
+	}
+
+	if _, err := OpenEncrypted(dir, FileKey([]byte("wrong"))); err == nil {
+		t.Fatal("opened with the wrong key")
+	}
+	if _, err := Open(dir); err == nil {
+		t.Fatal("Open created a plain database next to the encrypted one")
+	}
+
+	db, err = OpenEncrypted(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM notes`).Scan(&n); err != nil || n != 80 {
+		t.Fatalf("notes = %d, %v", n, err)
+	}
+	// Nested queries need a second connection to the same database.
+	if _, err := db.ListCachedPeers(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenEncrypted_MigratesPlain(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE notes (body TEXT); INSERT INTO notes VALUES ('kept')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	key := IdentityKey([]byte("identity private key bytes"))
+	db, err = OpenEncrypted(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body string
+	if err := db.QueryRow(`SELECT body FROM notes`).Scan(&body); err != nil || body != "kept" {
+		t.Fatalf("body = %q, %v", body, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.db")); !os.IsNotExist(err) {
+		t.Fatal("plain data.db left behind")
+	}
+
+	// Checkpoint writes changes out without closing.
+	if _, err := db.Exec(`INSERT INTO notes VALUES ('later')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := os.ReadFile(filepath.Join(dir, encryptedName))
+	e := &encrypted{}
+	image, err := e.unseal(sealed, key)
+	if err != nil || !bytes.Contains(image, []byte("later")) {
+		t.Fatalf("checkpointed image missing the new row: %v", err)
+	}
+	db.Close()
+}
+
+func TestOpenReplica(t *testing.T) {
+	plain := testDB(t)
+	r, err := plain.OpenReplica(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if r.enc != nil {
+		t.Fatal("replica of a plain database is encrypted")
+	}
+
+	db, err := OpenEncrypted(t.TempDir(), FileKey([]byte("k")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dir := t.TempDir()
+	if r, err = db.OpenReplica(dir); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := os.Stat(filepath.Join(dir, encryptedName)); err != nil {
+		t.Fatalf("replica of an encrypted database not encrypted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.db")); !os.IsNotExist(err) {
+		t.Fatal("replica written in the clear")
+	}
+}
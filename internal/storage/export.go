@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ExportCategory groups the system tables holding one kind of personal data,
+// for the full data export and the selective wipe.
+type ExportCategory struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tables      []string `json:"tables"`
+}
+
+// ExportCategories lists every system table that records something about me
+// or other peers. Site bookkeeping (_meta, _tables, _orm_schemas) is not
+// personal data and is left to the site export.
+var ExportCategories = []ExportCategory{
+	{"chat", "Direct chat history, including messages sent during calls, and chat room messages", []string{"_chat_messages", "_group_chat_messages"}},
+	{"outbox", "Messages waiting to be delivered to peers that were offline", []string{"_mq_outbox"}},
+	{"calls", "Call history", []string{"_call_log"}},
+	{"peers", "Cached presence of peers seen, favorites, notes, abuse reports, followed sites and access consent decisions", []string{"_peer_cache", "_favorites", "_peer_notes", "_peer_reports", "_site_follows", "_access_consent"}},
+	{"groups", "Groups hosted, co-hosted and joined, with their last known members and owner changes", []string{"_groups", "_group_subscriptions", "_group_members", "_relayed_groups", "_group_ownership"}},
+	{"audit", "Log of streams opened by remote peers", []string{"_audit_log"}},
+	{"cluster", "Cluster compute jobs", []string{"_cluster_jobs"}},
+	{"rules", "Automation rules", []string{"_rules"}},
+	{"schedule", "Scheduled listen sessions and group events, and calendar feed tokens", []string{"_schedule", "_calendar_feeds"}},
+	{"devices", "Paired remote-control devices (token hashes only)", []string{"_paired_devices"}},
+}
+
+// peerColumns names the column identifying the remote peer in tables that
+// can be wiped for a single peer.
+var peerColumns = map[string]string{
+	"_chat_messages":       "peer_id",
+	"_group_chat_messages": "from_id",
+	"_mq_outbox":           "peer_id",
+	"_call_log":            "peer_id",
+	"_peer_cache":          "peer_id",
+	"_favorites":           "peer_id",
+	"_peer_notes":          "peer_id",
+	"_peer_reports":        "peer_id",
+	"_site_follows":        "peer_id",
+	"_access_consent":      "peer_id",
+	"_group_subscriptions": "host_peer_id",
+	"_group_members":       "peer_id",
+	"_relayed_groups":      "owner",
+	"_audit_log":           "peer_id",
+}
+
+// FindExportCategory returns the category with the given name.
+func FindExportCategory(name string) (ExportCategory, bool) {
+	i := slices.IndexFunc(ExportCategories, func(c ExportCategory) bool { return c.Name == name })
+	if i < 0 {
+		return ExportCategory{}, false
+	}
+	return ExportCategories[i], true
+}
+
+// TableExport is one table in the data export: its columns (so the file
+// documents itself) and every row.
+type TableExport struct {
+	Table   string           `json:"table"`
+	Columns []ColumnInfo     `json:"columns"`
+	Rows    []map[string]any `json:"rows"`
+}
+
+// ExportTable dumps a whole table in insertion order.
+func (d *DB) ExportTable(table string) (TableExport, error) {
+	cols, err := d.DescribeTable(table)
+	if err != nil {
+		return TableExport{}, err
+	}
+	rows, err := d.SelectPaged(SelectOpts{Table: table, Order: "rowid"})
+	if err != nil {
+		return TableExport{}, err
+	}
+	if rows == nil {
+		rows = []map[string]any{}
+	}
+	return TableExport{Table: table, Columns: cols, Rows: rows}, nil
+}
+
+// CountRows returns the number of rows in a table.
+func (d *DB) CountRows(table string) (int64, error) {
+	if !validIdent(table) {
+		return 0, fmt.Errorf("invalid table name: %s", table)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var n int64
+	err := d.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	return n, err
+}
+
+// WipeCategory deletes the rows of every table in a category and returns
+// the count per table. With peerID set, only rows about that peer go, and
+// tables that do not record a peer are left alone. All or nothing.
+func (d *DB) WipeCategory(name, peerID string) (map[string]int64, error) {
+	c, ok := FindExportCategory(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown category %q", name)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deleted := make(map[string]int64, len(c.Tables))
+	for _, table := range c.Tables {
+		query, args := "DELETE FROM "+table, []any(nil)
+		if peerID != "" {
+			col, ok := peerColumns[table]
+			if !ok {
+				continue
+			}
+			query, args = query+" WHERE "+col+" = ?", []any{peerID}
+		}
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("wipe %s: %w", table, err)
+		}
+		deleted[table], _ = res.RowsAffected()
+	}
+	return deleted, tx.Commit()
+}
+
+// WipeOwnerRows deletes the rows a peer owns (by _owner) in every user table.
+func (d *DB) WipeOwnerRows(peerID string) (map[string]int64, error) {
+	if peerID == "" {
+		return nil, fmt.Errorf("peer required")
+	}
+	tables, err := d.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deleted := make(map[string]int64, len(tables))
+	for _, t := range tables {
+		if !validIdent(t.Name) {
+			continue
+		}
+		res, err := tx.Exec("DELETE FROM "+t.Name+" WHERE _owner = ?", peerID)
+		if err != nil {
+			return nil, fmt.Errorf("wipe %s: %w", t.Name, err)
+		}
+		deleted[t.Name], _ = res.RowsAffected()
+	}
+	return deleted, tx.Commit()
+}
@@ -0,0 +1,95 @@
+package storage
+
+import "testing"
+
+func TestExportCategoriesCoverPeerColumns(t *testing.T) {
+	for table := range peerColumns {
+		found := false
+		for _, c := range ExportCategories {
+			for _, tbl := range c.Tables {
+				found = found || tbl == table
+			}
+		}
+		if !found {
+			t.Errorf("%s has a peer column but no export category", table)
+		}
+	}
+}
+
+func TestExportTable(t *testing.T) {
+	db := testDB(t)
+	db.StoreChatMessage("alice", "alice", "hi", 1000)
+	db.StoreChatMessage("alice", "me", "hello", 2000)
+
+	ex, err := db.ExportTable("_chat_messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Rows) != 2 || ex.Rows[0]["content"] != "hi" {
+		t.Fatalf("rows = %+v", ex.Rows)
+	}
+	if len(ex.Columns) == 0 || ex.Columns[0].Name != "id" {
+		t.Fatalf("columns = %+v", ex.Columns)
+	}
+
+	empty, err := db.ExportTable("_call_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Rows == nil {
+		t.Fatal("empty table should export [] rows, not null")
+	}
+}
+
+func TestWipeCategory(t *testing.T) {
+	db := testDB(t)
+	db.StoreChatMessage("alice", "alice", "hi", 1000)
+	db.StoreChatMessage("bob", "bob", "yo", 1000)
+	db.SetAccessConsent("alice", "always")
+	db.SetAccessConsent("bob", "block")
+
+	got, err := db.WipeCategory("chat", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["_chat_messages"] != 1 {
+		t.Fatalf("deleted = %v", got)
+	}
+	if n, _ := db.CountRows("_chat_messages"); n != 1 {
+		t.Fatalf("bob's message should remain, have %d rows", n)
+	}
+
+	if _, err := db.WipeCategory("peers", ""); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.CountRows("_access_consent"); n != 0 {
+		t.Fatalf("consent rows = %d after full wipe", n)
+	}
+
+	if _, err := db.WipeCategory("nope", ""); err == nil {
+		t.Fatal("unknown category should fail")
+	}
+}
+
+func TestWipeOwnerRows(t *testing.T) {
+	db := testDB(t)
+	if err := db.CreateTable("notes", []ColumnDef{{Name: "body", Type: "TEXT"}}); err != nil {
+		t.Fatal(err)
+	}
+	db.Insert("notes", "alice", "", map[string]any{"body": "a"})
+	db.Insert("notes", "bob", "", map[string]any{"body": "b"})
+
+	got, err := db.WipeOwnerRows("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["notes"] != 1 {
+		t.Fatalf("deleted = %v", got)
+	}
+	if n, _ := db.CountRows("notes"); n != 1 {
+		t.Fatalf("rows = %d", n)
+	}
+	if _, err := db.WipeOwnerRows(""); err == nil {
+		t.Fatal("empty peer should fail")
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// SiteFollow is a peer whose site we keep a local copy of, and the state of
+// that copy.
+type SiteFollow struct {
+	PeerID     string `json:"peer_id"`
+	FollowedAt int64  `json:"followed_at"` // Unix ms
+	SyncedAt   int64  `json:"synced_at"`   // Unix ms of the last complete sync, 0 = never
+	SiteHash   string `json:"site_hash"`   // hash of the copy, as announced by the peer
+	Files      int    `json:"files"`
+	Bytes      int64  `json:"bytes"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// FollowSite starts following peerID's site. Following a followed peer
+// keeps its copy and sync state.
+func (d *DB) FollowSite(peerID string) (SiteFollow, error) {
+	if peerID == "" {
+		return SiteFollow{}, errors.New("peer_id required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.db.Exec(`
+		INSERT INTO _site_follows (peer_id, followed_at) VALUES (?, ?)
+		ON CONFLICT(peer_id) DO NOTHING`,
+		peerID, time.Now().UnixMilli(),
+	); err != nil {
+		return SiteFollow{}, err
+	}
+	return d.siteFollowLocked(peerID)
+}
+
+// UnfollowSite stops following peerID's site. It reports whether the peer
+// was followed.
+func (d *DB) UnfollowSite(peerID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.db.Exec(`DELETE FROM _site_follows WHERE peer_id = ?`, peerID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetSiteFollow returns the follow of peerID, or ok=false if the peer is
+// not followed.
+func (d *DB) GetSiteFollow(peerID string) (SiteFollow, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	f, err := d.siteFollowLocked(peerID)
+	return f, err == nil
+}
+
+func (d *DB) siteFollowLocked(peerID string) (SiteFollow, error) {
+	f := SiteFollow{PeerID: peerID}
+	err := d.db.QueryRow(`
+		SELECT followed_at, synced_at, site_hash, files, bytes, last_error
+		FROM _site_follows WHERE peer_id = ?`, peerID,
+	).Scan(&f.FollowedAt, &f.SyncedAt, &f.SiteHash, &f.Files, &f.Bytes, &f.LastError)
+	return f, err
+}
+
+// ListSiteFollows returns every followed peer, oldest follow first.
+func (d *DB) ListSiteFollows() ([]SiteFollow, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`
+		SELECT peer_id, followed_at, synced_at, site_hash, files, bytes, last_error
+		FROM _site_follows ORDER BY followed_at, peer_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SiteFollow
+	for rows.Next() {
+		var f SiteFollow
+		if err := rows.Scan(&f.PeerID, &f.FollowedAt, &f.SyncedAt, &f.SiteHash, &f.Files, &f.Bytes, &f.LastError); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// SetSiteSynced records a complete sync of peerID's site and clears the
+// last error.
+func (d *DB) SetSiteSynced(peerID, siteHash string, files int, bytes int64, at time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`
+		UPDATE _site_follows SET synced_at = ?, site_hash = ?, files = ?, bytes = ?, last_error = ''
+		WHERE peer_id = ?`,
+		at.UnixMilli(), siteHash, files, bytes, peerID)
+	return err
+}
+
+// SetSiteSyncError records why the last sync of peerID's site failed. The
+// previous copy and its sync time are kept.
+func (d *DB) SetSiteSyncError(peerID, msg string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`UPDATE _site_follows SET last_error = ? WHERE peer_id = ?`, msg, peerID)
+	return err
+}
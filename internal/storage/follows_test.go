@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSiteFollows(t *testing.T) {
+	db := testDB(t)
+
+	if _, ok := db.GetSiteFollow("p1"); ok {
+		t.Fatal("follow found before following")
+	}
+	f, err := db.FollowSite("p1")
+	if err != nil || f.FollowedAt == 0 || f.SyncedAt != 0 {
+		t.Fatalf("FollowSite = %+v, %v", f, err)
+	}
+
+	at := time.UnixMilli(5000)
+	if err := db.SetSiteSynced("p1", "abc", 3, 1200, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSiteSyncError("p1", "peer unreachable"); err != nil {
+		t.Fatal(err)
+	}
+	// Following again keeps the copy's state.
+	f, err = db.FollowSite("p1")
+	if err != nil || f.SyncedAt != 5000 || f.SiteHash != "abc" || f.Files != 3 || f.Bytes != 1200 || f.LastError != "peer unreachable" {
+		t.Fatalf("refollow = %+v, %v", f, err)
+	}
+	if err := db.SetSiteSynced("p1", "def", 4, 1300, at.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := db.GetSiteFollow("p1"); f.LastError != "" || f.SiteHash != "def" {
+		t.Fatalf("sync did not clear the error: %+v", f)
+	}
+
+	db.FollowSite("p2")
+	if list, err := db.ListSiteFollows(); err != nil || len(list) != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	if ok, err := db.UnfollowSite("p1"); !ok || err != nil {
+		t.Fatalf("UnfollowSite = %v, %v", ok, err)
+	}
+	if ok, _ := db.UnfollowSite("p1"); ok {
+		t.Fatal("second unfollow reported a follow")
+	}
+}
+
+func TestStalePeers_KeepsFollowed(t *testing.T) {
+	db := testDB(t)
+	old := time.Now().Add(-60 * 24 * time.Hour).UnixMilli()
+	db.StoreChatMessage("a", "a", "hi", old)
+	db.StoreChatMessage("b", "b", "hi", old)
+	db.FollowSite("b")
+
+	stale, err := db.StalePeers(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil || len(stale) != 1 || stale[0] != "a" {
+		t.Fatalf("stale = %v, %v", stale, err)
+	}
+}
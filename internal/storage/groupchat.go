@@ -0,0 +1,79 @@
+package storage
+
+// GroupChatMessage is one message of a chat room, as the chat group type
+// sends it.
+type GroupChatMessage struct {
+	ID        string `json:"id"`
+	From      string `json:"from"`
+	FromName  string `json:"from_name"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+const groupChatHistoryCap = 1000
+
+// StoreGroupChatMessage persists a chat room message. A message whose ID is
+// already stored for the group is ignored.
+func (d *DB) StoreGroupChatMessage(groupID string, m GroupChatMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	res, err := d.db.Exec(`
+		INSERT OR IGNORE INTO _group_chat_messages (group_id, msg_id, from_id, from_name, text, ts)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		groupID, m.ID, m.From, m.FromName, m.Text, m.Timestamp,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	// FIFO cap: keep only the newest groupChatHistoryCap messages per group.
+	_, err = d.db.Exec(`
+		DELETE FROM _group_chat_messages
+		WHERE group_id = ? AND id NOT IN (
+			SELECT id FROM _group_chat_messages WHERE group_id = ? ORDER BY ts DESC, id DESC LIMIT ?
+		)`, groupID, groupID, groupChatHistoryCap)
+	return err
+}
+
+// GetGroupChatHistory returns the last limit messages of a chat room,
+// oldest first. limit <= 0 returns everything kept.
+func (d *DB) GetGroupChatHistory(groupID string, limit int) ([]GroupChatMessage, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if limit <= 0 || limit > groupChatHistoryCap {
+		limit = groupChatHistoryCap
+	}
+	rows, err := d.db.Query(`
+		SELECT msg_id, from_id, from_name, text, ts FROM (
+			SELECT id, msg_id, from_id, from_name, text, ts FROM _group_chat_messages
+			WHERE group_id = ?
+			ORDER BY ts DESC, id DESC LIMIT ?
+		) ORDER BY ts ASC, id ASC`, groupID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := []GroupChatMessage{}
+	for rows.Next() {
+		var m GroupChatMessage
+		if err := rows.Scan(&m.ID, &m.From, &m.FromName, &m.Text, &m.Timestamp); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// ClearGroupChatHistory deletes the stored messages of a chat room.
+func (d *DB) ClearGroupChatHistory(groupID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _group_chat_messages WHERE group_id = ?`, groupID)
+	return err
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGroupChatHistory(t *testing.T) {
+	db := testDB(t)
+
+	for i, text := range []string{"hi", "hello", "hey"} {
+		m := GroupChatMessage{ID: fmt.Sprintf("m%d", i), From: "p1", FromName: "Alice", Text: text, Timestamp: int64(100 + i)}
+		if err := db.StoreGroupChatMessage("room1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The same message again, say from a backfill, is kept once.
+	if err := db.StoreGroupChatMessage("room1", GroupChatMessage{ID: "m0", From: "p1", Text: "hi", Timestamp: 100}); err != nil {
+		t.Fatal(err)
+	}
+	db.StoreGroupChatMessage("room2", GroupChatMessage{ID: "x", From: "p2", Text: "elsewhere", Timestamp: 1})
+
+	msgs, err := db.GetGroupChatHistory("room1", 0)
+	if err != nil || len(msgs) != 3 || msgs[0].Text != "hi" || msgs[2].Text != "hey" || msgs[0].FromName != "Alice" {
+		t.Fatalf("history = %+v, %v", msgs, err)
+	}
+	if last, _ := db.GetGroupChatHistory("room1", 2); len(last) != 2 || last[0].Text != "hello" {
+		t.Fatalf("last 2 = %+v", last)
+	}
+
+	if err := db.ClearGroupChatHistory("room1"); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, _ := db.GetGroupChatHistory("room1", 0); len(msgs) != 0 {
+		t.Fatalf("after clear = %+v", msgs)
+	}
+	if msgs, _ := db.GetGroupChatHistory("room2", 0); len(msgs) != 1 {
+		t.Fatalf("other room = %+v", msgs)
+	}
+}
+
+func TestGroupChatHistory_capped(t *testing.T) {
+	db := testDB(t)
+	for i := range groupChatHistoryCap + 5 {
+		db.StoreGroupChatMessage("room1", GroupChatMessage{ID: fmt.Sprint(i), From: "p1", Text: "x", Timestamp: int64(i)})
+	}
+	msgs, _ := db.GetGroupChatHistory("room1", 0)
+	if len(msgs) != groupChatHistoryCap || msgs[0].ID != "5" {
+		t.Fatalf("kept %d, oldest %q", len(msgs), msgs[0].ID)
+	}
+}
@@ -16,7 +16,9 @@ type GroupRow struct {
 	MaxMembers   int    `json:"max_members"`
 	DefaultRole  string   `json:"default_role"`
 	Roles        []string `json:"roles,omitempty"`
+	CoHosts      []string `json:"cohosts,omitempty"`
 	Volatile     bool     `json:"volatile"`
+	Ordered      bool     `json:"ordered"`
 	HostJoined   bool   `json:"host_joined"`
 	CreatedAt    string `json:"created_at"`
 }
@@ -31,9 +33,23 @@ type SubscriptionRow struct {
 	MaxMembers   int    `json:"max_members"`
 	Volatile     bool   `json:"volatile"`
 	Role         string `json:"role"`
+	Relays       []string `json:"relays,omitempty"`
 	SubscribedAt string `json:"subscribed_at"`
 }
 
+// RelayedGroup is a group this peer relays as a co-host for its owner.
+type RelayedGroup struct {
+	GroupID      string   `json:"group_id"`
+	Owner        string   `json:"owner"`
+	Name         string   `json:"name"`
+	GroupType    string   `json:"group_type"`
+	GroupContext string   `json:"group_context"`
+	MaxMembers   int      `json:"max_members"`
+	DefaultRole  string   `json:"default_role"`
+	Ordered      bool     `json:"ordered"`
+	Relays       []string `json:"relays"`
+}
+
 // CreateGroup inserts a new group into _groups.
 func (d *DB) CreateGroup(id, name, owner, groupType, groupContext string, maxMembers int, volatile bool) error {
 	d.mu.Lock()
@@ -58,7 +74,7 @@ func (d *DB) ListGroups() ([]GroupRow, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	rows, err := d.db.Query(`SELECT id, name, COALESCE(owner,''), group_type, COALESCE(group_context,''), max_members, COALESCE(default_role,'viewer'), COALESCE(roles,'[]'), COALESCE(volatile,0), host_joined, created_at FROM _groups ORDER BY created_at DESC`)
+	rows, err := d.db.Query(`SELECT id, name, COALESCE(owner,''), group_type, COALESCE(group_context,''), max_members, COALESCE(default_role,'viewer'), COALESCE(roles,'[]'), COALESCE(cohosts,'[]'), COALESCE(volatile,0), COALESCE(ordered,0), host_joined, created_at FROM _groups ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -67,13 +83,15 @@ func (d *DB) ListGroups() ([]GroupRow, error) {
 	var groups []GroupRow
 	for rows.Next() {
 		var g GroupRow
-		var vol int
-		var rolesJSON string
-		if err := rows.Scan(&g.ID, &g.Name, &g.Owner, &g.GroupType, &g.GroupContext, &g.MaxMembers, &g.DefaultRole, &rolesJSON, &vol, &g.HostJoined, &g.CreatedAt); err != nil {
+		var vol, ord int
+		var rolesJSON, cohostsJSON string
+		if err := rows.Scan(&g.ID, &g.Name, &g.Owner, &g.GroupType, &g.GroupContext, &g.MaxMembers, &g.DefaultRole, &rolesJSON, &cohostsJSON, &vol, &ord, &g.HostJoined, &g.CreatedAt); err != nil {
 			return nil, err
 		}
 		g.Volatile = vol != 0
+		g.Ordered = ord != 0
 		_ = json.Unmarshal([]byte(rolesJSON), &g.Roles)
+		_ = json.Unmarshal([]byte(cohostsJSON), &g.CoHosts)
 		groups = append(groups, g)
 	}
 	return groups, rows.Err()
@@ -85,16 +103,18 @@ func (d *DB) GetGroup(id string) (GroupRow, error) {
 	defer d.mu.RUnlock()
 
 	var g GroupRow
-	var vol int
-	var rolesJSON string
+	var vol, ord int
+	var rolesJSON, cohostsJSON string
 	err := d.db.QueryRow(
-		`SELECT id, name, COALESCE(owner,''), group_type, COALESCE(group_context,''), max_members, COALESCE(default_role,'viewer'), COALESCE(roles,'[]'), COALESCE(volatile,0), host_joined, created_at FROM _groups WHERE id = ?`, id,
-	).Scan(&g.ID, &g.Name, &g.Owner, &g.GroupType, &g.GroupContext, &g.MaxMembers, &g.DefaultRole, &rolesJSON, &vol, &g.HostJoined, &g.CreatedAt)
+		`SELECT id, name, COALESCE(owner,''), group_type, COALESCE(group_context,''), max_members, COALESCE(default_role,'viewer'), COALESCE(roles,'[]'), COALESCE(cohosts,'[]'), COALESCE(volatile,0), COALESCE(ordered,0), host_joined, created_at FROM _groups WHERE id = ?`, id,
+	).Scan(&g.ID, &g.Name, &g.Owner, &g.GroupType, &g.GroupContext, &g.MaxMembers, &g.DefaultRole, &rolesJSON, &cohostsJSON, &vol, &ord, &g.HostJoined, &g.CreatedAt)
 	if err != nil {
 		return g, fmt.Errorf("get group: %w", err)
 	}
 	g.Volatile = vol != 0
+	g.Ordered = ord != 0
 	_ = json.Unmarshal([]byte(rolesJSON), &g.Roles)
+	_ = json.Unmarshal([]byte(cohostsJSON), &g.CoHosts)
 	return g, nil
 }
 
@@ -138,6 +158,32 @@ func (d *DB) SetGroupRoles(groupID string, roles []string) error {
 	return err
 }
 
+// SetGroupOrdered turns ordered delivery on or off for a group.
+func (d *DB) SetGroupOrdered(groupID string, ordered bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v := 0
+	if ordered {
+		v = 1
+	}
+	_, err := d.db.Exec(`UPDATE _groups SET ordered = ? WHERE id = ?`, v, groupID)
+	return err
+}
+
+// SetGroupCoHosts updates the peers authorized to relay a group.
+func (d *DB) SetGroupCoHosts(groupID string, cohosts []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, err := json.Marshal(cohosts)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`UPDATE _groups SET cohosts = ? WHERE id = ?`, string(b), groupID)
+	return err
+}
+
 // SetDefaultRole updates the default_role for a group.
 func (d *DB) SetDefaultRole(groupID, role string) error {
 	d.mu.Lock()
@@ -178,8 +224,12 @@ func (d *DB) AddSubscription(hostPeerID, groupID, groupName, groupType string, m
 	if volatile {
 		v = 1
 	}
+	// Upsert rather than replace so the relay list survives metadata updates.
 	_, err := d.db.Exec(
-		`INSERT OR REPLACE INTO _group_subscriptions (host_peer_id, group_id, group_name, group_type, max_members, volatile, role, host_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO _group_subscriptions (host_peer_id, group_id, group_name, group_type, max_members, volatile, role, host_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(host_peer_id, group_id) DO UPDATE SET
+			group_name = excluded.group_name, group_type = excluded.group_type, max_members = excluded.max_members,
+			volatile = excluded.volatile, role = excluded.role, host_name = excluded.host_name, subscribed_at = CURRENT_TIMESTAMP`,
 		hostPeerID, groupID, groupName, groupType, maxMembers, v, role, hostName,
 	)
 	if err != nil {
@@ -188,6 +238,23 @@ func (d *DB) AddSubscription(hostPeerID, groupID, groupName, groupType string, m
 	return nil
 }
 
+// SetSubscriptionRelays records the peers relaying a co-hosted group we
+// subscribe to, so we can reconnect through any of them.
+func (d *DB) SetSubscriptionRelays(hostPeerID, groupID string, relays []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, err := json.Marshal(relays)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(
+		`UPDATE _group_subscriptions SET relays = ? WHERE host_peer_id = ? AND group_id = ?`,
+		string(b), hostPeerID, groupID,
+	)
+	return err
+}
+
 // UpdateSubscriptionHostName updates the host_name for all subscriptions to a given host peer.
 func (d *DB) UpdateSubscriptionHostName(hostPeerID, hostName string) error {
 	d.mu.Lock()
@@ -282,7 +349,7 @@ func (d *DB) ListSubscriptions() ([]SubscriptionRow, error) {
 	defer d.mu.RUnlock()
 
 	rows, err := d.db.Query(
-		`SELECT host_peer_id, group_id, group_name, group_type, COALESCE(max_members,0), COALESCE(volatile,0), role, subscribed_at, COALESCE(host_name,'') FROM _group_subscriptions ORDER BY subscribed_at DESC`,
+		`SELECT host_peer_id, group_id, group_name, group_type, COALESCE(max_members,0), COALESCE(volatile,0), role, subscribed_at, COALESCE(host_name,''), COALESCE(relays,'[]') FROM _group_subscriptions ORDER BY subscribed_at DESC`,
 	)
 	if err != nil {
 		return nil, err
@@ -293,11 +360,156 @@ func (d *DB) ListSubscriptions() ([]SubscriptionRow, error) {
 	for rows.Next() {
 		var s SubscriptionRow
 		var vol int
-		if err := rows.Scan(&s.HostPeerID, &s.GroupID, &s.GroupName, &s.GroupType, &s.MaxMembers, &vol, &s.Role, &s.SubscribedAt, &s.HostName); err != nil {
+		var relaysJSON string
+		if err := rows.Scan(&s.HostPeerID, &s.GroupID, &s.GroupName, &s.GroupType, &s.MaxMembers, &vol, &s.Role, &s.SubscribedAt, &s.HostName, &relaysJSON); err != nil {
 			return nil, err
 		}
 		s.Volatile = vol != 0
+		_ = json.Unmarshal([]byte(relaysJSON), &s.Relays)
 		subs = append(subs, s)
 	}
 	return subs, rows.Err()
 }
+
+// SaveRelayedGroup stores or replaces a group we relay as a co-host.
+func (d *DB) SaveRelayedGroup(g RelayedGroup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, err := json.Marshal(g.Relays)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(
+		`INSERT OR REPLACE INTO _relayed_groups (group_id, owner, name, group_type, group_context, max_members, default_role, ordered, relays) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		g.GroupID, g.Owner, g.Name, g.GroupType, g.GroupContext, g.MaxMembers, g.DefaultRole, g.Ordered, string(b),
+	)
+	if err != nil {
+		return fmt.Errorf("save relayed group: %w", err)
+	}
+	return nil
+}
+
+// ListRelayedGroups returns every group we relay as a co-host.
+func (d *DB) ListRelayedGroups() ([]RelayedGroup, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`SELECT group_id, owner, COALESCE(name,''), COALESCE(group_type,''), COALESCE(group_context,''), COALESCE(max_members,0), COALESCE(default_role,'viewer'), COALESCE(ordered,0), COALESCE(relays,'[]') FROM _relayed_groups`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RelayedGroup
+	for rows.Next() {
+		var g RelayedGroup
+		var ord int
+		var relaysJSON string
+		if err := rows.Scan(&g.GroupID, &g.Owner, &g.Name, &g.GroupType, &g.GroupContext, &g.MaxMembers, &g.DefaultRole, &ord, &relaysJSON); err != nil {
+			return nil, err
+		}
+		g.Ordered = ord != 0
+		_ = json.Unmarshal([]byte(relaysJSON), &g.Relays)
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// DeleteRelayedGroup stops remembering a co-hosted group.
+func (d *DB) DeleteRelayedGroup(groupID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _relayed_groups WHERE group_id = ?`, groupID)
+	return err
+}
+
+// OwnershipRecord is one change of a group's owner.
+type OwnershipRecord struct {
+	GroupID string `json:"group_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Reason  string `json:"reason"` // "transfer" or "election"
+	At      int64  `json:"at"`     // unix millis
+}
+
+// RecordOwnership stores a change of a group's owner.
+func (d *DB) RecordOwnership(r OwnershipRecord) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(
+		`INSERT INTO _group_ownership (group_id, from_peer, to_peer, reason, at) VALUES (?, ?, ?, ?, ?)`,
+		r.GroupID, r.From, r.To, r.Reason, r.At,
+	)
+	if err != nil {
+		return fmt.Errorf("record ownership: %w", err)
+	}
+	return nil
+}
+
+// OwnershipHistory returns the owner changes of a group, latest first.
+func (d *DB) OwnershipHistory(groupID string) ([]OwnershipRecord, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`SELECT group_id, from_peer, to_peer, COALESCE(reason,''), at FROM _group_ownership WHERE group_id = ? ORDER BY id DESC`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OwnershipRecord
+	for rows.Next() {
+		var r OwnershipRecord
+		if err := rows.Scan(&r.GroupID, &r.From, &r.To, &r.Reason, &r.At); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GroupStateRepair counts the rows RepairGroupState removed.
+type GroupStateRepair struct {
+	Subscriptions int `json:"subscriptions"` // to groups we host, or to ourselves
+	Members       int `json:"members"`       // of groups we neither host, relay nor subscribe to
+}
+
+// RepairGroupState removes group rows an interrupted run can leave behind:
+// a subscription to a group we took over (the group row is written before
+// the subscription is removed) or whose host is selfID, and stored member
+// lists of groups that have no group, relay or subscription row left.
+func (d *DB) RepairGroupState(selfID string) (GroupStateRepair, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var rep GroupStateRepair
+	tx, err := d.db.Begin()
+	if err != nil {
+		return rep, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`DELETE FROM _group_subscriptions WHERE host_peer_id = ? OR group_id IN (SELECT id FROM _groups)`,
+		selfID,
+	)
+	if err != nil {
+		return rep, fmt.Errorf("repair subscriptions: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	rep.Subscriptions = int(n)
+
+	res, err = tx.Exec(`DELETE FROM _group_members
+		WHERE group_id NOT IN (SELECT id FROM _groups)
+		AND group_id NOT IN (SELECT group_id FROM _relayed_groups)
+		AND group_id NOT IN (SELECT group_id FROM _group_subscriptions)`)
+	if err != nil {
+		return rep, fmt.Errorf("repair group members: %w", err)
+	}
+	n, _ = res.RowsAffected()
+	rep.Members = int(n)
+
+	return rep, tx.Commit()
+}
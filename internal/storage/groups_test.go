@@ -140,6 +140,36 @@ func TestSetGroupRoles(t *testing.T) {
 	}
 }
 
+func TestSetGroupCoHosts(t *testing.T) {
+	db := testDB(t)
+
+	db.CreateGroup("g1", "Test", "o", "files", "", 0, false)
+	if err := db.SetGroupCoHosts("g1", []string{"relay1", "relay2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, _ := db.GetGroup("g1")
+	if len(g.CoHosts) != 2 || g.CoHosts[1] != "relay2" {
+		t.Fatalf("cohosts = %v", g.CoHosts)
+	}
+}
+
+func TestSetGroupOrdered(t *testing.T) {
+	db := testDB(t)
+
+	db.CreateGroup("g1", "Test", "o", "chat", "", 0, false)
+	if g, _ := db.GetGroup("g1"); g.Ordered {
+		t.Fatal("new group is ordered")
+	}
+	if err := db.SetGroupOrdered("g1", true); err != nil {
+		t.Fatal(err)
+	}
+	groups, _ := db.ListGroups()
+	if len(groups) != 1 || !groups[0].Ordered {
+		t.Fatalf("groups = %+v, want g1 ordered", groups)
+	}
+}
+
 func TestSetDefaultRole(t *testing.T) {
 	db := testDB(t)
 
@@ -306,6 +336,60 @@ func TestAddAndListSubscriptions(t *testing.T) {
 	}
 }
 
+func TestSubscriptionRelaysSurviveUpdate(t *testing.T) {
+	db := testDB(t)
+
+	db.AddSubscription("host1", "g1", "Group 1", "files", 0, false, "member", "")
+	if err := db.SetSubscriptionRelays("host1", "g1", []string{"host1", "relay1"}); err != nil {
+		t.Fatal(err)
+	}
+	db.AddSubscription("host1", "g1", "Renamed", "files", 0, false, "member", "")
+
+	subs, _ := db.ListSubscriptions()
+	if len(subs) != 1 || subs[0].GroupName != "Renamed" || len(subs[0].Relays) != 2 {
+		t.Fatalf("subs = %+v", subs)
+	}
+}
+
+func TestRelayedGroups(t *testing.T) {
+	db := testDB(t)
+
+	g := RelayedGroup{GroupID: "g1", Owner: "o", Name: "Test", GroupType: "files", DefaultRole: "viewer", Relays: []string{"o", "relay2"}}
+	if err := db.SaveRelayedGroup(g); err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.ListRelayedGroups()
+	if err != nil || len(list) != 1 || list[0].Owner != "o" || len(list[0].Relays) != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if err := db.DeleteRelayedGroup("g1"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := db.ListRelayedGroups(); len(list) != 0 {
+		t.Fatalf("after delete = %+v", list)
+	}
+}
+
+func TestOwnershipHistory(t *testing.T) {
+	db := testDB(t)
+
+	_ = db.RecordOwnership(OwnershipRecord{GroupID: "g1", From: "a", To: "b", Reason: "transfer", At: 1})
+	_ = db.RecordOwnership(OwnershipRecord{GroupID: "g2", From: "x", To: "y", Reason: "transfer", At: 2})
+	if err := db.RecordOwnership(OwnershipRecord{GroupID: "g1", From: "b", To: "c", Reason: "election", At: 3}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.OwnershipHistory("g1")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("history = %+v, %v", list, err)
+	}
+	if list[0].To != "c" || list[0].Reason != "election" || list[1].From != "a" {
+		t.Fatalf("history not latest first: %+v", list)
+	}
+	if list, _ := db.OwnershipHistory("none"); len(list) != 0 {
+		t.Fatalf("unknown group = %+v", list)
+	}
+}
+
 func TestRemoveSubscription(t *testing.T) {
 	db := testDB(t)
 
@@ -352,3 +436,42 @@ func TestAddSubscriptionUpsert(t *testing.T) {
 		t.Fatalf("group_name = %q, want 'New Name'", subs[0].GroupName)
 	}
 }
+
+func TestRepairGroupState(t *testing.T) {
+	db := testDB(t)
+
+	// Taken over: the group row exists, the old subscription was not removed.
+	db.CreateGroup("taken", "Taken", "self", "files", "", 0, false)
+	db.AddSubscription("oldowner", "taken", "Taken", "files", 0, false, "member", "")
+	db.AddSubscription("self", "mine", "Mine", "files", 0, false, "member", "")
+	db.AddSubscription("host", "joined", "Joined", "files", 0, false, "member", "")
+	db.SaveRelayedGroup(RelayedGroup{GroupID: "relayed", Owner: "owner"})
+
+	for _, g := range []string{"taken", "joined", "relayed", "gone"} {
+		db.UpsertGroupMembers(g, []GroupMember{{PeerID: "p1"}, {PeerID: "p2"}})
+	}
+
+	rep, err := db.RepairGroupState("self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Subscriptions != 2 || rep.Members != 2 {
+		t.Fatalf("repair = %+v, want 2 subscriptions and 2 members", rep)
+	}
+	subs, _ := db.ListSubscriptions()
+	if len(subs) != 1 || subs[0].GroupID != "joined" {
+		t.Fatalf("subscriptions = %+v", subs)
+	}
+	for _, g := range []string{"taken", "joined", "relayed"} {
+		if m, _ := db.ListGroupMembers(g); len(m) != 2 {
+			t.Errorf("members of %s = %v", g, m)
+		}
+	}
+	if m, _ := db.ListGroupMembers("gone"); len(m) != 0 {
+		t.Errorf("members of gone = %v", m)
+	}
+
+	if rep, _ := db.RepairGroupState("self"); rep != (GroupStateRepair{}) {
+		t.Errorf("second repair = %+v", rep)
+	}
+}
@@ -260,6 +260,23 @@ func TestClearChatHistory(t *testing.T) {
 	}
 }
 
+func TestStoreCallChatMessage(t *testing.T) {
+	db := testDB(t)
+
+	db.StoreChatMessage("peer2", "me", "before the call", 1000)
+	if err := db.StoreCallChatMessage("peer2", "peer2", "https://example.com", "ch1", 2000); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := db.GetChatHistory("peer2", 50)
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].CallID != "" || msgs[1].CallID != "ch1" {
+		t.Fatalf("call IDs = %q, %q; want \"\", ch1", msgs[0].CallID, msgs[1].CallID)
+	}
+}
+
 func TestChatMessageFIFOCap(t *testing.T) {
 	db := testDB(t)
 
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// PeerNote is a private markdown note about another peer. It stays local.
+type PeerNote struct {
+	PeerID    string `json:"peer_id"`
+	Body      string `json:"body"`
+	CreatedAt int64  `json:"created_at"` // Unix ms
+	UpdatedAt int64  `json:"updated_at"` // Unix ms
+}
+
+// GetPeerNote returns the note for peerID, or ok=false if there is none.
+func (d *DB) GetPeerNote(peerID string) (PeerNote, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	n := PeerNote{PeerID: peerID}
+	err := d.db.QueryRow(`
+		SELECT body, created_at, updated_at FROM _peer_notes WHERE peer_id = ?`,
+		peerID,
+	).Scan(&n.Body, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return PeerNote{}, false
+	}
+	return n, true
+}
+
+// SetPeerNote stores the note for peerID, keeping its creation time. An
+// empty (or whitespace-only) body deletes the note and returns a zero
+// PeerNote.
+func (d *DB) SetPeerNote(peerID, body string) (PeerNote, error) {
+	if peerID == "" {
+		return PeerNote{}, errors.New("peer_id required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if strings.TrimSpace(body) == "" {
+		_, err := d.db.Exec(`DELETE FROM _peer_notes WHERE peer_id = ?`, peerID)
+		return PeerNote{}, err
+	}
+	now := time.Now().UnixMilli()
+	n := PeerNote{PeerID: peerID, Body: body}
+	err := d.db.QueryRow(`
+		INSERT INTO _peer_notes (peer_id, body, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at
+		RETURNING created_at, updated_at`,
+		peerID, body, now, now,
+	).Scan(&n.CreatedAt, &n.UpdatedAt)
+	return n, err
+}
+
+// ListPeerNotes returns every note, most recently edited first.
+func (d *DB) ListPeerNotes() ([]PeerNote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`SELECT peer_id, body, created_at, updated_at FROM _peer_notes ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PeerNote
+	for rows.Next() {
+		var n PeerNote
+		if err := rows.Scan(&n.PeerID, &n.Body, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
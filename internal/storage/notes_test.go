@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeerNoteRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	if _, ok := db.GetPeerNote("p1"); ok {
+		t.Fatal("note found before one was set")
+	}
+	first, err := db.SetPeerNote("p1", "met at the meetup")
+	if err != nil || first.CreatedAt == 0 {
+		t.Fatalf("SetPeerNote = %+v, %v", first, err)
+	}
+	db.Exec(`UPDATE _peer_notes SET created_at = 1, updated_at = 1 WHERE peer_id = 'p1'`)
+
+	second, err := db.SetPeerNote("p1", "hosts the jazz station")
+	if err != nil || second.CreatedAt != 1 || second.UpdatedAt <= 1 {
+		t.Fatalf("update = %+v, %v", second, err)
+	}
+	got, ok := db.GetPeerNote("p1")
+	if !ok || got.Body != "hosts the jazz station" {
+		t.Fatalf("GetPeerNote = %+v, %v", got, ok)
+	}
+	if list, err := db.ListPeerNotes(); err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	if _, err := db.SetPeerNote("p1", "  \n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.GetPeerNote("p1"); ok {
+		t.Fatal("blank note not deleted")
+	}
+}
+
+func TestStalePeers_KeepsNoted(t *testing.T) {
+	db := testDB(t)
+	old := time.Now().Add(-60 * 24 * time.Hour).UnixMilli()
+	db.StoreChatMessage("a", "a", "hi", old)
+	db.StoreChatMessage("b", "b", "hi", old)
+	db.SetPeerNote("b", "keep")
+
+	stale, err := db.StalePeers(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil || len(stale) != 1 || stale[0] != "a" {
+		t.Fatalf("stale = %v, %v", stale, err)
+	}
+	if _, err := db.ForgetPeer("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.GetPeerNote("b"); ok {
+		t.Fatal("ForgetPeer kept the note")
+	}
+}
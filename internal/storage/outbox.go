@@ -0,0 +1,143 @@
+package storage
+
+import "encoding/json"
+
+// OutboxEntry is a message waiting in the store-and-forward outbox.
+type OutboxEntry struct {
+	ID        int64           `json:"id"`
+	PeerID    string          `json:"peer_id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"` // Unix ms
+	ExpiresAt int64           `json:"expires_at"` // Unix ms
+	Attempts  int             `json:"attempts"`
+	NextTry   int64           `json:"next_try"` // Unix ms, 0 = as soon as possible
+	LastError string          `json:"last_error"`
+}
+
+// outboxCap bounds the outbox; the oldest messages are dropped beyond it.
+const outboxCap = 5000
+
+// EnqueueOutbox stores a message and returns its ID.
+func (d *DB) EnqueueOutbox(e OutboxEntry) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(e.Payload) == 0 {
+		e.Payload = json.RawMessage("null")
+	}
+	res, err := d.db.Exec(`
+		INSERT INTO _mq_outbox (peer_id, topic, payload, created_at, expires_at, next_try, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.PeerID, e.Topic, string(e.Payload), e.CreatedAt, e.ExpiresAt, e.NextTry, e.LastError,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	_, err = d.db.Exec(`DELETE FROM _mq_outbox WHERE id <= ? - ?`, id, outboxCap)
+	return id, err
+}
+
+// OutboxEntries returns queued messages oldest first, optionally for a
+// single peer.
+func (d *DB) OutboxEntries(peerID string) ([]OutboxEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	q := `SELECT id, peer_id, topic, payload, created_at, expires_at, attempts, next_try, last_error FROM _mq_outbox`
+	args := []any{}
+	if peerID != "" {
+		q += ` WHERE peer_id = ?`
+		args = append(args, peerID)
+	}
+	rows, err := d.db.Query(q+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []OutboxEntry{}
+	for rows.Next() {
+		var e OutboxEntry
+		var payload string
+		if err := rows.Scan(&e.ID, &e.PeerID, &e.Topic, &payload, &e.CreatedAt, &e.ExpiresAt, &e.Attempts, &e.NextTry, &e.LastError); err != nil {
+			return nil, err
+		}
+		e.Payload = json.RawMessage(payload)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// OutboxPeers returns the peers with queued messages.
+func (d *DB) OutboxPeers() ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`SELECT DISTINCT peer_id FROM _mq_outbox`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	peers := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		peers = append(peers, p)
+	}
+	return peers, rows.Err()
+}
+
+// RecordOutboxFailure counts a failed delivery attempt and sets the time of
+// the next one.
+func (d *DB) RecordOutboxFailure(id, nextTry int64, lastErr string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`UPDATE _mq_outbox SET attempts = attempts + 1, next_try = ?, last_error = ? WHERE id = ?`, nextTry, lastErr, id)
+	return err
+}
+
+// DeleteOutbox removes a delivered message.
+func (d *DB) DeleteOutbox(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _mq_outbox WHERE id = ?`, id)
+	return err
+}
+
+// PurgeOutbox removes queued messages, for one peer or, with peerID "",
+// all of them. Returns the number removed.
+func (d *DB) PurgeOutbox(peerID string) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q, args := `DELETE FROM _mq_outbox`, []any{}
+	if peerID != "" {
+		q += ` WHERE peer_id = ?`
+		args = append(args, peerID)
+	}
+	res, err := d.db.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ExpireOutbox removes messages whose TTL ran out before now (Unix ms).
+func (d *DB) ExpireOutbox(now int64) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	res, err := d.db.Exec(`DELETE FROM _mq_outbox WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
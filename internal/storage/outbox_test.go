@@ -0,0 +1,49 @@
+package storage
+
+import "testing"
+
+func TestOutboxQueueAndExpire(t *testing.T) {
+	db := testDB(t)
+
+	for i, e := range []OutboxEntry{
+		{PeerID: "alice", Topic: "chat", Payload: []byte(`{"content":"hi"}`), CreatedAt: 1000, ExpiresAt: 5000},
+		{PeerID: "bob", Topic: "chat", CreatedAt: 1100, ExpiresAt: 2000},
+		{PeerID: "alice", Topic: "chat", Payload: []byte(`{"content":"still there?"}`), CreatedAt: 1200, ExpiresAt: 5000},
+	} {
+		if id, err := db.EnqueueOutbox(e); err != nil || id != int64(i+1) {
+			t.Fatalf("enqueue %d: id %d, %v", i, id, err)
+		}
+	}
+
+	alice, err := db.OutboxEntries("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alice) != 2 || alice[0].ID != 1 || string(alice[1].Payload) != `{"content":"still there?"}` {
+		t.Fatalf("alice's entries = %+v", alice)
+	}
+	if all, _ := db.OutboxEntries(""); len(all) != 3 || string(all[1].Payload) != "null" {
+		t.Fatalf("all entries = %+v", all)
+	}
+	if peers, _ := db.OutboxPeers(); len(peers) != 2 {
+		t.Errorf("peers = %v", peers)
+	}
+
+	if err := db.RecordOutboxFailure(1, 9000, "unreachable"); err != nil {
+		t.Fatal(err)
+	}
+	alice, _ = db.OutboxEntries("alice")
+	if alice[0].Attempts != 1 || alice[0].NextTry != 9000 || alice[0].LastError != "unreachable" {
+		t.Errorf("after failure = %+v", alice[0])
+	}
+
+	if n, _ := db.ExpireOutbox(2000); n != 1 {
+		t.Errorf("expired %d, want bob's message", n)
+	}
+	if err := db.DeleteOutbox(1); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.PurgeOutbox(""); n != 1 {
+		t.Errorf("purged %d, want 1", n)
+	}
+}
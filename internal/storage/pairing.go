@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// PairedDevice is a remote-control session (typically a phone) paired with
+// this peer. The bearer token itself is never stored, only its hash.
+type PairedDevice struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt int64    `json:"created_at"` // Unix ms
+	LastSeen  int64    `json:"last_seen"`  // Unix ms, 0 if never used
+}
+
+// AddPairedDevice stores a newly paired device under tokenHash.
+func (d *DB) AddPairedDevice(dev PairedDevice, tokenHash string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`
+		INSERT INTO _paired_devices (id, name, token_hash, scopes, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		dev.ID, dev.Name, tokenHash, strings.Join(dev.Scopes, ","), dev.CreatedAt,
+	)
+	return err
+}
+
+// PairedDeviceByToken returns the device for tokenHash, or ok=false.
+func (d *DB) PairedDeviceByToken(tokenHash string) (PairedDevice, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var dev PairedDevice
+	var scopes string
+	err := d.db.QueryRow(`
+		SELECT id, name, scopes, created_at, last_seen FROM _paired_devices WHERE token_hash = ?`,
+		tokenHash,
+	).Scan(&dev.ID, &dev.Name, &scopes, &dev.CreatedAt, &dev.LastSeen)
+	if err != nil {
+		return PairedDevice{}, false
+	}
+	dev.Scopes = splitScopes(scopes)
+	return dev, true
+}
+
+// TouchPairedDevice records that the device was just used.
+func (d *DB) TouchPairedDevice(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`UPDATE _paired_devices SET last_seen = ? WHERE id = ?`, time.Now().UnixMilli(), id)
+	return err
+}
+
+// DeletePairedDevice unpairs a device; its token stops working at once.
+// Returns sql.ErrNoRows if there was no such device.
+func (d *DB) DeletePairedDevice(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.db.Exec(`DELETE FROM _paired_devices WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListPairedDevices returns all paired devices, most recently paired first.
+func (d *DB) ListPairedDevices() ([]PairedDevice, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`SELECT id, name, scopes, created_at, last_seen FROM _paired_devices ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []PairedDevice{}
+	for rows.Next() {
+		var dev PairedDevice
+		var scopes string
+		if err := rows.Scan(&dev.ID, &dev.Name, &scopes, &dev.CreatedAt, &dev.LastSeen); err != nil {
+			return nil, err
+		}
+		dev.Scopes = splitScopes(scopes)
+		out = append(out, dev)
+	}
+	return out, rows.Err()
+}
+
+func splitScopes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
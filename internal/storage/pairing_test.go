@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPairedDeviceRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	dev := PairedDevice{ID: "dev1", Name: "Phone", Scopes: []string{"notify", "call"}, CreatedAt: 1000}
+	if err := db.AddPairedDevice(dev, "hash1"); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := db.PairedDeviceByToken("hash1")
+	if !ok || got.Name != "Phone" || len(got.Scopes) != 2 || got.Scopes[1] != "call" {
+		t.Fatalf("PairedDeviceByToken = %+v, %v", got, ok)
+	}
+	if _, ok := db.PairedDeviceByToken("other"); ok {
+		t.Fatal("unknown token matched a device")
+	}
+
+	if err := db.TouchPairedDevice("dev1"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.ListPairedDevices()
+	if err != nil || len(list) != 1 || list[0].LastSeen == 0 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+
+	if err := db.DeletePairedDevice("dev1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.PairedDeviceByToken("hash1"); ok {
+		t.Fatal("token still valid after unpairing")
+	}
+	if err := db.DeletePairedDevice("dev1"); err != sql.ErrNoRows {
+		t.Fatalf("second delete err = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 	"time"
 )
@@ -261,6 +262,26 @@ func (d *DB) DeleteCachedPeer(peerID string) error {
 	return err
 }
 
+// TrimPeerCache keeps the keep most recently seen non-favorites in
+// _peer_cache and deletes the rest, returning how many rows went. Favorites
+// are never trimmed.
+func (d *DB) TrimPeerCache(keep int) (int64, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.db.Exec(`
+		DELETE FROM _peer_cache WHERE peer_id IN (
+			SELECT peer_id FROM _peer_cache
+			WHERE peer_id NOT IN (SELECT peer_id FROM _favorites)
+			ORDER BY last_seen DESC LIMIT -1 OFFSET ?)`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // SetFavorite marks a peer as favorite (or unfavorite).
 // When favoriting, copies full peer metadata from _peer_cache to _favorites so data is preserved if peer goes offline.
 // When unfavoriting, removes from _favorites.
@@ -291,3 +312,72 @@ func (d *DB) SetFavorite(peerID string, favorite bool) error {
 	}
 }
 
+
+// ForgetPeer deletes everything stored about a peer — cached presence,
+// favorite, consent decision, chat and call history, audit entries — in one
+// transaction, and returns the rows removed per table. Group membership is
+// left to the group manager, which has to tell the host.
+func (d *DB) ForgetPeer(peerID string) (map[string]int64, error) {
+	if peerID == "" {
+		return nil, fmt.Errorf("peer required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deleted := make(map[string]int64, len(peerColumns))
+	for table, col := range peerColumns {
+		if table == "_group_members" || table == "_group_subscriptions" {
+			continue
+		}
+		res, err := tx.Exec("DELETE FROM "+table+" WHERE "+col+" = ?", peerID)
+		if err != nil {
+			return nil, fmt.Errorf("forget %s: %w", table, err)
+		}
+		deleted[table], _ = res.RowsAffected()
+	}
+	return deleted, tx.Commit()
+}
+
+// StalePeers returns non-favorite, unfollowed peers without a note or report whose last contact
+// of any kind (presence, chat, call, inbound stream, consent prompt) is
+// before cutoff.
+func (d *DB) StalePeers(cutoff time.Time) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT peer_id FROM (
+			SELECT peer_id, CAST(strftime('%s', last_seen) AS INTEGER) * 1000 AS t FROM _peer_cache
+			UNION ALL SELECT peer_id, ts         FROM _chat_messages
+			UNION ALL SELECT peer_id, started_at FROM _call_log
+			UNION ALL SELECT peer_id, ts         FROM _audit_log
+			UNION ALL SELECT peer_id, updated_at FROM _access_consent
+		)
+		WHERE peer_id NOT IN (SELECT peer_id FROM _favorites)
+		  AND peer_id NOT IN (SELECT peer_id FROM _peer_notes)
+		  AND peer_id NOT IN (SELECT peer_id FROM _peer_reports)
+		  AND peer_id NOT IN (SELECT peer_id FROM _site_follows)
+		GROUP BY peer_id
+		HAVING MAX(t) < ?
+		ORDER BY peer_id`, cutoff.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
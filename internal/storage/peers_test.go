@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestUpsertAndGetCachedPeer(t *testing.T) {
@@ -108,6 +110,28 @@ func TestDeleteCachedPeer(t *testing.T) {
 	}
 }
 
+func TestTrimPeerCache(t *testing.T) {
+	db := testDB(t)
+	for i, id := range []string{"old", "fav", "mid", "new"} {
+		db.UpsertCachedPeer(CachedPeer{PeerID: id, Content: id})
+		db.db.Exec(`UPDATE _peer_cache SET last_seen = datetime('now', ?) WHERE peer_id = ?`, fmt.Sprintf("-%d minutes", 10-i), id)
+	}
+	db.SetFavorite("fav", true)
+
+	n, err := db.TrimPeerCache(2)
+	if err != nil || n != 1 {
+		t.Fatalf("trimmed %d, %v", n, err)
+	}
+	if _, ok := db.GetCachedPeer("old"); ok {
+		t.Fatal("oldest non-favorite should be trimmed")
+	}
+	for _, id := range []string{"fav", "mid", "new"} {
+		if _, ok := db.GetCachedPeer(id); !ok {
+			t.Fatalf("%s should be kept", id)
+		}
+	}
+}
+
 func TestGetPeerName(t *testing.T) {
 	db := testDB(t)
 
@@ -276,3 +300,53 @@ func TestUpsertCachedPeerMirrorsFavoriteMetadata(t *testing.T) {
 		t.Fatalf("favorites email = %q, want 'new@example.com'", got.Email)
 	}
 }
+
+func TestForgetPeer(t *testing.T) {
+	db := testDB(t)
+
+	db.UpsertCachedPeer(CachedPeer{PeerID: "p1", Content: "Alice"})
+	db.SetFavorite("p1", true)
+	db.SetAccessConsent("p1", "always")
+	db.StoreChatMessage("p1", "p1", "hi", 1000)
+	db.SaveCallLog(CallLogEntry{ChannelID: "c1", PeerID: "p1", Direction: CallIncoming, Outcome: CallMissed, StartedAt: 1000})
+	db.StoreChatMessage("p2", "p2", "yo", 1000)
+
+	deleted, err := db.ForgetPeer("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted["_chat_messages"] != 1 || deleted["_call_log"] != 1 || deleted["_favorites"] != 1 {
+		t.Fatalf("deleted = %v", deleted)
+	}
+	if _, ok := db.GetCachedPeer("p1"); ok {
+		t.Fatal("p1 should be gone from cache and favorites")
+	}
+	if db.GetAccessConsent("p1") != "" {
+		t.Fatal("consent should be forgotten")
+	}
+	if n, _ := db.CountRows("_chat_messages"); n != 1 {
+		t.Fatalf("p2's chat should remain, rows = %d", n)
+	}
+}
+
+func TestStalePeers(t *testing.T) {
+	db := testDB(t)
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour).UnixMilli()
+
+	db.StoreChatMessage("old", "old", "hi", old)
+	db.StoreChatMessage("recent", "recent", "hi", old)
+	db.StoreChatMessage("recent", "recent", "again", now.UnixMilli())
+	db.StoreChatMessage("fav", "fav", "hi", old)
+	db.UpsertCachedPeer(CachedPeer{PeerID: "fav"})
+	db.SetFavorite("fav", true)
+	db.Exec(`UPDATE _peer_cache SET last_seen = datetime('now', '-60 days') WHERE peer_id = 'fav'`)
+
+	stale, err := db.StalePeers(now.Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0] != "old" {
+		t.Fatalf("stale = %v, want [old]", stale)
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Reasons an abuse report can give.
+var ReportReasons = []string{"spam", "harassment", "impersonation", "illegal", "other"}
+
+// PeerReport is an abuse report I filed about another peer. It stays local
+// until it goes out in a reputation record.
+type PeerReport struct {
+	ID        int64  `json:"id"`
+	PeerID    string `json:"peer_id"`
+	Reason    string `json:"reason"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt int64  `json:"created_at"` // Unix ms
+}
+
+// FilePeerReport records an abuse report about peerID.
+func (d *DB) FilePeerReport(peerID, reason, note string) (PeerReport, error) {
+	if peerID == "" {
+		return PeerReport{}, errors.New("peer_id required")
+	}
+	if !slices.Contains(ReportReasons, reason) {
+		return PeerReport{}, errors.New("unknown report reason")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r := PeerReport{PeerID: peerID, Reason: reason, Note: note, CreatedAt: time.Now().UnixMilli()}
+	res, err := d.db.Exec(`INSERT INTO _peer_reports (peer_id, reason, note, created_at) VALUES (?, ?, ?, ?)`,
+		r.PeerID, r.Reason, r.Note, r.CreatedAt)
+	if err != nil {
+		return PeerReport{}, err
+	}
+	r.ID, err = res.LastInsertId()
+	return r, err
+}
+
+// ListPeerReports returns my reports about peerID, or about everyone when
+// peerID is "", newest first.
+func (d *DB) ListPeerReports(peerID string) ([]PeerReport, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`
+		SELECT id, peer_id, reason, note, created_at FROM _peer_reports
+		WHERE ? = '' OR peer_id = ?
+		ORDER BY created_at DESC, id DESC`, peerID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []PeerReport{}
+	for rows.Next() {
+		var r PeerReport
+		if err := rows.Scan(&r.ID, &r.PeerID, &r.Reason, &r.Note, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// DeletePeerReport withdraws a report. It reports whether one was deleted.
+func (d *DB) DeletePeerReport(id int64) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.db.Exec(`DELETE FROM _peer_reports WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
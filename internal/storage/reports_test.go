@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeerReports(t *testing.T) {
+	db := testDB(t)
+
+	if _, err := db.FilePeerReport("p1", "rude", ""); err == nil {
+		t.Fatal("unknown reason accepted")
+	}
+	first, err := db.FilePeerReport("p1", "spam", "floods the chat room")
+	if err != nil || first.ID == 0 {
+		t.Fatalf("FilePeerReport = %+v, %v", first, err)
+	}
+	db.FilePeerReport("p2", "harassment", "")
+	db.FilePeerReport("p1", "impersonation", "")
+
+	if list, _ := db.ListPeerReports("p1"); len(list) != 2 || list[0].Reason != "impersonation" {
+		t.Fatalf("reports about p1 = %+v", list)
+	}
+	if list, _ := db.ListPeerReports(""); len(list) != 3 {
+		t.Fatalf("all reports = %+v", list)
+	}
+	if ok, _ := db.DeletePeerReport(first.ID); !ok {
+		t.Fatal("report not deleted")
+	}
+	if ok, _ := db.DeletePeerReport(first.ID); ok {
+		t.Fatal("report deleted twice")
+	}
+
+	// A reported peer is kept through retention, and forgotten with its reports.
+	old := time.Now().Add(-60 * 24 * time.Hour).UnixMilli()
+	db.StoreChatMessage("p2", "p2", "hi", old)
+	if stale, _ := db.StalePeers(time.Now().Add(-30 * 24 * time.Hour)); len(stale) != 0 {
+		t.Fatalf("stale = %v", stale)
+	}
+	db.ForgetPeer("p2")
+	if list, _ := db.ListPeerReports("p2"); len(list) != 0 {
+		t.Fatalf("ForgetPeer kept %d reports", len(list))
+	}
+}
+
+func TestPeerAuditStats(t *testing.T) {
+	db := testDB(t)
+	for _, e := range []AuditEntry{
+		{Timestamp: 60_000, Protocol: "/goop/mq/1.0.0", PeerID: "bob", Outcome: "allowed"},
+		{Timestamp: 61_000, Protocol: "/goop/mq/1.0.0", PeerID: "bob", Outcome: "allowed"},
+		{Timestamp: 62_000, Protocol: "/goop/mq/1.0.0", PeerID: "bob", Outcome: "allowed"},
+		{Timestamp: 130_000, Protocol: "/goop/mq/1.0.0", PeerID: "bob", Outcome: "allowed"},
+		{Timestamp: 131_000, Protocol: "/goop/docs/1.0.0", PeerID: "bob", Outcome: "denied"},
+		{Timestamp: 132_000, Protocol: "/goop/data/1.0.0", PeerID: "bob", Outcome: "refused"},
+		{Timestamp: 133_000, Protocol: "/goop/mq/1.0.0", PeerID: "alice", Outcome: "allowed"},
+	} {
+		db.InsertAuditEntry(e)
+	}
+
+	all, err := db.PeerAuditStats("bob", "", 0)
+	if err != nil || all != (AuditStats{Streams: 6, Denied: 1, Refused: 1, PeakPerMinute: 3}) {
+		t.Fatalf("all = %+v, %v", all, err)
+	}
+	if mq, _ := db.PeerAuditStats("bob", "/goop/mq/1.0.0", 62_000); mq != (AuditStats{Streams: 2, PeakPerMinute: 1}) {
+		t.Fatalf("mq since 62s = %+v", mq)
+	}
+	if none, _ := db.PeerAuditStats("carol", "", 0); none != (AuditStats{}) {
+		t.Fatalf("unknown peer = %+v", none)
+	}
+}
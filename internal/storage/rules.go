@@ -0,0 +1,77 @@
+package storage
+
+import "database/sql"
+
+// RuleRow is a stored automation rule. Trigger and Action are JSON documents
+// interpreted by the rules package.
+type RuleRow struct {
+	ID        string
+	Name      string
+	Enabled   bool
+	Trigger   string
+	Action    string
+	CreatedAt int64 // Unix ms
+	LastRun   int64 // Unix ms, 0 if never fired
+	LastError string
+}
+
+// SaveRule inserts or replaces a rule. Run bookkeeping (last_run, last_error)
+// is kept across updates.
+func (d *DB) SaveRule(r RuleRow) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`
+		INSERT INTO _rules (id, name, enabled, trigger, action, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name, enabled = excluded.enabled,
+			trigger = excluded.trigger, action = excluded.action`,
+		r.ID, r.Name, r.Enabled, r.Trigger, r.Action, r.CreatedAt,
+	)
+	return err
+}
+
+// DeleteRule removes a rule. Returns sql.ErrNoRows if there was no such rule.
+func (d *DB) DeleteRule(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.db.Exec(`DELETE FROM _rules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordRuleRun stores the outcome of a rule firing; errMsg is "" on success.
+func (d *DB) RecordRuleRun(id string, at int64, errMsg string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`UPDATE _rules SET last_run = ?, last_error = ? WHERE id = ?`, at, errMsg, id)
+	return err
+}
+
+// ListRules returns all rules, oldest first (the order they are evaluated in).
+func (d *DB) ListRules() ([]RuleRow, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`
+		SELECT id, name, enabled, trigger, action, created_at, last_run, last_error
+		FROM _rules ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RuleRow
+	for rows.Next() {
+		var r RuleRow
+		if err := rows.Scan(&r.ID, &r.Name, &r.Enabled, &r.Trigger, &r.Action, &r.CreatedAt, &r.LastRun, &r.LastError); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
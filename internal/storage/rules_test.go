@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRuleRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	r := RuleRow{ID: "r1", Name: "Greet", Enabled: true, Trigger: `{"type":"peer_online"}`, Action: `{"type":"webhook"}`, CreatedAt: 1000}
+	if err := db.SaveRule(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordRuleRun("r1", 2000, "boom"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Updating the rule keeps its run bookkeeping.
+	r.Name, r.Enabled = "Greet friends", false
+	if err := db.SaveRule(r); err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.ListRules()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+	got := list[0]
+	if got.Name != "Greet friends" || got.Enabled || got.LastRun != 2000 || got.LastError != "boom" {
+		t.Fatalf("rule = %+v", got)
+	}
+
+	if err := db.DeleteRule("r1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteRule("r1"); err != sql.ErrNoRows {
+		t.Fatalf("second delete = %v, want sql.ErrNoRows", err)
+	}
+}
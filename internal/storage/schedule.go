@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Scheduled session kinds.
+const (
+	ScheduleKindListen = "listen"
+	ScheduleKindGroup  = "group"
+)
+
+// ScheduledSession is a planned listen session or group event. It is only
+// published through the calendar feeds; nothing starts automatically.
+type ScheduledSession struct {
+	ID          int64  `json:"id"`
+	Kind        string `json:"kind"`
+	GroupID     string `json:"group_id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	StartsAt    int64  `json:"starts_at"` // Unix ms
+	DurationMin int    `json:"duration_min"`
+	CreatedAt   int64  `json:"created_at"` // Unix ms
+	UpdatedAt   int64  `json:"updated_at"` // Unix ms
+}
+
+// CalendarFeed is an access token for an ICS feed. GroupID is empty for the
+// feed of every scheduled session on this peer.
+type CalendarFeed struct {
+	Token     string `json:"token"`
+	GroupID   string `json:"group_id,omitempty"`
+	CreatedAt int64  `json:"created_at"` // Unix ms
+}
+
+// SaveScheduledSession inserts s when s.ID is zero and updates it otherwise,
+// returning the stored row.
+func (d *DB) SaveScheduledSession(s ScheduledSession) (ScheduledSession, error) {
+	s.Title = strings.TrimSpace(s.Title)
+	switch {
+	case s.Title == "":
+		return ScheduledSession{}, errors.New("title required")
+	case s.StartsAt <= 0:
+		return ScheduledSession{}, errors.New("start time required")
+	case s.Kind == ScheduleKindGroup && s.GroupID == "":
+		return ScheduledSession{}, errors.New("group_id required for a group event")
+	case s.Kind == ScheduleKindListen:
+		s.GroupID = ""
+	case s.Kind != ScheduleKindGroup:
+		return ScheduledSession{}, errors.New("kind must be listen or group")
+	}
+	if s.DurationMin <= 0 {
+		s.DurationMin = 60
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now().UnixMilli()
+	s.UpdatedAt = now
+	if s.ID == 0 {
+		s.CreatedAt = now
+		err := d.db.QueryRow(`
+			INSERT INTO _schedule (kind, group_id, title, description, starts_at, duration_min, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING id`,
+			s.Kind, s.GroupID, s.Title, s.Description, s.StartsAt, s.DurationMin, now, now,
+		).Scan(&s.ID)
+		return s, err
+	}
+	err := d.db.QueryRow(`
+		UPDATE _schedule SET kind = ?, group_id = ?, title = ?, description = ?,
+			starts_at = ?, duration_min = ?, updated_at = ?
+		WHERE id = ?
+		RETURNING created_at`,
+		s.Kind, s.GroupID, s.Title, s.Description, s.StartsAt, s.DurationMin, now, s.ID,
+	).Scan(&s.CreatedAt)
+	return s, err
+}
+
+// DeleteScheduledSession removes the session with the given id.
+func (d *DB) DeleteScheduledSession(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _schedule WHERE id = ?`, id)
+	return err
+}
+
+// ListScheduledSessions returns the sessions of one group, or every session
+// when groupID is empty, earliest first.
+func (d *DB) ListScheduledSessions(groupID string) ([]ScheduledSession, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`
+		SELECT id, kind, group_id, title, description, starts_at, duration_min, created_at, updated_at
+		FROM _schedule WHERE ? = '' OR group_id = ?
+		ORDER BY starts_at`,
+		groupID, groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ScheduledSession
+	for rows.Next() {
+		var s ScheduledSession
+		if err := rows.Scan(&s.ID, &s.Kind, &s.GroupID, &s.Title, &s.Description,
+			&s.StartsAt, &s.DurationMin, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// CreateCalendarFeed stores token as the feed for groupID ("" for the whole
+// peer), revoking any earlier token for the same feed so that handing out
+// a new link also cuts off the old one.
+func (d *DB) CreateCalendarFeed(groupID, token string) (CalendarFeed, error) {
+	if token == "" {
+		return CalendarFeed{}, errors.New("token required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f := CalendarFeed{Token: token, GroupID: groupID, CreatedAt: time.Now().UnixMilli()}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return CalendarFeed{}, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM _calendar_feeds WHERE group_id = ?`, groupID); err != nil {
+		return CalendarFeed{}, err
+	}
+	if _, err := tx.Exec(`INSERT INTO _calendar_feeds (token, group_id, created_at) VALUES (?, ?, ?)`,
+		f.Token, f.GroupID, f.CreatedAt); err != nil {
+		return CalendarFeed{}, err
+	}
+	return f, tx.Commit()
+}
+
+// CalendarFeedByToken returns the feed for token, or ok=false if it was
+// never issued or has been revoked.
+func (d *DB) CalendarFeedByToken(token string) (CalendarFeed, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	f := CalendarFeed{Token: token}
+	err := d.db.QueryRow(`SELECT group_id, created_at FROM _calendar_feeds WHERE token = ?`, token).
+		Scan(&f.GroupID, &f.CreatedAt)
+	if err != nil {
+		return CalendarFeed{}, false
+	}
+	return f, true
+}
+
+// ListCalendarFeeds returns every issued feed token.
+func (d *DB) ListCalendarFeeds() ([]CalendarFeed, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.db.Query(`SELECT token, group_id, created_at FROM _calendar_feeds ORDER BY group_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CalendarFeed
+	for rows.Next() {
+		var f CalendarFeed
+		if err := rows.Scan(&f.Token, &f.GroupID, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// DeleteCalendarFeed revokes the feed for groupID ("" for the whole peer).
+func (d *DB) DeleteCalendarFeed(groupID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.db.Exec(`DELETE FROM _calendar_feeds WHERE group_id = ?`, groupID)
+	return err
+}
@@ -0,0 +1,67 @@
+package storage
+
+import "testing"
+
+func TestScheduledSessions(t *testing.T) {
+	db := testDB(t)
+
+	if _, err := db.SaveScheduledSession(ScheduledSession{Kind: ScheduleKindGroup, Title: "Jam", StartsAt: 1}); err == nil {
+		t.Fatal("group event without group accepted")
+	}
+	jam, err := db.SaveScheduledSession(ScheduledSession{Kind: ScheduleKindGroup, GroupID: "g1", Title: " Jam ", StartsAt: 2000})
+	if err != nil || jam.ID == 0 || jam.Title != "Jam" || jam.DurationMin != 60 {
+		t.Fatalf("save = %+v, %v", jam, err)
+	}
+	radio, err := db.SaveScheduledSession(ScheduledSession{Kind: ScheduleKindListen, GroupID: "ignored", Title: "Radio", StartsAt: 1000})
+	if err != nil || radio.GroupID != "" {
+		t.Fatalf("save listen = %+v, %v", radio, err)
+	}
+
+	jam.StartsAt = 500
+	if _, err := db.SaveScheduledSession(jam); err != nil {
+		t.Fatal(err)
+	}
+	all, err := db.ListScheduledSessions("")
+	if err != nil || len(all) != 2 || all[0].ID != jam.ID {
+		t.Fatalf("list all = %+v, %v", all, err)
+	}
+	if g, _ := db.ListScheduledSessions("g1"); len(g) != 1 || g[0].Title != "Jam" {
+		t.Fatalf("list g1 = %+v", g)
+	}
+
+	if err := db.DeleteScheduledSession(jam.ID); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := db.ListScheduledSessions(""); len(all) != 1 {
+		t.Fatalf("after delete = %+v", all)
+	}
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	db := testDB(t)
+
+	if _, err := db.CreateCalendarFeed("g1", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateCalendarFeed("g1", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.CalendarFeedByToken("old"); ok {
+		t.Fatal("rotated token still valid")
+	}
+	if f, ok := db.CalendarFeedByToken("new"); !ok || f.GroupID != "g1" {
+		t.Fatalf("feed = %+v, %v", f, ok)
+	}
+	if _, err := db.CreateCalendarFeed("", "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := db.ListCalendarFeeds(); len(list) != 2 {
+		t.Fatalf("list = %+v", list)
+	}
+	if err := db.DeleteCalendarFeed("g1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.CalendarFeedByToken("new"); ok {
+		t.Fatal("revoked token still valid")
+	}
+}
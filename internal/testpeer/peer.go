@@ -144,7 +144,7 @@ func (tp *TestPeer) wireIdentityHandlers() {
 				content, _ := m["content"].(string)
 				email, _ := m["email"].(string)
 				if content != "" {
-					tp.Peers.Upsert(from, content, email, "", false, "", "", false, false, "")
+					tp.Peers.Upsert(from, content, email, "", false, "", "", false, false, "", true)
 				}
 			}
 			return
@@ -152,7 +152,7 @@ func (tp *TestPeer) wireIdentityHandlers() {
 		if pm.Content != "" {
 			tp.Peers.Upsert(from, pm.Content, pm.Email, pm.AvatarHash,
 				pm.VideoDisabled, pm.ActiveTemplate, pm.PublicKey,
-				pm.EncryptionSupported, pm.Verified, pm.GoopClientVersion)
+				pm.EncryptionSupported, pm.Verified, pm.GoopClientVersion, true)
 		}
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/petervdpas/goop2/internal/group"
 	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/state"
 )
@@ -209,6 +210,49 @@ func TestGroupLifecycle_HostAndRemotePeer(t *testing.T) {
 	}
 }
 
+type snapshotHandler struct{}
+
+func (snapshotHandler) Flags() group.GroupTypeFlags       { return group.GroupTypeFlags{HostCanJoin: true} }
+func (snapshotHandler) OnCreate(_, _ string, _ int) error { return nil }
+func (snapshotHandler) OnJoin(_, _ string, _ bool)        {}
+func (snapshotHandler) OnLeave(_, _ string, _ bool)       {}
+func (snapshotHandler) OnClose(_ string)                  {}
+func (snapshotHandler) OnEvent(_ *group.Event)            {}
+func (snapshotHandler) SnapshotState(groupID string) any {
+	return map[string]any{"board": groupID + "-state"}
+}
+
+func TestGroupLifecycle_SnapshotSentToNewMember(t *testing.T) {
+	bus := NewTestBus()
+	host := NewTestPeer(t, bus, PeerConfig{ID: "host-peer", Content: "Host"})
+	remote := NewTestPeer(t, bus, PeerConfig{ID: "remote-peer", Content: "Remote"})
+	_ = remote
+
+	host.Groups.RegisterType("whiteboard", snapshotHandler{})
+	if err := host.Groups.CreateGroup("g1", "Board", "whiteboard", "", 0); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	host.Groups.SimulateJoin("remote-peer", "g1")
+
+	var msgs []BusMessage
+	for i := 0; i < 100 && len(msgs) == 0; i++ {
+		msgs = bus.MessagesForTopic("group:g1:" + group.TypeState)
+		time.Sleep(time.Millisecond)
+	}
+	if len(msgs) != 1 || msgs[0].To != "remote-peer" {
+		t.Fatalf("expected one snapshot to remote-peer, got %+v", msgs)
+	}
+
+	var state map[string]any
+	if !group.ParseSnapshot(msgs[0].Payload, &state) {
+		t.Fatalf("payload is not a snapshot: %#v", msgs[0].Payload)
+	}
+	if state["board"] != "g1-state" {
+		t.Fatalf("unexpected snapshot state: %v", state)
+	}
+}
+
 func TestPublishLocal_DeliveredToOwnSubscribers(t *testing.T) {
 	bus := NewTestBus()
 	alice := NewTestPeer(t, bus, PeerConfig{Content: "Alice"})
@@ -0,0 +1,73 @@
+// Package timeouts holds the network deadlines operators can tune in the
+// "timeouts" section of goop.json, for links where the built-in ones are
+// too tight (satellite, onion-routed overlays). mq, group, listen, p2p, the
+// docs routes and the rendezvous read them with Get; the process sets them
+// once at startup with Use. Every other timing stays a constant in its
+// package's timings.go.
+package timeouts
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts are the tunable deadlines.
+type Timeouts struct {
+	MQAck        time.Duration // transport ACK wait per MQ send attempt
+	MQSend       time.Duration // one delivery attempt of a queued MQ message; sends through /api/mq/send
+	GroupSend    time.Duration // one group control message or broadcast
+	GroupJoin    time.Duration // join handshake; reconnect per host; listen and cluster joins
+	Probe        time.Duration // reachability probe of a peer
+	DocsList     time.Duration // fetch a group member's shared file list
+	DocsFetch    time.Duration // fetch a shared file, or one range of it
+	ListenStream time.Duration // open the listen room's audio stream to the host
+	SSEHeartbeat time.Duration // rendezvous keep-alive on presence event streams
+}
+
+// Defaults returns the built-in deadlines.
+func Defaults() Timeouts {
+	return Timeouts{
+		MQAck:        2 * time.Second,
+		MQSend:       10 * time.Second,
+		GroupSend:    5 * time.Second,
+		GroupJoin:    5 * time.Second,
+		Probe:        2 * time.Second,
+		DocsList:     5 * time.Second,
+		DocsFetch:    15 * time.Second,
+		ListenStream: 5 * time.Second,
+		SSEHeartbeat: 25 * time.Second,
+	}
+}
+
+var current atomic.Pointer[Timeouts]
+
+func init() {
+	d := Defaults()
+	current.Store(&d)
+}
+
+// Use replaces the deadlines. A zero field keeps its default.
+func Use(t Timeouts) {
+	d := Defaults()
+	for _, f := range []struct{ dst, src *time.Duration }{
+		{&d.MQAck, &t.MQAck},
+		{&d.MQSend, &t.MQSend},
+		{&d.GroupSend, &t.GroupSend},
+		{&d.GroupJoin, &t.GroupJoin},
+		{&d.Probe, &t.Probe},
+		{&d.DocsList, &t.DocsList},
+		{&d.DocsFetch, &t.DocsFetch},
+		{&d.ListenStream, &t.ListenStream},
+		{&d.SSEHeartbeat, &t.SSEHeartbeat},
+	} {
+		if *f.src > 0 {
+			*f.dst = *f.src
+		}
+	}
+	current.Store(&d)
+}
+
+// Get returns the deadlines in use.
+func Get() Timeouts {
+	return *current.Load()
+}
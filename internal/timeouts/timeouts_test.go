@@ -0,0 +1,25 @@
+package timeouts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUse(t *testing.T) {
+	defer Use(Timeouts{})
+
+	Use(Timeouts{GroupJoin: 30 * time.Second, Probe: -1})
+	got := Get()
+	if got.GroupJoin != 30*time.Second {
+		t.Errorf("GroupJoin = %v", got.GroupJoin)
+	}
+	want := Defaults()
+	if got.Probe != want.Probe || got.MQAck != want.MQAck || got.SSEHeartbeat != want.SSEHeartbeat {
+		t.Errorf("unset fields lost their defaults: %+v", got)
+	}
+
+	Use(Timeouts{})
+	if Get() != want {
+		t.Errorf("Use(zero) = %+v, want defaults", Get())
+	}
+}
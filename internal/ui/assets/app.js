@@ -53,6 +53,7 @@
     "/assets/js/pages/documents.js",
     "/assets/js/pages/templates.js",
     "/assets/js/pages/apidocs.js",
+    "/assets/js/pages/self-rules.js",
     "/assets/js/pages/self.js",
   ];
 
@@ -20,7 +20,7 @@ import (
 // - app.css is now a manifest that @imports ./css/*.css
 // - so we must embed css/** as well, otherwise those imports 404.
 //
-//go:embed app.css app.js css/** js/** vendor/** images/**
+//go:embed app.css app.js sw.js css/** js/** vendor/** images/**
 var rawFS embed.FS
 
 // minified holds minified CSS/JS content keyed by path (e.g. "app.css").
@@ -108,6 +108,19 @@ func Handler() http.Handler {
 	})
 }
 
+// ServiceWorker serves sw.js. It is mounted at /sw.js rather than under
+// /assets/ because a worker only controls pages below its own path.
+func ServiceWorker() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := minified["sw.js"]
+		if !ok {
+			data, _ = rawFS.ReadFile("sw.js")
+		}
+		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+		w.Write(data)
+	})
+}
+
 func fmtBytes(b int) string {
 	if b < 1024 {
 		return fmt.Sprintf("%d B", b)
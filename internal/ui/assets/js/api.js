@@ -23,7 +23,7 @@
   }
   function _delete(url) {
     return fetch(url, { method: 'DELETE' }).then(function (r) {
-      if (!r.ok) return r.text().then(function (t) { throw new Error(t || r.statusText); });
+      if (!r.ok) return window.Goop.core.errorFromResponse(r).then(function (e) { throw e; });
       var ct = r.headers.get('Content-Type') || '';
       return ct.includes('application/json') ? r.json() : null;
     });
@@ -56,9 +56,15 @@
     // For P2P messaging use Goop.mq.send() (full stack with retry/sequence).
     // POST /api/mq/send and /api/mq/ack are the raw HTTP contract.
     mq: {
-      send:   function (p) { return _post('/api/mq/send', p); },
-      ack:    function (p) { return _post('/api/mq/ack', p); },
-      events: function ()  { return new EventSource('/api/mq/events'); },
+      send:    function (p) { return _post('/api/mq/send', p); },
+      ack:     function (p) { return _post('/api/mq/ack', p); },
+      // topics (optional): patterns such as ['call:*', 'group:+:state'] to
+      // receive only those messages
+      events:  function (topics) {
+        var qs = topics && topics.length ? '?topics=' + encodeURIComponent(topics.join(',')) : '';
+        return new EventSource('/api/mq/events' + qs);
+      },
+      metrics: function ()  { return _get('/api/mq/metrics'); },
     },
 
     // ── Call ───────────────────────────────────────────────────────────────────
@@ -71,6 +77,30 @@
       hangup:        function (p)      { return _post('/api/call/hangup', p); },
       toggleAudio:   function (p)      { return _post('/api/call/toggle-audio', p); },
       toggleVideo:   function (p)      { return _post('/api/call/toggle-video', p); },
+      hold:          function (p)      { return _post('/api/call/hold', p); },
+      resume:        function (p)      { return _post('/api/call/resume', p); },
+      transfer:      function (p)      { return _post('/api/call/transfer', p); },
+      callback:      function (p)      { return _post('/api/call/callback', p); },
+      chat:          function (p)      { return _post('/api/call/chat', p); },
+      file:          function (p)      { return _post('/api/call/file', p); },
+      captions:      function (p)      { return _post('/api/call/captions', p); },
+      audioProcessing:    function ()  { return _get('/api/call/audio-processing'); },
+      setAudioProcessing: function (p) { return _post('/api/call/audio-processing', p); },
+      devices:       function ()       { return _get('/api/call/devices'); },
+      device:        function (p)      { return _post('/api/call/device', p); },
+      callbacks:     function ()       { return _get('/api/call/callbacks'); },
+      dismissCallback: function (p)    { return _post('/api/call/callbacks/dismiss', p); },
+      // filters: { peer_id, limit } — both optional
+      history: function (filters) {
+        var qs = new URLSearchParams();
+        Object.keys(filters || {}).forEach(function (k) {
+          if (filters[k] !== undefined && filters[k] !== '') qs.set(k, filters[k]);
+        });
+        var s = qs.toString();
+        return _get('/api/call/history' + (s ? '?' + s : ''));
+      },
+      historySeen:   function ()       { return _post('/api/call/history/seen'); },
+      historyClear:  function ()       { return _post('/api/call/history/clear'); },
       loopbackOffer: function (ch, p)  { return _post('/api/call/loopback/' + ch + '/offer', p); },
       loopbackIce:   function (ch, p)  { return _post('/api/call/loopback/' + ch + '/ice', p); },
       // WebSocket URLs — pass to new WebSocket(url) or MSE adapter
@@ -89,17 +119,23 @@
       invite:             function (p) { return _post('/api/groups/invite', p); },
       join:               function (p) { return _post('/api/groups/join', p); },
       joinOwn:            function (p) { return _post('/api/groups/join-own', p); },
+      addCoHost:          function (p) { return _post('/api/groups/cohost', p); },
+      removeCoHost:       function (p) { return _post('/api/groups/cohost/remove', p); },
+      transfer:           function (p) { return _post('/api/groups/transfer', p); },
       kick:               function (p) { return _post('/api/groups/kick', p); },
+      unmute:             function (p) { return _post('/api/groups/unmute', p); },
       leave:              function (p) { return _post('/api/groups/leave', p); },
       leaveOwn:           function (p) { return _post('/api/groups/leave-own', p); },
       setMaxMembers:      function (p) { return _post('/api/groups/max-members', p); },
       setMeta:            function (p) { return _post('/api/groups/meta', p); },
+      setOrdered:         function (p) { return _post('/api/groups/ordered', p); },
       rejoin:             function (p) { return _post('/api/groups/rejoin', p); },
       send:               function (p) { return _post('/api/groups/send', p); },
       setRole:            function (p) { return _post('/api/groups/set-role', p); },
       setDefaultRole:     function (p) { return _post('/api/groups/set-default-role', p); },
       setGroupRoles:      function (p) { return _post('/api/groups/set-roles', p); },
       subscriptions:      function ()  { return _get('/api/groups/subscriptions'); },
+      presence:           function (id) { return _get('/api/groups/presence?group_id=' + encodeURIComponent(id)); },
       removeSubscription: function (p) { return _post('/api/groups/subscriptions/remove', p); },
     },
 
@@ -113,17 +149,41 @@
       control:   function (p) { return _post('/api/listen/control', p); },
       join:      function (p) { return _post('/api/listen/join', p); },
       leave:     function ()  { return _post('/api/listen/leave'); },
+      request:   function (p) { return _post('/api/listen/request', p); },
+      report:    function (p) { return _post('/api/listen/report', p); },
+      requests:  function ()  { return _get('/api/listen/requests'); },
+      approve:   function (p) { return _post('/api/listen/requests/approve', p); },
+      reject:    function (p) { return _post('/api/listen/requests/reject', p); },
+      jukebox:   function (p) { return _post('/api/listen/jukebox', p); },
       // Audio stream URL — assign directly to <audio>.src
       streamUrl: function ()  { return '/api/listen/stream'; },
     },
 
     // ── Peers ──────────────────────────────────────────────────────────────────
     peers: {
-      list:     function ()    { return _get('/api/peers'); },
-      self:     function ()    { return _get('/api/self'); },
-      content:  function (id)  { return _get('/api/peer/content?id=' + encodeURIComponent(id)); },
-      favorite: function (p)   { return _post('/api/peers/favorite', p); },
-      probe:    function ()    { return _post('/api/peers/probe'); },
+      list:         function (ns)     { return _get('/api/peers' + (ns ? '?namespace=' + encodeURIComponent(ns) : '')); },
+      namespaces:   function ()       { return _get('/api/peers/namespaces'); },
+      // opts: { limit, offset, q, by, sort, favorites, online, verified, namespace }
+      // — resolves to { peers, offset, limit, summary }
+      page: function (opts) {
+        var qs = new URLSearchParams({ limit: 0 });
+        Object.keys(opts || {}).forEach(function (k) {
+          if (opts[k] !== undefined && opts[k] !== '') qs.set(k, opts[k]);
+        });
+        return _get('/api/peers?' + qs.toString());
+      },
+      self:         function ()       { return _get('/api/self'); },
+      content:      function (id)     { return _get('/api/peer/content?id=' + encodeURIComponent(id)); },
+      favorite:     function (p)      { return _post('/api/peers/favorite', p); },
+      probe:        function ()       { return _post('/api/peers/probe'); },
+      forget:       function (p)      { return _post('/api/peers/forget', p); },
+      retention:    function ()       { return _get('/api/peers/retention'); },
+      note:         function (id)     { return _get('/api/peers/notes?peer=' + encodeURIComponent(id)); },
+      setNote:      function (p)      { return _post('/api/peers/notes', p); },
+      follows:      function ()       { return _get('/api/peers/follows'); },
+      follow:       function (p)      { return _post('/api/peers/follow', p); },
+      unfollow:     function (p)      { return _post('/api/peers/unfollow', p); },
+      syncFollow:   function (p)      { return _post('/api/peers/follows/sync', p); },
     },
 
     // ── Settings ───────────────────────────────────────────────────────────────
@@ -137,6 +197,52 @@
       },
     },
 
+    // ── Actions registry (command palette, bots) ──────────────────────────────
+    actions: {
+      list: function ()  { return _get('/api/actions'); },
+      run:  function (p) { return _post('/api/actions/run', p); },
+    },
+
+    // ── My data (full export, selective wipe) ──────────────────────────────────
+    mydata: {
+      summary: function ()  { return _get('/api/export/summary'); },
+      wipe:    function (p) { return _post('/api/export/wipe', p); },
+    },
+
+    // ── Automation rules ───────────────────────────────────────────────────────
+    rules: {
+      list:   function ()  { return _get('/api/rules'); },
+      save:   function (p) { return _post('/api/rules/save', p); },
+      remove: function (p) { return _post('/api/rules/delete', p); },
+      test:   function (p) { return _post('/api/rules/test', p); },
+    },
+
+    // ── Phone pairing (remote control) ─────────────────────────────────────────
+    pair: {
+      status: function ()  { return _get('/api/pair'); },
+      start:  function (p) { return _post('/api/pair/start', p); },
+      revoke: function (p) { return _post('/api/pair/revoke', p); },
+      guest:       function (p) { return _post('/api/pair/guest', p); },
+      revokeGuest: function (p) { return _post('/api/pair/guest/revoke', p); },
+      claim:  function (p) { return _post('/api/pair/claim', p); },
+    },
+
+    // ── Email digest (stored on the rendezvous) ────────────────────────────────
+    digest: {
+      status: function ()  { return _get('/api/digest'); },
+      set:    function (p) { return _post('/api/digest', p); },
+    },
+
+    // ── Scheduled sessions and calendar feeds ──────────────────────────────────
+    schedule: {
+      list:       function (groupId) { return _get('/api/schedule' + (groupId ? '?group=' + encodeURIComponent(groupId) : '')); },
+      save:       function (p)       { return _post('/api/schedule', p); },
+      remove:     function (p)       { return _post('/api/schedule/delete', p); },
+      feeds:      function ()        { return _get('/api/schedule/feeds'); },
+      createFeed: function (p)       { return _post('/api/schedule/feeds', p); },
+      revokeFeed: function (p)       { return _post('/api/schedule/feeds/revoke', p); },
+    },
+
     // ── Logs ───────────────────────────────────────────────────────────────────
     logs: {
       snapshot: function ()  { return _get('/api/logs'); },
@@ -144,6 +250,22 @@
       stream:   function ()  { return new EventSource('/api/logs/stream'); },
     },
 
+    // ── Security ───────────────────────────────────────────────────────────────
+    security: {
+      // filters: { peer_id, protocol, outcome, since, until, limit } — all optional
+      audit: function (filters) {
+        var qs = new URLSearchParams();
+        Object.keys(filters || {}).forEach(function (k) {
+          if (filters[k] !== undefined && filters[k] !== '') qs.set(k, filters[k]);
+        });
+        var s = qs.toString();
+        return _get('/api/security/audit' + (s ? '?' + s : ''));
+      },
+      consent:       function ()  { return _get('/api/security/consent'); },
+      decide:        function (p) { return _post('/api/security/consent', p); },
+      forgetConsent: function (p) { return _post('/api/security/consent/forget', p); },
+    },
+
     // ── Docs ───────────────────────────────────────────────────────────────────
     docs: {
       my:      function (groupId) {
@@ -152,7 +274,13 @@
       browse:  function (groupId) {
         return _get('/api/docs/browse?group_id=' + encodeURIComponent(groupId));
       },
+      // Members a browse listed as slow, once they answered
+      refresh: function (groupId, peerIds) {
+        return _post('/api/docs/browse/refresh', { group_id: groupId, peer_ids: peerIds });
+      },
       delete:  function (p) { return _post('/api/docs/delete', p); },
+      // Who may fetch a file: { group_id, filename, access, peers }
+      acl:     function (p) { return _post('/api/docs/acl', p); },
       // Returns a URL — pass to <a href> or fetch() for download
       downloadUrl: function (groupId, file, peerId, inline) {
         var qs = '?group_id=' + encodeURIComponent(groupId) + '&file=' + encodeURIComponent(file);
@@ -271,6 +399,8 @@
     chat: {
       history: function (peerId) { return _get('/api/chat/history?peer_id=' + encodeURIComponent(peerId)); },
       clear:   function (peerId) { return _delete('/api/chat/history?peer_id=' + encodeURIComponent(peerId)); },
+      // { peer_id, call_id, content, incoming } — persists a browser-mode in-call message
+      callMessage: function (p) { return _post('/api/chat/call', p); },
     },
 
     // ── Avatar ─────────────────────────────────────────────────────────────────
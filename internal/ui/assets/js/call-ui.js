@@ -78,6 +78,34 @@
       ".goop-call-status {",
       "  text-align: center; font-size: 11px; color: #7a8194;",
       "}",
+      ".goop-call-chat { display: flex; flex-direction: column; gap: 4px; }",
+      ".goop-call-chat-log {",
+      "  max-height: 120px; overflow-y: auto; display: flex; flex-direction: column; gap: 2px;",
+      "  font-size: 12px; word-break: break-word;",
+      "}",
+      ".goop-call-chat-log:empty { display: none; }",
+      ".goop-call-chat-msg { padding: 3px 8px; border-radius: 8px; background: #2a2a40; align-self: flex-start; max-width: 90%; }",
+      ".goop-call-chat-msg.out { background: #3a4a7a; align-self: flex-end; }",
+      ".goop-call-chat-msg a { color: #9ecbff; }",
+      ".goop-call-chat-form { display: flex; gap: 4px; }",
+      ".goop-call-chat-input {",
+      "  flex: 1; min-width: 0; background: #111; color: #e0e0e0; border: 1px solid #333;",
+      "  border-radius: 6px; padding: 4px 8px; font-size: 12px;",
+      "}",
+      ".goop-call-chat-attach { background: #333; color: #fff; border: none; border-radius: 6px; cursor: pointer; padding: 0 8px; }",
+      ".goop-call-overlay.drop-target { outline: 2px dashed #9ecbff; }",
+      ".goop-call-caption {",
+      "  position: absolute; left: 6px; right: 92px; bottom: 8px; z-index: 2;",
+      "  background: rgba(0,0,0,0.7); color: #fff; font-size: 12px; line-height: 1.3;",
+      "  padding: 3px 6px; border-radius: 4px;",
+      "}",
+      ".goop-call-caption:empty { display: none; }",
+      ".goop-call-cc { display: flex; gap: 4px; justify-content: center; align-items: center; font-size: 11px; }",
+      ".goop-call-cc.hidden { display: none; }",
+      ".goop-call-cc-btn { background: #333; color: #fff; border: none; border-radius: 4px; cursor: pointer; padding: 2px 8px; font-weight: 600; }",
+      ".goop-call-cc-btn.active { background: #3a4a7a; }",
+      ".goop-call-audio { margin-top: 2px; }",
+      ".goop-call-cc-lang { background: #111; color: #e0e0e0; border: 1px solid #333; border-radius: 4px; font-size: 11px; }",
       "",
       ".goop-call-incoming {",
       "  position: fixed; top: 50%; left: 50%; transform: translate(-50%, -50%);",
@@ -167,18 +195,54 @@
       info.reject();
     };
 
-    incomingEl = { backdrop: backdrop, modal: modal };
+    incomingEl = { backdrop: backdrop, modal: modal, channelId: info.channelId };
     document.body.appendChild(backdrop);
     document.body.appendChild(modal);
+    startRingtone();
   }
 
   function removeIncoming() {
+    stopRingtone();
     if (!incomingEl) return;
     if (incomingEl.backdrop.parentNode) incomingEl.backdrop.parentNode.removeChild(incomingEl.backdrop);
     if (incomingEl.modal.parentNode) incomingEl.modal.parentNode.removeChild(incomingEl.modal);
     incomingEl = null;
   }
 
+  // ── Ring tone ───────────────────────────────────────────────────────────────
+
+  // Two-tone ring synthesised with WebAudio (no asset to ship). Browsers may
+  // refuse to start audio without a prior user gesture; the modal still shows.
+  var ringCtx = null;
+  var ringTimer = null;
+
+  function startRingtone() {
+    stopRingtone();
+    var AC = window.AudioContext || window.webkitAudioContext;
+    if (!AC) return;
+    try { ringCtx = new AC(); } catch (e) { return; }
+    var ring = function() {
+      if (!ringCtx) return;
+      var t = ringCtx.currentTime;
+      [440, 480].forEach(function(freq) {
+        var osc = ringCtx.createOscillator();
+        var gain = ringCtx.createGain();
+        osc.frequency.value = freq;
+        gain.gain.setValueAtTime(0.08, t);
+        gain.gain.setValueAtTime(0, t + 1.5);
+        osc.connect(gain).connect(ringCtx.destination);
+        osc.start(t);
+        osc.stop(t + 1.5);
+      });
+    };
+    ring();
+    ringTimer = setInterval(ring, 4000);
+  }
+
+  function stopRingtone() {
+    if (ringTimer) { clearInterval(ringTimer); ringTimer = null; }
+    if (ringCtx) { ringCtx.close().catch(function() {}); ringCtx = null; }
+  }
 
   // ── Active call overlay ─────────────────────────────────────────────────────
 
@@ -187,7 +251,9 @@
   // and has placeholder button handlers.  Call wireSession() once the session
   // is available to activate the real callbacks.
   //
-  // Returns a {el, remoteVideo, localVideo, statusEl, muteBtn, hangupBtn, videoBtn} object.
+  // Returns a {el, remoteVideo, localVideo, statusEl, muteBtn, hangupBtn, videoBtn,
+  // chatLog, chatForm, chatInput, attachBtn, fileInput, captionEl, ccRow, ccBtn, ccLang,
+  // audioRow, nsBtn, ecBtn} object.
   //
   // Must be called from within a user-interaction task (click handler) so that
   // WebKitGTK composites and paints the element before any await suspends execution.
@@ -205,6 +271,7 @@
         '<div class="goop-call-local-wrap">' +
           '<video class="goop-call-local" autoplay playsinline muted></video>' +
         '</div>' +
+        '<div class="goop-call-caption"></div>' +
       '</div>' +
       '<div class="goop-call-status">Connecting\u2026</div>' +
       '<div class="goop-call-controls">' +
@@ -237,6 +304,24 @@
             '<line x1="1" y1="1" x2="23" y2="23"/>' +
           '</svg>' +
         '</button>' +
+      '</div>' +
+      '<div class="goop-call-cc hidden">' +
+        '<button type="button" class="goop-call-cc-btn" title="Live captions">CC</button>' +
+        '<select class="goop-call-cc-lang" title="Caption language">' +
+          CAPTION_LANGS.map(function(l) { return '<option value="' + l[0] + '">' + l[1] + '</option>'; }).join('') +
+        '</select>' +
+      '</div>' +
+      '<div class="goop-call-cc goop-call-audio hidden">' +
+        '<button type="button" class="goop-call-cc-btn goop-call-ns-btn" title="Noise suppression">NS</button>' +
+        '<button type="button" class="goop-call-cc-btn goop-call-ec-btn" title="Echo cancellation">EC</button>' +
+      '</div>' +
+      '<div class="goop-call-chat">' +
+        '<div class="goop-call-chat-log"></div>' +
+        '<form class="goop-call-chat-form">' +
+          '<input class="goop-call-chat-input" type="text" placeholder="Message\u2026" autocomplete="off">' +
+          '<button type="button" class="goop-call-chat-attach" title="Send a file (or drop it here)">\ud83d\udcce</button>' +
+          '<input class="goop-call-chat-file" type="file" hidden>' +
+        '</form>' +
       '</div>';
 
     overlayEl = el;
@@ -250,7 +335,155 @@
       muteBtn:     el.querySelector('.goop-call-btn-mute'),
       hangupBtn:   el.querySelector('.goop-call-btn-hangup'),
       videoBtn:    el.querySelector('.goop-call-btn-video'),
+      chatLog:     el.querySelector('.goop-call-chat-log'),
+      chatForm:    el.querySelector('.goop-call-chat-form'),
+      chatInput:   el.querySelector('.goop-call-chat-input'),
+      attachBtn:   el.querySelector('.goop-call-chat-attach'),
+      fileInput:   el.querySelector('.goop-call-chat-file'),
+      captionEl:   el.querySelector('.goop-call-caption'),
+      ccRow:       el.querySelector('.goop-call-cc'),
+      ccBtn:       el.querySelector('.goop-call-cc-btn'),
+      ccLang:      el.querySelector('.goop-call-cc-lang'),
+      audioRow:    el.querySelector('.goop-call-audio'),
+      nsBtn:       el.querySelector('.goop-call-ns-btn'),
+      ecBtn:       el.querySelector('.goop-call-ec-btn'),
+    };
+  }
+
+  // ── Live captions ───────────────────────────────────────────────────────────
+
+  var CAPTION_LANGS = [
+    ['auto', 'Auto'], ['en', 'English'], ['nl', 'Nederlands'], ['de', 'Deutsch'],
+    ['fr', 'Français'], ['es', 'Español'],
+  ];
+  var CAPTION_LANG_KEY = 'goop_caption_lang';
+  var CAPTION_HOLD_MS  = 6000; // how long a caption line stays up
+
+  // wireCaptions shows the CC toggle when the backend can transcribe, and
+  // renders call-caption events over the remote video.
+  function wireCaptions(parts, session) {
+    if (!Goop.call.captionsAvailable || typeof session.setCaptions !== 'function') return;
+    var clearTimer = null;
+
+    try { parts.ccLang.value = localStorage.getItem(CAPTION_LANG_KEY) || 'auto'; } catch (_) {}
+    if (session.captions) parts.ccLang.value = session.captions;
+
+    function sync() {
+      parts.ccBtn.classList.toggle('active', !!session.captions);
+      if (!session.captions) parts.captionEl.textContent = '';
+    }
+    function apply(on) {
+      session.setCaptions(on, parts.ccLang.value).then(sync).catch(function(e) {
+        log('warn', 'captions: ' + e.message);
+        if (Goop.notify) Goop.notify('Captions unavailable: ' + e.message, 'error');
+      });
+    }
+
+    Goop.call.captionsAvailable().then(function(ok) {
+      if (!ok) return;
+      parts.ccRow.classList.remove('hidden');
+      sync();
+    });
+    parts.ccBtn.onclick = function() { apply(!session.captions); };
+    parts.ccLang.onchange = function() {
+      try { localStorage.setItem(CAPTION_LANG_KEY, parts.ccLang.value); } catch (_) {}
+      if (session.captions) apply(true);
+    };
+    session.onCaption(function(c) {
+      parts.captionEl.textContent = c.text;
+      clearTimeout(clearTimer);
+      clearTimer = setTimeout(function() { parts.captionEl.textContent = ''; }, CAPTION_HOLD_MS);
+    });
+  }
+
+  // wireAudioProcessing shows the noise/echo suppression toggles for the
+  // native mic. The setting is global, so it carries over to later calls.
+  function wireAudioProcessing(parts) {
+    if (!Goop.call.audioProcessing) return;
+
+    function sync(ap) {
+      parts.nsBtn.classList.toggle('active', !!ap.noise_suppression);
+      parts.ecBtn.classList.toggle('active', !!ap.echo_cancellation);
+    }
+    function toggle(key, btn) {
+      var p = {};
+      p[key] = !btn.classList.contains('active');
+      Goop.call.setAudioProcessing(p).then(sync).catch(function(e) {
+        log('warn', 'audio processing: ' + e.message);
+      });
+    }
+
+    Goop.call.audioProcessing().then(function(ap) {
+      if (!ap) return;
+      sync(ap);
+      parts.audioRow.classList.remove('hidden');
+    }).catch(function() {});
+    parts.nsBtn.onclick = function() { toggle('noise_suppression', parts.nsBtn); };
+    parts.ecBtn.onclick = function() { toggle('echo_cancellation', parts.ecBtn); };
+  }
+
+  // wireChat connects the overlay's chat strip to the session's data channel:
+  // text messages, the attach button and files dropped onto the overlay.
+  function wireChat(parts, session) {
+    var chatLog = parts.chatLog;
+
+    function render(msg, outbound) {
+      var row = document.createElement('div');
+      row.className = 'goop-call-chat-msg' + (outbound ? ' out' : '');
+      if (msg.type === 'file') {
+        var label = '\ud83d\udcce ' + (msg.name || 'file');
+        if (msg.data) {
+          var a = document.createElement('a');
+          a.href = 'data:' + (msg.mime || 'application/octet-stream') + ';base64,' + msg.data;
+          a.download = msg.name || 'file';
+          a.textContent = label;
+          row.appendChild(a);
+        } else {
+          row.textContent = label;
+        }
+      } else {
+        row.textContent = msg.content;
+      }
+      chatLog.appendChild(row);
+      chatLog.scrollTop = chatLog.scrollHeight;
+    }
+
+    function fail(e) {
+      log('warn', 'in-call send failed: ' + (e && e.message));
+      if (Goop.notify) Goop.notify('Could not send: ' + (e && e.message), 'error');
+    }
+
+    function sendFiles(files) {
+      Array.prototype.forEach.call(files || [], function(f) {
+        session.sendFile(f).catch(fail);
+      });
+    }
+
+    (session.messages || []).forEach(function(m) { render(m.msg, m.outbound); });
+    session.onData(render);
+
+    parts.chatForm.onsubmit = function(e) {
+      e.preventDefault();
+      var text = parts.chatInput.value.trim();
+      if (!text) return;
+      parts.chatInput.value = '';
+      session.sendChat(text).catch(fail);
+    };
+    parts.attachBtn.onclick = function() { parts.fileInput.click(); };
+    parts.fileInput.onchange = function() {
+      sendFiles(parts.fileInput.files);
+      parts.fileInput.value = '';
     };
+    parts.el.addEventListener('dragover', function(e) {
+      e.preventDefault();
+      parts.el.classList.add('drop-target');
+    });
+    parts.el.addEventListener('dragleave', function() { parts.el.classList.remove('drop-target'); });
+    parts.el.addEventListener('drop', function(e) {
+      e.preventDefault();
+      parts.el.classList.remove('drop-target');
+      sendFiles(e.dataTransfer && e.dataTransfer.files);
+    });
   }
 
   // wireSession() attaches a live session to an overlay created by prepareOverlay().
@@ -359,6 +592,8 @@
       incoming:    'Incoming\u2026',
       connecting:  'Connecting\u2026',
       connected:   'Connected',
+      held:        'On hold',
+      'remote-held': 'Held by peer',
       idle:        '',
     };
     if (window.Goop && window.Goop.callState) {
@@ -394,6 +629,20 @@
       session.hangup();
     };
 
+    wireChat(parts, session);
+    wireCaptions(parts, session);
+    wireAudioProcessing(parts);
+
+    if (session.onDevice) {
+      session.onDevice(function(d) {
+        var what = d.kind === 'video' ? 'Camera' : 'Microphone';
+        if (d.state === 'lost') {
+          if (Goop.notify) Goop.notify(what + ' disconnected — no other device available', 'error');
+        } else {
+          log('info', what.toLowerCase() + ' now ' + (d.deviceId || 'default'));
+        }
+      });
+    }
   }
 
   // showActiveCall() — convenience wrapper for outbound calls and callUI.showCall().
@@ -414,6 +663,10 @@
     // appear to the user as if the call didn't close.
   }
 
+  function peerLabel(peerId) {
+    return (Goop.mq && Goop.mq.getPeerName && Goop.mq.getPeerName(peerId)) || (peerId || '').slice(0, 8) || 'Peer';
+  }
+
   var escapeHtml = (window.Goop && window.Goop.core && window.Goop.core.escapeHtml) || function(s) { if (!s) return ''; var d = document.createElement('div'); d.appendChild(document.createTextNode(s)); return d.innerHTML; };
 
   // ── Auto-register for incoming calls ────────────────────────────────────────
@@ -428,6 +681,45 @@
       log('info', 'Restoring call overlay for channel: ' + session.channelId);
       showActiveCall(session);
     });
+    Goop.call.onBusy(function(info) {
+      if (!Goop.toast) return;
+      Goop.toast({
+        icon: '📵',
+        title: peerLabel(info.peerId) + ' is busy',
+        message: info.callback ? 'Click to ask them to call you back.' : 'Try again later.',
+        duration: 8000,
+        onClick: info.callback ? function() {
+          info.requestCallback().catch(function(e) { log('warn', 'callback request failed: ' + e.message); });
+        } : null,
+      });
+    });
+    Goop.call.onCallbackRequest(function(info) {
+      if (!Goop.toast) return;
+      Goop.toast({
+        icon: '📞',
+        title: 'Callback requested',
+        message: peerLabel(info.peerId) + ' asked you to call back. Click to call.',
+        duration: 15000,
+        onClick: function() { Goop.callUI.startCall(info.peerId, 'video').catch(function() {}); },
+      });
+    });
+    if (Goop.mq && Goop.mq.onCallMissed) {
+      Goop.mq.onCallMissed(function(from, topic, payload) {
+        if (incomingEl && incomingEl.channelId === payload.channel_id) removeIncoming();
+        if (!Goop.toast) return;
+        Goop.toast({
+          icon: '📵',
+          title: 'Missed call',
+          message: peerLabel(payload.peer_id) + (payload.missed > 1 ? ' (' + payload.missed + ' missed calls)' : '') + '. Click to call back.',
+          duration: 15000,
+          onClick: function() { Goop.callUI.startCall(payload.peer_id, payload.media).catch(function() {}); },
+        });
+      });
+    }
+    Goop.call.onTransfer(function(info) {
+      log('info', 'Transferred by ' + info.from + ' to ' + info.target);
+      Goop.callUI.startCall(info.target, info.mediaType).catch(function() {});
+    });
   }
 
   // Gate all [data-call] buttons on call phase — disabled while a call is active.
@@ -22,6 +22,11 @@
  *   call-answer    target → origin    SDP answer (browser mode only)
  *   ice-candidate  either direction   trickle ICE (browser mode only)
  *   call-hangup    either direction   end call
+ *   call-busy      target → origin    already in a call; origin may send call-callback
+ *   call-callback  origin → target    "please call me back"
+ *   call-hold      either direction   put on hold
+ *   call-resume    either direction   take off hold
+ *   call-transfer  either direction   blind transfer: receiver dials payload.target
  *
  * Goop.call is set synchronously so call-ui.js can register onIncoming immediately.
  * Mode is loaded asynchronously; it is always known before any real call starts.
@@ -38,11 +43,15 @@
   // start() and accept() both await _modePromise so path selection never uses the default.
   var _mode        = 'browser'; // 'browser' | 'native'
   var _platform    = 'unknown';
+  var _captions    = false;     // live captions configured (native mode only)
   var _modePromise = null;      // set by _init(), awaited before any call path decision
 
   var _sessions     = {};  // channelId → CallSession
   var _incomingCbs  = [];
   var _restoreCbs   = [];
+  var _busyCbs      = [];
+  var _callbackCbs  = [];
+  var _transferCbs  = [];
   var _mqSubscribed = false;
 
   // ── Call state store ─────────────────────────────────────────────────────────
@@ -81,6 +90,15 @@
   //   session.onStateChange(cb)
   //   session.toggleAudio()       → bool
   //   session.toggleVideo()       → bool
+  //   session.hold() / resume()   → bool (on hold)
+  //   session.transfer(peerId)
+  //   session.sendChat(text)      → Promise (in-call chat on the data channel)
+  //   session.sendFile(file)      → Promise (small file drop, ≤ 4 MiB)
+  //   session.onData(cb)          cb(msg, outbound) — msg: { type, id, content, name, mime, size, data, ts }
+  //   session.setCaptions(on, lang) → Promise (native mode; see Goop.call.captionsAvailable)
+  //   session.onCaption(cb)       cb({ text, lang, ts })
+  //   session.switchDevice(kind, deviceId) → Promise (kind 'video' | 'audio'; '' = default)
+  //   session.onDevice(cb)        cb({ kind, state: 'active' | 'lost', deviceId })
   //   session.hangup()
 
   function CallSession(channelId, remotePeerId, isOrigin, mediaType) {
@@ -121,6 +139,26 @@
     // Toggle state (native mode — Go owns the tracks)
     this._audioEnabled = true;
     this._videoEnabled = true;
+
+    // Hold state. _heldTracks remembers which tracks hold disabled (browser path)
+    // so resume doesn't unmute a track the user had muted.
+    this.onHold      = false;
+    this.remoteHold  = false;
+    this._heldTracks = [];
+
+    // In-call chat / file drops. messages keeps the conversation so a
+    // re-wired overlay can replay it.
+    this._dc        = null;   // browser path: negotiated RTCDataChannel
+    this._dataCbs   = [];
+    this._fileParts = {};     // file id → { chunks, received }
+    this.messages   = [];
+
+    // Live captions (native mode — Go transcribes the received audio).
+    this.captions     = '';   // caption language while on, '' when off
+    this._captionCbs  = [];
+
+    // Capture device switches and unplugs.
+    this._deviceCbs   = [];
   }
 
   // ── Callbacks (replay-on-subscribe) ──
@@ -207,6 +245,275 @@
     return tracks[0].enabled;
   };
 
+  // ── In-call chat and file drops ──
+  //
+  // Both sides open the same negotiated data channel (label DATA_LABEL, id 0),
+  // which also matches Go's Pion sessions. Files travel as base64 chunks of
+  // DATA_CHUNK raw bytes sharing one id. Native mode: Go owns the channel and
+  // echoes every message (both directions) back as a local call-data signal.
+
+  var DATA_LABEL    = 'goop-call';
+  var DATA_CHUNK    = 16 * 1024;
+  var DATA_MAX_FILE = 4 * 1024 * 1024;
+
+  function _newId() {
+    return Date.now().toString(36) + Math.random().toString(36).slice(2, 10);
+  }
+
+  function _b64FromBytes(bytes) {
+    var bin = '';
+    for (var i = 0; i < bytes.length; i += 0x8000) {
+      bin += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+    }
+    return btoa(bin);
+  }
+
+  // dataSummary mirrors call.DataMessage.Summary() — the line kept in chat history.
+  function dataSummary(msg) {
+    if (msg.type !== 'file') return msg.content;
+    var n = msg.size || 0;
+    var size = n >= 1048576 ? (n / 1048576).toFixed(1) + ' MB'
+             : n >= 1024    ? (n / 1024).toFixed(1) + ' KB'
+             : n + ' B';
+    return '\ud83d\udcce ' + msg.name + ' (' + size + ')';
+  }
+
+  CallSession.prototype.onData = function (cb) { this._dataCbs.push(cb); };
+
+  CallSession.prototype._emitData = function (msg, outbound) {
+    this.messages.push({ msg: msg, outbound: outbound });
+    this._dataCbs.forEach(function (cb) { try { cb(msg, outbound); } catch (_) {} });
+  };
+
+  // _recordData emits a browser-path message and persists it to chat history.
+  CallSession.prototype._recordData = function (msg, outbound) {
+    this._emitData(msg, outbound);
+    Goop.api.chat.callMessage({
+      peer_id:  this.remotePeerId,
+      call_id:  this.channelId,
+      content:  dataSummary(msg),
+      incoming: !outbound,
+    }).catch(function () {});
+  };
+
+  CallSession.prototype._openDataChannel = function (pc) {
+    var self = this;
+    var dc = pc.createDataChannel(DATA_LABEL, { negotiated: true, id: 0 });
+    dc.onmessage = function (e) {
+      var msg;
+      try { msg = JSON.parse(e.data); } catch (_) { return; }
+      if (msg.type === 'chat' && msg.content) {
+        self._recordData(msg, false);
+      } else if (msg.type === 'file') {
+        var whole = self._addFileChunk(msg);
+        if (whole) self._recordData(whole, false);
+      }
+    };
+    this._dc = dc;
+  };
+
+  CallSession.prototype._addFileChunk = function (msg) {
+    if (!(msg.total > 0) || msg.seq < 0 || msg.seq >= msg.total || msg.size > DATA_MAX_FILE) return null;
+    var f = this._fileParts[msg.id];
+    if (!f) f = this._fileParts[msg.id] = { chunks: new Array(msg.total), received: 0 };
+    if (f.chunks.length !== msg.total) { delete this._fileParts[msg.id]; return null; }
+    if (f.chunks[msg.seq] === undefined) f.received++;
+    f.chunks[msg.seq] = atob(msg.data || '');
+    if (f.received < msg.total) return null;
+    delete this._fileParts[msg.id];
+    var bin = f.chunks.join('');
+    if (bin.length !== msg.size) return null;
+    return { type: 'file', id: msg.id, name: msg.name, mime: msg.mime, size: msg.size, data: btoa(bin), ts: msg.ts };
+  };
+
+  CallSession.prototype._sendData = function (msg) {
+    if (!this._dc || this._dc.readyState !== 'open') throw new Error('call data channel not open');
+    this._dc.send(JSON.stringify(msg));
+  };
+
+  CallSession.prototype.sendChat = function (text) {
+    if (!text) return Promise.resolve();
+    if (_mode === 'native') {
+      return Goop.api.call.chat({ channel_id: this.channelId, content: text });
+    }
+    var msg = { type: 'chat', id: _newId(), content: text, ts: Date.now() };
+    try { this._sendData(msg); } catch (e) { return Promise.reject(e); }
+    this._recordData(msg, true);
+    return Promise.resolve(msg);
+  };
+
+  // sendFile accepts a File or Blob (name falls back to 'file').
+  CallSession.prototype.sendFile = async function (file) {
+    if (file.size > DATA_MAX_FILE) throw new Error('file exceeds ' + DATA_MAX_FILE + ' bytes');
+    var bytes = new Uint8Array(await file.arrayBuffer());
+    var name  = file.name || 'file';
+    var mime  = file.type || 'application/octet-stream';
+    if (_mode === 'native') {
+      return Goop.api.call.file({ channel_id: this.channelId, name: name, mime: mime, data: _b64FromBytes(bytes) });
+    }
+    var id    = _newId();
+    var ts    = Date.now();
+    var total = Math.max(1, Math.ceil(bytes.length / DATA_CHUNK));
+    for (var i = 0; i < total; i++) {
+      this._sendData({
+        type: 'file', id: id, name: name, mime: mime, size: bytes.length, ts: ts,
+        seq: i, total: total,
+        data: _b64FromBytes(bytes.subarray(i * DATA_CHUNK, (i + 1) * DATA_CHUNK)),
+      });
+    }
+    var msg = { type: 'file', id: id, name: name, mime: mime, size: bytes.length, ts: ts };
+    this._recordData(msg, true);
+    return msg;
+  };
+
+  // ── Live captions ──
+
+  CallSession.prototype.onCaption = function (cb) { this._captionCbs.push(cb); };
+
+  CallSession.prototype.setCaptions = function (on, lang) {
+    var self = this;
+    if (_mode !== 'native') return Promise.reject(new Error('captions need the native call stack'));
+    return Goop.api.call.captions({ channel_id: this.channelId, enabled: !!on, language: lang || '' })
+      .then(function (r) {
+        self.captions = on ? ((r && r.language) || lang || 'auto') : '';
+        return self.captions;
+      });
+  };
+
+  CallSession.prototype._emitCaption = function (payload) {
+    var c = { text: payload.text, lang: payload.lang, ts: payload.ts };
+    this._captionCbs.forEach(function (cb) { try { cb(c); } catch (_) {} });
+  };
+
+  // ── Device switching ──
+  //
+  // Swaps the camera or mic mid-call without renegotiating. Native mode: Go
+  // replaces the track on its RTPSender. Browser mode: a new track goes onto
+  // the existing sender via replaceTrack and into localStream.
+
+  CallSession.prototype.onDevice = function (cb) { this._deviceCbs.push(cb); };
+
+  CallSession.prototype._emitDevice = function (kind, state, deviceId) {
+    var d = { kind: kind, state: state, deviceId: deviceId || '' };
+    this._deviceCbs.forEach(function (cb) { try { cb(d); } catch (_) {} });
+  };
+
+  CallSession.prototype.switchDevice = async function (kind, deviceId) {
+    if (_mode === 'native') {
+      await Goop.api.call.device({ channel_id: this.channelId, kind: kind, device_id: deviceId || '' });
+      return;
+    }
+    if (!this.pc || !this.localStream) throw new Error('call has no local media');
+    var sender = this.pc.getSenders().find(function (s) { return s.track && s.track.kind === kind; });
+    if (!sender) throw new Error('call is not sending ' + kind);
+
+    var c = {};
+    c[kind] = deviceId ? { deviceId: { exact: deviceId } } : true;
+    var stream = await navigator.mediaDevices.getUserMedia(c);
+    var track = stream.getTracks()[0];
+    var old = sender.track;
+    track.enabled = old.enabled; // keep mute / hold state
+    await sender.replaceTrack(track);
+    this.localStream.removeTrack(old);
+    this.localStream.addTrack(track);
+    old.onended = null;
+    old.stop();
+    this._watchTrack(track);
+    this._emitDevice(kind, 'active', track.getSettings().deviceId);
+  };
+
+  // _watchTrack falls back to the default device when a browser capture
+  // track ends on its own (device unplugged).
+  CallSession.prototype._watchTrack = function (track) {
+    var self = this;
+    track.onended = function () {
+      if (self._ending) return;
+      log('warn', track.kind + ' device lost — falling back to default');
+      self.switchDevice(track.kind, '').catch(function (e) {
+        log('warn', 'no ' + track.kind + ' device left: ' + e.message);
+        self._emitDevice(track.kind, 'lost');
+      });
+    };
+  };
+
+  // ── Hold / transfer ──
+
+  CallSession.prototype.hold   = function () { return this._setHold(true); };
+  CallSession.prototype.resume = function () { return this._setHold(false); };
+
+  CallSession.prototype._setHold = function (on) {
+    if (this.onHold === on) return on;
+    this.onHold = on;
+    if (_mode === 'native') {
+      var fn = on ? Goop.api.call.hold : Goop.api.call.resume;
+      fn({ channel_id: this.channelId }).catch(function () {});
+    } else {
+      if (on && this.localStream) {
+        this._heldTracks = this.localStream.getTracks().filter(function (t) { return t.enabled; });
+        this._heldTracks.forEach(function (t) { t.enabled = false; });
+      } else if (!on) {
+        this._heldTracks.forEach(function (t) { t.enabled = true; });
+        this._heldTracks = [];
+      }
+      _sendMQ(this.remotePeerId, this.channelId, { type: on ? 'call-hold' : 'call-resume' });
+    }
+    this._emitState(on ? 'held' : (this.remoteHold ? 'remote-held' : 'connected'));
+    return on;
+  };
+
+  CallSession.prototype._handleRemoteHold = function (on) {
+    this.remoteHold = on;
+    log('info', (on ? 'remote hold' : 'remote resume') + ' on ' + this.channelId);
+    this._emitState(this.onHold ? 'held' : (on ? 'remote-held' : 'connected'));
+  };
+
+  // transfer hands the remote peer over to targetPeer: they receive
+  // call-transfer, dial the target themselves, and this call ends.
+  CallSession.prototype.transfer = function (targetPeer) {
+    log('info', 'transfer ' + this.channelId + ' → ' + targetPeer);
+    if (_mode === 'native') {
+      // Go sends call-transfer, then hangs up; the local call-hangup it
+      // publishes tears down this session.
+      return Goop.api.call.transfer({ channel_id: this.channelId, target_peer: targetPeer });
+    }
+    _sendMQ(this.remotePeerId, this.channelId, { type: 'call-transfer', target: targetPeer });
+    this.hangup();
+    return Promise.resolve();
+  };
+
+  CallSession.prototype._handleTransfer = function (targetPeer) {
+    var info = { from: this.remotePeerId, target: targetPeer, mediaType: this.mediaType };
+    this._handleRemoteHangup();
+    if (!targetPeer) return;
+    _transferCbs.forEach(function (cb) {
+      try { cb(info); } catch (e) { log('error', 'transfer cb error: ' + e); }
+    });
+  };
+
+  // _handleBusy: target is already in a call. End our outgoing attempt and
+  // let the UI offer a callback request.
+  CallSession.prototype._handleBusy = function (payload) {
+    var channelId = this.channelId;
+    var peerId    = this.remotePeerId;
+    log('info', peerId + ' is busy (' + channelId + ')');
+    this._handleRemoteHangup();
+    var info = {
+      channelId: channelId,
+      peerId:    peerId,
+      callback:  !!(payload && payload.callback),
+      requestCallback: function () {
+        if (_mode === 'native') {
+          return Goop.api.call.callback({ channel_id: channelId });
+        }
+        _sendMQ(peerId, channelId, { type: 'call-callback' });
+        return Promise.resolve();
+      },
+    };
+    _busyCbs.forEach(function (cb) {
+      try { cb(info); } catch (e) { log('error', 'busy cb error: ' + e); }
+    });
+  };
+
   // ── Hangup ──
 
   CallSession.prototype.hangup = function () {
@@ -237,6 +544,7 @@
     if (this._mediaWs)    { this._mediaWs.close();    this._mediaWs    = null; }
     if (this._selfWs)     { this._selfWs.close();     this._selfWs     = null; }
     if (this.pc)          { this.pc.close();           this.pc          = null; }
+    this._dc = null;
     if (this.localStream) {
       this.localStream.getTracks().forEach(function (t) { t.stop(); });
       this.localStream = null;
@@ -314,7 +622,11 @@
     this.pc = pc;
     var self = this;
 
-    stream.getTracks().forEach(function (t) { pc.addTrack(t, stream); });
+    stream.getTracks().forEach(function (t) {
+      pc.addTrack(t, stream);
+      self._watchTrack(t);
+    });
+    this._openDataChannel(pc);
 
     pc.ontrack = function (e) {
       if (e.streams && e.streams[0]) {
@@ -775,10 +1087,27 @@
     var type = payload.type;
 
     if (type === 'call-request') {
+      if (_isBusy(channelId)) {
+        // Already in a call: decline with call-busy instead of ringing.
+        // In native mode Go's call.Manager sends call-busy itself.
+        log('info', 'busy — declining call-request from ' + from + ' on ' + channelId);
+        if (_mode !== 'native') {
+          _sendMQ(from, channelId, { type: 'call-busy', callback: true });
+        }
+        return;
+      }
       _handleIncoming(channelId, from, payload);
       return;
     }
 
+    if (type === 'call-callback') {
+      log('info', from + ' asked to be called back');
+      _callbackCbs.forEach(function (cb) {
+        try { cb({ peerId: from, channelId: channelId }); } catch (e) { log('error', 'callback cb error: ' + e); }
+      });
+      return;
+    }
+
     // call-reconnect: remote peer navigated and is re-establishing the call.
     // Handled outside the sess lookup because it creates a new session.
     if (type === 'call-reconnect') {
@@ -795,6 +1124,18 @@
     else if (type === 'call-answer')        { sess._handleAnswer(payload.sdp); }
     else if (type === 'ice-candidate')      { sess._addIceCandidate(payload.candidate); }
     else if (type === 'call-hangup')        { sess._handleRemoteHangup(); }
+    else if (type === 'call-busy')          { sess._handleBusy(payload); }
+    else if (type === 'call-hold')          { sess._handleRemoteHold(true); }
+    else if (type === 'call-resume')        { sess._handleRemoteHold(false); }
+    else if (type === 'call-transfer')      { sess._handleTransfer(payload.target); }
+    else if (type === 'call-data' && !from) { sess._emitData(payload.data, !!payload.outbound); } // native: Go echo
+    else if (type === 'call-caption' && !from) { sess._emitCaption(payload); }                     // native: Go STT
+    else if (type === 'call-device' && !from)  { sess._emitDevice(payload.kind, payload.state, payload.device_id); } // native
+  }
+
+  // _isBusy reports whether a call other than channelId is already active.
+  function _isBusy(channelId) {
+    return Object.keys(_sessions).some(function (k) { return k !== channelId; });
   }
 
   // ── Reconnect handling (browser mode, page navigation) ───────────────────────
@@ -1041,12 +1382,114 @@
       _restoreCbs.push(cb);
     },
 
+    /**
+     * Register a handler for outgoing calls answered with call-busy.
+     * cb is called with { channelId, peerId, callback, requestCallback() }.
+     */
+    onBusy: function (cb) {
+      _busyCbs.push(cb);
+      _ensureMQSubscription();
+    },
+
+    /**
+     * Register a handler for peers that found us busy and asked for a callback.
+     * cb is called with { peerId, channelId }.
+     */
+    onCallbackRequest: function (cb) {
+      _callbackCbs.push(cb);
+      _ensureMQSubscription();
+    },
+
+    /**
+     * Register a handler for calls transferred to us.
+     * cb is called with { from, target, mediaType }; the handler dials target.
+     */
+    onTransfer: function (cb) {
+      _transferCbs.push(cb);
+      _ensureMQSubscription();
+    },
+
     /**
      * Snapshot of active sessions (used by peer.js to detect existing calls on load).
      */
     activeCalls: function () {
       return Object.keys(_sessions).map(function (k) { return _sessions[k]; });
     },
+
+    /**
+     * Whether live captions can be turned on (native mode with a caption command).
+     */
+    captionsAvailable: function () {
+      return (_modePromise || Promise.resolve()).then(function () { return _captions; });
+    },
+
+    /**
+     * Noise/echo suppression on the native mic: resolves to
+     * { noise_suppression, echo_cancellation }, or null in browser mode
+     * (getUserMedia applies the browser's own processing there).
+     */
+    audioProcessing: function () {
+      return (_modePromise || Promise.resolve()).then(function () {
+        if (_mode !== 'native') return null;
+        return Goop.api.call.audioProcessing();
+      });
+    },
+
+    /**
+     * Change the native mic processing; p may carry either toggle.
+     * Applies to calls in progress.
+     */
+    setAudioProcessing: function (p) {
+      return Goop.api.call.setAudioProcessing(p);
+    },
+
+    /**
+     * Capture devices for the active call stack: resolves to
+     * [{ id, kind: 'video' | 'audio', label }]. Native mode lists what Go
+     * can open; browser mode what getUserMedia can.
+     */
+    devices: function () {
+      return (_modePromise || Promise.resolve()).then(function () {
+        if (_mode === 'native') {
+          return Goop.api.call.devices().then(function (r) { return (r && r.devices) || []; });
+        }
+        var md = navigator.mediaDevices;
+        if (!md || !md.enumerateDevices) return [];
+        // Labels stay empty until the page has had media permission once;
+        // briefly grab media to trigger the prompt.
+        return md.enumerateDevices().then(function (list) {
+          if (list.some(function (d) { return !!d.label; })) return;
+          return md.getUserMedia({ audio: true, video: true })
+            .then(function (st) { st.getTracks().forEach(function (t) { t.stop(); }); })
+            .catch(function () { /* denied — ids without labels */ });
+        }).then(function () {
+          return md.enumerateDevices();
+        }).then(function (list) {
+          return list.filter(function (d) {
+            return d.deviceId && (d.kind === 'videoinput' || d.kind === 'audioinput');
+          }).map(function (d) {
+            var kind = d.kind === 'videoinput' ? 'video' : 'audio';
+            return { id: d.deviceId, kind: kind, label: d.label || (kind + ' ' + d.deviceId.substring(0, 8)) };
+          });
+        });
+      });
+    },
+
+    /**
+     * Move every active call to new devices, e.g. after a settings change.
+     * p: { cam, mic } — a key that is undefined is left alone.
+     */
+    useDevices: function (p) {
+      var jobs = [];
+      Object.keys(_sessions).forEach(function (k) {
+        var sess = _sessions[k];
+        if (p.cam !== undefined) jobs.push(sess.switchDevice('video', p.cam));
+        if (p.mic !== undefined) jobs.push(sess.switchDevice('audio', p.mic));
+      });
+      return Promise.all(jobs.map(function (j) {
+        return j.catch(function (e) { log('warn', 'device switch: ' + e.message); });
+      }));
+    },
   };
 
   // ── Initialise — fetch mode, store as an awaitable promise ──────────────────
@@ -1069,6 +1512,10 @@
       .then(function (j) {
         _mode     = j.mode     || 'browser';
         _platform = j.platform || document.body.getAttribute('data-os') || 'unknown';
+        if (Array.isArray(j.stun_servers) && j.stun_servers.length) {
+          ICE_SERVERS = [{ urls: j.stun_servers }];
+        }
+        _captions = !!j.captions;
         if (!sessionStorage.getItem('call:mode-logged')) {
           sessionStorage.setItem('call:mode-logged', '1');
           log('info', 'mode=' + _mode + ' platform=' + _platform);
@@ -1099,6 +1546,7 @@
           if (_sessions[s.channel_id]) return; // already tracked
           log('info', 'restoring call session: ' + s.channel_id + ' remote=' + s.remote_peer);
           var sess = new CallSession(s.channel_id, s.remote_peer, s.is_origin, 'video');
+          sess.captions = s.captions || '';
           _sessions[s.channel_id] = sess;
           sess.onHangup(function () { delete _sessions[s.channel_id]; });
           _setCallState('connected', s.channel_id, s.remote_peer);
@@ -53,15 +53,31 @@
     return d.innerHTML;
   }
 
+  function idempotencyKey() {
+    if (window.crypto && window.crypto.randomUUID) return window.crypto.randomUUID();
+    return Date.now().toString(36) + Math.random().toString(36).slice(2);
+  }
+
   async function api(url, body) {
-    var resp = await fetch(url, {
+    var init = {
       method: body !== undefined ? "POST" : "GET",
       headers: body !== undefined ? { "Content-Type": "application/json" } : {},
       body: body !== undefined ? JSON.stringify(body) : undefined,
-    });
+    };
+    var resp;
+    if (body === undefined) {
+      resp = await fetch(url, init);
+    } else {
+      // Retry once after a network error; the key makes the retry safe.
+      init.headers["Idempotency-Key"] = idempotencyKey();
+      try {
+        resp = await fetch(url, init);
+      } catch (_) {
+        resp = await fetch(url, init);
+      }
+    }
     if (!resp.ok) {
-      var text = (await resp.text()).trim();
-      throw new Error(text || resp.statusText);
+      throw await errorFromResponse(resp);
     }
     var ct = resp.headers.get("Content-Type") || "";
     if (ct.includes("application/json")) {
@@ -70,6 +86,24 @@
     return null;
   }
 
+  // errorFromResponse turns a failed response into an Error. /api/ errors
+  // arrive as {"error": {"code", "message", "field"}}; code and field are
+  // copied onto the Error. Other bodies become the message as they are.
+  async function errorFromResponse(resp) {
+    var text = (await resp.text().catch(function() { return ""; })).trim();
+    var err = new Error(text || resp.statusText);
+    try {
+      var body = JSON.parse(text);
+      if (body && body.error && body.error.message) {
+        err.message = body.error.message;
+        err.code = body.error.code;
+        err.field = body.error.field;
+      }
+    } catch (_) {}
+    err.status = resp.status;
+    return err;
+  }
+
   function copyToClipboard(text, element) {
     navigator.clipboard.writeText(text).then(function() {
       if (element) {
@@ -201,6 +235,7 @@
     safeLocalStorageSet,
     escapeHtml,
     api,
+    errorFromResponse,
     toast,
     copyToClipboard,
     callDisabledReason,
@@ -15,9 +15,21 @@
   function Goop2Client(baseURL) {
     baseURL = (baseURL || '').replace(/\/$/, '');
 
+    // Errors come as {"error": {code, message, field}}; the Error carries
+    // all three, and the HTTP status.
+    function _error(r, text) {
+      var err = new Error(text.trim() || r.statusText);
+      try {
+        var e = JSON.parse(text).error;
+        if (e && e.message) { err.message = e.message; err.code = e.code; err.field = e.field; }
+      } catch (_) {}
+      err.status = r.status;
+      return err;
+    }
+
     function _get(path) {
       return fetch(baseURL + path).then(function (r) {
-        if (!r.ok) return r.text().then(function (t) { throw new Error(t.trim() || r.statusText); });
+        if (!r.ok) return r.text().then(function (t) { throw _error(r, t); });
         var ct = r.headers.get('Content-Type') || '';
         return ct.includes('application/json') ? r.json() : null;
       });
@@ -29,7 +41,7 @@
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(body !== undefined ? body : {}),
       }).then(function (r) {
-        if (!r.ok) return r.text().then(function (t) { throw new Error(t.trim() || r.statusText); });
+        if (!r.ok) return r.text().then(function (t) { throw _error(r, t); });
         var ct = r.headers.get('Content-Type') || '';
         return ct.includes('application/json') ? r.json() : null;
       });
@@ -37,7 +49,7 @@
 
     function _delete(path) {
       return fetch(baseURL + path, { method: 'DELETE' }).then(function (r) {
-        if (!r.ok) return r.text().then(function (t) { throw new Error(t.trim() || r.statusText); });
+        if (!r.ok) return r.text().then(function (t) { throw _error(r, t); });
         var ct = r.headers.get('Content-Type') || '';
         return ct.includes('application/json') ? r.json() : null;
       });
@@ -65,7 +77,10 @@
       mq: {
         send:   function (p) { return _post('/api/mq/send', p); },
         ack:    function (p) { return _post('/api/mq/ack', p); },
-        events: function ()  { return new EventSource(baseURL + '/api/mq/events'); },
+        events: function (topics) {
+          var qs = topics && topics.length ? '?topics=' + encodeURIComponent(topics.join(',')) : '';
+          return new EventSource(baseURL + '/api/mq/events' + qs);
+        },
       },
 
       // ── Call ─────────────────────────────────────────────────────────────────
@@ -91,6 +106,9 @@
         invite:             function (p) { return _post('/api/groups/invite', p); },
         join:               function (p) { return _post('/api/groups/join', p); },
         joinOwn:            function (p) { return _post('/api/groups/join-own', p); },
+        addCoHost:          function (p) { return _post('/api/groups/cohost', p); },
+        removeCoHost:       function (p) { return _post('/api/groups/cohost/remove', p); },
+        transfer:           function (p) { return _post('/api/groups/transfer', p); },
         kick:               function (p) { return _post('/api/groups/kick', p); },
         leave:              function (p) { return _post('/api/groups/leave', p); },
         leaveOwn:           function (p) { return _post('/api/groups/leave-own', p); },
@@ -112,10 +130,15 @@
         control:   function (p) { return _post('/api/listen/control', p); },
         join:      function (p) { return _post('/api/listen/join', p); },
         leave:     function ()  { return _post('/api/listen/leave'); },
+        request:   function (p) { return _post('/api/listen/request', p); },
+        requests:  function ()  { return _get('/api/listen/requests'); },
+        approve:   function (p) { return _post('/api/listen/requests/approve', p); },
+        reject:    function (p) { return _post('/api/listen/requests/reject', p); },
+        jukebox:   function (p) { return _post('/api/listen/jukebox', p); },
         streamUrl: function ()  { return baseURL + '/api/listen/stream'; },
         // Subscribe to listen state changes via MQ SSE
         subscribe: function (callback) {
-          var es = new EventSource(baseURL + '/api/mq/events');
+          var es = new EventSource(baseURL + '/api/mq/events?topics=listen:*');
           es.addEventListener('message', function (ev) {
             try {
               var msg = JSON.parse(ev.data);
@@ -130,7 +153,16 @@
 
       // ── Peers ─────────────────────────────────────────────────────────────────
       peers: {
-        list:     function ()   { return _get('/api/peers'); },
+        list:     function (ns) { return _get('/api/peers' + (ns ? '?namespace=' + encodeURIComponent(ns) : '')); },
+        namespaces: function () { return _get('/api/peers/namespaces'); },
+        // opts: { limit, offset, q, by, sort, favorites, online, verified, namespace }
+        page: function (opts) {
+          var qs = new URLSearchParams({ limit: 0 });
+          Object.keys(opts || {}).forEach(function (k) {
+            if (opts[k] !== undefined && opts[k] !== '') qs.set(k, opts[k]);
+          });
+          return _get('/api/peers?' + qs.toString());
+        },
         self:     function ()   { return _get('/api/self'); },
         content:  function (id) { return _get('/api/peer/content?id=' + encodeURIComponent(id)); },
         favorite: function (p)  { return _post('/api/peers/favorite', p); },
@@ -163,7 +195,11 @@
         browse: function (groupId) {
           return _get('/api/docs/browse?group_id=' + encodeURIComponent(groupId));
         },
+        refresh: function (groupId, peerIds) {
+          return _post('/api/docs/browse/refresh', { group_id: groupId, peer_ids: peerIds });
+        },
         delete: function (p) { return _post('/api/docs/delete', p); },
+        acl: function (p) { return _post('/api/docs/acl', p); },
         downloadUrl: function (groupId, file, peerId, inline) {
           var qs = '?group_id=' + encodeURIComponent(groupId) + '&file=' + encodeURIComponent(file);
           if (peerId) qs += '&peer_id=' + encodeURIComponent(peerId);
@@ -153,6 +153,7 @@
               '<div class="groups-card-name">' + escapeHtml(g.name) +
                 typeBadge(g.group_type) +
                 (g.host_in_group ? ' <span class="badge badge-connected">joined</span>' : '') +
+                (g.large ? ' <span class="badge badge-role" title="Messages spread over a GossipSub topic">large</span>' : '') +
               '</div>' +
               '<div class="groups-card-meta">' +
                 (showMgmt ? '<code>' : 'ID: <code>') + escapeHtml(shortId(g.id)) + '</code>' +
@@ -216,6 +217,20 @@
               g.members.map(function(m) {
                 var isSelf = m.peer_id === selfId;
                 var label = m.name || shortId(m.peer_id);
+                var isCoHost = (g.cohosts || []).indexOf(m.peer_id) !== -1;
+                var relayNote = '';
+                if (m.relay && m.relay !== selfId && m.relay !== m.peer_id) {
+                  var via = g.members.filter(function(r) { return r.peer_id === m.relay; })[0];
+                  relayNote = ' <span class="groups-member-relay">via ' + escapeHtml((via && via.name) || shortId(m.relay)) + '</span>';
+                }
+                var coHostBtn = '';
+                if (!isSelf && (isCoHost || (g.can_cohost && (!m.relay || m.relay === selfId)))) {
+                  coHostBtn = '<button class="groups-cohost-btn' + (isCoHost ? ' active' : '') + '" data-group="' + gid + '" data-peer="' + escapeHtml(m.peer_id) + '" data-cohost="' + (isCoHost ? '1' : '0') + '" title="' + (isCoHost ? 'Stop co-hosting' : 'Make co-host') + '">&#8644;</button>';
+                }
+                var handOverBtn = '';
+                if (!isSelf && g.can_hand_over && (!m.relay || m.relay === selfId)) {
+                  handOverBtn = '<button class="groups-handover-btn" data-group="' + gid + '" data-peer="' + escapeHtml(m.peer_id) + '" data-name="' + escapeHtml(label) + '" title="Hand group over">&#9819;</button>';
+                }
                 var roleCell = '';
                 if (hasRoles) {
                   if (isSelf) {
@@ -232,9 +247,9 @@
                 }
                 return '<tr>' +
                   '<td class="gmt-avatar"><img class="groups-member-avatar" src="/api/avatar/peer/' + encodeURIComponent(m.peer_id) + '"></td>' +
-                  '<td class="gmt-name">' + escapeHtml(label) + '</td>' +
+                  '<td class="gmt-name">' + escapeHtml(label) + (isCoHost ? ' <span class="badge badge-role">co-host</span>' : '') + relayNote + '</td>' +
                   roleCell +
-                  '<td class="gmt-actions">' + (!isSelf ? '<button class="groups-kick-btn" data-group="' + gid + '" data-peer="' + escapeHtml(m.peer_id) + '" title="Remove">&#10005;</button>' : '') + '</td>' +
+                  '<td class="gmt-actions">' + coHostBtn + handOverBtn + (!isSelf ? '<button class="groups-kick-btn" data-group="' + gid + '" data-peer="' + escapeHtml(m.peer_id) + '" title="Remove">&#10005;</button>' : '') + '</td>' +
                 '</tr>';
               }).join('') +
               '</tbody></table>';
@@ -377,6 +392,32 @@
           });
         });
 
+        // Co-host — toggle
+        containerEl.querySelectorAll('.groups-cohost-btn').forEach(function(btn) {
+          on(btn, 'click', function() {
+            var req = { group_id: btn.getAttribute('data-group'), peer_id: btn.getAttribute('data-peer') };
+            var removing = btn.getAttribute('data-cohost') === '1';
+            (removing ? Goop.api.groups.removeCoHost(req) : Goop.api.groups.addCoHost(req)).then(function() {
+              toast(removing ? 'Co-host removed' : 'Co-host added');
+              renderHostedGroups(containerEl, opts);
+            }).catch(function(err) { toast('Co-host update failed: ' + err.message, true); });
+          });
+        });
+
+        // Hand over — the member becomes the owner and everyone follows it
+        containerEl.querySelectorAll('.groups-handover-btn').forEach(function(btn) {
+          on(btn, 'click', function() {
+            var req = { group_id: btn.getAttribute('data-group'), peer_id: btn.getAttribute('data-peer') };
+            Goop.dialog.confirm('Hand this group over to ' + btn.getAttribute('data-name') + '? They become its owner and you rejoin as a member if you were in it.', 'Hand Over Group').then(function(ok) {
+              if (!ok) return;
+              Goop.api.groups.transfer(req).then(function() {
+                toast('Handing group over');
+                setTimeout(function() { renderHostedGroups(containerEl, opts); }, 1000);
+              }).catch(function(err) { toast('Hand over failed: ' + err.message, true); });
+            });
+          });
+        });
+
         // Kick
         containerEl.querySelectorAll('.groups-kick-btn').forEach(function(btn) {
           on(btn, 'click', function() {
@@ -431,7 +472,7 @@
         var isListen = s.group_type === 'listen';
         var isFiles = s.group_type === 'files';
         if (isListen && isActive) hasListenSub = true;
-        var cardDisabled = !isActive && !s.host_reachable;
+        var cardDisabled = !isActive && !s.cohosting && !s.host_reachable;
 
         html += '<div class="' + (isListen && isActive ? 'groups-card-wrap' : '') + '">' +
           '<div class="groups-card' + (cardDisabled ? ' dimmed' : '') + '">' +
@@ -439,16 +480,18 @@
               '<div class="groups-card-name">' + escapeHtml(displayName) +
                 typeBadge(s.group_type) +
                 (isActive ? ' <span class="badge badge-connected">connected</span>' : '') +
+                (s.cohosting ? ' <span class="badge badge-connected">co-hosting</span>' : '') +
               '</div>' +
               '<div class="groups-card-meta">Host: <code>' + escapeHtml(s.host_name || shortId(s.host_peer_id)) + '</code>' +
                 (s.role ? ' &middot; ' + escapeHtml(s.role) : '') +
+                (s.relays && s.relays.length > 1 ? ' &middot; ' + s.relays.length + ' relays' : '') +
               '</div>' +
             '</div>' +
             '<div class="groups-card-members">' + (s.member_count > 0 ? memberLabel(s.member_count) : '') + '</div>' +
             '<div class="groups-card-actions">' +
               '<span' + (cardDisabled ? ' inert' : '') + '>' +
                 (isFiles ? '<a class="groups-action-btn groups-btn-primary" href="/documents?group_id=' + encodeURIComponent(s.group_id) + '">Browse Files</a>' : '') +
-                (isActive
+                (s.cohosting ? '' : isActive
                   ? '<button class="groups-action-btn groups-btn-danger grph-leave-sub-btn" data-group="' + escapeHtml(s.group_id) + '">Leave</button>'
                   : '<button class="groups-action-btn groups-btn-primary grph-rejoin-btn" data-host="' + escapeHtml(s.host_peer_id) + '" data-group="' + escapeHtml(s.group_id) + '"' + (s.host_reachable ? '' : ' disabled title="Host is offline"') + '>Rejoin</button>') +
               '</span>' +
@@ -36,6 +36,15 @@
     navView.classList.toggle('hidden', window._openSitesExternal);
   }
 
+  // ── Service worker: installable UI + offline shell (browser only) ──
+  // The desktop app embeds the viewer and has a bridge URL; it needs neither.
+  // Browsers only allow workers in a secure context (https or localhost).
+  if ('serviceWorker' in navigator && window.isSecureContext && !window.Goop.bridgeURL) {
+    navigator.serviceWorker.register('/sw.js').catch(function(err) {
+      console.warn('service worker registration failed:', err);
+    });
+  }
+
   // ── Chat notifications (only when logged in) ──
   var selfID = document.body.dataset.selfId;
   if (selfID) {
@@ -29,6 +29,12 @@
     join:       function (hostPeerId, gid) { return Goop.api.listen.join({ host_peer_id: hostPeerId, group_id: gid }); },
     leave:      function ()                { return Goop.api.listen.leave(); },
     state:      function ()                { return Goop.api.listen.state(); },
+    request:    function (track)           { return Goop.api.listen.request({ track: track }); },
+    requests:   function ()                { return Goop.api.listen.requests(); },
+    approve:    function (id, filePath)    { return Goop.api.listen.approve({ id: id, file_path: filePath || '' }); },
+    reject:     function (id)              { return Goop.api.listen.reject({ id: id }); },
+    jukebox:    function (enabled)         { return Goop.api.listen.jukebox({ enabled: !!enabled }); },
+    report:     function (elapsed)         { return Goop.api.listen.report({ elapsed: elapsed }); },
 
     // subscribe(callback) — MQ subscription for peer sites.
     // callback receives the group object (or null) on every state change.
@@ -58,6 +64,7 @@
   // ── Audio / visualizer state ─────────────────────────────────────────────────
 
   var listenTimers = {};
+  var listenStreamGen = 0;   // the host's last resync of this listener
   var listenAudioEl = null;
   var listenAudioCtx = null;
   var listenAnalyser = null;
@@ -215,6 +222,16 @@
       '<button class="groups-action-btn groups-btn-secondary glisten-add-stream-btn">&#128225; Add Stream</button>';
     html += '</div>';
 
+    if (g) {
+      html += '<div class="glisten-requests">' +
+        '<label class="glisten-jukebox muted small">' +
+          '<input type="checkbox" class="glisten-jukebox-toggle"' + (g.jukebox ? ' checked' : '') + ' /> ' +
+          'Jukebox: queue listener requests automatically' +
+        '</label>' +
+        '<div class="glisten-request-list"></div>' +
+      '</div>';
+    }
+
     if (g && g.queue_total > 0 && g.queue && g.queue.length > 0) {
       html += '<div class="glisten-queue scroll-bounded">';
       g.queue.forEach(function(name, i) {
@@ -332,6 +349,20 @@
       });
     }
 
+    var jukeboxToggle = wrapperEl.querySelector('.glisten-jukebox-toggle');
+    if (jukeboxToggle) {
+      on(jukeboxToggle, 'change', function() {
+        api.jukebox(jukeboxToggle.checked).catch(function(e) {
+          jukeboxToggle.checked = !jukeboxToggle.checked;
+          toast('Jukebox failed: ' + e.message, true);
+        });
+      });
+    }
+
+    if (g && g.pending_requests > 0) {
+      renderRequests(wrapperEl.querySelector('.glisten-request-list'));
+    }
+
     var volEl = wrapperEl.querySelector('.glisten-volume');
     if (volEl) {
       on(volEl, 'input', function() {
@@ -447,15 +478,83 @@
     }
   }
 
+  // ── Listener requests (host side) ────────────────────────────────────────────
+
+  function renderRequests(listEl) {
+    if (!listEl) return;
+    api.requests().then(function(data) {
+      var reqs = (data && data.requests) || [];
+      var names = (data && data.peer_names) || {};
+      var html = '';
+      reqs.forEach(function(r) {
+        var who = names[r.peer_id] || r.peer_id.substring(0, 8) + '\u2026';
+        html += '<div class="glisten-request-item" data-request-id="' + escapeHtml(r.id) + '">' +
+          '<span class="glisten-request-name">' + escapeHtml(r.name) + '</span>' +
+          '<span class="glisten-request-from muted small">' + escapeHtml(who) + '</span>' +
+          '<button class="groups-action-btn groups-btn-primary glisten-request-approve">Queue</button>' +
+          '<button class="groups-action-btn groups-btn-secondary glisten-request-reject">Reject</button>' +
+          '</div>';
+      });
+      listEl.innerHTML = html;
+
+      listEl.querySelectorAll('.glisten-request-item').forEach(function(item) {
+        var id = item.getAttribute('data-request-id');
+        on(item.querySelector('.glisten-request-approve'), 'click', function() {
+          api.approve(id).catch(function(e) {
+            // The name did not match a file next to the queued tracks;
+            // let the host pick one through the bridge if there is one.
+            var bridgeURL = window.Goop && window.Goop.bridgeURL || '';
+            if (!bridgeURL) { toast('Queue failed: ' + e.message, true); return; }
+            fetch(bridgeURL + '/select-files?title=' + encodeURIComponent('Choose the requested track'), { method: 'POST' })
+              .then(function(r) { return r.json(); })
+              .then(function(sel) {
+                if (sel.cancelled || !sel.paths || sel.paths.length === 0) return;
+                return api.approve(id, sel.paths[0]);
+              })
+              .catch(function(e2) { toast('Queue failed: ' + e2.message, true); });
+          });
+        });
+        on(item.querySelector('.glisten-request-reject'), 'click', function() {
+          api.reject(id).catch(function(e) { toast('Reject failed: ' + e.message, true); });
+        });
+      });
+    }).catch(function(e) { log('warn', 'requests failed: ' + e); });
+  }
+
+  function requestFormHtml() {
+    return '<div class="glisten-request-form">' +
+      '<input type="text" class="glisten-request-input" placeholder="Request a track: file name or https://..." />' +
+      '<button class="groups-action-btn groups-btn-secondary glisten-request-btn">Request</button>' +
+      '</div>';
+  }
+
+  function bindRequestForm(wrapperEl) {
+    var input = wrapperEl.querySelector('.glisten-request-input');
+    var btn = wrapperEl.querySelector('.glisten-request-btn');
+    if (!input || !btn) return;
+    on(btn, 'click', function() {
+      var track = input.value.trim();
+      if (!track) return;
+      api.request(track).then(function() {
+        input.value = '';
+        toast('Request sent to the host');
+      }).catch(function(e) { toast('Request failed: ' + e.message, true); });
+    });
+  }
+
   // ── Listener player renderer ─────────────────────────────────────────────────
 
   function renderListenerPlayer(wrapperEl, groupState) {
     var g = groupState;
     var gid = (g && g.id) || 'listener';
+    var reportKey = gid + ':report';
     if (listenTimers[gid]) { clearInterval(listenTimers[gid]); delete listenTimers[gid]; }
+    if (listenTimers[reportKey]) { clearInterval(listenTimers[reportKey]); delete listenTimers[reportKey]; }
 
     if (!g || !g.track) {
-      wrapperEl.innerHTML = '<div class="groups-listen-waiting">Waiting for host to play a track...</div>';
+      wrapperEl.innerHTML = '<div class="groups-listen-waiting">Waiting for host to play a track...</div>' +
+        (g ? requestFormHtml() : '');
+      bindRequestForm(wrapperEl);
       stopVisualizer();
       return;
     }
@@ -489,9 +588,11 @@
         '<input type="range" class="glisten-volume" min="0" max="100" value="80" />' +
       '</div>' +
     '</div>' +
-    '</div>';
+    '</div>' +
+    requestFormHtml();
 
     wrapperEl.innerHTML = html;
+    bindRequestForm(wrapperEl);
 
     if (g.play_state) {
       var fillEl = wrapperEl.querySelector('.gprogress-fill');
@@ -516,7 +617,11 @@
 
         var audio = ensureAudioEl();
         var playFallback = wrapperEl.querySelector('.glisten-play-fallback');
-        if (!audio.src || audio.networkState !== 2) {
+        // A resync closed our stream; reconnect so the host restarts it
+        // at its current position.
+        var resynced = (g.stream_gen || 0) !== listenStreamGen;
+        listenStreamGen = g.stream_gen || 0;
+        if (resynced || !audio.src || audio.networkState !== 2) {
           audio.src = '';
           audio.src = Goop.api.listen.streamUrl();
           audio.volume = 0.8;
@@ -532,6 +637,14 @@
           });
         }
         startVisualizer(wrapperEl.querySelector('.glisten-wave'));
+
+        // Tell the host how far we got, so it can resync us if we drift.
+        if (!g.track.is_stream) {
+          listenTimers[reportKey] = setInterval(function() {
+            if (audio.paused || audio.readyState < 2) return;
+            api.report(audio.currentTime).catch(function(e) { log('warn', 'report failed: ' + e); });
+          }, 5000);
+        }
       } else {
         stopVisualizer();
         stopStallMonitor();
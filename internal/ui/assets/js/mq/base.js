@@ -140,11 +140,19 @@
   var _es = null;
   var _esReconnectTimer = null;
 
+  // Last journal event ID seen — persisted per tab so a webview reload can
+  // resume the stream (?since=) instead of starting from scratch.
+  var _lastEventId = sessionStorage.getItem("mq:last-event-id") || "";
+
   function ensureSSE() {
     if (_es) return;
-    _es = new EventSource("/api/mq/events");
+    _es = new EventSource("/api/mq/events" + (_lastEventId ? "?since=" + encodeURIComponent(_lastEventId) : ""));
 
     _es.addEventListener("message", function (e) {
+      if (e.lastEventId) {
+        _lastEventId = e.lastEventId;
+        sessionStorage.setItem("mq:last-event-id", _lastEventId);
+      }
       try {
         var evt = JSON.parse(e.data);
         handleSSEEvent(evt);
@@ -175,6 +183,13 @@
       return;
     }
 
+    if (evt.type === "reset") {
+      // Missed events could not be replayed — let pages reload their state.
+      log("warn", "MQ: event journal gap, resync required");
+      dispatch("", "mq:reset", {}, function () {});
+      return;
+    }
+
     if (evt.type === "delivered") {
       markDelivered(evt.msg_id);
       return;
@@ -254,13 +269,14 @@
   }
 
   // ── Core send ────────────────────────────────────────────────────────────────
-  function mqSend(peerID, topic, payload) {
+  function mqSend(peerID, topic, payload, opts) {
     var msgId = uuid4();
     var entry = {
       id:          msgId,
       peerID:      peerID,
       topic:       topic,
       payload:     payload,
+      queue:       !!(opts && opts.queue),
       status:      "pending",
       attempts:    0,
       created:     Date.now(),
@@ -292,10 +308,14 @@
         topic:   entry.topic,
         payload: entry.payload,
         msg_id:  entry.id,
+        queue:   entry.queue || undefined,
       }),
     }).then(function (res) {
       if (!res.ok) {
-        return res.text().then(function (t) { throw new Error("HTTP " + res.status + ": " + t); });
+        return window.Goop.core.errorFromResponse(res).then(function (e) {
+          e.message = "HTTP " + res.status + ": " + e.message;
+          throw e;
+        });
       }
       return res.json();
     });
@@ -335,8 +355,10 @@
 
   window.Goop.mq = {
     /**
-     * send(peerID, topic, payload) → Promise<{msg_id, status}>
+     * send(peerID, topic, payload, opts) → Promise<{msg_id, status}>
      * Writes to IndexedDB outbox, POSTs to /api/mq/send. Retried on failure.
+     * opts.queue: if the peer is offline the server keeps the message and
+     * delivers it later; resolves with status "queued".
      */
     send: mqSend,
 
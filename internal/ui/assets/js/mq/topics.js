@@ -23,6 +23,10 @@
  *   identity                  P2P            request peer's full identity
  *   identity.response         P2P            full identity reply
  *   log:mq                    Go → browser   MQ event log entry (PublishLocal)
+ *   security.consent          Go → browser   inbound docs/data access prompt (PublishLocal)
+ *   call.ringing              Go → browser   incoming call is ringing (PublishLocal)
+ *   call.missed               Go → browser   incoming call went unanswered (PublishLocal)
+ *   template:update-available Go → browser   store has a newer installed template (PublishLocal)
  *
  * ── Call signaling protocol ───────────────────────────────────────────────────
  *
@@ -63,6 +67,12 @@
     LOG_MQ:                "log:mq",
     LOG_CALL:              "log:call",
     RELAY_STATUS:          "relay:status",
+    SECURITY_CONSENT:      "security.consent",
+    CALL_RINGING:          "call.ringing",
+    CALL_MISSED:           "call.missed",
+    TEMPLATE_UPDATE:       "template:update-available",
+    DOCS_DOWNLOAD_PREFIX:  "docs:download:",   // + filename + ":progress"
+    HEALTH_STARTUP:        "health:startup",
   });
 
   // ── Call signal type constants ────────────────────────────────────────────────
@@ -74,6 +84,14 @@
     ANSWER:       "call-answer",   // callee → caller: SDP answer
     ICE:          "ice-candidate", // either → other: trickle ICE candidate
     HANGUP:       "call-hangup",   // either side: end the call
+    BUSY:         "call-busy",     // callee → caller: already in a call
+    CALLBACK:     "call-callback", // caller → callee: please call back
+    HOLD:         "call-hold",     // either side: put on hold
+    RESUME:       "call-resume",   // either side: take off hold
+    TRANSFER:     "call-transfer", // either side: receiver dials payload.target
+    DATA:         "call-data",     // Go → browser: in-call chat / file drop (native mode)
+    CAPTION:      "call-caption",  // Go → browser: live caption text (native mode)
+    DEVICE:       "call-device",   // Go → browser: capture device switched or lost (native mode)
     LOOPBACK_ICE: "loopback-ice",  // Go → browser: LocalPC ICE candidate (Phase 4)
   });
 
@@ -139,6 +157,45 @@
    */
   mq.onRelayStatus = function (fn) { return mq.subscribe(mq.TOPICS.RELAY_STATUS, fn); };
 
+  /**
+   * onSecurityConsent(fn) — a peer is waiting for access to docs/data.
+   * fn(from, topic, payload, ack) — payload: { id, peer_id, protocol, created }
+   */
+  mq.onSecurityConsent = function (fn) { return mq.subscribe(mq.TOPICS.SECURITY_CONSENT, fn); };
+
+  /**
+   * onCallRinging(fn) — an incoming call started ringing (play a ring tone).
+   * fn(from, topic, payload, ack) — payload: { channel_id, peer_id, media }
+   */
+  mq.onCallRinging = function (fn) { return mq.subscribe(mq.TOPICS.CALL_RINGING, fn); };
+
+  /**
+   * onCallMissed(fn) — an incoming call went unanswered.
+   * fn(from, topic, payload, ack) — payload: { channel_id, peer_id, media, started_at, missed }
+   *   missed: unseen missed-call count (for badges)
+   */
+  mq.onCallMissed = function (fn) { return mq.subscribe(mq.TOPICS.CALL_MISSED, fn); };
+
+  /**
+   * onTemplateUpdate(fn) — the store has a newer version of the installed template.
+   * fn(from, topic, payload, ack) — payload: { template, name, installed, latest }
+   */
+  mq.onTemplateUpdate = function (fn) { return mq.subscribe(mq.TOPICS.TEMPLATE_UPDATE, fn); };
+
+  /**
+   * onDocsDownload(fn) — progress of shared file downloads from peers.
+   * fn(from, topic, payload, ack) — payload: { peer_id, group_id, file, done, total, state, error }
+   *   state: "progress", "done" or "error"
+   */
+  mq.onDocsDownload = function (fn) { return mq.subscribe(mq.TOPICS.DOCS_DOWNLOAD_PREFIX + "*", fn); };
+
+  /**
+   * onHealthStartup(fn) — what the startup checks found, once per start.
+   * fn(from, topic, payload, ack) — payload: { unclean, healthy, repaired, failed }
+   *   Details at GET /api/health.
+   */
+  mq.onHealthStartup = function (fn) { return mq.subscribe(mq.TOPICS.HEALTH_STARTUP, fn); };
+
   // ── Typed send helpers — call protocol ───────────────────────────────────────
 
   /**
@@ -203,9 +260,9 @@
 
   // ── Typed send helpers — chat ────────────────────────────────────────────────
 
-  /** sendChat(peerId, payload) → Promise — P2P direct message */
+  /** sendChat(peerId, payload) → Promise — P2P direct message, queued while the peer is offline */
   mq.sendChat = function (peerId, payload) {
-    return mq.send(peerId, mq.TOPICS.CHAT, payload);
+    return mq.send(peerId, mq.TOPICS.CHAT, payload, { queue: true });
   };
 
   /** broadcastChat(payload) → Promise — broadcast to all peers */
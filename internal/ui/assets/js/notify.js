@@ -1,4 +1,4 @@
-// Global notifier: group invites and relay status toasts on any page.
+// Global notifier: group invites, relay status and startup check toasts and access consent prompts on any page.
 (function() {
   // Only run when a peer is active (body carries data-self-id).
   if (!document.body || !document.body.dataset.selfId) return;
@@ -64,6 +64,61 @@
         });
       }
     });
+
+    // ── Startup checks toast ──────────────────────────────────────────────────
+    // Sent once per start, only when the previous run did not shut down
+    // cleanly or the checks repaired or failed something.
+    Goop.mq.onHealthStartup(function(from, topic, payload, ack) {
+      ack();
+      if (!payload || !window.Goop || !window.Goop.toast) return;
+      var parts = [];
+      if (payload.unclean) parts.push('The last session did not shut down cleanly.');
+      if (payload.repaired > 0) parts.push('Repaired ' + payload.repaired + (payload.repaired === 1 ? ' item.' : ' items.'));
+      if (payload.failed && payload.failed.length) parts.push('Could not repair: ' + payload.failed.join(', ') + '.');
+      window.Goop.toast({
+        icon: payload.healthy ? '🩺' : '⚠️',
+        title: payload.healthy ? 'Startup checks' : 'Startup checks found problems',
+        message: parts.join(' ') + ' Click for details.',
+        duration: payload.healthy ? 6000 : 12000,
+        onClick: function() { window.location.href = '/logs'; }
+      });
+    });
+
+    // ── Access consent prompt ─────────────────────────────────────────────────
+    // A peer without a remembered decision asked for docs/data; the request is
+    // parked in Go until we answer (or it times out as deny).
+    Goop.mq.onSecurityConsent(function(from, topic, payload, ack) {
+      ack();
+      if (!payload || !payload.id || !window.Goop || !window.Goop.dialog) return;
+      var what = (payload.protocol || '').indexOf('/goop/docs') === 0 ? 'shared documents' : 'data';
+      var who = payload.peer_id || 'unknown peer';
+      if (Goop.mq.getPeerName) who = Goop.mq.getPeerName(payload.peer_id) || who;
+
+      window.Goop.dialog.custom({
+        title: 'Access request',
+        okText: 'Allow once',
+        cancelText: 'Deny',
+        build: function(body, done) {
+          var msg = document.createElement('p');
+          msg.textContent = who + ' wants to access your ' + what + '.';
+          body.appendChild(msg);
+          var row = document.createElement('div');
+          row.className = 'ed-dlg-actions';
+          [['Always allow', 'always'], ['Block', 'block']].forEach(function(b) {
+            var btn = document.createElement('button');
+            btn.type = 'button';
+            btn.className = 'ed-dlg-btn' + (b[1] === 'block' ? ' danger' : '');
+            btn.textContent = b[0];
+            btn.addEventListener('click', function() { done(b[1]); });
+            row.appendChild(btn);
+          });
+          body.appendChild(row);
+        },
+        collect: function() { return 'once'; }
+      }).then(function(decision) {
+        Goop.api.security.decide({ id: payload.id, decision: decision || 'deny' }).catch(function() {});
+      });
+    });
   }
 
   initNotify();
@@ -194,8 +194,11 @@
     });
   }
 
+  var browseSeq = 0;
+
   function loadBrowse() {
     if (!currentGroupID) return;
+    var seq = ++browseSeq;
 
     clearPreview();
     myList.innerHTML = '<p class="empty-state">Loading...</p>';
@@ -220,46 +223,23 @@
         if (selfPeer && selfPeer.files && selfPeer.files.length > 0) {
           myList.innerHTML = renderFileTable(selfPeer.files, selfPeer.peer_id, true);
           bindDeleteButtons(myList);
+          bindAccessButtons(myList);
           bindRowClicks(myList);
         } else {
           myList.innerHTML = '<p class="empty-state">No files shared yet. Use the upload form above.</p>';
         }
 
-        // Render peer files
-        if (otherPeers.length > 0) {
-          var html = "";
-          otherPeers.forEach(function(p) {
-            if (p.error) {
-              html += '<div class="docs-peer-block">' +
-                '<div class="docs-peer-label">' + escapeHtml(shortLabel(p.label, p.peer_id)) +
-                ' <span class="docs-status-offline">offline</span></div>' +
-                '</div>';
-              return;
-            }
-            if (p.files && p.files.length > 0) {
-              html += '<div class="docs-peer-block">' +
-                '<div class="docs-peer-label">' + escapeHtml(shortLabel(p.label, p.peer_id)) +
-                ' <span class="docs-status-online">online</span>' +
-                ' <span class="muted small">(' + p.files.length + ' file' + (p.files.length !== 1 ? 's' : '') + ')</span></div>' +
-                renderFileTable(p.files, p.peer_id, false) +
-                '</div>';
-            } else {
-              html += '<div class="docs-peer-block">' +
-                '<div class="docs-peer-label">' + escapeHtml(shortLabel(p.label, p.peer_id)) +
-                ' <span class="docs-status-online">online</span>' +
-                ' <span class="muted small">(no files)</span></div>' +
-                '</div>';
-            }
-          });
-          if (html) {
-            peersList.innerHTML = html;
-          } else {
-            peersList.innerHTML = '<p class="empty-state">No other members in this group.</p>';
-          }
-          bindRowClicks(peersList);
-        } else {
-          peersList.innerHTML = '<p class="empty-state">No other members in this group.</p>';
-        }
+        renderPeers(otherPeers);
+
+        // Members that did not answer in time: fetch them on and re-render.
+        var slow = data.slow || [];
+        if (slow.length === 0) return;
+        api.docs.refresh(currentGroupID, slow).then(function(late) {
+          if (seq !== browseSeq) return;
+          var byID = {};
+          (late.peers || []).forEach(function(p) { byID[p.peer_id] = p; });
+          renderPeers(otherPeers.map(function(p) { return byID[p.peer_id] || p; }));
+        }).catch(function() {});
       })
       .catch(function(err) {
         myList.innerHTML = '<p class="empty-state">Failed to load: ' + escapeHtml(err.message) + '</p>';
@@ -267,6 +247,60 @@
       });
   }
 
+  function renderPeers(otherPeers) {
+    if (otherPeers.length === 0) {
+      peersList.innerHTML = '<p class="empty-state">No other members in this group.</p>';
+      return;
+    }
+    var html = "";
+    otherPeers.forEach(function(p) {
+      var label = '<div class="docs-peer-label">' + escapeHtml(shortLabel(p.label, p.peer_id));
+      if (p.error === "slow") {
+        html += '<div class="docs-peer-block">' + label +
+          ' <span class="muted small">loading...</span></div>' +
+          '</div>';
+        return;
+      }
+      if (p.error) {
+        html += '<div class="docs-peer-block">' + label +
+          ' <span class="docs-status-offline">offline</span></div>' +
+          '</div>';
+        return;
+      }
+      if (p.files && p.files.length > 0) {
+        html += '<div class="docs-peer-block">' + label +
+          ' <span class="docs-status-online">online</span>' +
+          ' <span class="muted small">(' + p.files.length + ' file' + (p.files.length !== 1 ? 's' : '') + ')</span></div>' +
+          renderFileTable(p.files, p.peer_id, false) +
+          '</div>';
+      } else {
+        html += '<div class="docs-peer-block">' + label +
+          ' <span class="docs-status-online">online</span>' +
+          ' <span class="muted small">(no files)</span></div>' +
+          '</div>';
+      }
+    });
+    peersList.innerHTML = html;
+    bindRowClicks(peersList);
+  }
+
+  // Chunked downloads from peers report progress over MQ; a download that
+  // failed resumes where it stopped when started again.
+  if (window.Goop.mq && window.Goop.mq.onDocsDownload) {
+    window.Goop.mq.onDocsDownload(function(from, topic, p) {
+      if (!p || p.group_id !== currentGroupID) return;
+      peersList.querySelectorAll(".docs-data-row").forEach(function(row) {
+        if (row.getAttribute("data-filename") !== p.file || row.getAttribute("data-peer") !== p.peer_id) return;
+        var bar = row.querySelector(".docs-dl-progress");
+        if (!bar) return;
+        bar.value = p.total > 0 ? p.done / p.total : 0;
+        bar.title = formatSize(p.done) + " of " + formatSize(p.total);
+        setHidden(bar, p.state !== "progress");
+        if (p.state === "error") toast("Download of " + p.file + " stopped: " + p.error + ". Download again to resume.", true);
+      });
+    });
+  }
+
   function renderFileTable(files, peerID, isSelf) {
     var html = '<table class="data-table"><thead><tr>' +
       '<th>Name</th><th>Size</th><th>Actions</th>' +
@@ -281,13 +315,18 @@
         ' data-filename="' + escapeHtml(f.name) + '"' +
         ' data-url="' + escapeHtml(downloadUrl) + '"' +
         ' data-size="' + f.size + '"' +
+        ' data-peer="' + escapeHtml(peerID) + '"' +
         ' data-self="' + isSelf + '">' +
-        '<td class="docs-file-name">' + escapeHtml(f.name) + '</td>' +
-        '<td class="docs-file-size">' + formatSize(f.size) + '</td>' +
+        '<td class="docs-file-name">' + escapeHtml(f.name) + (isSelf ? accessBadge(f) : '') + '</td>' +
+        '<td class="docs-file-size">' + formatSize(f.size) +
+        (isSelf ? '' : '<progress class="docs-dl-progress hidden" max="1" value="0"></progress>') + '</td>' +
         '<td class="docs-file-actions">' +
         '<a href="' + downloadUrl + '" class="docs-action-btn docs-btn-small" download>Download</a>';
 
       if (isSelf) {
+        html += '<button class="docs-action-btn docs-btn-small docs-access-btn" ' +
+          'data-file="' + escapeHtml(f.name) + '" ' +
+          'data-access="' + escapeHtml(f.access === "peers" ? (f.peers || []).join(", ") : (f.access || "group")) + '">Access</button>';
         html += '<button class="docs-action-btn docs-btn-small docs-btn-danger docs-delete-btn" ' +
           'data-file="' + escapeHtml(f.name) + '">Delete</button>';
       }
@@ -584,6 +623,50 @@
       });
   }
 
+  // ---- Access ----
+
+  function accessBadge(f) {
+    if (f.access === "public") return ' <span class="muted small">(public)</span>';
+    if (f.access === "peers") {
+      var n = (f.peers || []).length;
+      return ' <span class="muted small">(' + n + ' peer' + (n !== 1 ? 's' : '') + ' only)</span>';
+    }
+    return '';
+  }
+
+  function bindAccessButtons(container) {
+    container.querySelectorAll(".docs-access-btn").forEach(function(btn) {
+      on(btn, "click", function() {
+        var filename = btn.getAttribute("data-file");
+        Goop.dialog.prompt({
+          title: 'Access to "' + filename + '"',
+          message: 'Who may fetch this file: "group" for the group\'s members, "public" for any peer, or peer IDs separated by commas.',
+          value: btn.getAttribute("data-access"),
+          okText: "Save"
+        }).then(function(value) {
+          if (value === null || value === undefined) return;
+          setAccess(filename, value.trim());
+        });
+      });
+    });
+  }
+
+  function setAccess(filename, value) {
+    var req = { group_id: currentGroupID, filename: filename, access: value || "group" };
+    if (req.access !== "group" && req.access !== "public") {
+      req.access = "peers";
+      req.peers = value.split(/[\s,]+/).filter(Boolean);
+    }
+    api.docs.acl(req)
+      .then(function() {
+        toast("Access to " + filename + " saved");
+        loadBrowse();
+      })
+      .catch(function(err) {
+        toast("Failed to set access: " + err.message, true);
+      });
+  }
+
   // ---- Helpers ----
 
   function shortLabel(label, id) {
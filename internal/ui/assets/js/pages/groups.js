@@ -1,4 +1,4 @@
-// Groups tabs: hosted, joined, events pages.
+// Groups tabs: hosted, joined, events and schedule pages.
 // Detects which elements are present and initializes accordingly.
 (function() {
   var g = window.Goop && window.Goop.groups;
@@ -123,4 +123,166 @@
     }
     startEventStream();
   }
+
+  // ── Schedule tab (/groups/schedule) ─────────────────────────────────────
+  var schedListEl = document.getElementById('sched-list');
+
+  if (schedListEl) {
+    var toast = core.toast;
+    var kindEl = document.getElementById('sched-kind');
+    var groupEl = document.getElementById('sched-group');
+    var titleEl = document.getElementById('sched-title');
+    var startEl = document.getElementById('sched-start');
+    var durationEl = document.getElementById('sched-duration');
+    var descEl = document.getElementById('sched-description');
+    var saveBtn = document.getElementById('sched-save');
+    var cancelBtn = document.getElementById('sched-cancel');
+    var feedsEl = document.getElementById('sched-feeds');
+    var feedScopeEl = document.getElementById('sched-feed-scope');
+    var feedCreateBtn = document.getElementById('sched-feed-create');
+    var editingId = 0;
+    var groupNames = {};
+
+    // datetime-local wants local time without a zone: YYYY-MM-DDTHH:MM.
+    function toLocalInput(ms) {
+      var d = new Date(ms - new Date(ms).getTimezoneOffset() * 60000);
+      return d.toISOString().slice(0, 16);
+    }
+
+    function resetForm() {
+      editingId = 0;
+      titleEl.value = '';
+      descEl.value = '';
+      startEl.value = '';
+      durationEl.value = '60';
+      cancelBtn.classList.add('hidden');
+    }
+
+    function loadGroups() {
+      return Goop.api.groups.list().then(function(groups) {
+        var opts = '';
+        var scopes = '<option value="">All sessions</option>';
+        groupNames = {};
+        (groups || []).forEach(function(grp) {
+          groupNames[grp.id] = grp.name;
+          opts += '<option value="' + escapeHtml(grp.id) + '">' + escapeHtml(grp.name) + '</option>';
+          scopes += '<option value="' + escapeHtml(grp.id) + '">' + escapeHtml(grp.name) + '</option>';
+        });
+        groupEl.innerHTML = opts;
+        feedScopeEl.innerHTML = scopes;
+      });
+    }
+
+    function refreshSessions() {
+      Goop.api.schedule.list().then(function(sessions) {
+        if (!sessions || !sessions.length) {
+          schedListEl.innerHTML = '<p class="empty-state">Nothing scheduled.</p>';
+          return;
+        }
+        schedListEl.innerHTML = sessions.map(function(s) {
+          var where = s.kind === 'group' ? (groupNames[s.group_id] || s.group_id) : 'Listen session';
+          return '<div class="groups-card">' +
+            '<div class="groups-card-info">' +
+              '<div class="groups-card-name">' + escapeHtml(s.title) + '</div>' +
+              '<div class="groups-card-meta">' + escapeHtml(new Date(s.starts_at).toLocaleString()) +
+                ' &middot; ' + escapeHtml(String(s.duration_min)) + ' min &middot; ' + escapeHtml(where) + '</div>' +
+            '</div>' +
+            '<div class="groups-card-actions">' +
+              '<button class="groups-action-btn sched-edit-btn" data-id="' + s.id + '">Edit</button>' +
+              '<button class="groups-action-btn groups-btn-danger sched-delete-btn" data-id="' + s.id + '">Delete</button>' +
+            '</div>' +
+          '</div>';
+        }).join('');
+
+        schedListEl.querySelectorAll('.sched-edit-btn').forEach(function(btn) {
+          btn.addEventListener('click', function() {
+            var id = Number(btn.getAttribute('data-id'));
+            var s = sessions.find(function(x) { return x.id === id; });
+            if (!s) return;
+            editingId = s.id;
+            kindEl.value = s.kind;
+            kindEl.dispatchEvent(new Event('change'));
+            if (s.group_id) groupEl.value = s.group_id;
+            titleEl.value = s.title;
+            descEl.value = s.description || '';
+            startEl.value = toLocalInput(s.starts_at);
+            durationEl.value = String(s.duration_min);
+            cancelBtn.classList.remove('hidden');
+          });
+        });
+        schedListEl.querySelectorAll('.sched-delete-btn').forEach(function(btn) {
+          btn.addEventListener('click', function() {
+            Goop.api.schedule.remove({ id: Number(btn.getAttribute('data-id')) }).then(refreshSessions)
+              .catch(function(err) { toast('Delete failed: ' + err.message, true); });
+          });
+        });
+      }).catch(function(err) {
+        schedListEl.innerHTML = '<p class="empty-state">Failed to load: ' + escapeHtml(err.message) + '</p>';
+      });
+    }
+
+    function refreshFeeds() {
+      Goop.api.schedule.feeds().then(function(feeds) {
+        if (!feeds || !feeds.length) {
+          feedsEl.innerHTML = '<p class="empty-state">No feed links issued.</p>';
+          return;
+        }
+        feedsEl.innerHTML = feeds.map(function(f) {
+          var url = location.origin + f.path;
+          return '<div class="groups-card">' +
+            '<div class="groups-card-info">' +
+              '<div class="groups-card-name">' + escapeHtml(f.name) + '</div>' +
+              '<div class="groups-card-meta"><code>' + escapeHtml(url) + '</code></div>' +
+            '</div>' +
+            '<div class="groups-card-actions">' +
+              '<button class="groups-action-btn sched-copy-btn" data-url="' + escapeHtml(url) + '">Copy</button>' +
+              '<button class="groups-action-btn groups-btn-danger sched-revoke-btn" data-group="' + escapeHtml(f.group_id || '') + '">Revoke</button>' +
+            '</div>' +
+          '</div>';
+        }).join('');
+
+        feedsEl.querySelectorAll('.sched-copy-btn').forEach(function(btn) {
+          btn.addEventListener('click', function() {
+            navigator.clipboard.writeText(btn.getAttribute('data-url')).then(function() { toast('Feed link copied'); });
+          });
+        });
+        feedsEl.querySelectorAll('.sched-revoke-btn').forEach(function(btn) {
+          btn.addEventListener('click', function() {
+            Goop.api.schedule.revokeFeed({ group_id: btn.getAttribute('data-group') }).then(refreshFeeds)
+              .catch(function(err) { toast('Revoke failed: ' + err.message, true); });
+          });
+        });
+      });
+    }
+
+    kindEl.addEventListener('change', function() {
+      groupEl.classList.toggle('hidden', kindEl.value !== 'group');
+    });
+
+    cancelBtn.addEventListener('click', resetForm);
+
+    saveBtn.addEventListener('click', function() {
+      var starts = startEl.value ? new Date(startEl.value).getTime() : 0;
+      Goop.api.schedule.save({
+        id: editingId,
+        kind: kindEl.value,
+        group_id: kindEl.value === 'group' ? groupEl.value : '',
+        title: titleEl.value,
+        description: descEl.value,
+        starts_at: starts,
+        duration_min: Number(durationEl.value) || 60,
+      }).then(function() {
+        resetForm();
+        refreshSessions();
+      }).catch(function(err) { toast('Save failed: ' + err.message, true); });
+    });
+
+    feedCreateBtn.addEventListener('click', function() {
+      Goop.api.schedule.createFeed({ group_id: feedScopeEl.value }).then(refreshFeeds)
+        .catch(function(err) { toast('Failed: ' + err.message, true); });
+    });
+
+    loadGroups().then(refreshSessions, refreshSessions);
+    refreshFeeds();
+  }
 })();
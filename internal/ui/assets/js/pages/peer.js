@@ -1,4 +1,4 @@
-// Peer page: chat messages, send handler, MQ subscription, call buttons.
+// Peer page: chat messages, send handler, MQ subscription, private notes, call buttons.
 (function() {
   var pageEl = document.querySelector('.peer-page');
   if (!pageEl) return;
@@ -86,6 +86,11 @@
     input.value = '';
 
     window.Goop.mq.sendChat(peerID, { content: content, to: peerID })
+      .then(function(res) {
+        if (res && res.status === 'queued') {
+          Goop.core.toast('Peer is offline; the message will be delivered when it is back', 'info');
+        }
+      })
       .catch(function(err) {
         Goop.log.error('peer', 'chat send failed: ' + err);
         Goop.dialog.alert('Error', 'Failed to send message');
@@ -121,6 +126,40 @@
     });
   }
 
+  // ── Private notes ──
+  var notesEl = document.getElementById('peer-notes');
+  var notesBody = document.getElementById('peer-notes-body');
+  var notesMeta = document.getElementById('peer-notes-meta');
+  var notesStatus = document.getElementById('peer-notes-status');
+  var notesSave = document.getElementById('peer-notes-save');
+
+  function showNote(note) {
+    notesBody.value = note.body || '';
+    if (note.updated_at) {
+      notesMeta.textContent = '— edited ' + new Date(note.updated_at).toLocaleString();
+      notesEl.open = true;
+    } else {
+      notesMeta.textContent = '';
+    }
+  }
+
+  if (notesEl) {
+    Goop.api.peers.note(peerID).then(showNote).catch(function() {});
+    notesSave.addEventListener('click', function() {
+      notesStatus.textContent = 'Saving...';
+      Goop.api.peers.setNote({ peer_id: peerID, body: notesBody.value })
+        .then(function(note) {
+          showNote(note);
+          notesStatus.textContent = 'Saved';
+          setTimeout(function() { notesStatus.textContent = ''; }, 1500);
+        })
+        .catch(function(err) {
+          notesStatus.textContent = '';
+          Goop.dialog.alert('Error', 'Failed to save notes: ' + err);
+        });
+    });
+  }
+
   // ── Call buttons ──
   var callActionsEl = document.querySelector('.chat-call-actions');
   if (callActionsEl) {
@@ -16,6 +16,7 @@
   var peerSearch = document.getElementById('peer-search');
   var peerSearchModeSelector = document.getElementById('peer-search-mode-selector');
   var peerSearchModeTrigger = document.getElementById('peer-search-mode-trigger');
+  var peerNamespaceSelector = document.getElementById('peer-namespace-selector');
   var messagesDiv = document.getElementById('broadcast-messages');
   var form = document.getElementById('broadcast-form');
   var input = document.getElementById('broadcast-input');
@@ -31,12 +32,20 @@
   // Current peers data for search filtering
   var currentPeers = [];
 
+  // Rows rendered at once; "Show more" adds another chunk. Keeps the page
+  // responsive with thousands of peers on a busy rendezvous.
+  var RENDER_CHUNK = 200;
+  var renderLimit = RENDER_CHUNK;
+
   // Address book mode
   var addrBookMode = false;
 
   // Search mode
   var searchMode = 'name';
 
+  // Presence namespace shown ('' = the global list, kept live over MQ)
+  var namespace = '';
+
   // Map of peer ID -> friendly label (populated by SSE snapshot)
   var peerLabels = {};
   peerLabels[selfID] = selfName || 'Me';
@@ -57,6 +66,21 @@
   // Peers List
   // =====================
 
+  var UNSIGNED_TITLE = "This label is not signed by the peer's key. Older clients do not sign; otherwise the rendezvous may have changed it.";
+
+  var LINK_LABELS = {
+    'direct-lan': ['LAN', 'Direct connection on the local network'],
+    'direct-wan': ['direct', 'Direct connection over the internet'],
+    'holepunch':  ['punched', 'Direct connection opened by a hole punch through the NAT'],
+    'relay':      ['relayed', 'Traffic goes through the relay; expect lower speed'],
+  };
+
+  function linkBadgeHTML(link) {
+    var l = LINK_LABELS[link];
+    if (!l) return '';
+    return '<span class="badge badge-link badge-link-' + link + '" title="' + l[1] + '">' + l[0] + '</span>';
+  }
+
   function renderPeerRow(peer) {
     var shortId = peer.ID.substring(0, 8) + '...';
     var lastSeen = new Date(peer.LastSeen).toISOString();
@@ -82,6 +106,8 @@
             (peer.Favorite ? '<span class="peer-fav-star">★</span>' : '') +
           '</a>' +
           (peer.Verified ? '' : '<span class="badge-unverified">unverified</span>') +
+          (peer.Signed || rowOffline ? '' : '<span class="badge-unsigned" title="' + UNSIGNED_TITLE + '">unsigned</span>') +
+          linkBadgeHTML(peer.Link) +
           (peer.Email ? '<span class="peeremail muted small">' + escapeHtml(peer.Email) + '</span>' : '') +
         '</div>' +
         '<span class="peercontent muted small"><code>' + escapeHtml(shortId) + '</code> &middot; seen ' + escapeHtml(lastSeen) + '</span>' +
@@ -136,7 +162,16 @@
       return;
     }
 
-    peersList.innerHTML = '<ul class="peers">' + filtered.map(renderPeerRow).join('') + '</ul>';
+    var more = filtered.length - renderLimit;
+    peersList.innerHTML = '<ul class="peers">' + filtered.slice(0, renderLimit).map(renderPeerRow).join('') + '</ul>' +
+      (more > 0 ? '<button type="button" class="btn btn-small peers-more">Show more (' + more + ')</button>' : '');
+    var moreBtn = peersList.querySelector('.peers-more');
+    if (moreBtn) {
+      moreBtn.addEventListener('click', function() {
+        renderLimit += RENDER_CHUNK;
+        renderPeersList(null);
+      });
+    }
 
     // Re-attach click handlers for clearing badges
     document.querySelectorAll('.peerrow').forEach(function(row) {
@@ -154,6 +189,7 @@
 
   // Wire up search
   peerSearch.addEventListener('input', function() {
+    renderLimit = RENDER_CHUNK;
     renderPeersList(null);
   });
 
@@ -173,10 +209,26 @@
     });
   }
 
+  function loadPeers() {
+    var ns = namespace;
+    Goop.api.peers.list(ns).then(function(peers) {
+      if (peers && ns === namespace) renderPeersList(peers);
+    }).catch(function() {});
+  }
+
+  // Namespace lists have no MQ announcements; refresh them while shown.
+  if (peerNamespaceSelector && window.Goop && window.Goop.select) {
+    Goop.select.init(peerNamespaceSelector, function(ns) {
+      namespace = ns;
+      loadPeers();
+    });
+    setInterval(function() {
+      if (namespace && document.hasFocus()) loadPeers();
+    }, 5000);
+  }
+
   // Initial peer list load.
-  Goop.api.peers.list().then(function(peers) {
-    if (peers) renderPeersList(peers);
-  }).catch(function() {});
+  loadPeers();
 
   // Converts a peer:announce MQ payload (camelCase) to the PeerRow shape (PascalCase)
   // used by renderPeerRow so both REST and MQ data go through the same renderer.
@@ -189,6 +241,7 @@
       VideoDisabled:  p.videoDisabled  || false,
       ActiveTemplate: p.activeTemplate || '',
       Verified:       p.verified       || false,
+      Signed:         p.signed         || false,
       Reachable:      p.reachable      || false,
       Offline:        p.offline        || false,
       LastSeen:       p.lastSeen ? new Date(p.lastSeen).toISOString() : new Date().toISOString(),
@@ -213,7 +266,7 @@
   function initPeersMQ() {
     if (!window.Goop || !window.Goop.mq) { setTimeout(initPeersMQ, 100); return; }
     Goop.mq.onPeerAnnounce( function(from, topic, payload, ack) {
-      if (!payload || !payload.peerID) { ack(); return; }
+      if (!payload || !payload.peerID || namespace) { ack(); return; }
       var peer = announceToRow(payload);
       var idx = currentPeers.findIndex(function(p) { return p.ID === peer.ID; });
       if (idx >= 0) {
@@ -226,7 +279,7 @@
       ack();
     });
     Goop.mq.onPeerGone( function(from, topic, payload, ack) {
-      if (payload && payload.peerID) {
+      if (payload && payload.peerID && !namespace) {
         currentPeers = currentPeers.filter(function(p) { return p.ID !== payload.peerID; });
         renderPeersList(null);
       }
@@ -240,8 +293,15 @@
   // =====================
 
   var ctxMenuTarget = null;
+  var followedSites = new Set();
 
   if (peerCtxMenu) {
+    Goop.api.peers.follows()
+      .then(function(list) {
+        (list || []).forEach(function(f) { followedSites.add(f.peer_id); });
+      })
+      .catch(function() {});
+
     // Show context menu on right-click of peer row
     document.addEventListener('contextmenu', function(e) {
       var peerRow = e.target.closest('.peerrow');
@@ -265,6 +325,12 @@
         if (unfavBtn) unfavBtn.style.display = 'none';
       }
 
+      var following = followedSites.has(ctxMenuTarget);
+      var followBtn = peerCtxMenu.querySelector('[data-action="follow"]');
+      var unfollowBtn = peerCtxMenu.querySelector('[data-action="unfollow"]');
+      if (followBtn) followBtn.style.display = following ? 'none' : 'block';
+      if (unfollowBtn) unfollowBtn.style.display = following ? 'block' : 'none';
+
       // Position menu
       peerCtxMenu.classList.remove('hidden');
       peerCtxMenu.style.position = 'fixed';
@@ -292,6 +358,42 @@
         var action = this.getAttribute('data-action');
         if (!ctxMenuTarget) return;
 
+        if (action === 'forget') {
+          var forgetId = ctxMenuTarget;
+          peerCtxMenu.classList.add('hidden');
+          Goop.dialog.confirm('Forget this peer? Their cached profile, avatar, chat and call history are deleted from this device.', 'Forget peer').then(function(ok) {
+            if (!ok) return;
+            Goop.api.peers.forget({ peer_id: forgetId })
+              .then(function() {
+                currentPeers = currentPeers.filter(function(p) { return p.ID !== forgetId; });
+                renderPeersList(null);
+              })
+              .catch(function(err) {
+                Goop.toast({ title: 'Forget peer', message: err.message, level: 'error' });
+              });
+          });
+          return;
+        }
+
+        if (action === 'follow' || action === 'unfollow') {
+          var siteId = ctxMenuTarget;
+          var follow = action === 'follow';
+          peerCtxMenu.classList.add('hidden');
+          (follow ? Goop.api.peers.follow({ peer_id: siteId }) : Goop.api.peers.unfollow({ peer_id: siteId }))
+            .then(function() {
+              if (follow) {
+                followedSites.add(siteId);
+                Goop.toast({ title: 'Offline copy', message: 'Their site is synced now and whenever it changes, and shown from this copy while they are offline.' });
+              } else {
+                followedSites.delete(siteId);
+              }
+            })
+            .catch(function(err) {
+              Goop.toast({ title: 'Offline copy', message: err.message, level: 'error' });
+            });
+          return;
+        }
+
         var isFav = action === 'favorite';
         Goop.api.peers.favorite({ peer_id: ctxMenuTarget, favorite: isFav })
           .then(function() {
@@ -517,7 +619,10 @@
     if (probing) return;
     probing = true;
     Goop.api.peers.probe()
-      .then(function(peers) { if (peers) renderPeersList(peers); })
+      .then(function(peers) {
+        if (namespace) loadPeers();
+        else if (peers) renderPeersList(peers);
+      })
       .catch(function() {})
       .then(function() { probing = false; });
   }
@@ -0,0 +1,168 @@
+// Phone remote control: /pair (code entry) and /remote (notifications,
+// access requests, listen controls; calls via call.js / call-ui.js).
+// Sections are shown per the device's scopes (data-scopes on .page-remote).
+(function() {
+  var core = window.Goop && window.Goop.core || {};
+  var escapeHtml = core.escapeHtml || function(s) { return String(s || ''); };
+
+  // ── /pair ─────────────────────────────────────────────────────────────────
+  var pairPage = document.querySelector('.page-pair');
+  if (pairPage) {
+    var form = document.getElementById('pair-form');
+    var errEl = document.getElementById('pair-error');
+    form.addEventListener('submit', function(e) {
+      e.preventDefault();
+      errEl.classList.add('hidden');
+      Goop.api.pair.claim({
+        code: form.code.value.trim(),
+        name: form.name.value.trim(),
+      }).then(function() {
+        location.href = '/remote';
+      }).catch(function(err) {
+        errEl.textContent = err.message || 'Pairing failed';
+        errEl.classList.remove('hidden');
+      });
+    });
+    return;
+  }
+
+  var page = document.querySelector('.page-remote');
+  if (!page) return;
+
+  var scopes = (page.dataset.scopes || '').split(',').filter(Boolean);
+  function has(scope) { return scopes.indexOf(scope) >= 0; }
+
+  page.querySelectorAll('[data-scope]').forEach(function(el) {
+    el.classList.toggle('hidden', !has(el.dataset.scope));
+  });
+
+  function peerName(id) {
+    return (Goop.mq && Goop.mq.getPeerName && Goop.mq.getPeerName(id)) || (id || '').slice(0, 8) || 'unknown peer';
+  }
+
+  // ── Notifications feed (+ system notification while in the background) ──
+  var feed = document.getElementById('remote-feed');
+  var feedMax = 30;
+
+  function push(icon, title, text) {
+    if (!feed) return;
+    var empty = feed.querySelector('.muted');
+    if (empty) empty.remove();
+    var li = document.createElement('li');
+    li.innerHTML = '<span class="remote-feed-icon">' + icon + '</span>' +
+      '<div><b>' + escapeHtml(title) + '</b><div class="small">' + escapeHtml(text) + '</div></div>' +
+      '<span class="muted small">' + new Date().toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' }) + '</span>';
+    feed.insertBefore(li, feed.firstChild);
+    while (feed.children.length > feedMax) feed.removeChild(feed.lastChild);
+
+    if (document.hidden && window.Notification && Notification.permission === 'granted') {
+      try { new Notification(title, { body: text, icon: '/assets/images/goop2-192.png' }); } catch (_) {}
+    }
+  }
+
+  // Ask once, on the first tap (browsers refuse permission prompts without a gesture).
+  if (window.Notification && Notification.permission === 'default') {
+    document.addEventListener('click', function ask() {
+      document.removeEventListener('click', ask);
+      Notification.requestPermission().catch(function() {});
+    });
+  }
+
+  // ── Access requests ───────────────────────────────────────────────────────
+  var consentEl = document.getElementById('remote-consent');
+
+  function renderConsent(pending) {
+    if (!pending || !pending.length) {
+      consentEl.innerHTML = '<p class="muted small">Nothing waiting.</p>';
+      return;
+    }
+    consentEl.innerHTML = pending.map(function(p) {
+      var what = (p.protocol || '').indexOf('/goop/docs') === 0 ? 'shared documents' : 'data';
+      return '<div class="remote-consent" data-id="' + escapeHtml(p.id) + '">' +
+        '<p><b>' + escapeHtml(peerName(p.peer_id)) + '</b> wants to access your ' + what + '.</p>' +
+        '<div class="remote-actions">' +
+          '<button type="button" class="btn remote-btn-primary" data-decision="once">Allow once</button>' +
+          '<button type="button" class="btn" data-decision="always">Always</button>' +
+          '<button type="button" class="btn" data-decision="deny">Deny</button>' +
+          '<button type="button" class="btn remote-btn-danger" data-decision="block">Block</button>' +
+        '</div></div>';
+    }).join('');
+  }
+
+  function loadConsent() {
+    Goop.api.security.consent()
+      .then(function(r) { renderConsent(r && r.pending); })
+      .catch(function() {});
+  }
+
+  if (has('consent')) {
+    consentEl.addEventListener('click', function(e) {
+      var btn = e.target.closest('[data-decision]');
+      if (!btn) return;
+      var id = btn.closest('[data-id]').dataset.id;
+      Goop.api.security.decide({ id: id, decision: btn.dataset.decision })
+        .catch(function(err) { Goop.toast && Goop.toast({ icon: '⚠️', title: 'Not sent', message: err.message }); })
+        .then(loadConsent);
+    });
+    loadConsent();
+  }
+
+  // ── Listen controls ───────────────────────────────────────────────────────
+  var listenEl = document.getElementById('remote-listen');
+  var listenCtl = document.getElementById('remote-listen-controls');
+
+  function loadListen() {
+    Goop.api.listen.state().then(function(r) {
+      var g = r && r.group;
+      listenCtl.classList.toggle('hidden', !g || g.role !== 'host');
+      if (!g) {
+        listenEl.innerHTML = '<p class="muted small">No listening room open.</p>';
+        return;
+      }
+      var playing = g.play_state && g.play_state.playing;
+      var track = (g.track && g.track.name) || 'Nothing loaded';
+      var pos = g.queue_total ? ' (' + (g.queue_index + 1) + '/' + g.queue_total + ')' : '';
+      listenEl.innerHTML = '<p><b>' + escapeHtml(g.name) + '</b></p>' +
+        '<p class="small">' + (playing ? '▶ ' : '⏸ ') + escapeHtml(track) + pos + '</p>';
+    }).catch(function() {});
+  }
+
+  if (has('listen')) {
+    listenCtl.addEventListener('click', function(e) {
+      var btn = e.target.closest('[data-listen]');
+      if (!btn) return;
+      Goop.api.listen.control({ action: btn.dataset.listen })
+        .catch(function(err) { Goop.toast && Goop.toast({ icon: '⚠️', title: 'Listen', message: err.message }); })
+        .then(loadListen);
+    });
+    loadListen();
+  }
+
+  // ── Push channel: the MQ SSE stream ───────────────────────────────────────
+  if (!has('notify') || !Goop.mq) return;
+
+  Goop.mq.onChat(function(from, topic, payload, ack) {
+    push('💬', peerName(from), (payload && payload.content) || '');
+    ack();
+  });
+  Goop.mq.onCallMissed(function(from, topic, payload, ack) {
+    push('📵', 'Missed call', peerName(payload && payload.peer_id));
+    ack();
+  });
+  Goop.mq.onGroupInvite(function(from, topic, payload, ack) {
+    var p = (payload && payload.payload) || {};
+    push('👥', 'Group invite', p.group_name || p.group_id || 'a group');
+    ack();
+  });
+  Goop.mq.onSecurityConsent(function(from, topic, payload, ack) {
+    push('🔐', 'Access request', peerName(payload && payload.peer_id));
+    if (has('consent')) loadConsent();
+    ack();
+  });
+  if (has('listen')) {
+    Goop.mq.onListen(function(from, topic, payload, ack) {
+      loadListen();
+      ack();
+    });
+  }
+})();
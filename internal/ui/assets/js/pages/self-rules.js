@@ -0,0 +1,260 @@
+// Settings — Automation rules ("when X then Y")
+(() => {
+  var G = window.Goop;
+  if (!G || !G.core || !G.api.rules) return;
+  var qs = G.core.qs, escapeHtml = G.core.escapeHtml;
+  var gsel = G.select;
+
+  var listEl = qs("#rules-list");
+  var editorEl = qs("#rule-editor");
+  var newBtn = qs("#rule-new-btn");
+  if (!listEl || !editorEl) return;
+
+  var TRIGGERS = [
+    { value: "peer_online",   label: "A peer comes online" },
+    { value: "chat_contains", label: "A chat message contains a word" },
+    { value: "file_received", label: "A file is received" },
+    { value: "time",          label: "Every day at a time" },
+  ];
+  var ACTIONS = [
+    { value: "message", label: "Send a message" },
+    { value: "lua",     label: "Run a Lua function" },
+    { value: "action",  label: "Run an action" },
+    { value: "webhook", label: "Call a webhook" },
+  ];
+
+  var rules = [];
+  var peers = [];
+  var actionOpts = [];
+
+  function fail(title) {
+    return function(err) {
+      G.toast({ title: title, message: err.message, level: "error" });
+    };
+  }
+
+  function peerName(id) {
+    for (var i = 0; i < peers.length; i++) {
+      if (peers[i].ID === id) return peers[i].Content || id.slice(0, 12);
+    }
+    return id.slice(0, 12);
+  }
+
+  function labelOf(list, v) {
+    for (var i = 0; i < list.length; i++) if (list[i].value === v) return list[i].label;
+    return v;
+  }
+
+  function describe(r) {
+    var t = r.trigger, a = r.action;
+    var when = labelOf(TRIGGERS, t.type);
+    if (t.type === "chat_contains" || (t.type === "file_received" && t.word)) when += " “" + t.word + "”";
+    if (t.type === "time") when = "Every day at " + t.at;
+    if (t.peer_id) when += " (" + peerName(t.peer_id) + ")";
+
+    var then = labelOf(ACTIONS, a.type);
+    if (a.type === "message") then += (a.peer_id ? " to " + peerName(a.peer_id) : "") + ": " + a.text;
+    if (a.type === "lua") then += ": " + a.function;
+    if (a.type === "action") then += ": " + a.action_id;
+    if (a.type === "webhook") then += ": " + a.url;
+    return "When " + when.charAt(0).toLowerCase() + when.slice(1) + ", " + then.charAt(0).toLowerCase() + then.slice(1);
+  }
+
+  function render() {
+    if (!rules.length) {
+      listEl.innerHTML = '<li class="muted small">No rules yet.</li>';
+      return;
+    }
+    listEl.innerHTML = rules.map(function(r) {
+      var status = r.last_run ? "Last ran " + new Date(r.last_run * 1000).toLocaleString() : "Never ran";
+      return '<li data-id="' + escapeHtml(r.id) + '">' +
+        '<label class="switch" title="Enabled"><input type="checkbox" data-enable' + (r.enabled ? " checked" : "") + '><span class="slider"></span></label>' +
+        '<div class="rule-info"><div class="rule-name">' + escapeHtml(r.name) + '</div>' +
+        '<div class="muted small">' + escapeHtml(describe(r)) + '</div>' +
+        '<div class="muted small">' + escapeHtml(status) +
+        (r.last_error ? ' — <span class="rule-error">' + escapeHtml(r.last_error) + '</span>' : '') + '</div></div>' +
+        '<button type="button" class="btn secondary" data-test>Test</button>' +
+        '<button type="button" class="btn secondary" data-edit>Edit</button>' +
+        '<button type="button" class="btn secondary" data-delete>Delete</button>' +
+        '</li>';
+    }).join("");
+  }
+
+  function load() {
+    return G.api.rules.list().then(function(list) {
+      rules = list || [];
+      render();
+    }).catch(fail("Automation"));
+  }
+
+  // Peers and actions only feed the editor's pickers; a failure leaves them empty.
+  function loadPickers() {
+    return Promise.all([
+      G.api.peers.list().catch(function() { return []; }),
+      G.api.actions.list().catch(function() { return []; }),
+    ]).then(function(res) {
+      peers = res[0] || [];
+      actionOpts = (res[1] || []).filter(function(a) { return a.path; }).map(function(a) {
+        return { value: a.id, label: a.category + " › " + a.title };
+      });
+    });
+  }
+
+  function peerOpts(anyLabel) {
+    return [{ value: "", label: anyLabel }].concat(peers.map(function(p) {
+      return { value: p.ID, label: p.Content || p.ID.slice(0, 12) };
+    }));
+  }
+
+  function field(label, key, inner) {
+    return '<div class="field" data-show="' + key + '"><label>' + label + '</label>' + inner + '</div>';
+  }
+
+  function openEditor(rule) {
+    var r = rule || { enabled: true, trigger: { type: "chat_contains" }, action: { type: "message" } };
+    var t = r.trigger, a = r.action;
+
+    editorEl.innerHTML =
+      '<div class="field"><label>Name</label><input id="rule-name" value="' + escapeHtml(r.name || "") + '" placeholder="Optional"></div>' +
+      '<div class="grid2">' +
+        '<div class="field"><label>When</label>' + gsel.html({ id: "rule-trigger", value: t.type, options: TRIGGERS }) + '</div>' +
+        field("From peer", "t-peer", gsel.html({ id: "rule-t-peer", value: t.peer_id || "", options: peerOpts("(any peer)") })) +
+        field("Word", "t-word", '<input id="rule-t-word" value="' + escapeHtml(t.word || "") + '">') +
+        field("At", "t-at", '<input id="rule-t-at" type="time" value="' + escapeHtml(t.at || "") + '">') +
+      '</div>' +
+      '<div class="grid2">' +
+        '<div class="field"><label>Then</label>' + gsel.html({ id: "rule-action", value: a.type, options: ACTIONS }) + '</div>' +
+        field("To peer", "a-peer", gsel.html({ id: "rule-a-peer", value: a.peer_id || "", options: peerOpts("(the peer that triggered it)") })) +
+        field("Action", "a-action", gsel.html({ id: "rule-a-action", value: a.action_id || "", placeholder: "Pick an action", options: actionOpts })) +
+        field("Function", "a-function", '<input id="rule-a-function" value="' + escapeHtml(a.function || "") + '" placeholder="on_rule">') +
+        field("URL", "a-url", '<input id="rule-a-url" value="' + escapeHtml(a.url || "") + '" placeholder="https://">') +
+        field("Secret", "a-secret", '<input id="rule-a-secret" value="' + escapeHtml(a.secret || "") + '" placeholder="Optional: secret name sent as bearer token">') +
+      '</div>' +
+      field("Text", "a-text", '<textarea id="rule-a-text" rows="2">' + escapeHtml(a.text || "") + '</textarea>') +
+      field("Params (JSON)", "a-params", '<textarea id="rule-a-params" rows="2" placeholder="{}">' +
+        escapeHtml(a.params ? JSON.stringify(a.params) : "") + '</textarea>') +
+      '<div class="rule-editor-actions">' +
+        '<button type="button" class="btn" id="rule-save-btn">Save rule</button>' +
+        '<button type="button" class="btn secondary" id="rule-cancel-btn">Cancel</button>' +
+      '</div>';
+
+    ["#rule-t-peer", "#rule-a-peer", "#rule-a-action"].forEach(function(sel) { gsel.init(qs(sel)); });
+    gsel.init(qs("#rule-trigger"), showFields);
+    gsel.init(qs("#rule-action"), showFields);
+    showFields();
+
+    qs("#rule-cancel-btn").addEventListener("click", closeEditor);
+    qs("#rule-save-btn").addEventListener("click", function() { save(r); });
+
+    editorEl.classList.remove("hidden");
+    newBtn.classList.add("hidden");
+  }
+
+  function showFields() {
+    var t = gsel.val(qs("#rule-trigger")), a = gsel.val(qs("#rule-action"));
+    var shown = {
+      "t-peer": t !== "time",
+      "t-word": t === "chat_contains" || t === "file_received",
+      "t-at": t === "time",
+      "a-peer": a === "message",
+      "a-text": a === "message",
+      "a-function": a === "lua",
+      "a-action": a === "action",
+      "a-params": a === "lua" || a === "action",
+      "a-url": a === "webhook",
+      "a-secret": a === "webhook",
+    };
+    editorEl.querySelectorAll("[data-show]").forEach(function(el) {
+      el.classList.toggle("hidden", !shown[el.getAttribute("data-show")]);
+    });
+  }
+
+  function closeEditor() {
+    editorEl.classList.add("hidden");
+    editorEl.innerHTML = "";
+    newBtn.classList.remove("hidden");
+  }
+
+  function val(id) {
+    return qs(id).value.trim();
+  }
+
+  function save(orig) {
+    var params;
+    var raw = val("#rule-a-params");
+    if (raw) {
+      try { params = JSON.parse(raw); } catch (e) {
+        G.toast({ title: "Automation", message: "Params must be a JSON object", level: "error" });
+        return;
+      }
+    }
+    var t = gsel.val(qs("#rule-trigger")), a = gsel.val(qs("#rule-action"));
+    var rule = {
+      id: orig.id || "",
+      name: val("#rule-name"),
+      enabled: orig.enabled !== false,
+      created_at: orig.created_at || 0,
+      trigger: { type: t },
+      action: { type: a },
+    };
+    if (t !== "time") rule.trigger.peer_id = gsel.val(qs("#rule-t-peer"));
+    if (t === "chat_contains" || t === "file_received") rule.trigger.word = val("#rule-t-word");
+    if (t === "time") rule.trigger.at = val("#rule-t-at");
+    if (a === "message") {
+      rule.action.peer_id = gsel.val(qs("#rule-a-peer"));
+      rule.action.text = val("#rule-a-text");
+    }
+    if (a === "lua") rule.action.function = val("#rule-a-function");
+    if (a === "action") rule.action.action_id = gsel.val(qs("#rule-a-action"));
+    if (a === "lua" || a === "action") rule.action.params = params;
+    if (a === "webhook") {
+      rule.action.url = val("#rule-a-url");
+      rule.action.secret = val("#rule-a-secret");
+    }
+
+    G.api.rules.save(rule).then(function() {
+      closeEditor();
+      return load();
+    }).catch(fail("Save rule"));
+  }
+
+  function find(id) {
+    for (var i = 0; i < rules.length; i++) if (rules[i].id === id) return rules[i];
+    return null;
+  }
+
+  listEl.addEventListener("change", function(e) {
+    if (!e.target.matches("[data-enable]")) return;
+    var r = find(e.target.closest("li").getAttribute("data-id"));
+    if (!r) return;
+    r.enabled = e.target.checked;
+    G.api.rules.save(r).catch(function(err) {
+      e.target.checked = !e.target.checked;
+      fail("Automation")(err);
+    });
+  });
+
+  listEl.addEventListener("click", function(e) {
+    var btn = e.target.closest("button");
+    if (!btn) return;
+    var id = btn.closest("li").getAttribute("data-id");
+    if (btn.hasAttribute("data-edit")) {
+      loadPickers().then(function() { openEditor(find(id)); });
+    } else if (btn.hasAttribute("data-test")) {
+      G.api.rules.test({ id: id }).then(function() {
+        G.toast({ title: "Automation", message: "Rule ran.", level: "success" });
+      }).catch(fail("Test rule")).then(load);
+    } else if (btn.hasAttribute("data-delete")) {
+      G.dialog.confirm("Delete this rule?", "Delete rule").then(function(ok) {
+        if (!ok) return;
+        G.api.rules.remove({ id: id }).then(load).catch(fail("Delete rule"));
+      });
+    }
+  });
+
+  newBtn.addEventListener("click", function() {
+    loadPickers().then(function() { openEditor(null); });
+  });
+
+  loadPickers().then(load);
+})();
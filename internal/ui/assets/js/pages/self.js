@@ -217,16 +217,18 @@
     });
   }
 
-  // ── Export site ──
-  var exportBtn = document.getElementById('export-btn');
-  if (exportBtn) {
-    exportBtn.addEventListener('click', function() {
-      exportBtn.disabled = true;
-      exportBtn.textContent = 'Exporting...';
-
-      fetch('/api/site/export')
+  // ── Export site / all data ──
+  // Downloads a zip from url, through the desktop bridge's save dialog when
+  // there is one.
+  function exportZip(btn, url, doneMsg) {
+    var idleText = btn.textContent;
+    btn.addEventListener('click', function() {
+      btn.disabled = true;
+      btn.textContent = 'Exporting...';
+
+      fetch(url)
         .then(function(res) {
-          if (!res.ok) return res.text().then(function(t) { throw new Error(t); });
+          if (!res.ok) return core.errorFromResponse(res).then(function(e) { throw e; });
           var cd = res.headers.get('Content-Disposition') || '';
           var match = cd.match(/filename="?([^"]+)"?/);
           var filename = match ? match[1] : 'goop-export.zip';
@@ -248,23 +250,59 @@
               });
           }
           // Fallback: browser download
-          var url = URL.createObjectURL(result.blob);
+          var blobURL = URL.createObjectURL(result.blob);
           var a = document.createElement('a');
-          a.href = url;
+          a.href = blobURL;
           a.download = result.filename;
           document.body.appendChild(a);
           a.click();
           document.body.removeChild(a);
-          URL.revokeObjectURL(url);
-          Goop.toast({ title: 'Exported', message: 'Site archive downloaded.', duration: 3000, level: 'success' });
+          URL.revokeObjectURL(blobURL);
+          Goop.toast({ title: 'Exported', message: doneMsg, duration: 3000, level: 'success' });
         })
         .catch(function(err) {
           var errMsg = err.message || 'Unknown error';
           Goop.toast({ title: 'Export Error', message: errMsg, duration: 6000, level: 'error' });
         })
         .finally(function() {
-          exportBtn.disabled = false;
-          exportBtn.textContent = 'Export site (.zip)';
+          btn.disabled = false;
+          btn.textContent = idleText;
+        });
+    });
+  }
+
+  var exportBtn = document.getElementById('export-btn');
+  if (exportBtn) exportZip(exportBtn, '/api/site/export', 'Site archive downloaded.');
+  var exportStaticBtn = document.getElementById('export-static-btn');
+  if (exportStaticBtn) exportZip(exportStaticBtn, '/api/site/export?format=static', 'Static site downloaded.');
+  var exportAllBtn = document.getElementById('export-all-btn');
+  if (exportAllBtn) exportZip(exportAllBtn, '/api/export/all', 'Data archive downloaded.');
+
+  // ── Publish static site ──
+  var publishBtn = document.getElementById('publish-btn');
+  if (publishBtn) {
+    var publishText = publishBtn.textContent;
+    publishBtn.addEventListener('click', function() {
+      publishBtn.disabled = true;
+      publishBtn.textContent = 'Publishing...';
+      fetch('/api/site/publish', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ csrf: csrf })
+      })
+        .then(function(res) {
+          if (!res.ok) return core.errorFromResponse(res).then(function(e) { throw e; });
+          return res.json();
+        })
+        .then(function(data) {
+          Goop.toast({ title: 'Published', message: data.files + ' files uploaded to ' + data.target + '.', duration: 4000, level: 'success' });
+        })
+        .catch(function(err) {
+          Goop.toast({ title: 'Publish Error', message: err.message || 'Unknown error', duration: 8000, level: 'error' });
+        })
+        .finally(function() {
+          publishBtn.disabled = false;
+          publishBtn.textContent = publishText;
         });
     });
   }
@@ -291,7 +329,7 @@
 
         fetch('/api/site/import', { method: 'POST', body: fd })
           .then(function(res) {
-            if (!res.ok) return res.text().then(function(t) { throw new Error(t); });
+            if (!res.ok) return core.errorFromResponse(res).then(function(e) { throw e; });
             return res.json();
           })
           .then(function() {
@@ -356,4 +394,186 @@
       });
     });
   }
+  // ── Email digest ──
+  var digestPanel = document.getElementById('digest-panel');
+  if (digestPanel && Goop.api.digest) {
+    var digestFreq = document.getElementById('digest-frequency');
+    var digestFavs = document.getElementById('digest-favorites');
+    var digestHint = document.getElementById('digest-hint');
+
+    function renderDigest(st) {
+      digestFreq.value = st.frequency || 'off';
+      digestFavs.checked = (st.favorites || 0) > 0;
+      digestFreq.disabled = false;
+      digestFavs.disabled = digestFreq.value === 'off';
+    }
+
+    Goop.api.digest.status().then(function(r) {
+      if (r.available) {
+        renderDigest(r.status || {});
+      } else {
+        digestHint.textContent = r.error || 'Email digests are not available on this rendezvous.';
+      }
+    }).catch(function() {});
+
+    function saveDigest() {
+      Goop.api.digest.set({ frequency: digestFreq.value, favorites: digestFavs.checked }).then(renderDigest).catch(function(err) {
+        Goop.toast({ title: 'Email digest', message: err.message, level: 'error' });
+      });
+    }
+    digestFreq.addEventListener('change', saveDigest);
+    digestFavs.addEventListener('change', saveDigest);
+  }
+
+  // ── Remote control pairing ──
+  var pairPanel = document.getElementById('pair-panel');
+  if (pairPanel && Goop.api.pair) {
+    var pairPending = document.getElementById('pair-pending');
+    var pairDevices = document.getElementById('pair-devices');
+    var pairTimer = null;
+
+    function renderPair(r) {
+      var p = r && r.pending;
+      pairPending.classList.toggle('hidden', !p);
+      if (p) {
+        var url = (r.remote_url || '') + '/pair';
+        var link = document.getElementById('pair-url');
+        link.textContent = r.remote_url ? url : '(set a remote control addr and restart)';
+        link.href = r.remote_url ? url : '#';
+        document.getElementById('pair-code').textContent = p.code;
+        var left = Math.max(0, Math.round((p.expires_at - Date.now()) / 1000));
+        document.getElementById('pair-expiry').textContent = 'Expires in ' + left + 's';
+      }
+      var devs = (r && r.devices) || [];
+      pairDevices.innerHTML = devs.length ? devs.map(function(d) {
+        var seen = d.last_seen ? new Date(d.last_seen).toLocaleString() : 'never';
+        return '<li data-id="' + Goop.core.escapeHtml(d.id) + '">' +
+          '<div><b>' + Goop.core.escapeHtml(d.name) + '</b>' +
+          '<div class="muted small">' + Goop.core.escapeHtml((d.scopes || []).join(', ')) + ' · last seen ' + seen + '</div></div>' +
+          '<button type="button" class="btn secondary" data-unpair>Unpair</button></li>';
+      }).join('') : '<li class="muted small">No devices paired.</li>';
+
+      var guests = (r && r.guests) || [];
+      if (guestList) {
+        guestList.innerHTML = guests.length ? guests.map(function(g) {
+          return '<li data-id="' + Goop.core.escapeHtml(g.id) + '">' +
+            '<div><b>Guest</b><div class="muted small">until ' + new Date(g.expires_at).toLocaleString() + '</div></div>' +
+            '<button type="button" class="btn secondary" data-revoke-guest>Revoke</button></li>';
+        }).join('') : '<li class="muted small">No guest sessions.</li>';
+      }
+
+      // Poll while a code is waiting so a successful claim shows up.
+      clearTimeout(pairTimer);
+      if (p) pairTimer = setTimeout(loadPair, 2000);
+    }
+
+    function loadPair() {
+      Goop.api.pair.status().then(renderPair).catch(function() {});
+    }
+
+    document.getElementById('pair-start-btn').addEventListener('click', function() {
+      Goop.api.pair.start({}).then(loadPair).catch(function(err) {
+        Goop.toast({ title: 'Pairing', message: err.message, level: 'error' });
+      });
+    });
+
+    var guestList = document.getElementById('guest-sessions');
+    document.getElementById('guest-start-btn').addEventListener('click', function() {
+      var minutes = parseInt(document.getElementById('guest-minutes').value, 10) || 0;
+      Goop.api.pair.guest({ minutes: minutes }).then(function(r) {
+        loadPair();
+        Goop.core.copyToClipboard(r.url);
+        Goop.toast({ title: 'Guest link copied', message: r.url });
+      }).catch(function(err) {
+        Goop.toast({ title: 'Guest link', message: err.message, level: 'error' });
+      });
+    });
+
+    guestList.addEventListener('click', function(e) {
+      var btn = e.target.closest('[data-revoke-guest]');
+      if (!btn) return;
+      Goop.api.pair.revokeGuest({ id: btn.closest('[data-id]').dataset.id }).then(loadPair).catch(function(err) {
+        Goop.toast({ title: 'Guest link', message: err.message, level: 'error' });
+      });
+    });
+
+    pairDevices.addEventListener('click', function(e) {
+      var btn = e.target.closest('[data-unpair]');
+      if (!btn) return;
+      var id = btn.closest('[data-id]').dataset.id;
+      Goop.dialog.confirm('Unpair this device? It loses access immediately.', 'Unpair').then(function(ok) {
+        if (!ok) return;
+        Goop.api.pair.revoke({ id: id }).then(loadPair).catch(function(err) {
+          Goop.toast({ title: 'Unpair', message: err.message, level: 'error' });
+        });
+      });
+    });
+
+    loadPair();
+  }
+
+  // ── Peer retention stats ──
+  var retentionStats = document.getElementById('retention-stats');
+  if (retentionStats) {
+    Goop.api.peers.retention().then(function(st) {
+      if (!st.days) return;
+      var last = st.last_run ? new Date(st.last_run).toLocaleString() : 'not yet';
+      retentionStats.textContent = 'Last check: ' + last + ' · forgot ' + st.last_forgotten +
+        ' · ' + st.total_forgotten + ' since start' + (st.last_error ? ' · ' + st.last_error : '');
+    }).catch(function() {});
+  }
+
+  // ── My data: per-category counts and wipe ──
+  var auditList = document.getElementById('data-audit');
+  if (auditList && Goop.api.mydata) {
+    var wipePeerEl = document.getElementById('data-wipe-peer');
+
+    function wipePeer() {
+      return Goop.select.val(wipePeerEl.querySelector('.gsel'));
+    }
+
+    function renderAudit(cats) {
+      var peer = wipePeer();
+      auditList.innerHTML = (cats || []).map(function(c) {
+        var total = 0;
+        var tables = (c.tables || []).map(function(t) { total += t.rows; return t.table + ' ' + t.rows; });
+        // Site data can only be wiped for one peer (by row owner).
+        var canWipe = total > 0 && (c.name !== 'data' || peer);
+        return '<li data-cat="' + Goop.core.escapeHtml(c.name) + '">' +
+          '<div><b>' + Goop.core.escapeHtml(c.name) + '</b> <span class="muted small">' + total + ' rows</span>' +
+          '<div class="muted small">' + Goop.core.escapeHtml(c.description) +
+          (tables.length ? ' · ' + Goop.core.escapeHtml(tables.join(', ')) : '') + '</div></div>' +
+          '<button type="button" class="btn secondary" data-wipe' + (canWipe ? '' : ' disabled') + '>Wipe</button></li>';
+      }).join('');
+    }
+
+    function loadAudit() {
+      Goop.api.mydata.summary().then(renderAudit).catch(function() {});
+    }
+
+    Goop.api.peers.list().catch(function() { return []; }).then(function(peers) {
+      wipePeerEl.innerHTML = Goop.select.html({
+        value: '',
+        options: [{ value: '', label: 'All peers' }].concat((peers || []).map(function(p) {
+          return { value: p.ID, label: p.Content || p.ID.slice(0, 12) };
+        })),
+      });
+      Goop.select.init(wipePeerEl.querySelector('.gsel'), loadAudit);
+      loadAudit();
+    });
+
+    auditList.addEventListener('click', function(e) {
+      var btn = e.target.closest('[data-wipe]');
+      if (!btn) return;
+      var cat = btn.closest('[data-cat]').dataset.cat;
+      var peer = wipePeer();
+      var who = peer ? 'about this peer' : 'for everyone';
+      Goop.dialog.confirm('Delete all stored ' + cat + ' data ' + who + '? This cannot be undone.', 'Wipe').then(function(ok) {
+        if (!ok) return;
+        Goop.api.mydata.wipe({ categories: [cat], peer_id: peer }).then(loadAudit).catch(function(err) {
+          Goop.toast({ title: 'Wipe', message: err.message, level: 'error' });
+        });
+      });
+    });
+  }
 })();
@@ -11,7 +11,7 @@
       var name   = btn.getAttribute('data-name');
       var source = btn.getAttribute('data-source');
 
-      var msg = 'Apply "' + name + '"?\n\nThis will DELETE all your current site files and database tables and replace them with the template. This cannot be undone.';
+      var msg = 'Apply "' + name + '"?\n\nThis will DELETE all your current site files and database tables and replace them with the template. A snapshot of the current site is kept so you can revert.';
 
       Goop.dialog.confirm(msg, 'Apply Template').then(function(ok) {
         if (ok) applyTemplate(dir, name, source);
@@ -21,20 +21,25 @@
 
   function applyTemplate(dir, name, source) {
     var url = source === 'store' ? '/api/templates/apply-store' : '/api/templates/apply';
+    var install = null;
 
-    fetch(url, {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ template: dir, csrf: csrf })
+    (source === 'store' ? openInstall(dir) : Promise.resolve(null))
+    .then(function(inst) {
+      install = inst;
+      return fetch(url, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ template: dir, csrf: csrf, session: inst ? inst.session : undefined })
+      });
     })
     .then(function(res) {
       if (res.status === 402) {
-        return res.text().then(function(t) {
-          Goop.toast({ title: 'Warning', message: t || 'Template could not be applied, insufficient funding', duration: 6000, level: 'warning' });
+        return Goop.core.errorFromResponse(res).then(function(e) {
+          Goop.toast({ title: 'Warning', message: e.message || 'Template could not be applied, insufficient funding', duration: 6000, level: 'warning' });
           return null;
         });
       }
-      if (!res.ok) return res.text().then(function(t) { throw new Error(t); });
+      if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
       return res.json();
     })
     .then(function(data) {
@@ -66,12 +71,187 @@
         if (el) el.textContent = '\uD83E\uDE99 ' + data.balance + ' credits';
       }
       Goop.toast({ title: 'Template Applied', message: msg, duration: 5000, level: 'success' });
+      loadSnapshots();
     })
     .catch(function(err) {
       var errMsg = err.message || 'Unknown error';
       Goop.toast({ title: 'Error', message: errMsg, duration: 6000, level: 'error' });
+    })
+    .then(function() { if (install) install.finish(); });
+  }
+
+  // ── Install progress of a store template ──
+  // The rendezvous reports the bundle download and the peer reports the
+  // apply; both arrive on the session's event stream. Resolves to null when
+  // the rendezvous has no install sessions, and the apply runs without.
+  function openInstall(dir) {
+    return fetch('/api/templates/install', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ template: dir, csrf: csrf })
+    })
+    .then(function(res) { return res.ok ? res.json() : null; })
+    .catch(function() { return null; })
+    .then(function(data) {
+      if (!data || !data.session || !window.EventSource) return null;
+      var card = document.querySelector('.tpl-card[data-dir="' + dir + '"]');
+      var box = document.createElement('div');
+      box.className = 'tpl-install';
+      box.innerHTML = '<div class="progress-bar"><div class="progress-fill" style="width:0%"></div></div>' +
+        '<div class="tpl-install-msg muted small">Starting\u2026</div>';
+      if (card) card.appendChild(box);
+      var fill = box.querySelector('.progress-fill');
+      var msg = box.querySelector('.tpl-install-msg');
+
+      var es = new EventSource('/api/templates/install/events?session=' + encodeURIComponent(data.session));
+      es.onmessage = function(e) {
+        var ev;
+        try { ev = JSON.parse(e.data); } catch (_) { return; }
+        showInstallStage(ev, fill, msg, box);
+        if (ev.done) es.close();
+      };
+
+      return {
+        session: data.session,
+        finish: function() {
+          // The apply answered; the stream has its last event or soon will.
+          setTimeout(function() {
+            es.close();
+            box.remove();
+          }, 4000);
+        }
+      };
+    });
+  }
+
+  function showInstallStage(ev, fill, msg, box) {
+    switch (ev.stage) {
+      case 'verifying':
+        msg.textContent = 'Checking access\u2026';
+        break;
+      case 'downloading':
+        if (ev.total) fill.style.width = Math.min(100, Math.round(ev.bytes * 100 / ev.total)) + '%';
+        msg.textContent = 'Downloading ' + formatSize(ev.bytes) + (ev.total ? ' of ' + formatSize(ev.total) : '');
+        break;
+      case 'downloaded':
+        fill.style.width = '100%';
+        msg.textContent = 'Downloaded ' + formatSize(ev.bytes) + ', checksum ' + (ev.sha256 || '').slice(0, 12);
+        break;
+      case 'applying':
+        msg.textContent = 'Applying\u2026';
+        break;
+      case 'applied':
+        msg.textContent = 'Applied';
+        break;
+      case 'failed':
+        box.classList.add('tpl-install-failed');
+        msg.textContent = 'Failed: ' + (ev.error || 'unknown error');
+        break;
+    }
+  }
+
+  function formatSize(bytes) {
+    if (!bytes) return '0 B';
+    var units = ['B', 'KB', 'MB', 'GB'];
+    var i = 0;
+    var b = bytes;
+    while (b >= 1024 && i < units.length - 1) {
+      b /= 1024;
+      i++;
+    }
+    return (i === 0 ? b : b.toFixed(1)) + ' ' + units[i];
+  }
+  // ── Update of the active store template ──
+  var updBox = document.getElementById('tpl-update');
+  var updBtn = document.getElementById('tpl-update-btn');
+
+  function showUpdate(name, installed, latest) {
+    if (!updBox) return;
+    document.getElementById('tpl-update-msg').textContent =
+      'A new version of "' + name + '" is available (' + (installed || '?') + ' \u2192 ' + latest + '). ' +
+      'Updating keeps your content and data.';
+    updBox.style.display = '';
+  }
+
+  fetch('/api/templates/update').then(function(r) { return r.json(); }).then(function(st) {
+    if (st.available) showUpdate(st.template, st.installed, st.latest);
+  }).catch(function() {});
+
+  if (Goop.mq && Goop.mq.onTemplateUpdate) {
+    Goop.mq.onTemplateUpdate(function(from, topic, p) {
+      showUpdate(p.name || p.template, p.installed, p.latest);
+    });
+  }
+
+  if (updBtn) {
+    updBtn.addEventListener('click', function() {
+      updBtn.disabled = true;
+      fetch('/api/templates/update', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ csrf: csrf })
+      })
+      .then(function(res) {
+        if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
+        return res.json();
+      })
+      .then(function(data) {
+        updBox.style.display = 'none';
+        Goop.toast({ title: 'Template Updated', message: '"' + data.template + '" is now at version ' + data.version + '.', duration: 5000, level: 'success' });
+        loadSnapshots();
+      })
+      .catch(function(err) {
+        Goop.toast({ title: 'Error', message: err.message || 'Unknown error', duration: 6000, level: 'error' });
+      })
+      .then(function() { updBtn.disabled = false; });
+    });
+  }
+
+  // ── Revert the last apply ──
+  var revBox = document.getElementById('tpl-revert');
+  var revBtn = document.getElementById('tpl-revert-btn');
+
+  function loadSnapshots() {
+    if (!revBox) return;
+    fetch('/api/templates/snapshots').then(function(r) { return r.json(); }).then(function(list) {
+      if (!list || !list.length) {
+        revBox.style.display = 'none';
+        return;
+      }
+      var s = list[0];
+      document.getElementById('tpl-revert-msg').textContent =
+        'Applied "' + s.reason + '" on ' + new Date(s.created).toLocaleString() + '. ' +
+        'Revert to ' + (s.template ? '"' + s.template + '"' : 'the site as it was') + '?';
+      revBox.style.display = '';
+    }).catch(function() {});
+  }
+  loadSnapshots();
+
+  if (revBtn) {
+    revBtn.addEventListener('click', function() {
+      Goop.dialog.confirm('Revert the last template apply?\n\nThe site and template tables are restored from the snapshot taken before it. Changes made since are lost.', 'Revert Template').then(function(ok) {
+        if (!ok) return;
+        revBtn.disabled = true;
+        fetch('/api/templates/revert', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ csrf: csrf })
+        })
+        .then(function(res) {
+          if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
+          return res.json();
+        })
+        .then(function() {
+          window.location.reload();
+        })
+        .catch(function(err) {
+          Goop.toast({ title: 'Error', message: err.message || 'Unknown error', duration: 6000, level: 'error' });
+          revBtn.disabled = false;
+        });
+      });
     });
   }
+
   // ── Local Template ──
   var tplLocal = document.getElementById('tpl-local');
   if (tplLocal && window.Goop.pathpicker) {
@@ -94,7 +274,7 @@
         body: JSON.stringify({ path: path })
       })
       .then(function(res) {
-        if (!res.ok) return res.text().then(function(t) { throw new Error(t); });
+        if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
         return res.json();
       })
       .then(function(meta) {
@@ -114,7 +294,7 @@
       applyBtn.addEventListener('click', function() {
         if (!localPath) return;
         var name = document.getElementById('local-tpl-name').textContent || 'local template';
-        var msg = 'Apply "' + name + '"?\n\nThis will DELETE all your current site files and database tables and replace them with the template. This cannot be undone.';
+        var msg = 'Apply "' + name + '"?\n\nThis will DELETE all your current site files and database tables and replace them with the template. A snapshot of the current site is kept so you can revert.';
 
         Goop.dialog.confirm(msg, 'Apply Template').then(function(ok) {
           if (!ok) return;
@@ -124,7 +304,7 @@
             body: JSON.stringify({ path: localPath, csrf: csrf })
           })
           .then(function(res) {
-            if (!res.ok) return res.text().then(function(t) { throw new Error(t); });
+            if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
             return res.json();
           })
           .then(function(data) {
@@ -137,6 +317,7 @@
             }
             document.querySelectorAll('.tpl-card-active').forEach(function(c) { c.classList.remove('tpl-card-active'); });
             Goop.toast({ title: 'Template Applied', message: '"' + (data.template || name) + '" is now active.', duration: 5000, level: 'success' });
+            loadSnapshots();
           })
           .catch(function(err) {
             Goop.toast({ title: 'Error', message: err.message || 'Unknown error', duration: 6000, level: 'error' });
@@ -144,5 +325,40 @@
         });
       });
     }
+
+    var publishBtn = document.getElementById('local-tpl-publish');
+    if (publishBtn) {
+      publishBtn.addEventListener('click', function() {
+        if (!localPath) return;
+        var name = document.getElementById('local-tpl-name').textContent || 'local template';
+        var msg = 'Publish "' + name + '" to the template store?\n\nEveryone on your rendezvous can then install it, listed under your peer ID. Publishing again updates it.';
+
+        Goop.dialog.confirm(msg, 'Publish Template').then(function(ok) {
+          if (!ok) return;
+          publishBtn.disabled = true;
+          fetch('/api/templates/publish', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ path: localPath, csrf: csrf })
+          })
+          .then(function(res) {
+            if (!res.ok) return Goop.core.errorFromResponse(res).then(function(e) { throw e; });
+            return res.json();
+          })
+          .then(function(data) {
+            var failed = (data.results || []).filter(function(r) { return r.error; });
+            if (failed.length) {
+              Goop.toast({ title: 'Publish Failed', message: failed.map(function(r) { return r.server + ': ' + r.error; }).join('\n'), duration: 8000, level: 'error' });
+            } else {
+              Goop.toast({ title: 'Template Published', message: '"' + name + '" is in the store as ' + data.template + '.', duration: 5000, level: 'success' });
+            }
+          })
+          .catch(function(err) {
+            Goop.toast({ title: 'Error', message: err.message || 'Unknown error', duration: 6000, level: 'error' });
+          })
+          .finally(function() { publishBtn.disabled = false; });
+        });
+      });
+    }
   }
 })();
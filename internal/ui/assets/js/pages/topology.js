@@ -20,9 +20,21 @@
   var _dragNode = null;    // node being dragged (from canvas._nodes)
   var _manualPos = {};     // peer id → {x, y} — manually placed nodes
 
+  // Link quality per peer from the previous fetch, to spot upgrades.
+  var _quality = {};
+
+  var LINK_BADGES = { 'direct-lan': 'LAN', 'direct-wan': 'direct', 'holepunch': 'punched', 'relay': 'relay' };
+  var LINK_TITLES = {
+    'direct-lan': 'direct (LAN)',
+    'direct-wan': 'direct (internet)',
+    'holepunch':  'direct (hole-punched)',
+    'relay':      'relayed',
+    'none':       'not connected'
+  };
+
   window._topologyStart = function() {
     _topologyStop();
-    _zoom = 1; _panX = 0; _panY = 0; _manualPos = {};
+    _zoom = 1; _panX = 0; _panY = 0; _manualPos = {}; _quality = {};
     fetchData();
     _timer = setInterval(fetchData, 10000);
     _pulse = 0;
@@ -39,6 +51,7 @@
   function fetchData() {
     fetch('/api/topology').then(function(r) { return r.json(); }).then(function(data) {
       _data = data;
+      celebrateDirectLinks(data.peers || []);
       render();
     }).catch(function(err) {
       _data = null;
@@ -195,8 +208,8 @@
       var shortLabel = truncLabel(p.label, 10);
       drawNodeLabel(ctx, shortLabel, pos.x, pos.y + nodeR + 10, colText, 11);
       // Connection type badge.
-      var badge = p.connection === 'bridge' ? 'bridge' :
-                  p.connection === 'direct' ? (isPrivateAddr(p.addr) ? 'LAN' : 'direct') : 'relay';
+      var badge = p.connection === 'bridge' ? 'bridge' : LINK_BADGES[p.quality] ||
+                  (p.connection === 'direct' ? (isPrivateAddr(p.addr) ? 'LAN' : 'direct') : 'relay');
       drawBadge(ctx, pos.x, pos.y - nodeR - 6, badge, col, colBg);
       // Encryption indicator (small lock icon top-right of node).
       if (p.encryption_supported) {
@@ -284,7 +297,7 @@
         if (p.age && p.age !== '0s') lines.push('uptime: ' + p.age);
         if (p.connected_peers) lines.push('peers: ' + p.connected_peers);
         if (p.streams) lines.push('streams: ' + p.streams);
-        if (p.connection) lines.push('via: ' + p.connection);
+        if (p.connection) lines.push('via: ' + (LINK_TITLES[p.quality] || p.connection));
         if (p.has_circuit !== undefined) lines.push('circuit: ' + (p.has_circuit ? 'active' : 'none'));
         lines.push('encryption: ' + (p.encryption_supported ? 'yes' : 'no'));
         drawTooltip(ctx, _hover.x, _hover.y - 16, lines, colPanel, colText);
@@ -322,6 +335,26 @@
     return out;
   }
 
+  // Toast when a relayed peer becomes directly connected, e.g. after a
+  // hole punch: the link just got a lot faster.
+  function celebrateDirectLinks(peers) {
+    var next = {};
+    peers.forEach(function(p) {
+      if (!p.quality) return;
+      next[p.id] = p.quality;
+      var direct = p.quality !== 'relay' && p.quality !== 'none';
+      if (direct && _quality[p.id] === 'relay' && Goop.toast) {
+        Goop.toast({
+          icon: '\u26A1',
+          title: 'Direct link to ' + (p.label || p.id.slice(0, 8)),
+          message: 'Now ' + LINK_TITLES[p.quality] + ', no longer through the relay.',
+          duration: 6000,
+        });
+      }
+    });
+    _quality = next;
+  }
+
   // Check if a multiaddr points to a private/LAN IP.
   function isPrivateAddr(addr) {
     if (!addr) return false;
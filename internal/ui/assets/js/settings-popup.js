@@ -11,6 +11,7 @@
 
   var isOpen = false;
   var backdrop = null;
+  var savedDevices = null; // { cam, mic } as loaded, so save can tell what changed
 
   // ── helpers ──────────────────────────────────────────────────────────────────
 
@@ -187,17 +188,6 @@
   // ── device enumeration ────────────────────────────────────────────────────
 
   // Request brief media permission so enumerateDevices returns real IDs/labels.
-  function ensureDevicePermission() {
-    return navigator.mediaDevices.enumerateDevices().then(function(devices) {
-      var hasLabels = devices.some(function(d) { return !!d.label; });
-      if (hasLabels) return; // permission already granted
-      // Briefly grab media to trigger the permission prompt
-      return navigator.mediaDevices.getUserMedia({ audio: true, video: true })
-        .then(function(stream) { stream.getTracks().forEach(function(t) { t.stop(); }); })
-        .catch(function() { /* user denied — we'll just show System default */ });
-    });
-  }
-
   function enumerateDevices(camEl, micEl) {
     if (!gsel()) return;
 
@@ -213,25 +203,23 @@
           return;
         }
 
-        if (!navigator.mediaDevices || !navigator.mediaDevices.enumerateDevices) return;
+        if (!Goop.call || !Goop.call.devices) return;
 
-        // Ensure permission first, then enumerate with full labels
-        return ensureDevicePermission().then(function() {
-          return navigator.mediaDevices.enumerateDevices();
-        }).then(function(devices) {
+        // Devices of the active call stack: Go's capture devices in native
+        // mode, getUserMedia's otherwise (permission is requested for labels).
+        return Goop.call.devices().then(function(devices) {
           var camPref = cfg.preferred_cam || '';
           var micPref = cfg.preferred_mic || '';
+          savedDevices = { cam: camPref, mic: micPref };
 
           var camOpts = [{ value: '', label: 'System default' }];
           var micOpts = [{ value: '', label: 'System default' }];
 
           devices.forEach(function(dev) {
-            if (!dev.deviceId) return; // skip phantom entries
-            var lbl = dev.label || (dev.kind + ' ' + dev.deviceId.substring(0, 8));
-            if (dev.kind === 'videoinput') {
-              camOpts.push({ value: dev.deviceId, label: lbl });
-            } else if (dev.kind === 'audioinput') {
-              micOpts.push({ value: dev.deviceId, label: lbl });
+            if (dev.kind === 'video') {
+              camOpts.push({ value: dev.id, label: dev.label });
+            } else if (dev.kind === 'audio') {
+              micOpts.push({ value: dev.id, label: dev.label });
             }
           });
 
@@ -273,6 +261,15 @@
     // Save all to peer config — close popup only after success
     Goop.api.settings.save(payload).then(function() {
 
+      // Calls in progress switch to the new devices right away.
+      if (savedDevices && Goop.call && Goop.call.useDevices) {
+        var change = {};
+        if (camVal !== savedDevices.cam) change.cam = camVal;
+        if (micVal !== savedDevices.mic) change.mic = micVal;
+        savedDevices = { cam: camVal, mic: micVal };
+        Goop.call.useDevices(change);
+      }
+
       // Update navbar name
       var meLabel = document.querySelector('.me-label');
       if (meLabel) meLabel.textContent = labelVal || 'Me';
@@ -0,0 +1,92 @@
+// Service worker for the installable viewer (served at /sw.js so its scope
+// is the whole UI). The viewer serves assets with no-cache headers so edits
+// show up at once; this worker keeps that: every request goes to the network
+// first and the cache only answers when the peer is unreachable.
+//
+// Never cached: /api/ (live state, SSE), /p/ (remote peer sites) and
+// anything that is not a same-origin GET.
+
+const CACHE = "goop-shell-v1";
+
+const SHELL = [
+  "/peers",
+  "/self",
+  "/assets/app.css",
+  "/assets/app.js",
+  "/assets/images/goop2-32.png",
+  "/assets/images/goop2-192.png",
+  "/manifest.webmanifest",
+];
+
+const OFFLINE_HTML = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Goop² — offline</title>
+<style>
+  body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;
+       font-family:system-ui,sans-serif;background:#0f1115;color:#e6e8ee;text-align:center}
+  img{width:96px;height:96px}
+  button{margin-top:16px;padding:8px 18px;border-radius:8px;border:1px solid #6c8cff;
+         background:transparent;color:#e6e8ee;cursor:pointer}
+</style></head>
+<body><div>
+  <img src="/assets/images/goop2-192.png" alt="">
+  <h2>Peer not reachable</h2>
+  <p>The Goop² peer serving this page is not running or the network is down.</p>
+  <button onclick="location.reload()">Retry</button>
+</div></body></html>`;
+
+self.addEventListener("install", (e) => {
+  // Add one by one: a missing entry (e.g. rendezvous-only mode) must not
+  // fail the whole install.
+  e.waitUntil(
+    caches.open(CACHE)
+      .then((c) => Promise.all(SHELL.map((u) => c.add(u).catch(() => {}))))
+      .then(() => self.skipWaiting())
+  );
+});
+
+self.addEventListener("activate", (e) => {
+  e.waitUntil(
+    caches.keys()
+      .then((keys) => Promise.all(keys.filter((k) => k !== CACHE).map((k) => caches.delete(k))))
+      .then(() => self.clients.claim())
+  );
+});
+
+function cacheable(req, url) {
+  if (req.method !== "GET" || url.origin !== self.location.origin) return false;
+  if (url.pathname.startsWith("/api/") || url.pathname.startsWith("/p/")) return false;
+  if ((req.headers.get("accept") || "").includes("text/event-stream")) return false;
+  return true;
+}
+
+self.addEventListener("fetch", (e) => {
+  const req = e.request;
+  const url = new URL(req.url);
+  if (!cacheable(req, url)) return;
+
+  e.respondWith(
+    fetch(req)
+      .then((res) => {
+        if (res.ok && res.type === "basic" && !res.redirected) {
+          const copy = res.clone();
+          caches.open(CACHE).then((c) => c.put(req, copy));
+        }
+        return res;
+      })
+      .catch(async () => {
+        const hit = await caches.match(req, { ignoreSearch: req.mode === "navigate" });
+        if (hit) return hit;
+        if (req.mode === "navigate") {
+          // "/" only ever redirects; fall back to the peers page.
+          const home = url.pathname === "/" && await caches.match("/peers");
+          if (home) return home;
+          return new Response(OFFLINE_HTML, {
+            status: 503,
+            headers: { "Content-Type": "text/html; charset=utf-8" },
+          });
+        }
+        return Response.error();
+      })
+  );
+});
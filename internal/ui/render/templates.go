@@ -32,6 +32,7 @@ func InitTemplates() error {
 			"rfc3339":  func(t time.Time) string { return t.Format(time.RFC3339) },
 			"isActive": func(active, key string) bool { return active == key },
 			"trim":     strings.TrimSpace,
+			"lines":    func(s []string) string { return strings.Join(s, "\n") },
 
 			"defaults": func() config.Config { return config.Default() },
 
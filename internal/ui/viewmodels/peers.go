@@ -3,6 +3,7 @@ package viewmodels
 
 import (
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/petervdpas/goop2/internal/state"
@@ -17,15 +18,18 @@ type PeerRow struct {
 	ActiveTemplate string    `json:"ActiveTemplate"`
 	PublicKey      string    `json:"PublicKey,omitempty"`
 	Verified       bool      `json:"Verified"`
+	Signed         bool      `json:"Signed"`
 	Reachable      bool      `json:"Reachable"`
 	Offline        bool      `json:"Offline"`
 	LastSeen       time.Time `json:"LastSeen"`
 	Favorite       bool      `json:"Favorite"`
+	Link           string    `json:"Link,omitempty"` // how the peer is reached, see p2p.LinkQuality
 }
 
 type PeersVM struct {
 	BaseVM
 	Peers             []PeerRow
+	Namespaces        []string // presence namespaces besides the global list
 	SelfVideoDisabled bool
 	HideUnverified    bool
 	Splash            string
@@ -41,6 +45,7 @@ func BuildPeerRow(id string, sp state.SeenPeer) PeerRow {
 		ActiveTemplate: sp.ActiveTemplate,
 		PublicKey:      sp.PublicKey,
 		Verified:       sp.Verified,
+		Signed:         sp.Signed,
 		Reachable:      sp.Reachable,
 		Offline:        !sp.OfflineSince.IsZero(),
 		LastSeen:       sp.LastSeen,
@@ -56,3 +61,83 @@ func BuildPeerRows(m map[string]state.SeenPeer) []PeerRow {
 	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
 	return rows
 }
+
+// PeerQuery filters, sorts and pages a peer list for /api/peers.
+type PeerQuery struct {
+	Query     string // case-insensitive substring of the label, or of the ID with ByID
+	ByID      bool
+	Favorites bool   // favorites only
+	Verified  bool   // verified peers only
+	Online    bool   // online peers only
+	Sort      string // "id" (default), "name" or "last_seen" (most recent first)
+	Offset    int
+	Limit     int // 0 = all
+}
+
+// PeerSummary counts the peers of a whole list, before filtering.
+type PeerSummary struct {
+	Total     int `json:"total"`
+	Online    int `json:"online"`
+	Favorites int `json:"favorites"`
+	Verified  int `json:"verified"`
+	Matched   int `json:"matched"` // rows passing the filters, before paging
+	Capacity  int `json:"capacity,omitempty"`
+	Evicted   int `json:"evicted,omitempty"`
+}
+
+// PeerPage is one page of a filtered peer list.
+type PeerPage struct {
+	Peers   []PeerRow   `json:"peers"`
+	Offset  int         `json:"offset"`
+	Limit   int         `json:"limit"`
+	Summary PeerSummary `json:"summary"`
+}
+
+// PagePeerRows applies q to rows, which are sorted by ID.
+func PagePeerRows(rows []PeerRow, q PeerQuery) PeerPage {
+	var sum PeerSummary
+	query := strings.ToLower(strings.TrimSpace(q.Query))
+	matched := make([]PeerRow, 0, len(rows))
+	for _, r := range rows {
+		sum.Total++
+		if !r.Offline {
+			sum.Online++
+		}
+		if r.Favorite {
+			sum.Favorites++
+		}
+		if r.Verified {
+			sum.Verified++
+		}
+		if (q.Favorites && !r.Favorite) || (q.Verified && !r.Verified) || (q.Online && r.Offline) {
+			continue
+		}
+		if query != "" {
+			field := r.Content
+			if q.ByID {
+				field = r.ID
+			}
+			if !strings.Contains(strings.ToLower(field), query) {
+				continue
+			}
+		}
+		matched = append(matched, r)
+	}
+	sum.Matched = len(matched)
+
+	switch q.Sort {
+	case "name":
+		sort.SliceStable(matched, func(i, j int) bool {
+			return strings.ToLower(matched[i].Content) < strings.ToLower(matched[j].Content)
+		})
+	case "last_seen":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].LastSeen.After(matched[j].LastSeen) })
+	}
+
+	offset := min(max(q.Offset, 0), len(matched))
+	end := len(matched)
+	if q.Limit > 0 {
+		end = min(offset+q.Limit, end)
+	}
+	return PeerPage{Peers: matched[offset:end], Offset: offset, Limit: q.Limit, Summary: sum}
+}
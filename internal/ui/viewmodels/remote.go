@@ -0,0 +1,10 @@
+package viewmodels
+
+// RemoteVM drives the standalone phone pages (/pair and /remote).
+type RemoteVM struct {
+	SelfName   string
+	SelfID     string
+	DeviceName string
+	Scopes     string // comma-separated; the page shows only what they allow
+	Theme      string
+}
@@ -9,8 +9,9 @@ type SettingsVM struct {
 	CSRF       string
 	AvatarHash string
 
-	Saved bool
-	Error string
+	Saved    bool
+	Error    string
+	Warnings []config.Issue // from config.Check
 
 	Cfg config.Config
 }
@@ -16,4 +16,5 @@ type TemplatesVM struct {
 	HasCredits           bool            // true when credit system is active
 	StoreError           string
 	ActiveTemplate       string // dir name of currently active template
+	CanPublish           bool   // a rendezvous is configured to publish local templates to
 }
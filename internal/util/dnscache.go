@@ -27,8 +27,11 @@ func NewDNSCache(host string, resolveTimeout, cacheTTL time.Duration) *DNSCache
 	}
 }
 
+// Resolve returns the cached address of Host, looking it up when the
+// cache is empty or expired. Behind a SOCKS5 proxy the host name itself is
+// returned; the proxy resolves it.
 func (d *DNSCache) Resolve(ctx context.Context) (string, error) {
-	if d.Host == "" || net.ParseIP(d.Host) != nil {
+	if d.Host == "" || net.ParseIP(d.Host) != nil || Proxied() {
 		return d.Host, nil
 	}
 
@@ -66,7 +69,7 @@ func (d *DNSCache) Resolve(ctx context.Context) (string, error) {
 }
 
 func (d *DNSCache) Ready() bool {
-	if d.Host == "" || net.ParseIP(d.Host) != nil {
+	if d.Host == "" || net.ParseIP(d.Host) != nil || Proxied() {
 		return true
 	}
 	d.mu.RLock()
@@ -83,12 +86,8 @@ func (d *DNSCache) Clear() {
 
 func (d *DNSCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
 	host, port, err := net.SplitHostPort(addr)
-	if err != nil {
-		return (&net.Dialer{}).DialContext(ctx, network, addr)
-	}
-
-	if host != d.Host {
-		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	if err != nil || host != d.Host || Proxied() {
+		return Dial(ctx, network, addr)
 	}
 
 	ip, err := d.Resolve(context.Background())
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/net/proxy"
+)
+
+// socksDialer is the SOCKS5 proxy every outbound connection goes through
+// once UseSOCKS5 is called; nil dials directly.
+var socksDialer atomic.Pointer[proxy.ContextDialer]
+
+// UseSOCKS5 sends outbound connections through the SOCKS5 proxy at addr,
+// such as Tor's 127.0.0.1:9050: those made with Dial, through a DNSCache
+// and by http.DefaultTransport. Host names go to the proxy unresolved, so
+// no DNS query leaves the machine. Loopback addresses are still dialed
+// directly. An empty addr goes back to dialing everything directly.
+func UseSOCKS5(addr string) error {
+	if addr == "" {
+		socksDialer.Store(nil)
+		return nil
+	}
+	d, err := proxy.SOCKS5("tcp", addr, nil, &net.Dialer{})
+	if err != nil {
+		return fmt.Errorf("socks5 proxy %s: %w", addr, err)
+	}
+	cd, ok := d.(proxy.ContextDialer)
+	if !ok {
+		return fmt.Errorf("socks5 proxy %s: dialer has no context support", addr)
+	}
+	socksDialer.Store(&cd)
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = nil // HTTP(S)_PROXY would bypass the SOCKS proxy
+		t.DialContext = Dial
+	}
+	return nil
+}
+
+// Proxied reports whether outbound connections go through a SOCKS5 proxy.
+func Proxied() bool {
+	return socksDialer.Load() != nil
+}
+
+// Dial connects to addr, through the SOCKS5 proxy when one is in use.
+func Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d := socksDialer.Load(); d != nil && !isLoopback(addr) {
+		return (*d).DialContext(ctx, network, addr)
+	}
+	return (&net.Dialer{}).DialContext(ctx, network, addr)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
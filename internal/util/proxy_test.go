@@ -0,0 +1,106 @@
+package util
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeSOCKS5 serves CONNECT requests for host names: it sends each
+// requested address on the returned channel and then echoes the connection.
+func fakeSOCKS5(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	targets := make(chan string, 4)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				buf := make([]byte, 262)
+				// Greeting: version, method count, methods; pick no auth.
+				if _, err := io.ReadFull(c, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(c, buf[:buf[1]])
+				c.Write([]byte{5, 0})
+				// Request: version, CONNECT, reserved, domain name type.
+				if _, err := io.ReadFull(c, buf[:5]); err != nil || buf[3] != 3 {
+					return
+				}
+				n := int(buf[4])
+				io.ReadFull(c, buf[:n+2])
+				targets <- net.JoinHostPort(string(buf[:n]), strconv.Itoa(int(buf[n])<<8|int(buf[n+1])))
+				c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String(), targets
+}
+
+func useTestProxy(t *testing.T) <-chan string {
+	t.Helper()
+	addr, targets := fakeSOCKS5(t)
+	dial := http.DefaultTransport.(*http.Transport).DialContext
+	if err := UseSOCKS5(addr); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		UseSOCKS5("")
+		http.DefaultTransport.(*http.Transport).DialContext = dial
+	})
+	return targets
+}
+
+func TestUseSOCKS5_HostNameReachesProxy(t *testing.T) {
+	targets := useTestProxy(t)
+
+	d := NewDNSCache("rendezvous.invalid", time.Second, time.Minute)
+	if !d.Ready() {
+		t.Fatal("cache should be ready behind a proxy")
+	}
+	conn, err := d.DialContext(t.Context(), "tcp", "rendezvous.invalid:8787")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if got := <-targets; got != "rendezvous.invalid:8787" {
+		t.Fatalf("proxy asked for %q", got)
+	}
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("echo = %q, %v", buf, err)
+	}
+}
+
+func TestUseSOCKS5_LoopbackIsDirect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	targets := useTestProxy(t)
+
+	conn, err := Dial(t.Context(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	select {
+	case got := <-targets:
+		t.Fatalf("loopback dial went to the proxy: %s", got)
+	default:
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/ui/render"
 	"github.com/petervdpas/goop2/internal/util"
 )
@@ -57,10 +58,20 @@ func proxyPeerSite(v Viewer) http.HandlerFunc {
 			ctx, cancel := context.WithTimeout(r.Context(), util.DefaultFetchTimeout)
 			defer cancel()
 
-			data, _, err := v.Content.Read(ctx, rel)
-			if err != nil {
-				http.NotFound(w, r)
-				return
+			// Same asset pipeline as visitors get, so the preview matches.
+			var data []byte
+			if v.Assets != nil {
+				data, _ = v.Assets.Variant(rel)
+			}
+			if data == nil {
+				var err error
+				if data, _, err = v.Content.Read(ctx, rel); err != nil {
+					http.NotFound(w, r)
+					return
+				}
+				if v.Assets != nil && strings.EqualFold(path.Ext(rel), ".html") {
+					data = v.Assets.RewriteHTML(rel, data)
+				}
 			}
 
 			setPeerSiteHeaders(w)
@@ -70,6 +81,25 @@ func proxyPeerSite(v Viewer) http.HandlerFunc {
 		}
 
 		// 🌍 Remote peer proxy (untrusted content)
+
+		// The site hash the peer announces versions everything it serves,
+		// so a browser that already holds the current copy is answered
+		// without another fetch over p2p.
+		etag := peerSiteETag(v.Peers, peerID)
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			setPeerSiteHeaders(w)
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		// A followed peer that is known to be offline is served from the
+		// local copy straight away rather than after the dial times out.
+		if peerOffline(v, peerID) && serveSiteCopy(w, v, peerID, reqPath, setPeerSiteHeaders) {
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(r.Context(), 2*util.DefaultFetchTimeout)
 		defer cancel()
 
@@ -81,6 +111,7 @@ func proxyPeerSite(v Viewer) http.HandlerFunc {
 				http.NotFound(w, r)
 			case strings.Contains(msg, "forbidden"):
 				http.Error(w, "forbidden", http.StatusForbidden)
+			case serveSiteCopy(w, v, peerID, reqPath, setPeerSiteHeaders):
 			default:
 				w.WriteHeader(http.StatusBadGateway)
 				render.RenderStandalone(w, "page.error_unreachable", struct{ Detail string }{
@@ -96,7 +127,24 @@ func proxyPeerSite(v Viewer) http.HandlerFunc {
 			mt = contentTypeForPath(strings.TrimPrefix(reqPath, "/"), data)
 		}
 		w.Header().Set("Content-Type", mt)
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "no-cache")
+		}
 
 		_, _ = w.Write(data)
 	}
 }
+
+// peerSiteETag is the validator for a remote peer's files, or "" when the
+// peer does not announce a site hash.
+func peerSiteETag(peers *state.PeerTable, peerID string) string {
+	if peers == nil {
+		return ""
+	}
+	sp, ok := peers.Get(peerID)
+	if !ok || sp.SiteHash == "" {
+		return ""
+	}
+	return `"` + sp.SiteHash + `"`
+}
@@ -0,0 +1,129 @@
+package viewer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/pairing"
+	"github.com/petervdpas/goop2/internal/viewer/routes"
+)
+
+// The remote listener serves the same mux as the local viewer on a LAN
+// address, but only to paired devices and guest sessions, and only the
+// routes their scopes allow (pairing.Allowed). The local viewer stays localhost-only and unchanged.
+
+// remoteMaxSendBody bounds the /api/mq/send body read to check its topic;
+// call signaling (SDP offers) is well below this.
+const remoteMaxSendBody = 1 << 20
+
+// remoteOpen is reachable before pairing: the code entry page and what it
+// needs to load and install, plus calendar feeds, which carry their own token.
+func remoteOpen(path string) bool {
+	switch path {
+	case "/pair", "/api/pair/claim", "/guest", "/sw.js", "/manifest.webmanifest":
+		return true
+	}
+	return strings.HasPrefix(path, "/assets/") || strings.HasPrefix(path, "/calendar/")
+}
+
+func remoteHandler(mux http.Handler, pm *pairing.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path == "/" {
+			http.Redirect(w, r, "/remote", http.StatusFound)
+			return
+		}
+		if remoteOpen(path) {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		dev, ok := pm.Authenticate(pairing.TokenFromRequest(r))
+		if !ok {
+			if strings.HasPrefix(path, "/api/") {
+				http.Error(w, "device not paired", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "/pair", http.StatusFound)
+			return
+		}
+		if path == "/remote" && pairing.IsGuest(dev) {
+			http.Redirect(w, r, "/peers", http.StatusFound)
+			return
+		}
+		if path != "/remote" && !pairing.Allowed(dev, r.Method, path) {
+			http.Error(w, "not allowed for this device", http.StatusForbidden)
+			return
+		}
+		if path == "/api/mq/send" {
+			body, err := io.ReadAll(io.LimitReader(r.Body, remoteMaxSendBody))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				Topic string `json:"topic"`
+			}
+			_ = json.Unmarshal(body, &req)
+			if !pairing.SendAllowed(dev, req.Topic) {
+				http.Error(w, "topic not allowed for this device", http.StatusForbidden)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		mux.ServeHTTP(w, r.WithContext(pairing.WithDevice(r.Context(), dev)))
+	})
+}
+
+// serveRemote runs the remote listener until it fails. With a certificate it
+// serves HTTPS, which phone browsers require for camera and mic access.
+func serveRemote(addr, certFile, keyFile string, mux http.Handler, pm *pairing.Manager, onServer func(*http.Server)) {
+	srv := &http.Server{Addr: addr, Handler: routes.ErrorEnvelope(remoteHandler(mux, pm))}
+	if onServer != nil {
+		onServer(srv)
+	}
+	var err error
+	if certFile != "" {
+		log.Printf("viewer: remote control on https://%s", addr)
+		err = srv.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		log.Printf("viewer: remote control on http://%s (no TLS: phone calls unavailable)", addr)
+		err = srv.ListenAndServe()
+	}
+	log.Printf("viewer: remote listener stopped: %v", err)
+}
+
+// remoteURL is the address to open on the phone. An unspecified host is
+// replaced with this machine's first private LAN address.
+func remoteURL(addr string, tls bool) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = lanIP()
+	}
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+func lanIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "localhost"
+	}
+	for _, a := range addrs {
+		if ipn, ok := a.(*net.IPNet); ok && ipn.IP.To4() != nil && ipn.IP.IsPrivate() {
+			return ipn.IP.String()
+		}
+	}
+	return "localhost"
+}
@@ -0,0 +1,134 @@
+package viewer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/pairing"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func pairedRemote(t *testing.T, scopes ...string) (http.Handler, string) {
+	t.Helper()
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	pm := pairing.New(db)
+	c, err := pm.Start(scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := pm.Claim(c.Code, "Phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := pairing.FromContext(r.Context()); ok {
+			w.Header().Set("X-Device", "1")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return remoteHandler(mux, pm), token
+}
+
+func TestRemoteHandler_Unpaired(t *testing.T) {
+	h, _ := pairedRemote(t)
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/", http.StatusFound},
+		{"/remote", http.StatusFound},
+		{"/api/peers", http.StatusUnauthorized},
+		{"/pair", http.StatusOK},
+		{"/assets/app.css", http.StatusOK},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.want {
+			t.Errorf("GET %s = %d, want %d", tc.path, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestRemoteHandler_Scopes(t *testing.T) {
+	h, token := pairedRemote(t, pairing.ScopeNotify)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/peers", ""); rec.Code != http.StatusOK || rec.Header().Get("X-Device") == "" {
+		t.Errorf("GET /api/peers = %d, device in context: %q", rec.Code, rec.Header().Get("X-Device"))
+	}
+	if rec := do(http.MethodGet, "/api/listen/state", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("listen without scope = %d, want 403", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/settings/save", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("unlisted route = %d, want 403", rec.Code)
+	}
+}
+
+func TestRemoteHandler_SendTopic(t *testing.T) {
+	h, token := pairedRemote(t, pairing.ScopeCall)
+
+	send := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/mq/send", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send(`{"peer_id":"x","topic":"call:abc","payload":{}}`); code != http.StatusOK {
+		t.Errorf("call topic = %d, want 200", code)
+	}
+	if code := send(`{"peer_id":"x","topic":"chat","payload":{}}`); code != http.StatusForbidden {
+		t.Errorf("chat topic = %d, want 403", code)
+	}
+}
+
+func TestRemoteHandler_Guest(t *testing.T) {
+	db, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	pm := pairing.New(db)
+	token, _, err := pm.StartGuest(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := remoteHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), pm)
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/remote", http.StatusFound},
+		{http.MethodGet, "/peers", http.StatusOK},
+		{http.MethodGet, "/p/12D3KooWExample/index.html", http.StatusOK},
+		{http.MethodPost, "/api/listen/control", http.StatusForbidden},
+		{http.MethodPost, "/api/peers/favorite", http.StatusForbidden},
+		{http.MethodGet, "/api/mq/events", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.AddCookie(&http.Cookie{Name: pairing.CookieName, Value: token})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/actions"
+	"github.com/petervdpas/goop2/internal/pairing"
+)
+
+func registerActionRoutes(mux *http.ServeMux, csrf string) {
+	// GET /api/actions — the actions registry. Actions whose route is not
+	// registered in this session (no listen room, no native calls, ...) are left out.
+	handleGet(mux, "/api/actions", func(w http.ResponseWriter, r *http.Request) {
+		all := actions.All()
+		out := make([]actions.Action, 0, len(all))
+		for _, a := range all {
+			if a.Path != "" {
+				probe, _ := http.NewRequest(a.Method, a.Path, nil)
+				if _, pattern := mux.Handler(probe); pattern == "" {
+					continue
+				}
+				a.Scopes = pairing.ScopesFor(a.Method, a.Path)
+			}
+			out = append(out, a)
+		}
+		writeJSON(w, out)
+	})
+
+	// POST /api/actions/run — invoke a server-side action by ID. The response
+	// is the underlying route's, unchanged.
+	handlePost(mux, "/api/actions/run", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID     string         `json:"id"`
+		Params map[string]any `json:"params"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		a, ok := actions.Get(req.ID)
+		if !ok {
+			http.Error(w, "unknown action: "+req.ID, http.StatusNotFound)
+			return
+		}
+		if a.NeedsCSRF() {
+			if req.Params == nil {
+				req.Params = map[string]any{}
+			}
+			req.Params["csrf"] = csrf
+		}
+		sub, err := actions.NewRequest(r.Context(), a, req.Params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sub.RemoteAddr = r.RemoteAddr
+		mux.ServeHTTP(w, sub)
+	})
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/actions"
+)
+
+func TestActionRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	registerActionRoutes(mux, "tok")
+
+	var applied map[string]any
+	mux.HandleFunc("/api/templates/apply", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&applied)
+		writeJSON(w, map[string]string{"status": "applied"})
+	})
+
+	// Only actions with a registered route (or a client handler) are listed.
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/actions", nil))
+	var list []actions.Action
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, a := range list {
+		ids[a.ID] = true
+	}
+	if !ids["templates.apply"] || !ids["call.start"] || ids["listen.play"] {
+		t.Fatalf("listed %v", ids)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/actions/run",
+		strings.NewReader(`{"id":"templates.apply","params":{"template":"blog"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "127.0.0.1:5555"
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || applied["template"] != "blog" || applied["csrf"] != "tok" {
+		t.Fatalf("run = %d %q, route got %v", rec.Code, rec.Body.String(), applied)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/actions/run",
+		strings.NewReader(`{"id":"call.start","params":{"peer_id":"p"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "127.0.0.1:5555"
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("client action run = %d, want 400", rec.Code)
+	}
+}
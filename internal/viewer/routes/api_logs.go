@@ -19,10 +19,10 @@ func registerAPILogRoutes(mux *http.ServeMux, d Deps) {
 	if d.Logs == nil {
 		return
 	}
-	mux.HandleFunc("/api/logs", d.Logs.ServeLogsJSON)
-	mux.HandleFunc("/api/logs/stream", d.Logs.ServeLogsSSE)
+	handleAny(mux, "/api/logs", d.Logs.ServeLogsJSON)
+	handleAny(mux, "/api/logs/stream", d.Logs.ServeLogsSSE)
 
-	mux.HandleFunc("/api/logs/verbose", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/logs/verbose", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]bool{"on": p2p.IsVerbose()})
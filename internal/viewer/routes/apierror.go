@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Errors from /api/ endpoints share one JSON shape:
+//
+//	{"error": {"code": "not_found", "message": "group not found", "field": "group_id"}}
+//
+// code is stable and meant for scripts; message is for people; field names
+// the request field at fault, when there is one. Handlers either call
+// writeError, or keep using http.Error and let ErrorEnvelope convert the
+// plain-text answer, so every route answers the same way.
+
+// Error codes. Statuses without a specific code get the one statusCode maps
+// them to.
+const (
+	ErrBadRequest       = "bad_request"
+	ErrInvalidJSON      = "invalid_json"
+	ErrMissingField     = "missing_field"
+	ErrInvalidField     = "invalid_field"
+	ErrUnauthorized     = "unauthorized"
+	ErrForbidden        = "forbidden"
+	ErrNotFound         = "not_found"
+	ErrMethodNotAllowed = "method_not_allowed"
+	ErrConflict         = "conflict"
+	ErrTooLarge         = "too_large"
+	ErrUnsupportedMedia = "unsupported_media_type"
+	ErrRateLimited      = "rate_limited"
+	ErrInternal         = "internal"
+	ErrUpstream         = "upstream_error"
+	ErrUnavailable      = "unavailable"
+	ErrTimeout          = "timeout"
+)
+
+// APIError is the body of an error response.
+type APIError struct {
+	Code    string `json:"code" example:"missing_field"`
+	Message string `json:"message" example:"group_id is required"`
+	Field   string `json:"field,omitempty" example:"group_id"`
+}
+
+// ErrorResponse is the envelope every /api/ error is sent in.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// writeError sends an error envelope. An empty code is derived from status.
+func writeError(w http.ResponseWriter, status int, code, message, field string) {
+	if code == "" {
+		code = statusCode(status)
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: APIError{Code: code, Message: message, Field: field}})
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound, http.StatusGone:
+		return ErrNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrMethodNotAllowed
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusBadGateway:
+		return ErrUpstream
+	case http.StatusServiceUnavailable:
+		return ErrUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrTimeout
+	}
+	if status >= 500 {
+		return ErrInternal
+	}
+	return ErrBadRequest
+}
+
+// ErrorEnvelope rewrites plain-text error responses of /api/ handlers, as
+// written by http.Error, into the JSON error envelope. Other responses,
+// streams and WebSocket upgrades pass through untouched.
+func ErrorEnvelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// maxErrorText caps the plain-text error kept for the envelope.
+const maxErrorText = 4096
+
+type envelopeWriter struct {
+	http.ResponseWriter
+	status  int
+	convert bool // a plain-text error is being collected
+	text    bytes.Buffer
+}
+
+func (ew *envelopeWriter) WriteHeader(status int) {
+	if ew.status != 0 {
+		return
+	}
+	ew.status = status
+	ct := ew.Header().Get("Content-Type")
+	if status >= 400 && (ct == "" || strings.HasPrefix(ct, "text/plain")) {
+		ew.convert = true
+		return
+	}
+	ew.ResponseWriter.WriteHeader(status)
+}
+
+func (ew *envelopeWriter) Write(b []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if !ew.convert {
+		return ew.ResponseWriter.Write(b)
+	}
+	if room := maxErrorText - ew.text.Len(); room > 0 {
+		ew.text.Write(b[:min(len(b), room)])
+	}
+	return len(b), nil
+}
+
+func (ew *envelopeWriter) Flush() {
+	if ew.convert {
+		return
+	}
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+func (ew *envelopeWriter) finish() {
+	if !ew.convert {
+		return
+	}
+	msg := strings.TrimSpace(ew.text.String())
+	if msg == "" {
+		msg = strings.ToLower(http.StatusText(ew.status))
+	}
+	writeError(ew.ResponseWriter, ew.status, "", msg, "")
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeEnvelope(t *testing.T, w *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, body %q", ct, w.Body.String())
+	}
+	var env ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return env.Error
+}
+
+func TestErrorEnvelope_convertsPlainTextErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "group not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/ok", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]bool{"ok": true})
+	})
+	mux.HandleFunc("/api/json-error", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusConflict, "", "already joined", "group_id")
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such page", http.StatusNotFound)
+	})
+	h := ErrorEnvelope(mux)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/api/missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d", w.Code)
+	}
+	if e := decodeEnvelope(t, w); e != (APIError{Code: ErrNotFound, Message: "group not found"}) {
+		t.Errorf("envelope = %+v", e)
+	}
+
+	if e := decodeEnvelope(t, get("/api/json-error")); e != (APIError{Code: ErrConflict, Message: "already joined", Field: "group_id"}) {
+		t.Errorf("envelope = %+v", e)
+	}
+	if w := get("/api/ok"); strings.TrimSpace(w.Body.String()) != `{"ok":true}` {
+		t.Errorf("success body = %q", w.Body.String())
+	}
+	if w := get("/page"); w.Body.String() != "no such page\n" {
+		t.Errorf("non-API error rewritten: %q", w.Body.String())
+	}
+	// Unknown /api/ paths fall to the mux's plain 404 and are converted too.
+	if e := decodeEnvelope(t, get("/api/nowhere")); e.Code != ErrNotFound {
+		t.Errorf("unrouted = %+v", e)
+	}
+}
+
+func TestHandlePost_validatesDocumentedBody(t *testing.T) {
+	mux := http.NewServeMux()
+	type consentReq struct {
+		ID       string `json:"id"`
+		Decision string `json:"decision"`
+	}
+	called := false
+	handlePost(mux, "/api/security/consent", func(w http.ResponseWriter, r *http.Request, req consentReq) {
+		called = true
+		writeJSON(w, req)
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/security/consent", strings.NewReader(body)))
+		return w
+	}
+
+	cases := []struct {
+		body string
+		want APIError
+	}{
+		{`{"decision":"once"}`, APIError{Code: ErrMissingField, Message: "id is required", Field: "id"}},
+		{`{"id":"","decision":"once"}`, APIError{Code: ErrMissingField, Message: "id is required", Field: "id"}},
+		{`{"id":"c1","decision":"maybe"}`, APIError{Code: ErrInvalidField, Message: "decision must be one of once, always, block, deny", Field: "decision"}},
+		{`{"id":7,"decision":"once"}`, APIError{Code: ErrInvalidField, Message: "id must be a string, not number", Field: "id"}},
+	}
+	for _, c := range cases {
+		w := post(c.body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", c.body, w.Code)
+			continue
+		}
+		if e := decodeEnvelope(t, w); e != c.want {
+			t.Errorf("%s: %+v, want %+v", c.body, e, c.want)
+		}
+	}
+	if e := decodeEnvelope(t, post(`{"id":`)); e.Code != ErrInvalidJSON {
+		t.Errorf("truncated body: %+v", e)
+	}
+	if called {
+		t.Error("handler ran for an invalid body")
+	}
+
+	if w := post(`{"id":"c1","decision":"always","extra":1}`); w.Code != http.StatusOK || !called {
+		t.Errorf("valid body: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestValidateBody_limits(t *testing.T) {
+	one, five := 1, 5
+	lo, hi := 1.0, 10.0
+	sc := &contractSchema{Properties: map[string]*contractSchema{
+		"name":  {Type: "string", MinLength: &one, MaxLength: &five},
+		"count": {Type: "integer", Minimum: &lo, Maximum: &hi},
+	}}
+	for body, want := range map[string]string{
+		`{"name":"abc","count":3}`: "",
+		`{"name":"abcdef"}`:        "name must be at most 5 characters",
+		`{"count":0}`:              "count must be at least 1",
+		`{"count":2.5}`:            "count must be a whole number",
+		`{"count":null}`:           "",
+	} {
+		got := ""
+		if e := validateBody([]byte(body), sc); e != nil {
+			got = e.Message
+		}
+		if got != want {
+			t.Errorf("%s: %q, want %q", body, got, want)
+		}
+	}
+}
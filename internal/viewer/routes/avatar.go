@@ -55,7 +55,7 @@ func registerAvatarRoutes(mux *http.ServeMux, d Deps) {
 	})
 
 	// DELETE /api/avatar — delete own avatar
-	mux.HandleFunc("/api/avatar/delete", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/avatar/delete", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
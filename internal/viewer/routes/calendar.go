@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/calendar"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// calendarFeedView is a feed token plus what the schedule page shows for it.
+type calendarFeedView struct {
+	storage.CalendarFeed
+	Name string `json:"name"`
+	Path string `json:"path"` // /calendar/<token>.ics, relative to wherever the viewer is reached
+}
+
+// registerCalendarRoutes manages scheduled listen sessions and group events
+// and serves them as ICS feeds. Managing is local-only; a feed is readable by
+// anyone holding its token, which is what lets a calendar app subscribe.
+func registerCalendarRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil || d.Node == nil {
+		return
+	}
+
+	// feedName is the calendar name shown in subscribers' apps.
+	feedName := func(groupID string) string {
+		if groupID != "" {
+			if g, err := d.DB.GetGroup(groupID); err == nil && g.Name != "" {
+				return g.Name
+			}
+			return groupID
+		}
+		label := "goop2"
+		if d.SelfLabel != nil && d.SelfLabel() != "" {
+			label = d.SelfLabel()
+		}
+		return label + " schedule"
+	}
+
+	// GET /api/schedule — scheduled sessions; ?group=<id> for one group.
+	// POST /api/schedule — create (id 0) or update a session.
+	handleGetPost(mux, "/api/schedule", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		sessions, err := d.DB.ListScheduledSessions(r.URL.Query().Get("group"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if sessions == nil {
+			sessions = []storage.ScheduledSession{}
+		}
+		writeJSON(w, sessions)
+	}, func(w http.ResponseWriter, r *http.Request, req storage.ScheduledSession) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.Kind == storage.ScheduleKindGroup {
+			if _, err := d.DB.GetGroup(req.GroupID); err != nil {
+				http.Error(w, "group events can only be scheduled for groups you host", http.StatusBadRequest)
+				return
+			}
+		}
+		s, err := d.DB.SaveScheduledSession(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, s)
+	})
+
+	// POST /api/schedule/delete — remove a session.
+	handlePost(mux, "/api/schedule/delete", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID int64 `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.DB.DeleteScheduledSession(req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]bool{"ok": true})
+	})
+
+	// GET /api/schedule/feeds — issued feed tokens.
+	// POST /api/schedule/feeds — issue a feed token for a group ("" = every
+	// session), replacing the previous one.
+	handleGetPost(mux, "/api/schedule/feeds", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		feeds, err := d.DB.ListCalendarFeeds()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out := make([]calendarFeedView, 0, len(feeds))
+		for _, f := range feeds {
+			out = append(out, calendarFeedView{CalendarFeed: f, Name: feedName(f.GroupID), Path: "/calendar/" + f.Token + ".ics"})
+		}
+		writeJSON(w, out)
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.GroupID != "" {
+			if _, err := d.DB.GetGroup(req.GroupID); err != nil {
+				http.Error(w, "unknown group", http.StatusBadRequest)
+				return
+			}
+		}
+		f, err := d.DB.CreateCalendarFeed(req.GroupID, newToken(24))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, calendarFeedView{CalendarFeed: f, Name: feedName(f.GroupID), Path: "/calendar/" + f.Token + ".ics"})
+	})
+
+	// POST /api/schedule/feeds/revoke — stop serving a feed.
+	handlePost(mux, "/api/schedule/feeds/revoke", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.DB.DeleteCalendarFeed(req.GroupID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]bool{"ok": true})
+	})
+
+	// GET /calendar/<token>.ics — the feed itself. The token is the only
+	// credential, so an unknown token is a plain 404.
+	handleGet(mux, "/calendar/", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/calendar/"), ".ics")
+		f, ok := d.DB.CalendarFeedByToken(token)
+		if token == "" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		sessions, err := d.DB.ListScheduledSessions(f.GroupID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		selfID := d.Node.ID()
+		events := make([]calendar.Event, 0, len(sessions))
+		for _, s := range sessions {
+			start := time.UnixMilli(s.StartsAt)
+			desc := s.Description
+			if s.Kind == storage.ScheduleKindGroup && f.GroupID == "" {
+				desc = strings.TrimSpace("Group: " + feedName(s.GroupID) + "\n\n" + desc)
+			}
+			events = append(events, calendar.Event{
+				UID:         fmt.Sprintf("%d@%s", s.ID, selfID),
+				Summary:     s.Title,
+				Description: desc,
+				Start:       start,
+				End:         start.Add(time.Duration(s.DurationMin) * time.Minute),
+				Updated:     time.UnixMilli(s.UpdatedAt),
+			})
+		}
+		w.Header().Set("Content-Type", calendar.ContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := calendar.Write(w, feedName(f.GroupID), events); err != nil {
+			log.Printf("calendar: write feed: %v", err)
+		}
+	})
+}
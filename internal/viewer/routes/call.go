@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/petervdpas/goop2/internal/call"
 	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/pairing"
 )
 
 var wsUpgrader = websocket.Upgrader{
@@ -34,7 +35,9 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 	handleGet(mux, "/api/call/mode", func(w http.ResponseWriter, r *http.Request) {
 		mode := "browser"
 		first := false
-		if callMgr != nil {
+		// A paired phone calls with its own camera and mic, never the host's.
+		_, remote := pairing.FromContext(r.Context())
+		if callMgr != nil && !remote {
 			mode = "native"
 			if !modeFirstSeen {
 				modeFirstSeen = true
@@ -44,7 +47,13 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 				log.Printf("[info] [call-native] mode=native — Go/Pion call stack active")
 			}
 		}
-		writeJSON(w, map[string]any{"mode": mode, "first": first, "platform": runtime.GOOS})
+		writeJSON(w, map[string]any{
+			"mode":         mode,
+			"first":        first,
+			"platform":     runtime.GOOS,
+			"stun_servers": call.STUNServers(),
+			"captions":     mode == "native" && call.CaptionsAvailable(),
+		})
 	})
 
 	if callMgr == nil {
@@ -129,6 +138,218 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 		writeJSON(w, map[string]string{"status": "hung_up"})
 	})
 
+	// POST /api/call/hold, /api/call/resume
+	for path, hold := range map[string]bool{"/api/call/hold": true, "/api/call/resume": false} {
+		hold := hold
+		handlePost(mux, path, func(w http.ResponseWriter, r *http.Request, req struct {
+			ChannelID string `json:"channel_id"`
+		}) {
+			sess, ok := callMgr.GetSession(req.ChannelID)
+			if !ok {
+				http.Error(w, "session not found", http.StatusNotFound)
+				return
+			}
+			var err error
+			if hold {
+				err = sess.Hold()
+			} else {
+				err = sess.Resume()
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeJSON(w, map[string]bool{"on_hold": hold})
+		})
+	}
+
+	// POST /api/call/chat — in-call chat message on the session's data channel
+	handlePost(mux, "/api/call/chat", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID string `json:"channel_id"`
+		Content   string `json:"content"`
+	}) {
+		if req.ChannelID == "" || req.Content == "" {
+			http.Error(w, "missing channel_id or content", http.StatusBadRequest)
+			return
+		}
+		sess, ok := callMgr.GetSession(req.ChannelID)
+		if !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		msg, err := sess.SendChat(req.Content)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, msg)
+	})
+
+	// POST /api/call/file — small file drop on the session's data channel.
+	// data is base64; the file is chunked on the wire.
+	handlePost(mux, "/api/call/file", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID string `json:"channel_id"`
+		Name      string `json:"name"`
+		Mime      string `json:"mime"`
+		Data      []byte `json:"data"`
+	}) {
+		if req.ChannelID == "" || req.Name == "" {
+			http.Error(w, "missing channel_id or name", http.StatusBadRequest)
+			return
+		}
+		sess, ok := callMgr.GetSession(req.ChannelID)
+		if !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		if len(req.Data) > call.MaxDataFileSize {
+			http.Error(w, fmt.Sprintf("file exceeds %d bytes", call.MaxDataFileSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		msg, err := sess.SendFile(req.Name, req.Mime, req.Data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		msg.Data = "" // the browser already has the bytes
+		writeJSON(w, msg)
+	})
+
+	// POST /api/call/captions — toggle live captions for one call.
+	handlePost(mux, "/api/call/captions", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID string `json:"channel_id"`
+		Enabled   bool   `json:"enabled"`
+		Language  string `json:"language"`
+	}) {
+		sess, ok := callMgr.GetSession(req.ChannelID)
+		if !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		if err := sess.SetCaptions(req.Enabled, req.Language); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, map[string]any{"enabled": req.Enabled, "language": sess.Status().Captions})
+	})
+
+	// GET /api/call/devices — local cameras and mics the native stack can open.
+	handleGet(mux, "/api/call/devices", func(w http.ResponseWriter, r *http.Request) {
+		devices := call.Devices()
+		if devices == nil {
+			devices = []call.MediaDevice{}
+		}
+		cam, mic := call.PreferredDevices()
+		writeJSON(w, map[string]any{
+			"devices":       devices,
+			"preferred_cam": cam,
+			"preferred_mic": mic,
+		})
+	})
+
+	// POST /api/call/device — switch camera or mic mid-call without
+	// renegotiating. Without channel_id every active call switches.
+	handlePost(mux, "/api/call/device", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID string `json:"channel_id"`
+		Kind      string `json:"kind"`
+		DeviceID  string `json:"device_id"`
+	}) {
+		if req.Kind != "video" && req.Kind != "audio" {
+			http.Error(w, "kind must be video or audio", http.StatusBadRequest)
+			return
+		}
+		if req.ChannelID != "" {
+			if _, ok := callMgr.GetSession(req.ChannelID); !ok {
+				http.Error(w, "session not found", http.StatusNotFound)
+				return
+			}
+		}
+		if err := callMgr.SwitchDevice(req.ChannelID, req.Kind, req.DeviceID); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "switched"})
+	})
+
+	// GET/POST /api/call/audio-processing — noise and echo suppression on the
+	// native mic. Applies to calls in progress; POST updates only the fields sent.
+	handleAny(mux, "/api/call/audio-processing", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var req struct {
+				NoiseSuppression *bool `json:"noise_suppression"`
+				EchoCancellation *bool `json:"echo_cancellation"`
+			}
+			if decodeJSON(w, r, &req) != nil {
+				return
+			}
+			ap := call.CurrentAudioProcessing()
+			if req.NoiseSuppression != nil {
+				ap.NoiseSuppression = *req.NoiseSuppression
+			}
+			if req.EchoCancellation != nil {
+				ap.EchoCancellation = *req.EchoCancellation
+			}
+			call.SetAudioProcessing(ap)
+			log.Printf("CALL: audio processing — noise_suppression=%v echo_cancellation=%v",
+				ap.NoiseSuppression, ap.EchoCancellation)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, call.CurrentAudioProcessing())
+	})
+
+	// POST /api/call/transfer — blind transfer: remote peer is asked to call target_peer.
+	handlePost(mux, "/api/call/transfer", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID  string `json:"channel_id"`
+		TargetPeer string `json:"target_peer"`
+	}) {
+		if req.ChannelID == "" || req.TargetPeer == "" {
+			http.Error(w, "missing channel_id or target_peer", http.StatusBadRequest)
+			return
+		}
+		sess, ok := callMgr.GetSession(req.ChannelID)
+		if !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		if err := sess.Transfer(req.TargetPeer); err != nil {
+			http.Error(w, fmt.Sprintf("transfer failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "transferred"})
+	})
+
+	// POST /api/call/callback — after call-busy, ask the callee to call us back.
+	handlePost(mux, "/api/call/callback", func(w http.ResponseWriter, r *http.Request, req struct {
+		ChannelID string `json:"channel_id"`
+	}) {
+		if req.ChannelID == "" {
+			http.Error(w, "missing channel_id", http.StatusBadRequest)
+			return
+		}
+		if err := callMgr.RequestCallback(req.ChannelID); err != nil {
+			http.Error(w, fmt.Sprintf("callback request failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "requested"})
+	})
+
+	// GET /api/call/callbacks — peers that found us busy and asked for a callback.
+	handleGet(mux, "/api/call/callbacks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, callMgr.Callbacks())
+	})
+
+	// POST /api/call/callbacks/dismiss — drop callback requests from peer_id ("" = all).
+	handlePost(mux, "/api/call/callbacks/dismiss", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		callMgr.DismissCallback(req.PeerID)
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
 	// POST /api/call/toggle-audio
 	handlePost(mux, "/api/call/toggle-audio", func(w http.ResponseWriter, r *http.Request, req struct {
 		ChannelID string `json:"channel_id"`
@@ -155,7 +376,7 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 
 	// GET /api/call/self/{channel} — WebSocket: self-view WebM stream (local camera, Linux only).
 	// Same protocol as /api/call/media/ but streams locally-captured VP8 frames.
-	mux.HandleFunc("/api/call/self/", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/call/self/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -213,7 +434,7 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 	// GET /api/call/media/{channel} — WebSocket: live WebM stream for Phase 4 browser display.
 	// The browser's MSE API receives binary WebM messages and feeds them to a <video> element.
 	// First message is the init segment; subsequent messages are clusters.
-	mux.HandleFunc("/api/call/media/", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/call/media/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -274,7 +495,7 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 	// WebKitGTK's <video src> uses GStreamer's native souphttpsrc pipeline, which
 	// reliably parses WebM video dimensions from the Tracks element.  Replaces the
 	// WebSocket+MSE path that suffered a ~50% loadedmetadata:0x0 race on page-nav.
-	mux.HandleFunc("/api/call/video/", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/call/video/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -325,7 +546,7 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 	})
 
 	// GET /api/call/selfvideo/{channel} — HTTP chunked self-view WebM stream.
-	mux.HandleFunc("/api/call/selfvideo/", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/call/selfvideo/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -378,7 +599,7 @@ func RegisterCall(mux *http.ServeMux, callMgr *call.Manager, mqMgr *mq.Manager)
 	// POST /api/call/loopback/{channel}/offer   → browser SDP offer; Go returns SDP answer
 	// POST /api/call/loopback/{channel}/ice      → browser ICE candidates → Go LocalPC
 	// GET  /api/call/loopback/{channel}/ice      → SSE: Go LocalPC ICE candidates → browser
-	mux.HandleFunc("/api/call/loopback/", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/call/loopback/", func(w http.ResponseWriter, r *http.Request) {
 		tail := strings.TrimPrefix(r.URL.Path, "/api/call/loopback/")
 		parts := strings.SplitN(tail, "/", 2)
 		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
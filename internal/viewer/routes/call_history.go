@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+)
+
+func registerCallHistoryRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil {
+		return
+	}
+
+	// GET /api/call/history?peer_id=&limit= — calls newest first, plus the
+	// number of missed calls not yet marked seen.
+	handleGet(mux, "/api/call/history", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		calls, err := d.DB.CallHistory(q.Get("peer_id"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		missed, err := d.DB.UnseenMissedCalls()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"calls": calls, "missed_unseen": missed})
+	})
+
+	// POST /api/call/history/seen — clear the missed-call badge
+	handlePostAction(mux, "/api/call/history/seen", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.DB.MarkMissedCallsSeen(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/call/history/clear — delete the whole call history
+	handlePostAction(mux, "/api/call/history/clear", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.DB.ClearCallHistory(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+}
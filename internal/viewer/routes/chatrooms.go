@@ -3,6 +3,7 @@ package routes
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/petervdpas/goop2/internal/group_types/chat"
 	"github.com/petervdpas/goop2/internal/state"
@@ -93,6 +94,40 @@ func RegisterChatRooms(mux *http.ServeMux, cm *chat.Manager, _ func(string) stat
 		writeJSON(w, map[string]string{"status": "sent"})
 	})
 
+	// GET /api/chat/group/history?group_id=...&limit=... — stored messages
+	// of a room, oldest first, also after it was left or closed.
+	handleGet(mux, "/api/chat/group/history", func(w http.ResponseWriter, r *http.Request) {
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(w, "group_id required", http.StatusBadRequest)
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		msgs, err := cm.History(groupID, limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("history failed: %v", err), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, msgs)
+	})
+
+	// POST /api/chat/group/backfill — ask the host of a joined room for its
+	// last messages; they arrive as a "history" event.
+	handlePost(mux, "/api/chat/group/backfill", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		Limit   int    `json:"limit"`
+	}) {
+		if req.GroupID == "" {
+			http.Error(w, "group_id required", http.StatusBadRequest)
+			return
+		}
+		if err := cm.RequestBackfill(req.GroupID, req.Limit); err != nil {
+			http.Error(w, fmt.Sprintf("backfill failed: %v", err), http.StatusConflict)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "requested"})
+	})
+
 	// GET /api/chat/rooms/state?group_id=...
 	handleGet(mux, "/api/chat/rooms/state", func(w http.ResponseWriter, r *http.Request) {
 		groupID := r.URL.Query().Get("group_id")
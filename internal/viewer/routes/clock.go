@@ -0,0 +1,25 @@
+package routes
+
+import "net/http"
+
+func registerClockRoutes(mux *http.ServeMux, d Deps) {
+	if d.Node == nil {
+		return
+	}
+
+	// GET /api/peers/clock — cached clock offsets to other peers.
+	// With ?peer=<id> the offset to that peer is measured now.
+	handleGet(mux, "/api/peers/clock", func(w http.ResponseWriter, r *http.Request) {
+		peerID := r.URL.Query().Get("peer")
+		if peerID == "" {
+			writeJSON(w, d.Node.ClockEstimates())
+			return
+		}
+		e, err := d.Node.MeasureClock(r.Context(), peerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, e)
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/petervdpas/goop2/internal/group_types/cluster"
 	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 func RegisterCluster(mux *http.ServeMux, cm *cluster.Manager, grpMgr *group.Manager, selfID string, saveBinary func(path, mode string)) {
@@ -63,7 +64,7 @@ func RegisterCluster(mux *http.ServeMux, cm *cluster.Manager, grpMgr *group.Mana
 			http.Error(w, "missing host_peer_id or group_id", http.StatusBadRequest)
 			return
 		}
-		ctx, cancel := context.WithTimeout(r.Context(), ClusterJoinTimeout)
+		ctx, cancel := context.WithTimeout(r.Context(), timeouts.Get().GroupJoin)
 		defer cancel()
 		if err := grpMgr.JoinRemoteGroup(ctx, req.HostPeerID, req.GroupID); err != nil {
 			http.Error(w, fmt.Sprintf("join group: %v", err), http.StatusBadGateway)
@@ -0,0 +1,353 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swaggo/swag"
+)
+
+// The OpenAPI contract check: every handler under /api/ must be documented
+// in the spec generated from openapi_annotations.go, with the method it
+// accepts and, for handlePost routes, the request body it decodes. The
+// stubs are written by hand and drift from the real closures otherwise.
+
+// apiRoute is a handler as it was registered. method is empty for handlers
+// that dispatch on the method themselves; body is the request type decoded
+// by handlePost.
+type apiRoute struct {
+	method string
+	path   string
+	body   reflect.Type
+}
+
+var (
+	routeLogMu sync.Mutex
+	routeLog   = map[*http.ServeMux][]apiRoute{}
+)
+
+// recordRoute notes a registration on mux for the contract check.
+func recordRoute(mux *http.ServeMux, method, path string, body reflect.Type) {
+	routeLogMu.Lock()
+	routeLog[mux] = append(routeLog[mux], apiRoute{method: method, path: path, body: body})
+	routeLogMu.Unlock()
+}
+
+func registeredRoutes(mux *http.ServeMux) []apiRoute {
+	routeLogMu.Lock()
+	defer routeLogMu.Unlock()
+	return append([]apiRoute(nil), routeLog[mux]...)
+}
+
+// undocumentedOK lists /api/ handlers that are deliberately not in the spec.
+var undocumentedOK = map[string]bool{
+	"/api/p/": true, // relays the data API of a remote peer; documented as /api/data/*
+}
+
+// Contract problem kinds.
+const (
+	contractMissing      = "missing"      // documented, but no handler
+	contractUndocumented = "undocumented" // handler without a spec entry
+	contractMethod       = "method"       // handler method not documented
+	contractBody         = "body"         // request body differs from the documented one
+)
+
+type contractProblem struct {
+	kind   string
+	method string
+	path   string
+	detail string
+}
+
+func (p contractProblem) String() string {
+	s := p.kind + ": " + strings.TrimSpace(p.method+" "+p.path)
+	if p.detail != "" {
+		s += ": " + p.detail
+	}
+	return s
+}
+
+// contractSpec is the part of the swagger document the check needs.
+type contractSpec struct {
+	Paths map[string]map[string]struct {
+		ServedBy   string `json:"x-served-by"` // routes of the rendezvous server
+		Parameters []struct {
+			In     string          `json:"in"`
+			Schema *contractSchema `json:"schema"`
+		} `json:"parameters"`
+	} `json:"paths"`
+	Definitions map[string]*contractSchema `json:"definitions"`
+}
+
+type contractSchema struct {
+	Ref        string                     `json:"$ref"`
+	Type       string                     `json:"type"`
+	Properties map[string]*contractSchema `json:"properties"`
+
+	// Constraints checked by validateBody.
+	Required  []string `json:"required"`
+	Enum      []any    `json:"enum"`
+	MinLength *int     `json:"minLength"`
+	MaxLength *int     `json:"maxLength"`
+	Minimum   *float64 `json:"minimum"`
+	Maximum   *float64 `json:"maximum"`
+}
+
+// VerifyContract compares the handlers registered on mux with the OpenAPI
+// spec and logs every route that is undocumented or documented wrongly.
+// Documented routes without a handler are not reported here, since they
+// belong to features that can be switched off. Runs at startup in debug mode.
+func VerifyContract(mux *http.ServeMux) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		log.Printf("OPENAPI: %v", err)
+		return
+	}
+	problems, err := checkContract([]byte(doc), registeredRoutes(mux), muxServes(mux))
+	if err != nil {
+		log.Printf("OPENAPI: %v", err)
+		return
+	}
+	n := 0
+	for _, p := range problems {
+		if p.kind != contractMissing {
+			log.Printf("OPENAPI: %s", p)
+			n++
+		}
+	}
+	if n > 0 {
+		log.Printf("OPENAPI: %d routes differ from the annotations; update openapi_annotations.go and run swag init", n)
+	}
+}
+
+// checkContract lists the differences between the registered routes and
+// the spec, sorted by path. served reports whether a documented path has a
+// handler the route log does not know about, such as the chat history
+// endpoints directchat registers itself.
+func checkContract(rawSpec []byte, routes []apiRoute, served func(path string) bool) ([]contractProblem, error) {
+	var spec contractSpec
+	if err := json.Unmarshal(rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+
+	var problems []contractProblem
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if op.ServedBy != "" {
+				continue
+			}
+			m := strings.ToUpper(method)
+			found := served != nil && served(path)
+			for _, rt := range routes {
+				if specPathMatches(path, rt.path) && (rt.method == "" || rt.method == m) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				problems = append(problems, contractProblem{kind: contractMissing, method: m, path: path})
+			}
+		}
+	}
+
+	for _, rt := range routes {
+		if !strings.HasPrefix(rt.path, "/api/") || undocumentedOK[rt.path] {
+			continue
+		}
+		var paths []string
+		for path := range spec.Paths {
+			if specPathMatches(path, rt.path) {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			problems = append(problems, contractProblem{kind: contractUndocumented, method: rt.method, path: rt.path})
+			continue
+		}
+		if rt.method == "" {
+			continue
+		}
+		sort.Strings(paths)
+		documented := false
+		for _, path := range paths {
+			op, ok := spec.Paths[path][strings.ToLower(rt.method)]
+			if !ok {
+				continue
+			}
+			documented = true
+			if rt.body == nil {
+				continue
+			}
+			var body *contractSchema
+			for _, p := range op.Parameters {
+				if p.In == "body" {
+					body = p.Schema
+				}
+			}
+			for _, d := range spec.compareBody(rt.body, body) {
+				problems = append(problems, contractProblem{kind: contractBody, method: rt.method, path: path, detail: d})
+			}
+		}
+		if !documented {
+			problems = append(problems, contractProblem{kind: contractMethod, method: rt.method, path: rt.path,
+				detail: "documented only as " + strings.Join(specMethods(spec, paths), ", ")})
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.detail < b.detail
+	})
+	return problems, nil
+}
+
+// muxServes reports whether mux routes a documented path to an /api/
+// handler rather than the "/" catch-all.
+func muxServes(mux *http.ServeMux) func(string) bool {
+	return func(path string) bool {
+		r, err := http.NewRequest(http.MethodGet, strings.NewReplacer("{", "", "}", "").Replace(path), nil)
+		if err != nil {
+			return false
+		}
+		_, pattern := mux.Handler(r)
+		return strings.HasPrefix(pattern, "/api/")
+	}
+}
+
+// specPathMatches reports whether a handler registered at pattern serves
+// the spec path: the same path, or a subtree pattern ("/api/call/self/")
+// covering a templated one ("/api/call/self/{channel}").
+func specPathMatches(specPath, pattern string) bool {
+	if specPath == pattern {
+		return true
+	}
+	return strings.HasSuffix(pattern, "/") && strings.HasPrefix(specPath, pattern) && strings.Contains(specPath, "{")
+}
+
+func specMethods(spec contractSpec, paths []string) []string {
+	var out []string
+	for _, path := range paths {
+		for m := range spec.Paths[path] {
+			out = append(out, strings.ToUpper(m))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s contractSpec) resolve(sc *contractSchema) *contractSchema {
+	for sc != nil && sc.Ref != "" {
+		sc = s.Definitions[strings.TrimPrefix(sc.Ref, "#/definitions/")]
+	}
+	return sc
+}
+
+// compareBody checks the JSON fields a handler decodes against the
+// documented body: the same field names, of compatible types.
+func (s contractSpec) compareBody(t reflect.Type, doc *contractSchema) []string {
+	fields := jsonFields(t)
+	if len(fields) == 0 {
+		return nil
+	}
+	doc = s.resolve(doc)
+	if doc == nil {
+		return []string{"request body not documented"}
+	}
+	if len(doc.Properties) == 0 {
+		return nil // swag drops the fields of inline object{...} bodies
+	}
+	var out []string
+	for name, ft := range fields {
+		prop, ok := doc.Properties[name]
+		if !ok {
+			out = append(out, fmt.Sprintf("field %q not documented", name))
+			continue
+		}
+		prop = s.resolve(prop)
+		if want := swaggerType(ft); want != "" && prop != nil && prop.Type != "" && prop.Type != want {
+			out = append(out, fmt.Sprintf("field %q is %s, documented as %s", name, want, prop.Type))
+		}
+	}
+	for name := range doc.Properties {
+		if _, ok := fields[name]; !ok {
+			out = append(out, fmt.Sprintf("documented field %q is not read", name))
+		}
+	}
+	return out
+}
+
+// jsonFields lists the fields encoding/json decodes into a struct type.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	out := map[string]reflect.Type{}
+	if t.Kind() != reflect.Struct {
+		return out
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for n, ft := range jsonFields(f.Type) {
+				out[n] = ft
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+// swaggerType is the swagger type swag documents for a Go type, or "" when
+// any documented type is acceptable.
+func swaggerType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "" // []byte and json.RawMessage
+		}
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/swaggo/swag"
+
+	"github.com/petervdpas/goop2/internal/avatar"
+	"github.com/petervdpas/goop2/internal/call"
+	"github.com/petervdpas/goop2/internal/datasync"
+	"github.com/petervdpas/goop2/internal/directchat"
+	"github.com/petervdpas/goop2/internal/group"
+	"github.com/petervdpas/goop2/internal/group_types/chat"
+	"github.com/petervdpas/goop2/internal/group_types/cluster"
+	"github.com/petervdpas/goop2/internal/group_types/datafed"
+	"github.com/petervdpas/goop2/internal/group_types/files"
+	"github.com/petervdpas/goop2/internal/group_types/listen"
+	"github.com/petervdpas/goop2/internal/jobs"
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/orm/gql"
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/pairing"
+	"github.com/petervdpas/goop2/internal/retention"
+	"github.com/petervdpas/goop2/internal/rules"
+	"github.com/petervdpas/goop2/internal/secrets"
+	"github.com/petervdpas/goop2/internal/siteassets"
+	"github.com/petervdpas/goop2/internal/sitesync"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+type nopLogs struct{}
+
+func (nopLogs) ServeLogsJSON(http.ResponseWriter, *http.Request) {}
+func (nopLogs) ServeLogsSSE(http.ResponseWriter, *http.Request)  {}
+
+// fullMux registers every route the viewer can serve. Registration only
+// captures the managers, so zero values are enough.
+func fullMux(peerDir string) *http.ServeMux {
+	mux := http.NewServeMux()
+	db, node, grp, mqm := &storage.DB{}, &p2p.Node{}, &group.Manager{}, &mq.Manager{}
+	Register(mux, Deps{
+		Node:         node,
+		PeerDir:      peerDir,
+		Peers:        &state.PeerTable{},
+		Logs:         nopLogs{},
+		DB:           db,
+		GroupManager: grp,
+		DocsStore:    &files.Store{},
+		AvatarStore:  &avatar.Store{},
+		AvatarCache:  &avatar.Cache{},
+		Pairing:      &pairing.Manager{},
+		Rules:        &rules.Engine{},
+		Secrets:      &secrets.Store{},
+		Retention:    &retention.Pruner{},
+		Assets:       &siteassets.Pipeline{},
+		SiteSync:     &sitesync.Syncer{},
+		DataSync:     &datasync.Syncer{},
+		Health:       func() any { return nil },
+		Startup:      func() any { return nil },
+		Jobs:         &jobs.Queue{},
+	})
+	RegisterMQ(mux, mqm, nil)
+	RegisterChat(mux, &directchat.Manager{})
+	RegisterData(mux, db, "self", nil, nil)
+	RegisterGraphQL(mux, &gql.Engine{})
+	RegisterTransformations(mux, "peer", db)
+	RegisterSchema(mux, "peer", db, nil)
+	RegisterGroups(mux, grp, "self", nil, mqm)
+	RegisterFS(mux)
+	RegisterCluster(mux, &cluster.Manager{}, grp, "self", nil)
+	RegisterCall(mux, &call.Manager{}, mqm)
+	RegisterListen(mux, &listen.Manager{}, nil)
+	RegisterChatRooms(mux, &chat.Manager{}, nil)
+	RegisterDataProxy(mux, node, nil)
+	RegisterDataFed(mux, &datafed.Manager{})
+	return mux
+}
+
+// Every /api/ handler is documented with its method and request body, and
+// every documented route has a handler.
+func TestOpenAPIContract(t *testing.T) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := fullMux(t.TempDir())
+	problems, err := checkContract([]byte(doc), registeredRoutes(mux), muxServes(mux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Error(p)
+	}
+	if len(problems) > 0 {
+		t.Log("update openapi_annotations.go and run swag init")
+	}
+}
+
+func TestCheckContract(t *testing.T) {
+	spec := `{
+		"paths": {
+			"/api/a": {"post": {"parameters": [{"in": "body", "schema": {"$ref": "#/definitions/aReq"}}]}},
+			"/api/b/{id}": {"get": {}},
+			"/api/c": {"get": {}},
+			"/api/gone": {"get": {}},
+			"/api/elsewhere": {"get": {}},
+			"/api/rendezvous": {"get": {"x-served-by": "rendezvous"}}
+		},
+		"definitions": {
+			"aReq": {"type": "object", "properties": {
+				"name": {"type": "string"},
+				"count": {"type": "string"},
+				"stale": {"type": "string"}
+			}}
+		}
+	}`
+	type aReq struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+		Extra bool   `json:"extra"`
+	}
+	routes := []apiRoute{
+		{method: "POST", path: "/api/a", body: reflect.TypeFor[aReq]()},
+		{method: "GET", path: "/api/b/"},
+		{method: "POST", path: "/api/c"},
+		{path: "/api/new"},
+		{path: "/api/p/"},
+		{method: "GET", path: "/settings"},
+	}
+	problems, err := checkContract([]byte(spec), routes, func(path string) bool { return path == "/api/elsewhere" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`body: POST /api/a: documented field "stale" is not read`,
+		`body: POST /api/a: field "count" is integer, documented as string`,
+		`body: POST /api/a: field "extra" not documented`,
+		`missing: GET /api/c`,
+		`method: POST /api/c: documented only as GET`,
+		`missing: GET /api/gone`,
+		`undocumented: /api/new`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -4,15 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/petervdpas/goop2/internal/datasync"
 	"github.com/petervdpas/goop2/internal/p2p"
 	"github.com/petervdpas/goop2/internal/storage"
 )
 
 // RegisterDataProxy mounts the /api/p/ prefix that relays data API calls
-// to a remote peer via the P2P data protocol.
-func RegisterDataProxy(mux *http.ServeMux, node *p2p.Node) {
-	mux.HandleFunc("/api/p/", func(w http.ResponseWriter, r *http.Request) {
+// to a remote peer via the P2P data protocol. While a template group host
+// is unreachable, its replicated tables are answered from dataSync's
+// replica (nil for none), marked with an X-Goop-Data-Copy header.
+func RegisterDataProxy(mux *http.ServeMux, node *p2p.Node, dataSync *datasync.Syncer) {
+	handleAny(mux, "/api/p/", func(w http.ResponseWriter, r *http.Request) {
 		// Parse: /api/p/<peerID>/data/<operation...>
 		path := strings.TrimPrefix(r.URL.Path, "/api/p/")
 		slash := strings.IndexByte(path, '/')
@@ -49,8 +53,17 @@ func RegisterDataProxy(mux *http.ServeMux, node *p2p.Node) {
 		} else {
 			resp, err = node.RemoteDataOp(r.Context(), peerID, req)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadGateway)
-				return
+				copied, syncedAt, ok := p2p.DataResponse{}, time.Time{}, false
+				if dataSync != nil {
+					copied, syncedAt, ok = dataSync.Offline(peerID, req)
+				}
+				if !ok {
+					http.Error(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+				resp = copied
+				w.Header().Set("Cache-Control", "no-store")
+				w.Header().Set("X-Goop-Data-Copy", syncedAt.UTC().Format(time.RFC3339))
 			}
 		}
 
@@ -61,6 +74,32 @@ func RegisterDataProxy(mux *http.ServeMux, node *p2p.Node) {
 
 		writeJSON(w, resp.Data)
 	})
+
+	// Lua data functions of our own site; goop-data.js calls these without
+	// the /api/p/<peerID> prefix when the page is served locally.
+	handlePost(mux, "/api/data/lua/call", func(w http.ResponseWriter, r *http.Request, req struct {
+		Function string         `json:"function"`
+		Params   map[string]any `json:"params"`
+	}) {
+		if req.Function == "" {
+			http.Error(w, "function name required", http.StatusBadRequest)
+			return
+		}
+		writeLocalDataOp(w, node, p2p.DataRequest{Op: "lua-call", Function: req.Function, Params: req.Params})
+	})
+
+	handleGet(mux, "/api/data/lua/list", func(w http.ResponseWriter, r *http.Request) {
+		writeLocalDataOp(w, node, p2p.DataRequest{Op: "lua-list"})
+	})
+}
+
+func writeLocalDataOp(w http.ResponseWriter, node *p2p.Node, req p2p.DataRequest) {
+	resp := node.LocalDataOp(node.ID(), req)
+	if !resp.OK {
+		http.Error(w, resp.Error, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp.Data)
 }
 
 func mapSuffixToOp(suffix string) string {
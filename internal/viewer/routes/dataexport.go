@@ -0,0 +1,213 @@
+package routes
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// DataExportManifest is written as manifest.json inside the full data export.
+// Files lists every other file in the archive with what it holds.
+type DataExportManifest struct {
+	Version    int              `json:"version"`
+	PeerID     string           `json:"peer_id"`
+	Label      string           `json:"label"`
+	ExportedAt string           `json:"exported_at"`
+	Files      []DataExportFile `json:"files"`
+}
+
+// DataExportFile describes one file in the full data export.
+type DataExportFile struct {
+	Path        string `json:"path"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	Table       string `json:"table,omitempty"`
+	Rows        int    `json:"rows,omitempty"`
+}
+
+// dataCategory is the export category for the site's own tables. It is not
+// in storage.ExportCategories because it can only be wiped per peer.
+const dataCategory = "data"
+
+func registerDataExportRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil {
+		return
+	}
+
+	// GET /api/export/summary — row counts per category, for auditing before
+	// exporting or wiping
+	handleGet(mux, "/api/export/summary", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		type tableCount struct {
+			Table string `json:"table"`
+			Rows  int64  `json:"rows"`
+		}
+		type category struct {
+			storage.ExportCategory
+			Tables []tableCount `json:"tables"`
+		}
+		var out []category
+		for _, c := range storage.ExportCategories {
+			cat := category{ExportCategory: c}
+			for _, t := range c.Tables {
+				n, _ := d.DB.CountRows(t)
+				cat.Tables = append(cat.Tables, tableCount{t, n})
+			}
+			out = append(out, cat)
+		}
+		data := category{ExportCategory: storage.ExportCategory{Name: dataCategory, Description: "Rows in this site's data tables"}}
+		if tables, err := d.DB.ListTables(); err == nil {
+			for _, t := range tables {
+				n, _ := d.DB.CountRows(t.Name)
+				data.Tables = append(data.Tables, tableCount{t.Name, n})
+			}
+		}
+		writeJSON(w, append(out, data))
+	})
+
+	// GET /api/export/all — download everything this peer stores as a zip of
+	// self-describing JSON files
+	handleGet(mux, "/api/export/all", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+
+		label := "peer"
+		if d.SelfLabel != nil {
+			if l := d.SelfLabel(); l != "" {
+				label = l
+			}
+		}
+		now := time.Now().UTC()
+		manifest := DataExportManifest{
+			Version:    1,
+			Label:      label,
+			ExportedAt: now.Format(time.RFC3339),
+		}
+		if d.Node != nil {
+			manifest.PeerID = d.Node.ID()
+		}
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		writeTable := func(path, category, description, table string) error {
+			ex, err := d.DB.ExportTable(table)
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			b, err := json.MarshalIndent(ex, "", "  ")
+			if err != nil {
+				return err
+			}
+			fw, err := zw.Create(path)
+			if err != nil {
+				return err
+			}
+			fw.Write(b)
+			manifest.Files = append(manifest.Files, DataExportFile{
+				Path: path, Category: category, Description: description, Table: table, Rows: len(ex.Rows),
+			})
+			return nil
+		}
+
+		for _, c := range storage.ExportCategories {
+			for _, t := range c.Tables {
+				if err := writeTable(c.Name+"/"+t+".json", c.Name, c.Description, t); err != nil {
+					http.Error(w, "export failed: "+err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		tables, _ := d.DB.ListTables()
+		for _, t := range tables {
+			if err := writeTable(dataCategory+"/"+t.Name+".json", dataCategory, "Site data table", t.Name); err != nil {
+				http.Error(w, "export failed: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// settings/ — the config file as it is on disk
+		if d.CfgPath != "" {
+			if b, err := os.ReadFile(d.CfgPath); err == nil {
+				path := "settings/" + filepath.Base(d.CfgPath)
+				if fw, err := zw.Create(path); err == nil {
+					fw.Write(b)
+					manifest.Files = append(manifest.Files, DataExportFile{
+						Path: path, Category: "settings", Description: "Peer configuration",
+					})
+				}
+			}
+		}
+
+		manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			http.Error(w, "failed to build manifest: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if fw, err := zw.Create("manifest.json"); err == nil {
+			fw.Write(manifestJSON)
+		}
+		if err := zw.Close(); err != nil {
+			http.Error(w, "failed to create zip: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		filename := fmt.Sprintf("goop-data-%s-%s.zip", label, now.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.Write(buf.Bytes())
+	})
+
+	// POST /api/export/wipe — delete stored data by category, optionally only
+	// what concerns one peer
+	handlePost(mux, "/api/export/wipe", func(w http.ResponseWriter, r *http.Request, req struct {
+		Categories []string `json:"categories"`
+		PeerID     string   `json:"peer_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if len(req.Categories) == 0 {
+			http.Error(w, "categories required", http.StatusBadRequest)
+			return
+		}
+		for _, c := range req.Categories {
+			if _, ok := storage.FindExportCategory(c); !ok && c != dataCategory {
+				http.Error(w, "unknown category: "+c, http.StatusBadRequest)
+				return
+			}
+			if c == dataCategory && req.PeerID == "" {
+				http.Error(w, "site data can only be wiped for one peer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		deleted := map[string]int64{}
+		for _, c := range req.Categories {
+			var n map[string]int64
+			var err error
+			if c == dataCategory {
+				n, err = d.DB.WipeOwnerRows(req.PeerID)
+			} else {
+				n, err = d.DB.WipeCategory(c, req.PeerID)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for t, k := range n {
+				deleted[t] += k
+			}
+		}
+		writeJSON(w, map[string]any{"deleted": deleted})
+	})
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDataExportAll(t *testing.T) {
+	d, _ := testDeps(t)
+	d.DB.StoreChatMessage("alice", "alice", "hi", 1000)
+	mux := http.NewServeMux()
+	registerDataExportRoutes(mux, d)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/export/all", nil)
+	r.RemoteAddr = "127.0.0.1:1234"
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	var m DataExportManifest
+	if err := json.Unmarshal(files["manifest.json"], &m); err != nil {
+		t.Fatal(err)
+	}
+	var chat *DataExportFile
+	for i := range m.Files {
+		if _, ok := files[m.Files[i].Path]; !ok {
+			t.Errorf("manifest lists %s but the archive lacks it", m.Files[i].Path)
+		}
+		if m.Files[i].Table == "_chat_messages" {
+			chat = &m.Files[i]
+		}
+	}
+	if chat == nil || chat.Rows != 1 || chat.Category != "chat" {
+		t.Fatalf("chat entry = %+v", chat)
+	}
+	if !strings.Contains(string(files[chat.Path]), `"content": "hi"`) {
+		t.Fatalf("chat file = %s", files[chat.Path])
+	}
+}
+
+func TestDataExportWipe(t *testing.T) {
+	d, _ := testDeps(t)
+	d.DB.StoreChatMessage("alice", "alice", "hi", 1000)
+	d.DB.StoreChatMessage("bob", "bob", "yo", 1000)
+	mux := http.NewServeMux()
+	registerDataExportRoutes(mux, d)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/api/export/wipe", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = "127.0.0.1:1234"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post(`{"categories":["data"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("wiping all site data: status = %d", w.Code)
+	}
+	if w := post(`{"categories":["nope"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown category: status = %d", w.Code)
+	}
+
+	w := post(`{"categories":["chat"],"peer_id":"alice"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Deleted map[string]int64 `json:"deleted"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Deleted["_chat_messages"] != 1 {
+		t.Fatalf("deleted = %v", resp.Deleted)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"net/http"
+)
+
+func registerDataSyncRoutes(mux *http.ServeMux, d Deps) {
+	if d.DataSync == nil {
+		return
+	}
+
+	// GET /api/data/replicas — replicas of template group hosts' tables
+	handleGet(mux, "/api/data/replicas", func(w http.ResponseWriter, r *http.Request) {
+		replicas, err := d.DataSync.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, replicas)
+	})
+}
@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+)
+
+// registerDigestRoutes lets the settings page read and change this peer's
+// email digest subscription, which lives on the rendezvous servers.
+func registerDigestRoutes(mux *http.ServeMux, d Deps) {
+	if d.Node == nil {
+		return
+	}
+
+	// digestToken returns the verification token, or a reason there is none.
+	digestToken := func() (string, string) {
+		cfg, err := config.Load(d.CfgPath)
+		if err != nil {
+			return "", "failed to load config"
+		}
+		if len(d.RVClients) == 0 {
+			return "", "no rendezvous configured"
+		}
+		token := strings.TrimSpace(cfg.Profile.VerificationToken)
+		if strings.TrimSpace(cfg.Profile.Email) == "" || token == "" {
+			return "", "email not verified — verify your email first"
+		}
+		return token, ""
+	}
+
+	// GET /api/digest — current subscription from the first rendezvous that has digests.
+	// POST /api/digest — set frequency; favorites includes our favorite peers' stations.
+	handleGetPost(mux, "/api/digest", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		token, reason := digestToken()
+		if token == "" {
+			writeJSON(w, map[string]any{"available": false, "error": reason})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), DigestTimeout)
+		defer cancel()
+		var lastErr error
+		for _, c := range d.RVClients {
+			st, err := c.DigestStatus(ctx, d.Node.ID(), token)
+			if err == nil {
+				writeJSON(w, map[string]any{"available": true, "status": st})
+				return
+			}
+			lastErr = err
+		}
+		writeJSON(w, map[string]any{"available": false, "error": lastErr.Error()})
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		Frequency string `json:"frequency"`
+		Favorites bool   `json:"favorites"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		token, reason := digestToken()
+		if token == "" {
+			http.Error(w, reason, http.StatusConflict)
+			return
+		}
+		prefs := rendezvous.DigestPrefs{PeerID: d.Node.ID(), Token: token, Frequency: req.Frequency}
+		if req.Favorites && d.DB != nil {
+			peers, _ := d.DB.ListCachedPeers()
+			for _, p := range peers {
+				if p.Favorite {
+					prefs.Favorites = append(prefs.Favorites, p.PeerID)
+				}
+			}
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), DigestTimeout)
+		defer cancel()
+		var lastErr error
+		for _, c := range d.RVClients {
+			st, err := c.SetDigestPrefs(ctx, prefs)
+			if err == nil {
+				writeJSON(w, st)
+				return
+			}
+			lastErr = err
+		}
+		http.Error(w, lastErr.Error(), http.StatusBadGateway)
+	})
+}
@@ -4,17 +4,17 @@ package routes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"sync"
-
-	"encoding/json"
 
 	files "github.com/petervdpas/goop2/internal/group_types/files"
+	"github.com/petervdpas/goop2/internal/timeouts"
+
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 func registerDocsRoutes(mux *http.ServeMux, d Deps) {
@@ -106,7 +106,7 @@ func registerDocsRoutes(mux *http.ServeMux, d Deps) {
 	})
 
 	// Upload a file to share with the group
-	mux.HandleFunc("/api/docs/upload", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/docs/upload", func(w http.ResponseWriter, r *http.Request) {
 		if !requireMethod(w, r, http.MethodPost) {
 			return
 		}
@@ -258,126 +258,46 @@ func registerDocsRoutes(mux *http.ServeMux, d Deps) {
 		writeJSON(w, map[string]string{"status": "deleted"})
 	})
 
-	// Browse: aggregate file lists from all group members
-	handleGet(mux, "/api/docs/browse", func(w http.ResponseWriter, r *http.Request) {
-		groupID := r.URL.Query().Get("group_id")
-		if groupID == "" {
-			http.Error(w, "Missing group_id", http.StatusBadRequest)
+	// Set who may fetch a shared file: the group, listed peers, or anyone
+	handlePost(mux, "/api/docs/acl", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID  string   `json:"group_id"`
+		Filename string   `json:"filename"`
+		Access   string   `json:"access"`
+		Peers    []string `json:"peers"`
+	}) {
+		if !requireLocal(w, r) {
 			return
 		}
-
-		selfID := ""
-		if d.Node != nil {
-			selfID = d.Node.ID()
-		}
-
-		type peerFiles struct {
-			PeerID string         `json:"peer_id"`
-			Label  string         `json:"label"`
-			Files  []files.DocInfo `json:"files"`
-			Self   bool           `json:"self"`
-			Error  string         `json:"error,omitempty"`
-		}
-
-		var results []peerFiles
-
-		// My own files
-		myFiles, err := d.DocsStore.List(groupID)
-		if err != nil {
-			myFiles = []files.DocInfo{}
-		}
-		myLabel := ""
-		if d.SelfLabel != nil {
-			myLabel = d.SelfLabel()
-		}
-		if myLabel == "" {
-			myLabel = "Me"
+		if req.GroupID == "" || req.Filename == "" {
+			http.Error(w, "Missing group_id or filename", http.StatusBadRequest)
+			return
 		}
-		results = append(results, peerFiles{
-			PeerID: selfID,
-			Label:  myLabel,
-			Files:  myFiles,
-			Self:   true,
-		})
-
-		// Query group members for their files
-		if d.Node != nil && d.GroupManager != nil {
-			// Collect all known member peer IDs from every source, deduplicating.
-			// StoredGroupMembers is the key fallback: it works even when the host is offline.
-			seen := map[string]bool{selfID: true}
-			var peerIDs []string
-			addPeer := func(pid string) {
-				if !seen[pid] {
-					seen[pid] = true
-					peerIDs = append(peerIDs, pid)
-				}
-			}
-			for _, m := range d.GroupManager.HostedGroupMembers(groupID) {
-				addPeer(m.PeerID)
-			}
-			for _, m := range d.GroupManager.ClientGroupMembers(groupID) {
-				addPeer(m.PeerID)
-			}
-			for _, gm := range d.GroupManager.StoredGroupMembers(groupID) {
-				addPeer(gm.PeerID)
+		for _, p := range req.Peers {
+			if _, err := peer.Decode(p); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid peer ID %q", p), http.StatusBadRequest)
+				return
 			}
+		}
 
-			// Query each peer in parallel
-			if len(peerIDs) > 0 {
-				var mu sync.Mutex
-				var wg sync.WaitGroup
-				for _, pid := range peerIDs {
-					wg.Add(1)
-					go func(peerID string) {
-						defer wg.Done()
-						ctx, cancel := context.WithTimeout(context.Background(), DocListFetchTimeout)
-						defer cancel()
-
-						rawFiles, err := d.Node.FetchDocList(ctx, peerID, groupID)
-
-						// Resolve peer label: live table first, persistent cache as fallback
-						label := peerID
-						if d.ResolvePeer != nil {
-							if n := d.ResolvePeer(peerID).Name(); n != "" {
-								label = n
-							}
-						}
-
-						pf := peerFiles{
-							PeerID: peerID,
-							Label:  label,
-							Self:   false,
-						}
-						if err != nil {
-							log.Printf("DOCS: Failed to fetch list from %s: %v", peerID, err)
-							pf.Error = "unreachable"
-							pf.Files = []files.DocInfo{}
-						} else {
-							var parsed []files.DocInfo
-							if rawFiles != nil {
-								json.Unmarshal(rawFiles, &parsed) //nolint:errcheck
-							}
-							if parsed == nil {
-								parsed = []files.DocInfo{}
-							}
-							pf.Files = parsed
-						}
-
-						mu.Lock()
-						results = append(results, pf)
-						mu.Unlock()
-					}(pid)
-				}
-				wg.Wait()
+		acl := files.ACL{Access: req.Access, Peers: req.Peers}
+		if err := d.DocsStore.SetACL(req.GroupID, req.Filename, acl); err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, files.ErrNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, files.ErrBadACL), errors.Is(err, files.ErrBadName):
+				status = http.StatusBadRequest
 			}
+			http.Error(w, fmt.Sprintf("Failed to set access: %v", err), status)
+			return
 		}
 
-		writeJSON(w, map[string]any{
-			"group_id": groupID,
-			"peers":    results,
-		})
+		writeJSON(w, d.DocsStore.ACL(req.GroupID, req.Filename))
 	})
 
+	// Browse: aggregate file lists from all group members (docs_browse.go).
+	registerDocsBrowse(mux, d)
+
 	// Download a file (from own store or proxy from remote peer).
 	// Pass ?inline=1 to serve with Content-Disposition: inline (for browser preview).
 	handleGet(mux, "/api/docs/download", func(w http.ResponseWriter, r *http.Request) {
@@ -421,7 +341,13 @@ func registerDocsRoutes(mux *http.ServeMux, d Deps) {
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), DocFileFetchTimeout)
+		// Peers that speak /goop/docs/2.0.0 send the file in chunks, which
+		// resume after a dropped connection; older ones send it whole.
+		if d.PeerDir != "" && serveDocChunked(w, r, d, peerID, groupID, filename, disposition) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().DocsFetch)
 		defer cancel()
 
 		mimeType, data, err := d.Node.FetchDocFile(ctx, peerID, groupID, filename)
@@ -0,0 +1,326 @@
+package routes
+
+// docs_browse.go — collecting the file lists of a files group's members.
+// Members are asked in parallel, but one relayed member can take seconds
+// to answer and used to hold up the whole listing. The viewer now keeps
+// how long each member takes, asks the fast ones first, and answers after
+// DocsBrowseDeadline with what came in: members still outstanding are
+// listed as "slow" and their fetch runs on into a short-lived cache, which
+// POST /api/docs/browse/refresh reads. With ?stream=1 each member is sent
+// as a server-sent event the moment it answers instead.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	files "github.com/petervdpas/goop2/internal/group_types/files"
+	"github.com/petervdpas/goop2/internal/timeouts"
+)
+
+// browsePeer is one member's entry in a browse result.
+type browsePeer struct {
+	PeerID  string          `json:"peer_id"`
+	Label   string          `json:"label"`
+	Files   []files.DocInfo `json:"files"`
+	Self    bool            `json:"self"`
+	Error   string          `json:"error,omitempty"` // "unreachable", or "slow" while still being fetched
+	Latency int64           `json:"latency_ms,omitempty"`
+}
+
+// browseCall is one member's file list fetch; pf is set once done closes.
+type browseCall struct {
+	peer string
+	done chan struct{}
+	pf   browsePeer
+	at   time.Time
+}
+
+// docsBrowser fetches member file lists, sharing fetches that are already
+// running and remembering each member's latency.
+type docsBrowser struct {
+	fetch func(ctx context.Context, peerID, groupID string) (json.RawMessage, error)
+	label func(peerID string) string
+
+	mu      sync.Mutex
+	latency map[string]time.Duration // peer -> smoothed time to answer
+	calls   map[string]*browseCall   // group + " " + peer -> running or recent fetch
+}
+
+func newDocsBrowser(d Deps) *docsBrowser {
+	return &docsBrowser{
+		fetch: d.Node.FetchDocList,
+		label: func(peerID string) string {
+			if d.ResolvePeer != nil {
+				if n := d.ResolvePeer(peerID).Name(); n != "" {
+					return n
+				}
+			}
+			return peerID
+		},
+		latency: make(map[string]time.Duration),
+		calls:   make(map[string]*browseCall),
+	}
+}
+
+func registerDocsBrowse(mux *http.ServeMux, d Deps) {
+	var b *docsBrowser
+	if d.Node != nil && d.GroupManager != nil {
+		b = newDocsBrowser(d)
+	}
+
+	handleGet(mux, "/api/docs/browse", func(w http.ResponseWriter, r *http.Request) {
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(w, "Missing group_id", http.StatusBadRequest)
+			return
+		}
+		self := docsSelf(d, groupID)
+		var peerIDs []string
+		if b != nil {
+			peerIDs = docsMembers(d, groupID, self.PeerID)
+		}
+
+		if r.URL.Query().Get("stream") == "1" {
+			sseHeaders(w)
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				http.Error(w, "streaming not supported", http.StatusInternalServerError)
+				return
+			}
+			send := func(event string, v any) {
+				data, _ := json.Marshal(v)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+				flusher.Flush()
+			}
+			send("peer", self)
+			if b != nil {
+				calls := b.start(groupID, peerIDs, 0)
+				answered := make(chan browsePeer, len(calls))
+				for _, c := range calls {
+					go func(c *browseCall) {
+						<-c.done
+						answered <- c.pf
+					}(c)
+				}
+				for range calls {
+					select {
+					case <-r.Context().Done():
+						return
+					case pf := <-answered:
+						send("peer", pf)
+					}
+				}
+			}
+			send("done", map[string]any{"group_id": groupID, "peers": len(peerIDs) + 1})
+			return
+		}
+
+		results := []browsePeer{self}
+		slow := []string{}
+		if b != nil {
+			got, late := b.collect(groupID, peerIDs, DocsBrowseDeadline)
+			results = append(results, got...)
+			slow = append(slow, late...)
+		}
+		writeJSON(w, map[string]any{
+			"group_id": groupID,
+			"peers":    results,
+			"slow":     slow,
+		})
+	})
+
+	// Refresh: the members a browse listed as slow, from the fetch still
+	// running for them or its cached answer.
+	handlePost(mux, "/api/docs/browse/refresh", func(w http.ResponseWriter, r *http.Request, req docsBrowseRefreshRequest) {
+		if req.GroupID == "" {
+			http.Error(w, "Missing group_id", http.StatusBadRequest)
+			return
+		}
+		if b == nil {
+			http.Error(w, "not available", http.StatusServiceUnavailable)
+			return
+		}
+		members := map[string]bool{}
+		for _, pid := range docsMembers(d, req.GroupID, d.Node.ID()) {
+			members[pid] = true
+		}
+		var peerIDs []string
+		for _, pid := range req.PeerIDs {
+			if members[pid] {
+				peerIDs = append(peerIDs, pid)
+			}
+		}
+		results := []browsePeer{}
+		for _, c := range b.start(req.GroupID, peerIDs, DocsBrowseCacheTTL) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-c.done:
+				results = append(results, c.pf)
+			}
+		}
+		writeJSON(w, map[string]any{
+			"group_id": req.GroupID,
+			"peers":    results,
+			"slow":     []string{},
+		})
+	})
+}
+
+// docsSelf is our own entry in a browse result.
+func docsSelf(d Deps, groupID string) browsePeer {
+	pf := browsePeer{Label: "Me", Self: true}
+	if d.Node != nil {
+		pf.PeerID = d.Node.ID()
+	}
+	if d.SelfLabel != nil {
+		if l := d.SelfLabel(); l != "" {
+			pf.Label = l
+		}
+	}
+	myFiles, err := d.DocsStore.List(groupID)
+	if err != nil {
+		myFiles = []files.DocInfo{}
+	}
+	pf.Files = myFiles
+	return pf
+}
+
+// docsMembers collects the group's members other than selfID from every
+// source, deduplicated. StoredGroupMembers is the key fallback: it works
+// even when the host is offline.
+func docsMembers(d Deps, groupID, selfID string) []string {
+	seen := map[string]bool{selfID: true}
+	var peerIDs []string
+	addPeer := func(pid string) {
+		if !seen[pid] {
+			seen[pid] = true
+			peerIDs = append(peerIDs, pid)
+		}
+	}
+	for _, m := range d.GroupManager.HostedGroupMembers(groupID) {
+		addPeer(m.PeerID)
+	}
+	for _, m := range d.GroupManager.ClientGroupMembers(groupID) {
+		addPeer(m.PeerID)
+	}
+	for _, gm := range d.GroupManager.StoredGroupMembers(groupID) {
+		addPeer(gm.PeerID)
+	}
+	return peerIDs
+}
+
+// collect fetches the members' file lists and returns them in launch
+// order once all answered or deadline passed, with the peer IDs of the
+// members marked slow.
+func (b *docsBrowser) collect(groupID string, peerIDs []string, deadline time.Duration) ([]browsePeer, []string) {
+	calls := b.start(groupID, peerIDs, 0)
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	expired := false
+	results := make([]browsePeer, 0, len(calls))
+	var slow []string
+	for _, c := range calls {
+		if !expired {
+			select {
+			case <-c.done:
+			case <-timer.C:
+				expired = true
+			}
+		}
+		select {
+		case <-c.done:
+			results = append(results, c.pf)
+		default:
+			// Out of time: leave it running for a refresh.
+			results = append(results, browsePeer{PeerID: c.peer, Label: b.label(c.peer), Files: []files.DocInfo{}, Error: "slow"})
+			slow = append(slow, c.peer)
+		}
+	}
+	return results, slow
+}
+
+// start returns a fetch per member, in the order they are launched: by
+// expected latency, members never measured between those known to answer
+// within DocsBrowseDeadline and those known not to. Launches are spread
+// DocsBrowseStagger apart. A fetch already running, or one finished
+// within maxAge, is shared rather than repeated.
+func (b *docsBrowser) start(groupID string, peerIDs []string, maxAge time.Duration) []*browseCall {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := append([]string(nil), peerIDs...)
+	expect := func(pid string) time.Duration {
+		if l, ok := b.latency[pid]; ok {
+			return l
+		}
+		return DocsBrowseDeadline
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return expect(ordered[i]) < expect(ordered[j]) })
+
+	now := time.Now()
+	for k, c := range b.calls {
+		if !c.at.IsZero() && now.Sub(c.at) > DocsBrowseCacheTTL {
+			delete(b.calls, k)
+		}
+	}
+	calls := make([]*browseCall, len(ordered))
+	launched := 0
+	for i, pid := range ordered {
+		key := groupID + " " + pid
+		if c, ok := b.calls[key]; ok && (c.at.IsZero() || now.Sub(c.at) <= maxAge) {
+			calls[i] = c
+			continue
+		}
+		c := &browseCall{peer: pid, done: make(chan struct{})}
+		b.calls[key] = c
+		calls[i] = c
+		go b.run(c, groupID, pid, time.Duration(launched)*DocsBrowseStagger)
+		launched++
+	}
+	return calls
+}
+
+// run fetches one member's file list after delay and records how long it
+// took. A member that fails counts as taking the whole timeout.
+func (b *docsBrowser) run(c *browseCall, groupID, peerID string, delay time.Duration) {
+	time.Sleep(delay)
+	limit := timeouts.Get().DocsList
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+
+	begin := time.Now()
+	raw, err := b.fetch(ctx, peerID, groupID)
+	took := time.Since(begin)
+
+	pf := browsePeer{PeerID: peerID, Label: b.label(peerID), Files: []files.DocInfo{}}
+	if err != nil {
+		log.Printf("DOCS: Failed to fetch list from %s: %v", peerID, err)
+		pf.Error = "unreachable"
+		took = limit
+	} else {
+		if raw != nil {
+			json.Unmarshal(raw, &pf.Files) //nolint:errcheck
+		}
+		if pf.Files == nil {
+			pf.Files = []files.DocInfo{}
+		}
+		pf.Latency = max(took.Milliseconds(), 1)
+	}
+
+	b.mu.Lock()
+	if l, ok := b.latency[peerID]; ok {
+		took = (l*7 + took*3) / 10
+	}
+	b.latency[peerID] = took
+	c.pf, c.at = pf, time.Now()
+	b.mu.Unlock()
+	close(c.done)
+}
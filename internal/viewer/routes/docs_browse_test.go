@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDocsBrowserSlowMembers(t *testing.T) {
+	release := make(chan struct{})
+	b := &docsBrowser{
+		fetch: func(ctx context.Context, peerID, groupID string) (json.RawMessage, error) {
+			if peerID == "relayed" {
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			return json.RawMessage(`[{"name":"` + peerID + `.txt","size":1}]`), nil
+		},
+		label:   func(peerID string) string { return peerID },
+		latency: make(map[string]time.Duration),
+		calls:   make(map[string]*browseCall),
+	}
+
+	got, slow := b.collect("g1", []string{"relayed", "direct"}, 200*time.Millisecond)
+	if len(got) != 2 || len(slow) != 1 || slow[0] != "relayed" {
+		t.Fatalf("got %+v, slow %v", got, slow)
+	}
+	for _, pf := range got {
+		switch pf.PeerID {
+		case "direct":
+			if pf.Error != "" || len(pf.Files) != 1 || pf.Latency == 0 {
+				t.Fatalf("direct = %+v", pf)
+			}
+		case "relayed":
+			if pf.Error != "slow" || pf.Files == nil {
+				t.Fatalf("relayed = %+v", pf)
+			}
+		}
+	}
+
+	// The refresh joins the fetch still running rather than starting one.
+	calls := b.start("g1", []string{"relayed"}, DocsBrowseCacheTTL)
+	close(release)
+	<-calls[0].done
+	if pf := calls[0].pf; pf.Error != "" || len(pf.Files) != 1 || pf.Files[0].Name != "relayed.txt" {
+		t.Fatalf("refreshed = %+v", pf)
+	}
+	if again := b.start("g1", []string{"relayed"}, DocsBrowseCacheTTL); again[0] != calls[0] {
+		t.Fatal("cached answer not reused")
+	}
+	if fresh := b.start("g1", []string{"relayed"}, 0); fresh[0] == calls[0] {
+		t.Fatal("browse reused a finished fetch")
+	}
+
+	// Members known to answer fast go first, unmeasured ones next.
+	b.mu.Lock()
+	b.latency["relayed"] = 3 * time.Second
+	b.latency["direct"] = 10 * time.Millisecond
+	b.mu.Unlock()
+	order := b.start("g2", []string{"relayed", "new", "direct"}, 0)
+	if order[0].peer != "direct" || order[1].peer != "new" || order[2].peer != "relayed" {
+		t.Fatalf("order = %s, %s, %s", order[0].peer, order[1].peer, order[2].peer)
+	}
+	for _, c := range order {
+		<-c.done
+	}
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/p2p"
+)
+
+// serveDocChunked downloads a peer's file over the chunked docs protocol
+// into <peerDir>/cache/docs and serves it from there, with Range support.
+// Progress goes to the browser as docs:download:<file>:progress events. A
+// download cut short keeps its partial file, so asking again resumes it.
+// It returns false, having written nothing, when the peer only speaks the
+// older protocol.
+func serveDocChunked(w http.ResponseWriter, r *http.Request, d Deps, peerID, groupID, filename, disposition string) bool {
+	dir := filepath.Join(d.PeerDir, "cache", "docs")
+	pruneDocCache(dir, DocCacheMaxAge)
+
+	base := mq.DocsDownloadProgressPayload{PeerID: peerID, GroupID: groupID, File: filename}
+	publish := func(p mq.DocsDownloadProgressPayload) {
+		if d.MQ != nil {
+			d.MQ.PublishDocsDownloadProgress(p)
+		}
+	}
+	path, stat, err := d.Node.DownloadDocFile(r.Context(), peerID, groupID, filename, dir, func(done, total int64) {
+		p := base
+		p.State, p.Done, p.Total = "progress", done, total
+		publish(p)
+	})
+	if errors.Is(err, p2p.ErrDocsChunkUnsupported) {
+		return false
+	}
+	if err != nil {
+		p := base
+		p.State, p.Error = "error", err.Error()
+		publish(p)
+		http.Error(w, fmt.Sprintf("Failed to fetch: %v", err), http.StatusBadGateway)
+		return true
+	}
+	p := base
+	p.State, p.Done, p.Total = "done", stat.Size, stat.Size
+	publish(p)
+
+	f, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "Failed to open download", http.StatusInternalServerError)
+		return true
+	}
+	defer f.Close()
+	if stat.Mime != "" {
+		w.Header().Set("Content-Type", stat.Mime)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="%s"`, disposition, filename))
+	w.Header().Set("ETag", `"`+stat.Hash+`"`)
+	http.ServeContent(w, r, filename, time.Time{}, f)
+	return true
+}
+
+// pruneDocCache removes downloads and partial files older than maxAge.
+func pruneDocCache(dir string, maxAge time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && !e.IsDir() && info.ModTime().Before(cutoff) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				log.Printf("DOCS: prune cache: %v", err)
+			}
+		}
+	}
+}
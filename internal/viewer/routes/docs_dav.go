@@ -0,0 +1,130 @@
+// WebDAV mount of the shared documents store.
+
+package routes
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/config"
+	files "github.com/petervdpas/goop2/internal/group_types/files"
+
+	"golang.org/x/net/webdav"
+)
+
+// registerDocsDavRoutes serves the docs store at /dav/docs/ so a file
+// manager can mount it: one folder per files group. Off unless
+// viewer.docs_webdav is "read" or "write"; the setting is read on every
+// request so it applies without a restart. Only local clients are served.
+func registerDocsDavRoutes(mux *http.ServeMux, d Deps) {
+	if d.DocsStore == nil {
+		return
+	}
+
+	fsys := &files.DavFS{
+		Store:  d.DocsStore,
+		Groups: func() []files.DavGroup { return davGroups(d) },
+		OnAdded: func(groupID, name string, size int, hash string) {
+			sendDocsEvent(d, groupID, map[string]any{
+				"action": "doc-added",
+				"file":   map[string]any{"name": name, "size": size, "hash": hash},
+			})
+		},
+		OnRemoved: func(groupID, name string) {
+			sendDocsEvent(d, groupID, map[string]any{"action": "doc-removed", "file": name})
+		},
+	}
+	readOnly := *fsys
+	readOnly.ReadOnly = true
+
+	locks := webdav.NewMemLS()
+	rw := &webdav.Handler{Prefix: "/dav/docs", FileSystem: fsys, LockSystem: locks}
+	ro := &webdav.Handler{Prefix: "/dav/docs", FileSystem: &readOnly, LockSystem: locks}
+
+	mux.HandleFunc("/dav/docs/", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		// File managers send neither; a browser page on another site does.
+		if r.Header.Get("Origin") != "" || !isLoopbackHost(r.Host) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		cfg, err := config.Load(d.CfgPath)
+		if err != nil {
+			http.Error(w, "failed to load config", http.StatusInternalServerError)
+			return
+		}
+		switch cfg.Viewer.DocsWebDAV {
+		case "write":
+			rw.ServeHTTP(w, r)
+		case "read":
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND":
+				ro.ServeHTTP(w, r)
+			default:
+				http.Error(w, "read-only mount", http.StatusForbidden)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// davGroups lists the files groups the user hosts or is subscribed to,
+// plus groups left behind on disk, which are shown read-only.
+func davGroups(d Deps) []files.DavGroup {
+	seen := map[string]bool{}
+	var out []files.DavGroup
+	if d.GroupManager != nil {
+		if hosted, err := d.GroupManager.ListHostedGroups(); err == nil {
+			for _, g := range hosted {
+				if g.GroupType == "files" && !seen[g.ID] {
+					seen[g.ID] = true
+					out = append(out, files.DavGroup{ID: g.ID, Name: g.Name, Writable: true})
+				}
+			}
+		}
+		if subs, err := d.GroupManager.ListSubscriptions(); err == nil {
+			for _, s := range subs {
+				if s.GroupType == "files" && !seen[s.GroupID] {
+					seen[s.GroupID] = true
+					out = append(out, files.DavGroup{ID: s.GroupID, Name: s.GroupName, Writable: true})
+				}
+			}
+		}
+	}
+	if disk, err := d.DocsStore.ListGroups(); err == nil {
+		for _, gid := range disk {
+			if !seen[gid] {
+				seen[gid] = true
+				out = append(out, files.DavGroup{ID: gid})
+			}
+		}
+	}
+	return out
+}
+
+// sendDocsEvent tells the group about a change, as host if we host it.
+func sendDocsEvent(d Deps, groupID string, payload map[string]any) {
+	if d.GroupManager == nil {
+		return
+	}
+	if err := d.GroupManager.SendToGroupAsHost(groupID, payload); err != nil {
+		_ = d.GroupManager.SendToGroup(groupID, payload)
+	}
+}
+
+func isLoopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
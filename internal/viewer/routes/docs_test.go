@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	files "github.com/petervdpas/goop2/internal/group_types/files"
+)
+
+func TestDocsACL(t *testing.T) {
+	store, err := files.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Save("g1", "notes.txt", []byte("notes"))
+	mux := http.NewServeMux()
+	registerDocsRoutes(mux, Deps{DocsStore: store})
+
+	const bob = "12D3KooWLpNs8Bi1kSe4k8QWAbJ1tHLb8cQ3ALWdLMWFk6xchsZb"
+	post := func(remote, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/docs/acl", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("127.0.0.1:1234", `{"group_id":"g1","filename":"notes.txt","access":"peers","peers":["`+bob+`"]}`)
+	var got files.ACL
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &got) != nil || got.Access != files.AccessPeers || len(got.Peers) != 1 {
+		t.Fatalf("set = %d %s", w.Code, w.Body)
+	}
+	if !store.CanRead("g1", "notes.txt", bob, false) || store.CanRead("g1", "notes.txt", "someone", true) {
+		t.Error("ACL not applied")
+	}
+
+	for body, want := range map[string]int{
+		`{"group_id":"g1","filename":"notes.txt","access":"peers","peers":["nope"]}`: http.StatusBadRequest,
+		`{"group_id":"g1","filename":"notes.txt","access":"friends"}`:                http.StatusBadRequest,
+		`{"group_id":"g1","filename":"gone.txt","access":"public"}`:                  http.StatusNotFound,
+	} {
+		if w := post("127.0.0.1:1234", body); w.Code != want {
+			t.Errorf("%s: %d, want %d", body, w.Code, want)
+		}
+	}
+	if w := post("192.0.2.1:1234", `{"group_id":"g1","filename":"notes.txt","access":"public"}`); w.Code != http.StatusForbidden {
+		t.Errorf("remote request: %d, want 403", w.Code)
+	}
+}
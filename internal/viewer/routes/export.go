@@ -4,6 +4,7 @@ package routes
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -33,11 +34,16 @@ type TablePolicyExport struct {
 }
 
 func registerExportRoutes(mux *http.ServeMux, d Deps, csrf string) {
-	// GET /api/site/export — download site as zip
+	// GET /api/site/export — download site as zip; ?format=static for a
+	// bundle ordinary web hosting can serve (see sitepublish.go)
 	handleGet(mux, "/api/site/export", func(w http.ResponseWriter, r *http.Request) {
 		if !requireLocal(w, r) {
 			return
 		}
+		if r.URL.Query().Get("format") == "static" {
+			serveStaticExport(w, r, d)
+			return
+		}
 
 		// Load config to get lua settings
 		var cfg config.Config
@@ -100,23 +106,7 @@ func registerExportRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			}
 		}
 
-		// Collect site files
-		siteFiles := make(map[string][]byte)
-		if d.Content != nil {
-			ctx := r.Context()
-			items, err := d.Content.ListTree(ctx, "")
-			if err == nil {
-				for _, item := range items {
-					if item.IsDir {
-						continue
-					}
-					data, _, readErr := d.Content.Read(ctx, item.Path)
-					if readErr == nil {
-						siteFiles[item.Path] = data
-					}
-				}
-			}
-		}
+		siteFiles := collectSiteFiles(r.Context(), d)
 
 		// Collect lua scripts
 		luaFiles := make(map[string][]byte)
@@ -258,6 +248,11 @@ func registerExportRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			}
 		}
 
+		if err := snapshotBeforeApply(d, "import:"+manifest.Label); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		// Reuse the existing template apply flow for site files + schema + policies
 		if err := applyTemplateFiles(d, siteFiles, schema, tablePolicies, manifest.Label, manifest.Schemas, manifest.RequireEmail, ""); err != nil {
 			http.Error(w, "failed to apply import: "+err.Error(), http.StatusInternalServerError)
@@ -436,3 +431,25 @@ func writeLuaScripts(scriptDir string, files map[string][]byte) error {
 
 	return nil
 }
+
+// collectSiteFiles reads every file of the site, keyed by relative path.
+func collectSiteFiles(ctx context.Context, d Deps) map[string][]byte {
+	siteFiles := make(map[string][]byte)
+	if d.Content == nil {
+		return siteFiles
+	}
+	items, err := d.Content.ListTree(ctx, "")
+	if err != nil {
+		return siteFiles
+	}
+	for _, item := range items {
+		if item.IsDir {
+			continue
+		}
+		data, _, readErr := d.Content.Read(ctx, item.Path)
+		if readErr == nil {
+			siteFiles[item.Path] = data
+		}
+	}
+	return siteFiles
+}
@@ -13,12 +13,13 @@ import (
 	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 // RegisterGroups adds group-related HTTP API endpoints.
 func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, resolvePeer func(string) state.PeerIdentityPayload, mqMgr *mq.Manager) {
 	// Create a hosted group / list hosted groups
-	mux.HandleFunc("/api/groups", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/groups", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			var req struct {
@@ -26,6 +27,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 				GroupType    string `json:"group_type"`
 				GroupContext  string `json:"group_context"`
 				MaxMembers   int    `json:"max_members"`
+				Ordered      bool   `json:"ordered"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				http.Error(w, "Invalid request", http.StatusBadRequest)
@@ -40,6 +42,14 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 				http.Error(w, fmt.Sprintf("Failed to create group: %v", err), http.StatusInternalServerError)
 				return
 			}
+			if req.Ordered {
+				if err := grpMgr.SetOrdered(id, true); err != nil {
+					// Don't leave behind a group the caller was told failed.
+					_ = grpMgr.CloseGroup(id)
+					http.Error(w, fmt.Sprintf("Failed to create group: %v", err), http.StatusInternalServerError)
+					return
+				}
+			}
 			writeJSON(w, map[string]any{
 				"status": "created",
 				"id":     id,
@@ -65,6 +75,10 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 				Members     []memberWithName `json:"members"`
 				HostInGroup bool             `json:"host_in_group"`
 				HostCanJoin bool             `json:"host_can_join"`
+				Muted       []group.MutedPayload `json:"muted,omitempty"`
+				CanCoHost   bool             `json:"can_cohost"`
+				CanHandOver bool             `json:"can_hand_over"`
+				Large       bool             `json:"large,omitempty"` // messages go over the group's GossipSub topic
 			}
 			result := make([]groupWithMembers, len(groups))
 			for i, g := range groups {
@@ -80,6 +94,10 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 					Members:     named,
 					HostInGroup: grpMgr.HostInGroup(g.ID),
 					HostCanJoin: flags.HostCanJoin,
+					Muted:       grpMgr.MutedMembers(g.ID),
+					CanCoHost:   grpMgr.CanCoHost(g.ID),
+					CanHandOver: grpMgr.CanHandOver(g.ID),
+					Large:       grpMgr.IsLargeGroup(g.ID),
 				}
 			}
 
@@ -147,6 +165,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 			storage.SubscriptionRow
 			HostReachable bool `json:"host_reachable"`
 			MemberCount   int  `json:"member_count"`
+			CoHosting     bool `json:"cohosting,omitempty"` // we relay this group for its owner
 		}
 		var enriched []subWithCount
 		for _, s := range subs {
@@ -161,6 +180,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 				SubscriptionRow: s,
 				HostReachable:   hostIdentity.Reachable,
 				MemberCount:     len(grpMgr.StoredGroupMembers(s.GroupID)),
+				CoHosting:       grpMgr.IsCoHosting(s.GroupID),
 			})
 		}
 
@@ -170,6 +190,34 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 		})
 	})
 
+	// GET /api/groups/presence?group_id= — online/offline status of every member
+	handleGet(mux, "/api/groups/presence", func(w http.ResponseWriter, r *http.Request) {
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(w, "missing group_id", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, grpMgr.GroupPresence(groupID))
+	})
+
+	// GET /api/groups/ownership?group_id= — owner changes of a group, latest first
+	handleGet(mux, "/api/groups/ownership", func(w http.ResponseWriter, r *http.Request) {
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(w, "missing group_id", http.StatusBadRequest)
+			return
+		}
+		history, err := grpMgr.OwnershipHistory(groupID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if history == nil {
+			history = []storage.OwnershipRecord{}
+		}
+		writeJSON(w, history)
+	})
+
 	// Join a remote group
 	handlePost(mux, "/api/groups/join", func(w http.ResponseWriter, r *http.Request, req struct {
 		HostPeerID string `json:"host_peer_id"`
@@ -180,7 +228,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), GroupJoinTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
 		defer cancel()
 
 		if err := grpMgr.JoinRemoteGroup(ctx, req.HostPeerID, req.GroupID); err != nil {
@@ -201,7 +249,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), GroupJoinTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
 		defer cancel()
 
 		if err := grpMgr.InvitePeer(ctx, req.PeerID, req.GroupID); err != nil {
@@ -222,7 +270,7 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), GroupJoinTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeouts.Get().GroupJoin)
 		defer cancel()
 
 		if err := grpMgr.RejoinSubscription(ctx, req.HostPeerID, req.GroupID); err != nil {
@@ -282,6 +330,70 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 		writeJSON(w, map[string]string{"status": "kicked"})
 	})
 
+	// POST /api/groups/cohost — authorize a member to relay a hosted group
+	handlePost(mux, "/api/groups/cohost", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		PeerID  string `json:"peer_id"`
+	}) {
+		if req.GroupID == "" || req.PeerID == "" {
+			http.Error(w, "missing group_id or peer_id", http.StatusBadRequest)
+			return
+		}
+		if err := grpMgr.AddCoHost(req.GroupID, req.PeerID); err != nil {
+			http.Error(w, fmt.Sprintf("add co-host failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/groups/cohost/remove — withdraw a co-host's authorization
+	handlePost(mux, "/api/groups/cohost/remove", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		PeerID  string `json:"peer_id"`
+	}) {
+		if req.GroupID == "" || req.PeerID == "" {
+			http.Error(w, "missing group_id or peer_id", http.StatusBadRequest)
+			return
+		}
+		if err := grpMgr.RemoveCoHost(req.GroupID, req.PeerID); err != nil {
+			http.Error(w, fmt.Sprintf("remove co-host failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/groups/transfer — hand a hosted group over to one of its members
+	handlePost(mux, "/api/groups/transfer", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		PeerID  string `json:"peer_id"`
+	}) {
+		if req.GroupID == "" || req.PeerID == "" {
+			http.Error(w, "missing group_id or peer_id", http.StatusBadRequest)
+			return
+		}
+		if err := grpMgr.TransferOwnership(req.GroupID, req.PeerID); err != nil {
+			http.Error(w, fmt.Sprintf("transfer failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/groups/unmute — lift a flood-protection mute on a member
+	handlePost(mux, "/api/groups/unmute", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		PeerID  string `json:"peer_id"`
+	}) {
+		if req.GroupID == "" || req.PeerID == "" {
+			http.Error(w, "missing group_id or peer_id", http.StatusBadRequest)
+			return
+		}
+		if err := grpMgr.UnmuteMember(req.GroupID, req.PeerID); err != nil {
+			http.Error(w, fmt.Sprintf("unmute failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
 	// POST /api/groups/max-members — update max member limit for a hosted group
 	handlePost(mux, "/api/groups/max-members", func(w http.ResponseWriter, r *http.Request, req struct {
 		GroupID    string `json:"group_id"`
@@ -376,6 +488,22 @@ func RegisterGroups(mux *http.ServeMux, grpMgr *group.Manager, selfID string, re
 		writeJSON(w, map[string]string{"status": "ok"})
 	})
 
+	// POST /api/groups/ordered — turn ordered delivery on or off for a hosted group
+	handlePost(mux, "/api/groups/ordered", func(w http.ResponseWriter, r *http.Request, req struct {
+		GroupID string `json:"group_id"`
+		Ordered bool   `json:"ordered"`
+	}) {
+		if req.GroupID == "" {
+			http.Error(w, "missing group_id", http.StatusBadRequest)
+			return
+		}
+		if err := grpMgr.SetOrdered(req.GroupID, req.Ordered); err != nil {
+			http.Error(w, fmt.Sprintf("set ordered failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
 	// POST /api/groups/set-roles — set the available roles for a group
 	handlePost(mux, "/api/groups/set-roles", func(w http.ResponseWriter, r *http.Request, req struct {
 		GroupID string   `json:"group_id"`
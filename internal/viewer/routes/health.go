@@ -0,0 +1,34 @@
+package routes
+
+import "net/http"
+
+func registerHealthRoutes(mux *http.ServeMux, d Deps) {
+	if d.Health == nil {
+		return
+	}
+
+	// GET /api/health — what the startup doctor found and repaired.
+	handleGet(mux, "/api/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Health())
+	})
+}
+
+func registerStartupRoutes(mux *http.ServeMux, d Deps) {
+	if d.Startup == nil {
+		return
+	}
+
+	// GET /api/startup — the startup stages, with timings and any failure.
+	handleGet(mux, "/api/startup", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Startup())
+	})
+}
+
+func registerMetricsRoutes(mux *http.ServeMux, d Deps) {
+	if d.Metrics == nil {
+		return
+	}
+
+	// GET /metrics — Prometheus text format, for scrapers rather than the UI.
+	mux.Handle("/metrics", d.Metrics)
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"path"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
@@ -171,6 +172,17 @@ func getTrimmedPostFormValue(form map[string][]string, key string) string {
 	return strings.TrimSpace(values[0])
 }
 
+// formLines splits a textarea form value into its trimmed, non-empty lines.
+func formLines(form map[string][]string, key string) []string {
+	var out []string
+	for _, line := range strings.Split(getTrimmedPostFormValue(form, key), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
 // requireContentStore checks if content store is configured and sends error if not.
 // Returns true if store is configured, false otherwise.
 func requireContentStore(w http.ResponseWriter, store any) bool {
@@ -221,7 +233,7 @@ func writeJSON(w http.ResponseWriter, v any) {
 // Returns nil on success; callers should return early on non-nil error.
 func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
-		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return err
 	}
 	return nil
@@ -238,22 +250,47 @@ func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
 }
 
 // handlePost registers a POST handler that decodes a JSON body into T
-// before calling fn. Method check and decode errors are handled automatically.
+// before calling fn. Method check, decode errors and validation against the
+// documented body schema are handled automatically.
 func handlePost[T any](mux *http.ServeMux, path string, fn func(http.ResponseWriter, *http.Request, T)) {
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+	recordRoute(mux, http.MethodPost, path, reflect.TypeFor[T]())
+	mux.HandleFunc(path, idempotent(func(w http.ResponseWriter, r *http.Request) {
 		if !requireMethod(w, r, http.MethodPost) {
 			return
 		}
 		var req T
-		if decodeJSON(w, r, &req) != nil {
+		if !decodeRequest(w, r, &req) {
 			return
 		}
 		fn(w, r, req)
-	})
+	}))
+}
+
+// handleGetPost registers a path that is read with GET and written with a
+// JSON POST decoded into T. The mux takes one handler per path, so the pair
+// cannot be registered with handleGet and handlePost separately.
+func handleGetPost[T any](mux *http.ServeMux, path string, get func(http.ResponseWriter, *http.Request), post func(http.ResponseWriter, *http.Request, T)) {
+	recordRoute(mux, http.MethodGet, path, nil)
+	recordRoute(mux, http.MethodPost, path, reflect.TypeFor[T]())
+	mux.HandleFunc(path, idempotent(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			get(w, r)
+		case http.MethodPost:
+			var req T
+			if !decodeRequest(w, r, &req) {
+				return
+			}
+			post(w, r, req)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
 }
 
 // handleGet registers a GET handler with an automatic method check.
 func handleGet(mux *http.ServeMux, path string, fn func(http.ResponseWriter, *http.Request)) {
+	recordRoute(mux, http.MethodGet, path, nil)
 	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 		if !requireMethod(w, r, http.MethodGet) {
 			return
@@ -265,24 +302,34 @@ func handleGet(mux *http.ServeMux, path string, fn func(http.ResponseWriter, *ht
 // handlePostAction registers a POST handler with no JSON body decoding.
 // Use for endpoints that either have no request body or read it themselves.
 func handlePostAction(mux *http.ServeMux, path string, fn func(http.ResponseWriter, *http.Request)) {
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+	recordRoute(mux, http.MethodPost, path, nil)
+	mux.HandleFunc(path, idempotent(func(w http.ResponseWriter, r *http.Request) {
 		if !requireMethod(w, r, http.MethodPost) {
 			return
 		}
 		fn(w, r)
-	})
+	}))
 }
 
 // handleFormPost registers a POST handler that validates CSRF + parses form data.
 // Method check, localhost check, form parsing, and CSRF validation are handled
 // automatically. The handler receives the request with r.PostForm populated.
 func handleFormPost(mux *http.ServeMux, path, csrf string, fn func(http.ResponseWriter, *http.Request)) {
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+	recordRoute(mux, http.MethodPost, path, nil)
+	mux.HandleFunc(path, idempotent(func(w http.ResponseWriter, r *http.Request) {
 		if err := validatePOSTRequest(w, r, csrf); err != nil {
 			return
 		}
 		fn(w, r)
-	})
+	}))
+}
+
+// handleAny registers a handler that checks the method itself. Use it
+// instead of mux.HandleFunc for /api/ routes, so the OpenAPI contract check
+// sees them and non-GET requests honor idempotency keys.
+func handleAny(mux *http.ServeMux, path string, fn func(http.ResponseWriter, *http.Request)) {
+	recordRoute(mux, "", path, nil)
+	mux.HandleFunc(path, idempotent(fn))
 }
 
 // requireLocal checks that the request originates from localhost and sends
@@ -2,13 +2,18 @@
 package routes
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/petervdpas/goop2/internal/config"
 	"github.com/petervdpas/goop2/internal/ui/render"
 	"github.com/petervdpas/goop2/internal/ui/viewmodels"
 )
 
+// maxPeerPageLimit caps the rows of one paged /api/peers response.
+const maxPeerPageLimit = 1000
+
 func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
@@ -24,7 +29,7 @@ func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 		splash := "goop2-splash2.png"
 		if d.CfgPath != "" {
 			if cfg, err := config.Load(d.CfgPath); err == nil {
-				selfVideoDisabled = cfg.Viewer.VideoDisabled
+				selfVideoDisabled = cfg.CallsDisabled()
 				hideUnverified = cfg.Viewer.HideUnverified
 				if cfg.Viewer.Splash != "" {
 					splash = cfg.Viewer.Splash
@@ -34,6 +39,7 @@ func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 		vm := viewmodels.PeersVM{
 			BaseVM:            baseVM("Goop", "peers", "page.peers", d),
 			Peers:             viewmodels.BuildPeerRows(d.Peers.Snapshot()),
+			Namespaces:        namespaceNames(d),
 			SelfVideoDisabled: selfVideoDisabled,
 			HideUnverified:    hideUnverified,
 			Splash:            splash,
@@ -44,7 +50,13 @@ func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 	// Probe all peers synchronously and return the updated list.
 	handlePostAction(mux, "/api/peers/probe", func(w http.ResponseWriter, r *http.Request) {
 		d.Node.ProbeAllPeers(r.Context())
-		writeJSON(w, viewmodels.BuildPeerRows(d.Peers.Snapshot()))
+		rows := viewmodels.BuildPeerRows(d.Peers.Snapshot())
+		if d.Node != nil {
+			for i := range rows {
+				rows[i].Link = d.Node.LinkQuality(rows[i].ID)
+			}
+		}
+		writeJSON(w, rows)
 	})
 
 	// Toggle favorite status for a peer
@@ -63,9 +75,62 @@ func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	// JSON endpoint for peers list
+	// JSON endpoint for peers list; ?namespace= picks a community's list.
+	// With ?limit= the list is filtered, sorted and paged server-side and
+	// comes wrapped with summary counts, see viewmodels.PeerPage.
 	handleGet(mux, "/api/peers", func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(w, viewmodels.BuildPeerRows(d.Peers.Snapshot()))
+		q := r.URL.Query()
+		table := d.Peers
+		if ns := q.Get("namespace"); ns != "" && ns != "global" {
+			if table = d.Namespaces[ns]; table == nil {
+				http.Error(w, "unknown namespace", http.StatusNotFound)
+				return
+			}
+		}
+		rows := viewmodels.BuildPeerRows(table.Snapshot())
+		if !q.Has("limit") {
+			writeJSON(w, rows)
+			return
+		}
+		pq := viewmodels.PeerQuery{
+			Query:     q.Get("q"),
+			ByID:      q.Get("by") == "id",
+			Favorites: q.Get("favorites") == "1",
+			Verified:  q.Get("verified") == "1",
+			Online:    q.Get("online") == "1",
+			Sort:      q.Get("sort"),
+			Offset:    max(atoiOrNeg(q.Get("offset")), 0),
+			Limit:     atoiOrNeg(q.Get("limit")),
+		}
+		switch pq.Sort {
+		case "", "id", "name", "last_seen":
+		default:
+			http.Error(w, "sort must be id, name or last_seen", http.StatusBadRequest)
+			return
+		}
+		if pq.Limit < 0 || pq.Limit > maxPeerPageLimit {
+			http.Error(w, fmt.Sprintf("limit must be 0-%d", maxPeerPageLimit), http.StatusBadRequest)
+			return
+		}
+		page := viewmodels.PagePeerRows(rows, pq)
+		page.Summary.Capacity, page.Summary.Evicted = table.Capacity()
+		writeJSON(w, page)
+	})
+
+	// Presence namespaces joined besides the global one, with peer counts
+	handleGet(mux, "/api/peers/namespaces", func(w http.ResponseWriter, r *http.Request) {
+		out := []namespaceInfo{}
+		for _, name := range namespaceNames(d) {
+			info := namespaceInfo{Name: name}
+			for _, sp := range d.Namespaces[name].Snapshot() {
+				info.Peers++
+				if sp.OfflineSince.IsZero() {
+					info.Online++
+				}
+			}
+			out = append(out, info)
+		}
+		writeJSON(w, out)
 	})
 
 	// JSON endpoint for network topology graph
@@ -85,3 +150,19 @@ func registerHomeRoutes(mux *http.ServeMux, d Deps) {
 		})
 	})
 }
+
+type namespaceInfo struct {
+	Name   string `json:"name"`
+	Peers  int    `json:"peers"`
+	Online int    `json:"online"`
+}
+
+// namespaceNames returns the joined presence namespaces, sorted.
+func namespaceNames(d Deps) []string {
+	names := make([]string, 0, len(d.Namespaces))
+	for name := range d.Namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
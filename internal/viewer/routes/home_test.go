@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/ui/viewmodels"
 )
 
 func TestHomeRedirectsToPeers(t *testing.T) {
@@ -63,6 +64,76 @@ func TestAPIPeersReturnsJSON(t *testing.T) {
 	}
 }
 
+func TestAPIPeers_namespace(t *testing.T) {
+	mux := http.NewServeMux()
+	gamers := state.NewPeerTable()
+	gamers.Upsert("peer-g", "Gamer", "", "", false, "", "", false, false, "", false)
+	global := state.NewPeerTable()
+	global.Upsert("peer-a", "Alice", "", "", false, "", "", false, false, "", false)
+	registerHomeRoutes(mux, Deps{Peers: global, Namespaces: map[string]*state.PeerTable{"gamers": gamers}})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+	if w := get("/api/peers?namespace=gamers"); !strings.Contains(w.Body.String(), "peer-g") || strings.Contains(w.Body.String(), "peer-a") {
+		t.Errorf("gamers list = %s", w.Body)
+	}
+	if w := get("/api/peers?namespace=global"); !strings.Contains(w.Body.String(), "peer-a") {
+		t.Errorf("global list = %s", w.Body)
+	}
+	if w := get("/api/peers?namespace=nope"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown namespace: %d", w.Code)
+	}
+	if w := get("/api/peers/namespaces"); !strings.Contains(w.Body.String(), `{"name":"gamers","peers":1,"online":1}`) {
+		t.Errorf("namespaces = %s", w.Body)
+	}
+}
+
+func TestAPIPeers_paged(t *testing.T) {
+	mux := http.NewServeMux()
+	pt := state.NewPeerTable()
+	for _, p := range []struct{ id, name string }{{"p1", "Carol"}, {"p2", "alice"}, {"p3", "Bob"}, {"p4", "Alicia"}} {
+		pt.Upsert(p.id, p.name, "", "", false, "", "", false, false, "", false)
+	}
+	pt.SetFavorite("p3", true)
+	pt.MarkOffline("p4")
+	registerHomeRoutes(mux, Deps{Peers: pt})
+
+	get := func(url string) (int, viewmodels.PeerPage) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		var page viewmodels.PeerPage
+		json.Unmarshal(w.Body.Bytes(), &page)
+		return w.Code, page
+	}
+
+	_, page := get("/api/peers?limit=2&sort=name")
+	if len(page.Peers) != 2 || page.Peers[0].ID != "p2" || page.Peers[1].ID != "p4" {
+		t.Fatalf("first page = %+v", page.Peers)
+	}
+	want := viewmodels.PeerSummary{Total: 4, Online: 3, Favorites: 1, Matched: 4}
+	if page.Summary != want {
+		t.Errorf("summary = %+v, want %+v", page.Summary, want)
+	}
+	if _, page = get("/api/peers?limit=2&offset=2&sort=name"); len(page.Peers) != 2 || page.Peers[0].ID != "p3" {
+		t.Errorf("second page = %+v", page.Peers)
+	}
+	if _, page = get("/api/peers?limit=0&q=ALI&online=1"); page.Summary.Matched != 1 || page.Peers[0].ID != "p2" {
+		t.Errorf("online alis = %+v", page)
+	}
+	if _, page = get("/api/peers?limit=10&favorites=1"); len(page.Peers) != 1 || page.Peers[0].ID != "p3" {
+		t.Errorf("favorites = %+v", page.Peers)
+	}
+	if code, _ := get("/api/peers?limit=10&sort=age"); code != http.StatusBadRequest {
+		t.Errorf("bad sort: %d", code)
+	}
+	if code, _ := get("/api/peers?limit=100000"); code != http.StatusBadRequest {
+		t.Errorf("huge limit: %d", code)
+	}
+}
+
 func TestAPIPeersRejectsPost(t *testing.T) {
 	mux := http.NewServeMux()
 	d := Deps{Peers: state.NewPeerTable()}
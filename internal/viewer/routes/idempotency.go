@@ -0,0 +1,282 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Idempotency keys make client retries of mutating requests safe: a POST
+// that carries an Idempotency-Key header runs once, and a retry with the
+// same key (same method and path) gets the recorded response back instead
+// of creating a second group, upload or row. A retry that arrives while the
+// first request is still running waits for it. Reusing a key with a
+// different request body is refused with 422.
+//
+// Server errors (5xx) are not kept, so a retry after one runs the handler
+// again. Neither are responses marked Cache-Control: no-store, which is how
+// handlers that return a secret keep it out of memory. Requests without the
+// header behave as before.
+
+const (
+	idempotencyHeader   = "Idempotency-Key"
+	idempotencyReplayed = "Idempotent-Replayed"
+
+	idempotencyTTL        = 10 * time.Minute // how long a response is replayed
+	idempotencyMaxKey     = 255              // longest accepted key
+	idempotencyMaxEntries = 2048             // cached responses before new keys are not kept
+	idempotencyMaxBody    = 1 << 20          // larger responses are not kept
+)
+
+type idemEntry struct {
+	done    chan struct{}     // closed when the first request has finished
+	keep    bool              // the response below can be replayed
+	sum     [sha256.Size]byte // hash of the request body that claimed the key
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+type idemCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idemEntry
+	now     func() time.Time
+}
+
+func newIdemCache(ttl time.Duration) *idemCache {
+	return &idemCache{ttl: ttl, entries: map[string]*idemEntry{}, now: time.Now}
+}
+
+var idempotency = newIdemCache(idempotencyTTL)
+
+// idempotent wraps a mutating handler with the shared idempotency cache.
+func idempotent(fn http.HandlerFunc) http.HandlerFunc {
+	return idempotency.wrap(fn)
+}
+
+func (c *idemCache) wrap(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			fn(w, r)
+			return
+		}
+		if len(key) > idempotencyMaxKey {
+			http.Error(w, "idempotency key too long", http.StatusBadRequest)
+			return
+		}
+		key = r.Method + " " + r.URL.Path + " " + key
+		sum, err := hashBody(r)
+		if err != nil {
+			http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Body != nil {
+			defer r.Body.Close() // removes a spooled copy
+		}
+
+		for {
+			e, first := c.claim(key, sum)
+			if e == nil {
+				fn(w, r) // cache full; run without a key
+				return
+			}
+			if first {
+				c.run(key, e, fn, w, r)
+				return
+			}
+			select {
+			case <-e.done:
+			case <-r.Context().Done():
+				return
+			}
+			if e.sum != sum {
+				http.Error(w, "idempotency key reused with a different request body", http.StatusUnprocessableEntity)
+				return
+			}
+			if e.keep {
+				e.replay(w)
+				return
+			}
+			// The first attempt failed and was dropped; claim the key again.
+		}
+	}
+}
+
+// claim returns the entry for key, creating it for a body hashing to sum
+// when there is none. first reports whether the caller created it and must
+// run the handler.
+func (c *idemCache) claim(key string, sum [sha256.Size]byte) (e *idemEntry, first bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if now.Before(e.expires) {
+				return e, false
+			}
+			delete(c.entries, key)
+		default:
+			return e, false
+		}
+	}
+	if len(c.entries) >= idempotencyMaxEntries {
+		c.pruneLocked(now)
+		if len(c.entries) >= idempotencyMaxEntries {
+			return nil, false
+		}
+	}
+	e = &idemEntry{done: make(chan struct{}), sum: sum}
+	c.entries[key] = e
+	return e, true
+}
+
+func (c *idemCache) run(key string, e *idemEntry, fn http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	rec := &idemRecorder{ResponseWriter: w}
+	completed := false
+	defer func() {
+		c.mu.Lock()
+		e.status = rec.status
+		if e.status == 0 {
+			e.status = http.StatusOK
+		}
+		e.header = rec.header
+		if e.header == nil {
+			e.header = w.Header().Clone()
+		}
+		e.body = rec.body
+		e.keep = completed && e.status < http.StatusInternalServerError && !rec.overflow && !rec.flushed &&
+			!strings.Contains(e.header.Get("Cache-Control"), "no-store")
+		if !e.keep {
+			e.header, e.body = nil, nil
+		}
+		e.expires = c.now().Add(c.ttl)
+		if !e.keep {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		close(e.done)
+	}()
+	fn(rec, r)
+	completed = true
+}
+
+// hashBody hashes the request body and puts back a copy the handler can
+// read. Bodies up to idempotencyMaxBody stay in memory; larger uploads are
+// spooled to a temporary file that is removed when the body is closed.
+func hashBody(r *http.Request) (sum [sha256.Size]byte, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return sha256.Sum256(nil), nil
+	}
+	defer r.Body.Close()
+	h := sha256.New()
+	head, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxBody+1))
+	if err != nil {
+		return sum, err
+	}
+	h.Write(head)
+	if len(head) <= idempotencyMaxBody {
+		h.Sum(sum[:0])
+		r.Body = io.NopCloser(bytes.NewReader(head))
+		return sum, nil
+	}
+	f, err := os.CreateTemp("", "goop2-idem-*")
+	if err != nil {
+		return sum, err
+	}
+	if _, err = f.Write(head); err == nil {
+		_, err = io.Copy(io.MultiWriter(f, h), r.Body)
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return sum, err
+	}
+	h.Sum(sum[:0])
+	r.Body = &spooledBody{f}
+	return sum, nil
+}
+
+// spooledBody is a request body read back from a temporary file.
+type spooledBody struct{ *os.File }
+
+func (b *spooledBody) Close() error {
+	err := b.File.Close()
+	os.Remove(b.Name())
+	return err
+}
+
+func (c *idemCache) pruneLocked(now time.Time) {
+	for k, e := range c.entries {
+		select {
+		case <-e.done:
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		default:
+		}
+	}
+}
+
+func (e *idemEntry) replay(w http.ResponseWriter) {
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(idempotencyReplayed, "true")
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// idemRecorder passes the response through while keeping a copy of it.
+type idemRecorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     []byte
+	overflow bool // body exceeded idempotencyMaxBody
+	flushed  bool // streamed responses are not replayed
+}
+
+func (rec *idemRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+		rec.header = rec.ResponseWriter.Header().Clone()
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idemRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if len(rec.body)+len(b) > idempotencyMaxBody {
+			rec.overflow, rec.body = true, nil
+		} else {
+			rec.body = append(rec.body, b...)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *idemRecorder) Flush() {
+	rec.flushed = true
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *idemRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func idemPost(h http.HandlerFunc, path, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotencyHeader, key)
+	}
+	h(w, r)
+	return w
+}
+
+func TestIdempotent_replaysResponse(t *testing.T) {
+	var calls atomic.Int32
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":` + string(rune('0'+n)) + `}`))
+	})
+
+	first := idemPost(h, "/api/groups", "k1", "{}")
+	again := idemPost(h, "/api/groups", "k1", "{}")
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+	if again.Code != http.StatusCreated || again.Body.String() != `{"id":1}` {
+		t.Errorf("replay = %d %q, want 201 %q", again.Code, again.Body.String(), first.Body.String())
+	}
+	if again.Header().Get("Content-Type") != "application/json" || again.Header().Get(idempotencyReplayed) != "true" {
+		t.Errorf("replay headers = %v", again.Header())
+	}
+	if first.Header().Get(idempotencyReplayed) != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	// Other keys, other paths and requests without a key run again.
+	idemPost(h, "/api/groups", "k2", "{}")
+	idemPost(h, "/api/docs/upload", "k1", "{}")
+	idemPost(h, "/api/groups", "", "{}")
+	idemPost(h, "/api/groups", "", "{}")
+	if calls.Load() != 5 {
+		t.Errorf("handler ran %d times, want 5", calls.Load())
+	}
+}
+
+func TestIdempotent_serverErrorsAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	if w := idemPost(h, "/x", "k", ""); w.Code != http.StatusBadGateway {
+		t.Fatalf("first = %d", w.Code)
+	}
+	if w := idemPost(h, "/x", "k", ""); w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("retry = %d %q, want 200 ok", w.Code, w.Body.String())
+	}
+	if w := idemPost(h, "/x", "k", ""); w.Header().Get(idempotencyReplayed) != "true" {
+		t.Error("successful retry was not kept")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestIdempotent_clientErrorsAreKept(t *testing.T) {
+	var calls atomic.Int32
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "group exists", http.StatusConflict)
+	})
+	idemPost(h, "/x", "k", "")
+	if w := idemPost(h, "/x", "k", ""); w.Code != http.StatusConflict {
+		t.Errorf("replay = %d, want 409", w.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotent_differentBodyRejected(t *testing.T) {
+	var calls atomic.Int32
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	if w := idemPost(h, "/x", "k", `{"name":"a"}`); w.Body.String() != `{"name":"a"}` {
+		t.Fatalf("handler saw %q", w.Body.String())
+	}
+	if w := idemPost(h, "/x", "k", `{"name":"b"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reuse with another body = %d, want 422", w.Code)
+	}
+	if w := idemPost(h, "/x", "k", `{"name":"a"}`); w.Header().Get(idempotencyReplayed) != "true" {
+		t.Error("same body was not replayed")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotent_largeBodySpooled(t *testing.T) {
+	big := strings.Repeat("x", idempotencyMaxBody+10)
+	var got atomic.Int64
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		got.Store(n)
+	})
+	idemPost(h, "/x", "k", big)
+	if got.Load() != int64(len(big)) {
+		t.Errorf("handler read %d bytes, want %d", got.Load(), len(big))
+	}
+	if w := idemPost(h, "/x", "k", big+"y"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reuse with a longer body = %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotent_noStoreNotKept(t *testing.T) {
+	c := newIdemCache(time.Minute)
+	var calls atomic.Int32
+	h := c.wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("secret"))
+	})
+	idemPost(h, "/api/secrets/rotate", "k", "")
+	if len(c.entries) != 0 {
+		t.Errorf("no-store response kept: %d entries", len(c.entries))
+	}
+	if w := idemPost(h, "/api/secrets/rotate", "k", ""); w.Header().Get(idempotencyReplayed) != "" {
+		t.Error("no-store response was replayed")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestIdempotent_expires(t *testing.T) {
+	c := newIdemCache(time.Minute)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	var calls atomic.Int32
+	h := c.wrap(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) })
+
+	idemPost(h, "/x", "k", "")
+	now = now.Add(59 * time.Second)
+	idemPost(h, "/x", "k", "")
+	now = now.Add(2 * time.Second)
+	idemPost(h, "/x", "k", "")
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestIdempotent_concurrentRetryWaits(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	var wg sync.WaitGroup
+	var second *httptest.ResponseRecorder
+	wg.Add(2)
+	go func() { defer wg.Done(); idemPost(h, "/x", "k", "") }()
+	<-started
+	go func() { defer wg.Done(); second = idemPost(h, "/x", "k", "") }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+	if second.Body.String() != "done" {
+		t.Errorf("waiting retry got %q", second.Body.String())
+	}
+}
+
+func TestIdempotent_ignoresGet(t *testing.T) {
+	var calls atomic.Int32
+	h := newIdemCache(time.Minute).wrap(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) })
+	for range 2 {
+		r := httptest.NewRequest("GET", "/x", nil)
+		r.Header.Set(idempotencyHeader, "k")
+		h(httptest.NewRecorder(), r)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestHandlePost_honorsIdempotencyKey(t *testing.T) {
+	mux := http.NewServeMux()
+	var calls atomic.Int32
+	handlePost(mux, "/api/test/idempotent", func(w http.ResponseWriter, r *http.Request, req struct {
+		Name string `json:"name"`
+	}) {
+		calls.Add(1)
+		writeJSON(w, map[string]string{"name": req.Name})
+	})
+	for range 2 {
+		r := httptest.NewRequest("POST", "/api/test/idempotent", strings.NewReader(`{"name":"a"}`))
+		r.Header.Set(idempotencyHeader, "same-key")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import "net/http"
+
+func registerJobRoutes(mux *http.ServeMux, d Deps) {
+	if d.Jobs == nil {
+		return
+	}
+
+	// GET /api/jobs — background job queue: counters and dead letters
+	handleGet(mux, "/api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Jobs.Report())
+	})
+
+	// POST /api/jobs/clear — empty the dead-letter list
+	handlePostAction(mux, "/api/jobs/clear", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		writeJSON(w, map[string]int{"cleared": d.Jobs.ClearDead()})
+	})
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -36,7 +37,7 @@ func RegisterListen(mux *http.ServeMux, lm *listen.Manager, resolvePeer func(str
 		writeJSON(w, map[string]string{"status": "closed"})
 	})
 
-	// POST /api/listen/load — host loads one or more MP3 files as a playlist.
+	// POST /api/listen/load — host loads one or more audio files as a playlist.
 	// Accepts either {file_path: "..."} or {file_paths: ["...", ...]}.
 	handlePost(mux, "/api/listen/load", func(w http.ResponseWriter, r *http.Request, req struct {
 		FilePath  string   `json:"file_path"`
@@ -137,9 +138,96 @@ func RegisterListen(mux *http.ServeMux, lm *listen.Manager, resolvePeer func(str
 		writeJSON(w, map[string]string{"status": "left"})
 	})
 
-	// GET /api/listen/stream — audio stream (Content-Type: audio/mpeg)
+	// POST /api/listen/request — listener asks the host for a track (file name or stream URL)
+	handlePost(mux, "/api/listen/request", func(w http.ResponseWriter, r *http.Request, req struct {
+		Track string `json:"track"`
+	}) {
+		if err := lm.RequestTrack(req.Track); err != nil {
+			http.Error(w, fmt.Sprintf("failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "requested"})
+	})
+
+	// POST /api/listen/report — listener reports how far its player got
+	handlePost(mux, "/api/listen/report", func(w http.ResponseWriter, r *http.Request, req struct {
+		Elapsed float64 `json:"elapsed"`
+	}) {
+		if err := lm.ReportPosition(req.Elapsed); err != nil {
+			http.Error(w, fmt.Sprintf("failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// GET /api/listen/requests — host's pending listener requests
+	handleGet(mux, "/api/listen/requests", func(w http.ResponseWriter, r *http.Request) {
+		reqs := lm.Requests()
+		names := make(map[string]string, len(reqs))
+		for _, rq := range reqs {
+			if n := resolvePeer(rq.PeerID).Name(); n != "" {
+				names[rq.PeerID] = n
+			}
+		}
+		jukebox := false
+		if g := lm.GetGroup(); g != nil {
+			jukebox = g.Jukebox
+		}
+		writeJSON(w, map[string]any{"requests": reqs, "jukebox": jukebox, "peer_names": names})
+	})
+
+	// POST /api/listen/requests/approve — host queues a request; file_path
+	// picks the file when the name does not match one next to the queue
+	handlePost(mux, "/api/listen/requests/approve", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID       string `json:"id"`
+		FilePath string `json:"file_path"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := lm.ApproveRequest(req.ID, req.FilePath); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, listen.ErrRequestNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, fmt.Sprintf("failed: %v", err), status)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/listen/requests/reject — host drops a request
+	handlePost(mux, "/api/listen/requests/reject", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID string `json:"id"`
+	}) {
+		if err := lm.RejectRequest(req.ID); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, listen.ErrRequestNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, fmt.Sprintf("failed: %v", err), status)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/listen/jukebox — host turns auto-accepting requests on or off
+	handlePost(mux, "/api/listen/jukebox", func(w http.ResponseWriter, r *http.Request, req struct {
+		Enabled bool `json:"enabled"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := lm.SetJukebox(req.Enabled); err != nil {
+			http.Error(w, fmt.Sprintf("failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// GET /api/listen/stream — audio stream, Content-Type per track format
 	handleGet(mux, "/api/listen/stream", func(w http.ResponseWriter, r *http.Request) {
-		reader, err := lm.AudioReader()
+		reader, format, err := lm.AudioReader()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed: %v", err), http.StatusServiceUnavailable)
 			return
@@ -153,7 +241,7 @@ func RegisterListen(mux *http.ServeMux, lm *listen.Manager, resolvePeer func(str
 			reader.Close()
 		}()
 
-		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("Content-Type", listen.ContentType(format))
 		w.Header().Set("Cache-Control", "no-cache, no-store")
 		w.Header().Set("Transfer-Encoding", "chunked")
 		w.WriteHeader(http.StatusOK)
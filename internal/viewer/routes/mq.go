@@ -3,35 +3,55 @@ package routes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/petervdpas/goop2/internal/mq"
+	"github.com/petervdpas/goop2/internal/timeouts"
 )
 
 // RegisterMQ adds the message-queue HTTP endpoints.
 //
 //	POST /api/mq/send   — send a message to a peer
 //	POST /api/mq/ack    — notify sender that we processed their message
+//	GET  /api/mq/outbox — messages queued for offline peers
+//	POST /api/mq/outbox/purge — drop queued messages
 //	GET  /api/mq/events — SSE stream of incoming messages and delivery receipts
+//	                      (resumable via Last-Event-ID header or ?since=,
+//	                      filtered by ?topics=)
 func RegisterMQ(mux *http.ServeMux, mqMgr *mq.Manager, onChatSent func(peerID, content string)) {
 	// POST /api/mq/send
 	handlePost(mux, "/api/mq/send", func(w http.ResponseWriter, r *http.Request, req struct {
-		PeerID  string `json:"peer_id"`
-		Topic   string `json:"topic"`
-		Payload any    `json:"payload"`
-		MsgID   string `json:"msg_id"` // client-generated ID for de-dup (optional)
+		PeerID   string `json:"peer_id"`
+		Topic    string `json:"topic"`
+		Payload  any    `json:"payload"`
+		MsgID    string `json:"msg_id"`   // client-generated ID for de-dup (optional)
+		Priority string `json:"priority"` // interactive | normal | bulk (optional, default by topic/size)
+		Queue    bool   `json:"queue"`    // keep in the outbox if the peer is unreachable
+		TTLSec   int    `json:"ttl_sec"`  // how long a queued message waits (optional)
 	}) {
 		if req.PeerID == "" || req.Topic == "" {
 			http.Error(w, "missing peer_id or topic", http.StatusBadRequest)
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(r.Context(), MQSendTimeout)
+		ctx, cancel := context.WithTimeout(r.Context(), timeouts.Get().MQSend)
 		defer cancel()
 
-		msgID, err := mqMgr.Send(ctx, req.PeerID, req.Topic, req.Payload)
+		var msgID string
+		var outboxID int64
+		var err error
+		if req.Queue {
+			msgID, outboxID, err = mqMgr.SendQueued(ctx, req.PeerID, req.Topic, req.Payload, time.Duration(req.TTLSec)*time.Second)
+		} else if prio, ok := mq.ParsePriority(req.Priority); ok {
+			msgID, err = mqMgr.SendPriority(ctx, req.PeerID, req.Topic, req.Payload, prio)
+		} else {
+			msgID, err = mqMgr.Send(ctx, req.PeerID, req.Topic, req.Payload)
+		}
 		if err != nil {
 			log.Printf("MQ: send to %s failed: %v", req.PeerID, err)
 			http.Error(w, fmt.Sprintf("send failed: %v", err), http.StatusGatewayTimeout)
@@ -46,12 +66,41 @@ func RegisterMQ(mux *http.ServeMux, mqMgr *mq.Manager, onChatSent func(peerID, c
 			}
 		}
 
+		if outboxID != 0 {
+			writeJSON(w, map[string]any{
+				"outbox_id": outboxID,
+				"status":    "queued",
+			})
+			return
+		}
 		writeJSON(w, map[string]string{
 			"msg_id": msgID,
 			"status": "delivered",
 		})
 	})
 
+	// GET /api/mq/outbox — store-and-forward queue, optionally for one peer
+	handleGet(mux, "/api/mq/outbox", func(w http.ResponseWriter, r *http.Request) {
+		entries, err := mqMgr.Outbox(r.URL.Query().Get("peer_id"))
+		if err != nil {
+			http.Error(w, err.Error(), outboxStatus(err))
+			return
+		}
+		writeJSON(w, entries)
+	})
+
+	// POST /api/mq/outbox/purge — drop queued messages for a peer, or all
+	handlePost(mux, "/api/mq/outbox/purge", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		n, err := mqMgr.PurgeOutbox(req.PeerID)
+		if err != nil {
+			http.Error(w, err.Error(), outboxStatus(err))
+			return
+		}
+		writeJSON(w, map[string]int64{"purged": n})
+	})
+
 	// POST /api/mq/ack  — browser signals it processed a message; we relay
 	// an application-level ACK back to the original sender peer.
 	handlePost(mux, "/api/mq/ack", func(w http.ResponseWriter, r *http.Request, req struct {
@@ -84,8 +133,23 @@ func RegisterMQ(mux *http.ServeMux, mqMgr *mq.Manager, onChatSent func(peerID, c
 		writeJSON(w, map[string]string{"status": "ok"})
 	})
 
+	// GET /api/mq/metrics — per-topic delivery counters and ack latency
+	handleGet(mux, "/api/mq/metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, mqMgr.Metrics())
+	})
+
 	// GET /api/mq/events — SSE stream
+	// ?topics=call:*,group:+:state limits it to messages on those topics.
 	handleGet(mux, "/api/mq/events", func(w http.ResponseWriter, r *http.Request) {
+		var filter *mq.TopicFilter
+		if topics := r.URL.Query().Get("topics"); topics != "" {
+			f, err := mq.ParseTopicFilter(topics)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter = f
+		}
 		sseHeaders(w)
 
 		flusher, ok := w.(http.Flusher)
@@ -94,10 +158,11 @@ func RegisterMQ(mux *http.ServeMux, mqMgr *mq.Manager, onChatSent func(peerID, c
 			return
 		}
 
-		evtCh, cancel := mqMgr.Subscribe()
+		since := lastEventID(r)
+		evtCh, cancel := mqMgr.SubscribeFiltered(since, filter)
 		defer cancel()
 
-		fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"ok\"}\n\n")
+		fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"ok\",\"last_event_id\":%d}\n\n", mqMgr.LastEventID())
 		flusher.Flush()
 
 		ctx := r.Context()
@@ -114,9 +179,34 @@ func RegisterMQ(mux *http.ServeMux, mqMgr *mq.Manager, onChatSent func(peerID, c
 					log.Printf("MQ: SSE marshal error: %v", err)
 					continue
 				}
+				if evt.JSeq > 0 {
+					fmt.Fprintf(w, "id: %d\n", evt.JSeq)
+				}
 				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
 				flusher.Flush()
 			}
 		}
 	})
 }
+
+// lastEventID returns the event ID a reconnecting SSE client last saw, from the
+// standard Last-Event-ID header or a ?since= query parameter (used after a
+// page reload, when EventSource has lost its state). Returns 0 if absent.
+func lastEventID(r *http.Request) int64 {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("since")
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func outboxStatus(err error) int {
+	if errors.Is(err, mq.ErrNoOutbox) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
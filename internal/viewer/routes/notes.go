@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// maxPeerNoteBytes caps a single peer note.
+const maxPeerNoteBytes = 16 << 10
+
+func registerNoteRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil {
+		return
+	}
+
+	// GET /api/peers/notes — every private note; ?peer=<id> for one.
+	// POST /api/peers/notes — set the note for a peer; an empty body deletes it.
+	handleGetPost(mux, "/api/peers/notes", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		peerID := r.URL.Query().Get("peer")
+		if peerID == "" {
+			notes, err := d.DB.ListPeerNotes()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if notes == nil {
+				notes = []storage.PeerNote{}
+			}
+			writeJSON(w, notes)
+			return
+		}
+		note, _ := d.DB.GetPeerNote(peerID)
+		note.PeerID = peerID
+		writeJSON(w, note)
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+		Body   string `json:"body"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.PeerID == "" {
+			http.Error(w, "peer_id required", http.StatusBadRequest)
+			return
+		}
+		if len(req.Body) > maxPeerNoteBytes || !utf8.ValidString(req.Body) {
+			http.Error(w, "note too long or not UTF-8", http.StatusBadRequest)
+			return
+		}
+		note, err := d.DB.SetPeerNote(req.PeerID, req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		note.PeerID = req.PeerID
+		writeJSON(w, note)
+	})
+}
@@ -12,17 +12,49 @@ type mqSendRequest struct {
 	Topic   string `json:"topic"    example:"call:nc-abc123"`
 	Payload any    `json:"payload"`
 	MsgID   string `json:"msg_id,omitempty" example:"uuid-optional"`
+	// Delivery lane; defaults from topic (call:/group: interactive) and payload size.
+	Priority string `json:"priority,omitempty" example:"interactive" enums:"interactive,normal,bulk"`
+	// Store and forward: if the peer is unreachable, keep the message in the outbox and deliver it later.
+	Queue bool `json:"queue,omitempty"`
+	// How long a queued message waits for the peer; default 7 days.
+	TTLSec int `json:"ttl_sec,omitempty" example:"86400"`
 }
 
 // mqSendResponse is the success body for POST /api/mq/send.
 type mqSendResponse struct {
-	MsgID  string `json:"msg_id"  example:"a1b2c3d4-..."`
-	Status string `json:"status"  example:"delivered"`
+	MsgID  string `json:"msg_id,omitempty"  example:"a1b2c3d4-..."`
+	Status string `json:"status"  example:"delivered" enums:"delivered,queued"`
+	// Outbox entry of a queued message.
+	OutboxID int64 `json:"outbox_id,omitempty" example:"12"`
+}
+
+// mqOutboxEntry is one message in GET /api/mq/outbox (storage.OutboxEntry).
+type mqOutboxEntry struct {
+	ID        int64  `json:"id" example:"12"`
+	PeerID    string `json:"peer_id" example:"12D3KooWXxx..."`
+	Topic     string `json:"topic" example:"chat"`
+	Payload   any    `json:"payload"`
+	CreatedAt int64  `json:"created_at" example:"1711234567890"`
+	ExpiresAt int64  `json:"expires_at" example:"1711839367890"`
+	Attempts  int    `json:"attempts" example:"2"`
+	NextTry   int64  `json:"next_try" example:"1711234627890"`
+	LastError string `json:"last_error" example:"peer unreachable"`
+}
+
+// mqOutboxPurgeRequest is the body for POST /api/mq/outbox/purge.
+type mqOutboxPurgeRequest struct {
+	// Peer whose queued messages are dropped; empty drops all.
+	PeerID string `json:"peer_id,omitempty" example:"12D3KooWXxx..."`
+}
+
+// mqOutboxPurgeResponse is the body returned by POST /api/mq/outbox/purge.
+type mqOutboxPurgeResponse struct {
+	Purged int64 `json:"purged" example:"3"`
 }
 
 // mqAckRequest is the body for POST /api/mq/ack.
 type mqAckRequest struct {
-	MsgID      string `json:"msg_id"                   example:"a1b2c3d4-..."`
+	MsgID      string `json:"msg_id"                   example:"a1b2c3d4-..." binding:"required"`
 	FromPeerID string `json:"from_peer_id,omitempty"   example:"12D3KooWXxx..."`
 }
 
@@ -31,6 +63,10 @@ type callModeResponse struct {
 	Mode     string `json:"mode"     example:"native"`
 	Platform string `json:"platform" example:"linux"`
 	First    bool   `json:"first"`
+	// STUN URLs for ICE: the rendezvous's own when advertised, else a public default.
+	STUNServers []string `json:"stun_servers" example:"stun:goop2.com:3478"`
+	// Live captions can be enabled (native mode with viewer.caption_command set).
+	Captions bool `json:"captions"`
 }
 
 // callSessionStatus mirrors call.SessionStatus.
@@ -42,6 +78,46 @@ type callSessionStatus struct {
 	AudioOn    bool   `json:"audio_on"`
 	VideoOn    bool   `json:"video_on"`
 	Hung       bool   `json:"hung"`
+	OnHold     bool   `json:"on_hold"`
+	RemoteHold bool   `json:"remote_hold"`
+	Captions   string `json:"captions,omitempty" example:"en"`
+	Camera     string `json:"camera,omitempty"`
+	Mic        string `json:"mic,omitempty"`
+}
+
+// callDevices is the body returned by GET /api/call/devices.
+type callDevices struct {
+	Devices      []callMediaDevice `json:"devices"`
+	PreferredCam string            `json:"preferred_cam"`
+	PreferredMic string            `json:"preferred_mic"`
+}
+
+// callMediaDevice is one local capture device.
+type callMediaDevice struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"  example:"video"`
+	Label string `json:"label" example:"Integrated Camera"`
+}
+
+// callDeviceRequest is the body for POST /api/call/device.
+type callDeviceRequest struct {
+	ChannelID string `json:"channel_id" example:"nc-abc123"`
+	Kind      string `json:"kind"       example:"audio"`
+	DeviceID  string `json:"device_id"`
+}
+
+// callCaptionsRequest is the body for POST /api/call/captions.
+type callCaptionsRequest struct {
+	ChannelID string `json:"channel_id" example:"nc-abc123"`
+	Enabled   bool   `json:"enabled"`
+	Language  string `json:"language"   example:"en"`
+}
+
+// callAudioProcessing is the mic processing state for /api/call/audio-processing.
+// On POST, omitted fields keep their current value.
+type callAudioProcessing struct {
+	NoiseSuppression bool `json:"noise_suppression" example:"true"`
+	EchoCancellation bool `json:"echo_cancellation" example:"true"`
 }
 
 // callStartRequest is the body for POST /api/call/start and /api/call/accept.
@@ -58,7 +134,7 @@ type callStartResponse struct {
 
 // callChannelRequest is the body for /api/call/hangup, toggle-audio, toggle-video.
 type callChannelRequest struct {
-	ChannelID string `json:"channel_id" example:"nc-abc123"`
+	ChannelID string `json:"channel_id" example:"nc-abc123" binding:"required"`
 }
 
 // callMuteResponse is the body for /api/call/toggle-audio.
@@ -71,6 +147,62 @@ type callVideoResponse struct {
 	Disabled bool `json:"disabled"`
 }
 
+// callHoldResponse is the body for /api/call/hold and /api/call/resume.
+type callHoldResponse struct {
+	OnHold bool `json:"on_hold"`
+}
+
+// callTransferRequest is the body for POST /api/call/transfer.
+type callTransferRequest struct {
+	ChannelID  string `json:"channel_id"  example:"nc-abc123"`
+	TargetPeer string `json:"target_peer" example:"12D3KooWYyy..."`
+}
+
+// callCallback mirrors call.CallbackRequest.
+type callCallback struct {
+	PeerID    string `json:"peer_id"`
+	ChannelID string `json:"channel_id"`
+	At        int64  `json:"at"`
+}
+
+// callbackDismissRequest is the body for POST /api/call/callbacks/dismiss.
+type callbackDismissRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..."`
+}
+
+// callChatRequest is the body for POST /api/call/chat.
+type callChatRequest struct {
+	ChannelID string `json:"channel_id" example:"nc-abc123"`
+	Content   string `json:"content"    example:"here's the link: https://example.com"`
+}
+
+// callFileRequest is the body for POST /api/call/file.
+type callFileRequest struct {
+	ChannelID string `json:"channel_id" example:"nc-abc123"`
+	Name      string `json:"name"       example:"notes.pdf"`
+	Mime      string `json:"mime"       example:"application/pdf"`
+	Data      string `json:"data"       format:"base64"`
+}
+
+// callDataMessage mirrors call.DataMessage as returned to the sender.
+type callDataMessage struct {
+	Type    string `json:"type"    example:"chat"`
+	ID      string `json:"id"`
+	Content string `json:"content,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Mime    string `json:"mime,omitempty"`
+	Size    int    `json:"size,omitempty"`
+	TS      int64  `json:"ts"`
+}
+
+// chatCallMessageRequest is the body for POST /api/chat/call.
+type chatCallMessageRequest struct {
+	PeerID   string `json:"peer_id"  example:"12D3KooWXxx..."`
+	CallID   string `json:"call_id"  example:"mc-abc123"`
+	Content  string `json:"content"`
+	Incoming bool   `json:"incoming"`
+}
+
 // loopbackOfferRequest is the body for POST /api/call/loopback/{channel}/offer.
 type loopbackOfferRequest struct {
 	SDP string `json:"sdp"`
@@ -95,6 +227,7 @@ type groupCreateRequest struct {
 	GroupContext  string `json:"group_context,omitempty"`
 	MaxMembers   int    `json:"max_members,omitempty"`
 	Volatile     bool   `json:"volatile,omitempty"`
+	Ordered      bool   `json:"ordered,omitempty"` // sequence-numbered delivery with resend
 }
 
 // groupCreateResponse is the response for POST /api/groups.
@@ -105,10 +238,10 @@ type groupCreateResponse struct {
 
 // groupIDRequest is the body for single-group-id endpoints.
 type groupIDRequest struct {
-	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2"`
+	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2" binding:"required"`
 }
 
-// groupPeerRequest is the body for invite / kick.
+// groupPeerRequest is the body for invite / kick / cohost / transfer.
 type groupPeerRequest struct {
 	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2"`
 	PeerID  string `json:"peer_id"  example:"12D3KooWXxx..."`
@@ -122,14 +255,14 @@ type groupHostJoinRequest struct {
 
 // groupMaxMembersRequest is the body for /api/groups/max-members.
 type groupMaxMembersRequest struct {
-	GroupID    string `json:"group_id"    example:"a1b2c3d4e5f6a1b2"`
+	GroupID    string `json:"group_id"    example:"a1b2c3d4e5f6a1b2" binding:"required"`
 	MaxMembers int    `json:"max_members" example:"20"`
 }
 
 // groupMetaRequest is the body for /api/groups/meta.
 type groupMetaRequest struct {
-	GroupID    string `json:"group_id"              example:"a1b2c3d4e5f6a1b2"`
-	Name       string `json:"name"                  example:"New Name"`
+	GroupID    string `json:"group_id"              example:"a1b2c3d4e5f6a1b2" binding:"required"`
+	Name       string `json:"name"                  example:"New Name" binding:"required"`
 	MaxMembers int    `json:"max_members,omitempty" example:"20"`
 }
 
@@ -146,15 +279,21 @@ type groupSetDefaultRoleRequest struct {
 	DefaultRole string `json:"default_role" example:"coauthor"`
 }
 
+// groupOrderedRequest is the body for POST /api/groups/ordered.
+type groupOrderedRequest struct {
+	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2" binding:"required"`
+	Ordered bool   `json:"ordered"  example:"true"`
+}
+
 // groupSetRolesListRequest is the body for POST /api/groups/set-roles.
 type groupSetRolesListRequest struct {
-	GroupID string   `json:"group_id" example:"a1b2c3d4e5f6a1b2"`
+	GroupID string   `json:"group_id" example:"a1b2c3d4e5f6a1b2" binding:"required"`
 	Roles   []string `json:"roles"    example:"[\"viewer\",\"coauthor\"]"`
 }
 
 // groupSendRequest is the body for /api/groups/send.
 type groupSendRequest struct {
-	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2"`
+	GroupID string `json:"group_id" example:"a1b2c3d4e5f6a1b2" binding:"required"`
 	Payload any    `json:"payload"`
 }
 
@@ -170,6 +309,10 @@ type listenGroup struct {
 	QueueTypes []string        `json:"queue_types,omitempty"`
 	QueueIndex int             `json:"queue_index"`
 	QueueTotal int             `json:"queue_total"`
+	Jukebox    bool            `json:"jukebox,omitempty"`
+	PendingRequests int        `json:"pending_requests,omitempty" example:"2"`
+	Drift      map[string]float64 `json:"drift,omitempty"`      // host: seconds each listener is ahead (+) or behind
+	StreamGen  int             `json:"stream_gen,omitempty"` // listener: bumped when the host resyncs us
 }
 
 // listenTrack describes the currently loaded audio track.
@@ -177,7 +320,7 @@ type listenTrack struct {
 	Name     string  `json:"name"      example:"song.mp3"`
 	Duration float64 `json:"duration"  example:"245.3"`
 	Bitrate  int     `json:"bitrate"   example:"320000"`
-	Format   string  `json:"format"    example:"mp3"`
+	Format   string  `json:"format"    example:"mp3" enums:"mp3,flac,ogg,aac,m4a,stream"`
 	IsStream bool    `json:"is_stream" example:"false"`
 }
 
@@ -223,6 +366,48 @@ type listenJoinRequest struct {
 	GroupID    string `json:"group_id"     example:"a1b2c3d4e5f6a1b2"`
 }
 
+// listenTrackRequestBody is the body for POST /api/listen/request.
+type listenTrackRequestBody struct {
+	Track string `json:"track" example:"song.mp3"`
+}
+
+// listenTrackRequest is a listener's pending request on the host.
+type listenTrackRequest struct {
+	ID     string `json:"id"      example:"a1b2c3d4e5f6"`
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..."`
+	Track  string `json:"track"   example:"song.mp3"`
+	Name   string `json:"name"    example:"song.mp3"`
+	At     int64  `json:"at"      example:"1709136000000"`
+}
+
+// listenReportRequest is the body for POST /api/listen/report.
+type listenReportRequest struct {
+	Elapsed float64 `json:"elapsed" example:"42.5"`
+}
+
+// listenRequestsResponse is the body for GET /api/listen/requests.
+type listenRequestsResponse struct {
+	Requests  []listenTrackRequest `json:"requests"`
+	Jukebox   bool                 `json:"jukebox"`
+	PeerNames map[string]string    `json:"peer_names,omitempty"`
+}
+
+// listenApproveRequest is the body for POST /api/listen/requests/approve.
+type listenApproveRequest struct {
+	ID       string `json:"id"                  example:"a1b2c3d4e5f6"`
+	FilePath string `json:"file_path,omitempty" example:"/home/me/Music/song.mp3"`
+}
+
+// listenRejectRequest is the body for POST /api/listen/requests/reject.
+type listenRejectRequest struct {
+	ID string `json:"id" example:"a1b2c3d4e5f6"`
+}
+
+// listenJukeboxRequest is the body for POST /api/listen/jukebox.
+type listenJukeboxRequest struct {
+	Enabled bool `json:"enabled" example:"true"`
+}
+
 // quickSettingsRequest is the body for POST /api/settings/quick.
 type quickSettingsRequest struct {
 	Label              *string `json:"label,omitempty"`
@@ -235,6 +420,8 @@ type quickSettingsRequest struct {
 	HideUnverified     *bool   `json:"hide_unverified,omitempty"`
 	OpenSitesExternal  *bool   `json:"open_sites_external,omitempty"`
 	UseServices        *bool   `json:"use_services,omitempty"`
+	BridgeToken        *string `json:"bridge_token,omitempty"`
+	BridgeMode         *bool   `json:"bridge_mode,omitempty"`
 }
 
 // quickSettingsResponse is the body for GET /api/settings/quick/get.
@@ -254,7 +441,113 @@ type quickSettingsResponse struct {
 type clientLogRequest struct {
 	Level   string `json:"level"   example:"warn"`
 	Source  string `json:"source"  example:"call"`
-	Message string `json:"message" example:"getUserMedia failed"`
+	Message string `json:"message" example:"getUserMedia failed" binding:"required"`
+}
+
+// consentDecisionRequest is the body for POST /api/security/consent.
+type consentDecisionRequest struct {
+	ID       string `json:"id"       example:"9f2c4e1a7b3d5f60" binding:"required"`
+	Decision string `json:"decision" example:"always" enums:"once,always,block,deny"`
+}
+
+// consentForgetRequest is the body for POST /api/security/consent/forget.
+type consentForgetRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+}
+
+// actionRunRequest is the body for POST /api/actions/run.
+type actionRunRequest struct {
+	ID     string         `json:"id"     example:"listen.pause"`
+	Params map[string]any `json:"params"`
+}
+
+// pairStartRequest is the body for POST /api/pair/start.
+type pairStartRequest struct {
+	Scopes []string `json:"scopes" example:"notify,call"`
+}
+
+// pairRevokeRequest is the body for POST /api/pair/revoke.
+type pairRevokeRequest struct {
+	ID string `json:"id" example:"3fa1c09b72d4e815" binding:"required"`
+}
+
+// pairGuestRequest is the body for POST /api/pair/guest.
+type pairGuestRequest struct {
+	Minutes int `json:"minutes" example:"30"`
+}
+
+// pairClaimRequest is the body for POST /api/pair/claim.
+type pairClaimRequest struct {
+	Code string `json:"code" example:"482913"`
+	Name string `json:"name" example:"Pixel 8"`
+}
+
+// peerForgetRequest is the body for POST /api/peers/forget.
+type peerForgetRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+}
+
+// peerFollowRequest is the body for POST /api/peers/follow, /api/peers/unfollow
+// and /api/peers/follows/sync.
+type peerFollowRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+}
+
+// peerNoteRequest is the body for POST /api/peers/notes.
+type peerNoteRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+	Body   string `json:"body"    example:"Met at the meetup, hosts the jazz station"`
+}
+
+// peerReportRequest is the body for POST /api/peers/reports.
+type peerReportRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+	Reason string `json:"reason"  example:"spam" enums:"spam,harassment,impersonation,illegal,other" binding:"required"`
+	Note   string `json:"note"    example:"Floods the chat room with links"`
+}
+
+// reputationSubmitRequest is the body for POST /api/peers/reputation/submit.
+type reputationSubmitRequest struct {
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
+	Days   int    `json:"days"    example:"30"`
+}
+
+// digestRequest is the body for POST /api/digest.
+type digestRequest struct {
+	Frequency string `json:"frequency" example:"daily"`
+	Favorites bool   `json:"favorites" example:"true"`
+}
+
+// scheduleDeleteRequest is the body for POST /api/schedule/delete.
+type scheduleDeleteRequest struct {
+	ID int64 `json:"id" example:"7"`
+}
+
+// calendarFeedRequest is the body for POST /api/schedule/feeds and /api/schedule/feeds/revoke.
+type calendarFeedRequest struct {
+	GroupID string `json:"group_id" example:"a1b2c3d4"`
+}
+
+// ruleIDRequest is the body for POST /api/rules/delete and /api/rules/test.
+type ruleIDRequest struct {
+	ID string `json:"id" example:"9c2e41d07a5b3f68"`
+}
+
+// secretSetRequest is the body for POST /api/secrets/set.
+type secretSetRequest struct {
+	Name  string `json:"name" example:"credits-admin"`
+	Value string `json:"value" example:"tok_4f1c0e"`
+}
+
+// secretNameRequest is the body for POST /api/secrets/rotate and /api/secrets/delete.
+type secretNameRequest struct {
+	Name string `json:"name" example:"credits-admin"`
+}
+
+// dataWipeRequest is the body for POST /api/export/wipe.
+type dataWipeRequest struct {
+	Categories []string `json:"categories" example:"chat,calls"`
+	PeerID     string   `json:"peer_id"    example:"12D3KooWXxx..."`
 }
 
 // docsDeleteRequest is the body for POST /api/docs/delete.
@@ -265,7 +558,7 @@ type docsDeleteRequest struct {
 
 // dataTableRequest is the body for single-table endpoints.
 type dataTableRequest struct {
-	Table string `json:"table" example:"my_table"`
+	Table string `json:"table" example:"my_table" binding:"required"`
 }
 
 // dataTableCreateRequest is the body for POST /api/data/tables/create.
@@ -273,7 +566,7 @@ type dataTableRequest struct {
 //   - Classic: [{"name":"col","type":"TEXT","not_null":false,"default":""}]
 //   - ORM (typed): [{"name":"col","type":"text","key":true,"required":false,"default":"value"}]
 type dataTableCreateRequest struct {
-	Name    string `json:"name"              example:"my_table"`
+	Name    string `json:"name"              example:"my_table" binding:"required"`
 	Columns []any  `json:"columns,omitempty"`
 }
 
@@ -338,28 +631,31 @@ type ormEnumValue struct {
 
 // dataInsertRequest is the body for POST /api/data/insert.
 type dataInsertRequest struct {
-	Table string         `json:"table" example:"my_table"`
-	Row   map[string]any `json:"row"`
+	Table string         `json:"table" example:"my_table" binding:"required"`
+	Data  map[string]any `json:"data"`
 }
 
 // dataQueryRequest is the body for POST /api/data/query.
 type dataQueryRequest struct {
-	Table  string         `json:"table"            example:"my_table"`
-	Filter map[string]any `json:"filter,omitempty"`
-	Limit  int            `json:"limit,omitempty"  example:"100"`
+	Table   string   `json:"table"   example:"my_table" binding:"required"`
+	Columns []string `json:"columns"`
+	Where   string   `json:"where"   example:"published = ?"`
+	Args    []any    `json:"args"`
+	Limit   int      `json:"limit"   example:"100"`
+	Offset  int      `json:"offset"  example:"0"`
 }
 
 // dataUpdateRequest is the body for POST /api/data/update.
 type dataUpdateRequest struct {
-	Table  string         `json:"table"  example:"my_table"`
-	Filter map[string]any `json:"filter"`
-	Set    map[string]any `json:"set"`
+	Table string         `json:"table" example:"my_table" binding:"required"`
+	ID    int64          `json:"id"    example:"1"`
+	Data  map[string]any `json:"data"`
 }
 
 // dataDeleteRequest is the body for POST /api/data/delete.
 type dataDeleteRequest struct {
-	Table  string         `json:"table"  example:"my_table"`
-	Filter map[string]any `json:"filter"`
+	Table string `json:"table" example:"my_table" binding:"required"`
+	ID    int64  `json:"id"    example:"1"`
 }
 
 // dataColumnRequest is the body for add-column / drop-column.
@@ -371,13 +667,13 @@ type dataColumnRequest struct {
 // dataPolicyRequest is the body for POST /api/data/tables/set-policy.
 // For ORM tables, updates the schema Access.Insert field directly.
 type dataPolicyRequest struct {
-	Table  string `json:"table"  example:"my_table"`
+	Table  string `json:"table"  example:"my_table" binding:"required"`
 	Policy string `json:"policy" example:"group"` // owner, open, group, local
 }
 
 // dataRenameRequest is the body for POST /api/data/tables/rename.
 type dataRenameRequest struct {
-	Table   string `json:"table"    example:"my_table"`
+	OldName string `json:"old_name" example:"my_table"`
 	NewName string `json:"new_name" example:"new_name"`
 }
 
@@ -541,6 +837,54 @@ type servicesHealthResponse struct {
 	Encryption   *serviceHealthEntry `json:"encryption,omitempty"`
 }
 
+// healthCheck is one startup check in healthReport.
+type healthCheck struct {
+	Name       string `json:"name" example:"group-state"`
+	Status     string `json:"status" example:"repaired"` // ok, repaired, failed or skipped
+	Repaired   int    `json:"repaired,omitempty" example:"2"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// healthReport is the body for GET /api/health.
+type healthReport struct {
+	At         int64         `json:"at"`
+	FirstStart bool          `json:"first_start"`
+	Unclean    bool          `json:"unclean"`
+	Healthy    bool          `json:"healthy"`
+	Repaired   int           `json:"repaired"`
+	ElapsedMs  int64         `json:"elapsed_ms"`
+	Checks     []healthCheck `json:"checks"`
+}
+
+// startupStage is one stage in startupReport.
+type startupStage struct {
+	Name       string `json:"name" example:"relay"`
+	Label      string `json:"label" example:"Discovering relay"`
+	Step       int    `json:"step" example:"1"`
+	Total      int    `json:"total" example:"5"`
+	State      string `json:"state" example:"done"` // running, done or failed
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty" example:"no relay"`
+	Error      string `json:"error,omitempty"`
+}
+
+// startupReport is the body for GET /api/startup.
+type startupReport struct {
+	StartedAt  int64          `json:"started_at"`
+	FinishedAt int64          `json:"finished_at,omitempty"`
+	Done       bool           `json:"done"`
+	Failed     bool           `json:"failed"`
+	Stages     []startupStage `json:"stages"`
+}
+
+// jobsClearResponse is the body for POST /api/jobs/clear.
+type jobsClearResponse struct {
+	Cleared int `json:"cleared" example:"3"`
+}
+
 // ── Docs response types ──────────────────────────────────────────────────────
 
 // docFileInfo describes a shared file.
@@ -548,6 +892,9 @@ type docFileInfo struct {
 	Name    string `json:"name"     example:"notes.pdf"`
 	Size    int64  `json:"size"     example:"1048576"`
 	ModTime string `json:"mod_time" example:"2026-03-08T12:00:00Z"`
+	// Who may fetch the file; peers is only in your own listings.
+	Access string   `json:"access,omitempty" example:"group" enums:"group,peers,public"`
+	Peers  []string `json:"peers,omitempty"`
 }
 
 // ── Cluster ──────────────────────────────────────────────────────────────────
@@ -564,8 +911,9 @@ type clusterStatusResponse struct {
 
 // clusterCreateRequest is the body for POST /api/cluster/create.
 type clusterCreateRequest struct {
-	Name    string `json:"name"              example:"My Cluster"`
-	GroupID string `json:"group_id,omitempty" example:"a1b2c3d4e5f6a1b2"`
+	Name       string `json:"name"                  example:"My Cluster"`
+	GroupID    string `json:"group_id,omitempty"    example:"a1b2c3d4e5f6a1b2"`
+	MaxMembers int    `json:"max_members,omitempty" example:"8"`
 }
 
 // clusterCreateResponse is the body for POST /api/cluster/create.
@@ -582,7 +930,7 @@ type clusterJoinRequest struct {
 
 // clusterSubmitRequest is the body for POST /api/cluster/submit.
 type clusterSubmitRequest struct {
-	Type     string         `json:"type"                example:"calculate"`
+	Type     string         `json:"type"                example:"calculate" binding:"required"`
 	Mode     string         `json:"mode,omitempty"      example:"oneshot"`
 	Payload  map[string]any `json:"payload,omitempty"`
 	Priority int            `json:"priority,omitempty"  example:"5"`
@@ -598,7 +946,7 @@ type clusterSubmitResponse struct {
 
 // clusterCancelRequest is the body for POST /api/cluster/cancel.
 type clusterCancelRequest struct {
-	JobID string `json:"job_id" example:"j-abc123"`
+	JobID string `json:"job_id" example:"j-abc123" binding:"required"`
 }
 
 // swagClusterStatus is a documentation stub for GET /api/cluster/status.
@@ -677,7 +1025,7 @@ func swagClusterCancel() {}
 
 // clusterDeleteRequest is the body for POST /api/cluster/delete.
 type clusterDeleteRequest struct {
-	JobID string `json:"job_id" example:"j-abc123"`
+	JobID string `json:"job_id" example:"j-abc123" binding:"required"`
 }
 
 // swagClusterDelete is a documentation stub for POST /api/cluster/delete.
@@ -746,7 +1094,7 @@ func swagClusterTypes() {}
 
 // clusterBinaryRequest is the body for POST /api/cluster/binary.
 type clusterBinaryRequest struct {
-	Path string `json:"path" example:"/usr/bin/renderer"`
+	Path string `json:"path" example:"/usr/bin/renderer" binding:"required"`
 	Mode string `json:"mode" example:"oneshot"`
 }
 
@@ -795,7 +1143,7 @@ func swagClusterResume() {}
 
 // clusterWorkerPeerRequest is the body for POST /api/cluster/worker/pause and /resume.
 type clusterWorkerPeerRequest struct {
-	PeerID string `json:"peer_id" example:"12D3KooWXxx..."`
+	PeerID string `json:"peer_id" example:"12D3KooWXxx..." binding:"required"`
 }
 
 // swagClusterWorkerPause is a documentation stub for POST /api/cluster/worker/pause.
@@ -831,7 +1179,7 @@ func swagClusterWorkerResume() {}
 // swagMQSend is a documentation stub for POST /api/mq/send.
 //
 //	@Summary	Send an MQ message to a peer
-//	@Description	Delivers the payload to the remote peer over the MQ P2P protocol.\nBlocks until the peer sends a transport ACK (up to 4 s, one retry).\nTopic convention: call:{channelId}, group:{groupId}:{type}, group.invite, chat, chat.broadcast, identity, identity.response
+//	@Description	Delivers the payload to the remote peer over the MQ P2P protocol.\nBlocks until the peer sends a transport ACK (up to 4 s, one retry).\nWith queue set, a message for an unreachable peer (or one with older messages still queued) is stored in the outbox instead and status is "queued"; it is delivered when the peer becomes reachable.\nTopic convention: call:{channelId}, group:{groupId}:{type}, group.invite, chat, chat.broadcast, identity, identity.response
 //	@Tags		mq
 //	@Accept		json
 //	@Produce	json
@@ -857,13 +1205,50 @@ func swagMQAck() {}
 // swagMQEvents is a documentation stub for GET /api/mq/events.
 //
 //	@Summary	SSE stream — incoming MQ messages and delivery receipts
-//	@Description	THE single browser event stream. Every incoming P2P MQ message and every PublishLocal event arrives here.\nEvent frames are SSE 'message' events with JSON body: {type, msg:{id,seq,topic,payload}, from}.\nKeep-alive: the stream never closes unless the server shuts down or the browser disconnects.
+//	@Description	THE single browser event stream. Every incoming P2P MQ message and every PublishLocal event arrives here.\nEvent frames are SSE 'message' events with JSON body: {type, msg:{id,seq,topic,payload}, from}.\nKeep-alive: the stream never closes unless the server shuts down or the browser disconnects.\nEach journaled frame carries an SSE id; reconnect with Last-Event-ID (or ?since=) to replay missed events. A {type:"reset"} frame means the journal no longer reaches back that far and the client should resync.
 //	@Tags		mq
 //	@Produce	text/event-stream
+//	@Param		Last-Event-ID	header		string	false	"Last event ID seen (resume)"
+//	@Param		since			query		int		false	"Last event ID seen, for clients that cannot set headers"
+//	@Param		topics			query		string	false	"Comma-separated topic patterns; only matching messages are sent. + matches one :-separated segment, a final * the rest (call:*, group:+:state)"
 //	@Success	200	{string}	string	"SSE stream"
+//	@Failure	400	{string}	string	"Invalid topic pattern"
 //	@Router		/api/mq/events [get]
 func swagMQEvents() {}
 
+// swagMQMetrics is a documentation stub for GET /api/mq/metrics.
+//
+//	@Summary	MQ delivery metrics per topic family (sent, delivered, timeouts, ack latency) and priority lane queue depths
+//	@Tags		mq
+//	@Produce	json
+//	@Success	200	{object}	mq.Metrics
+//	@Router		/api/mq/metrics [get]
+func swagMQMetrics() {}
+
+// swagMQOutbox is a documentation stub for GET /api/mq/outbox.
+//
+//	@Summary	Messages queued for peers that could not be reached
+//	@Description	Store-and-forward outbox, oldest first. Entries are retried with backoff, sent as soon as their peer becomes reachable and dropped when expires_at passes.
+//	@Tags		mq
+//	@Produce	json
+//	@Param		peer_id	query		string	false	"Only this peer's messages"
+//	@Success	200		{array}		mqOutboxEntry
+//	@Failure	503		{string}	string	"outbox not enabled"
+//	@Router		/api/mq/outbox [get]
+func swagMQOutbox() {}
+
+// swagMQOutboxPurge is a documentation stub for POST /api/mq/outbox/purge.
+//
+//	@Summary	Drop queued messages
+//	@Tags		mq
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		mqOutboxPurgeRequest	true	"Peer to purge, empty for all"
+//	@Success	200		{object}	mqOutboxPurgeResponse
+//	@Failure	503		{string}	string	"outbox not enabled"
+//	@Router		/api/mq/outbox/purge [post]
+func swagMQOutboxPurge() {}
+
 // ── Call ─────────────────────────────────────────────────────────────────────
 
 // swagCallMode is a documentation stub for GET /api/call/mode.
@@ -930,6 +1315,195 @@ func swagCallAccept() {}
 //	@Router		/api/call/hangup [post]
 func swagCallHangup() {}
 
+// swagCallHold is a documentation stub for POST /api/call/hold.
+//
+//	@Summary	Put a call on hold (native mode)
+//	@Description	Sends call-hold to the remote peer.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callChannelRequest	true	"Channel"
+//	@Success	200		{object}	callHoldResponse
+//	@Failure	404		{string}	string	"session not found"
+//	@Router		/api/call/hold [post]
+func swagCallHold() {}
+
+// swagCallResume is a documentation stub for POST /api/call/resume.
+//
+//	@Summary	Resume a held call (native mode)
+//	@Description	Sends call-resume to the remote peer.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callChannelRequest	true	"Channel"
+//	@Success	200		{object}	callHoldResponse
+//	@Failure	404		{string}	string	"session not found"
+//	@Router		/api/call/resume [post]
+func swagCallResume() {}
+
+// swagCallTransfer is a documentation stub for POST /api/call/transfer.
+//
+//	@Summary	Transfer a call to another peer (native mode)
+//	@Description	Blind transfer: the remote peer is sent call-transfer with the target and dials it; this session hangs up.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callTransferRequest	true	"Channel and target"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"session not found"
+//	@Router		/api/call/transfer [post]
+func swagCallTransfer() {}
+
+// swagCallChat is a documentation stub for POST /api/call/chat.
+//
+//	@Summary	Send an in-call chat message (native mode)
+//	@Description	Sent on the session's data channel and stored in chat history tagged with the call ID.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callChatRequest	true	"Channel and text"
+//	@Success	200		{object}	callDataMessage
+//	@Failure	404		{string}	string	"session not found"
+//	@Failure	409		{string}	string	"data channel not open"
+//	@Router		/api/call/chat [post]
+func swagCallChat() {}
+
+// swagCallFile is a documentation stub for POST /api/call/file.
+//
+//	@Summary	Drop a small file into the call (native mode)
+//	@Description	At most 4 MiB; chunked over the session's data channel. Chat history records the name and size only.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callFileRequest	true	"Channel and base64 file"
+//	@Success	200		{object}	callDataMessage
+//	@Failure	404		{string}	string	"session not found"
+//	@Failure	413		{string}	string	"file too large"
+//	@Router		/api/call/file [post]
+func swagCallFile() {}
+
+// swagCallCaptions is a documentation stub for POST /api/call/captions.
+//
+//	@Summary	Turn live captions on or off for a call (native mode)
+//	@Description	Received audio is transcribed locally by viewer.caption_command; captions arrive as call-caption signals on call:{channel}.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callCaptionsRequest	true	"Channel, toggle and language (empty = auto)"
+//	@Success	200		{object}	map[string]any	"enabled, language"
+//	@Failure	404		{string}	string	"session not found"
+//	@Failure	409		{string}	string	"captions not configured"
+//	@Router		/api/call/captions [post]
+func swagCallCaptions() {}
+
+// swagCallDevices is a documentation stub for GET /api/call/devices.
+//
+//	@Summary	List local cameras and mics (native mode)
+//	@Tags		call
+//	@Produce	json
+//	@Success	200	{object}	callDevices
+//	@Router		/api/call/devices [get]
+func swagCallDevices() {}
+
+// swagCallDevice is a documentation stub for POST /api/call/device.
+//
+//	@Summary	Switch camera or mic mid-call (native mode)
+//	@Description	The new capture track replaces the old one without renegotiation. Empty channel_id switches every active call; empty device_id picks any device.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callDeviceRequest	true	"Channel, kind (video|audio) and device"
+//	@Success	200		{object}	statusOK
+//	@Failure	400		{string}	string	"bad kind"
+//	@Failure	404		{string}	string	"session not found"
+//	@Failure	409		{string}	string	"switch failed"
+//	@Router		/api/call/device [post]
+func swagCallDevice() {}
+
+// swagCallAudioProcessingGet is a documentation stub for GET /api/call/audio-processing.
+//
+//	@Summary	Get mic noise and echo suppression state (native mode)
+//	@Tags		call
+//	@Produce	json
+//	@Success	200	{object}	callAudioProcessing
+//	@Router		/api/call/audio-processing [get]
+func swagCallAudioProcessingGet() {}
+
+// swagCallAudioProcessingSet is a documentation stub for POST /api/call/audio-processing.
+//
+//	@Summary	Toggle mic noise and echo suppression (native mode)
+//	@Description	Processing runs on the captured mic between capture and Opus encoding (Linux native capture). Changes apply to calls in progress.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callAudioProcessing	true	"Toggles to change"
+//	@Success	200		{object}	callAudioProcessing
+//	@Router		/api/call/audio-processing [post]
+func swagCallAudioProcessingSet() {}
+
+// swagCallCallback is a documentation stub for POST /api/call/callback.
+//
+//	@Summary	Ask a busy peer to call back (native mode)
+//	@Description	Sends call-callback on a channel that was answered with call-busy.
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callChannelRequest	true	"Channel"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/call/callback [post]
+func swagCallCallback() {}
+
+// swagCallCallbacks is a documentation stub for GET /api/call/callbacks.
+//
+//	@Summary	List callback requests (native mode)
+//	@Description	Peers that called while we were busy and asked to be called back, oldest first.
+//	@Tags		call
+//	@Produce	json
+//	@Success	200	{array}	callCallback
+//	@Router		/api/call/callbacks [get]
+func swagCallCallbacks() {}
+
+// swagCallCallbacksDismiss is a documentation stub for POST /api/call/callbacks/dismiss.
+//
+//	@Summary	Dismiss callback requests (native mode)
+//	@Tags		call
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		callbackDismissRequest	true	"Peer (empty = all)"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/call/callbacks/dismiss [post]
+func swagCallCallbacksDismiss() {}
+
+// swagCallHistory is a documentation stub for GET /api/call/history.
+//
+//	@Summary	Call history (newest first)
+//	@Description	Incoming and outgoing calls with their outcome, plus the number of missed calls not yet marked seen.
+//	@Tags		call
+//	@Produce	json
+//	@Param		peer_id	query		string	false	"Only calls with this peer"
+//	@Param		limit	query		int		false	"Maximum entries (default 100)"
+//	@Success	200		{object}	map[string]any	"calls: []storage.CallLogEntry, missed_unseen: int"
+//	@Router		/api/call/history [get]
+func swagCallHistory() {}
+
+// swagCallHistorySeen is a documentation stub for POST /api/call/history/seen.
+//
+//	@Summary	Mark all missed calls as seen
+//	@Tags		call
+//	@Produce	json
+//	@Success	200	{object}	statusOK
+//	@Router		/api/call/history/seen [post]
+func swagCallHistorySeen() {}
+
+// swagCallHistoryClear is a documentation stub for POST /api/call/history/clear.
+//
+//	@Summary	Delete the call history
+//	@Tags		call
+//	@Produce	json
+//	@Success	200	{object}	statusOK
+//	@Router		/api/call/history/clear [post]
+func swagCallHistoryClear() {}
+
 // swagCallToggleAudio is a documentation stub for POST /api/call/toggle-audio.
 //
 //	@Summary	Toggle local audio track mute (native mode)
@@ -1148,6 +1722,71 @@ func swagGroupsLeave() {}
 //	@Router		/api/groups/kick [post]
 func swagGroupsKick() {}
 
+// swagGroupsCoHost is a documentation stub for POST /api/groups/cohost.
+//
+//	@Summary	Authorize a member to co-host (relay) a hosted group
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		groupPeerRequest	true	"Co-host request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/groups/cohost [post]
+func swagGroupsCoHost() {}
+
+// swagGroupsCoHostRemove is a documentation stub for POST /api/groups/cohost/remove.
+//
+//	@Summary	Withdraw a co-host's authorization to relay a hosted group
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		groupPeerRequest	true	"Co-host request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/groups/cohost/remove [post]
+func swagGroupsCoHostRemove() {}
+
+// swagGroupsTransfer is a documentation stub for POST /api/groups/transfer.
+//
+//	@Summary	Hand a hosted group over to one of its members
+//	@Description	The member becomes the owner once it confirms; the other members follow it.
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		groupPeerRequest	true	"Transfer request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/groups/transfer [post]
+func swagGroupsTransfer() {}
+
+// swagGroupsOwnership is a documentation stub for GET /api/groups/ownership.
+//
+//	@Summary	Owner changes of a group, latest first
+//	@Tags		groups
+//	@Produce	json
+//	@Param		group_id	query		string	true	"Group ID"
+//	@Success	200			{array}		storage.OwnershipRecord
+//	@Router		/api/groups/ownership [get]
+func swagGroupsOwnership() {}
+
+// swagGroupsPresence is a documentation stub for GET /api/groups/presence.
+//
+//	@Summary	Online/offline status of every group member (separate from membership)
+//	@Tags		groups
+//	@Produce	json
+//	@Param		group_id	query		string	true	"Group ID"
+//	@Success	200			{array}		group.MemberPresence
+//	@Router		/api/groups/presence [get]
+func swagGroupsPresence() {}
+
+// swagGroupsUnmute is a documentation stub for POST /api/groups/unmute.
+//
+//	@Summary	Lift a flood-protection mute on a member of a hosted group
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		groupPeerRequest	true	"Unmute request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/groups/unmute [post]
+func swagGroupsUnmute() {}
+
 // swagGroupsMaxMembers is a documentation stub for POST /api/groups/max-members.
 //
 //	@Summary	Update max member limit for a hosted group
@@ -1203,6 +1842,17 @@ func swagGroupsSetRole() {}
 //	@Router		/api/groups/set-default-role [post]
 func swagGroupsSetDefaultRole() {}
 
+// swagGroupsOrdered is a documentation stub for POST /api/groups/ordered.
+//
+//	@Summary	Turn ordered delivery on or off for a hosted group
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		groupOrderedRequest	true	"Ordered request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/groups/ordered [post]
+func swagGroupsOrdered() {}
+
 // swagGroupsSetRolesList is a documentation stub for POST /api/groups/set-roles.
 //
 //	@Summary	Set the available roles for a hosted group
@@ -1248,7 +1898,8 @@ func swagListenClose() {}
 
 // swagListenLoad is a documentation stub for POST /api/listen/load.
 //
-//	@Summary	Load MP3 file(s) as playlist (local access only)
+//	@Summary	Load audio file(s) as playlist (local access only)
+//	@Description	Accepts MP3, FLAC, Ogg Vorbis, AAC (ADTS) and M4A files, detected from their first bytes, and HTTP stream URLs.
 //	@Tags		listen
 //	@Accept		json
 //	@Produce	json
@@ -1299,17 +1950,90 @@ func swagListenJoin() {}
 //	@Router		/api/listen/leave [post]
 func swagListenLeave() {}
 
-// swagListenStream is a documentation stub for GET /api/listen/stream.
+// swagListenRequest is a documentation stub for POST /api/listen/request.
 //
-//	@Summary	Live MP3 audio stream
-//	@Description	Chunked streaming response (audio/mpeg). Connect an HTML audio element src directly to this URL.
+//	@Summary	Listener requests a track from the host
+//	@Description	track is an audio file name (no path) or an http(s) stream URL. The host sees it under /api/listen/requests, or queues it at once in jukebox mode.
 //	@Tags		listen
-//	@Produce	audio/mpeg
-//	@Success	200	{string}	string	"Chunked audio stream"
-//	@Router		/api/listen/stream [get]
-func swagListenStream() {}
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		listenTrackRequestBody	true	"Track request"
+//	@Success	200		{object}	statusOK
+//	@Failure	400		{string}	string	"Invalid track or not a listener"
+//	@Router		/api/listen/request [post]
+func swagListenRequest() {}
 
-// swagListenState is a documentation stub for GET /api/listen/state.
+// swagListenReport is a documentation stub for POST /api/listen/report.
+//
+//	@Summary	Listener reports its playback position
+//	@Description	elapsed is the seconds of audio played since the audio stream started. The host compares it with its own position and resyncs listeners that drifted too far.
+//	@Tags		listen
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		listenReportRequest	true	"Playback position"
+//	@Success	200		{object}	statusOK
+//	@Failure	400		{string}	string	"Invalid position or not a listener"
+//	@Router		/api/listen/report [post]
+func swagListenReport() {}
+
+// swagListenRequests is a documentation stub for GET /api/listen/requests.
+//
+//	@Summary	Host's pending listener requests
+//	@Tags		listen
+//	@Produce	json
+//	@Success	200	{object}	listenRequestsResponse
+//	@Router		/api/listen/requests [get]
+func swagListenRequests() {}
+
+// swagListenRequestsApprove is a documentation stub for POST /api/listen/requests/approve.
+//
+//	@Summary	Queue a listener request (local access only)
+//	@Description	A stream URL is queued as is. A file name is matched in the folders of the queued tracks; file_path picks the file when it is not found there.
+//	@Tags		listen
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		listenApproveRequest	true	"Approve request"
+//	@Success	200		{object}	statusOK
+//	@Failure	400		{string}	string	"No matching file"
+//	@Failure	404		{string}	string	"Unknown request"
+//	@Router		/api/listen/requests/approve [post]
+func swagListenRequestsApprove() {}
+
+// swagListenRequestsReject is a documentation stub for POST /api/listen/requests/reject.
+//
+//	@Summary	Drop a listener request
+//	@Tags		listen
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		listenRejectRequest	true	"Reject request"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"Unknown request"
+//	@Router		/api/listen/requests/reject [post]
+func swagListenRequestsReject() {}
+
+// swagListenJukebox is a documentation stub for POST /api/listen/jukebox.
+//
+//	@Summary	Turn jukebox mode on or off (local access only)
+//	@Description	In jukebox mode requests the host can resolve are queued as they arrive; the others still wait for approval.
+//	@Tags		listen
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		listenJukeboxRequest	true	"Jukebox request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/listen/jukebox [post]
+func swagListenJukebox() {}
+
+// swagListenStream is a documentation stub for GET /api/listen/stream.
+//
+//	@Summary	Live audio stream
+//	@Description	Chunked streaming response in the track's format (audio/mpeg, audio/flac, audio/ogg, audio/aac or audio/mp4). Connect an HTML audio element src directly to this URL.
+//	@Tags		listen
+//	@Produce	audio/mpeg,audio/flac,audio/ogg,audio/aac,audio/mp4
+//	@Success	200	{string}	string	"Chunked audio stream"
+//	@Router		/api/listen/stream [get]
+func swagListenStream() {}
+
+// swagListenState is a documentation stub for GET /api/listen/state.
 //
 //	@Summary	Current listen group state
 //	@Tags		listen
@@ -1351,7 +2075,7 @@ type chatRoomStateResponse struct {
 
 // chatRoomCreateRequest is the body for POST /api/chat/rooms/create.
 type chatRoomCreateRequest struct {
-	Name        string `json:"name"                  example:"General"`
+	Name        string `json:"name"                  example:"General" binding:"required"`
 	Description string `json:"description,omitempty" example:"A friendly chat room"`
 	Context     string `json:"context,omitempty"     example:"Clubhouse"`
 	MaxMembers  int    `json:"max_members,omitempty" example:"10"`
@@ -1359,7 +2083,7 @@ type chatRoomCreateRequest struct {
 
 // chatRoomGroupIDRequest is the body for POST /api/chat/rooms/close and /leave.
 type chatRoomGroupIDRequest struct {
-	GroupID string `json:"group_id" example:"1a2b3c4d5e6f"`
+	GroupID string `json:"group_id" example:"1a2b3c4d5e6f" binding:"required"`
 }
 
 // chatRoomJoinRequest is the body for POST /api/chat/rooms/join.
@@ -1440,17 +2164,70 @@ func swagChatRoomSend() {}
 //	@Router		/api/chat/rooms/state [get]
 func swagChatRoomState() {}
 
+// chatGroupBackfillRequest is the body for POST /api/chat/group/backfill.
+type chatGroupBackfillRequest struct {
+	GroupID string `json:"group_id"        example:"1a2b3c4d5e6f" binding:"required"`
+	Limit   int    `json:"limit,omitempty" example:"200"`
+}
+
+// swagChatGroupHistory is a documentation stub for GET /api/chat/group/history.
+//
+//	@Summary	Get the stored messages of a chat room
+//	@Description	Oldest first, at most the last 1000. Rooms that were left or closed keep their history.
+//	@Tags		chat-rooms
+//	@Produce	json
+//	@Param		group_id	query		string	true	"Group ID"
+//	@Param		limit		query		int		false	"Last N messages (default: all kept)"
+//	@Success	200			{array}		chatRoomMessage
+//	@Router		/api/chat/group/history [get]
+func swagChatGroupHistory() {}
+
+// swagChatGroupBackfill is a documentation stub for POST /api/chat/group/backfill.
+//
+//	@Summary	Ask the host of a joined chat room for its last messages
+//	@Description	The host sends up to limit (max 500) messages on the room's history topic; they are merged into the local history.
+//	@Tags		chat-rooms
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		chatGroupBackfillRequest	true	"Backfill request"
+//	@Success	200		{object}	statusOK
+//	@Failure	409		{string}	string	"not connected to the room"
+//	@Router		/api/chat/group/backfill [post]
+func swagChatGroupBackfill() {}
+
 // ── Peers ────────────────────────────────────────────────────────────────────
 
 // swagPeersList is a documentation stub for GET /api/peers.
 //
 //	@Summary	List all known peers with metadata
+//	@Description	Each row's Link says how the peer is reached right now: direct-lan, direct-wan, holepunch (direct after a hole punch), relay or none. With limit the list is filtered, sorted and paged on the server and returned as {peers, offset, limit, summary}; summary counts total, online, favorites, verified and matched peers, plus the max_tracked_peers capacity and evictions so far.
 //	@Tags		peers
 //	@Produce	json
+//	@Param		namespace	query	string	false	"Presence namespace to list (default: the global list)"
+//	@Param		limit	query	int	false	"Page size (0 = all, max 1000); turns on paging and summary counts"
+//	@Param		offset	query	int	false	"Rows to skip"
+//	@Param		q	query	string	false	"Case-insensitive substring of the label (or of the ID with by=id)"
+//	@Param		by	query	string	false	"name (default) or id"
+//	@Param		sort	query	string	false	"id (default), name or last_seen"
+//	@Param		favorites	query	int	false	"1 = favorites only"
+//	@Param		online	query	int	false	"1 = online peers only"
+//	@Param		verified	query	int	false	"1 = verified peers only"
 //	@Success	200	{array}	map[string]any
+//	@Failure	400	{string}	string	"bad sort or limit"
+//	@Failure	404	{string}	string	"unknown namespace"
 //	@Router		/api/peers [get]
 func swagPeersList() {}
 
+// swagPeersNamespaces is a documentation stub for GET /api/peers/namespaces.
+//
+//	@Summary	List joined presence namespaces
+//	@Description	The communities from presence.namespaces, each with its number of known and online peers. The global list is not included.
+//	@Tags		peers
+//	@Produce	json
+//	@Success	200	{array}	namespaceInfo
+//	@Router		/api/peers/namespaces [get]
+func swagPeersNamespaces() {}
+
 // swagSelf is a documentation stub for GET /api/self.
 //
 //	@Summary	This peer's own identity and metadata
@@ -1475,128 +2252,748 @@ type topologyPeer struct {
 	Label      string `json:"label"      example:"Roadwarrior"`
 	Reachable  bool   `json:"reachable"  example:"true"`
 	Connection string `json:"connection" example:"direct"`
+	Quality    string `json:"quality"    example:"direct-lan" enums:"direct-lan,direct-wan,holepunch,relay,none"`
 	Addr       string `json:"addr"       example:"/ip4/192.168.1.42/tcp/4001"`
 	Age        string `json:"age"        example:"3m24s"`
 	Streams    int    `json:"streams"    example:"5"`
 }
 
-// topologyNode describes self or relay in the topology response.
-type topologyNode struct {
-	ID         string `json:"id"                    example:"12D3KooWXxx..."`
-	Label      string `json:"label"                 example:"EggMan"`
-	HasCircuit bool   `json:"has_circuit,omitempty"  example:"true"`
-	Addr       string `json:"addr,omitempty"         example:"/ip4/1.2.3.4/tcp/4001"`
-}
+// topologyNode describes self or relay in the topology response.
+type topologyNode struct {
+	ID         string `json:"id"                    example:"12D3KooWXxx..."`
+	Label      string `json:"label"                 example:"EggMan"`
+	HasCircuit bool   `json:"has_circuit,omitempty"  example:"true"`
+	Addr       string `json:"addr,omitempty"         example:"/ip4/1.2.3.4/tcp/4001"`
+}
+
+// topologyResponse is the full topology payload.
+type topologyResponse struct {
+	Self  topologyNode    `json:"self"`
+	Relay *topologyNode   `json:"relay,omitempty"`
+	Peers []topologyPeer  `json:"peers"`
+}
+
+// swagTopology is a documentation stub for GET /api/topology.
+//
+//	@Summary	Network topology graph data
+//	@Description	Returns this peer's view of the network: self node, relay node (if configured), and all known peers with connection type (direct/relay/none) and link quality (direct-lan, direct-wan, holepunch, relay, none).
+//	@Tags		peers
+//	@Produce	json
+//	@Success	200	{object}	topologyResponse
+//	@Failure	503	{string}	string	"no p2p node"
+//	@Router		/api/topology [get]
+func swagTopology() {}
+
+// swagPeersFavorite is a documentation stub for POST /api/peers/favorite.
+//
+//	@Summary	Toggle favorite flag for a peer
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		peerFavoriteRequest	true	"Favorite request"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/peers/favorite [post]
+func swagPeersFavorite() {}
+
+// swagPeersClock is a documentation stub for GET /api/peers/clock.
+//
+//	@Summary	Clock offsets to other peers
+//	@Description	Without peer, returns the cached estimates keyed by peer ID. With peer, measures the offset to that peer over /goop/time first. A peer's clock reads our clock plus offset_ms.
+//	@Tags		peers
+//	@Produce	json
+//	@Param		peer	query		string	false	"Peer ID to measure now"
+//	@Success	200		{object}	p2p.ClockEstimate
+//	@Failure	502		{string}	string	"Peer unreachable"
+//	@Router		/api/peers/clock [get]
+func swagPeersClock() {}
+
+// swagPeersNotes is a documentation stub for GET /api/peers/notes.
+//
+//	@Summary	Private notes about peers (local only)
+//	@Description	Without peer, returns every note, most recently edited first. With peer, returns that peer's note, with an empty body if there is none.
+//	@Tags		peers
+//	@Produce	json
+//	@Param		peer	query		string	false	"Peer ID"
+//	@Success	200		{object}	storage.PeerNote
+//	@Router		/api/peers/notes [get]
+func swagPeersNotes() {}
+
+// swagPeersNotesSet is a documentation stub for POST /api/peers/notes.
+//
+//	@Summary	Set the private note for a peer (local only)
+//	@Description	Markdown, up to 16 KiB. Notes never leave this peer. An empty body deletes the note.
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		peerNoteRequest	true	"Note"
+//	@Success	200		{object}	storage.PeerNote
+//	@Failure	400		{string}	string	"missing peer_id or note too long"
+//	@Router		/api/peers/notes [post]
+func swagPeersNotesSet() {}
+
+// swagPeersReports is a documentation stub for GET /api/peers/reports.
+//
+//	@Summary	Abuse reports I filed about peers (local only)
+//	@Description	Newest first. With peer, only the reports about that peer.
+//	@Tags		peers
+//	@Produce	json
+//	@Param		peer	query	string	false	"Peer ID"
+//	@Success	200		{array}	storage.PeerReport
+//	@Router		/api/peers/reports [get]
+func swagPeersReports() {}
+
+// swagPeersReportsFile is a documentation stub for POST /api/peers/reports.
+//
+//	@Summary	File an abuse report about a peer (local only)
+//	@Description	Reports stay on this peer until they go out in a reputation record (see /api/peers/reputation). Notes are up to 500 characters.
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		peerReportRequest	true	"Report"
+//	@Success	200		{object}	storage.PeerReport
+//	@Failure	400		{string}	string	"invalid peer ID, unknown reason or note too long"
+//	@Router		/api/peers/reports [post]
+func swagPeersReportsFile() {}
+
+// swagPeersReportsDelete is a documentation stub for POST /api/peers/reports/delete.
+//
+//	@Summary	Withdraw an abuse report (local only)
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		object{id int}	true	"Report ID"
+//	@Success	200		{object}	map[string]bool
+//	@Failure	404		{string}	string	"report not found"
+//	@Router		/api/peers/reports/delete [post]
+func swagPeersReportsDelete() {}
+
+// swagPeersReputation is a documentation stub for GET /api/peers/reputation.
+//
+//	@Summary	Signed reputation record about a peer (local only)
+//	@Description	What this peer saw of another over the last days (default 30, at most 90): the streams it opened, how many were refused, its MQ messages and their peak per minute from the audit log, and the abuse reports filed about it. Signed with this peer's identity key, so it can be shared and checked.
+//	@Tags		peers
+//	@Produce	json
+//	@Param		peer	query		string	true	"Peer ID"
+//	@Param		days	query		int		false	"Window in days"
+//	@Success	200		{object}	rendezvous.ReputationRecord
+//	@Failure	400		{string}	string	"invalid peer ID"
+//	@Router		/api/peers/reputation [get]
+func swagPeersReputation() {}
+
+// swagPeersReputationSubmit is a documentation stub for POST /api/peers/reputation/submit.
+//
+//	@Summary	Submit a reputation record to the rendezvous moderation queue (local only)
+//	@Description	Builds and signs the record as GET /api/peers/reputation does and posts it to every rendezvous, where moderators see it in the admin Reports tab. A newer record about the same peer replaces the older.
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		reputationSubmitRequest	true	"Peer and window"
+//	@Success	200		{object}	map[string]interface{}	"submitted: int, errors: []string (per rendezvous), record: rendezvous.ReputationRecord"
+//	@Failure	409		{string}	string	"no rendezvous configured"
+//	@Failure	502		{string}	string	"no rendezvous accepted the record"
+//	@Router		/api/peers/reputation/submit [post]
+func swagPeersReputationSubmit() {}
+
+// swagDigest is a documentation stub for GET /api/digest.
+//
+//	@Summary	Email digest subscription (local only)
+//	@Description	Reads this peer's digest subscription from the rendezvous. available is false, with an error, when the email is not verified or no rendezvous offers digests.
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	map[string]interface{}
+//	@Router		/api/digest [get]
+func swagDigest() {}
+
+// swagDigestSet is a documentation stub for POST /api/digest.
+//
+//	@Summary	Change the email digest subscription (local only)
+//	@Description	frequency is off, daily or weekly. With favorites, new listen stations of your favorite peers are included; their peer IDs are sent to the rendezvous.
+//	@Tags		settings
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		digestRequest	true	"Preferences"
+//	@Success	200		{object}	rendezvous.DigestStatus
+//	@Failure	409		{string}	string	"email not verified"
+//	@Failure	502		{string}	string	"no rendezvous accepted the change"
+//	@Router		/api/digest [post]
+func swagDigestSet() {}
+
+// swagSchedule is a documentation stub for GET /api/schedule.
+//
+//	@Summary	Scheduled listen sessions and group events (local only)
+//	@Tags		groups
+//	@Produce	json
+//	@Param		group	query		string	false	"Only this group's events"
+//	@Success	200		{array}		storage.ScheduledSession
+//	@Router		/api/schedule [get]
+func swagSchedule() {}
+
+// swagScheduleSave is a documentation stub for POST /api/schedule.
+//
+//	@Summary	Create or update a scheduled session (local only)
+//	@Description	id 0 creates. kind is listen or group; group events need a group you host. starts_at is Unix ms.
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		storage.ScheduledSession	true	"Session"
+//	@Success	200		{object}	storage.ScheduledSession
+//	@Failure	400		{string}	string	"invalid session"
+//	@Router		/api/schedule [post]
+func swagScheduleSave() {}
+
+// swagScheduleDelete is a documentation stub for POST /api/schedule/delete.
+//
+//	@Summary	Delete a scheduled session (local only)
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		scheduleDeleteRequest	true	"Session"
+//	@Success	200		{object}	map[string]bool
+//	@Router		/api/schedule/delete [post]
+func swagScheduleDelete() {}
+
+// swagScheduleFeeds is a documentation stub for GET /api/schedule/feeds.
+//
+//	@Summary	Issued calendar feed tokens (local only)
+//	@Tags		groups
+//	@Produce	json
+//	@Success	200	{array}	calendarFeedView
+//	@Router		/api/schedule/feeds [get]
+func swagScheduleFeeds() {}
+
+// swagScheduleFeedCreate is a documentation stub for POST /api/schedule/feeds.
+//
+//	@Summary	Issue a calendar feed token (local only)
+//	@Description	An empty group_id issues the feed of every scheduled session. Issuing again replaces the previous token, so old links stop working.
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		calendarFeedRequest	true	"Feed"
+//	@Success	200		{object}	calendarFeedView
+//	@Router		/api/schedule/feeds [post]
+func swagScheduleFeedCreate() {}
+
+// swagScheduleFeedRevoke is a documentation stub for POST /api/schedule/feeds/revoke.
+//
+//	@Summary	Revoke a calendar feed (local only)
+//	@Tags		groups
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		calendarFeedRequest	true	"Feed"
+//	@Success	200		{object}	map[string]bool
+//	@Router		/api/schedule/feeds/revoke [post]
+func swagScheduleFeedRevoke() {}
+
+// swagCalendarFeed is a documentation stub for GET /calendar/{token}.ics.
+//
+//	@Summary	ICS calendar feed
+//	@Description	iCalendar feed of scheduled sessions. The token is the only credential; unknown or revoked tokens get 404. Also reachable through the remote listener without pairing.
+//	@Tags		groups
+//	@Produce	text/calendar
+//	@Param		token	path		string	true	"Feed token"
+//	@Success	200		{string}	string	"VCALENDAR"
+//	@Failure	404		{string}	string	"unknown token"
+//	@Router		/calendar/{token}.ics [get]
+func swagCalendarFeed() {}
+
+// swagPeerContent is a documentation stub for GET /api/peer/content.
+//
+//	@Summary	Fetch a remote peer's site content (HTML string)
+//	@Tags		peers
+//	@Produce	json
+//	@Param		id	query		string	true	"Peer ID"
+//	@Success	200	{object}	peerContentResponse
+//	@Router		/api/peer/content [get]
+func swagPeerContent() {}
+
+// ── Settings ─────────────────────────────────────────────────────────────────
+
+// swagSettingsQuick is a documentation stub for POST /api/settings/quick.
+//
+//	@Summary	Partial settings update — only provided (non-null) fields are written
+//	@Tags		settings
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		quickSettingsRequest	true	"Partial settings"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/settings/quick [post]
+func swagSettingsQuick() {}
+
+// swagSettingsQuickGet is a documentation stub for GET /api/settings/quick/get.
+//
+//	@Summary	Read current quick settings (label, email, theme, device prefs, flags)
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	quickSettingsResponse
+//	@Router		/api/settings/quick/get [get]
+func swagSettingsQuickGet() {}
+
+// swagConfigValidate is a documentation stub for GET /api/config/validate.
+//
+//	@Summary	Validate goop.json
+//	@Description	Every problem in the config: JSON errors, invalid values (errors, which keep the peer from starting) and unknown keys with a suggested spelling or likely mistakes (warnings).
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	configValidation
+//	@Router		/api/config/validate [get]
+func swagConfigValidate() {}
+
+// swagConfigValidatePost is a documentation stub for POST /api/config/validate.
+//
+//	@Summary	Validate a config document
+//	@Description	Checks the posted goop.json content the same way, without writing it.
+//	@Tags		settings
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	configValidation
+//	@Router		/api/config/validate [post]
+func swagConfigValidatePost() {}
+
+// swagConfigPresets is a documentation stub for GET /api/config/presets.
+//
+//	@Summary	List deployment presets
+//	@Description	Named combinations of ports, discovery, relay and service settings: desktop, public-rendezvous, lan-kiosk and headless-bot.
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{array}	config.Preset
+//	@Router		/api/config/presets [get]
+func swagConfigPresets() {}
+
+// swagConfigPreset is a documentation stub for POST /api/config/preset.
+//
+//	@Summary	Apply a deployment preset
+//	@Description	Rewrites the settings the preset controls and keeps everything else. Takes effect after a restart.
+//	@Tags		settings
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		object{name string}	true	"Preset name"
+//	@Success	200		{object}	object{status string, preset string, restart_required bool}
+//	@Failure	400		{string}	string	"unknown preset"
+//	@Router		/api/config/preset [post]
+func swagConfigPreset() {}
+
+// swagServicesHealth is a documentation stub for GET /api/services/health.
+//
+//	@Summary	Ping all configured external services
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	servicesHealthResponse
+//	@Router		/api/services/health [get]
+func swagServicesHealth() {}
+
+// swagHealth is a documentation stub for GET /api/health.
+//
+//	@Summary	Startup checks
+//	@Description	What the startup doctor found when this peer started: whether the previous run shut down cleanly, and per check (database, group-state, listen-queue, docs-cache) whether it was fine, repaired, failed or skipped. Slow checks only run after an unclean shutdown.
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	healthReport
+//	@Router		/api/health [get]
+func swagHealth() {}
+
+// swagStartup is a documentation stub for GET /api/startup.
+//
+//	@Summary	Startup stages
+//	@Description	The stages this process went through while starting (relay discovery, P2P node, database, services, viewer, and the rendezvous server when hosted), each with its start and finish time, duration and any error. A stage still running reports its duration so far.
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	startupReport
+//	@Router		/api/startup [get]
+func swagStartup() {}
+
+// swagJobs is a documentation stub for GET /api/jobs.
+//
+//	@Summary	Background job queue
+//	@Description	Counters of the queue that runs cache writes, identity requests, avatar warming and service registrations on a fixed set of workers, and the dead letters: jobs that failed their last attempt or were refused by a full queue, newest first.
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	jobs.Report
+//	@Router		/api/jobs [get]
+func swagJobs() {}
+
+// swagJobsClear is a documentation stub for POST /api/jobs/clear.
+//
+//	@Summary	Empty the dead-letter list of the job queue
+//	@Tags		settings
+//	@Produce	json
+//	@Success	200	{object}	jobsClearResponse
+//	@Router		/api/jobs/clear [post]
+func swagJobsClear() {}
+
+// swagServicesCheck is a documentation stub for GET /api/services/check.
+//
+//	@Summary	Check a single service URL (pre-save validation)
+//	@Tags		settings
+//	@Produce	json
+//	@Param		url		query		string	true	"Service base URL"
+//	@Param		type	query		string	false	"Service type: registration, credits, email, templates"
+//	@Success	200		{object}	serviceHealthEntry
+//	@Router		/api/services/check [get]
+func swagServicesCheck() {}
+
+// ── Logs ─────────────────────────────────────────────────────────────────────
+
+// swagOpenAPISpec is a documentation stub for GET /api/openapi.json.
+//
+//	@Summary	This OpenAPI 3.0 spec (generated by swaggo/swag)
+//	@Tags		logs
+//	@Produce	application/json
+//	@Success	200	{object}	map[string]any
+//	@Router		/api/openapi.json [get]
+func swagOpenAPISpec() {}
+
+// swagLogsSnapshot is a documentation stub for GET /api/logs.
+//
+//	@Summary	Snapshot of recent Go process log lines
+//	@Tags		logs
+//	@Produce	json
+//	@Success	200	{array}	map[string]string
+//	@Router		/api/logs [get]
+func swagLogsSnapshot() {}
+
+// swagLogsStream is a documentation stub for GET /api/logs/stream.
+//
+//	@Summary	SSE stream of new Go process log lines
+//	@Tags		logs
+//	@Produce	text/event-stream
+//	@Success	200	{string}	string	"SSE stream"
+//	@Router		/api/logs/stream [get]
+func swagLogsStream() {}
+
+// swagSecurityAudit is a documentation stub for GET /api/security/audit.
+//
+//	@Summary	Audit log of inbound P2P streams (newest first)
+//	@Tags		security
+//	@Produce	json
+//	@Param		peer_id		query		string	false	"Filter by remote peer ID"
+//	@Param		protocol	query		string	false	"Filter by protocol ID"
+//	@Param		outcome		query		string	false	"Filter by outcome (allowed, denied)"
+//	@Param		since		query		int		false	"Only entries at or after this Unix ms"
+//	@Param		until		query		int		false	"Only entries at or before this Unix ms"
+//	@Param		limit		query		int		false	"Maximum entries (default 200)"
+//	@Success	200			{array}		storage.AuditEntry
+//	@Router		/api/security/audit [get]
+func swagSecurityAudit() {}
+
+// swagSecurityConsent is a documentation stub for GET /api/security/consent.
+//
+//	@Summary	Pending access prompts and remembered consent decisions
+//	@Tags		security
+//	@Produce	json
+//	@Success	200	{object}	map[string]any	"pending: []p2p.ConsentRequest, decisions: []storage.AccessConsent"
+//	@Router		/api/security/consent [get]
+func swagSecurityConsent() {}
+
+// swagSecurityConsentDecide is a documentation stub for POST /api/security/consent.
+//
+//	@Summary	Answer a parked inbound docs/data access prompt
+//	@Tags		security
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		consentDecisionRequest	true	"Decision"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/security/consent [post]
+func swagSecurityConsentDecide() {}
+
+// swagSecurityConsentForget is a documentation stub for POST /api/security/consent/forget.
+//
+//	@Summary	Forget a remembered consent decision so the peer is prompted again
+//	@Tags		security
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		consentForgetRequest	true	"Peer"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/security/consent/forget [post]
+func swagSecurityConsentForget() {}
+
+// swagActionsList is a documentation stub for GET /api/actions.
+//
+//	@Summary	Actions registry: every invocable operation with its parameters and permissions
+//	@Tags		actions
+//	@Produce	json
+//	@Success	200	{array}	actions.Action
+//	@Router		/api/actions [get]
+func swagActionsList() {}
+
+// swagActionsRun is a documentation stub for POST /api/actions/run.
+//
+//	@Summary	Run a server-side action by ID (local only); returns the underlying route's response
+//	@Tags		actions
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		actionRunRequest	true	"Action and parameters"
+//	@Success	200		{object}	map[string]any
+//	@Failure	400		{string}	string	"bad params or client-only action"
+//	@Failure	404		{string}	string	"unknown action"
+//	@Router		/api/actions/run [post]
+func swagActionsRun() {}
+
+// swagPairStatus is a documentation stub for GET /api/pair.
+//
+//	@Summary	Pending pairing code, paired devices, guest sessions and the remote control URL (local only)
+//	@Tags		pairing
+//	@Produce	json
+//	@Success	200	{object}	map[string]any	"pending: pairing.Code, devices: []storage.PairedDevice, guests: []pairing.Guest, remote_url"
+//	@Router		/api/pair [get]
+func swagPairStatus() {}
+
+// swagPairStart is a documentation stub for POST /api/pair/start.
+//
+//	@Summary	Issue a short-lived pairing code (local only)
+//	@Tags		pairing
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		pairStartRequest	true	"Scopes to grant (empty = all)"
+//	@Success	200		{object}	map[string]any		"code, scopes, expires_at, remote_url"
+//	@Router		/api/pair/start [post]
+func swagPairStart() {}
+
+// swagPairRevoke is a documentation stub for POST /api/pair/revoke.
+//
+//	@Summary	Unpair a device (local only)
+//	@Tags		pairing
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		pairRevokeRequest	true	"Device"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"no such device"
+//	@Router		/api/pair/revoke [post]
+func swagPairRevoke() {}
+
+// swagPairGuest is a documentation stub for POST /api/pair/guest.
+//
+//	@Summary	Open a read-only guest session (local only)
+//	@Description	The guest link works on the remote control listener until it expires (default 30 minutes, at most 8 hours) or is revoked. Guests can only GET the peers list, avatars, listen state and stream, and site previews.
+//	@Tags		pairing
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		pairGuestRequest	true	"Duration in minutes (0 = default)"
+//	@Success	200		{object}	map[string]any		"guest: pairing.Guest, url"
+//	@Failure	409		{string}	string				"remote control addr not set"
+//	@Router		/api/pair/guest [post]
+func swagPairGuest() {}
+
+// swagPairGuestRevoke is a documentation stub for POST /api/pair/guest/revoke.
+//
+//	@Summary	End a guest session (local only)
+//	@Tags		pairing
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		pairRevokeRequest	true	"Guest session"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"no such guest session"
+//	@Router		/api/pair/guest/revoke [post]
+func swagPairGuestRevoke() {}
+
+// swagPairClaim is a documentation stub for POST /api/pair/claim.
+//
+//	@Summary	Trade a pairing code for a device token
+//	@Tags		pairing
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		pairClaimRequest	true	"Code and device name"
+//	@Success	200		{object}	map[string]any		"token, device"
+//	@Failure	403		{string}	string				"wrong or expired code"
+//	@Router		/api/pair/claim [post]
+func swagPairClaim() {}
+
+// swagExportSummary is a documentation stub for GET /api/export/summary.
+//
+//	@Summary	Row counts per data category and table (local only)
+//	@Tags		export
+//	@Produce	json
+//	@Success	200	{array}	map[string]any	"name, description, tables: [{table, rows}]"
+//	@Router		/api/export/summary [get]
+func swagExportSummary() {}
+
+// swagExportAll is a documentation stub for GET /api/export/all.
+//
+//	@Summary	Download everything this peer stores (local only)
+//	@Description	A zip with one self-describing JSON file per table (columns and rows), the config file and manifest.json.
+//	@Tags		export
+//	@Produce	application/zip
+//	@Success	200	{file}	binary
+//	@Router		/api/export/all [get]
+func swagExportAll() {}
+
+// swagExportWipe is a documentation stub for POST /api/export/wipe.
+//
+//	@Summary	Delete stored data by category, optionally for one peer only (local only)
+//	@Tags		export
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		dataWipeRequest	true	"Categories and optional peer"
+//	@Success	200		{object}	map[string]any	"deleted: {table: rows}"
+//	@Failure	400		{string}	string	"unknown category, or site data without a peer"
+//	@Router		/api/export/wipe [post]
+func swagExportWipe() {}
+
+// swagPeersForget is a documentation stub for POST /api/peers/forget.
+//
+//	@Summary	Delete everything stored about a peer (local only)
+//	@Description	Removes the peer from the peer table, drops its cached avatar and deletes its cached presence, favorite, note, followed site, consent decision, chat and call history and audit entries.
+//	@Tags		peers
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		peerForgetRequest	true	"Peer"
+//	@Success	200		{object}	map[string]any	"deleted: {table: rows}"
+//	@Router		/api/peers/forget [post]
+func swagPeersForget() {}
+
+// swagPeersRetention is a documentation stub for GET /api/peers/retention.
+//
+//	@Summary	Peer retention pruning stats
+//	@Tags		peers
+//	@Produce	json
+//	@Success	200	{object}	retention.Stats
+//	@Router		/api/peers/retention [get]
+func swagPeersRetention() {}
 
-// topologyResponse is the full topology payload.
-type topologyResponse struct {
-	Self  topologyNode    `json:"self"`
-	Relay *topologyNode   `json:"relay,omitempty"`
-	Peers []topologyPeer  `json:"peers"`
-}
+// swagPeersRetentionRun is a documentation stub for POST /api/peers/retention/run.
+//
+//	@Summary	Forget peers past peer_retention_days now (local only)
+//	@Tags		peers
+//	@Produce	json
+//	@Success	200	{object}	retention.Stats
+//	@Router		/api/peers/retention/run [post]
+func swagPeersRetentionRun() {}
 
-// swagTopology is a documentation stub for GET /api/topology.
+// swagPeersFollows is a documentation stub for GET /api/peers/follows.
 //
-//	@Summary	Network topology graph data
-//	@Description	Returns this peer's view of the network: self node, relay node (if configured), and all known peers with connection type (direct/relay/none).
+//	@Summary	Followed sites and the state of their local copies
 //	@Tags		peers
 //	@Produce	json
-//	@Success	200	{object}	topologyResponse
-//	@Failure	503	{string}	string	"no p2p node"
-//	@Router		/api/topology [get]
-func swagTopology() {}
+//	@Success	200	{array}	storage.SiteFollow
+//	@Router		/api/peers/follows [get]
+func swagPeersFollows() {}
 
-// swagPeersFavorite is a documentation stub for POST /api/peers/favorite.
+// swagPeersFollow is a documentation stub for POST /api/peers/follow.
 //
-//	@Summary	Toggle favorite flag for a peer
+//	@Summary	Follow a peer's site (local only)
+//	@Description	Keeps a local copy of the peer's site over /goop/site-sync/1.0.0, shown with a "last synced" banner while the peer is offline. The first sync starts right away; after that the copy is synced whenever the peer announces a new site hash.
 //	@Tags		peers
 //	@Accept		json
 //	@Produce	json
-//	@Param		body	body		peerFavoriteRequest	true	"Favorite request"
-//	@Success	200		{object}	statusOK
-//	@Router		/api/peers/favorite [post]
-func swagPeersFavorite() {}
+//	@Param		body	body		peerFollowRequest	true	"Peer"
+//	@Success	200		{object}	storage.SiteFollow
+//	@Failure	400		{string}	string	"invalid peer ID"
+//	@Router		/api/peers/follow [post]
+func swagPeersFollow() {}
 
-// swagPeerContent is a documentation stub for GET /api/peer/content.
+// swagPeersUnfollow is a documentation stub for POST /api/peers/unfollow.
 //
-//	@Summary	Fetch a remote peer's site content (HTML string)
+//	@Summary	Stop following a peer's site and delete its copy (local only)
 //	@Tags		peers
+//	@Accept		json
 //	@Produce	json
-//	@Param		id	query		string	true	"Peer ID"
-//	@Success	200	{object}	peerContentResponse
-//	@Router		/api/peer/content [get]
-func swagPeerContent() {}
-
-// ── Settings ─────────────────────────────────────────────────────────────────
+//	@Param		body	body		peerFollowRequest	true	"Peer"
+//	@Success	200		{object}	map[string]bool	"removed: whether the peer was followed"
+//	@Router		/api/peers/unfollow [post]
+func swagPeersUnfollow() {}
 
-// swagSettingsQuick is a documentation stub for POST /api/settings/quick.
+// swagPeersFollowsSync is a documentation stub for POST /api/peers/follows/sync.
 //
-//	@Summary	Partial settings update — only provided (non-null) fields are written
-//	@Tags		settings
+//	@Summary	Sync a followed site now (local only)
+//	@Tags		peers
 //	@Accept		json
 //	@Produce	json
-//	@Param		body	body		quickSettingsRequest	true	"Partial settings"
-//	@Success	200		{object}	statusOK
-//	@Router		/api/settings/quick [post]
-func swagSettingsQuick() {}
+//	@Param		body	body		peerFollowRequest	true	"Peer"
+//	@Success	200		{object}	storage.SiteFollow
+//	@Failure	502		{string}	string	"sync failed; the previous copy is kept"
+//	@Router		/api/peers/follows/sync [post]
+func swagPeersFollowsSync() {}
 
-// swagSettingsQuickGet is a documentation stub for GET /api/settings/quick/get.
+// swagRulesList is a documentation stub for GET /api/rules.
 //
-//	@Summary	Read current quick settings (label, email, theme, device prefs, flags)
-//	@Tags		settings
+//	@Summary	Automation rules with their last run (local only)
+//	@Tags		rules
 //	@Produce	json
-//	@Success	200	{object}	quickSettingsResponse
-//	@Router		/api/settings/quick/get [get]
-func swagSettingsQuickGet() {}
+//	@Success	200	{array}	rules.Rule
+//	@Router		/api/rules [get]
+func swagRulesList() {}
 
-// swagServicesHealth is a documentation stub for GET /api/services/health.
+// swagRulesSave is a documentation stub for POST /api/rules/save.
 //
-//	@Summary	Ping all configured external services
-//	@Tags		settings
+//	@Summary	Create (no id) or update an automation rule (local only)
+//	@Tags		rules
+//	@Accept		json
 //	@Produce	json
-//	@Success	200	{object}	servicesHealthResponse
-//	@Router		/api/services/health [get]
-func swagServicesHealth() {}
+//	@Param		body	body		rules.Rule	true	"Rule"
+//	@Success	200		{object}	rules.Rule
+//	@Failure	400		{string}	string	"invalid trigger or action"
+//	@Router		/api/rules/save [post]
+func swagRulesSave() {}
 
-// swagServicesCheck is a documentation stub for GET /api/services/check.
+// swagRulesDelete is a documentation stub for POST /api/rules/delete.
 //
-//	@Summary	Check a single service URL (pre-save validation)
-//	@Tags		settings
+//	@Summary	Delete an automation rule (local only)
+//	@Tags		rules
+//	@Accept		json
 //	@Produce	json
-//	@Param		url		query		string	true	"Service base URL"
-//	@Param		type	query		string	false	"Service type: registration, credits, email, templates"
-//	@Success	200		{object}	serviceHealthEntry
-//	@Router		/api/services/check [get]
-func swagServicesCheck() {}
+//	@Param		body	body		ruleIDRequest	true	"Rule"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"no such rule"
+//	@Router		/api/rules/delete [post]
+func swagRulesDelete() {}
 
-// ── Logs ─────────────────────────────────────────────────────────────────────
+// swagRulesTest is a documentation stub for POST /api/rules/test.
+//
+//	@Summary	Run a rule's action now, regardless of its trigger (local only)
+//	@Tags		rules
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		ruleIDRequest	true	"Rule"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"no such rule"
+//	@Failure	502		{string}	string	"the action failed"
+//	@Router		/api/rules/test [post]
+func swagRulesTest() {}
 
-// swagOpenAPISpec is a documentation stub for GET /api/openapi.json.
+// swagSecretsList is a documentation stub for GET /api/secrets.
 //
-//	@Summary	This OpenAPI 3.0 spec (generated by swaggo/swag)
-//	@Tags		logs
-//	@Produce	application/json
-//	@Success	200	{object}	map[string]any
-//	@Router		/api/openapi.json [get]
-func swagOpenAPISpec() {}
+//	@Summary	Secrets by name and version, without values (local only)
+//	@Tags		secrets
+//	@Produce	json
+//	@Success	200	{array}	secrets.Info
+//	@Router		/api/secrets [get]
+func swagSecretsList() {}
 
-// swagLogsSnapshot is a documentation stub for GET /api/logs.
+// swagSecretsSet is a documentation stub for POST /api/secrets/set.
 //
-//	@Summary	Snapshot of recent Go process log lines
-//	@Tags		logs
+//	@Summary		Store a secret; replacing a value bumps its version (local only)
+//	@Description	Config values of the form "secret:NAME" refer to it.
+//	@Tags			secrets
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		secretSetRequest	true	"Secret"
+//	@Success		200		{object}	secrets.Info
+//	@Failure		400		{string}	string	"invalid name or empty value"
+//	@Router			/api/secrets/set [post]
+func swagSecretsSet() {}
+
+// swagSecretsRotate is a documentation stub for POST /api/secrets/rotate.
+//
+//	@Summary	Replace a secret with a new random token (local only)
+//	@Tags		secrets
+//	@Accept		json
 //	@Produce	json
-//	@Success	200	{array}	map[string]string
-//	@Router		/api/logs [get]
-func swagLogsSnapshot() {}
+//	@Param		body	body		secretNameRequest	true	"Secret"
+//	@Success	200		{object}	secretRotated	"the new value, shown only here"
+//	@Failure	400		{string}	string	"invalid name"
+//	@Router		/api/secrets/rotate [post]
+func swagSecretsRotate() {}
 
-// swagLogsStream is a documentation stub for GET /api/logs/stream.
+// swagSecretsDelete is a documentation stub for POST /api/secrets/delete.
 //
-//	@Summary	SSE stream of new Go process log lines
-//	@Tags		logs
-//	@Produce	text/event-stream
-//	@Success	200	{string}	string	"SSE stream"
-//	@Router		/api/logs/stream [get]
-func swagLogsStream() {}
+//	@Summary	Delete a secret (local only)
+//	@Tags		secrets
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		secretNameRequest	true	"Secret"
+//	@Success	200		{object}	statusOK
+//	@Failure	404		{string}	string	"no such secret"
+//	@Router		/api/secrets/delete [post]
+func swagSecretsDelete() {}
 
 // swagLogsClient is a documentation stub for POST /api/logs/client.
 //
@@ -1643,16 +3040,65 @@ func swagDocsUpload() {}
 //	@Router		/api/docs/delete [post]
 func swagDocsDelete() {}
 
+// docsACLRequest is the body for POST /api/docs/acl.
+type docsACLRequest struct {
+	GroupID  string   `json:"group_id" example:"a1b2c3d4e5f6a1b2"`
+	Filename string   `json:"filename" example:"notes.pdf"`
+	Access   string   `json:"access"   example:"peers" enums:"group,peers,public"`
+	Peers    []string `json:"peers"    example:"12D3KooWXxx..."`
+}
+
+// swagDocsACL is a documentation stub for POST /api/docs/acl.
+//
+//	@Summary		Set who may fetch a shared file (local access only)
+//	@Description	"group" (the default) lets the group's members fetch the file, "peers" only the listed peers, "public" any peer that asks. Other peers' file lists leave out what they may not fetch.
+//	@Tags			docs
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		docsACLRequest	true	"File and access"
+//	@Success		200		{object}	files.ACL
+//	@Failure		400		{string}	string	"Bad access, peers or filename"
+//	@Failure		404		{string}	string	"No such file"
+//	@Router			/api/docs/acl [post]
+func swagDocsACL() {}
+
+// docsBrowseResponse is the reply of GET /api/docs/browse.
+type docsBrowseResponse struct {
+	GroupID string       `json:"group_id"`
+	Peers   []browsePeer `json:"peers"`
+	Slow    []string     `json:"slow"` // members still being fetched; ask /api/docs/browse/refresh
+}
+
 // swagDocsBrowse is a documentation stub for GET /api/docs/browse.
 //
-//	@Summary	Aggregate file lists from all group members (parallel fetch)
-//	@Tags		docs
-//	@Produce	json
-//	@Param		group_id	query		string	true	"Group ID"
-//	@Success	200			{object}	map[string]any
-//	@Router		/api/docs/browse [get]
+//	@Summary		Aggregate file lists from all group members
+//	@Description	Members are asked fastest first. The reply comes once all answered or after 2s; members not done by then have error "slow" and are listed in slow. With stream=1 the reply is a server-sent event stream instead: a "peer" event per member as it answers, then "done".
+//	@Tags			docs
+//	@Produce		json
+//	@Param			group_id	query		string	true	"Group ID"
+//	@Param			stream		query		string	false	"1 to stream members as they answer"
+//	@Success		200			{object}	docsBrowseResponse
+//	@Router			/api/docs/browse [get]
 func swagDocsBrowse() {}
 
+// docsBrowseRefreshRequest is the body for POST /api/docs/browse/refresh.
+type docsBrowseRefreshRequest struct {
+	GroupID string   `json:"group_id" example:"e933372f2147..."`
+	PeerIDs []string `json:"peer_ids"`
+}
+
+// swagDocsBrowseRefresh is a documentation stub for POST /api/docs/browse/refresh.
+//
+//	@Summary		Fetch the file lists of members a browse listed as slow
+//	@Description	Waits for the fetch still running for each member, or uses its answer from the last 30s. Peers that are not group members are left out.
+//	@Tags			docs
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		docsBrowseRefreshRequest	true	"Group and members"
+//	@Success		200		{object}	docsBrowseResponse
+//	@Router			/api/docs/browse/refresh [post]
+func swagDocsBrowseRefresh() {}
+
 // docsUploadLocalRequest is the body for POST /api/docs/upload-local.
 type docsUploadLocalRequest struct {
 	GroupID string `json:"group_id" example:"e933372f2147..."`
@@ -1674,15 +3120,73 @@ func swagDocsUploadLocal() {}
 // swagDocsDownload is a documentation stub for GET /api/docs/download.
 //
 //	@Summary	Download a file (local store or proxied from remote peer)
+//	@Description	From peers that speak /goop/docs/2.0.0 the file is fetched in chunks into a cache, reporting docs:download:<file>:progress MQ events; a download that broke off resumes when requested again. Range requests are honored for such files.
 //	@Tags		docs
 //	@Param		group_id	query	string	true	"Group ID"
 //	@Param		file		query	string	true	"Filename"
 //	@Param		peer_id		query	string	false	"Peer ID (empty = self)"
 //	@Param		inline		query	string	false	"Pass '1' for Content-Disposition: inline"
 //	@Success	200			{string}	string	"File content"
+//	@Failure	502			{string}	string	"Fetch from the peer failed"
 //	@Router		/api/docs/download [get]
 func swagDocsDownload() {}
 
+// swagSearchNetwork is a documentation stub for GET /api/search/network.
+//
+//	@Summary	Search reachable favorite and group peers
+//	@Description	Sends the query to every reachable favorite or group peer over /goop/search and merges their hits by score. Each peer answers only from what it exposes in p2p.search_expose. peers lists every peer asked, with status ok, error or timeout.
+//	@Tags		docs
+//	@Produce	json
+//	@Param		q		query		string	true	"Keywords (max 200 characters); all must match"
+//	@Param		limit	query		int		false	"Maximum results (default 20, max 50)"
+//	@Success	200		{object}	object{query string, results []networkHit, peers []networkPeer}
+//	@Failure	400		{string}	string	"missing or too long q"
+//	@Router		/api/search/network [get]
+func swagSearchNetwork() {}
+
+// swagPermalinkCreate is a documentation stub for POST /api/permalink.
+//
+//	@Summary	Create a content-addressed permalink to a peer page
+//	@Description	Fetches the page, hashes it and returns goop://<peer>/<hash>/<path> with its /permalink/ viewer path. With pin (local access only) a copy is kept so the link resolves after the page changes.
+//	@Tags		site
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		object{peer_id string, path string, pin bool}	true	"Page to link"
+//	@Success	200		{object}	object{link string, hash string, url string, pinned bool}
+//	@Failure	502		{string}	string	"page could not be fetched"
+//	@Router		/api/permalink [post]
+func swagPermalinkCreate() {}
+
+// swagPermalinkResolve is a documentation stub for GET /api/permalink/resolve.
+//
+//	@Summary	Check a permalink against the live page
+//	@Tags		site
+//	@Produce	json
+//	@Param		link	query		string	true	"goop:// permalink"
+//	@Success	200		{object}	permalinkResolution
+//	@Router		/api/permalink/resolve [get]
+func swagPermalinkResolve() {}
+
+// swagPermalinkPins is a documentation stub for GET /api/permalink/pins.
+//
+//	@Summary	List pinned page copies, newest first
+//	@Tags		site
+//	@Produce	json
+//	@Success	200	{array}	permalink.Pin
+//	@Router		/api/permalink/pins [get]
+func swagPermalinkPins() {}
+
+// swagPermalinkUnpin is a documentation stub for POST /api/permalink/unpin.
+//
+//	@Summary	Remove a pinned copy (local access only)
+//	@Tags		site
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		object{hash string}	true	"Content hash"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/permalink/unpin [post]
+func swagPermalinkUnpin() {}
+
 // ── Data ─────────────────────────────────────────────────────────────────────
 
 // swagDataTables is a documentation stub for GET /api/data/tables.
@@ -1694,6 +3198,16 @@ func swagDocsDownload() {}
 //	@Router		/api/data/tables [get]
 func swagDataTables() {}
 
+// swagDataReplicas is a documentation stub for GET /api/data/replicas.
+//
+//	@Summary	Replicas of template group hosts' shared tables
+//	@Description	Tables with a "group" policy are replicated to the members of the host's template group over /goop/data-sync/1.0.0. While a host is offline, /api/p/{peerID}/data answers from its replica with an X-Goop-Data-Copy header.
+//	@Tags		data
+//	@Produce	json
+//	@Success	200	{array}	datasync.ReplicaStatus
+//	@Router		/api/data/replicas [get]
+func swagDataReplicas() {}
+
 // swagDataTablesCreate is a documentation stub for POST /api/data/tables/create.
 //
 //	@Summary	Create a new table (classic or ORM schema format, auto-detected)
@@ -1732,7 +3246,7 @@ func swagDataQuery() {}
 
 // swagDataUpdate is a documentation stub for POST /api/data/update.
 //
-//	@Summary	Update rows in a table
+//	@Summary	Update a row by id
 //	@Tags		data
 //	@Accept		json
 //	@Produce	json
@@ -1743,7 +3257,7 @@ func swagDataUpdate() {}
 
 // swagDataDelete is a documentation stub for POST /api/data/delete.
 //
-//	@Summary	Delete rows from a table
+//	@Summary	Delete a row by id
 //	@Tags		data
 //	@Accept		json
 //	@Produce	json
@@ -1857,7 +3371,7 @@ func swagDataFind() {}
 //	@Tags		data
 //	@Accept		json
 //	@Produce	json
-//	@Param		body	body		dataFindRequest	true	"Find parameters"
+//	@Param		body	body		dataFindOneRequest	true	"Find parameters"
 //	@Success	200		{object}	map[string]interface{}
 //	@Router		/api/data/find-one [post]
 func swagDataFindOne() {}
@@ -1963,27 +3477,35 @@ func swagDataUpsert() {}
 
 // dataFindRequest is the body for POST /api/data/find and /api/data/find-one.
 type dataFindRequest struct {
-	Table  string   `json:"table"  example:"posts"`
+	Table  string   `json:"table"  example:"posts" binding:"required"`
 	Where  string   `json:"where"  example:"published = ?"`
-	Args   []any    `json:"args"   example:"[1]"`
-	Fields []string `json:"fields" example:"[\"title\",\"slug\"]"`
+	Args   []any    `json:"args"`
+	Fields []string `json:"fields" example:"title,slug"`
 	Order  string   `json:"order"  example:"_id DESC"`
 	Limit  int      `json:"limit"  example:"50"`
 	Offset int      `json:"offset" example:"0"`
 }
 
+// dataFindOneRequest is the body for POST /api/data/find-one.
+type dataFindOneRequest struct {
+	Table  string   `json:"table"  example:"posts" binding:"required"`
+	Where  string   `json:"where"  example:"slug = ?"`
+	Args   []any    `json:"args"`
+	Fields []string `json:"fields" example:"title,slug"`
+}
+
 // dataGetByRequest is the body for POST /api/data/get-by.
 type dataGetByRequest struct {
 	Table  string `json:"table"  example:"posts"`
 	Column string `json:"column" example:"slug"`
-	Value  any    `json:"value"  example:"hello-world"`
+	Value  any    `json:"value"`
 }
 
 // dataWhereRequest is the body for exists and count endpoints.
 type dataWhereRequest struct {
-	Table string `json:"table" example:"posts"`
+	Table string `json:"table" example:"posts" binding:"required"`
 	Where string `json:"where" example:"published = ?"`
-	Args  []any  `json:"args"  example:"[1]"`
+	Args  []any  `json:"args"`
 }
 
 // dataPluckRequest is the body for POST /api/data/pluck.
@@ -1991,6 +3513,7 @@ type dataPluckRequest struct {
 	Table  string `json:"table"  example:"posts"`
 	Column string `json:"column" example:"title"`
 	Where  string `json:"where"  example:"published = 1"`
+	Args   []any  `json:"args"`
 	Order  string `json:"order"  example:"_id DESC"`
 	Limit  int    `json:"limit"  example:"100"`
 }
@@ -2000,6 +3523,7 @@ type dataDistinctRequest struct {
 	Table  string `json:"table"  example:"notes"`
 	Column string `json:"column" example:"category"`
 	Where  string `json:"where"`
+	Args   []any  `json:"args"`
 }
 
 // dataAggregateRequest is the body for POST /api/data/aggregate.
@@ -2007,6 +3531,7 @@ type dataAggregateRequest struct {
 	Table   string `json:"table"    example:"scores"`
 	Expr    string `json:"expr"     example:"SUM(score) as total, COUNT(*) as n"`
 	Where   string `json:"where"`
+	Args    []any  `json:"args"`
 	GroupBy string `json:"group_by" example:"player"`
 }
 
@@ -2015,14 +3540,14 @@ type dataUpdateWhereRequest struct {
 	Table string         `json:"table" example:"cards"`
 	Data  map[string]any `json:"data"`
 	Where string         `json:"where" example:"column_id = ?"`
-	Args  []any          `json:"args"  example:"[3]"`
+	Args  []any          `json:"args"`
 }
 
 // dataDeleteWhereRequest is the body for POST /api/data/delete-where.
 type dataDeleteWhereRequest struct {
 	Table string `json:"table" example:"cards"`
 	Where string `json:"where" example:"column_id = ?"`
-	Args  []any  `json:"args"  example:"[3]"`
+	Args  []any  `json:"args"`
 }
 
 // dataUpsertRequest is the body for POST /api/data/upsert.
@@ -2076,7 +3601,7 @@ func swagDataLuaList() {}
 
 // dataLuaCallRequest is the body for POST /api/data/lua/call.
 type dataLuaCallRequest struct {
-	Function string         `json:"function" example:"api"`
+	Function string         `json:"function" example:"api" binding:"required"`
 	Params   map[string]any `json:"params"`
 }
 
@@ -2153,6 +3678,18 @@ func swagChatHistory() {}
 //	@Router		/api/chat/history [delete]
 func swagChatClear() {}
 
+// swagChatCallMessage is a documentation stub for POST /api/chat/call.
+//
+//	@Summary	Record an in-call message (browser-mode calls)
+//	@Description	Browser-mode calls carry chat on the page's own data channel; the page reports each message here so it lands in chat history tagged with the call ID.
+//	@Tags		chat
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		chatCallMessageRequest	true	"Message"
+//	@Success	200		{object}	statusOK
+//	@Router		/api/chat/call [post]
+func swagChatCallMessage() {}
+
 // ── Site ─────────────────────────────────────────────────────────────────────
 
 // swagSiteContent is a documentation stub for GET /api/site/content.
@@ -2267,7 +3804,7 @@ func swagSiteFiles() {}
 
 // siteDeleteRequest is the body for POST /api/site/delete.
 type siteDeleteRequest struct {
-	Path string `json:"path" example:"css/style.css"`
+	Path string `json:"path" example:"css/style.css" binding:"required"`
 }
 
 // swagSiteDelete is a documentation stub for POST /api/site/delete.
@@ -2323,13 +3860,43 @@ func swagSiteUploadLocal() {}
 // swagSiteExport is a documentation stub for GET /api/site/export.
 //
 //	@Summary	Download the entire site as a zip archive
-//	@Description	Exports manifest, schema, site files, and Lua scripts into a zip. The response is an attachment download.
+//	@Description	Exports manifest, schema, site files, and Lua scripts into a zip. With format=static the zip instead holds the site as visitors see it (image variants and SDK files included, no Lua or database), ready for ordinary web hosting. The response is an attachment download.
 //	@Tags		site
 //	@Produce	application/zip
+//	@Param		format	query	string	false	"static for a hostable copy of the site"
 //	@Success	200	{file}	binary	"Zip archive"
 //	@Router		/api/site/export [get]
 func swagSiteExport() {}
 
+// sitePublishRequest is the body for POST /api/site/publish.
+type sitePublishRequest struct {
+	CSRF string `json:"csrf" example:"token123"`
+}
+
+// sitePublishResponse is the result of POST /api/site/publish.
+type sitePublishResponse struct {
+	Status     string `json:"status" example:"published"`
+	Target     string `json:"target" example:"sftp"`
+	Files      int    `json:"files" example:"42"`
+	Bytes      int64  `json:"bytes" example:"1830211"`
+	DurationMs int64  `json:"duration_ms" example:"2150"`
+}
+
+// swagSitePublish is a documentation stub for POST /api/site/publish.
+//
+//	@Summary	Publish a static copy of the site (local only)
+//	@Description	Uploads the same bundle as GET /api/site/export?format=static to the SFTP server or S3 bucket set in publish.*. Files of the same name are overwritten; nothing is deleted on the target.
+//	@Tags		site
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		sitePublishRequest	true	"CSRF token"
+//	@Success	200		{object}	sitePublishResponse
+//	@Failure	400		{string}	string	"no publish target configured"
+//	@Failure	403		{string}	string	"bad csrf"
+//	@Failure	502		{string}	string	"publish failed"
+//	@Router		/api/site/publish [post]
+func swagSitePublish() {}
+
 // siteImportResponse is the body for POST /api/site/import.
 type siteImportResponse struct {
 	Status string `json:"status" example:"imported"`
@@ -2350,6 +3917,37 @@ type siteImportResponse struct {
 //	@Router		/api/site/import [post]
 func swagSiteImport() {}
 
+// swagSiteAssets is a documentation stub for GET /api/site/assets.
+//
+//	@Summary	Site asset pipeline stats
+//	@Description	Result of the last scan: images with variants, variant files, files encoded by the last pass and the bytes saved. All zero while assets.enabled is off.
+//	@Tags		site
+//	@Produce	json
+//	@Success	200	{object}	siteassets.Stats
+//	@Router		/api/site/assets [get]
+func swagSiteAssets() {}
+
+// swagSiteAssetsRebuild is a documentation stub for POST /api/site/assets/rebuild.
+//
+//	@Summary	Rescan the site for image variants now (local only)
+//	@Description	Encodes variants of new or changed images and removes those of deleted ones, instead of waiting for the background pass.
+//	@Tags		site
+//	@Produce	json
+//	@Success	200	{object}	siteassets.Stats
+//	@Router		/api/site/assets/rebuild [post]
+func swagSiteAssetsRebuild() {}
+
+// swagSiteAnalytics is a documentation stub for GET /api/site/analytics.
+//
+//	@Summary	Site serving traffic
+//	@Description	Bytes and current rate spent serving the site and docs to other peers, overall and per requesting peer, with the p2p.serve_limit_kbps / serve_peer_limit_kbps caps and the time spent throttled.
+//	@Tags		site
+//	@Produce	json
+//	@Success	200	{object}	p2p.ServeStats
+//	@Failure	503	{string}	string	"p2p node not available"
+//	@Router		/api/site/analytics [get]
+func swagSiteAnalytics() {}
+
 // ── Filesystem ───────────────────────────────────────────────────────────────
 
 // fsBrowseEntry is a single entry in the /api/fs/browse response.
@@ -2381,7 +3979,7 @@ func swagFSBrowse() {}
 
 // luaPrefabApplyRequest is the body for POST /api/lua/prefabs/apply.
 type luaPrefabApplyRequest struct {
-	Prefab string `json:"prefab" example:"quiz-tools"`
+	Prefab string `json:"prefab" example:"quiz-tools" binding:"required"`
 	Script string `json:"script,omitempty" example:"score-tracker"`
 	CSRF   string `json:"csrf"   example:"token123"`
 }
@@ -2394,7 +3992,7 @@ type luaPrefabApplyResponse struct {
 
 // templateApplyRequest is the body for POST /api/templates/apply.
 type templateApplyRequest struct {
-	Template string `json:"template" example:"corkboard"`
+	Template string `json:"template" example:"corkboard" binding:"required"`
 	CSRF     string `json:"csrf"     example:"token123"`
 }
 
@@ -2420,7 +4018,7 @@ func swagTemplatesApply() {}
 
 // templateValidateLocalRequest is the body for POST /api/templates/validate-local.
 type templateValidateLocalRequest struct {
-	Path string `json:"path" example:"/home/user/my-template"`
+	Path string `json:"path" example:"/home/user/my-template" binding:"required"`
 }
 
 // templateValidateLocalResponse is the body for POST /api/templates/validate-local.
@@ -2446,7 +4044,7 @@ func swagTemplatesValidateLocal() {}
 
 // templateApplyLocalRequest is the body for POST /api/templates/apply-local.
 type templateApplyLocalRequest struct {
-	Path string `json:"path" example:"/home/user/my-template"`
+	Path string `json:"path" example:"/home/user/my-template" binding:"required"`
 	CSRF string `json:"csrf" example:"token123"`
 }
 
@@ -2464,10 +4062,83 @@ type templateApplyLocalRequest struct {
 //	@Router		/api/templates/apply-local [post]
 func swagTemplatesApplyLocal() {}
 
+// templatePublishRequest is the body for POST /api/templates/publish.
+type templatePublishRequest struct {
+	Path string `json:"path" example:"/home/user/my-template" binding:"required"`
+	CSRF string `json:"csrf" example:"token123"`
+}
+
+// templatePublishResult is the outcome of publishing to one rendezvous.
+type templatePublishResult struct {
+	Server  string `json:"server"            example:"https://rv.example.org"`
+	Version string `json:"version,omitempty" example:"1.0.0"`
+	Error   string `json:"error,omitempty"   example:"publish template: 409 Conflict: a template with this name belongs to someone else"`
+}
+
+// templatePublishResponse is the body returned by POST /api/templates/publish.
+type templatePublishResponse struct {
+	Template string                  `json:"template" example:"my-template"`
+	Results  []templatePublishResult `json:"results"`
+}
+
+// swagTemplatesPublish is a documentation stub for POST /api/templates/publish.
+//
+//	@Summary	Publish a local template folder to the template store
+//	@Description	Packs the folder (must contain manifest.json; its name becomes the template dir) into a bundle signed with this peer's key and publishes it to every rendezvous. Each result names the server and its error, if any.
+//	@Tags		templates
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		templatePublishRequest	true	"Folder path and CSRF"
+//	@Success	200		{object}	templatePublishResponse
+//	@Failure	400		{string}	string	"path required / invalid path / bad folder name / manifest.json not found"
+//	@Failure	403		{string}	string	"bad csrf"
+//	@Failure	503		{string}	string	"no rendezvous server configured"
+//	@Router		/api/templates/publish [post]
+func swagTemplatesPublish() {}
+
+// templateInstallRequest is the body for POST /api/templates/install.
+type templateInstallRequest struct {
+	Template string `json:"template" example:"kanban" binding:"required"`
+	CSRF     string `json:"csrf"     example:"token123"`
+}
+
+// templateInstallResponse is the body for POST /api/templates/install.
+type templateInstallResponse struct {
+	Session string `json:"session" example:"9f2c4e1ab07d45c3a1e6f0b2d8c97a13"`
+}
+
+// swagTemplatesInstall is a documentation stub for POST /api/templates/install.
+//
+//	@Summary	Open an install session for a store template
+//	@Description	Opens a session on the rendezvous that reports the progress of the next apply-store of this template. Pass the session to GET /api/templates/install/events and to POST /api/templates/apply-store. session is empty when the rendezvous does not support install sessions; apply-store works without one.
+//	@Tags		templates
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		templateInstallRequest	true	"Store template to install"
+//	@Success	200		{object}	templateInstallResponse
+//	@Failure	403		{string}	string	"bad csrf"
+//	@Router		/api/templates/install [post]
+func swagTemplatesInstall() {}
+
+// swagTemplatesInstallEvents is a documentation stub for GET /api/templates/install/events.
+//
+//	@Summary	SSE stream — progress of a store template install
+//	@Description	Relays the install session's events from the rendezvous. Each event is a JSON rendezvous.InstallEvent with an SSE id; stage goes created → verifying → downloading (bytes of total) → downloaded (sha256) → applying → applied, or ends in failed. The stream closes after the event with done set.
+//	@Tags		templates
+//	@Produce	text/event-stream
+//	@Param		session			query		string	true	"Install session from POST /api/templates/install"
+//	@Param		Last-Event-ID	header		string	false	"Last event ID seen (resume)"
+//	@Success	200	{string}	string	"SSE stream"
+//	@Failure	400	{string}	string	"session required"
+//	@Failure	502	{string}	string	"rendezvous error"
+//	@Router		/api/templates/install/events [get]
+func swagTemplatesInstallEvents() {}
+
 // templateApplyStoreRequest is the body for POST /api/templates/apply-store.
 type templateApplyStoreRequest struct {
-	Template string `json:"template" example:"kanban"`
+	Template string `json:"template" example:"kanban" binding:"required"`
 	CSRF     string `json:"csrf"     example:"token123"`
+	Session  string `json:"session"  example:"9f2c4e1ab07d45c3a1e6f0b2d8c97a13"` // install session to report progress to
 }
 
 // templateApplyStoreResponse is the body for POST /api/templates/apply-store.
@@ -2480,7 +4151,7 @@ type templateApplyStoreResponse struct {
 // swagTemplatesApplyStore is a documentation stub for POST /api/templates/apply-store.
 //
 //	@Summary	Apply a store template (download, spend credits, apply)
-//	@Description	Spends credits if required, downloads the template bundle from the rendezvous server, resets site and database, then applies the template.
+//	@Description	Spends credits if required, downloads the template bundle from the rendezvous server, resets site and database, then applies the template. With a session from POST /api/templates/install, the download progress and the outcome are reported to it.
 //	@Tags		templates
 //	@Accept		json
 //	@Produce	json
@@ -2493,6 +4164,95 @@ type templateApplyStoreResponse struct {
 //	@Router		/api/templates/apply-store [post]
 func swagTemplatesApplyStore() {}
 
+// templateUpdateStatusResponse is the body for GET /api/templates/update.
+type templateUpdateStatusResponse struct {
+	Template  string `json:"template"  example:"kanban"`
+	Source    string `json:"source"    example:"store"`
+	Installed string `json:"installed" example:"1.2.0"`
+	Latest    string `json:"latest"    example:"1.3.0"`
+	Available bool   `json:"available" example:"true"`
+}
+
+// swagTemplatesUpdateStatus is a documentation stub for GET /api/templates/update.
+//
+//	@Summary	Update status of the active template
+//	@Description	Returns the installed version of the active template and the newest store version seen by the background update check (every 6 hours). available is true for store templates with a newer version.
+//	@Tags		templates
+//	@Produce	json
+//	@Success	200	{object}	templateUpdateStatusResponse
+//	@Router		/api/templates/update [get]
+func swagTemplatesUpdateStatus() {}
+
+// templateUpdateRequest is the body for POST /api/templates/update.
+type templateUpdateRequest struct {
+	CSRF string `json:"csrf" example:"token123"`
+}
+
+// templateUpdateResponse is the body for POST /api/templates/update.
+type templateUpdateResponse struct {
+	Status   string `json:"status"   example:"updated"`
+	Template string `json:"template" example:"kanban"`
+	Version  string `json:"version"  example:"1.3.0"`
+}
+
+// swagTemplatesUpdate is a documentation stub for POST /api/templates/update.
+//
+//	@Summary	Update the active store template
+//	@Description	Re-downloads the active store template and writes its files over the site. Files the template does not ship, existing tables and their rows are kept; tables new in this version are created. No seed runs.
+//	@Tags		templates
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		templateUpdateRequest	true	"CSRF token"
+//	@Success	200		{object}	templateUpdateResponse
+//	@Failure	400		{string}	string	"active template is not from the store"
+//	@Failure	403		{string}	string	"bad csrf"
+//	@Failure	502		{string}	string	"failed to download template"
+//	@Router		/api/templates/update [post]
+func swagTemplatesUpdate() {}
+
+// templateSnapshotEntry is one element of the GET /api/templates/snapshots response.
+type templateSnapshotEntry struct {
+	ID       string `json:"id"       example:"ltq3k8w1p0"`
+	Created  int64  `json:"created"  example:"1760700000000"`
+	Reason   string `json:"reason"   example:"kanban"`
+	Template string `json:"template" example:"blog"`
+}
+
+// swagTemplatesSnapshots is a documentation stub for GET /api/templates/snapshots.
+//
+//	@Summary	List template snapshots
+//	@Description	Snapshots taken before each template apply, newest first. reason is what was applied; template is the template active before it. viewer.template_snapshots sets how many are kept.
+//	@Tags		templates
+//	@Produce	json
+//	@Success	200	{array}	templateSnapshotEntry
+//	@Router		/api/templates/snapshots [get]
+func swagTemplatesSnapshots() {}
+
+// templateRevertRequest is the body for POST /api/templates/revert.
+type templateRevertRequest struct {
+	CSRF string `json:"csrf" example:"token123"`
+}
+
+// templateRevertResponse is the body for POST /api/templates/revert.
+type templateRevertResponse struct {
+	Status   string `json:"status"   example:"reverted"`
+	Template string `json:"template" example:"blog"`
+}
+
+// swagTemplatesRevert is a documentation stub for POST /api/templates/revert.
+//
+//	@Summary	Revert the last template apply
+//	@Description	Restores the site files, template tables with their rows, schema files and template settings from the newest snapshot, and sets the active template back. The snapshot is consumed, so reverting again goes one apply further back.
+//	@Tags		templates
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		templateRevertRequest	true	"CSRF token"
+//	@Success	200		{object}	templateRevertResponse
+//	@Failure	403		{string}	string	"bad csrf"
+//	@Failure	404		{string}	string	"no snapshot to revert to"
+//	@Router		/api/templates/revert [post]
+func swagTemplatesRevert() {}
+
 // ── Transformation ───────────────────────────────────────────────────────────────────
 
 // transformListEntry describes one transformation in the GET /api/data/transformations response.
@@ -2506,7 +4266,7 @@ type transformListEntry struct {
 
 // transformNameRequest is the body for endpoints that take a transformation name.
 type transformNameRequest struct {
-	Name string `json:"name" example:"order-to-invoice"`
+	Name string `json:"name" example:"order-to-invoice" binding:"required"`
 }
 
 // transformDataEndpoint describes a source or target for a transformation.
@@ -2537,13 +4297,13 @@ type transformSaveRequest struct {
 
 // transformPreviewRequest is the body for POST /api/data/transformations/preview.
 type transformPreviewRequest struct {
-	Name string           `json:"name" example:"order-to-invoice"`
+	Name string           `json:"name" example:"order-to-invoice" binding:"required"`
 	Rows []map[string]any `json:"rows"`
 }
 
 // transformExecuteRequest is the body for POST /api/data/transformations/execute.
 type transformExecuteRequest struct {
-	Name  string `json:"name" example:"order-to-invoice"`
+	Name  string `json:"name" example:"order-to-invoice" binding:"required"`
 	Where string `json:"where,omitempty"`
 	Args        []any  `json:"args,omitempty"`
 	Limit       int    `json:"limit,omitempty" example:"10000"`
@@ -2657,6 +4417,7 @@ func swagTransformFileExists() {}
 //	@Tags			transformations
 //	@Accept			json
 //	@Produce		json
+//	@Param			body	body		transformDataEndpoint	true	"Data source"
 //	@Success		200		{array}		string
 //	@Router			/api/data/transformations/source-fields [post]
 func swagTransformSourceFields() {}
@@ -2673,7 +4434,7 @@ type schemaListEntry struct {
 
 // schemaNameRequest is the body for endpoints that take a schema name.
 type schemaNameRequest struct {
-	Name string `json:"name" example:"orders"`
+	Name string `json:"name" example:"orders" binding:"required"`
 }
 
 // schemaColumn describes a column in a schema definition.
@@ -2695,8 +4456,12 @@ type schemaEnumValue struct {
 
 // schemaSaveRequest is the body for POST /api/data/schemas/save.
 type schemaSaveRequest struct {
-	Name    string         `json:"name"    example:"orders"`
-	Columns []schemaColumn `json:"columns"`
+	Name      string                    `json:"name"                 example:"orders"`
+	Columns   []schemaColumn            `json:"columns"`
+	Context   bool                      `json:"context,omitempty"`
+	Access    *ormAccess                `json:"access,omitempty"`
+	Roles     map[string]ormSchemaRoles `json:"roles,omitempty"`
+	SystemKey bool                      `json:"system_key,omitempty"`
 }
 
 // schemaDdlResponse is the body for POST /api/data/schemas/ddl.
@@ -2775,7 +4540,7 @@ func swagSchemaApply() {}
 
 // schemaSetContextRequest is the body for POST /api/data/schemas/set-context.
 type schemaSetContextRequest struct {
-	Name    string `json:"name"    example:"orders"`
+	Name    string `json:"name"    example:"orders" binding:"required"`
 	Context bool   `json:"context" example:"true"`
 }
 
@@ -2794,7 +4559,7 @@ func swagSchemaSetContext() {}
 
 // schemaSetAccessRequest is the body for POST /api/data/schemas/set-access.
 type schemaSetAccessRequest struct {
-	Name   string    `json:"name"   example:"orders"`
+	Name   string    `json:"name"   example:"orders" binding:"required"`
 	Access ormAccess `json:"access"`
 }
 
@@ -2812,7 +4577,7 @@ func swagSchemaSetAccess() {}
 
 // schemaSetRolesRequest is the body for POST /api/data/schemas/set-roles.
 type schemaSetRolesRequest struct {
-	Name  string                    `json:"name"  example:"posts"`
+	Name  string                    `json:"name"  example:"posts" binding:"required"`
 	Roles map[string]ormSchemaRoles `json:"roles"`
 }
 
@@ -2838,7 +4603,7 @@ func swagSchemaSetRoles() {}
 
 // dataRoleRequest is the body for POST /api/data/role.
 type dataRoleRequest struct {
-	Table string `json:"table" example:"posts"`
+	Table string `json:"table" example:"posts" binding:"required"`
 }
 
 // dataRoleResponse is the body for POST /api/data/role.
@@ -2953,7 +4718,7 @@ type datafedGroupInfo struct {
 
 // datafedOfferRequest is the body for POST /api/datafed/offer.
 type datafedOfferRequest struct {
-	GroupID       string               `json:"group_id"       example:"a1b2c3d4e5f6g7h8"`
+	GroupID       string               `json:"group_id"       example:"a1b2c3d4e5f6g7h8" binding:"required"`
 	Tables        []string             `json:"tables"         example:"Person,Order"`
 	Relationships []datafedRelationship `json:"relationships,omitempty"`
 }
@@ -2968,7 +4733,7 @@ type datafedRelationship struct {
 
 // datafedGroupIDRequest is the body for endpoints that take a group_id.
 type datafedGroupIDRequest struct {
-	GroupID string `json:"group_id" example:"a1b2c3d4e5f6g7h8"`
+	GroupID string `json:"group_id" example:"a1b2c3d4e5f6g7h8" binding:"required"`
 }
 
 // datafedPeerContribution describes a single peer's contribution to a federation group.
@@ -3027,7 +4792,7 @@ func swagDataFedContributions() {}
 
 // splitPrefRequest is the body for POST /api/split-prefs.
 type splitPrefRequest struct {
-	Key   string  `json:"key"   example:"sidebar"`
+	Key   string  `json:"key"   example:"sidebar" binding:"required"`
 	Value float64 `json:"value" example:"30"`
 }
 
@@ -3075,6 +4840,7 @@ func swagSplitPrefs() {}
 //	@Tags		rendezvous
 //	@Produce	json
 //	@Success	200	{object}	map[string]bool	"Feature flags: encryption, registration, credits, templates, bridge, relay"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/capabilities [get]
 func swagCapabilities() {}
 
@@ -3085,15 +4851,29 @@ func swagCapabilities() {}
 //	@Produce	json
 //	@Param		peer	query	string	true	"Peer ID to refresh relay for"
 //	@Success	200		{object}	map[string]bool
+//	@x-served-by	"rendezvous"
 //	@Router		/api/pulse [post]
 func swagPulse() {}
 
+// swagRelayReport is a documentation stub for POST /api/relay-report.
+//
+//	@Summary	Report a peer's relay reservation health
+//	@Description	Peers using the relay post this every few minutes. Error classes: timeout, dial, refused, limit, no-circuit, other. Only peers that have published presence are recorded; the admin Relay tab shows the aggregate.
+//	@Tags		rendezvous
+//	@Accept		json
+//	@Param		body	body	object{peer_id string, reservation_ok bool, error_class string, error_age_sec int, failures int, rtt_ms int}	true	"Relay report"
+//	@Success	204
+//	@x-served-by	"rendezvous"
+//	@Router		/api/relay-report [post]
+func swagRelayReport() {}
+
 // swagServicesLogs is a documentation stub for GET /api/services/logs.
 //
 //	@Summary	Aggregate logs from all microservices (admin only)
 //	@Tags		rendezvous
 //	@Produce	json
 //	@Success	200	{array}	object	"Array of {service, message} objects"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/services/logs [get]
 func swagServicesLogs() {}
 
@@ -3103,6 +4883,7 @@ func swagServicesLogs() {}
 //	@Tags		rendezvous
 //	@Produce	text/yaml
 //	@Success	200	{string}	string	"OpenAPI YAML spec"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/executor-api.yaml [get]
 func swagExecutorAPIYaml() {}
 
@@ -3115,6 +4896,7 @@ func swagExecutorAPIYaml() {}
 //	@Produce	json
 //	@Param		peer_id	query	string	false	"Peer ID (defaults to caller)"
 //	@Success	200		{object}	map[string]int	"balance"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/balance [get]
 func swagCreditsBalance() {}
 
@@ -3126,6 +4908,7 @@ func swagCreditsBalance() {}
 //	@Produce	json
 //	@Param		body	body	object{amount int, reason string}	true	"Grant details"
 //	@Success	200		{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/grant [post]
 func swagCreditsGrant() {}
 
@@ -3137,6 +4920,7 @@ func swagCreditsGrant() {}
 //	@Produce	json
 //	@Param		body	body	object{template string}	true	"Template to purchase"
 //	@Success	200		{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/spend [post]
 func swagCreditsSpend() {}
 
@@ -3148,6 +4932,7 @@ func swagCreditsSpend() {}
 //	@Param		template_dir	query	string	true	"Template directory name"
 //	@Param		peer_id			query	string	false	"Peer ID (defaults to caller)"
 //	@Success	200				{object}	map[string]bool	"allowed"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/access [get]
 func swagCreditsAccess() {}
 
@@ -3158,6 +4943,7 @@ func swagCreditsAccess() {}
 //	@Produce	json
 //	@Param		peer_id	query	string	false	"Peer ID (defaults to caller)"
 //	@Success	200		{object}	map[string]any	"credits_active, email, balance, app_name"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/store-data [get]
 func swagCreditsStoreData() {}
 
@@ -3169,6 +4955,7 @@ func swagCreditsStoreData() {}
 //	@Param		template_dir	query	string	true	"Template directory name"
 //	@Param		peer_id			query	string	false	"Peer ID (defaults to caller)"
 //	@Success	200				{object}	map[string]any	"price, status"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/credits/template-info [get]
 func swagCreditsTemplateInfo() {}
 
@@ -3181,6 +4968,7 @@ func swagCreditsTemplateInfo() {}
 //	@Accept		json
 //	@Produce	json
 //	@Success	200	{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/encryption/keys [post]
 func swagEncryptionKeysUpload() {}
 
@@ -3191,6 +4979,7 @@ func swagEncryptionKeysUpload() {}
 //	@Produce	json
 //	@Param		peer_id	path	string	true	"Peer ID"
 //	@Success	200		{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/encryption/keys/{peer_id} [get]
 func swagEncryptionKeysGet() {}
 
@@ -3200,6 +4989,7 @@ func swagEncryptionKeysGet() {}
 //	@Tags		encryption
 //	@Produce	json
 //	@Success	200	{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/encryption/broadcast-key [get]
 func swagEncryptionBroadcastKey() {}
 
@@ -3209,6 +4999,7 @@ func swagEncryptionBroadcastKey() {}
 //
 //	@Summary	Reverse proxy for email service
 //	@Tags		services
+//	@x-served-by	"rendezvous"
 //	@Router		/api/email/ [get]
 func swagEmailProxy() {}
 
@@ -3216,6 +5007,7 @@ func swagEmailProxy() {}
 //
 //	@Summary	Reverse proxy for registration service
 //	@Tags		services
+//	@x-served-by	"rendezvous"
 //	@Router		/api/reg/ [get]
 func swagRegProxy() {}
 
@@ -3227,6 +5019,7 @@ func swagRegProxy() {}
 //	@Tags		templates
 //	@Produce	json
 //	@Success	200	{array}	object	"Array of StoreMeta objects"
+//	@x-served-by	"rendezvous"
 //	@Router		/api/templates [get]
 func swagTemplatesList() {}
 
@@ -3237,6 +5030,7 @@ func swagTemplatesList() {}
 //	@Produce	json
 //	@Param		dir	path	string	true	"Template directory name"
 //	@Success	200	{object}	object
+//	@x-served-by	"rendezvous"
 //	@Router		/api/templates/{dir} [get]
 func swagTemplatesBundle() {}
 
@@ -3247,5 +5041,6 @@ func swagTemplatesBundle() {}
 //	@Accept		json
 //	@Produce	json
 //	@Success	200	{object}	map[string]any
+//	@x-served-by	"rendezvous"
 //	@Router		/api/templates/prices [get]
 func swagTemplatesPrices() {}
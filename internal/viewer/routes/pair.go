@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/pairing"
+	"github.com/petervdpas/goop2/internal/ui/render"
+	"github.com/petervdpas/goop2/internal/ui/viewmodels"
+)
+
+// pairCookieMaxAge keeps a phone paired until it is revoked (one year,
+// renewed on every claim).
+const pairCookieMaxAge = 365 * 24 * 60 * 60
+
+func registerPairRoutes(mux *http.ServeMux, d Deps) {
+	if d.Pairing == nil {
+		return
+	}
+
+	// GET /api/pair — pending code, paired devices and the URL to open on the phone
+	handleGet(mux, "/api/pair", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		devices, err := d.Pairing.Devices()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var pending *pairing.Code
+		if c, ok := d.Pairing.Pending(); ok {
+			pending = &c
+		}
+		writeJSON(w, map[string]any{
+			"pending":    pending,
+			"devices":    devices,
+			"guests":     d.Pairing.Guests(),
+			"remote_url": d.RemoteURL,
+		})
+	})
+
+	// POST /api/pair/start — issue a pairing code for the given scopes (empty = all)
+	handlePost(mux, "/api/pair/start", func(w http.ResponseWriter, r *http.Request, req struct {
+		Scopes []string `json:"scopes"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		code, err := d.Pairing.Start(req.Scopes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"code":       code.Code,
+			"scopes":     code.Scopes,
+			"expires_at": code.ExpiresAt,
+			"remote_url": d.RemoteURL,
+		})
+	})
+
+	// POST /api/pair/revoke — unpair a device
+	handlePost(mux, "/api/pair/revoke", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID string `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.ID == "" {
+			http.Error(w, "missing id", http.StatusBadRequest)
+			return
+		}
+		if err := d.Pairing.Revoke(req.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "no such device", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/pair/guest — open a read-only guest session for minutes
+	// (0 = default) and return the link that starts it
+	handlePost(mux, "/api/pair/guest", func(w http.ResponseWriter, r *http.Request, req struct {
+		Minutes int `json:"minutes"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if d.RemoteURL == "" {
+			http.Error(w, "remote control addr not set", http.StatusConflict)
+			return
+		}
+		token, g, err := d.Pairing.StartGuest(time.Duration(req.Minutes) * time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"guest": g,
+			"url":   d.RemoteURL + "/guest?token=" + url.QueryEscape(token),
+		})
+	})
+
+	// POST /api/pair/guest/revoke — end a guest session
+	handlePost(mux, "/api/pair/guest/revoke", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID string `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.Pairing.RevokeGuest(req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// GET /guest?token= — the guest link: keeps the token in a cookie and
+	// goes to the peers list. The session itself expires server-side.
+	handleGet(mux, "/guest", func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		dev, ok := d.Pairing.Authenticate(token)
+		if !ok || !pairing.IsGuest(dev) {
+			http.Error(w, "guest link expired or revoked", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     pairing.CookieName,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(pairing.GuestMaxTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, "/peers", http.StatusFound)
+	})
+
+	// POST /api/pair/claim — the phone trades the pairing code for a device token.
+	// Browsers keep it in an HttpOnly cookie; other clients use the returned token
+	// as "Authorization: Bearer".
+	handlePost(mux, "/api/pair/claim", func(w http.ResponseWriter, r *http.Request, req struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}) {
+		token, dev, err := d.Pairing.Claim(req.Code, req.Name)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, pairing.ErrNoCode) || errors.Is(err, pairing.ErrBadCode) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     pairing.CookieName,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   pairCookieMaxAge,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		w.Header().Set("Cache-Control", "no-store") // keeps the token out of the idempotency cache
+		writeJSON(w, map[string]any{"token": token, "device": dev})
+	})
+
+	// GET /pair — code entry page for the phone
+	handleGet(mux, "/pair", func(w http.ResponseWriter, r *http.Request) {
+		render.RenderStandalone(w, "page.pair", remoteVM(r, d))
+	})
+
+	// GET /remote — the phone UI. Locally it shows every section.
+	handleGet(mux, "/remote", func(w http.ResponseWriter, r *http.Request) {
+		render.RenderStandalone(w, "page.remote", remoteVM(r, d))
+	})
+}
+
+func remoteVM(r *http.Request, d Deps) viewmodels.RemoteVM {
+	base := baseVM("", "", "", d)
+	vm := viewmodels.RemoteVM{
+		SelfName: base.SelfName,
+		SelfID:   base.SelfID,
+		Theme:    base.Theme,
+		Scopes:   strings.Join(pairing.AllScopes, ","),
+	}
+	if dev, ok := pairing.FromContext(r.Context()); ok {
+		vm.DeviceName = dev.Name
+		vm.Scopes = strings.Join(dev.Scopes, ",")
+	}
+	return vm
+}
@@ -39,7 +39,7 @@ func registerPeerRoutes(mux *http.ServeMux, d Deps) {
 		luaEnabled := false
 		if d.CfgPath != "" {
 			if cfg, err := config.Load(d.CfgPath); err == nil {
-				selfVideoDisabled = cfg.Viewer.VideoDisabled
+				selfVideoDisabled = cfg.CallsDisabled()
 				luaEnabled = cfg.Lua.Enabled
 			}
 		}
@@ -59,7 +59,7 @@ func registerPeerRoutes(mux *http.ServeMux, d Deps) {
 	})
 
 	// API route - fetches remote peer content
-	mux.HandleFunc("/api/peer/content", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/peer/content", func(w http.ResponseWriter, r *http.Request) {
 		peerID := r.URL.Query().Get("id")
 		if peerID == "" {
 			http.Error(w, "missing id", http.StatusBadRequest)
@@ -71,9 +71,7 @@ func registerPeerRoutes(mux *http.ServeMux, d Deps) {
 
 		content, err := d.Node.FetchContent(ctx, peerID)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadGateway)
-			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			writeError(w, http.StatusBadGateway, "", err.Error(), "")
 			return
 		}
 
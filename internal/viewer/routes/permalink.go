@@ -0,0 +1,212 @@
+// Content-addressed permalinks to peer pages (goop://<peer>/<hash>/<path>).
+
+package routes
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/permalink"
+	"github.com/petervdpas/goop2/internal/ui/render"
+)
+
+// Permalink resolution states.
+const (
+	permalinkCurrent     = "current"     // the live page still has the linked content
+	permalinkChanged     = "changed"     // the peer serves different content now
+	permalinkUnreachable = "unreachable" // the page could not be fetched
+)
+
+type permalinkResolution struct {
+	Link      string `json:"link"`
+	Status    string `json:"status"`
+	Pinned    bool   `json:"pinned"`
+	PinnedAt  int64  `json:"pinned_at,omitempty"`
+	LiveURL   string `json:"live_url"`
+	MirrorURL string `json:"mirror_url,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func registerPermalinkRoutes(mux *http.ServeMux, d Deps) {
+	if d.Node == nil || d.PeerDir == "" {
+		return
+	}
+	pins, err := permalink.NewStore(filepath.Join(d.PeerDir, "data", "pins"))
+	if err != nil {
+		return
+	}
+
+	// Create a permalink for a page as it is now, optionally pinning a copy.
+	handlePost(mux, "/api/permalink", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+		Path   string `json:"path"`
+		Pin    bool   `json:"pin"`
+	}) {
+		if req.PeerID == "" {
+			http.Error(w, "missing peer_id", http.StatusBadRequest)
+			return
+		}
+		if req.Pin && !requireLocal(w, r) {
+			return
+		}
+		l := permalink.Link{PeerID: req.PeerID, Path: permalink.CleanPath(req.Path)}
+		mt, data, err := fetchPermalinkPage(r.Context(), d, l)
+		if err != nil {
+			http.Error(w, "failed to fetch page: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		l.Hash = permalink.Hash(data)
+		if req.Pin {
+			if _, err := pins.Put(l, mt, data); err != nil {
+				http.Error(w, "failed to pin: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		writeJSON(w, map[string]any{
+			"link":   l.String(),
+			"hash":   l.Hash,
+			"url":    d.BaseURL + l.ViewerPath(),
+			"pinned": pins.Has(l.Hash),
+		})
+	})
+
+	// Check a permalink against the live page.
+	handleGet(mux, "/api/permalink/resolve", func(w http.ResponseWriter, r *http.Request) {
+		l, err := permalink.Parse(r.URL.Query().Get("link"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res := resolvePermalink(r.Context(), d, pins, l)
+		writeJSON(w, res)
+	})
+
+	handleGet(mux, "/api/permalink/pins", func(w http.ResponseWriter, r *http.Request) {
+		list, err := pins.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, list)
+	})
+
+	handlePost(mux, "/api/permalink/unpin", func(w http.ResponseWriter, r *http.Request, req struct {
+		Hash string `json:"hash"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := pins.Remove(req.Hash); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// /permalink/<peer>/<hash>/<path> — the live page while it still
+	// matches, otherwise a notice with the pinned copy if there is one.
+	mux.HandleFunc("/permalink/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/permalink/mirror/") {
+			servePermalinkMirror(w, r, d, pins)
+			return
+		}
+		l, err := permalink.Parse(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		res := resolvePermalink(r.Context(), d, pins, l)
+		if res.Status == permalinkCurrent {
+			http.Redirect(w, r, res.LiveURL, http.StatusFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		render.RenderStandalone(w, "page.permalink", struct {
+			permalinkResolution
+			PinnedTime string
+		}{res, pinnedTime(res.PinnedAt)})
+	})
+}
+
+// resolvePermalink fetches the live page and compares it with the link.
+func resolvePermalink(ctx context.Context, d Deps, pins *permalink.Store, l permalink.Link) permalinkResolution {
+	res := permalinkResolution{
+		Link:    l.String(),
+		LiveURL: d.BaseURL + "/p/" + l.PeerID + l.Path,
+	}
+	if p, _, err := pins.Get(l.Hash); err == nil {
+		res.Pinned = true
+		res.PinnedAt = p.PinnedAt
+		res.MirrorURL = d.BaseURL + "/permalink/mirror/" + l.Hash + l.Path
+	}
+
+	_, data, err := fetchPermalinkPage(ctx, d, l)
+	switch {
+	case err != nil:
+		res.Status, res.Error = permalinkUnreachable, err.Error()
+	case permalink.Hash(data) == l.Hash:
+		res.Status = permalinkCurrent
+	default:
+		res.Status = permalinkChanged
+	}
+	return res
+}
+
+func fetchPermalinkPage(ctx context.Context, d Deps, l permalink.Link) (string, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, PermalinkFetchTimeout)
+	defer cancel()
+	if l.PeerID == d.Node.ID() {
+		if d.Content == nil {
+			return "", nil, errors.New("content store not configured")
+		}
+		data, _, err := d.Content.Read(ctx, strings.TrimPrefix(l.Path, "/"))
+		return mime.TypeByExtension(path.Ext(l.Path)), data, err
+	}
+	return d.Node.FetchSiteFile(ctx, l.PeerID, l.Path)
+}
+
+// servePermalinkMirror serves /permalink/mirror/<hash>/<path>: the pinned
+// page itself, with the headers peer pages get, and a redirect to the live
+// peer for anything the page loads next to it.
+func servePermalinkMirror(w http.ResponseWriter, r *http.Request, d Deps, pins *permalink.Store) {
+	rest := strings.TrimPrefix(r.URL.Path, "/permalink/mirror/")
+	hash, p, _ := strings.Cut(rest, "/")
+	pin, data, err := pins.Get(hash)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if reqPath := permalink.CleanPath(p); reqPath != pin.Path {
+		http.Redirect(w, r, d.BaseURL+"/p/"+pin.PeerID+reqPath, http.StatusFound)
+		return
+	}
+	mt := pin.MIME
+	if mt == "" {
+		mt = http.DetectContentType(data)
+	}
+	w.Header().Set("Content-Type", mt)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy",
+		"default-src 'none'; style-src 'self'; script-src 'self'; img-src 'self' data:; "+
+			"font-src 'self' data:; connect-src 'self'; base-uri 'none'; frame-ancestors 'self'")
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	_, _ = w.Write(data)
+}
+
+func pinnedTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).Format("2006-01-02 15:04")
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Web app manifest for installing the viewer from a browser. Together with
+// /sw.js (mounted next to /assets/) it makes a peer's local UI installable
+// where the desktop app is not used, e.g. a headless CLI peer on the LAN.
+
+type manifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+type webManifest struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name"`
+	Description     string         `json:"description"`
+	StartURL        string         `json:"start_url"`
+	Scope           string         `json:"scope"`
+	Display         string         `json:"display"`
+	BackgroundColor string         `json:"background_color"`
+	ThemeColor      string         `json:"theme_color"`
+	Icons           []manifestIcon `json:"icons"`
+}
+
+// pwaThemeColor matches --bg of the dark theme (css/vars.css).
+const pwaThemeColor = "#0f1115"
+
+func registerPWARoutes(mux *http.ServeMux, d Deps) {
+	handleGet(mux, "/manifest.webmanifest", func(w http.ResponseWriter, r *http.Request) {
+		name := "Goop²"
+		if d.SelfLabel != nil {
+			if label := d.SelfLabel(); label != "" {
+				name = "Goop² — " + label
+			}
+		}
+		w.Header().Set("Content-Type", "application/manifest+json")
+		json.NewEncoder(w).Encode(webManifest{
+			ID:              "/",
+			Name:            name,
+			ShortName:       "Goop²",
+			Description:     "Local Goop² peer",
+			StartURL:        "/",
+			Scope:           "/",
+			Display:         "standalone",
+			BackgroundColor: pwaThemeColor,
+			ThemeColor:      pwaThemeColor,
+			Icons: []manifestIcon{
+				{Src: "/assets/images/goop2-192.png", Sizes: "192x192", Type: "image/png"},
+				{Src: "/assets/images/goop2-512.png", Sizes: "512x512", Type: "image/png"},
+				{Src: "/assets/images/goop2-512.png", Sizes: "512x512", Type: "image/png", Purpose: "maskable"},
+			},
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifestUsesSelfLabel(t *testing.T) {
+	mux := http.NewServeMux()
+	registerPWARoutes(mux, Deps{SelfLabel: func() string { return "Eggman" }})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/manifest+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var m webManifest
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Goop² — Eggman" || m.StartURL != "/" || m.Display != "standalone" {
+		t.Errorf("manifest = %+v", m)
+	}
+	// Chrome's install criteria need a 192px and a 512px icon.
+	sizes := map[string]bool{}
+	for _, ic := range m.Icons {
+		sizes[ic.Sizes] = true
+	}
+	if !sizes["192x192"] || !sizes["512x512"] {
+		t.Errorf("icons = %+v", m.Icons)
+	}
+}
+
+func TestManifestWithoutSelfLabel(t *testing.T) {
+	mux := http.NewServeMux()
+	registerPWARoutes(mux, Deps{})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+
+	var m webManifest
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Goop²" {
+		t.Errorf("Name = %q, want Goop²", m.Name)
+	}
+}
@@ -7,11 +7,20 @@ import (
 
 	"github.com/petervdpas/goop2/internal/avatar"
 	"github.com/petervdpas/goop2/internal/content"
+	"github.com/petervdpas/goop2/internal/datasync"
 	"github.com/petervdpas/goop2/internal/group"
 	"github.com/petervdpas/goop2/internal/group_types/files"
 	templateType "github.com/petervdpas/goop2/internal/group_types/template"
+	"github.com/petervdpas/goop2/internal/jobs"
+	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/pairing"
 	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/retention"
+	"github.com/petervdpas/goop2/internal/siteassets"
+	"github.com/petervdpas/goop2/internal/sitesync"
+	"github.com/petervdpas/goop2/internal/rules"
+	"github.com/petervdpas/goop2/internal/secrets"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
 )
@@ -27,6 +36,7 @@ type Deps struct {
 	SelfLabel   func() string
 	SelfEmail   func() string
 	Peers       *state.PeerTable
+	Namespaces  map[string]*state.PeerTable // peer tables of presence.namespaces
 	ResolvePeer func(string) state.PeerIdentityPayload
 
 	// Config & content
@@ -42,12 +52,25 @@ type Deps struct {
 	// Networking
 	BridgeURL  string
 	RVClients  []*rendezvous.Client
+	MQ         *mq.Manager // local event publishing; nil in rendezvous-only mode
 
 	// Rendezvous-only mode (no p2p node, limited routes)
 	RendezvousOnly bool
 	RendezvousURL  string
 	TopologyFunc   func() any
 
+	// Health returns the startup doctor's report (nil in rendezvous-only mode)
+	Health func() any
+
+	// Startup returns the startup stages and their timings
+	Startup func() any
+
+	// Metrics serves Prometheus metrics (nil unless viewer.metrics is set)
+	Metrics http.Handler
+
+	// Jobs is the background job queue (nil in rendezvous-only mode)
+	Jobs *jobs.Queue
+
 	// Group managers
 	GroupManager    *group.Manager
 	DocsStore       *files.Store
@@ -57,6 +80,28 @@ type Deps struct {
 	AvatarStore *avatar.Store
 	AvatarCache *avatar.Cache
 
+	// Remote control: paired phones and the URL they open (empty = no listener)
+	Pairing   *pairing.Manager
+	RemoteURL string
+
+	// Automation rules engine (nil in rendezvous-only mode)
+	Rules *rules.Engine
+
+	// Service tokens encrypted at rest
+	Secrets *secrets.Store
+
+	// Peer retention and "forget peer" (nil in rendezvous-only mode)
+	Retention *retention.Pruner
+
+	// Site asset pipeline (nil in rendezvous-only mode)
+	Assets *siteassets.Pipeline
+
+	// Followed sites and their local copies (nil in rendezvous-only mode)
+	SiteSync *sitesync.Syncer
+
+	// Template group table replicas (nil in rendezvous-only mode)
+	DataSync *datasync.Syncer
+
 	// Lua integration
 	EnsureLua func()
 	LuaCall   func(ctx context.Context, function string, params map[string]any) (any, error)
@@ -69,6 +114,8 @@ func Register(mux *http.ServeMux, d Deps) {
 	RegisterOpenAPI(mux)
 
 	registerAPILogRoutes(mux, d)
+	registerSecurityRoutes(mux, d)
+	registerCallHistoryRoutes(mux, d)
 
 	registerHomeRoutes(mux, d)
 	registerPeerRoutes(mux, d)
@@ -83,6 +130,7 @@ func Register(mux *http.ServeMux, d Deps) {
 		{"/groups/hosted", "Groups - Hosted", "groups", "page.groups_hosted"},
 		{"/groups/joined", "Groups - Joined", "groups", "page.groups_joined"},
 		{"/groups/events", "Groups - Events", "groups", "page.groups_events"},
+		{"/groups/schedule", "Groups - Schedule", "groups", "page.groups_schedule"},
 		{"/view", "View", "view", "page.view"},
 	})
 	registerApiDocs(mux, d)
@@ -107,10 +155,33 @@ func Register(mux *http.ServeMux, d Deps) {
 	registerTemplateRoutes(mux, d, csrf)
 	registerCreditsUIRoutes(mux, d)
 	registerExportRoutes(mux, d, csrf)
+	registerDataExportRoutes(mux, d)
 	registerLuaRoutes(mux, d, csrf)
 	registerDocsRoutes(mux, d)
+	registerDocsDavRoutes(mux, d)
+	registerSearchRoutes(mux, d)
+	registerPermalinkRoutes(mux, d)
+	registerClockRoutes(mux, d)
+	registerHealthRoutes(mux, d)
+	registerStartupRoutes(mux, d)
+	registerMetricsRoutes(mux, d)
+	registerJobRoutes(mux, d)
+	registerNoteRoutes(mux, d)
+	registerReputationRoutes(mux, d)
+	registerDigestRoutes(mux, d)
+	registerCalendarRoutes(mux, d)
 	registerAvatarRoutes(mux, d)
 	registerSplitPrefsRoutes(mux, d)
+	registerPWARoutes(mux, d)
+	registerPairRoutes(mux, d)
+	registerActionRoutes(mux, csrf)
+	registerRuleRoutes(mux, d)
+	registerSecretRoutes(mux, d)
+	registerRetentionRoutes(mux, d)
+	registerSiteAssetRoutes(mux, d)
+	registerSiteSyncRoutes(mux, d)
+	registerDataSyncRoutes(mux, d)
+	registerSitePublishRoutes(mux, d, csrf)
 }
 
 // RegisterMinimal registers only the routes that work without a p2p node.
@@ -130,12 +201,15 @@ func RegisterMinimal(mux *http.ServeMux, d Deps) {
 	RegisterOpenRoute(mux)
 	RegisterOpenAPI(mux)
 	registerAPILogRoutes(mux, d)
+	registerStartupRoutes(mux, d)
 	registerSelfRoutes(mux, d, csrf)
 	registerSettingsRoutes(mux, d, csrf)
 	registerSimplePages(mux, d, []simplePage{
 		{"/logs", "Logs", "logs", "page.logs"},
 	})
 	registerAvatarRoutes(mux, d)
+	registerPWARoutes(mux, d)
+	registerSecretRoutes(mux, d)
 
 	// MQ SSE stub — signals rendezvous mode, then holds the connection open.
 	handleGet(mux, "/api/mq/events", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// Observation window of a reputation record, in days.
+const (
+	reputationDefaultDays = 30
+	reputationMaxDays     = 90
+)
+
+// registerReputationRoutes serves the abuse reports I file about peers and
+// the signed reputation records built from them and the audit log.
+func registerReputationRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil || d.Node == nil {
+		return
+	}
+
+	// GET /api/peers/reports — my abuse reports; ?peer=<id> for one peer's.
+	// POST /api/peers/reports — file a report.
+	handleGetPost(mux, "/api/peers/reports", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		reports, err := d.DB.ListPeerReports(r.URL.Query().Get("peer"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, reports)
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+		Reason string `json:"reason"`
+		Note   string `json:"note"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if _, err := peer.Decode(req.PeerID); err != nil {
+			http.Error(w, "invalid peer ID", http.StatusBadRequest)
+			return
+		}
+		if !utf8.ValidString(req.Note) || utf8.RuneCountInString(req.Note) > rendezvous.ReputationMaxNote {
+			http.Error(w, "note too long or not UTF-8", http.StatusBadRequest)
+			return
+		}
+		report, err := d.DB.FilePeerReport(req.PeerID, req.Reason, strings.TrimSpace(req.Note))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, report)
+	})
+
+	// POST /api/peers/reports/delete — withdraw a report
+	handlePost(mux, "/api/peers/reports/delete", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID int64 `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		deleted, err := d.DB.DeletePeerReport(req.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, "report not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]bool{"deleted": true})
+	})
+
+	// GET /api/peers/reputation?peer=<id>&days=30 — a signed record of what
+	// this peer saw of another, to share or attach to a report
+	handleGet(mux, "/api/peers/reputation", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+		rec, err := buildReputation(d.DB, d.Node, r.URL.Query().Get("peer"), days)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, rec)
+	})
+
+	// POST /api/peers/reputation/submit — sign a record and send it to the
+	// moderation queue of every rendezvous
+	handlePost(mux, "/api/peers/reputation/submit", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+		Days   int    `json:"days"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if len(d.RVClients) == 0 {
+			http.Error(w, "no rendezvous configured", http.StatusConflict)
+			return
+		}
+		rec, err := buildReputation(d.DB, d.Node, req.PeerID, req.Days)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		submitted, errs := 0, []string{}
+		for _, c := range d.RVClients {
+			ctx, cancel := context.WithTimeout(r.Context(), ReputationSubmitTimeout)
+			err := c.SubmitReputation(ctx, rec)
+			cancel()
+			if err != nil {
+				errs = append(errs, c.BaseURL+": "+err.Error())
+				continue
+			}
+			submitted++
+		}
+		if submitted == 0 {
+			http.Error(w, strings.Join(errs, "; "), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]any{"submitted": submitted, "errors": errs, "record": rec})
+	})
+}
+
+// buildReputation puts together and signs what this peer saw of subject
+// over the last days: its inbound streams from the audit log, and the
+// abuse reports filed about it.
+func buildReputation(db *storage.DB, node *p2p.Node, subject string, days int) (rendezvous.ReputationRecord, error) {
+	if _, err := peer.Decode(subject); err != nil {
+		return rendezvous.ReputationRecord{}, errors.New("invalid peer ID")
+	}
+	if subject == node.ID() {
+		return rendezvous.ReputationRecord{}, errors.New("cannot report yourself")
+	}
+	if days <= 0 {
+		days = reputationDefaultDays
+	}
+	days = min(days, reputationMaxDays)
+	now := time.Now()
+	rec := rendezvous.ReputationRecord{
+		Subject:  subject,
+		Reporter: node.ID(),
+		Since:    now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli(),
+		Until:    now.UnixMilli(),
+	}
+
+	all, err := db.PeerAuditStats(subject, "", rec.Since)
+	if err != nil {
+		return rec, err
+	}
+	mq, err := db.PeerAuditStats(subject, proto.MQProtoID, rec.Since)
+	if err != nil {
+		return rec, err
+	}
+	rec.Streams, rec.Denied, rec.Refused = all.Streams, all.Denied, all.Refused
+	rec.MQMessages, rec.MQPeakPerMinute = mq.Streams, mq.PeakPerMinute
+
+	reports, err := db.ListPeerReports(subject)
+	if err != nil {
+		return rec, err
+	}
+	for _, r := range reports {
+		if r.CreatedAt < rec.Since || len(rec.Reports) == rendezvous.ReputationMaxReports {
+			break
+		}
+		rec.Reports = append(rec.Reports, rendezvous.ReputationReport{Reason: r.Reason, Note: r.Note, At: r.CreatedAt})
+	}
+
+	rec.Sig, err = node.Sign(rec.SigningBytes())
+	return rec, err
+}
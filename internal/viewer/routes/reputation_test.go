@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func TestReputationRoutes(t *testing.T) {
+	d, _ := testDeps(t)
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	d.Node = &p2p.Node{Host: h}
+
+	var got []rendezvous.ReputationRecord
+	rv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec rendezvous.ReputationRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		got = append(got, rec)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer rv.Close()
+	old := httptest.NewServer(http.NotFoundHandler())
+	defer old.Close()
+	d.RVClients = []*rendezvous.Client{rendezvous.NewClient(rv.URL), rendezvous.NewClient(old.URL)}
+
+	mux := http.NewServeMux()
+	registerReputationRoutes(mux, d)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = "127.0.0.1:5000"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	const subject = "12D3KooWGzGzVn6UQ1ywy8iXohNHgH1iWeUJkf4Uz2sDkbqt1Zvd"
+
+	if w := do("POST", "/api/peers/reports", `{"peer_id":"`+subject+`","reason":"rude"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown reason: status = %d", w.Code)
+	}
+	if w := do("POST", "/api/peers/reports", `{"peer_id":"`+subject+`","reason":"spam","note":"floods the chat room"}`); w.Code != http.StatusOK {
+		t.Fatalf("file: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	now := time.Now().UnixMilli()
+	for i := range 3 {
+		d.DB.InsertAuditEntry(storage.AuditEntry{Timestamp: now - int64(i)*1000, Protocol: proto.MQProtoID, PeerID: subject, Outcome: "allowed"})
+	}
+	d.DB.InsertAuditEntry(storage.AuditEntry{Timestamp: now, Protocol: proto.DocsProtoID, PeerID: subject, Outcome: "denied"})
+
+	w := do("GET", "/api/peers/reputation?peer="+subject, "")
+	var rec rendezvous.ReputationRecord
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &rec) != nil {
+		t.Fatalf("export: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if err := rec.Verify(); err != nil {
+		t.Fatalf("exported record: %v", err)
+	}
+	if rec.Streams != 4 || rec.Denied != 1 || rec.MQMessages != 3 || len(rec.Reports) != 1 || rec.Reports[0].Reason != "spam" {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	w = do("POST", "/api/peers/reputation/submit", `{"peer_id":"`+subject+`","days":7}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"submitted":1`) || !strings.Contains(w.Body.String(), "does not accept") {
+		t.Fatalf("submit: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if len(got) != 1 || got[0].Verify() != nil || got[0].Until-got[0].Since != 7*24*time.Hour.Milliseconds() {
+		t.Fatalf("submitted = %+v", got)
+	}
+
+	if w := do("GET", "/api/peers/reputation?peer="+d.Node.ID(), ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("self report: status = %d", w.Code)
+	}
+}
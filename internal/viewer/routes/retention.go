@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/config"
+)
+
+func registerRetentionRoutes(mux *http.ServeMux, d Deps) {
+	if d.Retention == nil {
+		return
+	}
+
+	// POST /api/peers/forget — delete everything stored about one peer
+	handlePost(mux, "/api/peers/forget", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.PeerID == "" {
+			http.Error(w, "peer_id required", http.StatusBadRequest)
+			return
+		}
+		deleted, err := d.Retention.Forget(req.PeerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"deleted": deleted})
+	})
+
+	// GET /api/peers/retention — background pruning stats
+	handleGet(mux, "/api/peers/retention", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Retention.Stats())
+	})
+
+	// POST /api/peers/retention/run — prune now with the configured retention
+	handlePostAction(mux, "/api/peers/retention/run", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		var days int
+		if d.CfgPath != "" {
+			if cfg, err := config.LoadPartial(d.CfgPath); err == nil {
+				days = cfg.Viewer.PeerRetentionDays
+			}
+		}
+		if _, err := d.Retention.Prune(days, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, d.Retention.Stats())
+	})
+}
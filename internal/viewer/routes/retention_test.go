@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/retention"
+	"github.com/petervdpas/goop2/internal/state"
+)
+
+func TestForgetPeerRoute(t *testing.T) {
+	d, _ := testDeps(t)
+	d.Peers = state.NewPeerTable()
+	d.Retention = retention.New(d.DB, d.Peers, nil)
+	d.DB.StoreChatMessage("p1", "p1", "hi", 1000)
+	mux := http.NewServeMux()
+	registerRetentionRoutes(mux, d)
+
+	post := func(remote, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/api/peers/forget", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = remote
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("192.168.1.20:5000", `{"peer_id":"p1"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("remote forget: status = %d", w.Code)
+	}
+	if w := post("127.0.0.1:5000", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing peer: status = %d", w.Code)
+	}
+	w := post("127.0.0.1:5000", `{"peer_id":"p1"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"_chat_messages":1`) {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/rules"
+)
+
+func registerRuleRoutes(mux *http.ServeMux, d Deps) {
+	if d.Rules == nil {
+		return
+	}
+
+	// GET /api/rules — all automation rules with their last run
+	handleGet(mux, "/api/rules", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		list, err := d.Rules.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, list)
+	})
+
+	// POST /api/rules/save — create (no id) or update a rule
+	handlePost(mux, "/api/rules/save", func(w http.ResponseWriter, r *http.Request, req rules.Rule) {
+		if !requireLocal(w, r) {
+			return
+		}
+		saved, err := d.Rules.Save(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, saved)
+	})
+
+	// POST /api/rules/delete — remove a rule
+	handlePost(mux, "/api/rules/delete", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID string `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.Rules.Delete(req.ID); err != nil {
+			writeRuleError(w, err, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/rules/test — run a rule's action now, regardless of its trigger
+	handlePost(mux, "/api/rules/test", func(w http.ResponseWriter, r *http.Request, req struct {
+		ID string `json:"id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.Rules.Test(r.Context(), req.ID); err != nil {
+			// The action itself failed (webhook down, peer unreachable, ...).
+			writeRuleError(w, err, http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+}
+
+func writeRuleError(w http.ResponseWriter, err error, status int) {
+	if errors.Is(err, rules.ErrNoRule) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,129 @@
+// Keyword search across favorite and group peers over /goop/search.
+
+package routes
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/proto"
+)
+
+// networkSearchFanout caps how many peers are queried at once.
+const networkSearchFanout = 8
+
+type networkHit struct {
+	proto.SearchHit
+	PeerID    string `json:"peer_id"`
+	PeerLabel string `json:"peer_label"`
+	URL       string `json:"url,omitempty"` // viewer link for pages
+}
+
+type networkPeer struct {
+	PeerID string `json:"peer_id"`
+	Label  string `json:"label"`
+	Status string `json:"status"` // "ok", "error" or "timeout"
+	Hits   int    `json:"hits"`
+	Error  string `json:"error,omitempty"`
+	Millis int64  `json:"ms"`
+}
+
+func registerSearchRoutes(mux *http.ServeMux, d Deps) {
+	if d.Node == nil || d.Peers == nil {
+		return
+	}
+
+	// GET /api/search/network?q=...&limit=N — query every reachable
+	// favorite and group peer and merge their hits by score. Each peer
+	// only answers from what it exposes (p2p.search_expose).
+	handleGet(mux, "/api/search/network", func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" || len(q) > p2p.SearchMaxQuery {
+			http.Error(w, "q is required (max 200 characters)", http.StatusBadRequest)
+			return
+		}
+		limit := 20
+		if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+			limit = min(n, p2p.SearchMaxLimit)
+		}
+
+		targets := searchTargets(d)
+		req := proto.SearchRequest{Query: q, Limit: limit}
+
+		var (
+			mu    sync.Mutex
+			wg    sync.WaitGroup
+			hits  = []networkHit{}
+			peers = make([]networkPeer, len(targets))
+			sem   = make(chan struct{}, networkSearchFanout)
+		)
+		for i, pid := range targets {
+			wg.Add(1)
+			go func(i int, pid string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				label := pid
+				if d.ResolvePeer != nil {
+					if n := d.ResolvePeer(pid).Name(); n != "" {
+						label = n
+					}
+				}
+				ctx, cancel := context.WithTimeout(r.Context(), NetworkSearchTimeout)
+				defer cancel()
+				start := time.Now()
+				got, err := d.Node.SearchPeer(ctx, pid, req)
+
+				np := networkPeer{PeerID: pid, Label: label, Status: "ok", Hits: len(got), Millis: time.Since(start).Milliseconds()}
+				switch {
+				case ctx.Err() == context.DeadlineExceeded:
+					np.Status = "timeout"
+				case err != nil:
+					np.Status, np.Error = "error", err.Error()
+				}
+				peers[i] = np
+
+				mu.Lock()
+				for _, h := range got {
+					nh := networkHit{SearchHit: h, PeerID: pid, PeerLabel: label}
+					if h.Kind == "page" {
+						nh.URL = "/p/" + pid + h.Path
+					}
+					hits = append(hits, nh)
+				}
+				mu.Unlock()
+			}(i, pid)
+		}
+		wg.Wait()
+
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		if len(hits) > limit {
+			hits = hits[:limit]
+		}
+		writeJSON(w, map[string]any{"query": q, "results": hits, "peers": peers})
+	})
+}
+
+// searchTargets lists the reachable peers that are favorites or share a
+// group with us.
+func searchTargets(d Deps) []string {
+	self := d.Node.ID()
+	var out []string
+	for id, sp := range d.Peers.Snapshot() {
+		if id == self || !sp.Reachable {
+			continue
+		}
+		if sp.Favorite || d.GroupManager != nil && d.GroupManager.SharesGroupWith(id) {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
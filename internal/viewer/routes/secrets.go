@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/secrets"
+)
+
+// secretRotated is a rotated secret with its new value, which is shown
+// only in this response.
+type secretRotated struct {
+	secrets.Info
+	Value string `json:"value"`
+}
+
+func registerSecretRoutes(mux *http.ServeMux, d Deps) {
+	if d.Secrets == nil {
+		return
+	}
+
+	// GET /api/secrets — names and versions, never values
+	handleGet(mux, "/api/secrets", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		list, err := d.Secrets.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, list)
+	})
+
+	// POST /api/secrets/set — store a value; replacing one is a rotation
+	handlePost(mux, "/api/secrets/set", func(w http.ResponseWriter, r *http.Request, req struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		info, err := d.Secrets.Set(req.Name, req.Value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, info)
+	})
+
+	// POST /api/secrets/rotate — replace a secret with a new random token
+	handlePost(mux, "/api/secrets/rotate", func(w http.ResponseWriter, r *http.Request, req struct {
+		Name string `json:"name"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		value, info, err := d.Secrets.Rotate(req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store") // keeps the value out of the idempotency cache
+		writeJSON(w, secretRotated{Info: info, Value: value})
+	})
+
+	// POST /api/secrets/delete — remove a secret
+	handlePost(mux, "/api/secrets/delete", func(w http.ResponseWriter, r *http.Request, req struct {
+		Name string `json:"name"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if err := d.Secrets.Delete(req.Name); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, secrets.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func registerSecurityRoutes(mux *http.ServeMux, d Deps) {
+	if d.DB == nil {
+		return
+	}
+
+	// GET /api/security/audit?peer_id=&protocol=&outcome=&since=&until=&limit=
+	// — inbound P2P stream audit log, newest first.
+	handleGet(mux, "/api/security/audit", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := storage.AuditFilter{
+			PeerID:   q.Get("peer_id"),
+			Protocol: q.Get("protocol"),
+			Outcome:  q.Get("outcome"),
+		}
+		f.Since, _ = strconv.ParseInt(q.Get("since"), 10, 64)
+		f.Until, _ = strconv.ParseInt(q.Get("until"), 10, 64)
+		f.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+		entries, err := d.DB.QueryAudit(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, entries)
+	})
+
+	if d.Node == nil {
+		return
+	}
+
+	// GET /api/security/consent — parked prompts and remembered decisions
+	// POST /api/security/consent — answer a parked prompt (once, always, block, deny)
+	handleGetPost(mux, "/api/security/consent", func(w http.ResponseWriter, r *http.Request) {
+		decisions, err := d.DB.ListAccessConsents()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"pending":   d.Node.Consent().Pending(),
+			"decisions": decisions,
+		})
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		ID       string `json:"id"`
+		Decision string `json:"decision"`
+	}) {
+		if req.ID == "" {
+			http.Error(w, "missing id", http.StatusBadRequest)
+			return
+		}
+		if err := d.Node.Consent().Decide(req.ID, req.Decision); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+
+	// POST /api/security/consent/forget — drop a remembered decision so the peer is asked again
+	handlePost(mux, "/api/security/consent/forget", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		if req.PeerID == "" {
+			http.Error(w, "missing peer_id", http.StatusBadRequest)
+			return
+		}
+		if err := d.DB.DeleteAccessConsent(req.PeerID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+}
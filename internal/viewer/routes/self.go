@@ -28,10 +28,20 @@ func registerSelfRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			return
 		}
 
+		var warnings []config.Issue
+		if issues, err := config.Check(d.CfgPath); err == nil {
+			for _, is := range issues {
+				if is.Warning {
+					warnings = append(warnings, is)
+				}
+			}
+		}
+
 		vm := viewmodels.SettingsVM{
 			BaseVM:     baseVM("Me", "self", "page.self", d),
 			CfgPath:    d.CfgPath,
 			AvatarHash: avatarHash,
+			Warnings:   warnings,
 			Cfg:        cfg,
 			Saved:      (r.URL.Query().Get("saved") == "1"),
 			CSRF:       csrf,
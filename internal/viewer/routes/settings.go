@@ -3,6 +3,7 @@ package routes
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -42,6 +43,7 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			http.Error(w, "failed to load config", http.StatusInternalServerError)
 			return
 		}
+		base := cfg
 
 		if req.Label != nil {
 			cfg.Profile.Label = strings.TrimSpace(*req.Label)
@@ -80,7 +82,7 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			cfg.P2P.BridgeMode = *req.BridgeMode
 		}
 
-		if err := config.Save(d.CfgPath, cfg); err != nil {
+		if _, err := config.SaveMerged(d.CfgPath, base, cfg); err != nil {
 			http.Error(w, "failed to save", http.StatusInternalServerError)
 			return
 		}
@@ -114,6 +116,9 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			http.Error(w, "failed to load config: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// Only the fields the form changes are written, so a template
+		// applied or Lua enabled meanwhile is not undone.
+		base := cfg
 
 		// Profile, theme, and devices are managed via /api/settings/quick (navbar popup).
 		cfg.Viewer.HTTPAddr = getTrimmedPostFormValue(r.PostForm, "viewer_http_addr")
@@ -124,6 +129,17 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		if v := atoiOrNeg(getTrimmedPostFormValue(r.PostForm, "viewer_peer_offline_grace_min")); v >= 1 && v <= 60 {
 			cfg.Viewer.PeerOfflineGraceMin = v
 		}
+		if v := atoiOrNeg(getTrimmedPostFormValue(r.PostForm, "viewer_peer_retention_days")); v >= 0 {
+			cfg.Viewer.PeerRetentionDays = v
+		}
+		if v := atoiOrNeg(getTrimmedPostFormValue(r.PostForm, "viewer_max_tracked_peers")); v == 0 || (v >= 50 && v <= 100000) {
+			cfg.Viewer.MaxTrackedPeers = v
+		}
+
+		// Only in the form when not in rendezvous-only mode; empty turns it off.
+		if _, ok := r.PostForm["viewer_remote_addr"]; ok {
+			cfg.Viewer.RemoteAddr = getTrimmedPostFormValue(r.PostForm, "viewer_remote_addr")
+		}
 
 		if sp := getTrimmedPostFormValue(r.PostForm, "viewer_splash"); sp != "" {
 			cfg.Viewer.Splash = sp
@@ -138,6 +154,19 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		}
 		cfg.P2P.BridgeMode = formBool(r.PostForm, "p2p_bridge_mode")
 		cfg.Profile.BridgeToken = getTrimmedPostFormValue(r.PostForm, "profile_bridge_token")
+		switch {
+		case !formBool(r.PostForm, "p2p_diag_enabled"):
+			cfg.P2P.DiagAccess = "off"
+		case formBool(r.PostForm, "p2p_diag_logs"):
+			cfg.P2P.DiagAccess = "full"
+		default:
+			cfg.P2P.DiagAccess = "connectivity"
+		}
+		cfg.Presence.PublicSiteMirror = formBool(r.PostForm, "presence_public_site_mirror")
+		if _, ok := r.PostForm["p2p_serve_limit_kbps"]; ok {
+			cfg.P2P.ServeLimitKBps = atoiOrNeg(getTrimmedPostFormValue(r.PostForm, "p2p_serve_limit_kbps"))
+			cfg.P2P.ServePeerLimitKBps = atoiOrNeg(getTrimmedPostFormValue(r.PostForm, "p2p_serve_peer_limit_kbps"))
+		}
 		if ttl := getTrimmedPostFormValue(r.PostForm, "presence_ttl_sec"); ttl != "" {
 			cfg.Presence.TTLSec = atoiOrNeg(ttl)
 		}
@@ -173,10 +202,29 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		} else {
 			cfg.Presence.RelayWSPort = 0
 		}
+		if sp := getTrimmedPostFormValue(r.PostForm, "presence_stun_port"); sp != "" {
+			cfg.Presence.STUNPort, _ = strconv.Atoi(sp)
+		} else {
+			cfg.Presence.STUNPort = 0
+		}
 		if rkf := getTrimmedPostFormValue(r.PostForm, "presence_relay_key_file"); rkf != "" {
 			cfg.Presence.RelayKeyFile = rkf
 		}
 
+		// Label policy (only in the form when the server is enabled)
+		if _, ok := r.PostForm["presence_label_max_len"]; ok {
+			cfg.Presence.LabelMaxLen, _ = strconv.Atoi(getTrimmedPostFormValue(r.PostForm, "presence_label_max_len"))
+			cfg.Presence.LabelMaxEmoji, _ = strconv.Atoi(getTrimmedPostFormValue(r.PostForm, "presence_label_max_emoji"))
+			cfg.Presence.LabelBannedWords = formLines(r.PostForm, "presence_label_banned_words")
+			cfg.Presence.LabelBannedPatterns = formLines(r.PostForm, "presence_label_banned_patterns")
+			cfg.Presence.LabelStripURLs = formBool(r.PostForm, "presence_label_strip_urls")
+			if formBool(r.PostForm, "presence_label_reject") {
+				cfg.Presence.LabelPolicy = "reject"
+			} else {
+				cfg.Presence.LabelPolicy = ""
+			}
+		}
+
 		// Services toggle + URLs + admin tokens
 		cfg.Presence.UseServices = formBool(r.PostForm, "presence_use_services")
 		cfg.Presence.CreditsURL = getTrimmedPostFormValue(r.PostForm, "presence_credits_url")
@@ -194,7 +242,7 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 
 		cfg.Lua.Enabled = formBool(r.PostForm, "lua_enabled")
 
-		if err := config.Save(d.CfgPath, cfg); err != nil {
+		if _, err := config.SaveMerged(d.CfgPath, base, cfg); err != nil {
 			vm := viewmodels.SettingsVM{
 				BaseVM:  baseVM("Me", "self", "page.self", d),
 				CfgPath: d.CfgPath,
@@ -205,10 +253,75 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			render.Render(w, vm)
 			return
 		}
-
 		http.Redirect(w, r, "/self?saved=1#settings", http.StatusFound)
 	})
 
+	// GET /api/config/validate — every problem in goop.json; POST checks a
+	// config document before it is written.
+	handleAny(mux, "/api/config/validate", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		var issues []config.Issue
+		switch r.Method {
+		case http.MethodGet:
+			var err error
+			if issues, err = config.Check(d.CfgPath); err != nil {
+				http.Error(w, "failed to read config: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case http.MethodPost:
+			b, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+			if err != nil {
+				http.Error(w, "failed to read body", http.StatusBadRequest)
+				return
+			}
+			issues = config.CheckJSON(b)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		resp := configValidation{Valid: true, Errors: []config.Issue{}, Warnings: []config.Issue{}}
+		for _, is := range issues {
+			if is.Warning {
+				resp.Warnings = append(resp.Warnings, is)
+			} else {
+				resp.Errors = append(resp.Errors, is)
+				resp.Valid = false
+			}
+		}
+		writeJSON(w, resp)
+	})
+
+	// Deployment presets (see config.Presets). Applying one rewrites only
+	// the ports, discovery, relay and service settings; they take effect
+	// after a restart.
+	handleGet(mux, "/api/config/presets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, config.Presets())
+	})
+
+	handlePost(mux, "/api/config/preset", func(w http.ResponseWriter, r *http.Request, req struct {
+		Name string `json:"name"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		var applyErr error
+		_, err := config.Update(d.CfgPath, func(c *config.Config) error {
+			applyErr = config.ApplyPreset(c, req.Name)
+			return applyErr
+		})
+		if applyErr != nil {
+			http.Error(w, applyErr.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to save: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"status": "ok", "preset": req.Name, "restart_required": true})
+	})
+
 	// Health check endpoint for external services
 	handleGet(mux, "/api/services/health", func(w http.ResponseWriter, r *http.Request) {
 		cfg, err := config.Load(d.CfgPath)
@@ -239,7 +352,7 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 	})
 
 	// Request a bridge token — peer must be verified.
-	mux.HandleFunc("/api/bridge/request-token", func(w http.ResponseWriter, r *http.Request) {
+	handleAny(mux, "/api/bridge/request-token", func(w http.ResponseWriter, r *http.Request) {
 		if !requireMethod(w, r, http.MethodPost) {
 			return
 		}
@@ -356,3 +469,10 @@ func registerSettingsRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		writeJSON(w, result)
 	})
 }
+
+// configValidation is the response of /api/config/validate.
+type configValidation struct {
+	Valid    bool           `json:"valid"`
+	Errors   []config.Issue `json:"errors"`
+	Warnings []config.Issue `json:"warnings"`
+}
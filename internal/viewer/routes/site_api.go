@@ -145,4 +145,14 @@ func registerSiteAPIRoutes(mux *http.ServeMux, d Deps) {
 			"status": "deleted",
 		})
 	})
+
+	// Upstream traffic spent serving the site and docs to other peers,
+	// with the configured limits
+	handleGet(mux, "/api/site/analytics", func(w http.ResponseWriter, r *http.Request) {
+		if d.Node == nil {
+			http.Error(w, "p2p node not available", http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, d.Node.ServeStats())
+	})
 }
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/siteassets"
+)
+
+func registerSiteAssetRoutes(mux *http.ServeMux, d Deps) {
+	if d.Assets == nil {
+		return
+	}
+
+	// GET /api/site/assets — statistics of the last asset build
+	handleGet(mux, "/api/site/assets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Assets.Stats())
+	})
+
+	// POST /api/site/assets/rebuild — rescan the site now instead of waiting
+	// for the next background pass
+	handlePostAction(mux, "/api/site/assets/rebuild", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		cfg := config.Default()
+		if d.CfgPath != "" {
+			if live, err := config.LoadPartial(d.CfgPath); err == nil {
+				cfg = live
+			}
+		}
+		st, err := d.Assets.Build(siteassets.Options{
+			Enabled: cfg.Assets.Enabled,
+			Widths:  cfg.Assets.Widths,
+			Quality: cfg.Assets.Quality,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, st)
+	})
+}
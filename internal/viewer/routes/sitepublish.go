@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/sdk"
+	"github.com/petervdpas/goop2/internal/sitepublish"
+)
+
+// publishTimeout bounds one push of the static bundle.
+const publishTimeout = 10 * time.Minute
+
+func registerSitePublishRoutes(mux *http.ServeMux, d Deps, csrf string) {
+	// POST /api/site/publish — push the static bundle to the configured
+	// SFTP server or S3 bucket
+	handlePost(mux, "/api/site/publish", func(w http.ResponseWriter, r *http.Request, req struct {
+		CSRF string `json:"csrf"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.CSRF != csrf {
+			http.Error(w, "bad csrf", http.StatusForbidden)
+			return
+		}
+		cfg, err := config.LoadPartial(d.CfgPath)
+		if err != nil {
+			http.Error(w, "failed to load config: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pub := cfg.Publish
+		if pub.Target == "" {
+			http.Error(w, "no publish target configured", http.StatusBadRequest)
+			return
+		}
+
+		files := buildStaticSite(r.Context(), d)
+		ctx, cancel := context.WithTimeout(r.Context(), publishTimeout)
+		defer cancel()
+		start := time.Now()
+		switch pub.Target {
+		case "sftp":
+			keyFile := pub.SFTP.KeyFile
+			if keyFile != "" && !filepath.IsAbs(keyFile) && d.PeerDir != "" {
+				keyFile = filepath.Join(d.PeerDir, keyFile)
+			}
+			err = sitepublish.PushSFTP(ctx, sitepublish.SFTPTarget{
+				Host:     pub.SFTP.Host,
+				Port:     pub.SFTP.Port,
+				User:     pub.SFTP.User,
+				Password: pub.SFTP.Password,
+				KeyFile:  keyFile,
+				HostKey:  pub.SFTP.HostKey,
+				Dir:      pub.SFTP.Dir,
+			}, files)
+		case "s3":
+			err = sitepublish.PushS3(ctx, sitepublish.S3Target{
+				Endpoint:  pub.S3.Endpoint,
+				Region:    pub.S3.Region,
+				Bucket:    pub.S3.Bucket,
+				Prefix:    pub.S3.Prefix,
+				AccessKey: pub.S3.AccessKey,
+				SecretKey: pub.S3.SecretKey,
+			}, files)
+		default:
+			err = fmt.Errorf("unknown publish target %q", pub.Target)
+		}
+		if err != nil {
+			http.Error(w, "publish failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"status":      "published",
+			"target":      pub.Target,
+			"files":       len(files),
+			"bytes":       sitepublish.Size(files),
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+	})
+}
+
+// buildStaticSite returns the site as a static bundle, as visitors see it.
+func buildStaticSite(ctx context.Context, d Deps) []sitepublish.File {
+	site := collectSiteFiles(ctx, d)
+	files := make([]sitepublish.File, 0, len(site))
+	for rel, data := range site {
+		files = append(files, sitepublish.File{Path: rel, Data: data})
+	}
+	var assets sitepublish.Assets
+	if d.Assets != nil {
+		assets = d.Assets
+	}
+	return sitepublish.Build(files, assets, sdk.File)
+}
+
+// serveStaticExport answers GET /api/site/export?format=static.
+func serveStaticExport(w http.ResponseWriter, r *http.Request, d Deps) {
+	files := buildStaticSite(r.Context(), d)
+	var buf bytes.Buffer
+	if err := sitepublish.WriteZip(&buf, files); err != nil {
+		http.Error(w, "failed to create zip: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	label := "site"
+	if d.SelfLabel != nil {
+		if l := d.SelfLabel(); l != "" {
+			label = l
+		}
+	}
+	filename := fmt.Sprintf("goop-static-%s-%s.zip", label, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+func registerSiteSyncRoutes(mux *http.ServeMux, d Deps) {
+	if d.SiteSync == nil {
+		return
+	}
+
+	// GET /api/peers/follows — followed sites and the state of their copies
+	handleGet(mux, "/api/peers/follows", func(w http.ResponseWriter, r *http.Request) {
+		follows, err := d.SiteSync.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if follows == nil {
+			follows = []storage.SiteFollow{}
+		}
+		writeJSON(w, follows)
+	})
+
+	// POST /api/peers/follow — keep a copy of a peer's site; the first sync starts right away
+	handlePost(mux, "/api/peers/follow", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.PeerID == "" {
+			http.Error(w, "peer_id required", http.StatusBadRequest)
+			return
+		}
+		f, err := d.SiteSync.Follow(req.PeerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		go func() {
+			if _, err := d.SiteSync.Sync(context.Background(), req.PeerID); err != nil {
+				log.Printf("sitesync: first sync of %s: %v", req.PeerID, err)
+			}
+		}()
+		writeJSON(w, f)
+	})
+
+	// POST /api/peers/unfollow — stop following and delete the copy
+	handlePost(mux, "/api/peers/unfollow", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.PeerID == "" {
+			http.Error(w, "peer_id required", http.StatusBadRequest)
+			return
+		}
+		removed, err := d.SiteSync.Unfollow(req.PeerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]bool{"removed": removed})
+	})
+
+	// POST /api/peers/follows/sync — sync one followed site now
+	handlePost(mux, "/api/peers/follows/sync", func(w http.ResponseWriter, r *http.Request, req struct {
+		PeerID string `json:"peer_id"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.PeerID == "" {
+			http.Error(w, "peer_id required", http.StatusBadRequest)
+			return
+		}
+		f, err := d.SiteSync.Sync(r.Context(), req.PeerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, f)
+	})
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petervdpas/goop2/internal/proto"
+	"github.com/petervdpas/goop2/internal/sitesync"
+	"github.com/petervdpas/goop2/internal/state"
+)
+
+type offlineSite struct{}
+
+func (offlineSite) SiteManifest(ctx context.Context, peerID string) (proto.SiteSyncResponse, error) {
+	return proto.SiteSyncResponse{}, errors.New("peer unreachable")
+}
+
+func (offlineSite) FetchSiteSyncFile(ctx context.Context, peerID, path string) ([]byte, error) {
+	return nil, errors.New("peer unreachable")
+}
+
+func TestSiteSyncRoutes(t *testing.T) {
+	d, dir := testDeps(t)
+	d.SiteSync = sitesync.New(d.DB, state.NewPeerTable(), offlineSite{}, filepath.Join(dir, "cache", "sites"))
+	mux := http.NewServeMux()
+	registerSiteSyncRoutes(mux, d)
+
+	do := func(method, path, remote, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = remote
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	const id = "12D3KooWGzGzVn6UQ1ywy8iXohNHgH1iWeUJkf4Uz2sDkbqt1Zvd"
+
+	if w := do("POST", "/api/peers/follow", "192.168.1.20:5000", `{"peer_id":"`+id+`"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("remote follow: status = %d", w.Code)
+	}
+	if w := do("POST", "/api/peers/follow", "127.0.0.1:5000", `{"peer_id":"nope"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad peer: status = %d", w.Code)
+	}
+	if w := do("POST", "/api/peers/follow", "127.0.0.1:5000", `{"peer_id":"`+id+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("follow: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	w := do("GET", "/api/peers/follows", "127.0.0.1:5000", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), id) {
+		t.Fatalf("list: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/peers/follows/sync", "127.0.0.1:5000", `{"peer_id":"`+id+`"}`); w.Code != http.StatusBadGateway {
+		t.Fatalf("sync of an offline peer: status = %d", w.Code)
+	}
+	w = do("POST", "/api/peers/unfollow", "127.0.0.1:5000", `{"peer_id":"`+id+`"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"removed":true`) {
+		t.Fatalf("unfollow: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
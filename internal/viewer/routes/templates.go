@@ -3,15 +3,20 @@ package routes
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"log"
@@ -103,6 +108,7 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			HasCredits:          storePrices != nil,
 			StoreError:          storeError,
 			ActiveTemplate:      activeTemplate,
+			CanPublish:          len(d.RVClients) > 0,
 		}
 		render.Render(w, vm)
 	})
@@ -134,6 +140,11 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		schema, _ := sitetemplates.Schema(req.Template)
 		meta, _ := sitetemplates.GetMeta(req.Template)
 
+		if err := snapshotBeforeApply(d, req.Template); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		var tablePolicies map[string]string
 		if len(meta.Tables) > 0 {
 			tablePolicies = make(map[string]string)
@@ -154,12 +165,13 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		setTemplateSource(d, "builtin")
 
 		// Save active template to config
-		if cfg, err := config.Load(d.CfgPath); err == nil {
+		config.Update(d.CfgPath, func(cfg *config.Config) error {
 			cfg.Viewer.ActiveTemplate = req.Template
-			config.Save(d.CfgPath, cfg)
-		}
+			return nil
+		})
 
 		writeJSON(w, map[string]string{
 			"status":   "applied",
@@ -236,31 +248,13 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			return
 		}
 
-		var schema string
-		var manifest rendezvous.StoreMeta
-		siteFiles := make(map[string][]byte)
-
-		for rel, data := range allFiles {
-			switch rel {
-			case "schema.sql":
-				schema = string(data)
-			case "manifest.json":
-				json.Unmarshal(data, &manifest)
-			default:
-				siteFiles[rel] = data
-			}
-		}
+		siteFiles, schema, manifest := splitTemplateBundle(allFiles)
+		tablePolicies := manifestTablePolicies(manifest)
 
-		var tablePolicies map[string]string
-		if len(manifest.Tables) > 0 {
-			tablePolicies = make(map[string]string)
-			for name, tp := range manifest.Tables {
-				if tp.InsertPolicy != "" {
-					tablePolicies[name] = tp.InsertPolicy
-				}
-			}
+		if err := snapshotBeforeApply(d, "local:"+filepath.Base(req.Path)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-
 		if d.DB != nil {
 			if b, err := json.Marshal(manifest); err == nil {
 				d.DB.SetMeta("template_manifest", string(b))
@@ -270,11 +264,12 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		setTemplateSource(d, "local")
 
-		if cfg, err := config.Load(d.CfgPath); err == nil {
+		config.Update(d.CfgPath, func(cfg *config.Config) error {
 			cfg.Viewer.ActiveTemplate = "local:" + filepath.Base(req.Path)
-			config.Save(d.CfgPath, cfg)
-		}
+			return nil
+		})
 
 		writeJSON(w, map[string]string{
 			"status":   "applied",
@@ -282,10 +277,141 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		})
 	})
 
+	// POST /api/templates/publish — publish a local template folder to the
+	// template store of every rendezvous, signed with this peer's key.
+	// The folder name becomes the template's dir in the store.
+	handlePost(mux, "/api/templates/publish", func(w http.ResponseWriter, r *http.Request, req struct {
+		Path string `json:"path"`
+		CSRF string `json:"csrf"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.CSRF != csrf {
+			http.Error(w, "bad csrf", http.StatusForbidden)
+			return
+		}
+		if req.Path == "" {
+			http.Error(w, "path required", http.StatusBadRequest)
+			return
+		}
+		if !filepath.IsAbs(req.Path) || strings.Contains(req.Path, "..") {
+			http.Error(w, "invalid path", http.StatusBadRequest)
+			return
+		}
+		if len(d.RVClients) == 0 || d.Node == nil {
+			http.Error(w, "no rendezvous server configured", http.StatusServiceUnavailable)
+			return
+		}
+		dir := filepath.Base(req.Path)
+		if !rendezvous.ValidPublishDir(dir) {
+			http.Error(w, "folder name must be lowercase letters, digits, - or _", http.StatusBadRequest)
+			return
+		}
+		files, err := readLocalTemplateDir(req.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bundle, err := packTemplateBundle(dir, files)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		type publishResult struct {
+			Server  string `json:"server"`
+			Version string `json:"version,omitempty"`
+			Error   string `json:"error,omitempty"`
+		}
+		results := make([]publishResult, 0, len(d.RVClients))
+		for _, c := range d.RVClients {
+			ctx, cancel := context.WithTimeout(r.Context(), TemplatePublishTimeout)
+			meta, err := c.PublishTemplate(ctx, d.Node.ID(), bundle, d.Node.Sign)
+			cancel()
+			res := publishResult{Server: c.BaseURL, Version: meta.Version}
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				log.Printf("template: published %q to %s", dir, c.BaseURL)
+			}
+			results = append(results, res)
+		}
+		writeJSON(w, map[string]any{"template": dir, "results": results})
+	})
+
+	// POST /api/templates/install — open an install session on the
+	// rendezvous, so the page can follow the apply-store that comes next.
+	// An empty session means the rendezvous cannot report progress.
+	handlePost(mux, "/api/templates/install", func(w http.ResponseWriter, r *http.Request, req struct {
+		Template string `json:"template"`
+		CSRF     string `json:"csrf"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.CSRF != csrf {
+			http.Error(w, "bad csrf", http.StatusForbidden)
+			return
+		}
+		session := ""
+		if len(d.RVClients) > 0 && d.Node != nil {
+			ctx, cancel := context.WithTimeout(r.Context(), ProxyTimeout)
+			defer cancel()
+			s, err := d.RVClients[0].StartInstall(ctx, req.Template, d.Node.ID())
+			if err != nil {
+				log.Printf("template: install session for %q: %v", req.Template, err)
+			}
+			session = s
+		}
+		writeJSON(w, map[string]string{"session": session})
+	})
+
+	// GET /api/templates/install/events?session=<id> — the install
+	// session's progress, relayed from the rendezvous as SSE.
+	handleGet(mux, "/api/templates/install/events", func(w http.ResponseWriter, r *http.Request) {
+		if !requireLocal(w, r) {
+			return
+		}
+		session := r.URL.Query().Get("session")
+		if session == "" {
+			http.Error(w, "session required", http.StatusBadRequest)
+			return
+		}
+		if len(d.RVClients) == 0 {
+			http.Error(w, "no rendezvous server configured", http.StatusServiceUnavailable)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		stream, err := d.RVClients[0].InstallEvents(r.Context(), session, r.Header.Get("Last-Event-ID"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer stream.Close()
+		sseHeaders(w)
+		buf := make([]byte, 4096)
+		for {
+			n, err := stream.Read(buf)
+			if n > 0 {
+				w.Write(buf[:n])
+				flusher.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+
 	// POST /api/templates/apply-store — apply a store template (resets site + db)
 	handlePost(mux, "/api/templates/apply-store", func(w http.ResponseWriter, r *http.Request, req struct {
 		Template string `json:"template"`
 		CSRF     string `json:"csrf"`
+		Session  string `json:"session"`
 	}) {
 		if !requireLocal(w, r) {
 			return
@@ -306,14 +432,22 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		if d.Node != nil {
 			peerID = d.Node.ID()
 		}
+		report := newInstallReport(d, req.Session, peerID)
 
 		// Spend credits (deduct + grant ownership) before downloading.
 		// If the template is free or already owned, this is a no-op.
 		var spendResult *rendezvous.SpendResult
 		if len(d.RVClients) > 0 {
 			sr, err := d.RVClients[0].SpendCredits(ctx, req.Template, peerID)
+			var netErr net.Error
+			if err != nil && errors.As(err, &netErr) && d.RVClients[0].TemplateCached(req.Template) {
+				// Offline: a cached bundle was downloaded with access before.
+				log.Printf("credits: rendezvous unreachable, applying cached %q: %v", req.Template, err)
+				sr, err = nil, nil
+			}
 			if err != nil {
 				log.Printf("credits: spend failed for %q peer=%s: %v", req.Template, peerID, err)
+				report.send(rendezvous.InstallFailed, err)
 				http.Error(w, err.Error(), http.StatusPaymentRequired)
 				return
 			}
@@ -325,69 +459,39 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 			spendResult = sr
 		}
 
-		// Download bundle from first rendezvous that has it
-		var body io.ReadCloser
-		var dlErr error
-		for _, c := range d.RVClients {
-			body, dlErr = c.DownloadTemplateBundle(ctx, req.Template, peerID)
-			if dlErr == nil {
-				break
-			}
-		}
-		if dlErr != nil {
-			http.Error(w, "failed to download template: "+dlErr.Error(), http.StatusBadGateway)
-			return
-		}
-		defer body.Close()
-
-		// Extract tar.gz into memory
-		allFiles, err := extractTarGz(body)
+		allFiles, status, err := downloadStoreTemplate(ctx, d, req.Template, peerID, req.Session)
 		if err != nil {
-			http.Error(w, "failed to extract template: "+err.Error(), http.StatusInternalServerError)
+			report.send(rendezvous.InstallFailed, err)
+			http.Error(w, err.Error(), status)
 			return
 		}
+		report.send(rendezvous.InstallApplying, nil)
+		siteFiles, schema, manifest := splitTemplateBundle(allFiles)
+		tablePolicies := manifestTablePolicies(manifest)
 
-		// Separate site files, schema, and manifest
-		var schema string
-		var manifest rendezvous.StoreMeta
-		siteFiles := make(map[string][]byte)
-
-		for rel, data := range allFiles {
-			switch rel {
-			case "schema.sql":
-				schema = string(data)
-			case "manifest.json":
-				json.Unmarshal(data, &manifest)
-			default:
-				siteFiles[rel] = data
-			}
-		}
-
-		var tablePolicies map[string]string
-		if len(manifest.Tables) > 0 {
-			tablePolicies = make(map[string]string)
-			for name, tp := range manifest.Tables {
-				if tp.InsertPolicy != "" {
-					tablePolicies[name] = tp.InsertPolicy
-				}
-			}
+		if err := snapshotBeforeApply(d, req.Template); err != nil {
+			report.send(rendezvous.InstallFailed, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-
 		if d.DB != nil {
 			if b, err := json.Marshal(manifest); err == nil {
 				d.DB.SetMeta("template_manifest", string(b))
 			}
 		}
 		if err := applyTemplateFiles(d, siteFiles, schema, tablePolicies, manifest.Name, manifest.Schemas, manifest.RequireEmail, manifest.DefaultRole); err != nil {
+			report.send(rendezvous.InstallFailed, err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		setTemplateSource(d, "store")
+		report.send(rendezvous.InstallApplied, nil)
 
 		// Save active template to config
-		if cfg, err := config.Load(d.CfgPath); err == nil {
+		config.Update(d.CfgPath, func(cfg *config.Config) error {
 			cfg.Viewer.ActiveTemplate = req.Template
-			config.Save(d.CfgPath, cfg)
-		}
+			return nil
+		})
 
 		resp := map[string]interface{}{
 			"status":   "applied",
@@ -399,6 +503,100 @@ func registerTemplateRoutes(mux *http.ServeMux, d Deps, csrf string) {
 		writeJSON(w, resp)
 	})
 
+	// GET /api/templates/update — installed vs. latest store version of the
+	// active template, as last seen by the background update check.
+	// POST /api/templates/update — re-download the active store template and
+	// re-apply it over the site, keeping user content and table data.
+	handleGetPost(mux, "/api/templates/update", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, templateUpdateStatus(d))
+	}, func(w http.ResponseWriter, r *http.Request, req struct {
+		CSRF string `json:"csrf"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.CSRF != csrf {
+			http.Error(w, "bad csrf", http.StatusForbidden)
+			return
+		}
+		st := templateUpdateStatus(d)
+		if st.Source != "store" || st.Template == "" {
+			http.Error(w, "active template is not from the store", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), TemplateBundleTimeout)
+		defer cancel()
+		var peerID string
+		if d.Node != nil {
+			peerID = d.Node.ID()
+		}
+		allFiles, status, err := downloadStoreTemplate(ctx, d, st.Template, peerID, "")
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		siteFiles, _, manifest := splitTemplateBundle(allFiles)
+		if err := snapshotBeforeApply(d, st.Template); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := updateTemplateFiles(d, siteFiles, manifest.Schemas); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if d.DB != nil {
+			if b, err := json.Marshal(manifest); err == nil {
+				d.DB.SetMeta("template_manifest", string(b))
+			}
+		}
+		log.Printf("template: updated %q from %s to %s", st.Template, st.Installed, manifest.Version)
+
+		writeJSON(w, map[string]string{
+			"status":   "updated",
+			"template": st.Template,
+			"version":  manifest.Version,
+		})
+	})
+
+	// GET /api/templates/snapshots — snapshots taken before template applies,
+	// newest first.
+	handleGet(mux, "/api/templates/snapshots", func(w http.ResponseWriter, r *http.Request) {
+		list := []templateSnapshotInfo{}
+		if d.DB != nil {
+			if l := listTemplateSnapshots(d.DB); l != nil {
+				list = l
+			}
+		}
+		writeJSON(w, list)
+	})
+
+	// POST /api/templates/revert — roll back the last apply from its snapshot.
+	handlePost(mux, "/api/templates/revert", func(w http.ResponseWriter, r *http.Request, req struct {
+		CSRF string `json:"csrf"`
+	}) {
+		if !requireLocal(w, r) {
+			return
+		}
+		if req.CSRF != csrf {
+			http.Error(w, "bad csrf", http.StatusForbidden)
+			return
+		}
+		if d.DB == nil || len(listTemplateSnapshots(d.DB)) == 0 {
+			http.Error(w, "no snapshot to revert to", http.StatusNotFound)
+			return
+		}
+		snap, err := revertTemplateSnapshot(d)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{
+			"status":   "reverted",
+			"template": snap.Template,
+		})
+	})
+
 	handleGet(mux, "/api/template/settings", func(w http.ResponseWriter, r *http.Request) {
 		if d.DB == nil {
 			writeJSON(w, map[string]any{})
@@ -586,6 +784,287 @@ func applyTemplateFiles(d Deps, files map[string][]byte, schema string, tablePol
 	return nil
 }
 
+// OfflineApply describes a template applied by ApplyTemplateOffline.
+type OfflineApply struct {
+	Name         string // the template's display name
+	Source       string // "builtin" or "store"
+	SeedSkipped  bool   // it has a seed function, which needs the Lua engine
+	GroupSkipped bool   // it needs a co-author group, which needs the group manager
+}
+
+// ApplyTemplateOffline applies a template to a peer that is not running,
+// for goop2 init. template names a built-in template or, when there is
+// none by that name, a store template downloaded from d.RVClients. Paid
+// store templates have to be bought from the templates page first. Only
+// d.DB, d.Content, d.PeerDir, d.CfgPath and d.RVClients are used, so a
+// seed function does not run and no co-author group is created; applying
+// the template again from the running peer does both.
+func ApplyTemplateOffline(ctx context.Context, d Deps, template, peerID string) (OfflineApply, error) {
+	var (
+		res           OfflineApply
+		files         map[string][]byte
+		schema        string
+		tablePolicies map[string]string
+		schemaNames   []string
+		requireEmail  bool
+		defaultRole   string
+		manifest      any
+	)
+	if meta, err := sitetemplates.GetMeta(template); err == nil {
+		if files, err = sitetemplates.SiteFiles(template); err != nil {
+			return res, err
+		}
+		schema, _ = sitetemplates.Schema(template)
+		if len(meta.Tables) > 0 {
+			tablePolicies = make(map[string]string)
+			for name, tp := range meta.Tables {
+				if tp.InsertPolicy != "" {
+					tablePolicies[name] = tp.InsertPolicy
+				}
+			}
+		}
+		res.Name, res.Source = meta.Name, "builtin"
+		schemaNames, requireEmail, defaultRole, manifest = meta.Schemas, meta.RequireEmail, meta.DefaultRole, meta
+	} else {
+		allFiles, _, err := downloadStoreTemplate(ctx, d, template, peerID, "")
+		if err != nil {
+			return res, err
+		}
+		var meta rendezvous.StoreMeta
+		files, schema, meta = splitTemplateBundle(allFiles)
+		tablePolicies = manifestTablePolicies(meta)
+		res.Name, res.Source = meta.Name, "store"
+		schemaNames, requireEmail, defaultRole, manifest = meta.Schemas, meta.RequireEmail, meta.DefaultRole, meta
+	}
+
+	if d.DB != nil {
+		if b, err := json.Marshal(manifest); err == nil {
+			d.DB.SetMeta("template_manifest", string(b))
+		}
+	}
+	if err := applyTemplateFiles(d, files, schema, tablePolicies, res.Name, schemaNames, requireEmail, defaultRole); err != nil {
+		return res, err
+	}
+	setTemplateSource(d, res.Source)
+	hasLua := false
+	for rel := range files {
+		if strings.HasPrefix(rel, "lua/functions/") && strings.HasSuffix(rel, ".lua") {
+			hasLua = true
+			break
+		}
+	}
+	if d.CfgPath != "" {
+		if _, err := config.Update(d.CfgPath, func(cfg *config.Config) error {
+			cfg.Viewer.ActiveTemplate = template
+			if hasLua {
+				cfg.Lua.Enabled = true // as EnsureLua does on a running peer
+			}
+			return nil
+		}); err != nil {
+			return res, err
+		}
+	}
+
+	_, res.SeedSkipped = files["lua/functions/seed.lua"]
+	res.GroupSkipped = templateType.AnalyzeSchemas(files, tablePolicies).NeedsGroup
+	return res, nil
+}
+
+// templateUpdate describes the installed template against the newest
+// store version seen by the background update check.
+type templateUpdate struct {
+	Template  string `json:"template"`
+	Source    string `json:"source"` // builtin, local or store
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+	Available bool   `json:"available"`
+}
+
+func templateUpdateStatus(d Deps) templateUpdate {
+	var st templateUpdate
+	if cfg, err := config.LoadPartial(d.CfgPath); err == nil {
+		st.Template = cfg.Viewer.ActiveTemplate
+	}
+	if d.DB == nil {
+		return st
+	}
+	st.Source = d.DB.GetMeta("template_source")
+	var manifest rendezvous.StoreMeta
+	json.Unmarshal([]byte(d.DB.GetMeta("template_manifest")), &manifest)
+	st.Installed = manifest.Version
+	st.Latest = d.DB.GetMeta("template_latest_version")
+	st.Available = st.Source == "store" && rendezvous.IsNewerVersion(st.Latest, st.Installed)
+	return st
+}
+
+// setTemplateSource records where the active template came from, so the
+// update check only looks at store templates.
+func setTemplateSource(d Deps, source string) {
+	if d.DB == nil {
+		return
+	}
+	d.DB.SetMeta("template_source", source)
+	d.DB.SetMeta("template_latest_version", "")
+}
+
+// downloadStoreTemplate fetches and unpacks a store template bundle from the
+// first rendezvous that has it. install is an install session opened on the
+// first rendezvous, or "". On error it also returns the HTTP status to
+// answer with.
+func downloadStoreTemplate(ctx context.Context, d Deps, dir, peerID, install string) (map[string][]byte, int, error) {
+	var body io.ReadCloser
+	dlErr := fmt.Errorf("no rendezvous server configured")
+	for i, c := range d.RVClients {
+		session := ""
+		if i == 0 {
+			session = install
+		}
+		body, dlErr = c.DownloadTemplateBundle(ctx, dir, peerID, session)
+		if dlErr == nil {
+			break
+		}
+	}
+	if dlErr != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("failed to download template: %w", dlErr)
+	}
+	defer body.Close()
+
+	files, err := extractTarGz(body)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to extract template: %w", err)
+	}
+	return files, 0, nil
+}
+
+// installReport tells the rendezvous how applying a store template went,
+// for the install session the templates page follows. Without a session it
+// does nothing.
+type installReport struct {
+	client  *rendezvous.Client
+	session string
+	peerID  string
+}
+
+func newInstallReport(d Deps, session, peerID string) installReport {
+	if session == "" || len(d.RVClients) == 0 {
+		return installReport{}
+	}
+	return installReport{client: d.RVClients[0], session: session, peerID: peerID}
+}
+
+func (ir installReport) send(stage string, err error) {
+	if ir.client == nil {
+		return
+	}
+	st := rendezvous.InstallStatus{PeerID: ir.peerID, Stage: stage}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ProxyTimeout)
+	defer cancel()
+	if rerr := ir.client.ReportInstall(ctx, ir.session, st); rerr != nil {
+		log.Printf("template: install %s: report %s: %v", ir.session, stage, rerr)
+	}
+}
+
+// splitTemplateBundle separates a template's site files from its legacy
+// schema.sql and its manifest.
+func splitTemplateBundle(allFiles map[string][]byte) (map[string][]byte, string, rendezvous.StoreMeta) {
+	var schema string
+	var manifest rendezvous.StoreMeta
+	siteFiles := make(map[string][]byte)
+	for rel, data := range allFiles {
+		switch rel {
+		case "schema.sql":
+			schema = string(data)
+		case "manifest.json":
+			json.Unmarshal(data, &manifest)
+		default:
+			siteFiles[rel] = data
+		}
+	}
+	return siteFiles, schema, manifest
+}
+
+// manifestTablePolicies returns the legacy per-table insert policies of a manifest.
+func manifestTablePolicies(manifest rendezvous.StoreMeta) map[string]string {
+	if len(manifest.Tables) == 0 {
+		return nil
+	}
+	tablePolicies := make(map[string]string)
+	for name, tp := range manifest.Tables {
+		if tp.InsertPolicy != "" {
+			tablePolicies[name] = tp.InsertPolicy
+		}
+	}
+	return tablePolicies
+}
+
+// updateTemplateFiles re-applies a newer version of the active template.
+// Unlike applyTemplateFiles it keeps the site and the database: template
+// files are written over the site, files the template does not ship (user
+// uploads and content directories) stay, existing tables keep their rows
+// and only tables new in this version are created. No seed runs.
+func updateTemplateFiles(d Deps, files map[string][]byte, schemaNames []string) error {
+	if d.Content != nil {
+		if err := d.Content.EnsureRoot(); err != nil {
+			return fmt.Errorf("failed to create site dir: %w", err)
+		}
+		root := d.Content.RootAbs()
+		for rel, data := range files {
+			abs := filepath.Join(root, rel)
+			if strings.HasPrefix(rel, "schemas/") {
+				if d.PeerDir == "" {
+					continue
+				}
+				abs = filepath.Join(d.PeerDir, rel)
+			}
+			if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+				return fmt.Errorf("failed to create dir: %w", err)
+			}
+			if err := os.WriteFile(abs, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+		}
+	}
+
+	if d.DB != nil {
+		templateTables := append([]string{}, schemaNames...)
+		for rel, data := range files {
+			if !strings.HasPrefix(rel, "schemas/") || !strings.HasSuffix(rel, ".json") {
+				continue
+			}
+			var tbl ormschema.Table
+			if err := json.Unmarshal(data, &tbl); err != nil || tbl.Validate() != nil {
+				log.Printf("template: skip invalid schema %s", rel)
+				continue
+			}
+			if len(schemaNames) == 0 {
+				templateTables = append(templateTables, tbl.Name)
+			}
+			if d.DB.IsORM(tbl.Name) {
+				continue
+			}
+			if err := d.DB.CreateTableORM(&tbl); err != nil {
+				log.Printf("template: failed to create ORM table %s: %v", tbl.Name, err)
+				continue
+			}
+			log.Printf("template: created ORM table %s from %s", tbl.Name, rel)
+		}
+		d.DB.SetMeta("template_tables", strings.Join(templateTables, ","))
+	}
+
+	if d.EnsureLua != nil {
+		for rel := range files {
+			if strings.HasPrefix(rel, "lua/functions/") && strings.HasSuffix(rel, ".lua") {
+				d.EnsureLua()
+				break
+			}
+		}
+	}
+	return nil
+}
+
 // readLocalTemplateDir walks a directory and returns a map of relative path → content.
 // Rejects paths with ".." and enforces a 10MB per-file limit.
 func readLocalTemplateDir(root string) (map[string][]byte, error) {
@@ -632,6 +1111,33 @@ func readLocalTemplateDir(root string) (map[string][]byte, error) {
 	return files, nil
 }
 
+// packTemplateBundle writes files as a tar.gz bundle with everything under
+// dir/, the layout the store serves. Dotfiles (.git and the like) are left out.
+func packTemplateBundle(dir string, files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, rel := range slices.Sorted(maps.Keys(files)) {
+		if strings.HasPrefix(rel, ".") || strings.Contains(rel, "/.") {
+			continue
+		}
+		data := files[rel]
+		if err := tw.WriteHeader(&tar.Header{Name: dir + "/" + rel, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // extractTarGz reads a tar.gz stream into a map of relative path → content.
 // Strips the top-level directory prefix, rejects paths with "..",
 // and enforces a 10MB per-file limit.
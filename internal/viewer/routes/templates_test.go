@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/petervdpas/goop2/internal/config"
 	"github.com/petervdpas/goop2/internal/content"
 	ormschema "github.com/petervdpas/goop2/internal/orm/schema"
 	"github.com/petervdpas/goop2/internal/sitetemplates"
@@ -794,3 +795,214 @@ func TestTemplateManifestStored(t *testing.T) {
 	}
 }
 
+
+func TestTemplateUpdateKeepsContentAndData(t *testing.T) {
+	d, dir := testDeps(t)
+
+	posts := ormschema.Table{
+		Name: "posts", SystemKey: true,
+		Columns: []ormschema.Column{{Name: "title", Type: "text", Required: true}},
+	}
+	tags := ormschema.Table{
+		Name: "tags", SystemKey: true,
+		Columns: []ormschema.Column{{Name: "name", Type: "text", Required: true}},
+	}
+	postsJSON, _ := json.Marshal(posts)
+	tagsJSON, _ := json.Marshal(tags)
+
+	v1 := map[string][]byte{
+		"index.html":         []byte("<h1>v1</h1>"),
+		"schemas/posts.json": postsJSON,
+	}
+	if err := applyTemplateFiles(d, v1, "", nil, "Blog", []string{"posts"}, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.DB.OrmInsert("posts", "user1", "", map[string]any{"title": "hello"})
+	upload := filepath.Join(dir, "site", "uploads", "photo.jpg")
+	os.MkdirAll(filepath.Dir(upload), 0o755)
+	os.WriteFile(upload, []byte("jpg"), 0o644)
+
+	v2 := map[string][]byte{
+		"index.html":         []byte("<h1>v2</h1>"),
+		"schemas/posts.json": postsJSON,
+		"schemas/tags.json":  tagsJSON,
+	}
+	if err := updateTemplateFiles(d, v2, []string{"posts", "tags"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "site", "index.html")); string(b) != "<h1>v2</h1>" {
+		t.Fatalf("index.html = %q, want v2", b)
+	}
+	if _, err := os.Stat(upload); err != nil {
+		t.Fatal("user upload should survive a template update")
+	}
+	rows, err := d.DB.OrmList("posts", 0)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("posts rows = %d (%v), want 1", len(rows), err)
+	}
+	if !d.DB.IsORM("tags") {
+		t.Fatal("tags should be created by the update")
+	}
+	if got := d.DB.GetMeta("template_tables"); got != "posts,tags" {
+		t.Fatalf("template_tables = %q", got)
+	}
+}
+
+func TestTemplateUpdateStatus(t *testing.T) {
+	d, dir := testDeps(t)
+	d.CfgPath = filepath.Join(dir, "goop.json")
+	os.WriteFile(d.CfgPath, []byte(`{"viewer":{"active_template":"kanban"}}`), 0o644)
+
+	d.DB.SetMeta("template_manifest", `{"name":"Kanban","version":"1.2.0"}`)
+	setTemplateSource(d, "store")
+	if st := templateUpdateStatus(d); st.Available || st.Template != "kanban" {
+		t.Fatalf("fresh apply: %+v", st)
+	}
+
+	d.DB.SetMeta("template_latest_version", "1.3.0")
+	st := templateUpdateStatus(d)
+	if !st.Available || st.Installed != "1.2.0" || st.Latest != "1.3.0" {
+		t.Fatalf("newer in store: %+v", st)
+	}
+
+	setTemplateSource(d, "builtin")
+	d.DB.SetMeta("template_latest_version", "9.0")
+	if templateUpdateStatus(d).Available {
+		t.Fatal("built-in templates are never updated from the store")
+	}
+}
+
+func TestTemplateRevertRestoresSnapshot(t *testing.T) {
+	d, dir := testDeps(t)
+
+	posts := ormschema.Table{
+		Name: "posts", SystemKey: true,
+		Columns: []ormschema.Column{{Name: "title", Type: "text", Required: true}},
+	}
+	games := ormschema.Table{
+		Name: "games", SystemKey: true,
+		Columns: []ormschema.Column{{Name: "board", Type: "text", Required: true}},
+	}
+	postsJSON, _ := json.Marshal(posts)
+	gamesJSON, _ := json.Marshal(games)
+
+	blog := map[string][]byte{
+		"index.html":         []byte("<h1>blog</h1>"),
+		"schemas/posts.json": postsJSON,
+	}
+	if err := applyTemplateFiles(d, blog, "", nil, "Blog", []string{"posts"}, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.DB.SetMeta("template_source", "builtin")
+	d.DB.OrmInsert("posts", "user1", "", map[string]any{"title": "hello"})
+	os.WriteFile(filepath.Join(dir, "site", "about.html"), []byte("mine"), 0o644)
+
+	if err := snapshotBeforeApply(d, "tictactoe"); err != nil {
+		t.Fatal(err)
+	}
+	ttt := map[string][]byte{
+		"index.html":         []byte("<h1>ttt</h1>"),
+		"schemas/games.json": gamesJSON,
+	}
+	if err := applyTemplateFiles(d, ttt, "", nil, "Tic-Tac-Toe", []string{"games"}, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if list := listTemplateSnapshots(d.DB); len(list) != 1 || list[0].Reason != "tictactoe" {
+		t.Fatalf("snapshots = %+v", list)
+	}
+
+	if _, err := revertTemplateSnapshot(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.DB.IsORM("games") {
+		t.Fatal("games should be dropped by the revert")
+	}
+	rows, err := d.DB.OrmList("posts", 0)
+	if err != nil || len(rows) != 1 || rows[0]["title"] != "hello" {
+		t.Fatalf("posts rows = %v (%v), want the one saved", rows, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "site", "index.html")); string(b) != "<h1>blog</h1>" {
+		t.Fatalf("index.html = %q", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "site", "about.html")); string(b) != "mine" {
+		t.Fatalf("about.html = %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "schemas", "posts.json")); err != nil {
+		t.Fatal("posts schema file should be restored")
+	}
+	if got := d.DB.GetMeta("template_tables"); got != "posts" {
+		t.Fatalf("template_tables = %q", got)
+	}
+	if d.DB.GetMeta("template_require_email") != "1" {
+		t.Fatal("require_email should be restored")
+	}
+	if len(listTemplateSnapshots(d.DB)) != 0 {
+		t.Fatal("the snapshot should be consumed")
+	}
+	if _, err := revertTemplateSnapshot(d); err == nil {
+		t.Fatal("second revert with no snapshot should fail")
+	}
+}
+
+func TestTemplateSnapshotPruning(t *testing.T) {
+	d, dir := testDeps(t)
+	d.CfgPath = filepath.Join(dir, "goop.json")
+	os.WriteFile(d.CfgPath, []byte(`{"viewer":{"template_snapshots":2}}`), 0o644)
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := snapshotBeforeApply(d, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := listTemplateSnapshots(d.DB)
+	if len(list) != 2 || list[0].Reason != "c" || list[1].Reason != "b" {
+		t.Fatalf("snapshots = %+v, want c, b", list)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "snapshots"))
+	if len(entries) != 2 {
+		t.Fatalf("snapshot dirs = %d, want 2", len(entries))
+	}
+
+	os.WriteFile(d.CfgPath, []byte(`{"viewer":{"template_snapshots":0}}`), 0o644)
+	if err := snapshotBeforeApply(d, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if len(listTemplateSnapshots(d.DB)) != 0 {
+		t.Fatal("template_snapshots 0 should drop all snapshots")
+	}
+}
+
+func TestApplyTemplateOffline(t *testing.T) {
+	d, dir := testDeps(t)
+	d.CfgPath = filepath.Join(dir, "goop.json")
+	if _, _, err := config.Ensure(d.CfgPath); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ApplyTemplateOffline(context.Background(), d, "todo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != "builtin" || res.Name == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	if !d.DB.IsORM("todos") {
+		t.Fatal("todos table not created")
+	}
+	if got := d.DB.GetMeta("template_source"); got != "builtin" {
+		t.Fatalf("template_source = %q", got)
+	}
+	cfg, err := config.Load(d.CfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Viewer.ActiveTemplate != "todo" || !cfg.Lua.Enabled {
+		t.Fatalf("active_template = %q, lua = %v", cfg.Viewer.ActiveTemplate, cfg.Lua.Enabled)
+	}
+
+	if _, err := ApplyTemplateOffline(context.Background(), d, "not-a-template", ""); err == nil {
+		t.Fatal("unknown template without a rendezvous applied")
+	}
+}
@@ -0,0 +1,374 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/config"
+	templateType "github.com/petervdpas/goop2/internal/group_types/template"
+	ormschema "github.com/petervdpas/goop2/internal/orm/schema"
+	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/storage"
+)
+
+// snapshotMetaKeys are the _meta entries a template apply rewrites.
+var snapshotMetaKeys = []string{
+	"template_manifest",
+	"template_tables",
+	"template_source",
+	"template_latest_version",
+	"template_require_email",
+}
+
+// templateSnapshotMu serializes snapshot, prune and revert, which all
+// rewrite the snapshot list in _meta.
+var templateSnapshotMu sync.Mutex
+
+// templateSnapshotInfo is one entry of the snapshot list kept in _meta
+// (template_snapshots), newest first.
+type templateSnapshotInfo struct {
+	ID       string `json:"id"`
+	Created  int64  `json:"created"`
+	Reason   string `json:"reason"`   // what was about to be applied
+	Template string `json:"template"` // active template at snapshot time
+}
+
+// snapshotTable is a template table with everything needed to recreate it.
+type snapshotTable struct {
+	Name         string               `json:"name"`
+	InsertPolicy string               `json:"insert_policy"`
+	Schema       *ormschema.Table     `json:"schema,omitempty"` // nil for classic tables
+	Columns      []storage.ColumnInfo `json:"columns"`
+	Rows         json.RawMessage      `json:"rows"` // decoded with UseNumber on restore
+}
+
+// templateSnapshot is written to snapshots/<id>/snapshot.json next to
+// copies of the site and schema directories.
+type templateSnapshot struct {
+	templateSnapshotInfo
+	Meta   map[string]string `json:"meta"`
+	Tables []snapshotTable   `json:"tables"`
+}
+
+func snapshotRoot(d Deps) string {
+	return filepath.Join(d.PeerDir, "snapshots")
+}
+
+// templateSnapshotKeep returns how many snapshots to keep, from
+// viewer.template_snapshots.
+func templateSnapshotKeep(d Deps) int {
+	if d.CfgPath != "" {
+		if cfg, err := config.LoadPartial(d.CfgPath); err == nil {
+			return cfg.Viewer.TemplateSnapshots
+		}
+	}
+	return config.Default().Viewer.TemplateSnapshots
+}
+
+func listTemplateSnapshots(db *storage.DB) []templateSnapshotInfo {
+	var list []templateSnapshotInfo
+	if raw := db.GetMeta("template_snapshots"); raw != "" {
+		json.Unmarshal([]byte(raw), &list)
+	}
+	return list
+}
+
+func saveTemplateSnapshots(db *storage.DB, list []templateSnapshotInfo) {
+	b, _ := json.Marshal(list)
+	db.SetMeta("template_snapshots", string(b))
+}
+
+// snapshotBeforeApply captures the site, the template tables with their
+// rows, the template schema files and the template settings before an
+// apply overwrites them, then prunes snapshots beyond the configured count.
+// reason names what is about to be applied.
+func snapshotBeforeApply(d Deps, reason string) error {
+	if d.DB == nil || d.PeerDir == "" {
+		return nil
+	}
+	keep := templateSnapshotKeep(d)
+
+	templateSnapshotMu.Lock()
+	defer templateSnapshotMu.Unlock()
+
+	list := listTemplateSnapshots(d.DB)
+	if keep <= 0 {
+		pruneTemplateSnapshots(d, list, 0)
+		return nil
+	}
+
+	now := time.Now()
+	snap := templateSnapshot{
+		templateSnapshotInfo: templateSnapshotInfo{
+			ID:      strconv.FormatInt(now.UnixNano(), 36),
+			Created: now.UnixMilli(),
+			Reason:  reason,
+		},
+		Meta: make(map[string]string, len(snapshotMetaKeys)),
+	}
+	if d.CfgPath != "" {
+		if cfg, err := config.LoadPartial(d.CfgPath); err == nil {
+			snap.Template = cfg.Viewer.ActiveTemplate
+		}
+	}
+	for _, k := range snapshotMetaKeys {
+		snap.Meta[k] = d.DB.GetMeta(k)
+	}
+	for _, name := range strings.Split(snap.Meta["template_tables"], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		t, err := snapshotTemplateTable(d.DB, name)
+		if err != nil {
+			log.Printf("template: snapshot skips table %s: %v", name, err)
+			continue
+		}
+		snap.Tables = append(snap.Tables, t)
+	}
+
+	dir := filepath.Join(snapshotRoot(d), snap.ID)
+	if err := writeTemplateSnapshot(d, dir, snap); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("snapshot before apply: %w", err)
+	}
+
+	list = append([]templateSnapshotInfo{snap.templateSnapshotInfo}, list...)
+	list = pruneTemplateSnapshots(d, list, keep)
+	saveTemplateSnapshots(d.DB, list)
+	log.Printf("template: snapshot %s taken before applying %q", snap.ID, reason)
+	return nil
+}
+
+func snapshotTemplateTable(db *storage.DB, name string) (snapshotTable, error) {
+	exp, err := db.ExportTable(name)
+	if err != nil {
+		return snapshotTable{}, err
+	}
+	t := snapshotTable{Name: name, Columns: exp.Columns}
+	if t.Rows, err = json.Marshal(exp.Rows); err != nil {
+		return snapshotTable{}, err
+	}
+	if db.IsORM(name) {
+		if t.Schema, err = db.GetSchema(name); err != nil {
+			return snapshotTable{}, err
+		}
+	} else {
+		t.InsertPolicy, _ = db.GetTableInsertPolicy(name)
+	}
+	return t, nil
+}
+
+func writeTemplateSnapshot(d Deps, dir string, snap templateSnapshot) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if d.Content != nil {
+		if err := copyTree(d.Content.RootAbs(), filepath.Join(dir, "site")); err != nil {
+			return fmt.Errorf("copy site: %w", err)
+		}
+	}
+	if err := copyTree(filepath.Join(d.PeerDir, "schemas"), filepath.Join(dir, "schemas")); err != nil {
+		return fmt.Errorf("copy schemas: %w", err)
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "snapshot.json"), b, 0o644)
+}
+
+// pruneTemplateSnapshots deletes snapshots beyond the newest keep and
+// returns the ones left.
+func pruneTemplateSnapshots(d Deps, list []templateSnapshotInfo, keep int) []templateSnapshotInfo {
+	if len(list) <= keep {
+		return list
+	}
+	for _, s := range list[keep:] {
+		if err := os.RemoveAll(filepath.Join(snapshotRoot(d), s.ID)); err != nil {
+			log.Printf("template: prune snapshot %s: %v", s.ID, err)
+		}
+	}
+	list = list[:keep]
+	saveTemplateSnapshots(d.DB, list)
+	return list
+}
+
+// revertTemplateSnapshot rolls back the last apply: the current template
+// tables and site are replaced by the newest snapshot, which is consumed,
+// so a second revert goes one apply further back.
+func revertTemplateSnapshot(d Deps) (templateSnapshotInfo, error) {
+	if d.DB == nil || d.PeerDir == "" {
+		return templateSnapshotInfo{}, fmt.Errorf("no snapshot to revert to")
+	}
+
+	templateSnapshotMu.Lock()
+	defer templateSnapshotMu.Unlock()
+
+	list := listTemplateSnapshots(d.DB)
+	if len(list) == 0 {
+		return templateSnapshotInfo{}, fmt.Errorf("no snapshot to revert to")
+	}
+	dir := filepath.Join(snapshotRoot(d), list[0].ID)
+	raw, err := os.ReadFile(filepath.Join(dir, "snapshot.json"))
+	if err != nil {
+		return templateSnapshotInfo{}, fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap templateSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return templateSnapshotInfo{}, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	// Tables: drop what the current template owns, recreate the old ones.
+	if err := dropTemplateTables(d.DB, d.PeerDir); err != nil {
+		return templateSnapshotInfo{}, fmt.Errorf("failed to clear template tables: %w", err)
+	}
+	for _, t := range snap.Tables {
+		if err := restoreSnapshotTable(d.DB, t); err != nil {
+			return templateSnapshotInfo{}, fmt.Errorf("restore table %s: %w", t.Name, err)
+		}
+	}
+	if err := copyTree(filepath.Join(dir, "schemas"), filepath.Join(d.PeerDir, "schemas")); err != nil {
+		return templateSnapshotInfo{}, fmt.Errorf("restore schemas: %w", err)
+	}
+
+	// Site: same clear as apply, then the copy back.
+	if d.Content != nil {
+		root := d.Content.RootAbs()
+		if err := clearSitePreserveLua(root); err != nil {
+			return templateSnapshotInfo{}, fmt.Errorf("failed to clear site: %w", err)
+		}
+		if err := d.Content.EnsureRoot(); err != nil {
+			return templateSnapshotInfo{}, fmt.Errorf("failed to recreate site dir: %w", err)
+		}
+		if err := copyTree(filepath.Join(dir, "site"), root); err != nil {
+			return templateSnapshotInfo{}, fmt.Errorf("restore site: %w", err)
+		}
+	}
+
+	for k, v := range snap.Meta {
+		d.DB.SetMeta(k, v)
+	}
+	if d.CfgPath != "" {
+		config.Update(d.CfgPath, func(cfg *config.Config) error {
+			cfg.Viewer.ActiveTemplate = snap.Template
+			return nil
+		})
+	}
+
+	if d.EnsureLua != nil {
+		if entries, _ := os.ReadDir(filepath.Join(dir, "site", "lua", "functions")); len(entries) > 0 {
+			d.EnsureLua()
+		}
+	}
+	if d.TemplateHandler != nil {
+		var manifest rendezvous.StoreMeta
+		json.Unmarshal([]byte(snap.Meta["template_manifest"]), &manifest)
+		schemaFiles := make(map[string][]byte)
+		for _, t := range snap.Tables {
+			if t.Schema != nil {
+				if b, err := json.Marshal(t.Schema); err == nil {
+					schemaFiles["schemas/"+t.Name+".json"] = b
+				}
+			}
+		}
+		d.TemplateHandler.Apply(templateType.ApplyConfig{
+			DB:           d.DB,
+			TemplateName: manifest.Name,
+			DefaultRole:  manifest.DefaultRole,
+			SchemaInfo:   templateType.AnalyzeSchemas(schemaFiles, manifestTablePolicies(manifest)),
+		})
+	}
+
+	os.RemoveAll(dir)
+	saveTemplateSnapshots(d.DB, list[1:])
+	log.Printf("template: reverted to snapshot %s (%q)", snap.ID, snap.Template)
+	return snap.templateSnapshotInfo, nil
+}
+
+func restoreSnapshotTable(db *storage.DB, t snapshotTable) error {
+	db.DeleteTable(t.Name)
+	if t.Schema != nil {
+		if err := db.CreateTableORM(t.Schema); err != nil {
+			return err
+		}
+	} else {
+		var cols []storage.ColumnDef
+		for _, c := range t.Columns {
+			if strings.HasPrefix(c.Name, "_") {
+				continue
+			}
+			def := storage.ColumnDef{Name: c.Name, Type: c.Type, NotNull: c.NotNull}
+			if c.Default != nil {
+				def.Default = *c.Default
+			}
+			cols = append(cols, def)
+		}
+		if err := db.CreateTable(t.Name, cols); err != nil {
+			return err
+		}
+		if t.InsertPolicy != "" {
+			db.SetTableInsertPolicy(t.Name, t.InsertPolicy)
+		}
+	}
+	// Numbers stay json.Number so integer columns come back exact.
+	var rows []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(t.Rows))
+	dec.UseNumber()
+	if err := dec.Decode(&rows); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		owner, _ := row["_owner"].(string)
+		email, _ := row["_owner_email"].(string)
+		data := make(map[string]any, len(row))
+		for k, v := range row {
+			if k != "_owner" && k != "_owner_email" {
+				data[k] = v
+			}
+		}
+		if _, err := db.Insert(t.Name, owner, email, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree copies the files under src into dst, creating directories as
+// needed. A missing src copies nothing.
+func copyTree(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if e.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
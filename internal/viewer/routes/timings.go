@@ -2,7 +2,8 @@ package routes
 
 import "time"
 
-// Viewer HTTP route timings.
+// Viewer HTTP route timings. Group joins, MQ sends and docs fetches use the
+// tunable deadlines in internal/timeouts.
 const (
 	ServiceCheckTimeout  = 3 * time.Second        // health check to microservices
 	BridgeCheckTimeout   = 3 * time.Second        // bridge token request
@@ -11,14 +12,18 @@ const (
 	CallWriteDeadline    = 5 * time.Second        // WebSocket write deadline for call streams
 	ListenPollInterval   = 500 * time.Millisecond // listen stream position poll
 	ListenHostTimeout    = 3 * time.Second        // listen stream host-gone detection
-	DocListFetchTimeout  = 5 * time.Second        // fetch doc list from peer
-	DocFileFetchTimeout  = 15 * time.Second       // fetch single doc file from peer
-	ClusterJoinTimeout   = 5 * time.Second        // cluster join operation
-	MQSendTimeout        = 5 * time.Second        // MQ send via API
+	DocCacheMaxAge       = 24 * time.Hour         // keep finished and partial chunked downloads this long
 	MQAckRelayTimeout    = 3 * time.Second        // MQ ack relay back to sender
 	AvatarFetchTimeout   = 5 * time.Second        // fetch avatar from peer
-	GroupJoinTimeout     = 5 * time.Second        // group join/invite/rejoin
 	TemplateListTimeout  = 3 * time.Second        // template store listing
 	TemplateBundleTimeout = 15 * time.Second      // template bundle download
+	TemplatePublishTimeout = 30 * time.Second     // template bundle upload to one rendezvous
 	CreditsBalanceTimeout = 3 * time.Second       // credits balance fetch
+	NetworkSearchTimeout  = 4 * time.Second       // per-peer /goop/search query
+	PermalinkFetchTimeout = 10 * time.Second      // fetch a page to hash for a permalink
+	DigestTimeout         = 5 * time.Second       // read or change the email digest on the rendezvous
+	ReputationSubmitTimeout = 5 * time.Second     // submit a reputation record to one rendezvous
+	DocsBrowseDeadline    = 2 * time.Second       // docs browse answers with the members done by then
+	DocsBrowseStagger     = 25 * time.Millisecond // between docs list fetches of one browse
+	DocsBrowseCacheTTL    = 30 * time.Second      // keep late docs lists for /api/docs/browse/refresh
 )
@@ -0,0 +1,226 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/swaggo/swag"
+)
+
+// Request bodies of handlePost routes are checked against the schema the
+// OpenAPI annotations document for them, before the handler runs: required
+// fields (binding:"required"), enums, string lengths and number ranges.
+// Field types are checked by encoding/json while decoding. A bad body gets a
+// missing_field or invalid_field error naming the field, so handlers only
+// check what the schema cannot express.
+
+// bodyIndex holds the documented request body of every POST route.
+type bodyIndex struct {
+	exact     map[string]*contractSchema // "POST /api/groups/leave"
+	templated []templatedBody            // paths with {params}
+}
+
+type templatedBody struct {
+	method string
+	parts  []string
+	schema *contractSchema
+}
+
+var bodySchemas = sync.OnceValue(func() *bodyIndex {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		log.Printf("OPENAPI: request validation off: %v", err)
+		return &bodyIndex{}
+	}
+	ix, err := newBodyIndex([]byte(doc))
+	if err != nil {
+		log.Printf("OPENAPI: request validation off: %v", err)
+		return &bodyIndex{}
+	}
+	return ix
+})
+
+func newBodyIndex(rawSpec []byte) (*bodyIndex, error) {
+	var spec contractSpec
+	if err := json.Unmarshal(rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	ix := &bodyIndex{exact: map[string]*contractSchema{}}
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if op.ServedBy != "" {
+				continue
+			}
+			var body *contractSchema
+			for _, p := range op.Parameters {
+				if p.In == "body" {
+					body = spec.resolve(p.Schema)
+				}
+			}
+			if body == nil || len(body.Properties) == 0 {
+				continue
+			}
+			// Resolve property refs once, so validation needs no spec.
+			for name, prop := range body.Properties {
+				body.Properties[name] = spec.resolve(prop)
+			}
+			m := strings.ToUpper(method)
+			if strings.Contains(path, "{") {
+				ix.templated = append(ix.templated, templatedBody{method: m, parts: strings.Split(path, "/"), schema: body})
+			} else {
+				ix.exact[m+" "+path] = body
+			}
+		}
+	}
+	return ix, nil
+}
+
+// lookup returns the documented body of a request, or nil.
+func (ix *bodyIndex) lookup(method, path string) *contractSchema {
+	if sc := ix.exact[method+" "+path]; sc != nil {
+		return sc
+	}
+	parts := strings.Split(path, "/")
+	for _, t := range ix.templated {
+		if t.method == method && len(t.parts) == len(parts) && templateMatches(t.parts, parts) {
+			return t.schema
+		}
+	}
+	return nil
+}
+
+func templateMatches(tmpl, parts []string) bool {
+	for i, p := range tmpl {
+		if strings.HasPrefix(p, "{") {
+			if parts[i] == "" {
+				return false
+			}
+		} else if p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeRequest decodes a JSON body into v and validates it against the
+// documented schema, writing the error response when either fails.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrBadRequest, "read body: "+err.Error(), "")
+		return false
+	}
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(v); err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	if sc := bodySchemas().lookup(r.Method, r.URL.Path); sc != nil {
+		if e := validateBody(raw, sc); e != nil {
+			writeError(w, http.StatusBadRequest, e.Code, e.Message, e.Field)
+			return false
+		}
+	}
+	return true
+}
+
+// writeDecodeError reports a body encoding/json could not decode, naming
+// the field when the error is a type mismatch.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) && te.Field != "" {
+		want := swaggerType(te.Type)
+		if want == "" {
+			want = te.Type.String()
+		}
+		writeError(w, http.StatusBadRequest, ErrInvalidField,
+			fmt.Sprintf("%s must be %s, not %s", te.Field, withArticle(want), te.Value), te.Field)
+		return
+	}
+	writeError(w, http.StatusBadRequest, ErrInvalidJSON, "invalid json: "+err.Error(), "")
+}
+
+func withArticle(s string) string {
+	if s != "" && strings.ContainsRune("aeiou", rune(s[0])) {
+		return "an " + s
+	}
+	return "a " + s
+}
+
+// validateBody checks the top-level fields of a JSON object against sc.
+func validateBody(raw []byte, sc *contractSchema) *APIError {
+	var body map[string]any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil // not an object; decoding into the handler type decided
+	}
+	for _, name := range sc.Required {
+		v, ok := body[name]
+		if !ok || v == nil || v == "" {
+			return &APIError{Code: ErrMissingField, Message: name + " is required", Field: name}
+		}
+	}
+	names := make([]string, 0, len(body))
+	for name := range body {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		prop := sc.Properties[name]
+		if prop == nil || body[name] == nil {
+			continue
+		}
+		if msg := checkValue(body[name], prop); msg != "" {
+			return &APIError{Code: ErrInvalidField, Message: name + " " + msg, Field: name}
+		}
+	}
+	return nil
+}
+
+// checkValue returns why v does not satisfy prop, or "".
+func checkValue(v any, prop *contractSchema) string {
+	if len(prop.Enum) > 0 {
+		s := fmt.Sprint(v)
+		var allowed []string
+		for _, e := range prop.Enum {
+			allowed = append(allowed, fmt.Sprint(e))
+		}
+		if !slices.Contains(allowed, s) {
+			return "must be one of " + strings.Join(allowed, ", ")
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if prop.MinLength != nil && n < *prop.MinLength {
+			return fmt.Sprintf("must be at least %d characters", *prop.MinLength)
+		}
+		if prop.MaxLength != nil && n > *prop.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *prop.MaxLength)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return "is not a number"
+		}
+		if prop.Type == "integer" && f != math.Trunc(f) {
+			return "must be a whole number"
+		}
+		if prop.Minimum != nil && f < *prop.Minimum {
+			return fmt.Sprintf("must be at least %v", *prop.Minimum)
+		}
+		if prop.Maximum != nil && f > *prop.Maximum {
+			return fmt.Sprintf("must be at most %v", *prop.Maximum)
+		}
+	}
+	return ""
+}
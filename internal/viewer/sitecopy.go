@@ -0,0 +1,70 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serveSiteCopy answers a request for a followed peer's site from the
+// local copy, with a banner on HTML pages saying when it was synced. It
+// reports false, without writing anything, when there is no copy of the
+// file.
+func serveSiteCopy(w http.ResponseWriter, v Viewer, peerID, reqPath string, setHeaders func(http.ResponseWriter)) bool {
+	if v.SiteSync == nil {
+		return false
+	}
+	data, f, err := v.SiteSync.ReadFile(peerID, reqPath)
+	if err != nil {
+		return false
+	}
+	rel := strings.TrimPrefix(reqPath, "/")
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		rel += "index.html"
+	}
+	mt := contentTypeForPath(rel, data)
+	if strings.HasPrefix(mt, "text/html") {
+		data = injectCopyBanner(data, time.UnixMilli(f.SyncedAt))
+	}
+
+	setHeaders(w)
+	w.Header().Set("Content-Type", mt)
+	// The copy changes under the same URL once the peer is back.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Goop-Site-Copy", time.UnixMilli(f.SyncedAt).UTC().Format(time.RFC3339))
+	_, _ = w.Write(data)
+	return true
+}
+
+// injectCopyBanner puts the "offline copy" banner at the top of the page
+// body. Peer pages may not carry inline styles, so it is styled by a viewer
+// stylesheet.
+func injectCopyBanner(page []byte, syncedAt time.Time) []byte {
+	banner := fmt.Sprintf(`<link rel="stylesheet" href="/assets/css/site-copy.css">`+
+		`<div class="goop-site-copy" role="status">This peer is offline. You are viewing a copy last synced %s.</div>`,
+		html.EscapeString(syncedAt.Local().Format("2 Jan 2006 15:04")))
+
+	lower := bytes.ToLower(page)
+	at := 0
+	if i := bytes.Index(lower, []byte("<body")); i >= 0 {
+		if j := bytes.IndexByte(lower[i:], '>'); j >= 0 {
+			at = i + j + 1
+		}
+	}
+	out := make([]byte, 0, len(page)+len(banner))
+	out = append(out, page[:at]...)
+	out = append(out, banner...)
+	return append(out, page[at:]...)
+}
+
+// peerOffline reports whether the peer table has peerID as gone offline.
+func peerOffline(v Viewer, peerID string) bool {
+	if v.Peers == nil {
+		return false
+	}
+	sp, ok := v.Peers.Get(peerID)
+	return ok && !sp.OfflineSince.IsZero()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/petervdpas/goop2/internal/actions"
 	"github.com/petervdpas/goop2/internal/avatar"
 	"github.com/petervdpas/goop2/internal/call"
 	"github.com/petervdpas/goop2/internal/directchat"
@@ -13,13 +14,21 @@ import (
 	"github.com/petervdpas/goop2/internal/group_types/datafed"
 	"github.com/petervdpas/goop2/internal/orm/gql"
 	"github.com/petervdpas/goop2/internal/content"
+	"github.com/petervdpas/goop2/internal/datasync"
 	"github.com/petervdpas/goop2/internal/group"
 	"github.com/petervdpas/goop2/internal/group_types/files"
 	"github.com/petervdpas/goop2/internal/group_types/listen"
 	templateType "github.com/petervdpas/goop2/internal/group_types/template"
+	"github.com/petervdpas/goop2/internal/jobs"
 	"github.com/petervdpas/goop2/internal/mq"
 	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/pairing"
 	"github.com/petervdpas/goop2/internal/rendezvous"
+	"github.com/petervdpas/goop2/internal/retention"
+	"github.com/petervdpas/goop2/internal/siteassets"
+	"github.com/petervdpas/goop2/internal/sitesync"
+	"github.com/petervdpas/goop2/internal/rules"
+	"github.com/petervdpas/goop2/internal/secrets"
 	"github.com/petervdpas/goop2/internal/sdk"
 	"github.com/petervdpas/goop2/internal/state"
 	"github.com/petervdpas/goop2/internal/storage"
@@ -34,6 +43,7 @@ type Viewer struct {
 	SelfLabel   func() string
 	SelfEmail   func() string
 	Peers       *state.PeerTable
+	Namespaces  map[string]*state.PeerTable // peer tables of presence.namespaces
 	ResolvePeer func(string) state.PeerIdentityPayload
 
 	// Config & content
@@ -50,6 +60,49 @@ type Viewer struct {
 	BridgeURL string
 	RVClients []*rendezvous.Client
 
+	// Remote control for paired phones (off when RemoteAddr is empty)
+	Pairing       *pairing.Manager
+	RemoteAddr    string
+	RemoteTLSCert string
+	RemoteTLSKey  string
+
+	// OnServer is handed each HTTP server before it starts listening, so
+	// the caller can shut it down. Optional.
+	OnServer func(*http.Server)
+
+	// Health returns the startup doctor's report for GET /api/health. Optional.
+	Health func() any
+
+	// Startup returns the startup stages for GET /api/startup. Optional.
+	Startup func() any
+
+	// Metrics serves GET /metrics. Optional.
+	Metrics http.Handler
+
+	// Jobs is the background job queue shown by GET /api/jobs. Optional.
+	Jobs *jobs.Queue
+
+	// Actions runs registry actions for Lua and rules; handed the mux on start
+	Actions *actions.Dispatcher
+
+	// Automation rules (configured under Settings → Automation)
+	Rules *rules.Engine
+
+	// Secrets holds service tokens encrypted at rest
+	Secrets *secrets.Store
+
+	// Retention forgets peers, on request and after peer_retention_days
+	Retention *retention.Pruner
+
+	// Assets builds image variants for the served site
+	Assets *siteassets.Pipeline
+
+	// SiteSync keeps copies of followed sites, served while their peer is offline
+	SiteSync *sitesync.Syncer
+
+	// DataSync replicates template group tables, answering for hosts that are offline
+	DataSync *datasync.Syncer
+
 	// Core managers
 	MQ         *mq.Manager
 	Groups     *group.Manager
@@ -89,6 +142,7 @@ func Start(addr string, v Viewer) error {
 	mux.Handle("/sdk/", http.StripPrefix("/sdk/",
 		noCache(sdk.Handler()),
 	))
+	mux.Handle("/sw.js", noCache(viewerassets.ServiceWorker()))
 	mux.HandleFunc("/p/", proxyPeerSite(v))
 
 	baseURL := v.BaseURL
@@ -102,6 +156,7 @@ func Start(addr string, v Viewer) error {
 		SelfLabel:    v.SelfLabel,
 		SelfEmail:    v.SelfEmail,
 		Peers:        v.Peers,
+		Namespaces:   v.Namespaces,
 		CfgPath:      v.CfgPath,
 		Logs:         v.Logs,
 		Content:      v.Content,
@@ -112,12 +167,28 @@ func Start(addr string, v Viewer) error {
 		PeerDir:      v.PeerDir,
 		RVClients:    v.RVClients,
 		BridgeURL:    v.BridgeURL,
+		MQ:           v.MQ,
 		ResolvePeer:     v.ResolvePeer,
 		DocsStore:    v.Docs,
 		GroupManager:    v.Groups,
 		TemplateHandler: v.TemplateHandler,
 		EnsureLua:       v.EnsureLua,
 		LuaCall:         v.LuaCall,
+		Pairing:         v.Pairing,
+		Rules:           v.Rules,
+		Secrets:         v.Secrets,
+		Retention:       v.Retention,
+		Assets:          v.Assets,
+		SiteSync:        v.SiteSync,
+		DataSync:        v.DataSync,
+		Health:          v.Health,
+		Startup:         v.Startup,
+		Metrics:         v.Metrics,
+		Jobs:            v.Jobs,
+	}
+	remote := v.RemoteAddr != "" && v.Pairing != nil
+	if remote {
+		deps.RemoteURL = remoteURL(v.RemoteAddr, v.RemoteTLSCert != "")
 	}
 	routes.Register(mux, deps)
 
@@ -158,11 +229,11 @@ func Start(addr string, v Viewer) error {
 	// Register cluster compute endpoints
 	if v.Cluster != nil {
 		routes.RegisterCluster(mux, v.Cluster, v.Groups, v.Node.ID(), func(path, mode string) {
-			if cfg, err := config.Load(v.CfgPath); err == nil {
+			_, _ = config.Update(v.CfgPath, func(cfg *config.Config) error {
 				cfg.Viewer.ClusterBinaryPath = path
 				cfg.Viewer.ClusterBinaryMode = mode
-				_ = config.Save(v.CfgPath, cfg)
-			}
+				return nil
+			})
 		})
 	}
 
@@ -181,13 +252,35 @@ func Start(addr string, v Viewer) error {
 
 	// Register data proxy for remote peer data operations
 	if v.Node != nil {
-		routes.RegisterDataProxy(mux, v.Node)
+		routes.RegisterDataProxy(mux, v.Node, v.DataSync)
 	}
 
 	// Register data federation endpoints
 	routes.RegisterDataFed(mux, v.DataFed)
 
-	return http.ListenAndServe(addr, mux)
+	if v.Actions != nil {
+		v.Actions.SetHandler(mux)
+	}
+
+	// In debug mode, report handlers that drift from the OpenAPI annotations.
+	if cfg, err := config.Load(v.CfgPath); err == nil && cfg.Viewer.Debug {
+		routes.VerifyContract(mux)
+	}
+
+	if remote {
+		go serveRemote(v.RemoteAddr, v.RemoteTLSCert, v.RemoteTLSKey, mux, v.Pairing, v.OnServer)
+	}
+
+	// Errors leave every /api/ route in the same JSON envelope. Actions
+	// call the mux directly and keep the plain-text errors.
+	srv := &http.Server{Addr: addr, Handler: routes.ErrorEnvelope(mux)}
+	if v.OnServer != nil {
+		v.OnServer(srv)
+	}
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 // MinimalViewer holds the config needed for a rendezvous-only settings viewer.
@@ -201,6 +294,8 @@ type MinimalViewer struct {
 	AvatarStore   *avatar.Store
 	BridgeURL     string
 	TopologyFunc  func() any
+	Startup       func() any
+	Secrets       *secrets.Store
 }
 
 // StartMinimal starts a lightweight viewer with only self/settings and logs.
@@ -218,6 +313,7 @@ func StartMinimal(addr string, v MinimalViewer) error {
 	mux.Handle("/sdk/", http.StripPrefix("/sdk/",
 		noCache(sdk.Handler()),
 	))
+	mux.Handle("/sw.js", noCache(viewerassets.ServiceWorker()))
 
 	baseURL := v.BaseURL
 	if baseURL == "" {
@@ -237,7 +333,9 @@ func StartMinimal(addr string, v MinimalViewer) error {
 		AvatarStore:    v.AvatarStore,
 		BridgeURL:      v.BridgeURL,
 		TopologyFunc:   v.TopologyFunc,
+		Startup:        v.Startup,
+		Secrets:        v.Secrets,
 	})
 
-	return http.ListenAndServe(addr, mux)
+	return http.ListenAndServe(addr, routes.ErrorEnvelope(mux))
 }
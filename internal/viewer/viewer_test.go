@@ -5,13 +5,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/petervdpas/goop2/internal/content"
 	"github.com/petervdpas/goop2/internal/p2p"
+	"github.com/petervdpas/goop2/internal/sitesync"
+	"github.com/petervdpas/goop2/internal/state"
+	"github.com/petervdpas/goop2/internal/storage"
 )
 
 func TestContentTypeForPath(t *testing.T) {
@@ -310,3 +316,61 @@ func TestProxyPeerSite_EmptyPath(t *testing.T) {
 		t.Errorf("expected 404, got %d", w.Code)
 	}
 }
+
+func TestProxyPeerSite_OfflineCopy(t *testing.T) {
+	origin, local := testNode(t), testNode(t)
+	site := t.TempDir()
+	os.WriteFile(filepath.Join(site, "index.html"), []byte("<html><body class=\"x\"><h1>away</h1></body></html>"), 0o644)
+	origin.EnableSite(site)
+	origin.EnableSiteSync()
+	if err := local.Host.Connect(context.Background(), peer.AddrInfo{ID: origin.Host.ID(), Addrs: origin.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	db, err := storage.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	peers := state.NewPeerTable()
+	syncer := sitesync.New(db, peers, local, filepath.Join(dir, "cache", "sites"))
+	id := origin.ID()
+	if _, err := syncer.Follow(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncer.Sync(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+
+	peers.Upsert(id, "Alice", "", "", false, "", "", false, false, "", false)
+	peers.MarkOffline(id)
+	handler := proxyPeerSite(Viewer{Node: local, Peers: peers, SiteSync: syncer})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/p/"+id+"/", nil))
+	if w.Code != 200 {
+		t.Fatalf("status %d, body: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<body class="x"><link rel="stylesheet" href="/assets/css/site-copy.css"><div class="goop-site-copy"`) ||
+		!strings.Contains(body, "<h1>away</h1>") {
+		t.Errorf("body = %q", body)
+	}
+	if w.Header().Get("X-Goop-Site-Copy") == "" || w.Header().Get("Content-Security-Policy") == "" {
+		t.Errorf("headers = %v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/p/"+id+"/.manifest.json", nil))
+	if w.Code == 200 {
+		t.Error("served the copy's bookkeeping")
+	}
+}
+
+func TestInjectCopyBanner_NoBody(t *testing.T) {
+	out := string(injectCopyBanner([]byte("<p>bare</p>"), time.Now()))
+	if !strings.HasPrefix(out, `<link rel="stylesheet"`) || !strings.HasSuffix(out, "<p>bare</p>") {
+		t.Errorf("out = %q", out)
+	}
+}
@@ -0,0 +1,89 @@
+// keys.go
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/petervdpas/goop2/internal/app"
+	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/keystore"
+)
+
+// keyPassphrase returns the passphrase for protected key files, read from
+// the -key-passphrase-file flag or, failing that, the GOOP2_KEY_PASSPHRASE
+// environment variable.
+func keyPassphrase(file string) (string, error) {
+	if file == "" {
+		return os.Getenv(keystore.PassphraseEnv), nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// runKeys shows or changes how a peer's key files are stored.
+func runKeys(peerDirArg, action string, args []string) {
+	fset := flag.NewFlagSet("keys", flag.ExitOnError)
+	useKeychain := fset.Bool("keychain", false, "Protect with a key kept in the OS keychain instead of the passphrase")
+	fset.Parse(args)
+
+	absDir, err := filepath.Abs(peerDirArg)
+	if err != nil {
+		log.Fatalf("Invalid peer directory: %v", err)
+	}
+	cfg, err := config.Load(filepath.Join(absDir, "goop.json"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var mode keystore.Mode
+	switch action {
+	case "status":
+	case "protect":
+		mode = keystore.ModePassphrase
+		if *useKeychain {
+			mode = keystore.ModeKeychain
+		}
+	case "unprotect":
+		mode = keystore.ModePlain
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown keys action '%s' (want status, protect or unprotect)\n", action)
+		os.Exit(1)
+	}
+
+	pass, err := keyPassphrase(*passphraseFile)
+	if err != nil {
+		log.Fatalf("Key passphrase: %v", err)
+	}
+	if mode == keystore.ModePassphrase && pass == "" {
+		log.Fatalf("Set %s or pass -key-passphrase-file to protect with a passphrase", keystore.PassphraseEnv)
+	}
+
+	for _, path := range app.KeyFiles(absDir, cfg) {
+		cur, err := keystore.Inspect(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("%-10s %s\n", "missing", path)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		if mode == "" {
+			fmt.Printf("%-10s %s\n", cur, path)
+			continue
+		}
+		if err := keystore.Protect(path, mode, pass); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		fmt.Printf("%-10s %s (was %s)\n", mode, path, cur)
+	}
+}
@@ -3,7 +3,7 @@ package main
 
 //	@title			goop2 Viewer API
 //	@version		1.0.0
-//	@description	All HTTP + MQ endpoints exposed by the goop2 viewer.\n\nAll peer-to-peer signaling travels through MQ (POST /api/mq/send → P2P → /api/mq/events SSE).\nSee x-mq-topics in the raw spec (/api/openapi.json) for the full topic contract.
+//	@description	All HTTP + MQ endpoints exposed by the goop2 viewer.\n\nAll peer-to-peer signaling travels through MQ (POST /api/mq/send → P2P → /api/mq/events SSE).\nSee x-mq-topics in the raw spec (/api/openapi.json) for the full topic contract.\n\nErrors share one JSON envelope: {"error": {"code": "missing_field", "message": "group_id is required", "field": "group_id"}}. POST bodies are validated against the schemas below before the handler runs.
 //	@BasePath		/
 //	@schemes		http
 
@@ -22,6 +22,7 @@ import (
 
 	"github.com/petervdpas/goop2/internal/app"
 	"github.com/petervdpas/goop2/internal/config"
+	"github.com/petervdpas/goop2/internal/keystore"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -36,8 +37,10 @@ var assets embed.FS
 var appIcon []byte
 
 var (
-	showHelp = flag.Bool("h", false, "Show help")
-	version  = flag.Bool("version", false, "Show version")
+	showHelp       = flag.Bool("h", false, "Show help")
+	version        = flag.Bool("version", false, "Show version")
+	passphraseFile = flag.String("key-passphrase-file", "", "Read the key file passphrase from this file")
+	echoPeer       = flag.Bool("echo-peer", false, "Run an in-process echo peer next to the peer (development)")
 )
 
 // appVersion is set at build time via -ldflags "-X main.appVersion=x.y.z"
@@ -58,6 +61,13 @@ func main() {
 		return
 	}
 
+	// Passphrase for protected key files (env or file, never the command line)
+	pass, err := keyPassphrase(*passphraseFile)
+	if err != nil {
+		log.Fatalf("Key passphrase: %v", err)
+	}
+	keystore.SetPassphrase(pass)
+
 	args := flag.Args()
 
 	// No arguments - run desktop UI
@@ -86,6 +96,36 @@ func main() {
 		}
 		runCLIRendezvous(args[1])
 
+	case "init":
+		runInit(args[1:])
+
+	case "daemon":
+		runDaemon(args[1:])
+
+	case "keys":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Error: keys command requires directory path and action")
+			fmt.Fprintln(os.Stderr, "Usage: goop2 keys <peer-directory> status|protect|unprotect [-keychain]")
+			os.Exit(1)
+		}
+		runKeys(args[1], args[2], args[3:])
+
+	case "ctl":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Error: ctl command requires directory path and command")
+			fmt.Fprintln(os.Stderr, "Usage: goop2 ctl <peer-directory> <command> [args] [-addr host:port]")
+			os.Exit(1)
+		}
+		runCtl(args[1], args[2], args[3:])
+
+	case "secrets":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Error: secrets command requires directory path and action")
+			fmt.Fprintln(os.Stderr, "Usage: goop2 secrets <peer-directory> list|set|rotate|rm [name]")
+			os.Exit(1)
+		}
+		runSecrets(args[1], args[2], args[3:])
+
 	default:
 		fmt.Fprintf(os.Stderr, "Error: unknown command '%s'\n", command)
 		fmt.Fprintln(os.Stderr)
@@ -150,8 +190,11 @@ func runCLIPeer(peerDirArg string) {
 
 	go func() {
 		<-sigCh
-		log.Println("\nShutting down gracefully...")
+		log.Println("\nShutting down gracefully... (interrupt again to force)")
 		cancel()
+		<-sigCh
+		log.Println("Forced exit")
+		os.Exit(1)
 	}()
 
 	// Run peer
@@ -160,6 +203,7 @@ func runCLIPeer(peerDirArg string) {
 		CfgPath:           cfgPath,
 		Cfg:               cfg,
 		GoopClientVersion: appVersion,
+		EchoPeer:          *echoPeer,
 	}); err != nil {
 		log.Fatalf("Peer failed: %v", err)
 	}
@@ -195,8 +239,11 @@ func runCLIRendezvous(peerDirArg string) {
 
 	go func() {
 		<-sigCh
-		log.Println("\nShutting down gracefully...")
+		log.Println("\nShutting down gracefully... (interrupt again to force)")
 		cancel()
+		<-sigCh
+		log.Println("Forced exit")
+		os.Exit(1)
 	}()
 
 	if err := app.Run(ctx, app.Options{
@@ -216,6 +263,11 @@ func showUsage() {
 	fmt.Println("  goop2                      Run desktop application (default)")
 	fmt.Println("  goop2 peer <directory>     Run peer in CLI mode")
 	fmt.Println("  goop2 rendezvous <directory>  Run peer configured as rendezvous server")
+	fmt.Println("  goop2 keys <directory> <action>  Protect or unprotect the peer's key files")
+	fmt.Println("  goop2 secrets <directory> <action>  Manage the peer's service tokens")
+	fmt.Println("  goop2 ctl <directory> <command>  Script a running peer")
+	fmt.Println("  goop2 init <directory>     Create a peer directory from a preset")
+	fmt.Println("  goop2 daemon <action>      Supervise several peer directories")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  peer <directory>")
@@ -226,20 +278,78 @@ func showUsage() {
 	fmt.Println("        Run a peer configured as rendezvous server")
 	fmt.Println("        The peer's goop.json should have rendezvousHost enabled")
 	fmt.Println()
+	fmt.Println("  keys <directory> status|protect|unprotect [-keychain]")
+	fmt.Println("        Show or change how the identity and relay keys are stored")
+	fmt.Println("        protect encrypts them with the passphrase, or with a key kept")
+	fmt.Println("        in the OS keychain when -keychain is given")
+	fmt.Println()
+	fmt.Println("  secrets <directory> list|set|rotate|rm [name]")
+	fmt.Println("        Keep service tokens encrypted in data/secrets.json; goop.json")
+	fmt.Println("        refers to one as \"secret:NAME\". set reads the value from stdin,")
+	fmt.Println("        rotate generates a new random one and prints it once")
+	fmt.Println()
+	fmt.Println("  ctl <directory> <command> [args] [-addr host:port]")
+	fmt.Println("        Drive a running peer through its control API (control.enabled)")
+	fmt.Println("        and print the result as JSON. Commands: status, peers, groups,")
+	fmt.Println("        join <host> <group>, leave <group>, group-send <group> <json>,")
+	fmt.Println("        chat <peer> <text>, mq <peer> <topic> <json>, publish")
+	fmt.Println()
+	fmt.Println("  init <directory> [-preset name] [-label text] [-rendezvous url] [-port n] [-template name] [-yes]")
+	fmt.Println("        Create a peer directory: goop.json from a preset (desktop (default),")
+	fmt.Println("        public-rendezvous, lan-kiosk or headless-bot), the identity key,")
+	fmt.Println("        the site folder and optionally a built-in or store template")
+	fmt.Println("        On a terminal it asks for what the flags leave out; -yes does not ask")
+	fmt.Println("        -list shows what each preset sets")
+	fmt.Println()
+	fmt.Println("  daemon start|status|reload|stop [-config goop2d.json]")
+	fmt.Println("        Run the peers listed in goop2d.json and restart them when they exit")
+	fmt.Println("        status, reload and stop talk to a running daemon over its admin socket")
+	fmt.Println("        start-peer, stop-peer and restart-peer <name> control one peer")
+	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -h        Show this help message")
 	fmt.Println("  -version  Show version information")
+	fmt.Println("  -key-passphrase-file <file>")
+	fmt.Println("            Read the key passphrase from a file (or set GOOP2_KEY_PASSPHRASE)")
+	fmt.Println("  -echo-peer")
+	fmt.Println("            Also run an echo peer that mirrors chat, joins groups it is")
+	fmt.Println("            invited to and answers calls (development on one machine)")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  # Run desktop app")
 	fmt.Println("  goop2")
 	fmt.Println()
-	fmt.Println("  # Run a peer from CLI")
+	fmt.Println("  # Create a peer with the blog template, then run it from CLI")
+	fmt.Println("  goop2 init ./peers/mysite -template blog -rendezvous https://goop2.com -yes")
 	fmt.Println("  goop2 peer ./peers/mysite")
 	fmt.Println()
+	fmt.Println("  # Develop on one machine with an echo peer to talk to")
+	fmt.Println("  goop2 -echo-peer peer ./peers/mysite")
+	fmt.Println()
 	fmt.Println("  # Run peer as rendezvous server")
 	fmt.Println("  goop2 rendezvous ./peers/server")
 	fmt.Println()
+	fmt.Println("  # Set up and run a public rendezvous server")
+	fmt.Println("  goop2 init ./peers/server -preset public-rendezvous")
+	fmt.Println("  goop2 rendezvous ./peers/server")
+	fmt.Println()
+	fmt.Println("  # Encrypt the keys with a passphrase, then run with it")
+	fmt.Println("  GOOP2_KEY_PASSPHRASE=... goop2 keys ./peers/mysite protect")
+	fmt.Println("  GOOP2_KEY_PASSPHRASE=... goop2 peer ./peers/mysite")
+	fmt.Println()
+	fmt.Println("  # Script a headless peer")
+	fmt.Println("  goop2 init ./peers/bot -preset headless-bot -yes")
+	fmt.Println("  goop2 peer ./peers/bot &")
+	fmt.Println("  goop2 ctl ./peers/bot chat 12D3KooW... hello from a script")
+	fmt.Println()
+	fmt.Println("  # Move the credits admin token out of goop.json")
+	fmt.Println("  goop2 secrets ./peers/server set credits-admin < token.txt")
+	fmt.Println("  # then set \"credits_admin_token\": \"secret:credits-admin\"")
+	fmt.Println()
+	fmt.Println("  # Run several peers under one daemon, then check on them")
+	fmt.Println("  goop2 daemon start -config ./goop2d.json")
+	fmt.Println("  goop2 daemon status -config ./goop2d.json")
+	fmt.Println()
 	fmt.Println("Documentation:")
 	fmt.Println("  • Desktop usage: README.md")
 	fmt.Println("  • CLI deployment: docs/CLI_TOOLS.md")
@@ -0,0 +1,76 @@
+// secrets.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/petervdpas/goop2/internal/app"
+	"github.com/petervdpas/goop2/internal/secrets"
+)
+
+// runSecrets lists and changes a peer's secrets. Values are read from
+// stdin, never from the command line, so they stay out of shell history.
+func runSecrets(peerDirArg, action string, args []string) {
+	absDir, err := filepath.Abs(peerDirArg)
+	if err != nil {
+		log.Fatalf("Invalid peer directory: %v", err)
+	}
+	store, err := secrets.Open(app.SecretsDir(absDir))
+	if err != nil {
+		log.Fatalf("Secrets: %v", err)
+	}
+
+	name := ""
+	if action != "list" {
+		if len(args) < 1 {
+			fmt.Fprintf(os.Stderr, "Error: secrets %s requires a name\n", action)
+			os.Exit(1)
+		}
+		name = args[0]
+	}
+
+	switch action {
+	case "list":
+		list, err := store.List()
+		if err != nil {
+			log.Fatalf("Secrets: %v", err)
+		}
+		for _, s := range list {
+			fmt.Printf("%-24s v%-4d %s\n", s.Name, s.Version, time.UnixMilli(s.Updated).Format(time.RFC3339))
+		}
+	case "set":
+		if isTerminal(os.Stdin) {
+			fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		}
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && value == "" {
+			log.Fatalf("Read value: %v", err)
+		}
+		info, err := store.Set(name, strings.TrimRight(value, "\r\n"))
+		if err != nil {
+			log.Fatalf("Secrets: %v", err)
+		}
+		fmt.Printf("%s set (v%d); refer to it as \"%s%s\"\n", info.Name, info.Version, secrets.RefPrefix, info.Name)
+	case "rotate":
+		value, info, err := store.Rotate(name)
+		if err != nil {
+			log.Fatalf("Secrets: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "%s rotated to v%d; the new value is shown once:\n", info.Name, info.Version)
+		fmt.Println(value)
+	case "rm":
+		if err := store.Delete(name); err != nil {
+			log.Fatalf("Secrets: %v", err)
+		}
+		fmt.Printf("%s removed\n", name)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown secrets action '%s' (want list, set, rotate or rm)\n", action)
+		os.Exit(1)
+	}
+}